@@ -0,0 +1,27 @@
+#!/bin/sh
+# Seeds the local stack by booking each ticket in seed-tickets.jsonl through the API.
+set -e
+
+SERVICE_URL="${SERVICE_URL:-http://localhost:8080}"
+DIR="$(dirname "$0")"
+
+echo "Waiting for $SERVICE_URL..."
+for i in $(seq 1 60); do
+  if curl -fs "$SERVICE_URL/health" > /dev/null; then
+    break
+  fi
+  if [ "$i" = 60 ]; then
+    echo "Ticket service did not become healthy" >&2
+    exit 1
+  fi
+  sleep 2
+done
+
+count=0
+while IFS= read -r ticket; do
+  [ -z "$ticket" ] && continue
+  curl -fsS -X POST "$SERVICE_URL/ticket" -H "Content-Type: application/json" --data "$ticket" > /dev/null
+  count=$((count + 1))
+done < "$DIR/seed-tickets.jsonl"
+
+echo "Seeded $count tickets"
@@ -0,0 +1,3 @@
+export { ApiError, FlightTicketClient } from "./client.js";
+export type { ClientOptions } from "./client.js";
+export type * from "./types.js";
@@ -0,0 +1,28 @@
+resource "google_firestore_index" "tickets" {
+  count = length(var.firestore_indexes)
+
+  database   = var.firestore_database
+  collection = var.firestore_indexes[count.index].collection
+
+  dynamic "fields" {
+    for_each = var.firestore_indexes[count.index].fields
+    content {
+      field_path = fields.value.field_path
+      order      = fields.value.order
+    }
+  }
+
+  depends_on = [google_project_service.apis]
+}
+
+resource "google_firestore_field" "ttl" {
+  count = length(var.firestore_ttl_fields)
+
+  database   = var.firestore_database
+  collection = var.firestore_ttl_fields[count.index].collection
+  field      = var.firestore_ttl_fields[count.index].field
+
+  ttl_config {}
+
+  depends_on = [google_project_service.apis]
+}
@@ -0,0 +1,42 @@
+locals {
+  services = [
+    "artifactregistry.googleapis.com",
+    "run.googleapis.com",
+    "firestore.googleapis.com",
+    "pubsub.googleapis.com",
+    "cloudscheduler.googleapis.com",
+    "iam.googleapis.com",
+    "clouderrorreporting.googleapis.com",
+    "monitoring.googleapis.com",
+  ]
+}
+
+resource "google_project_service" "apis" {
+  for_each = toset(local.services)
+
+  service            = each.value
+  disable_on_destroy = false
+}
+
+resource "google_artifact_registry_repository" "images" {
+  location      = var.region
+  repository_id = var.repository
+  format        = "DOCKER"
+  description   = "Flight Ticket Service images"
+
+  depends_on = [google_project_service.apis]
+}
+
+resource "google_service_account" "service" {
+  account_id   = var.service_name
+  display_name = "Flight Ticket Service"
+  description  = "Service account for Flight Ticket Service Cloud Run deployment"
+}
+
+resource "google_project_iam_member" "service" {
+  for_each = toset(var.service_account_roles)
+
+  project = var.project_id
+  role    = each.value
+  member  = "serviceAccount:${google_service_account.service.email}"
+}
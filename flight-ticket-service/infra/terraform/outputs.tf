@@ -0,0 +1,14 @@
+output "service_url" {
+  description = "Cloud Run service URL"
+  value       = google_cloud_run_v2_service.api.uri
+}
+
+output "service_account_email" {
+  description = "Service account the service runs as"
+  value       = google_service_account.service.email
+}
+
+output "image_repository" {
+  description = "Docker repository URL"
+  value       = "${var.region}-docker.pkg.dev/${var.project_id}/${google_artifact_registry_repository.images.repository_id}"
+}
@@ -0,0 +1,15 @@
+resource "google_pubsub_topic" "topics" {
+  for_each = toset(var.pubsub_topics)
+
+  name = each.value
+
+  depends_on = [google_project_service.apis]
+}
+
+resource "google_pubsub_topic_iam_member" "publisher" {
+  for_each = google_pubsub_topic.topics
+
+  topic  = each.value.name
+  role   = "roles/pubsub.publisher"
+  member = "serviceAccount:${google_service_account.service.email}"
+}
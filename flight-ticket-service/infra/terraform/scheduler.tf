@@ -0,0 +1,38 @@
+resource "google_service_account" "scheduler" {
+  count = length(var.scheduler_jobs) > 0 ? 1 : 0
+
+  account_id   = "${var.service_name}-scheduler"
+  display_name = "Flight Ticket Service scheduler"
+}
+
+resource "google_cloud_run_v2_service_iam_member" "scheduler" {
+  count = length(var.scheduler_jobs) > 0 ? 1 : 0
+
+  name     = google_cloud_run_v2_service.api.name
+  location = var.region
+  role     = "roles/run.invoker"
+  member   = "serviceAccount:${google_service_account.scheduler[0].email}"
+}
+
+resource "google_cloud_scheduler_job" "jobs" {
+  for_each = var.scheduler_jobs
+
+  name      = each.key
+  region    = var.region
+  schedule  = each.value.schedule
+  time_zone = each.value.time_zone
+
+  http_target {
+    uri         = "${google_cloud_run_v2_service.api.uri}${each.value.path}"
+    http_method = each.value.http_method
+    body        = each.value.body == null ? null : base64encode(each.value.body)
+    headers     = each.value.body == null ? {} : { "Content-Type" = "application/json" }
+
+    oidc_token {
+      service_account_email = google_service_account.scheduler[0].email
+      audience              = google_cloud_run_v2_service.api.uri
+    }
+  }
+
+  depends_on = [google_project_service.apis]
+}
@@ -0,0 +1,180 @@
+variable "project_id" {
+  description = "GCP project ID"
+  type        = string
+}
+
+variable "region" {
+  description = "Region for Artifact Registry, Cloud Run, Pub/Sub and Scheduler"
+  type        = string
+  default     = "us-east1"
+}
+
+variable "repository" {
+  description = "Artifact Registry repository name"
+  type        = string
+}
+
+variable "service_name" {
+  description = "Cloud Run service name, also used for the service account"
+  type        = string
+  default     = "flight-ticket-service"
+}
+
+variable "image" {
+  description = "Container image to deploy"
+  type        = string
+}
+
+variable "allow_unauthenticated" {
+  description = "Allow public access to the Cloud Run service"
+  type        = bool
+  default     = true
+}
+
+variable "ingress" {
+  description = "Traffic the service accepts: INGRESS_TRAFFIC_ALL, INGRESS_TRAFFIC_INTERNAL_ONLY (from the VPC) or INGRESS_TRAFFIC_INTERNAL_LOAD_BALANCER (also through Cloud Load Balancing, e.g. one terminating mTLS)"
+  type        = string
+  default     = "INGRESS_TRAFFIC_ALL"
+
+  validation {
+    condition     = contains(["INGRESS_TRAFFIC_ALL", "INGRESS_TRAFFIC_INTERNAL_ONLY", "INGRESS_TRAFFIC_INTERNAL_LOAD_BALANCER"], var.ingress)
+    error_message = "ingress must be INGRESS_TRAFFIC_ALL, INGRESS_TRAFFIC_INTERNAL_ONLY or INGRESS_TRAFFIC_INTERNAL_LOAD_BALANCER."
+  }
+}
+
+variable "invokers" {
+  description = "Principals allowed to call the service when allow_unauthenticated is false, e.g. serviceAccount:mcp-server@my-project.iam.gserviceaccount.com"
+  type        = list(string)
+  default     = []
+}
+
+variable "min_instances" {
+  description = "Minimum Cloud Run instances kept warm; set from the deploy profile by mage infraGenerate"
+  type        = number
+  default     = 0
+}
+
+variable "max_instances" {
+  description = "Maximum Cloud Run instances"
+  type        = number
+  default     = 10
+}
+
+variable "cpu_always_on" {
+  description = "Keep CPU allocated outside requests (latency-optimized profile) instead of only while serving them"
+  type        = bool
+  default     = false
+}
+
+variable "env" {
+  description = "Additional environment variables for the service"
+  type        = map(string)
+  default     = {}
+}
+
+variable "service_account_roles" {
+  description = "Project roles granted to the service account"
+  type        = list(string)
+  default = [
+    "roles/datastore.user",
+    "roles/firebase.admin",
+    "roles/errorreporting.writer",
+    "roles/monitoring.metricWriter",
+  ]
+}
+
+variable "pubsub_topics" {
+  description = "Pub/Sub topics to create, e.g. the change feed topic and its dead-letter topic"
+  type        = list(string)
+  default = [
+    "flight-ticket-changes",
+    "flight-ticket-changes-dlq",
+  ]
+}
+
+variable "firestore_database" {
+  description = "Firestore database of the service, \"(default)\" or a named database; passed to the service as FIRESTORE_DATABASE"
+  type        = string
+  default     = "(default)"
+}
+
+variable "firestore_indexes" {
+  description = "Composite indexes on the tickets database; the job queue claims jobs by status and age or lease expiry, and limited ticket searches filter on fields and labels newest first"
+  type = list(object({
+    collection = string
+    fields = list(object({
+      field_path = string
+      order      = string
+    }))
+  }))
+  default = [
+    {
+      collection = "jobs"
+      fields = [
+        { field_path = "status", order = "ASCENDING" },
+        { field_path = "created_at", order = "ASCENDING" },
+      ]
+    },
+    {
+      collection = "jobs"
+      fields = [
+        { field_path = "status", order = "ASCENDING" },
+        { field_path = "lease_expires_at", order = "ASCENDING" },
+      ]
+    },
+    {
+      collection = "flight_tickets"
+      fields = [
+        { field_path = "status", order = "ASCENDING" },
+        { field_path = "created_at", order = "DESCENDING" },
+      ]
+    },
+    {
+      collection = "flight_tickets"
+      fields = [
+        { field_path = "flight_number", order = "ASCENDING" },
+        { field_path = "departure_date", order = "ASCENDING" },
+        { field_path = "created_at", order = "DESCENDING" },
+      ]
+    },
+    {
+      collection = "flight_tickets"
+      fields = [
+        { field_path = "origin", order = "ASCENDING" },
+        { field_path = "destination", order = "ASCENDING" },
+        { field_path = "departure_date", order = "ASCENDING" },
+        { field_path = "created_at", order = "DESCENDING" },
+      ]
+    },
+    {
+      collection = "flight_tickets"
+      fields = [
+        { field_path = "labels.itinerary", order = "ASCENDING" },
+        { field_path = "created_at", order = "DESCENDING" },
+      ]
+    },
+  ]
+}
+
+variable "firestore_ttl_fields" {
+  description = "Firestore TTL policies, the timestamp field after which documents of a collection group are deleted; sandbox tickets expire at expire_at"
+  type = list(object({
+    collection = string
+    field      = string
+  }))
+  default = [
+    { collection = "flight_tickets_sandbox", field = "expire_at" },
+  ]
+}
+
+variable "scheduler_jobs" {
+  description = "Cloud Scheduler jobs that call the service with an OIDC token, keyed by job name"
+  type = map(object({
+    schedule    = string
+    path        = string
+    http_method = optional(string, "POST")
+    body        = optional(string)
+    time_zone   = optional(string, "Etc/UTC")
+  }))
+  default = {}
+}
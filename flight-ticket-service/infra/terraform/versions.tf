@@ -0,0 +1,21 @@
+terraform {
+  required_version = ">= 1.5"
+
+  required_providers {
+    google = {
+      source  = "hashicorp/google"
+      version = "~> 5.40"
+    }
+  }
+
+  # State lives in a GCS bucket so changes can be reviewed and rolled back;
+  # the bucket is passed at init time (mage infraPlan uses TF_STATE_BUCKET)
+  backend "gcs" {
+    prefix = "flight-ticket-service"
+  }
+}
+
+provider "google" {
+  project = var.project_id
+  region  = var.region
+}
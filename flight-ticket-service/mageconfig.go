@@ -0,0 +1,385 @@
+//go:build mage
+// +build mage
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ConfigFile holds deployment settings as KEY=VALUE lines; environment variables take precedence
+const ConfigFile = "deploy.env"
+
+// Deployment setting defaults
+const (
+	DefaultRegion       = "us-east1"
+	DefaultServiceName  = "flight-ticket-service"
+	DefaultMaxInstances = 10
+)
+
+// Deploy profiles trade Cloud Run latency against cost
+const (
+	ProfileLatency = "latency-optimized"
+	ProfileCost    = "cost-optimized"
+)
+
+// deployProfile holds the scaling and CPU allocation of a deploy profile
+type deployProfile struct {
+	minInstances int  // default MIN_INSTANCES
+	cpuAlwaysOn  bool // keep CPU allocated outside requests (--no-cpu-throttling)
+}
+
+// Profiles lists the deploy profiles selectable with DEPLOY_PROFILE. A
+// latency-optimized service keeps warm instances with CPU always allocated,
+// so requests never wait for a cold start and background work (job workers,
+// cache refreshes) keeps running between requests. A cost-optimized service
+// scales to zero and is only billed while it serves requests.
+var Profiles = map[string]deployProfile{
+	ProfileLatency: {minInstances: 1, cpuAlwaysOn: true},
+	ProfileCost:    {minInstances: 0, cpuAlwaysOn: false},
+}
+
+var (
+	projectIDPattern   = regexp.MustCompile(`^[a-z][a-z0-9-]{4,28}[a-z0-9]$`)
+	regionPattern      = regexp.MustCompile(`^[a-z]+-[a-z]+[0-9]+$`)
+	repositoryPattern  = regexp.MustCompile(`^[a-z][a-z0-9-]{0,62}$`)
+	serviceNamePattern = regexp.MustCompile(`^[a-z][a-z0-9-]{0,48}$`)
+)
+
+// environmentDefaults are the per-environment settings used when not configured
+type environmentDefaults struct {
+	profile       string
+	maxInstances  int
+	canaryPercent int
+}
+
+// Environments lists the deployment environments and their defaults. Settings
+// for an environment are read from <ENV>_-prefixed variables (e.g.
+// PROD_GOOGLE_CLOUD_PROJECT) and deploy.<env>.env before the shared ones.
+var Environments = map[string]environmentDefaults{
+	"dev":     {profile: ProfileCost, maxInstances: 2},
+	"staging": {profile: ProfileCost, maxInstances: 5},
+	"prod":    {profile: ProfileLatency, maxInstances: 10, canaryPercent: 10},
+}
+
+// Ingress settings of the Cloud Run service
+const (
+	IngressAll                  = "all"
+	IngressInternal             = "internal"
+	IngressInternalLoadBalancer = "internal-and-cloud-load-balancing"
+)
+
+// Ingresses maps the INGRESS values, as gcloud takes them, to the Terraform ingress
+var Ingresses = map[string]string{
+	IngressAll:                  "INGRESS_TRAFFIC_ALL",
+	IngressInternal:             "INGRESS_TRAFFIC_INTERNAL_ONLY",
+	IngressInternalLoadBalancer: "INGRESS_TRAFFIC_INTERNAL_LOAD_BALANCER",
+}
+
+// DeployConfig is the Google Cloud deployment configuration
+type DeployConfig struct {
+	ProjectID      string // GOOGLE_CLOUD_PROJECT
+	Region         string // GCP_REGION
+	Repository     string // ARTIFACT_REPOSITORY
+	ServiceName    string // SERVICE_NAME
+	ServiceAccount string // SERVICE_ACCOUNT
+
+	// ALLOW_UNAUTHENTICATED, true by default; false makes Cloud Run require
+	// IAM authentication, so only principals with roles/run.invoker can call
+	AllowUnauthenticated bool
+	// INGRESS: all (default), internal, or internal-and-cloud-load-balancing
+	// for services reachable only from the VPC or through a load balancer
+	Ingress string
+
+	Profile      string // DEPLOY_PROFILE: latency-optimized or cost-optimized
+	MinInstances int    // MIN_INSTANCES, defaults to the profile's
+	MaxInstances int    // MAX_INSTANCES
+	CPUAlwaysOn  bool   // set by the profile
+
+	// Set for environment deployments only
+	Environment   string
+	CanaryPercent int      // CANARY_PERCENT, 0 routes all traffic to new revisions
+	EnvVars       []string // ENV_VARS, comma-separated KEY=VALUE pairs
+}
+
+// ImageURL returns the Artifact Registry image URL
+func (c DeployConfig) ImageURL() string {
+	return c.RegistryImage(ImageName)
+}
+
+// RegistryImage returns the Artifact Registry URL of an image in the repository
+func (c DeployConfig) RegistryImage(name string) string {
+	return fmt.Sprintf("%s-docker.pkg.dev/%s/%s/%s", c.Region, c.ProjectID, c.Repository, name)
+}
+
+// loadConfig reads the deployment configuration from the environment and deploy.env.
+// Targets that push or pull images pass needRepository to require ARTIFACT_REPOSITORY.
+func loadConfig(needRepository bool) (DeployConfig, error) {
+	return loadEnvironmentConfig("", needRepository)
+}
+
+// loadEnvironmentConfig reads the configuration of a deployment environment;
+// an empty environment reads only the shared settings
+func loadEnvironmentConfig(environment string, needRepository bool) (DeployConfig, error) {
+	defaults, known := Environments[environment]
+	if environment != "" && !known {
+		return DeployConfig{}, fmt.Errorf("unknown environment %q, expected one of: %s", environment, strings.Join(environmentNames(), ", "))
+	}
+
+	file, err := readConfigFile(ConfigFile)
+	if err != nil {
+		return DeployConfig{}, err
+	}
+	envFile := map[string]string{}
+	if environment != "" {
+		if envFile, err = readConfigFile(fmt.Sprintf("deploy.%s.env", environment)); err != nil {
+			return DeployConfig{}, err
+		}
+	}
+	prefix := strings.ToUpper(environment) + "_"
+
+	get := func(key, fallback string) string {
+		if environment != "" {
+			if value := strings.TrimSpace(os.Getenv(prefix + key)); value != "" {
+				return value
+			}
+			if value := envFile[key]; value != "" {
+				return value
+			}
+		}
+		if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+			return value
+		}
+		if value := file[key]; value != "" {
+			return value
+		}
+		return fallback
+	}
+
+	cfg := DeployConfig{
+		ProjectID:   get("GOOGLE_CLOUD_PROJECT", ""),
+		Region:      get("GCP_REGION", DefaultRegion),
+		Repository:  get("ARTIFACT_REPOSITORY", ""),
+		ServiceName: get("SERVICE_NAME", DefaultServiceName),
+	}
+	cfg.ServiceAccount = get("SERVICE_ACCOUNT", fmt.Sprintf("%s@%s.iam.gserviceaccount.com", cfg.ServiceName, cfg.ProjectID))
+
+	var errs []error
+	cfg.Ingress = get("INGRESS", IngressAll)
+	if _, known := Ingresses[cfg.Ingress]; !known {
+		errs = append(errs, fmt.Errorf("INGRESS=%q must be %s, %s or %s", cfg.Ingress, IngressAll, IngressInternal, IngressInternalLoadBalancer))
+	}
+	allowUnauthenticated := get("ALLOW_UNAUTHENTICATED", "true")
+	if cfg.AllowUnauthenticated, err = strconv.ParseBool(allowUnauthenticated); err != nil {
+		errs = append(errs, fmt.Errorf("ALLOW_UNAUTHENTICATED=%q must be true or false", allowUnauthenticated))
+	}
+	check := func(key, value, example string, pattern *regexp.Regexp) {
+		switch {
+		case value == "":
+			if environment != "" {
+				errs = append(errs, fmt.Errorf("%s is not set for %s: export %s or %s%s, or add %s=%s to deploy.%s.env or %s", key, environment, key, prefix, key, key, example, environment, ConfigFile))
+				return
+			}
+			errs = append(errs, fmt.Errorf("%s is not set: export it or add %s=%s to %s", key, key, example, ConfigFile))
+		case isPlaceholder(value):
+			errs = append(errs, fmt.Errorf("%s is still a placeholder (%q): set it to a real value, e.g. %s", key, value, example))
+		case !pattern.MatchString(value):
+			errs = append(errs, fmt.Errorf("%s=%q is not valid, expected something like %s", key, value, example))
+		}
+	}
+
+	check("GOOGLE_CLOUD_PROJECT", cfg.ProjectID, "my-project-123", projectIDPattern)
+	check("GCP_REGION", cfg.Region, "us-east1", regionPattern)
+	check("SERVICE_NAME", cfg.ServiceName, "flight-ticket-service", serviceNamePattern)
+	if needRepository {
+		check("ARTIFACT_REPOSITORY", cfg.Repository, "flight-tickets", repositoryPattern)
+	}
+	if !strings.Contains(cfg.ServiceAccount, "@") || isPlaceholder(cfg.ServiceAccount) {
+		errs = append(errs, fmt.Errorf("SERVICE_ACCOUNT=%q is not a service account email", cfg.ServiceAccount))
+	}
+
+	target := "the service"
+	if environment != "" {
+		target = environment
+	}
+	number := func(key string, fallback, lo, hi int) int {
+		value := get(key, strconv.Itoa(fallback))
+		n, err := strconv.Atoi(value)
+		if err != nil || n < lo || n > hi {
+			errs = append(errs, fmt.Errorf("%s=%q for %s must be a number from %d to %d", key, value, target, lo, hi))
+		}
+		return n
+	}
+
+	if defaults.profile == "" {
+		defaults.profile = ProfileCost
+	}
+	if defaults.maxInstances == 0 {
+		defaults.maxInstances = DefaultMaxInstances
+	}
+	cfg.Profile = get("DEPLOY_PROFILE", defaults.profile)
+	profile, known := Profiles[cfg.Profile]
+	if !known {
+		errs = append(errs, fmt.Errorf("DEPLOY_PROFILE=%q for %s must be %s or %s", cfg.Profile, target, ProfileLatency, ProfileCost))
+	}
+	cfg.CPUAlwaysOn = profile.cpuAlwaysOn
+	cfg.MinInstances = number("MIN_INSTANCES", profile.minInstances, 0, 1000)
+	cfg.MaxInstances = number("MAX_INSTANCES", defaults.maxInstances, 1, 1000)
+	if cfg.MinInstances > cfg.MaxInstances {
+		errs = append(errs, fmt.Errorf("MIN_INSTANCES (%d) for %s is greater than MAX_INSTANCES (%d)", cfg.MinInstances, target, cfg.MaxInstances))
+	}
+	if cfg.Profile == ProfileLatency && cfg.MinInstances == 0 {
+		errs = append(errs, fmt.Errorf("MIN_INSTANCES for %s must be at least 1 with the %s profile", target, ProfileLatency))
+	}
+
+	if environment != "" {
+		cfg.Environment = environment
+		cfg.CanaryPercent = number("CANARY_PERCENT", defaults.canaryPercent, 0, 99)
+
+		cfg.EnvVars = []string{"APP_ENV=" + environment}
+		for _, pair := range strings.Split(get("ENV_VARS", ""), ",") {
+			if pair = strings.TrimSpace(pair); pair == "" {
+				continue
+			}
+			if !strings.Contains(pair, "=") {
+				errs = append(errs, fmt.Errorf("ENV_VARS entry %q for %s is not KEY=VALUE", pair, environment))
+				continue
+			}
+			cfg.EnvVars = append(cfg.EnvVars, pair)
+		}
+	}
+
+	if len(errs) > 0 {
+		return cfg, fmt.Errorf("invalid deployment configuration (run mage doctor for details):\n  %w", joinLines(errs))
+	}
+	return cfg, nil
+}
+
+// environmentNames returns the known environments in order
+func environmentNames() []string {
+	names := make([]string, 0, len(Environments))
+	for name := range Environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// readConfigFile parses KEY=VALUE lines, ignoring blanks and comments; a missing file is empty
+func readConfigFile(path string) (map[string]string, error) {
+	values := make(map[string]string)
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return values, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, n)
+		}
+		values[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+	}
+	return values, scanner.Err()
+}
+
+// isPlaceholder detects template values such as [Project ID] or <SERVICE_ACCOUNT_EMAIL>
+func isPlaceholder(value string) bool {
+	return strings.ContainsAny(value, "[]<>")
+}
+
+func joinLines(errs []error) error {
+	lines := make([]string, len(errs))
+	for i, err := range errs {
+		lines[i] = err.Error()
+	}
+	return errors.New(strings.Join(lines, "\n  "))
+}
+
+// Doctor - Check tools, gcloud authentication, configuration and project access
+func Doctor() error {
+	failed := 0
+	report := func(ok bool, name, detail string) {
+		mark := "✅"
+		if !ok {
+			mark = "❌"
+			failed++
+		}
+		fmt.Printf("%s %-28s %s\n", mark, name, detail)
+	}
+	output := func(name string, args ...string) (string, error) {
+		out, err := exec.Command(name, args...).CombinedOutput()
+		return strings.TrimSpace(string(out)), err
+	}
+
+	_, gcloudErr := exec.LookPath("gcloud")
+	if gcloudErr != nil {
+		report(false, "gcloud installed", "install the Google Cloud CLI: https://cloud.google.com/sdk/docs/install")
+	} else {
+		report(true, "gcloud installed", "")
+		account, err := output("gcloud", "auth", "list", "--filter=status:ACTIVE", "--format=value(account)")
+		if err != nil || account == "" {
+			report(false, "gcloud authenticated", "run: gcloud auth login")
+		} else {
+			report(true, "gcloud authenticated", account)
+		}
+		if _, err := output("gcloud", "auth", "application-default", "print-access-token"); err != nil {
+			report(false, "application default creds", "run: gcloud auth application-default login")
+		} else {
+			report(true, "application default creds", "")
+		}
+	}
+
+	if _, err := exec.LookPath("docker"); err != nil {
+		report(false, "docker installed", "install Docker: https://docs.docker.com/get-docker/")
+	} else if _, err := output("docker", "info", "--format", "{{.ServerVersion}}"); err != nil {
+		report(false, "docker daemon running", "start Docker and retry")
+	} else {
+		report(true, "docker daemon running", "")
+	}
+
+	cfg, err := loadConfig(true)
+	if err != nil {
+		report(false, "configuration", err.Error())
+	} else {
+		report(true, "configuration", fmt.Sprintf("project=%s region=%s repository=%s service=%s", cfg.ProjectID, cfg.Region, cfg.Repository, cfg.ServiceName))
+	}
+
+	// Project and repository checks need gcloud and a usable configuration
+	if gcloudErr == nil && err == nil {
+		if _, err := output("gcloud", "projects", "describe", cfg.ProjectID, "--format=value(projectId)"); err != nil {
+			report(false, "project access", fmt.Sprintf("cannot access %s: check the project ID and your IAM roles", cfg.ProjectID))
+		} else {
+			report(true, "project access", cfg.ProjectID)
+		}
+		if _, err := output("gcloud", "artifacts", "repositories", "describe", cfg.Repository, "--location", cfg.Region, "--project", cfg.ProjectID); err != nil {
+			report(false, "artifact repository", fmt.Sprintf("%s not found in %s: run mage setup", cfg.Repository, cfg.Region))
+		} else {
+			report(true, "artifact repository", cfg.ImageURL())
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d check(s) failed", failed)
+	}
+	fmt.Println("All checks passed")
+	return nil
+}
@@ -4,10 +4,17 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
+
+	"flight-ticket-service/src/opsnotify"
 )
 
 const (
@@ -15,42 +22,145 @@ const (
 	ImageName     = "flight-ticket-service"
 	LocalPort     = "8080"
 	ContainerPort = "8080"
-
-	// Google Cloud configuration
-	ProjectID   = ""                      // GCP project ID
-	Region      = "us-east1"              // GCP region
-	Repository  = ""                      // Artifact Registry repository name
-	ServiceName = "flight-ticket-service" // Cloud Run service name
 )
 
 // Default target to run when none is specified
 var Default = Build
 
+// Aliases exposes the environment deploy targets as deploy:<env>, the hot reload target as dev:watch,
+// the SLO alerting target as slo:alerts and the TypeScript client generator as generate:tsclient
+var Aliases = map[string]interface{}{
+	"deploy:dev":        DeployDev,
+	"deploy:staging":    DeployStaging,
+	"deploy:prod":       DeployProd,
+	"dev:watch":         DevWatch,
+	"slo:alerts":        SloAlerts,
+	"generate:tsclient": GenerateTSClient,
+}
+
 // Build Go application locally
 func Build() error {
 	fmt.Println("Building Go application...")
-	cmd := exec.Command("go", "build", "-o", "server", "src/cmd/server/server.go")
+	cmd := exec.Command("go", "build", "-ldflags", versionLDFlags(), "-o", "server", "./src/cmd/server")
 	return cmd.Run()
 }
 
+// buildVersion returns the version, commit and UTC build time for the current checkout
+func buildVersion() (version, commit, buildTime string) {
+	version, commit = "dev", "unknown"
+	if out, err := exec.Command("git", "describe", "--tags", "--always", "--dirty").Output(); err == nil {
+		version = strings.TrimSpace(string(out))
+	}
+	if out, err := exec.Command("git", "rev-parse", "--short", "HEAD").Output(); err == nil {
+		commit = strings.TrimSpace(string(out))
+	}
+	return version, commit, time.Now().UTC().Format(time.RFC3339)
+}
+
+// versionLDFlags returns the -ldflags that inject build information into src/version
+func versionLDFlags() string {
+	version, commit, buildTime := buildVersion()
+	const pkg = "flight-ticket-service/src/version"
+	return fmt.Sprintf("-X %s.Version=%s -X %s.Commit=%s -X %s.BuildTime=%s",
+		pkg, version, pkg, commit, pkg, buildTime)
+}
+
 // Run Go application locally
 func Run() error {
 	fmt.Println("Running Go application locally on port 6000...")
-	cmd := exec.Command("go", "run", "src/cmd/server/server.go")
+	cmd := exec.Command("go", "run", "./src/cmd/server")
 	cmd.Env = append(os.Environ(), "PORT=6000")
 	return cmd.Run()
 }
 
+// RunLocal - Run Go application on port 6000 with SQLite storage (no GCP project needed)
+func RunLocal() error {
+	fmt.Println("Running Go application locally on port 6000 with SQLite storage...")
+	cmd := exec.Command("go", "run", "./src/cmd/server", "--storage=sqlite")
+	cmd.Env = append(os.Environ(), "PORT=6000")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
 // Clean up build artifacts
 func Clean() error {
 	fmt.Println("Cleaning up...")
 	return os.RemoveAll("server")
 }
 
+// MigrateStorage - Copy tickets between storage backends (e.g. mage migrateStorage firestore spanner)
+func MigrateStorage(from, to string) error {
+	fmt.Printf("Migrating tickets from %s to %s...\n", from, to)
+	cmd := exec.Command("go", "run", "./src/cmd/migrate", "-from", from, "-to", to)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// SpannerSchema - Apply the flight tickets schema to the configured Spanner database
+func SpannerSchema() error {
+	fmt.Println("Applying Spanner schema...")
+	cmd := exec.Command("go", "run", "./src/cmd/migrate", "-to", "spanner", "-schema-only")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// GenerateTSClient - Regenerate the OpenAPI spec, the MCP tools and the typed TypeScript client in clients/typescript
+func GenerateTSClient() error {
+	fmt.Println("Generating TypeScript client...")
+	cmd := exec.Command("make", "docs")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// Backup - Dump all tickets as JSON to BACKUP_BUCKET with a point-in-time label
+func Backup() error {
+	fmt.Println("Backing up flight tickets...")
+	return runBackupCommand("backup")
+}
+
+// BackupList - List JSON backups and Firestore exports in BACKUP_BUCKET
+func BackupList() error {
+	return runBackupCommand("list")
+}
+
+// Restore - Restore tickets from a JSON backup (e.g. mage restore 20240712T190000Z)
+func Restore(label string) error {
+	fmt.Printf("Restoring flight tickets from backup %s...\n", label)
+	return runBackupCommand("restore", "-label", label)
+}
+
+// FirestoreExport - Run a Firestore managed export of the tickets collection (FIRESTORE_COLLECTION) to BACKUP_BUCKET
+func FirestoreExport() error {
+	fmt.Println("Exporting tickets collection...")
+	return runBackupCommand("export")
+}
+
+// FirestoreImport - Import the tickets collection from a Firestore managed export (e.g. mage firestoreImport 20240712T190000Z)
+func FirestoreImport(label string) error {
+	fmt.Printf("Importing tickets collection from export %s...\n", label)
+	return runBackupCommand("import", "-label", label)
+}
+
+func runBackupCommand(args ...string) error {
+	cmd := exec.Command("go", append([]string{"run", "./src/cmd/backup"}, args...)...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
 // Docker build - Build Docker image
 func DockerBuild() error {
 	fmt.Printf("Building Docker image: %s\n", ImageName)
-	cmd := exec.Command("docker", "build", "-t", ImageName, ".")
+	version, commit, buildTime := buildVersion()
+	cmd := exec.Command("docker", "build",
+		"--build-arg", "VERSION="+version,
+		"--build-arg", "COMMIT="+commit,
+		"--build-arg", "BUILD_TIME="+buildTime,
+		"-t", ImageName, ".")
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
@@ -98,7 +208,12 @@ func DockerStop() error {
 
 // Docker push - Tag and push image to Google Artifact Registry
 func DockerPush() error {
-	artifactRegistryURL := fmt.Sprintf("%s-docker.pkg.dev/%s/%s/%s", Region, ProjectID, Repository, ImageName)
+	cfg, err := loadConfig(true)
+	if err != nil {
+		return err
+	}
+
+	artifactRegistryURL := cfg.ImageURL()
 
 	fmt.Printf("Tagging image for Artifact Registry: %s\n", artifactRegistryURL)
 	tagCmd := exec.Command("docker", "tag", ImageName, artifactRegistryURL)
@@ -115,60 +230,289 @@ func DockerPush() error {
 	return pushCmd.Run()
 }
 
+// SelfCheck - Run the pushed image with --check as a Cloud Run Job: configuration, credentials, Firestore and Secret Manager
+func SelfCheck() error {
+	cfg, err := loadConfig(true)
+	if err != nil {
+		return err
+	}
+	return selfCheck(cfg)
+}
+
 // Deploy - Deploy to Google Cloud Run
 func Deploy() error {
-	artifactRegistryURL := fmt.Sprintf("%s-docker.pkg.dev/%s/%s/%s", Region, ProjectID, Repository, ImageName)
+	cfg, err := loadConfig(true)
+	if err != nil {
+		return err
+	}
 
-	fmt.Printf("Deploying %s to Cloud Run service: %s\n", artifactRegistryURL, ServiceName)
+	fmt.Printf("Deploying %s to Cloud Run service: %s\n", cfg.ImageURL(), cfg.ServiceName)
 
+	cmd := exec.Command("gcloud", deployArgs(cfg)...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// deployArgs returns the gcloud arguments of the basic deployment
+func deployArgs(cfg DeployConfig) []string {
+	args := []string{"run", "deploy", cfg.ServiceName, "--image", cfg.ImageURL()}
+	args = append(args, serviceArgs(cfg)...)
+	return append(args,
+		"--set-env-vars", fmt.Sprintf("GOOGLE_CLOUD_PROJECT=%s", cfg.ProjectID),
+		"--set-env-vars", "GIN_MODE=release",
+	)
+}
+
+// serviceArgs returns the gcloud run deploy flags shared by every deploy target:
+// ingress and public access, the container size, and the scaling and CPU
+// allocation of the deploy profile.
+// The default TCP startup probe holds traffic back until the server has warmed
+// up and listens; startup CPU boost shortens the warm-up.
+func serviceArgs(cfg DeployConfig) []string {
 	args := []string{
-		"run", "deploy", ServiceName,
-		"--image", artifactRegistryURL,
 		"--platform", "managed",
-		"--region", Region,
-		"--allow-unauthenticated",
+		"--region", cfg.Region,
+		"--ingress", cfg.Ingress,
 		"--port", ContainerPort,
-		"--project", ProjectID,
+		"--project", cfg.ProjectID,
 		"--memory", "512Mi",
 		"--cpu", "1",
+		"--cpu-boost",
 		"--timeout", "300",
 		"--concurrency", "100",
-		"--max-instances", "10",
-		"--set-env-vars", fmt.Sprintf("GOOGLE_CLOUD_PROJECT=%s", ProjectID),
-		"--set-env-vars", "GIN_MODE=release",
+		"--min-instances", strconv.Itoa(cfg.MinInstances),
+		"--max-instances", strconv.Itoa(cfg.MaxInstances),
+	}
+	if cfg.AllowUnauthenticated {
+		args = append(args, "--allow-unauthenticated")
+	} else {
+		args = append(args, "--no-allow-unauthenticated")
+	}
+	if cfg.CPUAlwaysOn {
+		return append(args, "--no-cpu-throttling")
+	}
+	return append(args, "--cpu-throttling")
+}
+
+// DeployWithServiceAccount - Deploy to Cloud Run with service account for Firestore access
+func DeployWithServiceAccount() error {
+	cfg, err := loadConfig(true)
+	if err != nil {
+		return err
 	}
 
-	cmd := exec.Command("gcloud", args...)
+	fmt.Printf("Deploying %s to Cloud Run with service account: %s (%s)\n", cfg.ImageURL(), cfg.ServiceAccount, cfg.Profile)
+
+	cmd := exec.Command("gcloud", append(deployArgs(cfg), "--service-account", cfg.ServiceAccount)...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
 }
 
-// DeployWithServiceAccount - Deploy to Cloud Run with service account for Firestore access
-func DeployWithServiceAccount() error {
-	serviceAccountEmail := fmt.Sprintf("%s@%s.iam.gserviceaccount.com", ServiceName, ProjectID)
-	artifactRegistryURL := fmt.Sprintf("%s-docker.pkg.dev/%s/%s/%s", Region, ProjectID, Repository, ImageName)
+// CanaryTag is the revision tag given to canary deployments
+const CanaryTag = "canary"
 
-	fmt.Printf("Deploying %s to Cloud Run with service account: %s\n", artifactRegistryURL, serviceAccountEmail)
+// DeployDev - Deploy IMAGE_TAG (default latest) to the dev environment
+func DeployDev() error {
+	return deployEnvironment("dev", -1)
+}
 
-	args := []string{
-		"run", "deploy", ServiceName,
-		"--image", artifactRegistryURL,
-		"--platform", "managed",
-		"--region", Region,
-		"--allow-unauthenticated",
-		"--port", ContainerPort,
-		"--project", ProjectID,
-		"--memory", "512Mi",
-		"--cpu", "1",
-		"--timeout", "300",
-		"--concurrency", "100",
-		"--max-instances", "10",
-		"--service-account", serviceAccountEmail,
-		"--set-env-vars", fmt.Sprintf("GOOGLE_CLOUD_PROJECT=%s", ProjectID),
-		"--set-env-vars", "GIN_MODE=release",
+// DeployStaging - Deploy IMAGE_TAG (default latest) to the staging environment
+func DeployStaging() error {
+	return deployEnvironment("staging", -1)
+}
+
+// DeployProd - Deploy IMAGE_TAG (default latest) to prod as a canary receiving CANARY_PERCENT of traffic (default 10)
+func DeployProd() error {
+	return deployEnvironment("prod", -1)
+}
+
+// Canary - Deploy a new revision to an environment and route a percentage of traffic to it (e.g. mage canary prod 25)
+func Canary(environment string, percent int) error {
+	if percent < 1 || percent > 99 {
+		return fmt.Errorf("canary percent must be between 1 and 99, got %d", percent)
+	}
+	return deployEnvironment(environment, percent)
+}
+
+// Promote - Route all traffic of an environment to its latest revision after a canary (e.g. mage promote prod)
+func Promote(environment string) error {
+	cfg, err := loadEnvironmentConfig(environment, false)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Promoting latest %s revision of %s to 100%% of traffic\n", environment, cfg.ServiceName)
+	if err := gcloud("run", "services", "update-traffic", cfg.ServiceName,
+		"--to-latest",
+		"--remove-tags", CanaryTag,
+		"--region", cfg.Region,
+		"--project", cfg.ProjectID); err != nil {
+		return err
+	}
+	notifyDeployment(cfg, "promoted the latest revision to 100% of traffic")
+	return nil
+}
+
+// Rollback - Route all traffic of an environment to the revision before the newest serving one (ROLLBACK_REVISION selects a revision)
+func Rollback(environment string) error {
+	cfg, err := loadEnvironmentConfig(environment, false)
+	if err != nil {
+		return err
+	}
+
+	revision := os.Getenv("ROLLBACK_REVISION")
+	if revision == "" {
+		if revision, err = previousRevision(cfg); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Rolling back %s (%s) to revision %s\n", cfg.ServiceName, environment, revision)
+	if err := gcloud("run", "services", "update-traffic", cfg.ServiceName,
+		"--to-revisions", revision+"=100",
+		"--region", cfg.Region,
+		"--project", cfg.ProjectID); err != nil {
+		return err
+	}
+	notifyDeployment(cfg, "rolled back to revision "+revision)
+	return nil
+}
+
+// deployEnvironment deploys IMAGE_TAG to an environment. A canaryPercent of -1
+// uses the environment's CANARY_PERCENT; 0 routes all traffic to the new revision.
+func deployEnvironment(environment string, canaryPercent int) error {
+	cfg, err := loadEnvironmentConfig(environment, true)
+	if err != nil {
+		return err
 	}
+	if canaryPercent < 0 {
+		canaryPercent = cfg.CanaryPercent
+	}
+
+	tag := os.Getenv("IMAGE_TAG")
+	if tag == "" {
+		tag = "latest"
+	}
+	image := cfg.ImageURL() + ":" + tag
 
+	// A new service has no revision to keep serving, so it cannot start as a canary
+	if canaryPercent > 0 && !serviceExists(cfg) {
+		fmt.Printf("Service %s does not exist yet, deploying without a canary\n", cfg.ServiceName)
+		canaryPercent = 0
+	}
+
+	fmt.Printf("Deploying %s to %s in %s (project %s, region %s, %s, %d-%d instances)\n",
+		image, cfg.ServiceName, environment, cfg.ProjectID, cfg.Region, cfg.Profile, cfg.MinInstances, cfg.MaxInstances)
+
+	envVars := append([]string{"GOOGLE_CLOUD_PROJECT=" + cfg.ProjectID, "GIN_MODE=release"}, cfg.EnvVars...)
+	args := []string{"run", "deploy", cfg.ServiceName, "--image", image}
+	args = append(args, serviceArgs(cfg)...)
+	args = append(args,
+		"--service-account", cfg.ServiceAccount,
+		"--set-env-vars", strings.Join(envVars, ","),
+	)
+	if canaryPercent > 0 {
+		args = append(args, "--no-traffic", "--tag", CanaryTag)
+	}
+
+	if err := gcloud(args...); err != nil {
+		return fmt.Errorf("deployment failed: %v", err)
+	}
+
+	if canaryPercent == 0 {
+		// Clears revision pins left by an earlier canary or rollback
+		if err := gcloud("run", "services", "update-traffic", cfg.ServiceName,
+			"--to-latest",
+			"--region", cfg.Region,
+			"--project", cfg.ProjectID); err != nil {
+			return err
+		}
+		notifyDeployment(cfg, "deployed "+image)
+		return nil
+	}
+
+	fmt.Printf("Routing %d%% of traffic to the canary revision\n", canaryPercent)
+	if err := gcloud("run", "services", "update-traffic", cfg.ServiceName,
+		"--to-tags", fmt.Sprintf("%s=%d", CanaryTag, canaryPercent),
+		"--region", cfg.Region,
+		"--project", cfg.ProjectID); err != nil {
+		return fmt.Errorf("failed to route traffic to canary: %v", err)
+	}
+	notifyDeployment(cfg, fmt.Sprintf("deployed %s as a canary receiving %d%% of traffic", image, canaryPercent))
+	fmt.Printf("Canary deployed. Run 'mage promote %s' to send it all traffic or 'mage rollback %s' to undo.\n", environment, environment)
+	return nil
+}
+
+// notifyDeployment posts a deployment event to the chat webhook in
+// OPS_WEBHOOK_URL_DEPLOYMENT or OPS_WEBHOOK_URL, when set. A failed post is
+// reported but does not fail the deployment.
+func notifyDeployment(cfg DeployConfig, text string) {
+	opsConfig, err := opsnotify.ConfigFromEnv()
+	if err != nil {
+		fmt.Printf("Not posting the deployment: %v\n", err)
+		return
+	}
+	opsConfig.Source = cfg.ServiceName + " (" + cfg.Environment + ")"
+	notifier := opsnotify.New(opsConfig)
+	if notifier == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := notifier.Notify(ctx, opsnotify.Event{Type: opsnotify.EventDeployment, Text: text}); err != nil {
+		fmt.Printf("Failed to post the deployment: %v\n", err)
+	}
+}
+
+// serviceExists reports whether the Cloud Run service has been deployed
+func serviceExists(cfg DeployConfig) bool {
+	cmd := exec.Command("gcloud", "run", "services", "describe", cfg.ServiceName,
+		"--region", cfg.Region,
+		"--project", cfg.ProjectID,
+		"--format", "value(metadata.name)")
+	return cmd.Run() == nil
+}
+
+// previousRevision returns the revision created before the newest one receiving traffic
+func previousRevision(cfg DeployConfig) (string, error) {
+	traffic, err := describeTraffic(cfg)
+	if err != nil {
+		return "", err
+	}
+	serving := make(map[string]bool)
+	for _, target := range traffic {
+		if target.Percent > 0 {
+			serving[target.RevisionName] = true
+		}
+	}
+
+	// Newest first
+	out, err := exec.Command("gcloud", "run", "revisions", "list",
+		"--service", cfg.ServiceName,
+		"--region", cfg.Region,
+		"--project", cfg.ProjectID,
+		"--sort-by", "~metadata.creationTimestamp",
+		"--format", "value(metadata.name)").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to list revisions: %v", err)
+	}
+	revisions := strings.Fields(string(out))
+
+	for i, revision := range revisions {
+		if serving[revision] {
+			if i+1 < len(revisions) {
+				return revisions[i+1], nil
+			}
+			break
+		}
+	}
+	return "", fmt.Errorf("no earlier revision of %s to roll back to", cfg.ServiceName)
+}
+
+// gcloud runs a gcloud command with its output attached to the terminal
+func gcloud(args ...string) error {
 	cmd := exec.Command("gcloud", args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -177,15 +521,21 @@ func DeployWithServiceAccount() error {
 
 // SetupServiceAccount - Create and configure service account for Firestore access
 func SetupServiceAccount() error {
-	serviceAccountEmail := fmt.Sprintf("%s@%s.iam.gserviceaccount.com", ServiceName, ProjectID)
+	cfg, err := loadConfig(false)
+	if err != nil {
+		return err
+	}
+
+	serviceAccountEmail := cfg.ServiceAccount
 
 	fmt.Printf("Creating service account: %s\n", serviceAccountEmail)
 
 	// Create service account
-	createCmd := exec.Command("gcloud", "iam", "service-accounts", "create", ServiceName,
+	accountID, _, _ := strings.Cut(serviceAccountEmail, "@")
+	createCmd := exec.Command("gcloud", "iam", "service-accounts", "create", accountID,
 		"--display-name", "Flight Ticket Service",
 		"--description", "Service account for Flight Ticket Service Cloud Run deployment",
-		"--project", ProjectID)
+		"--project", cfg.ProjectID)
 	createCmd.Stdout = os.Stdout
 	createCmd.Stderr = os.Stderr
 
@@ -193,15 +543,17 @@ func SetupServiceAccount() error {
 		fmt.Printf("Note: Service account creation failed (might already exist): %v\n", err)
 	}
 
-	// Grant Firestore permissions
-	fmt.Println("Granting Firestore permissions...")
+	// Grant Firestore, Error Reporting and Cloud Monitoring permissions
+	fmt.Println("Granting Firestore, Error Reporting and Cloud Monitoring permissions...")
 	roles := []string{
 		"roles/datastore.user",
 		"roles/firebase.admin",
+		"roles/errorreporting.writer",
+		"roles/monitoring.metricWriter",
 	}
 
 	for _, role := range roles {
-		bindCmd := exec.Command("gcloud", "projects", "add-iam-policy-binding", ProjectID,
+		bindCmd := exec.Command("gcloud", "projects", "add-iam-policy-binding", cfg.ProjectID,
 			"--member", fmt.Sprintf("serviceAccount:%s", serviceAccountEmail),
 			"--role", role)
 		bindCmd.Stdout = os.Stdout
@@ -216,9 +568,14 @@ func SetupServiceAccount() error {
 	return nil
 }
 
-// Full pipeline - Build, push, and deploy
+// Full pipeline - Build, push, deploy without traffic, smoke test the new revision, then shift traffic (or roll back)
 func Pipeline() error {
-	fmt.Println("Running full pipeline: Build -> Push -> Deploy")
+	cfg, err := loadConfig(true)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Running full pipeline: Build -> Push -> Self-check -> Deploy -> Verify")
 
 	if err := DockerBuild(); err != nil {
 		return fmt.Errorf("docker build failed: %v", err)
@@ -228,7 +585,11 @@ func Pipeline() error {
 		return fmt.Errorf("docker push failed: %v", err)
 	}
 
-	if err := Deploy(); err != nil {
+	if err := selfCheck(cfg); err != nil {
+		return err
+	}
+
+	if err := deployVerified(cfg); err != nil {
 		return fmt.Errorf("deployment failed: %v", err)
 	}
 
@@ -238,6 +599,10 @@ func Pipeline() error {
 
 // FullPipeline - Complete pipeline with service account setup
 func FullPipeline() error {
+	if _, err := loadConfig(true); err != nil {
+		return err
+	}
+
 	fmt.Println("Running full pipeline with service account: Setup -> Build -> Push -> Deploy")
 
 	if err := SetupServiceAccount(); err != nil {
@@ -262,13 +627,18 @@ func FullPipeline() error {
 
 // Setup - Create Artifact Registry repository (run once)
 func Setup() error {
-	fmt.Printf("Creating Artifact Registry repository: %s\n", Repository)
+	cfg, err := loadConfig(true)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Creating Artifact Registry repository: %s\n", cfg.Repository)
 
 	args := []string{
-		"artifacts", "repositories", "create", Repository,
+		"artifacts", "repositories", "create", cfg.Repository,
 		"--repository-format", "docker",
-		"--location", Region,
-		"--project", ProjectID,
+		"--location", cfg.Region,
+		"--project", cfg.ProjectID,
 	}
 
 	cmd := exec.Command("gcloud", args...)
@@ -283,7 +653,7 @@ func Setup() error {
 	// Configure Docker to use gcloud as credential helper
 	fmt.Println("Configuring Docker authentication for Artifact Registry...")
 	authCmd := exec.Command("gcloud", "auth", "configure-docker",
-		fmt.Sprintf("%s-docker.pkg.dev", Region), "--project", ProjectID)
+		fmt.Sprintf("%s-docker.pkg.dev", cfg.Region), "--project", cfg.ProjectID)
 	authCmd.Stdout = os.Stdout
 	authCmd.Stderr = os.Stderr
 	return authCmd.Run()
@@ -291,12 +661,17 @@ func Setup() error {
 
 // Logs - View Cloud Run service logs
 func Logs() error {
-	fmt.Printf("Fetching logs for Cloud Run service: %s\n", ServiceName)
+	cfg, err := loadConfig(false)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Fetching logs for Cloud Run service: %s\n", cfg.ServiceName)
 
 	cmd := exec.Command("gcloud", "logs", "tail",
-		fmt.Sprintf("projects/%s/logs/run.googleapis.com%%2Fstdout", ProjectID),
-		"--filter", fmt.Sprintf("resource.labels.service_name=%s", ServiceName),
-		"--project", ProjectID)
+		fmt.Sprintf("projects/%s/logs/run.googleapis.com%%2Fstdout", cfg.ProjectID),
+		"--filter", fmt.Sprintf("resource.labels.service_name=%s", cfg.ServiceName),
+		"--project", cfg.ProjectID)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
@@ -304,11 +679,16 @@ func Logs() error {
 
 // Status - Get Cloud Run service status and URL
 func Status() error {
-	fmt.Printf("Getting status for Cloud Run service: %s\n", ServiceName)
+	cfg, err := loadConfig(false)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Getting status for Cloud Run service: %s\n", cfg.ServiceName)
 
-	cmd := exec.Command("gcloud", "run", "services", "describe", ServiceName,
-		"--region", Region,
-		"--project", ProjectID,
+	cmd := exec.Command("gcloud", "run", "services", "describe", cfg.ServiceName,
+		"--region", cfg.Region,
+		"--project", cfg.ProjectID,
 		"--format", "value(status.url)")
 
 	output, err := cmd.Output()
@@ -325,3 +705,246 @@ func Status() error {
 
 	return nil
 }
+
+// JobsImageName is the image holding the src/cmd/jobs entrypoint
+const JobsImageName = "flight-ticket-jobs"
+
+// batchJob is a Cloud Run Job running one src/cmd/jobs command on a schedule
+type batchJob struct {
+	name       string
+	args       []string
+	schedule   string // cron, Etc/UTC
+	maxRetries int
+}
+
+// BatchJobs are deployed as <service>-<name> Cloud Run Jobs
+var BatchJobs = []batchJob{
+	{name: "cleanup", args: []string{"cleanup"}, schedule: "0 3 * * *", maxRetries: 3},
+	{name: "export", args: []string{"export"}, schedule: "0 2 * * *", maxRetries: 1},
+	// One run per hourly departure slot; retries would resend reminders
+	{name: "reminders", args: []string{"reminders", "-window=1h"}, schedule: "0 * * * *", maxRetries: 0},
+	// Emails last month's report on the 1st; a retry would email it twice
+	{name: "usage-report", args: []string{"usage-report"}, schedule: "0 6 1 * *", maxRetries: 0},
+}
+
+// JobsBuild - Build the batch jobs Docker image
+func JobsBuild() error {
+	fmt.Printf("Building Docker image: %s\n", JobsImageName)
+	version, commit, buildTime := buildVersion()
+	cmd := exec.Command("docker", "build",
+		"--build-arg", "SERVICE=jobs",
+		"--build-arg", "VERSION="+version,
+		"--build-arg", "COMMIT="+commit,
+		"--build-arg", "BUILD_TIME="+buildTime,
+		"-t", JobsImageName, ".")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// JobsPush - Tag and push the batch jobs image to Google Artifact Registry
+func JobsPush() error {
+	cfg, err := loadConfig(true)
+	if err != nil {
+		return err
+	}
+
+	imageURL := cfg.RegistryImage(JobsImageName)
+	fmt.Printf("Pushing image to Artifact Registry: %s\n", imageURL)
+	if err := exec.Command("docker", "tag", JobsImageName, imageURL).Run(); err != nil {
+		return fmt.Errorf("failed to tag image: %v", err)
+	}
+	cmd := exec.Command("docker", "push", imageURL)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// JobsDeploy - Deploy the cleanup, export, reminders and usage-report Cloud Run Jobs and their Cloud Scheduler triggers
+func JobsDeploy() error {
+	cfg, err := loadConfig(true)
+	if err != nil {
+		return err
+	}
+
+	envVars := []string{"GOOGLE_CLOUD_PROJECT=" + cfg.ProjectID}
+	for _, key := range []string{"STORAGE_BACKEND", "FIRESTORE_DATABASE", "FIRESTORE_COLLECTION", "BACKUP_BUCKET", "REMINDER_TOPIC",
+		"USAGE_REPORT_TO", "USAGE_REPORT_FROM", "SMTP_ADDR", "SMTP_USERNAME"} {
+		if value := os.Getenv(key); value != "" {
+			envVars = append(envVars, key+"="+value)
+		}
+	}
+	// The SMTP password is read from Secret Manager rather than set in the job
+	var secrets []string
+	if secret := os.Getenv("SMTP_PASSWORD_SECRET"); secret != "" {
+		secrets = append(secrets, "--set-secrets", "SMTP_PASSWORD="+secret+":latest")
+	}
+
+	for _, job := range BatchJobs {
+		name := cfg.ServiceName + "-" + job.name
+		fmt.Printf("Deploying Cloud Run Job: %s\n", name)
+		args := append([]string{"run", "jobs", "deploy", name,
+			"--image", cfg.RegistryImage(JobsImageName),
+			"--args", strings.Join(job.args, ","),
+			"--region", cfg.Region,
+			"--project", cfg.ProjectID,
+			"--service-account", cfg.ServiceAccount,
+			"--max-retries", strconv.Itoa(job.maxRetries),
+			"--task-timeout", "1h",
+			"--memory", "512Mi",
+			"--set-env-vars", strings.Join(envVars, ",")}, secrets...)
+		if err := gcloud(args...); err != nil {
+			return fmt.Errorf("failed to deploy job %s: %v", name, err)
+		}
+
+		// Cloud Scheduler starts executions through the Cloud Run Admin API
+		uri := fmt.Sprintf("https://run.googleapis.com/v2/projects/%s/locations/%s/jobs/%s:run", cfg.ProjectID, cfg.Region, name)
+		trigger := []string{name,
+			"--location", cfg.Region,
+			"--project", cfg.ProjectID,
+			"--schedule", job.schedule,
+			"--time-zone", "Etc/UTC",
+			"--uri", uri,
+			"--http-method", "POST",
+			"--oauth-service-account-email", cfg.ServiceAccount,
+		}
+		verb := "update"
+		if exec.Command("gcloud", "scheduler", "jobs", "describe", name, "--location", cfg.Region, "--project", cfg.ProjectID).Run() != nil {
+			verb = "create"
+		}
+		fmt.Printf("Scheduling %s: %s\n", name, job.schedule)
+		if err := gcloud(append([]string{"scheduler", "jobs", verb, "http"}, trigger...)...); err != nil {
+			return fmt.Errorf("failed to schedule job %s: %v", name, err)
+		}
+	}
+
+	fmt.Println("Jobs deployed. The service account needs roles/run.invoker to start them from Cloud Scheduler.")
+	return nil
+}
+
+// JobsRun - Execute a Cloud Run Job now and wait for it (e.g. mage jobsRun cleanup)
+func JobsRun(job string) error {
+	cfg, err := loadConfig(false)
+	if err != nil {
+		return err
+	}
+
+	name := cfg.ServiceName + "-" + job
+	fmt.Printf("Executing Cloud Run Job: %s\n", name)
+	return gcloud("run", "jobs", "execute", name,
+		"--region", cfg.Region,
+		"--project", cfg.ProjectID,
+		"--wait")
+}
+
+// JobsPipeline - Build, push and deploy the batch jobs
+func JobsPipeline() error {
+	if _, err := loadConfig(true); err != nil {
+		return err
+	}
+	if err := JobsBuild(); err != nil {
+		return fmt.Errorf("docker build failed: %v", err)
+	}
+	if err := JobsPush(); err != nil {
+		return fmt.Errorf("docker push failed: %v", err)
+	}
+	return JobsDeploy()
+}
+
+// TerraformDir holds the infrastructure definition
+const TerraformDir = "infra/terraform"
+
+// InfraGenerate - Write terraform.tfvars.json from the deployment configuration (IMAGE_TAG selects the image, default latest)
+func InfraGenerate() error {
+	cfg, err := loadConfig(true)
+	if err != nil {
+		return err
+	}
+
+	tag := os.Getenv("IMAGE_TAG")
+	if tag == "" {
+		tag = "latest"
+	}
+
+	vars := map[string]interface{}{
+		"project_id":    cfg.ProjectID,
+		"region":        cfg.Region,
+		"repository":    cfg.Repository,
+		"service_name":  cfg.ServiceName,
+		"image":         cfg.ImageURL() + ":" + tag,
+		"min_instances": cfg.MinInstances,
+		"max_instances": cfg.MaxInstances,
+		"cpu_always_on": cfg.CPUAlwaysOn,
+
+		"allow_unauthenticated": cfg.AllowUnauthenticated,
+		"ingress":               Ingresses[cfg.Ingress],
+	}
+
+	data, err := json.MarshalIndent(vars, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(TerraformDir, "terraform.tfvars.json")
+	fmt.Printf("Writing %s\n", path)
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// InfraPlan - Initialize Terraform with state in TF_STATE_BUCKET and save a plan to review
+func InfraPlan() error {
+	if err := InfraGenerate(); err != nil {
+		return fmt.Errorf("failed to generate tfvars: %v", err)
+	}
+
+	bucket := os.Getenv("TF_STATE_BUCKET")
+	if bucket == "" {
+		return fmt.Errorf("TF_STATE_BUCKET must name the GCS bucket holding Terraform state")
+	}
+
+	if err := terraform("init", "-input=false", "-backend-config=bucket="+bucket); err != nil {
+		return fmt.Errorf("terraform init failed: %v", err)
+	}
+	return terraform("plan", "-input=false", "-out=tfplan")
+}
+
+// InfraApply - Apply the plan saved by infraPlan
+func InfraApply() error {
+	if _, err := os.Stat(filepath.Join(TerraformDir, "tfplan")); err != nil {
+		return fmt.Errorf("no saved plan: run mage infraPlan first")
+	}
+	if err := terraform("apply", "-input=false", "tfplan"); err != nil {
+		return fmt.Errorf("terraform apply failed: %v", err)
+	}
+	return os.Remove(filepath.Join(TerraformDir, "tfplan"))
+}
+
+// InfraImport - Adopt resources previously created with gcloud (setup, setupServiceAccount, deploy) into Terraform state
+func InfraImport() error {
+	cfg, err := loadConfig(true)
+	if err != nil {
+		return err
+	}
+
+	serviceAccountEmail := cfg.ServiceAccount
+	imports := [][2]string{
+		{"google_artifact_registry_repository.images", fmt.Sprintf("projects/%s/locations/%s/repositories/%s", cfg.ProjectID, cfg.Region, cfg.Repository)},
+		{"google_service_account.service", fmt.Sprintf("projects/%s/serviceAccounts/%s", cfg.ProjectID, serviceAccountEmail)},
+		{"google_cloud_run_v2_service.api", fmt.Sprintf("projects/%s/locations/%s/services/%s", cfg.ProjectID, cfg.Region, cfg.ServiceName)},
+	}
+
+	for _, imp := range imports {
+		fmt.Printf("Importing %s\n", imp[0])
+		if err := terraform("import", "-input=false", imp[0], imp[1]); err != nil {
+			fmt.Printf("Note: Import of %s failed (might not exist or already be managed): %v\n", imp[0], err)
+		}
+	}
+	return nil
+}
+
+// terraform runs a terraform command in TerraformDir
+func terraform(args ...string) error {
+	cmd := exec.Command("terraform", append([]string{"-chdir=" + TerraformDir}, args...)...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
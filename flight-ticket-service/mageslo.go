@@ -0,0 +1,182 @@
+//go:build mage
+// +build mage
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"flight-ticket-service/src/metrics"
+
+	"google.golang.org/api/googleapi"
+	monitoring "google.golang.org/api/monitoring/v3"
+)
+
+// SloAlerts - Create or update the Cloud Monitoring SLOs and burn rate alert policies of an environment (e.g. mage slo:alerts prod)
+func SloAlerts(environment string) error {
+	cfg, err := loadEnvironmentConfig(environment, false)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	client, err := monitoring.NewService(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create Cloud Monitoring client: %v", err)
+	}
+
+	// ALERT_NOTIFICATION_CHANNELS lists notification channel resource names, comma-separated
+	var channels []string
+	for _, channel := range strings.Split(os.Getenv("ALERT_NOTIFICATION_CHANNELS"), ",") {
+		if channel = strings.TrimSpace(channel); channel != "" {
+			channels = append(channels, channel)
+		}
+	}
+	if len(channels) == 0 {
+		fmt.Println("⚠️  ALERT_NOTIFICATION_CHANNELS is not set, alerts will only show in the console")
+	}
+
+	project := "projects/" + cfg.ProjectID
+	service, err := ensureMonitoringService(ctx, client, project, cfg)
+	if err != nil {
+		return err
+	}
+
+	for _, slo := range metrics.SLOs {
+		objective, err := ensureSLO(ctx, client, service, slo)
+		if err != nil {
+			return err
+		}
+		for _, alert := range metrics.BurnRateAlerts {
+			policy := burnRatePolicy(cfg, environment, objective, slo, alert, channels)
+			if err := upsertAlertPolicy(ctx, client, project, policy); err != nil {
+				return err
+			}
+			fmt.Printf("✅ %s\n", policy.DisplayName)
+		}
+	}
+	return nil
+}
+
+// ensureMonitoringService registers the Cloud Run service with Cloud Monitoring
+func ensureMonitoringService(ctx context.Context, client *monitoring.Service, project string, cfg DeployConfig) (string, error) {
+	name := project + "/services/" + cfg.ServiceName
+	if _, err := client.Services.Get(name).Context(ctx).Do(); err == nil {
+		return name, nil
+	} else if !isNotFound(err) {
+		return "", fmt.Errorf("failed to get monitoring service: %v", err)
+	}
+
+	_, err := client.Services.Create(project, &monitoring.MService{
+		DisplayName: cfg.ServiceName,
+		BasicService: &monitoring.BasicService{
+			ServiceType:   "CLOUD_RUN",
+			ServiceLabels: map[string]string{"service_name": cfg.ServiceName, "location": cfg.Region},
+		},
+	}).ServiceId(cfg.ServiceName).Context(ctx).Do()
+	if err != nil {
+		return "", fmt.Errorf("failed to create monitoring service: %v", err)
+	}
+	fmt.Printf("✅ Monitoring service %s\n", cfg.ServiceName)
+	return name, nil
+}
+
+// ensureSLO creates or updates an SLO from its definition in src/metrics
+func ensureSLO(ctx context.Context, client *monitoring.Service, service string, slo metrics.SLO) (string, error) {
+	sli := &monitoring.BasicSli{Availability: &monitoring.AvailabilityCriteria{}}
+	if slo.LatencyThreshold > 0 {
+		sli = &monitoring.BasicSli{Latency: &monitoring.LatencyCriteria{Threshold: seconds(slo.LatencyThreshold)}}
+	}
+	objective := &monitoring.ServiceLevelObjective{
+		DisplayName:           slo.Description,
+		Goal:                  slo.Objective,
+		RollingPeriod:         seconds(slo.Window),
+		ServiceLevelIndicator: &monitoring.ServiceLevelIndicator{BasicSli: sli},
+	}
+
+	name := service + "/serviceLevelObjectives/" + slo.Name
+	_, err := client.Services.ServiceLevelObjectives.Get(name).Context(ctx).Do()
+	switch {
+	case err == nil:
+		_, err = client.Services.ServiceLevelObjectives.Patch(name, objective).
+			UpdateMask("displayName,goal,rollingPeriod,serviceLevelIndicator").Context(ctx).Do()
+	case isNotFound(err):
+		_, err = client.Services.ServiceLevelObjectives.Create(service, objective).
+			ServiceLevelObjectiveId(slo.Name).Context(ctx).Do()
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to save SLO %s: %v", slo.Name, err)
+	}
+	fmt.Printf("✅ SLO %s: %s\n", slo.Name, slo.Description)
+	return name, nil
+}
+
+// burnRatePolicy fires when the budget burns faster than the threshold over both windows
+func burnRatePolicy(cfg DeployConfig, environment, objective string, slo metrics.SLO, alert metrics.BurnRateAlert, channels []string) *monitoring.AlertPolicy {
+	condition := func(window time.Duration) *monitoring.Condition {
+		return &monitoring.Condition{
+			DisplayName: fmt.Sprintf("%s burn rate over %s > %g", slo.Name, metrics.FormatWindow(window), alert.Threshold),
+			ConditionThreshold: &monitoring.MetricThreshold{
+				Filter:         fmt.Sprintf("select_slo_burn_rate(%q, %q)", objective, seconds(window)),
+				Comparison:     "COMPARISON_GT",
+				ThresholdValue: alert.Threshold,
+				Duration:       "0s",
+			},
+		}
+	}
+
+	name := cfg.ServiceName
+	if environment != "" {
+		name += " (" + environment + ")"
+	}
+	return &monitoring.AlertPolicy{
+		DisplayName: fmt.Sprintf("%s %s SLO %s burn", name, slo.Name, alert.Name),
+		Combiner:    "AND",
+		Conditions:  []*monitoring.Condition{condition(alert.LongWindow), condition(alert.ShortWindow)},
+		Documentation: &monitoring.Documentation{
+			MimeType: "text/markdown",
+			Content: fmt.Sprintf("%s is spending its error budget (%s) %gx faster than sustainable over the last %s and %s. Severity: %s.",
+				cfg.ServiceName, slo.Description, alert.Threshold, metrics.FormatWindow(alert.LongWindow), metrics.FormatWindow(alert.ShortWindow), alert.Severity),
+		},
+		Enabled:              true,
+		NotificationChannels: channels,
+		UserLabels:           map[string]string{"slo": slo.Name, "severity": alert.Severity},
+		AlertStrategy:        &monitoring.AlertStrategy{AutoClose: seconds(7 * 24 * time.Hour)},
+	}
+}
+
+// upsertAlertPolicy updates the policy with the same display name, or creates it
+func upsertAlertPolicy(ctx context.Context, client *monitoring.Service, project string, policy *monitoring.AlertPolicy) error {
+	existing, err := client.Projects.AlertPolicies.List(project).
+		Filter(fmt.Sprintf("display_name=%q", policy.DisplayName)).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("failed to list alert policies: %v", err)
+	}
+
+	if len(existing.AlertPolicies) > 0 {
+		_, err = client.Projects.AlertPolicies.Patch(existing.AlertPolicies[0].Name, policy).Context(ctx).Do()
+	} else {
+		_, err = client.Projects.AlertPolicies.Create(project, policy).Context(ctx).Do()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save alert policy %q: %v", policy.DisplayName, err)
+	}
+	return nil
+}
+
+// seconds formats a duration as a protobuf Duration string
+func seconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
+}
+
+func isNotFound(err error) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound
+}
@@ -0,0 +1,330 @@
+//go:build mage
+// +build mage
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// VerifyTag is the revision tag that exposes a new revision before it receives traffic
+const VerifyTag = "verify"
+
+// Deploy verification timeouts
+const (
+	RevisionReadyTimeout = 5 * time.Minute
+	HealthCheckAttempts  = 5
+)
+
+// SelfCheckJob suffixes the Cloud Run Job that runs server --check
+const SelfCheckJob = "check"
+
+// trafficTarget is an entry of a Cloud Run service's traffic split
+type trafficTarget struct {
+	RevisionName string `json:"revisionName"`
+	Percent      int    `json:"percent"`
+	Tag          string `json:"tag"`
+	URL          string `json:"url"`
+}
+
+// describeTraffic returns the service's current traffic split
+func describeTraffic(cfg DeployConfig) ([]trafficTarget, error) {
+	out, err := exec.Command("gcloud", "run", "services", "describe", cfg.ServiceName,
+		"--region", cfg.Region,
+		"--project", cfg.ProjectID,
+		"--format", "json").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe service: %v", err)
+	}
+
+	var service struct {
+		Status struct {
+			Traffic []trafficTarget `json:"traffic"`
+		} `json:"status"`
+	}
+	if err := json.Unmarshal(out, &service); err != nil {
+		return nil, fmt.Errorf("failed to parse service: %v", err)
+	}
+	return service.Status.Traffic, nil
+}
+
+// deployVerified deploys a revision without traffic, waits for it to become
+// ready and smoke tests it through its tagged URL. Traffic moves to the new
+// revision only if the test passes; otherwise the previous split is restored.
+func deployVerified(cfg DeployConfig) error {
+	if !serviceExists(cfg) {
+		// Nothing to fall back to: deploy with traffic and test the service URL
+		fmt.Printf("Service %s does not exist yet, deploying without a previous revision to roll back to\n", cfg.ServiceName)
+		if err := gcloud(deployArgs(cfg)...); err != nil {
+			return err
+		}
+		url, err := exec.Command("gcloud", "run", "services", "describe", cfg.ServiceName,
+			"--region", cfg.Region,
+			"--project", cfg.ProjectID,
+			"--format", "value(status.url)").Output()
+		if err != nil {
+			return fmt.Errorf("failed to get service URL: %v", err)
+		}
+		return smokeTest(cfg, strings.TrimSpace(string(url)))
+	}
+
+	previous, err := describeTraffic(cfg)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Deploying %s to %s without traffic\n", cfg.ImageURL(), cfg.ServiceName)
+	if err := gcloud(append(deployArgs(cfg), "--no-traffic", "--tag", VerifyTag)...); err != nil {
+		return err
+	}
+
+	traffic, err := describeTraffic(cfg)
+	if err != nil {
+		return err
+	}
+	var candidate trafficTarget
+	for _, target := range traffic {
+		if target.Tag == VerifyTag {
+			candidate = target
+		}
+	}
+	if candidate.RevisionName == "" || candidate.URL == "" {
+		return fmt.Errorf("new revision has no %s tag URL", VerifyTag)
+	}
+
+	fmt.Printf("Waiting for revision %s to become ready\n", candidate.RevisionName)
+	err = waitForRevision(cfg, candidate.RevisionName, RevisionReadyTimeout)
+	if err == nil {
+		fmt.Printf("Smoke testing %s\n", candidate.URL)
+		err = smokeTest(cfg, candidate.URL)
+	}
+	if err != nil {
+		fmt.Printf("❌ Verification of %s failed: %v\n", candidate.RevisionName, err)
+		if rollbackErr := restoreTraffic(cfg, previous); rollbackErr != nil {
+			return fmt.Errorf("verification failed (%v) and restoring traffic failed: %v", err, rollbackErr)
+		}
+		return fmt.Errorf("verification failed, traffic restored to the previous revision: %v", err)
+	}
+
+	// The latest ready revision is the verified one; routing to latest keeps later deploys serving
+	fmt.Printf("✅ Revision %s verified, routing all traffic to it\n", candidate.RevisionName)
+	return gcloud("run", "services", "update-traffic", cfg.ServiceName,
+		"--to-latest",
+		"--remove-tags", VerifyTag,
+		"--region", cfg.Region,
+		"--project", cfg.ProjectID)
+}
+
+// waitForRevision polls the revision's Ready condition
+func waitForRevision(cfg DeployConfig, revision string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		out, err := exec.Command("gcloud", "run", "revisions", "describe", revision,
+			"--region", cfg.Region,
+			"--project", cfg.ProjectID,
+			"--format", "json").Output()
+		if err != nil {
+			return fmt.Errorf("failed to describe revision: %v", err)
+		}
+
+		var rev struct {
+			Status struct {
+				Conditions []struct {
+					Type    string `json:"type"`
+					Status  string `json:"status"`
+					Message string `json:"message"`
+				} `json:"conditions"`
+			} `json:"status"`
+		}
+		if err := json.Unmarshal(out, &rev); err != nil {
+			return fmt.Errorf("failed to parse revision: %v", err)
+		}
+		for _, condition := range rev.Status.Conditions {
+			if condition.Type != "Ready" {
+				continue
+			}
+			switch condition.Status {
+			case "True":
+				return nil
+			case "False":
+				return fmt.Errorf("revision %s is not ready: %s", revision, condition.Message)
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("revision %s not ready after %s", revision, timeout)
+		}
+		time.Sleep(5 * time.Second)
+	}
+}
+
+// restoreTraffic routes traffic back to the revisions that served it before the deployment
+func restoreTraffic(cfg DeployConfig, previous []trafficTarget) error {
+	var split []string
+	for _, target := range previous {
+		if target.Percent > 0 {
+			split = append(split, fmt.Sprintf("%s=%d", target.RevisionName, target.Percent))
+		}
+	}
+	if len(split) == 0 {
+		return fmt.Errorf("no previous revision was serving traffic")
+	}
+
+	fmt.Printf("Restoring traffic: %s\n", strings.Join(split, ", "))
+	return gcloud("run", "services", "update-traffic", cfg.ServiceName,
+		"--to-revisions", strings.Join(split, ","),
+		"--remove-tags", VerifyTag,
+		"--region", cfg.Region,
+		"--project", cfg.ProjectID)
+}
+
+// smokeTest checks /health and runs a create, get and cancel booking flow
+// against baseURL. SMOKE_TEST_API_KEY is sent as X-API-Key when set.
+func smokeTest(cfg DeployConfig, baseURL string) error {
+	client := &http.Client{Timeout: 30 * time.Second}
+	apiKey := os.Getenv("SMOKE_TEST_API_KEY")
+
+	// Without public access, Cloud Run admits the deployer's own identity token
+	var identityToken string
+	if !cfg.AllowUnauthenticated {
+		out, err := exec.Command("gcloud", "auth", "print-identity-token").Output()
+		if err != nil {
+			return fmt.Errorf("failed to get an identity token for the smoke test: %v", err)
+		}
+		identityToken = strings.TrimSpace(string(out))
+	}
+
+	call := func(method, path string, body interface{}, want int, out interface{}) error {
+		var reader io.Reader
+		if body != nil {
+			data, err := json.Marshal(body)
+			if err != nil {
+				return err
+			}
+			reader = bytes.NewReader(data)
+		}
+		req, err := http.NewRequest(method, baseURL+path, reader)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		if identityToken != "" {
+			req.Header.Set("Authorization", "Bearer "+identityToken)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("%s %s: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != want {
+			return fmt.Errorf("%s %s: expected status %d, got %d: %s", method, path, want, resp.StatusCode, strings.TrimSpace(string(data)))
+		}
+		if out != nil {
+			if err := json.Unmarshal(data, out); err != nil {
+				return fmt.Errorf("%s %s: invalid response: %v", method, path, err)
+			}
+		}
+		fmt.Printf("  ✓ %s %s\n", method, path)
+		return nil
+	}
+
+	// Allow for cold starts
+	var health struct {
+		Status string `json:"status"`
+	}
+	var err error
+	for attempt := 1; attempt <= HealthCheckAttempts; attempt++ {
+		if err = call(http.MethodGet, "/health", nil, http.StatusOK, &health); err == nil {
+			break
+		}
+		time.Sleep(time.Duration(attempt) * 2 * time.Second)
+	}
+	if err != nil && cfg.Ingress != IngressAll {
+		return fmt.Errorf("health check failed: %v (with %s ingress, deploy from a network that can reach the service, e.g. a Cloud Build private pool in its VPC)", err, cfg.Ingress)
+	}
+	if err != nil {
+		return fmt.Errorf("health check failed: %v", err)
+	}
+	if health.Status != "healthy" {
+		return fmt.Errorf("health check reported %q", health.Status)
+	}
+
+	// Booking flow; the ticket is cancelled afterwards and marked with flight ZZ9999
+	var ticket struct {
+		ConfirmationID string `json:"confirmation_id"`
+	}
+	booking := map[string]interface{}{
+		"origin":         "JFK",
+		"destination":    "LAX",
+		"departure_date": time.Now().AddDate(0, 0, 30).Format("2006-01-02"),
+		"departure_time": "14:30",
+		"flight_number":  "ZZ9999",
+		"passengers":     1,
+	}
+	if err := call(http.MethodPost, "/ticket", booking, http.StatusCreated, &ticket); err != nil {
+		return err
+	}
+	if ticket.ConfirmationID == "" {
+		return fmt.Errorf("created ticket has no confirmation_id")
+	}
+
+	var fetched struct {
+		ConfirmationID string `json:"confirmation_id"`
+	}
+	if err := call(http.MethodGet, "/ticket/"+ticket.ConfirmationID, nil, http.StatusOK, &fetched); err != nil {
+		return err
+	}
+	if fetched.ConfirmationID != ticket.ConfirmationID {
+		return fmt.Errorf("fetched ticket %q, expected %q", fetched.ConfirmationID, ticket.ConfirmationID)
+	}
+
+	return call(http.MethodDelete, "/ticket/"+ticket.ConfirmationID, nil, http.StatusOK, nil)
+}
+
+// selfCheck runs the pushed image with --check as a Cloud Run Job, with the
+// service account and settings of the service, and fails when a check fails.
+// The report is in the job execution's logs.
+func selfCheck(cfg DeployConfig) error {
+	name := cfg.ServiceName + "-" + SelfCheckJob
+	envVars := append([]string{"GOOGLE_CLOUD_PROJECT=" + cfg.ProjectID, "GIN_MODE=release"}, cfg.EnvVars...)
+	if secrets := os.Getenv("SELF_CHECK_SECRETS"); secrets != "" {
+		envVars = append(envVars, "SELF_CHECK_SECRETS="+secrets)
+	}
+
+	fmt.Printf("Deploying self-check job %s with %s\n", name, cfg.ImageURL())
+	if err := gcloud("run", "jobs", "deploy", name,
+		"--image", cfg.ImageURL(),
+		"--args", "--check",
+		"--region", cfg.Region,
+		"--project", cfg.ProjectID,
+		"--service-account", cfg.ServiceAccount,
+		"--max-retries", "0",
+		"--task-timeout", "5m",
+		"--memory", "512Mi",
+		"--set-env-vars", strings.Join(envVars, ",")); err != nil {
+		return fmt.Errorf("failed to deploy self-check job: %v", err)
+	}
+
+	fmt.Printf("Running self-check job %s\n", name)
+	if err := gcloud("run", "jobs", "execute", name,
+		"--region", cfg.Region,
+		"--project", cfg.ProjectID,
+		"--wait"); err != nil {
+		return fmt.Errorf("self-check failed, see the logs of job %s: %v", name, err)
+	}
+	fmt.Println("✅ Self-check passed")
+	return nil
+}
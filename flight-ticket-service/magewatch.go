@@ -0,0 +1,153 @@
+//go:build mage
+// +build mage
+
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Hot reload settings
+const (
+	WatchPort         = "6000"
+	WatchInterval     = 500 * time.Millisecond
+	LocalEmulatorHost = "localhost:8081"
+	LocalProjectID    = "demo-flight-tickets"
+)
+
+// watchPaths are scanned for changes to .go files, go.mod and go.sum
+var watchPaths = []string{"src", "pkg", "docs", "go.mod", "go.sum"}
+
+// DevWatch - Run the server on port 6000, rebuilding and restarting it when Go sources change
+func DevWatch() error {
+	binary := filepath.Join(os.TempDir(), "flight-ticket-service-dev")
+	env := watchEnv()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+
+	var server *exec.Cmd
+	restart := func() {
+		fmt.Println("🔨 Building...")
+		build := exec.Command("go", "build", "-ldflags", versionLDFlags(), "-o", binary, "./src/cmd/server")
+		build.Stdout = os.Stdout
+		build.Stderr = os.Stderr
+		if err := build.Run(); err != nil {
+			// Keep the last good build serving
+			fmt.Println("❌ Build failed, waiting for changes")
+			return
+		}
+
+		stopServer(server)
+		server = exec.Command(binary)
+		server.Env = env
+		server.Stdout = os.Stdout
+		server.Stderr = os.Stderr
+		if err := server.Start(); err != nil {
+			fmt.Printf("❌ Failed to start server: %v\n", err)
+			server = nil
+			return
+		}
+		fmt.Printf("🚀 Server running on http://localhost:%s (pid %d)\n", WatchPort, server.Process.Pid)
+	}
+
+	last := sourceSignature()
+	restart()
+
+	ticker := time.NewTicker(WatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-interrupt:
+			fmt.Println("Stopping...")
+			stopServer(server)
+			return nil
+		case <-ticker.C:
+			signature := sourceSignature()
+			if signature == last {
+				continue
+			}
+			// Let editors finish writing related files
+			time.Sleep(2 * WatchInterval)
+			last = sourceSignature()
+			fmt.Println("🔄 Change detected")
+			restart()
+		}
+	}
+}
+
+// watchEnv returns the server environment. When the Firestore backend is used
+// without an emulator host and the docker-compose emulator is running, the
+// server is pointed at it, so every restart reconnects to the same data.
+func watchEnv() []string {
+	env := append(os.Environ(), "PORT="+WatchPort)
+	backend := os.Getenv("STORAGE_BACKEND")
+	if (backend != "" && backend != "firestore") || os.Getenv("FIRESTORE_EMULATOR_HOST") != "" {
+		return env
+	}
+
+	conn, err := net.DialTimeout("tcp", LocalEmulatorHost, time.Second)
+	if err != nil {
+		fmt.Println("No Firestore emulator found; start one with 'mage dev:up' in flight-ticket-tools or set STORAGE_BACKEND")
+		return env
+	}
+	conn.Close()
+
+	fmt.Printf("Using the Firestore emulator at %s\n", LocalEmulatorHost)
+	env = append(env, "FIRESTORE_EMULATOR_HOST="+LocalEmulatorHost)
+	if os.Getenv("GOOGLE_CLOUD_PROJECT") == "" {
+		env = append(env, "GOOGLE_CLOUD_PROJECT="+LocalProjectID)
+	}
+	return env
+}
+
+// sourceSignature summarizes the watched files' names, sizes and modification times
+func sourceSignature() string {
+	var b strings.Builder
+	for _, root := range watchPaths {
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			if !strings.HasSuffix(path, ".go") && path != "go.mod" && path != "go.sum" {
+				return nil
+			}
+			if info, err := d.Info(); err == nil {
+				fmt.Fprintf(&b, "%s:%d:%d;", path, info.Size(), info.ModTime().UnixNano())
+			}
+			return nil
+		})
+	}
+	return b.String()
+}
+
+// stopServer shuts the server down gracefully, killing it after 10 seconds
+func stopServer(server *exec.Cmd) {
+	if server == nil || server.Process == nil {
+		return
+	}
+
+	done := make(chan struct{})
+	go func() {
+		server.Wait()
+		close(done)
+	}()
+
+	server.Process.Signal(os.Interrupt)
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		server.Process.Kill()
+		<-done
+	}
+}
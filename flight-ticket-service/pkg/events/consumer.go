@@ -0,0 +1,221 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+
+	"cloud.google.com/go/pubsub"
+)
+
+// DefaultMaxAttempts is the number of deliveries before a message is dead-lettered
+const DefaultMaxAttempts = 5
+
+// Handlers are called for each event type; nil handlers skip the event
+type Handlers struct {
+	Created   func(ctx context.Context, event TicketCreated) error
+	Updated   func(ctx context.Context, event TicketUpdated) error
+	Cancelled func(ctx context.Context, event TicketCancelled) error
+	Upgrade   func(ctx context.Context, event UpgradeChanged) error
+}
+
+// Options configures a Consumer
+type Options struct {
+	// Deduper skips events that were already processed; nil disables deduplication
+	Deduper Deduper
+	// MaxAttempts is the number of deliveries before dead-lettering (default 5)
+	MaxAttempts int
+	// DeadLetter receives messages that fail permanently or exhaust their attempts.
+	// When nil, failed messages are nacked indefinitely, leaving dead-lettering to
+	// the subscription's dead-letter policy.
+	DeadLetter *pubsub.Topic
+}
+
+// permanentError marks an error that retrying will not fix
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps a handler error so the message is dead-lettered without retries
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// outcome is what to do with a message after processing
+type outcome int
+
+const (
+	ack outcome = iota
+	nack
+	deadLetter
+)
+
+// Consumer processes change feed messages
+type Consumer struct {
+	handlers Handlers
+	opts     Options
+
+	mu       sync.Mutex
+	attempts map[string]int
+}
+
+// NewConsumer creates a consumer
+func NewConsumer(handlers Handlers, opts Options) *Consumer {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultMaxAttempts
+	}
+	return &Consumer{
+		handlers: handlers,
+		opts:     opts,
+		attempts: make(map[string]int),
+	}
+}
+
+// Receive processes messages from the subscription until ctx is cancelled.
+// Enable a retry policy with a minimum backoff on the subscription, since
+// nacked messages are otherwise redelivered immediately.
+func (c *Consumer) Receive(ctx context.Context, sub *pubsub.Subscription) error {
+	return sub.Receive(ctx, func(ctx context.Context, msg *pubsub.Message) {
+		attempt := c.attempt(msg)
+		result, err := c.process(ctx, msg.Data, attempt)
+
+		switch result {
+		case ack:
+			c.forget(msg.ID)
+			msg.Ack()
+		case nack:
+			log.Printf("Event message %s failed, will retry: %v", msg.ID, err)
+			msg.Nack()
+		case deadLetter:
+			if c.opts.DeadLetter == nil {
+				log.Printf("Dropping event message %s: %v", msg.ID, err)
+				c.forget(msg.ID)
+				msg.Ack()
+				return
+			}
+			if dlqErr := c.publishDeadLetter(ctx, msg, attempt, err); dlqErr != nil {
+				log.Printf("Failed to dead-letter event message %s: %v", msg.ID, dlqErr)
+				msg.Nack()
+				return
+			}
+			log.Printf("Dead-lettered event message %s: %v", msg.ID, err)
+			c.forget(msg.ID)
+			msg.Ack()
+		}
+	})
+}
+
+// process decodes and handles one message
+func (c *Consumer) process(ctx context.Context, data []byte, attempt int) (outcome, error) {
+	event, err := Decode(data)
+	if err != nil {
+		return deadLetter, err
+	}
+
+	id := eventID(event)
+	if c.opts.Deduper != nil {
+		claimed, err := c.opts.Deduper.Claim(ctx, id)
+		if err != nil {
+			return nack, err
+		}
+		if !claimed {
+			log.Printf("Skipping duplicate event %s", id)
+			return ack, nil
+		}
+	}
+
+	if err := c.dispatch(ctx, event); err != nil {
+		if c.opts.Deduper != nil {
+			if releaseErr := c.opts.Deduper.Release(ctx, id); releaseErr != nil {
+				log.Printf("Failed to release event %s: %v", id, releaseErr)
+			}
+		}
+
+		var permanent permanentError
+		if errors.As(err, &permanent) || (c.opts.DeadLetter != nil && attempt >= c.opts.MaxAttempts) {
+			return deadLetter, err
+		}
+		return nack, err
+	}
+
+	return ack, nil
+}
+
+// dispatch calls the handler for the event's type
+func (c *Consumer) dispatch(ctx context.Context, event interface{}) error {
+	switch e := event.(type) {
+	case TicketCreated:
+		if c.handlers.Created != nil {
+			return c.handlers.Created(ctx, e)
+		}
+	case TicketUpdated:
+		if c.handlers.Updated != nil {
+			return c.handlers.Updated(ctx, e)
+		}
+	case TicketCancelled:
+		if c.handlers.Cancelled != nil {
+			return c.handlers.Cancelled(ctx, e)
+		}
+	case UpgradeChanged:
+		if c.handlers.Upgrade != nil {
+			return c.handlers.Upgrade(ctx, e)
+		}
+	}
+	return nil
+}
+
+// attempt returns the message's delivery attempt, counting locally when the
+// subscription has no dead-letter policy (Pub/Sub only reports attempts when one is set)
+func (c *Consumer) attempt(msg *pubsub.Message) int {
+	if msg.DeliveryAttempt != nil {
+		return *msg.DeliveryAttempt
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.attempts[msg.ID]++
+	return c.attempts[msg.ID]
+}
+
+func (c *Consumer) forget(messageID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.attempts, messageID)
+}
+
+// publishDeadLetter forwards the original message with the failure reason
+func (c *Consumer) publishDeadLetter(ctx context.Context, msg *pubsub.Message, attempt int, cause error) error {
+	attributes := make(map[string]string, len(msg.Attributes)+3)
+	for key, value := range msg.Attributes {
+		attributes[key] = value
+	}
+	attributes["dead_letter_reason"] = cause.Error()
+	attributes["original_message_id"] = msg.ID
+	attributes["attempts"] = strconv.Itoa(attempt)
+
+	result := c.opts.DeadLetter.Publish(ctx, &pubsub.Message{Data: msg.Data, Attributes: attributes})
+	if _, err := result.Get(ctx); err != nil {
+		return fmt.Errorf("failed to publish to dead-letter topic: %v", err)
+	}
+	return nil
+}
+
+func eventID(event interface{}) string {
+	switch e := event.(type) {
+	case TicketCreated:
+		return e.ID
+	case TicketUpdated:
+		return e.ID
+	case TicketCancelled:
+		return e.ID
+	case UpgradeChanged:
+		return e.ID
+	}
+	return ""
+}
@@ -0,0 +1,109 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Deduper records which events have been processed.
+//
+// Claim returns false when the event was already processed or is being
+// processed by another worker. Release undoes a claim after a failure so the
+// event can be retried.
+type Deduper interface {
+	Claim(ctx context.Context, eventID string) (bool, error)
+	Release(ctx context.Context, eventID string) error
+}
+
+// MemoryDeduper remembers event IDs in memory for a retention period.
+// It only deduplicates within one process.
+type MemoryDeduper struct {
+	retention time.Duration
+
+	mu      sync.Mutex
+	claimed map[string]time.Time
+}
+
+// NewMemoryDeduper creates an in-memory deduper
+func NewMemoryDeduper(retention time.Duration) *MemoryDeduper {
+	return &MemoryDeduper{
+		retention: retention,
+		claimed:   make(map[string]time.Time),
+	}
+}
+
+// Claim marks the event as processed unless it already is
+func (d *MemoryDeduper) Claim(ctx context.Context, eventID string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	for id, at := range d.claimed {
+		if now.Sub(at) > d.retention {
+			delete(d.claimed, id)
+		}
+	}
+
+	if _, ok := d.claimed[eventID]; ok {
+		return false, nil
+	}
+	d.claimed[eventID] = now
+	return true, nil
+}
+
+// Release forgets the event
+func (d *MemoryDeduper) Release(ctx context.Context, eventID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.claimed, eventID)
+	return nil
+}
+
+// FirestoreDeduper records processed event IDs as documents, so deduplication
+// holds across instances. Configure a TTL policy on the expire_at field to
+// clean up old records.
+type FirestoreDeduper struct {
+	client     *firestore.Client
+	collection string
+	retention  time.Duration
+}
+
+// NewFirestoreDeduper creates a deduper storing records in the given
+// collection; use one collection per consumer
+func NewFirestoreDeduper(client *firestore.Client, collection string, retention time.Duration) *FirestoreDeduper {
+	return &FirestoreDeduper{
+		client:     client,
+		collection: collection,
+		retention:  retention,
+	}
+}
+
+// Claim creates the event's record; it fails if the record exists
+func (d *FirestoreDeduper) Claim(ctx context.Context, eventID string) (bool, error) {
+	now := time.Now()
+	_, err := d.client.Collection(d.collection).Doc(eventID).Create(ctx, map[string]interface{}{
+		"processed_at": now,
+		"expire_at":    now.Add(d.retention),
+	})
+	if status.Code(err) == codes.AlreadyExists {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to claim event %s: %v", eventID, err)
+	}
+	return true, nil
+}
+
+// Release deletes the event's record
+func (d *FirestoreDeduper) Release(ctx context.Context, eventID string) error {
+	if _, err := d.client.Collection(d.collection).Doc(eventID).Delete(ctx); err != nil {
+		return fmt.Errorf("failed to release event %s: %v", eventID, err)
+	}
+	return nil
+}
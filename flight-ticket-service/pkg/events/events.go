@@ -0,0 +1,108 @@
+// Package events consumes the ticket change events published by the change
+// feed (src/cmd/changefeed) for downstream services.
+//
+// Messages are decoded into TicketCreated, TicketUpdated, TicketCancelled and
+// UpgradeChanged, deduplicated by event ID, retried on failure and moved to a dead-letter
+// topic when they keep failing:
+//
+//	consumer := events.NewConsumer(events.Handlers{
+//		Created: func(ctx context.Context, e events.TicketCreated) error { ... },
+//		Cancelled: func(ctx context.Context, e events.TicketCancelled) error { ... },
+//	}, events.Options{Deduper: events.NewMemoryDeduper(24 * time.Hour)})
+//	err := consumer.Receive(ctx, client.Subscription("ticket-changes-billing"))
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"flight-ticket-service/src/changefeed"
+	"flight-ticket-service/src/models"
+)
+
+// Ticket is the ticket carried by events
+type Ticket = models.FlightTicket
+
+// CancelledStatus is the status of a cancelled ticket
+const CancelledStatus = "CANCELLED"
+
+// Metadata is common to every event
+type Metadata struct {
+	// ID is unique per change and stable across redeliveries
+	ID             string
+	ConfirmationID string
+	Time           time.Time
+}
+
+// TicketCreated is emitted when a ticket document is created
+type TicketCreated struct {
+	Metadata
+	Ticket *Ticket
+}
+
+// TicketUpdated is emitted when a ticket changes without being cancelled
+type TicketUpdated struct {
+	Metadata
+	Ticket   *Ticket
+	Previous *Ticket
+	// ChangedFields lists the Firestore field paths that changed
+	ChangedFields []string
+}
+
+// TicketCancelled is emitted when a ticket's status becomes CANCELLED or its document is deleted
+type TicketCancelled struct {
+	Metadata
+	// Ticket is nil when the document was deleted
+	Ticket   *Ticket
+	Previous *Ticket
+}
+
+// UpgradeChanged is emitted when an upgrade offer is made to a ticket or
+// changes status: upgrade_offered, upgrade_bid_placed, upgrade_accepted,
+// upgrade_declined or upgrade_rejected
+type UpgradeChanged struct {
+	Metadata
+	Type  string
+	Offer *models.UpgradeOffer
+	// Ticket is set when the event was published by the API
+	Ticket *Ticket
+}
+
+// Decode parses a change feed message into TicketCreated, TicketUpdated, TicketCancelled or UpgradeChanged.
+// Updates to tickets that were already cancelled are reported as TicketUpdated.
+func Decode(data []byte) (interface{}, error) {
+	var change changefeed.ChangeEvent
+	if err := json.Unmarshal(data, &change); err != nil {
+		return nil, fmt.Errorf("failed to parse change event: %v", err)
+	}
+	if change.ID == "" || change.ConfirmationID == "" {
+		return nil, fmt.Errorf("change event is missing id or confirmation_id")
+	}
+
+	meta := Metadata{ID: change.ID, ConfirmationID: change.ConfirmationID, Time: change.Time}
+
+	switch change.Type {
+	case changefeed.ChangeCreated:
+		return TicketCreated{Metadata: meta, Ticket: change.Ticket}, nil
+	case changefeed.ChangeDeleted:
+		return TicketCancelled{Metadata: meta, Previous: change.Previous}, nil
+	case changefeed.ChangeUpdated:
+		if cancelled(change.Ticket) && !cancelled(change.Previous) {
+			return TicketCancelled{Metadata: meta, Ticket: change.Ticket, Previous: change.Previous}, nil
+		}
+		return TicketUpdated{Metadata: meta, Ticket: change.Ticket, Previous: change.Previous, ChangedFields: change.ChangedFields}, nil
+	case changefeed.ChangeUpgradeOffered, changefeed.ChangeUpgradeBidPlaced, changefeed.ChangeUpgradeAccepted,
+		changefeed.ChangeUpgradeDeclined, changefeed.ChangeUpgradeRejected:
+		if change.Upgrade == nil {
+			return nil, fmt.Errorf("%s event is missing the upgrade offer", change.Type)
+		}
+		return UpgradeChanged{Metadata: meta, Type: change.Type, Offer: change.Upgrade, Ticket: change.Ticket}, nil
+	default:
+		return nil, fmt.Errorf("unknown change type %q", change.Type)
+	}
+}
+
+func cancelled(ticket *Ticket) bool {
+	return ticket != nil && ticket.Status == CancelledStatus
+}
@@ -0,0 +1,101 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want interface{}
+	}{
+		{"created", `{"id":"e1","type":"created","confirmation_id":"ABC123","ticket":{"status":"CONFIRMED"}}`, TicketCreated{}},
+		{"updated", `{"id":"e2","type":"updated","confirmation_id":"ABC123","ticket":{"status":"CONFIRMED","passengers":3},"previous":{"status":"CONFIRMED"}}`, TicketUpdated{}},
+		{"cancelled", `{"id":"e3","type":"updated","confirmation_id":"ABC123","ticket":{"status":"CANCELLED"},"previous":{"status":"CONFIRMED"}}`, TicketCancelled{}},
+		{"already cancelled", `{"id":"e4","type":"updated","confirmation_id":"ABC123","ticket":{"status":"CANCELLED"},"previous":{"status":"CANCELLED"}}`, TicketUpdated{}},
+		{"deleted", `{"id":"e5","type":"deleted","confirmation_id":"ABC123","previous":{"status":"CONFIRMED"}}`, TicketCancelled{}},
+		{"upgrade", `{"id":"e6","type":"upgrade_offered","confirmation_id":"ABC123","upgrade":{"id":"5b7d","cabin":"J","status":"OFFERED"}}`, UpgradeChanged{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := Decode([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if reflect.TypeOf(event) != reflect.TypeOf(tt.want) {
+				t.Errorf("Expected %T, got %T", tt.want, event)
+			}
+		})
+	}
+
+	for _, data := range []string{`not json`, `{"type":"created"}`, `{"id":"e6","type":"moved","confirmation_id":"ABC123"}`, `{"id":"e7","type":"upgrade_accepted","confirmation_id":"ABC123"}`} {
+		if _, err := Decode([]byte(data)); err == nil {
+			t.Errorf("Expected error for %s", data)
+		}
+	}
+}
+
+func TestProcessDeduplicates(t *testing.T) {
+	calls := 0
+	consumer := NewConsumer(Handlers{
+		Created: func(ctx context.Context, event TicketCreated) error {
+			calls++
+			return nil
+		},
+	}, Options{Deduper: NewMemoryDeduper(time.Hour)})
+
+	data := []byte(`{"id":"e1","type":"created","confirmation_id":"ABC123"}`)
+	for i := 0; i < 2; i++ {
+		if result, err := consumer.process(context.Background(), data, 1); result != ack || err != nil {
+			t.Errorf("Expected ack, got %v, %v", result, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expected handler to run once, ran %d times", calls)
+	}
+}
+
+func TestProcessRetriesFailures(t *testing.T) {
+	fail := errors.New("downstream unavailable")
+	calls := 0
+	consumer := NewConsumer(Handlers{
+		Created: func(ctx context.Context, event TicketCreated) error {
+			calls++
+			if calls == 1 {
+				return fail
+			}
+			return nil
+		},
+	}, Options{Deduper: NewMemoryDeduper(time.Hour)})
+
+	data := []byte(`{"id":"e1","type":"created","confirmation_id":"ABC123"}`)
+	if result, err := consumer.process(context.Background(), data, 1); result != nack || !errors.Is(err, fail) {
+		t.Errorf("Expected nack, got %v, %v", result, err)
+	}
+	// The failed claim was released, so the redelivery is processed
+	if result, _ := consumer.process(context.Background(), data, 2); result != ack || calls != 2 {
+		t.Errorf("Expected retry to be processed, got %v after %d calls", result, calls)
+	}
+}
+
+func TestProcessDeadLetters(t *testing.T) {
+	consumer := NewConsumer(Handlers{
+		Cancelled: func(ctx context.Context, event TicketCancelled) error {
+			return Permanent(errors.New("unknown ticket"))
+		},
+	}, Options{})
+
+	data := []byte(`{"id":"e1","type":"deleted","confirmation_id":"ABC123"}`)
+	if result, _ := consumer.process(context.Background(), data, 1); result != deadLetter {
+		t.Errorf("Expected permanent error to be dead-lettered, got %v", result)
+	}
+	if result, _ := consumer.process(context.Background(), []byte(`garbage`), 1); result != deadLetter {
+		t.Errorf("Expected malformed message to be dead-lettered, got %v", result)
+	}
+}
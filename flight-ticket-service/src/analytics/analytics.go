@@ -0,0 +1,369 @@
+// Package analytics counts the requests of each API key per month: totals,
+// 4xx and 5xx responses and requests per endpoint, so the demo owner can see
+// which partners and agents use what.
+//
+// Each instance adds up its requests in memory and flushes them to a Store
+// every USAGE_FLUSH_INTERVAL (default 1m), so a request costs no storage
+// write. With Firestore storage the counts are sharded counters shared by
+// every instance; otherwise they are kept in memory. Months are UTC.
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"flight-ticket-service/src/auth"
+	"flight-ticket-service/src/models"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/chi/v5"
+)
+
+// Anonymous is the key name of requests without an API key
+const Anonymous = "anonymous"
+
+// Unmatched is the endpoint of requests that matched no route, so that
+// probing random paths does not add an endpoint per path
+const Unmatched = "unmatched"
+
+// MonthLayout is the layout of month names
+const MonthLayout = "2006-01"
+
+// Defaults of the analytics settings
+const (
+	DefaultFlushInterval = time.Minute
+	DefaultShards        = 5
+	DefaultTopEndpoints  = 5
+)
+
+// flushTimeout bounds a single flush so a slow store never piles up goroutines
+const flushTimeout = 30 * time.Second
+
+// MaxAddKeys is the most keys one Add writes, the writes of one Firestore
+// batch, so that an Add either stores all its counts or none
+const MaxAddKeys = 500
+
+// Counts are the requests of one key in a month
+type Counts struct {
+	Requests     int64
+	ClientErrors int64 // 4xx responses
+	ServerErrors int64 // 5xx responses
+	Endpoints    map[string]int64
+}
+
+// add adds other to c
+func (c *Counts) add(other Counts) {
+	c.Requests += other.Requests
+	c.ClientErrors += other.ClientErrors
+	c.ServerErrors += other.ServerErrors
+	if c.Endpoints == nil {
+		c.Endpoints = make(map[string]int64)
+	}
+	for endpoint, requests := range other.Endpoints {
+		c.Endpoints[endpoint] += requests
+	}
+}
+
+// Store keeps the counts of every key per month
+type Store interface {
+	// Add adds counts of a month, by key, at most MaxAddKeys of them.
+	// On error none of the counts are added.
+	Add(ctx context.Context, month string, counts map[string]Counts) error
+	// Month returns the counts of a month, by key
+	Month(ctx context.Context, month string) (map[string]Counts, error)
+}
+
+// Config holds the analytics settings
+type Config struct {
+	FlushInterval time.Duration
+	Shards        int // Firestore counter shards per key and month
+}
+
+// ConfigFromEnv reads USAGE_FLUSH_INTERVAL and USAGE_SHARDS
+func ConfigFromEnv() (Config, error) {
+	config := Config{FlushInterval: DefaultFlushInterval, Shards: DefaultShards}
+	if value := strings.TrimSpace(os.Getenv("USAGE_FLUSH_INTERVAL")); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil || interval < time.Second {
+			return Config{}, fmt.Errorf("invalid USAGE_FLUSH_INTERVAL %q: must be a duration of at least 1s", value)
+		}
+		config.FlushInterval = interval
+	}
+	if value := strings.TrimSpace(os.Getenv("USAGE_SHARDS")); value != "" {
+		shards, err := strconv.Atoi(value)
+		if err != nil || shards < 1 {
+			return Config{}, fmt.Errorf("invalid USAGE_SHARDS %q: must be a positive number", value)
+		}
+		config.Shards = shards
+	}
+	return config, nil
+}
+
+// ParseMonth checks a YYYY-MM month name; empty means the current month
+func ParseMonth(value string, now time.Time) (string, error) {
+	if value == "" {
+		return now.UTC().Format(MonthLayout), nil
+	}
+	if _, err := time.Parse(MonthLayout, value); err != nil {
+		return "", fmt.Errorf("month %q must be YYYY-MM", value)
+	}
+	return value, nil
+}
+
+// PreviousMonth returns the month before the one of now
+func PreviousMonth(now time.Time) string {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0).Format(MonthLayout)
+}
+
+// Recorder counts requests in memory and flushes them to the store
+type Recorder struct {
+	store    Store
+	interval time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	pending map[string]map[string]Counts // by month, then key
+
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+// NewRecorder creates a recorder flushing to store every interval once started
+func NewRecorder(store Store, interval time.Duration) *Recorder {
+	return &Recorder{
+		store:    store,
+		interval: interval,
+		now:      time.Now,
+		pending:  make(map[string]map[string]Counts),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Middleware counts every response by the caller's key, the route pattern
+// and the status. It runs after authentication.
+func (r *Recorder) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ww := middleware.NewWrapResponseWriter(w, req.ProtoMajor)
+		next.ServeHTTP(ww, req)
+
+		// The route pattern is only known once chi has routed the request
+		endpoint := Unmatched
+		if rctx := chi.RouteContext(req.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			endpoint = req.Method + " " + rctx.RoutePattern()
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		r.Record(CallerName(req), endpoint, status)
+	})
+}
+
+// Record counts one response
+func (r *Recorder) Record(key, endpoint string, status int) {
+	month := r.now().UTC().Format(MonthLayout)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	keys := r.pending[month]
+	if keys == nil {
+		keys = make(map[string]Counts)
+		r.pending[month] = keys
+	}
+	counts := keys[key]
+	counts.add(Counts{Requests: 1, Endpoints: map[string]int64{endpoint: 1}})
+	switch {
+	case status >= 500:
+		counts.ServerErrors++
+	case status >= 400:
+		counts.ClientErrors++
+	}
+	keys[key] = counts
+}
+
+// Flush writes the pending counts to the store, MaxAddKeys keys at a time.
+// Counts that fail to be written are kept for the next flush.
+func (r *Recorder) Flush(ctx context.Context) error {
+	r.mu.Lock()
+	pending := r.pending
+	r.pending = make(map[string]map[string]Counts)
+	r.mu.Unlock()
+
+	var failed error
+	for month, counts := range pending {
+		for _, chunk := range splitCounts(counts, MaxAddKeys) {
+			if err := r.store.Add(ctx, month, chunk); err != nil {
+				failed = err
+				r.restore(month, chunk)
+			}
+		}
+	}
+	return failed
+}
+
+// splitCounts splits counts into maps of at most size keys
+func splitCounts(counts map[string]Counts, size int) []map[string]Counts {
+	var chunks []map[string]Counts
+	chunk := make(map[string]Counts)
+	for key, c := range counts {
+		if len(chunk) == size {
+			chunks = append(chunks, chunk)
+			chunk = make(map[string]Counts)
+		}
+		chunk[key] = c
+	}
+	return append(chunks, chunk)
+}
+
+// restore puts counts that could not be flushed back with the pending ones
+func (r *Recorder) restore(month string, counts map[string]Counts) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	keys := r.pending[month]
+	if keys == nil {
+		keys = make(map[string]Counts)
+		r.pending[month] = keys
+	}
+	for key, c := range counts {
+		merged := keys[key]
+		merged.add(c)
+		keys[key] = merged
+	}
+}
+
+// Start flushes every interval until Close
+func (r *Recorder) Start() {
+	r.done.Add(1)
+	go func() {
+		defer r.done.Done()
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+				if err := r.Flush(ctx); err != nil {
+					log.Printf("Failed to flush API usage: %v", err)
+				}
+				cancel()
+			case <-r.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops flushing and flushes the final counts
+func (r *Recorder) Close() error {
+	close(r.stop)
+	r.done.Wait()
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+	return r.Flush(ctx)
+}
+
+// Report flushes this instance's counts and reports a month, with up to top
+// endpoints per key
+func (r *Recorder) Report(ctx context.Context, month string, top int) (models.APIUsageReport, error) {
+	if err := r.Flush(ctx); err != nil {
+		log.Printf("Failed to flush API usage: %v", err)
+	}
+	counts, err := r.store.Month(ctx, month)
+	if err != nil {
+		return models.APIUsageReport{}, err
+	}
+	return BuildReport(month, counts, top, r.now()), nil
+}
+
+// BuildReport reports the counts of a month, keys with the most requests first
+func BuildReport(month string, counts map[string]Counts, top int, now time.Time) models.APIUsageReport {
+	report := models.APIUsageReport{Month: month, GeneratedAt: now.UTC(), Keys: []models.APIKeyUsage{}}
+	for key, c := range counts {
+		usage := models.APIKeyUsage{
+			Key:          key,
+			Requests:     c.Requests,
+			ClientErrors: c.ClientErrors,
+			ServerErrors: c.ServerErrors,
+			Endpoints:    len(c.Endpoints),
+			TopEndpoints: []models.EndpointRequests{},
+		}
+		if c.Requests > 0 {
+			usage.ErrorRate = float64(c.ClientErrors+c.ServerErrors) / float64(c.Requests)
+		}
+		for endpoint, requests := range c.Endpoints {
+			usage.TopEndpoints = append(usage.TopEndpoints, models.EndpointRequests{Endpoint: endpoint, Requests: requests})
+		}
+		sort.Slice(usage.TopEndpoints, func(i, j int) bool {
+			a, b := usage.TopEndpoints[i], usage.TopEndpoints[j]
+			return a.Requests > b.Requests || (a.Requests == b.Requests && a.Endpoint < b.Endpoint)
+		})
+		if len(usage.TopEndpoints) > top {
+			usage.TopEndpoints = usage.TopEndpoints[:top]
+		}
+		report.Requests += c.Requests
+		report.Keys = append(report.Keys, usage)
+	}
+	sort.Slice(report.Keys, func(i, j int) bool {
+		a, b := report.Keys[i], report.Keys[j]
+		return a.Requests > b.Requests || (a.Requests == b.Requests && a.Key < b.Key)
+	})
+	return report
+}
+
+// CallerName returns the API key name of the request, or Anonymous
+func CallerName(r *http.Request) string {
+	if principal, ok := auth.FromContext(r.Context()); ok && principal.Name != "" {
+		return principal.Name
+	}
+	return Anonymous
+}
+
+// MemoryStore keeps counts in memory, per instance; counts are lost on restart
+type MemoryStore struct {
+	mu     sync.Mutex
+	months map[string]map[string]Counts
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{months: make(map[string]map[string]Counts)}
+}
+
+// Add adds counts of a month
+func (s *MemoryStore) Add(ctx context.Context, month string, counts map[string]Counts) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := s.months[month]
+	if keys == nil {
+		keys = make(map[string]Counts)
+		s.months[month] = keys
+	}
+	for key, c := range counts {
+		merged := keys[key]
+		merged.add(c)
+		keys[key] = merged
+	}
+	return nil
+}
+
+// Month returns a copy of the counts of a month
+func (s *MemoryStore) Month(ctx context.Context, month string) (map[string]Counts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := make(map[string]Counts, len(s.months[month]))
+	for key, c := range s.months[month] {
+		var copied Counts
+		copied.add(c)
+		counts[key] = copied
+	}
+	return counts, nil
+}
@@ -0,0 +1,261 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/auth"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestMiddlewareCountsPerKey(t *testing.T) {
+	keys, _ := auth.ParseKeys("acme:agent:acme-key")
+	store := NewMemoryStore()
+	recorder := NewRecorder(store, time.Minute)
+	recorder.now = func() time.Time { return time.Date(2026, 9, 30, 23, 59, 0, 0, time.UTC) }
+
+	r := chi.NewRouter()
+	r.Use(keys.Authenticate, recorder.Middleware)
+	r.Get("/ticket/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if chi.URLParam(r, "id") == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	r.Get("/tickets", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusInternalServerError) })
+
+	for _, request := range []struct{ path, key string }{
+		{"/ticket/ABC123", "acme-key"},
+		{"/ticket/DEF456", "acme-key"},
+		{"/ticket/missing", "acme-key"},
+		{"/tickets", "acme-key"},
+		{"/tickets", ""},
+		{"/no/such/path", ""},
+	} {
+		req := httptest.NewRequest(http.MethodGet, request.path, nil)
+		if request.key != "" {
+			req.Header.Set("X-API-Key", request.key)
+		}
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	report, err := recorder.Report(context.Background(), "2026-09", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Requests != 6 || len(report.Keys) != 2 {
+		t.Fatalf("Unexpected report %+v", report)
+	}
+	acme := report.Keys[0]
+	if acme.Key != "acme" || acme.Requests != 4 || acme.ClientErrors != 1 || acme.ServerErrors != 1 || acme.ErrorRate != 0.5 || acme.Endpoints != 2 {
+		t.Errorf("Unexpected usage %+v", acme)
+	}
+	if len(acme.TopEndpoints) != 1 || acme.TopEndpoints[0].Endpoint != "GET /ticket/{id}" || acme.TopEndpoints[0].Requests != 3 {
+		t.Errorf("Unexpected top endpoints %+v", acme.TopEndpoints)
+	}
+	anonymous := report.Keys[1]
+	if anonymous.Key != Anonymous || anonymous.Endpoints != 2 || anonymous.ClientErrors != 1 {
+		t.Errorf("Expected the unmatched request counted once as %s, got %+v", Unmatched, anonymous)
+	}
+
+	if other, _ := recorder.Report(context.Background(), "2026-10", 5); len(other.Keys) != 0 {
+		t.Errorf("Expected no usage in another month, got %+v", other.Keys)
+	}
+}
+
+// failingStore fails every Add after the allowed ones until told otherwise
+type failingStore struct {
+	*MemoryStore
+	fail    bool
+	allowed int
+}
+
+func (s *failingStore) Add(ctx context.Context, month string, counts map[string]Counts) error {
+	if len(counts) > MaxAddKeys {
+		return errors.New("too many keys")
+	}
+	if s.fail {
+		if s.allowed == 0 {
+			return errors.New("unavailable")
+		}
+		s.allowed--
+	}
+	return s.MemoryStore.Add(ctx, month, counts)
+}
+
+func TestFlushKeepsFailedCounts(t *testing.T) {
+	store := &failingStore{MemoryStore: NewMemoryStore(), fail: true}
+	recorder := NewRecorder(store, time.Minute)
+	month := time.Now().UTC().Format(MonthLayout)
+
+	recorder.Record("acme", "GET /tickets", http.StatusOK)
+	if err := recorder.Flush(context.Background()); err == nil {
+		t.Fatal("Expected the flush to fail")
+	}
+	recorder.Record("acme", "GET /tickets", http.StatusOK)
+	store.fail = false
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	counts, _ := store.Month(context.Background(), month)
+	if counts["acme"].Requests != 2 || counts["acme"].Endpoints["GET /tickets"] != 2 {
+		t.Errorf("Expected both requests after the retry, got %+v", counts)
+	}
+}
+
+func TestFlushKeepsOnlyFailedBatches(t *testing.T) {
+	store := &failingStore{MemoryStore: NewMemoryStore(), fail: true, allowed: 1}
+	recorder := NewRecorder(store, time.Minute)
+	month := time.Now().UTC().Format(MonthLayout)
+
+	keys := MaxAddKeys + 20
+	for i := 0; i < keys; i++ {
+		recorder.Record(fmt.Sprintf("key-%d", i), "GET /tickets", http.StatusOK)
+	}
+	if err := recorder.Flush(context.Background()); err == nil {
+		t.Fatal("Expected the second batch to fail")
+	}
+	if counts, _ := store.Month(context.Background(), month); len(counts) != MaxAddKeys {
+		t.Errorf("Expected the first batch stored, got %d keys", len(counts))
+	}
+
+	store.fail = false
+	if err := recorder.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	counts, _ := store.Month(context.Background(), month)
+	for i := 0; i < keys; i++ {
+		if requests := counts[fmt.Sprintf("key-%d", i)].Requests; requests != 1 {
+			t.Fatalf("Expected each request counted once, got %d for key-%d", requests, i)
+		}
+	}
+}
+
+func TestReportCountsFailedFlushOnce(t *testing.T) {
+	store := &failingStore{MemoryStore: NewMemoryStore(), fail: true, allowed: 1}
+	recorder := NewRecorder(store, time.Minute)
+	month := time.Now().UTC().Format(MonthLayout)
+
+	// Two requests per key over two batches; the second batch fails
+	keys := MaxAddKeys + 20
+	for i := 0; i < keys; i++ {
+		recorder.Record(fmt.Sprintf("key-%d", i), "GET /tickets", http.StatusOK)
+		recorder.Record(fmt.Sprintf("key-%d", i), "POST /ticket", http.StatusServiceUnavailable)
+	}
+	if err := recorder.Flush(context.Background()); err == nil {
+		t.Fatal("Expected the second batch to fail")
+	}
+
+	// The report flushes what is left; reporting again flushes nothing more
+	store.fail = false
+	for i := 0; i < 2; i++ {
+		report, err := recorder.Report(context.Background(), month, 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Requests != int64(2*keys) || len(report.Keys) != keys {
+			t.Fatalf("Expected %d requests of %d keys, got %d of %d", 2*keys, keys, report.Requests, len(report.Keys))
+		}
+		for _, usage := range report.Keys {
+			if usage.Requests != 2 || usage.ServerErrors != 1 || usage.Endpoints != 2 {
+				t.Fatalf("Expected each request of %s counted once, got %+v", usage.Key, usage)
+			}
+		}
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("USAGE_FLUSH_INTERVAL", "30s")
+	t.Setenv("USAGE_SHARDS", "10")
+	config, err := ConfigFromEnv()
+	if err != nil || config.FlushInterval != 30*time.Second || config.Shards != 10 {
+		t.Fatalf("Unexpected config %+v, %v", config, err)
+	}
+
+	for name, value := range map[string]string{"USAGE_FLUSH_INTERVAL": "10ms", "USAGE_SHARDS": "0"} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(name, value)
+			if _, err := ConfigFromEnv(); err == nil {
+				t.Errorf("Expected an error for %s=%s", name, value)
+			}
+		})
+	}
+}
+
+func TestMonths(t *testing.T) {
+	now := time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC)
+	if month := PreviousMonth(now); month != "2025-12" {
+		t.Errorf("Expected 2025-12, got %s", month)
+	}
+	if month, err := ParseMonth("", now); err != nil || month != "2026-01" {
+		t.Errorf("Expected the current month, got %s, %v", month, err)
+	}
+	for _, value := range []string{"2026-13", "2026-1", "January"} {
+		if _, err := ParseMonth(value, now); err == nil {
+			t.Errorf("Expected an error for %q", value)
+		}
+	}
+}
+
+func TestReportEmail(t *testing.T) {
+	t.Setenv("USAGE_REPORT_TO", "owner@example.com, partners@example.com")
+	t.Setenv("USAGE_REPORT_FROM", "reports@example.com")
+	t.Setenv("SMTP_ADDR", "smtp.example.com:587")
+	config, err := EmailConfigFromEnv()
+	if err != nil || !config.Enabled() || len(config.To) != 2 {
+		t.Fatalf("Unexpected config %+v, %v", config, err)
+	}
+
+	report := BuildReport("2026-09", map[string]Counts{
+		"acme": {Requests: 10, ClientErrors: 1, Endpoints: map[string]int64{"GET /tickets": 7, "POST /ticket": 3}},
+	}, 5, time.Now())
+	message, err := reportMessage(config, report)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := mail.ReadMessage(bytes.NewReader(message))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject := parsed.Header.Get("Subject"); subject != "API usage report for 2026-09" {
+		t.Errorf("Unexpected subject %q", subject)
+	}
+	_, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := multipart.NewReader(parsed.Body, params["boundary"])
+	text, _ := parts.NextPart()
+	summary, _ := io.ReadAll(text)
+	if !strings.Contains(string(summary), "acme: 10 requests, 10.0% errors, mostly GET /tickets") {
+		t.Errorf("Unexpected summary %q", summary)
+	}
+	attachment, err := parts.NextRawPart()
+	if err != nil || attachment.FileName() != "api-usage-2026-09.csv" {
+		t.Fatalf("Expected the CSV attachment, got %v", err)
+	}
+
+	var table bytes.Buffer
+	WriteCSV(&table, report)
+	if !strings.Contains(table.String(), "2026-09,acme,10,1,0,0.1000,2,GET /tickets=7; POST /ticket=3") {
+		t.Errorf("Unexpected CSV %q", table.String())
+	}
+
+	t.Setenv("SMTP_ADDR", "")
+	if _, err := EmailConfigFromEnv(); err == nil {
+		t.Error("Expected an error without an SMTP server")
+	}
+}
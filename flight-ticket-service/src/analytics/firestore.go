@@ -0,0 +1,107 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"flight-ticket-service/src/internal/shardcounter"
+
+	"cloud.google.com/go/firestore"
+)
+
+// counterRetention keeps counter documents for a year after their month
+// ends, for a Firestore TTL policy on expires_at to remove
+const counterRetention = 366 * 24 * time.Hour
+
+// FirestoreStore keeps counts in sharded Firestore counters, shared by every
+// instance. The keys collection of each month's api_usage document holds a
+// document per key and shard; a flush increments one random shard of each
+// key, so concurrent instances rarely write the same document.
+type FirestoreStore struct {
+	client  *firestore.Client
+	counter shardcounter.Counter
+}
+
+// NewFirestoreStore creates a store in the database of the given client with the given number of shards per key and month
+func NewFirestoreStore(client *firestore.Client, shards int) (*FirestoreStore, error) {
+	counter, err := shardcounter.New(shards)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FirestoreStore{client: client, counter: counter}, nil
+}
+
+// Add increments a random shard of each key in one batch
+func (s *FirestoreStore) Add(ctx context.Context, month string, counts map[string]Counts) error {
+	if len(counts) == 0 {
+		return nil
+	}
+	if len(counts) > MaxAddKeys {
+		return fmt.Errorf("cannot increment more than %d API usage counters at once, got %d", MaxAddKeys, len(counts))
+	}
+	expiresAt := time.Now().Add(counterRetention)
+	if start, err := time.Parse(MonthLayout, month); err == nil {
+		expiresAt = start.AddDate(0, 1, 0).Add(counterRetention)
+	}
+
+	batch := s.client.Batch()
+	for key, c := range counts {
+		fields := shardcounter.Increments(map[string]int64{
+			"requests":      c.Requests,
+			"client_errors": c.ClientErrors,
+			"server_errors": c.ServerErrors,
+		}, map[string]map[string]int64{"endpoints": c.Endpoints})
+		fields["key"] = key
+		fields["expires_at"] = expiresAt
+		// Key names may hold characters document IDs cannot; the key field keeps the name
+		id := strings.ReplaceAll(key, "/", "_") + "_" + s.counter.Shard()
+		batch.Set(s.collection(month).Doc(id), fields, firestore.MergeAll)
+	}
+	if _, err := batch.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to increment API usage counters: %v", err)
+	}
+	return nil
+}
+
+// Month sums the shards of every key of a month
+func (s *FirestoreStore) Month(ctx context.Context, month string) (map[string]Counts, error) {
+	docs, err := s.collection(month).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API usage counters: %v", err)
+	}
+
+	shards := make(map[string][]map[string]interface{})
+	for _, doc := range docs {
+		data := doc.Data()
+		key, _ := data["key"].(string)
+		shards[key] = append(shards[key], data)
+	}
+
+	counts := make(map[string]Counts, len(shards))
+	for key, data := range shards {
+		totals := shardcounter.Sum(data)
+		endpoints := totals.Maps["endpoints"]
+		if endpoints == nil {
+			endpoints = make(map[string]int64)
+		}
+		counts[key] = Counts{
+			Requests:     totals.Counts["requests"],
+			ClientErrors: totals.Counts["client_errors"],
+			ServerErrors: totals.Counts["server_errors"],
+			Endpoints:    endpoints,
+		}
+	}
+	return counts, nil
+}
+
+// Close leaves the client open: it belongs to the ticket repository
+func (s *FirestoreStore) Close() error {
+	return nil
+}
+
+func (s *FirestoreStore) collection(month string) *firestore.CollectionRef {
+	return s.client.Collection("api_usage").Doc(month).Collection("keys")
+}
@@ -0,0 +1,168 @@
+package analytics
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+
+	"flight-ticket-service/src/models"
+)
+
+// csvHeader names the CSV columns
+var csvHeader = []string{"month", "key", "requests", "client_errors", "server_errors", "error_rate", "endpoints", "top_endpoints"}
+
+// WriteCSV writes one row per key; top_endpoints lists "endpoint=requests"
+// entries separated by semicolons
+func WriteCSV(w io.Writer, report models.APIUsageReport) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, usage := range report.Keys {
+		top := make([]string, len(usage.TopEndpoints))
+		for i, endpoint := range usage.TopEndpoints {
+			top[i] = endpoint.Endpoint + "=" + strconv.FormatInt(endpoint.Requests, 10)
+		}
+		record := []string{
+			report.Month,
+			usage.Key,
+			strconv.FormatInt(usage.Requests, 10),
+			strconv.FormatInt(usage.ClientErrors, 10),
+			strconv.FormatInt(usage.ServerErrors, 10),
+			strconv.FormatFloat(usage.ErrorRate, 'f', 4, 64),
+			strconv.Itoa(usage.Endpoints),
+			strings.Join(top, "; "),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// EmailConfig addresses the monthly report email
+type EmailConfig struct {
+	SMTPAddr string // host:port of an SMTP server that supports STARTTLS
+	Username string // SMTP login; no authentication when empty
+	Password string
+	From     string
+	To       []string
+}
+
+// Enabled reports whether reports are emailed
+func (c EmailConfig) Enabled() bool {
+	return len(c.To) > 0
+}
+
+// EmailConfigFromEnv reads USAGE_REPORT_TO (comma-separated addresses),
+// USAGE_REPORT_FROM, SMTP_ADDR, SMTP_USERNAME and SMTP_PASSWORD
+func EmailConfigFromEnv() (EmailConfig, error) {
+	config := EmailConfig{
+		SMTPAddr: strings.TrimSpace(os.Getenv("SMTP_ADDR")),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     strings.TrimSpace(os.Getenv("USAGE_REPORT_FROM")),
+	}
+	for _, address := range strings.Split(os.Getenv("USAGE_REPORT_TO"), ",") {
+		address = strings.TrimSpace(address)
+		if address == "" {
+			continue
+		}
+		if _, err := mail.ParseAddress(address); err != nil {
+			return EmailConfig{}, fmt.Errorf("invalid USAGE_REPORT_TO address %q: %v", address, err)
+		}
+		config.To = append(config.To, address)
+	}
+	if !config.Enabled() {
+		return config, nil
+	}
+
+	if !strings.Contains(config.SMTPAddr, ":") {
+		return EmailConfig{}, fmt.Errorf("invalid SMTP_ADDR %q: must be host:port when USAGE_REPORT_TO is set", config.SMTPAddr)
+	}
+	if _, err := mail.ParseAddress(config.From); err != nil {
+		return EmailConfig{}, fmt.Errorf("invalid USAGE_REPORT_FROM %q: must be an email address when USAGE_REPORT_TO is set", config.From)
+	}
+	return config, nil
+}
+
+// SendReport emails the report with its CSV attached
+func SendReport(config EmailConfig, report models.APIUsageReport) error {
+	message, err := reportMessage(config, report)
+	if err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if config.Username != "" {
+		host, _, _ := strings.Cut(config.SMTPAddr, ":")
+		auth = smtp.PlainAuth("", config.Username, config.Password, host)
+	}
+	if err := smtp.SendMail(config.SMTPAddr, auth, config.From, config.To, message); err != nil {
+		return fmt.Errorf("failed to email usage report: %v", err)
+	}
+	return nil
+}
+
+// reportMessage builds a multipart email of a plain text summary and the CSV
+func reportMessage(config EmailConfig, report models.APIUsageReport) ([]byte, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	var summary bytes.Buffer
+	fmt.Fprintf(&summary, "API usage in %s: %d requests from %d keys.\r\n\r\n", report.Month, report.Requests, len(report.Keys))
+	for _, usage := range report.Keys {
+		fmt.Fprintf(&summary, "%s: %d requests, %.1f%% errors", usage.Key, usage.Requests, usage.ErrorRate*100)
+		if len(usage.TopEndpoints) > 0 {
+			fmt.Fprintf(&summary, ", mostly %s", usage.TopEndpoints[0].Endpoint)
+		}
+		summary.WriteString("\r\n")
+	}
+	part, err := writer.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/plain; charset=utf-8"}})
+	if err != nil {
+		return nil, err
+	}
+	part.Write(summary.Bytes())
+
+	var table bytes.Buffer
+	if err := WriteCSV(&table, report); err != nil {
+		return nil, err
+	}
+	part, err = writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/csv; charset=utf-8"},
+		"Content-Disposition":       {fmt.Sprintf(`attachment; filename="api-usage-%s.csv"`, report.Month)},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	encoded := base64.StdEncoding.EncodeToString(table.Bytes())
+	for len(encoded) > 76 {
+		fmt.Fprintf(part, "%s\r\n", encoded[:76])
+		encoded = encoded[76:]
+	}
+	fmt.Fprintf(part, "%s\r\n", encoded)
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", config.From)
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(config.To, ", "))
+	fmt.Fprintf(&message, "Subject: API usage report for %s\r\n", report.Month)
+	message.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&message, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", writer.Boundary())
+	message.Write(body.Bytes())
+	return message.Bytes(), nil
+}
@@ -0,0 +1,201 @@
+// Package auth authenticates API keys and enforces role-based access.
+//
+// Keys are configured with API_KEYS as a comma-separated list of name:role:key
+// entries, e.g. "ops:admin:s3cret,desk:agent:an0ther". Requests present a key
+// with "Authorization: Bearer <key>" or "X-API-Key: <key>".
+//
+// Other services can instead present a Google-signed ID token, see
+// IDTokenVerifier, so that Cloud Run can require IAM authentication.
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"flight-ticket-service/src/models"
+)
+
+// Role is the access level granted to an API key
+type Role string
+
+const (
+	// RoleAdmin can access every endpoint, including /admin
+	RoleAdmin Role = "admin"
+	// RoleAgent is a travel agent or gate agent
+	RoleAgent Role = "agent"
+)
+
+var validRoles = map[Role]bool{
+	RoleAdmin: true,
+	RoleAgent: true,
+}
+
+// Principal is the authenticated caller
+type Principal struct {
+	Name string
+	Role Role
+}
+
+type contextKey struct{}
+
+// KeyStore holds the configured API keys, indexed by SHA-256 hash
+type KeyStore struct {
+	keys     map[[sha256.Size]byte]Principal
+	idTokens *IDTokenVerifier
+}
+
+// ParseKeys parses a name:role:key list
+func ParseKeys(spec string) (*KeyStore, error) {
+	ks := &KeyStore{keys: make(map[[sha256.Size]byte]Principal)}
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("invalid API key entry %q: expected name:role:key", parts[0])
+		}
+
+		role := Role(parts[1])
+		if !validRoles[role] {
+			return nil, fmt.Errorf("invalid role %q for API key %s", parts[1], parts[0])
+		}
+
+		hash := sha256.Sum256([]byte(parts[2]))
+		if _, exists := ks.keys[hash]; exists {
+			return nil, fmt.Errorf("duplicate API key for %s", parts[0])
+		}
+		ks.keys[hash] = Principal{Name: parts[0], Role: role}
+	}
+
+	return ks, nil
+}
+
+// KeyStoreFromEnv parses the API_KEYS environment variable
+func KeyStoreFromEnv() (*KeyStore, error) {
+	return ParseKeys(os.Getenv("API_KEYS"))
+}
+
+// Len returns the number of configured keys
+func (ks *KeyStore) Len() int {
+	return len(ks.keys)
+}
+
+// Lookup returns the principal for an API key
+func (ks *KeyStore) Lookup(key string) (Principal, bool) {
+	principal, ok := ks.keys[sha256.Sum256([]byte(key))]
+	return principal, ok
+}
+
+// SetIDTokenVerifier makes Authenticate accept ID tokens verified by v; nil disables them
+func (ks *KeyStore) SetIDTokenVerifier(v *IDTokenVerifier) {
+	ks.idTokens = v
+}
+
+// Authenticate resolves the request's API key or ID token, if any, and stores the principal in the context.
+// Requests without either continue anonymously; requests with an unknown key or invalid token are rejected.
+// An API key takes precedence over an ID token. Valid ID tokens of service accounts without a role, such as
+// Cloud Scheduler's, also continue anonymously: Cloud Run's IAM check already admitted them.
+func (ks *KeyStore) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := requestKey(r)
+		if ks.idTokens != nil && (key == "" || isJWT(key)) {
+			if token := requestIDToken(r); token != "" {
+				principal, err := ks.idTokens.Verify(r.Context(), token)
+				switch {
+				case errors.Is(err, ErrIDTokenNotAuthorized):
+					next.ServeHTTP(w, r)
+					return
+				case err != nil:
+					writeError(w, http.StatusUnauthorized, "Invalid ID token")
+					return
+				}
+				next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+				return
+			}
+		}
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		principal, ok := ks.Lookup(key)
+		if !ok {
+			writeError(w, http.StatusUnauthorized, "Invalid API key")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+	})
+}
+
+// RequireRole rejects requests whose principal does not have one of the given roles.
+// Admins are always allowed.
+func RequireRole(roles ...Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := FromContext(r.Context())
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="flight-ticket-service"`)
+				writeError(w, http.StatusUnauthorized, "Authentication required")
+				return
+			}
+
+			if !principal.HasRole(roles...) {
+				writeError(w, http.StatusForbidden, "Insufficient permissions")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// HasRole reports whether the principal has one of the given roles
+func (p Principal) HasRole(roles ...Role) bool {
+	if p.Role == RoleAdmin {
+		return true
+	}
+	for _, role := range roles {
+		if p.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// WithPrincipal returns a context carrying the principal
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, principal)
+}
+
+// FromContext returns the authenticated principal, if any
+func FromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(contextKey{}).(Principal)
+	return principal, ok
+}
+
+func requestKey(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(bearer)
+	}
+	return ""
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(models.ErrorResponse{Error: message})
+}
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseKeys(t *testing.T) {
+	ks, err := ParseKeys("ops:admin:s3cret, desk:agent:an0ther")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if ks.Len() != 2 {
+		t.Errorf("Expected 2 keys, got %d", ks.Len())
+	}
+
+	principal, ok := ks.Lookup("an0ther")
+	if !ok || principal.Name != "desk" || principal.Role != RoleAgent {
+		t.Errorf("Unexpected principal %+v", principal)
+	}
+	if _, ok := ks.Lookup("wrong"); ok {
+		t.Error("Expected unknown key to be rejected")
+	}
+
+	for _, spec := range []string{"ops:admin", "ops:root:s3cret", "a:admin:k,b:agent:k"} {
+		if _, err := ParseKeys(spec); err == nil {
+			t.Errorf("Expected error for %q", spec)
+		}
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	ks, _ := ParseKeys("ops:admin:admin-key,desk:agent:agent-key")
+	handler := ks.Authenticate(RequireRole(RoleAdmin)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		status int
+	}{
+		{"anonymous", "", "", http.StatusUnauthorized},
+		{"invalid key", "X-API-Key", "nope", http.StatusUnauthorized},
+		{"agent", "X-API-Key", "agent-key", http.StatusForbidden},
+		{"admin", "X-API-Key", "admin-key", http.StatusOK},
+		{"admin bearer", "Authorization", "Bearer admin-key", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin/stats", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"google.golang.org/api/idtoken"
+	"google.golang.org/api/option"
+)
+
+// IDTokenConfig configures service-to-service authentication with
+// Google-signed ID tokens, which Cloud Run services and jobs present when the
+// service requires IAM authentication instead of --allow-unauthenticated
+type IDTokenConfig struct {
+	// Audiences are the accepted aud claims: the URLs the service is called
+	// at, e.g. https://flight-ticket-service-abc123-ue.a.run.app
+	Audiences []string
+	// Principals grant a role to calling service accounts, by email
+	Principals map[string]Role
+}
+
+// Enabled reports whether ID tokens are accepted
+func (c IDTokenConfig) Enabled() bool {
+	return len(c.Audiences) > 0
+}
+
+// IDTokenConfigFromEnv reads ID_TOKEN_AUDIENCES, a comma-separated list of
+// service URLs, and ID_TOKEN_PRINCIPALS, a comma-separated list of
+// email:role entries, e.g. "mcp-server@my-project.iam.gserviceaccount.com:agent"
+func IDTokenConfigFromEnv() (IDTokenConfig, error) {
+	var config IDTokenConfig
+	for _, audience := range strings.Split(os.Getenv("ID_TOKEN_AUDIENCES"), ",") {
+		audience = strings.TrimRight(strings.TrimSpace(audience), "/")
+		if audience == "" {
+			continue
+		}
+		if parsed, err := url.Parse(audience); err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+			return IDTokenConfig{}, fmt.Errorf("invalid ID_TOKEN_AUDIENCES entry %q: must be an https:// service URL", audience)
+		}
+		config.Audiences = append(config.Audiences, audience)
+	}
+
+	config.Principals = make(map[string]Role)
+	for _, entry := range strings.Split(os.Getenv("ID_TOKEN_PRINCIPALS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		separator := strings.LastIndex(entry, ":")
+		if separator <= 0 || !strings.Contains(entry[:separator], "@") {
+			return IDTokenConfig{}, fmt.Errorf("invalid ID_TOKEN_PRINCIPALS entry %q: must be email:role", entry)
+		}
+		email, role := strings.ToLower(entry[:separator]), Role(entry[separator+1:])
+		if !validRoles[role] {
+			return IDTokenConfig{}, fmt.Errorf("invalid role %q for ID token principal %s", role, email)
+		}
+		config.Principals[email] = role
+	}
+
+	if config.Enabled() && len(config.Principals) == 0 {
+		return IDTokenConfig{}, errors.New("ID_TOKEN_AUDIENCES is set but ID_TOKEN_PRINCIPALS is not: no caller would be allowed")
+	}
+	return config, nil
+}
+
+// Errors of ID token verification
+var (
+	ErrInvalidIDToken       = errors.New("invalid ID token")
+	ErrIDTokenNotAuthorized = errors.New("service account has no role")
+)
+
+// IDTokenVerifier verifies Google-signed ID tokens and maps their service
+// account to a principal
+type IDTokenVerifier struct {
+	config    IDTokenConfig
+	validator *idtoken.Validator
+}
+
+// NewIDTokenVerifier creates a verifier fetching Google's signing keys with
+// the given client options. It returns nil when ID tokens are not enabled.
+func NewIDTokenVerifier(ctx context.Context, config IDTokenConfig, opts ...option.ClientOption) (*IDTokenVerifier, error) {
+	if !config.Enabled() {
+		return nil, nil
+	}
+	validator, err := idtoken.NewValidator(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ID token validator: %v", err)
+	}
+	return &IDTokenVerifier{config: config, validator: validator}, nil
+}
+
+// Verify checks the token's signature, expiry, issuer and audience, and
+// returns the principal of its verified service account email
+func (v *IDTokenVerifier) Verify(ctx context.Context, token string) (Principal, error) {
+	audience, err := tokenAudience(token)
+	if err != nil {
+		return Principal{}, err
+	}
+	accepted := false
+	for _, allowed := range v.config.Audiences {
+		if audience == allowed || audience == allowed+"/" {
+			accepted = true
+		}
+	}
+	if !accepted {
+		return Principal{}, fmt.Errorf("%w: audience %q is not this service", ErrInvalidIDToken, audience)
+	}
+
+	payload, err := v.validator.Validate(ctx, token, audience)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	if payload.Issuer != "https://accounts.google.com" && payload.Issuer != "accounts.google.com" {
+		return Principal{}, fmt.Errorf("%w: issuer %q is not Google", ErrInvalidIDToken, payload.Issuer)
+	}
+	email, _ := payload.Claims["email"].(string)
+	if verified, _ := payload.Claims["email_verified"].(bool); email == "" || !verified {
+		return Principal{}, fmt.Errorf("%w: no verified email; request the token with the service account's email", ErrInvalidIDToken)
+	}
+	email = strings.ToLower(email)
+	role, ok := v.config.Principals[email]
+	if !ok {
+		return Principal{}, fmt.Errorf("%w: %s", ErrIDTokenNotAuthorized, email)
+	}
+	return Principal{Name: email, Role: role}, nil
+}
+
+// tokenAudience reads the aud claim of an unverified token, to pick the
+// audience it is validated for
+func tokenAudience(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("%w: not a JWT", ErrInvalidIDToken)
+	}
+	data, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	var claims struct {
+		Audience string `json:"aud"`
+	}
+	if err := json.Unmarshal(data, &claims); err != nil || claims.Audience == "" {
+		return "", fmt.Errorf("%w: no audience", ErrInvalidIDToken)
+	}
+	return claims.Audience, nil
+}
+
+// isJWT reports whether a bearer credential looks like a JWT rather than an API key
+func isJWT(credential string) bool {
+	return strings.HasPrefix(credential, "eyJ") && strings.Count(credential, ".") == 2
+}
+
+// requestIDToken returns the ID token of a request: a JWT bearer token of
+// Authorization or, for callers sending an API key there, of
+// X-Serverless-Authorization, which Cloud Run also accepts
+func requestIDToken(r *http.Request) string {
+	for _, header := range []string{"Authorization", "X-Serverless-Authorization"} {
+		if bearer, ok := strings.CutPrefix(r.Header.Get(header), "Bearer "); ok && isJWT(strings.TrimSpace(bearer)) {
+			return strings.TrimSpace(bearer)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/api/option"
+)
+
+const testAudience = "https://flight-ticket-service-abc123-ue.a.run.app"
+
+// certsTransport serves the JWK set of a test key in place of Google's certs
+type certsTransport struct {
+	key *rsa.PrivateKey
+}
+
+func (c certsTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	jwks, _ := json.Marshal(map[string]any{"keys": []map[string]string{{
+		"kid": "test",
+		"kty": "RSA",
+		"alg": "RS256",
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(c.key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(c.key.E)).Bytes()),
+	}}})
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}, "Cache-Control": {"max-age=3600"}},
+		Body:       io.NopCloser(strings.NewReader(string(jwks))),
+		Request:    r,
+	}, nil
+}
+
+func signToken(t *testing.T, key *rsa.PrivateKey, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "test", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	content := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	hash := sha256.Sum256([]byte(content))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return content + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestIDTokenConfigFromEnv(t *testing.T) {
+	t.Setenv("ID_TOKEN_AUDIENCES", testAudience+"/, https://tickets.example.com")
+	t.Setenv("ID_TOKEN_PRINCIPALS", "MCP-Server@demo.iam.gserviceaccount.com:agent")
+	config, err := IDTokenConfigFromEnv()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(config.Audiences) != 2 || config.Audiences[0] != testAudience {
+		t.Errorf("Unexpected audiences %v", config.Audiences)
+	}
+	if config.Principals["mcp-server@demo.iam.gserviceaccount.com"] != RoleAgent {
+		t.Errorf("Unexpected principals %v", config.Principals)
+	}
+
+	invalid := []struct{ audiences, principals string }{
+		{"http://tickets.example.com", "a@b.com:agent"},
+		{testAudience, ""},
+		{testAudience, "a@b.com:root"},
+		{testAudience, "agent"},
+	}
+	for _, tt := range invalid {
+		t.Setenv("ID_TOKEN_AUDIENCES", tt.audiences)
+		t.Setenv("ID_TOKEN_PRINCIPALS", tt.principals)
+		if _, err := IDTokenConfigFromEnv(); err == nil {
+			t.Errorf("Expected error for %q, %q", tt.audiences, tt.principals)
+		}
+	}
+
+	t.Setenv("ID_TOKEN_AUDIENCES", "")
+	t.Setenv("ID_TOKEN_PRINCIPALS", "")
+	if config, err := IDTokenConfigFromEnv(); err != nil || config.Enabled() {
+		t.Errorf("Expected ID tokens to be disabled by default, got %+v, %v", config, err)
+	}
+}
+
+func TestAuthenticateIDToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := IDTokenConfig{
+		Audiences:  []string{testAudience},
+		Principals: map[string]Role{"mcp-server@demo.iam.gserviceaccount.com": RoleAgent},
+	}
+	verifier, err := NewIDTokenVerifier(context.Background(), config, option.WithHTTPClient(&http.Client{Transport: certsTransport{key}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks, _ := ParseKeys("ops:admin:admin-key")
+	ks.SetIDTokenVerifier(verifier)
+
+	var got Principal
+	handler := ks.Authenticate(RequireRole(RoleAgent)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = FromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	claims := func(changes map[string]any) map[string]any {
+		c := map[string]any{
+			"iss":            "https://accounts.google.com",
+			"aud":            testAudience,
+			"email":          "mcp-server@demo.iam.gserviceaccount.com",
+			"email_verified": true,
+			"iat":            time.Now().Unix(),
+			"exp":            time.Now().Add(time.Hour).Unix(),
+		}
+		for k, v := range changes {
+			c[k] = v
+		}
+		return c
+	}
+	valid := signToken(t, key, claims(nil))
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		status  int
+		caller  string
+	}{
+		{"bearer", map[string]string{"Authorization": "Bearer " + valid}, http.StatusOK, "mcp-server@demo.iam.gserviceaccount.com"},
+		{"serverless header", map[string]string{"X-Serverless-Authorization": "Bearer " + valid}, http.StatusOK, "mcp-server@demo.iam.gserviceaccount.com"},
+		{"API key wins", map[string]string{"X-API-Key": "admin-key", "X-Serverless-Authorization": "Bearer " + valid}, http.StatusOK, "ops"},
+		{"audience with slash", map[string]string{"Authorization": "Bearer " + signToken(t, key, claims(map[string]any{"aud": testAudience + "/"}))}, http.StatusOK, "mcp-server@demo.iam.gserviceaccount.com"},
+		{"other audience", map[string]string{"Authorization": "Bearer " + signToken(t, key, claims(map[string]any{"aud": "https://other.a.run.app"}))}, http.StatusUnauthorized, ""},
+		{"expired", map[string]string{"Authorization": "Bearer " + signToken(t, key, claims(map[string]any{"exp": time.Now().Add(-time.Minute).Unix()}))}, http.StatusUnauthorized, ""},
+		{"other issuer", map[string]string{"Authorization": "Bearer " + signToken(t, key, claims(map[string]any{"iss": "https://example.com"}))}, http.StatusUnauthorized, ""},
+		{"unverified email", map[string]string{"Authorization": "Bearer " + signToken(t, key, claims(map[string]any{"email_verified": false}))}, http.StatusUnauthorized, ""},
+		{"forged signature", map[string]string{"Authorization": "Bearer " + signToken(t, otherKey, claims(nil))}, http.StatusUnauthorized, ""},
+		{"service account without role", map[string]string{"Authorization": "Bearer " + signToken(t, key, claims(map[string]any{"email": "scheduler@demo.iam.gserviceaccount.com"}))}, http.StatusUnauthorized, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = Principal{}
+			req := httptest.NewRequest(http.MethodGet, "/tickets", nil)
+			for header, value := range tt.headers {
+				req.Header.Set(header, value)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+			if got.Name != tt.caller {
+				t.Errorf("Expected principal %q, got %q", tt.caller, got.Name)
+			}
+		})
+	}
+
+	// without a verifier, a token is an unknown API key
+	ks.SetIDTokenVerifier(nil)
+	req := httptest.NewRequest(http.MethodGet, "/tickets", nil)
+	req.Header.Set("Authorization", "Bearer "+valid)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without a verifier, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,176 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const (
+	// RolePublic in a policy rule lets anyone call, with or without credentials
+	RolePublic Role = "public"
+	// RoleAuthenticated in a policy rule lets any authenticated caller call
+	RoleAuthenticated Role = "authenticated"
+)
+
+// PolicyRule requires a role for the requests matching a method and path
+// pattern. Patterns are route patterns: {name} matches one path segment and
+// a trailing /* any number of them, including none. Method * matches every
+// method.
+type PolicyRule struct {
+	Method  string `json:"method"`
+	Pattern string `json:"pattern"`
+	Role    Role   `json:"role"`
+}
+
+// matches reports whether the rule applies to a request
+func (rule PolicyRule) matches(method string, segments []string) bool {
+	if rule.Method != "*" && rule.Method != method && !(rule.Method == http.MethodGet && method == http.MethodHead) {
+		return false
+	}
+	pattern := splitPath(rule.Pattern)
+	for i, part := range pattern {
+		if part == "*" && i == len(pattern)-1 {
+			return true
+		}
+		if i >= len(segments) {
+			return false
+		}
+		if !strings.HasPrefix(part, "{") && part != segments[i] {
+			return false
+		}
+	}
+	return len(pattern) == len(segments)
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+// defaultRules are the access rules of the API's endpoints, most specific
+// first. Endpoints without a rule are public. A new endpoint that needs a
+// role gets a rule here rather than a check in its handler.
+var defaultRules = []PolicyRule{
+	{"GET", "/health/details", RoleAdmin},
+
+	{"*", "/ticket/{confirmationID}/notes", RoleAgent},
+	{"POST", "/tickets/bulk-cancel", RoleAdmin},
+	{"GET", "/flights/{flightNumber}/{date}/manifest", RoleAgent},
+	{"*", "/travelers/*", RoleAgent},
+
+	{"POST", "/jobs", RoleAdmin},
+	{"*", "/jobs/*", RoleAgent},
+
+	{"POST", "/views", RoleAgent},
+	{"PUT", "/views/{name}", RoleAgent},
+	{"DELETE", "/views/{name}", RoleAgent},
+
+	// the admin UI signs in with a session cookie of its own
+	{"*", "/admin/ui/*", RolePublic},
+	{"*", "/admin/*", RoleAdmin},
+}
+
+// Policy maps endpoints to the role they require. The first matching rule
+// applies.
+type Policy struct {
+	rules []PolicyRule
+}
+
+// DefaultPolicy returns the policy of the default rules
+func DefaultPolicy() *Policy {
+	return &Policy{rules: append([]PolicyRule{}, defaultRules...)}
+}
+
+// NewPolicy returns a policy of the rules of spec ahead of the default ones
+func NewPolicy(spec string) (*Policy, error) {
+	rules, err := ParsePolicy(spec)
+	if err != nil {
+		return nil, err
+	}
+	return &Policy{rules: append(rules, defaultRules...)}, nil
+}
+
+// PolicyFromEnv creates a policy of the default rules with AUTH_POLICY applied on top
+func PolicyFromEnv() (*Policy, error) {
+	policy, err := NewPolicy(os.Getenv("AUTH_POLICY"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid AUTH_POLICY: %v", err)
+	}
+	return policy, nil
+}
+
+// ParsePolicy parses comma-separated "METHOD /pattern=role" entries, e.g.
+// "GET /rules=agent, * /admin/stats/*=agent"
+func ParsePolicy(spec string) ([]PolicyRule, error) {
+	var rules []PolicyRule
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		route, role, ok := strings.Cut(entry, "=")
+		fields := strings.Fields(route)
+		if !ok || len(fields) != 2 || !strings.HasPrefix(fields[1], "/") {
+			return nil, fmt.Errorf("entry %q must be METHOD /pattern=role", entry)
+		}
+		rule := PolicyRule{Method: strings.ToUpper(fields[0]), Pattern: fields[1], Role: Role(strings.TrimSpace(role))}
+		if !validRoles[rule.Role] && rule.Role != RolePublic && rule.Role != RoleAuthenticated {
+			return nil, fmt.Errorf("entry %q has role %q: must be public, authenticated, agent or admin", entry, rule.Role)
+		}
+		for i, part := range splitPath(rule.Pattern) {
+			if strings.Contains(part, "*") && (part != "*" || i != len(splitPath(rule.Pattern))-1) {
+				return nil, fmt.Errorf("entry %q may only end with /*", entry)
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// Rules returns the rules in the order they are checked
+func (p *Policy) Rules() []PolicyRule {
+	return append([]PolicyRule{}, p.rules...)
+}
+
+// Required returns the role a request needs: that of the first matching
+// rule, or RolePublic
+func (p *Policy) Required(method, path string) Role {
+	segments := splitPath(path)
+	for _, rule := range p.rules {
+		if rule.matches(method, segments) {
+			return rule.Role
+		}
+	}
+	return RolePublic
+}
+
+// Authorize rejects requests whose principal lacks the role the policy
+// requires for their endpoint, like RequireRole. It runs after Authenticate.
+func (p *Policy) Authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// escaped like the router matches it, so that an encoded slash is part of a segment
+		required := p.Required(r.Method, r.URL.EscapedPath())
+		if required == RolePublic {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		principal, ok := FromContext(r.Context())
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="flight-ticket-service"`)
+			writeError(w, http.StatusUnauthorized, "Authentication required")
+			return
+		}
+		if required != RoleAuthenticated && !principal.HasRole(required) {
+			writeError(w, http.StatusForbidden, "Insufficient permissions")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParsePolicy(t *testing.T) {
+	rules, err := ParsePolicy("get /rules=agent, * /admin/stats/*=authenticated")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(rules) != 2 || rules[0] != (PolicyRule{Method: "GET", Pattern: "/rules", Role: RoleAgent}) || rules[1].Role != RoleAuthenticated {
+		t.Errorf("Unexpected rules %+v", rules)
+	}
+
+	for _, spec := range []string{"/rules=agent", "GET rules=agent", "GET /rules", "GET /rules=root", "GET /admin/*/stats=admin", "GET /tickets*=agent"} {
+		if _, err := ParsePolicy(spec); err == nil {
+			t.Errorf("Expected error for %q", spec)
+		}
+	}
+}
+
+func TestPolicyRequired(t *testing.T) {
+	policy, err := NewPolicy("GET /admin/stats=agent, PUT /views/{name}=admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method, path string
+		role         Role
+	}{
+		{"GET", "/tickets", RolePublic},
+		{"GET", "/health/details", RoleAdmin},
+		{"HEAD", "/health/details", RoleAdmin},
+		{"POST", "/ticket/ABC123/notes", RoleAgent},
+		{"GET", "/ticket/ABC123", RolePublic},
+		{"POST", "/jobs", RoleAdmin},
+		{"POST", "/jobs/", RoleAdmin},
+		{"GET", "/jobs/42/result", RoleAgent},
+		{"GET", "/views/daily", RolePublic},
+		{"PUT", "/views/daily", RoleAdmin}, // overridden
+		{"GET", "/admin/stats", RoleAgent}, // overridden
+		{"GET", "/admin/stats/bookings", RoleAdmin},
+		{"GET", "/admin", RoleAdmin},
+		{"POST", "/admin/ui/login", RolePublic},
+		{"GET", "/travelers", RoleAgent},
+	}
+	for _, tt := range tests {
+		if got := policy.Required(tt.method, tt.path); got != tt.role {
+			t.Errorf("%s %s: expected %s, got %s", tt.method, tt.path, tt.role, got)
+		}
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	ks, _ := ParseKeys("ops:admin:admin-key,desk:agent:agent-key")
+	policy, _ := NewPolicy("GET /quota=authenticated")
+	handler := ks.Authenticate(policy.Authorize(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		key    string
+		status int
+	}{
+		{"public", http.MethodGet, "/tickets", "", http.StatusOK},
+		{"anonymous agent endpoint", http.MethodGet, "/ticket/ABC123/notes", "", http.StatusUnauthorized},
+		{"agent", http.MethodGet, "/ticket/ABC123/notes", "agent-key", http.StatusOK},
+		{"encoded slash", http.MethodGet, "/ticket/ABC%2F123/notes", "", http.StatusUnauthorized},
+		{"agent on admin endpoint", http.MethodPost, "/tickets/bulk-cancel", "agent-key", http.StatusForbidden},
+		{"admin", http.MethodPost, "/tickets/bulk-cancel", "admin-key", http.StatusOK},
+		{"authenticated", http.MethodGet, "/quota", "agent-key", http.StatusOK},
+		{"anonymous authenticated endpoint", http.MethodGet, "/quota", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.key != "" {
+				req.Header.Set("X-API-Key", tt.key)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rec.Code)
+			}
+			if rec.Code == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("Expected a WWW-Authenticate challenge")
+			}
+		})
+	}
+}
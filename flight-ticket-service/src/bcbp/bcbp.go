@@ -0,0 +1,195 @@
+// Package bcbp encodes and decodes IATA Bar Coded Boarding Pass (Resolution 792) payloads.
+//
+// Only the mandatory items of a single-leg "M1" boarding pass are produced; the
+// conditional and security sections are left empty, which scanners accept.
+package bcbp
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MandatoryLength is the length of the mandatory items of a single-leg boarding pass
+const MandatoryLength = 60
+
+// Passenger status codes
+const (
+	StatusNotCheckedIn = '0'
+	StatusCheckedIn    = '1'
+)
+
+var (
+	flightNumberPattern = regexp.MustCompile(`^([A-Z0-9]{2}[A-Z]?)(\d{1,4})([A-Z]?)$`)
+	seatPattern         = regexp.MustCompile(`^(\d{1,3})([A-Z])$`)
+	nameCharacters      = regexp.MustCompile(`[^A-Z ]+`)
+)
+
+// BoardingPass holds the data encoded in a single-leg boarding pass
+type BoardingPass struct {
+	LastName       string
+	FirstName      string
+	PNR            string    // Operating carrier booking reference (confirmation ID)
+	From           string    // IATA origin airport code
+	To             string    // IATA destination airport code
+	FlightNumber   string    // Full flight number including carrier, e.g. AA1234
+	DepartureDate  time.Time // Only the day of the year is encoded
+	Compartment    string    // Cabin compartment code, e.g. Y, W, J, F
+	Seat           string    // e.g. 12A; empty when no seat is assigned
+	SequenceNumber int       // Check-in sequence number
+	Status         byte      // Passenger status (StatusCheckedIn by default)
+}
+
+// SplitFlightNumber splits a flight number such as AA1234 or BA12A into carrier, number and suffix
+func SplitFlightNumber(flightNumber string) (carrier, number, suffix string, err error) {
+	match := flightNumberPattern.FindStringSubmatch(strings.ToUpper(strings.ReplaceAll(flightNumber, " ", "")))
+	if match == nil {
+		return "", "", "", fmt.Errorf("invalid flight number %q", flightNumber)
+	}
+	return match[1], match[2], match[3], nil
+}
+
+// FormatName formats a passenger name as SURNAME/GIVEN, truncated to the 20-character field
+func FormatName(lastName, firstName string) string {
+	clean := func(s string) string {
+		return strings.TrimSpace(nameCharacters.ReplaceAllString(strings.ToUpper(s), ""))
+	}
+
+	name := clean(lastName)
+	if first := clean(firstName); first != "" {
+		name += "/" + first
+	}
+	if len(name) > 20 {
+		name = name[:20]
+	}
+	return name
+}
+
+// Encode returns the BCBP string for the boarding pass
+func (bp *BoardingPass) Encode() (string, error) {
+	name := FormatName(bp.LastName, bp.FirstName)
+	if name == "" {
+		return "", fmt.Errorf("passenger last name is required")
+	}
+
+	pnr := strings.ToUpper(bp.PNR)
+	if pnr == "" || len(pnr) > 7 {
+		return "", fmt.Errorf("invalid booking reference %q", bp.PNR)
+	}
+
+	from, to := strings.ToUpper(bp.From), strings.ToUpper(bp.To)
+	if len(from) != 3 || len(to) != 3 {
+		return "", fmt.Errorf("invalid route %s-%s", bp.From, bp.To)
+	}
+
+	carrier, number, suffix, err := SplitFlightNumber(bp.FlightNumber)
+	if err != nil {
+		return "", err
+	}
+
+	if bp.DepartureDate.IsZero() {
+		return "", fmt.Errorf("departure date is required")
+	}
+
+	compartment := strings.ToUpper(bp.Compartment)
+	if compartment == "" {
+		compartment = "Y"
+	}
+	if len(compartment) != 1 {
+		return "", fmt.Errorf("invalid compartment code %q", bp.Compartment)
+	}
+
+	seat := "    "
+	if bp.Seat != "" {
+		match := seatPattern.FindStringSubmatch(strings.ToUpper(bp.Seat))
+		if match == nil {
+			return "", fmt.Errorf("invalid seat %q", bp.Seat)
+		}
+		row, _ := strconv.Atoi(match[1])
+		seat = fmt.Sprintf("%03d%s", row, match[2])
+	}
+
+	if bp.SequenceNumber < 0 || bp.SequenceNumber > 9999 {
+		return "", fmt.Errorf("invalid check-in sequence number %d", bp.SequenceNumber)
+	}
+
+	status := bp.Status
+	if status == 0 {
+		status = StatusCheckedIn
+	}
+
+	flightNumber, _ := strconv.Atoi(number)
+
+	var b strings.Builder
+	b.WriteString("M1")                                 // Format code and number of legs
+	fmt.Fprintf(&b, "%-20s", name)                      // Passenger name
+	b.WriteString("E")                                  // Electronic ticket indicator
+	fmt.Fprintf(&b, "%-7s", pnr)                        // Operating carrier PNR code
+	b.WriteString(from)                                 // From city airport code
+	b.WriteString(to)                                   // To city airport code
+	fmt.Fprintf(&b, "%-3s", carrier)                    // Operating carrier designator
+	fmt.Fprintf(&b, "%04d%-1s", flightNumber, suffix)   // Flight number
+	fmt.Fprintf(&b, "%03d", bp.DepartureDate.YearDay()) // Date of flight (Julian)
+	b.WriteString(compartment)                          // Compartment code
+	b.WriteString(seat)                                 // Seat number
+	fmt.Fprintf(&b, "%04d ", bp.SequenceNumber)         // Check-in sequence number
+	b.WriteByte(status)                                 // Passenger status
+	b.WriteString("00")                                 // Size of conditional section (hex)
+
+	return b.String(), nil
+}
+
+// Decode parses the mandatory items of a single-leg BCBP string. The departure date is
+// returned as a day of the year in the given reference year.
+func Decode(payload string, year int) (*BoardingPass, error) {
+	if len(payload) < MandatoryLength {
+		return nil, fmt.Errorf("BCBP payload too short: %d characters", len(payload))
+	}
+	if payload[0] != 'M' || payload[1] != '1' {
+		return nil, fmt.Errorf("unsupported BCBP format %q", payload[:2])
+	}
+
+	field := func(start, length int) string {
+		return strings.TrimSpace(payload[start : start+length])
+	}
+
+	bp := &BoardingPass{
+		PNR:         field(23, 7),
+		From:        field(30, 3),
+		To:          field(33, 3),
+		Compartment: field(47, 1),
+		Seat:        field(48, 4),
+		Status:      payload[57],
+	}
+
+	lastName, firstName, _ := strings.Cut(field(2, 20), "/")
+	bp.LastName, bp.FirstName = lastName, firstName
+
+	number, err := strconv.Atoi(payload[39:43])
+	if err != nil {
+		return nil, fmt.Errorf("invalid flight number: %v", err)
+	}
+	bp.FlightNumber = fmt.Sprintf("%s%d%s", field(36, 3), number, field(43, 1))
+
+	day, err := strconv.Atoi(payload[44:47])
+	if err != nil || day < 1 || day > 366 {
+		return nil, fmt.Errorf("invalid date of flight %q", payload[44:47])
+	}
+	bp.DepartureDate = time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, day-1)
+
+	if bp.Seat != "" {
+		row, err := strconv.Atoi(bp.Seat[:3])
+		if err != nil {
+			return nil, fmt.Errorf("invalid seat %q", bp.Seat)
+		}
+		bp.Seat = fmt.Sprintf("%d%s", row, bp.Seat[3:])
+	}
+
+	if bp.SequenceNumber, err = strconv.Atoi(payload[52:56]); err != nil {
+		return nil, fmt.Errorf("invalid check-in sequence number: %v", err)
+	}
+
+	return bp, nil
+}
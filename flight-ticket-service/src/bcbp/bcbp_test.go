@@ -0,0 +1,108 @@
+package bcbp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEncode(t *testing.T) {
+	bp := &BoardingPass{
+		LastName:       "Doe",
+		FirstName:      "John",
+		PNR:            "ABC123",
+		From:           "JFK",
+		To:             "LAX",
+		FlightNumber:   "AA1234",
+		DepartureDate:  time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC),
+		Seat:           "12A",
+		SequenceNumber: 1,
+	}
+
+	payload, err := bp.Encode()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "M1DOE/JOHN            EABC123 JFKLAXAA 1234 360Y012A0001 100"
+	if payload != expected {
+		t.Errorf("Expected %q, got %q", expected, payload)
+	}
+	if len(payload) != MandatoryLength {
+		t.Errorf("Expected %d characters, got %d", MandatoryLength, len(payload))
+	}
+}
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	bp := &BoardingPass{
+		LastName:       "Van der Berg",
+		FirstName:      "Anna-Maria",
+		PNR:            "XYZ789",
+		From:           "AMS",
+		To:             "LHR",
+		FlightNumber:   "KL12A",
+		DepartureDate:  time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		Compartment:    "J",
+		Seat:           "2C",
+		SequenceNumber: 42,
+	}
+
+	payload, err := bp.Encode()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	decoded, err := Decode(payload, 2024)
+	if err != nil {
+		t.Fatalf("Unexpected error decoding %q: %v", payload, err)
+	}
+
+	// The name is truncated to the 20-character field
+	if decoded.LastName != "VAN DER BERG" || decoded.FirstName != "ANNAMAR" {
+		t.Errorf("Unexpected name %s/%s", decoded.LastName, decoded.FirstName)
+	}
+	if decoded.FlightNumber != "KL12A" || decoded.Seat != "2C" || decoded.SequenceNumber != 42 || decoded.Compartment != "J" {
+		t.Errorf("Unexpected decoded pass %+v", decoded)
+	}
+	if !decoded.DepartureDate.Equal(bp.DepartureDate) {
+		t.Errorf("Expected departure date %v, got %v", bp.DepartureDate, decoded.DepartureDate)
+	}
+	if decoded.Status != StatusCheckedIn {
+		t.Errorf("Expected checked-in status, got %c", decoded.Status)
+	}
+}
+
+func TestFormatNameTruncates(t *testing.T) {
+	name := FormatName("Wolfeschlegelsteinhausen", "Hubert")
+	if len(name) != 20 || name != "WOLFESCHLEGELSTEINHA" {
+		t.Errorf("Unexpected truncated name %q", name)
+	}
+}
+
+func TestEncodeValidation(t *testing.T) {
+	valid := BoardingPass{
+		LastName:      "Doe",
+		PNR:           "ABC123",
+		From:          "JFK",
+		To:            "LAX",
+		FlightNumber:  "AA1234",
+		DepartureDate: time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC),
+	}
+
+	tests := map[string]func(bp *BoardingPass){
+		"missing name": func(bp *BoardingPass) { bp.LastName = "" },
+		"long PNR":     func(bp *BoardingPass) { bp.PNR = "ABCDEFGH" },
+		"bad route":    func(bp *BoardingPass) { bp.From = "JFKX" },
+		"bad flight":   func(bp *BoardingPass) { bp.FlightNumber = "AA12345" },
+		"bad seat":     func(bp *BoardingPass) { bp.Seat = "A12" },
+		"missing date": func(bp *BoardingPass) { bp.DepartureDate = time.Time{} },
+		"bad sequence": func(bp *BoardingPass) { bp.SequenceNumber = 10000 },
+		"bad cabin":    func(bp *BoardingPass) { bp.Compartment = "YY" },
+	}
+	for name, mutate := range tests {
+		bp := valid
+		mutate(&bp)
+		if _, err := bp.Encode(); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
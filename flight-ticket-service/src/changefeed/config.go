@@ -0,0 +1,55 @@
+package changefeed
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"flight-ticket-service/src/services"
+)
+
+// SinksFromEnv builds the sinks configured with CHANGEFEED_TOPIC,
+// CHANGEFEED_WEBHOOK_URLS, CHANGEFEED_WEBHOOK_SECRET and NOTIFICATION_TOPIC.
+// The repository supplies notification preferences and templates, when not
+// nil, the templates of tenants; both are only used with NOTIFICATION_TOPIC.
+// No sinks are returned when nothing is configured.
+func SinksFromEnv(ctx context.Context, repository services.TicketRepository, templates services.NotificationTemplates) ([]Sink, error) {
+	var sinks []Sink
+	projectID := os.Getenv("GOOGLE_CLOUD_PROJECT")
+	credentialsPath := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+
+	if topic := os.Getenv("CHANGEFEED_TOPIC"); topic != "" {
+		if projectID == "" {
+			return nil, fmt.Errorf("GOOGLE_CLOUD_PROJECT is required for CHANGEFEED_TOPIC")
+		}
+		sink, err := NewPubSubSink(ctx, projectID, credentialsPath, topic)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize Pub/Sub sink: %v", err)
+		}
+		sinks = append(sinks, sink)
+	}
+
+	for _, url := range strings.Split(os.Getenv("CHANGEFEED_WEBHOOK_URLS"), ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			sinks = append(sinks, NewWebhookSink(url, os.Getenv("CHANGEFEED_WEBHOOK_SECRET")))
+		}
+	}
+
+	if topic := os.Getenv("NOTIFICATION_TOPIC"); topic != "" {
+		if projectID == "" {
+			NewFanout(sinks...).Close()
+			return nil, fmt.Errorf("GOOGLE_CLOUD_PROJECT is required for NOTIFICATION_TOPIC")
+		}
+		sender, err := services.NewPubSubNotificationSender(ctx, projectID, credentialsPath, topic)
+		if err != nil {
+			NewFanout(sinks...).Close()
+			return nil, fmt.Errorf("failed to initialize notification topic: %v", err)
+		}
+		sink := NewNotificationSink(services.NewNotificationService(repository, sender).WithTemplates(templates))
+		sink.sender = sender
+		sinks = append(sinks, sink)
+	}
+
+	return sinks, nil
+}
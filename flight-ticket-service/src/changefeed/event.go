@@ -0,0 +1,350 @@
+// Package changefeed turns Eventarc Firestore document events for flight
+// tickets and upgrade offers into change events and fans them out to Pub/Sub
+// and webhooks.
+//
+// Triggers must be created with --event-data-content-type=application/json;
+// the default protobuf encoding is not supported.
+package changefeed
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"flight-ticket-service/src/mapping"
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/upgrades"
+)
+
+// TicketCollection is the Firestore collection whose changes are captured. It
+// follows FIRESTORE_COLLECTION, which the commands apply at startup.
+var TicketCollection = "flight_tickets"
+
+// Change types
+const (
+	ChangeCreated = "created"
+	ChangeUpdated = "updated"
+	ChangeDeleted = "deleted"
+)
+
+// Change types of upgrade offers, after the offer's new status
+const (
+	ChangeUpgradeOffered   = "upgrade_offered"
+	ChangeUpgradeBidPlaced = "upgrade_bid_placed"
+	ChangeUpgradeAccepted  = "upgrade_accepted"
+	ChangeUpgradeDeclined  = "upgrade_declined"
+	ChangeUpgradeRejected  = "upgrade_rejected"
+)
+
+// upgradeChanges maps offer statuses to change types; expiry is not a change
+var upgradeChanges = map[string]string{
+	models.UpgradeOffered:   ChangeUpgradeOffered,
+	models.UpgradeBidPlaced: ChangeUpgradeBidPlaced,
+	models.UpgradeAccepted:  ChangeUpgradeAccepted,
+	models.UpgradeDeclined:  ChangeUpgradeDeclined,
+	models.UpgradeRejected:  ChangeUpgradeRejected,
+}
+
+// Eventarc Firestore event type prefix, followed by created, updated, deleted or written
+const eventTypePrefix = "google.cloud.firestore.document.v1."
+
+// ChangeEvent is published for every change to a ticket document
+type ChangeEvent struct {
+	ID             string               `json:"id"`
+	Type           string               `json:"type"`
+	ConfirmationID string               `json:"confirmation_id"`
+	Document       string               `json:"document"`
+	Time           time.Time            `json:"time"`
+	ChangedFields  []string             `json:"changed_fields,omitempty"`
+	Ticket         *models.FlightTicket `json:"ticket,omitempty"`
+	Previous       *models.FlightTicket `json:"previous,omitempty"`
+	Upgrade        *models.UpgradeOffer `json:"upgrade,omitempty"`
+}
+
+// documentEventData is the JSON form of google.events.cloud.firestore.v1.DocumentEventData
+type documentEventData struct {
+	Value      *document `json:"value"`
+	OldValue   *document `json:"oldValue"`
+	UpdateMask *struct {
+		FieldPaths []string `json:"fieldPaths"`
+	} `json:"updateMask"`
+}
+
+type document struct {
+	Name       string           `json:"name"`
+	Fields     map[string]value `json:"fields"`
+	CreateTime time.Time        `json:"createTime"`
+	UpdateTime time.Time        `json:"updateTime"`
+}
+
+// value is the JSON form of a Firestore Value; exactly one field is set
+type value struct {
+	NullValue      *string          `json:"nullValue"`
+	BooleanValue   *bool            `json:"booleanValue"`
+	IntegerValue   *string          `json:"integerValue"`
+	DoubleValue    *float64         `json:"doubleValue"`
+	TimestampValue *time.Time       `json:"timestampValue"`
+	StringValue    *string          `json:"stringValue"`
+	BytesValue     *string          `json:"bytesValue"`
+	ReferenceValue *string          `json:"referenceValue"`
+	GeoPointValue  *json.RawMessage `json:"geoPointValue"`
+	ArrayValue     *struct {
+		Values []value `json:"values"`
+	} `json:"arrayValue"`
+	MapValue *struct {
+		Fields map[string]value `json:"fields"`
+	} `json:"mapValue"`
+}
+
+// ParseEvent decodes an Eventarc Firestore event. It returns nil without an
+// error for documents outside the tickets and upgrade offers collections
+// (e.g. subcollections).
+func ParseEvent(id, eventType, subject string, eventTime time.Time, data []byte) (*ChangeEvent, error) {
+	if !strings.HasPrefix(eventType, eventTypePrefix) {
+		return nil, fmt.Errorf("unsupported event type: %s", eventType)
+	}
+
+	confirmationID, ok := ticketID(subject)
+	_, upgrade := documentID(subject, upgrades.Collection)
+	if !ok && !upgrade {
+		return nil, nil
+	}
+
+	var payload documentEventData
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("failed to parse document event (is the trigger using application/json?): %v", err)
+	}
+	if upgrade {
+		return parseUpgradeEvent(id, subject, eventTime, &payload)
+	}
+
+	event := &ChangeEvent{
+		ID:             id,
+		ConfirmationID: confirmationID,
+		Document:       subject,
+		Time:           eventTime,
+	}
+
+	switch {
+	case payload.Value != nil && payload.OldValue == nil:
+		event.Type = ChangeCreated
+	case payload.Value == nil && payload.OldValue != nil:
+		event.Type = ChangeDeleted
+	case payload.Value != nil && payload.OldValue != nil:
+		event.Type = ChangeUpdated
+	default:
+		return nil, fmt.Errorf("document event %s has neither value nor oldValue", id)
+	}
+
+	var err error
+	if event.Ticket, err = decodeTicket(payload.Value); err != nil {
+		return nil, err
+	}
+	if event.Previous, err = decodeTicket(payload.OldValue); err != nil {
+		return nil, err
+	}
+	if payload.UpdateMask != nil {
+		event.ChangedFields = payload.UpdateMask.FieldPaths
+	}
+
+	return event, nil
+}
+
+// NewUpdateEvent builds an update event for a change made by the API itself,
+// for deployments where Firestore change events are not available
+func NewUpdateEvent(previous, current *models.FlightTicket, changedFields []string) (*ChangeEvent, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate event ID: %v", err)
+	}
+
+	for _, ticket := range []*models.FlightTicket{previous, current} {
+		if ticket != nil {
+			ticket.SpecialRequests = models.TicketSpecialRequests(ticket)
+		}
+	}
+
+	return &ChangeEvent{
+		ID:             "api-" + hex.EncodeToString(id),
+		Type:           ChangeUpdated,
+		ConfirmationID: current.ConfirmationID,
+		Document:       "documents/" + TicketCollection + "/" + current.ConfirmationID,
+		Time:           time.Now().UTC(),
+		ChangedFields:  changedFields,
+		Ticket:         current,
+		Previous:       previous,
+	}, nil
+}
+
+// NewUpgradeEvent builds the event of an upgrade offer's new status, for
+// deployments where Firestore change events are not available. It returns nil
+// for statuses that are not changes.
+func NewUpgradeEvent(offer *models.UpgradeOffer, ticket *models.FlightTicket) (*ChangeEvent, error) {
+	changeType, ok := upgradeChanges[offer.Status]
+	if !ok {
+		return nil, nil
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate event ID: %v", err)
+	}
+
+	return &ChangeEvent{
+		ID:             "api-" + hex.EncodeToString(id),
+		Type:           changeType,
+		ConfirmationID: offer.ConfirmationID,
+		Document:       "documents/" + upgrades.Collection + "/" + offer.ID,
+		Time:           time.Now().UTC(),
+		Ticket:         ticket,
+		Upgrade:        offer,
+	}, nil
+}
+
+// ticketID extracts the confirmation ID from a subject such as documents/flight_tickets/ABC123
+func ticketID(subject string) (string, bool) {
+	return documentID(subject, TicketCollection)
+}
+
+// documentID extracts the document ID from a subject of the collection
+func documentID(subject, collection string) (string, bool) {
+	parts := strings.Split(strings.TrimPrefix(subject, "documents/"), "/")
+	if len(parts) != 2 || parts[0] != collection || parts[1] == "" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// parseUpgradeEvent turns the change of an upgrade offer document into an
+// event named after the offer's new status. Deleted offers and changes that
+// keep the status produce no event.
+func parseUpgradeEvent(id, subject string, eventTime time.Time, payload *documentEventData) (*ChangeEvent, error) {
+	offer, err := decodeUpgrade(payload.Value)
+	if err != nil || offer == nil {
+		return nil, err
+	}
+	previous, err := decodeUpgrade(payload.OldValue)
+	if err != nil {
+		return nil, err
+	}
+	changeType, ok := upgradeChanges[offer.Status]
+	if !ok || (previous != nil && previous.Status == offer.Status) {
+		return nil, nil
+	}
+
+	event := &ChangeEvent{
+		ID:             id,
+		Type:           changeType,
+		ConfirmationID: offer.ConfirmationID,
+		Document:       subject,
+		Time:           eventTime,
+		Upgrade:        offer,
+	}
+	if payload.UpdateMask != nil {
+		event.ChangedFields = payload.UpdateMask.FieldPaths
+	}
+	return event, nil
+}
+
+// decodeUpgrade converts a Firestore document into an upgrade offer; the
+// offer's Firestore fields are named like its JSON fields
+func decodeUpgrade(doc *document) (*models.UpgradeOffer, error) {
+	if doc == nil {
+		return nil, nil
+	}
+
+	fields := make(map[string]interface{}, len(doc.Fields))
+	for name, v := range doc.Fields {
+		decoded, err := v.decode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode field %s: %v", name, err)
+		}
+		fields[name] = decoded
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse upgrade offer data: %v", err)
+	}
+	var offer models.UpgradeOffer
+	if err := json.Unmarshal(data, &offer); err != nil {
+		return nil, fmt.Errorf("failed to parse upgrade offer data: %v", err)
+	}
+	return &offer, nil
+}
+
+// decodeTicket converts a Firestore document into a ticket, upgrading
+// documents stored with an older schema, and lists its special requests for
+// ground handling
+func decodeTicket(doc *document) (*models.FlightTicket, error) {
+	if doc == nil {
+		return nil, nil
+	}
+
+	fields := make(map[string]interface{}, len(doc.Fields))
+	for name, v := range doc.Fields {
+		decoded, err := v.decode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode field %s: %v", name, err)
+		}
+		fields[name] = decoded
+	}
+
+	var stored mapping.TicketDocument
+	if err := stored.DecodeFields(fields); err != nil {
+		return nil, fmt.Errorf("failed to parse ticket data: %v", err)
+	}
+	ticket := mapping.TicketFromDocument(&stored)
+	ticket.SpecialRequests = models.TicketSpecialRequests(ticket)
+	return ticket, nil
+}
+
+// decode converts a Firestore Value into plain Go values
+func (v value) decode() (interface{}, error) {
+	switch {
+	case v.NullValue != nil:
+		return nil, nil
+	case v.BooleanValue != nil:
+		return *v.BooleanValue, nil
+	case v.IntegerValue != nil:
+		return strconv.ParseInt(*v.IntegerValue, 10, 64)
+	case v.DoubleValue != nil:
+		return *v.DoubleValue, nil
+	case v.TimestampValue != nil:
+		return *v.TimestampValue, nil
+	case v.StringValue != nil:
+		return *v.StringValue, nil
+	case v.BytesValue != nil:
+		return base64.StdEncoding.DecodeString(*v.BytesValue)
+	case v.ReferenceValue != nil:
+		return *v.ReferenceValue, nil
+	case v.GeoPointValue != nil:
+		return *v.GeoPointValue, nil
+	case v.ArrayValue != nil:
+		values := make([]interface{}, len(v.ArrayValue.Values))
+		for i, element := range v.ArrayValue.Values {
+			decoded, err := element.decode()
+			if err != nil {
+				return nil, err
+			}
+			values[i] = decoded
+		}
+		return values, nil
+	case v.MapValue != nil:
+		fields := make(map[string]interface{}, len(v.MapValue.Fields))
+		for name, element := range v.MapValue.Fields {
+			decoded, err := element.decode()
+			if err != nil {
+				return nil, err
+			}
+			fields[name] = decoded
+		}
+		return fields, nil
+	default:
+		return nil, fmt.Errorf("unsupported Firestore value")
+	}
+}
@@ -0,0 +1,147 @@
+package changefeed
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const updatedEvent = `{
+  "value": {
+    "name": "projects/p/databases/(default)/documents/flight_tickets/ABC123",
+    "fields": {
+      "confirmation_id": {"stringValue": "ABC123"},
+      "passengers": {"integerValue": "3"},
+      "status": {"stringValue": "CANCELLED"},
+      "labels": {"mapValue": {"fields": {"ssr_2": {"stringValue": "vgml-wchr"}}}},
+      "departure_date": {"timestampValue": "2024-12-25T00:00:00Z"},
+      "price": {"mapValue": {"fields": {"amount": {"doubleValue": 597}, "currency": {"stringValue": "USD"}}}}
+    }
+  },
+  "oldValue": {
+    "name": "projects/p/databases/(default)/documents/flight_tickets/ABC123",
+    "fields": {
+      "confirmation_id": {"stringValue": "ABC123"},
+      "passengers": {"integerValue": "3"},
+      "status": {"stringValue": "CONFIRMED"}
+    }
+  },
+  "updateMask": {"fieldPaths": ["status"]}
+}`
+
+func TestParseEventUpdated(t *testing.T) {
+	now := time.Date(2024, 7, 12, 19, 0, 0, 0, time.UTC)
+	event, err := ParseEvent("evt-1", "google.cloud.firestore.document.v1.written", "documents/flight_tickets/ABC123", now, []byte(updatedEvent))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if event.Type != ChangeUpdated || event.ConfirmationID != "ABC123" {
+		t.Errorf("Unexpected event %+v", event)
+	}
+	if event.Ticket.Status != "CANCELLED" || event.Previous.Status != "CONFIRMED" {
+		t.Errorf("Unexpected statuses %s -> %s", event.Previous.Status, event.Ticket.Status)
+	}
+	if event.Ticket.Passengers != 3 || event.Ticket.Price == nil || event.Ticket.Price.Amount != 597 {
+		t.Errorf("Unexpected ticket %+v", event.Ticket)
+	}
+	if requests := event.Ticket.SpecialRequests; len(requests) != 2 || requests[0].Passenger != 2 || requests[0].Code != "VGML" || requests[1].Code != "WCHR" {
+		t.Errorf("Unexpected special requests %+v", requests)
+	}
+	if !event.Ticket.DepartureDate.Equal(time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected departure date %v", event.Ticket.DepartureDate)
+	}
+	if len(event.ChangedFields) != 1 || event.ChangedFields[0] != "status" {
+		t.Errorf("Unexpected changed fields %v", event.ChangedFields)
+	}
+}
+
+func TestParseEventCreatedAndDeleted(t *testing.T) {
+	doc := `{"fields": {"confirmation_id": {"stringValue": "ABC123"}}}`
+
+	created, err := ParseEvent("evt-2", "google.cloud.firestore.document.v1.created", "documents/flight_tickets/ABC123", time.Now(), []byte(`{"value": `+doc+`}`))
+	if err != nil || created.Type != ChangeCreated || created.Previous != nil {
+		t.Errorf("Unexpected created event %+v, %v", created, err)
+	}
+
+	deleted, err := ParseEvent("evt-3", "google.cloud.firestore.document.v1.deleted", "documents/flight_tickets/ABC123", time.Now(), []byte(`{"oldValue": `+doc+`}`))
+	if err != nil || deleted.Type != ChangeDeleted || deleted.Ticket != nil {
+		t.Errorf("Unexpected deleted event %+v, %v", deleted, err)
+	}
+}
+
+func TestParseEventIgnoresOtherDocuments(t *testing.T) {
+	for _, subject := range []string{
+		"documents/flight_tickets/ABC123/attachments/a1",
+		"documents/config/feature_flags",
+	} {
+		event, err := ParseEvent("evt-4", "google.cloud.firestore.document.v1.written", subject, time.Now(), []byte(`{}`))
+		if err != nil || event != nil {
+			t.Errorf("Expected %s to be ignored, got %+v, %v", subject, event, err)
+		}
+	}
+
+	if _, err := ParseEvent("evt-5", "google.cloud.storage.object.v1.finalized", "documents/flight_tickets/ABC123", time.Now(), nil); err == nil {
+		t.Error("Expected error for non-Firestore event")
+	}
+}
+
+func TestParseEventUpgrade(t *testing.T) {
+	doc := func(status string) string {
+		return `{"fields": {
+			"id": {"stringValue": "5b7d3f9a1c0e4a6f"},
+			"confirmation_id": {"stringValue": "ABC123"},
+			"cabin": {"stringValue": "J"},
+			"type": {"stringValue": "BID"},
+			"passengers": {"integerValue": "2"},
+			"bid": {"doubleValue": 120.5},
+			"status": {"stringValue": "` + status + `"},
+			"expires_at": {"timestampValue": "2024-12-21T10:00:00Z"}
+		}}`
+	}
+	subject := "documents/upgrade_offers/5b7d3f9a1c0e4a6f"
+
+	event, err := ParseEvent("evt-6", "google.cloud.firestore.document.v1.written", subject, time.Now(),
+		[]byte(`{"value": `+doc("ACCEPTED")+`, "oldValue": `+doc("BID_PLACED")+`}`))
+	if err != nil || event == nil {
+		t.Fatalf("Unexpected result %+v, %v", event, err)
+	}
+	if event.Type != ChangeUpgradeAccepted || event.ConfirmationID != "ABC123" || event.Ticket != nil {
+		t.Errorf("Unexpected event %+v", event)
+	}
+	if offer := event.Upgrade; offer.Passengers != 2 || offer.Bid != 120.5 || offer.ExpiresAt.Hour() != 10 {
+		t.Errorf("Unexpected offer %+v", offer)
+	}
+
+	// Expiry, unchanged statuses and deleted offers are not changes
+	for _, data := range []string{
+		`{"value": ` + doc("EXPIRED") + `, "oldValue": ` + doc("OFFERED") + `}`,
+		`{"value": ` + doc("BID_PLACED") + `, "oldValue": ` + doc("BID_PLACED") + `}`,
+		`{"oldValue": ` + doc("OFFERED") + `}`,
+	} {
+		if event, err := ParseEvent("evt-7", "google.cloud.firestore.document.v1.written", subject, time.Now(), []byte(data)); err != nil || event != nil {
+			t.Errorf("Expected no event for %s, got %+v, %v", data, event, err)
+		}
+	}
+}
+
+func TestWebhookSinkSignsBody(t *testing.T) {
+	var signature, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		body, signature = string(raw), r.Header.Get("X-Signature-256")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	event := &ChangeEvent{ID: "evt-1", Type: ChangeUpdated, ConfirmationID: "ABC123"}
+	if err := NewFanout(NewWebhookSink(server.URL, "s3cret")).Publish(context.Background(), event); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if signature != "sha256="+Sign([]byte("s3cret"), []byte(body)) {
+		t.Errorf("Signature %q does not match body", signature)
+	}
+}
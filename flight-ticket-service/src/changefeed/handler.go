@@ -0,0 +1,62 @@
+package changefeed
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// maxEventSize bounds the request body; Firestore documents are at most 1 MiB
+const maxEventSize = 4 << 20
+
+// Handler receives Eventarc events delivered in CloudEvents binary mode
+type Handler struct {
+	fanout *Fanout
+}
+
+// NewHandler creates a handler that publishes to the fanout
+func NewHandler(fanout *Fanout) *Handler {
+	return &Handler{fanout: fanout}
+}
+
+// ServeHTTP handles one event. Non-2xx responses make Eventarc retry delivery.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxEventSize))
+	if err != nil {
+		log.Printf("Failed to read event body: %v", err)
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	id := r.Header.Get("Ce-Id")
+	eventTime, err := time.Parse(time.RFC3339Nano, r.Header.Get("Ce-Time"))
+	if err != nil {
+		eventTime = time.Now().UTC()
+	}
+
+	event, err := ParseEvent(id, r.Header.Get("Ce-Type"), r.Header.Get("Ce-Subject"), eventTime, data)
+	if err != nil {
+		// Malformed events will never parse; acknowledge them so they are not retried forever
+		log.Printf("Dropping event %s: %v", id, err)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if event == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if err := h.fanout.Publish(r.Context(), event); err != nil {
+		http.Error(w, "failed to deliver event", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Delivered %s event %s for ticket %s", event.Type, event.ID, event.ConfirmationID)
+	w.WriteHeader(http.StatusNoContent)
+}
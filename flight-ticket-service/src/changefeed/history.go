@@ -0,0 +1,58 @@
+package changefeed
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/services"
+)
+
+// HistorySink records each change as a revision in the ticket's history, from
+// which past versions of the ticket are reconstructed
+type HistorySink struct {
+	history services.TicketHistory
+}
+
+// NewHistorySink creates a sink writing to the given history
+func NewHistorySink(history services.TicketHistory) *HistorySink {
+	return &HistorySink{history: history}
+}
+
+// Name identifies the sink
+func (s *HistorySink) Name() string {
+	return "history"
+}
+
+// Publish records the revision. It is keyed by the event ID, so a retried event replaces it.
+// Upgrade offer events are not ticket revisions; the ticket update of an accepted offer is.
+func (s *HistorySink) Publish(ctx context.Context, event *ChangeEvent, body []byte) error {
+	if event.Upgrade != nil {
+		return nil
+	}
+	return s.history.RecordRevision(ctx, Revision(event))
+}
+
+// Close is a no-op; the history belongs to the repository
+func (s *HistorySink) Close() error {
+	return nil
+}
+
+// Revision converts a change event into a ticket revision
+func Revision(event *ChangeEvent) *models.TicketRevision {
+	// Document IDs cannot contain slashes
+	id := strings.ReplaceAll(event.ID, "/", "_")
+	if id == "" {
+		id = strconv.FormatInt(event.Time.UnixNano(), 10)
+	}
+	return &models.TicketRevision{
+		ID:             id,
+		ConfirmationID: event.ConfirmationID,
+		Type:           event.Type,
+		Time:           event.Time,
+		ChangedFields:  event.ChangedFields,
+		Ticket:         event.Ticket,
+		Previous:       event.Previous,
+	}
+}
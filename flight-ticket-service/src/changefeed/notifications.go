@@ -0,0 +1,109 @@
+package changefeed
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"flight-ticket-service/src/services"
+)
+
+// notifiedFields are the ticket fields whose changes travellers are told about
+var notifiedFields = map[string]bool{
+	"origin":         true,
+	"destination":    true,
+	"departure_date": true,
+	"departure_time": true,
+	"flight_number":  true,
+	"status":         true,
+}
+
+// upgradeNotifications are the upgrade changes travellers are told about, by
+// notification type; travellers answering an offer know what they did
+var upgradeNotifications = map[string]string{
+	ChangeUpgradeOffered:  services.NotificationUpgradeOffered,
+	ChangeUpgradeAccepted: services.NotificationUpgradeAccepted,
+	ChangeUpgradeRejected: services.NotificationUpgradeRejected,
+}
+
+// NotificationSink notifies travellers of itinerary and status changes and of
+// upgrade offers, following each ticket's notification preferences
+type NotificationSink struct {
+	notifications *services.NotificationService
+	sender        io.Closer // closed with the sink when the sink owns it
+}
+
+// NewNotificationSink creates a sink sending through the notification service
+func NewNotificationSink(notifications *services.NotificationService) *NotificationSink {
+	return &NotificationSink{notifications: notifications}
+}
+
+// Name identifies the sink
+func (s *NotificationSink) Name() string {
+	return "notifications"
+}
+
+// Publish notifies updates that touch an itinerary or status field, upgrade
+// offers, and awarded or rejected bids. Tickets that turned notifications off
+// are skipped.
+func (s *NotificationSink) Publish(ctx context.Context, event *ChangeEvent, body []byte) error {
+	if notificationType, ok := upgradeNotifications[event.Type]; ok && event.Upgrade != nil {
+		err := s.notifications.NotifyUpgrade(ctx, notificationType, event.Upgrade)
+		if errors.Is(err, services.ErrNotificationsDisabled) {
+			return nil
+		}
+		return err
+	}
+	if event.Type != ChangeUpdated || event.Ticket == nil {
+		return nil
+	}
+
+	changed := NotifiedChanges(event)
+	if len(changed) == 0 {
+		return nil
+	}
+
+	err := s.notifications.NotifyTicketChange(ctx, event.Ticket, changed)
+	if errors.Is(err, services.ErrNotificationsDisabled) {
+		return nil
+	}
+	return err
+}
+
+// Close closes the notification sender when the sink owns it
+func (s *NotificationSink) Close() error {
+	if s.sender == nil {
+		return nil
+	}
+	return s.sender.Close()
+}
+
+// NotifiedChanges returns the changed fields travellers are told about. Without
+// an update mask the previous and current tickets are compared.
+func NotifiedChanges(event *ChangeEvent) []string {
+	fields := event.ChangedFields
+	if len(fields) == 0 && event.Previous != nil && event.Ticket != nil {
+		previous, current := event.Previous, event.Ticket
+		differs := map[string]bool{
+			"origin":         previous.Origin != current.Origin,
+			"destination":    previous.Destination != current.Destination,
+			"departure_date": !previous.DepartureDate.Equal(current.DepartureDate),
+			"departure_time": !previous.DepartureTime.Equal(current.DepartureTime),
+			"flight_number":  previous.FlightNumber != current.FlightNumber,
+			"status":         previous.Status != current.Status,
+		}
+		for _, field := range []string{"origin", "destination", "departure_date", "departure_time", "flight_number", "status"} {
+			if differs[field] {
+				fields = append(fields, field)
+			}
+		}
+	}
+
+	var notified []string
+	for _, field := range fields {
+		if notifiedFields[field] {
+			notified = append(notified, field)
+		}
+	}
+	return notified
+}
@@ -0,0 +1,181 @@
+package changefeed
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"google.golang.org/api/option"
+)
+
+// Sink receives change events
+type Sink interface {
+	// Name identifies the sink in logs
+	Name() string
+	// Publish delivers an event; an error makes Eventarc retry the whole event
+	Publish(ctx context.Context, event *ChangeEvent, body []byte) error
+	// Close releases resources
+	Close() error
+}
+
+// PubSubSink publishes change events to a Pub/Sub topic, ordered per ticket
+type PubSubSink struct {
+	client *pubsub.Client
+	topic  *pubsub.Topic
+}
+
+// NewPubSubSink creates a sink for the given topic ID
+func NewPubSubSink(ctx context.Context, projectID, credentialsPath, topicID string) (*PubSubSink, error) {
+	var opts []option.ClientOption
+	if credentialsPath != "" {
+		opts = append(opts, option.WithCredentialsFile(credentialsPath))
+	}
+
+	client, err := pubsub.NewClient(ctx, projectID, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Pub/Sub client: %v", err)
+	}
+
+	topic := client.Topic(topicID)
+	topic.EnableMessageOrdering = true
+
+	return &PubSubSink{client: client, topic: topic}, nil
+}
+
+// Name returns the topic name
+func (s *PubSubSink) Name() string {
+	return "pubsub:" + s.topic.ID()
+}
+
+// Publish publishes the event with the confirmation ID as ordering key
+func (s *PubSubSink) Publish(ctx context.Context, event *ChangeEvent, body []byte) error {
+	result := s.topic.Publish(ctx, &pubsub.Message{
+		Data:        body,
+		OrderingKey: event.ConfirmationID,
+		Attributes: map[string]string{
+			"event_id":        event.ID,
+			"event_type":      event.Type,
+			"confirmation_id": event.ConfirmationID,
+		},
+	})
+	if _, err := result.Get(ctx); err != nil {
+		// Publishing for this key is paused after a failure until resumed
+		s.topic.ResumePublish(event.ConfirmationID)
+		return fmt.Errorf("failed to publish event %s: %v", event.ID, err)
+	}
+	return nil
+}
+
+// Close flushes pending messages and closes the client
+func (s *PubSubSink) Close() error {
+	s.topic.Stop()
+	return s.client.Close()
+}
+
+// WebhookSink POSTs change events as JSON to a URL
+type WebhookSink struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+// NewWebhookSink creates a webhook sink. When secret is set, requests carry an
+// X-Signature-256 header with the hex HMAC-SHA256 of the body.
+func NewWebhookSink(url, secret string) *WebhookSink {
+	return &WebhookSink{
+		url:    url,
+		secret: []byte(secret),
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name returns the webhook URL
+func (s *WebhookSink) Name() string {
+	return "webhook:" + s.url
+}
+
+// Publish POSTs the event and treats any non-2xx response as a failure
+func (s *WebhookSink) Publish(ctx context.Context, event *ChangeEvent, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", event.ID)
+	req.Header.Set("X-Event-Type", event.Type)
+	if len(s.secret) > 0 {
+		req.Header.Set("X-Signature-256", "sha256="+Sign(s.secret, body))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call webhook: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Close is a no-op
+func (s *WebhookSink) Close() error {
+	return nil
+}
+
+// Sign returns the hex HMAC-SHA256 of body
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Fanout delivers events to every sink
+type Fanout struct {
+	sinks []Sink
+}
+
+// NewFanout creates a fanout over the given sinks
+func NewFanout(sinks ...Sink) *Fanout {
+	return &Fanout{sinks: sinks}
+}
+
+// Publish delivers the event to all sinks and returns the combined errors.
+// Sinks that already succeeded receive the event again when Eventarc retries,
+// so consumers should deduplicate on the event ID.
+func (f *Fanout) Publish(ctx context.Context, event *ChangeEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %v", err)
+	}
+
+	var errs []error
+	for _, sink := range f.sinks {
+		if err := sink.Publish(ctx, event, body); err != nil {
+			log.Printf("Failed to deliver event %s to %s: %v", event.ID, sink.Name(), err)
+			errs = append(errs, fmt.Errorf("%s: %v", sink.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes all sinks
+func (f *Fanout) Close() error {
+	var errs []error
+	for _, sink := range f.sinks {
+		if err := sink.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
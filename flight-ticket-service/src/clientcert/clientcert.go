@@ -0,0 +1,226 @@
+// Package clientcert reads the client certificate of requests whose mutual
+// TLS was terminated by a Google Cloud load balancer, and logs or enforces it.
+//
+// The load balancer forwards what it verified as custom request headers,
+// configured on its backend service with the names of Headers, e.g.
+// "X-Client-Cert-Present:{client_cert_present}". The headers are only as
+// trustworthy as the path to the service: deploy it with ingress
+// internal-and-cloud-load-balancing so that clients cannot bypass the load
+// balancer and send their own.
+package clientcert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+// Mode is what the service does with forwarded client certificates
+type Mode string
+
+const (
+	// ModeOff ignores the headers
+	ModeOff Mode = "off"
+	// ModeLog logs the certificate of each request that has the headers
+	ModeLog Mode = "log"
+	// ModeRequire rejects requests without a verified, allowed certificate
+	ModeRequire Mode = "require"
+)
+
+// Headers forwarded by the load balancer, by the mTLS variable they carry
+var Headers = map[string]string{
+	"client_cert_present":            "X-Client-Cert-Present",
+	"client_cert_chain_verified":     "X-Client-Cert-Chain-Verified",
+	"client_cert_error":              "X-Client-Cert-Error",
+	"client_cert_sha256_fingerprint": "X-Client-Cert-Hash",
+	"client_cert_serial_number":      "X-Client-Cert-Serial-Number",
+	"client_cert_spiffe_id":          "X-Client-Cert-SPIFFE-ID",
+	"client_cert_uri_sans":           "X-Client-Cert-URI-SANs",
+	"client_cert_dnsname_sans":       "X-Client-Cert-DNSName-SANs",
+	"client_cert_valid_not_before":   "X-Client-Cert-Valid-Not-Before",
+	"client_cert_valid_not_after":    "X-Client-Cert-Valid-Not-After",
+}
+
+// exemptPaths are served without a certificate in require mode, for uptime checks
+var exemptPaths = []string{"/health", "/version"}
+
+// Cert is the client certificate of a request, as forwarded by the load balancer
+type Cert struct {
+	Present     bool
+	Verified    bool   // chained to the load balancer's trust config
+	Error       string // why it was not verified
+	Fingerprint string // SHA-256, base64 as forwarded
+	Serial      string
+	SPIFFEID    string
+	URISANs     []string
+	DNSSANs     []string
+	NotBefore   time.Time
+	NotAfter    time.Time
+}
+
+// Identities returns the names the certificate can be allowed by: its
+// SPIFFE ID, URI and DNS SANs, and SHA-256 fingerprint
+func (c Cert) Identities() []string {
+	var ids []string
+	if c.SPIFFEID != "" {
+		ids = append(ids, c.SPIFFEID)
+	}
+	ids = append(ids, c.URISANs...)
+	ids = append(ids, c.DNSSANs...)
+	if c.Fingerprint != "" {
+		ids = append(ids, c.Fingerprint)
+	}
+	return ids
+}
+
+// FromRequest reads the certificate headers; ok is false when the request
+// has none, i.e. did not come through an mTLS load balancer
+func FromRequest(r *http.Request) (cert Cert, ok bool) {
+	present := r.Header.Get(Headers["client_cert_present"])
+	if present == "" {
+		return Cert{}, false
+	}
+	cert = Cert{
+		Present:     present == "true",
+		Verified:    r.Header.Get(Headers["client_cert_chain_verified"]) == "true",
+		Error:       r.Header.Get(Headers["client_cert_error"]),
+		Fingerprint: r.Header.Get(Headers["client_cert_sha256_fingerprint"]),
+		Serial:      r.Header.Get(Headers["client_cert_serial_number"]),
+		SPIFFEID:    r.Header.Get(Headers["client_cert_spiffe_id"]),
+		URISANs:     splitList(r.Header.Get(Headers["client_cert_uri_sans"])),
+		DNSSANs:     splitList(r.Header.Get(Headers["client_cert_dnsname_sans"])),
+	}
+	cert.NotBefore, _ = time.Parse(time.RFC1123, r.Header.Get(Headers["client_cert_valid_not_before"]))
+	cert.NotAfter, _ = time.Parse(time.RFC1123, r.Header.Get(Headers["client_cert_valid_not_after"]))
+	return cert, true
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Config configures client certificate handling
+type Config struct {
+	Mode Mode
+	// Allowed are the identities accepted in require mode; any verified
+	// certificate is accepted when empty
+	Allowed map[string]bool
+}
+
+// ConfigFromEnv reads CLIENT_CERT_MODE (off, log or require) and
+// CLIENT_CERT_ALLOWED, a comma-separated list of SPIFFE IDs, SANs or
+// SHA-256 fingerprints
+func ConfigFromEnv() (Config, error) {
+	config := Config{Mode: ModeOff, Allowed: map[string]bool{}}
+	switch mode := Mode(strings.ToLower(strings.TrimSpace(os.Getenv("CLIENT_CERT_MODE")))); mode {
+	case "", ModeOff:
+	case ModeLog, ModeRequire:
+		config.Mode = mode
+	default:
+		return Config{}, fmt.Errorf("invalid CLIENT_CERT_MODE %q: must be off, log or require", mode)
+	}
+	for _, id := range splitList(os.Getenv("CLIENT_CERT_ALLOWED")) {
+		config.Allowed[id] = true
+	}
+	if len(config.Allowed) > 0 && config.Mode != ModeRequire {
+		return Config{}, fmt.Errorf("CLIENT_CERT_ALLOWED is set but CLIENT_CERT_MODE is %s: it only applies in require mode", config.Mode)
+	}
+	return config, nil
+}
+
+type contextKey struct{}
+
+// FromContext returns the request's client certificate, if it has one
+func FromContext(ctx context.Context) (Cert, bool) {
+	cert, ok := ctx.Value(contextKey{}).(Cert)
+	return cert, ok
+}
+
+// Middleware logs or enforces the forwarded client certificate and stores
+// it in the request context. It returns next unchanged in off mode, or
+// without a mode.
+func Middleware(config Config) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if config.Mode != ModeLog && config.Mode != ModeRequire {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cert, ok := FromRequest(r)
+			if config.Mode == ModeLog && ok {
+				log.Printf("Client certificate for %s %s: %s", r.Method, r.URL.Path, cert)
+			}
+			if config.Mode == ModeRequire && !exempt(r.URL.Path) {
+				if reason := config.reject(cert); reason != "" {
+					log.Printf("Rejected %s %s: %s (%s)", r.Method, r.URL.Path, reason, cert)
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusForbidden)
+					json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Client certificate required", Message: reason})
+					return
+				}
+			}
+			if ok {
+				r = r.WithContext(context.WithValue(r.Context(), contextKey{}, cert))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// reject returns why a certificate is not accepted in require mode, or ""
+func (c Config) reject(cert Cert) string {
+	switch {
+	case !cert.Present:
+		return "no client certificate was presented"
+	case !cert.Verified:
+		return "the client certificate could not be verified"
+	case len(c.Allowed) == 0:
+		return ""
+	}
+	for _, id := range cert.Identities() {
+		if c.Allowed[id] {
+			return ""
+		}
+	}
+	return "the client certificate is not allowed"
+}
+
+func exempt(path string) bool {
+	for _, p := range exemptPaths {
+		if path == p {
+			return true
+		}
+	}
+	return false
+}
+
+// String describes the certificate for the logs
+func (c Cert) String() string {
+	if !c.Present {
+		return "none"
+	}
+	var parts []string
+	if id := c.Identities(); len(id) > 0 {
+		parts = append(parts, "identity="+id[0])
+	}
+	parts = append(parts, "sha256="+c.Fingerprint, fmt.Sprintf("verified=%t", c.Verified))
+	if c.Error != "" {
+		parts = append(parts, "error="+c.Error)
+	}
+	if !c.NotAfter.IsZero() {
+		parts = append(parts, "expires="+c.NotAfter.UTC().Format(time.RFC3339))
+	}
+	return strings.Join(parts, " ")
+}
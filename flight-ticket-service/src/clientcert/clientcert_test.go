@@ -0,0 +1,108 @@
+package clientcert
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func certHeaders(present, verified, spiffeID string) map[string]string {
+	return map[string]string{
+		"X-Client-Cert-Present":          present,
+		"X-Client-Cert-Chain-Verified":   verified,
+		"X-Client-Cert-Hash":             "q0h2ZVq0b1Zl5c3Q=",
+		"X-Client-Cert-SPIFFE-ID":        spiffeID,
+		"X-Client-Cert-DNSName-SANs":     "desk.example.com, kiosk.example.com",
+		"X-Client-Cert-Valid-Not-After":  "Sat, 16 Oct 2027 08:00:00 GMT",
+		"X-Client-Cert-Valid-Not-Before": "Fri, 16 Oct 2026 08:00:00 GMT",
+	}
+}
+
+func TestFromRequest(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/tickets", nil)
+	if _, ok := FromRequest(req); ok {
+		t.Error("Expected no certificate without the headers")
+	}
+	for header, value := range certHeaders("true", "true", "spiffe://example.com/desk") {
+		req.Header.Set(header, value)
+	}
+	cert, ok := FromRequest(req)
+	if !ok || !cert.Present || !cert.Verified || len(cert.DNSSANs) != 2 || cert.NotAfter.Year() != 2027 {
+		t.Fatalf("Unexpected certificate %+v", cert)
+	}
+	ids := cert.Identities()
+	if len(ids) != 4 || ids[0] != "spiffe://example.com/desk" || ids[3] != "q0h2ZVq0b1Zl5c3Q=" {
+		t.Errorf("Unexpected identities %v", ids)
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("CLIENT_CERT_MODE", "Require")
+	t.Setenv("CLIENT_CERT_ALLOWED", "spiffe://example.com/desk, kiosk.example.com")
+	config, err := ConfigFromEnv()
+	if err != nil || config.Mode != ModeRequire || !config.Allowed["kiosk.example.com"] {
+		t.Fatalf("Unexpected config %+v, %v", config, err)
+	}
+
+	t.Setenv("CLIENT_CERT_MODE", "log")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Error("Expected an error for an allow list outside require mode")
+	}
+	t.Setenv("CLIENT_CERT_MODE", "strict")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Error("Expected an error for an unknown mode")
+	}
+	t.Setenv("CLIENT_CERT_MODE", "")
+	t.Setenv("CLIENT_CERT_ALLOWED", "")
+	if config, err := ConfigFromEnv(); err != nil || config.Mode != ModeOff {
+		t.Errorf("Expected off by default, got %+v, %v", config, err)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var got Cert
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = FromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+	require := Middleware(Config{Mode: ModeRequire, Allowed: map[string]bool{"spiffe://example.com/desk": true, "kiosk.example.com": true}})(next)
+	anyVerified := Middleware(Config{Mode: ModeRequire})(next)
+	logged := Middleware(Config{Mode: ModeLog})(next)
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		path    string
+		headers map[string]string
+		status  int
+	}{
+		{"allowed", require, "/tickets", certHeaders("true", "true", "spiffe://example.com/desk"), http.StatusOK},
+		{"allowed by SAN", require, "/tickets", certHeaders("true", "true", ""), http.StatusOK},
+		{"any verified", anyVerified, "/tickets", certHeaders("true", "true", "spiffe://example.com/other"), http.StatusOK},
+		{"not allowed", require, "/tickets", map[string]string{"X-Client-Cert-Present": "true", "X-Client-Cert-Chain-Verified": "true", "X-Client-Cert-SPIFFE-ID": "spiffe://example.com/other"}, http.StatusForbidden},
+		{"not verified", require, "/tickets", certHeaders("true", "false", "spiffe://example.com/desk"), http.StatusForbidden},
+		{"not presented", require, "/tickets", certHeaders("false", "false", ""), http.StatusForbidden},
+		{"no load balancer", require, "/tickets", nil, http.StatusForbidden},
+		{"health check", require, "/health", nil, http.StatusOK},
+		{"health details", require, "/health/details", nil, http.StatusForbidden},
+		{"logged", logged, "/tickets", certHeaders("true", "false", ""), http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = Cert{}
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			for header, value := range tt.headers {
+				req.Header.Set(header, value)
+			}
+			rec := httptest.NewRecorder()
+			tt.handler.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+			if rec.Code == http.StatusOK && tt.headers != nil && got.Fingerprint == "" {
+				t.Error("Expected the certificate in the request context")
+			}
+		})
+	}
+}
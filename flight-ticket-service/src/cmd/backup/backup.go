@@ -0,0 +1,128 @@
+// Command backup exports and restores the flight tickets collection via Cloud Storage.
+//
+// Usage:
+//
+//	go run ./src/cmd/backup [-bucket BUCKET] backup [-label LABEL]
+//	go run ./src/cmd/backup [-bucket BUCKET] list
+//	go run ./src/cmd/backup [-bucket BUCKET] restore -label LABEL [-overwrite]
+//	go run ./src/cmd/backup [-bucket BUCKET] export [-label LABEL] [-at 2024-07-12T19:00:00Z]
+//	go run ./src/cmd/backup [-bucket BUCKET] import -label LABEL
+//
+// backup/restore write a JSON dump through the configured storage backend and work with
+// every backend. export/import use Firestore managed exports and require the firestore
+// backend. Labels default to the current UTC time (e.g. 20240712T190000Z). The bucket
+// defaults to BACKUP_BUCKET; backend settings are read from the same environment
+// variables as the server.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"flight-ticket-service/src/services"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: backup [-bucket BUCKET] <backup|list|restore|export|import> [flags]")
+	flag.PrintDefaults()
+}
+
+func main() {
+	bucket := flag.String("bucket", os.Getenv("BACKUP_BUCKET"), "Cloud Storage bucket for backups (defaults to BACKUP_BUCKET)")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		usage()
+		os.Exit(2)
+	}
+	command, args := flag.Arg(0), flag.Args()[1:]
+
+	cmdFlags := flag.NewFlagSet(command, flag.ExitOnError)
+	label := cmdFlags.String("label", "", "Snapshot label (defaults to the current UTC time for backup/export)")
+	overwrite := cmdFlags.Bool("overwrite", false, "Reset existing tickets to the snapshot contents (restore only)")
+	at := cmdFlags.String("at", "", "RFC 3339 point in time to export (export only, rounded down to the minute)")
+	cmdFlags.Parse(args)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	storageConfig := services.StorageConfigFromEnv()
+	repository, err := services.NewTicketRepository(storageConfig)
+	if err != nil {
+		log.Fatalf("Failed to initialize %s storage: %v", storageConfig.Backend, err)
+	}
+	defer repository.Close()
+
+	backupService, err := services.NewBackupService(repository, storageConfig, *bucket)
+	if err != nil {
+		log.Fatalf("Failed to initialize backup service: %v", err)
+	}
+
+	if *label == "" && (command == "backup" || command == "export") {
+		*label = services.NewBackupLabel(time.Now())
+	}
+	if *label == "" && (command == "restore" || command == "import") {
+		log.Fatalf("-label is required for %s", command)
+	}
+	if (command == "export" || command == "import") && storageConfig.Backend != services.BackendFirestore {
+		log.Fatalf("%s requires the firestore backend; use backup/restore for %s", command, storageConfig.Backend)
+	}
+
+	switch command {
+	case "backup":
+		info, err := backupService.Backup(ctx, *label)
+		if err != nil {
+			log.Fatalf("Backup failed: %v", err)
+		}
+		printJSON(info)
+	case "list":
+		backups, err := backupService.ListBackups(ctx)
+		if err != nil {
+			log.Fatalf("Failed to list backups: %v", err)
+		}
+		printJSON(backups)
+	case "restore":
+		result, err := backupService.Restore(ctx, *label, *overwrite)
+		if err != nil {
+			log.Fatalf("Restore failed: %v", err)
+		}
+		printJSON(result)
+		if result.Failed > 0 {
+			log.Fatal("Restore finished with failures")
+		}
+	case "export":
+		var snapshotTime time.Time
+		if *at != "" {
+			snapshotTime, err = time.Parse(time.RFC3339, *at)
+			if err != nil {
+				log.Fatalf("Invalid -at time: %v", err)
+			}
+		}
+		info, err := backupService.ExportFirestore(ctx, *label, snapshotTime)
+		if err != nil {
+			log.Fatalf("Export failed: %v", err)
+		}
+		printJSON(info)
+	case "import":
+		if err := backupService.ImportFirestore(ctx, *label); err != nil {
+			log.Fatalf("Import failed: %v", err)
+		}
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+func printJSON(v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to encode output: %v", err)
+	}
+	fmt.Println(string(data))
+}
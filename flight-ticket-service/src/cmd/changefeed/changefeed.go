@@ -0,0 +1,137 @@
+// Command changefeed is a companion Cloud Run service that receives Eventarc
+// Firestore document events for the flight_tickets collection and fans them out
+// to Pub/Sub and webhooks, so changes made outside the API (e.g. console edits)
+// are captured too.
+//
+// Configuration:
+//
+//	CHANGEFEED_TOPIC           Pub/Sub topic ID to publish to (optional)
+//	CHANGEFEED_WEBHOOK_URLS    comma-separated webhook URLs (optional)
+//	CHANGEFEED_WEBHOOK_SECRET  HMAC-SHA256 key for the X-Signature-256 header (optional)
+//	NOTIFICATION_TOPIC         Pub/Sub topic for traveller change notifications (optional)
+//	CHANGEFEED_HISTORY         record every change in the ticket's history subcollection (optional)
+//	GOOGLE_CLOUD_PROJECT       project of the Pub/Sub topics
+//	FIRESTORE_COLLECTION       ticket collection of the events (default flight_tickets)
+//
+// At least one of CHANGEFEED_TOPIC, CHANGEFEED_WEBHOOK_URLS,
+// NOTIFICATION_TOPIC and CHANGEFEED_HISTORY must be set. Change notifications
+// follow each ticket's notification preferences, and the history is written,
+// with the same storage settings as the server.
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"flight-ticket-service/src/changefeed"
+	"flight-ticket-service/src/handlers"
+	"flight-ticket-service/src/services"
+	"flight-ticket-service/src/tenants"
+)
+
+func main() {
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+
+	ctx := context.Background()
+
+	storageConfig := services.StorageConfigFromEnv()
+	changefeed.TicketCollection = storageConfig.FirestoreCollection
+
+	var recordHistory bool
+	if value := os.Getenv("CHANGEFEED_HISTORY"); value != "" {
+		var err error
+		if recordHistory, err = strconv.ParseBool(value); err != nil {
+			log.Fatalf("Invalid CHANGEFEED_HISTORY %q: must be true or false", value)
+		}
+	}
+
+	// Change notifications and the history use the same storage settings as the server
+	var repository services.TicketRepository
+	if os.Getenv("NOTIFICATION_TOPIC") != "" || recordHistory {
+		var err error
+		repository, err = services.NewTicketRepository(storageConfig)
+		if err != nil {
+			log.Fatalf("Failed to initialize %s storage: %v", storageConfig.Backend, err)
+		}
+		defer repository.Close()
+	}
+
+	// Notifications use the templates of the tenant that booked the ticket
+	var templates services.NotificationTemplates
+	if os.Getenv("NOTIFICATION_TOPIC") != "" {
+		tenantStore, err := tenants.NewStore(repository)
+		if err != nil {
+			log.Fatalf("Failed to initialize tenant store: %v", err)
+		}
+		defer tenantStore.Close()
+		refreshInterval, err := tenants.RefreshIntervalFromEnv()
+		if err != nil {
+			log.Fatal(err)
+		}
+		registry := tenants.NewRegistry(tenantStore)
+		if err := registry.Load(ctx); err != nil {
+			log.Fatal(err)
+		}
+		registry.Watch(ctx, refreshInterval)
+		templates = registry
+	}
+
+	sinks, err := changefeed.SinksFromEnv(ctx, repository, templates)
+	if err != nil {
+		log.Fatalf("Failed to initialize sinks: %v", err)
+	}
+	if recordHistory {
+		history, ok := repository.(services.TicketHistory)
+		if !ok {
+			log.Fatalf("CHANGEFEED_HISTORY is not supported by the %s storage backend", storageConfig.Backend)
+		}
+		sinks = append(sinks, changefeed.NewHistorySink(history))
+	}
+	if len(sinks) == 0 {
+		log.Fatal("No sinks configured: set CHANGEFEED_TOPIC, CHANGEFEED_WEBHOOK_URLS, NOTIFICATION_TOPIC and/or CHANGEFEED_HISTORY")
+	}
+
+	fanout := changefeed.NewFanout(sinks...)
+	defer fanout.Close()
+
+	mux := http.NewServeMux()
+	mux.Handle("/", changefeed.NewHandler(fanout))
+	mux.HandleFunc("/health", handlers.HealthCheck)
+
+	server := &http.Server{
+		Addr:              ":" + port,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		log.Printf("Change feed starting on port %s", port)
+		for _, sink := range sinks {
+			log.Printf("Publishing ticket changes to %s", sink.Name())
+		}
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	// Graceful shutdown
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGTERM, syscall.SIGINT)
+	<-quit
+
+	log.Println("Change feed shutting down gracefully...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Error shutting down: %v", err)
+	}
+}
@@ -0,0 +1,189 @@
+// Command jobs runs the batch workloads as Cloud Run Jobs (or locally) instead
+// of behind HTTP endpoints.
+//
+// Usage:
+//
+//	go run ./src/cmd/jobs cleanup [-max-age 24h] [-dry-run]
+//	go run ./src/cmd/jobs export [-bucket BUCKET] [-firestore]
+//	go run ./src/cmd/jobs reminders [-lead 24h] [-window 1h] [-topic TOPIC]
+//	go run ./src/cmd/jobs usage-report [-month YYYY-MM] [-top 5]
+//
+// cleanup cancels PENDING tickets older than -max-age or past departure.
+// export writes a JSON backup (or, with -firestore, a Firestore managed
+// export) to BACKUP_BUCKET labelled with the current time. reminders publishes
+// a reminder for each confirmed ticket departing in the -window slot -lead from
+// now to REMINDER_TOPIC, or logs them when no topic is set; schedule it once per
+// window. Reminders are addressed by the ticket's notification preferences and
+// skipped for tickets that turned notifications off; tickets booked by a tenant
+// use its notification templates. usage-report reports the requests, error
+// rates and top endpoints of each API key in -month (default the previous
+// month) as JSON, and emails it with a CSV attachment to USAGE_REPORT_TO when
+// set. Storage is configured with the same environment variables as the
+// server. The command exits non-zero when any item fails, so Cloud Run retries
+// the task.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"flight-ticket-service/src/analytics"
+	"flight-ticket-service/src/seats"
+	"flight-ticket-service/src/services"
+	"flight-ticket-service/src/tenants"
+	"flight-ticket-service/src/version"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: jobs <cleanup|export|reminders|usage-report> [flags]")
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() < 1 {
+		usage()
+		os.Exit(2)
+	}
+	command, args := flag.Arg(0), flag.Args()[1:]
+
+	cmdFlags := flag.NewFlagSet(command, flag.ExitOnError)
+	maxAge := cmdFlags.Duration("max-age", 24*time.Hour, "Age after which pending tickets are cancelled (cleanup)")
+	dryRun := cmdFlags.Bool("dry-run", false, "Report stale tickets without cancelling them (cleanup)")
+	bucket := cmdFlags.String("bucket", os.Getenv("BACKUP_BUCKET"), "Cloud Storage bucket (export, defaults to BACKUP_BUCKET)")
+	managed := cmdFlags.Bool("firestore", false, "Run a Firestore managed export instead of a JSON backup (export)")
+	lead := cmdFlags.Duration("lead", 24*time.Hour, "How long before departure to remind (reminders)")
+	window := cmdFlags.Duration("window", time.Hour, "Departure slot covered by one run; match the schedule (reminders)")
+	topic := cmdFlags.String("topic", os.Getenv("REMINDER_TOPIC"), "Pub/Sub topic for reminders (reminders, defaults to REMINDER_TOPIC)")
+	month := cmdFlags.String("month", analytics.PreviousMonth(time.Now()), "Month to report, YYYY-MM (usage-report)")
+	top := cmdFlags.Int("top", analytics.DefaultTopEndpoints, "Top endpoints per key (usage-report)")
+	cmdFlags.Parse(args)
+
+	log.Printf("Running %s job (version %s, execution %s, task %s)",
+		command, version.Version, os.Getenv("CLOUD_RUN_EXECUTION"), os.Getenv("CLOUD_RUN_TASK_INDEX"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	storageConfig := services.StorageConfigFromEnv()
+	repository, err := services.NewTicketRepository(storageConfig)
+	if err != nil {
+		log.Fatalf("Failed to initialize %s storage: %v", storageConfig.Backend, err)
+	}
+	defer repository.Close()
+
+	jobs := services.NewTicketJobs(repository)
+
+	switch command {
+	case "cleanup":
+		seatStore, err := seats.NewStore(repository)
+		if err != nil {
+			log.Fatalf("Failed to initialize seat store: %v", err)
+		}
+		defer seatStore.Close()
+		result, err := jobs.WithSeats(seatStore).CleanupPending(ctx, *maxAge, *dryRun)
+		if err != nil {
+			log.Fatalf("Cleanup failed: %v", err)
+		}
+		printJSON(result)
+		if result.Failed > 0 {
+			log.Fatal("Cleanup finished with failures")
+		}
+	case "export":
+		backupService, err := services.NewBackupService(repository, storageConfig, *bucket)
+		if err != nil {
+			log.Fatalf("Failed to initialize backup service: %v", err)
+		}
+		label := services.NewBackupLabel(time.Now())
+		var info *services.BackupInfo
+		if *managed {
+			if storageConfig.Backend != services.BackendFirestore {
+				log.Fatalf("-firestore requires the firestore backend, not %s", storageConfig.Backend)
+			}
+			info, err = backupService.ExportFirestore(ctx, label, time.Time{})
+		} else {
+			info, err = backupService.Backup(ctx, label)
+		}
+		if err != nil {
+			log.Fatalf("Export failed: %v", err)
+		}
+		printJSON(info)
+	case "reminders":
+		var sender services.NotificationSender = services.LogNotificationSender{}
+		if *topic != "" {
+			pubsubSender, err := services.NewPubSubNotificationSender(ctx, storageConfig.ProjectID, storageConfig.CredentialsPath, *topic)
+			if err != nil {
+				log.Fatalf("Failed to initialize reminder topic: %v", err)
+			}
+			defer pubsubSender.Close()
+			sender = pubsubSender
+		}
+		// Reminders follow each ticket's notification preferences and its tenant's templates
+		tenantStore, err := tenants.NewStore(repository)
+		if err != nil {
+			log.Fatalf("Failed to initialize tenant store: %v", err)
+		}
+		defer tenantStore.Close()
+		registry := tenants.NewRegistry(tenantStore)
+		if err := registry.Load(ctx); err != nil {
+			log.Fatal(err)
+		}
+		notifications := services.NewNotificationService(repository, sender).WithTemplates(registry)
+		result, err := jobs.SendReminders(ctx, *lead, *window, notifications)
+		if err != nil {
+			log.Fatalf("Reminders failed: %v", err)
+		}
+		printJSON(result)
+		if result.Failed > 0 {
+			log.Fatal("Reminders finished with failures")
+		}
+	case "usage-report":
+		if _, err := analytics.ParseMonth(*month, time.Now()); err != nil {
+			log.Fatal(err)
+		}
+		emailConfig, err := analytics.EmailConfigFromEnv()
+		if err != nil {
+			log.Fatal(err)
+		}
+		firestoreService, ok := repository.(*services.FirestoreService)
+		if !ok {
+			log.Fatalf("usage-report reads the API usage counters in Firestore; the %s backend keeps them in each server's memory", storageConfig.Backend)
+		}
+		analyticsConfig, err := analytics.ConfigFromEnv()
+		if err != nil {
+			log.Fatal(err)
+		}
+		store, err := analytics.NewFirestoreStore(firestoreService.Client(), analyticsConfig.Shards)
+		if err != nil {
+			log.Fatalf("Failed to initialize API usage counters: %v", err)
+		}
+		counts, err := store.Month(ctx, *month)
+		if err != nil {
+			log.Fatalf("Usage report failed: %v", err)
+		}
+		report := analytics.BuildReport(*month, counts, *top, time.Now())
+		printJSON(report)
+		if emailConfig.Enabled() {
+			if err := analytics.SendReport(emailConfig, report); err != nil {
+				log.Fatal(err)
+			}
+			log.Printf("Emailed the %s usage report to %d recipients", *month, len(emailConfig.To))
+		}
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+func printJSON(v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to encode output: %v", err)
+	}
+	fmt.Println(string(data))
+}
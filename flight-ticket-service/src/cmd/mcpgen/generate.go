@@ -0,0 +1,306 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+
+	"flight-ticket-service/src/internal/openapi"
+)
+
+// initialisms are kept upper case in Go names
+var initialisms = map[string]bool{"api": true, "csv": true, "id": true, "ids": true, "ip": true, "pdf": true, "pnr": true, "qr": true, "ui": true, "url": true}
+
+type options struct {
+	Package string
+	Source  string   // spec file named in the header
+	Exclude []string // path prefixes
+}
+
+// tool is a generated tool before it is written as Go
+type tool struct {
+	Name         string
+	FuncName     string
+	Description  string
+	Title        string
+	InputSchema  string
+	OutputSchema string
+	Method       string
+	Path         string
+	PathParams   []string
+	QueryParams  []string
+	HeaderParams map[string]string
+	BodyParams   []string
+	BodyArgument string
+}
+
+// generate returns the Go source of the tools of the spec and their number
+func generate(data []byte, opts options) ([]byte, int, error) {
+	spec, err := openapi.Parse(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	endpoints, err := spec.Endpoints(opts.Exclude)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	tools := make([]*tool, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		t, err := newTool(spec, endpoint)
+		if err != nil {
+			return nil, 0, err
+		}
+		tools = append(tools, t)
+	}
+
+	source, err := render(tools, opts)
+	return source, len(tools), err
+}
+
+// newTool builds the tool of an endpoint
+func newTool(spec *openapi.Spec, endpoint openapi.Endpoint) (*tool, error) {
+	op, path := endpoint.Operation, endpoint.Path
+	t := &tool{Name: endpoint.Name, Method: endpoint.Method, Path: path, Title: op.Summary}
+	t.FuncName = goName(t.Name)
+	t.Description = strings.TrimSpace(op.Description)
+	if t.Description == "" {
+		t.Description = strings.TrimSpace(op.Summary)
+	}
+
+	properties := make(map[string]any)
+	var required []string
+	add := func(name string, schema map[string]any, isRequired bool) error {
+		if _, ok := properties[name]; ok {
+			return fmt.Errorf("%s %s: argument %s is defined twice", t.Method, path, name)
+		}
+		properties[name] = schema
+		if isRequired {
+			required = append(required, name)
+		}
+		return nil
+	}
+
+	var body *openapi.Parameter
+	for i := range op.Parameters {
+		param := op.Parameters[i]
+		switch param.In {
+		case "path":
+			t.PathParams = append(t.PathParams, param.Name)
+			if err := add(param.Name, paramSchema(param), true); err != nil {
+				return nil, err
+			}
+		case "query":
+			t.QueryParams = append(t.QueryParams, param.Name)
+			if err := add(param.Name, paramSchema(param), param.Required); err != nil {
+				return nil, err
+			}
+		case "header":
+			name := headerArgument(param.Name)
+			if t.HeaderParams == nil {
+				t.HeaderParams = make(map[string]string)
+			}
+			t.HeaderParams[name] = param.Name
+			if err := add(name, paramSchema(param), param.Required); err != nil {
+				return nil, err
+			}
+		case "body":
+			body = &op.Parameters[i]
+		}
+	}
+
+	if body != nil {
+		schema := spec.Resolve(body.Schema)
+		fields, _ := schema["properties"].(map[string]any)
+		flatten := schema["type"] == "object" && len(fields) > 0
+		for name := range fields {
+			if _, ok := properties[name]; ok {
+				flatten = false
+			}
+		}
+		if flatten {
+			fieldRequired := make(map[string]bool)
+			if names, ok := schema["required"].([]any); ok {
+				for _, name := range names {
+					fieldRequired[fmt.Sprint(name)] = true
+				}
+			}
+			for name, field := range fields {
+				t.BodyParams = append(t.BodyParams, name)
+				fieldSchema, _ := field.(map[string]any)
+				if err := add(name, fieldSchema, body.Required && fieldRequired[name]); err != nil {
+					return nil, err
+				}
+			}
+			sort.Strings(t.BodyParams)
+		} else {
+			t.BodyArgument = openapi.Snake(body.Name)
+			if body.Description != "" {
+				schema["description"] = body.Description
+			}
+			if err := add(t.BodyArgument, schema, body.Required); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	sort.Strings(required)
+	input := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		input["required"] = required
+	}
+	encoded, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: failed to encode input schema: %v", t.Method, path, err)
+	}
+	t.InputSchema = string(encoded)
+
+	// MCP output schemas describe objects; arrays and files have none
+	if response, ok := op.SuccessResponse(); ok && response.Schema != nil {
+		if output := spec.Resolve(response.Schema); output["type"] == "object" {
+			encoded, err := json.Marshal(output)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: failed to encode output schema: %v", t.Method, path, err)
+			}
+			t.OutputSchema = string(encoded)
+		}
+	}
+	return t, nil
+}
+
+// headerArgument names the argument of a header parameter: X-Lock-Token is lock_token
+func headerArgument(header string) string {
+	name := strings.ToLower(header)
+	name = strings.TrimPrefix(name, "x-")
+	return strings.ReplaceAll(name, "-", "_")
+}
+
+// goName converts a snake_case tool name to an exported Go name
+func goName(name string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		if part == "" {
+			continue
+		}
+		if initialisms[part] {
+			b.WriteString(strings.ToUpper(part))
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// paramSchema returns the JSON schema of a path, query or header parameter
+func paramSchema(param openapi.Parameter) map[string]any {
+	schema := map[string]any{"type": param.Type}
+	if param.Type == "" {
+		schema["type"] = "string"
+	}
+	if param.Description != "" {
+		schema["description"] = param.Description
+	}
+	if param.Format != "" {
+		schema["format"] = param.Format
+	}
+	if len(param.Enum) > 0 {
+		schema["enum"] = param.Enum
+	}
+	if param.Default != nil {
+		schema["default"] = param.Default
+	}
+	if param.Minimum != nil {
+		schema["minimum"] = *param.Minimum
+	}
+	if param.Maximum != nil {
+		schema["maximum"] = *param.Maximum
+	}
+	if param.Items != nil {
+		schema["items"] = param.Items
+	}
+	return schema
+}
+
+// render writes the tools as a gofmt-ed Go file
+func render(tools []*tool, opts options) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by mcpgen from %s; DO NOT EDIT.\n\n", opts.Source)
+	fmt.Fprintf(&b, "package %s\n\n", opts.Package)
+	b.WriteString("import (\n\t\"context\"\n\t\"encoding/json\"\n)\n\n")
+
+	b.WriteString("// Tools are the tools of the API, by path\n")
+	b.WriteString("var Tools = []Tool{\n")
+	for _, t := range tools {
+		b.WriteString("\t{\n")
+		fmt.Fprintf(&b, "\t\tName: %s,\n", strconv.Quote(t.Name))
+		fmt.Fprintf(&b, "\t\tDescription: %s,\n", strconv.Quote(t.Description))
+		fmt.Fprintf(&b, "\t\tInputSchema: json.RawMessage(%s),\n", strconv.Quote(t.InputSchema))
+		if t.OutputSchema != "" {
+			fmt.Fprintf(&b, "\t\tOutputSchema: json.RawMessage(%s),\n", strconv.Quote(t.OutputSchema))
+		}
+		fmt.Fprintf(&b, "\t\tAnnotations: Annotations{Title: %s, ReadOnlyHint: %t, DestructiveHint: %t, IdempotentHint: %t},\n",
+			strconv.Quote(t.Title), t.Method == "GET", t.Method == "DELETE", t.Method == "GET" || t.Method == "PUT" || t.Method == "DELETE")
+		fmt.Fprintf(&b, "\t\tMethod: %s,\n", strconv.Quote(t.Method))
+		fmt.Fprintf(&b, "\t\tPath: %s,\n", strconv.Quote(t.Path))
+		writeStrings(&b, "PathParams", t.PathParams)
+		writeStrings(&b, "QueryParams", t.QueryParams)
+		if len(t.HeaderParams) > 0 {
+			names := make([]string, 0, len(t.HeaderParams))
+			for name := range t.HeaderParams {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			b.WriteString("\t\tHeaderParams: map[string]string{")
+			for i, name := range names {
+				if i > 0 {
+					b.WriteString(", ")
+				}
+				fmt.Fprintf(&b, "%s: %s", strconv.Quote(name), strconv.Quote(t.HeaderParams[name]))
+			}
+			b.WriteString("},\n")
+		}
+		writeStrings(&b, "BodyParams", t.BodyParams)
+		if t.BodyArgument != "" {
+			fmt.Fprintf(&b, "\t\tBodyArgument: %s,\n", strconv.Quote(t.BodyArgument))
+		}
+		b.WriteString("\t},\n")
+	}
+	b.WriteString("}\n\n")
+
+	b.WriteString("// Handlers call the endpoints of the tools, by tool name\n")
+	b.WriteString("var Handlers = map[string]Handler{\n")
+	for _, t := range tools {
+		fmt.Fprintf(&b, "\t%s: (*Client).%s,\n", strconv.Quote(t.Name), t.FuncName)
+	}
+	b.WriteString("}\n")
+
+	for i, t := range tools {
+		fmt.Fprintf(&b, "\n// %s calls %s %s", t.FuncName, t.Method, t.Path)
+		if t.Title != "" {
+			fmt.Fprintf(&b, ": %s", strings.ReplaceAll(t.Title, "\n", " "))
+		}
+		fmt.Fprintf(&b, "\nfunc (c *Client) %s(ctx context.Context, args map[string]any) (json.RawMessage, error) {\n", t.FuncName)
+		fmt.Fprintf(&b, "\treturn c.Call(ctx, Tools[%d], args)\n}\n", i)
+	}
+
+	source, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code: %v", err)
+	}
+	return source, nil
+}
+
+func writeStrings(b *bytes.Buffer, field string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = strconv.Quote(value)
+	}
+	fmt.Fprintf(b, "\t\t%s: []string{%s},\n", field, strings.Join(quoted, ", "))
+}
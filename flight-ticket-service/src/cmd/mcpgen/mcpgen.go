@@ -0,0 +1,71 @@
+// Command mcpgen generates the MCP tools of package mcptools from the
+// service's OpenAPI spec, so new REST endpoints become MCP tools without
+// hand-written definitions.
+//
+// Usage:
+//
+//	go run ./src/cmd/mcpgen [-spec docs/swagger.json] [-out src/mcptools/tools_gen.go] [-exclude PREFIXES]
+//
+// Every operation becomes a tool named after its operationId, or else after
+// its method and path (GET /ticket/{confirmationID} is
+// get_ticket_by_confirmation_id). The tool's input schema has the path, query
+// and header parameters of the operation and, when the body is a JSON object,
+// its fields; other bodies are one argument named after the body parameter.
+// When the first 2xx response is a JSON object, its schema is the tool's
+// output schema.
+// Operations under the -exclude path prefixes and deprecated ones are skipped.
+// Run `make docs` first, so the spec has the latest endpoints.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"flight-ticket-service/src/internal/openapi"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: mcpgen [-spec FILE] [-out FILE] [-exclude PREFIXES]")
+	flag.PrintDefaults()
+}
+
+func main() {
+	specPath := flag.String("spec", "docs/swagger.json", "OpenAPI 2.0 spec generated by swag")
+	out := flag.String("out", "src/mcptools/tools_gen.go", "Go file to write, - for stdout")
+	pkg := flag.String("package", "mcptools", "Package of the generated file")
+	exclude := flag.String("exclude", strings.Join(openapi.DefaultExcludes, ","), "Comma-separated path prefixes of operations that are not tools")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() > 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	spec, err := os.ReadFile(*specPath)
+	if err != nil {
+		log.Fatalf("Failed to read spec: %v", err)
+	}
+	var prefixes []string
+	for _, prefix := range strings.Split(*exclude, ",") {
+		if prefix = strings.TrimSpace(prefix); prefix != "" {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+
+	source, count, err := generate(spec, options{Package: *pkg, Source: filepath.Base(*specPath), Exclude: prefixes})
+	if err != nil {
+		log.Fatalf("Failed to generate tools: %v", err)
+	}
+	if *out == "-" {
+		os.Stdout.Write(source)
+		return
+	}
+	if err := os.WriteFile(*out, source, 0644); err != nil {
+		log.Fatalf("Failed to write %s: %v", *out, err)
+	}
+	log.Printf("Wrote %d tools to %s", count, *out)
+}
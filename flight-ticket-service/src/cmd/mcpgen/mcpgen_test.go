@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"flight-ticket-service/src/internal/openapi"
+)
+
+// TestGeneratedToolsUpToDate fails when the spec changed since the tools were generated
+func TestGeneratedToolsUpToDate(t *testing.T) {
+	data, err := os.ReadFile("../../../docs/swagger.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, _, err := generate(data, options{Package: "mcptools", Source: "swagger.json", Exclude: openapi.DefaultExcludes})
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated, err := os.ReadFile("../../mcptools/tools_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(source, generated) {
+		t.Error("src/mcptools/tools_gen.go is out of date; run go generate ./src/mcptools")
+	}
+}
+
+const testSpec = `{
+  "swagger": "2.0",
+  "paths": {
+    "/health": {"get": {"summary": "Health check"}},
+    "/admin/review-queue/{confirmationID}/approve": {"post": {
+      "summary": "Approve a booking held for review",
+      "parameters": [
+        {"name": "confirmationID", "in": "path", "required": true, "type": "string"},
+        {"name": "decision", "in": "body", "schema": {"$ref": "#/definitions/models.ReviewDecisionRequest"}},
+        {"name": "X-Lock-Token", "in": "header", "type": "string"}
+      ],
+      "responses": {"200": {"schema": {"$ref": "#/definitions/models.ReviewDecisionRequest"}}, "400": {"schema": {"type": "string"}}}
+    }},
+    "/admin/review-queue/{confirmationID}/reject": {"post": {"summary": "Reject", "parameters": [{"name": "confirmationID", "in": "path", "required": true, "type": "string"}]}},
+    "/tickets/{confirmationID}/seats": {"put": {
+      "operationId": "assignSeats",
+      "parameters": [
+        {"name": "confirmationID", "in": "path", "required": true, "type": "string"},
+        {"name": "seats", "in": "body", "required": true, "schema": {"type": "array", "items": {"$ref": "#/definitions/models.Seat"}}}
+      ]
+    }},
+    "/old": {"get": {"deprecated": true}}
+  },
+  "definitions": {
+    "models.ReviewDecisionRequest": {"type": "object", "required": ["reason"], "properties": {"reason": {"type": "string"}, "next": {"$ref": "#/definitions/models.ReviewDecisionRequest"}}},
+    "models.Seat": {"type": "object", "properties": {"seat": {"description": "Seat", "allOf": [{"$ref": "#/definitions/models.SeatNumber"}]}}},
+    "models.SeatNumber": {"type": "string", "example": "12A"}
+  }
+}`
+
+func TestGenerate(t *testing.T) {
+	source, count, err := generate([]byte(testSpec), options{Package: "tools", Source: "test.json", Exclude: openapi.DefaultExcludes})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Errorf("Expected 3 tools without health and deprecated ones, got %d", count)
+	}
+	for _, want := range []string{
+		`Name:         "create_admin_review_queue_approve"`,
+		`HeaderParams: map[string]string{"lock_token": "X-Lock-Token"}`,
+		`BodyParams:   []string{"next", "reason"}`,
+		`Name:         "assign_seats"`,
+		`BodyArgument: "seats"`,
+		`"assign_seats":                      (*Client).AssignSeats,`,
+		`func (c *Client) CreateAdminReviewQueueApprove(ctx context.Context`,
+	} {
+		if !strings.Contains(string(source), want) {
+			t.Errorf("Expected %s in:\n%s", want, source)
+		}
+	}
+
+	if strings.Count(string(source), "OutputSchema:") != 1 || !strings.Contains(string(source), `OutputSchema: json.RawMessage("{\"properties\":{\"next\":{\"type\":\"object\"}`) {
+		t.Errorf("Expected an output schema for the object response only:\n%s", source)
+	}
+
+	// The self reference ends in a plain object, and allOf wrappers are unwrapped
+	var schema struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+		Required   []string                   `json:"required"`
+	}
+	for _, line := range strings.Split(string(source), "\n") {
+		if strings.Contains(line, "InputSchema") && strings.Contains(line, "seats") {
+			json.Unmarshal([]byte(unquoteSchema(t, line)), &schema)
+		}
+	}
+	if !strings.Contains(string(schema.Properties["seats"]), `"seat":{"description":"Seat","example":"12A","type":"string"}`) || len(schema.Required) != 2 {
+		t.Errorf("Unexpected seats schema %s, required %v", schema.Properties["seats"], schema.Required)
+	}
+}
+
+func unquoteSchema(t *testing.T, line string) string {
+	start := strings.Index(line, "(")
+	var value string
+	if err := json.Unmarshal([]byte(strings.TrimSuffix(strings.TrimSpace(line[start+1:]), "),")), &value); err != nil {
+		t.Fatalf("Failed to read %s: %v", line, err)
+	}
+	return value
+}
+
+func TestToolNames(t *testing.T) {
+	if got := goName(openapi.Snake("getPNRExport")); got != "GetPNRExport" {
+		t.Errorf("Expected GetPNRExport, got %s", got)
+	}
+	spec := `{"swagger": "2.0", "paths": {
+	  "/a/{x}/b": {"get": {}},
+	  "/a/{y}/b": {"get": {}}
+	}}`
+	source, _, err := generate([]byte(spec), options{Package: "tools"})
+	if err != nil || !strings.Contains(string(source), `"get_a_by_y_b"`) {
+		t.Errorf("Expected the second tool named after all its parameters, got %v", err)
+	}
+	spec = `{"swagger": "2.0", "paths": {"/a": {"get": {"operationId": "a"}}, "/b": {"get": {"operationId": "a"}}}}`
+	if _, _, err := generate([]byte(spec), options{Package: "tools"}); err == nil {
+		t.Error("Expected an error for a duplicate operationId")
+	}
+}
@@ -0,0 +1,91 @@
+// Command mcpserver serves the generated MCP tools of package mcptools,
+// calling the REST API of a running service.
+//
+// Usage:
+//
+//	go run ./src/cmd/mcpserver -target URL [-api-key KEY] [-id-token-audience URL] [-listen ADDR]
+//
+// Without -listen it speaks MCP over stdio, one JSON-RPC message per line, for
+// clients that start it as a subprocess. With -listen it serves the streamable
+// HTTP transport on POST /mcp. Requests to the API are authenticated with
+// -api-key, which defaults to MCP_API_KEY, and with Google-signed ID tokens
+// for -id-token-audience, which defaults to MCP_ID_TOKEN_AUDIENCE, when the
+// service requires IAM authentication. Errors of the API are returned as
+// tool results with isError set. The undo_last_action tool undoes the last
+// change made in the session, and the session://summary resource lists the
+// bookings it changed; the summary of each session that ends is logged. The
+// summarize_upcoming_trips tool asks the client's model, with an MCP sampling
+// request, to summarize the upcoming trips.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"flight-ticket-service/src/mcptools"
+	"flight-ticket-service/src/version"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: mcpserver -target URL [-api-key KEY] [-id-token-audience URL] [-listen ADDR]")
+	flag.PrintDefaults()
+}
+
+func main() {
+	target := flag.String("target", os.Getenv("MCP_API_URL"), "Base URL of the flight ticket service (defaults to MCP_API_URL)")
+	apiKey := flag.String("api-key", os.Getenv("MCP_API_KEY"), "API key sent as X-API-Key (defaults to MCP_API_KEY)")
+	audience := flag.String("id-token-audience", os.Getenv("MCP_ID_TOKEN_AUDIENCE"), "Send ID tokens for this audience, the service URL, e.g. the -target (defaults to MCP_ID_TOKEN_AUDIENCE)")
+	listen := flag.String("listen", "", "Address to serve the HTTP transport on, e.g. :8090; stdio when empty")
+	flag.Usage = usage
+	flag.Parse()
+	if *target == "" || flag.NArg() > 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	// stdout carries the protocol; logs go to stderr
+	log.SetOutput(os.Stderr)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	client := mcptools.NewClient(*target, *apiKey)
+	if *audience != "" {
+		if err := client.UseIDTokens(ctx, *audience); err != nil {
+			log.Fatal(err)
+		}
+	}
+	server := mcptools.NewServer(client, "flight-ticket-service", version.Get().Version)
+	server.OnSessionClose = func(summary mcptools.SessionSummary) {
+		encoded, _ := json.Marshal(summary)
+		log.Printf("MCP session summary: %s", encoded)
+	}
+
+	if *listen == "" {
+		if err := server.Serve(ctx, os.Stdin, os.Stdout); err != nil && !errors.Is(err, context.Canceled) {
+			log.Fatalf("MCP stdio transport failed: %v", err)
+		}
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/mcp", server)
+	httpServer := &http.Server{Addr: *listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdown)
+	}()
+	log.Printf("Serving %d MCP tools for %s on %s/mcp", len(mcptools.Tools), *target, *listen)
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatalf("MCP HTTP transport failed: %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+// Command migrate copies flight tickets between storage backends.
+//
+// Usage:
+//
+//	go run ./src/cmd/migrate -from firestore -to spanner [-create-schema] [-dry-run]
+//	go run ./src/cmd/migrate -to spanner -schema-only
+//	go run ./src/cmd/migrate -to postgres -schema-only
+//
+// Backend settings (project, credentials, Spanner instance/database, PostgreSQL DSN) are read from
+// the same environment variables as the server. Tickets that already exist in the
+// destination are skipped, so the migration can be re-run safely.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"time"
+
+	"flight-ticket-service/src/services"
+)
+
+func main() {
+	from := flag.String("from", services.BackendFirestore, "Source storage backend")
+	to := flag.String("to", services.BackendSpanner, "Destination storage backend")
+	createSchema := flag.Bool("create-schema", false, "Apply the destination schema before copying (Spanner and PostgreSQL)")
+	schemaOnly := flag.Bool("schema-only", false, "Apply the destination schema and exit without copying")
+	dryRun := flag.Bool("dry-run", false, "Report what would be copied without writing")
+	flag.Parse()
+
+	if *from == *to {
+		log.Fatal("Source and destination backends must differ")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	destConfig := services.StorageConfigFromEnv()
+	destConfig.Backend = *to
+	dest, err := services.NewTicketRepository(destConfig)
+	if err != nil {
+		log.Fatalf("Failed to initialize %s storage: %v", *to, err)
+	}
+	defer dest.Close()
+
+	if *createSchema || *schemaOnly {
+		creator, ok := dest.(services.SchemaCreator)
+		if !ok {
+			log.Fatalf("-create-schema is not supported for the %s backend", *to)
+		}
+		if err := creator.CreateSchema(ctx); err != nil {
+			log.Fatalf("Failed to create schema: %v", err)
+		}
+		if *schemaOnly {
+			return
+		}
+	}
+
+	sourceConfig := services.StorageConfigFromEnv()
+	sourceConfig.Backend = *from
+	source, err := services.NewTicketRepository(sourceConfig)
+	if err != nil {
+		log.Fatalf("Failed to initialize %s storage: %v", *from, err)
+	}
+	defer source.Close()
+
+	tickets, err := source.ListTickets(ctx, 0)
+	if err != nil {
+		log.Fatalf("Failed to read tickets from %s: %v", *from, err)
+	}
+	log.Printf("Found %d tickets in %s", len(tickets), *from)
+
+	var copied, skipped, failed int
+	for _, ticket := range tickets {
+		if _, err := dest.GetTicket(ctx, ticket.ConfirmationID); err == nil {
+			skipped++
+			continue
+		}
+
+		if *dryRun {
+			log.Printf("Would copy ticket %s", ticket.ConfirmationID)
+			copied++
+			continue
+		}
+
+		if err := dest.CreateTicket(ctx, ticket); err != nil {
+			log.Printf("Failed to copy ticket %s: %v", ticket.ConfirmationID, err)
+			failed++
+			continue
+		}
+		copied++
+	}
+
+	log.Printf("Migration from %s to %s complete: %d copied, %d skipped (already present), %d failed", *from, *to, copied, skipped, failed)
+	if failed > 0 {
+		log.Fatal("Migration finished with failures")
+	}
+}
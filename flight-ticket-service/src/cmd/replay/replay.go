@@ -0,0 +1,122 @@
+// Command replay re-sends requests recorded with RECORD_DESTINATION to another
+// environment, e.g. to reproduce a production issue against staging or the
+// local emulator stack.
+//
+// Usage:
+//
+//	go run ./src/cmd/replay -target URL [-api-key KEY] [flags] SOURCE...
+//
+// SOURCE is a recording file, a directory of them or a gs://bucket/prefix.
+// Requests are sent in recorded order; -speed 1 keeps the recorded spacing and
+// 0 (the default) sends them back to back. Requests recorded with an API key
+// are sent with -api-key (defaults to REPLAY_API_KEY) since keys are never
+// recorded. Each result is printed as a JSON line; the command exits non-zero
+// when any status differs from the recorded one.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"flight-ticket-service/src/recording"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: replay -target URL [-api-key KEY] [flags] SOURCE...")
+	flag.PrintDefaults()
+}
+
+func main() {
+	target := flag.String("target", "", "Base URL of the environment to replay against (required)")
+	apiKey := flag.String("api-key", os.Getenv("REPLAY_API_KEY"), "API key for requests recorded with one (defaults to REPLAY_API_KEY)")
+	speed := flag.Float64("speed", 0, "Replay speed relative to the recording; 0 sends requests back to back")
+	readOnly := flag.Bool("read-only", false, "Only replay GET and HEAD requests")
+	pathPrefix := flag.String("path", "", "Only replay requests whose path starts with this prefix")
+	since := flag.String("since", "", "Only replay requests recorded at or after this RFC 3339 time")
+	until := flag.String("until", "", "Only replay requests recorded before this RFC 3339 time")
+	limit := flag.Int("limit", 0, "Maximum number of requests to replay (0 for all)")
+	dryRun := flag.Bool("dry-run", false, "List the requests that would be replayed without sending them")
+	flag.Usage = usage
+	flag.Parse()
+
+	if (*target == "" && !*dryRun) || flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	var from, to time.Time
+	var err error
+	if *since != "" {
+		if from, err = time.Parse(time.RFC3339, *since); err != nil {
+			log.Fatalf("Invalid -since: %v", err)
+		}
+	}
+	if *until != "" {
+		if to, err = time.Parse(time.RFC3339, *until); err != nil {
+			log.Fatalf("Invalid -until: %v", err)
+		}
+	}
+
+	ctx := context.Background()
+
+	var records []recording.Record
+	for _, source := range flag.Args() {
+		loaded, err := recording.Load(ctx, source, os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"))
+		if err != nil {
+			log.Fatalf("Failed to load recordings: %v", err)
+		}
+		records = append(records, loaded...)
+	}
+
+	var selected []recording.Record
+	for _, rec := range records {
+		if *readOnly && rec.Method != http.MethodGet && rec.Method != http.MethodHead {
+			continue
+		}
+		if !strings.HasPrefix(rec.Path, *pathPrefix) {
+			continue
+		}
+		if (!from.IsZero() && rec.Time.Before(from)) || (!to.IsZero() && !rec.Time.Before(to)) {
+			continue
+		}
+		selected = append(selected, rec)
+		if *limit > 0 && len(selected) == *limit {
+			break
+		}
+	}
+	log.Printf("Replaying %d of %d recorded requests against %s", len(selected), len(records), *target)
+
+	encoder := json.NewEncoder(os.Stdout)
+	if *dryRun {
+		for _, rec := range selected {
+			encoder.Encode(rec)
+		}
+		return
+	}
+
+	replayer := recording.NewReplayer(*target, *apiKey)
+	var summary recording.Summary
+	start := time.Now()
+	for i, rec := range selected {
+		if *speed > 0 && i > 0 {
+			offset := time.Duration(float64(rec.Time.Sub(selected[0].Time)) / *speed)
+			time.Sleep(time.Until(start.Add(offset)))
+		}
+
+		result := replayer.Replay(ctx, rec)
+		summary.Add(result)
+		encoder.Encode(result)
+	}
+
+	log.Printf("Replay finished: %s", summary)
+	if summary.Matched != summary.Total {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+func TestAdminUI(t *testing.T) {
+	router := newTestRouter(t)
+	do := func(method, target string, form url.Values, cookies []*http.Cookie) *httptest.ResponseRecorder {
+		var body *strings.Reader
+		if form != nil {
+			body = strings.NewReader(form.Encode())
+		} else {
+			body = strings.NewReader("")
+		}
+		req := httptest.NewRequest(method, target, body)
+		if form != nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(http.MethodGet, "/admin/ui/", nil, nil); rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/admin/ui/login" {
+		t.Fatalf("Expected a redirect to the sign-in page, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+	if rec := do(http.MethodGet, "/admin/ui/login", nil, nil); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `name="api_key"`) {
+		t.Fatalf("Expected the sign-in page, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/admin/ui/login", url.Values{"api_key": {"wrong"}}, nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected 401 for an unknown key, got %d", rec.Code)
+	}
+
+	rec := do(http.MethodPost, "/admin/ui/login", url.Values{"api_key": {"fuzz-key"}}, nil)
+	cookies := rec.Result().Cookies()
+	if rec.Code != http.StatusSeeOther || len(cookies) != 1 || !cookies[0].HttpOnly {
+		t.Fatalf("Expected a session cookie, got %d %v", rec.Code, cookies)
+	}
+
+	rec = do(http.MethodGet, "/admin/ui/?origin=jfk", nil, cookies)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), seededTicket) {
+		t.Fatalf("Expected the seeded ticket in the list, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/admin/ui/?origin=SFO", nil, cookies); strings.Contains(rec.Body.String(), seededTicket) {
+		t.Error("Expected the search to filter out the seeded ticket")
+	}
+
+	rec = do(http.MethodPost, "/admin/ui/tickets/"+seededTicket+"/rebook", url.Values{
+		"flight_number":  {"ua900"},
+		"departure_date": {"2030-01-15"},
+		"departure_time": {"08:45"},
+	}, cookies)
+	if rec.Code != http.StatusSeeOther || !strings.Contains(rec.Header().Get("Location"), "rebooked") {
+		t.Fatalf("Expected a redirect after rebooking, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+	rec = do(http.MethodGet, "/admin/ui/tickets/"+seededTicket, nil, cookies)
+	if !strings.Contains(rec.Body.String(), "UA900") || !strings.Contains(rec.Body.String(), "2030-01-15 08:45") {
+		t.Errorf("Expected the rebooked flight on the ticket page")
+	}
+
+	if rec := do(http.MethodPost, "/admin/ui/tickets/"+seededTicket+"/cancel", nil, cookies); rec.Code != http.StatusSeeOther {
+		t.Fatalf("Expected a redirect after cancelling, got %d", rec.Code)
+	}
+	rec = do(http.MethodGet, "/admin/ui/tickets/"+seededTicket, nil, cookies)
+	if !strings.Contains(rec.Body.String(), "CANCELLED") || strings.Contains(rec.Body.String(), "Cancel ticket") {
+		t.Errorf("Expected a cancelled ticket without actions")
+	}
+
+	// Forms posted from other sites are refused
+	req := httptest.NewRequest(http.MethodPost, "/admin/ui/tickets/"+seededTicket+"/cancel", nil)
+	req.Header.Set("Origin", "https://evil.example")
+	req.AddCookie(cookies[0])
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for a cross-origin post, got %d", rec.Code)
+	}
+}
+
+func TestAdminUIFreesSeats(t *testing.T) {
+	router := newTestRouter(t)
+	send := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", "desk-key")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	var cookies []*http.Cookie
+	post := func(target string, form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	if cookies = post("/admin/ui/login", url.Values{"api_key": {"fuzz-key"}}).Result().Cookies(); len(cookies) != 1 {
+		t.Fatalf("Expected a session cookie, got %v", cookies)
+	}
+
+	departure := time.Now().UTC().AddDate(0, 1, 0).Format("2006-01-02")
+	seatsURL := "/flights/AA1234/" + departure + "/seats"
+	book := func(seat string) string {
+		var hold models.SeatHold
+		json.NewDecoder(send(http.MethodPost, seatsURL+"/hold", `{"seats":["`+seat+`"]}`).Body).Decode(&hold)
+		var ticket models.FlightTicket
+		json.NewDecoder(send(http.MethodPost, "/ticket", `{"origin":"JFK","destination":"LAX","departure_date":"`+departure+
+			`","departure_time":"09:00","flight_number":"AA1234","passengers":1,"seat_hold_id":"`+hold.ID+`"}`).Body).Decode(&ticket)
+		if len(ticket.AssignedSeats) != 1 {
+			t.Fatalf("Expected seat %s assigned, got %+v", seat, ticket)
+		}
+		return ticket.ConfirmationID
+	}
+	retimed, rebooked, cancelled := book("7A"), book("8A"), book("9A")
+
+	// A new departure time on the same flight keeps the seat; a new date frees it
+	rebook := func(confirmationID, date string) {
+		form := url.Values{"departure_date": {date}, "departure_time": {"11:30"}}
+		if rec := post("/admin/ui/tickets/"+confirmationID+"/rebook", form); !strings.Contains(rec.Header().Get("Location"), "rebooked") {
+			t.Fatalf("Expected %s rebooked, got %d %q", confirmationID, rec.Code, rec.Header().Get("Location"))
+		}
+	}
+	rebook(retimed, departure)
+	rebook(rebooked, time.Now().UTC().AddDate(0, 2, 0).Format("2006-01-02"))
+	if rec := post("/admin/ui/tickets/"+cancelled+"/cancel", nil); rec.Code != http.StatusSeeOther {
+		t.Fatalf("Expected a redirect after cancelling, got %d", rec.Code)
+	}
+
+	var seatMap models.SeatMapResponse
+	json.NewDecoder(send(http.MethodGet, seatsURL, "").Body).Decode(&seatMap)
+	if seatMap.Count != 1 || seatMap.Seats[0].Seat != "7A" {
+		t.Errorf("Expected only the seat of the retimed ticket taken, got %+v", seatMap)
+	}
+	var ticket models.FlightTicket
+	json.NewDecoder(send(http.MethodGet, "/ticket/"+rebooked, "").Body).Decode(&ticket)
+	if len(ticket.AssignedSeats) != 0 {
+		t.Errorf("Expected the rebooked ticket without seats, got %v", ticket.AssignedSeats)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"flight-ticket-service/src/models"
+)
+
+func TestAPIUsage(t *testing.T) {
+	router := newTestRouter(t)
+	send := func(target, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	send("/ticket/"+seededTicket, "desk-key")
+	send("/ticket/ZZZZZZ", "desk-key")
+	send("/tickets", "")
+
+	if rec := send("/admin/usage", "desk-key"); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for an agent, got %d", rec.Code)
+	}
+	rec := send("/admin/usage?top=1", "fuzz-key")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var report models.APIUsageReport
+	json.NewDecoder(rec.Body).Decode(&report)
+	var desk *models.APIKeyUsage
+	for i := range report.Keys {
+		if report.Keys[i].Key == "desk" {
+			desk = &report.Keys[i]
+		}
+	}
+	if desk == nil || desk.Requests != 3 || desk.ClientErrors != 2 || len(desk.TopEndpoints) != 1 || desk.TopEndpoints[0].Endpoint != "GET /ticket/{confirmationID}" {
+		t.Errorf("Unexpected usage of desk %+v in %+v", desk, report)
+	}
+
+	rec = send("/admin/usage?format=csv", "fuzz-key")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/csv") || !strings.Contains(rec.Body.String(), ",anonymous,1,") {
+		t.Errorf("Unexpected CSV %d %q", rec.Code, rec.Body.String())
+	}
+	if rec := send("/admin/usage?month=2026-13", "fuzz-key"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid month, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetTicketConditionalRequests(t *testing.T) {
+	router := newTestRouter(t)
+	get := func(headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/ticket/"+seededTicket, nil)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get(nil)
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || etag == "" || rec.Header().Get("Last-Modified") == "" {
+		t.Fatalf("Expected validators, got %d %v", rec.Code, rec.Header())
+	}
+	if rec.Header().Get("Cache-Control") != "public, max-age=60" {
+		t.Errorf("Unexpected Cache-Control %q", rec.Header().Get("Cache-Control"))
+	}
+
+	if rec := get(map[string]string{"If-None-Match": `"stale", ` + etag}); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("Expected 304 for a matching ETag, got %d", rec.Code)
+	}
+	if rec := get(map[string]string{"If-None-Match": `"stale"`}); rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 for a stale ETag, got %d", rec.Code)
+	}
+	if rec := get(map[string]string{"If-Modified-Since": time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)}); rec.Code != http.StatusNotModified {
+		t.Errorf("Expected 304 for an unchanged ticket, got %d", rec.Code)
+	}
+	if rec := get(map[string]string{"If-Modified-Since": "Mon, 01 Jan 2001 00:00:00 GMT"}); rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 for a ticket changed since, got %d", rec.Code)
+	}
+
+	// Other encodings have their own ETag
+	if rec := get(map[string]string{"Accept": "application/xml", "If-None-Match": etag}); rec.Code != http.StatusOK || rec.Header().Get("ETag") == etag {
+		t.Errorf("Expected a different ETag for XML, got %d %s", rec.Code, rec.Header().Get("ETag"))
+	}
+
+	// Responses to API key holders may include notes and stay out of shared caches
+	rec = get(map[string]string{"X-API-Key": "fuzz-key"})
+	if !strings.HasPrefix(rec.Header().Get("Cache-Control"), "private") {
+		t.Errorf("Expected a private response for an admin, got %q", rec.Header().Get("Cache-Control"))
+	}
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"flight-ticket-service/src/analytics"
+	"flight-ticket-service/src/auth"
+	"flight-ticket-service/src/clientcert"
+	"flight-ticket-service/src/currency"
+	"flight-ticket-service/src/debuglog"
+	"flight-ticket-service/src/entry"
+	"flight-ticket-service/src/featureflags"
+	"flight-ticket-service/src/forecast"
+	"flight-ticket-service/src/handlers"
+	"flight-ticket-service/src/health"
+	"flight-ticket-service/src/internal/docstore"
+	"flight-ticket-service/src/jobs"
+	"flight-ticket-service/src/liveconfig"
+	"flight-ticket-service/src/maintenance"
+	"flight-ticket-service/src/metrics"
+	"flight-ticket-service/src/network"
+	"flight-ticket-service/src/pricing"
+	"flight-ticket-service/src/quota"
+	"flight-ticket-service/src/recording"
+	"flight-ticket-service/src/rules"
+	"flight-ticket-service/src/scheduling"
+	"flight-ticket-service/src/selfcheck"
+	"flight-ticket-service/src/services"
+	"flight-ticket-service/src/tenants"
+	"flight-ticket-service/src/workers"
+
+	"golang.org/x/oauth2/google"
+	firestoreadmin "google.golang.org/api/firestore/v1"
+	"google.golang.org/api/option"
+	secretmanager "google.golang.org/api/secretmanager/v1"
+)
+
+// cloudPlatformScope is the OAuth scope of the Google Cloud APIs
+const cloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+
+// durationSettings are the TTLs server.go parses inline
+var durationSettings = []string{"WEATHER_CACHE_TTL", "FX_CACHE_TTL", "ATTACHMENT_URL_TTL", "DOCUMENT_URL_TTL"}
+
+// runSelfCheck checks the configuration and the dependencies the server
+// needs, writes the report and returns whether every check passed
+func runSelfCheck(storageConfig services.StorageConfig, w io.Writer) bool {
+	report := selfcheck.Run(context.Background(), selfcheck.DefaultTimeout, selfChecks(storageConfig)...)
+	report.Write(w)
+	return report.Failed() == 0
+}
+
+// selfChecks lists the checks of server --check, in the order they run
+func selfChecks(storageConfig services.StorageConfig) []selfcheck.Check {
+	return []selfcheck.Check{
+		{Name: "configuration", Run: checkConfiguration(storageConfig)},
+		{Name: "credentials", Run: checkCredentials(storageConfig)},
+		{Name: "storage", Run: checkStorage(storageConfig)},
+		{Name: "firestore indexes", Run: checkFirestoreIndexes(storageConfig)},
+		{Name: "secret manager", Run: checkSecrets(storageConfig, os.Getenv("SELF_CHECK_SECRETS"))},
+	}
+}
+
+// checkConfiguration parses every setting read at startup and reports all invalid ones
+func checkConfiguration(storageConfig services.StorageConfig) func(context.Context) (string, error) {
+	return func(ctx context.Context) (string, error) {
+		settings := map[string]func() error{
+			"API_KEYS":                 func() error { _, err := auth.KeyStoreFromEnv(); return err },
+			"ID tokens":                func() error { _, err := auth.IDTokenConfigFromEnv(); return err },
+			"client certificates":      func() error { _, err := clientcert.ConfigFromEnv(); return err },
+			"AUTH_POLICY":              func() error { _, err := auth.PolicyFromEnv(); return err },
+			"FEATURE_FLAGS":            func() error { _, err := featureflags.ParseEnv(os.Getenv("FEATURE_FLAGS")); return err },
+			"MAINTENANCE_MODE":         func() error { _, err := maintenance.ParseMode(os.Getenv("MAINTENANCE_MODE")); return err },
+			"operation budget":         func() error { _, err := services.OperationBudgetFromEnv(); return err },
+			"sandbox":                  func() error { _, err := services.SandboxConfigFromEnv(storageConfig); return err },
+			"metrics export":           func() error { _, err := metrics.ExportConfigFromEnv(storageConfig.ProjectID, ""); return err },
+			"booking quota":            func() error { _, err := quota.ConfigFromEnv(); return err },
+			"API usage":                func() error { _, err := analytics.ConfigFromEnv(); return err },
+			"forecast":                 func() error { _, err := forecast.ConfigFromEnv(); return err },
+			"health checks":            func() error { _, err := health.ConfigFromEnv(); return err },
+			"worker pool":              func() error { _, err := workers.ConfigFromEnv(); return err },
+			"jobs":                     func() error { _, err := jobs.ConfigFromEnv(); return err },
+			"request recording":        func() error { _, err := recording.ConfigFromEnv(); return err },
+			"scheduling":               func() error { _, err := scheduling.PolicyFromEnv(); return err },
+			"public URL":               func() error { _, err := handlers.PublicURLFromEnv(); return err },
+			"warm-up":                  func() error { _, err := warmUpConfigFromEnv(); return err },
+			"WEATHER_PROVIDER":         func() error { _, err := services.NewWeatherProvider(os.Getenv("WEATHER_PROVIDER")); return err },
+			"FX_RATE_PROVIDER":         func() error { _, err := currency.NewRateProvider(os.Getenv("FX_RATE_PROVIDER")); return err },
+			"DOCUMENTS_CDN_URL":        checkCDNConfig,
+			"LOG_LEVEL":                func() error { _, err := debuglog.LevelFromEnv(); return err },
+			"CORS_ALLOWED_ORIGINS":     func() error { _, err := corsOriginsFromEnv(); return err },
+			"config source":            func() error { _, err := liveconfig.ConfigFromEnv(); return err },
+			"TENANTS_REFRESH_INTERVAL": func() error { _, err := tenants.RefreshIntervalFromEnv(); return err },
+			"RULES_REFRESH_INTERVAL":   func() error { _, err := rules.RefreshIntervalFromEnv(); return err },
+			"ROUTES_REFRESH_INTERVAL":  func() error { _, err := network.RefreshIntervalFromEnv(); return err },
+			"FARES_CACHE_TTL":          func() error { _, err := pricing.CacheTTLFromEnv(); return err },
+			"ENTRY_RULES":              func() error { _, err := entry.TableFromEnv(); return err },
+		}
+		for _, name := range durationSettings {
+			name := name
+			settings[name] = func() error {
+				if value := os.Getenv(name); value != "" {
+					if _, err := time.ParseDuration(value); err != nil {
+						return fmt.Errorf("invalid %s %q: %v", name, value, err)
+					}
+				}
+				return nil
+			}
+		}
+
+		names := make([]string, 0, len(settings))
+		for name := range settings {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		var problems []string
+		for _, name := range names {
+			if err := settings[name](); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+			}
+		}
+		if len(problems) > 0 {
+			return "", errors.New(strings.Join(problems, "\n"))
+		}
+		return fmt.Sprintf("%d settings valid, %s storage", len(names), storageConfig.Backend), nil
+	}
+}
+
+// checkCDNConfig checks the CDN URL signing settings when a CDN is configured
+func checkCDNConfig() error {
+	cdnURL := os.Getenv("DOCUMENTS_CDN_URL")
+	if cdnURL == "" {
+		return nil
+	}
+	_, err := services.NewCDNSigner(cdnURL, os.Getenv("DOCUMENTS_CDN_KEY_NAME"), os.Getenv("DOCUMENTS_CDN_KEY"))
+	return err
+}
+
+// usesGoogleCloud reports whether the configuration needs Google Cloud credentials
+func usesGoogleCloud(storageConfig services.StorageConfig) bool {
+	switch {
+	case storageConfig.Backend == services.BackendFirestore, storageConfig.Backend == services.BackendSpanner:
+		return true
+	case storageConfig.CloudSQLInstance != "":
+		return true
+	}
+	for _, name := range []string{"ATTACHMENTS_BUCKET", "DOCUMENTS_BUCKET", "BACKUP_BUCKET", "SELF_CHECK_SECRETS"} {
+		if os.Getenv(name) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// checkCredentials finds the application default credentials and fetches a token with them
+func checkCredentials(storageConfig services.StorageConfig) func(context.Context) (string, error) {
+	return func(ctx context.Context) (string, error) {
+		if !usesGoogleCloud(storageConfig) {
+			return "", selfcheck.Skip("no Google Cloud services configured")
+		}
+
+		var credentials *google.Credentials
+		var err error
+		if storageConfig.CredentialsPath != "" {
+			data, readErr := os.ReadFile(storageConfig.CredentialsPath)
+			if readErr != nil {
+				return "", fmt.Errorf("failed to read GOOGLE_APPLICATION_CREDENTIALS: %v", readErr)
+			}
+			credentials, err = google.CredentialsFromJSON(ctx, data, cloudPlatformScope)
+		} else {
+			credentials, err = google.FindDefaultCredentials(ctx, cloudPlatformScope)
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to find credentials: %v", err)
+		}
+		if _, err := credentials.TokenSource.Token(); err != nil {
+			return "", fmt.Errorf("failed to get an access token: %v", err)
+		}
+
+		source := "application default credentials"
+		if storageConfig.CredentialsPath != "" {
+			source = storageConfig.CredentialsPath
+		}
+		if storageConfig.ProjectID == "" && storageConfig.Backend == services.BackendFirestore {
+			return "", fmt.Errorf("GOOGLE_CLOUD_PROJECT is not set (credentials from %s belong to project %q)", source, credentials.ProjectID)
+		}
+		return fmt.Sprintf("access token from %s", source), nil
+	}
+}
+
+// checkStorage connects to the ticket storage and reads a ticket; for
+// Firestore it also checks the location of the database
+func checkStorage(storageConfig services.StorageConfig) func(context.Context) (string, error) {
+	return func(ctx context.Context) (string, error) {
+		repository, err := services.NewTicketRepository(storageConfig)
+		if err != nil {
+			return "", fmt.Errorf("failed to initialize %s storage: %v", storageConfig.Backend, err)
+		}
+		defer repository.Close()
+
+		if _, err := repository.ListTickets(ctx, 1); err != nil {
+			return "", fmt.Errorf("failed to read from %s storage: %v", storageConfig.Backend, err)
+		}
+		if storageConfig.Backend != services.BackendFirestore {
+			return fmt.Sprintf("%s storage readable", storageConfig.Backend), nil
+		}
+
+		location, err := services.CheckFirestoreRegion(ctx, storageConfig)
+		if err != nil {
+			return "", err
+		}
+		if location == "" {
+			location = "unknown location"
+		}
+		return fmt.Sprintf("Firestore database %s readable (%s)", storageConfig.FirestoreDatabase, location), nil
+	}
+}
+
+// checkFirestoreIndexes checks that the composite indexes the service queries need exist and are ready
+func checkFirestoreIndexes(storageConfig services.StorageConfig) func(context.Context) (string, error) {
+	return func(ctx context.Context) (string, error) {
+		if storageConfig.Backend != services.BackendFirestore {
+			return "", selfcheck.Skip("%s storage", storageConfig.Backend)
+		}
+
+		var opts []option.ClientOption
+		if storageConfig.CredentialsPath != "" {
+			opts = append(opts, option.WithCredentialsFile(storageConfig.CredentialsPath))
+		}
+		admin, err := firestoreadmin.NewService(ctx, opts...)
+		if err != nil {
+			return "", fmt.Errorf("failed to create Firestore admin client: %v", err)
+		}
+
+		database := fmt.Sprintf("projects/%s/databases/%s", storageConfig.ProjectID, storageConfig.FirestoreDatabase)
+		missing, err := docstore.MissingIndexes(ctx, admin, database, jobs.FirestoreIndexes)
+		if err != nil {
+			return "", err
+		}
+		if len(missing) > 0 {
+			problems := make([]string, 0, len(missing))
+			for index, reason := range missing {
+				problems = append(problems, fmt.Sprintf("%s: %s", index, reason))
+			}
+			sort.Strings(problems)
+			return "", fmt.Errorf("composite indexes not ready:\n%s", strings.Join(problems, "\n"))
+		}
+		return fmt.Sprintf("%d composite indexes ready", len(jobs.FirestoreIndexes)), nil
+	}
+}
+
+// secretVersion returns the resource name of a secret version: names without
+// a project are looked up in GOOGLE_CLOUD_PROJECT, at their latest version
+func secretVersion(projectID, secret string) string {
+	switch {
+	case strings.HasPrefix(secret, "projects/") && strings.Contains(secret, "/versions/"):
+		return secret
+	case strings.HasPrefix(secret, "projects/"):
+		return secret + "/versions/latest"
+	default:
+		return fmt.Sprintf("projects/%s/secrets/%s/versions/latest", projectID, secret)
+	}
+}
+
+// checkSecrets accesses each secret of SELF_CHECK_SECRETS, a comma-separated
+// list, without revealing the values
+func checkSecrets(storageConfig services.StorageConfig, secrets string) func(context.Context) (string, error) {
+	return func(ctx context.Context) (string, error) {
+		var names []string
+		for _, secret := range strings.Split(secrets, ",") {
+			if secret = strings.TrimSpace(secret); secret != "" {
+				names = append(names, secretVersion(storageConfig.ProjectID, secret))
+			}
+		}
+		if len(names) == 0 {
+			return "", selfcheck.Skip("SELF_CHECK_SECRETS not set")
+		}
+
+		var opts []option.ClientOption
+		if storageConfig.CredentialsPath != "" {
+			opts = append(opts, option.WithCredentialsFile(storageConfig.CredentialsPath))
+		}
+		client, err := secretmanager.NewService(ctx, opts...)
+		if err != nil {
+			return "", fmt.Errorf("failed to create Secret Manager client: %v", err)
+		}
+
+		var problems []string
+		for _, name := range names {
+			if _, err := client.Projects.Secrets.Versions.Access(name).Context(ctx).Do(); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+			}
+		}
+		if len(problems) > 0 {
+			return "", errors.New(strings.Join(problems, "\n"))
+		}
+		return fmt.Sprintf("%d secrets accessible", len(names)), nil
+	}
+}
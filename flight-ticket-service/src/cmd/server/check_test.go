@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"flight-ticket-service/src/services"
+)
+
+func TestSelfCheckMemoryBackend(t *testing.T) {
+	for _, name := range []string{"ATTACHMENTS_BUCKET", "DOCUMENTS_BUCKET", "BACKUP_BUCKET", "SELF_CHECK_SECRETS"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("WEATHER_PROVIDER", "static")
+	config := services.StorageConfig{Backend: services.BackendMemory}
+
+	var out bytes.Buffer
+	if !runSelfCheck(config, &out) {
+		t.Fatalf("Expected the self-check to pass:\n%s", out.String())
+	}
+	for _, want := range []string{"PASS  configuration", "SKIP  credentials", "PASS  storage", "SKIP  firestore indexes", "SKIP  secret manager", "Self-check passed"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Report lacks %q:\n%s", want, out.String())
+		}
+	}
+
+	t.Setenv("WEATHER_PROVIDER", "nowhere")
+	t.Setenv("FX_CACHE_TTL", "an hour")
+	out.Reset()
+	if runSelfCheck(config, &out) {
+		t.Fatalf("Expected the self-check to fail:\n%s", out.String())
+	}
+	for _, want := range []string{"FAIL  configuration", "unknown weather provider: nowhere", `invalid FX_CACHE_TTL "an hour"`, "Self-check failed: 1 of 5 checks failed"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Report lacks %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestSecretVersion(t *testing.T) {
+	tests := map[string]string{
+		"qr-signing-key":                             "projects/demo/secrets/qr-signing-key/versions/latest",
+		"projects/other/secrets/api-keys":            "projects/other/secrets/api-keys/versions/latest",
+		"projects/other/secrets/api-keys/versions/3": "projects/other/secrets/api-keys/versions/3",
+	}
+	for secret, want := range tests {
+		if got := secretVersion("demo", secret); got != want {
+			t.Errorf("secretVersion(%q) = %q, want %q", secret, got, want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"flight-ticket-service/src/debuglog"
+	"flight-ticket-service/src/handlers"
+
+	"github.com/go-chi/cors"
+)
+
+// corsPolicy answers CORS requests for origins that can change at runtime
+type corsPolicy struct {
+	current atomic.Pointer[cors.Cors]
+}
+
+// newCORSPolicy creates a policy allowing the given origins
+func newCORSPolicy(origins []string) *corsPolicy {
+	p := &corsPolicy{}
+	p.SetOrigins(origins)
+	return p
+}
+
+// SetOrigins replaces the allowed origins; requests in flight keep the previous ones
+func (p *corsPolicy) SetOrigins(origins []string) {
+	p.current.Store(cors.New(cors.Options{
+		AllowedOrigins:   origins,
+		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "If-Modified-Since", "If-None-Match", "X-CSRF-Token", "X-API-Key", handlers.SandboxHeader, handlers.LockTokenHeader, debuglog.Header},
+		ExposedHeaders:   []string{"ETag", "Last-Modified", "Link", handlers.SandboxHeader, handlers.DryRunHeader, debuglog.TraceHeader},
+		AllowCredentials: false,
+		MaxAge:           300, // Maximum value not ignored by any of major browsers
+	}))
+}
+
+// Handler applies the current origins to each request
+func (p *corsPolicy) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p.current.Load().Handler(next).ServeHTTP(w, r)
+	})
+}
+
+// parseCORSOrigins parses a CORS_ALLOWED_ORIGINS value: "*" (the default) or
+// a comma-separated list of origins such as https://app.example.com
+func parseCORSOrigins(value string) ([]string, error) {
+	var origins []string
+	for _, origin := range strings.Split(value, ",") {
+		origin = strings.TrimSpace(origin)
+		if origin == "" {
+			continue
+		}
+		if origin != "*" {
+			parsed, err := url.Parse(origin)
+			if err != nil || parsed.Scheme == "" || parsed.Host == "" || parsed.Path != "" {
+				return nil, fmt.Errorf("invalid origin %q: must be * or scheme://host[:port]", origin)
+			}
+		}
+		origins = append(origins, origin)
+	}
+	if len(origins) == 0 {
+		return []string{"*"}, nil
+	}
+	return origins, nil
+}
+
+// corsOriginsFromEnv reads CORS_ALLOWED_ORIGINS
+func corsOriginsFromEnv() ([]string, error) {
+	origins, err := parseCORSOrigins(os.Getenv("CORS_ALLOWED_ORIGINS"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CORS_ALLOWED_ORIGINS: %v", err)
+	}
+	return origins, nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"flight-ticket-service/src/models"
+)
+
+func TestDryRunDoesNotStore(t *testing.T) {
+	router := newTestRouter(t)
+	send := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("X-API-Key", "fuzz-key")
+		req.Header.Set("Origin", "https://agent.example.com")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	stored := func() *models.FlightTicket {
+		var ticket models.FlightTicket
+		json.NewDecoder(send(http.MethodGet, "/ticket/"+seededTicket, "").Body).Decode(&ticket)
+		return &ticket
+	}
+
+	rec := send(http.MethodPost, "/ticket?dry_run=true", `{"origin": "JFK", "destination": "LAX", "departure_date": "2030-12-25", "departure_time": "14:30", "passengers": 2}`)
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Dry-Run") != "true" {
+		t.Fatalf("Expected a 200 dry run, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if exposed := rec.Header().Get("Access-Control-Expose-Headers"); !strings.Contains(exposed, "X-Dry-Run") {
+		t.Errorf("Expected X-Dry-Run exposed to browsers, got %q", exposed)
+	}
+	var created models.FlightTicket
+	json.NewDecoder(rec.Body).Decode(&created)
+	if created.Price == nil || created.Passengers != 2 {
+		t.Errorf("Expected a priced ticket, got %+v", created)
+	}
+	if rec := send(http.MethodGet, "/ticket/"+created.ConfirmationID, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected the dry-run ticket not to be stored, got %d", rec.Code)
+	}
+	if rec := send(http.MethodPost, "/ticket?dry_run=true", `{"origin": "JFK"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected a dry run to validate, got %d", rec.Code)
+	}
+
+	rec = send(http.MethodPut, "/ticket/"+seededTicket+"?dry_run=1", `{"passengers": 3}`)
+	var updated models.FlightTicket
+	json.NewDecoder(rec.Body).Decode(&updated)
+	if rec.Code != http.StatusOK || updated.Passengers != 3 {
+		t.Errorf("Expected the updated ticket, got %d %+v", rec.Code, updated)
+	}
+	if ticket := stored(); ticket.Passengers == 3 {
+		t.Error("Expected the dry-run update not to be stored")
+	}
+	if rec := send(http.MethodPut, "/ticket/ZZZZZZ?dry_run=true", `{"passengers": 3}`); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown ticket, got %d", rec.Code)
+	}
+
+	if rec := send(http.MethodDelete, "/ticket/"+seededTicket+"?dry_run=true", ""); rec.Code != http.StatusOK {
+		t.Errorf("Expected 200, got %d", rec.Code)
+	}
+	if ticket := stored(); ticket.Status == "CANCELLED" {
+		t.Error("Expected the dry-run cancellation not to be stored")
+	}
+	if rec := send(http.MethodDelete, "/ticket/"+seededTicket+"?dry_run=maybe", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid dry_run, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+func TestFareCalendar(t *testing.T) {
+	router := newTestRouter(t)
+	send := func(target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("X-API-Key", "fuzz-key")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	month := time.Now().UTC().AddDate(0, 2, 0).Format("2006-01")
+
+	rec := send("/fares/calendar?origin=jfk&destination=LAX&month=" + month)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if cacheControl := rec.Header().Get("Cache-Control"); !strings.HasPrefix(cacheControl, "public, max-age=") {
+		t.Errorf("Expected a public Cache-Control, got %q", cacheControl)
+	}
+	var calendar models.FareCalendarResponse
+	json.NewDecoder(rec.Body).Decode(&calendar)
+	if calendar.Origin != "JFK" || calendar.Month != month || len(calendar.Days) < 28 || calendar.Cheapest == nil {
+		t.Fatalf("Expected a full month of fares, got %+v", calendar)
+	}
+
+	rec = send("/fares/calendar?origin=JFK&destination=LAX&currency=EUR&month=" + month)
+	var converted models.FareCalendarResponse
+	json.NewDecoder(rec.Body).Decode(&converted)
+	if rec.Code != http.StatusOK || converted.Currency != "EUR" || converted.Days[0].Fare == calendar.Days[0].Fare {
+		t.Errorf("Expected fares in EUR, got %d: %+v", rec.Code, converted)
+	}
+
+	for _, target := range []string{
+		"/fares/calendar?origin=JFK&destination=JFK&month=" + month,
+		"/fares/calendar?origin=JFK&destination=LAX&month=August",
+		"/fares/calendar?origin=JFK&destination=LAX&month=2020-01",
+		"/fares/calendar?origin=JFK&destination=LAX&currency=EURO&month=" + month,
+	} {
+		if rec := send(target); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", target, rec.Code)
+		}
+	}
+}
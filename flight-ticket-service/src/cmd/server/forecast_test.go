@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+func TestDemandForecast(t *testing.T) {
+	router := newTestRouter(t)
+	send := func(target, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("X-API-Key", key)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := send("/admin/forecast", "desk-key"); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for an agent, got %d", rec.Code)
+	}
+	for _, query := range []string{"history=6", "history=32", "horizon=0", "horizon=15", "limit=abc", "route=JFK", "route=JFK-LAXX"} {
+		if rec := send("/admin/forecast?"+query, "fuzz-key"); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", query, rec.Code)
+		}
+	}
+
+	rec := send("/admin/forecast?route=jfk-lax&history=14&horizon=3", "fuzz-key")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var forecast models.DemandForecast
+	json.NewDecoder(rec.Body).Decode(&forecast)
+	today := time.Now().UTC().Format("2006-01-02")
+	if forecast.Provider != "static" || forecast.Horizon != 3 || forecast.HistoryTo != time.Now().UTC().AddDate(0, 0, -1).Format("2006-01-02") {
+		t.Errorf("Unexpected forecast %+v", forecast)
+	}
+	if len(forecast.Routes) != 1 || forecast.Routes[0].Route != "JFK-LAX" || len(forecast.Routes[0].History) != 14 ||
+		len(forecast.Routes[0].Forecast) != 3 || forecast.Routes[0].Forecast[0].Date != today {
+		t.Errorf("Unexpected routes %+v", forecast.Routes)
+	}
+}
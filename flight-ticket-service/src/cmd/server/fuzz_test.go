@@ -0,0 +1,483 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/analytics"
+	"flight-ticket-service/src/auth"
+	"flight-ticket-service/src/currency"
+	"flight-ticket-service/src/entry"
+	"flight-ticket-service/src/featureflags"
+	"flight-ticket-service/src/forecast"
+	"flight-ticket-service/src/handlers"
+	"flight-ticket-service/src/health"
+	"flight-ticket-service/src/jobs"
+	"flight-ticket-service/src/liveconfig"
+	"flight-ticket-service/src/maintenance"
+	"flight-ticket-service/src/metrics"
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/network"
+	"flight-ticket-service/src/pricing"
+	"flight-ticket-service/src/quota"
+	"flight-ticket-service/src/risk"
+	"flight-ticket-service/src/rules"
+	"flight-ticket-service/src/scheduling"
+	"flight-ticket-service/src/seats"
+	"flight-ticket-service/src/services"
+	"flight-ticket-service/src/tenants"
+	"flight-ticket-service/src/travelers"
+	"flight-ticket-service/src/upgrades"
+	"flight-ticket-service/src/vouchers"
+	"flight-ticket-service/src/workers"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// The harness reads docs/swagger.json and sends schema-valid and
+// schema-invalid requests for every documented operation to the router backed
+// by the in-memory repository. It fails when a handler panics, answers an
+// error without a JSON models.ErrorResponse body, or returns a 5xx for a
+// request that storage could serve.
+//
+//	go test ./src/cmd/server -run FuzzAPI               # spec-derived seeds only
+//	go test ./src/cmd/server -fuzz FuzzAPI -fuzztime 1m # mutate them further
+
+const (
+	specPath     = "../../../docs/swagger.json"
+	seededTicket = "FUZZ01"
+)
+
+type apiSpec struct {
+	Paths       map[string]map[string]specOperation `json:"paths"`
+	Definitions map[string]*specSchema              `json:"definitions"`
+}
+
+type specOperation struct {
+	Parameters []specParam `json:"parameters"`
+	Produces   []string    `json:"produces"`
+}
+
+type specParam struct {
+	In     string      `json:"in"`
+	Name   string      `json:"name"`
+	Type   string      `json:"type"`
+	Schema *specSchema `json:"schema"`
+}
+
+type specSchema struct {
+	Ref        string                 `json:"$ref"`
+	Type       string                 `json:"type"`
+	Required   []string               `json:"required"`
+	Properties map[string]*specSchema `json:"properties"`
+	Items      *specSchema            `json:"items"`
+	Enum       []interface{}          `json:"enum"`
+	Example    interface{}            `json:"example"`
+}
+
+// operation is a documented endpoint with its parameters resolved
+type operation struct {
+	method    string
+	path      string
+	pathParam string
+	query     string
+	queryType string
+	body      *specSchema
+	json      bool
+}
+
+func loadOperations(t testing.TB) ([]operation, *apiSpec) {
+	data, err := os.ReadFile(specPath)
+	if err != nil {
+		t.Fatalf("Failed to read OpenAPI spec: %v", err)
+	}
+	var spec apiSpec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatalf("Failed to parse OpenAPI spec: %v", err)
+	}
+
+	var ops []operation
+	for path, methods := range spec.Paths {
+		for method, specOp := range methods {
+			op := operation{method: strings.ToUpper(method), path: path}
+			for _, param := range specOp.Parameters {
+				switch param.In {
+				case "path":
+					op.pathParam = param.Name
+				case "query":
+					op.query, op.queryType = param.Name, param.Type
+				case "body":
+					op.body = spec.resolve(param.Schema)
+				}
+			}
+			for _, produces := range specOp.Produces {
+				op.json = op.json || produces == "application/json"
+			}
+			ops = append(ops, op)
+		}
+	}
+	// Map iteration is random; the fuzzer's operation index needs a stable order
+	sort.Slice(ops, func(i, j int) bool {
+		return ops[i].path+" "+ops[i].method < ops[j].path+" "+ops[j].method
+	})
+	return ops, &spec
+}
+
+func (s *apiSpec) resolve(schema *specSchema) *specSchema {
+	if schema != nil && schema.Ref != "" {
+		return s.resolve(s.Definitions[strings.TrimPrefix(schema.Ref, "#/definitions/")])
+	}
+	return schema
+}
+
+// validValue builds a schema-valid value from examples, enums and types
+func (s *apiSpec) validValue(schema *specSchema) interface{} {
+	schema = s.resolve(schema)
+	switch {
+	case schema == nil:
+		return nil
+	case schema.Example != nil:
+		return schema.Example
+	case len(schema.Enum) > 0:
+		return schema.Enum[0]
+	}
+
+	switch schema.Type {
+	case "object":
+		object := make(map[string]interface{})
+		for name, property := range schema.Properties {
+			object[name] = s.validValue(property)
+		}
+		return object
+	case "array":
+		return []interface{}{s.validValue(schema.Items)}
+	case "integer":
+		return 1
+	case "number":
+		return 1.5
+	case "boolean":
+		return true
+	default:
+		return "A"
+	}
+}
+
+// invalidValues are type and range violations for a property of the given type
+func invalidValues(schemaType string) []interface{} {
+	common := []interface{}{nil, map[string]interface{}{"nested": true}, []interface{}{1, "2"}}
+	switch schemaType {
+	case "integer", "number":
+		return append(common, "not-a-number", -1, 0, 1e300, 9223372036854775807, 1.5)
+	case "boolean":
+		return append(common, "yes", 1)
+	default:
+		return append(common, 12345, true, "", " ", strings.Repeat("A", 10000), "\x00‮<script>", "9999-99-99", "25:61", "😀😀😀")
+	}
+}
+
+// bodies returns one schema-valid body followed by schema-invalid variants
+func (s *apiSpec) bodies(schema *specSchema) [][]byte {
+	valid := s.validValue(schema)
+	encode := func(v interface{}) []byte {
+		data, _ := json.Marshal(v)
+		return data
+	}
+
+	bodies := [][]byte{
+		encode(valid),
+		nil,
+		[]byte("{"),
+		[]byte("null"),
+		[]byte("[]"),
+		[]byte(`"ticket"`),
+		[]byte("{}"),
+		[]byte("\xff\xfe"),
+	}
+
+	object, ok := valid.(map[string]interface{})
+	if !ok {
+		return bodies
+	}
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	withField := func(name string, value interface{}) []byte {
+		mutated := make(map[string]interface{}, len(object)+1)
+		for k, v := range object {
+			mutated[k] = v
+		}
+		mutated[name] = value
+		return encode(mutated)
+	}
+	for _, name := range names {
+		// Missing field
+		mutated := make(map[string]interface{}, len(object))
+		for k, v := range object {
+			if k != name {
+				mutated[k] = v
+			}
+		}
+		bodies = append(bodies, encode(mutated))
+
+		for _, value := range invalidValues(s.resolve(schema).Properties[name].Type) {
+			bodies = append(bodies, withField(name, value))
+		}
+	}
+	return append(bodies, withField("unknown_field", "x"))
+}
+
+var pathValues = []string{seededTicket, "ZZZZZZ", "", "fuzz01", "a/b", "../admin/stats", "%00", "' OR 1=1 --", "😀", strings.Repeat("X", 2048)}
+
+var queryValues = map[string][]string{
+	"integer": {"10", "0", "-1", "abc", "99999999999999999999", "1.5", ""},
+	"":        {"x", ""},
+}
+
+// newTestRouter builds the production router without panic recovery, backed
+// by the in-memory repository and static providers
+func newTestRouter(t testing.TB) http.Handler {
+	log.SetOutput(io.Discard)
+	middleware.DefaultLogger = middleware.RequestLogger(&middleware.DefaultLogFormatter{Logger: log.New(io.Discard, "", 0)})
+
+	repository := services.NewMemoryRepository()
+	departure := time.Now().AddDate(0, 1, 0).UTC().Truncate(24 * time.Hour)
+	err := repository.CreateTicket(context.Background(), &models.FlightTicket{
+		ConfirmationID: seededTicket,
+		Origin:         "JFK",
+		Destination:    "LAX",
+		DepartureDate:  departure,
+		DepartureTime:  departure.Add(14*time.Hour + 30*time.Minute),
+		FlightNumber:   "AA1234",
+		Passengers:     2,
+		Status:         "CONFIRMED",
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to seed ticket: %v", err)
+	}
+
+	keyStore, err := auth.ParseKeys("fuzz:admin:fuzz-key,desk:agent:desk-key")
+	if err != nil {
+		t.Fatalf("Failed to parse keys: %v", err)
+	}
+	usage, err := services.NewUsageTracker(services.BackendMemory, "", prometheus.NewRegistry())
+	if err != nil {
+		t.Fatalf("Failed to create usage tracker: %v", err)
+	}
+	slo, err := metrics.NewTracker(metrics.SLOs, prometheus.NewRegistry())
+	if err != nil {
+		t.Fatalf("Failed to create SLO tracker: %v", err)
+	}
+	rates, err := currency.NewRateProvider("static")
+	if err != nil {
+		t.Fatalf("Failed to create rate provider: %v", err)
+	}
+	weather, err := services.NewWeatherProvider("static")
+	if err != nil {
+		t.Fatalf("Failed to create weather provider: %v", err)
+	}
+	healthTracker := health.NewTracker(10)
+	healthTracker.Watch(context.Background(), health.Config{}, storageCheck(repository))
+	healthTracker.Register(weatherDependency)
+	weather = trackedWeatherProvider{WeatherProvider: weather, tracker: healthTracker}
+	qrService, err := services.NewQRService("fuzz-signing-key")
+	if err != nil {
+		t.Fatalf("Failed to create QR service: %v", err)
+	}
+	maintenanceSwitch := maintenance.New(maintenance.ModeOff, "")
+	limiter := quota.New(quota.Config{}, quota.NewMemoryCounter())
+	scheduler := scheduling.NewScheduler(scheduling.DefaultPolicy)
+	flags := featureflags.New(map[string]bool{featureflags.Search: true})
+	cors := newCORSPolicy([]string{"*"})
+	tenantCache := tenants.NewRegistry(tenants.NewMemoryStore())
+	ruleEngine := rules.NewEngine(rules.NewMemoryStore())
+	converter := currency.NewConverter(rates, time.Hour)
+	travelerStore := travelers.NewMemoryStore()
+	entryRules, err := entry.NewTable("")
+	if err != nil {
+		t.Fatalf("Failed to create entry rules: %v", err)
+	}
+	seatStore := seats.NewMemoryStore()
+	riskConfig, err := risk.ConfigFromEnv()
+	if err != nil {
+		t.Fatalf("Failed to read risk scoring settings: %v", err)
+	}
+	ticketOptions := handlers.TicketHandlerOptions{
+		Converter:  converter,
+		Scheduler:  scheduler,
+		Rules:      ruleEngine,
+		Travelers:  travelerStore,
+		EntryRules: entryRules,
+		Seats:      seatStore,
+		Risk:       risk.New(riskConfig),
+	}
+	tickets := handlers.NewTicketHandler(repository, ticketOptions)
+	sandboxOptions := ticketOptions
+	sandboxOptions.Seats, sandboxOptions.Risk = seats.NewMemoryStore(), nil
+	sandboxTickets := handlers.NewTicketHandler(services.NewSandboxRepository(services.NewMemoryRepository(), time.Hour), sandboxOptions)
+	pool := workers.New(workers.Config{Workers: 2, QueueSize: 8})
+	t.Cleanup(pool.Close)
+	jobManager := jobs.NewManager(jobs.NewMemoryStore(), jobs.Config{Workers: 1, PollInterval: 10 * time.Millisecond})
+	voucherStore := vouchers.NewMemoryStore()
+	registerJobKinds(jobManager, repository, nil, vouchers.NewIssuer(voucherStore, ruleEngine), seatStore)
+	jobManager.Start()
+	t.Cleanup(jobManager.Stop)
+
+	apiUsage := analytics.NewRecorder(analytics.NewMemoryStore(), time.Minute)
+	return newRouter(routes{
+		keyStore:      keyStore,
+		usage:         usage,
+		apiUsage:      apiUsage,
+		budget:        services.OperationBudget{Reads: services.DefaultRequestMaxReads, Writes: services.DefaultRequestMaxWrites},
+		slo:           slo,
+		maintenance:   maintenanceSwitch,
+		flags:         flags,
+		quota:         limiter,
+		tickets:       tickets,
+		advisories:    handlers.NewAdvisoryHandler(repository, services.NewWeatherService(weather, time.Hour)),
+		qr:            handlers.NewQRHandler(repository, qrService, nil),
+		checkIn:       handlers.NewCheckInHandler(repository, scheduler, travelerStore, entryRules),
+		notifications: handlers.NewNotificationHandler(repository),
+		notes:         handlers.NewNoteHandler(repository),
+		views:         handlers.NewViewHandler(repository, tickets),
+		manifests:     handlers.NewManifestHandler(repository, nil, pool),
+		seatHolds:     handlers.NewSeatHandler(seatStore),
+		upgrades:      handlers.NewUpgradeHandler(repository, upgrades.NewMemoryStore(), upgrades.DefaultCapacity, nil),
+		rebooking:     handlers.NewRebookingHandler(tickets, network.New(repository, 0), nil),
+		vouchers:      handlers.NewVoucherHandler(repository, voucherStore, nil),
+		admin:         handlers.NewAdminHandler(usage, flags, maintenanceSwitch),
+		delays:        handlers.NewFlightDelayHandler(repository, scheduler, maintenanceSwitch, nil),
+		inventory:     handlers.NewInventoryHandler(repository, maintenanceSwitch),
+		quotas:        handlers.NewQuotaHandler(limiter),
+		bookingStats:  handlers.NewBookingStatsHandler(repository),
+		usageReport:   handlers.NewAPIUsageHandler(apiUsage),
+		forecasts:     handlers.NewForecastHandler(repository, forecast.StaticForecaster{}),
+		adminUI:       handlers.NewAdminUIHandler(repository, keyStore, maintenanceSwitch, seatStore),
+		jobs:          handlers.NewJobHandler(pool, jobManager),
+		bulkCancel:    handlers.NewBulkCancelHandler(repository, jobManager),
+		quarantine:    handlers.NewQuarantineHandler(repository),
+		review:        handlers.NewReviewHandler(tickets, nil),
+		locks:         handlers.NewLockHandler(repository),
+		health:        handlers.NewHealthHandler(healthTracker),
+		config:        handlers.NewConfigHandler(liveconfig.New(reloadableSettings(limiter, flags, cors)...)),
+		cors:          cors,
+		tenantCache:   tenantCache,
+		tenants:       handlers.NewTenantHandler(tenantCache),
+		rules:         handlers.NewRuleHandler(ruleEngine),
+		routeSearch:   handlers.NewRouteHandler(network.New(repository, 0), ruleEngine),
+		fares:         handlers.NewFareHandler(pricing.NewCalendar(converter, time.Hour)),
+		travelers:     handlers.NewTravelerHandler(travelerStore),
+
+		sandboxTickets: sandboxTickets,
+	})
+}
+
+// serve sends a request and checks for panics, server errors and the error format
+func serve(t *testing.T, router http.Handler, op operation, pathValue, queryValue string, body []byte) {
+	target := op.path
+	if op.pathParam != "" {
+		target = strings.Replace(target, "{"+op.pathParam+"}", url.PathEscape(pathValue), 1)
+	}
+	if op.query != "" {
+		target += "?" + url.Values{op.query: {queryValue}}.Encode()
+	}
+
+	// Server requests always have a body, as http.NoBody when empty
+	var reader io.Reader = http.NoBody
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(op.method, target, reader)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	label := fmt.Sprintf("%s %s body=%.200q", op.method, req.URL.RequestURI(), body)
+
+	rec := httptest.NewRecorder()
+	func() {
+		defer func() {
+			if p := recover(); p != nil {
+				t.Fatalf("%s panicked: %v\n%s", label, p, debug.Stack())
+			}
+		}()
+		router.ServeHTTP(rec, req)
+	}()
+
+	// The in-memory store never fails, so a 5xx for the seeded ticket is a handler bug.
+	// Unknown tickets may still yield 500 on update and cancel, as documented.
+	if rec.Code >= 500 && (op.pathParam == "" || pathValue == seededTicket) {
+		t.Errorf("%s: status %d: %s", label, rec.Code, rec.Body.String())
+	}
+	if rec.Code >= 400 {
+		var errResp models.ErrorResponse
+		if !strings.HasPrefix(rec.Header().Get("Content-Type"), "application/json") ||
+			json.Unmarshal(rec.Body.Bytes(), &errResp) != nil || errResp.Error == "" {
+			t.Errorf("%s: status %d without a JSON error response: %q (%s)",
+				label, rec.Code, rec.Body.String(), rec.Header().Get("Content-Type"))
+		}
+	} else if op.json && !json.Valid(rec.Body.Bytes()) {
+		t.Errorf("%s: status %d with invalid JSON: %q", label, rec.Code, rec.Body.String())
+	}
+}
+
+func FuzzAPI(f *testing.F) {
+	ops, spec := loadOperations(f)
+	if len(ops) == 0 {
+		f.Fatal("OpenAPI spec has no operations")
+	}
+
+	// Seeds: every operation with each path and query value and, for
+	// operations with a body, every body variant with the seeded ticket
+	for i, op := range ops {
+		queries := queryValues[op.queryType]
+		if op.query == "" {
+			queries = []string{""}
+		}
+		paths := []string{seededTicket}
+		if op.pathParam != "" {
+			paths = pathValues
+		}
+		for _, path := range paths {
+			for _, query := range queries {
+				var body []byte
+				if op.body != nil {
+					body = spec.bodies(op.body)[0]
+				}
+				f.Add(i, path, query, body)
+			}
+		}
+		if op.body != nil {
+			for _, body := range spec.bodies(op.body) {
+				f.Add(i, seededTicket, "", body)
+			}
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, index int, pathValue, queryValue string, body []byte) {
+		if index < 0 {
+			index = -index
+		}
+		// A fresh store per input keeps failures reproducible and stops
+		// created tickets from piling up in list responses
+		serve(t, newTestRouter(t), ops[index%len(ops)], pathValue, queryValue, body)
+	})
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"flight-ticket-service/src/changefeed"
+	"flight-ticket-service/src/currency"
+	"flight-ticket-service/src/health"
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/services"
+)
+
+// Dependency names in /health/details
+const (
+	storageDependency  = "storage"
+	weatherDependency  = "weather"
+	currencyDependency = "exchange_rates"
+)
+
+// storageCheck reads a ticket, like the warm-up
+func storageCheck(repository services.TicketRepository) health.Check {
+	return health.Check{Name: storageDependency, Run: func(ctx context.Context) error {
+		_, err := repository.ListTickets(ctx, 1)
+		return err
+	}}
+}
+
+// trackedWeatherProvider records the results of weather lookups
+type trackedWeatherProvider struct {
+	services.WeatherProvider
+	tracker *health.Tracker
+}
+
+func (p trackedWeatherProvider) GetWeather(ctx context.Context, airport string, loc services.AirportLocation, at time.Time) (*models.AirportWeather, error) {
+	start := time.Now()
+	weather, err := p.WeatherProvider.GetWeather(ctx, airport, loc, at)
+	p.tracker.Record(weatherDependency, start, err)
+	return weather, err
+}
+
+// trackedRateProvider records the results of exchange rate lookups
+type trackedRateProvider struct {
+	currency.RateProvider
+	tracker *health.Tracker
+}
+
+func (p trackedRateProvider) Rates(ctx context.Context, base string) (map[string]float64, time.Time, error) {
+	start := time.Now()
+	rates, published, err := p.RateProvider.Rates(ctx, base)
+	p.tracker.Record(currencyDependency, start, err)
+	return rates, published, err
+}
+
+// trackedSink records the results of event deliveries under the sink's name
+type trackedSink struct {
+	changefeed.Sink
+	tracker *health.Tracker
+}
+
+func (s trackedSink) Publish(ctx context.Context, event *changefeed.ChangeEvent, body []byte) error {
+	start := time.Now()
+	err := s.Sink.Publish(ctx, event, body)
+	s.tracker.Record(s.Name(), start, err)
+	return err
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"flight-ticket-service/src/health"
+)
+
+func TestHealthDetailsTracksDependencies(t *testing.T) {
+	router := newTestRouter(t)
+	details := func() health.Details {
+		req := httptest.NewRequest(http.MethodGet, "/health/details", nil)
+		req.Header.Set("X-API-Key", "fuzz-key")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var details health.Details
+		json.NewDecoder(rec.Body).Decode(&details)
+		return details
+	}
+	statuses := func(details health.Details) map[string]health.Dependency {
+		byName := make(map[string]health.Dependency)
+		for _, dependency := range details.Dependencies {
+			byName[dependency.Name] = dependency
+		}
+		return byName
+	}
+
+	// Periodic checks are off in tests, and nothing has called the weather provider
+	before := statuses(details())
+	if before[storageDependency].Status != health.StatusUnknown || before[weatherDependency].Status != health.StatusUnknown {
+		t.Fatalf("Expected unknown dependencies, got %+v", before)
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ticket/"+seededTicket+"/advisories", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected advisories, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	after := details()
+	weather := statuses(after)[weatherDependency]
+	if after.Status != health.StatusHealthy || weather.Status != health.StatusUp || weather.Checks == 0 || weather.LastSuccess == nil {
+		t.Errorf("Expected the weather provider to be up after a lookup, got %s %+v", after.Status, weather)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health/details", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without a key, got %d", rec.Code)
+	}
+}
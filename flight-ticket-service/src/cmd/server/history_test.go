@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+func TestGetTicketAsOf(t *testing.T) {
+	router := newTestRouter(t)
+	send := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("X-API-Key", "fuzz-key")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	getAsOf := func(at time.Time) *httptest.ResponseRecorder {
+		return send(http.MethodGet, "/ticket/"+seededTicket+"?as_of="+url.QueryEscape(at.Format(time.RFC3339Nano)), "")
+	}
+
+	before := time.Now()
+	time.Sleep(time.Millisecond)
+	if rec := send(http.MethodPut, "/ticket/"+seededTicket, `{"passengers": 3}`); rec.Code != http.StatusOK {
+		t.Fatalf("Failed to update ticket: %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec := getAsOf(before)
+	var ticket models.FlightTicket
+	json.NewDecoder(rec.Body).Decode(&ticket)
+	if rec.Code != http.StatusOK || ticket.Passengers != 2 {
+		t.Errorf("Expected the ticket before the update, got %d %+v", rec.Code, ticket)
+	}
+
+	rec = getAsOf(time.Now())
+	ticket = models.FlightTicket{}
+	json.NewDecoder(rec.Body).Decode(&ticket)
+	if rec.Code != http.StatusOK || ticket.Passengers != 3 {
+		t.Errorf("Expected the updated ticket, got %d %+v", rec.Code, ticket)
+	}
+
+	if rec := getAsOf(before.Add(-time.Hour)); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 before the ticket was created, got %d", rec.Code)
+	}
+	if rec := send(http.MethodGet, "/ticket/"+seededTicket+"?as_of=yesterday", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid as_of, got %d", rec.Code)
+	}
+}
+
+func TestTicketHistoryDiff(t *testing.T) {
+	router := newTestRouter(t)
+	send := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("X-API-Key", "fuzz-key")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	diffURL := "/ticket/" + seededTicket + "/history/diff"
+
+	for _, body := range []string{`{"passengers": 3}`, `{"labels": {"campaign": "spring"}}`} {
+		if rec := send(http.MethodPut, "/ticket/"+seededTicket, body); rec.Code != http.StatusOK {
+			t.Fatalf("Failed to update ticket: %d %s", rec.Code, rec.Body.String())
+		}
+	}
+
+	// Without revisions, the last change is compared
+	rec := send(http.MethodGet, diffURL, "")
+	var diff models.TicketDiff
+	json.NewDecoder(rec.Body).Decode(&diff)
+	if rec.Code != http.StatusOK || diff.To.Type != models.RevisionUpdated || diff.From.Number != diff.To.Number-1 {
+		t.Fatalf("Expected the last revision compared to the one before, got %d %+v", rec.Code, diff)
+	}
+	if diff.Count != 2 || diff.Changes[0].Field != "labels.campaign" || diff.Changes[0].To != "spring" || diff.Changes[1].Field != "updated_at" {
+		t.Errorf("Expected the relabeling, got %+v", diff.Changes)
+	}
+
+	last := diff.To.Number
+	rec = send(http.MethodGet, diffURL+"?from="+strconv.Itoa(last-2)+"&to="+strconv.Itoa(last), "")
+	diff = models.TicketDiff{}
+	json.NewDecoder(rec.Body).Decode(&diff)
+	changed := make(map[string]models.FieldChange)
+	for _, change := range diff.Changes {
+		changed[change.Field] = change
+	}
+	if passengers := changed["passengers"]; rec.Code != http.StatusOK || passengers.From != float64(2) || passengers.To != float64(3) {
+		t.Errorf("Expected the passenger change across both updates, got %d %+v", rec.Code, diff.Changes)
+	}
+
+	for query, code := range map[string]int{"?from=-1": http.StatusBadRequest, "?to=abc": http.StatusBadRequest, "?to=" + strconv.Itoa(last+1): http.StatusNotFound} {
+		if rec := send(http.MethodGet, diffURL+query, ""); rec.Code != code {
+			t.Errorf("Expected %d for %s, got %d", code, query, rec.Code)
+		}
+	}
+	if rec := send(http.MethodGet, "/ticket/NOPE00/history/diff", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown ticket, got %d", rec.Code)
+	}
+}
+
+func TestUndoTicket(t *testing.T) {
+	router := newTestRouter(t)
+	send := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("X-API-Key", "fuzz-key")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	undoURL := "/ticket/" + seededTicket + "/undo"
+
+	if rec := send(http.MethodPut, "/ticket/"+seededTicket, `{"passengers": 3, "labels": {"campaign": "spring"}}`); rec.Code != http.StatusOK {
+		t.Fatalf("Failed to update ticket: %d %s", rec.Code, rec.Body.String())
+	}
+	var diff models.TicketDiff
+	json.NewDecoder(send(http.MethodGet, "/ticket/"+seededTicket+"/history/diff", "").Body).Decode(&diff)
+	latest := diff.To.Number
+
+	if rec := send(http.MethodPost, undoURL+"?revision="+strconv.Itoa(latest-1), ""); rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for a stale revision, got %d", rec.Code)
+	}
+	if rec := send(http.MethodPost, undoURL+"?revision=abc", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid revision, got %d", rec.Code)
+	}
+
+	rec := send(http.MethodPost, undoURL+"?revision="+strconv.Itoa(latest), "")
+	var ticket models.FlightTicket
+	json.NewDecoder(rec.Body).Decode(&ticket)
+	if rec.Code != http.StatusOK || ticket.Passengers != 2 || ticket.Labels["campaign"] != "" {
+		t.Fatalf("Expected the update undone, got %d %+v", rec.Code, ticket)
+	}
+
+	// The undo is a change of its own, which can be undone in turn
+	rec = send(http.MethodPost, undoURL, "")
+	ticket = models.FlightTicket{}
+	json.NewDecoder(rec.Body).Decode(&ticket)
+	if rec.Code != http.StatusOK || ticket.Passengers != 3 || ticket.Labels["campaign"] != "spring" {
+		t.Errorf("Expected the undo undone, got %d %+v", rec.Code, ticket)
+	}
+
+	// An earlier revision can be undone once the changes after it are undone
+	if rec := send(http.MethodPut, "/ticket/"+seededTicket, `{"passengers": 4}`); rec.Code != http.StatusOK {
+		t.Fatalf("Failed to update ticket: %d %s", rec.Code, rec.Body.String())
+	}
+	json.NewDecoder(send(http.MethodGet, "/ticket/"+seededTicket+"/history/diff", "").Body).Decode(&diff)
+	target := undoURL + "?target=" + strconv.Itoa(diff.To.Number)
+	send(http.MethodPut, "/ticket/"+seededTicket, `{"passengers": 5}`)
+	if rec := send(http.MethodPost, target, ""); rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for a target changed since, got %d", rec.Code)
+	}
+	send(http.MethodPost, undoURL, "")
+	rec = send(http.MethodPost, target, "")
+	ticket = models.FlightTicket{}
+	json.NewDecoder(rec.Body).Decode(&ticket)
+	if rec.Code != http.StatusOK || ticket.Passengers != 3 {
+		t.Errorf("Expected the target undone, got %d %+v", rec.Code, ticket)
+	}
+	if rec := send(http.MethodPost, undoURL+"?target=999", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown target, got %d", rec.Code)
+	}
+
+	// A booking cannot be undone
+	rec = send(http.MethodPost, "/ticket", `{"origin":"JFK","destination":"LAX","departure_date":"2030-01-15","departure_time":"09:00","passengers":1}`)
+	json.NewDecoder(rec.Body).Decode(&ticket)
+	if rec := send(http.MethodPost, "/ticket/"+ticket.ConfirmationID+"/undo", ""); rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 undoing a booking, got %d", rec.Code)
+	}
+	if rec := send(http.MethodPost, "/ticket/NOPE00/undo", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown ticket, got %d", rec.Code)
+	}
+}
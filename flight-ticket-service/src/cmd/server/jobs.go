@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"flight-ticket-service/src/jobs"
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/services"
+)
+
+// registerJobKinds adds the jobs that can be submitted to POST /jobs. export
+// and import need a backup service and are left out without one;
+// migrate_schema is only available with versioned ticket documents (Firestore).
+// Bulk cancellations issue vouchers through issuer when it is set and free
+// the assigned seats of the cancelled tickets in seatStore.
+func registerJobKinds(manager *jobs.Manager, repository services.TicketRepository, backups *services.BackupService, issuer services.VoucherIssuer, seatStore services.SeatAssignments) {
+	ticketJobs := services.NewTicketJobs(repository).WithSeats(seatStore)
+
+	manager.Register("bulk_cancel", jobs.Kind{
+		Prepare: func(params map[string]interface{}) error {
+			query, err := bulkCancelQuery(params)
+			if err != nil {
+				return err
+			}
+			if _, err := stringsParam(params, "confirmation_ids"); err != nil {
+				return err
+			}
+			if !query.DepartureDate.IsZero() {
+				// Resolve today and tomorrow at submission, so that a resumed job cancels the same day
+				params["departure_date"] = query.DepartureDate.Format("2006-01-02")
+			}
+			return nil
+		},
+		Run: func(ctx context.Context, params map[string]interface{}, progress jobs.Progress) (map[string]interface{}, error) {
+			query, err := bulkCancelQuery(params)
+			if err != nil {
+				return nil, err
+			}
+			confirmationIDs, _ := stringsParam(params, "confirmation_ids")
+			actor, _ := stringParam(params, "requested_by")
+			if actor == "" {
+				actor = "job"
+			}
+			return resultMap(ticketJobs.BulkCancel(ctx, query, confirmationIDs, actor, issuer, progress))
+		},
+	})
+
+	if migrator, ok := services.Capability[services.TicketSchemaMigrator](repository); ok {
+		manager.Register("migrate_schema", jobs.Kind{
+			Run: func(ctx context.Context, params map[string]interface{}, progress jobs.Progress) (map[string]interface{}, error) {
+				return resultMap(migrator.MigrateTickets(ctx, progress))
+			},
+		})
+	}
+
+	if backups == nil {
+		return
+	}
+
+	manager.Register("export", jobs.Kind{
+		Prepare: func(params map[string]interface{}) error {
+			label, err := stringParam(params, "label")
+			if err != nil {
+				return err
+			}
+			if label == "" {
+				// Fixed at submission, so that a resumed job overwrites the same backup
+				label = services.NewBackupLabel(time.Now())
+				params["label"] = label
+			}
+			return services.ValidateBackupLabel(label)
+		},
+		Run: func(ctx context.Context, params map[string]interface{}, progress jobs.Progress) (map[string]interface{}, error) {
+			label, _ := stringParam(params, "label")
+			return resultMap(backups.Backup(ctx, label))
+		},
+	})
+
+	manager.Register("import", jobs.Kind{
+		Prepare: func(params map[string]interface{}) error {
+			label, err := stringParam(params, "label")
+			if err != nil {
+				return err
+			}
+			if label == "" {
+				return fmt.Errorf("label is required")
+			}
+			if _, err := boolParam(params, "overwrite"); err != nil {
+				return err
+			}
+			return services.ValidateBackupLabel(label)
+		},
+		Run: func(ctx context.Context, params map[string]interface{}, progress jobs.Progress) (map[string]interface{}, error) {
+			label, _ := stringParam(params, "label")
+			overwrite, _ := boolParam(params, "overwrite")
+			return resultMap(backups.Restore(ctx, label, overwrite))
+		},
+	})
+}
+
+// bulkCancelQuery reads the flight_number, departure_date and status filters of a bulk cancellation
+func bulkCancelQuery(params map[string]interface{}) (models.TicketQuery, error) {
+	var filter models.BulkCancelFilter
+	var err error
+	if filter.FlightNumber, err = stringParam(params, "flight_number"); err != nil {
+		return models.TicketQuery{}, err
+	}
+	if filter.DepartureDate, err = stringParam(params, "departure_date"); err != nil {
+		return models.TicketQuery{}, err
+	}
+	if filter.Status, err = stringParam(params, "status"); err != nil {
+		return models.TicketQuery{}, err
+	}
+	return filter.Query(time.Now())
+}
+
+func stringParam(params map[string]interface{}, name string) (string, error) {
+	value, ok := params[name]
+	if !ok || value == nil {
+		return "", nil
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("%s must be a string", name)
+	}
+	return strings.TrimSpace(s), nil
+}
+
+// stringsParam reads a list of strings, returning nil when the parameter is not set
+func stringsParam(params map[string]interface{}, name string) ([]string, error) {
+	value, ok := params[name]
+	if !ok || value == nil {
+		return nil, nil
+	}
+	switch list := value.(type) {
+	case []string:
+		return list, nil
+	case []interface{}:
+		values := make([]string, len(list))
+		for i, item := range list {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s must be a list of strings", name)
+			}
+			values[i] = s
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("%s must be a list of strings", name)
+}
+
+func boolParam(params map[string]interface{}, name string) (bool, error) {
+	value, ok := params[name]
+	if !ok || value == nil {
+		return false, nil
+	}
+	b, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("%s must be true or false", name)
+	}
+	return b, nil
+}
+
+// resultMap turns the result of a job into the map stored with the job
+func resultMap(result interface{}, err error) (map[string]interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	return m, json.Unmarshal(data, &m)
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+func TestAsyncManifestExport(t *testing.T) {
+	router := newTestRouter(t)
+	get := func(target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("X-API-Key", "fuzz-key")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	date := time.Now().AddDate(0, 1, 0).UTC().Format("2006-01-02")
+	rec := get("/flights/AA1234/" + date + "/manifest?format=csv&async=true")
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var job models.Job
+	if err := json.NewDecoder(rec.Body).Decode(&job); err != nil {
+		t.Fatalf("Failed to decode job: %v", err)
+	}
+	if rec.Header().Get("Location") != "/jobs/"+job.ID {
+		t.Errorf("Unexpected Location %q", rec.Header().Get("Location"))
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for job.Status != "succeeded" {
+		if job.Status == "failed" || time.Now().After(deadline) {
+			t.Fatalf("Job did not succeed: %+v", job)
+		}
+		time.Sleep(10 * time.Millisecond)
+		rec = get("/jobs/" + job.ID)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d", rec.Code)
+		}
+		job = models.Job{}
+		json.NewDecoder(rec.Body).Decode(&job)
+	}
+
+	rec = get(job.ResultURL)
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/csv") || !strings.Contains(rec.Body.String(), "FUZZ01") {
+		t.Errorf("Unexpected result %d %s: %s", rec.Code, rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+
+	if rec := get("/flights/AA1234/" + date + "/manifest?async=true"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an async JSON manifest, got %d", rec.Code)
+	}
+	if rec := get("/jobs/unknown"); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown job, got %d", rec.Code)
+	}
+}
+
+func TestBulkCancelJob(t *testing.T) {
+	router := newTestRouter(t)
+	send := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("X-API-Key", "fuzz-key")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := send(http.MethodPost, "/jobs", `{"kind": "bulk_cancel", "params": {"status": "CONFIRMED"}}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without a flight or date, got %d", rec.Code)
+	}
+	if rec := send(http.MethodPost, "/jobs", `{"kind": "export"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for export without a backup bucket, got %d", rec.Code)
+	}
+
+	rec := send(http.MethodPost, "/jobs", `{"kind": "bulk_cancel", "params": {"flight_number": "aa1234"}}`)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var job models.Job
+	json.NewDecoder(rec.Body).Decode(&job)
+	jobID := job.ID
+
+	deadline := time.Now().Add(5 * time.Second)
+	for job.Status != "succeeded" {
+		if job.Status == "failed" || time.Now().After(deadline) {
+			t.Fatalf("Job did not succeed: %+v", job)
+		}
+		time.Sleep(10 * time.Millisecond)
+		job = models.Job{}
+		json.NewDecoder(send(http.MethodGet, "/jobs/"+jobID, "").Body).Decode(&job)
+	}
+
+	if job.Result["cancelled"] != float64(1) || job.Progress == nil || job.Progress.Done != 1 || job.Progress.Total != 1 {
+		t.Errorf("Unexpected job outcome: result %v, progress %+v", job.Result, job.Progress)
+	}
+	var ticket models.FlightTicket
+	json.NewDecoder(send(http.MethodGet, "/ticket/"+seededTicket, "").Body).Decode(&ticket)
+	if ticket.Status != "CANCELLED" {
+		t.Errorf("Expected the ticket to be cancelled, got %s", ticket.Status)
+	}
+}
+
+func TestBulkCancelDryRun(t *testing.T) {
+	router := newTestRouter(t)
+	send := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/tickets/bulk-cancel", strings.NewReader(body))
+		req.Header.Set("X-API-Key", "fuzz-key")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := send(`{"status": "CONFIRMED"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without a flight or date, got %d", rec.Code)
+	}
+	if rec := send(`{"flight_number": "AA1234", "dry_run": false}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a confirmed run without a preview token, got %d", rec.Code)
+	}
+
+	rec := send(`{"flight_number": "aa1234"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 for a dry run, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var preview models.BulkCancelPreview
+	json.NewDecoder(rec.Body).Decode(&preview)
+	if !preview.DryRun || preview.Matched != 1 || len(preview.Tickets) != 1 || preview.Tickets[0].ConfirmationID != seededTicket || preview.PreviewToken == "" {
+		t.Fatalf("Unexpected preview: %+v", preview)
+	}
+
+	if rec := send(`{"flight_number": "AA1234", "dry_run": false, "preview_token": "stale"}`); rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for a stale preview token, got %d", rec.Code)
+	}
+	if rec := send(`{"flight_number": "AA1234", "status": "PENDING", "dry_run": false, "preview_token": "` + preview.PreviewToken + `"}`); rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for a token of another filter, got %d", rec.Code)
+	}
+
+	rec = send(`{"flight_number": "AA1234", "dry_run": false, "preview_token": "` + preview.PreviewToken + `"}`)
+	if rec.Code != http.StatusAccepted || rec.Header().Get("Location") == "" {
+		t.Fatalf("Expected 202 with a job location, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var job models.Job
+	deadline := time.Now().Add(5 * time.Second)
+	for job.Status != "succeeded" {
+		if job.Status == "failed" || time.Now().After(deadline) {
+			t.Fatalf("Job did not succeed: %+v", job)
+		}
+		time.Sleep(10 * time.Millisecond)
+		req := httptest.NewRequest(http.MethodGet, rec.Header().Get("Location"), nil)
+		req.Header.Set("X-API-Key", "fuzz-key")
+		status := httptest.NewRecorder()
+		router.ServeHTTP(status, req)
+		job = models.Job{}
+		json.NewDecoder(status.Body).Decode(&job)
+	}
+	if job.Result["cancelled"] != float64(1) {
+		t.Errorf("Expected one cancelled ticket, got %v", job.Result)
+	}
+
+	rec = send(`{"flight_number": "AA1234"}`)
+	json.NewDecoder(rec.Body).Decode(&preview)
+	if preview.Matched != 0 {
+		t.Errorf("Expected cancelled tickets to drop out of the preview, got %d", preview.Matched)
+	}
+}
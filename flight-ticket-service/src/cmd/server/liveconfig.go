@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"flight-ticket-service/src/debuglog"
+	"flight-ticket-service/src/featureflags"
+	"flight-ticket-service/src/liveconfig"
+	"flight-ticket-service/src/quota"
+)
+
+// reloadableSettings are the settings a config source may change while the
+// instance serves: none of them needs a new client or connection
+func reloadableSettings(limiter *quota.Limiter, flags *featureflags.Store, policy *corsPolicy) []liveconfig.Setting {
+	env := func(name string) string {
+		return strings.TrimSpace(os.Getenv(name))
+	}
+	return []liveconfig.Setting{
+		{Name: "LOG_LEVEL", Default: env("LOG_LEVEL"), Apply: debuglog.SetLevel},
+		{Name: "BOOKING_QUOTA", Default: env("BOOKING_QUOTA"), Apply: func(value string) error {
+			limit, err := quota.ParseLimit(value)
+			if err != nil {
+				return err
+			}
+			limiter.SetLimits(limit, limiter.Config().Overrides)
+			return nil
+		}},
+		{Name: "BOOKING_QUOTA_OVERRIDES", Default: env("BOOKING_QUOTA_OVERRIDES"), Apply: func(value string) error {
+			overrides, err := quota.ParseOverrides(value)
+			if err != nil {
+				return err
+			}
+			limiter.SetLimits(limiter.Config().Limit, overrides)
+			return nil
+		}},
+		{Name: "FEATURE_FLAGS", Default: env("FEATURE_FLAGS"), Apply: func(value string) error {
+			defaults, err := featureflags.ParseEnv(value)
+			if err != nil {
+				return err
+			}
+			flags.SetDefaults(defaults)
+			return nil
+		}},
+		{Name: "CORS_ALLOWED_ORIGINS", Default: env("CORS_ALLOWED_ORIGINS"), Apply: func(value string) error {
+			origins, err := parseCORSOrigins(value)
+			if err != nil {
+				return err
+			}
+			policy.SetOrigins(origins)
+			return nil
+		}},
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"flight-ticket-service/src/debuglog"
+	"flight-ticket-service/src/featureflags"
+	"flight-ticket-service/src/liveconfig"
+	"flight-ticket-service/src/quota"
+)
+
+func TestReloadableSettings(t *testing.T) {
+	for _, name := range []string{"LOG_LEVEL", "BOOKING_QUOTA", "BOOKING_QUOTA_OVERRIDES", "FEATURE_FLAGS", "CORS_ALLOWED_ORIGINS"} {
+		t.Setenv(name, "")
+	}
+	t.Cleanup(func() { debuglog.SetLevel(debuglog.LevelInfo) })
+
+	limiter := quota.New(quota.Config{}, quota.NewMemoryCounter())
+	flags := featureflags.New(nil)
+	policy := newCORSPolicy([]string{"*"})
+	watcher := liveconfig.New(reloadableSettings(limiter, flags, policy)...)
+	handler := policy.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	allowedOrigin := func(origin string) string {
+		req := httptest.NewRequest(http.MethodGet, "/ticket/ABC123", nil)
+		req.Header.Set("Origin", origin)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Header().Get("Access-Control-Allow-Origin")
+	}
+	if allowedOrigin("https://evil.example.com") != "*" {
+		t.Fatal("Expected every origin allowed by default")
+	}
+
+	watcher.Load(map[string]interface{}{
+		"LOG_LEVEL":               "debug",
+		"BOOKING_QUOTA":           int64(50),
+		"BOOKING_QUOTA_OVERRIDES": "ci:1000",
+		"FEATURE_FLAGS":           "search",
+		"CORS_ALLOWED_ORIGINS":    []interface{}{"https://app.example.com"},
+	}, "test")
+
+	if debuglog.Level() != debuglog.LevelDebug {
+		t.Errorf("Expected the debug level, got %s", debuglog.Level())
+	}
+	if config := limiter.Config(); config.LimitFor("desk") != 50 || config.LimitFor("ci") != 1000 {
+		t.Errorf("Unexpected quota %+v", config)
+	}
+	if !flags.Enabled(featureflags.Search) {
+		t.Error("Expected search enabled")
+	}
+	if allowedOrigin("https://app.example.com") != "https://app.example.com" || allowedOrigin("https://evil.example.com") != "" {
+		t.Error("Expected only the configured origin allowed")
+	}
+
+	// Invalid values are rejected and leave the setting as it was
+	watcher.Load(map[string]interface{}{"LOG_LEVEL": "chatty", "CORS_ALLOWED_ORIGINS": "app.example.com"}, "test")
+	if debuglog.Level() != debuglog.LevelDebug || allowedOrigin("https://app.example.com") != "https://app.example.com" {
+		t.Error("Expected the previous level and origins kept")
+	}
+	rejected := 0
+	for _, change := range watcher.State().Changes {
+		if change.Outcome == liveconfig.OutcomeRejected {
+			rejected++
+		}
+	}
+	if rejected != 2 {
+		t.Errorf("Expected 2 rejected changes, got %+v", watcher.State().Changes)
+	}
+
+	// Settings dropped from the source return to their values from the environment
+	watcher.Load(nil, "test")
+	if debuglog.Level() != debuglog.LevelInfo || limiter.Enabled() || flags.Enabled(featureflags.Search) || allowedOrigin("https://evil.example.com") != "*" {
+		t.Error("Expected the defaults restored")
+	}
+}
+
+func TestAdminConfig(t *testing.T) {
+	router := newTestRouter(t)
+	req := httptest.NewRequest(http.MethodGet, "/admin/config", nil)
+	req.Header.Set("X-API-Key", "fuzz-key")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var state liveconfig.State
+	json.NewDecoder(rec.Body).Decode(&state)
+	if state.Source != liveconfig.SourceEnv || len(state.Settings) != 5 {
+		t.Errorf("Unexpected state %+v", state)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/config", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without a key, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"flight-ticket-service/src/models"
+)
+
+func TestLockRefusesOtherWriters(t *testing.T) {
+	router := newTestRouter(t)
+	send := func(method, target, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("X-API-Key", "fuzz-key")
+		if token != "" {
+			req.Header.Set("X-Lock-Token", token)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send(http.MethodPost, "/ticket/"+seededTicket+"/lock", "", `{"ttl_seconds": 60}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected a new lock, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var lock models.TicketLock
+	json.NewDecoder(rec.Body).Decode(&lock)
+	if lock.Token == "" || lock.Holder != "fuzz" {
+		t.Fatalf("Expected a token held by the caller, got %+v", lock)
+	}
+
+	if rec := send(http.MethodPost, "/ticket/"+seededTicket+"/lock", "", ""); rec.Code != http.StatusLocked {
+		t.Errorf("Expected 423 for a second lock, got %d", rec.Code)
+	}
+	if rec := send(http.MethodPut, "/ticket/"+seededTicket, "", `{"passengers": 3}`); rec.Code != http.StatusLocked {
+		t.Errorf("Expected 423 for an update without the token, got %d", rec.Code)
+	}
+	if rec := send(http.MethodDelete, "/ticket/"+seededTicket+"?dry_run=true", "", ""); rec.Code != http.StatusLocked {
+		t.Errorf("Expected 423 for a cancellation without the token, got %d", rec.Code)
+	}
+	if rec := send(http.MethodPost, "/ticket/"+seededTicket+"/checkin", "", `{"passengers": [{"first_name": "Jane", "last_name": "Doe"}]}`); rec.Code != http.StatusLocked {
+		t.Errorf("Expected 423 for a check-in without the token, got %d", rec.Code)
+	}
+	if rec := send(http.MethodPut, "/ticket/"+seededTicket, lock.Token, `{"passengers": 3}`); rec.Code != http.StatusOK {
+		t.Errorf("Expected the holder to update, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := send(http.MethodPost, "/ticket/"+seededTicket+"/lock", lock.Token, ""); rec.Code != http.StatusOK {
+		t.Errorf("Expected the holder to renew, got %d", rec.Code)
+	}
+
+	if rec := send(http.MethodDelete, "/ticket/"+seededTicket+"/lock", lock.Token, ""); rec.Code != http.StatusOK {
+		t.Errorf("Expected the lock to be released, got %d", rec.Code)
+	}
+	if rec := send(http.MethodPut, "/ticket/"+seededTicket, lock.Token, `{"passengers": 2}`); rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for a replayed token, got %d", rec.Code)
+	}
+	if rec := send(http.MethodPut, "/ticket/"+seededTicket, "", `{"passengers": 2}`); rec.Code != http.StatusOK {
+		t.Errorf("Expected writes once unlocked, got %d", rec.Code)
+	}
+
+	if rec := send(http.MethodPost, "/ticket/"+seededTicket+"/lock", "", `{"ttl_seconds": 3600}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a long lock, got %d", rec.Code)
+	}
+	if rec := send(http.MethodPost, "/ticket/ZZZZZZ/lock", "", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown ticket, got %d", rec.Code)
+	}
+}
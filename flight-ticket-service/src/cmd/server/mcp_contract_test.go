@@ -0,0 +1,550 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/internal/openapi"
+	"flight-ticket-service/src/mcptools"
+)
+
+// mcpSession sends JSON-RPC requests to an MCP server over HTTP
+type mcpSession struct {
+	t         *testing.T
+	url       string
+	id        int
+	sessionID string // from initialize
+}
+
+type mcpCallResult struct {
+	Content           []mcptools.Content `json:"content"`
+	StructuredContent json.RawMessage    `json:"structuredContent"`
+	IsError           bool               `json:"isError"`
+}
+
+type mcpRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (s *mcpSession) request(method string, params any, result any) *mcpRPCError {
+	s.t.Helper()
+	s.id++
+	body, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": s.id, "method": method, "params": params})
+	req, _ := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if s.sessionID != "" {
+		req.Header.Set(mcptools.SessionHeader, s.sessionID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		s.t.Fatalf("%s: %v", method, err)
+	}
+	defer resp.Body.Close()
+	if method == "initialize" {
+		s.sessionID = resp.Header.Get(mcptools.SessionHeader)
+	}
+	var decoded struct {
+		ID     int             `json:"id"`
+		Result json.RawMessage `json:"result"`
+		Error  *mcpRPCError    `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil || decoded.ID != s.id {
+		s.t.Fatalf("%s: invalid response %+v: %v", method, decoded, err)
+	}
+	if decoded.Error == nil && result != nil {
+		if err := json.Unmarshal(decoded.Result, result); err != nil {
+			s.t.Fatalf("%s: invalid result %s: %v", method, decoded.Result, err)
+		}
+	}
+	return decoded.Error
+}
+
+func (s *mcpSession) callTool(name string, args map[string]any) mcpCallResult {
+	s.t.Helper()
+	var result mcpCallResult
+	if rpcErr := s.request("tools/call", map[string]any{"name": name, "arguments": args}, &result); rpcErr != nil {
+		s.t.Fatalf("%s: protocol error %d %s", name, rpcErr.Code, rpcErr.Message)
+	}
+	return result
+}
+
+type listedTool struct {
+	Name         string         `json:"name"`
+	InputSchema  map[string]any `json:"inputSchema"`
+	OutputSchema map[string]any `json:"outputSchema"`
+}
+
+// TestMCPContract runs the MCP server of the generated tools against the
+// in-memory API and checks every tool against what the API does: arguments
+// built from the input schema are accepted, required body arguments are
+// required by the API, results match the output schema, and errors are
+// documented ErrorResponses reported as tool errors with their status.
+func TestMCPContract(t *testing.T) {
+	data, err := os.ReadFile("../../../docs/swagger.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec, err := openapi.Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoints, err := spec.Endpoints(openapi.DefaultExcludes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := make(map[string]openapi.Endpoint)
+	for _, endpoint := range endpoints {
+		byName[endpoint.Name] = endpoint
+	}
+
+	api := httptest.NewServer(newTestRouter(t))
+	defer api.Close()
+	client := mcptools.NewClient(api.URL, "fuzz-key")
+	mcp := httptest.NewServer(mcptools.NewServer(client, "flight-ticket-service", "test"))
+	defer mcp.Close()
+	session := &mcpSession{t: t, url: mcp.URL}
+
+	var initialized struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	if rpcErr := session.request("initialize", map[string]any{"protocolVersion": mcptools.ProtocolVersion, "capabilities": map[string]any{}}, &initialized); rpcErr != nil || initialized.ProtocolVersion != mcptools.ProtocolVersion {
+		t.Fatalf("Failed to initialize: %+v %+v", initialized, rpcErr)
+	}
+	var listed struct {
+		Tools []listedTool `json:"tools"`
+	}
+	session.request("tools/list", nil, &listed)
+	if len(listed.Tools) != len(endpoints)+2 {
+		t.Errorf("Expected a tool per operation of the spec, %s and %s, got %d tools for %d operations", mcptools.UndoToolName, mcptools.SummarizeTripsToolName, len(listed.Tools), len(endpoints))
+	}
+
+	// Path parameters have no example in the spec; fixtures create what they name
+	departure := time.Now().UTC().AddDate(0, 0, 30).Format("2006-01-02")
+	fixtures := map[string]func(session *mcpSession) any{
+		"confirmationID": func(session *mcpSession) any {
+			var ticket struct {
+				ConfirmationID string `json:"confirmation_id"`
+			}
+			result := session.callTool("create_ticket", map[string]any{"origin": "JFK", "destination": "LAX", "departure_date": departure, "departure_time": "09:00", "flight_number": "AA1234", "passengers": 1})
+			if result.IsError || json.Unmarshal(result.StructuredContent, &ticket) != nil || ticket.ConfirmationID == "" {
+				session.t.Fatalf("Failed to book a fixture ticket: %+v", result)
+			}
+			return ticket.ConfirmationID
+		},
+	}
+	// Spec examples the API rejects, e.g. dates in the past
+	overrides := map[string]any{"departure_date": departure}
+
+	for _, tool := range listed.Tools {
+		if tool.Name == mcptools.UndoToolName || tool.Name == mcptools.SummarizeTripsToolName {
+			continue
+		}
+		t.Run(tool.Name, func(t *testing.T) {
+			session := &mcpSession{t: t, url: mcp.URL, id: session.id + 1000, sessionID: session.sessionID}
+			endpoint, ok := byName[tool.Name]
+			if !ok {
+				t.Fatalf("No operation of the spec is named %s", tool.Name)
+			}
+			generated, ok := mcptools.Lookup(tool.Name)
+			if !ok {
+				t.Fatalf("Listed tool %s is not generated", tool.Name)
+			}
+			args, err := exampleArguments(session, tool.InputSchema, fixtures, overrides)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Required arguments of the body are required by the API
+			for _, name := range generated.BodyParams {
+				if !contains(requiredArguments(tool.InputSchema), name) {
+					continue
+				}
+				partial := make(map[string]any, len(args))
+				for arg, value := range args {
+					if arg != name {
+						partial[arg] = value
+					}
+				}
+				_, err := client.Call(context.Background(), generated, partial)
+				if status := checkAPIError(t, spec, endpoint, err); status != http.StatusBadRequest {
+					t.Errorf("Expected 400 without required argument %s, got %d", name, status)
+				}
+			}
+
+			// Unknown tickets are documented errors, mapped to tool errors
+			if contains(generated.PathParams, "confirmationID") {
+				missing := make(map[string]any, len(args))
+				for arg, value := range args {
+					missing[arg] = value
+				}
+				missing["confirmationID"] = "ZZ9999"
+				_, err := client.Call(context.Background(), generated, missing)
+				status := checkAPIError(t, spec, endpoint, err)
+				if _, documented := endpoint.Responses["404"]; documented && status != http.StatusNotFound {
+					t.Errorf("Expected the documented 404 for an unknown ticket, got %d", status)
+				}
+				result := session.callTool(tool.Name, missing)
+				if !result.IsError || len(result.Content) != 1 || !strings.Contains(result.Content[0].Text, fmt.Sprintf("(status %d)", status)) {
+					t.Errorf("Expected a tool error with the status, got %+v", result)
+				}
+			}
+
+			result := session.callTool(tool.Name, args)
+			if result.IsError {
+				t.Fatalf("Expected arguments built from the input schema to be accepted, got %+v", result)
+			}
+			if tool.OutputSchema == nil {
+				return
+			}
+			var structured any
+			if err := json.Unmarshal(result.StructuredContent, &structured); err != nil {
+				t.Fatalf("Expected structured content for the output schema, got %q", result.StructuredContent)
+			}
+			for _, problem := range validateSchema(tool.OutputSchema, structured, "result") {
+				t.Errorf("Result does not match the output schema: %s", problem)
+			}
+		})
+	}
+
+	// Protocol errors
+	if rpcErr := session.request("tools/call", map[string]any{"name": "no_such_tool"}, nil); rpcErr == nil || rpcErr.Code != -32602 {
+		t.Errorf("Expected an invalid params error for an unknown tool, got %+v", rpcErr)
+	}
+	if rpcErr := session.request("tools/call", map[string]any{"name": "get_ticket_by_confirmation_id", "arguments": map[string]any{}}, nil); rpcErr == nil || !strings.Contains(rpcErr.Message, "confirmationID") {
+		t.Errorf("Expected an error naming the missing argument, got %+v", rpcErr)
+	}
+}
+
+// TestMCPUndo books, updates and cancels a ticket through the MCP server and
+// undoes the changes one by one with undo_last_action
+func TestMCPUndo(t *testing.T) {
+	api := httptest.NewServer(newTestRouter(t))
+	defer api.Close()
+	mcp := httptest.NewServer(mcptools.NewServer(mcptools.NewClient(api.URL, "desk-key"), "flight-ticket-service", "test"))
+	defer mcp.Close()
+	session := &mcpSession{t: t, url: mcp.URL}
+	other := &mcpSession{t: t, url: mcp.URL}
+	session.request("initialize", map[string]any{"protocolVersion": mcptools.ProtocolVersion}, nil)
+	other.request("initialize", map[string]any{"protocolVersion": mcptools.ProtocolVersion}, nil)
+
+	type ticket struct {
+		ConfirmationID string `json:"confirmation_id"`
+		Passengers     int    `json:"passengers"`
+		Status         string `json:"status"`
+	}
+	get := func(id string) ticket {
+		var found ticket
+		result := session.callTool("get_ticket_by_confirmation_id", map[string]any{"confirmationID": id})
+		json.Unmarshal(result.StructuredContent, &found)
+		return found
+	}
+	undo := func() mcpCallResult {
+		return session.callTool(mcptools.UndoToolName, map[string]any{})
+	}
+
+	departure := time.Now().UTC().AddDate(0, 0, 30).Format("2006-01-02")
+	var booked ticket
+	json.Unmarshal(session.callTool("create_ticket", map[string]any{"origin": "JFK", "destination": "LAX", "departure_date": departure, "departure_time": "09:00", "flight_number": "AA1234", "passengers": 1}).StructuredContent, &booked)
+	id := booked.ConfirmationID
+	if id == "" {
+		t.Fatal("Failed to book a ticket")
+	}
+	// Reads are not changes, and failed calls are not recorded
+	get(id)
+	session.callTool("update_ticket_by_confirmation_id", map[string]any{"confirmationID": "ZZ9999", "passengers": 2})
+	if result := session.callTool("update_ticket_by_confirmation_id", map[string]any{"confirmationID": id, "passengers": 3}); result.IsError {
+		t.Fatalf("Failed to update the ticket: %+v", result)
+	}
+	if result := session.callTool("delete_ticket_by_confirmation_id", map[string]any{"confirmationID": id}); result.IsError {
+		t.Fatalf("Failed to cancel the ticket: %+v", result)
+	}
+
+	// Another session has nothing to undo
+	if result := other.callTool(mcptools.UndoToolName, nil); !result.IsError {
+		t.Errorf("Expected nothing to undo in another session, got %+v", result)
+	}
+
+	if result := undo(); result.IsError || get(id).Status != "CONFIRMED" || get(id).Passengers != 3 {
+		t.Fatalf("Expected the cancellation undone, got %+v and %+v", result, get(id))
+	}
+
+	// A change made since by another session keeps the update until it is undone too
+	other.callTool("update_ticket_by_confirmation_id", map[string]any{"confirmationID": id, "passengers": 4})
+	if result := undo(); !result.IsError || get(id).Passengers != 4 {
+		t.Fatalf("Expected the undo refused after another change, got %+v and %+v", result, get(id))
+	}
+	if result := other.callTool(mcptools.UndoToolName, nil); result.IsError {
+		t.Fatalf("Failed to undo the other session's change: %+v", result)
+	}
+	if result := undo(); result.IsError || get(id).Passengers != 1 {
+		t.Fatalf("Expected the update undone, got %+v and %+v", result, get(id))
+	}
+	result := undo()
+	var undone struct {
+		Undone    string `json:"undone"`
+		Remaining int    `json:"remaining"`
+	}
+	json.Unmarshal(result.StructuredContent, &undone)
+	if result.IsError || get(id).Status != "CANCELLED" || undone.Undone != "create_ticket" || undone.Remaining != 0 {
+		t.Fatalf("Expected the booking cancelled, got %+v and %+v", result, get(id))
+	}
+	if result := undo(); !result.IsError || !strings.Contains(result.Content[0].Text, "Nothing to undo") {
+		t.Errorf("Expected nothing left to undo, got %+v", result)
+	}
+}
+
+// TestMCPSessionSummary reads the summary resource of a session that books,
+// changes and cancels tickets, and gets it again when the session ends
+func TestMCPSessionSummary(t *testing.T) {
+	api := httptest.NewServer(newTestRouter(t))
+	defer api.Close()
+	server := mcptools.NewServer(mcptools.NewClient(api.URL, "desk-key"), "flight-ticket-service", "test")
+	closed := make(chan mcptools.SessionSummary, 1)
+	server.OnSessionClose = func(summary mcptools.SessionSummary) { closed <- summary }
+	mcp := httptest.NewServer(server)
+	defer mcp.Close()
+	session := &mcpSession{t: t, url: mcp.URL}
+	var initialized struct {
+		Capabilities map[string]any `json:"capabilities"`
+	}
+	session.request("initialize", map[string]any{"protocolVersion": mcptools.ProtocolVersion}, &initialized)
+	if initialized.Capabilities["resources"] == nil {
+		t.Errorf("Expected the resources capability, got %v", initialized.Capabilities)
+	}
+
+	departure := time.Now().UTC().AddDate(0, 0, 30).Format("2006-01-02")
+	book := func() string {
+		var ticket struct {
+			ConfirmationID string `json:"confirmation_id"`
+		}
+		json.Unmarshal(session.callTool("create_ticket", map[string]any{"origin": "JFK", "destination": "LAX", "departure_date": departure, "departure_time": "09:00", "flight_number": "AA1234", "passengers": 1}).StructuredContent, &ticket)
+		return ticket.ConfirmationID
+	}
+	kept, undone := book(), book()
+	session.callTool("update_ticket_by_confirmation_id", map[string]any{"confirmationID": kept, "passengers": 2})
+	session.callTool(mcptools.UndoToolName, nil)
+	session.callTool(mcptools.UndoToolName, nil)
+	session.callTool("update_ticket_by_confirmation_id", map[string]any{"confirmationID": seededTicket, "passengers": 3})
+	session.callTool("delete_ticket_by_confirmation_id", map[string]any{"confirmationID": kept})
+	session.callTool("get_ticket_by_confirmation_id", map[string]any{"confirmationID": seededTicket})
+	booked := book()
+
+	var listed struct {
+		Resources []struct {
+			URI string `json:"uri"`
+		} `json:"resources"`
+	}
+	session.request("resources/list", nil, &listed)
+	if len(listed.Resources) != 1 || listed.Resources[0].URI != mcptools.SummaryURI {
+		t.Errorf("Expected the summary resource, got %+v", listed)
+	}
+	var read struct {
+		Contents []struct {
+			URI      string `json:"uri"`
+			MimeType string `json:"mimeType"`
+			Text     string `json:"text"`
+		} `json:"contents"`
+	}
+	if rpcErr := session.request("resources/read", map[string]any{"uri": mcptools.SummaryURI}, &read); rpcErr != nil || len(read.Contents) != 1 || read.Contents[0].MimeType != "application/json" {
+		t.Fatalf("Failed to read the summary: %+v %+v", read, rpcErr)
+	}
+	var summary mcptools.SessionSummary
+	if err := json.Unmarshal([]byte(read.Contents[0].Text), &summary); err != nil {
+		t.Fatal(err)
+	}
+	ids := func(bookings []mcptools.BookingSummary) []string {
+		var ids []string
+		for _, booking := range bookings {
+			ids = append(ids, booking.ConfirmationID)
+		}
+		return ids
+	}
+	if got := ids(summary.Created); len(got) != 1 || got[0] != booked {
+		t.Errorf("Expected %s created, got %v", booked, got)
+	}
+	if got := ids(summary.Modified); len(got) != 1 || got[0] != seededTicket {
+		t.Errorf("Expected %s modified, got %v", seededTicket, got)
+	}
+	if got := ids(summary.Cancelled); len(got) != 2 || got[0] != kept || got[1] != undone {
+		t.Errorf("Expected %s and %s cancelled, got %v", kept, undone, got)
+	}
+	if changes := strings.Join(summary.Cancelled[0].Changes, ","); changes != "create_ticket,update_ticket_by_confirmation_id,undo_last_action,delete_ticket_by_confirmation_id" {
+		t.Errorf("Unexpected changes of %s: %s", kept, changes)
+	}
+	var current struct {
+		Passengers int `json:"passengers"`
+	}
+	if json.Unmarshal(summary.Modified[0].Ticket, &current); current.Passengers != 3 {
+		t.Errorf("Expected the current state of %s, got %s", seededTicket, summary.Modified[0].Ticket)
+	}
+	if rpcErr := session.request("resources/read", map[string]any{"uri": "session://other"}, nil); rpcErr == nil || rpcErr.Code != -32002 {
+		t.Errorf("Expected resource not found, got %+v", rpcErr)
+	}
+
+	end, _ := http.NewRequest(http.MethodDelete, mcp.URL, nil)
+	end.Header.Set(mcptools.SessionHeader, session.sessionID)
+	resp, err := http.DefaultClient.Do(end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var receipt mcptools.SessionSummary
+	if err := json.NewDecoder(resp.Body).Decode(&receipt); err != nil || resp.StatusCode != http.StatusOK || len(receipt.Cancelled) != 2 {
+		t.Errorf("Expected the summary when the session ends, got %d %+v: %v", resp.StatusCode, receipt, err)
+	}
+	if summary := <-closed; len(summary.Created) != 1 || !summary.SessionStarted.Equal(receipt.SessionStarted) {
+		t.Errorf("Expected OnSessionClose to get the summary, got %+v", summary)
+	}
+}
+
+// checkAPIError checks that a call failed with a status the spec documents
+// for the operation, with a body matching its schema, and returns the status
+func checkAPIError(t *testing.T, spec *openapi.Spec, endpoint openapi.Endpoint, err error) int {
+	t.Helper()
+	var apiErr *mcptools.APIError
+	if !errors.As(err, &apiErr) {
+		t.Errorf("Expected an API error, got %v", err)
+		return 0
+	}
+	response, ok := endpoint.Responses[strconv.Itoa(apiErr.Status)]
+	if !ok {
+		t.Errorf("%s %s returned %d, which the spec does not document: %s", endpoint.Method, endpoint.Path, apiErr.Status, apiErr.Body)
+		return apiErr.Status
+	}
+	var body any
+	if err := json.Unmarshal(apiErr.Body, &body); err != nil {
+		t.Errorf("Expected a JSON error body, got %s", apiErr.Body)
+		return apiErr.Status
+	}
+	for _, problem := range validateSchema(spec.Resolve(response.Schema), body, "error") {
+		t.Errorf("Error of status %d does not match the spec: %s", apiErr.Status, problem)
+	}
+	if text := mcptools.ErrorText(err); !strings.Contains(text, fmt.Sprintf("(status %d)", apiErr.Status)) {
+		t.Errorf("Expected the tool error to name the status, got %q", text)
+	}
+	return apiErr.Status
+}
+
+// exampleArguments builds arguments from the examples and defaults of an input schema
+func exampleArguments(session *mcpSession, schema map[string]any, fixtures map[string]func(*mcpSession) any, overrides map[string]any) (map[string]any, error) {
+	properties, _ := schema["properties"].(map[string]any)
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	args := make(map[string]any)
+	for _, name := range names {
+		property, _ := properties[name].(map[string]any)
+		switch {
+		case fixtures[name] != nil:
+			args[name] = fixtures[name](session)
+		case overrides[name] != nil:
+			args[name] = overrides[name]
+		case property["example"] != nil:
+			args[name] = property["example"]
+		case property["default"] != nil:
+			args[name] = property["default"]
+		case contains(requiredArguments(schema), name):
+			return nil, fmt.Errorf("required argument %s has no example, default or fixture", name)
+		}
+	}
+	return args, nil
+}
+
+func requiredArguments(schema map[string]any) []string {
+	var names []string
+	required, _ := schema["required"].([]any)
+	for _, name := range required {
+		names = append(names, fmt.Sprint(name))
+	}
+	return names
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// validateSchema checks a decoded JSON value against the type, enum,
+// required and properties keywords of a JSON schema, and items of arrays
+func validateSchema(schema map[string]any, value any, path string) []string {
+	if value == nil {
+		// omitted optional fields are null in swag's models
+		return nil
+	}
+	var problems []string
+	switch schema["type"] {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: expected an object, got %T", path, value)}
+		}
+		for _, name := range requiredArguments(schema) {
+			if _, ok := object[name]; !ok {
+				problems = append(problems, fmt.Sprintf("%s: missing required field %s", path, name))
+			}
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		for name, field := range object {
+			if property, ok := properties[name].(map[string]any); ok {
+				problems = append(problems, validateSchema(property, field, path+"."+name)...)
+			}
+		}
+	case "array":
+		array, ok := value.([]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: expected an array, got %T", path, value)}
+		}
+		items, _ := schema["items"].(map[string]any)
+		for i, item := range array {
+			problems = append(problems, validateSchema(items, item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			problems = append(problems, fmt.Sprintf("%s: expected a string, got %T", path, value))
+		}
+	case "integer":
+		if number, ok := value.(float64); !ok || number != math.Trunc(number) {
+			problems = append(problems, fmt.Sprintf("%s: expected an integer, got %v", path, value))
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			problems = append(problems, fmt.Sprintf("%s: expected a number, got %T", path, value))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			problems = append(problems, fmt.Sprintf("%s: expected a boolean, got %T", path, value))
+		}
+	}
+	if enum, ok := schema["enum"].([]any); ok && len(problems) == 0 {
+		found := false
+		for _, allowed := range enum {
+			if allowed == value {
+				found = true
+			}
+		}
+		if !found {
+			problems = append(problems, fmt.Sprintf("%s: %v is not one of %v", path, value, enum))
+		}
+	}
+	return problems
+}
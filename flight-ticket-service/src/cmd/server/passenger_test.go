@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+func TestPassengerTypes(t *testing.T) {
+	router := newTestRouter(t)
+	send := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", "fuzz-key")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	departure := time.Now().UTC().AddDate(0, 1, 0).Format("2006-01-02")
+	booking := `{"origin":"JFK","destination":"LAX","departure_date":"` + departure + `","departure_time":"09:00","flight_number":"AA1234",`
+	if rec := send(http.MethodPost, "/admin/inventory/AA1234/"+departure+"/adjustments", `{"seats":10,"reason":"Initial capacity"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201 putting seats on sale, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec := send(http.MethodPost, "/ticket", booking+`"passenger_types":{"ADT":1,"CHD":1,"INF":1}}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var ticket models.FlightTicket
+	json.NewDecoder(rec.Body).Decode(&ticket)
+	if ticket.Passengers != 3 || ticket.PassengerTypes == nil || *ticket.PassengerTypes != (models.PassengerTypes{Adults: 1, Children: 1, Infants: 1}) {
+		t.Errorf("Expected 3 passengers by type, got %d and %+v", ticket.Passengers, ticket.PassengerTypes)
+	}
+	if ticket.Price == nil || ticket.Price.BaseAmount != 368.15 {
+		t.Errorf("Expected one adult, child and infant fare of 368.15, got %+v", ticket.Price)
+	}
+
+	var inventory models.InventoryResponse
+	json.NewDecoder(send(http.MethodGet, "/admin/inventory/AA1234/"+departure, "").Body).Decode(&inventory)
+	if inventory.Balance == nil || inventory.Balance.Held[ticket.ConfirmationID] != 2 {
+		t.Errorf("Expected the infant to take no seat, got %+v", inventory.Balance)
+	}
+
+	invalid := map[string]string{
+		"infant-ratio":   `"passenger_types":{"ADT":1,"INF":2}}`,
+		"no-adult":       `"passenger_types":{"CHD":1}}`,
+		"count-mismatch": `"passengers":2,"passenger_types":{"ADT":1,"CHD":2}}`,
+		"reserved-label": `"passengers":2,"labels":{"passengers_inf":"1"}}`,
+	}
+	for name, body := range invalid {
+		if rec := send(http.MethodPost, "/ticket", booking+body); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d: %s", name, rec.Code, rec.Body.String())
+		}
+	}
+
+	rec = send(http.MethodPut, "/ticket/"+ticket.ConfirmationID, `{"passengers":1}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 dropping below the children and infants, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
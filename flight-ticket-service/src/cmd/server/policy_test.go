@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"flight-ticket-service/src/auth"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// TestPolicyCoversRoutes checks the default policy against the registered
+// routes: admin routes need the admin role, and anonymous requests to routes
+// that need a role are refused before reaching their handler
+func TestPolicyCoversRoutes(t *testing.T) {
+	router := newTestRouter(t).(chi.Router)
+	policy := auth.DefaultPolicy()
+	param := regexp.MustCompile(`\{[^}]+\}`)
+
+	protected := 0
+	err := chi.Walk(router, func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		path := strings.TrimSuffix(param.ReplaceAllString(strings.TrimSuffix(route, "/*"), "X"), "/")
+		if path == "" {
+			path = "/"
+		}
+		required := policy.Required(method, path)
+		if strings.HasPrefix(path, "/admin/") && !strings.HasPrefix(path, "/admin/ui") && required != auth.RoleAdmin {
+			t.Errorf("%s %s requires %s, expected admin", method, route, required)
+		}
+		if required == auth.RolePublic {
+			return nil
+		}
+
+		protected++
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("Anonymous %s %s: expected 401, got %d", method, route, rec.Code)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if protected == 0 {
+		t.Error("Expected routes that need a role")
+	}
+}
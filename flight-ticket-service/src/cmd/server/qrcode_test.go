@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+func TestBoardingPassQRCode(t *testing.T) {
+	router := newTestRouter(t)
+	send := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", "fuzz-key")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	departure := time.Now().UTC().Add(6 * time.Hour)
+
+	rec := send(http.MethodPost, "/ticket", `{"origin":"JFK","destination":"LAX","departure_date":"`+departure.Format("2006-01-02")+`","departure_time":"`+departure.Format("15:04")+`","passengers":1}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var ticket models.FlightTicket
+	json.NewDecoder(rec.Body).Decode(&ticket)
+	qrURL := "/ticket/" + ticket.ConfirmationID + "/qr?payload=bcbp&last_name=Doe"
+	if rec := send(http.MethodGet, qrURL, ""); rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for a ticket not checked in, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = send(http.MethodPost, "/ticket/"+ticket.ConfirmationID+"/checkin", `{"passengers":[{"first_name":"Jane","last_name":"Doe","seat":"12A"}],"compartment":"W"}`)
+	var checkIn models.CheckInResponse
+	json.NewDecoder(rec.Body).Decode(&checkIn)
+	if rec.Code != http.StatusOK || len(checkIn.BoardingPasses) != 1 {
+		t.Fatalf("Expected a boarding pass, got %d: %s", rec.Code, rec.Body.String())
+	}
+	pass := checkIn.BoardingPasses[0]
+	if pass.QRURL != "/ticket/"+ticket.ConfirmationID+"/qr?payload=bcbp&sequence=1" {
+		t.Errorf("Unexpected QR URL %s", pass.QRURL)
+	}
+	rec = send(http.MethodGet, pass.QRURL, "")
+	if rec.Code != http.StatusOK || rec.Header().Get("X-QR-Payload") != pass.BCBP {
+		t.Errorf("Expected the QR code of the boarding pass %q, got %d %q", pass.BCBP, rec.Code, rec.Header().Get("X-QR-Payload"))
+	}
+	rec = send(http.MethodGet, pass.QRURL+"&last_name=Smith&first_name=John&seat=1A&compartment=F", "")
+	if rec.Code != http.StatusOK || rec.Header().Get("X-QR-Payload") != pass.BCBP {
+		t.Errorf("Expected the passenger of the check-in, not the query, got %q", rec.Header().Get("X-QR-Payload"))
+	}
+	if rec := send(http.MethodGet, "/ticket/"+ticket.ConfirmationID+"/qr?payload=bcbp&sequence=2", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a sequence number not checked in, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+func TestRebookDisruptedTicket(t *testing.T) {
+	router := newTestRouter(t)
+	send := func(key, method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", key)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	book := func(flightNumber, destination, date, departureTime string, passengers int) models.FlightTicket {
+		var ticket models.FlightTicket
+		body := `{"origin":"JFK","destination":"` + destination + `","departure_date":"` + date + `","departure_time":"` + departureTime + `","flight_number":"` + flightNumber + `","passengers":` + strconv.Itoa(passengers) + `}`
+		rec := send("desk-key", http.MethodPost, "/ticket", body)
+		json.NewDecoder(rec.Body).Decode(&ticket)
+		if ticket.ConfirmationID == "" {
+			t.Fatalf("Failed to book %s: %d %s", flightNumber, rec.Code, rec.Body.String())
+		}
+		return ticket
+	}
+
+	departure := time.Now().UTC().AddDate(0, 0, 40)
+	date, nextDay := departure.Format("2006-01-02"), departure.AddDate(0, 0, 1).Format("2006-01-02")
+	disrupted := book("AA1234", "LAX", date, "09:00", 2)
+	book("AA1250", "LAX", date, "14:30", 1)
+	book("AA1300", "LAX", nextDay, "08:00", 1)
+	book("AA1400", "SFO", date, "12:00", 1)
+
+	// A full flight is not an option
+	if rec := send("fuzz-key", http.MethodPost, "/admin/inventory/AA1500/"+date+"/adjustments", `{"seats":1,"reason":"Initial capacity"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("Failed to open inventory: %d %s", rec.Code, rec.Body.String())
+	}
+	book("AA1500", "LAX", date, "16:00", 1)
+
+	optionsURL := "/ticket/" + disrupted.ConfirmationID + "/rebooking-options"
+	if rec := send("desk-key", http.MethodGet, optionsURL, ""); rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for a confirmed ticket, got %d", rec.Code)
+	}
+	if rec := send("desk-key", http.MethodGet, "/ticket/NOPE00/rebooking-options", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing ticket, got %d", rec.Code)
+	}
+
+	rec := send("fuzz-key", http.MethodPost, "/admin/flights/AA1234/"+date+"/delay", `{"delay_minutes":240,"disrupt":true}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 for the delay, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var listed models.RebookingOptionsResponse
+	json.NewDecoder(send("desk-key", http.MethodGet, optionsURL, "").Body).Decode(&listed)
+	if listed.Count != 2 || listed.Options[0].FlightNumber != "AA1250" || listed.Options[1].FlightNumber != "AA1300" {
+		t.Fatalf("Expected AA1250 and AA1300, got %+v", listed)
+	}
+	if option := listed.Options[0]; option.DelayMinutes != 90 || option.DepartureDate != date {
+		t.Errorf("Expected AA1250 90 minutes after the delayed departure, got %+v", option)
+	}
+
+	if rec := send("desk-key", http.MethodPost, optionsURL+"/AA9999-202401010000/accept", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown option, got %d", rec.Code)
+	}
+	rec = send("desk-key", http.MethodPost, optionsURL+"/"+listed.Options[1].ID+"/accept", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var rebooked models.FlightTicket
+	json.NewDecoder(rec.Body).Decode(&rebooked)
+	if rebooked.Status != "CONFIRMED" || rebooked.FlightNumber != "AA1300" || rebooked.DepartureDate.Format("2006-01-02") != nextDay || rebooked.DepartureTime.Hour() != 8 {
+		t.Errorf("Expected the ticket confirmed on AA1300, got %s on %s at %v", rebooked.Status, rebooked.FlightNumber, rebooked.DepartureTime)
+	}
+	if rebooked.Price == nil || rebooked.Price.Amount != disrupted.Price.Amount {
+		t.Errorf("Expected the price kept, got %+v", rebooked.Price)
+	}
+
+	// A retried accept finds the ticket confirmed
+	if rec := send("desk-key", http.MethodPost, optionsURL+"/"+listed.Options[1].ID+"/accept", ""); rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 accepting twice, got %d", rec.Code)
+	}
+}
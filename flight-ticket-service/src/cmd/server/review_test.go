@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+func TestRiskyBookingHeldForReview(t *testing.T) {
+	// A second booking of a route and date by the same key is held
+	t.Setenv("RISK_SCORING_ENABLED", "true")
+	t.Setenv("RISK_ROUTE_DATE_LIMIT", "1")
+	t.Setenv("RISK_REVIEW_SCORE", "30")
+	router := newTestRouter(t)
+	send := func(key, method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", key)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	departure := time.Now().UTC().AddDate(0, 0, 20).Format("2006-01-02")
+	book := func() models.FlightTicket {
+		var ticket models.FlightTicket
+		rec := send("desk-key", http.MethodPost, "/ticket", `{"origin":"JFK","destination":"LAX","departure_date":"`+departure+`","departure_time":"09:00","flight_number":"AA4321","passengers":1}`)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body.String())
+		}
+		json.NewDecoder(rec.Body).Decode(&ticket)
+		return ticket
+	}
+
+	if first := book(); first.Status != "CONFIRMED" {
+		t.Errorf("Expected the first booking confirmed, got %s", first.Status)
+	}
+	held := book()
+	if held.Status != models.ReviewStatus || held.Labels[models.RiskReasonsLabel] != "repeated_route_date" {
+		t.Fatalf("Expected the second booking held for repeated_route_date, got %s with %v", held.Status, held.Labels)
+	}
+
+	ticketURL := "/ticket/" + held.ConfirmationID
+	if rec := send("desk-key", http.MethodPut, ticketURL, `{"status":"CONFIRMED"}`); rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 confirming a held booking as an agent, got %d", rec.Code)
+	}
+	if rec := send("desk-key", http.MethodPost, ticketURL+"/checkin", `{"passengers":[{"passenger_name":"DOE/JANE"}]}`); rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 checking in a held booking, got %d", rec.Code)
+	}
+	if rec := send("desk-key", http.MethodPut, ticketURL, `{"labels":{"risk_score":"0"}}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 setting a risk label, got %d", rec.Code)
+	}
+
+	if rec := send("desk-key", http.MethodGet, "/admin/review-queue", ""); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for an agent, got %d", rec.Code)
+	}
+	var queue models.ReviewQueueResponse
+	json.NewDecoder(send("fuzz-key", http.MethodGet, "/admin/review-queue", "").Body).Decode(&queue)
+	if queue.Count != 1 || queue.Tickets[0].ConfirmationID != held.ConfirmationID {
+		t.Fatalf("Expected the held booking queued, got %+v", queue)
+	}
+	if risk := queue.Tickets[0].Risk; risk == nil || risk.Score != 30 || len(risk.Reasons) != 1 {
+		t.Errorf("Expected a risk score of 30, got %+v", risk)
+	}
+
+	if rec := send("fuzz-key", http.MethodPut, ticketURL, `{"status":"CONFIRMED"}`); rec.Code != http.StatusOK {
+		t.Fatalf("Expected an admin to confirm the booking, got %d: %s", rec.Code, rec.Body.String())
+	}
+	queue = models.ReviewQueueResponse{}
+	json.NewDecoder(send("fuzz-key", http.MethodGet, "/admin/review-queue", "").Body).Decode(&queue)
+	if queue.Count != 0 || queue.Tickets == nil {
+		t.Errorf("Expected an empty queue, got %+v", queue)
+	}
+}
+
+func TestReviewDecisions(t *testing.T) {
+	router := newTestRouter(t)
+	send := func(key, method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", key)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := send("fuzz-key", http.MethodPut, "/admin/rules/groups-review", `{"type":"max_passengers","max_passengers":4,"review":true}`); rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 saving the rule, got %d: %s", rec.Code, rec.Body.String())
+	}
+	departure := time.Now().UTC().AddDate(0, 0, 20).Format("2006-01-02")
+	book := func(passengers string) models.FlightTicket {
+		var ticket models.FlightTicket
+		rec := send("desk-key", http.MethodPost, "/ticket", `{"origin":"JFK","destination":"LAX","departure_date":"`+departure+`","departure_time":"09:00","flight_number":"AA4321","passengers":`+passengers+`}`)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body.String())
+		}
+		json.NewDecoder(rec.Body).Decode(&ticket)
+		return ticket
+	}
+
+	if confirmed := book("2"); confirmed.Status != "CONFIRMED" {
+		t.Errorf("Expected a small booking confirmed, got %s", confirmed.Status)
+	} else if rec := send("fuzz-key", http.MethodPost, "/admin/review-queue/"+confirmed.ConfirmationID+"/approve", ""); rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 approving a ticket not under review, got %d", rec.Code)
+	}
+	approved, rejected := book("6"), book("5")
+	if approved.Status != models.ReviewStatus || approved.Labels[models.ReviewRuleLabel] != "groups-review" {
+		t.Fatalf("Expected the group held by groups-review, got %s with %v", approved.Status, approved.Labels)
+	}
+	var queue models.ReviewQueueResponse
+	json.NewDecoder(send("fuzz-key", http.MethodGet, "/admin/review-queue", "").Body).Decode(&queue)
+	if queue.Count != 2 || queue.Tickets[0].Risk == nil || queue.Tickets[0].Risk.Rule != "groups-review" {
+		t.Fatalf("Expected both groups queued with their rule, got %+v", queue)
+	}
+	// Changes breaking the rule are still refused
+	if rec := send("desk-key", http.MethodPut, "/ticket/"+approved.ConfirmationID, `{"passengers":7}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 changing a booking against the rule, got %d", rec.Code)
+	}
+
+	approveURL := "/admin/review-queue/" + approved.ConfirmationID + "/approve"
+	if rec := send("desk-key", http.MethodPost, approveURL, ""); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for an agent, got %d", rec.Code)
+	}
+	if rec := send("fuzz-key", http.MethodPost, approveURL, `{"reason":"`+strings.Repeat("x", models.MaxReviewReasonLength+1)+`"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a long reason, got %d", rec.Code)
+	}
+	var ticket models.FlightTicket
+	rec := send("fuzz-key", http.MethodPost, approveURL, `{"reason":"School trip confirmed"}`)
+	json.NewDecoder(rec.Body).Decode(&ticket)
+	if rec.Code != http.StatusOK || ticket.Status != "CONFIRMED" {
+		t.Fatalf("Expected the booking confirmed, got %d: %+v", rec.Code, ticket)
+	}
+	if rec := send("fuzz-key", http.MethodPost, approveURL, ""); rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 approving twice, got %d", rec.Code)
+	}
+
+	ticket = models.FlightTicket{}
+	rec = send("fuzz-key", http.MethodPost, "/admin/review-queue/"+rejected.ConfirmationID+"/reject", "")
+	json.NewDecoder(rec.Body).Decode(&ticket)
+	if rec.Code != http.StatusOK || ticket.Status != "CANCELLED" {
+		t.Fatalf("Expected the booking cancelled, got %d: %+v", rec.Code, ticket)
+	}
+	if rec := send("fuzz-key", http.MethodPost, "/admin/review-queue/NOPE99/reject", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown ticket, got %d", rec.Code)
+	}
+
+	// The reviewer is kept in the ticket's notes
+	for id, want := range map[string]string{
+		approved.ConfirmationID: "Approved after review by fuzz: School trip confirmed",
+		rejected.ConfirmationID: "Rejected after review by fuzz",
+	} {
+		var notes models.NoteListResponse
+		json.NewDecoder(send("fuzz-key", http.MethodGet, "/ticket/"+id+"/notes", "").Body).Decode(&notes)
+		if notes.Count != 1 || notes.Notes[0].Text != want || notes.Notes[0].Author != "fuzz" {
+			t.Errorf("Expected the note %q on %s, got %+v", want, id, notes)
+		}
+	}
+	queue = models.ReviewQueueResponse{}
+	json.NewDecoder(send("fuzz-key", http.MethodGet, "/admin/review-queue", "").Body).Decode(&queue)
+	if queue.Count != 0 {
+		t.Errorf("Expected an empty queue, got %+v", queue)
+	}
+}
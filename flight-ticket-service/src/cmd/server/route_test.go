@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+func TestRouteSearch(t *testing.T) {
+	router := newTestRouter(t)
+	send := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("X-API-Key", "fuzz-key")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	departure := time.Now().UTC().AddDate(0, 0, 20).Truncate(24 * time.Hour).Add(8 * time.Hour)
+	book := func(flightNumber, origin, destination string, departure time.Time) {
+		body := fmt.Sprintf(`{"origin": %q, "destination": %q, "departure_date": %q, "departure_time": %q, "flight_number": %q, "passengers": 1}`,
+			origin, destination, departure.Format("2006-01-02"), departure.Format("15:04"), flightNumber)
+		if rec := send(http.MethodPost, "/ticket", body); rec.Code != http.StatusCreated {
+			t.Fatalf("Expected 201 booking %s, got %d: %s", flightNumber, rec.Code, rec.Body.String())
+		}
+	}
+	book("AA1", "JFK", "LAX", departure.Add(time.Hour))
+	book("AA2", "JFK", "ORD", departure)
+	book("AA3", "ORD", "LAX", departure.Add(3*time.Hour)) // about an hour after landing
+	book("AA4", "ORD", "LAX", departure.Add(5*time.Hour))
+
+	search := func() models.RouteSearchResponse {
+		rec := send(http.MethodGet, "/routes/search?origin=jfk&destination=LAX&date="+departure.Format("2006-01-02"), "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var response models.RouteSearchResponse
+		json.NewDecoder(rec.Body).Decode(&response)
+		return response
+	}
+	if response := search(); response.Count != 3 || response.Options[0].Stops != 0 || response.Options[1].Via != "ORD" {
+		t.Errorf("Expected a direct and two one-stop options, got %+v", response)
+	}
+
+	// Minimum connection time rules apply to the connecting airport
+	if rec := send(http.MethodPut, "/admin/rules/mct-ord", `{"type": "min_connection_time", "origin": "ORD", "min_connection_minutes": 120}`); rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 saving the rule, got %d", rec.Code)
+	}
+	if response := search(); response.Count != 2 || response.Options[1].Flights[1].FlightNumber != "AA4" {
+		t.Errorf("Expected the short connection ruled out, got %+v", response)
+	}
+
+	for _, target := range []string{"/routes/search?origin=JFK&destination=JFK&date=2025-01-01", "/routes/search?origin=JFK&destination=LAX", "/routes/search?destination=LAX&date=2025-01-01"} {
+		if rec := send(http.MethodGet, target, ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", target, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"flight-ticket-service/src/analytics"
+	"flight-ticket-service/src/auth"
+	"flight-ticket-service/src/clientcert"
+	"flight-ticket-service/src/debuglog"
+	"flight-ticket-service/src/errorreport"
+	"flight-ticket-service/src/featureflags"
+	"flight-ticket-service/src/handlers"
+	"flight-ticket-service/src/maintenance"
+	"flight-ticket-service/src/metrics"
+	"flight-ticket-service/src/opsnotify"
+	"flight-ticket-service/src/quota"
+	"flight-ticket-service/src/recording"
+	"flight-ticket-service/src/services"
+	"flight-ticket-service/src/tenants"
+	"flight-ticket-service/src/version"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	httpSwagger "github.com/swaggo/http-swagger"
+)
+
+// routes holds the handlers and middleware dependencies of the router
+type routes struct {
+	keyStore    *auth.KeyStore
+	policy      *auth.Policy // endpoint roles; the default policy when nil
+	clientCerts clientcert.Config
+	usage       *services.UsageTracker
+	budget      services.OperationBudget // per-request operation budget
+	apiUsage    *analytics.Recorder      // requests per API key and month
+	slo         *metrics.Tracker
+	maintenance *maintenance.Switch
+	flags       *featureflags.Store
+	quota       *quota.Limiter
+	recorder    *recording.Recorder         // optional
+	errorRate   *opsnotify.ErrorRateMonitor // optional
+	publicURL   *url.URL                    // optional external base URL for the OpenAPI spec
+	cors        *corsPolicy
+	tenantCache *tenants.Registry
+
+	tickets       *handlers.TicketHandler
+	advisories    *handlers.AdvisoryHandler
+	qr            *handlers.QRHandler
+	checkIn       *handlers.CheckInHandler
+	notifications *handlers.NotificationHandler
+	notes         *handlers.NoteHandler
+	views         *handlers.ViewHandler
+	manifests     *handlers.ManifestHandler
+	seatHolds     *handlers.SeatHandler
+	upgrades      *handlers.UpgradeHandler
+	rebooking     *handlers.RebookingHandler
+	vouchers      *handlers.VoucherHandler
+	admin         *handlers.AdminHandler
+	delays        *handlers.FlightDelayHandler
+	quotas        *handlers.QuotaHandler
+	bookingStats  *handlers.BookingStatsHandler
+	usageReport   *handlers.APIUsageHandler
+	forecasts     *handlers.ForecastHandler
+	inventory     *handlers.InventoryHandler
+	adminUI       *handlers.AdminUIHandler
+	jobs          *handlers.JobHandler
+	bulkCancel    *handlers.BulkCancelHandler
+	quarantine    *handlers.QuarantineHandler
+	review        *handlers.ReviewHandler
+	locks         *handlers.LockHandler
+	health        *handlers.HealthHandler
+	config        *handlers.ConfigHandler
+	tenants       *handlers.TenantHandler
+	rules         *handlers.RuleHandler
+	routeSearch   *handlers.RouteHandler
+	fares         *handlers.FareHandler
+	travelers     *handlers.TravelerHandler
+	attachments   *handlers.AttachmentHandler // optional
+
+	// Ticket routes of sandbox requests, and the API keys that always use them; nil when the sandbox is disabled
+	sandboxTickets *handlers.TicketHandler
+	sandboxKeys    map[string]bool
+
+	// recoverPanics turns handler panics into reported 500 responses; tests leave it off so panics surface
+	recoverPanics bool
+	errorReporter errorreport.Reporter
+}
+
+// newRouter registers the middleware and API routes
+func newRouter(rt routes) chi.Router {
+	r := chi.NewRouter()
+	// Set before the routes so sub-routers inherit them
+	r.NotFound(handlers.NotFound)
+	r.MethodNotAllowed(handlers.MethodNotAllowed)
+
+	r.Use(debuglog.AccessLog)
+	r.Use(middleware.RequestID)
+	r.Use(middleware.RealIP)
+	r.Use(clientcert.Middleware(rt.clientCerts))
+	if rt.recoverPanics {
+		r.Use(errorreport.Middleware(rt.errorReporter))
+	}
+	r.Use(middleware.Timeout(60 * time.Second))
+	r.Use(rt.keyStore.Authenticate)
+	r.Use(rt.tenantCache.Middleware) // the tenant follows the API key
+	r.Use(debuglog.Middleware)       // before the SLO middleware, which records the sampled trace
+	r.Use(rt.slo.Middleware)
+	if rt.recorder != nil {
+		r.Use(rt.recorder.Middleware)
+	}
+	if rt.errorRate != nil {
+		r.Use(rt.errorRate.Middleware)
+	}
+	r.Use(handlers.UsageMiddleware(rt.usage, rt.budget))
+	r.Use(rt.apiUsage.Middleware)
+
+	// CORS middleware; CORS_ALLOWED_ORIGINS can be reloaded
+	r.Use(rt.cors.Handler)
+
+	// Maintenance mode (health, version, metrics and admin stay available)
+	r.Use(rt.maintenance.Middleware)
+
+	// Roles required by endpoints, declared in one policy rather than per route
+	policy := rt.policy
+	if policy == nil {
+		policy = auth.DefaultPolicy()
+	}
+	r.Use(policy.Authorize)
+
+	// Sandbox requests are served by their own routes, backed by the sandbox store
+	var sandbox http.Handler
+	if rt.sandboxTickets != nil {
+		sandbox = rt.sandboxRouter()
+	}
+	r.Use(handlers.SandboxMiddleware(sandbox, rt.sandboxKeys))
+
+	// Health check endpoint
+	r.Get("/health", handlers.HealthCheck)
+	r.Get("/health/details", rt.health.GetHealthDetails)
+	r.Get("/version", handlers.GetVersion)
+
+	// Prometheus metrics
+	r.Handle("/metrics", promhttp.Handler())
+
+	// Root endpoint
+	// @Summary API Information
+	// @Description Get basic information about the Flight Ticket Service API
+	// @Tags health
+	// @Accept json
+	// @Produce json
+	// @Success 200 {object} map[string]string "API information"
+	// @Router / [get]
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		log.Println("Called /")
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"message": "Flight Ticket Service API",
+			"version": version.Version,
+			"swagger": "/swagger/",
+		})
+	})
+
+	// Swagger documentation endpoint; the spec names the host the UI was loaded from
+	r.Get("/swagger/doc.json", handlers.SwaggerDoc(rt.publicURL))
+	r.Get("/swagger/*", httpSwagger.Handler(
+		httpSwagger.URL("/swagger/doc.json"),   // Use relative URL for Cloud Run compatibility
+		httpSwagger.PersistAuthorization(true), // Keep the API key across page reloads
+	))
+
+	// Ticket endpoints
+	r.Route("/ticket", func(r chi.Router) {
+		r.With(rt.quota.Middleware).Post("/", rt.tickets.CreateTicket)                     // Create new ticket
+		r.Get("/{confirmationID}", rt.tickets.GetTicket)                                   // Get ticket by confirmation ID
+		r.Put("/{confirmationID}", rt.tickets.UpdateTicket)                                // Update ticket
+		r.Delete("/{confirmationID}", rt.tickets.DeleteTicket)                             // Cancel ticket
+		r.Post("/{confirmationID}/undo", rt.tickets.UndoTicket)                            // Revert the last change
+		r.Post("/{confirmationID}/lock", rt.locks.LockTicket)                              // Take or renew an edit lock
+		r.Delete("/{confirmationID}/lock", rt.locks.UnlockTicket)                          // Release an edit lock
+		r.Get("/{confirmationID}/history/diff", rt.tickets.GetTicketHistoryDiff)           // Changes between two revisions
+		r.Get("/{confirmationID}/advisories", rt.advisories.GetAdvisories)                 // Weather advisories
+		r.Get("/{confirmationID}/qr", rt.qr.GetQRCode)                                     // QR code for gate scanning
+		r.Post("/{confirmationID}/checkin", rt.checkIn.CheckIn)                            // Check in and issue boarding passes
+		r.Get("/{confirmationID}/notifications", rt.notifications.GetPreferences)          // Notification preferences
+		r.Put("/{confirmationID}/notifications", rt.notifications.UpdatePreferences)       // Update notification preferences
+		r.Get("/{confirmationID}/upgrades", rt.upgrades.ListTicketUpgrades)                // Upgrade offers
+		r.Post("/{confirmationID}/upgrades/{offerID}/accept", rt.upgrades.AcceptUpgrade)   // Accept an offer or bid
+		r.Post("/{confirmationID}/upgrades/{offerID}/decline", rt.upgrades.DeclineUpgrade) // Decline an offer
+
+		// Disrupted tickets are moved to another flight by the traveller or their agent
+		r.Get("/{confirmationID}/rebooking-options", rt.rebooking.GetRebookingOptions)                      // Flights to move to
+		r.Post("/{confirmationID}/rebooking-options/{optionID}/accept", rt.rebooking.AcceptRebookingOption) // Rebook on a flight
+		r.Get("/{confirmationID}/vouchers", rt.vouchers.ListTicketVouchers)                                 // Vouchers for a cancelled flight
+
+		// Notes are internal remarks for agents
+		r.Post("/{confirmationID}/notes", rt.notes.CreateNote) // Add note
+		r.Get("/{confirmationID}/notes", rt.notes.ListNotes)   // List notes
+
+		if rt.attachments != nil {
+			r.Post("/{confirmationID}/attachments", rt.attachments.CreateAttachment)            // Attach document
+			r.Get("/{confirmationID}/attachments", rt.attachments.ListAttachments)              // List attachments
+			r.Get("/{confirmationID}/attachments/{attachmentID}", rt.attachments.GetAttachment) // Get attachment
+		}
+	})
+
+	// Travel credit issued for cancelled flights
+	r.Get("/vouchers/{code}", rt.vouchers.GetVoucher)            // Balance and redemptions
+	r.Post("/vouchers/{code}/redeem", rt.vouchers.RedeemVoucher) // Take the credit off a booking
+
+	// Daily booking quota of the caller
+	r.Get("/quota", rt.quotas.GetQuota)
+
+	// Booking rules that bookings and changes are checked against
+	r.Get("/rules", rt.rules.GetRules)
+
+	// Direct and one-stop connections on the route network
+	r.Get("/routes/search", rt.routeSearch.SearchRoutes)
+
+	// Lowest fare of each day of a month
+	r.Get("/fares/calendar", rt.fares.GetFareCalendar)
+
+	// Traveler profiles of the caller, referenced by ID when booking
+	r.Route("/travelers", func(r chi.Router) {
+		r.Get("/", rt.travelers.ListTravelers)                 // List travelers
+		r.Post("/", rt.travelers.CreateTraveler)               // Save traveler
+		r.Get("/{travelerID}", rt.travelers.GetTraveler)       // Get traveler
+		r.Put("/{travelerID}", rt.travelers.UpdateTraveler)    // Replace traveler
+		r.Delete("/{travelerID}", rt.travelers.DeleteTraveler) // Delete traveler
+	})
+
+	// List all tickets endpoint
+	r.Get("/tickets", rt.tickets.ListTickets)
+	r.With(rt.flags.Require(featureflags.Search)).Get("/tickets/search", rt.tickets.SearchTickets) // Search by labels and fields
+	r.Post("/tickets/bulk-cancel", rt.bulkCancel.BulkCancel)                                       // Preview or run a bulk cancellation
+
+	// Departure manifests list passenger details for gate agents
+	r.Get("/flights/{flightNumber}/{date}/manifest", rt.manifests.GetManifest)
+
+	// Seat holds keep chosen seats for a shopper until the booking is made
+	r.Route("/flights/{flightNumber}/{date}/seats", func(r chi.Router) {
+		r.Get("/", rt.seatHolds.GetSeats)                        // Taken seats
+		r.Post("/hold", rt.seatHolds.HoldSeats)                  // Hold seats
+		r.Delete("/hold/{holdID}", rt.seatHolds.ReleaseSeatHold) // Release a hold
+	})
+
+	// Background document and export jobs
+	r.Route("/jobs", func(r chi.Router) {
+		r.Post("/", rt.jobs.CreateJob)                 // Submit a job
+		r.Get("/{jobID}", rt.jobs.GetJob)              // Job status
+		r.Get("/{jobID}/result", rt.jobs.GetJobResult) // Download the result
+	})
+
+	// Saved views are named searches shared by the CLI and dashboards
+	r.Route("/views", func(r chi.Router) {
+		r.Use(rt.flags.Require(featureflags.Search))
+		r.Get("/", rt.views.ListViews)                    // List views
+		r.Post("/", rt.views.CreateView)                  // Save view
+		r.Get("/{name}", rt.views.GetView)                // Get view
+		r.Put("/{name}", rt.views.UpdateView)             // Update view
+		r.Delete("/{name}", rt.views.DeleteView)          // Delete view
+		r.Get("/{name}/results", rt.views.GetViewResults) // Run view
+	})
+
+	// Admin web UI; signs in with an admin API key and keeps it in a session cookie
+	r.Mount("/admin/ui", rt.adminUI.Routes())
+
+	// Admin endpoints
+	r.Route("/admin", func(r chi.Router) {
+		r.Get("/stats", rt.admin.GetStats)                                                        // Firestore usage and cost estimate
+		r.Get("/stats/bookings", rt.bookingStats.GetBookingStats)                                 // Booking counters per day and route
+		r.Get("/forecast", rt.forecasts.GetForecast)                                              // Booking demand forecast per route
+		r.Get("/usage", rt.usageReport.GetAPIUsage)                                               // Requests per API key and month
+		r.Get("/flags", rt.admin.GetFeatureFlags)                                                 // Feature flag values
+		r.Get("/config", rt.config.GetConfig)                                                     // Reloadable settings and recent changes
+		r.Get("/tenants", rt.tenants.ListTenants)                                                 // Tenants and their overrides
+		r.Get("/tenants/{tenantID}", rt.tenants.GetTenant)                                        // Tenant overrides
+		r.Put("/tenants/{tenantID}", rt.tenants.SaveTenant)                                       // Create or replace a tenant
+		r.Delete("/tenants/{tenantID}", rt.tenants.DeleteTenant)                                  // Delete a tenant
+		r.Get("/rules", rt.rules.ListRules)                                                       // Booking rules, including disabled ones
+		r.Put("/rules/{ruleID}", rt.rules.SaveRule)                                               // Create or replace a booking rule
+		r.Delete("/rules/{ruleID}", rt.rules.DeleteRule)                                          // Delete a booking rule
+		r.Get("/maintenance", rt.admin.GetMaintenance)                                            // Maintenance mode state
+		r.Put("/maintenance", rt.admin.SetMaintenance)                                            // Read-only or full maintenance mode
+		r.Post("/flights/{flightNumber}/{date}/delay", rt.delays.DelayFlight)                     // Simulate a flight delay
+		r.Get("/upgrades/{flightNumber}/{date}", rt.upgrades.GetDepartureUpgrades)                // Upgrade offers and cabin seats
+		r.Post("/upgrades/{flightNumber}/{date}/offers", rt.upgrades.OfferUpgrades)               // Offer upgrades to eligible tickets
+		r.Post("/upgrades/{flightNumber}/{date}/award", rt.upgrades.AwardUpgrades)                // Award bids, highest first
+		r.Get("/inventory/{flightNumber}/{date}", rt.inventory.GetInventory)                      // Seat balance and ledger
+		r.Post("/inventory/{flightNumber}/{date}/adjustments", rt.inventory.AdjustInventory)      // Put seats on or off sale
+		r.Get("/inventory/{flightNumber}/{date}/reconciliation", rt.inventory.ReconcileInventory) // Audit the seat ledger
+		r.Get("/inventory/{flightNumber}/{date}/snapshot", rt.inventory.GetInventorySnapshot)     // Sold, held and available seats
+		r.Put("/inventory/{flightNumber}/{date}/limits", rt.inventory.SetInventoryLimits)         // Overbooking and sales freeze
+		r.Get("/quarantine", rt.quarantine.ListQuarantined)                                       // Unreadable ticket documents
+		r.Get("/quarantine/{confirmationID}", rt.quarantine.GetQuarantined)                       // Why a ticket was quarantined
+		r.Post("/quarantine/{confirmationID}/repair", rt.quarantine.RepairQuarantined)            // Fix and release a quarantined ticket
+		r.Get("/review-queue", rt.review.ListReviewQueue)                                         // Bookings held for review
+		r.Post("/review-queue/{confirmationID}/approve", rt.review.ApproveBooking)                // Confirm a held booking
+		r.Post("/review-queue/{confirmationID}/reject", rt.review.RejectBooking)                  // Cancel a held booking
+		r.Get("/debug/vars", rt.admin.GetDebugVars)                                               // Runtime diagnostics
+		r.Mount("/debug/pprof", handlers.Profiler())                                              // CPU, heap and goroutine profiles
+	})
+
+	return r
+}
+
+// sandboxRouter registers the routes available to sandbox requests: the
+// ticket endpoints, on sandbox tickets, and health checks. Everything else
+// only serves live tickets and is not found in the sandbox.
+func (rt routes) sandboxRouter() http.Handler {
+	r := chi.NewRouter()
+	r.NotFound(handlers.SandboxNotAvailable)
+	r.MethodNotAllowed(handlers.MethodNotAllowed)
+
+	r.Get("/health", handlers.HealthCheck)
+	r.Get("/version", handlers.GetVersion)
+
+	r.Route("/ticket", func(r chi.Router) {
+		r.With(rt.quota.Middleware).Post("/", rt.sandboxTickets.CreateTicket)
+		r.Get("/{confirmationID}", rt.sandboxTickets.GetTicket)
+		r.Put("/{confirmationID}", rt.sandboxTickets.UpdateTicket)
+		r.Delete("/{confirmationID}", rt.sandboxTickets.DeleteTicket)
+	})
+	r.Get("/tickets", rt.sandboxTickets.ListTickets)
+	r.With(rt.flags.Require(featureflags.Search)).Get("/tickets/search", rt.sandboxTickets.SearchTickets)
+
+	return r
+}
+
+// routeMethods orders the methods of a route in the route table
+var routeMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions, http.MethodConnect, http.MethodTrace}
+
+// routeTable lists every route of r with its methods, as logged at startup.
+// Routes served for any method, like /metrics, are listed with "*".
+func routeTable(r chi.Routes) []string {
+	var patterns []string
+	methods := make(map[string]map[string]bool)
+	chi.Walk(r, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if methods[route] == nil {
+			methods[route] = make(map[string]bool)
+			patterns = append(patterns, route)
+		}
+		methods[route][method] = true
+		return nil
+	})
+
+	table := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		var names []string
+		for _, method := range routeMethods {
+			if methods[pattern][method] {
+				names = append(names, method)
+			}
+		}
+		if len(names) == len(routeMethods) {
+			names = []string{"*"}
+		}
+		table = append(table, fmt.Sprintf("%-14s %s", strings.Join(names, ","), pattern))
+	}
+	return table
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestRouteTable(t *testing.T) {
+	table := strings.Join(routeTable(newTestRouter(t).(chi.Routes)), "\n")
+	for _, want := range []string{
+		"GET,PUT,DELETE /ticket/{confirmationID}\n",
+		"POST           /ticket/{confirmationID}/undo\n",
+		"GET            /ticket/{confirmationID}/history/diff\n",
+		"*              /metrics\n",
+		"GET            /admin/debug/pprof/\n",
+	} {
+		if !strings.Contains(table+"\n", want) {
+			t.Errorf("Expected %q in the route table:\n%s", want, table)
+		}
+	}
+}
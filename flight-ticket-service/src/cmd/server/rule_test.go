@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+func TestBookingRules(t *testing.T) {
+	router := newTestRouter(t)
+	send := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("X-API-Key", "fuzz-key")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	departure := time.Now().UTC().AddDate(0, 0, 20).Truncate(time.Hour)
+	booking := func(origin, destination string, departure time.Time, passengers int, labels string) string {
+		return fmt.Sprintf(`{"origin": %q, "destination": %q, "departure_date": %q, "departure_time": %q, "flight_number": "AA1234", "passengers": %d, "labels": %s}`,
+			origin, destination, departure.Format("2006-01-02"), departure.Format("15:04"), passengers, labels)
+	}
+
+	for id, body := range map[string]string{
+		"max-2":  `{"type": "max_passengers", "max_passengers": 2, "origin": "JFK"}`,
+		"mct":    `{"type": "min_connection_time", "min_connection_minutes": 90}`,
+		"no-sea": `{"type": "blocked_route", "destination": "SEA", "enabled": false}`,
+	} {
+		if rec := send(http.MethodPut, "/admin/rules/"+id, body); rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200 saving %s, got %d: %s", id, rec.Code, rec.Body.String())
+		}
+	}
+	if rec := send(http.MethodPut, "/admin/rules/bad", `{"type": "max_passengers"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid rule, got %d", rec.Code)
+	}
+
+	var listed models.RulesResponse
+	json.NewDecoder(send(http.MethodGet, "/rules", "").Body).Decode(&listed)
+	if listed.Count != 2 || listed.Rules[0].ID != "max-2" || listed.Tenant != nil {
+		t.Errorf("Expected the two enabled rules, got %+v", listed)
+	}
+	json.NewDecoder(send(http.MethodGet, "/admin/rules", "").Body).Decode(&listed)
+	if listed.Count != 3 {
+		t.Errorf("Expected every rule for admins, got %d", listed.Count)
+	}
+
+	if rec := send(http.MethodPost, "/ticket", booking("JFK", "ORD", departure, 3, `{}`)); rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), "max-2") {
+		t.Errorf("Expected 422 naming the rule, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := send(http.MethodPost, "/ticket", booking("JFK", "SEA", departure, 1, `{}`)); rec.Code != http.StatusCreated {
+		t.Errorf("Expected disabled rules skipped, got %d", rec.Code)
+	}
+
+	// Connections between the tickets of an itinerary
+	rec := send(http.MethodPost, "/ticket", booking("JFK", "ORD", departure, 2, `{"itinerary": "trip"}`))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201 for the first leg, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := send(http.MethodPost, "/ticket", booking("ORD", "LAX", departure.Add(3*time.Hour), 2, `{"itinerary": "trip"}`)); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for a short connection, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = send(http.MethodPost, "/ticket", booking("ORD", "LAX", departure.Add(5*time.Hour), 2, `{"itinerary": "trip"}`))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201 for a long enough connection, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var second models.FlightTicket
+	json.NewDecoder(rec.Body).Decode(&second)
+
+	// Changes are checked too
+	moved := departure.Add(3 * time.Hour)
+	update := fmt.Sprintf(`{"departure_date": %q, "departure_time": %q}`, moved.Format("2006-01-02"), moved.Format("15:04"))
+	if rec := send(http.MethodPut, "/ticket/"+second.ConfirmationID, update); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 moving the second leg closer, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := send(http.MethodPut, "/ticket/"+second.ConfirmationID, `{"labels": {"itinerary": "other"}}`); rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 moving the leg to another itinerary, got %d", rec.Code)
+	}
+
+	if rec := send(http.MethodDelete, "/admin/rules/max-2", ""); rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 deleting the rule, got %d", rec.Code)
+	}
+	if rec := send(http.MethodDelete, "/admin/rules/max-2", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a deleted rule, got %d", rec.Code)
+	}
+	if rec := send(http.MethodPost, "/ticket", booking("JFK", "LAX", departure, 3, `{}`)); rec.Code != http.StatusCreated {
+		t.Errorf("Expected 201 after deleting the rule, got %d", rec.Code)
+	}
+}
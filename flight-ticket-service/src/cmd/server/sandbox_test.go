@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"flight-ticket-service/src/models"
+)
+
+func TestSandboxKeepsTicketsApart(t *testing.T) {
+	router := newTestRouter(t)
+	send := func(method, target, sandbox, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("X-API-Key", "fuzz-key")
+		if sandbox != "" {
+			req.Header.Set("X-Sandbox", sandbox)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send(http.MethodPost, "/ticket", "true", `{"origin": "JFK", "destination": "LAX", "departure_date": "2030-12-25", "departure_time": "14:30", "passengers": 1}`)
+	if rec.Code != http.StatusCreated || rec.Header().Get("X-Sandbox") != "true" {
+		t.Fatalf("Expected a created sandbox ticket, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created models.FlightTicket
+	json.NewDecoder(rec.Body).Decode(&created)
+	if created.ExpiresAt == nil {
+		t.Error("Expected the sandbox ticket to expire")
+	}
+
+	if rec := send(http.MethodGet, "/ticket/"+created.ConfirmationID, "true", ""); rec.Code != http.StatusOK {
+		t.Errorf("Expected the ticket in the sandbox, got %d", rec.Code)
+	}
+	if rec := send(http.MethodGet, "/ticket/"+created.ConfirmationID, "", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected the sandbox ticket to be hidden from live requests, got %d", rec.Code)
+	}
+	if rec := send(http.MethodGet, "/ticket/"+seededTicket, "true", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected live tickets to be hidden from the sandbox, got %d", rec.Code)
+	}
+	if rec := send(http.MethodGet, "/admin/stats", "true", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected live-only routes to be unavailable in the sandbox, got %d", rec.Code)
+	}
+	if rec := send(http.MethodGet, "/tickets", "maybe", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid X-Sandbox, got %d", rec.Code)
+	}
+	if rec := send(http.MethodGet, "/ticket/"+seededTicket, "false", ""); rec.Code != http.StatusOK {
+		t.Errorf("Expected X-Sandbox: false to use live tickets, got %d", rec.Code)
+	}
+}
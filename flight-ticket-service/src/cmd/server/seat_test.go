@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+func TestSeatHolds(t *testing.T) {
+	router := newTestRouter(t)
+	send := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", "desk-key")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	departure := time.Now().UTC().AddDate(0, 1, 0).Format("2006-01-02")
+	seatsURL := "/flights/AA1234/" + departure + "/seats"
+	rec := send(http.MethodPost, seatsURL+"/hold", `{"seats":["12b","12A"]}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var hold models.SeatHold
+	json.NewDecoder(rec.Body).Decode(&hold)
+	if hold.ID == "" || len(hold.Seats) != 2 || hold.Seats[0] != "12A" || hold.Holder != "desk" {
+		t.Errorf("Expected 12A and 12B held by desk, got %+v", hold)
+	}
+
+	// Another shopper cannot pick a held seat
+	if rec := send(http.MethodPost, seatsURL+"/hold", `{"seats":["12B"]}`); rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for a held seat, got %d", rec.Code)
+	}
+	if rec := send(http.MethodPost, seatsURL+"/hold", `{"seats":["12L"]}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid seat, got %d", rec.Code)
+	}
+
+	booking := `{"origin":"JFK","destination":"LAX","departure_date":"` + departure + `","departure_time":"09:00","flight_number":"AA1234",`
+	if rec := send(http.MethodPost, "/ticket", booking+`"passengers":1,"seat_hold_id":"`+hold.ID+`"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for more held seats than passengers, got %d", rec.Code)
+	}
+	if rec := send(http.MethodPost, "/ticket", booking+`"passengers":2,"seat_hold_id":"unknown"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown hold, got %d", rec.Code)
+	}
+	if rec := send(http.MethodPost, "/ticket", booking+`"passengers":2,"labels":{"seat_1":"1a"}}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a reserved seat label, got %d", rec.Code)
+	}
+
+	rec = send(http.MethodPost, "/ticket", booking+`"passengers":2,"seat_hold_id":"`+hold.ID+`"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var ticket models.FlightTicket
+	json.NewDecoder(rec.Body).Decode(&ticket)
+	if len(ticket.AssignedSeats) != 2 || ticket.AssignedSeats[0] != "12A" || ticket.AssignedSeats[1] != "12B" {
+		t.Errorf("Expected 12A and 12B assigned, got %v", ticket.AssignedSeats)
+	}
+
+	var seatMap models.SeatMapResponse
+	json.NewDecoder(send(http.MethodGet, seatsURL, "").Body).Decode(&seatMap)
+	if seatMap.Count != 2 || seatMap.Seats[0].Status != models.SeatAssigned {
+		t.Errorf("Expected 2 assigned seats, got %+v", seatMap)
+	}
+	if rec := send(http.MethodPost, "/ticket", booking+`"passengers":2,"seat_hold_id":"`+hold.ID+`"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 booking with a used hold, got %d", rec.Code)
+	}
+	if rec := send(http.MethodPut, "/ticket/"+ticket.ConfirmationID, `{"passengers":1}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 dropping a passenger with a seat, got %d", rec.Code)
+	}
+
+	// Relabeling keeps the seats
+	rec = send(http.MethodPut, "/ticket/"+ticket.ConfirmationID, `{"labels":{"campaign":"spring"}}`)
+	var relabeled models.FlightTicket
+	json.NewDecoder(rec.Body).Decode(&relabeled)
+	if len(relabeled.AssignedSeats) != 2 {
+		t.Errorf("Expected the seats kept on relabel, got %v", relabeled.AssignedSeats)
+	}
+
+	// Cancelling frees the seats
+	if rec := send(http.MethodDelete, "/ticket/"+ticket.ConfirmationID, ""); rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	json.NewDecoder(send(http.MethodGet, seatsURL, "").Body).Decode(&seatMap)
+	if seatMap.Count != 0 {
+		t.Errorf("Expected the seats freed, got %+v", seatMap)
+	}
+
+	// Moving a ticket to another date or cancelling it with an update frees its seats too
+	book := func(seat string) models.FlightTicket {
+		var hold models.SeatHold
+		json.NewDecoder(send(http.MethodPost, seatsURL+"/hold", `{"seats":["`+seat+`"]}`).Body).Decode(&hold)
+		var ticket models.FlightTicket
+		json.NewDecoder(send(http.MethodPost, "/ticket", booking+`"passengers":1,"seat_hold_id":"`+hold.ID+`"}`).Body).Decode(&ticket)
+		if len(ticket.AssignedSeats) != 1 {
+			t.Fatalf("Expected seat %s assigned, got %+v", seat, ticket)
+		}
+		return ticket
+	}
+	moved, cancelled := book("20A"), book("21A")
+	later := time.Now().UTC().AddDate(0, 2, 0).Format("2006-01-02")
+	rec = send(http.MethodPut, "/ticket/"+moved.ConfirmationID, `{"departure_date":"`+later+`","labels":{"campaign":"spring"}}`)
+	var updated models.FlightTicket
+	json.NewDecoder(rec.Body).Decode(&updated)
+	if rec.Code != http.StatusOK || len(updated.AssignedSeats) != 0 || updated.Labels["campaign"] != "spring" {
+		t.Errorf("Expected the moved ticket without seats, got %d: %+v", rec.Code, updated)
+	}
+	if rec := send(http.MethodPut, "/ticket/"+cancelled.ConfirmationID, `{"status":"CANCELLED"}`); rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 cancelling, got %d: %s", rec.Code, rec.Body.String())
+	}
+	json.NewDecoder(send(http.MethodGet, seatsURL, "").Body).Decode(&seatMap)
+	if seatMap.Count != 0 {
+		t.Errorf("Expected the seats of the moved and cancelled tickets freed, got %+v", seatMap)
+	}
+
+	rec = send(http.MethodPost, seatsURL+"/hold", `{"seats":["14C"],"ttl_seconds":60}`)
+	json.NewDecoder(rec.Body).Decode(&hold)
+	if rec := send(http.MethodDelete, "/flights/AA1234/"+departure+"/seats/hold/"+hold.ID, ""); rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 releasing the hold, got %d", rec.Code)
+	}
+	if rec := send(http.MethodDelete, "/flights/AA1234/"+departure+"/seats/hold/"+hold.ID, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 releasing it again, got %d", rec.Code)
+	}
+}
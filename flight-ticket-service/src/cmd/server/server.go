@@ -17,32 +17,90 @@
 // @tag.name tickets
 // @tag.description Flight ticket management operations
 
+// @tag.name advisories
+// @tag.description Weather advisories for ticket airports
+
+// @tag.name attachments
+// @tag.description Documents attached to tickets
+
+// @tag.name jobs
+// @tag.description Background document and export jobs
+
+// @tag.name admin
+// @tag.description Operator endpoints (admin API key required)
+
 // @tag.name health
 // @tag.description Health check operations
 
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description API key from API_KEYS
+
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description The same API key as a bearer token: "Bearer <key>"
+
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
 	"math/rand"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"flight-ticket-service/src/analytics"
+	"flight-ticket-service/src/auth"
+	"flight-ticket-service/src/changefeed"
+	"flight-ticket-service/src/clientcert"
+	"flight-ticket-service/src/currency"
+	"flight-ticket-service/src/debuglog"
+	"flight-ticket-service/src/entry"
+	"flight-ticket-service/src/errorreport"
+	"flight-ticket-service/src/featureflags"
+	"flight-ticket-service/src/forecast"
 	"flight-ticket-service/src/handlers"
+	"flight-ticket-service/src/health"
+	"flight-ticket-service/src/jobs"
+	"flight-ticket-service/src/liveconfig"
+	"flight-ticket-service/src/maintenance"
+	"flight-ticket-service/src/metrics"
+	"flight-ticket-service/src/network"
+	"flight-ticket-service/src/opsnotify"
+	"flight-ticket-service/src/pricing"
+	"flight-ticket-service/src/quota"
+	"flight-ticket-service/src/recording"
+	"flight-ticket-service/src/risk"
+	"flight-ticket-service/src/rules"
+	"flight-ticket-service/src/scheduling"
+	"flight-ticket-service/src/seats"
 	"flight-ticket-service/src/services"
+	"flight-ticket-service/src/tenants"
+	"flight-ticket-service/src/travelers"
+	"flight-ticket-service/src/upgrades"
+	"flight-ticket-service/src/version"
+	"flight-ticket-service/src/vouchers"
+	"flight-ticket-service/src/workers"
 
-	"github.com/go-chi/chi/middleware"
-	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/cors"
-	httpSwagger "github.com/swaggo/http-swagger"
+	"cloud.google.com/go/firestore"
+	"github.com/prometheus/client_golang/prometheus"
 
 	_ "flight-ticket-service/docs" // Import generated docs
 )
 
 func main() {
+	storageBackend := flag.String("storage", "", "Storage backend: firestore, spanner, postgres, sqlite or memory (overrides STORAGE_BACKEND)")
+	sqlitePath := flag.String("sqlite-path", "", "SQLite database file for the sqlite backend (overrides SQLITE_PATH)")
+	check := flag.Bool("check", false, "Check the configuration, credentials and dependencies, print a report and exit")
+	flag.Parse()
+
 	// Initialize random seed for confirmation ID generation
 	rand.Seed(time.Now().UnixNano())
 
@@ -52,108 +110,739 @@ func main() {
 		port = "8080"
 	}
 
-	projectID := os.Getenv("GOOGLE_CLOUD_PROJECT")
-	if projectID == "" {
-		log.Fatal("GOOGLE_CLOUD_PROJECT environment variable is required")
+	storageConfig := services.StorageConfigFromEnv()
+	if *storageBackend != "" {
+		storageConfig.Backend = *storageBackend
+	}
+	if *sqlitePath != "" {
+		storageConfig.SQLitePath = *sqlitePath
+	}
+
+	// Run the self-check instead of the server, e.g. as a deployment gate
+	if *check {
+		if !runSelfCheck(storageConfig, os.Stdout) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Log level; reloadable, like the other settings in reloadableSettings
+	logLevel, err := debuglog.LevelFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid LOG_LEVEL: %v", err)
+	}
+	debuglog.SetLevel(logLevel)
+
+	// Initialize ticket storage (Firestore by default)
+	repository, err := services.NewTicketRepository(storageConfig)
+	if err != nil {
+		log.Fatalf("Failed to initialize %s storage: %v", storageConfig.Backend, err)
+	}
+	// The stores kept with the tickets share the repository's connections; with
+	// Firestore, one client serves the tickets and every Firestore store. The
+	// repository owns it and closes it once at shutdown, after the HTTP server drains
+	backendRepository := repository
+	var firestoreClient *firestore.Client
+	if firestoreService, ok := repository.(*services.FirestoreService); ok {
+		firestoreClient = firestoreService.Client()
+	}
+
+	// Check that the Firestore database is where it is expected and close to the service
+	var firestoreLocation string
+	if storageConfig.Backend == services.BackendFirestore {
+		firestoreLocation, err = services.CheckFirestoreRegion(context.Background(), storageConfig)
+		if err != nil {
+			log.Fatalf("Invalid Firestore location: %v", err)
+		}
+	}
+
+	// Report panics to Cloud Error Reporting on Cloud Run, to the log elsewhere
+	errorReporter, err := errorreport.FromEnv(context.Background(), storageConfig.ProjectID, storageConfig.CredentialsPath, version.Version)
+	if err != nil {
+		log.Fatalf("Failed to initialize error reporting: %v", err)
+	}
+
+	// Count Firestore document operations per endpoint for /metrics and /admin/stats
+	usageTracker, err := services.NewUsageTracker(storageConfig.Backend, os.Getenv("FIRESTORE_PRICING_TIER"), prometheus.DefaultRegisterer)
+	if err != nil {
+		log.Fatalf("Failed to initialize usage tracking: %v", err)
+	}
+	operationBudget, err := services.OperationBudgetFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid operation budget: %v", err)
+	}
+	if storageConfig.Backend == services.BackendFirestore {
+		repository = services.NewInstrumentedRepository(repository)
+	}
+
+	// Sandbox tickets are kept in their own store and expire; they never reach the change feed
+	sandboxConfig, err := services.SandboxConfigFromEnv(storageConfig)
+	if err != nil {
+		log.Fatalf("Invalid sandbox settings: %v", err)
+	}
+	var sandboxRepository services.TicketRepository
+	if sandboxConfig.Enabled {
+		sandboxStore, err := services.NewSandboxStore(storageConfig, sandboxConfig, backendRepository)
+		if err != nil {
+			log.Fatalf("Failed to initialize sandbox storage: %v", err)
+		}
+		defer sandboxStore.Close()
+		stopSandboxSweeper := services.StartSandboxSweeper(sandboxStore)
+		defer stopSandboxSweeper()
+		if storageConfig.Backend == services.BackendFirestore {
+			sandboxStore = services.NewInstrumentedRepository(sandboxStore)
+		}
+		sandboxRepository = services.NewSandboxRepository(sandboxStore, sandboxConfig.TTL)
 	}
 
-	credentialsPath := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	// Track in-flight requests and SLO error budgets for /metrics
+	sloTracker, err := metrics.NewTracker(metrics.SLOs, prometheus.DefaultRegisterer)
+	if err != nil {
+		log.Fatalf("Failed to initialize SLO tracking: %v", err)
+	}
 
-	// Initialize Firestore service
-	firestoreService, err := services.NewFirestoreService(projectID, credentialsPath)
+	// Export request latency, Firestore operations and cache lookups to Cloud Monitoring (optional)
+	serviceRegion, err := services.ServiceRegion()
 	if err != nil {
-		log.Fatalf("Failed to initialize Firestore service: %v", err)
+		log.Printf("Metrics export location unknown: %v", err)
+	}
+	exportConfig, err := metrics.ExportConfigFromEnv(storageConfig.ProjectID, serviceRegion)
+	if err != nil {
+		log.Fatalf("Invalid metrics export settings: %v", err)
+	}
+	var metricsExporter *metrics.Exporter
+	if exportConfig.Enabled {
+		metricsExporter, err = metrics.NewExporter(context.Background(), exportConfig, prometheus.DefaultGatherer, storageConfig.CredentialsPath)
+		if err != nil {
+			log.Fatalf("Failed to initialize metrics export: %v", err)
+		}
+		metricsExporter.Start()
+		log.Printf("Exporting %s to Cloud Monitoring every %s", strings.Join(exportConfig.Metrics, ", "), exportConfig.Interval)
+	}
+
+	// Load API keys
+	keyStore, err := auth.KeyStoreFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid API_KEYS: %v", err)
+	}
+	if keyStore.Len() == 0 {
+		log.Println("API_KEYS not set; admin endpoints are inaccessible")
+	}
+	idTokenConfig, err := auth.IDTokenConfigFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	idTokenVerifier, err := auth.NewIDTokenVerifier(context.Background(), idTokenConfig)
+	if err != nil {
+		log.Fatalf("Failed to initialize ID token verification: %v", err)
+	}
+	if idTokenVerifier != nil {
+		keyStore.SetIDTokenVerifier(idTokenVerifier)
+		log.Printf("Accepting ID tokens for %s from %d service accounts", strings.Join(idTokenConfig.Audiences, ", "), len(idTokenConfig.Principals))
+	}
+
+	// Roles required by endpoints
+	policy, err := auth.PolicyFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if os.Getenv("AUTH_POLICY") != "" {
+		log.Printf("Authorization policy has %d rules, including AUTH_POLICY", len(policy.Rules()))
+	}
+
+	// Client certificates of mTLS terminated by a load balancer
+	clientCerts, err := clientcert.ConfigFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	switch clientCerts.Mode {
+	case clientcert.ModeLog:
+		log.Println("Logging forwarded client certificates")
+	case clientcert.ModeRequire:
+		log.Printf("Requiring verified client certificates (%d allowed identities); deploy with internal-and-cloud-load-balancing ingress so they cannot be forged", len(clientCerts.Allowed))
+	}
+
+	// Load tenant overrides; Firestore tenants are shared by every instance and reloaded periodically
+	tenantStore, err := tenants.NewStore(backendRepository)
+	if err != nil {
+		log.Fatalf("Failed to initialize tenant store: %v", err)
+	}
+	defer tenantStore.Close()
+	tenantRefresh, err := tenants.RefreshIntervalFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	tenantCache := tenants.NewRegistry(tenantStore)
+	if err := tenantCache.Load(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+	tenantsCtx, stopTenants := context.WithCancel(context.Background())
+	defer stopTenants()
+	tenantCache.Watch(tenantsCtx, tenantRefresh)
+	if count := tenantCache.Len(); count > 0 {
+		log.Printf("Loaded %d tenants", count)
+	}
+
+	// Load booking rules; like tenants they are shared through Firestore and reloaded periodically
+	ruleStore, err := rules.NewStore(backendRepository)
+	if err != nil {
+		log.Fatalf("Failed to initialize booking rule store: %v", err)
+	}
+	defer ruleStore.Close()
+	ruleRefresh, err := rules.RefreshIntervalFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	ruleEngine := rules.NewEngine(ruleStore)
+	if err := ruleEngine.Load(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+	rulesCtx, stopRules := context.WithCancel(context.Background())
+	defer stopRules()
+	ruleEngine.Watch(rulesCtx, ruleRefresh)
+	if count := len(ruleEngine.List(false)); count > 0 {
+		log.Printf("Loaded %d active booking rules", count)
+	}
+	travelerStore, err := travelers.NewStore(backendRepository)
+	if err != nil {
+		log.Fatalf("Failed to initialize traveler store: %v", err)
+	}
+	defer travelerStore.Close()
+	seatStore, err := seats.NewStore(backendRepository)
+	if err != nil {
+		log.Fatalf("Failed to initialize seat store: %v", err)
+	}
+	defer seatStore.Close()
+	stopSeatSweeper := seats.StartSweeper(seatStore)
+	defer stopSeatSweeper()
+	upgradeStore, err := upgrades.NewStore(backendRepository)
+	if err != nil {
+		log.Fatalf("Failed to initialize upgrade offer store: %v", err)
+	}
+	defer upgradeStore.Close()
+	upgradeCapacity, err := upgrades.CapacityFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	voucherStore, err := vouchers.NewStore(backendRepository)
+	if err != nil {
+		log.Fatalf("Failed to initialize voucher store: %v", err)
+	}
+	defer voucherStore.Close()
+	entryRules, err := entry.TableFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	routeRefresh, err := network.RefreshIntervalFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	fareCacheTTL, err := pricing.CacheTTLFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Initialize feature flags
+	flagDefaults, err := featureflags.ParseEnv(os.Getenv("FEATURE_FLAGS"))
+	if err != nil {
+		log.Fatalf("Invalid FEATURE_FLAGS: %v", err)
+	}
+	flags := featureflags.New(flagDefaults)
+	liveConfig, err := liveconfig.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid config source: %v", err)
+	}
+	// Flag and config documents are read from Firestore with any ticket backend;
+	// without a Firestore repository the client is opened, and closed, here
+	if firestoreClient == nil && (os.Getenv("FEATURE_FLAGS_DOCUMENT") != "" || liveConfig.Document != "") {
+		firestoreClient, err = services.NewFirestoreClient(context.Background(), storageConfig.ProjectID, storageConfig.FirestoreDatabase, storageConfig.CredentialsPath)
+		if err != nil {
+			log.Fatalf("Failed to initialize Firestore: %v", err)
+		}
+		defer firestoreClient.Close()
+	}
+	flagsCtx, stopFlags := context.WithCancel(context.Background())
+	defer stopFlags()
+	if document := os.Getenv("FEATURE_FLAGS_DOCUMENT"); document != "" {
+		if err := flags.WatchFirestore(flagsCtx, firestoreClient, document); err != nil {
+			log.Fatalf("Failed to load feature flags: %v", err)
+		}
+	}
+	log.Printf("Feature flags (%s): %s", flags.State().Source, flags.State())
+
+	// Initialize maintenance mode
+	maintenanceMode, err := maintenance.ParseMode(os.Getenv("MAINTENANCE_MODE"))
+	if err != nil {
+		log.Fatalf("Invalid MAINTENANCE_MODE: %v", err)
+	}
+	maintenanceSwitch := maintenance.New(maintenanceMode, os.Getenv("MAINTENANCE_MESSAGE"))
+	if maintenanceMode != maintenance.ModeOff {
+		log.Printf("Starting in %s maintenance mode", maintenanceMode)
+	}
+
+	// Initialize booking quotas. With Firestore storage the counts live in
+	// Firestore so that every instance enforces the same totals.
+	quotaConfig, err := quota.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid booking quota settings: %v", err)
+	}
+	var quotaCounter quota.Counter = quota.NewMemoryCounter()
+	// A config source may set a quota later, so the counts are shared from the start
+	if (quotaConfig.Enabled() || liveConfig.Enabled()) && storageConfig.Backend == services.BackendFirestore {
+		firestoreCounter, err := quota.NewFirestoreCounter(firestoreClient, quotaConfig.Shards)
+		if err != nil {
+			log.Fatalf("Failed to initialize booking quota counters: %v", err)
+		}
+		quotaCounter = firestoreCounter
+	}
+	limiter := quota.New(quotaConfig, quotaCounter)
+	if limiter.Enabled() {
+		log.Printf("Booking quota: %d tickets per API key per day (%d overrides)", quotaConfig.Limit, len(quotaConfig.Overrides))
+	}
+
+	// Count requests per API key and month for /admin/usage and the monthly
+	// usage report; with Firestore storage every instance adds to the same counters
+	analyticsConfig, err := analytics.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid API usage settings: %v", err)
+	}
+	var analyticsStore analytics.Store = analytics.NewMemoryStore()
+	if storageConfig.Backend == services.BackendFirestore {
+		firestoreStore, err := analytics.NewFirestoreStore(firestoreClient, analyticsConfig.Shards)
+		if err != nil {
+			log.Fatalf("Failed to initialize API usage counters: %v", err)
+		}
+		analyticsStore = firestoreStore
+	}
+	apiUsage := analytics.NewRecorder(analyticsStore, analyticsConfig.FlushInterval)
+	apiUsage.Start()
+
+	// Apply changes of the config source without a restart
+	corsOrigins, err := corsOriginsFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+	cors := newCORSPolicy(corsOrigins)
+	configWatcher := liveconfig.New(reloadableSettings(limiter, flags, cors)...)
+	configCtx, stopConfig := context.WithCancel(context.Background())
+	defer stopConfig()
+	if liveConfig.Enabled() {
+		if err := configWatcher.Watch(configCtx, liveConfig, firestoreClient); err != nil {
+			log.Fatalf("Failed to load config: %v", err)
+		}
+		log.Printf("Reloading settings from %s %s", configWatcher.State().Source, configWatcher.State().Location)
+	}
+
+	// Track recent dependency results for /health/details
+	healthConfig, err := health.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid health check settings: %v", err)
+	}
+	healthTracker := health.NewTracker(healthConfig.HistorySize)
+	healthCtx, stopHealthChecks := context.WithCancel(context.Background())
+	defer stopHealthChecks()
+	healthTracker.Watch(healthCtx, healthConfig, storageCheck(repository))
+
+	// Initialize weather service
+	weatherProvider, err := services.NewWeatherProvider(os.Getenv("WEATHER_PROVIDER"))
+	if err != nil {
+		log.Fatalf("Failed to initialize weather provider: %v", err)
+	}
+	healthTracker.Register(weatherDependency)
+	weatherProvider = trackedWeatherProvider{WeatherProvider: weatherProvider, tracker: healthTracker}
+
+	weatherCacheTTL := 15 * time.Minute
+	if ttl := os.Getenv("WEATHER_CACHE_TTL"); ttl != "" {
+		parsedTTL, err := time.ParseDuration(ttl)
+		if err != nil {
+			log.Fatalf("Invalid WEATHER_CACHE_TTL: %v", err)
+		}
+		weatherCacheTTL = parsedTTL
+	}
+	weatherService := services.NewWeatherService(weatherProvider, weatherCacheTTL)
+
+	// Initialize currency converter
+	rateProvider, err := currency.NewRateProvider(os.Getenv("FX_RATE_PROVIDER"))
+	if err != nil {
+		log.Fatalf("Failed to initialize exchange rate provider: %v", err)
+	}
+	healthTracker.Register(currencyDependency)
+	rateProvider = trackedRateProvider{RateProvider: rateProvider, tracker: healthTracker}
+
+	fxCacheTTL := time.Hour
+	if ttl := os.Getenv("FX_CACHE_TTL"); ttl != "" {
+		parsedTTL, err := time.ParseDuration(ttl)
+		if err != nil {
+			log.Fatalf("Invalid FX_CACHE_TTL: %v", err)
+		}
+		fxCacheTTL = parsedTTL
+	}
+	converter := currency.NewConverter(rateProvider, fxCacheTTL)
+
+	// Initialize attachment storage (optional)
+	var attachmentHandler *handlers.AttachmentHandler
+	if bucket := os.Getenv("ATTACHMENTS_BUCKET"); bucket != "" {
+		urlTTL := 15 * time.Minute
+		if ttl := os.Getenv("ATTACHMENT_URL_TTL"); ttl != "" {
+			parsedTTL, err := time.ParseDuration(ttl)
+			if err != nil {
+				log.Fatalf("Invalid ATTACHMENT_URL_TTL: %v", err)
+			}
+			urlTTL = parsedTTL
+		}
+
+		attachmentStorage, err := services.NewAttachmentStorage(bucket, storageConfig.CredentialsPath, urlTTL)
+		if err != nil {
+			log.Fatalf("Failed to initialize attachment storage: %v", err)
+		}
+		defer attachmentStorage.Close()
+
+		attachmentHandler = handlers.NewAttachmentHandler(repository, attachmentStorage)
+	} else {
+		log.Println("ATTACHMENTS_BUCKET not set; attachment endpoints disabled")
+	}
+
+	// Initialize generated document storage (optional)
+	var documentCache *services.DocumentCache
+	if bucket := os.Getenv("DOCUMENTS_BUCKET"); bucket != "" {
+		urlTTL := 5 * time.Minute
+		if ttl := os.Getenv("DOCUMENT_URL_TTL"); ttl != "" {
+			parsedTTL, err := time.ParseDuration(ttl)
+			if err != nil {
+				log.Fatalf("Invalid DOCUMENT_URL_TTL: %v", err)
+			}
+			urlTTL = parsedTTL
+		}
+
+		var cdnSigner *services.CDNSigner
+		if cdnURL := os.Getenv("DOCUMENTS_CDN_URL"); cdnURL != "" {
+			cdnSigner, err = services.NewCDNSigner(cdnURL, os.Getenv("DOCUMENTS_CDN_KEY_NAME"), os.Getenv("DOCUMENTS_CDN_KEY"))
+			if err != nil {
+				log.Fatalf("Failed to initialize CDN URL signing: %v", err)
+			}
+		}
+
+		documentStorage, err := services.NewDocumentStorage(bucket, storageConfig.CredentialsPath, urlTTL, cdnSigner)
+		if err != nil {
+			log.Fatalf("Failed to initialize document storage: %v", err)
+		}
+		defer documentStorage.Close()
+
+		documentCache = services.NewDocumentCache(documentStorage)
+		if err := documentCache.Instrument(prometheus.DefaultRegisterer); err != nil {
+			log.Fatalf("Failed to initialize document cache metrics: %v", err)
+		}
+	} else {
+		log.Println("DOCUMENTS_BUCKET not set; QR codes and PDF manifests are generated on every request")
+	}
+
+	// Initialize the worker pool rendering documents and exports
+	workerConfig, err := workers.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid worker pool configuration: %v", err)
+	}
+	workerPool := workers.New(workerConfig)
+	defer workerPool.Close()
+
+	// Initialize the background job manager. With Firestore storage the job
+	// records are shared, so jobs survive restarts and any instance can run them.
+	jobConfig, err := jobs.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid job settings: %v", err)
+	}
+	var jobStore jobs.Store = jobs.NewMemoryStore()
+	if storageConfig.Backend == services.BackendFirestore {
+		jobStore = jobs.NewFirestoreStore(firestoreClient)
+	}
+	var backupService *services.BackupService
+	if bucket := os.Getenv("BACKUP_BUCKET"); bucket != "" {
+		backupService, err = services.NewBackupService(repository, storageConfig, bucket)
+		if err != nil {
+			log.Fatalf("Failed to initialize backup service: %v", err)
+		}
+	} else {
+		log.Println("BACKUP_BUCKET not set; export and import jobs disabled")
+	}
+	jobManager := jobs.NewManager(jobStore, jobConfig)
+	registerJobKinds(jobManager, repository, backupService, vouchers.NewIssuer(voucherStore, ruleEngine), seatStore)
+	jobManager.Start()
+	defer jobManager.Stop()
+
+	// Initialize QR code signing
+	qrSigningKey := os.Getenv("QR_SIGNING_KEY")
+	if qrSigningKey == "" {
+		log.Println("QR_SIGNING_KEY not set; using a random key (QR codes will not verify after restart)")
+	}
+	qrService, err := services.NewQRService(qrSigningKey)
+	if err != nil {
+		log.Fatalf("Failed to initialize QR service: %v", err)
+	}
+
+	// Initialize request recording (optional)
+	recordingConfig, err := recording.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid request recording settings: %v", err)
+	}
+	var recorder *recording.Recorder
+	if recordingConfig.Destination != "" {
+		recorder, err = recording.New(context.Background(), recordingConfig)
+		if err != nil {
+			log.Fatalf("Failed to initialize request recording: %v", err)
+		}
+		log.Printf("Recording %.0f%% of requests to %s", recordingConfig.SampleRate*100, recorder.Sink())
+	}
+
+	// Initialize booking risk scoring (optional)
+	riskConfig, err := risk.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid risk scoring settings: %v", err)
+	}
+	riskScorer := risk.New(riskConfig)
+	if riskScorer != nil {
+		log.Printf("Holding bookings with a risk score of %d or more for review", riskConfig.ReviewScore)
+	}
+
+	// Initialize Slack or Google Chat notifications of ops events (optional)
+	opsConfig, err := opsnotify.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid ops notification settings: %v", err)
+	}
+	opsNotifier := opsnotify.New(opsConfig)
+	if opsNotifier != nil {
+		defer opsNotifier.Close()
+		for _, eventType := range opsnotify.EventTypes {
+			if opsNotifier.Enabled(eventType) {
+				log.Printf("Posting %s events to a chat webhook", eventType)
+			}
+		}
+	}
+
+	// Initialize check-in and boarding times
+	schedulingPolicy, err := scheduling.PolicyFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid scheduling settings: %v", err)
+	}
+	scheduler := scheduling.NewScheduler(schedulingPolicy)
+
+	// Publish change events for simulated flight delays. Firestore changes
+	// reach webhooks and notifications through the change feed service instead.
+	var changeEvents *changefeed.Fanout
+	if storageConfig.Backend != services.BackendFirestore {
+		sinks, err := changefeed.SinksFromEnv(context.Background(), repository, tenantCache)
+		if err != nil {
+			log.Fatalf("Failed to initialize change event sinks: %v", err)
+		}
+		for i, sink := range sinks {
+			healthTracker.Register(sink.Name())
+			sinks[i] = trackedSink{Sink: sink, tracker: healthTracker}
+		}
+		if len(sinks) > 0 {
+			changeEvents = changefeed.NewFanout(sinks...)
+			defer changeEvents.Close()
+		}
 	}
-	defer firestoreService.Close()
 
 	// Initialize handlers
-	ticketHandler := handlers.NewTicketHandler(firestoreService)
+	ticketOptions := handlers.TicketHandlerOptions{
+		Converter:  converter,
+		Scheduler:  scheduler,
+		Rules:      ruleEngine,
+		Travelers:  travelerStore,
+		EntryRules: entryRules,
+		Seats:      seatStore,
+		Risk:       riskScorer,
+		Ops:        opsNotifier,
+	}
+	ticketHandler := handlers.NewTicketHandler(repository, ticketOptions)
+	advisoryHandler := handlers.NewAdvisoryHandler(repository, weatherService)
+	qrHandler := handlers.NewQRHandler(repository, qrService, documentCache)
+	checkInHandler := handlers.NewCheckInHandler(repository, scheduler, travelerStore, entryRules)
+	notificationHandler := handlers.NewNotificationHandler(repository)
+	noteHandler := handlers.NewNoteHandler(repository)
+	viewHandler := handlers.NewViewHandler(repository, ticketHandler)
+	manifestHandler := handlers.NewManifestHandler(repository, documentCache, workerPool)
+	adminHandler := handlers.NewAdminHandler(usageTracker, flags, maintenanceSwitch)
+	delayHandler := handlers.NewFlightDelayHandler(repository, scheduler, maintenanceSwitch, changeEvents)
+	upgradeHandler := handlers.NewUpgradeHandler(repository, upgradeStore, upgradeCapacity, changeEvents)
+	inventoryHandler := handlers.NewInventoryHandler(repository, maintenanceSwitch)
+	quotaHandler := handlers.NewQuotaHandler(limiter)
+	bookingStatsHandler := handlers.NewBookingStatsHandler(repository)
+
+	// Demand forecasts from a Vertex AI endpoint, or the offline baseline
+	forecastConfig, err := forecast.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid forecast settings: %v", err)
+	}
+	forecaster, err := forecast.NewForecaster(context.Background(), forecastConfig, storageConfig.CredentialsPath)
+	if err != nil {
+		log.Fatalf("Failed to initialize demand forecasts: %v", err)
+	}
+	if forecastConfig.Provider == forecast.ProviderVertex {
+		log.Printf("Demand forecasts from Vertex AI endpoint %s", forecastConfig.Endpoint)
+	}
+	adminUIHandler := handlers.NewAdminUIHandler(repository, keyStore, maintenanceSwitch, seatStore)
+	jobHandler := handlers.NewJobHandler(workerPool, jobManager)
+	bulkCancelHandler := handlers.NewBulkCancelHandler(repository, jobManager)
+	quarantineHandler := handlers.NewQuarantineHandler(repository)
+	reviewHandler := handlers.NewReviewHandler(ticketHandler, changeEvents)
+	lockHandler := handlers.NewLockHandler(repository)
+	healthHandler := handlers.NewHealthHandler(healthTracker)
+	routeNetwork := network.New(repository, routeRefresh)
+	routeHandler := handlers.NewRouteHandler(routeNetwork, ruleEngine)
+	rebookingHandler := handlers.NewRebookingHandler(ticketHandler, routeNetwork, changeEvents)
+	voucherHandler := handlers.NewVoucherHandler(repository, voucherStore, changeEvents)
+	fareHandler := handlers.NewFareHandler(pricing.NewCalendar(converter, fareCacheTTL))
+	var sandboxTicketHandler *handlers.TicketHandler
+	if sandboxRepository != nil {
+		// Sandbox bookings keep their own seats and are neither scored nor announced
+		sandboxOptions := ticketOptions
+		sandboxOptions.Seats, sandboxOptions.Risk, sandboxOptions.Ops = seats.NewMemoryStore(), nil, nil
+		sandboxTicketHandler = handlers.NewTicketHandler(sandboxRepository, sandboxOptions)
+	}
+
+	// External base URL for the OpenAPI spec; by default it follows the request
+	publicURL, err := handlers.PublicURLFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid public URL: %v", err)
+	}
 
 	// Setup router
-	r := chi.NewRouter()
-	r.Use(middleware.Logger)
-	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
-	r.Use(middleware.Recoverer)
-	r.Use(middleware.Timeout(60 * time.Second))
-
-	// CORS middleware
-	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"*"}, // In production, specify your frontend domains
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
-		AllowCredentials: false,
-		MaxAge:           300, // Maximum value not ignored by any of major browsers
-	}))
-
-	// Health check endpoint
-	r.Get("/health", handlers.HealthCheck)
-
-	// Root endpoint
-	// @Summary API Information
-	// @Description Get basic information about the Flight Ticket Service API
-	// @Tags health
-	// @Accept json
-	// @Produce json
-	// @Success 200 {object} map[string]string "API information"
-	// @Router / [get]
-	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
-		log.Println("Called /")
-		w.Header().Set("Content-Type", "application/json")
-		w.Write([]byte(`{"message": "Flight Ticket Service API", "version": "1.0.0", "swagger": "/swagger/"}`))
-	})
+	r := newRouter(routes{
+		keyStore:      keyStore,
+		policy:        policy,
+		clientCerts:   clientCerts,
+		usage:         usageTracker,
+		budget:        operationBudget,
+		slo:           sloTracker,
+		maintenance:   maintenanceSwitch,
+		flags:         flags,
+		quota:         limiter,
+		recorder:      recorder,
+		errorRate:     opsnotify.NewErrorRateMonitor(opsNotifier, opsConfig),
+		publicURL:     publicURL,
+		cors:          cors,
+		tenantCache:   tenantCache,
+		tickets:       ticketHandler,
+		advisories:    advisoryHandler,
+		qr:            qrHandler,
+		checkIn:       checkInHandler,
+		notifications: notificationHandler,
+		notes:         noteHandler,
+		views:         viewHandler,
+		manifests:     manifestHandler,
+		seatHolds:     handlers.NewSeatHandler(seatStore),
+		upgrades:      upgradeHandler,
+		rebooking:     rebookingHandler,
+		vouchers:      voucherHandler,
+		admin:         adminHandler,
+		delays:        delayHandler,
+		inventory:     inventoryHandler,
+		quotas:        quotaHandler,
+		apiUsage:      apiUsage,
+		bookingStats:  bookingStatsHandler,
+		usageReport:   handlers.NewAPIUsageHandler(apiUsage),
+		forecasts:     handlers.NewForecastHandler(repository, forecaster),
+		adminUI:       adminUIHandler,
+		jobs:          jobHandler,
+		bulkCancel:    bulkCancelHandler,
+		quarantine:    quarantineHandler,
+		review:        reviewHandler,
+		locks:         lockHandler,
+		health:        healthHandler,
+		config:        handlers.NewConfigHandler(configWatcher),
+		tenants:       handlers.NewTenantHandler(tenantCache),
+		rules:         handlers.NewRuleHandler(ruleEngine),
+		routeSearch:   routeHandler,
+		fares:         fareHandler,
+		travelers:     handlers.NewTravelerHandler(travelerStore),
+		attachments:   attachmentHandler,
+		recoverPanics: true,
+		errorReporter: errorReporter,
 
-	// Swagger documentation endpoint
-	r.Get("/swagger/*", httpSwagger.Handler(
-		httpSwagger.URL("/swagger/doc.json"), // Use relative URL for Cloud Run compatibility
-	))
-
-	// Ticket endpoints
-	r.Route("/ticket", func(r chi.Router) {
-		r.Post("/", ticketHandler.CreateTicket)                        // Create new ticket
-		r.Get("/{confirmationID}", ticketHandler.GetTicket)            // Get ticket by confirmation ID
-		r.Put("/{confirmationID}", ticketHandler.UpdateTicket)         // Update ticket
-		r.Delete("/{confirmationID}", ticketHandler.DeleteTicket)      // Cancel ticket
+		sandboxTickets: sandboxTicketHandler,
+		sandboxKeys:    sandboxConfig.Keys,
 	})
 
-	// List all tickets endpoint
-	r.Get("/tickets", ticketHandler.ListTickets)
+	// Warm up storage (and optionally handlers) before listening, so that the startup probe holds traffic back
+	warmUpSettings, err := warmUpConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid warm-up settings: %v", err)
+	}
+	warmUp(repository, r, warmUpSettings)
 
 	// Start server
+	server := &http.Server{Addr: ":" + port, Handler: r}
 	go func() {
 		log.Printf("Flight Ticket Service starting on port %s", port)
-		log.Printf("Using Firestore in project: %s", projectID)
-		log.Printf("Firestore region: us-east1")
-		
-		err := http.ListenAndServe(":"+port, r)
-		if err != nil {
+		if storageConfig.Backend == services.BackendSQLite {
+			log.Printf("Using sqlite storage at: %s", storageConfig.SQLitePath)
+		} else {
+			log.Printf("Using %s storage in project: %s", storageConfig.Backend, storageConfig.ProjectID)
+		}
+		if storageConfig.Backend == services.BackendFirestore {
+			log.Printf("Firestore database %s, tickets in collection %s", storageConfig.FirestoreDatabase, storageConfig.FirestoreCollection)
+			if firestoreLocation != "" {
+				log.Printf("Firestore location: %s", firestoreLocation)
+			}
+		}
+		if sandboxConfig.Enabled {
+			if storageConfig.Backend == services.BackendFirestore {
+				log.Printf("Sandbox enabled: tickets in collection %s expire after %s", sandboxConfig.Collection, sandboxConfig.TTL)
+			} else {
+				log.Printf("Sandbox enabled: tickets kept in memory expire after %s", sandboxConfig.TTL)
+			}
+		}
+
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatal(err)
 		}
 	}()
 
+	build := version.Get()
 	log.Printf("Server Started on PORT %s", port)
+	log.Printf("Version %s (commit %s, built %s, %s)", build.Version, build.Commit, build.BuildTime, build.GoVersion)
 	log.Println("API Endpoints:")
-	log.Println("  POST   /ticket              - Create new flight ticket")
-	log.Println("  GET    /ticket/{id}         - Get flight ticket by confirmation ID")
-	log.Println("  PUT    /ticket/{id}         - Update flight ticket")
-	log.Println("  DELETE /ticket/{id}         - Cancel flight ticket")
-	log.Println("  GET    /tickets             - List all flight tickets")
-	log.Println("  GET    /health              - Health check")
-	log.Printf("  GET    /swagger/            - Swagger UI documentation")
-	log.Printf("  GET    /swagger/doc.json    - OpenAPI specification")
+	for _, route := range routeTable(r) {
+		log.Printf("  %s", route)
+	}
 
 	// Graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGTERM, syscall.SIGINT)
 	<-quit
-	
+
 	log.Println("Server shutting down gracefully...")
-	
-	// Close Firestore connection
-	if err := firestoreService.Close(); err != nil {
-		log.Printf("Error closing Firestore connection: %v", err)
+
+	// Let in-flight requests finish before closing what they use
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelShutdown()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Error shutting down: %v", err)
+	}
+
+	// Export the final metric values
+	if metricsExporter != nil {
+		if err := metricsExporter.Close(); err != nil {
+			log.Printf("Error exporting metrics: %v", err)
+		}
+	}
+
+	// Flush recorded requests
+	if recorder != nil {
+		if err := recorder.Close(); err != nil {
+			log.Printf("Error closing request recording: %v", err)
+		}
+	}
+
+	// Flush the API usage counted since the last flush
+	if err := apiUsage.Close(); err != nil {
+		log.Printf("Error flushing API usage: %v", err)
 	}
-	
+
+	// Close storage connection
+	if err := repository.Close(); err != nil {
+		log.Printf("Error closing storage connection: %v", err)
+	}
+
 	log.Println("Server shutdown complete")
 }
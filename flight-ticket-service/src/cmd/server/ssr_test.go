@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+func TestSpecialRequests(t *testing.T) {
+	router := newTestRouter(t)
+	send := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", "fuzz-key")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	departure := time.Now().UTC().AddDate(0, 1, 0).Format("2006-01-02")
+	booking := `{"origin":"JFK","destination":"LAX","departure_date":"` + departure + `","departure_time":"09:00","flight_number":"AA1234",`
+	rec := send(http.MethodPost, "/ticket", booking+`"passengers":2,"special_requests":[{"passenger":2,"code":"wchr"},{"passenger":2,"code":"VGML"}]}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var ticket models.FlightTicket
+	json.NewDecoder(rec.Body).Decode(&ticket)
+	if len(ticket.SpecialRequests) != 2 || ticket.SpecialRequests[0].Code != "VGML" || ticket.SpecialRequests[1].Passenger != 2 {
+		t.Errorf("Expected VGML and WCHR for passenger 2, got %+v", ticket.SpecialRequests)
+	}
+
+	var manifest models.FlightManifest
+	json.NewDecoder(send(http.MethodGet, "/flights/AA1234/"+departure+"/manifest", "").Body).Decode(&manifest)
+	var listed []string
+	for _, entry := range manifest.Entries {
+		if entry.ConfirmationID == ticket.ConfirmationID {
+			listed = append(listed, strings.Join(entry.SpecialRequests, " "))
+		}
+	}
+	if len(listed) != 2 || listed[0] != "" || listed[1] != "VGML WCHR" {
+		t.Errorf("Expected the manifest to list the SSRs of passenger 2, got %q", listed)
+	}
+
+	invalid := map[string]string{
+		"unknown-code":   `"passengers":1,"special_requests":[{"passenger":1,"code":"ZZZZ"}]}`,
+		"no-passenger":   `"passengers":1,"special_requests":[{"passenger":2,"code":"WCHR"}]}`,
+		"adult-umnr":     `"passengers":1,"special_requests":[{"passenger":1,"code":"UMNR"}]}`,
+		"reserved-label": `"passengers":1,"labels":{"ssr_1":"wchr"}}`,
+	}
+	for name, body := range invalid {
+		if rec := send(http.MethodPost, "/ticket", booking+body); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d: %s", name, rec.Code, rec.Body.String())
+		}
+	}
+
+	if rec := send(http.MethodPut, "/ticket/"+ticket.ConfirmationID, `{"passengers":1}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 dropping a passenger with SSRs, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = send(http.MethodPut, "/ticket/"+ticket.ConfirmationID, `{"labels":{"campaign":"demo"}}`)
+	var relabeled models.FlightTicket
+	json.NewDecoder(rec.Body).Decode(&relabeled)
+	if rec.Code != http.StatusOK || len(relabeled.SpecialRequests) != 2 {
+		t.Errorf("Expected the SSRs to survive relabeling, got %d: %+v", rec.Code, relabeled.SpecialRequests)
+	}
+}
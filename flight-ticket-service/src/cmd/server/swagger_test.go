@@ -0,0 +1,32 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSwaggerDocUsesRequestHost(t *testing.T) {
+	router := newTestRouter(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/swagger/doc.json", nil)
+	req.Host = "flight-ticket-service-abc123-ue.a.run.app"
+	req.Header.Set("X-Forwarded-Proto", "https")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+
+	var spec struct {
+		Host    string   `json:"host"`
+		Schemes []string `json:"schemes"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&spec); err != nil {
+		t.Fatalf("Failed to decode spec: %v", err)
+	}
+	if spec.Host != req.Host || len(spec.Schemes) != 1 || spec.Schemes[0] != "https" {
+		t.Errorf("Expected the Cloud Run host over https, got %s %v", spec.Host, spec.Schemes)
+	}
+}
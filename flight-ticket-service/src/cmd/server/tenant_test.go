@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+func TestTenantOverrides(t *testing.T) {
+	router := newTestRouter(t)
+	send := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("X-API-Key", "fuzz-key")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	booking := func(days, passengers int, labels string) string {
+		date := time.Now().UTC().AddDate(0, 0, days).Format("2006-01-02")
+		return fmt.Sprintf(`{"origin": "JFK", "destination": "LAX", "departure_date": %q, "departure_time": "14:30", "flight_number": "AA1234", "passengers": %d, "labels": %s}`, date, passengers, labels)
+	}
+
+	// Without a tenant the service defaults apply
+	if rec := send(http.MethodPost, "/ticket", booking(60, 3, `{}`)); rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201 without a tenant, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec := send(http.MethodPut, "/admin/tenants/acme", `{
+		"name": "Acme Travel",
+		"api_keys": ["fuzz"],
+		"notification_templates": {"ticket_changed": "acme-ticket-changed"},
+		"booking_rules": {"max_passengers": 2, "max_advance_days": 30},
+		"branding": {"display_name": "Acme Travel", "footer": "Acme internal use"}
+	}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 saving the tenant, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := send(http.MethodPut, "/admin/tenants/other", `{"name": "Other", "api_keys": ["fuzz"]}`); rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for a key of another tenant, got %d", rec.Code)
+	}
+	if rec := send(http.MethodPut, "/admin/tenants/Bad!", `{"name": "Bad"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid tenant ID, got %d", rec.Code)
+	}
+
+	// Booking rules of the tenant
+	for _, body := range []string{booking(10, 3, `{}`), booking(60, 1, `{}`)} {
+		if rec := send(http.MethodPost, "/ticket", body); rec.Code != http.StatusUnprocessableEntity {
+			t.Errorf("Expected 422 for %s, got %d: %s", body, rec.Code, rec.Body.String())
+		}
+	}
+	if rec := send(http.MethodPost, "/ticket", booking(10, 1, `{"tenant": "other"}`)); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for the reserved label, got %d", rec.Code)
+	}
+
+	rec = send(http.MethodPost, "/ticket", booking(10, 2, `{"desk": "ny"}`))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201 within the rules, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var ticket models.FlightTicket
+	json.NewDecoder(rec.Body).Decode(&ticket)
+	if ticket.Labels[models.TenantLabel] != "acme" || ticket.Labels["desk"] != "ny" {
+		t.Fatalf("Expected the tenant label, got %v", ticket.Labels)
+	}
+
+	// Updates are checked too, and replacing the labels keeps the tenant
+	if rec := send(http.MethodPut, "/ticket/"+ticket.ConfirmationID, `{"passengers": 4}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for too many passengers, got %d", rec.Code)
+	}
+	rec = send(http.MethodPut, "/ticket/"+ticket.ConfirmationID, `{"labels": {"desk": "sf"}}`)
+	json.NewDecoder(rec.Body).Decode(&ticket)
+	if rec.Code != http.StatusOK || ticket.Labels[models.TenantLabel] != "acme" || ticket.Labels["desk"] != "sf" {
+		t.Errorf("Expected the tenant label kept, got %d: %v", rec.Code, ticket.Labels)
+	}
+
+	// Generated documents carry the branding
+	rec = send(http.MethodGet, "/flights/AA1234/"+ticket.DepartureDate.Format("2006-01-02")+"/manifest?format=pdf", "")
+	if rec.Code != http.StatusOK || !bytes.Contains(rec.Body.Bytes(), []byte("(Acme Travel) '")) || !bytes.Contains(rec.Body.Bytes(), []byte("(Acme internal use) '")) {
+		t.Errorf("Expected a branded manifest, got %d", rec.Code)
+	}
+
+	if rec := send(http.MethodDelete, "/admin/tenants/acme", ""); rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 deleting the tenant, got %d", rec.Code)
+	}
+	if rec := send(http.MethodGet, "/admin/tenants/acme", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 after deleting the tenant, got %d", rec.Code)
+	}
+	if rec := send(http.MethodPost, "/ticket", booking(10, 3, `{}`)); rec.Code != http.StatusCreated {
+		t.Errorf("Expected the defaults after deleting the tenant, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+func TestTravelers(t *testing.T) {
+	router := newTestRouter(t)
+	send := func(method, target, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", key)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send(http.MethodPost, "/travelers", "desk-key", `{"first_name":" Jane ","last_name":"Doe","date_of_birth":"1985-04-12",
+		"documents":[{"type":"passport","number":"x 1234567","issuing_country":"us","expires_on":"2030-05-31"}]}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var traveler models.Traveler
+	json.NewDecoder(rec.Body).Decode(&traveler)
+	if traveler.Owner != "desk" || traveler.FirstName != "Jane" || traveler.Documents[0].Number != "X1234567" {
+		t.Fatalf("Expected a normalized traveler of desk, got %+v", traveler)
+	}
+
+	if rec := send(http.MethodPost, "/travelers", "desk-key", `{"first_name":"Jane","last_name":"Doe","date_of_birth":"12/04/1985"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid date of birth, got %d", rec.Code)
+	}
+	if rec := send(http.MethodGet, "/travelers/"+traveler.ID, "fuzz-key", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected another key's traveler to be hidden, got %d", rec.Code)
+	}
+	var list models.TravelerListResponse
+	json.NewDecoder(send(http.MethodGet, "/travelers", "fuzz-key", "").Body).Decode(&list)
+	if list.Count != 0 {
+		t.Errorf("Expected no travelers for another key, got %+v", list)
+	}
+
+	departure := time.Now().UTC().AddDate(0, 1, 0).Format("2006-01-02")
+	booking := `{"origin":"JFK","destination":"LAX","departure_date":"` + departure + `","departure_time":"09:00","traveler_ids":["` + traveler.ID + `"]}`
+	rec = send(http.MethodPost, "/ticket", "desk-key", booking)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201 booking a saved traveler, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var ticket models.FlightTicket
+	json.NewDecoder(rec.Body).Decode(&ticket)
+	if ticket.Passengers != 1 || ticket.Labels[models.TravelerLabel(1)] != traveler.ID {
+		t.Errorf("Expected one passenger referencing the traveler, got %d passengers and labels %v", ticket.Passengers, ticket.Labels)
+	}
+	if rec := send(http.MethodPost, "/ticket", "fuzz-key", booking); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 booking another key's traveler, got %d", rec.Code)
+	}
+	twice := strings.Replace(booking, `"traveler_ids":[`, `"traveler_ids":["`+traveler.ID+`",`, 1)
+	if rec := send(http.MethodPost, "/ticket", "desk-key", twice); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a traveler listed twice, got %d", rec.Code)
+	}
+	labeled := strings.Replace(booking, `"traveler_ids"`, `"labels":{"traveler_1":"aaaaaaaaaaaa"},"traveler_ids"`, 1)
+	if rec := send(http.MethodPost, "/ticket", "desk-key", labeled); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a reserved traveler label, got %d", rec.Code)
+	}
+
+	rec = send(http.MethodPut, "/travelers/"+traveler.ID, "desk-key", `{"first_name":"Jane","last_name":"Smith","date_of_birth":"1985-04-12","loyalty_number":"aa123"}`)
+	var replaced models.Traveler
+	json.NewDecoder(rec.Body).Decode(&replaced)
+	if rec.Code != http.StatusOK || replaced.LastName != "Smith" || replaced.LoyaltyNumber != "AA123" || len(replaced.Documents) != 0 {
+		t.Errorf("Expected the traveler replaced, got %d: %+v", rec.Code, replaced)
+	}
+	if rec := send(http.MethodDelete, "/travelers/"+traveler.ID, "desk-key", ""); rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 deleting the traveler, got %d", rec.Code)
+	}
+	if rec := send(http.MethodGet, "/travelers/"+traveler.ID, "desk-key", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 after deleting, got %d", rec.Code)
+	}
+}
+
+func TestTravelDocuments(t *testing.T) {
+	router := newTestRouter(t)
+	send := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", "desk-key")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	departure := time.Now().UTC().AddDate(0, 1, 0)
+	save := func(expires time.Time) string {
+		rec := send(http.MethodPost, "/travelers", `{"first_name":"Jane","last_name":"Doe","date_of_birth":"1985-04-12",
+			"documents":[{"type":"PASSPORT","number":"X1234567","issuing_country":"US","expires_on":"`+expires.Format("2006-01-02")+`"}]}`)
+		var traveler models.Traveler
+		json.NewDecoder(rec.Body).Decode(&traveler)
+		return traveler.ID
+	}
+	book := func(destination, id string) *httptest.ResponseRecorder {
+		return send(http.MethodPost, "/ticket", `{"origin":"JFK","destination":"`+destination+`","departure_date":"`+departure.Format("2006-01-02")+`","departure_time":"09:00","traveler_ids":["`+id+`"]}`)
+	}
+
+	valid := save(departure.AddDate(2, 0, 0))
+	rec := book("DEL", valid)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var ticket models.FlightTicket
+	json.NewDecoder(rec.Body).Decode(&ticket)
+	if len(ticket.DocumentIssues) != 1 || ticket.DocumentIssues[0].Code != models.DocumentIssueVisaRequired || ticket.DocumentIssues[0].Blocking {
+		t.Errorf("Expected a visa flag, got %+v", ticket.DocumentIssues)
+	}
+
+	expiring := save(departure.AddDate(0, 3, 0))
+	if rec := book("DEL", expiring); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for a passport expiring within 6 months of the trip, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := book("LAX", expiring); rec.Code != http.StatusCreated || strings.Contains(rec.Body.String(), "document_issues") {
+		t.Errorf("Expected domestic flights unchecked, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestInternationalAdvisories(t *testing.T) {
+	router := newTestRouter(t)
+	send := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", "fuzz-key")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	departure := time.Now().UTC().Add(6 * time.Hour)
+
+	rec := send(http.MethodPost, "/ticket", `{"origin":"JFK","destination":"SIN","departure_date":"`+departure.Format("2006-01-02")+`","departure_time":"`+departure.Format("15:04")+`","passengers":1}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var ticket models.FlightTicket
+	json.NewDecoder(rec.Body).Decode(&ticket)
+	if !ticket.International || len(ticket.Advisories) != 2 || ticket.Advisories[1].Type != models.AdvisoryArrivalForm {
+		t.Errorf("Expected an international ticket with the SG requirements, got %+v", ticket)
+	}
+
+	var stored models.FlightTicket
+	json.NewDecoder(send(http.MethodGet, "/ticket/"+ticket.ConfirmationID, "").Body).Decode(&stored)
+	if !stored.International || len(stored.Advisories) != 2 {
+		t.Errorf("Expected advisories on the stored ticket, got %+v", stored)
+	}
+
+	rec = send(http.MethodPost, "/ticket/"+ticket.ConfirmationID+"/checkin", `{"passengers":[{"first_name":"Jane","last_name":"Doe"}]}`)
+	var checkIn models.CheckInResponse
+	json.NewDecoder(rec.Body).Decode(&checkIn)
+	if rec.Code != http.StatusOK || len(checkIn.Advisories) != 2 || checkIn.Advisories[0].Country != "SG" {
+		t.Errorf("Expected the advisories with the boarding passes, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = send(http.MethodGet, "/ticket/"+seededTicket, "")
+	if strings.Contains(rec.Body.String(), "travel_advisories") || strings.Contains(rec.Body.String(), "international") {
+		t.Errorf("Expected no advisories on domestic tickets, got %s", rec.Body.String())
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+func TestUpgradeOffers(t *testing.T) {
+	router := newTestRouter(t)
+	send := func(key, method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", key)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	getTicket := func(id string) models.FlightTicket {
+		var ticket models.FlightTicket
+		json.NewDecoder(send("desk-key", http.MethodGet, "/ticket/"+id, "").Body).Decode(&ticket)
+		return ticket
+	}
+
+	departure := time.Now().UTC().AddDate(0, 0, 40).Format("2006-01-02")
+	booking := `{"origin":"JFK","destination":"LAX","departure_date":"` + departure + `","departure_time":"09:00","flight_number":"AA1234",`
+	var pair, single models.FlightTicket
+	json.NewDecoder(send("desk-key", http.MethodPost, "/ticket", booking+`"passengers":2}`).Body).Decode(&pair)
+	json.NewDecoder(send("desk-key", http.MethodPost, "/ticket", booking+`"passengers":1}`).Body).Decode(&single)
+	if pair.ConfirmationID == "" || single.ConfirmationID == "" || pair.Price == nil {
+		t.Fatalf("Failed to book tickets: %+v, %+v", pair, single)
+	}
+
+	upgradesURL := "/admin/upgrades/AA1234/" + departure
+	if rec := send("desk-key", http.MethodPost, upgradesURL+"/offers", `{"cabin":"J","price":150}`); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for an agent key, got %d", rec.Code)
+	}
+	if rec := send("fuzz-key", http.MethodPost, upgradesURL+"/offers", `{"cabin":"J","type":"BID","price":150}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a bid offer with a price, got %d", rec.Code)
+	}
+	if rec := send("fuzz-key", http.MethodPost, upgradesURL+"/offers", `{"cabin":"F","price":400}`); rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for a cabin without upgrade seats, got %d", rec.Code)
+	}
+
+	rec := send("fuzz-key", http.MethodPost, upgradesURL+"/offers", `{"cabin":"j","price":150}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var offered models.UpgradeOffersResponse
+	json.NewDecoder(rec.Body).Decode(&offered)
+	if offered.Count != 2 {
+		t.Fatalf("Expected offers to both tickets, got %+v", offered)
+	}
+	rec = send("fuzz-key", http.MethodPost, upgradesURL+"/offers", `{"cabin":"J","price":150}`)
+	json.NewDecoder(rec.Body).Decode(&offered)
+	if offered.Count != 0 {
+		t.Errorf("Expected no second offer while the first is open, got %d", offered.Count)
+	}
+
+	var listed models.UpgradeOffersResponse
+	json.NewDecoder(send("desk-key", http.MethodGet, "/ticket/"+pair.ConfirmationID+"/upgrades", "").Body).Decode(&listed)
+	if listed.Count != 1 || listed.Offers[0].Passengers != 2 || listed.Offers[0].Status != models.UpgradeOffered {
+		t.Fatalf("Expected one open offer for 2 passengers, got %+v", listed)
+	}
+	offer := listed.Offers[0]
+
+	if rec := send("desk-key", http.MethodPost, "/ticket/"+single.ConfirmationID+"/upgrades/"+offer.ID+"/accept", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for another ticket's offer, got %d", rec.Code)
+	}
+	var lock models.TicketLock
+	json.NewDecoder(send("fuzz-key", http.MethodPost, "/ticket/"+pair.ConfirmationID+"/lock", "").Body).Decode(&lock)
+	if rec := send("desk-key", http.MethodPost, "/ticket/"+pair.ConfirmationID+"/upgrades/"+offer.ID+"/accept", ""); rec.Code != http.StatusLocked {
+		t.Errorf("Expected 423 accepting for a locked ticket, got %d", rec.Code)
+	}
+	unlock := httptest.NewRequest(http.MethodDelete, "/ticket/"+pair.ConfirmationID+"/lock", nil)
+	unlock.Header.Set("X-API-Key", "fuzz-key")
+	unlock.Header.Set("X-Lock-Token", lock.Token)
+	router.ServeHTTP(httptest.NewRecorder(), unlock)
+	rec = send("desk-key", http.MethodPost, "/ticket/"+pair.ConfirmationID+"/upgrades/"+offer.ID+"/accept", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	upgraded := getTicket(pair.ConfirmationID)
+	if upgraded.Cabin != models.CabinBusiness || upgraded.Price.BaseAmount != pair.Price.BaseAmount+300 {
+		t.Errorf("Expected the ticket in J for 300 more, got %s at %+v", upgraded.Cabin, upgraded.Price)
+	}
+	if rec := send("desk-key", http.MethodPost, "/ticket/"+pair.ConfirmationID+"/upgrades/"+offer.ID+"/decline", ""); rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 declining an accepted offer, got %d", rec.Code)
+	}
+
+	// The cabin is kept on relabel and cannot be set directly
+	send("desk-key", http.MethodPut, "/ticket/"+pair.ConfirmationID, `{"labels":{"campaign":"spring"}}`)
+	if cabin := getTicket(pair.ConfirmationID).Cabin; cabin != models.CabinBusiness {
+		t.Errorf("Expected the cabin kept on relabel, got %q", cabin)
+	}
+	if rec := send("desk-key", http.MethodPut, "/ticket/"+single.ConfirmationID, `{"labels":{"cabin":"f"}}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a reserved cabin label, got %d", rec.Code)
+	}
+
+	var departureUpgrades models.UpgradeDepartureResponse
+	json.NewDecoder(send("fuzz-key", http.MethodGet, upgradesURL, "").Body).Decode(&departureUpgrades)
+	if departureUpgrades.Count != 2 || departureUpgrades.Cabins[1].Cabin != models.CabinBusiness || departureUpgrades.Cabins[1].Available != 2 {
+		t.Errorf("Expected 2 business seats left, got %+v", departureUpgrades)
+	}
+
+	// Only the economy ticket can bid for premium economy
+	rec = send("fuzz-key", http.MethodPost, upgradesURL+"/offers", `{"cabin":"W","type":"BID","min_bid":50,"max_bid":120}`)
+	json.NewDecoder(rec.Body).Decode(&offered)
+	if offered.Count != 1 || offered.Offers[0].ConfirmationID != single.ConfirmationID {
+		t.Fatalf("Expected a bid offer to the economy ticket, got %+v", offered)
+	}
+	bidURL := "/ticket/" + single.ConfirmationID + "/upgrades/" + offered.Offers[0].ID + "/accept"
+	if rec := send("desk-key", http.MethodPost, bidURL, `{"bid":40}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a bid under the minimum, got %d", rec.Code)
+	}
+	rec = send("desk-key", http.MethodPost, bidURL, `{"bid":60}`)
+	var bid models.UpgradeOffer
+	json.NewDecoder(rec.Body).Decode(&bid)
+	if rec.Code != http.StatusOK || bid.Status != models.UpgradeBidPlaced || bid.Bid != 60 {
+		t.Fatalf("Expected the bid placed, got %d: %+v", rec.Code, bid)
+	}
+	if getTicket(single.ConfirmationID).Cabin != "" {
+		t.Error("Expected the ticket to stay in economy until bids are awarded")
+	}
+
+	rec = send("fuzz-key", http.MethodPost, upgradesURL+"/award", `{"cabin":"W"}`)
+	var award models.UpgradeAwardResponse
+	json.NewDecoder(rec.Body).Decode(&award)
+	if rec.Code != http.StatusOK || len(award.Awarded) != 1 || len(award.Rejected) != 0 {
+		t.Fatalf("Expected the bid awarded, got %d: %+v", rec.Code, award)
+	}
+	if ticket := getTicket(single.ConfirmationID); ticket.Cabin != models.CabinPremiumEconomy || ticket.Price.BaseAmount != single.Price.BaseAmount+60 {
+		t.Errorf("Expected the ticket in W for 60 more, got %s at %+v", ticket.Cabin, ticket.Price)
+	}
+}
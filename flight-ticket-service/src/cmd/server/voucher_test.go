@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+func TestCancelledFlightVouchers(t *testing.T) {
+	router := newTestRouter(t)
+	send := func(key, method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", key)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	book := func(flightNumber, passengers string) models.FlightTicket {
+		var ticket models.FlightTicket
+		departure := time.Now().UTC().AddDate(0, 0, 30).Format("2006-01-02")
+		body := `{"origin":"JFK","destination":"LAX","departure_date":"` + departure + `","departure_time":"09:00","flight_number":"` + flightNumber + `","passengers":` + passengers + `}`
+		json.NewDecoder(send("desk-key", http.MethodPost, "/ticket", body).Body).Decode(&ticket)
+		if ticket.ConfirmationID == "" || ticket.Price == nil {
+			t.Fatalf("Failed to book %s", flightNumber)
+		}
+		return ticket
+	}
+
+	// JFK departures are compensated with the fare and 50 per passenger
+	if rec := send("fuzz-key", http.MethodPut, "/admin/rules/jfk-refund", `{"type":"cancellation_compensation","origin":"JFK","compensation_percent":100,"compensation_amount":50}`); rec.Code != http.StatusOK && rec.Code != http.StatusCreated {
+		t.Fatalf("Failed to save the compensation rule: %d %s", rec.Code, rec.Body.String())
+	}
+	cancelled := book("AA7777", "2")
+	rebooked := book("AA7788", "1")
+
+	rec := send("fuzz-key", http.MethodPost, "/jobs", `{"kind":"bulk_cancel","params":{"flight_number":"AA7777"}}`)
+	var job models.Job
+	json.NewDecoder(rec.Body).Decode(&job)
+	deadline := time.Now().Add(5 * time.Second)
+	for job.Status != "succeeded" {
+		if job.Status == "failed" || time.Now().After(deadline) {
+			t.Fatalf("Job did not succeed: %+v", job)
+		}
+		time.Sleep(10 * time.Millisecond)
+		id := job.ID
+		job = models.Job{}
+		json.NewDecoder(send("fuzz-key", http.MethodGet, "/jobs/"+id, "").Body).Decode(&job)
+	}
+	if job.Result["vouchers_issued"] != float64(1) {
+		t.Fatalf("Expected one voucher issued, got %v", job.Result)
+	}
+
+	var listed models.VouchersResponse
+	json.NewDecoder(send("desk-key", http.MethodGet, "/ticket/"+cancelled.ConfirmationID+"/vouchers", "").Body).Decode(&listed)
+	if listed.Count != 1 {
+		t.Fatalf("Expected one voucher, got %+v", listed)
+	}
+	voucher := listed.Vouchers[0]
+	if want := cancelled.Price.BaseAmount + 100; voucher.Amount != want || voucher.Balance != want || voucher.Rule != "jfk-refund" || voucher.Status != models.VoucherIssued {
+		t.Errorf("Expected a voucher of %.2f from jfk-refund, got %+v", want, voucher)
+	}
+
+	redeemURL := "/vouchers/" + strings.ToLower(voucher.Code) + "/redeem"
+	if rec := send("desk-key", http.MethodPost, "/vouchers/NOSUCHCODE00/redeem", `{"confirmation_id":"`+rebooked.ConfirmationID+`"}`); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown code, got %d", rec.Code)
+	}
+	if rec := send("desk-key", http.MethodPost, redeemURL, `{"confirmation_id":"`+cancelled.ConfirmationID+`"}`); rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 redeeming against a cancelled ticket, got %d", rec.Code)
+	}
+
+	rec = send("desk-key", http.MethodPost, redeemURL, `{"confirmation_id":"`+rebooked.ConfirmationID+`"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var redeemed models.VoucherRedeemResponse
+	json.NewDecoder(rec.Body).Decode(&redeemed)
+	fare := rebooked.Price.BaseAmount
+	if redeemed.Credit != fare || redeemed.Ticket.Price.BaseAmount != 0 || redeemed.Ticket.Price.VoucherCredit != fare {
+		t.Errorf("Expected the whole fare of %.2f credited, got %.2f and %+v", fare, redeemed.Credit, redeemed.Ticket.Price)
+	}
+	if redeemed.Voucher.Balance != voucher.Amount-fare || redeemed.Voucher.Status != models.VoucherIssued || len(redeemed.Voucher.Redemptions) != 1 {
+		t.Errorf("Expected the rest of the voucher left, got %+v", redeemed.Voucher)
+	}
+	if rec := send("desk-key", http.MethodPost, redeemURL, `{"confirmation_id":"`+rebooked.ConfirmationID+`"}`); rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 redeeming twice against a ticket, got %d", rec.Code)
+	}
+
+	// The credit is stored on the ticket once, and taken off the voucher once
+	var stored models.FlightTicket
+	json.NewDecoder(send("desk-key", http.MethodGet, "/ticket/"+rebooked.ConfirmationID, "").Body).Decode(&stored)
+	if stored.Price == nil || stored.Price.BaseAmount != 0 || stored.Price.VoucherCredit != fare {
+		t.Errorf("Expected the stored ticket credited %.2f once, got %+v", fare, stored.Price)
+	}
+	listed = models.VouchersResponse{}
+	json.NewDecoder(send("desk-key", http.MethodGet, "/ticket/"+cancelled.ConfirmationID+"/vouchers", "").Body).Decode(&listed)
+	if listed.Count != 1 || listed.Vouchers[0].Balance != voucher.Amount-fare || len(listed.Vouchers[0].Redemptions) != 1 {
+		t.Errorf("Expected the stored voucher redeemed once, got %+v", listed)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"time"
+
+	"flight-ticket-service/src/services"
+)
+
+// defaultWarmUpTimeout bounds the warm-up read; a slow backend delays startup by at most this long
+const defaultWarmUpTimeout = 10 * time.Second
+
+// warmUpPaths are requested in-process by the CPU warm-up. They touch no
+// storage but build the OpenAPI spec and run every middleware once.
+var warmUpPaths = []string{"/health", "/version", "/swagger/doc.json"}
+
+// warmUpConfig selects the warm-up done before the server starts listening
+type warmUpConfig struct {
+	Timeout time.Duration // 0 skips the warm-up
+	CPU     bool
+}
+
+// warmUpConfigFromEnv reads WARMUP_TIMEOUT and WARMUP_CPU
+func warmUpConfigFromEnv() (warmUpConfig, error) {
+	config := warmUpConfig{Timeout: defaultWarmUpTimeout}
+	if value := os.Getenv("WARMUP_TIMEOUT"); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout < 0 {
+			return warmUpConfig{}, fmt.Errorf("invalid WARMUP_TIMEOUT %q: must be a duration, 0 to skip the warm-up", value)
+		}
+		config.Timeout = timeout
+	}
+	if value := os.Getenv("WARMUP_CPU"); value != "" {
+		cpu, err := strconv.ParseBool(value)
+		if err != nil {
+			return warmUpConfig{}, fmt.Errorf("invalid WARMUP_CPU %q: must be true or false", value)
+		}
+		config.CPU = cpu
+	}
+	return config, nil
+}
+
+// warmUp reads a ticket, so that the storage client has connected and fetched
+// its credentials before the first request, and with CPU set serves a few
+// requests in-process. It runs before the server listens: Cloud Run's startup
+// probe only passes once the port is open, so no traffic reaches a cold
+// instance. Failures are logged and do not stop the server.
+func warmUp(repository services.TicketRepository, router http.Handler, config warmUpConfig) {
+	if config.Timeout == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := repository.ListTickets(ctx, 1); err != nil {
+		log.Printf("Warning: storage warm-up failed after %v: %v", time.Since(start).Round(time.Millisecond), err)
+	} else {
+		log.Printf("Storage warm-up read took %v", time.Since(start).Round(time.Millisecond))
+	}
+
+	if !config.CPU {
+		return
+	}
+	start = time.Now()
+	for _, path := range warmUpPaths {
+		if ctx.Err() != nil {
+			break
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil).WithContext(ctx))
+		if rec.Code != http.StatusOK {
+			log.Printf("Warning: warm-up request GET %s returned %d", path, rec.Code)
+		}
+	}
+	log.Printf("CPU warm-up took %v", time.Since(start).Round(time.Millisecond))
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/services"
+)
+
+// slowRepository blocks reads until their context is done, like an unreachable backend
+type slowRepository struct {
+	services.TicketRepository
+	reads int
+}
+
+func (r *slowRepository) ListTickets(ctx context.Context, limit int) ([]*models.FlightTicket, error) {
+	r.reads++
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestWarmUpConfigFromEnv(t *testing.T) {
+	t.Setenv("WARMUP_TIMEOUT", "")
+	t.Setenv("WARMUP_CPU", "")
+	config, err := warmUpConfigFromEnv()
+	if err != nil || config.Timeout != defaultWarmUpTimeout || config.CPU {
+		t.Errorf("Unexpected default config %+v, %v", config, err)
+	}
+
+	t.Setenv("WARMUP_TIMEOUT", "0")
+	t.Setenv("WARMUP_CPU", "true")
+	config, err = warmUpConfigFromEnv()
+	if err != nil || config.Timeout != 0 || !config.CPU {
+		t.Errorf("Unexpected config %+v, %v", config, err)
+	}
+
+	t.Setenv("WARMUP_TIMEOUT", "soon")
+	if _, err := warmUpConfigFromEnv(); err == nil {
+		t.Error("Expected an error for an invalid timeout")
+	}
+}
+
+func TestWarmUpIsBounded(t *testing.T) {
+	repository := &slowRepository{TicketRepository: services.NewMemoryRepository()}
+
+	warmUp(repository, newTestRouter(t), warmUpConfig{Timeout: 0})
+	if repository.reads != 0 {
+		t.Errorf("Expected no warm-up with a zero timeout, got %d reads", repository.reads)
+	}
+
+	start := time.Now()
+	warmUp(repository, newTestRouter(t), warmUpConfig{Timeout: 50 * time.Millisecond, CPU: true})
+	if repository.reads != 1 {
+		t.Errorf("Expected one warm-up read, got %d", repository.reads)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the warm-up to give up after its timeout, took %v", elapsed)
+	}
+}
@@ -0,0 +1,522 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"flight-ticket-service/src/internal/openapi"
+)
+
+type options struct {
+	Source  string   // spec file named in the headers
+	Exclude []string // path prefixes
+}
+
+// identifier matches property names that need no quotes
+var identifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// reserved are the TypeScript words that cannot name parameters
+var reserved = map[string]bool{"break": true, "case": true, "class": true, "const": true, "default": true, "delete": true, "do": true, "else": true, "enum": true, "export": true, "for": true, "function": true, "if": true, "import": true, "in": true, "new": true, "return": true, "switch": true, "this": true, "type": true, "var": true, "void": true, "while": true, "with": true}
+
+// generator writes the TypeScript of one spec
+type generator struct {
+	spec  *openapi.Spec
+	names map[string]string // TypeScript type by definition
+}
+
+// generate returns types.ts and client.ts and the number of operations
+func generate(data []byte, opts options) (map[string][]byte, int, error) {
+	spec, err := openapi.Parse(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	endpoints, err := spec.Endpoints(opts.Exclude)
+	if err != nil {
+		return nil, 0, err
+	}
+	g := &generator{spec: spec, names: typeNames(spec)}
+	header := fmt.Sprintf("// Code generated by tsclient from %s; DO NOT EDIT.\n\n", opts.Source)
+	types := g.types()
+	client, err := g.client(endpoints)
+	if err != nil {
+		return nil, 0, err
+	}
+	return map[string][]byte{
+		"types.ts":  append([]byte(header), types...),
+		"client.ts": append([]byte(header), client...),
+	}, len(endpoints), nil
+}
+
+// typeNames names the definitions without their Go package, e.g.
+// models.FlightTicket is FlightTicket, unless two packages share a name
+func typeNames(spec *openapi.Spec) map[string]string {
+	count := make(map[string]int)
+	for definition := range spec.Definitions {
+		count[shortName(definition)]++
+	}
+	names := make(map[string]string, len(spec.Definitions))
+	for definition := range spec.Definitions {
+		name := shortName(definition)
+		if count[name] > 1 {
+			name = pascal(definition)
+		}
+		names[definition] = name
+	}
+	return names
+}
+
+func shortName(definition string) string {
+	return pascal(definition[strings.LastIndex(definition, ".")+1:])
+}
+
+// pascal converts a name to PascalCase: models.flight_ticket is ModelsFlightTicket
+func pascal(name string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(openapi.Snake(name), "_") {
+		if part != "" {
+			b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	return b.String()
+}
+
+// camel converts a name to camelCase: confirmationID is confirmationId
+func camel(name string) string {
+	name = pascal(name)
+	if name == "" {
+		return name
+	}
+	name = strings.ToLower(name[:1]) + name[1:]
+	if reserved[name] {
+		name += "_"
+	}
+	return name
+}
+
+// types returns an interface per object definition and a type alias per other definition
+func (g *generator) types() []byte {
+	definitions := make([]string, 0, len(g.spec.Definitions))
+	for definition := range g.spec.Definitions {
+		definitions = append(definitions, definition)
+	}
+	sort.Slice(definitions, func(i, j int) bool { return g.names[definitions[i]] < g.names[definitions[j]] })
+
+	var b bytes.Buffer
+	for i, definition := range definitions {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		schema := g.spec.Definitions[definition]
+		writeDoc(&b, "", description(schema))
+		properties, _ := schema["properties"].(map[string]any)
+		if schema["type"] == "object" && len(properties) > 0 {
+			fmt.Fprintf(&b, "export interface %s %s\n", g.names[definition], g.objectType(schema, "", ""))
+			continue
+		}
+		fmt.Fprintf(&b, "export type %s = %s;\n", g.names[definition], g.tsType(schema, "", ""))
+	}
+	return b.Bytes()
+}
+
+// tsType returns the TypeScript type of a schema. Definitions are named with
+// the prefix, e.g. "types." in client.ts.
+func (g *generator) tsType(schema map[string]any, prefix, indent string) string {
+	if schema == nil {
+		return "unknown"
+	}
+	schema = openapi.Unwrap(schema)
+	if name := openapi.RefName(schema); name != "" {
+		if typeName, ok := g.names[name]; ok {
+			return prefix + typeName
+		}
+		return "unknown"
+	}
+	if values, ok := schema["enum"].([]any); ok && len(values) > 0 {
+		literals := make([]string, len(values))
+		for i, value := range values {
+			switch v := value.(type) {
+			case string:
+				literals[i] = strconv.Quote(v)
+			default:
+				literals[i] = fmt.Sprint(v)
+			}
+		}
+		return strings.Join(literals, " | ")
+	}
+	switch schema["type"] {
+	case "string":
+		return "string"
+	case "integer", "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "file":
+		return "Blob"
+	case "array":
+		items, _ := schema["items"].(map[string]any)
+		item := g.tsType(items, prefix, indent)
+		if strings.Contains(item, " | ") {
+			item = "(" + item + ")"
+		}
+		return item + "[]"
+	case "object":
+		if properties, _ := schema["properties"].(map[string]any); len(properties) > 0 {
+			return g.objectType(schema, prefix, indent)
+		}
+		if values, ok := schema["additionalProperties"].(map[string]any); ok {
+			return "Record<string, " + g.tsType(values, prefix, indent) + ">"
+		}
+		return "Record<string, unknown>"
+	}
+	return "unknown"
+}
+
+// objectType returns the object literal type of a schema with properties
+func (g *generator) objectType(schema map[string]any, prefix, indent string) string {
+	properties, _ := schema["properties"].(map[string]any)
+	required := make(map[string]bool)
+	if names, ok := schema["required"].([]any); ok {
+		for _, name := range names {
+			required[fmt.Sprint(name)] = true
+		}
+	}
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b bytes.Buffer
+	b.WriteString("{\n")
+	inner := indent + "  "
+	for _, name := range names {
+		property, _ := properties[name].(map[string]any)
+		writeDoc(&b, inner, description(openapi.Unwrap(property)))
+		key := name
+		if !identifier.MatchString(name) {
+			key = strconv.Quote(name)
+		}
+		optional := "?"
+		if required[name] {
+			optional = ""
+		}
+		fmt.Fprintf(&b, "%s%s%s: %s;\n", inner, key, optional, g.tsType(property, prefix, inner))
+	}
+	b.WriteString(indent + "}")
+	return b.String()
+}
+
+func description(schema map[string]any) string {
+	text, _ := schema["description"].(string)
+	return strings.TrimSpace(text)
+}
+
+// writeDoc writes a JSDoc comment, if there is text
+func writeDoc(b *bytes.Buffer, indent, text string) {
+	if text == "" {
+		return
+	}
+	text = strings.ReplaceAll(text, "*/", "*\\/")
+	lines := strings.Split(text, "\n")
+	if len(lines) == 1 {
+		fmt.Fprintf(b, "%s/** %s */\n", indent, lines[0])
+		return
+	}
+	fmt.Fprintf(b, "%s/**\n", indent)
+	for _, line := range lines {
+		if line = strings.TrimRight(line, " "); line == "" {
+			fmt.Fprintf(b, "%s *\n", indent)
+			continue
+		}
+		fmt.Fprintf(b, "%s * %s\n", indent, line)
+	}
+	fmt.Fprintf(b, "%s */\n", indent)
+}
+
+// client returns the client class with a method per endpoint
+func (g *generator) client(endpoints []openapi.Endpoint) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString(clientPrelude)
+	for _, endpoint := range endpoints {
+		method, err := g.method(endpoint)
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString("\n")
+		b.WriteString(method)
+	}
+	b.WriteString("}\n")
+	return b.Bytes(), nil
+}
+
+// method returns the client method of an endpoint
+func (g *generator) method(endpoint openapi.Endpoint) (string, error) {
+	op := endpoint.Operation
+	var args []string
+	used := make(map[string]bool)
+	argument := func(name string) (string, error) {
+		arg := camel(name)
+		if used[arg] || arg == "options" {
+			return "", fmt.Errorf("%s %s: parameter %s is defined twice", endpoint.Method, endpoint.Path, name)
+		}
+		used[arg] = true
+		return arg, nil
+	}
+
+	// Path parameters, in the order of the path
+	pathArgs := make(map[string]string)
+	for _, param := range op.Parameters {
+		if param.In != "path" {
+			continue
+		}
+		arg, err := argument(param.Name)
+		if err != nil {
+			return "", err
+		}
+		pathArgs[param.Name] = arg
+		args = append(args, fmt.Sprintf("%s: %s", arg, g.tsType(paramSchema(param), "types.", "  ")))
+	}
+	path, err := pathExpression(endpoint.Path, pathArgs)
+	if err != nil {
+		return "", fmt.Errorf("%s %s: %v", endpoint.Method, endpoint.Path, err)
+	}
+
+	body := "undefined"
+	for _, param := range op.Parameters {
+		if param.In != "body" {
+			continue
+		}
+		arg, err := argument(param.Name)
+		if err != nil {
+			return "", err
+		}
+		optional := "?"
+		if param.Required {
+			optional = ""
+		}
+		args = append(args, fmt.Sprintf("%s%s: %s", arg, optional, g.tsType(param.Schema, "types.", "  ")))
+		body = arg
+	}
+
+	// Query and header parameters go in an options object
+	var fields, query, headers []string
+	optionsRequired := false
+	for _, param := range op.Parameters {
+		if param.In != "query" && param.In != "header" {
+			continue
+		}
+		key := param.Name
+		if param.In == "header" {
+			key = camel(strings.TrimPrefix(strings.ToLower(param.Name), "x-"))
+		}
+		quoted := key
+		if !identifier.MatchString(key) {
+			quoted = strconv.Quote(key)
+		}
+		optional := "?"
+		if param.Required {
+			optional = ""
+			optionsRequired = true
+		}
+		doc := ""
+		if param.Description != "" {
+			doc = "/** " + strings.ReplaceAll(param.Description, "*/", "*\\/") + " */ "
+		}
+		fields = append(fields, fmt.Sprintf("%s%s%s: %s", doc, quoted, optional, g.tsType(paramSchema(param), "types.", "    ")))
+		value := "options." + key
+		if !identifier.MatchString(key) {
+			value = "options[" + strconv.Quote(key) + "]"
+		}
+		entry := fmt.Sprintf("%s: %s", strconv.Quote(param.Name), value)
+		if param.In == "query" {
+			query = append(query, entry)
+		} else {
+			headers = append(headers, entry)
+		}
+	}
+	if len(fields) > 0 {
+		arg := "options: { " + strings.Join(fields, "; ") + " }"
+		if !optionsRequired {
+			arg += " = {}"
+		}
+		args = append(args, arg)
+	}
+
+	result, responseType := "void", "none"
+	if response, ok := op.SuccessResponse(); ok && response.Schema != nil {
+		result, responseType = g.tsType(response.Schema, "types.", "  "), "json"
+		if result == "Blob" {
+			responseType = "blob"
+		}
+	} else if len(op.Produces) > 0 && !contains(op.Produces, "application/json") {
+		result, responseType = "Blob", "blob"
+	}
+
+	var b strings.Builder
+	doc := strings.TrimSpace(op.Summary)
+	if op.Description != "" && op.Description != op.Summary {
+		doc += "\n\n" + strings.TrimSpace(op.Description)
+	}
+	doc += "\n\n" + endpoint.Method + " " + endpoint.Path
+	writeDocString(&b, "  ", strings.TrimSpace(doc))
+	fmt.Fprintf(&b, "  %s(%s): Promise<%s> {\n", camel(endpoint.Name), strings.Join(args, ", "), result)
+	fmt.Fprintf(&b, "    return this.request<%s>(%s, %s, { %s }, { %s }, %s, %s);\n",
+		result, strconv.Quote(endpoint.Method), path, strings.Join(query, ", "), strings.Join(headers, ", "), body, strconv.Quote(responseType))
+	b.WriteString("  }\n")
+	return strings.ReplaceAll(b.String(), "{  }", "{}"), nil
+}
+
+func writeDocString(b *strings.Builder, indent, text string) {
+	var buf bytes.Buffer
+	writeDoc(&buf, indent, text)
+	b.Write(buf.Bytes())
+}
+
+// pathExpression returns the TypeScript expression of a path with its
+// parameters encoded: "/ticket/" + encodeURIComponent(confirmationId)
+func pathExpression(path string, args map[string]string) (string, error) {
+	var parts []string
+	rest := path
+	for {
+		start := strings.Index(rest, "{")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(rest[start:], "}")
+		if end < 0 {
+			return "", fmt.Errorf("unclosed parameter")
+		}
+		name := rest[start+1 : start+end]
+		arg, ok := args[name]
+		if !ok {
+			return "", fmt.Errorf("path parameter %s is not documented", name)
+		}
+		if rest[:start] != "" {
+			parts = append(parts, strconv.Quote(rest[:start]))
+		}
+		parts = append(parts, "encodeURIComponent(String("+arg+"))")
+		rest = rest[start+end+1:]
+	}
+	if rest != "" || len(parts) == 0 {
+		parts = append(parts, strconv.Quote(rest))
+	}
+	return strings.Join(parts, " + "), nil
+}
+
+// paramSchema returns the schema of a path, query or header parameter
+func paramSchema(param openapi.Parameter) map[string]any {
+	schema := map[string]any{"type": param.Type}
+	if len(param.Enum) > 0 {
+		schema["enum"] = param.Enum
+	}
+	if param.Items != nil {
+		schema["items"] = param.Items
+	}
+	return schema
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// clientPrelude is the part of client.ts that does not depend on the spec
+const clientPrelude = `import type * as types from "./types.js";
+
+/** ApiError is thrown for responses with a non-2xx status */
+export class ApiError extends Error {
+  /** HTTP status of the response */
+  readonly status: number;
+  /** Parsed JSON body, usually an ErrorResponse, or the text of the body */
+  readonly body: unknown;
+
+  constructor(status: number, body: unknown) {
+    super("API returned status " + status);
+    this.name = "ApiError";
+    this.status = status;
+    this.body = body;
+  }
+}
+
+export interface ClientOptions {
+  /** Base URL of the service, e.g. http://localhost:8080 */
+  baseUrl: string;
+  /** API key, sent as X-API-Key */
+  apiKey?: string;
+  /** Identity token, sent as a Bearer token */
+  bearerToken?: string;
+  /** fetch implementation; defaults to the global fetch */
+  fetch?: typeof fetch;
+}
+
+type Params = Record<string, unknown>;
+
+/** FlightTicketClient calls the Flight Ticket Service API */
+export class FlightTicketClient {
+  private readonly baseUrl: string;
+  private readonly apiKey?: string;
+  private readonly bearerToken?: string;
+  private readonly fetchImpl: typeof fetch;
+
+  constructor(options: ClientOptions) {
+    this.baseUrl = options.baseUrl.replace(/\/+$/, "");
+    this.apiKey = options.apiKey;
+    this.bearerToken = options.bearerToken;
+    this.fetchImpl = options.fetch ?? globalThis.fetch.bind(globalThis);
+  }
+
+  private async request<T>(method: string, path: string, query: Params, headers: Params, body: unknown, responseType: "json" | "blob" | "none"): Promise<T> {
+    const url = new URL(this.baseUrl + path);
+    for (const [name, value] of Object.entries(query)) {
+      if (Array.isArray(value)) {
+        value.forEach((item) => url.searchParams.append(name, String(item)));
+      } else if (value !== undefined) {
+        url.searchParams.set(name, String(value));
+      }
+    }
+    const requestHeaders: Record<string, string> = { Accept: "application/json" };
+    if (this.apiKey) {
+      requestHeaders["X-API-Key"] = this.apiKey;
+    }
+    if (this.bearerToken) {
+      requestHeaders["Authorization"] = "Bearer " + this.bearerToken;
+    }
+    for (const [name, value] of Object.entries(headers)) {
+      if (value !== undefined) {
+        requestHeaders[name] = String(value);
+      }
+    }
+    const init: RequestInit = { method, headers: requestHeaders };
+    if (body !== undefined) {
+      requestHeaders["Content-Type"] = "application/json";
+      init.body = JSON.stringify(body);
+    }
+
+    const response = await this.fetchImpl(url, init);
+    if (!response.ok) {
+      const text = await response.text();
+      let parsed: unknown = text;
+      try {
+        parsed = JSON.parse(text);
+      } catch {
+        // not JSON
+      }
+      throw new ApiError(response.status, parsed);
+    }
+    if (responseType === "blob") {
+      return (await response.blob()) as T;
+    }
+    if (responseType === "none" || response.status === 204) {
+      return undefined as T;
+    }
+    return (await response.json()) as T;
+  }
+`
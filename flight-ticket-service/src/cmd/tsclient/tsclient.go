@@ -0,0 +1,69 @@
+// Command tsclient generates the typed TypeScript client of the API in
+// clients/typescript from the service's OpenAPI spec, so the web frontend and
+// MCP tooling stay in sync with the endpoints.
+//
+// Usage:
+//
+//	go run ./src/cmd/tsclient [-spec docs/swagger.json] [-out clients/typescript/src] [-exclude PREFIXES]
+//
+// It writes types.ts, an interface or type per definition of the spec, and
+// client.ts, a FlightTicketClient class with a method per operation. Methods
+// are named like the MCP tools of cmd/mcpgen, in camel case: GET
+// /ticket/{confirmationID} is getTicketByConfirmationId. They take the path
+// parameters, then the body, then an object of the query and header
+// parameters. Run `make docs` first, so the spec has the latest endpoints;
+// `mage generate:tsclient` runs both.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"flight-ticket-service/src/internal/openapi"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: tsclient [-spec FILE] [-out DIR] [-exclude PREFIXES]")
+	flag.PrintDefaults()
+}
+
+func main() {
+	specPath := flag.String("spec", "docs/swagger.json", "OpenAPI 2.0 spec generated by swag")
+	out := flag.String("out", "clients/typescript/src", "Directory to write types.ts and client.ts to")
+	exclude := flag.String("exclude", strings.Join(openapi.DefaultExcludes, ","), "Comma-separated path prefixes of operations left out of the client")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() > 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	spec, err := os.ReadFile(*specPath)
+	if err != nil {
+		log.Fatalf("Failed to read spec: %v", err)
+	}
+	var prefixes []string
+	for _, prefix := range strings.Split(*exclude, ",") {
+		if prefix = strings.TrimSpace(prefix); prefix != "" {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+
+	files, count, err := generate(spec, options{Source: filepath.Base(*specPath), Exclude: prefixes})
+	if err != nil {
+		log.Fatalf("Failed to generate client: %v", err)
+	}
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		log.Fatalf("Failed to create %s: %v", *out, err)
+	}
+	for name, source := range files {
+		if err := os.WriteFile(filepath.Join(*out, name), source, 0644); err != nil {
+			log.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	log.Printf("Wrote a client of %d operations to %s", count, *out)
+}
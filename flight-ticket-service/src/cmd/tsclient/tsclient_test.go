@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"flight-ticket-service/src/internal/openapi"
+)
+
+// TestGeneratedClientUpToDate fails when the spec changed since the client was generated
+func TestGeneratedClientUpToDate(t *testing.T) {
+	data, err := os.ReadFile("../../../docs/swagger.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, _, err := generate(data, options{Source: "swagger.json", Exclude: openapi.DefaultExcludes})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, source := range files {
+		generated, err := os.ReadFile("../../../clients/typescript/src/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(source, generated) {
+			t.Errorf("clients/typescript/src/%s is out of date; run mage generate:tsclient", name)
+		}
+	}
+}
+
+const testSpec = `{
+  "swagger": "2.0",
+  "paths": {
+    "/health": {"get": {"summary": "Health check"}},
+    "/admin/review-queue/{confirmationID}/approve": {"post": {
+      "summary": "Approve a booking held for review",
+      "parameters": [
+        {"name": "confirmationID", "in": "path", "required": true, "type": "string"},
+        {"name": "decision", "in": "body", "schema": {"$ref": "#/definitions/models.ReviewDecisionRequest"}},
+        {"name": "X-Lock-Token", "in": "header", "type": "string", "description": "Edit lock token"}
+      ],
+      "responses": {"200": {"schema": {"$ref": "#/definitions/models.FlightTicket"}}}
+    }},
+    "/tickets": {"get": {
+      "parameters": [
+        {"name": "status", "in": "query", "required": true, "type": "string", "enum": ["CONFIRMED", "CANCELLED"]},
+        {"name": "fields", "in": "query", "type": "array", "items": {"type": "string"}}
+      ],
+      "responses": {"200": {"schema": {"type": "array", "items": {"$ref": "#/definitions/models.FlightTicket"}}}}
+    }},
+    "/tickets/{confirmationID}/pdf": {"get": {
+      "produces": ["application/pdf"],
+      "parameters": [{"name": "confirmationID", "in": "path", "required": true, "type": "string"}],
+      "responses": {"200": {"description": "OK"}}
+    }},
+    "/old": {"get": {"deprecated": true}}
+  },
+  "definitions": {
+    "models.ReviewDecisionRequest": {"type": "object", "required": ["reason"], "properties": {"reason": {"type": "string", "description": "Why"}}},
+    "models.FlightTicket": {"type": "object", "properties": {"confirmationID": {"type": "string"}, "passengers": {"type": "integer"}}},
+    "audit.FlightTicket": {"type": "string"}
+  }
+}`
+
+func TestGenerate(t *testing.T) {
+	files, count, err := generate([]byte(testSpec), options{Source: "test.json", Exclude: openapi.DefaultExcludes})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Errorf("Expected 3 operations without health and deprecated ones, got %d", count)
+	}
+	for file, wants := range map[string][]string{
+		"types.ts": {
+			"export interface ModelsFlightTicket {",
+			"  confirmationID?: string;",
+			"export type AuditFlightTicket = string;",
+			"export interface ReviewDecisionRequest {",
+			"  reason: string;",
+		},
+		"client.ts": {
+			`createAdminReviewQueueApprove(confirmationId: string, decision?: types.ReviewDecisionRequest, options: { /** Edit lock token */ lockToken?: string } = {}): Promise<types.ModelsFlightTicket> {`,
+			`"/admin/review-queue/" + encodeURIComponent(String(confirmationId)) + "/approve"`,
+			`{ "X-Lock-Token": options.lockToken }, decision, "json");`,
+			`getTickets(options: { status: "CONFIRMED" | "CANCELLED"; fields?: string[] }): Promise<types.ModelsFlightTicket[]> {`,
+			`{ "status": options.status, "fields": options.fields }`,
+			`getTicketsPdf(confirmationId: string): Promise<Blob> {`,
+		},
+	} {
+		source := string(files[file])
+		for _, want := range wants {
+			if !strings.Contains(source, want) {
+				t.Errorf("Expected %s in %s:\n%s", want, file, source)
+			}
+		}
+	}
+	if strings.Contains(string(files["client.ts"]), "health") {
+		t.Error("Expected /health to be excluded")
+	}
+}
+
+func TestNames(t *testing.T) {
+	for name, want := range map[string]string{"confirmationID": "confirmationId", "X-Lock-Token": "xLockToken", "default": "default_"} {
+		if got := camel(name); got != want {
+			t.Errorf("camel(%s) = %s, expected %s", name, got, want)
+		}
+	}
+	if got := pascal("models.flight_ticket"); got != "ModelsFlightTicket" {
+		t.Errorf("Expected ModelsFlightTicket, got %s", got)
+	}
+	if _, err := pathExpression("/ticket/{confirmationID}", nil); err == nil {
+		t.Error("Expected an error for an undocumented path parameter")
+	}
+}
@@ -0,0 +1,144 @@
+package currency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BaseCurrency is the currency ticket prices are stored in
+const BaseCurrency = "USD"
+
+// ErrUnsupportedCurrency is returned when no rate is available for a currency
+var ErrUnsupportedCurrency = errors.New("unsupported currency")
+
+// RateProvider fetches exchange rates relative to a base currency
+type RateProvider interface {
+	// Name returns the provider identifier
+	Name() string
+	// Rates returns the value of one unit of base in each quoted currency and the time the rates were published
+	Rates(ctx context.Context, base string) (map[string]float64, time.Time, error)
+}
+
+// Conversion describes the result of converting an amount between currencies
+type Conversion struct {
+	Amount   float64
+	Currency string
+	Rate     float64
+	RateAsOf time.Time
+	Provider string
+}
+
+type cachedRates struct {
+	rates     map[string]float64
+	asOf      time.Time
+	expiresAt time.Time
+}
+
+// Converter converts amounts between currencies using a cached RateProvider
+type Converter struct {
+	provider RateProvider
+	ttl      time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedRates
+}
+
+// NewConverter creates a new currency converter with the given cache TTL
+func NewConverter(provider RateProvider, ttl time.Duration) *Converter {
+	return &Converter{
+		provider: provider,
+		ttl:      ttl,
+		cache:    make(map[string]cachedRates),
+	}
+}
+
+// Normalize upper-cases a currency code and validates its ISO 4217 shape
+func Normalize(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 3 {
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedCurrency, code)
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return "", fmt.Errorf("%w: %q", ErrUnsupportedCurrency, code)
+		}
+	}
+	return code, nil
+}
+
+// Rate returns the exchange rate from one currency to another
+func (c *Converter) Rate(ctx context.Context, from, to string) (float64, time.Time, error) {
+	from, err := Normalize(from)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	to, err = Normalize(to)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	if from == to {
+		return 1, time.Now().UTC(), nil
+	}
+
+	rates, asOf, err := c.rates(ctx, from)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	rate, ok := rates[to]
+	if !ok {
+		return 0, time.Time{}, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, to)
+	}
+	return rate, asOf, nil
+}
+
+// Convert converts an amount from one currency to another, rounding to two decimal places
+func (c *Converter) Convert(ctx context.Context, amount float64, from, to string) (*Conversion, error) {
+	rate, asOf, err := c.Rate(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	to, _ = Normalize(to)
+	return &Conversion{
+		Amount:   Round(amount * rate),
+		Currency: to,
+		Rate:     rate,
+		RateAsOf: asOf,
+		Provider: c.provider.Name(),
+	}, nil
+}
+
+func (c *Converter) rates(ctx context.Context, base string) (map[string]float64, time.Time, error) {
+	c.mu.Lock()
+	entry, ok := c.cache[base]
+	c.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.rates, entry.asOf, nil
+	}
+
+	rates, asOf, err := c.provider.Rates(ctx, base)
+	if err != nil {
+		// Serve stale rates rather than failing outright
+		if ok {
+			return entry.rates, entry.asOf, nil
+		}
+		return nil, time.Time{}, fmt.Errorf("failed to fetch exchange rates: %v", err)
+	}
+
+	c.mu.Lock()
+	c.cache[base] = cachedRates{rates: rates, asOf: asOf, expiresAt: time.Now().Add(c.ttl)}
+	c.mu.Unlock()
+
+	return rates, asOf, nil
+}
+
+// Round rounds an amount to two decimal places
+func Round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,116 @@
+package currency
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type countingProvider struct {
+	calls int
+	fail  bool
+}
+
+func (p *countingProvider) Name() string { return "counting" }
+
+func (p *countingProvider) Rates(ctx context.Context, base string) (map[string]float64, time.Time, error) {
+	p.calls++
+	if p.fail {
+		return nil, time.Time{}, errors.New("provider down")
+	}
+	return map[string]float64{"EUR": 0.5}, time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), nil
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		code     string
+		expected string
+		valid    bool
+	}{
+		{"EUR", "EUR", true},
+		{"eur", "EUR", true},
+		{" gbp ", "GBP", true},
+		{"EURO", "", false},
+		{"E1R", "", false},
+		{"", "", false},
+	}
+
+	for _, test := range tests {
+		result, err := Normalize(test.code)
+		if (err == nil) != test.valid {
+			t.Errorf("Normalize(%q) error = %v, expected valid %v", test.code, err, test.valid)
+			continue
+		}
+		if result != test.expected {
+			t.Errorf("Normalize(%q) = %q, expected %q", test.code, result, test.expected)
+		}
+	}
+}
+
+func TestConvertUsesCachedRates(t *testing.T) {
+	provider := &countingProvider{}
+	converter := NewConverter(provider, time.Minute)
+
+	conversion, err := converter.Convert(context.Background(), 398, "USD", "eur")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if conversion.Amount != 199 || conversion.Currency != "EUR" || conversion.Rate != 0.5 {
+		t.Errorf("Unexpected conversion: %+v", conversion)
+	}
+
+	converter.Convert(context.Background(), 10, "USD", "EUR")
+	if provider.calls != 1 {
+		t.Errorf("Expected 1 provider call, got %d", provider.calls)
+	}
+}
+
+func TestConvertSameCurrency(t *testing.T) {
+	provider := &countingProvider{}
+	converter := NewConverter(provider, time.Minute)
+
+	conversion, err := converter.Convert(context.Background(), 12.345, "USD", "USD")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if conversion.Amount != 12.35 || conversion.Rate != 1 {
+		t.Errorf("Unexpected conversion: %+v", conversion)
+	}
+	if provider.calls != 0 {
+		t.Errorf("Expected no provider calls, got %d", provider.calls)
+	}
+}
+
+func TestConvertUnsupportedCurrency(t *testing.T) {
+	converter := NewConverter(&countingProvider{}, time.Minute)
+
+	_, err := converter.Convert(context.Background(), 10, "USD", "XYZ")
+	if !errors.Is(err, ErrUnsupportedCurrency) {
+		t.Errorf("Expected ErrUnsupportedCurrency, got %v", err)
+	}
+}
+
+func TestConvertServesStaleRatesOnProviderFailure(t *testing.T) {
+	provider := &countingProvider{}
+	converter := NewConverter(provider, -time.Second) // entries expire immediately
+
+	if _, err := converter.Convert(context.Background(), 10, "USD", "EUR"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	provider.fail = true
+	if _, err := converter.Convert(context.Background(), 10, "USD", "EUR"); err != nil {
+		t.Errorf("Expected stale rates to be served, got %v", err)
+	}
+}
+
+func TestStaticRateProviderCrossRates(t *testing.T) {
+	rates, _, err := NewStaticRateProvider().Rates(context.Background(), "EUR")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if usd := rates["USD"]; usd < 1.08 || usd > 1.09 {
+		t.Errorf("Expected EUR->USD rate of about 1.087, got %f", usd)
+	}
+}
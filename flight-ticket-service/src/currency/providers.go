@@ -0,0 +1,123 @@
+package currency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// NewRateProvider creates an exchange rate provider by name
+func NewRateProvider(name string) (RateProvider, error) {
+	switch name {
+	case "", "frankfurter":
+		return NewFrankfurterProvider(), nil
+	case "static":
+		return NewStaticRateProvider(), nil
+	default:
+		return nil, fmt.Errorf("unknown exchange rate provider: %s", name)
+	}
+}
+
+// FrankfurterProvider retrieves European Central Bank reference rates from the Frankfurter API (no API key required)
+type FrankfurterProvider struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewFrankfurterProvider creates a new Frankfurter exchange rate provider
+func NewFrankfurterProvider() *FrankfurterProvider {
+	return &FrankfurterProvider{
+		baseURL:    "https://api.frankfurter.app/latest",
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name returns the provider identifier
+func (p *FrankfurterProvider) Name() string {
+	return "frankfurter"
+}
+
+// Rates fetches the latest rates for the given base currency
+func (p *FrankfurterProvider) Rates(ctx context.Context, base string) (map[string]float64, time.Time, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"?"+url.Values{"from": {base}}.Encode(), nil)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to build rates request: %v", err)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to fetch rates: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, time.Time{}, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, base)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, time.Time{}, fmt.Errorf("rates provider returned HTTP %d", resp.StatusCode)
+	}
+
+	var data struct {
+		Base  string             `json:"base"`
+		Date  string             `json:"date"`
+		Rates map[string]float64 `json:"rates"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to parse rates response: %v", err)
+	}
+
+	asOf, err := time.Parse("2006-01-02", data.Date)
+	if err != nil {
+		asOf = time.Now().UTC()
+	}
+	return data.Rates, asOf, nil
+}
+
+// StaticRateProvider serves a fixed table of USD rates, useful for offline demos and tests
+type StaticRateProvider struct {
+	usdRates map[string]float64
+	asOf     time.Time
+}
+
+// NewStaticRateProvider creates a provider with approximate USD reference rates
+func NewStaticRateProvider() *StaticRateProvider {
+	return &StaticRateProvider{
+		usdRates: map[string]float64{
+			"USD": 1,
+			"EUR": 0.92,
+			"GBP": 0.79,
+			"JPY": 151.5,
+			"CAD": 1.36,
+			"AUD": 1.52,
+			"CHF": 0.90,
+			"INR": 83.4,
+			"MXN": 17.1,
+			"SGD": 1.35,
+		},
+		asOf: time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+// Name returns the provider identifier
+func (p *StaticRateProvider) Name() string {
+	return "static"
+}
+
+// Rates derives cross rates for the given base from the USD table
+func (p *StaticRateProvider) Rates(ctx context.Context, base string) (map[string]float64, time.Time, error) {
+	baseRate, ok := p.usdRates[base]
+	if !ok {
+		return nil, time.Time{}, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, base)
+	}
+
+	rates := make(map[string]float64, len(p.usdRates))
+	for code, rate := range p.usdRates {
+		if code != base {
+			rates[code] = rate / baseRate
+		}
+	}
+	return rates, p.asOf, nil
+}
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS flight_tickets (
+    confirmation_id VARCHAR(16) PRIMARY KEY,
+    origin          CHAR(3)      NOT NULL,
+    destination     CHAR(3)      NOT NULL,
+    departure_date  TIMESTAMPTZ  NOT NULL,
+    departure_time  TIMESTAMPTZ  NOT NULL,
+    flight_number   VARCHAR(16)  NOT NULL,
+    passengers      INTEGER      NOT NULL CHECK (passengers > 0),
+    status          VARCHAR(16)  NOT NULL,
+    price           JSONB,
+    created_at      TIMESTAMPTZ  NOT NULL,
+    updated_at      TIMESTAMPTZ  NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS flight_tickets_created_at_idx ON flight_tickets (created_at DESC);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS flight_tickets_labels_idx;
+
+ALTER TABLE flight_tickets DROP COLUMN IF EXISTS labels;
@@ -0,0 +1,3 @@
+ALTER TABLE flight_tickets ADD COLUMN IF NOT EXISTS labels JSONB;
+
+CREATE INDEX IF NOT EXISTS flight_tickets_labels_idx ON flight_tickets USING GIN (labels jsonb_path_ops);
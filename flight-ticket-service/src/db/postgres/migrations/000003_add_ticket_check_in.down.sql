@@ -0,0 +1 @@
+ALTER TABLE flight_tickets DROP COLUMN IF EXISTS check_in;
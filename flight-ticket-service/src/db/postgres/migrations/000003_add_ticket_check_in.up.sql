@@ -0,0 +1 @@
+ALTER TABLE flight_tickets ADD COLUMN IF NOT EXISTS check_in JSONB;
@@ -0,0 +1,44 @@
+-- name: CreateTicket :exec
+INSERT INTO flight_tickets (
+    confirmation_id, origin, destination, departure_date, departure_time,
+    flight_number, passengers, status, price, created_at, updated_at, labels,
+    check_in
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+);
+
+-- name: GetTicket :one
+SELECT * FROM flight_tickets
+WHERE confirmation_id = $1;
+
+-- name: ListTickets :many
+SELECT * FROM flight_tickets
+ORDER BY created_at DESC
+LIMIT sqlc.narg('limit');
+
+-- name: SearchTickets :many
+SELECT * FROM flight_tickets
+WHERE (sqlc.narg('labels')::jsonb IS NULL OR labels @> sqlc.narg('labels')::jsonb)
+  AND (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status')::text)
+  AND (sqlc.narg('origin')::text IS NULL OR origin = sqlc.narg('origin')::text)
+  AND (sqlc.narg('destination')::text IS NULL OR destination = sqlc.narg('destination')::text)
+  AND (sqlc.narg('flight_number')::text IS NULL OR flight_number = sqlc.narg('flight_number')::text)
+  AND (sqlc.narg('departure_from')::timestamptz IS NULL OR departure_date >= sqlc.narg('departure_from')::timestamptz)
+  AND (sqlc.narg('departure_until')::timestamptz IS NULL OR departure_date < sqlc.narg('departure_until')::timestamptz)
+ORDER BY created_at DESC
+LIMIT sqlc.narg('limit');
+
+-- name: UpdateTicket :execrows
+UPDATE flight_tickets SET
+    origin         = COALESCE(sqlc.narg('origin'), origin),
+    destination    = COALESCE(sqlc.narg('destination'), destination),
+    departure_date = COALESCE(sqlc.narg('departure_date'), departure_date),
+    departure_time = COALESCE(sqlc.narg('departure_time'), departure_time),
+    flight_number  = COALESCE(sqlc.narg('flight_number'), flight_number),
+    passengers     = COALESCE(sqlc.narg('passengers'), passengers),
+    status         = COALESCE(sqlc.narg('status'), status),
+    price          = COALESCE(sqlc.narg('price'), price),
+    labels         = COALESCE(sqlc.narg('labels'), labels),
+    check_in       = COALESCE(sqlc.narg('check_in'), check_in),
+    updated_at     = sqlc.arg('updated_at')
+WHERE confirmation_id = sqlc.arg('confirmation_id');
@@ -0,0 +1,176 @@
+// Package debuglog logs single requests verbosely, so that a problematic call
+// can be diagnosed in production without raising the log volume of all others,
+// and holds the log level of the instance (LOG_LEVEL).
+//
+// An admin sends "X-Debug: true" with a request. The request's headers (with
+// credentials redacted), the steps the handlers log with Printf, its storage
+// operations and its outcome, including the body of error responses, are then
+// logged under its request ID. Its trace is marked as sampled, and the trace ID
+// is returned in X-Debug-Trace.
+package debuglog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"flight-ticket-service/src/auth"
+	"flight-ticket-service/src/metrics"
+	"flight-ticket-service/src/models"
+
+	"github.com/go-chi/chi/middleware"
+)
+
+// Header asks for a request to be logged verbosely
+const Header = "X-Debug"
+
+// TraceHeader returns the trace ID of a debug request
+const TraceHeader = "X-Debug-Trace"
+
+// maxLoggedBody bounds how much of an error response body is logged
+const maxLoggedBody = 1024
+
+// redactedHeaders carry credentials and are never logged
+var redactedHeaders = map[string]bool{
+	"Authorization":              true,
+	"Cookie":                     true,
+	"X-Api-Key":                  true,
+	"X-Lock-Token":               true,
+	"X-Serverless-Authorization": true,
+}
+
+type contextKey struct{}
+
+// Enabled reports whether a request is being debugged, or every request is
+// at the debug log level
+func Enabled(ctx context.Context) bool {
+	_, ok := ctx.Value(contextKey{}).(string)
+	return ok || Level() == LevelDebug
+}
+
+// Printf logs a line for a debug request, prefixed with its request ID; it
+// logs nothing for other requests unless the log level is debug
+func Printf(ctx context.Context, format string, args ...interface{}) {
+	requestID, ok := ctx.Value(contextKey{}).(string)
+	if !ok {
+		if Level() != LevelDebug {
+			return
+		}
+		if requestID = middleware.GetReqID(ctx); requestID == "" {
+			requestID = "-"
+		}
+	}
+	log.Printf("[debug %s] %s", requestID, fmt.Sprintf(format, args...))
+}
+
+// Middleware debugs requests that send X-Debug: true. Only admins may debug
+// requests; others are refused. It must run after authentication and before
+// the middleware that records traces, so they see the trace as sampled.
+func Middleware(next http.Handler) http.Handler {
+	debug := auth.RequireRole(auth.RoleAdmin)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveDebug(next, w, r)
+	}))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value := r.Header.Get(Header)
+		if value == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid " + Header, Message: Header + " must be true or false"})
+			return
+		}
+		if !enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+		debug.ServeHTTP(w, r)
+	})
+}
+
+// serveDebug serves a request with verbose logging and a sampled trace
+func serveDebug(next http.Handler, w http.ResponseWriter, r *http.Request) {
+	requestID := middleware.GetReqID(r.Context())
+	if requestID == "" {
+		requestID = "-"
+	}
+	ctx := context.WithValue(r.Context(), contextKey{}, requestID)
+	r = r.WithContext(ctx)
+
+	span := metrics.ForceSampling(r)
+	w.Header().Set(TraceHeader, span.TraceID)
+
+	caller := "anonymous"
+	if principal, ok := auth.FromContext(ctx); ok {
+		caller = principal.Name
+	}
+	Printf(ctx, "%s %s from %s (%s), trace %s", r.Method, r.URL.RequestURI(), caller, r.RemoteAddr, span.TraceID)
+	Printf(ctx, "Headers: %s", formatHeaders(r.Header))
+
+	start := time.Now()
+	dw := &debugWriter{ResponseWriter: w, status: http.StatusOK}
+	defer func() {
+		Printf(ctx, "Answered %d with %d bytes of %q in %s", dw.status, dw.written, dw.Header().Get("Content-Type"), time.Since(start))
+		if dw.status >= http.StatusBadRequest && dw.body.Len() > 0 {
+			Printf(ctx, "Response body: %s", strings.TrimSpace(dw.body.String()))
+		}
+	}()
+	next.ServeHTTP(dw, r)
+}
+
+// formatHeaders lists request headers in name order, with credentials redacted
+func formatHeaders(header http.Header) string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		value := strings.Join(header[name], ", ")
+		if redactedHeaders[http.CanonicalHeaderKey(name)] {
+			value = "[redacted]"
+		}
+		parts = append(parts, fmt.Sprintf("%s=%q", name, value))
+	}
+	return strings.Join(parts, " ")
+}
+
+// debugWriter captures the status, size and the start of error bodies
+type debugWriter struct {
+	http.ResponseWriter
+	status  int
+	written int
+	body    bytes.Buffer
+}
+
+func (w *debugWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *debugWriter) Write(p []byte) (int, error) {
+	if w.status >= http.StatusBadRequest && w.body.Len() < maxLoggedBody {
+		w.body.Write(p[:min(len(p), maxLoggedBody-w.body.Len())])
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.written += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *debugWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
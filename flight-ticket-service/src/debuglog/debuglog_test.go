@@ -0,0 +1,123 @@
+package debuglog
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"flight-ticket-service/src/auth"
+	"flight-ticket-service/src/metrics"
+
+	"github.com/go-chi/chi/middleware"
+)
+
+func TestMiddlewareDebugsAdminRequests(t *testing.T) {
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	var sampled bool
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, sampled = metrics.SpanFromRequest(r)
+		Printf(r.Context(), "Looking up ticket")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"Ticket not found"}`))
+	}))
+	send := func(role auth.Role, debug string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/ticket/ABC123", nil)
+		req.Header.Set("X-API-Key", "s3cret")
+		req.Header.Set(metrics.CloudTraceContextHeader, "105445aa7843bc8bf206b12000100000/1;o=0")
+		if debug != "" {
+			req.Header.Set(Header, debug)
+		}
+		if role != "" {
+			req = req.WithContext(auth.WithPrincipal(req.Context(), auth.Principal{Name: "ops", Role: role}))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send(auth.RoleAdmin, "true")
+	if rec.Code != http.StatusNotFound || rec.Header().Get(TraceHeader) != "105445aa7843bc8bf206b12000100000" {
+		t.Fatalf("Expected the handler's response with the trace ID, got %d %q", rec.Code, rec.Header().Get(TraceHeader))
+	}
+	if !sampled {
+		t.Error("Expected the trace of a debug request to be sampled")
+	}
+	output := logged.String()
+	for _, want := range []string{"GET /ticket/ABC123 from ops", "Looking up ticket", "Answered 404", `"error":"Ticket not found"`, `X-Api-Key="[redacted]"`} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Debug log lacks %q:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "s3cret") {
+		t.Error("Debug log contains the API key")
+	}
+
+	logged.Reset()
+	for _, debug := range []string{"", "false"} {
+		if rec := send(auth.RoleAdmin, debug); rec.Header().Get(TraceHeader) != "" || sampled {
+			t.Errorf("X-Debug %q: expected a normal request", debug)
+		}
+	}
+	if logged.Len() != 0 {
+		t.Errorf("Expected nothing logged for normal requests, got:\n%s", logged.String())
+	}
+
+	if rec := send(auth.RoleAgent, "true"); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 for an agent, got %d", rec.Code)
+	}
+	if rec := send("", "true"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without a key, got %d", rec.Code)
+	}
+	if rec := send(auth.RoleAdmin, "verbose"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid X-Debug, got %d", rec.Code)
+	}
+}
+
+func TestDebugLevelLogsEveryRequest(t *testing.T) {
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	t.Cleanup(func() {
+		log.SetOutput(os.Stderr)
+		SetLevel(LevelInfo)
+	})
+
+	ctx := httptest.NewRequest(http.MethodGet, "/", nil).Context()
+	Printf(ctx, "Looking up ticket")
+	if logged.Len() != 0 {
+		t.Fatalf("Expected nothing logged at the info level, got %s", logged.String())
+	}
+
+	if err := SetLevel("DEBUG"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	Printf(ctx, "Looking up ticket")
+	if !strings.Contains(logged.String(), "[debug -] Looking up ticket") {
+		t.Errorf("Expected the line logged at the debug level, got %q", logged.String())
+	}
+	if err := SetLevel("verbose"); err == nil || Level() != LevelDebug {
+		t.Error("Expected an invalid level to be rejected and the level kept")
+	}
+
+	logged.Reset()
+	defaultLogger := middleware.DefaultLogger
+	middleware.DefaultLogger = middleware.RequestLogger(&middleware.DefaultLogFormatter{Logger: log.New(&logged, "", 0), NoColor: true})
+	t.Cleanup(func() { middleware.DefaultLogger = defaultLogger })
+	handler := AccessLog(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	SetLevel(LevelWarn)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+	if logged.Len() != 0 {
+		t.Errorf("Expected no access log at the warn level, got %s", logged.String())
+	}
+	SetLevel(LevelInfo)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+	if !strings.Contains(logged.String(), "/health") {
+		t.Errorf("Expected the request logged at the info level, got %q", logged.String())
+	}
+}
@@ -0,0 +1,68 @@
+package debuglog
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/go-chi/chi/middleware"
+)
+
+// Log levels
+const (
+	LevelDebug = "debug" // log requests and the debug lines of every request
+	LevelInfo  = "info"  // log requests (the default)
+	LevelWarn  = "warn"  // log warnings and errors only, not every request
+)
+
+var level atomic.Value
+
+func init() {
+	level.Store(LevelInfo)
+}
+
+// ParseLevel parses a LOG_LEVEL value; empty means info
+func ParseLevel(value string) (string, error) {
+	switch value = strings.ToLower(strings.TrimSpace(value)); value {
+	case "":
+		return LevelInfo, nil
+	case LevelDebug, LevelInfo, LevelWarn:
+		return value, nil
+	default:
+		return "", fmt.Errorf("invalid log level %q: must be %s, %s or %s", value, LevelDebug, LevelInfo, LevelWarn)
+	}
+}
+
+// LevelFromEnv reads LOG_LEVEL
+func LevelFromEnv() (string, error) {
+	return ParseLevel(os.Getenv("LOG_LEVEL"))
+}
+
+// SetLevel changes the log level of the instance
+func SetLevel(value string) error {
+	parsed, err := ParseLevel(value)
+	if err != nil {
+		return err
+	}
+	level.Store(parsed)
+	return nil
+}
+
+// Level returns the log level of the instance
+func Level() string {
+	return level.Load().(string)
+}
+
+// AccessLog logs each request, except at the warn level
+func AccessLog(next http.Handler) http.Handler {
+	logged := middleware.Logger(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if Level() == LevelWarn {
+			next.ServeHTTP(w, r)
+			return
+		}
+		logged.ServeHTTP(w, r)
+	})
+}
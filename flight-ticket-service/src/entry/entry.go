@@ -0,0 +1,240 @@
+// Package entry checks the travel documents of saved travelers against the
+// entry requirements of the destination country, for international flights.
+//
+// Requirements come from a country rules table: how many months a passport
+// must stay valid after the end of the trip, and whether holders of foreign
+// passports need a visa. ENTRY_RULES adds to or replaces the built-in rules
+// with comma-separated CC:months or CC:months:visa entries, e.g.
+// "GB:6,IN:6:visa". Countries without a rule need a passport valid until the
+// end of the trip. Missing or expiring passports refuse the booking; visa
+// requirements are flags for the agent to check, since exemptions depend on
+// more than the passport. Tickets and boarding passes of international
+// flights carry the requirements as travel advisories, with the arrival form
+// of the destination.
+package entry
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/services"
+)
+
+// DefaultRules are the built-in entry requirements, in ENTRY_RULES format
+const DefaultRules = "AE:6,AU:0:visa,BR:0,CA:0,DE:3,ES:3,FR:3,GB:0,HK:1,IN:6:visa,IT:3,JP:0,KR:0,MX:6,NL:3,SG:6,US:0:visa"
+
+// arrivalForms names the forms that travelers fill in before arriving in a
+// country, by country code
+var arrivalForms = map[string]string{
+	"AU": "Incoming Passenger Card",
+	"CA": "Advance Declaration (ArriveCAN)",
+	"IN": "e-Arrival Card",
+	"JP": "Visit Japan Web declaration",
+	"KR": "e-Arrival Card",
+	"MX": "Forma Migratoria Multiple (FMM)",
+	"SG": "SG Arrival Card",
+	"US": "ESTA, or a visa, and CBP declaration",
+}
+
+// maxValidityMonths caps the passport validity a rule can require
+const maxValidityMonths = 24
+
+// Rule holds the entry requirements of a country
+type Rule struct {
+	Country string
+	// PassportMonths is how long passports must stay valid after the trip
+	PassportMonths int
+	// Visa is set when holders of foreign passports need a visa
+	Visa bool
+}
+
+// Table holds the entry rules by country
+type Table struct {
+	rules map[string]Rule
+}
+
+// NewTable creates a table of the default rules with the given spec applied on top
+func NewTable(spec string) (*Table, error) {
+	rules, err := ParseRules(DefaultRules)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := ParseRules(spec)
+	if err != nil {
+		return nil, err
+	}
+	for country, rule := range overrides {
+		rules[country] = rule
+	}
+	return &Table{rules: rules}, nil
+}
+
+// TableFromEnv creates a table of the default rules with ENTRY_RULES applied on top
+func TableFromEnv() (*Table, error) {
+	table, err := NewTable(os.Getenv("ENTRY_RULES"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ENTRY_RULES: %v", err)
+	}
+	return table, nil
+}
+
+// ParseRules parses comma-separated CC:months or CC:months:visa entries
+func ParseRules(spec string) (map[string]Rule, error) {
+	rules := make(map[string]Rule)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, ":")
+		country := strings.ToUpper(strings.TrimSpace(parts[0]))
+		if len(parts) < 2 || len(parts) > 3 || len(country) != 2 || strings.Trim(country, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+			return nil, fmt.Errorf("entry %q must be CC:months or CC:months:visa", entry)
+		}
+		months, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || months < 0 || months > maxValidityMonths {
+			return nil, fmt.Errorf("entry %q must require 0 to %d months of passport validity", entry, maxValidityMonths)
+		}
+		if len(parts) == 3 && strings.TrimSpace(parts[2]) != "visa" {
+			return nil, fmt.Errorf("entry %q must end with :visa or nothing", entry)
+		}
+		rules[country] = Rule{Country: country, PassportMonths: months, Visa: len(parts) == 3}
+	}
+	return rules, nil
+}
+
+// Rule returns the entry rule of a country; countries without one only need a valid passport
+func (t *Table) Rule(country string) Rule {
+	if rule, ok := t.rules[country]; ok {
+		return rule
+	}
+	return Rule{Country: country}
+}
+
+// Check returns the document issues of the travelers of a ticket. Tickets of
+// domestic flights, or between airports of unknown country, have none.
+// tripEnd is the last departure of the ticket's itinerary.
+func (t *Table) Check(ticket *models.FlightTicket, travelers []*models.Traveler, tripEnd time.Time) []models.DocumentIssue {
+	if !services.International(ticket.Origin, ticket.Destination) {
+		return nil
+	}
+	country, _ := services.AirportCountry(ticket.Destination)
+	rule := t.Rule(country)
+	validUntil := tripEnd.UTC().AddDate(0, rule.PassportMonths, 0).Format("2006-01-02")
+
+	var issues []models.DocumentIssue
+	for _, traveler := range travelers {
+		passport := latestPassport(traveler)
+		if passport == nil {
+			issues = append(issues, models.DocumentIssue{
+				TravelerID: traveler.ID,
+				Code:       models.DocumentIssuePassportMissing,
+				Message:    fmt.Sprintf("traveler %s has no passport for a flight to %s", traveler.ID, country),
+				Blocking:   true,
+			})
+			continue
+		}
+		// Dates are YYYY-MM-DD, so they compare as strings
+		if passport.ExpiresOn < validUntil {
+			issues = append(issues, models.DocumentIssue{
+				TravelerID: traveler.ID,
+				Code:       models.DocumentIssuePassportExpiring,
+				Message:    fmt.Sprintf("the passport of traveler %s expires on %s; %s requires it valid until %s", traveler.ID, passport.ExpiresOn, country, validUntil),
+				Blocking:   true,
+			})
+		}
+		if rule.Visa && passport.IssuingCountry != country {
+			issues = append(issues, models.DocumentIssue{
+				TravelerID: traveler.ID,
+				Code:       models.DocumentIssueVisaRequired,
+				Message:    fmt.Sprintf("holders of %s passports may need a visa for %s; check before travel", passport.IssuingCountry, country),
+			})
+		}
+	}
+	return issues
+}
+
+// Advisories returns what travelers need to know before flying to another
+// country: the passport validity and visa requirements of the destination, and
+// the arrival form to fill in. Domestic flights, and flights from or to
+// airports of unknown country, have none.
+func (t *Table) Advisories(origin, destination string) []models.TravelAdvisory {
+	if !services.International(origin, destination) {
+		return nil
+	}
+	country, _ := services.AirportCountry(destination)
+	rule := t.Rule(country)
+
+	validity := "until the end of the trip"
+	if rule.PassportMonths > 0 {
+		validity = fmt.Sprintf("for %d months after the end of the trip", rule.PassportMonths)
+	}
+	advisories := []models.TravelAdvisory{{
+		Type:    models.AdvisoryDocuments,
+		Country: country,
+		Message: fmt.Sprintf("A passport valid %s is required to enter %s", validity, country),
+	}}
+	if rule.Visa {
+		advisories = append(advisories, models.TravelAdvisory{
+			Type:    models.AdvisoryVisa,
+			Country: country,
+			Message: fmt.Sprintf("Holders of passports from other countries may need a visa to enter %s", country),
+		})
+	}
+	if form, ok := arrivalForms[country]; ok {
+		advisories = append(advisories, models.TravelAdvisory{
+			Type:    models.AdvisoryArrivalForm,
+			Country: country,
+			Message: fmt.Sprintf("Complete the %s before arriving in %s", form, country),
+		})
+	}
+	return advisories
+}
+
+// Blocking returns the messages of the issues that refuse a booking or check-in
+func Blocking(issues []models.DocumentIssue) []string {
+	var messages []string
+	for _, issue := range issues {
+		if issue.Blocking {
+			messages = append(messages, issue.Message)
+		}
+	}
+	return messages
+}
+
+// TripEnd returns the last departure of the ticket's itinerary: the latest
+// active ticket with its itinerary label, or the ticket's own departure
+func TripEnd(ctx context.Context, repository services.TicketRepository, ticket *models.FlightTicket) (time.Time, error) {
+	end := ticket.DepartureTime
+	itinerary := ticket.Labels[models.ItineraryLabel]
+	if itinerary == "" {
+		return end, nil
+	}
+	others, err := services.SearchTickets(ctx, repository, models.TicketQuery{Labels: map[string]string{models.ItineraryLabel: itinerary}})
+	if err != nil {
+		return end, fmt.Errorf("failed to read itinerary %s: %v", itinerary, err)
+	}
+	for _, other := range others {
+		if other.Status != "CANCELLED" && other.DepartureTime.After(end) {
+			end = other.DepartureTime
+		}
+	}
+	return end, nil
+}
+
+// latestPassport returns the traveler's passport that expires last, or nil
+func latestPassport(traveler *models.Traveler) *models.TravelDocument {
+	var latest *models.TravelDocument
+	for i := range traveler.Documents {
+		document := &traveler.Documents[i]
+		if document.Type == models.DocumentPassport && (latest == nil || document.ExpiresOn > latest.ExpiresOn) {
+			latest = document
+		}
+	}
+	return latest
+}
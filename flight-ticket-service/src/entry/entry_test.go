@@ -0,0 +1,96 @@
+package entry
+
+import (
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules(" gb:6, IN:6:visa ,,")
+	if err != nil {
+		t.Fatalf("ParseRules failed: %v", err)
+	}
+	if rules["GB"] != (Rule{Country: "GB", PassportMonths: 6}) || !rules["IN"].Visa {
+		t.Errorf("Unexpected rules %+v", rules)
+	}
+	for _, spec := range []string{"GB", "GBR:6", "G1:6", "GB:-1", "GB:36", "GB:6:eta", "GB:6:visa:x"} {
+		if _, err := ParseRules(spec); err == nil {
+			t.Errorf("Expected an error for %q", spec)
+		}
+	}
+
+	table, err := NewTable("FR:0,NZ:3:visa")
+	if err != nil {
+		t.Fatalf("NewTable failed: %v", err)
+	}
+	if table.Rule("FR").PassportMonths != 0 || !table.Rule("NZ").Visa || table.Rule("IN").PassportMonths != 6 {
+		t.Error("Expected the spec applied over the default rules")
+	}
+	if rule := table.Rule("ZZ"); rule.PassportMonths != 0 || rule.Visa {
+		t.Errorf("Expected no requirements for countries without a rule, got %+v", rule)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	table, _ := NewTable("")
+	departure := time.Date(2025, 8, 1, 10, 0, 0, 0, time.UTC)
+	passport := func(country, expires string) []models.TravelDocument {
+		return []models.TravelDocument{{Type: models.DocumentPassport, Number: "X1", IssuingCountry: country, ExpiresOn: expires}}
+	}
+	travelers := []*models.Traveler{
+		{ID: "aaaaaaaaaaaa", Documents: passport("US", "2030-01-01")},
+		{ID: "bbbbbbbbbbbb", Documents: []models.TravelDocument{{Type: models.DocumentNationalID, Number: "1", IssuingCountry: "US", ExpiresOn: "2030-01-01"}}},
+		{ID: "cccccccccccc", Documents: append(passport("US", "2025-12-31"), passport("US", "2026-02-01")...)},
+		{ID: "dddddddddddd", Documents: passport("IN", "2025-09-01")},
+	}
+
+	issues := table.Check(&models.FlightTicket{Origin: "JFK", Destination: "DEL", DepartureTime: departure}, travelers, departure)
+	codes := make(map[string]string)
+	for _, issue := range issues {
+		codes[issue.TravelerID] += issue.Code + " "
+	}
+	want := map[string]string{
+		"aaaaaaaaaaaa": "VISA_REQUIRED ",
+		"bbbbbbbbbbbb": "PASSPORT_MISSING ",
+		"cccccccccccc": "VISA_REQUIRED ",
+		"dddddddddddd": "PASSPORT_EXPIRING ",
+	}
+	for id, code := range want {
+		if codes[id] != code {
+			t.Errorf("Traveler %s: got %q, want %q", id, codes[id], code)
+		}
+	}
+	if blocking := Blocking(issues); len(blocking) != 2 {
+		t.Errorf("Expected two blocking issues, got %v", blocking)
+	}
+
+	if issues := table.Check(&models.FlightTicket{Origin: "JFK", Destination: "LAX"}, travelers, departure); issues != nil {
+		t.Errorf("Expected no checks on domestic flights, got %+v", issues)
+	}
+	if issues := table.Check(&models.FlightTicket{Origin: "JFK", Destination: "XYZ"}, travelers, departure); issues != nil {
+		t.Errorf("Expected no checks for airports of unknown country, got %+v", issues)
+	}
+}
+
+func TestAdvisories(t *testing.T) {
+	table, _ := NewTable("")
+	advisories := table.Advisories("JFK", "DEL")
+	types := ""
+	for _, advisory := range advisories {
+		if advisory.Country != "IN" {
+			t.Errorf("Expected advisories for IN, got %+v", advisory)
+		}
+		types += advisory.Type + " "
+	}
+	if types != "DOCUMENTS VISA ARRIVAL_FORM " {
+		t.Errorf("Unexpected advisories %+v", advisories)
+	}
+	if advisories := table.Advisories("LHR", "CDG"); len(advisories) != 1 || advisories[0].Message != "A passport valid for 3 months after the end of the trip is required to enter FR" {
+		t.Errorf("Expected only the document requirements of FR, got %+v", advisories)
+	}
+	if advisories := table.Advisories("JFK", "LAX"); advisories != nil {
+		t.Errorf("Expected no advisories on domestic flights, got %+v", advisories)
+	}
+}
@@ -0,0 +1,233 @@
+// Package errorreport recovers from handler panics, reports them with their
+// stack trace and request context to Google Cloud Error Reporting (or the log)
+// and answers the request with an application/problem+json 500 response.
+//
+// ERROR_REPORTING selects the reporter, "cloud" or "log". By default
+// panics go to Cloud Error Reporting when running on Cloud Run with
+// GOOGLE_CLOUD_PROJECT set, and to the log otherwise. The service account
+// needs roles/errorreporting.writer.
+package errorreport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+	clouderrorreporting "google.golang.org/api/clouderrorreporting/v1beta1"
+	"google.golang.org/api/option"
+)
+
+// Reporter modes
+const (
+	ModeCloud = "cloud"
+	ModeLog   = "log"
+)
+
+// reportTimeout bounds a single report so a slow API never piles up goroutines
+const reportTimeout = 10 * time.Second
+
+// Event is a recovered panic with the request that caused it
+type Event struct {
+	Time      time.Time
+	Message   string // "panic: <value>" followed by the goroutine stack
+	Method    string
+	URL       string
+	UserAgent string
+	RemoteIP  string
+	RequestID string
+}
+
+// Reporter delivers panic events
+type Reporter interface {
+	Report(ctx context.Context, event Event) error
+}
+
+// LogReporter writes events to the standard logger
+type LogReporter struct{}
+
+// Report logs the event with its stack trace
+func (LogReporter) Report(ctx context.Context, event Event) error {
+	log.Printf("Panic serving %s %s (request %s): %s", event.Method, event.URL, event.RequestID, event.Message)
+	return nil
+}
+
+// CloudReporter sends events to the Cloud Error Reporting API
+type CloudReporter struct {
+	service *clouderrorreporting.Service
+	project string
+	context *clouderrorreporting.ServiceContext
+}
+
+// NewCloudReporter creates a reporter grouping errors under the given service name and version
+func NewCloudReporter(ctx context.Context, projectID, credentialsPath, serviceName, serviceVersion string) (*CloudReporter, error) {
+	if projectID == "" {
+		return nil, fmt.Errorf("GOOGLE_CLOUD_PROJECT is required for Cloud Error Reporting")
+	}
+
+	var opts []option.ClientOption
+	if credentialsPath != "" {
+		// Use service account key file
+		opts = append(opts, option.WithCredentialsFile(credentialsPath))
+	}
+
+	service, err := clouderrorreporting.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Error Reporting client: %v", err)
+	}
+
+	return &CloudReporter{
+		service: service,
+		project: "projects/" + projectID,
+		context: &clouderrorreporting.ServiceContext{Service: serviceName, Version: serviceVersion},
+	}, nil
+}
+
+// Report sends the event to Error Reporting
+func (r *CloudReporter) Report(ctx context.Context, event Event) error {
+	reported := &clouderrorreporting.ReportedErrorEvent{
+		EventTime:      event.Time.UTC().Format(time.RFC3339Nano),
+		Message:        event.Message,
+		ServiceContext: r.context,
+		Context: &clouderrorreporting.ErrorContext{
+			HttpRequest: &clouderrorreporting.HttpRequestContext{
+				Method:             event.Method,
+				Url:                event.URL,
+				UserAgent:          event.UserAgent,
+				RemoteIp:           event.RemoteIP,
+				ResponseStatusCode: http.StatusInternalServerError,
+			},
+		},
+	}
+
+	if _, err := r.service.Projects.Events.Report(r.project, reported).Context(ctx).Do(); err != nil {
+		return fmt.Errorf("failed to report error: %v", err)
+	}
+	return nil
+}
+
+// FromEnv returns the reporter selected by ERROR_REPORTING
+func FromEnv(ctx context.Context, projectID, credentialsPath, serviceVersion string) (Reporter, error) {
+	serviceName := os.Getenv("K_SERVICE")
+	mode := strings.ToLower(os.Getenv("ERROR_REPORTING"))
+	if mode == "" {
+		mode = ModeLog
+		if serviceName != "" && projectID != "" {
+			mode = ModeCloud
+		}
+	}
+
+	switch mode {
+	case ModeCloud:
+		if serviceName == "" {
+			serviceName = "flight-ticket-service"
+		}
+		return NewCloudReporter(ctx, projectID, credentialsPath, serviceName, serviceVersion)
+	case ModeLog:
+		return LogReporter{}, nil
+	default:
+		return nil, fmt.Errorf("invalid ERROR_REPORTING %q: must be cloud or log", mode)
+	}
+}
+
+// Problem is an RFC 9457 problem details body. Error repeats the title so
+// clients reading models.ErrorResponse still get a message.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	Error     string `json:"error"`
+}
+
+// Middleware replaces chi's Recoverer: it recovers panics, reports them and
+// responds with a problem+json 500 unless the handler already started the
+// response. A nil reporter only logs. http.ErrAbortHandler is re-raised so
+// net/http aborts the connection as usual.
+func Middleware(reporter Reporter) func(http.Handler) http.Handler {
+	if reporter == nil {
+		reporter = LogReporter{}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tracker := &headerTracker{ResponseWriter: w}
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+				if err, ok := recovered.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					panic(recovered)
+				}
+
+				event := NewEvent(r, recovered, debug.Stack())
+				log.Printf("Recovered panic in %s %s (request %s): %v", r.Method, r.URL.Path, event.RequestID, recovered)
+
+				// Report in the background with a detached context so the client isn't kept waiting
+				go func() {
+					ctx, cancel := context.WithTimeout(context.Background(), reportTimeout)
+					defer cancel()
+					if err := reporter.Report(ctx, event); err != nil {
+						log.Printf("Failed to report panic: %v\n%s", err, event.Message)
+					}
+				}()
+
+				if tracker.wroteHeader {
+					return
+				}
+				w.Header().Set("Content-Type", "application/problem+json")
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(Problem{
+					Type:      "about:blank",
+					Title:     "Internal Server Error",
+					Status:    http.StatusInternalServerError,
+					Detail:    "The server encountered an unexpected error. It has been reported.",
+					Instance:  r.URL.Path,
+					RequestID: event.RequestID,
+					Error:     "Internal server error",
+				})
+			}()
+
+			next.ServeHTTP(tracker, r)
+		})
+	}
+}
+
+// NewEvent describes a panic; the message uses the Go panic format Error Reporting parses
+func NewEvent(r *http.Request, recovered interface{}, stack []byte) Event {
+	return Event{
+		Time:      time.Now(),
+		Message:   fmt.Sprintf("panic: %v\n\n%s", recovered, stack),
+		Method:    r.Method,
+		URL:       r.URL.RequestURI(),
+		UserAgent: r.UserAgent(),
+		RemoteIP:  r.RemoteAddr,
+		RequestID: middleware.GetReqID(r.Context()),
+	}
+}
+
+// headerTracker records whether the response has started
+type headerTracker struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *headerTracker) WriteHeader(status int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *headerTracker) Write(data []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(data)
+}
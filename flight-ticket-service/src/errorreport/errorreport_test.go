@@ -0,0 +1,83 @@
+package errorreport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type channelReporter chan Event
+
+func (c channelReporter) Report(ctx context.Context, event Event) error {
+	c <- event
+	return nil
+}
+
+func TestMiddlewareReportsPanicAsProblem(t *testing.T) {
+	reporter := make(channelReporter, 1)
+	handler := Middleware(reporter)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/ticket/ABC123?x=1", nil)
+	req.Header.Set("User-Agent", "test-agent")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError || rec.Header().Get("Content-Type") != "application/problem+json" {
+		t.Fatalf("Got status %d, content type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var problem Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("Invalid problem body: %v", err)
+	}
+	if problem.Status != 500 || problem.Instance != "/ticket/ABC123" || problem.Error == "" {
+		t.Errorf("Unexpected problem %+v", problem)
+	}
+
+	select {
+	case event := <-reporter:
+		if !strings.HasPrefix(event.Message, "panic: boom\n\ngoroutine ") || !strings.Contains(event.Message, "TestMiddlewareReportsPanicAsProblem") {
+			t.Errorf("Message lacks the panic and stack: %q", event.Message)
+		}
+		if event.Method != http.MethodGet || event.URL != "/ticket/ABC123?x=1" || event.UserAgent != "test-agent" {
+			t.Errorf("Unexpected request context %+v", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Panic was not reported")
+	}
+}
+
+func TestMiddlewareKeepsStartedResponse(t *testing.T) {
+	reporter := make(channelReporter, 1)
+	handler := Middleware(reporter)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("partial"))
+		panic("late")
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusAccepted || rec.Body.String() != "partial" {
+		t.Errorf("Started response was modified: %d %q", rec.Code, rec.Body.String())
+	}
+	<-reporter
+}
+
+func TestMiddlewareReraisesAbortHandler(t *testing.T) {
+	handler := Middleware(make(channelReporter, 1))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		if recovered := recover(); recovered != http.ErrAbortHandler {
+			t.Errorf("Expected http.ErrAbortHandler, got %v", recovered)
+		}
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
@@ -0,0 +1,273 @@
+// Package featureflags toggles optional features at runtime.
+//
+// Defaults come from FEATURE_FLAGS, a comma-separated list such as
+// "webhooks,search=false". When FEATURE_FLAGS_DOCUMENT names a Firestore
+// document (e.g. "config/feature_flags"), its boolean fields override the
+// defaults and changes are applied as soon as the document is written.
+package featureflags
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"flight-ticket-service/src/models"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Known feature flags
+const (
+	Webhooks      = "webhooks"
+	Notifications = "notifications"
+	Search        = "search"
+)
+
+// Known lists every flag; all default to disabled
+var Known = []string{Webhooks, Notifications, Search}
+
+// Flag sources
+const (
+	SourceEnv       = "env"
+	SourceFirestore = "firestore"
+)
+
+// retryInterval is the delay before re-opening a failed snapshot listener
+const retryInterval = 30 * time.Second
+
+// State is the current value of every flag
+// @Description Feature flag values
+type State struct {
+	Flags     map[string]bool `json:"flags" description:"Flag values by name"`
+	Source    string          `json:"source" example:"firestore" description:"Where overrides come from (env or firestore)"`
+	Document  string          `json:"document,omitempty" example:"config/feature_flags" description:"Firestore document holding overrides"`
+	UpdatedAt time.Time       `json:"updated_at" example:"2024-07-12T19:00:00Z" description:"When the flags last changed"`
+}
+
+// Store holds flag values and is safe for concurrent use
+type Store struct {
+	mu        sync.RWMutex
+	defaults  map[string]bool
+	overrides map[string]bool
+	source    string
+	document  string
+	updatedAt time.Time
+}
+
+// New creates a store with the given defaults
+func New(defaults map[string]bool) *Store {
+	values := make(map[string]bool, len(Known))
+	for _, name := range Known {
+		values[name] = defaults[name]
+	}
+	return &Store{
+		defaults:  values,
+		overrides: map[string]bool{},
+		source:    SourceEnv,
+		updatedAt: time.Now().UTC(),
+	}
+}
+
+// SetDefaults replaces the defaults, e.g. when FEATURE_FLAGS is reloaded;
+// Firestore overrides still take precedence
+func (s *Store) SetDefaults(defaults map[string]bool) {
+	values := make(map[string]bool, len(Known))
+	for _, name := range Known {
+		values[name] = defaults[name]
+	}
+
+	s.mu.Lock()
+	s.defaults = values
+	s.updatedAt = time.Now().UTC()
+	s.mu.Unlock()
+
+	log.Printf("Feature flags updated: %s", s.State())
+}
+
+// ParseEnv parses a FEATURE_FLAGS value. A bare name enables the flag.
+func ParseEnv(spec string) (map[string]bool, error) {
+	flags := make(map[string]bool)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(entry, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !isKnown(name) {
+			return nil, fmt.Errorf("unknown feature flag %q (known: %s)", name, strings.Join(Known, ", "))
+		}
+
+		enabled := true
+		if hasValue {
+			parsed, err := strconv.ParseBool(strings.TrimSpace(value))
+			if err != nil {
+				return nil, fmt.Errorf("invalid value for feature flag %s: %q", name, value)
+			}
+			enabled = parsed
+		}
+		flags[name] = enabled
+	}
+	return flags, nil
+}
+
+// Enabled reports whether a flag is on
+func (s *Store) Enabled(name string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if enabled, ok := s.overrides[name]; ok {
+		return enabled
+	}
+	return s.defaults[name]
+}
+
+// State returns the current value of every flag
+func (s *Store) State() State {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	state := State{
+		Flags:     make(map[string]bool, len(s.defaults)),
+		Source:    s.source,
+		Document:  s.document,
+		UpdatedAt: s.updatedAt,
+	}
+	for name, enabled := range s.defaults {
+		state.Flags[name] = enabled
+	}
+	for name, enabled := range s.overrides {
+		state.Flags[name] = enabled
+	}
+	return state
+}
+
+// apply replaces the overrides with the boolean fields of a config document
+func (s *Store) apply(data map[string]interface{}) {
+	overrides := make(map[string]bool)
+	var ignored []string
+	for name, value := range data {
+		enabled, ok := value.(bool)
+		if !ok || !isKnown(name) {
+			ignored = append(ignored, name)
+			continue
+		}
+		overrides[name] = enabled
+	}
+	if len(ignored) > 0 {
+		sort.Strings(ignored)
+		log.Printf("Ignoring unknown or non-boolean feature flag fields: %s", strings.Join(ignored, ", "))
+	}
+
+	s.mu.Lock()
+	s.overrides = overrides
+	s.updatedAt = time.Now().UTC()
+	s.mu.Unlock()
+
+	log.Printf("Feature flags updated: %s", s.State())
+}
+
+// String formats the flags as name=value pairs
+func (st State) String() string {
+	names := make([]string, 0, len(st.Flags))
+	for name := range st.Flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = fmt.Sprintf("%s=%t", name, st.Flags[name])
+	}
+	return strings.Join(pairs, " ")
+}
+
+// WatchFirestore loads overrides from a Firestore document and keeps them
+// current with a snapshot listener until ctx is cancelled. It blocks until
+// the first snapshot has been applied.
+func (s *Store) WatchFirestore(ctx context.Context, client *firestore.Client, document string) error {
+	doc := client.Doc(document)
+	if doc == nil {
+		return fmt.Errorf("invalid feature flag document path: %s", document)
+	}
+
+	snap, err := doc.Get(ctx)
+	if err != nil && status.Code(err) != codes.NotFound {
+		return fmt.Errorf("failed to read feature flags: %v", err)
+	}
+
+	s.mu.Lock()
+	s.source = SourceFirestore
+	s.document = document
+	s.mu.Unlock()
+	s.apply(snap.Data())
+
+	go func() {
+		for {
+			err := s.listen(ctx, doc)
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Feature flag listener stopped, retrying in %s: %v", retryInterval, err)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(retryInterval):
+			}
+		}
+	}()
+
+	return nil
+}
+
+// listen applies document snapshots until the listener fails
+func (s *Store) listen(ctx context.Context, doc *firestore.DocumentRef) error {
+	it := doc.Snapshots(ctx)
+	defer it.Stop()
+
+	for {
+		snap, err := it.Next()
+		if err != nil {
+			return err
+		}
+		// A missing document clears all overrides
+		s.apply(snap.Data())
+	}
+}
+
+// Require responds 404 when a flag is off, as if the route did not exist
+func (s *Store) Require(name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !s.Enabled(name) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusNotFound)
+				json.NewEncoder(w).Encode(models.ErrorResponse{
+					Error:   "Not found",
+					Message: fmt.Sprintf("The %s feature is disabled", name),
+				})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isKnown(name string) bool {
+	for _, known := range Known {
+		if name == known {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package featureflags
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseEnv(t *testing.T) {
+	flags, err := ParseEnv("webhooks, search=false,Notifications=1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !flags[Webhooks] || flags[Search] || !flags[Notifications] {
+		t.Errorf("Unexpected flags %v", flags)
+	}
+
+	for _, spec := range []string{"webhook", "search=maybe"} {
+		if _, err := ParseEnv(spec); err == nil {
+			t.Errorf("Expected error for %q", spec)
+		}
+	}
+}
+
+func TestStoreOverrides(t *testing.T) {
+	store := New(map[string]bool{Webhooks: true})
+	if !store.Enabled(Webhooks) || store.Enabled(Search) {
+		t.Fatalf("Unexpected defaults %v", store.State().Flags)
+	}
+
+	store.apply(map[string]interface{}{Webhooks: false, Search: true, "unknown": true, Notifications: "yes"})
+	if store.Enabled(Webhooks) || !store.Enabled(Search) || store.Enabled(Notifications) {
+		t.Errorf("Unexpected flags after override %v", store.State().Flags)
+	}
+	if _, ok := store.State().Flags["unknown"]; ok {
+		t.Error("Unknown fields should be ignored")
+	}
+
+	// A missing document restores the defaults
+	store.apply(nil)
+	if !store.Enabled(Webhooks) || store.Enabled(Search) {
+		t.Errorf("Expected defaults after document removal, got %v", store.State().Flags)
+	}
+}
+
+func TestRequire(t *testing.T) {
+	store := New(nil)
+	handler := store.Require(Search)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tickets/search", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 while disabled, got %d", rec.Code)
+	}
+
+	store.apply(map[string]interface{}{Search: true})
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tickets/search", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 while enabled, got %d", rec.Code)
+	}
+}
+
+func TestSetDefaultsKeepsOverrides(t *testing.T) {
+	store := New(map[string]bool{Webhooks: true})
+	store.apply(map[string]interface{}{Search: false})
+
+	store.SetDefaults(map[string]bool{Search: true, Notifications: true})
+	if store.Enabled(Webhooks) || !store.Enabled(Notifications) {
+		t.Errorf("Expected the new defaults, got %v", store.State().Flags)
+	}
+	if store.Enabled(Search) {
+		t.Error("Expected the Firestore override to win over the defaults")
+	}
+}
@@ -0,0 +1,195 @@
+// Package forecast predicts the bookings of each route over the next days
+// from its booking history, with a model deployed to a Vertex AI endpoint or
+// a deterministic statistical baseline that runs offline.
+//
+// FORECAST_PROVIDER selects the provider: static (the default) or vertex,
+// which calls the online prediction endpoint named by VERTEX_AI_ENDPOINT.
+package forecast
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"google.golang.org/api/option"
+)
+
+// Provider names
+const (
+	ProviderStatic = "static"
+	ProviderVertex = "vertex"
+)
+
+// Series is the daily booking counts of a route, oldest first
+type Series struct {
+	Route  string
+	Start  time.Time // day of the first count
+	Counts []int64
+}
+
+// Point is the forecast of one day
+type Point struct {
+	Bookings float64
+	Lower    float64
+	Upper    float64
+}
+
+// Forecaster predicts the bookings of the days after each series
+type Forecaster interface {
+	// Name returns the provider identifier reported in responses
+	Name() string
+	// Model names the model that makes the forecasts, if any
+	Model() string
+	// Forecast returns horizon points per series, in the order of the series
+	Forecast(ctx context.Context, series []Series, horizon int) ([][]Point, error)
+}
+
+// endpointPattern matches the resource name of a Vertex AI endpoint
+var endpointPattern = regexp.MustCompile(`^projects/[^/]+/locations/([a-z0-9-]+)/endpoints/[^/]+$`)
+
+// Config selects the forecast provider
+type Config struct {
+	Provider string
+	Endpoint string // Vertex AI endpoint resource name
+}
+
+// ConfigFromEnv reads FORECAST_PROVIDER and VERTEX_AI_ENDPOINT
+func ConfigFromEnv() (Config, error) {
+	config := Config{
+		Provider: strings.ToLower(strings.TrimSpace(os.Getenv("FORECAST_PROVIDER"))),
+		Endpoint: strings.TrimSpace(os.Getenv("VERTEX_AI_ENDPOINT")),
+	}
+	if config.Provider == "" {
+		config.Provider = ProviderStatic
+	}
+	switch config.Provider {
+	case ProviderStatic:
+	case ProviderVertex:
+		if !endpointPattern.MatchString(config.Endpoint) {
+			return Config{}, fmt.Errorf("invalid VERTEX_AI_ENDPOINT %q: must be projects/PROJECT/locations/LOCATION/endpoints/ENDPOINT_ID", config.Endpoint)
+		}
+	default:
+		return Config{}, fmt.Errorf("invalid FORECAST_PROVIDER %q: must be static or vertex", config.Provider)
+	}
+	return config, nil
+}
+
+// NewForecaster creates the forecaster of a config, calling Vertex AI with
+// the service account key file at credentialsPath or the default credentials
+func NewForecaster(ctx context.Context, config Config, credentialsPath string) (Forecaster, error) {
+	switch config.Provider {
+	case "", ProviderStatic:
+		return StaticForecaster{}, nil
+	case ProviderVertex:
+		var opts []option.ClientOption
+		if credentialsPath != "" {
+			opts = append(opts, option.WithCredentialsFile(credentialsPath))
+		}
+		return NewVertexForecaster(ctx, config.Endpoint, opts...)
+	default:
+		return nil, fmt.Errorf("unknown forecast provider: %s", config.Provider)
+	}
+}
+
+// StaticForecaster forecasts without a model: the mean of the last week,
+// moved by the change from the week before and scaled by the weekday's share
+// of bookings. The same history always gives the same forecast, which suits
+// offline demos and tests.
+type StaticForecaster struct{}
+
+// Name returns the provider identifier
+func (StaticForecaster) Name() string {
+	return ProviderStatic
+}
+
+// Model returns no model name
+func (StaticForecaster) Model() string {
+	return ""
+}
+
+// Forecast predicts each series on its own
+func (StaticForecaster) Forecast(ctx context.Context, series []Series, horizon int) ([][]Point, error) {
+	points := make([][]Point, len(series))
+	for i, s := range series {
+		points[i] = staticForecast(s, horizon)
+	}
+	return points, nil
+}
+
+// staticForecast forecasts one series
+func staticForecast(s Series, horizon int) []Point {
+	counts := s.Counts
+	lastWeek := mean(tail(counts, 7))
+	var trend float64 // change per day
+	if len(counts) >= 14 {
+		trend = (lastWeek - mean(counts[len(counts)-14:len(counts)-7])) / 7
+	}
+
+	// Weekday shares need two full weeks to tell a pattern from noise
+	overall := mean(counts)
+	factors := [7]float64{1, 1, 1, 1, 1, 1, 1}
+	if len(counts) >= 14 && overall > 0 {
+		var sums [7]float64
+		var days [7]int
+		for i, count := range counts {
+			weekday := s.Start.AddDate(0, 0, i).Weekday()
+			sums[weekday] += float64(count)
+			days[weekday]++
+		}
+		for weekday := range factors {
+			if days[weekday] > 0 {
+				factors[weekday] = sums[weekday] / float64(days[weekday]) / overall
+			}
+		}
+	}
+
+	// An 80% interval from the spread of the history
+	var variance float64
+	for _, count := range counts {
+		variance += (float64(count) - overall) * (float64(count) - overall)
+	}
+	if len(counts) > 1 {
+		variance /= float64(len(counts) - 1)
+	}
+	spread := 1.28 * math.Sqrt(variance)
+
+	end := s.Start.AddDate(0, 0, len(counts))
+	points := make([]Point, horizon)
+	for i := range points {
+		weekday := end.AddDate(0, 0, i).Weekday()
+		bookings := math.Max(0, (lastWeek+trend*float64(i+1))*factors[weekday])
+		points[i] = Point{
+			Bookings: round(bookings),
+			Lower:    round(math.Max(0, bookings-spread)),
+			Upper:    round(bookings + spread),
+		}
+	}
+	return points
+}
+
+func tail(counts []int64, n int) []int64 {
+	if len(counts) <= n {
+		return counts
+	}
+	return counts[len(counts)-n:]
+}
+
+func mean(counts []int64) float64 {
+	if len(counts) == 0 {
+		return 0
+	}
+	var sum int64
+	for _, count := range counts {
+		sum += count
+	}
+	return float64(sum) / float64(len(counts))
+}
+
+// round keeps two decimals
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
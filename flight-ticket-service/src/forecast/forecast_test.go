@@ -0,0 +1,118 @@
+package forecast
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/api/option"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	if config, err := ConfigFromEnv(); err != nil || config.Provider != ProviderStatic {
+		t.Errorf("Expected the static provider by default, got %+v, %v", config, err)
+	}
+
+	t.Setenv("FORECAST_PROVIDER", "Vertex")
+	t.Setenv("VERTEX_AI_ENDPOINT", "projects/demo/locations/us-central1/endpoints/1234567890")
+	if config, err := ConfigFromEnv(); err != nil || config.Provider != ProviderVertex {
+		t.Errorf("Unexpected config %+v, %v", config, err)
+	}
+
+	for _, endpoint := range []string{"", "1234567890", "projects/demo/endpoints/1234567890"} {
+		t.Setenv("VERTEX_AI_ENDPOINT", endpoint)
+		if _, err := ConfigFromEnv(); err == nil {
+			t.Errorf("Expected an error for endpoint %q", endpoint)
+		}
+	}
+	t.Setenv("FORECAST_PROVIDER", "prophet")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Error("Expected an error for an unknown provider")
+	}
+}
+
+func TestStaticForecast(t *testing.T) {
+	// Two weeks starting on a Monday, busy on Fridays and growing by one booking a day in the second week
+	start := time.Date(2026, 9, 28, 0, 0, 0, 0, time.UTC)
+	counts := []int64{10, 10, 10, 10, 20, 10, 10, 11, 11, 11, 11, 21, 11, 11}
+	series := []Series{{Route: "JFK-LAX", Start: start, Counts: counts}, {Route: "SFO-SEA", Start: start, Counts: []int64{3}}}
+
+	points, err := StaticForecaster{}.Forecast(context.Background(), series, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := StaticForecaster{}.Forecast(context.Background(), series, 7)
+	if !reflect.DeepEqual(points, again) {
+		t.Error("Expected the same forecast for the same history")
+	}
+
+	busy := points[0]
+	if len(busy) != 7 || busy[4].Bookings <= busy[3].Bookings || busy[1].Bookings <= busy[0].Bookings {
+		t.Errorf("Expected a growing forecast with a busy Friday, got %+v", busy)
+	}
+	for _, point := range busy {
+		if point.Lower > point.Bookings || point.Upper < point.Bookings || point.Lower < 0 {
+			t.Errorf("Expected the forecast within its interval, got %+v", point)
+		}
+	}
+	if short := points[1]; short[0] != (Point{Bookings: 3, Lower: 3, Upper: 3}) {
+		t.Errorf("Expected a flat forecast of a one-day history, got %+v", short[0])
+	}
+}
+
+func TestVertexForecast(t *testing.T) {
+	var request struct {
+		Instances []vertexInstance `json:"instances"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/projects/demo/locations/europe-west1/endpoints/42:predict" {
+			http.Error(w, `{"error": {"code": 404}}`, http.StatusNotFound)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"predictions": []map[string]interface{}{
+				{"forecast": []float64{4, 5}, "lower": []float64{3, 4}, "upper": []float64{5, 6}},
+				{"forecast": []float64{1, 2, 3}},
+			},
+			"deployedModelId": "7",
+		})
+	}))
+	defer server.Close()
+
+	forecaster, err := NewVertexForecaster(context.Background(), "projects/demo/locations/europe-west1/endpoints/42", option.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if forecaster.url != "https://europe-west1-aiplatform.googleapis.com/v1/projects/demo/locations/europe-west1/endpoints/42:predict" {
+		t.Errorf("Unexpected prediction URL %s", forecaster.url)
+	}
+	forecaster.url = server.URL + "/v1/projects/demo/locations/europe-west1/endpoints/42:predict"
+
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	points, err := forecaster.Forecast(context.Background(), []Series{
+		{Route: "JFK-LAX", Start: start, Counts: []int64{4, 4}},
+		{Route: "SFO-SEA", Start: start, Counts: []int64{1, 1}},
+	}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(request.Instances) != 2 || request.Instances[0].StartDate != "2026-10-01" || request.Instances[1].Horizon != 2 {
+		t.Errorf("Unexpected instances %+v", request.Instances)
+	}
+	if points[0][1] != (Point{Bookings: 5, Lower: 4, Upper: 6}) || points[1][0] != (Point{Bookings: 1, Lower: 1, Upper: 1}) {
+		t.Errorf("Unexpected points %+v", points)
+	}
+
+	if _, err := forecaster.Forecast(context.Background(), []Series{{Route: "JFK-LAX", Start: start, Counts: []int64{4}}}, 2); err == nil {
+		t.Error("Expected an error when the predictions do not match the routes")
+	}
+	forecaster.url = server.URL + "/v1/missing:predict"
+	if _, err := forecaster.Forecast(context.Background(), []Series{{Route: "JFK-LAX", Start: start}}, 2); err == nil {
+		t.Error("Expected an error for a failed prediction")
+	}
+}
@@ -0,0 +1,136 @@
+package forecast
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
+)
+
+// predictTimeout bounds a prediction request
+const predictTimeout = 30 * time.Second
+
+// VertexForecaster calls a model deployed to a Vertex AI endpoint. The model
+// takes one instance per route and returns one prediction per instance:
+//
+//	instance:   {"route": "JFK-LAX", "start_date": "2026-09-19", "history": [12, 9, ...], "horizon": 7}
+//	prediction: {"forecast": [11.2, ...], "lower": [7.9, ...], "upper": [14.5, ...]}
+//
+// lower and upper are optional; without them the interval is the forecast.
+type VertexForecaster struct {
+	endpoint string
+	url      string
+	client   *http.Client
+}
+
+// NewVertexForecaster creates a forecaster calling the endpoint with the
+// application default credentials, or those of opts
+func NewVertexForecaster(ctx context.Context, endpoint string, opts ...option.ClientOption) (*VertexForecaster, error) {
+	match := endpointPattern.FindStringSubmatch(endpoint)
+	if match == nil {
+		return nil, fmt.Errorf("invalid Vertex AI endpoint %q", endpoint)
+	}
+	opts = append([]option.ClientOption{option.WithScopes("https://www.googleapis.com/auth/cloud-platform")}, opts...)
+	client, _, err := htransport.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Vertex AI client: %v", err)
+	}
+	return &VertexForecaster{
+		endpoint: endpoint,
+		url:      fmt.Sprintf("https://%s-aiplatform.googleapis.com/v1/%s:predict", match[1], endpoint),
+		client:   client,
+	}, nil
+}
+
+// Name returns the provider identifier
+func (f *VertexForecaster) Name() string {
+	return ProviderVertex
+}
+
+// Model returns the endpoint resource name
+func (f *VertexForecaster) Model() string {
+	return f.endpoint
+}
+
+type vertexInstance struct {
+	Route     string  `json:"route"`
+	StartDate string  `json:"start_date"`
+	History   []int64 `json:"history"`
+	Horizon   int     `json:"horizon"`
+}
+
+type vertexPrediction struct {
+	Forecast []float64 `json:"forecast"`
+	Lower    []float64 `json:"lower"`
+	Upper    []float64 `json:"upper"`
+}
+
+type vertexResponse struct {
+	Predictions []vertexPrediction `json:"predictions"`
+}
+
+// Forecast sends every series in one prediction request
+func (f *VertexForecaster) Forecast(ctx context.Context, series []Series, horizon int) ([][]Point, error) {
+	if len(series) == 0 {
+		return [][]Point{}, nil
+	}
+	instances := make([]vertexInstance, len(series))
+	for i, s := range series {
+		instances[i] = vertexInstance{Route: s.Route, StartDate: s.Start.Format("2006-01-02"), History: s.Counts, Horizon: horizon}
+	}
+	body, err := json.Marshal(map[string]interface{}{"instances": instances})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode prediction request: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, predictTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build prediction request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call Vertex AI: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("Vertex AI returned HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(detail))
+	}
+
+	var result vertexResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode predictions: %v", err)
+	}
+	if len(result.Predictions) != len(series) {
+		return nil, fmt.Errorf("Vertex AI returned %d predictions for %d routes", len(result.Predictions), len(series))
+	}
+
+	points := make([][]Point, len(series))
+	for i, prediction := range result.Predictions {
+		if len(prediction.Forecast) < horizon {
+			return nil, fmt.Errorf("Vertex AI forecast %d days of %s, expected %d", len(prediction.Forecast), series[i].Route, horizon)
+		}
+		points[i] = make([]Point, horizon)
+		for day := range points[i] {
+			point := Point{Bookings: prediction.Forecast[day], Lower: prediction.Forecast[day], Upper: prediction.Forecast[day]}
+			if day < len(prediction.Lower) {
+				point.Lower = prediction.Lower[day]
+			}
+			if day < len(prediction.Upper) {
+				point.Upper = prediction.Upper[day]
+			}
+			points[i][day] = point
+		}
+	}
+	return points, nil
+}
@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"flight-ticket-service/src/debuglog"
+	"flight-ticket-service/src/featureflags"
+	"flight-ticket-service/src/maintenance"
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/services"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type AdminHandler struct {
+	usage       *services.UsageTracker
+	flags       *featureflags.Store
+	maintenance *maintenance.Switch
+	started     time.Time
+}
+
+func NewAdminHandler(usage *services.UsageTracker, flags *featureflags.Store, maintenanceSwitch *maintenance.Switch) *AdminHandler {
+	return &AdminHandler{
+		usage:       usage,
+		flags:       flags,
+		maintenance: maintenanceSwitch,
+		started:     time.Now(),
+	}
+}
+
+// UsageMiddleware attributes the repository operations of each request to its
+// route and holds each request to the operation budget. When a request runs
+// out of budget, the handler's server error is replaced with one saying so.
+func UsageMiddleware(usage *services.UsageTracker, budget services.OperationBudget) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, scope := services.WithUsageBudget(r.Context(), budget)
+			next.ServeHTTP(&budgetWriter{ResponseWriter: w, scope: scope}, r.WithContext(ctx))
+
+			// The route pattern is only known once chi has routed the request
+			endpoint := r.Method + " " + r.URL.Path
+			if rctx := chi.RouteContext(ctx); rctx != nil && rctx.RoutePattern() != "" {
+				endpoint = r.Method + " " + rctx.RoutePattern()
+			}
+			counts := scope.Counts()
+			debuglog.Printf(ctx, "%s made %d document reads, %d writes and %d deletes", endpoint, counts.Reads, counts.Writes, counts.Deletes)
+			if scope.Exceeded() {
+				log.Printf("%s exceeded its operation budget after %d reads and %d writes", endpoint, counts.Reads, counts.Writes+counts.Deletes)
+			}
+			usage.Record(endpoint, counts)
+		})
+	}
+}
+
+// budgetWriter replaces a server error caused by an exhausted operation budget
+// with an error naming the budget; the handler's own response body is dropped
+type budgetWriter struct {
+	http.ResponseWriter
+	scope    *services.UsageScope
+	replaced bool
+}
+
+func (w *budgetWriter) WriteHeader(status int) {
+	if status < http.StatusInternalServerError || !w.scope.Exceeded() {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+
+	w.replaced = true
+	budget := w.scope.Budget()
+	w.ResponseWriter.Header().Set("Content-Type", "application/json")
+	w.ResponseWriter.WriteHeader(http.StatusInternalServerError)
+	json.NewEncoder(w.ResponseWriter).Encode(models.ErrorResponse{
+		Error:   "Request exceeded its storage operation budget",
+		Message: fmt.Sprintf("A single request may make %s document reads and %s writes; narrow the request, e.g. with a smaller limit", budgetLimit(budget.Reads), budgetLimit(budget.Writes)),
+	})
+}
+
+func budgetLimit(limit int64) string {
+	if limit == 0 {
+		return "unlimited"
+	}
+	return fmt.Sprintf("at most %d", limit)
+}
+
+func (w *budgetWriter) Write(data []byte) (int, error) {
+	if w.replaced {
+		return len(data), nil
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// GetStats handles GET /admin/stats
+// @Summary Get Firestore usage statistics
+// @Description Document reads, writes and deletes per endpoint since the server started, with a projected monthly Firestore cost. Requires an admin API key.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Success 200 {object} models.UsageStats "Usage statistics"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Router /admin/stats [get]
+func (h *AdminHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(h.usage.Stats(time.Now()))
+}
+
+// GetFeatureFlags handles GET /admin/flags
+// @Summary Get feature flags
+// @Description Current value of every feature flag and where overrides come from. Requires an admin API key.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Success 200 {object} featureflags.State "Feature flags"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Router /admin/flags [get]
+func (h *AdminHandler) GetFeatureFlags(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(h.flags.State())
+}
+
+// GetMaintenance handles GET /admin/maintenance
+// @Summary Get maintenance mode
+// @Description Current maintenance mode of this instance. Requires an admin API key.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Success 200 {object} maintenance.Status "Maintenance state"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Router /admin/maintenance [get]
+func (h *AdminHandler) GetMaintenance(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(h.maintenance.Status())
+}
+
+// SetMaintenance handles PUT /admin/maintenance
+// @Summary Set maintenance mode
+// @Description Put this instance into read-only mode (mutating endpoints return 503) or full maintenance mode (all API endpoints return 503), or turn maintenance off. Health, version, metrics and admin endpoints stay available. Requires an admin API key.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param request body maintenance.Request true "Maintenance mode"
+// @Success 200 {object} maintenance.Status "Maintenance state"
+// @Failure 400 {object} models.ErrorResponse "Invalid request"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Router /admin/maintenance [put]
+func (h *AdminHandler) SetMaintenance(w http.ResponseWriter, r *http.Request) {
+	var req maintenance.Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid JSON", Message: err.Error()})
+		return
+	}
+
+	mode, err := maintenance.ParseMode(string(req.Mode))
+	if err != nil || req.Mode == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid mode", Message: "mode must be off, read-only or full"})
+		return
+	}
+
+	status := h.maintenance.Set(mode, req.Message)
+	log.Printf("Maintenance mode set to %s", status.Mode)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(status)
+}
+
+// GetDebugVars handles GET /admin/debug/vars
+// @Summary Get runtime diagnostics
+// @Description Goroutine count, heap and GC statistics, and build information of the running instance. Requires an admin API key.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Success 200 {object} models.DebugVars "Runtime diagnostics"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Router /admin/debug/vars [get]
+func (h *AdminHandler) GetDebugVars(w http.ResponseWriter, r *http.Request) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	vars := models.DebugVars{
+		Timestamp:     time.Now(),
+		UptimeSeconds: time.Since(h.started).Seconds(),
+		Goroutines:    runtime.NumGoroutine(),
+		NumCPU:        runtime.NumCPU(),
+		GOMAXPROCS:    runtime.GOMAXPROCS(0),
+		Memory: models.MemoryStats{
+			HeapAllocBytes:   mem.HeapAlloc,
+			HeapInuseBytes:   mem.HeapInuse,
+			HeapObjects:      mem.HeapObjects,
+			SysBytes:         mem.Sys,
+			TotalAllocBytes:  mem.TotalAlloc,
+			NumGC:            mem.NumGC,
+			LastGC:           time.Unix(0, int64(mem.LastGC)).UTC(),
+			PauseTotalNs:     mem.PauseTotalNs,
+			GCCPUFraction:    mem.GCCPUFraction,
+			NextGCBytes:      mem.NextGC,
+			StackInuseBytes:  mem.StackInuse,
+			MemoryLimitBytes: debug.SetMemoryLimit(-1),
+		},
+		Build: models.BuildInfo{GoVersion: runtime.Version()},
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		vars.Build.Path = info.Path
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				vars.Build.VCSRevision = setting.Value
+			case "vcs.time":
+				vars.Build.VCSTime = setting.Value
+			case "vcs.modified":
+				vars.Build.VCSModified = setting.Value == "true"
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(vars)
+}
+
+// Profiler returns the net/http/pprof handlers for mounting under /admin/debug/pprof.
+// pprof.Index only resolves named profiles under /debug/pprof/, so they are routed explicitly.
+func Profiler() http.Handler {
+	r := chi.NewRouter()
+	r.Get("/", pprof.Index)
+	r.Get("/cmdline", pprof.Cmdline)
+	r.Get("/profile", pprof.Profile)
+	r.Post("/symbol", pprof.Symbol)
+	r.Get("/symbol", pprof.Symbol)
+	r.Get("/trace", pprof.Trace)
+	r.Get("/{profile}", func(w http.ResponseWriter, r *http.Request) {
+		pprof.Handler(chi.URLParam(r, "profile")).ServeHTTP(w, r)
+	})
+	return r
+}
@@ -0,0 +1,329 @@
+package handlers
+
+import (
+	"embed"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"flight-ticket-service/src/auth"
+	"flight-ticket-service/src/maintenance"
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/seats"
+	"flight-ticket-service/src/services"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// adminSessionCookie carries the admin API key of a signed-in browser. It is
+// scoped to the UI path and sent only on same-site requests.
+const adminSessionCookie = "admin_ui_key"
+
+//go:embed templates/adminui/*.html
+var adminUITemplates embed.FS
+
+// adminUIPages are parsed once, each page together with the shared layout
+var adminUIPages = func() map[string]*template.Template {
+	pages := make(map[string]*template.Template)
+	for _, page := range []string{"login", "tickets", "ticket"} {
+		pages[page] = template.Must(template.ParseFS(adminUITemplates, "templates/adminui/layout.html", "templates/adminui/"+page+".html"))
+	}
+	return pages
+}()
+
+// adminUIPage is the data of a rendered page
+type adminUIPage struct {
+	Principal string
+	Message   string
+	Error     string
+	Query     map[string]string
+	Statuses  []string
+	Tickets   []*models.FlightTicket
+	Ticket    *models.FlightTicket
+	Notes     []*models.TicketNote
+}
+
+type AdminUIHandler struct {
+	repository  services.TicketRepository
+	keyStore    *auth.KeyStore
+	maintenance *maintenance.Switch
+	inventory   *services.SeatInventory
+	seats       seats.Store
+}
+
+func NewAdminUIHandler(repository services.TicketRepository, keyStore *auth.KeyStore, maintenanceSwitch *maintenance.Switch, seatStore seats.Store) *AdminUIHandler {
+	return &AdminUIHandler{
+		repository:  repository,
+		keyStore:    keyStore,
+		maintenance: maintenanceSwitch,
+		inventory:   services.NewSeatInventory(repository),
+		seats:       seatStore,
+	}
+}
+
+// Routes returns the router of the admin UI, to be mounted at /admin/ui
+func (h *AdminUIHandler) Routes() chi.Router {
+	r := chi.NewRouter()
+	r.Use(sameOriginForms)
+	r.Get("/login", h.LoginPage)
+	r.Post("/login", h.Login)
+	r.Post("/logout", h.Logout)
+	r.Group(func(r chi.Router) {
+		r.Use(h.requireSession)
+		r.Get("/", h.ListTickets)
+		r.Get("/tickets/{confirmationID}", h.ShowTicket)
+		r.Post("/tickets/{confirmationID}/cancel", h.CancelTicket)
+		r.Post("/tickets/{confirmationID}/rebook", h.RebookTicket)
+	})
+	return r
+}
+
+// sameOriginForms rejects form posts from other sites, on top of the SameSite session cookie
+func sameOriginForms(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			if origin := r.Header.Get("Origin"); origin != "" {
+				if u, err := url.Parse(origin); err != nil || u.Host != r.Host {
+					http.Error(w, "Cross-origin form submission", http.StatusForbidden)
+					return
+				}
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireSession lets admins in, either by API key header or by session
+// cookie, and sends everyone else to the sign-in page
+func (h *AdminUIHandler) requireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := auth.FromContext(r.Context())
+		if !ok {
+			if cookie, err := r.Cookie(adminSessionCookie); err == nil {
+				principal, ok = h.keyStore.Lookup(cookie.Value)
+			}
+		}
+		if !ok || principal.Role != auth.RoleAdmin {
+			http.Redirect(w, r, "/admin/ui/login", http.StatusSeeOther)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+	})
+}
+
+// render writes a page, logging template errors that occur after the header is sent
+func (h *AdminUIHandler) render(w http.ResponseWriter, r *http.Request, status int, page string, data adminUIPage) {
+	if principal, ok := auth.FromContext(r.Context()); ok {
+		data.Principal = principal.Name
+	}
+	if data.Message == "" {
+		data.Message = r.URL.Query().Get("message")
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := adminUIPages[page].ExecuteTemplate(w, "layout", data); err != nil {
+		log.Printf("Failed to render admin UI page %s: %v", page, err)
+	}
+}
+
+// LoginPage handles GET /admin/ui/login
+func (h *AdminUIHandler) LoginPage(w http.ResponseWriter, r *http.Request) {
+	h.render(w, r, http.StatusOK, "login", adminUIPage{})
+}
+
+// Login handles POST /admin/ui/login and stores an admin API key in the session cookie
+func (h *AdminUIHandler) Login(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimSpace(r.PostFormValue("api_key"))
+	principal, ok := h.keyStore.Lookup(key)
+	if !ok || principal.Role != auth.RoleAdmin {
+		h.render(w, r, http.StatusUnauthorized, "login", adminUIPage{Error: "Unknown key, or the key does not have the admin role"})
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     adminSessionCookie,
+		Value:    key,
+		Path:     "/admin/ui",
+		MaxAge:   int((12 * time.Hour).Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteStrictMode,
+	})
+	http.Redirect(w, r, "/admin/ui/", http.StatusSeeOther)
+}
+
+// Logout handles POST /admin/ui/logout
+func (h *AdminUIHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{Name: adminSessionCookie, Path: "/admin/ui", MaxAge: -1, HttpOnly: true, SameSite: http.SameSiteStrictMode})
+	http.Redirect(w, r, "/admin/ui/login", http.StatusSeeOther)
+}
+
+// ListTickets handles GET /admin/ui/, listing the newest tickets or the results of a search
+func (h *AdminUIHandler) ListTickets(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	data := adminUIPage{
+		Query: map[string]string{
+			"Origin":        strings.ToUpper(params.Get("origin")),
+			"Destination":   strings.ToUpper(params.Get("destination")),
+			"FlightNumber":  strings.ToUpper(params.Get("flight_number")),
+			"Status":        strings.ToUpper(params.Get("status")),
+			"DepartureDate": params.Get("departure_date"),
+		},
+		Statuses: []string{"CONFIRMED", "PENDING", "REVIEW", "DISRUPTED", "CANCELLED"},
+	}
+
+	query := models.TicketQuery{
+		Origin:       data.Query["Origin"],
+		Destination:  data.Query["Destination"],
+		FlightNumber: data.Query["FlightNumber"],
+		Status:       data.Query["Status"],
+		Limit:        100,
+	}
+	if date := data.Query["DepartureDate"]; date != "" {
+		departureDate, err := models.ParseDepartureDate(date, time.Now())
+		if err != nil {
+			data.Error = err.Error()
+			h.render(w, r, http.StatusBadRequest, "tickets", data)
+			return
+		}
+		query.DepartureDate = departureDate
+	}
+
+	tickets, err := services.SearchTickets(r.Context(), h.repository, query)
+	if err != nil {
+		log.Printf("Failed to search tickets: %v", err)
+		data.Error = "Failed to retrieve tickets"
+		h.render(w, r, http.StatusInternalServerError, "tickets", data)
+		return
+	}
+	data.Tickets = tickets
+	h.render(w, r, http.StatusOK, "tickets", data)
+}
+
+// ShowTicket handles GET /admin/ui/tickets/{confirmationID}
+func (h *AdminUIHandler) ShowTicket(w http.ResponseWriter, r *http.Request) {
+	confirmationID := chi.URLParam(r, "confirmationID")
+	ticket, err := h.repository.GetTicket(r.Context(), confirmationID)
+	if err != nil {
+		h.render(w, r, http.StatusNotFound, "tickets", adminUIPage{Error: "Ticket " + confirmationID + " not found"})
+		return
+	}
+
+	data := adminUIPage{Ticket: ticket}
+	if notes, ok := services.Capability[services.NoteRepository](h.repository); ok {
+		if data.Notes, err = notes.ListNotes(r.Context(), confirmationID); err != nil {
+			log.Printf("Failed to list notes for ticket %s: %v", confirmationID, err)
+		}
+	}
+	h.render(w, r, http.StatusOK, "ticket", data)
+}
+
+// CancelTicket handles POST /admin/ui/tickets/{confirmationID}/cancel
+func (h *AdminUIHandler) CancelTicket(w http.ResponseWriter, r *http.Request) {
+	confirmationID := chi.URLParam(r, "confirmationID")
+	if !h.writable(w, r, confirmationID) {
+		return
+	}
+
+	// The stored ticket names the inventory and the assigned seats to give back
+	previous, _ := h.repository.GetTicket(r.Context(), confirmationID)
+	if err := h.repository.DeleteTicket(r.Context(), confirmationID); err != nil {
+		log.Printf("Failed to cancel ticket %s: %v", confirmationID, err)
+		h.redirectToTicket(w, r, confirmationID, "Failed to cancel the ticket")
+		return
+	}
+	if previous != nil {
+		if h.inventory.Enabled() {
+			if err := h.inventory.Release(r.Context(), previous, requestActor(r)); err != nil {
+				log.Printf("Failed to release seats of ticket %s: %v", confirmationID, err)
+			}
+		}
+		freeSeats(r.Context(), h.seats, previous)
+	}
+
+	h.redirectToTicket(w, r, confirmationID, "Ticket cancelled")
+}
+
+// RebookTicket handles POST /admin/ui/tickets/{confirmationID}/rebook, moving
+// the ticket to another flight number, date or departure time
+func (h *AdminUIHandler) RebookTicket(w http.ResponseWriter, r *http.Request) {
+	confirmationID := chi.URLParam(r, "confirmationID")
+	if !h.writable(w, r, confirmationID) {
+		return
+	}
+
+	departureDate, err := time.Parse("2006-01-02", r.PostFormValue("departure_date"))
+	if err != nil {
+		h.redirectToTicket(w, r, confirmationID, "Invalid departure date, use YYYY-MM-DD")
+		return
+	}
+	timeOnly, err := time.Parse("15:04", r.PostFormValue("departure_time"))
+	if err != nil {
+		h.redirectToTicket(w, r, confirmationID, "Invalid departure time, use HH:MM")
+		return
+	}
+
+	previous, err := h.repository.GetTicket(r.Context(), confirmationID)
+	if err != nil {
+		h.render(w, r, http.StatusNotFound, "tickets", adminUIPage{Error: "Ticket " + confirmationID + " not found"})
+		return
+	}
+	if previous.Status == "CANCELLED" {
+		h.redirectToTicket(w, r, confirmationID, "Cancelled tickets cannot be rebooked")
+		return
+	}
+
+	updates := map[string]interface{}{
+		"departure_date": departureDate,
+		"departure_time": time.Date(departureDate.Year(), departureDate.Month(), departureDate.Day(), timeOnly.Hour(), timeOnly.Minute(), 0, 0, time.UTC),
+	}
+	if flightNumber := strings.ToUpper(strings.TrimSpace(r.PostFormValue("flight_number"))); flightNumber != "" {
+		updates["flight_number"] = flightNumber
+	}
+	unseat := leaveSeats(previous, updates)
+
+	actor := requestActor(r)
+	booked := bookedTicket(previous, updates)
+	if err := h.inventory.Change(r.Context(), previous, booked, actor); err != nil {
+		log.Printf("Failed to move seats of ticket %s: %v", confirmationID, err)
+		if errors.Is(err, services.ErrSalesFrozen) {
+			h.redirectToTicket(w, r, confirmationID, "Sales are frozen on the new flight")
+			return
+		}
+		h.redirectToTicket(w, r, confirmationID, "Not enough seats on the new flight")
+		return
+	}
+	if err := h.repository.UpdateTicket(r.Context(), confirmationID, updates); err != nil {
+		log.Printf("Failed to rebook ticket %s: %v", confirmationID, err)
+		if err := h.inventory.Change(r.Context(), booked, previous, actor); err != nil {
+			log.Printf("Failed to restore seats of ticket %s: %v", confirmationID, err)
+		}
+		h.redirectToTicket(w, r, confirmationID, "Failed to rebook the ticket")
+		return
+	}
+	if unseat {
+		freeSeats(r.Context(), h.seats, previous)
+	}
+
+	h.redirectToTicket(w, r, confirmationID, "Ticket rebooked")
+}
+
+// writable reports whether tickets may be changed, redirecting with a message during maintenance
+func (h *AdminUIHandler) writable(w http.ResponseWriter, r *http.Request, confirmationID string) bool {
+	// Admin routes stay open during maintenance, but the actions write tickets
+	if h.maintenance.Status().Mode != maintenance.ModeOff {
+		h.redirectToTicket(w, r, confirmationID, "Tickets cannot be changed during maintenance")
+		return false
+	}
+	return true
+}
+
+// redirectToTicket sends the browser back to the ticket page with a message (post/redirect/get)
+func (h *AdminUIHandler) redirectToTicket(w http.ResponseWriter, r *http.Request, confirmationID, message string) {
+	target := "/admin/ui/tickets/" + url.PathEscape(confirmationID) + "?message=" + url.QueryEscape(message)
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/services"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type AdvisoryHandler struct {
+	repository     services.TicketRepository
+	weatherService *services.WeatherService
+}
+
+func NewAdvisoryHandler(repository services.TicketRepository, weatherService *services.WeatherService) *AdvisoryHandler {
+	return &AdvisoryHandler{
+		repository:     repository,
+		weatherService: weatherService,
+	}
+}
+
+// GetAdvisories handles GET /ticket/{confirmationID}/advisories
+// @Summary Get weather advisories for a ticket
+// @Description Fetch current and forecast weather for the ticket's origin and destination airports and flag likely disruption
+// @Tags advisories
+// @Accept json
+// @Produce json
+// @Param confirmationID path string true "Ticket confirmation ID" example("ABC123")
+// @Success 200 {object} models.TicketAdvisoriesResponse "Weather advisories for the ticket"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Ticket not found"
+// @Router /ticket/{confirmationID}/advisories [get]
+func (h *AdvisoryHandler) GetAdvisories(w http.ResponseWriter, r *http.Request) {
+	confirmationID := chi.URLParam(r, "confirmationID")
+	if confirmationID == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Confirmation ID is required"})
+		return
+	}
+
+	ticket, err := h.repository.GetTicket(r.Context(), confirmationID)
+	if err != nil {
+		log.Printf("Failed to get ticket %s: %v", confirmationID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Ticket not found"})
+		return
+	}
+
+	advisories := h.weatherService.GetTicketAdvisories(r.Context(), ticket)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(advisories)
+}
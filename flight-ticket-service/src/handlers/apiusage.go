@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"flight-ticket-service/src/analytics"
+	"flight-ticket-service/src/models"
+)
+
+// maxTopEndpoints caps the top query parameter of GET /admin/usage
+const maxTopEndpoints = 50
+
+type APIUsageHandler struct {
+	usage *analytics.Recorder
+}
+
+func NewAPIUsageHandler(usage *analytics.Recorder) *APIUsageHandler {
+	return &APIUsageHandler{usage: usage}
+}
+
+// GetAPIUsage handles GET /admin/usage
+// @Summary Get API usage per key
+// @Description Requests, 4xx and 5xx responses and the most requested endpoints of each API key in a month, read from sharded counters. Requests without a key are reported as anonymous and requests that matched no route as the unmatched endpoint. Other instances flush their counts every USAGE_FLUSH_INTERVAL, so the current month may lag by that much. Months are UTC. Requires an admin API key.
+// @Tags admin
+// @Produce json
+// @Produce text/csv
+// @Security ApiKeyAuth || BearerAuth
+// @Param month query string false "Month (YYYY-MM), default the current month" example(2026-09)
+// @Param top query int false "Top endpoints per key" default(5) minimum(1) maximum(50)
+// @Param format query string false "Output format" Enums(json, csv) default(json)
+// @Success 200 {object} models.APIUsageReport "API usage per key"
+// @Failure 400 {object} models.ErrorResponse "Invalid month, top or format"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /admin/usage [get]
+func (h *APIUsageHandler) GetAPIUsage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	query := r.URL.Query()
+
+	month, err := analytics.ParseMonth(query.Get("month"), time.Now())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid month", Message: "Use YYYY-MM format"})
+		return
+	}
+	top := analytics.DefaultTopEndpoints
+	if value := query.Get("top"); value != "" {
+		top, err = strconv.Atoi(value)
+		if err != nil || top < 1 || top > maxTopEndpoints {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid top", Message: fmt.Sprintf("top must be between 1 and %d", maxTopEndpoints)})
+			return
+		}
+	}
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid format", Message: "Use json or csv"})
+		return
+	}
+
+	report, err := h.usage.Report(r.Context(), month, top)
+	if err != nil {
+		log.Printf("Failed to get API usage: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to get API usage"})
+		return
+	}
+
+	if format == "csv" {
+		var body bytes.Buffer
+		if err := analytics.WriteCSV(&body, report); err != nil {
+			log.Printf("Failed to render API usage: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to render API usage"})
+			return
+		}
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="api-usage-%s.csv"`, month))
+		w.WriteHeader(http.StatusOK)
+		w.Write(body.Bytes())
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(report)
+}
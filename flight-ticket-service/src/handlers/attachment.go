@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/services"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type AttachmentHandler struct {
+	repository services.TicketRepository
+	storage    *services.AttachmentStorage
+}
+
+func NewAttachmentHandler(repository services.TicketRepository, storage *services.AttachmentStorage) *AttachmentHandler {
+	return &AttachmentHandler{
+		repository: repository,
+		storage:    storage,
+	}
+}
+
+// attachmentRepository returns the attachment store, writing an error response when unavailable
+func (h *AttachmentHandler) attachmentRepository(w http.ResponseWriter) (services.AttachmentRepository, bool) {
+	attachments, ok := services.Capability[services.AttachmentRepository](h.repository)
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotImplemented)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Attachments are not supported by the configured storage backend"})
+		return nil, false
+	}
+	return attachments, true
+}
+
+// requireTicket checks that the ticket in the URL exists, writing an error response otherwise
+func (h *AttachmentHandler) requireTicket(w http.ResponseWriter, r *http.Request) (string, bool) {
+	confirmationID := chi.URLParam(r, "confirmationID")
+	if confirmationID == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Confirmation ID is required"})
+		return "", false
+	}
+
+	if _, err := h.repository.GetTicket(r.Context(), confirmationID); err != nil {
+		log.Printf("Failed to get ticket %s: %v", confirmationID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Ticket not found"})
+		return "", false
+	}
+
+	return confirmationID, true
+}
+
+// CreateAttachment handles POST /ticket/{confirmationID}/attachments
+// @Summary Attach a document to a ticket
+// @Description Register a document (visa scan, receipt, ...) and get a signed Cloud Storage URL to upload it with PUT. The upload must send the returned headers; documents are limited to 10 MiB.
+// @Tags attachments
+// @Accept json
+// @Produce json
+// @Param confirmationID path string true "Ticket confirmation ID" example("ABC123")
+// @Param attachment body models.CreateAttachmentRequest true "Attachment details"
+// @Success 201 {object} models.AttachmentUploadResponse "Attachment created with upload URL"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Ticket not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 501 {object} models.ErrorResponse "Attachments not supported by storage backend"
+// @Router /ticket/{confirmationID}/attachments [post]
+func (h *AttachmentHandler) CreateAttachment(w http.ResponseWriter, r *http.Request) {
+	attachments, ok := h.attachmentRepository(w)
+	if !ok {
+		return
+	}
+
+	confirmationID, ok := h.requireTicket(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.CreateAttachmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid JSON payload"})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid attachment", Message: err.Error()})
+		return
+	}
+
+	attachment, err := services.NewAttachment(confirmationID, &req)
+	if err != nil {
+		log.Printf("Failed to create attachment: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to create attachment"})
+		return
+	}
+
+	uploadURL, headers, expiresAt, err := h.storage.UploadURL(attachment)
+	if err != nil {
+		log.Printf("Failed to sign upload URL for ticket %s: %v", confirmationID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to create upload URL"})
+		return
+	}
+
+	if err := attachments.CreateAttachment(r.Context(), attachment); err != nil {
+		log.Printf("Failed to save attachment for ticket %s: %v", confirmationID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to create attachment"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(models.AttachmentUploadResponse{
+		Attachment:    attachment,
+		UploadURL:     uploadURL,
+		UploadMethod:  http.MethodPut,
+		UploadHeaders: headers,
+		ExpiresAt:     expiresAt,
+	})
+}
+
+// ListAttachments handles GET /ticket/{confirmationID}/attachments
+// @Summary List ticket attachments
+// @Description List the documents attached to a ticket with signed download URLs
+// @Tags attachments
+// @Accept json
+// @Produce json
+// @Param confirmationID path string true "Ticket confirmation ID" example("ABC123")
+// @Success 200 {object} models.AttachmentListResponse "Attachments with download URLs"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Ticket not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 501 {object} models.ErrorResponse "Attachments not supported by storage backend"
+// @Router /ticket/{confirmationID}/attachments [get]
+func (h *AttachmentHandler) ListAttachments(w http.ResponseWriter, r *http.Request) {
+	attachments, ok := h.attachmentRepository(w)
+	if !ok {
+		return
+	}
+
+	confirmationID, ok := h.requireTicket(w, r)
+	if !ok {
+		return
+	}
+
+	items, err := attachments.ListAttachments(r.Context(), confirmationID)
+	if err != nil {
+		log.Printf("Failed to list attachments for ticket %s: %v", confirmationID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to retrieve attachments"})
+		return
+	}
+
+	response := models.AttachmentListResponse{Attachments: []models.AttachmentDownload{}}
+	for _, attachment := range items {
+		download, err := h.download(attachment)
+		if err != nil {
+			log.Printf("Failed to sign download URL for attachment %s: %v", attachment.ID, err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to create download URL"})
+			return
+		}
+		response.Attachments = append(response.Attachments, *download)
+	}
+	response.Count = len(response.Attachments)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetAttachment handles GET /ticket/{confirmationID}/attachments/{attachmentID}
+// @Summary Get a ticket attachment
+// @Description Get a single attachment with a signed download URL
+// @Tags attachments
+// @Accept json
+// @Produce json
+// @Param confirmationID path string true "Ticket confirmation ID" example("ABC123")
+// @Param attachmentID path string true "Attachment ID" example("3f9c2a7e41b8d05c")
+// @Success 200 {object} models.AttachmentDownload "Attachment with download URL"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Ticket or attachment not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 501 {object} models.ErrorResponse "Attachments not supported by storage backend"
+// @Router /ticket/{confirmationID}/attachments/{attachmentID} [get]
+func (h *AttachmentHandler) GetAttachment(w http.ResponseWriter, r *http.Request) {
+	attachments, ok := h.attachmentRepository(w)
+	if !ok {
+		return
+	}
+
+	confirmationID, ok := h.requireTicket(w, r)
+	if !ok {
+		return
+	}
+
+	attachmentID := chi.URLParam(r, "attachmentID")
+	attachment, err := attachments.GetAttachment(r.Context(), confirmationID, attachmentID)
+	if err != nil {
+		log.Printf("Failed to get attachment %s for ticket %s: %v", attachmentID, confirmationID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Attachment not found"})
+		return
+	}
+
+	download, err := h.download(attachment)
+	if err != nil {
+		log.Printf("Failed to sign download URL for attachment %s: %v", attachment.ID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to create download URL"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(download)
+}
+
+func (h *AttachmentHandler) download(attachment *models.Attachment) (*models.AttachmentDownload, error) {
+	downloadURL, expiresAt, err := h.storage.DownloadURL(attachment)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AttachmentDownload{
+		Attachment:  *attachment,
+		DownloadURL: downloadURL,
+		ExpiresAt:   expiresAt,
+	}, nil
+}
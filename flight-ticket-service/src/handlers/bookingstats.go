@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/services"
+)
+
+type BookingStatsHandler struct {
+	bookings *services.BookingStats
+}
+
+func NewBookingStatsHandler(repository services.TicketRepository) *BookingStatsHandler {
+	return &BookingStatsHandler{
+		bookings: services.NewBookingStats(repository),
+	}
+}
+
+// GetBookingStats handles GET /admin/stats/bookings
+// @Summary Get booking statistics
+// @Description Tickets booked overall, and per day and route over a date range, read from sharded counters instead of aggregating the tickets. Days are UTC booking dates. Requires an admin API key.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param from query string false "First day (YYYY-MM-DD), default six days before to" example(2024-07-06)
+// @Param to query string false "Last day (YYYY-MM-DD), default today" example(2024-07-12)
+// @Success 200 {object} models.BookingStats "Booking statistics"
+// @Failure 400 {object} models.ErrorResponse "Invalid date range"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 501 {object} models.ErrorResponse "Booking counters not supported by storage backend"
+// @Router /admin/stats/bookings [get]
+func (h *BookingStatsHandler) GetBookingStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if !h.bookings.Enabled() {
+		w.WriteHeader(http.StatusNotImplemented)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Booking counters are not supported by the configured storage backend"})
+		return
+	}
+
+	now := time.Now().UTC()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if value := r.URL.Query().Get("to"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid to date", Message: "Use YYYY-MM-DD format"})
+			return
+		}
+		to = parsed
+	}
+	from := to.AddDate(0, 0, -6)
+	if value := r.URL.Query().Get("from"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid from date", Message: "Use YYYY-MM-DD format"})
+			return
+		}
+		from = parsed
+	}
+	if from.After(to) || to.Sub(from) >= services.MaxBookingStatsDays*24*time.Hour {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Error:   "Invalid date range",
+			Message: fmt.Sprintf("from must not be after to, and the range may cover at most %d days", services.MaxBookingStatsDays),
+		})
+		return
+	}
+
+	stats, err := h.bookings.Stats(r.Context(), from, to)
+	if err != nil {
+		log.Printf("Failed to get booking statistics: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to get booking statistics"})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(stats)
+}
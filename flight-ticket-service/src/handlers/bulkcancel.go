@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"flight-ticket-service/src/jobs"
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/services"
+)
+
+// BulkCancelHandler previews and runs bulk cancellations of tickets
+type BulkCancelHandler struct {
+	repository services.TicketRepository
+	manager    *jobs.Manager
+}
+
+func NewBulkCancelHandler(repository services.TicketRepository, manager *jobs.Manager) *BulkCancelHandler {
+	return &BulkCancelHandler{
+		repository: repository,
+		manager:    manager,
+	}
+}
+
+// BulkCancel handles POST /tickets/bulk-cancel
+// @Summary Cancel tickets in bulk
+// @Description Cancel the tickets of a flight, departure date and optionally status. The request is a dry run unless dry_run is false: it lists the tickets that would be cancelled and returns a preview_token. Send the same filter with dry_run false and that preview_token to cancel exactly those tickets; if the matching tickets changed since the dry run the request fails with 409 and must be previewed again. The cancellation runs as a bulk_cancel job in batches of 50 tickets; poll the returned job for its progress. Requires an admin API key.
+// @Tags tickets
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param request body models.BulkCancelRequest true "Bulk cancellation"
+// @Success 200 {object} models.BulkCancelPreview "Dry run"
+// @Success 202 {object} models.Job "Cancellation queued"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 409 {object} models.ErrorResponse "Tickets changed since the dry run"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /tickets/bulk-cancel [post]
+func (h *BulkCancelHandler) BulkCancel(w http.ResponseWriter, r *http.Request) {
+	var req models.BulkCancelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid JSON payload"})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid bulk cancellation", Message: err.Error()})
+		return
+	}
+
+	query, err := req.Query(time.Now())
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid filter", Message: err.Error()})
+		return
+	}
+
+	tickets, token, err := services.PreviewBulkCancel(r.Context(), h.repository, query)
+	if err != nil {
+		log.Printf("Failed to preview bulk cancellation: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to retrieve tickets"})
+		return
+	}
+
+	filter := models.BulkCancelFilterOf(query)
+	if req.IsDryRun() {
+		preview := models.BulkCancelPreview{
+			DryRun:       true,
+			Filter:       filter,
+			Matched:      len(tickets),
+			Tickets:      make([]models.BulkCancelTicket, len(tickets)),
+			PreviewToken: token,
+		}
+		for i, ticket := range tickets {
+			preview.Passengers += ticket.Passengers
+			preview.Tickets[i] = models.BulkCancelTicket{
+				ConfirmationID: ticket.ConfirmationID,
+				FlightNumber:   ticket.FlightNumber,
+				DepartureDate:  ticket.DepartureDate,
+				Status:         ticket.Status,
+				Passengers:     ticket.Passengers,
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(preview)
+		return
+	}
+
+	if req.PreviewToken != token {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Tickets changed since the dry run", Message: "Preview the cancellation again and confirm the new preview_token"})
+		return
+	}
+
+	confirmationIDs := make([]string, len(tickets))
+	for i, ticket := range tickets {
+		confirmationIDs[i] = ticket.ConfirmationID
+	}
+	params := map[string]interface{}{
+		"flight_number":    filter.FlightNumber,
+		"departure_date":   filter.DepartureDate,
+		"status":           filter.Status,
+		"confirmation_ids": confirmationIDs,
+		"requested_by":     requestActor(r),
+	}
+	job, err := h.manager.Submit(r.Context(), "bulk_cancel", params, req.CallbackURL)
+	if errors.Is(err, jobs.ErrInvalid) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid bulk cancellation", Message: err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to submit bulk cancellation: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to submit bulk cancellation"})
+		return
+	}
+
+	log.Printf("Bulk cancellation of %d tickets queued by %s as job %s", len(confirmationIDs), requestActor(r), job.ID)
+	writeJobAccepted(w, job)
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"flight-ticket-service/src/debuglog"
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/services"
+)
+
+// ticketCacheMaxAge is how long browsers and CDNs may reuse a ticket read without revalidating
+const ticketCacheMaxAge = time.Minute
+
+// writeCached writes a GET response with Cache-Control, Last-Modified and
+// ETag headers, and answers 304 Not Modified when the client's copy is
+// current. The ETag hashes the encoded body, so it changes with the encoding,
+// display currency and schedule as well as with the stored ticket. Shared
+// caches may only store public responses; responses to authenticated callers,
+// which can carry internal notes, are private.
+func writeCached(w http.ResponseWriter, r *http.Request, lastModified time.Time, public bool, contentType string, body []byte) {
+	sum := sha256.Sum256(append([]byte(contentType+"\n"), body...))
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	header := w.Header()
+	header.Set("ETag", etag)
+	if !lastModified.IsZero() {
+		header.Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	if public {
+		header.Set("Cache-Control", "public, max-age="+strconv.Itoa(int(ticketCacheMaxAge.Seconds())))
+	} else {
+		header.Set("Cache-Control", "private, no-cache")
+	}
+	header.Add("Vary", "Accept")
+	header.Add("Vary", "Authorization, X-API-Key")
+
+	if notModified(r, etag, lastModified) {
+		debuglog.Printf(r.Context(), "Client copy is current (ETag %s); answering 304", etag)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	header.Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// writeCachedValue encodes v in the format negotiated for the request and writes it with writeCached
+func (h *TicketHandler) writeCachedValue(w http.ResponseWriter, r *http.Request, lastModified time.Time, public bool, v interface{}) {
+	contentType, body, err := h.encoders.Encode(r, v)
+	if err != nil {
+		log.Printf("Failed to encode response: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to encode response"})
+		return
+	}
+	writeCached(w, r, lastModified, public, contentType, body)
+}
+
+// notModified evaluates If-None-Match, or If-Modified-Since when no entity tags are sent (RFC 9110 13.2.2)
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(ims)
+		return err == nil && !lastModified.Truncate(time.Second).After(since)
+	}
+	return false
+}
+
+// redirectToDocument redirects to the signed URL of a stored document. The
+// redirect must not be cached past the expiry of the URL, so it is not cached
+// at all; the document behind it is immutable and cached by the CDN.
+func redirectToDocument(w http.ResponseWriter, r *http.Request, link *services.DocumentLink) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Document-Expires", link.ExpiresAt.UTC().Format(time.RFC3339))
+	if link.Cached {
+		w.Header().Set("X-Document-Cache", "HIT")
+	} else {
+		w.Header().Set("X-Document-Cache", "MISS")
+	}
+	http.Redirect(w, r, link.URL, http.StatusFound)
+}
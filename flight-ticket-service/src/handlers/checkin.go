@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"flight-ticket-service/src/bcbp"
+	"flight-ticket-service/src/entry"
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/scheduling"
+	"flight-ticket-service/src/services"
+	"flight-ticket-service/src/travelers"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type CheckInHandler struct {
+	repository services.TicketRepository
+	scheduler  *scheduling.Scheduler
+	travelers  travelers.Store
+	entryRules *entry.Table
+}
+
+func NewCheckInHandler(repository services.TicketRepository, scheduler *scheduling.Scheduler, travelerStore travelers.Store, entryRules *entry.Table) *CheckInHandler {
+	return &CheckInHandler{
+		repository: repository,
+		scheduler:  scheduler,
+		travelers:  travelerStore,
+		entryRules: entryRules,
+	}
+}
+
+// CheckIn handles POST /ticket/{confirmationID}/checkin
+// @Summary Check in passengers
+// @Description Check in passengers on a ticket and issue boarding passes with IATA BCBP (Bar Coded Boarding Pass) payloads. Sequence numbers follow the order of the passengers in the request. Check-in is only open within the window given by the ticket's schedule. The ticket's status becomes CHECKED_IN and the passengers are recorded for the departure manifest; checking in again replaces them. Passengers without a seat get the seat assigned to them at booking, in order, and upgraded tickets check in to their cabin unless a compartment is given. On international flights the passports of the ticket's saved travelers are checked again against the entry rules of the destination. Send X-Lock-Token when holding the ticket's edit lock.
+// @Tags tickets
+// @Accept json
+// @Produce json
+// @Param confirmationID path string true "Ticket confirmation ID" example("ABC123")
+// @Param checkin body models.CheckInRequest true "Passengers to check in"
+// @Param X-Lock-Token header string false "Token of the ticket's edit lock, required while it is locked"
+// @Success 200 {object} models.CheckInResponse "Boarding passes"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Ticket not found"
+// @Failure 409 {object} models.ErrorResponse "Ticket is cancelled or under review, or lock token not current"
+// @Failure 422 {object} models.ErrorResponse "Check-in is not open yet or has closed, or travel documents are invalid"
+// @Failure 423 {object} models.ErrorResponse "Ticket is locked by another caller"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /ticket/{confirmationID}/checkin [post]
+func (h *CheckInHandler) CheckIn(w http.ResponseWriter, r *http.Request) {
+	confirmationID := chi.URLParam(r, "confirmationID")
+	if confirmationID == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Confirmation ID is required"})
+		return
+	}
+
+	var req models.CheckInRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid JSON payload"})
+		return
+	}
+
+	ticket, err := h.repository.GetTicket(r.Context(), confirmationID)
+	if err != nil {
+		log.Printf("Failed to get ticket %s: %v", confirmationID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Ticket not found"})
+		return
+	}
+
+	if ticket.Status == "CANCELLED" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Ticket is cancelled"})
+		return
+	}
+	if ticket.Status == models.ReviewStatus {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Ticket is under review"})
+		return
+	}
+	if err := services.CheckLock(r.Context(), h.repository, confirmationID, r.Header.Get(LockTokenHeader)); err != nil {
+		writeLockError(w, err)
+		return
+	}
+
+	if err := h.scheduler.CheckInAllowed(ticket); err != nil {
+		message := "Check-in has closed"
+		if errors.Is(err, scheduling.ErrCheckInNotOpen) {
+			message = "Check-in is not open yet"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: message, Message: err.Error()})
+		return
+	}
+
+	// Upgraded tickets check in to their cabin unless another is asked for
+	if req.Compartment == "" {
+		if cabin := models.TicketCabin(ticket); cabin != models.CabinEconomy {
+			req.Compartment = cabin
+		}
+	}
+
+	// Passengers checking in without a seat keep the one assigned at booking
+	for i, seat := range models.TicketSeats(ticket) {
+		if i < len(req.Passengers) && req.Passengers[i].Seat == "" {
+			req.Passengers[i].Seat = seat
+		}
+	}
+
+	if err := req.Validate(ticket); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid check-in request", Message: err.Error()})
+		return
+	}
+
+	// Documents of saved travelers may have changed since the booking
+	list, err := ticketTravelers(r.Context(), h.travelers, ticket)
+	if err != nil {
+		log.Printf("Failed to get travelers of ticket %s: %v", confirmationID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to check travel documents"})
+		return
+	}
+	documentIssues, ok := checkTravelDocuments(w, r, h.repository, h.entryRules, ticket, list)
+	if !ok {
+		return
+	}
+
+	response := models.CheckInResponse{
+		ConfirmationID: ticket.ConfirmationID,
+		FlightNumber:   ticket.FlightNumber,
+		Origin:         ticket.Origin,
+		Destination:    ticket.Destination,
+		DepartureTime:  ticket.DepartureTime,
+		Schedule:       h.scheduler.Schedule(ticket),
+		Advisories:     h.entryRules.Advisories(ticket.Origin, ticket.Destination),
+		DocumentIssues: documentIssues,
+	}
+
+	for i, passenger := range req.Passengers {
+		pass := ticketBoardingPass(ticket, passenger, req.Compartment, i+1)
+		payload, err := pass.Encode()
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid check-in request", Message: err.Error()})
+			return
+		}
+
+		response.BoardingPasses = append(response.BoardingPasses, models.BoardingPass{
+			PassengerName:  bcbp.FormatName(passenger.LastName, passenger.FirstName),
+			Seat:           passenger.Seat,
+			SequenceNumber: i + 1,
+			BCBP:           payload,
+			QRURL:          boardingPassQRURL(ticket.ConfirmationID, i+1),
+		})
+	}
+
+	// Record the passengers for the departure manifest
+	record := &models.CheckInRecord{
+		Compartment: strings.ToUpper(req.Compartment),
+		CheckedInAt: time.Now().UTC(),
+	}
+	if record.Compartment == "" {
+		record.Compartment = "Y"
+	}
+	for _, pass := range response.BoardingPasses {
+		record.Passengers = append(record.Passengers, models.CheckedInPassenger{
+			PassengerName:  pass.PassengerName,
+			Seat:           strings.ToUpper(pass.Seat),
+			SequenceNumber: pass.SequenceNumber,
+		})
+	}
+	updates := map[string]interface{}{
+		"status":   services.StatusCheckedIn,
+		"check_in": record,
+	}
+	if err := h.repository.UpdateTicket(r.Context(), ticket.ConfirmationID, updates); err != nil {
+		log.Printf("Failed to record check-in of ticket %s: %v", ticket.ConfirmationID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to check in"})
+		return
+	}
+
+	log.Printf("Checked in %d passengers on ticket %s", len(response.BoardingPasses), ticket.ConfirmationID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// ticketBoardingPass builds the BCBP data for a passenger on a ticket
+func ticketBoardingPass(ticket *models.FlightTicket, passenger models.CheckInPassenger, compartment string, sequence int) *bcbp.BoardingPass {
+	return &bcbp.BoardingPass{
+		LastName:       passenger.LastName,
+		FirstName:      passenger.FirstName,
+		PNR:            ticket.ConfirmationID,
+		From:           ticket.Origin,
+		To:             ticket.Destination,
+		FlightNumber:   ticket.FlightNumber,
+		DepartureDate:  ticket.DepartureDate,
+		Compartment:    compartment,
+		Seat:           passenger.Seat,
+		SequenceNumber: sequence,
+	}
+}
+
+// checkedInPassenger returns the passenger of a check-in record with a
+// sequence number, named as on their boarding pass
+func checkedInPassenger(record *models.CheckInRecord, sequence int) (models.CheckInPassenger, bool) {
+	for _, passenger := range record.Passengers {
+		if passenger.SequenceNumber == sequence {
+			lastName, firstName, _ := strings.Cut(passenger.PassengerName, "/")
+			return models.CheckInPassenger{FirstName: firstName, LastName: lastName, Seat: passenger.Seat}, true
+		}
+	}
+	return models.CheckInPassenger{}, false
+}
+
+// boardingPassQRURL returns the QR endpoint URL rendering the BCBP payload of
+// a checked-in passenger
+func boardingPassQRURL(confirmationID string, sequence int) string {
+	query := url.Values{
+		"payload":  {"bcbp"},
+		"sequence": {strconv.Itoa(sequence)},
+	}
+	return fmt.Sprintf("/ticket/%s/qr?%s", url.PathEscape(confirmationID), query.Encode())
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"flight-ticket-service/src/liveconfig"
+)
+
+// ConfigHandler reports the settings reloaded at runtime
+type ConfigHandler struct {
+	watcher *liveconfig.Watcher
+}
+
+func NewConfigHandler(watcher *liveconfig.Watcher) *ConfigHandler {
+	return &ConfigHandler{watcher: watcher}
+}
+
+// GetConfig handles GET /admin/config
+// @Summary Get reloadable settings
+// @Description Current value of each setting that is reloaded without a restart (LOG_LEVEL, BOOKING_QUOTA, BOOKING_QUOTA_OVERRIDES, FEATURE_FLAGS and CORS_ALLOWED_ORIGINS), the config source, and the audit log of recent changes: applied, rejected as invalid, or ignored as not reloadable. Each instance reports its own state. Requires an admin API key.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Success 200 {object} liveconfig.State "Reloadable settings"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Router /admin/config [get]
+func (h *ConfigHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(h.watcher.State())
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"flight-ticket-service/src/changefeed"
+	"flight-ticket-service/src/maintenance"
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/scheduling"
+	"flight-ticket-service/src/services"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type FlightDelayHandler struct {
+	jobs        *services.TicketJobs
+	scheduler   *scheduling.Scheduler
+	maintenance *maintenance.Switch
+	events      *changefeed.Fanout // nil when the change feed service publishes Firestore changes
+}
+
+func NewFlightDelayHandler(repository services.TicketRepository, scheduler *scheduling.Scheduler, maintenanceSwitch *maintenance.Switch, events *changefeed.Fanout) *FlightDelayHandler {
+	return &FlightDelayHandler{
+		jobs:        services.NewTicketJobs(repository),
+		scheduler:   scheduler,
+		maintenance: maintenanceSwitch,
+		events:      events,
+	}
+}
+
+// DelayFlight handles POST /admin/flights/{flightNumber}/{date}/delay
+// @Summary Simulate a flight delay
+// @Description Push back the departure of every ticket on a flight, for demos of the event-driven features. Each ticket's update produces a change event, so webhooks and traveller notifications fire as for a real delay. With disrupt, confirmed and checked-in tickets also become DISRUPTED, and their travellers can pick another flight from the ticket's rebooking options. Requires an admin API key.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param flightNumber path string true "Flight number" example("AA1234")
+// @Param date path string true "Scheduled departure date in YYYY-MM-DD format" example("2024-12-25")
+// @Param delay body models.FlightDelayRequest true "Delay"
+// @Success 200 {object} models.FlightDelayResponse "Delayed tickets"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} models.ErrorResponse "No tickets on the flight"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 503 {object} models.ErrorResponse "Service under maintenance"
+// @Router /admin/flights/{flightNumber}/{date}/delay [post]
+func (h *FlightDelayHandler) DelayFlight(w http.ResponseWriter, r *http.Request) {
+	// Admin routes stay open during maintenance, but this one writes tickets
+	if h.maintenance.Status().Mode != maintenance.ModeOff {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Service under maintenance", Message: "Flight delays cannot be simulated during maintenance"})
+		return
+	}
+
+	flightNumber := strings.ToUpper(chi.URLParam(r, "flightNumber"))
+	date, err := time.Parse("2006-01-02", chi.URLParam(r, "date"))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid date format", Message: "Use YYYY-MM-DD format"})
+		return
+	}
+
+	var req models.FlightDelayRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid JSON payload"})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid delay", Message: err.Error()})
+		return
+	}
+
+	result, err := h.jobs.DelayFlight(r.Context(), flightNumber, date, time.Duration(req.DelayMinutes)*time.Minute, req.Disrupt)
+	if err != nil {
+		log.Printf("Failed to delay flight %s: %v", flightNumber, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to delay flight"})
+		return
+	}
+	if len(result.Delayed) == 0 && result.Failed == 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "No tickets on the flight"})
+		return
+	}
+
+	response := models.FlightDelayResponse{
+		FlightNumber: flightNumber,
+		Date:         date.Format("2006-01-02"),
+		DelayMinutes: req.DelayMinutes,
+		Tickets:      []*models.FlightTicket{},
+		Failed:       result.Failed,
+	}
+	changed := []string{"departure_time", "updated_at"}
+	if req.Disrupt {
+		changed = []string{"departure_time", "status", "updated_at"}
+	}
+	for _, delayed := range result.Delayed {
+		if h.events != nil {
+			event, err := changefeed.NewUpdateEvent(delayed.Previous, delayed.Current, changed)
+			if err == nil {
+				err = h.events.Publish(r.Context(), event)
+			}
+			if err != nil {
+				log.Printf("Failed to publish delay of ticket %s: %v", delayed.Current.ConfirmationID, err)
+			} else {
+				response.EventsPublished++
+			}
+		}
+
+		delayed.Current.Schedule = h.scheduler.Schedule(delayed.Current)
+		response.Tickets = append(response.Tickets, delayed.Current)
+	}
+
+	log.Printf("Delayed flight %s on %s by %d minutes: %d tickets updated, %d failed",
+		flightNumber, response.Date, req.DelayMinutes, len(result.Delayed), result.Failed)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
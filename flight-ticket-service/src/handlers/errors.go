@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"flight-ticket-service/src/models"
+)
+
+// NotFound answers unknown routes with a JSON error like the API handlers
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Not found", Message: r.Method + " " + r.URL.Path + " does not exist"})
+}
+
+// MethodNotAllowed answers unsupported methods with a JSON error like the API handlers
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusMethodNotAllowed)
+	json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Method not allowed", Message: r.Method + " is not supported for " + r.URL.Path})
+}
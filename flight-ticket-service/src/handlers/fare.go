@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/pricing"
+)
+
+type FareHandler struct {
+	calendar *pricing.Calendar
+}
+
+func NewFareHandler(calendar *pricing.Calendar) *FareHandler {
+	return &FareHandler{calendar: calendar}
+}
+
+// GetFareCalendar handles GET /fares/calendar
+// @Summary Get the lowest fare of each day of a month
+// @Description Get the lowest per-passenger fare of each day of a month from today on, for finding the cheapest day to fly. Fares are computed from the distance between the airports, the day of the week, the hour of departure (UTC) and how far ahead the flight is booked; each day gives the fare of its cheapest hourly departure. Calendars are cached for FARES_CACHE_TTL, and at most until the end of the UTC day. Fares in USD can be passed as base_fare when booking to be charged them.
+// @Tags fares
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param origin query string true "Origin airport code" example(JFK)
+// @Param destination query string true "Destination airport code" example(LAX)
+// @Param month query string true "Month (YYYY-MM), from the current one up to 12 months ahead" example(2025-08)
+// @Param currency query string false "ISO 4217 currency code for the fares" example(EUR)
+// @Success 200 {object} models.FareCalendarResponse "Lowest fare per day"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 503 {object} models.ErrorResponse "Exchange rates unavailable"
+// @Router /fares/calendar [get]
+func (h *FareHandler) GetFareCalendar(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	origin := strings.ToUpper(strings.TrimSpace(query.Get("origin")))
+	destination := strings.ToUpper(strings.TrimSpace(query.Get("destination")))
+	if !models.ValidateAirportCode(origin) || !models.ValidateAirportCode(destination) || origin == destination {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Error:   "Invalid airport codes",
+			Message: "origin and destination must be different 3-letter IATA codes",
+		})
+		return
+	}
+	month, err := time.Parse("2006-01", query.Get("month"))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid month", Message: "Use YYYY-MM format"})
+		return
+	}
+
+	calendar, err := h.calendar.Month(r.Context(), origin, destination, month, query.Get("currency"))
+	if errors.Is(err, pricing.ErrMonthOutOfRange) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Error:   "Month out of range",
+			Message: fmt.Sprintf("month must be the current month or one of the next %d", pricing.MaxMonthsAhead),
+		})
+		return
+	}
+	if err != nil {
+		writeCurrencyError(w, err)
+		return
+	}
+
+	maxAge := int(time.Until(calendar.ExpiresAt).Seconds())
+	if maxAge < 0 {
+		maxAge = 0
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", maxAge))
+	json.NewEncoder(w).Encode(calendar)
+}
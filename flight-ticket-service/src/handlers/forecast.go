@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"flight-ticket-service/src/forecast"
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/services"
+)
+
+// Limits of the GET /admin/forecast query parameters
+const (
+	defaultForecastHistory = 28
+	minForecastHistory     = 7
+	defaultForecastHorizon = 7
+	maxForecastHorizon     = 14
+	defaultForecastRoutes  = 10
+	maxForecastRoutes      = 50
+)
+
+type ForecastHandler struct {
+	bookings   *services.BookingStats
+	forecaster forecast.Forecaster
+}
+
+func NewForecastHandler(repository services.TicketRepository, forecaster forecast.Forecaster) *ForecastHandler {
+	return &ForecastHandler{
+		bookings:   services.NewBookingStats(repository),
+		forecaster: forecaster,
+	}
+}
+
+// GetForecast handles GET /admin/forecast
+// @Summary Get a booking demand forecast
+// @Description Forecast the bookings per day of the most booked routes from their booking counters over the last complete days. With FORECAST_PROVIDER=vertex the forecast comes from a model on a Vertex AI endpoint; the static provider computes a deterministic baseline offline. Days are UTC booking dates. Requires an admin API key.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param history query int false "Days of booking history, ending yesterday" default(28) minimum(7) maximum(31)
+// @Param horizon query int false "Days to forecast, starting today" default(7) minimum(1) maximum(14)
+// @Param route query string false "Only forecast this route" example(JFK-LAX)
+// @Param limit query int false "Most booked routes to forecast" default(10) minimum(1) maximum(50)
+// @Success 200 {object} models.DemandForecast "Demand forecast"
+// @Failure 400 {object} models.ErrorResponse "Invalid history, horizon, route or limit"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 501 {object} models.ErrorResponse "Booking counters not supported by storage backend"
+// @Failure 502 {object} models.ErrorResponse "Forecast model failed"
+// @Router /admin/forecast [get]
+func (h *ForecastHandler) GetForecast(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if !h.bookings.Enabled() {
+		w.WriteHeader(http.StatusNotImplemented)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Booking counters are not supported by the configured storage backend"})
+		return
+	}
+
+	query := r.URL.Query()
+	history, ok := intParam(w, query.Get("history"), "history", defaultForecastHistory, minForecastHistory, services.MaxBookingStatsDays)
+	if !ok {
+		return
+	}
+	horizon, ok := intParam(w, query.Get("horizon"), "horizon", defaultForecastHorizon, 1, maxForecastHorizon)
+	if !ok {
+		return
+	}
+	limit, ok := intParam(w, query.Get("limit"), "limit", defaultForecastRoutes, 1, maxForecastRoutes)
+	if !ok {
+		return
+	}
+	route := strings.ToUpper(strings.TrimSpace(query.Get("route")))
+	if route != "" && !isRoute(route) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid route", Message: "Use ORIGIN-DESTINATION IATA codes, e.g. JFK-LAX"})
+		return
+	}
+
+	// Today is not over, so the history ends yesterday
+	now := time.Now().UTC()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -1)
+	from := to.AddDate(0, 0, 1-history)
+	stats, err := h.bookings.Stats(r.Context(), from, to)
+	if err != nil {
+		log.Printf("Failed to get booking history: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to get booking history"})
+		return
+	}
+
+	series := routeSeries(stats, from, route, limit)
+	points, err := h.forecaster.Forecast(r.Context(), series, horizon)
+	if err != nil {
+		log.Printf("Failed to forecast demand with %s: %v", h.forecaster.Name(), err)
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Forecast model failed", Message: fmt.Sprintf("The %s forecast provider did not answer; try again later", h.forecaster.Name())})
+		return
+	}
+
+	result := models.DemandForecast{
+		Provider:    h.forecaster.Name(),
+		Model:       h.forecaster.Model(),
+		HistoryFrom: stats.From,
+		HistoryTo:   stats.To,
+		Horizon:     horizon,
+		GeneratedAt: now,
+		Routes:      make([]models.RouteForecast, len(series)),
+	}
+	for i, s := range series {
+		days := make([]models.ForecastDay, len(points[i]))
+		for day, point := range points[i] {
+			days[day] = models.ForecastDay{
+				Date:     to.AddDate(0, 0, day+1).Format("2006-01-02"),
+				Bookings: point.Bookings,
+				Lower:    point.Lower,
+				Upper:    point.Upper,
+			}
+		}
+		result.Routes[i] = models.RouteForecast{Route: s.Route, History: s.Counts, Forecast: days}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+// routeSeries returns the daily bookings of the most booked routes, or of one route
+func routeSeries(stats *models.BookingStats, from time.Time, route string, limit int) []forecast.Series {
+	counts := make(map[string][]int64)
+	totals := make(map[string]int64)
+	for i, day := range stats.Days {
+		for _, booked := range day.Routes {
+			if route != "" && booked.Route != route {
+				continue
+			}
+			if counts[booked.Route] == nil {
+				counts[booked.Route] = make([]int64, len(stats.Days))
+			}
+			counts[booked.Route][i] = booked.Bookings
+			totals[booked.Route] += booked.Bookings
+		}
+	}
+	if route != "" && counts[route] == nil {
+		// A route without bookings is forecast from a history of zeros
+		counts[route] = make([]int64, len(stats.Days))
+	}
+
+	routes := make([]string, 0, len(counts))
+	for name := range counts {
+		routes = append(routes, name)
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if totals[routes[i]] != totals[routes[j]] {
+			return totals[routes[i]] > totals[routes[j]]
+		}
+		return routes[i] < routes[j]
+	})
+	if len(routes) > limit {
+		routes = routes[:limit]
+	}
+
+	series := make([]forecast.Series, len(routes))
+	for i, name := range routes {
+		series[i] = forecast.Series{Route: name, Start: from, Counts: counts[name]}
+	}
+	return series
+}
+
+// intParam parses an optional integer query parameter within bounds, writing a 400 response when it is not
+func intParam(w http.ResponseWriter, value, name string, fallback, min, max int) (int, bool) {
+	if value == "" {
+		return fallback, true
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < min || parsed > max {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid " + name, Message: fmt.Sprintf("%s must be between %d and %d", name, min, max)})
+		return 0, false
+	}
+	return parsed, true
+}
+
+// isRoute reports whether a route is two airport codes joined by a dash
+func isRoute(route string) bool {
+	origin, destination, ok := strings.Cut(route, "-")
+	return ok && models.ValidateAirportCode(origin) && models.ValidateAirportCode(destination)
+}
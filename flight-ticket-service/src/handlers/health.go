@@ -4,13 +4,19 @@ import (
 	"encoding/json"
 	"net/http"
 	"time"
+
+	"flight-ticket-service/src/health"
+	"flight-ticket-service/src/version"
 )
 
 // HealthResponse represents the health check response
 type HealthResponse struct {
 	Status    string    `json:"status" example:"healthy" description:"Service health status"`
 	Timestamp time.Time `json:"timestamp" example:"2024-07-13T05:00:00Z" description:"Health check timestamp"`
-	Version   string    `json:"version" example:"1.0.0" description:"API version"`
+	Version   string    `json:"version" example:"v1.2.0" description:"Release version"`
+	Commit    string    `json:"commit" example:"5b6962d" description:"Git commit the binary was built from"`
+	BuildTime string    `json:"build_time" example:"2024-07-12T19:00:00Z" description:"UTC build time"`
+	GoVersion string    `json:"go_version" example:"go1.24.5" description:"Go toolchain version"`
 	Service   string    `json:"service" example:"flight-ticket-service" description:"Service name"`
 }
 
@@ -23,10 +29,14 @@ type HealthResponse struct {
 // @Success 200 {object} HealthResponse "Service is healthy"
 // @Router /health [get]
 func HealthCheck(w http.ResponseWriter, r *http.Request) {
+	build := version.Get()
 	response := HealthResponse{
 		Status:    "healthy",
 		Timestamp: time.Now(),
-		Version:   "1.0.0",
+		Version:   build.Version,
+		Commit:    build.Commit,
+		BuildTime: build.BuildTime,
+		GoVersion: build.GoVersion,
 		Service:   "flight-ticket-service",
 	}
 
@@ -34,3 +44,42 @@ func HealthCheck(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(response)
 }
+
+// GetVersion handles GET /version
+// @Summary Version information
+// @Description Get the release version, git commit, build time and Go version of the running binary
+// @Tags health
+// @Produce json
+// @Success 200 {object} version.Info "Build information"
+// @Router /version [get]
+func GetVersion(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(version.Get())
+}
+
+// HealthHandler reports the recent health of the service's dependencies
+type HealthHandler struct {
+	tracker *health.Tracker
+}
+
+func NewHealthHandler(tracker *health.Tracker) *HealthHandler {
+	return &HealthHandler{tracker: tracker}
+}
+
+// GetHealthDetails handles GET /health/details
+// @Summary Dependency health
+// @Description Recent check results of each dependency of this instance: the ticket storage, checked periodically, and the weather and exchange rate providers and change event sinks, as the service calls them. Each dependency reports its status, the time of its last success and failure, and its error rate over its last results. The status is degraded when a dependency's last result failed. Admin only.
+// @Tags health
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Success 200 {object} health.Details "Dependency health"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Router /health/details [get]
+func (h *HealthHandler) GetHealthDetails(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(h.tracker.Details())
+}
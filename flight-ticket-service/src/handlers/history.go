@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/services"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// GetTicketHistoryDiff handles GET /ticket/{confirmationID}/history/diff
+// @Summary Compare two revisions of a ticket
+// @Description Return the fields that changed between two revisions of a ticket's history, for dispute resolution. Revisions are numbered from 1 for the oldest; 0 is the ticket before its first revision. Without to, the latest revision is used, and without from, the revision before to, so a bare request shows the last change. Nested fields such as price.amount and labels.campaign are compared one by one, lists as a whole.
+// @Tags tickets
+// @Produce json
+// @Param confirmationID path string true "Ticket confirmation ID" example("ABC123")
+// @Param from query int false "Revision to compare from" minimum(0) example(3)
+// @Param to query int false "Revision to compare to" minimum(0) example(5)
+// @Success 200 {object} models.TicketDiff "Changed fields"
+// @Failure 400 {object} models.ErrorResponse "Invalid from or to"
+// @Failure 404 {object} models.ErrorResponse "Ticket or revision not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 501 {object} models.ErrorResponse "Ticket history not supported by storage backend"
+// @Router /ticket/{confirmationID}/history/diff [get]
+func (h *TicketHandler) GetTicketHistoryDiff(w http.ResponseWriter, r *http.Request) {
+	confirmationID := chi.URLParam(r, "confirmationID")
+	w.Header().Set("Content-Type", "application/json")
+	query := r.URL.Query()
+	from, ok := revisionParam(w, query.Get("from"), "from")
+	if !ok {
+		return
+	}
+	to, ok := revisionParam(w, query.Get("to"), "to")
+	if !ok {
+		return
+	}
+
+	_, revisions, ok := h.ticketRevisions(w, r, confirmationID)
+	if !ok {
+		return
+	}
+	if to < 0 {
+		to = len(revisions)
+	}
+	if from < 0 {
+		from = max(to-1, 0)
+	}
+
+	if len(revisions) == 0 || from > len(revisions) || to > len(revisions) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Error:   "Revision not found",
+			Message: fmt.Sprintf("the ticket has %d revisions", len(revisions)),
+		})
+		return
+	}
+
+	before, _ := services.RevisionTicket(revisions, from)
+	after, _ := services.RevisionTicket(revisions, to)
+	changes, err := services.DiffTickets(before, after)
+	if err != nil {
+		log.Printf("Failed to compare revisions of ticket %s: %v", confirmationID, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to compare revisions"})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.TicketDiff{
+		ConfirmationID: confirmationID,
+		From:           revisionSummary(revisions, from),
+		To:             revisionSummary(revisions, to),
+		Changes:        changes,
+		Count:          len(changes),
+	})
+}
+
+// ticketRevisions returns an existing ticket and its history, oldest first,
+// writing an error response when it cannot
+func (h *TicketHandler) ticketRevisions(w http.ResponseWriter, r *http.Request, confirmationID string) (*models.FlightTicket, []*models.TicketRevision, bool) {
+	history, ok := services.Capability[services.TicketHistory](h.repository)
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotImplemented)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Ticket history is not supported by the configured storage backend"})
+		return nil, nil, false
+	}
+	ticket, err := h.repository.GetTicket(r.Context(), confirmationID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Ticket not found"})
+		return nil, nil, false
+	}
+
+	revisions, err := history.ListRevisions(r.Context(), confirmationID)
+	if err != nil {
+		log.Printf("Failed to list history of ticket %s: %v", confirmationID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to retrieve ticket history"})
+		return nil, nil, false
+	}
+	return ticket, revisions, true
+}
+
+// UndoTicket handles POST /ticket/{confirmationID}/undo
+// @Summary Undo the last change of a ticket
+// @Description Put the ticket back as it was before the latest revision of its history. Only changes of the fields PUT /ticket/{confirmationID} sets can be undone; bookings, check-ins, price changes, changes of assigned seats and cancellations that freed seats cannot. Pass revision, the number the caller saw as latest, to have the undo refused when the ticket changed since. The undo is itself a change, so undoing again reverts it. Pass target to undo an earlier revision instead, e.g. after undoing the changes made after it; it is refused unless the ticket is as it was after that revision. With Firestore the history is written by the change feed shortly after each change; until the latest change is recorded, undo answers 409 and can be retried.
+// @Tags tickets
+// @Produce json,xml,application/msgpack
+// @Param confirmationID path string true "Ticket confirmation ID" example("ABC123")
+// @Param revision query int false "Revision expected to be the latest" minimum(1) example(5)
+// @Param target query int false "Revision to undo, the latest when absent" minimum(1) example(3)
+// @Param X-Lock-Token header string false "Token of the ticket's edit lock, required while it is locked"
+// @Success 200 {object} models.FlightTicket "Ticket as it was before the change"
+// @Failure 400 {object} models.ErrorResponse "Invalid revision or target"
+// @Failure 404 {object} models.ErrorResponse "Ticket or target revision not found"
+// @Failure 409 {object} models.ErrorResponse "Ticket changed since the revision or the target, change not reversible, not enough seats or lock token not current"
+// @Failure 422 {object} models.ErrorResponse "Booking rule of the caller's tenant violated"
+// @Failure 423 {object} models.ErrorResponse "Ticket is locked by another caller"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 501 {object} models.ErrorResponse "Ticket history not supported by storage backend"
+// @Router /ticket/{confirmationID}/undo [post]
+func (h *TicketHandler) UndoTicket(w http.ResponseWriter, r *http.Request) {
+	confirmationID := chi.URLParam(r, "confirmationID")
+	expected, ok := revisionParam(w, r.URL.Query().Get("revision"), "revision")
+	if !ok {
+		return
+	}
+	target, ok := revisionParam(w, r.URL.Query().Get("target"), "target")
+	if !ok {
+		return
+	}
+	if !h.checkLock(w, r, confirmationID) {
+		return
+	}
+	current, revisions, ok := h.ticketRevisions(w, r, confirmationID)
+	if !ok {
+		return
+	}
+	if len(revisions) == 0 {
+		writeUndoConflict(w, "Nothing to undo", "the ticket has no recorded changes")
+		return
+	}
+
+	// The latest revision must be the ticket as stored now, and the one the caller saw
+	last := revisions[len(revisions)-1]
+	if expected >= 0 && expected != len(revisions) {
+		writeUndoConflict(w, "Ticket changed", fmt.Sprintf("the latest revision is %d, not %d", len(revisions), expected))
+		return
+	}
+	if last.Ticket == nil || !last.Ticket.UpdatedAt.Equal(current.UpdatedAt) {
+		writeUndoConflict(w, "Ticket changed", "the ticket changed after its latest recorded revision; retry once the history catches up")
+		return
+	}
+	if target >= 0 && target != len(revisions) {
+		if target == 0 || target > len(revisions) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Revision not found", Message: fmt.Sprintf("the ticket has %d revisions", len(revisions))})
+			return
+		}
+		// The revisions since the target must have left the ticket as it was after it
+		last = revisions[target-1]
+		if !unchangedSince(w, last, current) {
+			return
+		}
+	}
+	updates, err := services.UndoUpdates(last)
+	if errors.Is(err, services.ErrNotReversible) {
+		writeUndoConflict(w, "Change not reversible", err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to undo revision %s of ticket %s: %v", last.ID, confirmationID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to undo the change"})
+		return
+	}
+	if !h.checkRulesUpdate(w, r, current, updates) || !h.checkReview(w, r, current, updates) {
+		return
+	}
+	unseated := leaveSeats(current, updates)
+
+	// The undo moves seats like any other update
+	actor := requestActor(r)
+	booked := bookedTicket(current, updates)
+	if err := h.inventory.Change(r.Context(), current, booked, actor); err != nil {
+		writeInventoryError(w, err)
+		return
+	}
+	if err := h.repository.UpdateTicket(r.Context(), confirmationID, updates); err != nil {
+		log.Printf("Failed to undo revision %s of ticket %s: %v", last.ID, confirmationID, err)
+		if err := h.inventory.Change(r.Context(), booked, current, actor); err != nil {
+			log.Printf("Failed to restore seats of ticket %s: %v", confirmationID, err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to undo the change"})
+		return
+	}
+	if unseated {
+		h.unassignSeats(r.Context(), current)
+	}
+	log.Printf("Undid revision %s of ticket %s, by %s", last.ID, confirmationID, actor)
+
+	ticket, err := h.repository.GetTicket(r.Context(), confirmationID)
+	if err != nil {
+		log.Printf("Failed to get ticket %s after undo: %v", confirmationID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Change undone but failed to retrieve the ticket"})
+		return
+	}
+	h.annotate(ticket)
+	h.encoders.Write(w, r, http.StatusOK, ticket)
+}
+
+// unchangedSince reports whether a ticket is as it was after a revision,
+// other than when it was updated, writing a conflict response when it is not
+func unchangedSince(w http.ResponseWriter, revision *models.TicketRevision, current *models.FlightTicket) bool {
+	changes, err := services.DiffTickets(revision.Ticket, current)
+	if err != nil {
+		log.Printf("Failed to compare revision %s of ticket %s: %v", revision.ID, current.ConfirmationID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to undo the change"})
+		return false
+	}
+	for _, change := range changes {
+		if change.Field != "updated_at" {
+			writeUndoConflict(w, "Ticket changed", fmt.Sprintf("%s changed after the target revision", change.Field))
+			return false
+		}
+	}
+	return true
+}
+
+// writeUndoConflict writes the response for an undo that cannot be made
+func writeUndoConflict(w http.ResponseWriter, problem, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(models.ErrorResponse{Error: problem, Message: message})
+}
+
+// revisionParam parses an optional revision number, -1 when it is absent,
+// writing a 400 response when it is invalid
+func revisionParam(w http.ResponseWriter, value, name string) (int, bool) {
+	if value == "" {
+		return -1, true
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid " + name, Message: name + " must be a revision number from 0"})
+		return 0, false
+	}
+	return number, true
+}
+
+// revisionSummary describes a revision by its number; revision 0 has no change of its own
+func revisionSummary(revisions []*models.TicketRevision, number int) models.RevisionSummary {
+	summary := models.RevisionSummary{Number: number}
+	if number > 0 {
+		revision := revisions[number-1]
+		summary.ID, summary.Type = revision.ID, revision.Type
+		at := revision.Time
+		summary.Time = &at
+	}
+	return summary
+}
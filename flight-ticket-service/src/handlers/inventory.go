@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"flight-ticket-service/src/maintenance"
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/services"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type InventoryHandler struct {
+	inventory   *services.SeatInventory
+	maintenance *maintenance.Switch
+}
+
+func NewInventoryHandler(repository services.TicketRepository, maintenanceSwitch *maintenance.Switch) *InventoryHandler {
+	return &InventoryHandler{
+		inventory:   services.NewSeatInventory(repository),
+		maintenance: maintenanceSwitch,
+	}
+}
+
+// departure reads the flight number and date from the URL, writing an error response when they are not usable
+func (h *InventoryHandler) departure(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	if !h.inventory.Enabled() {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotImplemented)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Seat inventory is not supported by the configured storage backend"})
+		return "", "", false
+	}
+
+	date := chi.URLParam(r, "date")
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid date format", Message: "Use YYYY-MM-DD format"})
+		return "", "", false
+	}
+
+	return strings.ToUpper(chi.URLParam(r, "flightNumber")), date, true
+}
+
+// GetInventory handles GET /admin/inventory/{flightNumber}/{date}
+// @Summary Get the seat inventory of a departure
+// @Description Show the seats on sale, available and sold for a departure, with every ledger entry that produced them. Requires an admin API key.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param flightNumber path string true "Flight number" example("AA1234")
+// @Param date path string true "Departure date in YYYY-MM-DD format" example("2024-12-25")
+// @Success 200 {object} models.InventoryResponse "Balance and ledger"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} models.ErrorResponse "No seat inventory for the departure"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 501 {object} models.ErrorResponse "Seat inventory not supported by storage backend"
+// @Router /admin/inventory/{flightNumber}/{date} [get]
+func (h *InventoryHandler) GetInventory(w http.ResponseWriter, r *http.Request) {
+	flightNumber, date, ok := h.departure(w, r)
+	if !ok {
+		return
+	}
+
+	balance, entries, err := h.inventory.Statement(r.Context(), flightNumber, date)
+	if err != nil {
+		log.Printf("Failed to get seat inventory of %s %s: %v", flightNumber, date, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to get seat inventory"})
+		return
+	}
+	if balance == nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "No seat inventory for the departure", Message: "Put seats on sale with an adjustment first"})
+		return
+	}
+	if entries == nil {
+		entries = []*models.InventoryEntry{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.InventoryResponse{
+		Balance: balance,
+		Entries: entries,
+		Count:   len(entries),
+	})
+}
+
+// AdjustInventory handles POST /admin/inventory/{flightNumber}/{date}/adjustments
+// @Summary Adjust the seats on sale for a departure
+// @Description Put seats on sale or take unsold seats off sale. The first adjustment opens the departure's ledger; from then on bookings, cancellations and rebookings are checked against it. Requires an admin API key.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param flightNumber path string true "Flight number" example("AA1234")
+// @Param date path string true "Departure date in YYYY-MM-DD format" example("2024-12-25")
+// @Param adjustment body models.InventoryAdjustmentRequest true "Adjustment"
+// @Success 201 {object} models.InventoryEntry "Posted ledger entry"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 409 {object} models.ErrorResponse "Not enough unsold seats to take off sale"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 501 {object} models.ErrorResponse "Seat inventory not supported by storage backend"
+// @Failure 503 {object} models.ErrorResponse "Service under maintenance"
+// @Router /admin/inventory/{flightNumber}/{date}/adjustments [post]
+func (h *InventoryHandler) AdjustInventory(w http.ResponseWriter, r *http.Request) {
+	// Admin routes stay open during maintenance, but this one writes the ledger
+	if h.maintenance.Status().Mode != maintenance.ModeOff {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Service under maintenance", Message: "Seat inventory cannot be adjusted during maintenance"})
+		return
+	}
+
+	flightNumber, date, ok := h.departure(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.InventoryAdjustmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid JSON payload"})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid adjustment", Message: err.Error()})
+		return
+	}
+
+	entry, err := h.inventory.Adjust(r.Context(), flightNumber, date, req.Seats, req.Reason, requestActor(r))
+	if err != nil {
+		if errors.Is(err, services.ErrInsufficientSeats) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Not enough unsold seats", Message: err.Error()})
+			return
+		}
+		log.Printf("Failed to adjust seat inventory of %s %s: %v", flightNumber, date, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to adjust seat inventory"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(entry)
+}
+
+// SetInventoryLimits handles PUT /admin/inventory/{flightNumber}/{date}/limits
+// @Summary Set the sell limits of a departure
+// @Description Set how far beyond its capacity a departure may be sold, as a percentage, and freeze or reopen its sales. Bookings and rebookings onto the departure are checked against the limits in the same transaction that holds their seats; cancellations still give seats back while sales are frozen. The change is posted to the ledger as a LIMITS entry. Requires an admin API key.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param flightNumber path string true "Flight number" example("AA1234")
+// @Param date path string true "Departure date in YYYY-MM-DD format" example("2024-12-25")
+// @Param limits body models.InventoryLimitsRequest true "Sell limits"
+// @Success 200 {object} models.InventorySnapshot "Seats under the new limits"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} models.ErrorResponse "No seat inventory for the departure"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 501 {object} models.ErrorResponse "Seat inventory not supported by storage backend"
+// @Failure 503 {object} models.ErrorResponse "Service under maintenance"
+// @Router /admin/inventory/{flightNumber}/{date}/limits [put]
+func (h *InventoryHandler) SetInventoryLimits(w http.ResponseWriter, r *http.Request) {
+	// Admin routes stay open during maintenance, but this one writes the ledger
+	if h.maintenance.Status().Mode != maintenance.ModeOff {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Service under maintenance", Message: "Sell limits cannot be changed during maintenance"})
+		return
+	}
+
+	flightNumber, date, ok := h.departure(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.InventoryLimitsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid JSON payload"})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid limits", Message: err.Error()})
+		return
+	}
+
+	if _, err := h.inventory.SetLimits(r.Context(), flightNumber, date, req.OverbookingPercent, req.Frozen, req.Reason, requestActor(r)); err != nil {
+		if errors.Is(err, services.ErrNoInventory) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "No seat inventory for the departure", Message: "Put seats on sale with an adjustment first"})
+			return
+		}
+		log.Printf("Failed to set sell limits of %s %s: %v", flightNumber, date, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to set sell limits"})
+		return
+	}
+
+	h.writeSnapshot(w, r, flightNumber, date)
+}
+
+// GetInventorySnapshot handles GET /admin/inventory/{flightNumber}/{date}/snapshot
+// @Summary Get the sold, held and available seats of a departure
+// @Description Count the seats of a departure sold to confirmed and checked-in tickets, held by pending tickets and left to sell up to the sell limit, with its overbooking percentage and whether sales are frozen. Requires an admin API key.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param flightNumber path string true "Flight number" example("AA1234")
+// @Param date path string true "Departure date in YYYY-MM-DD format" example("2024-12-25")
+// @Success 200 {object} models.InventorySnapshot "Seat counts"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} models.ErrorResponse "No seat inventory for the departure"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 501 {object} models.ErrorResponse "Seat inventory not supported by storage backend"
+// @Router /admin/inventory/{flightNumber}/{date}/snapshot [get]
+func (h *InventoryHandler) GetInventorySnapshot(w http.ResponseWriter, r *http.Request) {
+	flightNumber, date, ok := h.departure(w, r)
+	if !ok {
+		return
+	}
+	h.writeSnapshot(w, r, flightNumber, date)
+}
+
+// writeSnapshot writes the seat counts of a departure
+func (h *InventoryHandler) writeSnapshot(w http.ResponseWriter, r *http.Request, flightNumber, date string) {
+	snapshot, err := h.inventory.Snapshot(r.Context(), flightNumber, date)
+	if err != nil {
+		log.Printf("Failed to get seat counts of %s %s: %v", flightNumber, date, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to get seat inventory"})
+		return
+	}
+	if snapshot == nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "No seat inventory for the departure", Message: "Put seats on sale with an adjustment first"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(snapshot)
+}
+
+// ReconcileInventory handles GET /admin/inventory/{flightNumber}/{date}/reconciliation
+// @Summary Reconcile the seat inventory of a departure
+// @Description Replay the departure's ledger and compare it with the stored balance and with the passengers of the departure's tickets. Tickets booked before the ledger was opened show up as discrepancies. Requires an admin API key.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param flightNumber path string true "Flight number" example("AA1234")
+// @Param date path string true "Departure date in YYYY-MM-DD format" example("2024-12-25")
+// @Success 200 {object} models.InventoryReconciliation "Reconciliation"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 501 {object} models.ErrorResponse "Seat inventory not supported by storage backend"
+// @Router /admin/inventory/{flightNumber}/{date}/reconciliation [get]
+func (h *InventoryHandler) ReconcileInventory(w http.ResponseWriter, r *http.Request) {
+	flightNumber, date, ok := h.departure(w, r)
+	if !ok {
+		return
+	}
+
+	reconciliation, err := h.inventory.Reconcile(r.Context(), flightNumber, date)
+	if err != nil {
+		log.Printf("Failed to reconcile seat inventory of %s %s: %v", flightNumber, date, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to reconcile seat inventory"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(reconciliation)
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"flight-ticket-service/src/jobs"
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/workers"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// JobHandler serves the jobs of the worker pool (manifest exports) and the
+// jobs submitted to the job manager under the same endpoints
+type JobHandler struct {
+	pool    *workers.Pool
+	manager *jobs.Manager
+}
+
+func NewJobHandler(pool *workers.Pool, manager *jobs.Manager) *JobHandler {
+	return &JobHandler{
+		pool:    pool,
+		manager: manager,
+	}
+}
+
+// CreateJob handles POST /jobs
+// @Summary Submit a background job
+// @Description Queue an export (JSON backup to BACKUP_BUCKET, params: label), import (restore of a backup, params: label, overwrite) bulk_cancel (params: flight_number, departure_date, status, confirmation_ids) or migrate_schema (upgrade of ticket documents stored with an older schema version, Firestore only) job. export and import are only available when BACKUP_BUCKET is set. Poll the returned job, or pass a callback_url to have the finished job POSTed to it. Requires an admin API key.
+// @Tags jobs
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param job body models.CreateJobRequest true "Job"
+// @Success 202 {object} models.Job "Job queued"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /jobs [post]
+func (h *JobHandler) CreateJob(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateJobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid JSON payload"})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid job", Message: err.Error()})
+		return
+	}
+
+	job, err := h.manager.Submit(r.Context(), req.Kind, req.Params, req.CallbackURL)
+	if errors.Is(err, jobs.ErrInvalid) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid job", Message: err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to submit %s job: %v", req.Kind, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to submit job"})
+		return
+	}
+
+	writeJobAccepted(w, job)
+}
+
+// GetJob handles GET /jobs/{jobID}
+// @Summary Get a background job
+// @Description Poll the status and progress of a job submitted to POST /jobs, or of a document export such as a manifest requested with async=true. Once an export has succeeded, result_url downloads the document. Submitted jobs are stored with the tickets; export jobs are kept for an hour after they finish by the instance that ran them. Requires an agent or admin API key.
+// @Tags jobs
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param jobID path string true "Job ID" example("3f9c2a7e41b8d05c")
+// @Success 200 {object} models.Job "Job status"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} models.ErrorResponse "Job not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /jobs/{jobID} [get]
+func (h *JobHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "jobID")
+	if exportJob, err := h.pool.Get(jobID); err == nil {
+		writeJob(w, poolJob(exportJob))
+		return
+	}
+
+	job, err := h.manager.Get(r.Context(), jobID)
+	if errors.Is(err, jobs.ErrNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Job not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to get job %s: %v", jobID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to retrieve job"})
+		return
+	}
+
+	writeJob(w, job)
+}
+
+// writeJob writes a job, asking pollers to come back shortly while it is unfinished
+func writeJob(w http.ResponseWriter, job *models.Job) {
+	if job.Status == jobs.StatusQueued || job.Status == jobs.StatusRunning {
+		w.Header().Set("Retry-After", "1")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(job)
+}
+
+// GetJobResult handles GET /jobs/{jobID}/result
+// @Summary Download the result of a background job
+// @Description Download the document produced by a succeeded job. Requires an agent or admin API key.
+// @Tags jobs
+// @Produce application/pdf
+// @Produce text/csv
+// @Security ApiKeyAuth || BearerAuth
+// @Param jobID path string true "Job ID" example("3f9c2a7e41b8d05c")
+// @Success 200 {file} binary "Job result"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} models.ErrorResponse "Job not found"
+// @Failure 409 {object} models.ErrorResponse "Job has not succeeded"
+// @Router /jobs/{jobID}/result [get]
+func (h *JobHandler) GetJobResult(w http.ResponseWriter, r *http.Request) {
+	job, err := h.pool.Get(chi.URLParam(r, "jobID"))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Job not found"})
+		return
+	}
+
+	if job.Status != workers.StatusSucceeded || job.Result == nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Job has not succeeded", Message: "Job status is " + job.Status})
+		return
+	}
+
+	w.Header().Set("Content-Type", job.Result.ContentType)
+	if job.Result.FileName != "" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", job.Result.FileName))
+	}
+	w.Write(job.Result.Data)
+}
+
+// writeJobAccepted answers 202 Accepted for a queued job, pointing at its status
+func writeJobAccepted(w http.ResponseWriter, job *models.Job) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/jobs/"+job.ID)
+	w.Header().Set("Retry-After", "1")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+// writeQueueFull answers 503 when the worker pool cannot take more jobs
+func writeQueueFull(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", "5")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Too many exports in progress", Message: "Try again shortly"})
+}
+
+// poolJob describes a job of the worker pool in the API's job format
+func poolJob(job workers.Job) *models.Job {
+	status := &models.Job{
+		ID:        job.ID,
+		Kind:      job.Kind,
+		Status:    job.Status,
+		CreatedAt: job.CreatedAt,
+		Error:     job.Error,
+	}
+	if !job.StartedAt.IsZero() {
+		status.StartedAt = timePtr(job.StartedAt)
+	}
+	if !job.FinishedAt.IsZero() {
+		status.FinishedAt = timePtr(job.FinishedAt)
+	}
+	if job.Status == workers.StatusSucceeded {
+		status.ResultURL = "/jobs/" + job.ID + "/result"
+	}
+	return status
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
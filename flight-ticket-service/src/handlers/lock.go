@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/services"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// LockTokenHeader carries the token of the edit lock a caller holds
+const LockTokenHeader = "X-Lock-Token"
+
+// LockHandler lets agents hold short-lived edit locks on tickets
+type LockHandler struct {
+	repository services.TicketRepository
+}
+
+func NewLockHandler(repository services.TicketRepository) *LockHandler {
+	return &LockHandler{repository: repository}
+}
+
+// writeLockError answers a request refused by a ticket's lock
+func writeLockError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case errors.Is(err, services.ErrTicketLocked):
+		w.WriteHeader(http.StatusLocked)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Ticket is locked", Message: err.Error()})
+	case errors.Is(err, services.ErrStaleLockToken):
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Lock token is not current", Message: "The lock expired or was released; lock the ticket again"})
+	default:
+		log.Printf("Failed to check ticket lock: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to check ticket lock"})
+	}
+}
+
+// lockStore returns the lock store, writing an error response when unavailable
+func (h *LockHandler) lockStore(w http.ResponseWriter) (services.TicketLockStore, bool) {
+	store, ok := services.Capability[services.TicketLockStore](h.repository)
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotImplemented)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Locks are not supported by the configured storage backend"})
+		return nil, false
+	}
+	return store, true
+}
+
+// LockTicket handles POST /ticket/{confirmationID}/lock
+// @Summary Lock a ticket for editing
+// @Description Take a short-lived edit lock on a ticket. While it holds, updates and cancellations without its token are refused with 423, so agents working on the same ticket do not interleave their changes. The token is only returned here; send it in X-Lock-Token with the writes and to release the lock. Sending the token of the lock in force renews it. Tokens of locks that expired or were released are refused with 409, so a retried request cannot write under a lock its sender lost.
+// @Tags tickets
+// @Accept json
+// @Produce json
+// @Param confirmationID path string true "Ticket confirmation ID" example("ABC123")
+// @Param lock body models.LockTicketRequest false "Lock duration"
+// @Param X-Lock-Token header string false "Token of the lock to renew"
+// @Success 200 {object} models.TicketLock "Renewed lock"
+// @Success 201 {object} models.TicketLock "New lock"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Ticket not found"
+// @Failure 409 {object} models.ErrorResponse "Lock token is not current"
+// @Failure 423 {object} models.ErrorResponse "Ticket is locked by another caller"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 501 {object} models.ErrorResponse "Locks not supported by storage backend"
+// @Router /ticket/{confirmationID}/lock [post]
+func (h *LockHandler) LockTicket(w http.ResponseWriter, r *http.Request) {
+	store, ok := h.lockStore(w)
+	if !ok {
+		return
+	}
+
+	// The body is optional; an empty one takes the default duration
+	var req models.LockTicketRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid JSON payload"})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid lock", Message: err.Error()})
+		return
+	}
+
+	confirmationID := chi.URLParam(r, "confirmationID")
+	if _, err := h.repository.GetTicket(r.Context(), confirmationID); err != nil {
+		log.Printf("Failed to get ticket %s: %v", confirmationID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Ticket not found"})
+		return
+	}
+
+	ttl := time.Duration(req.TTLSeconds) * time.Second
+	lock, renewed, err := services.AcquireLock(r.Context(), store, confirmationID, requestActor(r), r.Header.Get(LockTokenHeader), ttl)
+	if err != nil {
+		writeLockError(w, err)
+		return
+	}
+
+	code := http.StatusCreated
+	if renewed {
+		code = http.StatusOK
+	} else {
+		log.Printf("Ticket %s locked by %q until %s", confirmationID, lock.Holder, lock.ExpiresAt.Format(time.RFC3339))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(lock)
+}
+
+// UnlockTicket handles DELETE /ticket/{confirmationID}/lock
+// @Summary Release a ticket's edit lock
+// @Description Release the edit lock whose token is sent in X-Lock-Token, before it expires.
+// @Tags tickets
+// @Produce json
+// @Param confirmationID path string true "Ticket confirmation ID" example("ABC123")
+// @Param X-Lock-Token header string true "Token of the lock"
+// @Success 200 {object} models.SuccessResponse "Lock released"
+// @Failure 409 {object} models.ErrorResponse "Lock token is not current"
+// @Failure 423 {object} models.ErrorResponse "Ticket is locked by another caller"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 501 {object} models.ErrorResponse "Locks not supported by storage backend"
+// @Router /ticket/{confirmationID}/lock [delete]
+func (h *LockHandler) UnlockTicket(w http.ResponseWriter, r *http.Request) {
+	store, ok := h.lockStore(w)
+	if !ok {
+		return
+	}
+
+	confirmationID := chi.URLParam(r, "confirmationID")
+	if err := services.ReleaseLock(r.Context(), store, confirmationID, r.Header.Get(LockTokenHeader)); err != nil {
+		writeLockError(w, err)
+		return
+	}
+
+	log.Printf("Ticket %s unlocked", confirmationID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.SuccessResponse{
+		Message:        "Ticket unlocked",
+		ConfirmationID: confirmationID,
+	})
+}
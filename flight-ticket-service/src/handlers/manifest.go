@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"flight-ticket-service/src/manifest"
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/services"
+	"flight-ticket-service/src/tenants"
+	"flight-ticket-service/src/workers"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type ManifestHandler struct {
+	repository services.TicketRepository
+	documents  *services.DocumentCache // optional
+	pool       *workers.Pool
+}
+
+// NewManifestHandler creates the manifest handler. CSV and PDF manifests are
+// rendered on the worker pool. With a document cache, PDF manifests are
+// stored once per manifest content and served through signed URLs.
+func NewManifestHandler(repository services.TicketRepository, documents *services.DocumentCache, pool *workers.Pool) *ManifestHandler {
+	return &ManifestHandler{
+		repository: repository,
+		documents:  documents,
+		pool:       pool,
+	}
+}
+
+// GetManifest handles GET /flights/{flightNumber}/{date}/manifest
+// @Summary Get a departure manifest
+// @Description List every passenger on the confirmed and checked-in tickets of a departure, for gate agents. Checked-in passengers carry their names and seats; others are listed as PAX/ADULTn, PAX/CHILDn or PAX/INFANTn placeholders, with their passenger type. Requires an agent or admin API key. CSV and PDF manifests are rendered on a bounded worker pool; with async=true the response is 202 Accepted with a job to poll at GET /jobs/{id}. When document storage is configured, PDF manifests redirect to a short-lived signed URL and are only regenerated when the passenger list changes; use delivery=inline to receive the PDF directly. PDF manifests carry the branding of the caller's tenant.
+// @Tags flights
+// @Produce json
+// @Produce text/csv
+// @Produce application/pdf
+// @Security ApiKeyAuth || BearerAuth
+// @Param flightNumber path string true "Flight number" example("AA1234")
+// @Param date path string true "Scheduled departure date in YYYY-MM-DD format" example("2024-12-25")
+// @Param format query string false "Output format" Enums(json, csv, pdf) default(json)
+// @Param delivery query string false "Redirect to a signed URL or return the PDF (only with document storage)" Enums(redirect, inline) default(redirect)
+// @Success 200 {object} models.FlightManifest "Manifest"
+// @Param async query bool false "Render the csv or pdf manifest in the background and return a job" default(false)
+// @Success 202 {object} models.Job "Export job queued"
+// @Success 302 {string} string "Redirect to a signed URL of the PDF"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 503 {object} models.ErrorResponse "Too many exports in progress"
+// @Router /flights/{flightNumber}/{date}/manifest [get]
+func (h *ManifestHandler) GetManifest(w http.ResponseWriter, r *http.Request) {
+	flightNumber := strings.ToUpper(chi.URLParam(r, "flightNumber"))
+	date, err := time.Parse("2006-01-02", chi.URLParam(r, "date"))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid date format", Message: "Use YYYY-MM-DD format"})
+		return
+	}
+
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format != "" && format != "json" && format != "csv" && format != "pdf" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid format", Message: "Use json, csv or pdf"})
+		return
+	}
+
+	delivery := r.URL.Query().Get("delivery")
+	if delivery != "" && delivery != "redirect" && delivery != "inline" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid delivery", Message: "Use redirect or inline"})
+		return
+	}
+
+	async := false
+	if value := r.URL.Query().Get("async"); value != "" {
+		async, err = strconv.ParseBool(value)
+		if err != nil || (async && format != "csv" && format != "pdf") {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid async", Message: "async must be true or false and requires format csv or pdf"})
+			return
+		}
+	}
+
+	tickets, err := services.SearchTickets(r.Context(), h.repository, models.TicketQuery{
+		FlightNumber:  flightNumber,
+		DepartureDate: date,
+	})
+	if err != nil {
+		log.Printf("Failed to list tickets on flight %s: %v", flightNumber, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to build manifest"})
+		return
+	}
+
+	m := manifest.Build(flightNumber, date, tickets, time.Now())
+	if tenant := tenants.FromContext(r.Context()); tenant != nil && !tenant.Branding.IsZero() {
+		branding := tenant.Branding
+		m.Branding = &branding
+	}
+	filename := fmt.Sprintf("manifest-%s-%s", flightNumber, m.Date)
+
+	if format != "csv" && format != "pdf" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(m)
+		return
+	}
+
+	task := renderManifest(m, format, filename+"."+format)
+	if async {
+		job, err := h.pool.Submit("manifest_"+format, task)
+		if err != nil {
+			h.renderFailed(w, m, format, err)
+			return
+		}
+		writeJobAccepted(w, poolJob(job))
+		return
+	}
+
+	if format == "pdf" && h.documents != nil && delivery != "inline" {
+		h.redirectToPDF(w, r, m, task)
+		return
+	}
+
+	result, err := h.pool.Run(r.Context(), "manifest_"+format, task)
+	if err != nil {
+		h.renderFailed(w, m, format, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", result.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", result.FileName))
+	w.Write(result.Data)
+}
+
+// renderManifest returns the task rendering a manifest as csv or pdf
+func renderManifest(m *models.FlightManifest, format, filename string) workers.Task {
+	return func(ctx context.Context) (*workers.Result, error) {
+		var body bytes.Buffer
+		result := &workers.Result{FileName: filename}
+		var err error
+		if format == "csv" {
+			err = manifest.WriteCSV(&body, m)
+			result.ContentType = "text/csv; charset=utf-8"
+		} else {
+			err = manifest.WritePDF(&body, m)
+			result.ContentType = "application/pdf"
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.Data = body.Bytes()
+		return result, nil
+	}
+}
+
+// renderFailed writes the error response of a manifest that could not be rendered
+func (h *ManifestHandler) renderFailed(w http.ResponseWriter, m *models.FlightManifest, format string, err error) {
+	if errors.Is(err, workers.ErrQueueFull) {
+		writeQueueFull(w)
+		return
+	}
+
+	log.Printf("Failed to render %s manifest of flight %s: %v", format, m.FlightNumber, err)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to build manifest"})
+}
+
+// redirectToPDF redirects to a stored PDF of the manifest, generating it when
+// the passenger list has changed since the last stored copy
+func (h *ManifestHandler) redirectToPDF(w http.ResponseWriter, r *http.Request, m *models.FlightManifest, render workers.Task) {
+	// The manifest content without its generation time identifies the PDF
+	content := *m
+	content.GeneratedAt = time.Time{}
+	version, _ := json.Marshal(content)
+
+	document := services.Document{
+		ObjectName:  services.DocumentObjectName("manifests/"+m.FlightNumber+"/"+m.Date, "manifest", "pdf", string(version)),
+		FileName:    fmt.Sprintf("manifest-%s-%s.pdf", m.FlightNumber, m.Date),
+		ContentType: "application/pdf",
+	}
+	link, err := h.documents.Link(r.Context(), document, func() ([]byte, error) {
+		result, err := h.pool.Run(r.Context(), "manifest_pdf", render)
+		if err != nil {
+			return nil, err
+		}
+		return result.Data, nil
+	})
+	if err != nil {
+		h.renderFailed(w, m, "pdf", err)
+		return
+	}
+
+	redirectToDocument(w, r, link)
+}
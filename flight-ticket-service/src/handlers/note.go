@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"flight-ticket-service/src/auth"
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/services"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type NoteHandler struct {
+	repository services.TicketRepository
+}
+
+func NewNoteHandler(repository services.TicketRepository) *NoteHandler {
+	return &NoteHandler{
+		repository: repository,
+	}
+}
+
+// noteRepository returns the note store, writing an error response when unavailable
+func (h *NoteHandler) noteRepository(w http.ResponseWriter) (services.NoteRepository, bool) {
+	notes, ok := services.Capability[services.NoteRepository](h.repository)
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotImplemented)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Notes are not supported by the configured storage backend"})
+		return nil, false
+	}
+	return notes, true
+}
+
+// requireTicket checks that the ticket in the URL exists, writing an error response otherwise
+func (h *NoteHandler) requireTicket(w http.ResponseWriter, r *http.Request) (string, bool) {
+	confirmationID := chi.URLParam(r, "confirmationID")
+	if confirmationID == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Confirmation ID is required"})
+		return "", false
+	}
+
+	if _, err := h.repository.GetTicket(r.Context(), confirmationID); err != nil {
+		log.Printf("Failed to get ticket %s: %v", confirmationID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Ticket not found"})
+		return "", false
+	}
+
+	return confirmationID, true
+}
+
+// CreateNote handles POST /ticket/{confirmationID}/notes
+// @Summary Add a note to a ticket
+// @Description Leave an internal remark on a ticket. The author is the name of the API key. Notes are never shown to passengers. Requires an agent or admin API key.
+// @Tags notes
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param confirmationID path string true "Ticket confirmation ID" example("ABC123")
+// @Param note body models.CreateNoteRequest true "Note"
+// @Success 201 {object} models.TicketNote "Created note"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} models.ErrorResponse "Ticket not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 501 {object} models.ErrorResponse "Notes not supported by storage backend"
+// @Router /ticket/{confirmationID}/notes [post]
+func (h *NoteHandler) CreateNote(w http.ResponseWriter, r *http.Request) {
+	repository, ok := h.noteRepository(w)
+	if !ok {
+		return
+	}
+
+	confirmationID, ok := h.requireTicket(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.CreateNoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid JSON payload"})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid note", Message: err.Error()})
+		return
+	}
+
+	principal, _ := auth.FromContext(r.Context())
+	note, err := services.NewNote(confirmationID, principal.Name, req.Text)
+	if err == nil {
+		err = repository.CreateNote(r.Context(), note)
+	}
+	if err != nil {
+		log.Printf("Failed to create note for ticket %s: %v", confirmationID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to create note"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(note)
+}
+
+// ListNotes handles GET /ticket/{confirmationID}/notes
+// @Summary List the notes of a ticket
+// @Description List the internal remarks on a ticket, oldest first. Requires an agent or admin API key.
+// @Tags notes
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param confirmationID path string true "Ticket confirmation ID" example("ABC123")
+// @Success 200 {object} models.NoteListResponse "Notes"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} models.ErrorResponse "Ticket not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 501 {object} models.ErrorResponse "Notes not supported by storage backend"
+// @Router /ticket/{confirmationID}/notes [get]
+func (h *NoteHandler) ListNotes(w http.ResponseWriter, r *http.Request) {
+	repository, ok := h.noteRepository(w)
+	if !ok {
+		return
+	}
+
+	confirmationID, ok := h.requireTicket(w, r)
+	if !ok {
+		return
+	}
+
+	notes, err := repository.ListNotes(r.Context(), confirmationID)
+	if err != nil {
+		log.Printf("Failed to list notes for ticket %s: %v", confirmationID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to list notes"})
+		return
+	}
+	if notes == nil {
+		notes = []*models.TicketNote{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.NoteListResponse{
+		Notes: notes,
+		Count: len(notes),
+	})
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/services"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type NotificationHandler struct {
+	repository services.TicketRepository
+}
+
+func NewNotificationHandler(repository services.TicketRepository) *NotificationHandler {
+	return &NotificationHandler{
+		repository: repository,
+	}
+}
+
+// preferenceRepository returns the preference store, writing an error response when unavailable
+func (h *NotificationHandler) preferenceRepository(w http.ResponseWriter) (services.NotificationPreferenceRepository, bool) {
+	preferences, ok := services.Capability[services.NotificationPreferenceRepository](h.repository)
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotImplemented)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Notification preferences are not supported by the configured storage backend"})
+		return nil, false
+	}
+	return preferences, true
+}
+
+// requireTicket checks that the ticket in the URL exists, writing an error response otherwise
+func (h *NotificationHandler) requireTicket(w http.ResponseWriter, r *http.Request) (string, bool) {
+	confirmationID := chi.URLParam(r, "confirmationID")
+	if confirmationID == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Confirmation ID is required"})
+		return "", false
+	}
+
+	if _, err := h.repository.GetTicket(r.Context(), confirmationID); err != nil {
+		log.Printf("Failed to get ticket %s: %v", confirmationID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Ticket not found"})
+		return "", false
+	}
+
+	return confirmationID, true
+}
+
+// GetPreferences handles GET /ticket/{confirmationID}/notifications
+// @Summary Get notification preferences
+// @Description Get how the travellers on a ticket are notified of changes and departure reminders. Tickets without saved preferences are notified by email in English at any time.
+// @Tags notifications
+// @Produce json
+// @Param confirmationID path string true "Ticket confirmation ID" example("ABC123")
+// @Success 200 {object} models.NotificationPreferences "Notification preferences"
+// @Failure 404 {object} models.ErrorResponse "Ticket not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 501 {object} models.ErrorResponse "Notification preferences not supported by storage backend"
+// @Router /ticket/{confirmationID}/notifications [get]
+func (h *NotificationHandler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	repository, ok := h.preferenceRepository(w)
+	if !ok {
+		return
+	}
+
+	confirmationID, ok := h.requireTicket(w, r)
+	if !ok {
+		return
+	}
+
+	preferences, err := repository.GetNotificationPreferences(r.Context(), confirmationID)
+	if err != nil {
+		log.Printf("Failed to get notification preferences for ticket %s: %v", confirmationID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to get notification preferences"})
+		return
+	}
+	if preferences == nil {
+		preferences = models.DefaultNotificationPreferences(confirmationID)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(preferences)
+}
+
+// UpdatePreferences handles PUT /ticket/{confirmationID}/notifications
+// @Summary Set notification preferences
+// @Description Replace the notification preferences of a ticket: the channel (email, sms or none), the language and optional quiet hours during which messages are held back.
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param confirmationID path string true "Ticket confirmation ID" example("ABC123")
+// @Param preferences body models.UpdateNotificationPreferencesRequest true "Notification preferences"
+// @Success 200 {object} models.NotificationPreferences "Saved notification preferences"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Ticket not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 501 {object} models.ErrorResponse "Notification preferences not supported by storage backend"
+// @Router /ticket/{confirmationID}/notifications [put]
+func (h *NotificationHandler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	repository, ok := h.preferenceRepository(w)
+	if !ok {
+		return
+	}
+
+	confirmationID, ok := h.requireTicket(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateNotificationPreferencesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid JSON payload"})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid notification preferences", Message: err.Error()})
+		return
+	}
+
+	preferences := req.Preferences(confirmationID)
+	if err := repository.SaveNotificationPreferences(r.Context(), preferences); err != nil {
+		log.Printf("Failed to save notification preferences for ticket %s: %v", confirmationID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to save notification preferences"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(preferences)
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/services"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type QRHandler struct {
+	repository services.TicketRepository
+	qrService  *services.QRService
+	documents  *services.DocumentCache // optional
+}
+
+// NewQRHandler creates the QR code handler. With a document cache, QR codes
+// are stored once per ticket version and served through signed URLs.
+func NewQRHandler(repository services.TicketRepository, qrService *services.QRService, documents *services.DocumentCache) *QRHandler {
+	return &QRHandler{
+		repository: repository,
+		qrService:  qrService,
+		documents:  documents,
+	}
+}
+
+// GetQRCode handles GET /ticket/{confirmationID}/qr
+// @Summary Get a QR code for a ticket
+// @Description Render a QR code for gate scanning. By default it encodes a signed payload of the confirmation ID (FTS1.<confirmation ID>.<issued unix time>.<signature>); with payload=bcbp it encodes the IATA Bar Coded Boarding Pass issued at check-in to the passenger with the given sequence number, so the ticket must be checked in. The payload is also returned in the X-QR-Payload header. When document storage is configured the image is generated once per ticket version and the response redirects to a short-lived signed URL; the signed payload is then issued at the ticket's last update. Use delivery=inline to receive the image directly.
+// @Tags tickets
+// @Produce png
+// @Produce image/svg+xml
+// @Param confirmationID path string true "Ticket confirmation ID" example("ABC123")
+// @Param format query string false "Image format" Enums(png, svg) default(png)
+// @Param size query int false "Image size in pixels (64-1024)" default(256)
+// @Param level query string false "Error-correction level" Enums(L, M, Q, H) default(M)
+// @Param payload query string false "Payload type" Enums(signed, bcbp) default(signed)
+// @Param sequence query int false "Check-in sequence number of the passenger (bcbp)" default(1)
+// @Param delivery query string false "Redirect to a signed URL or return the image (only with document storage)" Enums(redirect, inline) default(redirect)
+// @Success 200 {file} binary "QR code image"
+// @Success 302 {string} string "Redirect to a signed URL of the image"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Ticket or checked-in passenger not found"
+// @Failure 409 {object} models.ErrorResponse "Ticket is cancelled, or not checked in for bcbp"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /ticket/{confirmationID}/qr [get]
+func (h *QRHandler) GetQRCode(w http.ResponseWriter, r *http.Request) {
+	confirmationID := chi.URLParam(r, "confirmationID")
+	if confirmationID == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Confirmation ID is required"})
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "png"
+	}
+	if format != "png" && format != "svg" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid format", Message: "format must be png or svg"})
+		return
+	}
+
+	size := services.DefaultQRSize
+	if sizeParam := r.URL.Query().Get("size"); sizeParam != "" {
+		parsed, err := strconv.Atoi(sizeParam)
+		if err != nil || parsed < services.MinQRSize || parsed > services.MaxQRSize {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid size", Message: "size must be between 64 and 1024 pixels"})
+			return
+		}
+		size = parsed
+	}
+
+	level, err := services.ParseQRLevel(r.URL.Query().Get("level"))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid level", Message: err.Error()})
+		return
+	}
+
+	payloadType := r.URL.Query().Get("payload")
+	if payloadType != "" && payloadType != "signed" && payloadType != "bcbp" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid payload", Message: "payload must be signed or bcbp"})
+		return
+	}
+
+	delivery := r.URL.Query().Get("delivery")
+	if delivery != "" && delivery != "redirect" && delivery != "inline" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid delivery", Message: "delivery must be redirect or inline"})
+		return
+	}
+	redirect := h.documents != nil && delivery != "inline"
+
+	sequence := 1
+	if sequenceParam := r.URL.Query().Get("sequence"); sequenceParam != "" {
+		parsed, err := strconv.Atoi(sequenceParam)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid sequence", Message: "sequence must be a number"})
+			return
+		}
+		sequence = parsed
+	}
+
+	ticket, err := h.repository.GetTicket(r.Context(), confirmationID)
+	if err != nil {
+		log.Printf("Failed to get ticket %s: %v", confirmationID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Ticket not found"})
+		return
+	}
+
+	if ticket.Status == "CANCELLED" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Ticket is cancelled"})
+		return
+	}
+
+	// A stored image must stay valid for the ticket version it was generated for
+	issuedAt := time.Now()
+	if redirect {
+		issuedAt = ticket.UpdatedAt
+	}
+	payload := h.qrService.Payload(ticket.ConfirmationID, issuedAt)
+	if payloadType == "bcbp" {
+		// Only the boarding passes issued at check-in can be rendered
+		if ticket.Status != services.StatusCheckedIn || ticket.CheckIn == nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Ticket is not checked in"})
+			return
+		}
+		passenger, ok := checkedInPassenger(ticket.CheckIn, sequence)
+		if !ok {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Passenger not found", Message: fmt.Sprintf("no passenger checked in with sequence number %d", sequence)})
+			return
+		}
+		payload, err = ticketBoardingPass(ticket, passenger, ticket.CheckIn.Compartment, sequence).Encode()
+		if err != nil {
+			log.Printf("Failed to encode boarding pass %d of ticket %s: %v", sequence, confirmationID, err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to generate QR code"})
+			return
+		}
+	}
+
+	contentType := "image/png"
+	if format == "svg" {
+		contentType = "image/svg+xml"
+	}
+	render := func() ([]byte, error) {
+		if format == "svg" {
+			return h.qrService.SVG(payload, level, size)
+		}
+		return h.qrService.PNG(payload, level, size)
+	}
+
+	if redirect {
+		document := services.Document{
+			ObjectName: services.DocumentObjectName("tickets/"+ticket.ConfirmationID, "qr", format,
+				ticket.UpdatedAt.UTC().Format(time.RFC3339Nano), payload, strconv.Itoa(int(level)), strconv.Itoa(size)),
+			FileName:    ticket.ConfirmationID + "-qr." + format,
+			ContentType: contentType,
+		}
+		link, err := h.documents.Link(r.Context(), document, render)
+		if err != nil {
+			log.Printf("Failed to store QR code for ticket %s: %v", confirmationID, err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to generate QR code"})
+			return
+		}
+		w.Header().Set("X-QR-Payload", payload)
+		redirectToDocument(w, r, link)
+		return
+	}
+
+	image, err := render()
+	if err != nil {
+		log.Printf("Failed to render QR code for ticket %s: %v", confirmationID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to generate QR code"})
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-QR-Payload", payload)
+	w.Write(image)
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/services"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// QuarantineHandler lets admins inspect and repair unreadable ticket documents
+type QuarantineHandler struct {
+	repository services.TicketRepository
+}
+
+func NewQuarantineHandler(repository services.TicketRepository) *QuarantineHandler {
+	return &QuarantineHandler{repository: repository}
+}
+
+// quarantineRepository returns the quarantine store, writing an error response when unavailable
+func (h *QuarantineHandler) quarantineRepository(w http.ResponseWriter) (services.TicketQuarantine, bool) {
+	quarantine, ok := services.Capability[services.TicketQuarantine](h.repository)
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotImplemented)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Quarantine is not supported by the configured storage backend"})
+		return nil, false
+	}
+	return quarantine, true
+}
+
+// requireQuarantined loads the quarantined ticket named in the URL, writing an error response when it is missing
+func (h *QuarantineHandler) requireQuarantined(w http.ResponseWriter, r *http.Request, quarantine services.TicketQuarantine) (*models.QuarantinedTicket, bool) {
+	confirmationID := chi.URLParam(r, "confirmationID")
+	ticket, err := quarantine.GetQuarantined(r.Context(), confirmationID)
+	if err != nil {
+		log.Printf("Failed to get quarantined ticket %s: %v", confirmationID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to retrieve quarantined ticket"})
+		return nil, false
+	}
+	if ticket == nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Ticket is not quarantined"})
+		return nil, false
+	}
+	return ticket, true
+}
+
+// ListQuarantined handles GET /admin/quarantine
+// @Summary List quarantined tickets
+// @Description List the ticket documents that could not be read when listing or searching tickets, with the reason and their stored fields. Quarantined tickets are left out of lists and searches until they are repaired. Requires an admin API key.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Success 200 {object} models.QuarantineListResponse "Quarantined tickets"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 501 {object} models.ErrorResponse "Quarantine not supported by storage backend"
+// @Router /admin/quarantine [get]
+func (h *QuarantineHandler) ListQuarantined(w http.ResponseWriter, r *http.Request) {
+	quarantine, ok := h.quarantineRepository(w)
+	if !ok {
+		return
+	}
+
+	tickets, err := quarantine.ListQuarantined(r.Context())
+	if err != nil {
+		log.Printf("Failed to list quarantined tickets: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to retrieve quarantined tickets"})
+		return
+	}
+	if tickets == nil {
+		tickets = []*models.QuarantinedTicket{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.QuarantineListResponse{Tickets: tickets, Count: len(tickets)})
+}
+
+// GetQuarantined handles GET /admin/quarantine/{confirmationID}
+// @Summary Get a quarantined ticket
+// @Description Show why a ticket document was quarantined and the fields it had then. Requires an admin API key.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param confirmationID path string true "Confirmation ID" example("ABC123")
+// @Success 200 {object} models.QuarantinedTicket "Quarantined ticket"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} models.ErrorResponse "Ticket is not quarantined"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 501 {object} models.ErrorResponse "Quarantine not supported by storage backend"
+// @Router /admin/quarantine/{confirmationID} [get]
+func (h *QuarantineHandler) GetQuarantined(w http.ResponseWriter, r *http.Request) {
+	quarantine, ok := h.quarantineRepository(w)
+	if !ok {
+		return
+	}
+	ticket, ok := h.requireQuarantined(w, r, quarantine)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ticket)
+}
+
+// RepairQuarantined handles POST /admin/quarantine/{confirmationID}/repair
+// @Summary Repair a quarantined ticket
+// @Description Set and remove fields of a quarantined ticket's document, by document field name. The changes are only stored when the document then passes the strict field checks: the ticket is rewritten in the current schema, released from quarantine and returned. Otherwise nothing changes and the remaining problems are returned. An empty repair releases a ticket that was fixed by other means. Requires an admin API key.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param confirmationID path string true "Confirmation ID" example("ABC123")
+// @Param repair body models.RepairTicketRequest true "Field changes"
+// @Success 200 {object} models.FlightTicket "Repaired ticket"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} models.ErrorResponse "Ticket is not quarantined"
+// @Failure 422 {object} models.ErrorResponse "Document still invalid"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 501 {object} models.ErrorResponse "Quarantine not supported by storage backend"
+// @Router /admin/quarantine/{confirmationID}/repair [post]
+func (h *QuarantineHandler) RepairQuarantined(w http.ResponseWriter, r *http.Request) {
+	quarantine, ok := h.quarantineRepository(w)
+	if !ok {
+		return
+	}
+
+	var req models.RepairTicketRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid JSON payload"})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid repair", Message: err.Error()})
+		return
+	}
+
+	entry, ok := h.requireQuarantined(w, r, quarantine)
+	if !ok {
+		return
+	}
+
+	ticket, err := quarantine.RepairQuarantined(r.Context(), entry.ConfirmationID, req.Set, req.Remove)
+	if errors.Is(err, services.ErrInvalidTicketDocument) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Ticket document is still invalid", Message: err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to repair quarantined ticket %s: %v", entry.ConfirmationID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to repair ticket"})
+		return
+	}
+
+	log.Printf("Quarantined ticket %s repaired by %s", entry.ConfirmationID, requestActor(r))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ticket)
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/quota"
+)
+
+type QuotaHandler struct {
+	limiter *quota.Limiter
+}
+
+func NewQuotaHandler(limiter *quota.Limiter) *QuotaHandler {
+	return &QuotaHandler{
+		limiter: limiter,
+	}
+}
+
+// GetQuota handles GET /quota
+// @Summary Get the booking quota of the caller
+// @Description How many tickets the calling API key may still book today. Requests without a key share the anonymous quota. Limits reset at midnight UTC.
+// @Tags tickets
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Success 200 {object} models.QuotaStatus "Booking quota"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /quota [get]
+func (h *QuotaHandler) GetQuota(w http.ResponseWriter, r *http.Request) {
+	name := quota.CallerName(r)
+	status, err := h.limiter.Status(r.Context(), name)
+	if err != nil {
+		log.Printf("Failed to read booking quota of %s: %v", name, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to read booking quota"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(status)
+}
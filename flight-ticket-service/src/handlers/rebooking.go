@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"flight-ticket-service/src/changefeed"
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/network"
+	"flight-ticket-service/src/services"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// rebookingLockTTL bounds the edit lock taken while a rebooking is performed
+const rebookingLockTTL = 30 * time.Second
+
+type RebookingHandler struct {
+	tickets *TicketHandler
+	network *network.Network
+	events  *changefeed.Fanout // nil when the change feed service publishes Firestore changes
+
+	// mu serializes rebookings on backends without ticket locks
+	mu sync.Mutex
+}
+
+func NewRebookingHandler(tickets *TicketHandler, routes *network.Network, events *changefeed.Fanout) *RebookingHandler {
+	return &RebookingHandler{tickets: tickets, network: routes, events: events}
+}
+
+// GetRebookingOptions handles GET /ticket/{confirmationID}/rebooking-options
+// @Summary List the rebooking options of a disrupted ticket
+// @Description List the flights a DISRUPTED ticket can be moved to: direct flights on the same route departing later, within 3 days of the disrupted departure date, with seats left for the ticket's passengers. Flights come from the route network, so they are the flights other tickets are booked on. Rebooking keeps the ticket's price. Meant for agents acting for the traveller, who accept an option with POST /ticket/{confirmationID}/rebooking-options/{optionID}/accept.
+// @Tags tickets
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param confirmationID path string true "Ticket confirmation ID" example("ABC123")
+// @Success 200 {object} models.RebookingOptionsResponse "Rebooking options, earliest first"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 404 {object} models.ErrorResponse "Ticket not found"
+// @Failure 409 {object} models.ErrorResponse "Ticket is not disrupted"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /ticket/{confirmationID}/rebooking-options [get]
+func (h *RebookingHandler) GetRebookingOptions(w http.ResponseWriter, r *http.Request) {
+	ticket, ok := h.disruptedTicket(w, r, chi.URLParam(r, "confirmationID"))
+	if !ok {
+		return
+	}
+	options, err := h.options(r.Context(), ticket)
+	if err != nil {
+		log.Printf("Failed to find rebooking options of ticket %s: %v", ticket.ConfirmationID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to find rebooking options"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.RebookingOptionsResponse{
+		ConfirmationID: ticket.ConfirmationID,
+		FlightNumber:   ticket.FlightNumber,
+		DepartureTime:  ticket.DepartureTime,
+		Options:        options,
+		Count:          len(options),
+	})
+}
+
+// AcceptRebookingOption handles POST /ticket/{confirmationID}/rebooking-options/{optionID}/accept
+// @Summary Rebook a disrupted ticket
+// @Description Move a DISRUPTED ticket to one of its rebooking options, in one step: the option is checked again, the passengers' seats move to the new flight, and the ticket is CONFIRMED on it at the same price. Seats assigned on the disrupted flight are freed. The ticket is locked for the duration, so a retried request finds it confirmed and gets 409. Send X-Lock-Token when holding the ticket's edit lock.
+// @Tags tickets
+// @Produce json,xml,application/msgpack
+// @Security ApiKeyAuth || BearerAuth
+// @Param confirmationID path string true "Ticket confirmation ID" example("ABC123")
+// @Param optionID path string true "Rebooking option ID" example("AA1250-202412251430")
+// @Param X-Lock-Token header string false "Token of the ticket's edit lock, required while it is locked"
+// @Success 200 {object} models.FlightTicket "Rebooked ticket"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 404 {object} models.ErrorResponse "Ticket or option not found"
+// @Failure 409 {object} models.ErrorResponse "Ticket is not disrupted, or the flight has no seats left"
+// @Failure 423 {object} models.ErrorResponse "Ticket is locked by another caller"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /ticket/{confirmationID}/rebooking-options/{optionID}/accept [post]
+func (h *RebookingHandler) AcceptRebookingOption(w http.ResponseWriter, r *http.Request) {
+	confirmationID := chi.URLParam(r, "confirmationID")
+	optionID := chi.URLParam(r, "optionID")
+	release, ok := h.lock(w, r, confirmationID)
+	if !ok {
+		return
+	}
+	defer release()
+
+	previous, ok := h.disruptedTicket(w, r, confirmationID)
+	if !ok {
+		return
+	}
+	options, err := h.options(r.Context(), previous)
+	if err != nil {
+		log.Printf("Failed to find rebooking options of ticket %s: %v", confirmationID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to find rebooking options"})
+		return
+	}
+	var option *models.RebookingOption
+	for i := range options {
+		if options[i].ID == optionID {
+			option = &options[i]
+		}
+	}
+	if option == nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Error:   "Rebooking option not found",
+			Message: "the flight departed or has no seats left; list the options again",
+		})
+		return
+	}
+
+	// Seat labels name seats on the disrupted flight; the cabin and other labels stay
+	labels := make(map[string]string, len(previous.Labels))
+	for key, value := range previous.Labels {
+		if !models.IsSeatLabel(key) {
+			labels[key] = value
+		}
+	}
+	departureDate, _ := time.Parse("2006-01-02", option.DepartureDate)
+	updates := map[string]interface{}{
+		"flight_number":  option.FlightNumber,
+		"departure_date": departureDate,
+		"departure_time": option.DepartureTime,
+		"status":         "CONFIRMED",
+		"labels":         labels,
+	}
+
+	actor := requestActor(r)
+	booked := bookedTicket(previous, updates)
+	if err := h.tickets.inventory.Change(r.Context(), previous, booked, actor); err != nil {
+		writeInventoryError(w, err)
+		return
+	}
+	if err := h.tickets.repository.UpdateTicket(r.Context(), confirmationID, updates); err != nil {
+		log.Printf("Failed to rebook ticket %s: %v", confirmationID, err)
+		if err := h.tickets.inventory.Change(r.Context(), booked, previous, actor); err != nil {
+			log.Printf("Failed to restore seats of ticket %s: %v", confirmationID, err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to rebook ticket"})
+		return
+	}
+	h.tickets.unassignSeats(r.Context(), previous)
+
+	ticket, err := h.tickets.repository.GetTicket(r.Context(), confirmationID)
+	if err != nil {
+		log.Printf("Failed to get rebooked ticket %s: %v", confirmationID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Ticket rebooked but failed to retrieve"})
+		return
+	}
+	if h.events != nil {
+		event, err := changefeed.NewUpdateEvent(previous, ticket, []string{"flight_number", "departure_date", "departure_time", "status", "labels", "updated_at"})
+		if err == nil {
+			err = h.events.Publish(r.Context(), event)
+		}
+		if err != nil {
+			log.Printf("Failed to publish rebooking of ticket %s: %v", confirmationID, err)
+		}
+	}
+
+	h.tickets.annotate(ticket)
+	h.tickets.encoders.Write(w, r, http.StatusOK, ticket)
+}
+
+// disruptedTicket returns the ticket, writing an error response when it is
+// missing or not disrupted
+func (h *RebookingHandler) disruptedTicket(w http.ResponseWriter, r *http.Request, confirmationID string) (*models.FlightTicket, bool) {
+	ticket, err := h.tickets.repository.GetTicket(r.Context(), confirmationID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Ticket not found"})
+		return nil, false
+	}
+	if ticket.Status != models.DisruptedStatus {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Error:   "Ticket is not disrupted",
+			Message: "only DISRUPTED tickets can be rebooked; the ticket is " + ticket.Status,
+		})
+		return nil, false
+	}
+	return ticket, true
+}
+
+// options returns the direct flights on the ticket's route departing after
+// now within RebookingDays of its departure date, other than its own flight,
+// that have seats left for its passengers
+func (h *RebookingHandler) options(ctx context.Context, ticket *models.FlightTicket) ([]models.RebookingOption, error) {
+	graph, err := h.network.Graph(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	options := []models.RebookingOption{}
+	for day := 0; day < models.RebookingDays; day++ {
+		date := ticket.DepartureDate.AddDate(0, 0, day)
+		for _, route := range graph.Search(ticket.Origin, ticket.Destination, date, h.tickets.rules) {
+			if route.Stops != 0 {
+				continue
+			}
+			flight := route.Flights[0]
+			departureDate := flight.DepartureTime.UTC().Format("2006-01-02")
+			if !flight.DepartureTime.After(now) || (flight.FlightNumber == ticket.FlightNumber && departureDate == ticket.DepartureDate.Format("2006-01-02")) {
+				continue
+			}
+
+			if h.tickets.inventory.Enabled() {
+				rebooked := *ticket
+				rebooked.FlightNumber = flight.FlightNumber
+				rebooked.DepartureDate, _ = time.Parse("2006-01-02", departureDate)
+				rebooked.DepartureTime = flight.DepartureTime
+				rebooked.Status = "CONFIRMED"
+				if err := h.tickets.inventory.Check(ctx, ticket, &rebooked); err != nil {
+					if errors.Is(err, services.ErrInsufficientSeats) || errors.Is(err, services.ErrSalesFrozen) {
+						continue
+					}
+					return nil, err
+				}
+			}
+
+			options = append(options, models.RebookingOption{
+				ID:              models.RebookingOptionID(flight.FlightNumber, flight.DepartureTime),
+				FlightNumber:    flight.FlightNumber,
+				Origin:          flight.Origin,
+				Destination:     flight.Destination,
+				DepartureDate:   departureDate,
+				DepartureTime:   flight.DepartureTime,
+				ArrivalTime:     flight.ArrivalTime,
+				DurationMinutes: flight.DurationMinutes,
+				DelayMinutes:    int(flight.DepartureTime.Sub(ticket.DepartureTime).Minutes()),
+			})
+		}
+	}
+	return options, nil
+}
+
+// lock keeps other writers off the ticket while it is rebooked, writing an
+// error response when it cannot. A caller holding the ticket's edit lock
+// sends its token; otherwise a short lock is taken and released by the
+// returned function. Backends without locks are serialized in this instance.
+func (h *RebookingHandler) lock(w http.ResponseWriter, r *http.Request, confirmationID string) (func(), bool) {
+	store, ok := services.Capability[services.TicketLockStore](h.tickets.repository)
+	if !ok {
+		h.mu.Lock()
+		return h.mu.Unlock, true
+	}
+	if r.Header.Get(LockTokenHeader) != "" {
+		if !h.tickets.checkLock(w, r, confirmationID) {
+			return nil, false
+		}
+		return func() {}, true
+	}
+
+	lock, _, err := services.AcquireLock(r.Context(), store, confirmationID, requestActor(r), "", rebookingLockTTL)
+	if err != nil {
+		writeLockError(w, err)
+		return nil, false
+	}
+	return func() {
+		if err := services.ReleaseLock(context.Background(), store, confirmationID, lock.Token); err != nil {
+			log.Printf("Failed to release rebooking lock of ticket %s: %v", confirmationID, err)
+		}
+	}, true
+}
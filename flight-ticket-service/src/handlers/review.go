@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"flight-ticket-service/src/auth"
+	"flight-ticket-service/src/changefeed"
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/risk"
+	"flight-ticket-service/src/rules"
+	"flight-ticket-service/src/services"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ReviewHandler lists the bookings held for review and records the decisions of admins
+type ReviewHandler struct {
+	tickets *TicketHandler
+	events  *changefeed.Fanout // nil when the change feed service publishes Firestore changes
+	mu      sync.Mutex         // serializes decisions, so a booking is approved or rejected once
+}
+
+func NewReviewHandler(tickets *TicketHandler, events *changefeed.Fanout) *ReviewHandler {
+	return &ReviewHandler{tickets: tickets, events: events}
+}
+
+// ListReviewQueue handles GET /admin/review-queue
+// @Summary List the bookings held for review
+// @Description List the tickets in REVIEW, oldest first. Bookings are held for review instead of being confirmed when their risk score reaches the review score, or when they break a booking rule saved with review; each ticket carries its score, the risk signals and the rule behind it. Requires an admin API key.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Success 200 {object} models.ReviewQueueResponse "Bookings held for review"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /admin/review-queue [get]
+func (h *ReviewHandler) ListReviewQueue(w http.ResponseWriter, r *http.Request) {
+	tickets, err := services.SearchTickets(r.Context(), h.tickets.repository, models.TicketQuery{Status: models.ReviewStatus})
+	if err != nil {
+		log.Printf("Failed to list tickets held for review: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to list the review queue"})
+		return
+	}
+	sort.Slice(tickets, func(i, j int) bool {
+		if !tickets[i].CreatedAt.Equal(tickets[j].CreatedAt) {
+			return tickets[i].CreatedAt.Before(tickets[j].CreatedAt)
+		}
+		return tickets[i].ConfirmationID < tickets[j].ConfirmationID
+	})
+	for _, ticket := range tickets {
+		ticket.Risk = models.TicketRisk(ticket)
+	}
+	if tickets == nil {
+		tickets = []*models.FlightTicket{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.ReviewQueueResponse{Tickets: tickets, Count: len(tickets)})
+}
+
+// ApproveBooking handles POST /admin/review-queue/{confirmationID}/approve
+// @Summary Approve a booking held for review
+// @Description Confirm a ticket in REVIEW. The reviewer, the name of the admin API key, and the reason are kept in a note on the ticket, which also keeps the risk labels that held it. Send X-Lock-Token when holding the ticket's edit lock. Requires an admin API key.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param confirmationID path string true "Ticket confirmation ID" example("ABC123")
+// @Param decision body models.ReviewDecisionRequest false "Reason of the decision"
+// @Param X-Lock-Token header string false "Token of the ticket's edit lock, required while it is locked"
+// @Success 200 {object} models.FlightTicket "Confirmed ticket"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} models.ErrorResponse "Ticket not found"
+// @Failure 409 {object} models.ErrorResponse "Ticket is not under review, or lock token not current"
+// @Failure 423 {object} models.ErrorResponse "Ticket is locked by another caller"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /admin/review-queue/{confirmationID}/approve [post]
+func (h *ReviewHandler) ApproveBooking(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, true)
+}
+
+// RejectBooking handles POST /admin/review-queue/{confirmationID}/reject
+// @Summary Reject a booking held for review
+// @Description Cancel a ticket in REVIEW and give its seats back. The reviewer, the name of the admin API key, and the reason are kept in a note on the ticket and in the seat ledger. Send X-Lock-Token when holding the ticket's edit lock. Requires an admin API key.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param confirmationID path string true "Ticket confirmation ID" example("ABC123")
+// @Param decision body models.ReviewDecisionRequest false "Reason of the decision"
+// @Param X-Lock-Token header string false "Token of the ticket's edit lock, required while it is locked"
+// @Success 200 {object} models.FlightTicket "Cancelled ticket"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} models.ErrorResponse "Ticket not found"
+// @Failure 409 {object} models.ErrorResponse "Ticket is not under review, or lock token not current"
+// @Failure 423 {object} models.ErrorResponse "Ticket is locked by another caller"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /admin/review-queue/{confirmationID}/reject [post]
+func (h *ReviewHandler) RejectBooking(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, false)
+}
+
+// decide confirms or cancels a ticket in REVIEW and records the decision
+func (h *ReviewHandler) decide(w http.ResponseWriter, r *http.Request, approve bool) {
+	confirmationID := chi.URLParam(r, "confirmationID")
+	var req models.ReviewDecisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid JSON payload"})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid review decision", Message: err.Error()})
+		return
+	}
+	if !h.tickets.checkLock(w, r, confirmationID) {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	previous, err := h.tickets.repository.GetTicket(r.Context(), confirmationID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Ticket not found"})
+		return
+	}
+	if previous.Status != models.ReviewStatus {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Ticket is not under review", Message: "the ticket is " + previous.Status})
+		return
+	}
+
+	reviewer := requestActor(r)
+	decision, status := "Rejected", "CANCELLED"
+	if approve {
+		decision, status = "Approved", "CONFIRMED"
+	}
+	if approve {
+		err = h.tickets.repository.UpdateTicket(r.Context(), confirmationID, map[string]interface{}{"status": status})
+	} else {
+		err = h.tickets.repository.DeleteTicket(r.Context(), confirmationID)
+	}
+	if err != nil {
+		log.Printf("Failed to record review of ticket %s: %v", confirmationID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to record the review"})
+		return
+	}
+	if !approve {
+		if h.tickets.inventory.Enabled() {
+			if err := h.tickets.inventory.Release(r.Context(), previous, reviewer); err != nil {
+				log.Printf("Failed to release seats of rejected ticket %s: %v", confirmationID, err)
+			}
+		}
+		h.tickets.unassignSeats(r.Context(), previous)
+	}
+	log.Printf("%s booking %s held for review, by %s", decision, confirmationID, reviewer)
+
+	// The note is the audit trail of the decision
+	if notes, ok := services.Capability[services.NoteRepository](h.tickets.repository); ok {
+		text := decision + " after review by " + reviewer
+		if req.Reason != "" {
+			text += ": " + req.Reason
+		}
+		note, err := services.NewNote(confirmationID, reviewer, text)
+		if err == nil {
+			err = notes.CreateNote(r.Context(), note)
+		}
+		if err != nil {
+			log.Printf("Failed to record review note of ticket %s: %v", confirmationID, err)
+		}
+	}
+
+	ticket, err := h.tickets.repository.GetTicket(r.Context(), confirmationID)
+	if err != nil {
+		log.Printf("Failed to get reviewed ticket %s: %v", confirmationID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Ticket reviewed but failed to retrieve"})
+		return
+	}
+	if h.events != nil {
+		event, err := changefeed.NewUpdateEvent(previous, ticket, []string{"status", "updated_at"})
+		if err == nil {
+			err = h.events.Publish(r.Context(), event)
+		}
+		if err != nil {
+			log.Printf("Failed to publish review of ticket %s: %v", confirmationID, err)
+		}
+	}
+
+	h.tickets.annotate(ticket)
+	ticket.Risk = models.TicketRisk(ticket)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ticket)
+}
+
+// holdForReview scores a new booking. One that scores high enough, or that
+// breaks a booking rule holding bookings for review, is held in REVIEW with
+// the reserved risk labels instead of being confirmed. Bookings are not held
+// for their score when scoring fails.
+func (h *TicketHandler) holdForReview(r *http.Request, ticket *models.FlightTicket, heldBy *rules.Violation) {
+	var assessment *models.RiskAssessment
+	var review bool
+	if h.risk != nil {
+		var err error
+		assessment, review, err = h.risk.Assess(r.Context(), risk.Booking{
+			Ticket:   ticket,
+			Actor:    requestActor(r),
+			ClientIP: clientIP(r),
+			Time:     time.Now(),
+		})
+		if err != nil {
+			log.Printf("Failed to score the risk of booking %s: %v", ticket.ConfirmationID, err)
+		}
+	}
+	if heldBy != nil {
+		if assessment == nil {
+			assessment = &models.RiskAssessment{}
+		}
+		assessment.Rule = heldBy.Rule
+		review = true
+	}
+	if !review {
+		return
+	}
+
+	ticket.Status = models.ReviewStatus
+	if ticket.Labels == nil {
+		ticket.Labels = make(map[string]string, 3)
+	}
+	for key, value := range assessment.Labels() {
+		ticket.Labels[key] = value
+	}
+	log.Printf("Holding booking %s for review: risk score %d (%s), rule %q", ticket.ConfirmationID, assessment.Score, strings.Join(assessment.Reasons, ", "), assessment.Rule)
+}
+
+// checkReview refuses status changes of tickets held for review, except by
+// admins, so a flagged booking is not confirmed by the caller who made it
+func (h *TicketHandler) checkReview(w http.ResponseWriter, r *http.Request, stored *models.FlightTicket, updates map[string]interface{}) bool {
+	if _, ok := updates["status"]; !ok {
+		return true
+	}
+	if principal, ok := auth.FromContext(r.Context()); ok && principal.Role == auth.RoleAdmin {
+		return true
+	}
+	if stored.Status != models.ReviewStatus {
+		return true
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(models.ErrorResponse{
+		Error:   "Ticket is under review",
+		Message: "the booking was held for review; an admin approves or rejects it",
+	})
+	return false
+}
+
+// clientIP returns the caller's IP address. Behind Cloud Run the front end
+// appends the address it received the request from to X-Forwarded-For, so
+// the last entry is the one the caller cannot forge.
+func clientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		entries := strings.Split(forwarded, ",")
+		return strings.TrimSpace(entries[len(entries)-1])
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
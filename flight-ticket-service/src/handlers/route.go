@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/network"
+)
+
+type RouteHandler struct {
+	network *network.Network
+	times   network.ConnectionTimes
+}
+
+func NewRouteHandler(routes *network.Network, times network.ConnectionTimes) *RouteHandler {
+	return &RouteHandler{network: routes, times: times}
+}
+
+// SearchRoutes handles GET /routes/search
+// @Summary Search direct and one-stop connections
+// @Description Search the route network for direct and one-stop options from one airport to another, with the first flight departing on the date (UTC). The network holds the flights that tickets are booked on, with arrival times estimated from the distance between the airports. Connections need at least the minimum connection time of the connecting airport's min_connection_time rules, or 45 minutes without one, and at most 24 hours. Other bookings show up when the network is next rebuilt, within ROUTES_REFRESH_INTERVAL.
+// @Tags routes
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param origin query string true "Origin airport code" example(JFK)
+// @Param destination query string true "Destination airport code" example(LAX)
+// @Param date query string true "Departure date of the first flight (YYYY-MM-DD)" example(2024-07-15)
+// @Success 200 {object} models.RouteSearchResponse "Direct options, then one-stop options"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /routes/search [get]
+func (h *RouteHandler) SearchRoutes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	query := r.URL.Query()
+	origin := strings.ToUpper(strings.TrimSpace(query.Get("origin")))
+	destination := strings.ToUpper(strings.TrimSpace(query.Get("destination")))
+	if !models.ValidateAirportCode(origin) || !models.ValidateAirportCode(destination) || origin == destination {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Error:   "Invalid airport codes",
+			Message: "origin and destination must be different 3-letter IATA codes",
+		})
+		return
+	}
+	date, err := time.Parse("2006-01-02", query.Get("date"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid date", Message: "Use YYYY-MM-DD format"})
+		return
+	}
+
+	graph, err := h.network.Graph(r.Context())
+	if err != nil {
+		log.Printf("Failed to search routes: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to search routes"})
+		return
+	}
+	options := graph.Search(origin, destination, date, h.times)
+	if options == nil {
+		options = []*models.RouteOption{}
+	}
+	json.NewEncoder(w).Encode(models.RouteSearchResponse{
+		Origin:         origin,
+		Destination:    destination,
+		Date:           date.Format("2006-01-02"),
+		Options:        options,
+		Count:          len(options),
+		GraphUpdatedAt: graph.BuiltAt(),
+	})
+}
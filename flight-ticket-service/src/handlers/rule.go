@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/rules"
+	"flight-ticket-service/src/tenants"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RuleHandler lists and manages the booking rules
+type RuleHandler struct {
+	engine *rules.Engine
+}
+
+func NewRuleHandler(engine *rules.Engine) *RuleHandler {
+	return &RuleHandler{engine: engine}
+}
+
+// checkBookingRules refuses a ticket that breaks the booking rules or those of the caller's tenant, writing an error response
+func (h *TicketHandler) checkBookingRules(w http.ResponseWriter, r *http.Request, ticket *models.FlightTicket) bool {
+	held, ok := h.checkNewBooking(w, r, ticket)
+	if ok && held != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Booking rule violated", Message: held.Error()})
+		return false
+	}
+	return ok
+}
+
+// checkNewBooking checks a new booking like checkBookingRules, but returns
+// the violation of a rule that holds bookings for review instead of refusing
+// the booking
+func (h *TicketHandler) checkNewBooking(w http.ResponseWriter, r *http.Request, ticket *models.FlightTicket) (*rules.Violation, bool) {
+	err := h.rules.Check(r.Context(), h.repository, ticket, tenants.FromContext(r.Context()), time.Now())
+	if err == nil {
+		return nil, true
+	}
+
+	var violation *rules.Violation
+	if errors.As(err, &violation) && violation.Review {
+		return violation, true
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if violation != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Booking rule violated", Message: err.Error()})
+		return nil, false
+	}
+	log.Printf("Failed to check booking rules for %s: %v", ticket.ConfirmationID, err)
+	w.WriteHeader(http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to check booking rules"})
+	return nil, false
+}
+
+// GetRules handles GET /rules
+// @Summary List active booking rules
+// @Description List the enabled booking rules that bookings and changes are checked against, with the booking rules of the caller's tenant. Tickets that break a rule are refused with 422.
+// @Tags rules
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Success 200 {object} models.RulesResponse "Active booking rules"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Router /rules [get]
+func (h *RuleHandler) GetRules(w http.ResponseWriter, r *http.Request) {
+	list := h.engine.List(false)
+	response := models.RulesResponse{Rules: list, Count: len(list)}
+	if tenant := tenants.FromContext(r.Context()); tenant != nil {
+		response.Tenant = &models.TenantRules{ID: tenant.ID, BookingRules: tenant.BookingRules}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// ListRules handles GET /admin/rules
+// @Summary List booking rules
+// @Description List every booking rule, including disabled ones, as cached by this instance. Requires an admin API key.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Success 200 {object} models.RulesResponse "Booking rules"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Router /admin/rules [get]
+func (h *RuleHandler) ListRules(w http.ResponseWriter, r *http.Request) {
+	list := h.engine.List(true)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.RulesResponse{Rules: list, Count: len(list)})
+}
+
+// SaveRule handles PUT /admin/rules/{ruleID}
+// @Summary Create or replace a booking rule
+// @Description Save a booking rule: the most passengers per ticket, the minimum connection time between tickets with the same itinerary label, how far ahead flights can be booked, or a blocked route. Origin and destination limit the rule to a route. With review, new bookings that break the rule are held in REVIEW for an admin instead of being refused. Existing tickets are not re-checked. Other instances pick the change up within RULES_REFRESH_INTERVAL. Requires an admin API key.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param ruleID path string true "Rule ID (lowercase letters, digits, underscores and dashes, starting with a letter)" example("max-9-passengers")
+// @Param rule body models.BookingRuleRequest true "Booking rule"
+// @Success 200 {object} models.BookingRule "Saved rule"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /admin/rules/{ruleID} [put]
+func (h *RuleHandler) SaveRule(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "ruleID")
+	var req models.BookingRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid JSON payload"})
+		return
+	}
+	rule, err := req.Rule(id)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid booking rule", Message: err.Error()})
+		return
+	}
+	rule.UpdatedAt = time.Now().UTC()
+
+	if err := h.engine.Save(r.Context(), rule); err != nil {
+		log.Printf("Failed to save booking rule %s: %v", id, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to save booking rule"})
+		return
+	}
+	log.Printf("Booking rule %s saved by %s", id, requestActor(r))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rule)
+}
+
+// DeleteRule handles DELETE /admin/rules/{ruleID}
+// @Summary Delete a booking rule
+// @Description Remove a booking rule; to keep it for later, save it with enabled set to false instead. Requires an admin API key.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param ruleID path string true "Rule ID" example("max-9-passengers")
+// @Success 200 {object} models.SuccessResponse "Deleted rule"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} models.ErrorResponse "Booking rule not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /admin/rules/{ruleID} [delete]
+func (h *RuleHandler) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "ruleID")
+	if err := h.engine.Delete(r.Context(), id); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		if errors.Is(err, rules.ErrNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Booking rule not found"})
+			return
+		}
+		log.Printf("Failed to delete booking rule %s: %v", id, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to delete booking rule"})
+		return
+	}
+	log.Printf("Booking rule %s deleted by %s", id, requestActor(r))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.SuccessResponse{Message: "Booking rule deleted successfully"})
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"flight-ticket-service/src/auth"
+	"flight-ticket-service/src/debuglog"
+	"flight-ticket-service/src/models"
+)
+
+// SandboxHeader asks for a request to be served from the sandbox
+const SandboxHeader = "X-Sandbox"
+
+// SandboxMiddleware serves sandbox requests with the sandbox handler instead
+// of the live routes. Requests are sandboxed when they send "X-Sandbox: true"
+// or use one of the given API keys, which cannot opt out. The sandbox handler
+// is nil when the sandbox is disabled; requests for it are then refused.
+func SandboxMiddleware(sandbox http.Handler, keys map[string]bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requested, err := sandboxRequested(r, keys)
+			if err != nil {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid " + SandboxHeader, Message: SandboxHeader + " must be true or false"})
+				return
+			}
+			if !requested {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if sandbox == nil {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Sandbox mode is not enabled"})
+				return
+			}
+
+			debuglog.Printf(r.Context(), "Serving from the sandbox")
+			w.Header().Set(SandboxHeader, "true")
+			sandbox.ServeHTTP(w, r)
+		})
+	}
+}
+
+// sandboxRequested reports whether the request's key or header selects the sandbox
+func sandboxRequested(r *http.Request, keys map[string]bool) (bool, error) {
+	if principal, ok := auth.FromContext(r.Context()); ok && keys[principal.Name] {
+		return true, nil
+	}
+	value := r.Header.Get(SandboxHeader)
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+// SandboxNotAvailable answers sandbox requests for endpoints that only serve live tickets
+func SandboxNotAvailable(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Not available in sandbox mode", Message: r.Method + " " + r.URL.Path + " only serves live tickets"})
+}
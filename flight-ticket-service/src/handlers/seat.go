@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/seats"
+	"flight-ticket-service/src/services"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type SeatHandler struct {
+	store seats.Store
+}
+
+func NewSeatHandler(store seats.Store) *SeatHandler {
+	return &SeatHandler{store: store}
+}
+
+// GetSeats handles GET /flights/{flightNumber}/{date}/seats
+// @Summary Get the taken seats of a departure
+// @Description List the seats of a departure that are held while a booking is made or assigned to a ticket, so shoppers can pick free ones. Held seats are freed when their hold expires.
+// @Tags seats
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param flightNumber path string true "Flight number" example("AA1234")
+// @Param date path string true "Scheduled departure date in YYYY-MM-DD format" example("2024-12-25")
+// @Success 200 {object} models.SeatMapResponse "Taken seats"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /flights/{flightNumber}/{date}/seats [get]
+func (h *SeatHandler) GetSeats(w http.ResponseWriter, r *http.Request) {
+	flightNumber, date, ok := departureParams(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := h.store.List(r.Context(), flightNumber, date, time.Now())
+	if err != nil {
+		log.Printf("Failed to list seats of %s on %s: %v", flightNumber, date, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to list seats"})
+		return
+	}
+	if list == nil {
+		list = []models.SeatStatus{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.SeatMapResponse{
+		FlightNumber: flightNumber,
+		Date:         date,
+		Seats:        list,
+		Count:        len(list),
+	})
+}
+
+// HoldSeats handles POST /flights/{flightNumber}/{date}/seats/hold
+// @Summary Hold seats on a departure
+// @Description Hold up to 9 seats for a few minutes (10 by default, at most 30) while a booking is made, so no other shopper can select them. Book with the hold's ID as seat_hold_id to assign the seats to the ticket; seats not booked before the hold expires are freed. Seats held by another unexpired hold or assigned to a ticket are refused with 409 Conflict.
+// @Tags seats
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param flightNumber path string true "Flight number" example("AA1234")
+// @Param date path string true "Scheduled departure date in YYYY-MM-DD format" example("2024-12-25")
+// @Param hold body models.SeatHoldRequest true "Seats to hold"
+// @Success 201 {object} models.SeatHold "Seats held"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 409 {object} models.ErrorResponse "Seat already taken"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /flights/{flightNumber}/{date}/seats/hold [post]
+func (h *SeatHandler) HoldSeats(w http.ResponseWriter, r *http.Request) {
+	flightNumber, date, ok := departureParams(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.SeatHoldRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid JSON payload"})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid seat hold", Message: err.Error()})
+		return
+	}
+
+	now := time.Now()
+	hold, err := seats.NewHold(flightNumber, date, req.Seats, requestActor(r), now, time.Duration(req.TTLSeconds)*time.Second)
+	if err == nil {
+		err = h.store.Hold(r.Context(), hold, now)
+	}
+	if errors.Is(err, seats.ErrSeatTaken) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Seat already taken", Message: err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to hold seats on %s on %s: %v", flightNumber, date, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to hold seats"})
+		return
+	}
+	log.Printf("Seats %s held on %s on %s by %s until %s", strings.Join(hold.Seats, " "), flightNumber, date, hold.Holder, hold.ExpiresAt.Format(time.RFC3339))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(hold)
+}
+
+// ReleaseSeatHold handles DELETE /flights/{flightNumber}/{date}/seats/hold/{holdID}
+// @Summary Release a seat hold
+// @Description Free the seats of a hold before it expires, e.g. when the shopper picks other seats. Seats already booked stay assigned to their ticket.
+// @Tags seats
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param flightNumber path string true "Flight number" example("AA1234")
+// @Param date path string true "Scheduled departure date in YYYY-MM-DD format" example("2024-12-25")
+// @Param holdID path string true "Seat hold ID" example(3f9a1c0e7b2d4a6f8e1c3b5d7f9a0c2e)
+// @Success 200 {object} models.SuccessResponse "Hold released"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 404 {object} models.ErrorResponse "Seat hold not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /flights/{flightNumber}/{date}/seats/hold/{holdID} [delete]
+func (h *SeatHandler) ReleaseSeatHold(w http.ResponseWriter, r *http.Request) {
+	flightNumber, date, ok := departureParams(w, r)
+	if !ok {
+		return
+	}
+
+	id := chi.URLParam(r, "holdID")
+	hold, err := h.store.GetHold(r.Context(), id)
+	if err == nil && (hold.FlightNumber != flightNumber || hold.Date != date) {
+		err = seats.ErrNotFound
+	}
+	if err == nil {
+		err = h.store.Release(r.Context(), id)
+	}
+	if errors.Is(err, seats.ErrNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Seat hold not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to release seat hold %s: %v", id, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to release seat hold"})
+		return
+	}
+	log.Printf("Seat hold %s on %s on %s released by %s", id, flightNumber, date, requestActor(r))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.SuccessResponse{Message: "Seat hold released successfully"})
+}
+
+// checkSeatHold returns the seat hold the booking refers to, if any, writing
+// an error response unless it is an unexpired hold on the ticket's departure
+// with no more seats than passengers. Its seats are recorded in labels, by passenger.
+func (h *TicketHandler) checkSeatHold(w http.ResponseWriter, r *http.Request, req *models.CreateTicketRequest, ticket *models.FlightTicket) (*models.SeatHold, bool) {
+	if req.SeatHoldID == "" {
+		return nil, true
+	}
+
+	hold, err := h.seats.GetHold(r.Context(), req.SeatHoldID)
+	if err != nil && !errors.Is(err, seats.ErrNotFound) {
+		log.Printf("Failed to get seat hold %s: %v", req.SeatHoldID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to check seat hold"})
+		return nil, false
+	}
+	message := ""
+	switch {
+	case err != nil:
+		message = "seat hold not found"
+	case hold.FlightNumber != ticket.FlightNumber || hold.Date != ticket.DepartureDate.Format("2006-01-02"):
+		message = fmt.Sprintf("the seats are held on %s on %s", hold.FlightNumber, hold.Date)
+	case len(hold.Seats) > ticket.Seats():
+		message = fmt.Sprintf("%d seats are held for %d passengers needing a seat", len(hold.Seats), ticket.Seats())
+	}
+	if message != "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid seat_hold_id", Message: message})
+		return nil, false
+	}
+	if hold.Expired(time.Now()) {
+		writeSeatHoldExpired(w)
+		return nil, false
+	}
+
+	if ticket.Labels == nil {
+		ticket.Labels = make(map[string]string, len(hold.Seats))
+	}
+	for i, seat := range hold.Seats {
+		ticket.Labels[models.SeatLabel(i+1)] = strings.ToLower(seat)
+	}
+	return hold, true
+}
+
+// writeSeatHoldExpired writes the response for bookings whose seat hold lapsed
+func writeSeatHoldExpired(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Seat hold expired", Message: "hold the seats again and retry"})
+}
+
+// unassignSeats frees the seats assigned to a ticket, logging failures
+func (h *TicketHandler) unassignSeats(ctx context.Context, ticket *models.FlightTicket) {
+	freeSeats(ctx, h.seats, ticket)
+}
+
+// freeSeats frees the seats assigned to a ticket in a seat store, logging failures
+func freeSeats(ctx context.Context, store seats.Store, ticket *models.FlightTicket) {
+	if err := services.UnassignTicketSeats(ctx, store, ticket); err != nil {
+		log.Printf("Failed to free seats of ticket %s: %v", ticket.ConfirmationID, err)
+	}
+}
+
+// leaveSeats drops the seat labels from the updates of a ticket that move it
+// to another flight or date, or cancel it, as its seats are on the departure
+// it leaves; it reports whether the ticket's seats are to be freed
+func leaveSeats(ticket *models.FlightTicket, updates map[string]interface{}) bool {
+	if len(models.TicketSeats(ticket)) == 0 {
+		return false
+	}
+	booked := bookedTicket(ticket, updates)
+	if booked.FlightNumber == ticket.FlightNumber && booked.DepartureDate.Format("2006-01-02") == ticket.DepartureDate.Format("2006-01-02") &&
+		booked.Status != "CANCELLED" {
+		return false
+	}
+	labels := ticket.Labels
+	if replaced, ok := updates["labels"].(map[string]string); ok {
+		labels = replaced
+	}
+	kept := make(map[string]string, len(labels))
+	for key, value := range labels {
+		if !models.IsSeatLabel(key) {
+			kept[key] = value
+		}
+	}
+	updates["labels"] = kept
+	return true
+}
+
+// departureParams returns the flight number and date of the path, writing an error response when the date is invalid
+func departureParams(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	flightNumber := strings.ToUpper(chi.URLParam(r, "flightNumber"))
+	date, err := time.Parse("2006-01-02", chi.URLParam(r, "date"))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid date format", Message: "Use YYYY-MM-DD format"})
+		return "", "", false
+	}
+	return flightNumber, date.Format("2006-01-02"), true
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"flight-ticket-service/src/models"
+
+	"github.com/swaggo/swag"
+)
+
+// PublicURLFromEnv reads PUBLIC_URL, the external base URL of the service
+// (e.g. a custom domain in front of Cloud Run). It returns nil when unset.
+func PublicURLFromEnv() (*url.URL, error) {
+	value := strings.TrimSpace(os.Getenv("PUBLIC_URL"))
+	if value == "" {
+		return nil, nil
+	}
+	publicURL, err := url.Parse(value)
+	if err != nil || (publicURL.Scheme != "http" && publicURL.Scheme != "https") || publicURL.Host == "" {
+		return nil, fmt.Errorf("invalid PUBLIC_URL %q: must be an absolute http or https URL", value)
+	}
+	return publicURL, nil
+}
+
+// SwaggerDoc serves the OpenAPI spec with the host and scheme the caller used,
+// so "Try it out" in the Swagger UI targets the Cloud Run URL rather than the
+// localhost:8080 compiled into the spec. A non-nil publicURL takes precedence.
+func SwaggerDoc(publicURL *url.URL) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		doc, err := swag.ReadDoc()
+		var spec map[string]interface{}
+		if err == nil {
+			err = json.Unmarshal([]byte(doc), &spec)
+		}
+		if err != nil {
+			log.Printf("Failed to read OpenAPI spec: %v", err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "OpenAPI spec unavailable"})
+			return
+		}
+
+		host, scheme := requestOrigin(r)
+		if publicURL != nil {
+			host, scheme = publicURL.Host, publicURL.Scheme
+		}
+		spec["host"] = host
+		spec["schemes"] = []string{scheme}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		json.NewEncoder(w).Encode(spec)
+	}
+}
+
+// requestOrigin returns the host and scheme the client used. Cloud Run
+// terminates TLS and reports the original scheme in X-Forwarded-Proto.
+func requestOrigin(r *http.Request) (string, string) {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+	host := r.Host
+	if forwarded := r.Header.Get("X-Forwarded-Host"); forwarded != "" {
+		host = forwarded
+	}
+	return host, scheme
+}
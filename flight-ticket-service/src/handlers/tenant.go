@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/tenants"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// TenantHandler manages the configuration overrides of tenants
+type TenantHandler struct {
+	registry *tenants.Registry
+}
+
+func NewTenantHandler(registry *tenants.Registry) *TenantHandler {
+	return &TenantHandler{registry: registry}
+}
+
+// checkReservedLabels refuses labels set by the service, writing an error response
+func checkReservedLabels(w http.ResponseWriter, labels map[string]string) bool {
+	if _, ok := labels[models.TenantLabel]; ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Error:   "Invalid labels",
+			Message: fmt.Sprintf("the %s label is reserved; it is set from the API key", models.TenantLabel),
+		})
+		return false
+	}
+	if _, ok := labels[models.CabinLabel]; ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Error:   "Invalid labels",
+			Message: fmt.Sprintf("the %s label is reserved; it is set from upgrade offers", models.CabinLabel),
+		})
+		return false
+	}
+	for key := range labels {
+		if models.IsPassengerTypeLabel(key) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.ErrorResponse{
+				Error:   "Invalid labels",
+				Message: fmt.Sprintf("the %s label is reserved; it is set from passenger_types", key),
+			})
+			return false
+		}
+		if models.IsTravelerLabel(key) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.ErrorResponse{
+				Error:   "Invalid labels",
+				Message: fmt.Sprintf("the %s label is reserved; it is set from traveler_ids", key),
+			})
+			return false
+		}
+		if models.IsSpecialRequestLabel(key) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.ErrorResponse{
+				Error:   "Invalid labels",
+				Message: fmt.Sprintf("the %s label is reserved; it is set from special_requests", key),
+			})
+			return false
+		}
+		if models.IsRiskLabel(key) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.ErrorResponse{
+				Error:   "Invalid labels",
+				Message: fmt.Sprintf("the %s label is reserved; it is set by risk scoring", key),
+			})
+			return false
+		}
+		if models.IsSeatLabel(key) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.ErrorResponse{
+				Error:   "Invalid labels",
+				Message: fmt.Sprintf("the %s label is reserved; it is set from seat_hold_id", key),
+			})
+			return false
+		}
+	}
+	return true
+}
+
+// ListTenants handles GET /admin/tenants
+// @Summary List tenants
+// @Description List the tenants and their configuration overrides, as cached by this instance. Requires an admin API key.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Success 200 {object} models.TenantListResponse "Tenants"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Router /admin/tenants [get]
+func (h *TenantHandler) ListTenants(w http.ResponseWriter, r *http.Request) {
+	list := h.registry.List()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.TenantListResponse{Tenants: list, Count: len(list)})
+}
+
+// GetTenant handles GET /admin/tenants/{tenantID}
+// @Summary Get a tenant
+// @Description Get the configuration overrides of a tenant. Requires an admin API key.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param tenantID path string true "Tenant ID" example("acme")
+// @Success 200 {object} models.Tenant "Tenant"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} models.ErrorResponse "Tenant not found"
+// @Router /admin/tenants/{tenantID} [get]
+func (h *TenantHandler) GetTenant(w http.ResponseWriter, r *http.Request) {
+	tenant := h.registry.Tenant(chi.URLParam(r, "tenantID"))
+	if tenant == nil {
+		writeTenantNotFound(w)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tenant)
+}
+
+// SaveTenant handles PUT /admin/tenants/{tenantID}
+// @Summary Create or replace a tenant
+// @Description Set the API keys, notification templates, booking rules and document branding of a tenant. Requests with one of its API keys are checked against its booking rules, and the tickets they book carry the reserved tenant label, which selects the tenant's notification templates. Other instances pick the change up within TENANTS_REFRESH_INTERVAL. Requires an admin API key.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param tenantID path string true "Tenant ID (lowercase letters, digits, underscores and dashes, starting with a letter)" example("acme")
+// @Param tenant body models.TenantRequest true "Tenant"
+// @Success 200 {object} models.Tenant "Saved tenant"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 409 {object} models.ErrorResponse "API key belongs to another tenant"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /admin/tenants/{tenantID} [put]
+func (h *TenantHandler) SaveTenant(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "tenantID")
+	if err := models.ValidateTenantID(id); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid tenant ID", Message: err.Error()})
+		return
+	}
+
+	var req models.TenantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid JSON payload"})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid tenant", Message: err.Error()})
+		return
+	}
+
+	tenant := &models.Tenant{
+		ID:                    id,
+		Name:                  req.Name,
+		APIKeys:               req.APIKeys,
+		NotificationTemplates: req.NotificationTemplates,
+		BookingRules:          req.BookingRules,
+		Branding:              req.Branding,
+		UpdatedAt:             time.Now().UTC(),
+	}
+	if err := h.registry.Save(r.Context(), tenant); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		if errors.Is(err, tenants.ErrKeyInUse) {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "API key in use", Message: err.Error()})
+			return
+		}
+		log.Printf("Failed to save tenant %s: %v", id, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to save tenant"})
+		return
+	}
+	log.Printf("Tenant %s saved by %s", id, requestActor(r))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tenant)
+}
+
+// DeleteTenant handles DELETE /admin/tenants/{tenantID}
+// @Summary Delete a tenant
+// @Description Remove a tenant; its API keys and tickets return to the service defaults. Requires an admin API key.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param tenantID path string true "Tenant ID" example("acme")
+// @Success 200 {object} models.SuccessResponse "Deleted tenant"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} models.ErrorResponse "Tenant not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /admin/tenants/{tenantID} [delete]
+func (h *TenantHandler) DeleteTenant(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "tenantID")
+	if err := h.registry.Delete(r.Context(), id); err != nil {
+		if errors.Is(err, tenants.ErrNotFound) {
+			writeTenantNotFound(w)
+			return
+		}
+		log.Printf("Failed to delete tenant %s: %v", id, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to delete tenant"})
+		return
+	}
+	log.Printf("Tenant %s deleted by %s", id, requestActor(r))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.SuccessResponse{Message: "Tenant deleted successfully"})
+}
+
+func writeTenantNotFound(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Tenant not found"})
+}
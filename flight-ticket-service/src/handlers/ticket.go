@@ -1,40 +1,404 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"flight-ticket-service/src/auth"
+	"flight-ticket-service/src/currency"
+	"flight-ticket-service/src/debuglog"
+	"flight-ticket-service/src/entry"
 	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/opsnotify"
+	"flight-ticket-service/src/pnr"
+	"flight-ticket-service/src/render"
+	"flight-ticket-service/src/risk"
+	"flight-ticket-service/src/rules"
+	"flight-ticket-service/src/scheduling"
+	"flight-ticket-service/src/seats"
 	"flight-ticket-service/src/services"
+	"flight-ticket-service/src/tenants"
+	"flight-ticket-service/src/travelers"
 
 	"github.com/go-chi/chi/v5"
 )
 
 type TicketHandler struct {
-	firestoreService *services.FirestoreService
+	repository services.TicketRepository
+	converter  *currency.Converter
+	scheduler  *scheduling.Scheduler
+	inventory  *services.SeatInventory
+	bookings   *services.BookingStats
+	encoders   *render.Registry
+	rules      *rules.Engine
+	travelers  travelers.Store
+	entryRules *entry.Table
+	seats      seats.Store
+	risk       *risk.Scorer        // nil when bookings are not scored
+	ops        *opsnotify.Notifier // nil when ops events are not posted
 }
 
-func NewTicketHandler(firestoreService *services.FirestoreService) *TicketHandler {
+// TicketHandlerOptions holds the services a TicketHandler prices, checks and
+// books tickets with
+type TicketHandlerOptions struct {
+	Converter  *currency.Converter
+	Scheduler  *scheduling.Scheduler
+	Rules      *rules.Engine
+	Travelers  travelers.Store
+	EntryRules *entry.Table
+	Seats      seats.Store
+	Risk       *risk.Scorer        // nil when bookings are not scored
+	Ops        *opsnotify.Notifier // nil when ops events are not posted
+}
+
+func NewTicketHandler(repository services.TicketRepository, options TicketHandlerOptions) *TicketHandler {
 	return &TicketHandler{
-		firestoreService: firestoreService,
+		repository: repository,
+		converter:  options.Converter,
+		scheduler:  options.Scheduler,
+		inventory:  services.NewSeatInventory(repository),
+		bookings:   services.NewBookingStats(repository),
+		encoders:   render.Default,
+		rules:      options.Rules,
+		travelers:  options.Travelers,
+		entryRules: options.EntryRules,
+		seats:      options.Seats,
+		risk:       options.Risk,
+		ops:        options.Ops,
+	}
+}
+
+// annotate sets the fields computed for responses: the schedule, the
+// passenger types, the assigned seats and, for international flights, the
+// entry requirements of the destination
+func (h *TicketHandler) annotate(ticket *models.FlightTicket) {
+	ticket.Schedule = h.scheduler.Schedule(ticket)
+	if types := models.TicketPassengerTypes(ticket); types.Adults != ticket.Passengers {
+		ticket.PassengerTypes = &types
+	}
+	ticket.SpecialRequests = models.TicketSpecialRequests(ticket)
+	ticket.AssignedSeats = models.TicketSeats(ticket)
+	if cabin := models.TicketCabin(ticket); cabin != models.CabinEconomy {
+		ticket.Cabin = cabin
+	}
+	ticket.International = services.International(ticket.Origin, ticket.Destination)
+	ticket.Advisories = h.entryRules.Advisories(ticket.Origin, ticket.Destination)
+}
+
+// priceTicket sets the ticket price from the per-passenger base fare, converted to the requested currency.
+// Children and infants are charged their share of the fare.
+func (h *TicketHandler) priceTicket(ctx context.Context, ticket *models.FlightTicket, baseFare float64, displayCurrency string) error {
+	if displayCurrency == "" {
+		displayCurrency = currency.BaseCurrency
+	}
+
+	baseAmount := currency.Round(baseFare * models.TicketPassengerTypes(ticket).FareUnits())
+	conversion, err := h.converter.Convert(ctx, baseAmount, currency.BaseCurrency, displayCurrency)
+	if err != nil {
+		return err
+	}
+
+	ticket.Price = &models.Price{
+		Amount:       conversion.Amount,
+		Currency:     conversion.Currency,
+		BaseAmount:   baseAmount,
+		BaseCurrency: currency.BaseCurrency,
+		ExchangeRate: conversion.Rate,
+		RateAsOf:     conversion.RateAsOf,
+	}
+	return nil
+}
+
+// displayPrice converts the ticket's stored base price into the requested currency at the current rate.
+// The stored ticket is not modified.
+func (h *TicketHandler) displayPrice(ctx context.Context, ticket *models.FlightTicket, displayCurrency string) error {
+	if displayCurrency == "" || ticket.Price == nil {
+		return nil
+	}
+
+	conversion, err := h.converter.Convert(ctx, ticket.Price.BaseAmount, ticket.Price.BaseCurrency, displayCurrency)
+	if err != nil {
+		return err
+	}
+
+	price := *ticket.Price
+	price.Amount = conversion.Amount
+	price.Currency = conversion.Currency
+	price.ExchangeRate = conversion.Rate
+	price.RateAsOf = conversion.RateAsOf
+	ticket.Price = &price
+	return nil
+}
+
+// writeCurrencyError writes the response for a failed currency conversion
+func writeCurrencyError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	if errors.Is(err, currency.ErrUnsupportedCurrency) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Error:   "Unsupported currency",
+			Message: "Use a 3-letter ISO 4217 currency code such as USD, EUR or GBP",
+		})
+		return
+	}
+
+	log.Printf("Failed to convert currency: %v", err)
+	w.WriteHeader(http.StatusServiceUnavailable)
+	json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Exchange rates unavailable"})
+}
+
+// writeInventoryError writes the response for seats that could not be held
+func writeInventoryError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	if errors.Is(err, services.ErrInsufficientSeats) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Not enough seats", Message: err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrSalesFrozen) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Sales frozen", Message: err.Error()})
+		return
+	}
+
+	log.Printf("Failed to update seat inventory: %v", err)
+	w.WriteHeader(http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to update seat inventory"})
+}
+
+// checkLock refuses a write to a ticket locked by another caller, writing an error response
+func (h *TicketHandler) checkLock(w http.ResponseWriter, r *http.Request, confirmationID string) bool {
+	if err := services.CheckLock(r.Context(), h.repository, confirmationID, r.Header.Get(LockTokenHeader)); err != nil {
+		debuglog.Printf(r.Context(), "Write to ticket %s refused by its lock: %v", confirmationID, err)
+		writeLockError(w, err)
+		return false
+	}
+	return true
+}
+
+// authenticated reports whether the request carries an API key
+func authenticated(r *http.Request) bool {
+	_, ok := auth.FromContext(r.Context())
+	return ok
+}
+
+// requestActor names the caller in the seat ledger
+func requestActor(r *http.Request) string {
+	principal, _ := auth.FromContext(r.Context())
+	return principal.Name
+}
+
+// bookedTicket returns a copy of the ticket with the updates that affect its seats applied
+func bookedTicket(ticket *models.FlightTicket, updates map[string]interface{}) *models.FlightTicket {
+	booked := *ticket
+	if flightNumber, ok := updates["flight_number"].(string); ok {
+		booked.FlightNumber = flightNumber
+	}
+	if departureDate, ok := updates["departure_date"].(time.Time); ok {
+		booked.DepartureDate = departureDate
+	}
+	if passengers, ok := updates["passengers"].(int); ok {
+		booked.Passengers = passengers
+	}
+	if status, ok := updates["status"].(string); ok {
+		booked.Status = status
+	}
+	return &booked
+}
+
+// previewTicket returns a copy of the ticket with the updates applied, as UpdateTicket would store it
+func previewTicket(ticket *models.FlightTicket, updates map[string]interface{}) *models.FlightTicket {
+	preview := bookedTicket(ticket, updates)
+	if origin, ok := updates["origin"].(string); ok {
+		preview.Origin = origin
+	}
+	if destination, ok := updates["destination"].(string); ok {
+		preview.Destination = destination
+	}
+	if departureTime, ok := updates["departure_time"].(time.Time); ok {
+		preview.DepartureTime = departureTime
+	}
+	if labels, ok := updates["labels"].(map[string]string); ok {
+		preview.Labels = labels
+		if len(labels) == 0 {
+			preview.Labels = nil
+		}
+	}
+	preview.UpdatedAt = time.Now()
+	return preview
+}
+
+// checkRulesUpdate checks a change of route, schedule, passengers or
+// itinerary against the booking rules, the passenger types, the special
+// requests and the assigned seats of the ticket, writing an error response,
+// and keeps the labels set by the service when the ticket's labels are replaced
+func (h *TicketHandler) checkRulesUpdate(w http.ResponseWriter, r *http.Request, stored *models.FlightTicket, updates map[string]interface{}) bool {
+	labels, relabel := updates["labels"].(map[string]string)
+	rebook := false
+	for _, field := range []string{"origin", "destination", "departure_date", "departure_time", "flight_number", "passengers"} {
+		if _, ok := updates[field]; ok {
+			rebook = true
+		}
+	}
+	if !relabel && !rebook {
+		return true
+	}
+
+	// A new passenger count keeps the children, infants, special requests and
+	// seats, so every infant still needs an adult and no passenger with an SSR
+	// or an assigned seat may be dropped
+	if passengers, ok := updates["passengers"].(int); ok {
+		types := models.TicketPassengerTypes(stored)
+		types.Adults = passengers - types.Children - types.Infants
+		if err := types.Validate(); err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.ErrorResponse{
+				Error:   "Invalid passengers",
+				Message: fmt.Sprintf("the ticket has %d children and %d infants: %v", types.Children, types.Infants, err),
+			})
+			return false
+		}
+		for _, request := range models.TicketSpecialRequests(stored) {
+			if request.Passenger > passengers {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(models.ErrorResponse{
+					Error:   "Invalid passengers",
+					Message: fmt.Sprintf("passenger %d has the special request %s", request.Passenger, request.Code),
+				})
+				return false
+			}
+		}
+		if assigned := models.TicketSeats(stored); len(assigned) > passengers-types.Infants {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.ErrorResponse{
+				Error:   "Invalid passengers",
+				Message: fmt.Sprintf("the ticket has %d assigned seats", len(assigned)),
+			})
+			return false
+		}
+	}
+	if relabel && labels[models.ItineraryLabel] != stored.Labels[models.ItineraryLabel] {
+		rebook = true
 	}
+	// Labels set by the service survive relabeling
+	if relabel {
+		kept := make(map[string]string, len(labels)+1)
+		for key, value := range labels {
+			kept[key] = value
+		}
+		for key, value := range stored.Labels {
+			if key == models.TenantLabel || models.IsTravelerLabel(key) || models.IsPassengerTypeLabel(key) || models.IsSpecialRequestLabel(key) || models.IsSeatLabel(key) || key == models.CabinLabel {
+				kept[key] = value
+			}
+		}
+		if len(kept) > len(labels) {
+			updates["labels"] = kept
+		}
+	}
+	return !rebook || h.checkBookingRules(w, r, previewTicket(stored, updates))
+}
+
+// DryRunHeader marks the response of a dry run
+const DryRunHeader = "X-Dry-Run"
+
+// dryRun reads the dry_run query parameter, writing an error response when it is invalid
+func dryRun(w http.ResponseWriter, r *http.Request) (bool, bool) {
+	value := r.URL.Query().Get("dry_run")
+	if value == "" {
+		return false, true
+	}
+	dryRun, err := strconv.ParseBool(value)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid dry_run", Message: "dry_run must be true or false"})
+		return false, false
+	}
+	if dryRun {
+		w.Header().Set(DryRunHeader, "true")
+	}
+	return dryRun, true
+}
+
+// asOf reads the as_of query parameter, writing an error response when it is invalid; zero means now
+func asOf(w http.ResponseWriter, r *http.Request) (time.Time, bool) {
+	value := r.URL.Query().Get("as_of")
+	if value == "" {
+		return time.Time{}, true
+	}
+	at, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid as_of", Message: "as_of must be an RFC 3339 time such as 2024-12-01T00:00:00Z"})
+		return time.Time{}, false
+	}
+	return at, true
+}
+
+// ticketAsOf reconstructs the ticket at a past time from its history, writing an error response when it cannot
+func (h *TicketHandler) ticketAsOf(w http.ResponseWriter, r *http.Request, current *models.FlightTicket, at time.Time) (*models.FlightTicket, bool) {
+	history, ok := services.Capability[services.TicketHistory](h.repository)
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotImplemented)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Ticket history is not supported by the configured storage backend"})
+		return nil, false
+	}
+
+	revisions, err := history.ListRevisions(r.Context(), current.ConfirmationID)
+	if err != nil {
+		log.Printf("Failed to list history of ticket %s: %v", current.ConfirmationID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to retrieve ticket history"})
+		return nil, false
+	}
+
+	ticket, err := services.TicketAsOf(revisions, current, at)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Ticket not found at that time", Message: err.Error()})
+		return nil, false
+	}
+	return ticket, true
 }
 
 // CreateTicket handles POST /ticket
 // @Summary Create a new flight ticket
-// @Description Create a new flight ticket with the provided details
+// @Description Create a new flight ticket with the provided details. Each API key may book a limited number of tickets per day when BOOKING_QUOTA is set. Saved travelers can be booked by ID with traveler_ids; on international flights their passports are checked against the entry rules of the destination, and visa requirements are flagged in document_issues. Special service requests (SSRs) such as WCHR, VGML or UMNR are attached to passengers with special_requests. Seats held with POST /flights/{flightNumber}/{date}/seats/hold are assigned to the passengers with seat_hold_id; a hold that expired is refused with 409 Conflict. When risk scoring is enabled, bookings that look risky are created with status REVIEW and wait for an admin instead of being confirmed.
 // @Tags tickets
 // @Accept json
-// @Produce json
+// @Produce json,xml,application/msgpack
 // @Param ticket body models.CreateTicketRequest true "Ticket creation request"
+// @Param currency query string false "ISO 4217 currency to price the ticket in (overrides the request body)" example(EUR)
+// @Param dry_run query bool false "Validate and price the ticket and check seats without booking it; the ticket is returned with 200 and its confirmation ID is not reserved" default(false)
 // @Success 201 {object} models.FlightTicket "Successfully created ticket"
+// @Success 200 {object} models.FlightTicket "Ticket that would be created (dry run)"
 // @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 409 {object} models.ErrorResponse "Not enough seats on the flight, or seat hold expired"
+// @Failure 422 {object} models.ErrorResponse "Booking rule of the caller's tenant violated, or travel documents invalid"
+// @Failure 429 {object} models.QuotaExceededResponse "Daily booking quota of the API key used up"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 503 {object} models.ErrorResponse "Exchange rates unavailable"
 // @Router /ticket [post]
 func (h *TicketHandler) CreateTicket(w http.ResponseWriter, r *http.Request) {
+	preview, ok := dryRun(w, r)
+	if !ok {
+		return
+	}
+
 	var req models.CreateTicketRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		w.Header().Set("Content-Type", "application/json")
@@ -43,6 +407,27 @@ func (h *TicketHandler) CreateTicket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Children and infants are recorded in labels; passengers defaults to their total
+	if req.PassengerTypes != nil {
+		if err := req.PassengerTypes.Validate(); err != nil || (req.Passengers != 0 && req.Passengers != req.PassengerTypes.Total()) {
+			message := "passengers must be the total of passenger_types"
+			if err != nil {
+				message = err.Error()
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid passenger_types", Message: message})
+			return
+		}
+		req.Passengers = req.PassengerTypes.Total()
+	}
+
+	// Saved travelers are referenced by labels; passengers defaults to their number
+	travelerList, ok := h.resolveTravelers(w, r, &req)
+	if !ok {
+		return
+	}
+
 	// Validate required fields
 	if req.Origin == "" || req.Destination == "" || req.DepartureDate == "" || req.DepartureTime == "" || req.Passengers <= 0 {
 		w.Header().Set("Content-Type", "application/json")
@@ -110,30 +495,182 @@ func (h *TicketHandler) CreateTicket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Save to Firestore
-	if err := h.firestoreService.CreateTicket(r.Context(), ticket); err != nil {
+	if err := models.ValidateLabels(req.Labels); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid labels", Message: err.Error()})
+		return
+	}
+	if !checkReservedLabels(w, req.Labels) {
+		return
+	}
+	if len(req.Labels) > 0 {
+		ticket.Labels = req.Labels
+	}
+	if len(travelerList) > 0 {
+		if ticket.Labels == nil {
+			ticket.Labels = make(map[string]string, len(travelerList))
+		}
+		for i, traveler := range travelerList {
+			ticket.Labels[models.TravelerLabel(i+1)] = traveler.ID
+		}
+	}
+	if req.PassengerTypes != nil {
+		if ticket.Labels == nil {
+			ticket.Labels = make(map[string]string, 2)
+		}
+		for key, value := range req.PassengerTypes.Labels() {
+			ticket.Labels[key] = value
+		}
+	}
+	// SSR codes are recorded in one label per passenger
+	if len(req.SpecialRequests) > 0 {
+		types := models.PassengerTypes{Adults: ticket.Passengers}
+		if req.PassengerTypes != nil {
+			types = *req.PassengerTypes
+		}
+		if err := models.ValidateSpecialRequests(req.SpecialRequests, types); err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid special_requests", Message: err.Error()})
+			return
+		}
+		if ticket.Labels == nil {
+			ticket.Labels = make(map[string]string, len(req.SpecialRequests))
+		}
+		for key, value := range models.SpecialRequestLabels(req.SpecialRequests) {
+			ticket.Labels[key] = value
+		}
+	}
+	// Seats of a seat hold are recorded in one label per passenger
+	seatHold, ok := h.checkSeatHold(w, r, &req, ticket)
+	if !ok {
+		return
+	}
+	documentIssues, ok := checkTravelDocuments(w, r, h.repository, h.entryRules, ticket, travelerList)
+	if !ok {
+		return
+	}
+
+	// Bookings are checked against the booking rules, including those of the
+	// caller's tenant; tickets of tenants carry the tenant label
+	heldBy, ok := h.checkNewBooking(w, r, ticket)
+	if !ok {
+		return
+	}
+	if tenant := tenants.FromContext(r.Context()); tenant != nil {
+		if ticket.Labels == nil {
+			ticket.Labels = make(map[string]string, 1)
+		}
+		ticket.Labels[models.TenantLabel] = tenant.ID
+	}
+
+	// Price the ticket in the requested currency
+	if req.BaseFare < 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "base_fare must not be negative"})
+		return
+	}
+	baseFare := req.BaseFare
+	if baseFare == 0 {
+		baseFare = models.DefaultBaseFare
+	}
+	displayCurrency := req.Currency
+	if q := r.URL.Query().Get("currency"); q != "" {
+		displayCurrency = q
+	}
+	if err := h.priceTicket(r.Context(), ticket, baseFare, displayCurrency); err != nil {
+		writeCurrencyError(w, err)
+		return
+	}
+
+	if preview {
+		if err := h.inventory.Check(r.Context(), nil, ticket); err != nil {
+			writeInventoryError(w, err)
+			return
+		}
+		h.annotate(ticket)
+		ticket.DocumentIssues = documentIssues
+		h.encoders.Write(w, r, http.StatusOK, ticket)
+		return
+	}
+
+	// Risky bookings, and those breaking rules that hold them, are held for review instead of being confirmed
+	h.holdForReview(r, ticket, heldBy)
+
+	// Hold the seats first so a sold-out flight rejects the booking
+	actor := requestActor(r)
+	if err := h.inventory.Book(r.Context(), ticket, actor); err != nil {
+		writeInventoryError(w, err)
+		return
+	}
+
+	// Turn the seat hold into assignments; a hold that lapsed meanwhile may have lost its seats
+	if seatHold != nil {
+		if _, err := h.seats.Assign(r.Context(), seatHold.ID, ticket.ConfirmationID, time.Now()); err != nil {
+			if err := h.inventory.Release(r.Context(), ticket, actor); err != nil {
+				log.Printf("Failed to release seats of unsaved ticket %s: %v", ticket.ConfirmationID, err)
+			}
+			if errors.Is(err, seats.ErrHoldExpired) || errors.Is(err, seats.ErrNotFound) {
+				writeSeatHoldExpired(w)
+				return
+			}
+			log.Printf("Failed to assign seats of hold %s: %v", seatHold.ID, err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to create ticket"})
+			return
+		}
+	}
+
+	// Save to storage
+	if err := h.repository.CreateTicket(r.Context(), ticket); err != nil {
 		log.Printf("Failed to create ticket: %v", err)
+		if err := h.inventory.Release(r.Context(), ticket, actor); err != nil {
+			log.Printf("Failed to release seats of unsaved ticket %s: %v", ticket.ConfirmationID, err)
+		}
+		h.unassignSeats(r.Context(), ticket)
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to create ticket"})
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(ticket)
+	// Booking counters feed the statistics only, so a failure does not fail the booking
+	if err := h.bookings.Record(r.Context(), ticket); err != nil {
+		log.Printf("Failed to count booking of ticket %s: %v", ticket.ConfirmationID, err)
+	}
+	if h.ops != nil {
+		h.ops.GroupBooking(ticket, actor)
+	}
+
+	h.annotate(ticket)
+	ticket.DocumentIssues = documentIssues
+
+	h.encoders.Write(w, r, http.StatusCreated, ticket)
 }
 
 // GetTicket handles GET /ticket/{confirmationID}
 // @Summary Get a flight ticket by confirmation ID
-// @Description Retrieve a flight ticket using its confirmation ID
+// @Description Retrieve a flight ticket using its confirmation ID. Admin callers also get the ticket's internal notes. Responses carry ETag, Last-Modified and Cache-Control headers; anonymous reads may be cached publicly for a minute. With as_of, the ticket is returned as it was at that time.
 // @Tags tickets
 // @Accept json
-// @Produce json
+// @Produce json,xml,application/msgpack
 // @Param confirmationID path string true "Ticket confirmation ID" example("ABC123")
+// @Param currency query string false "ISO 4217 currency to display the price in" example(EUR)
+// @Param format query string false "Response format: json or pnr (GDS-style plain-text PNR block)" Enums(json, pnr) default(json)
+// @Param names query string false "Comma-separated passenger names as SURNAME/GIVEN for the pnr format" example(DOE/JOHN,DOE/JANE)
+// @Param as_of query string false "Return the ticket as it was at this RFC 3339 time, reconstructed from its history" example(2024-12-01T00:00:00Z)
+// @Param If-None-Match header string false "ETag of a cached copy; answered with 304 when it is still current"
+// @Param If-Modified-Since header string false "HTTP date of a cached copy; answered with 304 when the ticket has not changed since"
 // @Success 200 {object} models.FlightTicket "Successfully retrieved ticket"
+// @Success 304 "Cached copy is current"
 // @Failure 400 {object} models.ErrorResponse "Bad request"
-// @Failure 404 {object} models.ErrorResponse "Ticket not found"
+// @Failure 404 {object} models.ErrorResponse "Ticket not found, or not found at the as_of time"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 501 {object} models.ErrorResponse "Ticket history not supported by storage backend"
+// @Failure 503 {object} models.ErrorResponse "Exchange rates unavailable"
 // @Router /ticket/{confirmationID} [get]
 func (h *TicketHandler) GetTicket(w http.ResponseWriter, r *http.Request) {
 	confirmationID := chi.URLParam(r, "confirmationID")
@@ -143,8 +680,12 @@ func (h *TicketHandler) GetTicket(w http.ResponseWriter, r *http.Request) {
 		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Confirmation ID is required"})
 		return
 	}
+	at, ok := asOf(w, r)
+	if !ok {
+		return
+	}
 
-	ticket, err := h.firestoreService.GetTicket(r.Context(), confirmationID)
+	ticket, err := h.repository.GetTicket(r.Context(), confirmationID)
 	if err != nil {
 		log.Printf("Failed to get ticket %s: %v", confirmationID, err)
 		w.Header().Set("Content-Type", "application/json")
@@ -152,21 +693,64 @@ func (h *TicketHandler) GetTicket(w http.ResponseWriter, r *http.Request) {
 		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Ticket not found"})
 		return
 	}
+	if !at.IsZero() {
+		if ticket, ok = h.ticketAsOf(w, r, ticket, at); !ok {
+			return
+		}
+	}
+
+	if err := h.displayPrice(r.Context(), ticket, r.URL.Query().Get("currency")); err != nil {
+		writeCurrencyError(w, err)
+		return
+	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(ticket)
+	switch r.URL.Query().Get("format") {
+	case "", "json":
+	case "pnr":
+		var names []string
+		if nameParam := r.URL.Query().Get("names"); nameParam != "" {
+			names = strings.Split(nameParam, ",")
+		}
+		writeCached(w, r, ticket.UpdatedAt, !authenticated(r), "text/plain; charset=utf-8", []byte(pnr.Format(ticket, names)))
+		return
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid format", Message: "format must be json or pnr"})
+		return
+	}
+
+	h.annotate(ticket)
+
+	// Notes are internal; they are redacted from everyone but admins
+	if principal, ok := auth.FromContext(r.Context()); ok && principal.Role == auth.RoleAdmin {
+		if notes, ok := services.Capability[services.NoteRepository](h.repository); ok {
+			ticket.Notes, err = notes.ListNotes(r.Context(), confirmationID)
+			if err != nil {
+				log.Printf("Failed to list notes for ticket %s: %v", confirmationID, err)
+			}
+		}
+	}
+
+	h.writeCachedValue(w, r, ticket.UpdatedAt, !authenticated(r), ticket)
 }
 
 // UpdateTicket handles PUT /ticket/{confirmationID}
 // @Summary Update a flight ticket
-// @Description Update an existing flight ticket with new information
+// @Description Update an existing flight ticket with new information. Moving the ticket to another flight number or departure date, or setting its status to CANCELLED, frees its assigned seats.
 // @Tags tickets
 // @Accept json
-// @Produce json
+// @Produce json,xml,application/msgpack
 // @Param confirmationID path string true "Ticket confirmation ID" example("ABC123")
 // @Param ticket body models.UpdateTicketRequest true "Ticket update request"
+// @Param dry_run query bool false "Validate the update and check seats without storing it; the ticket is returned as it would be" default(false)
+// @Param X-Lock-Token header string false "Token of the ticket's edit lock, required while it is locked"
 // @Success 200 {object} models.FlightTicket "Successfully updated ticket"
 // @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Ticket not found"
+// @Failure 409 {object} models.ErrorResponse "Not enough seats on the flight, lock token not current, or status change of a ticket under review"
+// @Failure 422 {object} models.ErrorResponse "Booking rule of the caller's tenant violated"
+// @Failure 423 {object} models.ErrorResponse "Ticket is locked by another caller"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
 // @Router /ticket/{confirmationID} [put]
 func (h *TicketHandler) UpdateTicket(w http.ResponseWriter, r *http.Request) {
@@ -177,6 +761,10 @@ func (h *TicketHandler) UpdateTicket(w http.ResponseWriter, r *http.Request) {
 		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Confirmation ID is required"})
 		return
 	}
+	preview, ok := dryRun(w, r)
+	if !ok {
+		return
+	}
 
 	var req models.UpdateTicketRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -235,7 +823,7 @@ func (h *TicketHandler) UpdateTicket(w http.ResponseWriter, r *http.Request) {
 			})
 			return
 		}
-		
+
 		// If we also have a departure date, combine them
 		if req.DepartureDate != "" {
 			departureDate, err := time.Parse("2006-01-02", req.DepartureDate)
@@ -248,7 +836,7 @@ func (h *TicketHandler) UpdateTicket(w http.ResponseWriter, r *http.Request) {
 				})
 				return
 			}
-			
+
 			departureTime := time.Date(
 				departureDate.Year(),
 				departureDate.Month(),
@@ -282,36 +870,100 @@ func (h *TicketHandler) UpdateTicket(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if req.Status != "" {
-		if req.Status != "CONFIRMED" && req.Status != "CANCELLED" && req.Status != "PENDING" {
+		if req.Status != "CONFIRMED" && req.Status != "CANCELLED" && req.Status != "PENDING" && req.Status != models.DisruptedStatus {
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusBadRequest)
 			json.NewEncoder(w).Encode(models.ErrorResponse{
 				Error:   "Invalid status",
-				Message: "Use CONFIRMED, CANCELLED, or PENDING",
+				Message: "Use CONFIRMED, CANCELLED, PENDING, or DISRUPTED",
 			})
 			return
 		}
 		updates["status"] = req.Status
 	}
 
+	// A present labels object replaces the ticket's labels; an empty one clears them
+	if req.Labels != nil {
+		if err := models.ValidateLabels(req.Labels); err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid labels", Message: err.Error()})
+			return
+		}
+		if !checkReservedLabels(w, req.Labels) {
+			return
+		}
+		updates["labels"] = req.Labels
+	}
+
 	if len(updates) == 0 {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "No fields to update"})
 		return
 	}
+	if !h.checkLock(w, r, confirmationID) {
+		return
+	}
+	// The stored ticket is read once and backs every check of the update
+	stored, err := h.repository.GetTicket(r.Context(), confirmationID)
+	if err != nil {
+		log.Printf("Failed to get ticket %s: %v", confirmationID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Ticket not found"})
+		return
+	}
+	if !h.checkRulesUpdate(w, r, stored, updates) {
+		return
+	}
+	if !h.checkReview(w, r, stored, updates) {
+		return
+	}
+	// Moving the ticket to another flight or date, or cancelling it, frees its seats as rebooking does
+	unseated := leaveSeats(stored, updates)
+
+	if preview {
+		ticket := previewTicket(stored, updates)
+		if err := h.inventory.Check(r.Context(), stored, ticket); err != nil {
+			writeInventoryError(w, err)
+			return
+		}
+		h.annotate(ticket)
+		h.encoders.Write(w, r, http.StatusOK, ticket)
+		return
+	}
+
+	// Move the ticket's seats before a change of flight, date, passengers or status is stored
+	var previous, booked *models.FlightTicket
+	actor := requestActor(r)
+	if h.inventory.Enabled() {
+		previous, booked = stored, bookedTicket(stored, updates)
+		if err := h.inventory.Change(r.Context(), previous, booked, actor); err != nil {
+			writeInventoryError(w, err)
+			return
+		}
+	}
 
 	// Update ticket
-	if err := h.firestoreService.UpdateTicket(r.Context(), confirmationID, updates); err != nil {
+	if err := h.repository.UpdateTicket(r.Context(), confirmationID, updates); err != nil {
 		log.Printf("Failed to update ticket %s: %v", confirmationID, err)
+		if previous != nil {
+			if err := h.inventory.Change(r.Context(), booked, previous, actor); err != nil {
+				log.Printf("Failed to restore seats of ticket %s: %v", confirmationID, err)
+			}
+		}
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to update ticket"})
 		return
 	}
+	if unseated {
+		h.unassignSeats(r.Context(), stored)
+	}
 
-	// Get updated ticket
-	ticket, err := h.firestoreService.GetTicket(r.Context(), confirmationID)
+	// The stored ticket carries the updated_at the storage backend stamped
+	ticket, err := h.repository.GetTicket(r.Context(), confirmationID)
 	if err != nil {
 		log.Printf("Failed to get updated ticket %s: %v", confirmationID, err)
 		w.Header().Set("Content-Type", "application/json")
@@ -320,8 +972,9 @@ func (h *TicketHandler) UpdateTicket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(ticket)
+	h.annotate(ticket)
+
+	h.encoders.Write(w, r, http.StatusOK, ticket)
 }
 
 // DeleteTicket handles DELETE /ticket/{confirmationID}
@@ -329,10 +982,15 @@ func (h *TicketHandler) UpdateTicket(w http.ResponseWriter, r *http.Request) {
 // @Description Cancel (soft delete) a flight ticket by setting its status to CANCELLED
 // @Tags tickets
 // @Accept json
-// @Produce json
+// @Produce json,xml,application/msgpack
 // @Param confirmationID path string true "Ticket confirmation ID" example("ABC123")
+// @Param dry_run query bool false "Check that the ticket exists without cancelling it" default(false)
+// @Param X-Lock-Token header string false "Token of the ticket's edit lock, required while it is locked"
 // @Success 200 {object} models.SuccessResponse "Successfully cancelled ticket"
 // @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Ticket not found"
+// @Failure 409 {object} models.ErrorResponse "Lock token not current"
+// @Failure 423 {object} models.ErrorResponse "Ticket is locked by another caller"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
 // @Router /ticket/{confirmationID} [delete]
 func (h *TicketHandler) DeleteTicket(w http.ResponseWriter, r *http.Request) {
@@ -344,7 +1002,31 @@ func (h *TicketHandler) DeleteTicket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.firestoreService.DeleteTicket(r.Context(), confirmationID); err != nil {
+	preview, ok := dryRun(w, r)
+	if !ok {
+		return
+	}
+	if !h.checkLock(w, r, confirmationID) {
+		return
+	}
+	// The stored ticket names the inventory and the assigned seats to give back
+	previous, err := h.repository.GetTicket(r.Context(), confirmationID)
+	if err != nil {
+		log.Printf("Failed to get ticket %s: %v", confirmationID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Ticket not found"})
+		return
+	}
+	if preview {
+		h.encoders.Write(w, r, http.StatusOK, models.SuccessResponse{
+			Message:        "Ticket would be cancelled",
+			ConfirmationID: confirmationID,
+		})
+		return
+	}
+
+	if err := h.repository.DeleteTicket(r.Context(), confirmationID); err != nil {
 		log.Printf("Failed to cancel ticket %s: %v", confirmationID, err)
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusInternalServerError)
@@ -352,9 +1034,15 @@ func (h *TicketHandler) DeleteTicket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(models.SuccessResponse{
+	// Give the seats back once the cancellation is stored
+	if h.inventory.Enabled() {
+		if err := h.inventory.Release(r.Context(), previous, requestActor(r)); err != nil {
+			log.Printf("Failed to release seats of ticket %s: %v", confirmationID, err)
+		}
+	}
+	h.unassignSeats(r.Context(), previous)
+
+	h.encoders.Write(w, r, http.StatusOK, models.SuccessResponse{
 		Message:        "Ticket cancelled successfully",
 		ConfirmationID: confirmationID,
 	})
@@ -365,10 +1053,13 @@ func (h *TicketHandler) DeleteTicket(w http.ResponseWriter, r *http.Request) {
 // @Description Retrieve a list of all flight tickets with optional pagination
 // @Tags tickets
 // @Accept json
-// @Produce json
+// @Produce json,xml,application/msgpack
 // @Param limit query int false "Maximum number of tickets to return" default(50) example(10)
+// @Param currency query string false "ISO 4217 currency to display prices in" example(EUR)
 // @Success 200 {object} models.TicketListResponse "Successfully retrieved tickets"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
 // @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 503 {object} models.ErrorResponse "Exchange rates unavailable"
 // @Router /tickets [get]
 func (h *TicketHandler) ListTickets(w http.ResponseWriter, r *http.Request) {
 	limitStr := r.URL.Query().Get("limit")
@@ -380,7 +1071,7 @@ func (h *TicketHandler) ListTickets(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	tickets, err := h.firestoreService.ListTickets(r.Context(), limit)
+	tickets, err := h.repository.ListTickets(r.Context(), limit)
 	if err != nil {
 		log.Printf("Failed to list tickets: %v", err)
 		w.Header().Set("Content-Type", "application/json")
@@ -389,8 +1080,108 @@ func (h *TicketHandler) ListTickets(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(models.TicketListResponse{
+	displayCurrency := r.URL.Query().Get("currency")
+	for _, ticket := range tickets {
+		if err := h.displayPrice(r.Context(), ticket, displayCurrency); err != nil {
+			writeCurrencyError(w, err)
+			return
+		}
+		h.annotate(ticket)
+	}
+
+	h.encoders.Write(w, r, http.StatusOK, models.TicketListResponse{
+		Tickets: tickets,
+		Count:   len(tickets),
+	})
+}
+
+// SearchTickets handles GET /tickets/search
+// @Summary Search flight tickets
+// @Description Find tickets by label and field filters. Every filter must match. Label filters are key:value pairs and may be repeated. Only available when the search feature flag is on.
+// @Tags tickets
+// @Accept json
+// @Produce json,xml,application/msgpack
+// @Param label query []string false "Label filter as key:value, repeatable" collectionFormat(multi) example(corporate_account:acme)
+// @Param status query string false "Ticket status" Enums(CONFIRMED, CHECKED_IN, CANCELLED, PENDING, DISRUPTED, REVIEW)
+// @Param origin query string false "3-letter IATA origin airport code" example(JFK)
+// @Param destination query string false "3-letter IATA destination airport code" example(LAX)
+// @Param flight_number query string false "Flight number" example(AA1234)
+// @Param departure_date query string false "Departure date: today, tomorrow (UTC) or YYYY-MM-DD" example(2024-12-25)
+// @Param limit query int false "Maximum number of tickets to return" default(50) example(10)
+// @Param currency query string false "ISO 4217 currency to display prices in" example(EUR)
+// @Success 200 {object} models.TicketListResponse "Matching tickets, newest first"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 404 {object} models.ErrorResponse "Search is disabled"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 501 {object} models.ErrorResponse "Search not supported by storage backend"
+// @Failure 503 {object} models.ErrorResponse "Exchange rates unavailable"
+// @Router /tickets/search [get]
+func (h *TicketHandler) SearchTickets(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	labels, err := models.ParseLabelFilters(params["label"])
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid label filter", Message: err.Error()})
+		return
+	}
+
+	query := models.TicketQuery{
+		Labels:       labels,
+		Status:       strings.ToUpper(params.Get("status")),
+		Origin:       strings.ToUpper(params.Get("origin")),
+		Destination:  strings.ToUpper(params.Get("destination")),
+		FlightNumber: strings.ToUpper(params.Get("flight_number")),
+		Limit:        50,
+	}
+	if limitStr := params.Get("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
+			query.Limit = parsedLimit
+		}
+	}
+	if date := params.Get("departure_date"); date != "" {
+		if query.DepartureDate, err = models.ParseDepartureDate(date, time.Now()); err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid departure_date", Message: err.Error()})
+			return
+		}
+	}
+
+	h.writeSearchResults(w, r, query)
+}
+
+// writeSearchResults runs a ticket query and writes the matching tickets
+func (h *TicketHandler) writeSearchResults(w http.ResponseWriter, r *http.Request, query models.TicketQuery) {
+	tickets, err := services.SearchTickets(r.Context(), h.repository, query)
+	if errors.Is(err, services.ErrSearchUnsupported) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotImplemented)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Search not supported", Message: err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to search tickets: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to search tickets"})
+		return
+	}
+	if tickets == nil {
+		tickets = []*models.FlightTicket{}
+	}
+
+	displayCurrency := r.URL.Query().Get("currency")
+	for _, ticket := range tickets {
+		if err := h.displayPrice(r.Context(), ticket, displayCurrency); err != nil {
+			writeCurrencyError(w, err)
+			return
+		}
+		h.annotate(ticket)
+	}
+
+	h.encoders.Write(w, r, http.StatusOK, models.TicketListResponse{
 		Tickets: tickets,
 		Count:   len(tickets),
 	})
@@ -0,0 +1,431 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/currency"
+	"flight-ticket-service/src/entry"
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/rules"
+	"flight-ticket-service/src/scheduling"
+	"flight-ticket-service/src/seats"
+	"flight-ticket-service/src/services"
+	"flight-ticket-service/src/travelers"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// newTestTicketHandler returns a ticket handler over a memory repository
+func newTestTicketHandler(t *testing.T) (*TicketHandler, *services.MemoryRepository) {
+	t.Helper()
+	rates, err := currency.NewRateProvider("static")
+	if err != nil {
+		t.Fatalf("Failed to create rate provider: %v", err)
+	}
+	entryRules, err := entry.NewTable("")
+	if err != nil {
+		t.Fatalf("Failed to create entry rules: %v", err)
+	}
+	repository := services.NewMemoryRepository()
+	return NewTicketHandler(repository, TicketHandlerOptions{
+		Converter:  currency.NewConverter(rates, time.Hour),
+		Scheduler:  scheduling.NewScheduler(scheduling.DefaultPolicy),
+		Rules:      rules.NewEngine(rules.NewMemoryStore()),
+		Travelers:  travelers.NewMemoryStore(),
+		EntryRules: entryRules,
+		Seats:      seats.NewMemoryStore(),
+	}), repository
+}
+
+// ticketRouter routes the ticket endpoints to h as the server does
+func ticketRouter(h *TicketHandler) http.Handler {
+	r := chi.NewRouter()
+	r.Post("/ticket", h.CreateTicket)
+	r.Get("/ticket/{confirmationID}", h.GetTicket)
+	r.Put("/ticket/{confirmationID}", h.UpdateTicket)
+	r.Delete("/ticket/{confirmationID}", h.DeleteTicket)
+	r.Get("/tickets", h.ListTickets)
+	r.Get("/tickets/search", h.SearchTickets)
+	return r
+}
+
+// seedTicket stores a confirmed two-passenger ticket departing in a month
+func seedTicket(t *testing.T, repository services.TicketRepository, confirmationID string) *models.FlightTicket {
+	t.Helper()
+	departure := time.Now().AddDate(0, 1, 0).UTC().Truncate(24 * time.Hour)
+	created := time.Date(2024, 7, 12, 19, 0, 0, 0, time.UTC)
+	ticket := &models.FlightTicket{
+		ConfirmationID: confirmationID,
+		Origin:         "JFK",
+		Destination:    "LAX",
+		DepartureDate:  departure,
+		DepartureTime:  departure.Add(14*time.Hour + 30*time.Minute),
+		FlightNumber:   "AA1234",
+		Passengers:     2,
+		Status:         "CONFIRMED",
+		Price:          &models.Price{Amount: 597, Currency: "USD", BaseAmount: 597, BaseCurrency: "USD", ExchangeRate: 1},
+		CreatedAt:      created,
+		UpdatedAt:      created,
+	}
+	if err := repository.CreateTicket(context.Background(), ticket); err != nil {
+		t.Fatalf("Failed to seed ticket: %v", err)
+	}
+	return ticket
+}
+
+// serveRequest sends a request to the router and returns the recorded response
+func serveRequest(router http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	var reader io.Reader = http.NoBody
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, target, reader)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+// decodeError decodes an error response, failing the test when it is not one
+func decodeError(t *testing.T, rec *httptest.ResponseRecorder) models.ErrorResponse {
+	t.Helper()
+	var response models.ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil || response.Error == "" {
+		t.Fatalf("Expected an error response, got %q (%v)", rec.Body.String(), err)
+	}
+	return response
+}
+
+func TestGetTicketPNR(t *testing.T) {
+	h, repository := newTestTicketHandler(t)
+	ticket := seedTicket(t, repository, "ABC123")
+
+	rec := serveRequest(ticketRouter(h), http.MethodGet, "/ticket/ABC123?format=pnr&names=Doe/John", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if contentType := rec.Header().Get("Content-Type"); contentType != "text/plain; charset=utf-8" {
+		t.Errorf("Expected a plain text PNR, got Content-Type %q", contentType)
+	}
+
+	body := rec.Body.String()
+	segment := "AA1234 Y " + strings.ToUpper(ticket.DepartureDate.Format("02Jan")) + " "
+	for _, want := range []string{"12JUL24/1900Z   ABC123\n", "1.DOE/JOHN   2.PAX/ADULT2\n", segment, "JFKLAX HK2  1430", "RM STATUS CONFIRMED\n", "RM FARE USD 597.00\n"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q in the PNR:\n%s", want, body)
+		}
+	}
+}
+
+func TestGetTicketPNRNotModified(t *testing.T) {
+	h, repository := newTestTicketHandler(t)
+	seedTicket(t, repository, "ABC123")
+	router := ticketRouter(h)
+
+	rec := serveRequest(router, http.MethodGet, "/ticket/ABC123?format=pnr", "")
+	etag := rec.Header().Get("ETag")
+	if etag == "" {
+		t.Fatalf("Expected an ETag on the PNR")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/ticket/ABC123?format=pnr", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("Expected status 304 for a current PNR, got %d", rec.Code)
+	}
+}
+
+func TestGetTicketErrors(t *testing.T) {
+	h, repository := newTestTicketHandler(t)
+	seedTicket(t, repository, "ABC123")
+	router := ticketRouter(h)
+
+	tests := []struct {
+		name     string
+		target   string
+		expected int
+		error    string
+	}{
+		{"unknown ticket", "/ticket/NOPE00?format=pnr", http.StatusNotFound, "Ticket not found"},
+		{"unknown format", "/ticket/ABC123?format=edifact", http.StatusBadRequest, "Invalid format"},
+		{"unknown currency", "/ticket/ABC123?format=pnr&currency=XXX", http.StatusBadRequest, "Unsupported currency"},
+		{"invalid as_of", "/ticket/ABC123?as_of=yesterday", http.StatusBadRequest, "Invalid as_of"},
+	}
+
+	for _, test := range tests {
+		rec := serveRequest(router, http.MethodGet, test.target, "")
+		if rec.Code != test.expected {
+			t.Errorf("%s: expected status %d, got %d: %s", test.name, test.expected, rec.Code, rec.Body.String())
+			continue
+		}
+		if response := decodeError(t, rec); response.Error != test.error {
+			t.Errorf("%s: expected error %q, got %q", test.name, test.error, response.Error)
+		}
+	}
+}
+
+func TestCreateTicketErrors(t *testing.T) {
+	h, repository := newTestTicketHandler(t)
+	router := ticketRouter(h)
+	departure := time.Now().AddDate(0, 1, 0).Format("2006-01-02")
+
+	tests := []struct {
+		name  string
+		body  string
+		error string
+	}{
+		{"invalid JSON", `{"origin":`, "Invalid JSON payload"},
+		{"missing fields", `{"origin":"JFK","destination":"LAX"}`, "Missing required fields"},
+		{"invalid date", `{"origin":"JFK","destination":"LAX","departure_date":"25/12/2024","departure_time":"14:30","passengers":1}`, "Invalid departure_date format"},
+		{"invalid time", `{"origin":"JFK","destination":"LAX","departure_date":"` + departure + `","departure_time":"2pm","passengers":1}`, "Invalid departure_time format"},
+		{"invalid airport", `{"origin":"jfk","destination":"LAX","departure_date":"` + departure + `","departure_time":"14:30","passengers":1}`, "Invalid airport codes"},
+	}
+
+	for _, test := range tests {
+		rec := serveRequest(router, http.MethodPost, "/ticket", test.body)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d: %s", test.name, rec.Code, rec.Body.String())
+			continue
+		}
+		if response := decodeError(t, rec); response.Error != test.error {
+			t.Errorf("%s: expected error %q, got %q", test.name, test.error, response.Error)
+		}
+	}
+
+	if tickets, _ := repository.ListTickets(context.Background(), 0); len(tickets) != 0 {
+		t.Errorf("Expected no ticket to be stored, got %d", len(tickets))
+	}
+}
+
+func TestCreateTicketPNR(t *testing.T) {
+	h, _ := newTestTicketHandler(t)
+	router := ticketRouter(h)
+	departure := time.Now().AddDate(0, 1, 0).Format("2006-01-02")
+
+	rec := serveRequest(router, http.MethodPost, "/ticket", `{"origin":"JFK","destination":"LAX","departure_date":"`+departure+`","departure_time":"14:30","flight_number":"AA1234","passengers":1}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created models.FlightTicket
+	if err := json.NewDecoder(rec.Body).Decode(&created); err != nil {
+		t.Fatalf("Failed to decode the created ticket: %v", err)
+	}
+
+	rec = serveRequest(router, http.MethodGet, "/ticket/"+created.ConfirmationID+"?format=pnr", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if body := rec.Body.String(); !strings.Contains(body, created.ConfirmationID) || !strings.Contains(body, "1.PAX/ADULT1\n") {
+		t.Errorf("Expected the created booking in the PNR:\n%s", body)
+	}
+}
+
+// listOnlyRepository hides the optional capabilities of the repository it wraps
+type listOnlyRepository struct {
+	services.TicketRepository
+}
+
+func TestSearchTickets(t *testing.T) {
+	h, repository := newTestTicketHandler(t)
+	seedTicket(t, repository, "ABC123")
+	cancelled := seedTicket(t, repository, "XYZ789")
+	if err := repository.DeleteTicket(context.Background(), cancelled.ConfirmationID); err != nil {
+		t.Fatalf("Failed to cancel ticket: %v", err)
+	}
+	departure := cancelled.DepartureDate.Format("2006-01-02")
+
+	rec := serveRequest(ticketRouter(h), http.MethodGet, "/tickets/search?flight_number=aa1234&departure_date="+departure+"&status=confirmed", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var response models.TicketListResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode the search results: %v", err)
+	}
+	if response.Count != 1 || response.Tickets[0].ConfirmationID != "ABC123" {
+		t.Errorf("Expected only the confirmed ticket, got %+v", response)
+	}
+}
+
+func TestSearchTicketsErrors(t *testing.T) {
+	h, repository := newTestTicketHandler(t)
+	seedTicket(t, repository, "ABC123")
+	unsupported, _ := newTestTicketHandler(t)
+	unsupported.repository = listOnlyRepository{repository}
+
+	tests := []struct {
+		name     string
+		handler  *TicketHandler
+		target   string
+		expected int
+		error    string
+	}{
+		{"invalid label", h, "/tickets/search?label=campaign", http.StatusBadRequest, "Invalid label filter"},
+		{"invalid date", h, "/tickets/search?departure_date=soon", http.StatusBadRequest, "Invalid departure_date"},
+		{"backend without search", unsupported, "/tickets/search?status=CONFIRMED", http.StatusNotImplemented, "Search not supported"},
+	}
+
+	for _, test := range tests {
+		rec := serveRequest(ticketRouter(test.handler), http.MethodGet, test.target, "")
+		if rec.Code != test.expected {
+			t.Errorf("%s: expected status %d, got %d: %s", test.name, test.expected, rec.Code, rec.Body.String())
+			continue
+		}
+		if response := decodeError(t, rec); response.Error != test.error {
+			t.Errorf("%s: expected error %q, got %q", test.name, test.error, response.Error)
+		}
+	}
+}
+
+// countingRepository counts the ticket reads of the repository it wraps
+type countingRepository struct {
+	services.TicketRepository
+	reads int
+}
+
+func (c *countingRepository) GetTicket(ctx context.Context, confirmationID string) (*models.FlightTicket, error) {
+	c.reads++
+	return c.TicketRepository.GetTicket(ctx, confirmationID)
+}
+
+func TestUpdateAndCancelReadTicketOnce(t *testing.T) {
+	h, repository := newTestTicketHandler(t)
+	seedTicket(t, repository, "ABC123")
+	counting := &countingRepository{TicketRepository: repository}
+	h.repository = counting
+	router := ticketRouter(h)
+
+	// The update reads the ticket once for its checks and once for the response
+	rec := serveRequest(router, http.MethodPut, "/ticket/ABC123", `{"passengers": 3, "status": "PENDING", "labels": {"campaign": "spring"}}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if counting.reads != 2 {
+		t.Errorf("Expected 2 reads for the update, got %d", counting.reads)
+	}
+
+	counting.reads = 0
+	rec = serveRequest(router, http.MethodPut, "/ticket/ABC123?dry_run=true", `{"flight_number": "AA1235"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if counting.reads != 1 {
+		t.Errorf("Expected 1 read for the dry run, got %d", counting.reads)
+	}
+
+	counting.reads = 0
+	rec = serveRequest(router, http.MethodDelete, "/ticket/ABC123", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if counting.reads != 1 {
+		t.Errorf("Expected 1 read for the cancellation, got %d", counting.reads)
+	}
+}
+
+func TestUpdateAndCancelMissingTicket(t *testing.T) {
+	h, _ := newTestTicketHandler(t)
+	router := ticketRouter(h)
+
+	for _, method := range []string{http.MethodPut, http.MethodDelete} {
+		rec := serveRequest(router, method, "/ticket/NOPE42", `{"status": "CONFIRMED"}`)
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("%s: expected status 404, got %d: %s", method, rec.Code, rec.Body.String())
+			continue
+		}
+		if response := decodeError(t, rec); response.Error != "Ticket not found" {
+			t.Errorf("%s: expected error %q, got %q", method, "Ticket not found", response.Error)
+		}
+	}
+}
+
+// seatTicket seeds a ticket with seats 12A and 12B assigned in the handler's seat store
+func seatTicket(t *testing.T, h *TicketHandler, repository services.TicketRepository, confirmationID string) *models.FlightTicket {
+	t.Helper()
+	ctx := context.Background()
+	ticket := seedTicket(t, repository, confirmationID)
+	labels := map[string]string{models.SeatLabel(1): "12a", models.SeatLabel(2): "12b"}
+	if err := repository.UpdateTicket(ctx, confirmationID, map[string]interface{}{"labels": labels}); err != nil {
+		t.Fatalf("Failed to label seats: %v", err)
+	}
+	now := time.Now()
+	hold := &models.SeatHold{ID: "hold-" + confirmationID, FlightNumber: ticket.FlightNumber, Date: ticket.DepartureDate.Format("2006-01-02"),
+		Seats: []string{"12A", "12B"}, CreatedAt: now, ExpiresAt: now.Add(time.Minute)}
+	if err := h.seats.Hold(ctx, hold, now); err != nil {
+		t.Fatalf("Failed to hold seats: %v", err)
+	}
+	if _, err := h.seats.Assign(ctx, hold.ID, confirmationID, now); err != nil {
+		t.Fatalf("Failed to assign seats: %v", err)
+	}
+	return ticket
+}
+
+// takenSeats returns the held and assigned seats of the ticket's departure
+func takenSeats(t *testing.T, h *TicketHandler, ticket *models.FlightTicket) []models.SeatStatus {
+	t.Helper()
+	taken, err := h.seats.List(context.Background(), ticket.FlightNumber, ticket.DepartureDate.Format("2006-01-02"), time.Now())
+	if err != nil {
+		t.Fatalf("Failed to list seats: %v", err)
+	}
+	return taken
+}
+
+func TestUpdateAndCancelFreeSeats(t *testing.T) {
+	// Changing the passengers keeps the seats on the same departure
+	h, repository := newTestTicketHandler(t)
+	kept := seatTicket(t, h, repository, "KEEP01")
+	rec := serveRequest(ticketRouter(h), http.MethodPut, "/ticket/KEEP01", `{"passengers": 3}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if taken := takenSeats(t, h, kept); len(taken) != 2 {
+		t.Errorf("Expected the seats to stay assigned, got %+v", taken)
+	}
+
+	for _, tc := range []struct {
+		name, method, id, body string
+	}{
+		{"moved", http.MethodPut, "MOVE01", `{"flight_number": "AA9999"}`},
+		{"cancelled by update", http.MethodPut, "CANC01", `{"status": "CANCELLED"}`},
+		{"deleted", http.MethodDelete, "DELE01", ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h, repository := newTestTicketHandler(t)
+			ticket := seatTicket(t, h, repository, tc.id)
+			rec := serveRequest(ticketRouter(h), tc.method, "/ticket/"+tc.id, tc.body)
+			if rec.Code != http.StatusOK && rec.Code != http.StatusNoContent {
+				t.Fatalf("Expected success, got %d: %s", rec.Code, rec.Body.String())
+			}
+			if taken := takenSeats(t, h, ticket); len(taken) != 0 {
+				t.Errorf("Expected the seats to be freed, got %+v", taken)
+			}
+			// A cancellation keeps the ticket as it was; an update stores it without its seats
+			if tc.method == http.MethodDelete {
+				return
+			}
+			stored, err := repository.GetTicket(context.Background(), tc.id)
+			if err != nil {
+				t.Fatalf("Failed to read ticket: %v", err)
+			}
+			if seats := models.TicketSeats(stored); len(seats) != 0 {
+				t.Errorf("Expected no seat labels on the ticket, got %v", seats)
+			}
+		})
+	}
+}
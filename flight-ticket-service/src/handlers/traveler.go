@@ -0,0 +1,349 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"flight-ticket-service/src/entry"
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/services"
+	"flight-ticket-service/src/travelers"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// TravelerHandler manages the traveler profiles of the caller. Profiles hold
+// personal data, so their details are never logged.
+type TravelerHandler struct {
+	store travelers.Store
+}
+
+func NewTravelerHandler(store travelers.Store) *TravelerHandler {
+	return &TravelerHandler{store: store}
+}
+
+// resolveTravelers returns the travelers of the traveler_ids of a booking,
+// defaulting passengers to their number. Travelers of other API keys are
+// unknown. Writes an error response when the IDs are invalid.
+func (h *TicketHandler) resolveTravelers(w http.ResponseWriter, r *http.Request, req *models.CreateTicketRequest) ([]*models.Traveler, bool) {
+	if len(req.TravelerIDs) == 0 {
+		return nil, true
+	}
+	if req.Passengers == 0 {
+		req.Passengers = len(req.TravelerIDs)
+	}
+
+	invalid := func(message string) ([]*models.Traveler, bool) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid traveler_ids", Message: message})
+		return nil, false
+	}
+	if len(req.TravelerIDs) > models.MaxTravelersPerTicket || len(req.TravelerIDs) > req.Passengers {
+		return invalid(fmt.Sprintf("at most %d travelers, and no more than passengers", models.MaxTravelersPerTicket))
+	}
+
+	owner := requestActor(r)
+	list := make([]*models.Traveler, 0, len(req.TravelerIDs))
+	seen := make(map[string]bool, len(req.TravelerIDs))
+	for _, id := range req.TravelerIDs {
+		if seen[id] {
+			return invalid(fmt.Sprintf("traveler %s is listed twice", id))
+		}
+		seen[id] = true
+
+		traveler, err := travelers.Owned(r.Context(), h.travelers, owner, id)
+		if errors.Is(err, travelers.ErrNotFound) {
+			return invalid(fmt.Sprintf("traveler %q not found", id))
+		}
+		if err != nil {
+			log.Printf("Failed to get traveler %s: %v", id, err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to get travelers"})
+			return nil, false
+		}
+		list = append(list, traveler)
+	}
+	return list, true
+}
+
+// ticketTravelers returns the saved travelers referenced by a ticket's labels;
+// travelers deleted since the booking are left out
+func ticketTravelers(ctx context.Context, store travelers.Store, ticket *models.FlightTicket) ([]*models.Traveler, error) {
+	var list []*models.Traveler
+	for n := 1; n <= models.MaxTravelersPerTicket; n++ {
+		id, ok := ticket.Labels[models.TravelerLabel(n)]
+		if !ok {
+			continue
+		}
+		traveler, err := store.Get(ctx, id)
+		if errors.Is(err, travelers.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, traveler)
+	}
+	return list, nil
+}
+
+// checkTravelDocuments checks the documents of a ticket's travelers against
+// the entry rules of its destination, returning the issues that do not refuse
+// it. Writes an error response when the documents refuse the ticket.
+func checkTravelDocuments(w http.ResponseWriter, r *http.Request, repository services.TicketRepository, table *entry.Table, ticket *models.FlightTicket, list []*models.Traveler) ([]models.DocumentIssue, bool) {
+	if len(list) == 0 || !services.International(ticket.Origin, ticket.Destination) {
+		return nil, true
+	}
+	tripEnd, err := entry.TripEnd(r.Context(), repository, ticket)
+	if err != nil {
+		log.Printf("Failed to check travel documents for %s: %v", ticket.ConfirmationID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to check travel documents"})
+		return nil, false
+	}
+
+	issues := table.Check(ticket, list, tripEnd)
+	if blocking := entry.Blocking(issues); len(blocking) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Travel documents invalid", Message: strings.Join(blocking, "; ")})
+		return nil, false
+	}
+	return issues, true
+}
+
+// ListTravelers handles GET /travelers
+// @Summary List saved travelers
+// @Description List the traveler profiles saved by the caller's API key, by surname and given name. Their IDs can be passed as traveler_ids when booking instead of entering passenger details again.
+// @Tags travelers
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Success 200 {object} models.TravelerListResponse "Travelers of the caller"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /travelers [get]
+func (h *TravelerHandler) ListTravelers(w http.ResponseWriter, r *http.Request) {
+	list, err := travelers.ListOwned(r.Context(), h.store, requestActor(r))
+	if err != nil {
+		log.Printf("Failed to list travelers of %s: %v", requestActor(r), err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to list travelers"})
+		return
+	}
+	if list == nil {
+		list = []*models.Traveler{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.TravelerListResponse{Travelers: list, Count: len(list)})
+}
+
+// CreateTraveler handles POST /travelers
+// @Summary Save a traveler
+// @Description Save the details of a passenger for repeat bookings. The profile belongs to the caller's API key, which can keep up to 100 travelers.
+// @Tags travelers
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param traveler body models.TravelerRequest true "Traveler details"
+// @Success 201 {object} models.Traveler "Saved traveler"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 409 {object} models.ErrorResponse "Too many travelers"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /travelers [post]
+func (h *TravelerHandler) CreateTraveler(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeTraveler(w, r)
+	if !ok {
+		return
+	}
+
+	owner := requestActor(r)
+	list, err := h.store.List(r.Context(), owner)
+	if err != nil {
+		log.Printf("Failed to list travelers of %s: %v", owner, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to save traveler"})
+		return
+	}
+	if len(list) >= models.MaxTravelersPerOwner {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Error:   "Too many travelers",
+			Message: fmt.Sprintf("an API key can keep at most %d travelers", models.MaxTravelersPerOwner),
+		})
+		return
+	}
+
+	id, err := travelers.NewID()
+	if err != nil {
+		log.Printf("Failed to create traveler for %s: %v", owner, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to save traveler"})
+		return
+	}
+	now := time.Now().UTC()
+	traveler := &models.Traveler{ID: id, Owner: owner, CreatedAt: now}
+	if !h.saveTraveler(w, r, traveler, req, now) {
+		return
+	}
+	log.Printf("Traveler %s saved by %s", id, owner)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(traveler)
+}
+
+// GetTraveler handles GET /travelers/{travelerID}
+// @Summary Get a saved traveler
+// @Description Get a traveler profile saved by the caller's API key
+// @Tags travelers
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param travelerID path string true "Traveler ID" example(3f9a1c0e5b7d)
+// @Success 200 {object} models.Traveler "Traveler"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 404 {object} models.ErrorResponse "Traveler not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /travelers/{travelerID} [get]
+func (h *TravelerHandler) GetTraveler(w http.ResponseWriter, r *http.Request) {
+	traveler, ok := h.ownedTraveler(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(traveler)
+}
+
+// UpdateTraveler handles PUT /travelers/{travelerID}
+// @Summary Replace a saved traveler
+// @Description Replace the details of a traveler profile saved by the caller's API key. Tickets already booked keep referencing the traveler by ID.
+// @Tags travelers
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param travelerID path string true "Traveler ID" example(3f9a1c0e5b7d)
+// @Param traveler body models.TravelerRequest true "Traveler details"
+// @Success 200 {object} models.Traveler "Saved traveler"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 404 {object} models.ErrorResponse "Traveler not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /travelers/{travelerID} [put]
+func (h *TravelerHandler) UpdateTraveler(w http.ResponseWriter, r *http.Request) {
+	traveler, ok := h.ownedTraveler(w, r)
+	if !ok {
+		return
+	}
+	req, ok := decodeTraveler(w, r)
+	if !ok {
+		return
+	}
+	if !h.saveTraveler(w, r, traveler, req, time.Now().UTC()) {
+		return
+	}
+	log.Printf("Traveler %s updated by %s", traveler.ID, traveler.Owner)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(traveler)
+}
+
+// DeleteTraveler handles DELETE /travelers/{travelerID}
+// @Summary Delete a saved traveler
+// @Description Delete a traveler profile saved by the caller's API key. Tickets already booked keep the traveler ID in their labels.
+// @Tags travelers
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param travelerID path string true "Traveler ID" example(3f9a1c0e5b7d)
+// @Success 200 {object} models.SuccessResponse "Deleted traveler"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 404 {object} models.ErrorResponse "Traveler not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /travelers/{travelerID} [delete]
+func (h *TravelerHandler) DeleteTraveler(w http.ResponseWriter, r *http.Request) {
+	traveler, ok := h.ownedTraveler(w, r)
+	if !ok {
+		return
+	}
+	if err := h.store.Delete(r.Context(), traveler.ID); err != nil && !errors.Is(err, travelers.ErrNotFound) {
+		log.Printf("Failed to delete traveler %s: %v", traveler.ID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to delete traveler"})
+		return
+	}
+	log.Printf("Traveler %s deleted by %s", traveler.ID, traveler.Owner)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.SuccessResponse{Message: "Traveler deleted successfully"})
+}
+
+// ownedTraveler returns the traveler of the path if it belongs to the caller, writing an error response otherwise
+func (h *TravelerHandler) ownedTraveler(w http.ResponseWriter, r *http.Request) (*models.Traveler, bool) {
+	id := chi.URLParam(r, "travelerID")
+	traveler, err := travelers.Owned(r.Context(), h.store, requestActor(r), id)
+	if err == nil {
+		return traveler, true
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if errors.Is(err, travelers.ErrNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Traveler not found"})
+		return nil, false
+	}
+	log.Printf("Failed to get traveler %s: %v", id, err)
+	w.WriteHeader(http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to get traveler"})
+	return nil, false
+}
+
+// decodeTraveler reads and validates a traveler request, writing an error response when it is invalid
+func decodeTraveler(w http.ResponseWriter, r *http.Request) (*models.TravelerRequest, bool) {
+	var req models.TravelerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid JSON payload"})
+		return nil, false
+	}
+	if err := req.Validate(time.Now().UTC()); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid traveler", Message: err.Error()})
+		return nil, false
+	}
+	return &req, true
+}
+
+// saveTraveler applies the request to the traveler and stores it, writing an error response on failure
+func (h *TravelerHandler) saveTraveler(w http.ResponseWriter, r *http.Request, traveler *models.Traveler, req *models.TravelerRequest, now time.Time) bool {
+	traveler.FirstName = req.FirstName
+	traveler.LastName = req.LastName
+	traveler.DateOfBirth = req.DateOfBirth
+	traveler.Documents = req.Documents
+	traveler.LoyaltyNumber = req.LoyaltyNumber
+	traveler.UpdatedAt = now
+
+	if err := h.store.Save(r.Context(), traveler); err != nil {
+		log.Printf("Failed to save traveler %s: %v", traveler.ID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to save traveler"})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,587 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"flight-ticket-service/src/changefeed"
+	"flight-ticket-service/src/currency"
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/services"
+	"flight-ticket-service/src/upgrades"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// errTicketCancelled is returned when upgrading a ticket that was cancelled since the offer
+var errTicketCancelled = errors.New("ticket is cancelled")
+
+type UpgradeHandler struct {
+	repository services.TicketRepository
+	store      upgrades.Store
+	capacity   upgrades.Capacity
+	events     *changefeed.Fanout // nil when the change feed service publishes Firestore changes
+}
+
+func NewUpgradeHandler(repository services.TicketRepository, store upgrades.Store, capacity upgrades.Capacity, events *changefeed.Fanout) *UpgradeHandler {
+	return &UpgradeHandler{
+		repository: repository,
+		store:      store,
+		capacity:   capacity,
+		events:     events,
+	}
+}
+
+// OfferUpgrades handles POST /admin/upgrades/{flightNumber}/{date}/offers
+// @Summary Offer upgrades on a departure
+// @Description Offer a premium cabin to the eligible tickets of a departure: confirmed or checked-in tickets departing later, booked in a lower cabin, without an open offer for the cabin, and whose passengers fit in the cabin's upgrade seats left. FIXED offers upgrade the ticket as soon as they are accepted, while seats are left; BID offers collect bids until they are awarded. Offers expire after ttl_hours, or at departure. Each offer produces an upgrade_offered change event, which notifies the travellers. Requires an admin API key.
+// @Tags upgrades
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param flightNumber path string true "Flight number" example("AA1234")
+// @Param date path string true "Scheduled departure date in YYYY-MM-DD format" example("2024-12-25")
+// @Param offer body models.UpgradeOfferRequest true "Offer"
+// @Success 201 {object} models.UpgradeOffersResponse "Offers made"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} models.ErrorResponse "No tickets on the flight"
+// @Failure 409 {object} models.ErrorResponse "No upgrade seats left in the cabin"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /admin/upgrades/{flightNumber}/{date}/offers [post]
+func (h *UpgradeHandler) OfferUpgrades(w http.ResponseWriter, r *http.Request) {
+	flightNumber, date, ok := departureParams(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.UpgradeOfferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid JSON payload"})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid upgrade offer", Message: err.Error()})
+		return
+	}
+
+	departureDate, _ := time.Parse("2006-01-02", date)
+	tickets, err := services.SearchTickets(r.Context(), h.repository, models.TicketQuery{
+		FlightNumber:  flightNumber,
+		DepartureDate: departureDate,
+	})
+	if err != nil {
+		log.Printf("Failed to list tickets on flight %s: %v", flightNumber, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to offer upgrades"})
+		return
+	}
+	if len(tickets) == 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "No tickets on the flight"})
+		return
+	}
+
+	departure, err := h.store.ListDeparture(r.Context(), flightNumber, date)
+	if err != nil {
+		log.Printf("Failed to list upgrade offers of %s on %s: %v", flightNumber, date, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to offer upgrades"})
+		return
+	}
+	available := h.capacity[req.Cabin] - upgrades.Upgraded(departure, req.Cabin)
+	if available <= 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.ErrorResponse{
+			Error:   "No upgrade seats left in the cabin",
+			Message: fmt.Sprintf("cabin %s has %d seats for upgrades on this departure", req.Cabin, h.capacity[req.Cabin]),
+		})
+		return
+	}
+
+	now := time.Now().UTC()
+	open := make(map[string]bool)
+	for _, offer := range departure {
+		if offer.Cabin == req.Cabin && offer.Open(now) {
+			open[offer.ConfirmationID] = true
+		}
+	}
+
+	offers := []*models.UpgradeOffer{}
+	for _, ticket := range tickets {
+		if (ticket.Status != "CONFIRMED" && ticket.Status != "CHECKED_IN") || !ticket.DepartureTime.After(now) ||
+			!models.CabinAbove(req.Cabin, models.TicketCabin(ticket)) || open[ticket.ConfirmationID] || ticket.Seats() > available {
+			continue
+		}
+		id, err := upgrades.NewID()
+		if err != nil {
+			log.Printf("Failed to offer upgrades on %s on %s: %v", flightNumber, date, err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to offer upgrades"})
+			return
+		}
+		expires := now.Add(time.Duration(req.TTLHours) * time.Hour)
+		if ticket.DepartureTime.Before(expires) {
+			expires = ticket.DepartureTime.UTC()
+		}
+		offers = append(offers, &models.UpgradeOffer{
+			ID:             id,
+			ConfirmationID: ticket.ConfirmationID,
+			Tenant:         ticket.Labels[models.TenantLabel],
+			FlightNumber:   flightNumber,
+			Date:           date,
+			Cabin:          req.Cabin,
+			Type:           req.Type,
+			Passengers:     ticket.Seats(),
+			Price:          req.Price,
+			MinBid:         req.MinBid,
+			MaxBid:         req.MaxBid,
+			Currency:       currency.BaseCurrency,
+			Status:         models.UpgradeOffered,
+			OfferedBy:      requestActor(r),
+			CreatedAt:      now,
+			ExpiresAt:      expires,
+		})
+	}
+
+	if len(offers) > 0 {
+		if err := h.store.Create(r.Context(), offers); err != nil {
+			log.Printf("Failed to offer upgrades on %s on %s: %v", flightNumber, date, err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to offer upgrades"})
+			return
+		}
+	}
+	for _, offer := range offers {
+		h.publish(r.Context(), offer, nil)
+	}
+	log.Printf("Offered %s upgrades to cabin %s on %s on %s to %d tickets", strings.ToLower(req.Type), req.Cabin, flightNumber, date, len(offers))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(models.UpgradeOffersResponse{Offers: offers, Count: len(offers)})
+}
+
+// GetDepartureUpgrades handles GET /admin/upgrades/{flightNumber}/{date}
+// @Summary Get the upgrade offers of a departure
+// @Description List the upgrade offers made on a departure and the seats of each premium cabin left for upgrades. Requires an admin API key.
+// @Tags upgrades
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param flightNumber path string true "Flight number" example("AA1234")
+// @Param date path string true "Scheduled departure date in YYYY-MM-DD format" example("2024-12-25")
+// @Success 200 {object} models.UpgradeDepartureResponse "Offers and cabin capacity"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /admin/upgrades/{flightNumber}/{date} [get]
+func (h *UpgradeHandler) GetDepartureUpgrades(w http.ResponseWriter, r *http.Request) {
+	flightNumber, date, ok := departureParams(w, r)
+	if !ok {
+		return
+	}
+
+	offers, err := h.store.ListDeparture(r.Context(), flightNumber, date)
+	if err != nil {
+		log.Printf("Failed to list upgrade offers of %s on %s: %v", flightNumber, date, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to list upgrade offers"})
+		return
+	}
+	now := time.Now()
+	for _, offer := range offers {
+		offer.Expire(now)
+	}
+	upgrades.SortNewest(offers)
+	if offers == nil {
+		offers = []*models.UpgradeOffer{}
+	}
+
+	response := models.UpgradeDepartureResponse{FlightNumber: flightNumber, Date: date, Offers: offers, Count: len(offers)}
+	for _, cabin := range []string{models.CabinPremiumEconomy, models.CabinBusiness, models.CabinFirst} {
+		availability := models.CabinAvailability{Cabin: cabin, Capacity: h.capacity[cabin], Upgraded: upgrades.Upgraded(offers, cabin)}
+		availability.Available = max(availability.Capacity-availability.Upgraded, 0)
+		response.Cabins = append(response.Cabins, availability)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// AwardUpgrades handles POST /admin/upgrades/{flightNumber}/{date}/award
+// @Summary Award upgrade bids
+// @Description Decide the bids placed for a cabin of a departure: the highest bids are accepted, oldest first on equal bids, while the cabin has upgrade seats left, and the others are rejected. Accepted tickets move to the cabin and their price rises by the bid for each passenger. Each decision produces an upgrade_accepted or upgrade_rejected change event, which notifies the travellers. Accepted tickets are upgraded even while they are locked, since the bid was placed by their holder. Requires an admin API key.
+// @Tags upgrades
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param flightNumber path string true "Flight number" example("AA1234")
+// @Param date path string true "Scheduled departure date in YYYY-MM-DD format" example("2024-12-25")
+// @Param award body models.UpgradeAwardRequest true "Cabin"
+// @Success 200 {object} models.UpgradeAwardResponse "Bids decided"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /admin/upgrades/{flightNumber}/{date}/award [post]
+func (h *UpgradeHandler) AwardUpgrades(w http.ResponseWriter, r *http.Request) {
+	flightNumber, date, ok := departureParams(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.UpgradeAwardRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid JSON payload"})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid award", Message: err.Error()})
+		return
+	}
+
+	now := time.Now()
+	awarded, rejected, err := h.store.Award(r.Context(), flightNumber, date, req.Cabin, h.capacity[req.Cabin], now)
+	if err != nil {
+		log.Printf("Failed to award upgrades on %s on %s: %v", flightNumber, date, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to award upgrades"})
+		return
+	}
+
+	response := models.UpgradeAwardResponse{
+		FlightNumber: flightNumber,
+		Date:         date,
+		Cabin:        req.Cabin,
+		Awarded:      []*models.UpgradeOffer{},
+		Rejected:     rejected,
+	}
+	for _, offer := range awarded {
+		ticket, err := h.upgradeTicket(r.Context(), offer)
+		if err != nil {
+			// The ticket cannot take the seat, e.g. it was cancelled since the bid
+			log.Printf("Failed to upgrade ticket %s with offer %s: %v", offer.ConfirmationID, offer.ID, err)
+			failed, err := h.store.Update(r.Context(), offer.ID, func(offer *models.UpgradeOffer) error {
+				offer.Status = models.UpgradeRejected
+				return nil
+			})
+			if err != nil {
+				log.Printf("Failed to reject upgrade offer %s: %v", offer.ID, err)
+				continue
+			}
+			h.publish(r.Context(), failed, nil)
+			response.Rejected = append(response.Rejected, failed)
+			continue
+		}
+		h.publish(r.Context(), offer, ticket)
+		response.Awarded = append(response.Awarded, offer)
+	}
+	for _, offer := range rejected {
+		h.publish(r.Context(), offer, nil)
+	}
+	if response.Rejected == nil {
+		response.Rejected = []*models.UpgradeOffer{}
+	}
+	log.Printf("Awarded upgrades to cabin %s on %s on %s: %d accepted, %d rejected",
+		req.Cabin, flightNumber, date, len(response.Awarded), len(response.Rejected))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// ListTicketUpgrades handles GET /ticket/{confirmationID}/upgrades
+// @Summary List the upgrade offers of a ticket
+// @Description List the upgrade offers made to a ticket, newest first, with their status. Offers left unanswered past their deadline are EXPIRED.
+// @Tags upgrades
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param confirmationID path string true "Ticket confirmation ID" example("ABC123")
+// @Success 200 {object} models.UpgradeOffersResponse "Offers"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 404 {object} models.ErrorResponse "Ticket not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /ticket/{confirmationID}/upgrades [get]
+func (h *UpgradeHandler) ListTicketUpgrades(w http.ResponseWriter, r *http.Request) {
+	confirmationID := chi.URLParam(r, "confirmationID")
+	if _, err := h.repository.GetTicket(r.Context(), confirmationID); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Ticket not found"})
+		return
+	}
+
+	offers, err := h.store.ListTicket(r.Context(), confirmationID)
+	if err != nil {
+		log.Printf("Failed to list upgrade offers of ticket %s: %v", confirmationID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to list upgrade offers"})
+		return
+	}
+	now := time.Now()
+	for _, offer := range offers {
+		offer.Expire(now)
+	}
+	upgrades.SortNewest(offers)
+	if offers == nil {
+		offers = []*models.UpgradeOffer{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.UpgradeOffersResponse{Offers: offers, Count: len(offers)})
+}
+
+// AcceptUpgrade handles POST /ticket/{confirmationID}/upgrades/{offerID}/accept
+// @Summary Accept an upgrade offer
+// @Description Accept a FIXED offer, which moves the ticket to the cabin at once and raises its price by the offer's price for each passenger, or place a bid on a BID offer, which waits until bids are awarded; bids can be raised until the offer's deadline. FIXED offers are refused with 409 Conflict once the cabin's upgrade seats are taken. Send X-Lock-Token when holding the ticket's edit lock; bids do not write the ticket, so they are placed while it is locked.
+// @Tags upgrades
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param confirmationID path string true "Ticket confirmation ID" example("ABC123")
+// @Param offerID path string true "Upgrade offer ID" example(5b7d3f9a1c0e4a6f)
+// @Param accept body models.UpgradeAcceptRequest false "Bid, for BID offers"
+// @Param X-Lock-Token header string false "Token of the ticket's edit lock, required while it is locked"
+// @Success 200 {object} models.UpgradeOffer "Offer accepted or bid placed"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 404 {object} models.ErrorResponse "Upgrade offer not found"
+// @Failure 409 {object} models.ErrorResponse "Offer no longer open, cabin full, or lock token not current"
+// @Failure 423 {object} models.ErrorResponse "Ticket is locked by another caller"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /ticket/{confirmationID}/upgrades/{offerID}/accept [post]
+func (h *UpgradeHandler) AcceptUpgrade(w http.ResponseWriter, r *http.Request) {
+	offer, ok := h.ticketOffer(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.UpgradeAcceptRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid JSON payload"})
+			return
+		}
+	}
+
+	now := time.Now()
+	if offer.Type == models.UpgradeBid {
+		if err := offer.CheckBid(req.Bid); err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid bid", Message: err.Error()})
+			return
+		}
+		placed, err := h.store.Update(r.Context(), offer.ID, func(offer *models.UpgradeOffer) error {
+			if !offer.Open(now) {
+				return upgrades.ErrNotOpen
+			}
+			offer.Bid, offer.Status = req.Bid, models.UpgradeBidPlaced
+			return nil
+		})
+		if err != nil {
+			writeUpgradeError(w, offer.ID, err)
+			return
+		}
+		log.Printf("Bid of %.2f %s placed on upgrade offer %s of ticket %s", placed.Bid, placed.Currency, placed.ID, placed.ConfirmationID)
+		h.publish(r.Context(), placed, nil)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(placed)
+		return
+	}
+
+	if req.Bid != 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid bid", Message: "FIXED offers are accepted at their price"})
+		return
+	}
+	if err := services.CheckLock(r.Context(), h.repository, offer.ConfirmationID, r.Header.Get(LockTokenHeader)); err != nil {
+		writeLockError(w, err)
+		return
+	}
+	accepted, err := h.store.Accept(r.Context(), offer.ID, h.capacity[offer.Cabin], now)
+	if err != nil {
+		writeUpgradeError(w, offer.ID, err)
+		return
+	}
+	ticket, err := h.upgradeTicket(r.Context(), accepted)
+	if err != nil {
+		// Give the seat back; the offer can be accepted again if the ticket allows it
+		if _, restoreErr := h.store.Update(r.Context(), accepted.ID, func(offer *models.UpgradeOffer) error {
+			offer.Status, offer.DecidedAt = models.UpgradeOffered, nil
+			return nil
+		}); restoreErr != nil {
+			log.Printf("Failed to reopen upgrade offer %s: %v", accepted.ID, restoreErr)
+		}
+		if errors.Is(err, errTicketCancelled) {
+			writeUpgradeError(w, offer.ID, err)
+			return
+		}
+		log.Printf("Failed to upgrade ticket %s with offer %s: %v", accepted.ConfirmationID, accepted.ID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to upgrade ticket"})
+		return
+	}
+	log.Printf("Upgrade offer %s accepted: ticket %s moved to cabin %s", accepted.ID, accepted.ConfirmationID, accepted.Cabin)
+	h.publish(r.Context(), accepted, ticket)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(accepted)
+}
+
+// DeclineUpgrade handles POST /ticket/{confirmationID}/upgrades/{offerID}/decline
+// @Summary Decline an upgrade offer
+// @Description Decline an open upgrade offer, or withdraw the bid placed on it before bids are awarded.
+// @Tags upgrades
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param confirmationID path string true "Ticket confirmation ID" example("ABC123")
+// @Param offerID path string true "Upgrade offer ID" example(5b7d3f9a1c0e4a6f)
+// @Success 200 {object} models.UpgradeOffer "Offer declined"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 404 {object} models.ErrorResponse "Upgrade offer not found"
+// @Failure 409 {object} models.ErrorResponse "Offer no longer open"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /ticket/{confirmationID}/upgrades/{offerID}/decline [post]
+func (h *UpgradeHandler) DeclineUpgrade(w http.ResponseWriter, r *http.Request) {
+	offer, ok := h.ticketOffer(w, r)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	declined, err := h.store.Update(r.Context(), offer.ID, func(offer *models.UpgradeOffer) error {
+		if !offer.Open(now) {
+			return upgrades.ErrNotOpen
+		}
+		decided := now.UTC()
+		offer.Status, offer.DecidedAt = models.UpgradeDeclined, &decided
+		return nil
+	})
+	if err != nil {
+		writeUpgradeError(w, offer.ID, err)
+		return
+	}
+	log.Printf("Upgrade offer %s of ticket %s declined", declined.ID, declined.ConfirmationID)
+	h.publish(r.Context(), declined, nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(declined)
+}
+
+// ticketOffer returns the offer in the URL if it was made to the ticket in the URL, writing an error response otherwise
+func (h *UpgradeHandler) ticketOffer(w http.ResponseWriter, r *http.Request) (*models.UpgradeOffer, bool) {
+	offer, err := h.store.Get(r.Context(), chi.URLParam(r, "offerID"))
+	if err == nil && offer.ConfirmationID != chi.URLParam(r, "confirmationID") {
+		err = upgrades.ErrNotFound
+	}
+	if err != nil {
+		writeUpgradeError(w, chi.URLParam(r, "offerID"), err)
+		return nil, false
+	}
+	return offer, true
+}
+
+// upgradeTicket moves the offer's ticket to the offer's cabin and adds the
+// upgrade to its price, returning the upgraded ticket
+func (h *UpgradeHandler) upgradeTicket(ctx context.Context, offer *models.UpgradeOffer) (*models.FlightTicket, error) {
+	previous, err := h.repository.GetTicket(ctx, offer.ConfirmationID)
+	if err != nil {
+		return nil, err
+	}
+	if previous.Status == "CANCELLED" {
+		return nil, errTicketCancelled
+	}
+
+	labels := make(map[string]string, len(previous.Labels)+1)
+	for key, value := range previous.Labels {
+		labels[key] = value
+	}
+	labels[models.CabinLabel] = strings.ToLower(offer.Cabin)
+	updates := map[string]interface{}{"labels": labels}
+	if previous.Price != nil {
+		price := *previous.Price
+		price.BaseAmount = currency.Round(price.BaseAmount + offer.Amount())
+		price.Amount = currency.Round(price.BaseAmount * price.ExchangeRate)
+		updates["price"] = &price
+	}
+	if err := h.repository.UpdateTicket(ctx, offer.ConfirmationID, updates); err != nil {
+		return nil, err
+	}
+
+	ticket, err := h.repository.GetTicket(ctx, offer.ConfirmationID)
+	if err != nil {
+		return nil, err
+	}
+	if h.events != nil {
+		event, err := changefeed.NewUpdateEvent(previous, ticket, []string{"labels", "price", "updated_at"})
+		if err == nil {
+			err = h.events.Publish(ctx, event)
+		}
+		if err != nil {
+			log.Printf("Failed to publish upgrade of ticket %s: %v", ticket.ConfirmationID, err)
+		}
+	}
+	return ticket, nil
+}
+
+// publish sends the event of the offer's new status when this service
+// publishes change events; Firestore offers reach the change feed service instead
+func (h *UpgradeHandler) publish(ctx context.Context, offer *models.UpgradeOffer, ticket *models.FlightTicket) {
+	if h.events == nil {
+		return
+	}
+	event, err := changefeed.NewUpgradeEvent(offer, ticket)
+	if err == nil && event != nil {
+		err = h.events.Publish(ctx, event)
+	}
+	if err != nil {
+		log.Printf("Failed to publish upgrade offer %s: %v", offer.ID, err)
+	}
+}
+
+// writeUpgradeError writes the response of an upgrade store error
+func writeUpgradeError(w http.ResponseWriter, offerID string, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case errors.Is(err, upgrades.ErrNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Upgrade offer not found"})
+	case errors.Is(err, upgrades.ErrNotOpen), errors.Is(err, upgrades.ErrCabinFull), errors.Is(err, errTicketCancelled):
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Upgrade not possible", Message: err.Error()})
+	default:
+		log.Printf("Failed to update upgrade offer %s: %v", offerID, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to update upgrade offer"})
+	}
+}
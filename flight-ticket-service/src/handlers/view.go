@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"flight-ticket-service/src/auth"
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/services"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type ViewHandler struct {
+	repository services.TicketRepository
+	tickets    *TicketHandler
+}
+
+func NewViewHandler(repository services.TicketRepository, tickets *TicketHandler) *ViewHandler {
+	return &ViewHandler{
+		repository: repository,
+		tickets:    tickets,
+	}
+}
+
+// viewRepository returns the view store, writing an error response when unavailable
+func (h *ViewHandler) viewRepository(w http.ResponseWriter) (services.ViewRepository, bool) {
+	views, ok := services.Capability[services.ViewRepository](h.repository)
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotImplemented)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Saved views are not supported by the configured storage backend"})
+		return nil, false
+	}
+	return views, true
+}
+
+// requireView loads the view named in the URL, writing an error response when it is missing
+func (h *ViewHandler) requireView(w http.ResponseWriter, r *http.Request, repository services.ViewRepository) (*models.SavedView, bool) {
+	name := chi.URLParam(r, "name")
+	view, err := repository.GetView(r.Context(), name)
+	if err != nil {
+		log.Printf("Failed to get view %s: %v", name, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to retrieve view"})
+		return nil, false
+	}
+	if view == nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "View not found"})
+		return nil, false
+	}
+	return view, true
+}
+
+// ListViews handles GET /views
+// @Summary List saved views
+// @Description List the named ticket searches, ordered by name. Only available when the search feature flag is on.
+// @Tags views
+// @Produce json
+// @Success 200 {object} models.ViewListResponse "Views"
+// @Failure 404 {object} models.ErrorResponse "Search is disabled"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 501 {object} models.ErrorResponse "Views not supported by storage backend"
+// @Router /views [get]
+func (h *ViewHandler) ListViews(w http.ResponseWriter, r *http.Request) {
+	repository, ok := h.viewRepository(w)
+	if !ok {
+		return
+	}
+
+	views, err := repository.ListViews(r.Context())
+	if err != nil {
+		log.Printf("Failed to list views: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to retrieve views"})
+		return
+	}
+	if views == nil {
+		views = []*models.SavedView{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.ViewListResponse{
+		Views: views,
+		Count: len(views),
+	})
+}
+
+// CreateView handles POST /views
+// @Summary Save a view
+// @Description Save a named ticket search. Requires an agent or admin API key.
+// @Tags views
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param view body models.CreateViewRequest true "View"
+// @Success 201 {object} models.SavedView "Created view"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} models.ErrorResponse "Search is disabled"
+// @Failure 409 {object} models.ErrorResponse "View already exists"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 501 {object} models.ErrorResponse "Views not supported by storage backend"
+// @Router /views [post]
+func (h *ViewHandler) CreateView(w http.ResponseWriter, r *http.Request) {
+	repository, ok := h.viewRepository(w)
+	if !ok {
+		return
+	}
+
+	var req models.CreateViewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid JSON payload"})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid view", Message: err.Error()})
+		return
+	}
+
+	existing, err := repository.GetView(r.Context(), req.Name)
+	if err != nil {
+		log.Printf("Failed to get view %s: %v", req.Name, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to save view"})
+		return
+	}
+	if existing != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "View already exists", Message: "Use PUT /views/" + req.Name + " to change it"})
+		return
+	}
+
+	principal, _ := auth.FromContext(r.Context())
+	now := time.Now().UTC()
+	view := &models.SavedView{
+		Name:        req.Name,
+		Description: req.Description,
+		Filters:     req.Filters,
+		CreatedBy:   principal.Name,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := repository.SaveView(r.Context(), view); err != nil {
+		log.Printf("Failed to save view %s: %v", view.Name, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to save view"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(view)
+}
+
+// GetView handles GET /views/{name}
+// @Summary Get a saved view
+// @Description Retrieve a named ticket search. Only available when the search feature flag is on.
+// @Tags views
+// @Produce json
+// @Param name path string true "View name" example("jfk-departures-today")
+// @Success 200 {object} models.SavedView "View"
+// @Failure 404 {object} models.ErrorResponse "View not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 501 {object} models.ErrorResponse "Views not supported by storage backend"
+// @Router /views/{name} [get]
+func (h *ViewHandler) GetView(w http.ResponseWriter, r *http.Request) {
+	repository, ok := h.viewRepository(w)
+	if !ok {
+		return
+	}
+
+	view, ok := h.requireView(w, r, repository)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(view)
+}
+
+// UpdateView handles PUT /views/{name}
+// @Summary Update a saved view
+// @Description Replace the description and filters of a named ticket search. Requires an agent or admin API key.
+// @Tags views
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param name path string true "View name" example("jfk-departures-today")
+// @Param view body models.UpdateViewRequest true "View"
+// @Success 200 {object} models.SavedView "Updated view"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} models.ErrorResponse "View not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 501 {object} models.ErrorResponse "Views not supported by storage backend"
+// @Router /views/{name} [put]
+func (h *ViewHandler) UpdateView(w http.ResponseWriter, r *http.Request) {
+	repository, ok := h.viewRepository(w)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateViewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid JSON payload"})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid view", Message: err.Error()})
+		return
+	}
+
+	view, ok := h.requireView(w, r, repository)
+	if !ok {
+		return
+	}
+
+	view.Description = req.Description
+	view.Filters = req.Filters
+	view.UpdatedAt = time.Now().UTC()
+	if err := repository.SaveView(r.Context(), view); err != nil {
+		log.Printf("Failed to save view %s: %v", view.Name, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to save view"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(view)
+}
+
+// DeleteView handles DELETE /views/{name}
+// @Summary Delete a saved view
+// @Description Remove a named ticket search. Requires an agent or admin API key.
+// @Tags views
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param name path string true "View name" example("jfk-departures-today")
+// @Success 200 {object} models.SuccessResponse "Deleted view"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 403 {object} models.ErrorResponse "Insufficient permissions"
+// @Failure 404 {object} models.ErrorResponse "View not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 501 {object} models.ErrorResponse "Views not supported by storage backend"
+// @Router /views/{name} [delete]
+func (h *ViewHandler) DeleteView(w http.ResponseWriter, r *http.Request) {
+	repository, ok := h.viewRepository(w)
+	if !ok {
+		return
+	}
+
+	view, ok := h.requireView(w, r, repository)
+	if !ok {
+		return
+	}
+
+	if err := repository.DeleteView(r.Context(), view.Name); err != nil {
+		log.Printf("Failed to delete view %s: %v", view.Name, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to delete view"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.SuccessResponse{Message: "View deleted successfully"})
+}
+
+// GetViewResults handles GET /views/{name}/results
+// @Summary Run a saved view
+// @Description Return the tickets matching a named search, newest first. Relative departure dates such as today are resolved when the view runs. Only available when the search feature flag is on.
+// @Tags views
+// @Produce json
+// @Param name path string true "View name" example("jfk-departures-today")
+// @Param currency query string false "ISO 4217 currency to display prices in" example(EUR)
+// @Success 200 {object} models.TicketListResponse "Matching tickets"
+// @Failure 404 {object} models.ErrorResponse "View not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Failure 501 {object} models.ErrorResponse "Views or search not supported by storage backend"
+// @Failure 503 {object} models.ErrorResponse "Exchange rates unavailable"
+// @Router /views/{name}/results [get]
+func (h *ViewHandler) GetViewResults(w http.ResponseWriter, r *http.Request) {
+	repository, ok := h.viewRepository(w)
+	if !ok {
+		return
+	}
+
+	view, ok := h.requireView(w, r, repository)
+	if !ok {
+		return
+	}
+
+	query, err := view.Filters.Query(time.Now())
+	if err != nil {
+		log.Printf("Failed to run view %s: %v", view.Name, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid saved view", Message: err.Error()})
+		return
+	}
+
+	h.tickets.writeSearchResults(w, r, query)
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"flight-ticket-service/src/changefeed"
+	"flight-ticket-service/src/currency"
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/services"
+	"flight-ticket-service/src/vouchers"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type VoucherHandler struct {
+	repository services.TicketRepository
+	store      vouchers.Store
+	events     *changefeed.Fanout // nil when the change feed service publishes Firestore changes
+}
+
+func NewVoucherHandler(repository services.TicketRepository, store vouchers.Store, events *changefeed.Fanout) *VoucherHandler {
+	return &VoucherHandler{
+		repository: repository,
+		store:      store,
+		events:     events,
+	}
+}
+
+// ListTicketVouchers handles GET /ticket/{confirmationID}/vouchers
+// @Summary List the vouchers of a ticket
+// @Description List the vouchers issued for a ticket the airline cancelled, newest first, with their balance and redemptions.
+// @Tags vouchers
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param confirmationID path string true "Ticket confirmation ID" example("ABC123")
+// @Success 200 {object} models.VouchersResponse "Vouchers"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 404 {object} models.ErrorResponse "Ticket not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /ticket/{confirmationID}/vouchers [get]
+func (h *VoucherHandler) ListTicketVouchers(w http.ResponseWriter, r *http.Request) {
+	confirmationID := chi.URLParam(r, "confirmationID")
+	if _, err := h.repository.GetTicket(r.Context(), confirmationID); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Ticket not found"})
+		return
+	}
+
+	list, err := h.store.ListTicket(r.Context(), confirmationID)
+	if err != nil {
+		log.Printf("Failed to list vouchers of ticket %s: %v", confirmationID, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to list vouchers"})
+		return
+	}
+	now := time.Now()
+	for _, voucher := range list {
+		voucher.Expire(now)
+	}
+	vouchers.SortNewest(list)
+	if list == nil {
+		list = []*models.Voucher{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.VouchersResponse{Vouchers: list, Count: len(list)})
+}
+
+// GetVoucher handles GET /vouchers/{code}
+// @Summary Get a voucher
+// @Description Get a voucher by its code, with its balance, expiry and redemptions.
+// @Tags vouchers
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param code path string true "Voucher code" example(VQ7K2M9X4TPA)
+// @Success 200 {object} models.Voucher "Voucher"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 404 {object} models.ErrorResponse "Voucher not found"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /vouchers/{code} [get]
+func (h *VoucherHandler) GetVoucher(w http.ResponseWriter, r *http.Request) {
+	code := strings.ToUpper(chi.URLParam(r, "code"))
+	voucher, err := h.store.Get(r.Context(), code)
+	if err != nil {
+		writeVoucherError(w, code, err)
+		return
+	}
+	voucher.Expire(time.Now())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(voucher)
+}
+
+// RedeemVoucher handles POST /vouchers/{code}/redeem
+// @Summary Redeem a voucher against a booking
+// @Description Take a voucher's credit off the price of a booking: its balance, up to the ticket's fare. What is left stays on the voucher for other bookings until it expires. A voucher is redeemed once per ticket. The ticket's price shows the credit in voucher_credit.
+// @Tags vouchers
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth || BearerAuth
+// @Param code path string true "Voucher code" example(VQ7K2M9X4TPA)
+// @Param redeem body models.VoucherRedeemRequest true "Ticket to redeem the voucher against"
+// @Param X-Lock-Token header string false "Token of the ticket's edit lock, required while it is locked"
+// @Success 200 {object} models.VoucherRedeemResponse "Voucher redeemed"
+// @Failure 400 {object} models.ErrorResponse "Bad request"
+// @Failure 401 {object} models.ErrorResponse "Authentication required"
+// @Failure 404 {object} models.ErrorResponse "Voucher or ticket not found"
+// @Failure 409 {object} models.ErrorResponse "Voucher used up, expired or already redeemed against the ticket; or the ticket is cancelled"
+// @Failure 423 {object} models.ErrorResponse "Ticket is locked by another caller"
+// @Failure 500 {object} models.ErrorResponse "Internal server error"
+// @Router /vouchers/{code}/redeem [post]
+func (h *VoucherHandler) RedeemVoucher(w http.ResponseWriter, r *http.Request) {
+	code := strings.ToUpper(chi.URLParam(r, "code"))
+	var req models.VoucherRedeemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid JSON payload"})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Invalid redemption", Message: err.Error()})
+		return
+	}
+
+	previous, err := h.repository.GetTicket(r.Context(), req.ConfirmationID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Ticket not found"})
+		return
+	}
+	if previous.Status == "CANCELLED" || previous.Price == nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Voucher not redeemable", Message: "the ticket is cancelled or has no price"})
+		return
+	}
+	if err := services.CheckLock(r.Context(), h.repository, req.ConfirmationID, r.Header.Get(LockTokenHeader)); err != nil {
+		writeLockError(w, err)
+		return
+	}
+
+	var credit float64
+	voucher, err := h.store.Update(r.Context(), code, func(voucher *models.Voucher) error {
+		credit, err = vouchers.Redeem(voucher, previous, requestActor(r), time.Now().UTC())
+		return err
+	})
+	if err != nil {
+		writeVoucherError(w, code, err)
+		return
+	}
+
+	ticket, err := h.creditTicket(r.Context(), previous, credit)
+	if err != nil {
+		log.Printf("Failed to redeem voucher %s against ticket %s: %v", code, previous.ConfirmationID, err)
+		if _, err := h.store.Update(r.Context(), code, func(voucher *models.Voucher) error {
+			vouchers.Restore(voucher, previous.ConfirmationID)
+			return nil
+		}); err != nil {
+			log.Printf("Failed to restore voucher %s: %v", code, err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to redeem voucher"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.VoucherRedeemResponse{Credit: credit, Voucher: voucher, Ticket: ticket})
+}
+
+// creditTicket takes the credit off the ticket's price and publishes the change
+func (h *VoucherHandler) creditTicket(ctx context.Context, previous *models.FlightTicket, credit float64) (*models.FlightTicket, error) {
+	price := *previous.Price
+	price.BaseAmount = currency.Round(price.BaseAmount - credit)
+	price.Amount = currency.Round(price.BaseAmount * price.ExchangeRate)
+	price.VoucherCredit = currency.Round(price.VoucherCredit + credit)
+	if err := h.repository.UpdateTicket(ctx, previous.ConfirmationID, map[string]interface{}{"price": &price}); err != nil {
+		return nil, err
+	}
+
+	ticket, err := h.repository.GetTicket(ctx, previous.ConfirmationID)
+	if err != nil {
+		return nil, err
+	}
+	if h.events != nil {
+		event, err := changefeed.NewUpdateEvent(previous, ticket, []string{"price", "updated_at"})
+		if err == nil {
+			err = h.events.Publish(ctx, event)
+		}
+		if err != nil {
+			log.Printf("Failed to publish voucher credit of ticket %s: %v", ticket.ConfirmationID, err)
+		}
+	}
+	return ticket, nil
+}
+
+// writeVoucherError writes the response of a voucher store error
+func writeVoucherError(w http.ResponseWriter, code string, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case errors.Is(err, vouchers.ErrNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Voucher not found"})
+	case errors.Is(err, vouchers.ErrNotRedeemable), errors.Is(err, vouchers.ErrAlreadyRedeemed):
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Voucher not redeemable", Message: err.Error()})
+	default:
+		log.Printf("Failed to update voucher %s: %v", code, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: "Failed to update voucher"})
+	}
+}
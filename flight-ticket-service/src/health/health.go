@@ -0,0 +1,293 @@
+// Package health keeps the recent results of dependency checks, so that an
+// instance can report when each dependency last worked and how often it has
+// been failing lately.
+//
+// Results come from periodic checks (the ticket storage) and from the calls
+// the service makes anyway (weather and exchange rate providers, change event
+// sinks). Each dependency keeps its last HEALTH_HISTORY_SIZE results in a
+// ring buffer.
+package health
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults
+const (
+	DefaultCheckInterval = time.Minute
+	DefaultCheckTimeout  = 10 * time.Second
+	DefaultHistorySize   = 100
+)
+
+// Dependency statuses
+const (
+	StatusUp      = "up"      // the last result succeeded
+	StatusDown    = "down"    // the last result failed
+	StatusUnknown = "unknown" // no results yet
+)
+
+// Overall statuses
+const (
+	StatusHealthy  = "healthy"
+	StatusDegraded = "degraded"
+)
+
+// Config configures the dependency checks
+type Config struct {
+	Interval    time.Duration // between periodic checks; 0 disables them
+	Timeout     time.Duration // of a single check
+	HistorySize int           // results kept per dependency
+}
+
+// ConfigFromEnv reads HEALTH_CHECK_INTERVAL, HEALTH_CHECK_TIMEOUT and HEALTH_HISTORY_SIZE
+func ConfigFromEnv() (Config, error) {
+	config := Config{Interval: DefaultCheckInterval, Timeout: DefaultCheckTimeout, HistorySize: DefaultHistorySize}
+	if value := strings.TrimSpace(os.Getenv("HEALTH_CHECK_INTERVAL")); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil || interval < 0 {
+			return Config{}, fmt.Errorf("invalid HEALTH_CHECK_INTERVAL %q: must be a duration, 0 to disable periodic checks", value)
+		}
+		config.Interval = interval
+	}
+	if value := strings.TrimSpace(os.Getenv("HEALTH_CHECK_TIMEOUT")); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			return Config{}, fmt.Errorf("invalid HEALTH_CHECK_TIMEOUT %q: must be a positive duration", value)
+		}
+		config.Timeout = timeout
+	}
+	if value := strings.TrimSpace(os.Getenv("HEALTH_HISTORY_SIZE")); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size < 1 {
+			return Config{}, fmt.Errorf("invalid HEALTH_HISTORY_SIZE %q: must be a positive number", value)
+		}
+		config.HistorySize = size
+	}
+	return config, nil
+}
+
+// Check is a periodic dependency check
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Dependency is the recent health of a dependency
+// @Description Recent check results of a dependency
+type Dependency struct {
+	Name        string     `json:"name" example:"storage" description:"Dependency name"`
+	Status      string     `json:"status" example:"up" description:"up when the last result succeeded, down when it failed, unknown without results"`
+	Checks      int        `json:"checks" example:"60" description:"Results in the history"`
+	Failures    int        `json:"failures" example:"1" description:"Failed results in the history"`
+	ErrorRate   float64    `json:"error_rate" example:"0.016" description:"Fraction of failed results in the history"`
+	Since       *time.Time `json:"since,omitempty" example:"2024-07-12T18:00:00Z" description:"Time of the oldest result in the history"`
+	LastCheck   *time.Time `json:"last_check,omitempty" example:"2024-07-12T19:00:00Z" description:"Time of the last result"`
+	LastSuccess *time.Time `json:"last_success,omitempty" example:"2024-07-12T19:00:00Z" description:"Time of the last success, also before the history"`
+	LastFailure *time.Time `json:"last_failure,omitempty" example:"2024-07-12T18:42:00Z" description:"Time of the last failure, also before the history"`
+	LastError   string     `json:"last_error,omitempty" example:"context deadline exceeded" description:"Error of the last failure"`
+	LatencyMS   float64    `json:"latency_ms" example:"12.5" description:"Duration of the last check or call in milliseconds"`
+}
+
+// Details is the health of the instance and its dependencies
+// @Description Health of the instance and its dependencies
+type Details struct {
+	Status       string       `json:"status" example:"healthy" description:"healthy, or degraded when a dependency is down"`
+	Timestamp    time.Time    `json:"timestamp" example:"2024-07-12T19:00:00Z" description:"Time of the report"`
+	Dependencies []Dependency `json:"dependencies" description:"Dependencies in the order they were registered"`
+}
+
+// result is one check result
+type result struct {
+	time    time.Time
+	latency time.Duration
+	err     string
+}
+
+// history is the ring buffer of a dependency's results
+type history struct {
+	results     []result
+	next        int // index the next result is written to
+	full        bool
+	lastSuccess time.Time
+	lastFailure time.Time
+	lastError   string
+}
+
+// Tracker keeps the recent results of each dependency
+type Tracker struct {
+	size int
+	now  func() time.Time
+
+	mu        sync.Mutex
+	names     []string
+	histories map[string]*history
+}
+
+// NewTracker creates a tracker keeping size results per dependency
+func NewTracker(size int) *Tracker {
+	if size < 1 {
+		size = DefaultHistorySize
+	}
+	return &Tracker{size: size, now: time.Now, histories: make(map[string]*history)}
+}
+
+// Register lists a dependency before it has results, so it is reported as unknown
+func (t *Tracker) Register(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.history(name)
+}
+
+// Record adds the result of a check or call that started at start
+func (t *Tracker) Record(name string, start time.Time, err error) {
+	now := t.now()
+	r := result{time: now, latency: now.Sub(start)}
+	if err != nil {
+		r.err = err.Error()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	h := t.history(name)
+	h.results[h.next] = r
+	h.next = (h.next + 1) % len(h.results)
+	if h.next == 0 {
+		h.full = true
+	}
+	if err != nil {
+		h.lastFailure, h.lastError = now, r.err
+	} else {
+		h.lastSuccess = now
+	}
+}
+
+// history returns the history of a dependency, creating it; t.mu must be held
+func (t *Tracker) history(name string) *history {
+	h, ok := t.histories[name]
+	if !ok {
+		h = &history{results: make([]result, t.size)}
+		t.histories[name] = h
+		t.names = append(t.names, name)
+	}
+	return h
+}
+
+// Details reports every dependency; the instance is degraded when one is down
+func (t *Tracker) Details() Details {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	details := Details{Status: StatusHealthy, Timestamp: t.now().UTC(), Dependencies: make([]Dependency, 0, len(t.names))}
+	for _, name := range t.names {
+		dependency := t.histories[name].dependency(name)
+		if dependency.Status == StatusDown {
+			details.Status = StatusDegraded
+		}
+		details.Dependencies = append(details.Dependencies, dependency)
+	}
+	return details
+}
+
+// dependency summarizes a history
+func (h *history) dependency(name string) Dependency {
+	d := Dependency{Name: name, Status: StatusUnknown}
+	count := h.next
+	if h.full {
+		count = len(h.results)
+	}
+	if count == 0 {
+		return d
+	}
+
+	oldest := 0
+	if h.full {
+		oldest = h.next
+	}
+	last := h.results[(h.next-1+len(h.results))%len(h.results)]
+	for i := 0; i < count; i++ {
+		if h.results[(oldest+i)%len(h.results)].err != "" {
+			d.Failures++
+		}
+	}
+
+	d.Checks = count
+	d.ErrorRate = float64(d.Failures) / float64(count)
+	d.Since = timePtr(h.results[oldest].time)
+	d.LastCheck = timePtr(last.time)
+	d.LatencyMS = float64(last.latency.Microseconds()) / 1000
+	d.LastSuccess = timePtr(h.lastSuccess)
+	d.LastFailure = timePtr(h.lastFailure)
+	d.LastError = h.lastError
+	d.Status = StatusUp
+	if last.err != "" {
+		d.Status = StatusDown
+	}
+	return d
+}
+
+// Watch runs the checks right away and then every interval until ctx is
+// done. Each check runs with the timeout; failures are logged when a
+// dependency goes down and when it recovers.
+func (t *Tracker) Watch(ctx context.Context, config Config, checks ...Check) {
+	for _, check := range checks {
+		t.Register(check.Name)
+	}
+	if config.Interval == 0 {
+		return
+	}
+
+	go func() {
+		down := make(map[string]bool)
+		ticker := time.NewTicker(config.Interval)
+		defer ticker.Stop()
+		for {
+			for _, check := range checks {
+				err := t.run(ctx, check, config.Timeout)
+				if ctx.Err() != nil {
+					return
+				}
+				switch {
+				case err != nil && !down[check.Name]:
+					log.Printf("Health check %s failed: %v", check.Name, err)
+				case err == nil && down[check.Name]:
+					log.Printf("Health check %s recovered", check.Name)
+				}
+				down[check.Name] = err != nil
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// run runs a check with a timeout and records its result
+func (t *Tracker) run(ctx context.Context, check Check, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	start := t.now()
+	err := check.Run(ctx)
+	if ctx.Err() == context.Canceled {
+		return err
+	}
+	t.Record(check.Name, start, err)
+	return err
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	t = t.UTC()
+	return &t
+}
@@ -0,0 +1,82 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTrackerKeepsRecentResults(t *testing.T) {
+	now := time.Date(2024, 7, 12, 19, 0, 0, 0, time.UTC)
+	tracker := NewTracker(4)
+	tracker.now = func() time.Time { return now }
+	tracker.Register("storage")
+
+	if d := tracker.Details(); d.Status != StatusHealthy || d.Dependencies[0].Status != StatusUnknown {
+		t.Fatalf("Expected an unknown dependency, got %+v", d)
+	}
+
+	// Fail once, then succeed five times: the failure drops out of the last four results
+	failedAt := now
+	tracker.Record("storage", now.Add(-time.Second), errors.New("deadline exceeded"))
+	d := tracker.Details()
+	if d.Status != StatusDegraded || d.Dependencies[0].Status != StatusDown || d.Dependencies[0].ErrorRate != 1 || d.Dependencies[0].LatencyMS != 1000 {
+		t.Fatalf("Expected a failing dependency, got %+v", d)
+	}
+	for i := 0; i < 5; i++ {
+		now = now.Add(time.Minute)
+		if i == 2 {
+			tracker.Record("weather", now, errors.New("timeout"))
+		}
+		tracker.Record("storage", now, nil)
+	}
+
+	d = tracker.Details()
+	storage := d.Dependencies[0]
+	if d.Status != StatusDegraded || d.Dependencies[1].Name != "weather" {
+		t.Errorf("Expected the failing weather provider to degrade the instance, got %+v", d)
+	}
+	if storage.Status != StatusUp || storage.Checks != 4 || storage.Failures != 0 || storage.ErrorRate != 0 {
+		t.Errorf("Expected four successful results, got %+v", storage)
+	}
+	if !storage.Since.Equal(failedAt.Add(2*time.Minute)) || !storage.LastCheck.Equal(now) || !storage.LastSuccess.Equal(now) {
+		t.Errorf("Unexpected times %v %v %v", storage.Since, storage.LastCheck, storage.LastSuccess)
+	}
+	// The last failure is remembered after it left the history
+	if !storage.LastFailure.Equal(failedAt) || storage.LastError != "deadline exceeded" {
+		t.Errorf("Expected the last failure to be kept, got %v %q", storage.LastFailure, storage.LastError)
+	}
+}
+
+func TestWatchRunsChecks(t *testing.T) {
+	tracker := NewTracker(10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ran := make(chan struct{}, 10)
+	tracker.Watch(ctx, Config{Interval: time.Millisecond, Timeout: time.Second}, Check{Name: "storage", Run: func(ctx context.Context) error {
+		ran <- struct{}{}
+		return nil
+	}})
+	<-ran
+	<-ran
+	cancel()
+
+	if d := tracker.Details().Dependencies[0]; d.Checks == 0 || d.Status != StatusUp {
+		t.Errorf("Expected recorded checks, got %+v", d)
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("HEALTH_CHECK_INTERVAL", "0")
+	t.Setenv("HEALTH_HISTORY_SIZE", "20")
+	config, err := ConfigFromEnv()
+	if err != nil || config.Interval != 0 || config.HistorySize != 20 || config.Timeout != DefaultCheckTimeout {
+		t.Fatalf("Unexpected config %+v, %v", config, err)
+	}
+	t.Setenv("HEALTH_HISTORY_SIZE", "0")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Error("Expected an error for an empty history")
+	}
+}
@@ -0,0 +1,117 @@
+// Package docstore reads and writes Firestore documents as Go structs, so that
+// each collection's repository does not repeat the DataTo loops and error
+// wrapping. The kind names the documents in errors and logs, e.g. "ticket".
+package docstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrNotFound is wrapped by Get and Update when the document does not exist
+var ErrNotFound = errors.New("not found")
+
+// Decoder is implemented by documents that decode their own fields, e.g. to
+// upgrade documents stored with an older schema; others are decoded with DataTo
+type Decoder interface {
+	DecodeFields(fields map[string]interface{}) error
+}
+
+// Get reads a document, failing with ErrNotFound when it does not exist
+func Get[T any](ctx context.Context, ref *firestore.DocumentRef, kind string) (*T, error) {
+	doc, err := ref.Get(ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, fmt.Errorf("failed to get %s: %s %w", kind, ref.ID, ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %v", kind, err)
+	}
+	return Decode[T](doc, kind)
+}
+
+// Find reads a document, returning nil without an error when it does not exist
+func Find[T any](ctx context.Context, ref *firestore.DocumentRef, kind string) (*T, error) {
+	value, err := Get[T](ctx, ref, kind)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	return value, err
+}
+
+// List runs a query and decodes its documents in order. Documents that do
+// not decode are logged and skipped, so one bad document does not hide the rest.
+func List[T any](ctx context.Context, query firestore.Query, kind string) ([]*T, error) {
+	docs, err := query.Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s documents: %v", kind, err)
+	}
+
+	var values []*T
+	for _, doc := range docs {
+		value, err := Decode[T](doc, kind)
+		if err != nil {
+			log.Print(err)
+			continue
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// Set creates or replaces a document
+func Set[T any](ctx context.Context, ref *firestore.DocumentRef, value *T, kind string) error {
+	if _, err := ref.Set(ctx, value); err != nil {
+		return fmt.Errorf("failed to save %s: %v", kind, err)
+	}
+	return nil
+}
+
+// Update reads a document, applies change and writes it back in a
+// transaction, so concurrent updates of the document are serialized. An error
+// returned by change aborts the update and is returned as is.
+func Update[T any](ctx context.Context, client *firestore.Client, ref *firestore.DocumentRef, kind string, change func(*T) error) (*T, error) {
+	var updated *T
+	err := client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if status.Code(err) == codes.NotFound {
+			return fmt.Errorf("failed to get %s: %s %w", kind, ref.ID, ErrNotFound)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get %s: %v", kind, err)
+		}
+		value, err := Decode[T](doc, kind)
+		if err != nil {
+			return err
+		}
+		if err := change(value); err != nil {
+			return err
+		}
+		updated = value
+		return tx.Set(ref, value)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// Decode converts a document snapshot to a T
+func Decode[T any](doc *firestore.DocumentSnapshot, kind string) (*T, error) {
+	var value T
+	var err error
+	if decoder, ok := any(&value).(Decoder); ok {
+		err = decoder.DecodeFields(doc.Data())
+	} else {
+		err = doc.DataTo(&value)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s %s: %v", kind, doc.Ref.ID, err)
+	}
+	return &value, nil
+}
@@ -0,0 +1,90 @@
+package docstore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	firestoreadmin "google.golang.org/api/firestore/v1"
+)
+
+// Index orders
+const (
+	Ascending  = "ASCENDING"
+	Descending = "DESCENDING"
+)
+
+// IndexField is a field of a composite index
+type IndexField struct {
+	Path  string
+	Order string // Ascending or Descending
+}
+
+// Index is a composite index a query needs, on a collection
+type Index struct {
+	Collection string
+	Fields     []IndexField
+}
+
+// String describes the index, e.g. "jobs (status ASCENDING, created_at ASCENDING)"
+func (i Index) String() string {
+	fields := make([]string, len(i.Fields))
+	for n, field := range i.Fields {
+		fields[n] = field.Path + " " + field.Order
+	}
+	return fmt.Sprintf("%s (%s)", i.Collection, strings.Join(fields, ", "))
+}
+
+// MissingIndexes lists the composite indexes of a database, e.g.
+// projects/my-project/databases/(default), and returns the required indexes
+// that do not exist, with the reason: missing, or the state of an index that
+// is not ready yet.
+func MissingIndexes(ctx context.Context, admin *firestoreadmin.Service, database string, required []Index) (map[string]string, error) {
+	missing := make(map[string]string)
+	existing := make(map[string][]*firestoreadmin.GoogleFirestoreAdminV1Index)
+	for _, index := range required {
+		indexes, ok := existing[index.Collection]
+		if !ok {
+			parent := fmt.Sprintf("%s/collectionGroups/%s", database, index.Collection)
+			err := admin.Projects.Databases.CollectionGroups.Indexes.List(parent).Pages(ctx, func(page *firestoreadmin.GoogleFirestoreAdminV1ListIndexesResponse) error {
+				indexes = append(indexes, page.Indexes...)
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to list indexes of %s: %v", index.Collection, err)
+			}
+			existing[index.Collection] = indexes
+		}
+
+		missing[index.String()] = "missing"
+		for _, candidate := range indexes {
+			if candidate.QueryScope == "COLLECTION" && indexMatches(candidate, index) {
+				if candidate.State == "READY" {
+					delete(missing, index.String())
+				} else {
+					missing[index.String()] = strings.ToLower(candidate.State)
+				}
+				break
+			}
+		}
+	}
+	return missing, nil
+}
+
+// indexMatches compares the fields of an index, ignoring the __name__ field
+// Firestore appends to composite indexes
+func indexMatches(candidate *firestoreadmin.GoogleFirestoreAdminV1Index, index Index) bool {
+	fields := candidate.Fields
+	if n := len(fields); n > 0 && fields[n-1].FieldPath == "__name__" {
+		fields = fields[:n-1]
+	}
+	if len(fields) != len(index.Fields) {
+		return false
+	}
+	for i, field := range fields {
+		if field.FieldPath != index.Fields[i].Path || field.Order != index.Fields[i].Order {
+			return false
+		}
+	}
+	return true
+}
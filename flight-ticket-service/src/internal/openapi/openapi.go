@@ -0,0 +1,279 @@
+// Package openapi reads the OpenAPI 2.0 spec that swag generates into
+// docs/swagger.json, for the generators of the MCP tools and API clients.
+// It lists the operations with stable names and inlines the definitions
+// schemas refer to.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// DefaultExcludes are the path prefixes of operations meant for probes,
+// scrapers and browsers rather than for API clients
+var DefaultExcludes = []string{"/health", "/metrics", "/swagger", "/admin/debug", "/admin/ui"}
+
+// Methods are the HTTP methods of operations, in the order operations of a path are listed
+var Methods = []string{"get", "post", "put", "patch", "delete"}
+
+// verbs name the operations without an operationId
+var verbs = map[string]string{"get": "get", "post": "create", "put": "update", "patch": "patch", "delete": "delete"}
+
+// Spec is the part of an OpenAPI 2.0 document the generators read
+type Spec struct {
+	Swagger string `json:"swagger"`
+	Info    struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Version     string `json:"version"`
+	} `json:"info"`
+	Paths       map[string]map[string]Operation `json:"paths"`
+	Definitions map[string]map[string]any       `json:"definitions"`
+}
+
+// Operation is an operation of a path
+type Operation struct {
+	OperationID string              `json:"operationId"`
+	Summary     string              `json:"summary"`
+	Description string              `json:"description"`
+	Deprecated  bool                `json:"deprecated"`
+	Produces    []string            `json:"produces"`
+	Parameters  []Parameter         `json:"parameters"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+// Parameter is a path, query, header or body parameter of an operation
+type Parameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Description string         `json:"description"`
+	Required    bool           `json:"required"`
+	Type        string         `json:"type"`
+	Format      string         `json:"format"`
+	Enum        []any          `json:"enum"`
+	Default     any            `json:"default"`
+	Minimum     *float64       `json:"minimum"`
+	Maximum     *float64       `json:"maximum"`
+	Items       map[string]any `json:"items"`
+	Schema      map[string]any `json:"schema"` // of body parameters
+}
+
+// Response is a documented response of an operation
+type Response struct {
+	Description string         `json:"description"`
+	Schema      map[string]any `json:"schema"`
+}
+
+// Endpoint is an operation with its path, method and name
+type Endpoint struct {
+	Name   string // snake_case operationId, or else named after the method and path
+	Method string // upper case
+	Path   string // with {name} placeholders
+	Operation
+}
+
+// Parse reads a spec generated by swag
+func Parse(data []byte) (*Spec, error) {
+	var spec Spec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("invalid spec: %v", err)
+	}
+	if spec.Swagger != "2.0" {
+		return nil, fmt.Errorf("unsupported spec version %q: expected swag's OpenAPI 2.0", spec.Swagger)
+	}
+	return &spec, nil
+}
+
+// Endpoints lists the operations by path, skipping deprecated ones and those
+// under the excluded path prefixes. An operation without an operationId is
+// named after its method and path, with only the last path parameter unless
+// another operation already has that name.
+func (s *Spec) Endpoints(exclude []string) ([]Endpoint, error) {
+	paths := make([]string, 0, len(s.Paths))
+	for path := range s.Paths {
+		if !excluded(path, exclude) {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var endpoints []Endpoint
+	byName := make(map[string]Endpoint)
+	for _, path := range paths {
+		for _, method := range Methods {
+			op, ok := s.Paths[path][method]
+			if !ok || op.Deprecated {
+				continue
+			}
+			name := Snake(op.OperationID)
+			if name == "" {
+				name = endpointName(path, method, false)
+				if _, ok := byName[name]; ok {
+					name = endpointName(path, method, true)
+				}
+			}
+			endpoint := Endpoint{Name: name, Method: strings.ToUpper(method), Path: path, Operation: op}
+			if other, ok := byName[name]; ok {
+				return nil, fmt.Errorf("%s %s and %s %s are both named %s; set an operationId (@ID) on one", other.Method, other.Path, endpoint.Method, path, name)
+			}
+			byName[name] = endpoint
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints, nil
+}
+
+// excluded reports whether a path is under one of the prefixes
+func excluded(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// endpointName names an operation after its method and path:
+// GET /ticket/{confirmationID} is get_ticket_by_confirmation_id
+func endpointName(path, method string, allParams bool) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	parts := []string{verbs[method]}
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			if allParams || i == len(segments)-1 {
+				parts = append(parts, "by", Snake(strings.Trim(segment, "{}")))
+			}
+			continue
+		}
+		parts = append(parts, Snake(segment))
+	}
+	return strings.Join(parts, "_")
+}
+
+// SuccessResponse returns the first documented 2xx response, by status
+func (op Operation) SuccessResponse() (Response, bool) {
+	var statuses []string
+	for status := range op.Responses {
+		if strings.HasPrefix(status, "2") {
+			statuses = append(statuses, status)
+		}
+	}
+	if len(statuses) == 0 {
+		return Response{}, false
+	}
+	sort.Strings(statuses)
+	return op.Responses[statuses[0]], true
+}
+
+// RefName returns the definition a schema refers to, or ""
+func RefName(schema map[string]any) string {
+	ref, _ := schema["$ref"].(string)
+	return strings.TrimPrefix(ref, "#/definitions/")
+}
+
+// Unwrap returns the schema wrapped by swag in a single-entry allOf, which it
+// generates for referenced fields with a description, or the schema itself
+func Unwrap(schema map[string]any) map[string]any {
+	all, ok := schema["allOf"].([]any)
+	if !ok || len(all) != 1 {
+		return schema
+	}
+	inner, ok := all[0].(map[string]any)
+	if !ok {
+		return schema
+	}
+	unwrapped := make(map[string]any, len(schema)+len(inner))
+	for key, value := range inner {
+		unwrapped[key] = value
+	}
+	for key, value := range schema {
+		if key != "allOf" {
+			unwrapped[key] = value
+		}
+	}
+	return unwrapped
+}
+
+// Resolve returns a copy of the schema with the definitions it refers to
+// inlined and swag's allOf wrappers removed. A definition that refers to
+// itself becomes a plain object. Vendor extensions are dropped.
+func (s *Spec) Resolve(schema map[string]any) map[string]any {
+	return s.resolve(schema, nil)
+}
+
+func (s *Spec) resolve(schema map[string]any, seen []string) map[string]any {
+	if name := RefName(schema); name != "" {
+		for _, other := range seen {
+			if other == name {
+				return map[string]any{"type": "object"}
+			}
+		}
+		definition, ok := s.Definitions[name]
+		if !ok {
+			return map[string]any{"type": "object"}
+		}
+		return s.resolve(definition, append(seen, name))
+	}
+
+	schema = Unwrap(schema)
+	if name := RefName(schema); name != "" {
+		// the allOf wrapped a reference; keep the wrapper's description
+		resolved := s.resolve(map[string]any{"$ref": schema["$ref"]}, seen)
+		for key, value := range schema {
+			if key != "$ref" && !strings.HasPrefix(key, "x-") {
+				resolved[key] = s.resolveValue(value, seen)
+			}
+		}
+		return resolved
+	}
+	resolved := make(map[string]any, len(schema))
+	for key, value := range schema {
+		if !strings.HasPrefix(key, "x-") {
+			resolved[key] = s.resolveValue(value, seen)
+		}
+	}
+	return resolved
+}
+
+func (s *Spec) resolveValue(value any, seen []string) any {
+	switch v := value.(type) {
+	case map[string]any:
+		return s.resolve(v, seen)
+	case []any:
+		values := make([]any, len(v))
+		for i, item := range v {
+			values[i] = s.resolveValue(item, seen)
+		}
+		return values
+	default:
+		return v
+	}
+}
+
+// Snake converts camelCase, kebab-case and spaced names to snake_case:
+// confirmationID is confirmation_id and review-queue is review_queue
+func Snake(name string) string {
+	var b strings.Builder
+	runes := []rune(strings.TrimSpace(name))
+	for i, r := range runes {
+		switch {
+		case r == '-' || r == ' ' || r == '.' || r == '_':
+			if b.Len() > 0 && !strings.HasSuffix(b.String(), "_") {
+				b.WriteByte('_')
+			}
+		case unicode.IsUpper(r):
+			lowerBefore := i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]))
+			lowerAfter := i > 0 && i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsLower(runes[i+1])
+			if (lowerBefore || lowerAfter) && !strings.HasSuffix(b.String(), "_") {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		}
+	}
+	return strings.Trim(b.String(), "_")
+}
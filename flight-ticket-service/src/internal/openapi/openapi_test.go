@@ -0,0 +1,77 @@
+package openapi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEndpoints(t *testing.T) {
+	spec, err := Parse([]byte(`{"swagger": "2.0", "paths": {
+	  "/health": {"get": {}},
+	  "/ticket/{confirmationID}": {"get": {}, "delete": {}},
+	  "/admin/upgrades/{flightNumber}/{date}": {"get": {}},
+	  "/admin/inventory/{flightNumber}/{date}": {"get": {"operationId": "getInventory"}},
+	  "/a/{x}/b": {"get": {}},
+	  "/a/{y}/b": {"get": {}},
+	  "/old": {"get": {"deprecated": true}}
+	}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoints, err := spec.Endpoints(DefaultExcludes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, endpoint := range endpoints {
+		names = append(names, endpoint.Method+" "+endpoint.Name)
+	}
+	want := []string{
+		"GET get_a_b",
+		"GET get_a_by_y_b",
+		"GET get_inventory",
+		"GET get_admin_upgrades_by_date",
+		"GET get_ticket_by_confirmation_id",
+		"DELETE delete_ticket_by_confirmation_id",
+	}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("Expected %v, got %v", want, names)
+	}
+
+	spec, _ = Parse([]byte(`{"swagger": "2.0", "paths": {"/a": {"get": {"operationId": "a"}}, "/b": {"get": {"operationId": "a"}}}}`))
+	if _, err := spec.Endpoints(nil); err == nil {
+		t.Error("Expected an error for a duplicate operationId")
+	}
+	if _, err := Parse([]byte(`{"openapi": "3.0.0"}`)); err == nil {
+		t.Error("Expected an error for OpenAPI 3")
+	}
+}
+
+func TestSnake(t *testing.T) {
+	for name, want := range map[string]string{
+		"confirmationID": "confirmation_id",
+		"review-queue":   "review_queue",
+		"getPNRExport":   "get_pnr_export",
+		"X-Lock-Token":   "x_lock_token",
+		"flight_number":  "flight_number",
+	} {
+		if got := Snake(name); got != want {
+			t.Errorf("%s: expected %s, got %s", name, want, got)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	spec, _ := Parse([]byte(`{"swagger": "2.0", "definitions": {
+	  "Node": {"type": "object", "properties": {"next": {"$ref": "#/definitions/Node"}, "seat": {"description": "Seat", "allOf": [{"$ref": "#/definitions/Seat"}]}}},
+	  "Seat": {"type": "string", "example": "12A", "x-order": 1}
+	}}`))
+	got := spec.Resolve(map[string]any{"$ref": "#/definitions/Node"})
+	want := map[string]any{"type": "object", "properties": map[string]any{
+		"next": map[string]any{"type": "object"},
+		"seat": map[string]any{"type": "string", "example": "12A", "description": "Seat"},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,111 @@
+// Package shardcounter keeps counts in sharded Firestore documents. A
+// Firestore document sustains about one write per second, so every increment
+// writes one random shard of a counter and reads add the shards up.
+package shardcounter
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+
+	"cloud.google.com/go/firestore"
+)
+
+// Counter picks the shards of counters with a fixed number of shards
+type Counter struct {
+	shards int
+}
+
+// New returns a counter with the given number of shards
+func New(shards int) (Counter, error) {
+	if shards < 1 {
+		return Counter{}, fmt.Errorf("counter shards must be positive, got %d", shards)
+	}
+	return Counter{shards: shards}, nil
+}
+
+// MustNew is like New but panics on a shard count below one, for counters
+// whose shard count is a constant
+func MustNew(shards int) Counter {
+	counter, err := New(shards)
+	if err != nil {
+		panic(err)
+	}
+	return counter
+}
+
+// Shards returns the number of shards of each counter
+func (c Counter) Shards() int {
+	return c.shards
+}
+
+// Shard returns the ID of a random shard, for the next increment
+func (c Counter) Shard() string {
+	return strconv.Itoa(rand.Intn(c.shards))
+}
+
+// ShardRef returns a random shard document in the shards subcollection of a
+// counter document
+func (c Counter) ShardRef(counter *firestore.DocumentRef) *firestore.DocumentRef {
+	return counter.Collection("shards").Doc(c.Shard())
+}
+
+// ShardRefs returns every shard document of a counter document, for reading
+// them with one GetAll
+func (c Counter) ShardRefs(counter *firestore.DocumentRef) []*firestore.DocumentRef {
+	refs := make([]*firestore.DocumentRef, c.shards)
+	for shard := range refs {
+		refs[shard] = counter.Collection("shards").Doc(strconv.Itoa(shard))
+	}
+	return refs
+}
+
+// Increments returns the fields that add counts to a shard, and maps of counts
+// key by key, when set with firestore.MergeAll
+func Increments(counts map[string]int64, maps map[string]map[string]int64) map[string]interface{} {
+	fields := make(map[string]interface{}, len(counts)+len(maps))
+	for field, count := range counts {
+		fields[field] = firestore.Increment(count)
+	}
+	for field, values := range maps {
+		increments := make(map[string]interface{}, len(values))
+		for key, count := range values {
+			increments[key] = firestore.Increment(count)
+		}
+		fields[field] = increments
+	}
+	return fields
+}
+
+// Totals are the sums of a counter's shards: Counts by field, and Maps by
+// field and key for the fields that hold maps of counts
+type Totals struct {
+	Counts map[string]int64
+	Maps   map[string]map[string]int64
+}
+
+// Sum adds up the fields of shard documents. Fields that are neither counts
+// nor maps of counts, such as expiry times, are left out.
+func Sum(shards []map[string]interface{}) Totals {
+	totals := Totals{Counts: make(map[string]int64), Maps: make(map[string]map[string]int64)}
+	for _, data := range shards {
+		for field, value := range data {
+			switch value := value.(type) {
+			case int64:
+				totals.Counts[field] += value
+			case map[string]interface{}:
+				for key, value := range value {
+					count, ok := value.(int64)
+					if !ok {
+						continue
+					}
+					if totals.Maps[field] == nil {
+						totals.Maps[field] = make(map[string]int64)
+					}
+					totals.Maps[field][key] += count
+				}
+			}
+		}
+	}
+	return totals
+}
@@ -0,0 +1,111 @@
+package shardcounter
+
+import (
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/firestore"
+)
+
+func TestNewRejectsNoShards(t *testing.T) {
+	if _, err := New(0); err == nil {
+		t.Error("Expected an error for zero shards")
+	}
+}
+
+func TestShardRefs(t *testing.T) {
+	counter, err := New(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &firestore.Client{}
+	refs := counter.ShardRefs(client.Collection("counters").Doc("total"))
+	if len(refs) != 3 || refs[2].Path != refs[2].Parent.Path+"/2" || refs[0].Parent.ID != "shards" {
+		t.Errorf("Unexpected shard refs %v", refs)
+	}
+	for i := 0; i < 20; i++ {
+		if shard := counter.Shard(); shard != "0" && shard != "1" && shard != "2" {
+			t.Fatalf("Shard %q out of range", shard)
+		}
+	}
+}
+
+func TestIncrements(t *testing.T) {
+	fields := Increments(map[string]int64{"count": 2}, map[string]map[string]int64{"routes": {"JFK-LAX": 1}})
+	routes, _ := fields["routes"].(map[string]interface{})
+	if !reflect.DeepEqual(fields["count"], firestore.Increment(2)) || !reflect.DeepEqual(routes["JFK-LAX"], firestore.Increment(1)) {
+		t.Errorf("Unexpected increments %v", fields)
+	}
+}
+
+func TestSum(t *testing.T) {
+	totals := Sum([]map[string]interface{}{
+		{"count": int64(2), "routes": map[string]interface{}{"JFK-LAX": int64(2)}, "expires_at": time.Now()},
+		{"count": int64(3), "routes": map[string]interface{}{"JFK-LAX": int64(1), "SFO-SEA": int64(2)}},
+	})
+	if totals.Counts["count"] != 5 || len(totals.Counts) != 1 {
+		t.Errorf("Unexpected counts %v", totals.Counts)
+	}
+	if totals.Maps["routes"]["JFK-LAX"] != 3 || totals.Maps["routes"]["SFO-SEA"] != 2 {
+		t.Errorf("Unexpected maps %v", totals.Maps)
+	}
+}
+
+func TestConcurrentIncrementsSum(t *testing.T) {
+	counter, err := New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each shard stands in for a shard document, written as the increment transforms do
+	var locks [4]sync.Mutex
+	shards := make([]map[string]interface{}, counter.Shards())
+	for i := range shards {
+		shards[i] = map[string]interface{}{"routes": map[string]interface{}{}}
+	}
+
+	const workers, increments = 8, 250
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(route string) {
+			defer wg.Done()
+			for i := 0; i < increments; i++ {
+				shard, err := strconv.Atoi(counter.Shard())
+				if err != nil {
+					t.Errorf("Shard ID is not a number: %v", err)
+					return
+				}
+				locks[shard].Lock()
+				count, _ := shards[shard]["count"].(int64)
+				shards[shard]["count"] = count + 1
+				routes := shards[shard]["routes"].(map[string]interface{})
+				routeCount, _ := routes[route].(int64)
+				routes[route] = routeCount + 1
+				locks[shard].Unlock()
+			}
+		}([]string{"JFK-LAX", "SFO-SEA"}[w%2])
+	}
+	wg.Wait()
+
+	written := 0
+	for _, data := range shards {
+		if _, ok := data["count"]; ok {
+			written++
+		}
+	}
+	if written < 2 {
+		t.Errorf("Expected the increments to spread over the shards, only %d written", written)
+	}
+
+	totals := Sum(shards)
+	if totals.Counts["count"] != workers*increments {
+		t.Errorf("Expected a total of %d, got %d", workers*increments, totals.Counts["count"])
+	}
+	if totals.Maps["routes"]["JFK-LAX"] != workers/2*increments || totals.Maps["routes"]["SFO-SEA"] != workers/2*increments {
+		t.Errorf("Unexpected route totals %v", totals.Maps["routes"])
+	}
+}
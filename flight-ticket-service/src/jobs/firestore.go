@@ -0,0 +1,107 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"flight-ticket-service/src/internal/docstore"
+	"flight-ticket-service/src/models"
+
+	"cloud.google.com/go/firestore"
+)
+
+// firestoreCollection holds one document per job, keyed by job ID
+const firestoreCollection = "jobs"
+
+// FirestoreIndexes are the composite indexes Claim queries need
+var FirestoreIndexes = []docstore.Index{
+	{Collection: firestoreCollection, Fields: []docstore.IndexField{{Path: "status", Order: docstore.Ascending}, {Path: "created_at", Order: docstore.Ascending}}},
+	{Collection: firestoreCollection, Fields: []docstore.IndexField{{Path: "status", Order: docstore.Ascending}, {Path: "lease_expires_at", Order: docstore.Ascending}}},
+}
+
+// FirestoreStore keeps jobs in Firestore, shared by every instance. Claims
+// and updates run in transactions so that only one worker holds a job.
+// Claiming needs the composite indexes in FirestoreIndexes.
+type FirestoreStore struct {
+	client *firestore.Client
+}
+
+// NewFirestoreStore creates a job store in the database of the given client
+func NewFirestoreStore(client *firestore.Client) *FirestoreStore {
+	return &FirestoreStore{client: client}
+}
+
+// Create stores a new job
+func (s *FirestoreStore) Create(ctx context.Context, job *models.Job) error {
+	if _, err := s.client.Collection(firestoreCollection).Doc(job.ID).Create(ctx, job); err != nil {
+		return fmt.Errorf("failed to create job: %v", err)
+	}
+	return nil
+}
+
+// Get returns a job
+func (s *FirestoreStore) Get(ctx context.Context, id string) (*models.Job, error) {
+	job, err := docstore.Get[models.Job](ctx, s.client.Collection(firestoreCollection).Doc(id), "job")
+	if errors.Is(err, docstore.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	return job, err
+}
+
+// Claim leases the oldest queued job, or else the running job whose lease expired first
+func (s *FirestoreStore) Claim(ctx context.Context, owner string, now time.Time, lease time.Duration) (*models.Job, error) {
+	jobs := s.client.Collection(firestoreCollection)
+	queries := []firestore.Query{
+		jobs.Where("status", "==", StatusQueued).OrderBy("created_at", firestore.Asc).Limit(1),
+		jobs.Where("status", "==", StatusRunning).Where("lease_expires_at", "<=", now).OrderBy("lease_expires_at", firestore.Asc).Limit(1),
+	}
+
+	var claimed *models.Job
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		claimed = nil
+		for _, query := range queries {
+			docs, err := tx.Documents(query).GetAll()
+			if err != nil {
+				return err
+			}
+			if len(docs) == 0 {
+				continue
+			}
+
+			job, err := docstore.Decode[models.Job](docs[0], "job")
+			if err != nil {
+				return err
+			}
+			grantLease(job, owner, now, lease)
+			claimed = job
+			return tx.Set(docs[0].Ref, job)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim job: %v", err)
+	}
+	return claimed, nil
+}
+
+// Update saves a job if its owner still holds it
+func (s *FirestoreStore) Update(ctx context.Context, job *models.Job) error {
+	_, err := docstore.Update(ctx, s.client, s.client.Collection(firestoreCollection).Doc(job.ID), "job", func(stored *models.Job) error {
+		if stored.Owner != job.Owner {
+			return ErrLeaseLost
+		}
+		*stored = *job
+		return nil
+	})
+	if errors.Is(err, docstore.ErrNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// Close leaves the client open: it belongs to the ticket repository
+func (s *FirestoreStore) Close() error {
+	return nil
+}
@@ -0,0 +1,412 @@
+// Package jobs runs long operations (exports, imports, bulk cancellations)
+// in the background. A submitted job is stored as a record and gets an ID
+// that clients poll; when the job finishes it can also be POSTed to a
+// callback URL.
+//
+// Workers on every instance claim queued jobs from the store under a lease
+// that they keep renewing while the job runs. When an instance stops or
+// crashes its lease runs out and another worker picks the job up again, so a
+// job's Run must be safe to repeat. A job that keeps getting interrupted
+// fails after MaxAttempts starts.
+//
+// JOB_WORKERS sets the workers per instance (default 2), JOB_POLL_INTERVAL
+// how often idle workers look for jobs (default 2s) and JOB_LEASE the lease
+// length (default 1m). With JOB_CALLBACK_SECRET set, callbacks carry an
+// X-Signature-256 header with the hex HMAC-SHA256 of the body.
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+// Job statuses
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// MaxAttempts is how many times a job is started before it is given up
+const MaxAttempts = 3
+
+// callbackAttempts is how many times a callback is tried before it is given up
+const callbackAttempts = 3
+
+var (
+	// ErrNotFound is returned for unknown job IDs
+	ErrNotFound = errors.New("job not found")
+	// ErrLeaseLost is returned when another worker has taken over a job
+	ErrLeaseLost = errors.New("job lease lost")
+	// ErrInvalid is wrapped by submission errors for unknown kinds and invalid parameters
+	ErrInvalid = errors.New("invalid job")
+)
+
+// Progress reports how many of the job's items have been processed
+type Progress func(done, total int)
+
+// Kind is a type of job that can be submitted
+type Kind struct {
+	// Prepare checks the parameters of a submission and fills in defaults (optional)
+	Prepare func(params map[string]interface{}) error
+	// Run does the work and returns the job's result
+	Run func(ctx context.Context, params map[string]interface{}, progress Progress) (map[string]interface{}, error)
+}
+
+// Store keeps job records
+type Store interface {
+	// Create stores a new job
+	Create(ctx context.Context, job *models.Job) error
+	// Get returns a job, or ErrNotFound
+	Get(ctx context.Context, id string) (*models.Job, error)
+	// Claim leases the oldest queued job, or a running job whose lease has
+	// expired, to owner until now+lease and counts the attempt. It returns
+	// nil when there is none.
+	Claim(ctx context.Context, owner string, now time.Time, lease time.Duration) (*models.Job, error)
+	// Update saves a job held by its owner, or returns ErrLeaseLost when another worker holds it
+	Update(ctx context.Context, job *models.Job) error
+}
+
+// Config holds the worker settings of a manager
+type Config struct {
+	Workers        int
+	PollInterval   time.Duration
+	Lease          time.Duration
+	CallbackSecret string
+}
+
+// ConfigFromEnv reads JOB_WORKERS, JOB_POLL_INTERVAL, JOB_LEASE and JOB_CALLBACK_SECRET
+func ConfigFromEnv() (Config, error) {
+	config := Config{Workers: 2, PollInterval: 2 * time.Second, Lease: time.Minute, CallbackSecret: os.Getenv("JOB_CALLBACK_SECRET")}
+	if value := strings.TrimSpace(os.Getenv("JOB_WORKERS")); value != "" {
+		workers, err := strconv.Atoi(value)
+		if err != nil || workers < 0 {
+			return Config{}, fmt.Errorf("invalid JOB_WORKERS %q: must be a non-negative number", value)
+		}
+		config.Workers = workers
+	}
+	for name, target := range map[string]*time.Duration{"JOB_POLL_INTERVAL": &config.PollInterval, "JOB_LEASE": &config.Lease} {
+		if value := strings.TrimSpace(os.Getenv(name)); value != "" {
+			duration, err := time.ParseDuration(value)
+			if err != nil || duration <= 0 {
+				return Config{}, fmt.Errorf("invalid %s %q: must be a positive duration", name, value)
+			}
+			*target = duration
+		}
+	}
+	return config, nil
+}
+
+// Manager submits jobs and runs them on its workers
+type Manager struct {
+	store  Store
+	config Config
+	kinds  map[string]Kind
+	owner  string
+	client *http.Client
+	now    func() time.Time
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewManager creates a manager on the given store
+func NewManager(store Store, config Config) *Manager {
+	if config.PollInterval <= 0 {
+		config.PollInterval = 2 * time.Second
+	}
+	if config.Lease <= 0 {
+		config.Lease = time.Minute
+	}
+
+	host, _ := os.Hostname()
+	return &Manager{
+		store:  store,
+		config: config,
+		kinds:  make(map[string]Kind),
+		owner:  fmt.Sprintf("%s-%s", host, newID()[:8]),
+		client: &http.Client{Timeout: 10 * time.Second},
+		now:    time.Now,
+	}
+}
+
+// Register adds a job kind; call it before Start
+func (m *Manager) Register(name string, kind Kind) {
+	m.kinds[name] = kind
+}
+
+// Kinds lists the registered job kinds
+func (m *Manager) Kinds() []string {
+	names := make([]string, 0, len(m.kinds))
+	for name := range m.kinds {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Submit validates and queues a job
+func (m *Manager) Submit(ctx context.Context, kind string, params map[string]interface{}, callbackURL string) (*models.Job, error) {
+	definition, ok := m.kinds[kind]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown kind %q", ErrInvalid, kind)
+	}
+	if params == nil {
+		params = make(map[string]interface{})
+	}
+	if definition.Prepare != nil {
+		if err := definition.Prepare(params); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+	}
+
+	now := m.now().UTC()
+	job := &models.Job{
+		ID:          newID(),
+		Kind:        kind,
+		Status:      StatusQueued,
+		Params:      params,
+		CallbackURL: callbackURL,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := m.store.Create(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Get returns a job
+func (m *Manager) Get(ctx context.Context, id string) (*models.Job, error) {
+	return m.store.Get(ctx, id)
+}
+
+// Start runs the workers until Stop
+func (m *Manager) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	for i := 0; i < m.config.Workers; i++ {
+		m.wg.Add(1)
+		go m.work(ctx)
+	}
+}
+
+// Stop stops the workers. Running jobs are interrupted and picked up again
+// by a worker once their lease runs out.
+func (m *Manager) Stop() {
+	if m.cancel != nil {
+		m.cancel()
+	}
+	m.wg.Wait()
+}
+
+func (m *Manager) work(ctx context.Context) {
+	defer m.wg.Done()
+	for {
+		ran, err := m.RunNext(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Job worker: %v", err)
+		}
+		if ran {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(m.config.PollInterval):
+		}
+	}
+}
+
+// RunNext claims and runs one job. It reports whether there was a job to run.
+func (m *Manager) RunNext(ctx context.Context) (bool, error) {
+	job, err := m.store.Claim(ctx, m.owner, m.now().UTC(), m.config.Lease)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim job: %v", err)
+	}
+	if job == nil {
+		return false, nil
+	}
+
+	if job.Attempts > MaxAttempts {
+		m.finish(ctx, job, nil, fmt.Errorf("job was interrupted %d times", MaxAttempts))
+		return true, nil
+	}
+	kind, ok := m.kinds[job.Kind]
+	if !ok {
+		m.finish(ctx, job, nil, fmt.Errorf("unknown job kind %q", job.Kind))
+		return true, nil
+	}
+
+	result, err := m.run(ctx, job, kind)
+	if ctx.Err() != nil {
+		// Shutting down: end the lease so that another worker resumes the job right away
+		job.LeaseExpiresAt = m.now().UTC()
+		if err := m.store.Update(context.Background(), job); err != nil {
+			log.Printf("Failed to release job %s: %v", job.ID, err)
+		}
+		return true, nil
+	}
+	if errors.Is(err, ErrLeaseLost) {
+		log.Printf("Job %s was taken over by another worker", job.ID)
+		return true, nil
+	}
+	m.finish(ctx, job, result, err)
+	return true, nil
+}
+
+// run executes a claimed job, renewing its lease and saving progress while it runs
+func (m *Manager) run(ctx context.Context, job *models.Job, kind Kind) (map[string]interface{}, error) {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	var saveErr error
+	save := func(change func()) {
+		mu.Lock()
+		defer mu.Unlock()
+		if saveErr != nil {
+			return
+		}
+		change()
+		now := m.now().UTC()
+		job.UpdatedAt = now
+		job.LeaseExpiresAt = now.Add(m.config.Lease)
+		if err := m.store.Update(ctx, job); err != nil {
+			saveErr = err
+			if errors.Is(err, ErrLeaseLost) {
+				cancel()
+			}
+		}
+	}
+
+	// Renew the lease well before it runs out
+	renewed := make(chan struct{})
+	go func() {
+		defer close(renewed)
+		ticker := time.NewTicker(m.config.Lease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-runCtx.Done():
+				return
+			case <-ticker.C:
+				save(func() {})
+			}
+		}
+	}()
+
+	log.Printf("Running job %s (%s), attempt %d", job.ID, job.Kind, job.Attempts)
+	result, err := kind.Run(runCtx, job.Params, func(done, total int) {
+		save(func() { job.Progress = &models.JobProgress{Done: done, Total: total} })
+	})
+	cancel()
+	<-renewed
+
+	mu.Lock()
+	defer mu.Unlock()
+	if errors.Is(saveErr, ErrLeaseLost) {
+		return nil, ErrLeaseLost
+	}
+	return result, err
+}
+
+// finish records the outcome of a job and delivers its callback
+func (m *Manager) finish(ctx context.Context, job *models.Job, result map[string]interface{}, err error) {
+	now := m.now().UTC()
+	job.FinishedAt = &now
+	job.UpdatedAt = now
+	job.LeaseExpiresAt = time.Time{}
+	if err != nil {
+		log.Printf("Job %s (%s) failed: %v", job.ID, job.Kind, err)
+		job.Status = StatusFailed
+		job.Error = err.Error()
+	} else {
+		job.Status = StatusSucceeded
+		job.Result = result
+	}
+	if err := m.store.Update(ctx, job); err != nil {
+		log.Printf("Failed to save job %s: %v", job.ID, err)
+		return
+	}
+
+	if job.CallbackURL == "" {
+		return
+	}
+	if err := m.notify(ctx, job); err != nil {
+		log.Printf("Failed to deliver callback of job %s: %v", job.ID, err)
+		job.CallbackError = err.Error()
+		if err := m.store.Update(ctx, job); err != nil {
+			log.Printf("Failed to save job %s: %v", job.ID, err)
+		}
+	}
+}
+
+// notify POSTs the finished job to its callback URL, retrying failures with backoff
+func (m *Manager) notify(ctx context.Context, job *models.Job) error {
+	body, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	backoff := time.Second
+	for attempt := 1; ; attempt++ {
+		err = m.post(ctx, job, body)
+		if err == nil || attempt == callbackAttempts {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (m *Manager) post(ctx context.Context, job *models.Job, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.CallbackURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create callback request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Job-ID", job.ID)
+	if m.config.CallbackSecret != "" {
+		mac := hmac.New(sha256.New, []byte(m.config.CallbackSecret))
+		mac.Write(body)
+		req.Header.Set("X-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call callback URL: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("callback URL returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func newID() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,170 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+func newTestManager(store Store) *Manager {
+	manager := NewManager(store, Config{Workers: 1, PollInterval: time.Millisecond, Lease: time.Minute, CallbackSecret: "secret"})
+	manager.Register("count", Kind{
+		Prepare: func(params map[string]interface{}) error {
+			if _, ok := params["items"].(float64); !ok {
+				return errors.New("items must be a number")
+			}
+			return nil
+		},
+		Run: func(ctx context.Context, params map[string]interface{}, progress Progress) (map[string]interface{}, error) {
+			items := int(params["items"].(float64))
+			for i := 1; i <= items; i++ {
+				progress(i, items)
+			}
+			return map[string]interface{}{"counted": items}, nil
+		},
+	})
+	manager.Register("fail", Kind{
+		Run: func(ctx context.Context, params map[string]interface{}, progress Progress) (map[string]interface{}, error) {
+			return nil, errors.New("export failed")
+		},
+	})
+	return manager
+}
+
+func TestSubmitValidatesJobs(t *testing.T) {
+	manager := newTestManager(NewMemoryStore())
+	ctx := context.Background()
+
+	if _, err := manager.Submit(ctx, "unknown", nil, ""); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Expected ErrInvalid for an unknown kind, got %v", err)
+	}
+	if _, err := manager.Submit(ctx, "count", map[string]interface{}{"items": "three"}, ""); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Expected ErrInvalid for invalid parameters, got %v", err)
+	}
+
+	job, err := manager.Submit(ctx, "count", map[string]interface{}{"items": 3.0}, "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if job.Status != StatusQueued || len(job.ID) != 16 {
+		t.Errorf("Unexpected job %+v", job)
+	}
+}
+
+func TestRunNextRecordsOutcome(t *testing.T) {
+	store := NewMemoryStore()
+	manager := newTestManager(store)
+	ctx := context.Background()
+
+	counted, _ := manager.Submit(ctx, "count", map[string]interface{}{"items": 3.0}, "")
+	failed, _ := manager.Submit(ctx, "fail", nil, "")
+
+	for i := 0; i < 2; i++ {
+		if ran, err := manager.RunNext(ctx); !ran || err != nil {
+			t.Fatalf("Expected a job to run, got %v %v", ran, err)
+		}
+	}
+	if ran, _ := manager.RunNext(ctx); ran {
+		t.Error("Expected no job left to run")
+	}
+
+	job, _ := manager.Get(ctx, counted.ID)
+	if job.Status != StatusSucceeded || job.Result["counted"] != 3 || job.Progress == nil || job.Progress.Done != 3 || job.Attempts != 1 || job.FinishedAt == nil {
+		t.Errorf("Unexpected succeeded job %+v", job)
+	}
+	job, _ = manager.Get(ctx, failed.ID)
+	if job.Status != StatusFailed || job.Error != "export failed" {
+		t.Errorf("Unexpected failed job %+v", job)
+	}
+}
+
+func TestExpiredLeasesAreReclaimed(t *testing.T) {
+	store := NewMemoryStore()
+	manager := newTestManager(store)
+	ctx := context.Background()
+	now := time.Now()
+
+	job, _ := manager.Submit(ctx, "count", map[string]interface{}{"items": 1.0}, "")
+
+	// An instance claims the job and dies
+	claimed, err := store.Claim(ctx, "crashed", now, time.Minute)
+	if err != nil || claimed == nil || claimed.ID != job.ID {
+		t.Fatalf("Expected to claim the job, got %v %v", claimed, err)
+	}
+	if again, _ := store.Claim(ctx, "other", now.Add(30*time.Second), time.Minute); again != nil {
+		t.Error("Expected a leased job not to be claimed")
+	}
+
+	manager.now = func() time.Time { return now.Add(2 * time.Minute) }
+	if ran, err := manager.RunNext(ctx); !ran || err != nil {
+		t.Fatalf("Expected the job to be resumed, got %v %v", ran, err)
+	}
+	resumed, _ := manager.Get(ctx, job.ID)
+	if resumed.Status != StatusSucceeded || resumed.Attempts != 2 {
+		t.Errorf("Unexpected resumed job %+v", resumed)
+	}
+
+	// The crashed owner can no longer save the job
+	claimed.Status = StatusFailed
+	if err := store.Update(ctx, claimed); !errors.Is(err, ErrLeaseLost) {
+		t.Errorf("Expected ErrLeaseLost, got %v", err)
+	}
+}
+
+func TestJobsFailAfterMaxAttempts(t *testing.T) {
+	store := NewMemoryStore()
+	manager := newTestManager(store)
+	ctx := context.Background()
+	now := time.Now()
+
+	job, _ := manager.Submit(ctx, "count", map[string]interface{}{"items": 1.0}, "")
+	for i := 0; i < MaxAttempts; i++ {
+		store.Claim(ctx, "crashed", now.Add(time.Duration(i)*2*time.Minute), time.Minute)
+	}
+
+	manager.now = func() time.Time { return now.Add(time.Hour) }
+	manager.RunNext(ctx)
+	failed, _ := manager.Get(ctx, job.ID)
+	if failed.Status != StatusFailed || failed.Error == "" {
+		t.Errorf("Expected the job to fail after %d attempts, got %+v", MaxAttempts, failed)
+	}
+}
+
+func TestCallbacks(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		received <- r
+	}))
+	defer server.Close()
+
+	manager := newTestManager(NewMemoryStore())
+	ctx := context.Background()
+	job, _ := manager.Submit(ctx, "count", map[string]interface{}{"items": 2.0}, server.URL)
+	manager.RunNext(ctx)
+
+	select {
+	case r := <-received:
+		if r.Header.Get("X-Job-ID") != job.ID || r.Header.Get("X-Signature-256") == "" {
+			t.Errorf("Unexpected callback headers %v", r.Header)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a callback")
+	}
+
+	var notified models.Job
+	if err := json.Unmarshal(body, &notified); err != nil {
+		t.Fatalf("Failed to decode callback: %v", err)
+	}
+	if notified.ID != job.ID || notified.Status != StatusSucceeded {
+		t.Errorf("Unexpected callback body %s", body)
+	}
+}
@@ -0,0 +1,102 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+// MemoryStore keeps jobs in memory, for single-instance deployments and tests.
+// Jobs are lost when the instance stops.
+type MemoryStore struct {
+	mu   sync.Mutex
+	jobs map[string]*models.Job
+}
+
+// NewMemoryStore creates an empty job store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{jobs: make(map[string]*models.Job)}
+}
+
+// Create stores a new job
+func (s *MemoryStore) Create(ctx context.Context, job *models.Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = copyJob(job)
+	return nil
+}
+
+// Get returns a copy of a job
+func (s *MemoryStore) Get(ctx context.Context, id string) (*models.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyJob(job), nil
+}
+
+// Claim leases the oldest claimable job
+func (s *MemoryStore) Claim(ctx context.Context, owner string, now time.Time, lease time.Duration) (*models.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var next *models.Job
+	for _, job := range s.jobs {
+		if !claimable(job, now) {
+			continue
+		}
+		if next == nil || job.CreatedAt.Before(next.CreatedAt) {
+			next = job
+		}
+	}
+	if next == nil {
+		return nil, nil
+	}
+
+	grantLease(next, owner, now, lease)
+	return copyJob(next), nil
+}
+
+// Update saves a job if its owner still holds it
+func (s *MemoryStore) Update(ctx context.Context, job *models.Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.jobs[job.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if stored.Owner != job.Owner {
+		return ErrLeaseLost
+	}
+	s.jobs[job.ID] = copyJob(job)
+	return nil
+}
+
+// claimable reports whether a job is waiting for a worker
+func claimable(job *models.Job, now time.Time) bool {
+	return job.Status == StatusQueued || (job.Status == StatusRunning && !job.LeaseExpiresAt.After(now))
+}
+
+// grantLease hands a claimed job to its new owner
+func grantLease(job *models.Job, owner string, now time.Time, duration time.Duration) {
+	job.Status = StatusRunning
+	job.Owner = owner
+	job.LeaseExpiresAt = now.Add(duration)
+	job.Attempts++
+	job.StartedAt = &now
+	job.UpdatedAt = now
+}
+
+// copyJob copies a job so that callers cannot change the stored one
+func copyJob(job *models.Job) *models.Job {
+	copied := *job
+	if job.Progress != nil {
+		progress := *job.Progress
+		copied.Progress = &progress
+	}
+	return &copied
+}
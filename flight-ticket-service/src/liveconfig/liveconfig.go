@@ -0,0 +1,257 @@
+// Package liveconfig applies configuration changes at runtime, without
+// restarting the instance.
+//
+// The config source is a JSON file (CONFIG_FILE, re-read every
+// CONFIG_POLL_INTERVAL, e.g. a Secret Manager volume) or a Firestore document
+// (CONFIG_DOCUMENT, applied as soon as it is written). It holds environment
+// variable overrides, such as {"LOG_LEVEL": "debug"}, in the format of the
+// variable. Only settings that are safe to change while serving can be
+// reloaded; the others are ignored until the next deploy. A setting missing
+// from the source returns to its value from the environment.
+//
+// Every change is logged and kept in an audit log of recent changes, applied
+// or rejected.
+package liveconfig
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults
+const (
+	DefaultPollInterval = 10 * time.Second
+	DefaultAuditSize    = 100
+)
+
+// Sources
+const (
+	SourceEnv       = "env"
+	SourceFile      = "file"
+	SourceFirestore = "firestore"
+)
+
+// Change outcomes
+const (
+	OutcomeApplied  = "applied"
+	OutcomeRejected = "rejected" // the value is invalid; the previous value stays
+	OutcomeIgnored  = "ignored"  // the setting cannot be reloaded
+)
+
+// Config selects the config source
+type Config struct {
+	File         string
+	Document     string
+	PollInterval time.Duration
+}
+
+// Enabled reports whether a config source is set
+func (c Config) Enabled() bool {
+	return c.File != "" || c.Document != ""
+}
+
+// ConfigFromEnv reads CONFIG_FILE, CONFIG_DOCUMENT and CONFIG_POLL_INTERVAL
+func ConfigFromEnv() (Config, error) {
+	config := Config{
+		File:         strings.TrimSpace(os.Getenv("CONFIG_FILE")),
+		Document:     strings.TrimSpace(os.Getenv("CONFIG_DOCUMENT")),
+		PollInterval: DefaultPollInterval,
+	}
+	if config.File != "" && config.Document != "" {
+		return Config{}, fmt.Errorf("CONFIG_FILE and CONFIG_DOCUMENT are exclusive")
+	}
+	if value := strings.TrimSpace(os.Getenv("CONFIG_POLL_INTERVAL")); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil || interval < time.Second {
+			return Config{}, fmt.Errorf("invalid CONFIG_POLL_INTERVAL %q: must be a duration of at least 1s", value)
+		}
+		config.PollInterval = interval
+	}
+	return config, nil
+}
+
+// Setting is a setting that can be reloaded
+type Setting struct {
+	Name    string // environment variable
+	Default string // value from the environment, restored when the source drops the setting
+	// Apply validates and applies a value; on error nothing may change
+	Apply func(value string) error
+}
+
+// Change is an entry of the audit log
+// @Description A configuration change and its outcome
+type Change struct {
+	Time    time.Time `json:"time" example:"2024-07-12T19:00:00Z" description:"When the change was seen"`
+	Setting string    `json:"setting" example:"LOG_LEVEL" description:"Environment variable"`
+	From    string    `json:"from" example:"info" description:"Previous value"`
+	To      string    `json:"to" example:"debug" description:"New value"`
+	Source  string    `json:"source" example:"firestore config/service" description:"Where the change came from"`
+	Outcome string    `json:"outcome" example:"applied" description:"applied, rejected (invalid value) or ignored (not reloadable)"`
+	Error   string    `json:"error,omitempty" description:"Why the change was rejected or ignored"`
+}
+
+// State is the current value of every reloadable setting and the audit log
+// @Description Reloadable settings and recent changes
+type State struct {
+	Source   string            `json:"source" example:"firestore" description:"Config source (env, file or firestore)"`
+	Location string            `json:"location,omitempty" example:"config/service" description:"Config file or Firestore document"`
+	LoadedAt *time.Time        `json:"loaded_at,omitempty" example:"2024-07-12T19:00:00Z" description:"When the source was last read"`
+	Settings map[string]string `json:"settings" description:"Current value of each reloadable setting"`
+	Changes  []Change          `json:"changes" description:"Recent changes, newest first"`
+}
+
+// Watcher applies the settings of a config source as it changes
+type Watcher struct {
+	settings []Setting
+	now      func() time.Time
+
+	mu       sync.Mutex
+	current  map[string]string
+	source   string
+	location string
+	loadedAt time.Time
+	changes  []Change // oldest first, at most DefaultAuditSize
+}
+
+// New creates a watcher of the given settings; their defaults are assumed applied
+func New(settings ...Setting) *Watcher {
+	current := make(map[string]string, len(settings))
+	for _, setting := range settings {
+		current[setting.Name] = setting.Default
+	}
+	return &Watcher{settings: settings, now: time.Now, current: current, source: SourceEnv}
+}
+
+// Load applies the values of a config source. Settings whose value changed are
+// applied in order; invalid values are rejected, keeping the previous value,
+// and unknown settings are ignored. Each of these is logged and audited.
+func (w *Watcher) Load(data map[string]interface{}, source string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	now := w.now().UTC()
+	w.loadedAt = now
+
+	known := make(map[string]bool, len(w.settings))
+	for _, setting := range w.settings {
+		known[setting.Name] = true
+		value := setting.Default
+		if raw, ok := data[setting.Name]; ok {
+			formatted, err := formatValue(raw)
+			if err != nil {
+				w.audit(Change{Time: now, Setting: setting.Name, From: w.current[setting.Name], To: fmt.Sprint(raw), Source: source, Outcome: OutcomeRejected, Error: err.Error()})
+				continue
+			}
+			value = formatted
+		}
+		if value == w.current[setting.Name] {
+			continue
+		}
+
+		change := Change{Time: now, Setting: setting.Name, From: w.current[setting.Name], To: value, Source: source, Outcome: OutcomeApplied}
+		if err := setting.Apply(value); err != nil {
+			change.Outcome, change.Error = OutcomeRejected, err.Error()
+		} else {
+			w.current[setting.Name] = value
+		}
+		w.audit(change)
+	}
+
+	var unknown []string
+	for name := range data {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		w.audit(Change{Time: now, Setting: name, To: fmt.Sprint(data[name]), Source: source, Outcome: OutcomeIgnored, Error: "not reloadable; takes effect on the next deploy"})
+	}
+}
+
+// audit logs a change and adds it to the audit log; w.mu must be held
+func (w *Watcher) audit(change Change) {
+	// Repeated loads of the same source report an ignored or rejected value once
+	for i := len(w.changes) - 1; i >= 0; i-- {
+		if previous := w.changes[i]; previous.Setting == change.Setting {
+			if previous.Outcome == change.Outcome && previous.To == change.To && change.Outcome != OutcomeApplied {
+				return
+			}
+			break
+		}
+	}
+
+	switch change.Outcome {
+	case OutcomeApplied:
+		log.Printf("Config change applied from %s: %s %q -> %q", change.Source, change.Setting, change.From, change.To)
+	default:
+		log.Printf("Config change %s from %s: %s %q -> %q: %s", change.Outcome, change.Source, change.Setting, change.From, change.To, change.Error)
+	}
+	w.changes = append(w.changes, change)
+	if len(w.changes) > DefaultAuditSize {
+		w.changes = w.changes[len(w.changes)-DefaultAuditSize:]
+	}
+}
+
+// State returns the current settings and recent changes
+func (w *Watcher) State() State {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	state := State{
+		Source:   w.source,
+		Location: w.location,
+		Settings: make(map[string]string, len(w.current)),
+		Changes:  make([]Change, 0, len(w.changes)),
+	}
+	if !w.loadedAt.IsZero() {
+		loadedAt := w.loadedAt
+		state.LoadedAt = &loadedAt
+	}
+	for name, value := range w.current {
+		state.Settings[name] = value
+	}
+	for i := len(w.changes) - 1; i >= 0; i-- {
+		state.Changes = append(state.Changes, w.changes[i])
+	}
+	return state
+}
+
+// setSource records where settings come from
+func (w *Watcher) setSource(source, location string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.source, w.location = source, location
+}
+
+// formatValue converts a JSON or Firestore value to the format of an
+// environment variable: lists become comma-separated
+func formatValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			formatted, err := formatValue(item)
+			if err != nil {
+				return "", err
+			}
+			items[i] = formatted
+		}
+		return strings.Join(items, ","), nil
+	default:
+		return "", fmt.Errorf("unsupported value type %T", value)
+	}
+}
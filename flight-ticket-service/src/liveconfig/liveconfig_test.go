@@ -0,0 +1,139 @@
+package liveconfig
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestLoadAppliesChanges(t *testing.T) {
+	var level string
+	quota := 100
+	w := New(
+		Setting{Name: "LOG_LEVEL", Default: "info", Apply: func(value string) error { level = value; return nil }},
+		Setting{Name: "BOOKING_QUOTA", Default: "100", Apply: func(value string) error {
+			limit, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("%q is not a number", value)
+			}
+			quota = limit
+			return nil
+		}},
+	)
+
+	w.Load(map[string]interface{}{"LOG_LEVEL": "debug", "BOOKING_QUOTA": int64(20), "PORT": "9090"}, "test")
+	if level != "debug" || quota != 20 {
+		t.Fatalf("Expected the changes applied, got level %q and quota %d", level, quota)
+	}
+	state := w.State()
+	if state.Settings["LOG_LEVEL"] != "debug" || state.Settings["BOOKING_QUOTA"] != "20" {
+		t.Errorf("Unexpected settings %v", state.Settings)
+	}
+	if len(state.Changes) != 3 || state.Changes[0].Setting != "PORT" || state.Changes[0].Outcome != OutcomeIgnored {
+		t.Fatalf("Expected two applied changes and the ignored PORT, got %+v", state.Changes)
+	}
+	if change := state.Changes[1]; change.Setting != "BOOKING_QUOTA" || change.From != "100" || change.To != "20" || change.Outcome != OutcomeApplied {
+		t.Errorf("Unexpected change %+v", change)
+	}
+
+	// An invalid value keeps the previous one and is audited once
+	for i := 0; i < 3; i++ {
+		w.Load(map[string]interface{}{"LOG_LEVEL": "debug", "BOOKING_QUOTA": "lots"}, "test")
+	}
+	state = w.State()
+	if quota != 20 || state.Settings["BOOKING_QUOTA"] != "20" {
+		t.Errorf("Expected the quota to stay 20, got %d", quota)
+	}
+	if len(state.Changes) != 4 || state.Changes[0].Outcome != OutcomeRejected || state.Changes[0].Error == "" {
+		t.Errorf("Expected one rejected change, got %+v", state.Changes)
+	}
+
+	// Removed settings return to their defaults
+	w.Load(nil, "test")
+	if level != "info" || quota != 100 {
+		t.Errorf("Expected the defaults restored, got level %q and quota %d", level, quota)
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{" debug ", "debug"},
+		{true, "true"},
+		{float64(25), "25"},
+		{int64(7), "7"},
+		{[]interface{}{"https://a.example.com", "https://b.example.com"}, "https://a.example.com,https://b.example.com"},
+	}
+	for _, test := range tests {
+		if got, err := formatValue(test.value); err != nil || got != test.want {
+			t.Errorf("formatValue(%v) = %q, %v; want %q", test.value, got, err, test.want)
+		}
+	}
+	if _, err := formatValue(map[string]interface{}{"search": true}); err == nil {
+		t.Error("Expected an error for a map")
+	}
+}
+
+func TestWatchFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"LOG_LEVEL": "warn"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	applied := make(chan string, 4)
+	w := New(Setting{Name: "LOG_LEVEL", Default: "info", Apply: func(value string) error { applied <- value; return nil }})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := w.WatchFile(ctx, path, 10*time.Millisecond); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if value := <-applied; value != "warn" {
+		t.Fatalf("Expected warn applied on load, got %q", value)
+	}
+	if state := w.State(); state.Source != SourceFile || state.Location != path {
+		t.Errorf("Unexpected source %s %s", state.Source, state.Location)
+	}
+
+	// A broken file keeps the settings; the next valid content applies
+	os.WriteFile(path, []byte(`{"LOG_LEVEL": `), 0o600)
+	time.Sleep(50 * time.Millisecond)
+	os.WriteFile(path, []byte(`{"LOG_LEVEL": "debug"}`), 0o600)
+	select {
+	case value := <-applied:
+		if value != "debug" {
+			t.Errorf("Expected debug applied, got %q", value)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for the file change")
+	}
+
+	if err := New().WatchFile(ctx, filepath.Join(t.TempDir(), "missing.json"), time.Second); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "/etc/flight-tickets/config.json")
+	t.Setenv("CONFIG_DOCUMENT", "")
+	t.Setenv("CONFIG_POLL_INTERVAL", "30s")
+	config, err := ConfigFromEnv()
+	if err != nil || !config.Enabled() || config.PollInterval != 30*time.Second {
+		t.Fatalf("Unexpected config %+v, %v", config, err)
+	}
+
+	t.Setenv("CONFIG_DOCUMENT", "config/service")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Error("Expected an error with both sources")
+	}
+	t.Setenv("CONFIG_DOCUMENT", "")
+	t.Setenv("CONFIG_POLL_INTERVAL", "10ms")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Error("Expected an error for a poll interval below 1s")
+	}
+}
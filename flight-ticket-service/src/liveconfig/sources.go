@@ -0,0 +1,133 @@
+package liveconfig
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// retryInterval is the delay before re-opening a failed snapshot listener
+const retryInterval = 30 * time.Second
+
+// Watch loads the configured source and keeps applying its changes until ctx
+// is cancelled. It blocks until the source has been read once. A Firestore
+// document is read through client.
+func (w *Watcher) Watch(ctx context.Context, config Config, client *firestore.Client) error {
+	switch {
+	case config.File != "":
+		return w.WatchFile(ctx, config.File, config.PollInterval)
+	case config.Document != "":
+		return w.WatchFirestore(ctx, client, config.Document)
+	}
+	return nil
+}
+
+// WatchFile applies a JSON file and re-reads it every interval, applying it
+// again when its content changed. A file that cannot be read or parsed later
+// on is logged and the current settings stay.
+func (w *Watcher) WatchFile(ctx context.Context, path string, interval time.Duration) error {
+	content, data, err := readFile(path)
+	if err != nil {
+		return err
+	}
+	w.setSource(SourceFile, path)
+	w.Load(data, "file "+path)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		failing := false
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			latest, data, err := readFile(path)
+			if err != nil {
+				if !failing {
+					log.Printf("Failed to reload config, keeping the current settings: %v", err)
+				}
+				failing = true
+				continue
+			}
+			failing = false
+			if bytes.Equal(latest, content) {
+				continue
+			}
+			content = latest
+			w.Load(data, "file "+path)
+		}
+	}()
+	return nil
+}
+
+// readFile reads a JSON object
+func readFile(path string) ([]byte, map[string]interface{}, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal(content, &data); err != nil {
+		return nil, nil, fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+	return content, data, nil
+}
+
+// WatchFirestore applies a Firestore document and keeps applying it with a
+// snapshot listener. A missing document restores every default.
+func (w *Watcher) WatchFirestore(ctx context.Context, client *firestore.Client, document string) error {
+	doc := client.Doc(document)
+	if doc == nil {
+		return fmt.Errorf("invalid config document path: %s", document)
+	}
+
+	snap, err := doc.Get(ctx)
+	if err != nil && status.Code(err) != codes.NotFound {
+		return fmt.Errorf("failed to read config document: %v", err)
+	}
+	source := "firestore " + document
+	w.setSource(SourceFirestore, document)
+	w.Load(snap.Data(), source)
+
+	go func() {
+		for {
+			err := w.listen(ctx, doc, source)
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Config listener stopped, retrying in %s: %v", retryInterval, err)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(retryInterval):
+			}
+		}
+	}()
+	return nil
+}
+
+// listen applies document snapshots until the listener fails
+func (w *Watcher) listen(ctx context.Context, doc *firestore.DocumentRef, source string) error {
+	it := doc.Snapshots(ctx)
+	defer it.Stop()
+
+	for {
+		snap, err := it.Next()
+		if err != nil {
+			return err
+		}
+		w.Load(snap.Data(), source)
+	}
+}
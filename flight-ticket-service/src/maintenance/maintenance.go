@@ -0,0 +1,158 @@
+// Package maintenance switches the API into read-only or full maintenance mode,
+// e.g. while migrating between storage backends.
+package maintenance
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mode is the maintenance level
+type Mode string
+
+const (
+	// ModeOff serves all requests
+	ModeOff Mode = "off"
+	// ModeReadOnly rejects requests that modify data
+	ModeReadOnly Mode = "read-only"
+	// ModeFull rejects all API requests
+	ModeFull Mode = "full"
+)
+
+// DefaultMessage is shown when no message has been set
+const DefaultMessage = "The service is undergoing scheduled maintenance. Please try again later."
+
+// retryAfter is the Retry-After hint sent with 503 responses
+const retryAfter = 5 * time.Minute
+
+// exemptPrefixes stay available in every mode so health checks stay green
+// and operators can still turn maintenance off
+var exemptPrefixes = []string{"/health", "/version", "/metrics", "/admin/"}
+
+// Status describes the current maintenance state
+// @Description Maintenance mode state
+type Status struct {
+	Mode    Mode       `json:"mode" example:"read-only" description:"Maintenance mode (off, read-only or full)"`
+	Message string     `json:"message,omitempty" example:"Migrating to Spanner, writes are paused" description:"Message returned to clients"`
+	Since   *time.Time `json:"since,omitempty" example:"2024-07-12T19:00:00Z" description:"When maintenance started"`
+}
+
+// Request is the body of PUT /admin/maintenance
+// @Description Maintenance mode change
+type Request struct {
+	Mode    Mode   `json:"mode" example:"read-only" description:"Maintenance mode (off, read-only or full)"`
+	Message string `json:"message,omitempty" example:"Migrating to Spanner, writes are paused" description:"Message returned to clients"`
+}
+
+// Response is returned with 503 while a request is blocked by maintenance
+// @Description Maintenance error response
+type Response struct {
+	Error   string     `json:"error" example:"Service under maintenance" description:"Error message"`
+	Message string     `json:"message" example:"Migrating to Spanner, writes are paused" description:"Maintenance message"`
+	Mode    Mode       `json:"mode" example:"read-only" description:"Maintenance mode"`
+	Since   *time.Time `json:"since,omitempty" example:"2024-07-12T19:00:00Z" description:"When maintenance started"`
+}
+
+// ParseMode parses a mode name; an empty string is ModeOff
+func ParseMode(value string) (Mode, error) {
+	switch mode := Mode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case "", ModeOff:
+		return ModeOff, nil
+	case ModeReadOnly, "readonly":
+		return ModeReadOnly, nil
+	case ModeFull:
+		return ModeFull, nil
+	default:
+		return "", fmt.Errorf("invalid maintenance mode %q: must be off, read-only or full", value)
+	}
+}
+
+// Switch holds the maintenance state and is safe for concurrent use
+type Switch struct {
+	mu     sync.RWMutex
+	status Status
+}
+
+// New creates a switch in the given mode
+func New(mode Mode, message string) *Switch {
+	s := &Switch{}
+	s.Set(mode, message)
+	return s
+}
+
+// Set changes the maintenance mode
+func (s *Switch) Set(mode Mode, message string) Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if mode == ModeOff {
+		s.status = Status{Mode: ModeOff}
+		return s.status
+	}
+
+	if message == "" {
+		message = DefaultMessage
+	}
+	since := s.status.Since
+	if since == nil || s.status.Mode != mode {
+		now := time.Now().UTC()
+		since = &now
+	}
+	s.status = Status{Mode: mode, Message: message, Since: since}
+	return s.status
+}
+
+// Status returns the current maintenance state
+func (s *Switch) Status() Status {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.status
+}
+
+// Blocks reports whether a request is rejected in the current mode
+func (s *Switch) Blocks(r *http.Request) bool {
+	for _, prefix := range exemptPrefixes {
+		if r.URL.Path == strings.TrimSuffix(prefix, "/") || strings.HasPrefix(r.URL.Path, prefix) {
+			return false
+		}
+	}
+
+	switch s.Status().Mode {
+	case ModeFull:
+		return r.Method != http.MethodOptions
+	case ModeReadOnly:
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			return false
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// Middleware responds 503 to requests blocked by maintenance
+func (s *Switch) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.Blocks(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		status := s.Status()
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(Response{
+			Error:   "Service under maintenance",
+			Message: status.Message,
+			Mode:    status.Mode,
+			Since:   status.Since,
+		})
+	})
+}
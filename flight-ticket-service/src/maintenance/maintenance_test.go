@@ -0,0 +1,73 @@
+package maintenance
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseMode(t *testing.T) {
+	tests := map[string]Mode{"": ModeOff, "off": ModeOff, "Read-Only": ModeReadOnly, "readonly": ModeReadOnly, "full": ModeFull}
+	for input, expected := range tests {
+		mode, err := ParseMode(input)
+		if err != nil || mode != expected {
+			t.Errorf("ParseMode(%q) = %q, %v; want %q", input, mode, err, expected)
+		}
+	}
+	if _, err := ParseMode("partial"); err == nil {
+		t.Error("Expected error for unknown mode")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	s := New(ModeOff, "")
+	handler := s.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		mode   Mode
+		method string
+		path   string
+		status int
+	}{
+		{ModeOff, http.MethodPost, "/ticket/", http.StatusOK},
+		{ModeReadOnly, http.MethodGet, "/ticket/ABC123", http.StatusOK},
+		{ModeReadOnly, http.MethodPut, "/ticket/ABC123", http.StatusServiceUnavailable},
+		{ModeReadOnly, http.MethodDelete, "/ticket/ABC123", http.StatusServiceUnavailable},
+		{ModeReadOnly, http.MethodPut, "/admin/maintenance", http.StatusOK},
+		{ModeFull, http.MethodGet, "/tickets", http.StatusServiceUnavailable},
+		{ModeFull, http.MethodGet, "/health", http.StatusOK},
+		{ModeFull, http.MethodGet, "/admin", http.StatusOK},
+		{ModeFull, http.MethodOptions, "/ticket/", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		s.Set(tt.mode, "")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Code != tt.status {
+			t.Errorf("%s: %s %s returned %d, want %d", tt.mode, tt.method, tt.path, rec.Code, tt.status)
+		}
+		if rec.Code == http.StatusServiceUnavailable && rec.Header().Get("Retry-After") == "" {
+			t.Errorf("%s: expected Retry-After header", tt.mode)
+		}
+	}
+}
+
+func TestSetKeepsSince(t *testing.T) {
+	s := New(ModeReadOnly, "Migrating")
+	first := s.Status()
+	if first.Since == nil || first.Message != "Migrating" {
+		t.Fatalf("Unexpected status %+v", first)
+	}
+
+	second := s.Set(ModeReadOnly, "Still migrating")
+	if !second.Since.Equal(*first.Since) {
+		t.Error("Changing only the message should keep the start time")
+	}
+
+	if off := s.Set(ModeOff, "ignored"); off.Since != nil || off.Message != "" {
+		t.Errorf("Unexpected status after turning off %+v", off)
+	}
+}
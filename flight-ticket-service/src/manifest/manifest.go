@@ -0,0 +1,125 @@
+// Package manifest builds departure manifests for gate agents and renders them as CSV or PDF.
+package manifest
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+// Ticket statuses that put passengers on the manifest
+const (
+	statusConfirmed = "CONFIRMED"
+	statusCheckedIn = "CHECKED_IN"
+)
+
+// placeholderNames names passengers who have not checked in yet, by type
+var placeholderNames = map[string]string{
+	models.PassengerAdult:  "ADULT",
+	models.PassengerChild:  "CHILD",
+	models.PassengerInfant: "INFANT",
+}
+
+// Build lists the passengers of the confirmed and checked-in tickets on a flight.
+// Passengers who have not checked in yet are listed as PAX/ADULTn, PAX/CHILDn
+// or PAX/INFANTn placeholders; each ticket lists its adults first, then its
+// children, then its infants, with their SSR codes.
+func Build(flightNumber string, date time.Time, tickets []*models.FlightTicket, now time.Time) *models.FlightManifest {
+	manifest := &models.FlightManifest{
+		FlightNumber: flightNumber,
+		Date:         date.Format("2006-01-02"),
+		GeneratedAt:  now.UTC(),
+		Entries:      []models.ManifestPassenger{},
+	}
+
+	var onboard []*models.FlightTicket
+	for _, ticket := range tickets {
+		if ticket.Status == statusConfirmed || ticket.Status == statusCheckedIn {
+			onboard = append(onboard, ticket)
+		}
+	}
+	sort.Slice(onboard, func(i, j int) bool {
+		return onboard[i].ConfirmationID < onboard[j].ConfirmationID
+	})
+
+	for _, ticket := range onboard {
+		manifest.Tickets++
+		manifest.Passengers += ticket.Passengers
+		types := models.TicketPassengerTypes(ticket)
+		manifest.Infants += types.Infants
+		counts := make(map[string]int, 3)
+		requests := make(map[int][]string)
+		for _, request := range models.TicketSpecialRequests(ticket) {
+			requests[request.Passenger] = append(requests[request.Passenger], request.Code)
+		}
+
+		var checkedIn []models.CheckedInPassenger
+		if ticket.Status == statusCheckedIn && ticket.CheckIn != nil {
+			checkedIn = ticket.CheckIn.Passengers
+		}
+		for i := 0; i < ticket.Passengers || i < len(checkedIn); i++ {
+			passengerType := types.Type(i)
+			counts[passengerType]++
+			entry := models.ManifestPassenger{
+				ConfirmationID:  ticket.ConfirmationID,
+				PassengerName:   fmt.Sprintf("PAX/%s%d", placeholderNames[passengerType], counts[passengerType]),
+				PassengerType:   passengerType,
+				SpecialRequests: requests[i+1],
+				Status:          statusConfirmed,
+				Route:           ticket.Origin + "-" + ticket.Destination,
+				DepartureTime:   ticket.DepartureTime,
+			}
+			if i < len(checkedIn) {
+				entry.PassengerName = checkedIn[i].PassengerName
+				entry.Status = statusCheckedIn
+				entry.Seat = checkedIn[i].Seat
+				entry.SequenceNumber = checkedIn[i].SequenceNumber
+				manifest.CheckedIn++
+			}
+			manifest.Entries = append(manifest.Entries, entry)
+		}
+	}
+
+	return manifest
+}
+
+// csvHeader names the CSV columns
+var csvHeader = []string{"confirmation_id", "passenger_name", "status", "seat", "sequence_number", "route", "departure_time", "passenger_type", "special_requests"}
+
+// WriteCSV writes one row per passenger
+func WriteCSV(w io.Writer, manifest *models.FlightManifest) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, entry := range manifest.Entries {
+		sequence := ""
+		if entry.SequenceNumber > 0 {
+			sequence = strconv.Itoa(entry.SequenceNumber)
+		}
+		record := []string{
+			entry.ConfirmationID,
+			entry.PassengerName,
+			entry.Status,
+			entry.Seat,
+			sequence,
+			entry.Route,
+			entry.DepartureTime.UTC().Format(time.RFC3339),
+			entry.PassengerType,
+			strings.Join(entry.SpecialRequests, " "),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
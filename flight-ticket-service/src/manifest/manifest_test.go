@@ -0,0 +1,106 @@
+package manifest
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+func testManifest() *models.FlightManifest {
+	date := time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC)
+	departure := time.Date(2024, 12, 25, 14, 30, 0, 0, time.UTC)
+	tickets := []*models.FlightTicket{
+		{ConfirmationID: "BBB222", Origin: "JFK", Destination: "LAX", Passengers: 1, Status: "CONFIRMED", DepartureTime: departure},
+		{ConfirmationID: "AAA111", Origin: "JFK", Destination: "LAX", Passengers: 2, Status: "CHECKED_IN", DepartureTime: departure,
+			CheckIn: &models.CheckInRecord{Passengers: []models.CheckedInPassenger{{PassengerName: "DOE/JOHN", Seat: "12A", SequenceNumber: 1}}}},
+		{ConfirmationID: "CCC333", Origin: "JFK", Destination: "LAX", Passengers: 4, Status: "CANCELLED", DepartureTime: departure},
+	}
+	return Build("AA1234", date, tickets, departure.Add(-2*time.Hour))
+}
+
+func TestBuild(t *testing.T) {
+	m := testManifest()
+
+	if m.Tickets != 2 || m.Passengers != 3 || m.CheckedIn != 1 || m.Date != "2024-12-25" {
+		t.Errorf("Unexpected totals: %+v", m)
+	}
+	if len(m.Entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(m.Entries))
+	}
+
+	want := []struct{ id, name, status string }{
+		{"AAA111", "DOE/JOHN", "CHECKED_IN"},
+		{"AAA111", "PAX/ADULT2", "CONFIRMED"},
+		{"BBB222", "PAX/ADULT1", "CONFIRMED"},
+	}
+	for i, w := range want {
+		entry := m.Entries[i]
+		if entry.ConfirmationID != w.id || entry.PassengerName != w.name || entry.Status != w.status {
+			t.Errorf("Entry %d = %+v, want %s %s %s", i, entry, w.id, w.name, w.status)
+		}
+	}
+}
+
+func TestBuildPassengerTypesAndSpecialRequests(t *testing.T) {
+	date := time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC)
+	tickets := []*models.FlightTicket{{
+		ConfirmationID: "DDD444", Origin: "JFK", Destination: "LAX", Passengers: 4, Status: "CONFIRMED",
+		Labels: map[string]string{models.ChildrenLabel: "1", models.InfantsLabel: "1", "ssr_1": "wchr-vgml", "ssr_3": "chml"},
+	}}
+	m := Build("AA1234", date, tickets, date)
+
+	if m.Passengers != 4 || m.Infants != 1 {
+		t.Errorf("Unexpected totals: %+v", m)
+	}
+	want := []struct{ name, passengerType, requests string }{
+		{"PAX/ADULT1", models.PassengerAdult, "VGML WCHR"},
+		{"PAX/ADULT2", models.PassengerAdult, ""},
+		{"PAX/CHILD1", models.PassengerChild, "CHML"},
+		{"PAX/INFANT1", models.PassengerInfant, ""},
+	}
+	for i, w := range want {
+		entry := m.Entries[i]
+		if entry.PassengerName != w.name || entry.PassengerType != w.passengerType || strings.Join(entry.SpecialRequests, " ") != w.requests {
+			t.Errorf("Entry %d = %+v, want %s %s %q", i, entry, w.name, w.passengerType, w.requests)
+		}
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, testManifest()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected header and 3 rows, got %q", buf.String())
+	}
+	if lines[1] != "AAA111,DOE/JOHN,CHECKED_IN,12A,1,JFK-LAX,2024-12-25T14:30:00Z,ADT," {
+		t.Errorf("Unexpected first row: %q", lines[1])
+	}
+}
+
+func TestWritePDF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WritePDF(&buf, testManifest()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	pdf := buf.String()
+	if !strings.HasPrefix(pdf, "%PDF-1.4\n") || !strings.HasSuffix(pdf, "%%EOF\n") {
+		t.Errorf("Output is not a complete PDF document")
+	}
+	if !strings.Contains(pdf, "DOE/JOHN") || !strings.Contains(pdf, "/Count 1") {
+		t.Errorf("PDF is missing the passenger line or page tree:\n%s", pdf)
+	}
+}
+
+func TestPDFEscape(t *testing.T) {
+	if got := pdfEscape(`O(BRIEN)\é`); got != `O\(BRIEN\)\\?` {
+		t.Errorf("pdfEscape() = %q", got)
+	}
+}
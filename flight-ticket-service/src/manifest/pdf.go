@@ -0,0 +1,134 @@
+package manifest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"flight-ticket-service/src/models"
+)
+
+// Page layout in points: US Letter, Courier 9pt
+const (
+	pdfPageWidth    = 612
+	pdfPageHeight   = 792
+	pdfMargin       = 40
+	pdfFontSize     = 9
+	pdfLeading      = 12
+	pdfLinesPerPage = (pdfPageHeight - 2*pdfMargin) / pdfLeading
+)
+
+// WritePDF renders the manifest as a printable text table. The document uses
+// the built-in Courier font, so no fonts are embedded.
+func WritePDF(w io.Writer, manifest *models.FlightManifest) error {
+	lines := pdfLines(manifest)
+
+	var pages [][]string
+	for start := 0; start < len(lines); start += pdfLinesPerPage {
+		end := start + pdfLinesPerPage
+		if end > len(lines) {
+			end = len(lines)
+		}
+		pages = append(pages, lines[start:end])
+	}
+
+	_, err := w.Write(renderPDF(pages))
+	return err
+}
+
+// pdfLines lays out the manifest header and one line per passenger, within
+// the tenant's branding when the manifest has one
+func pdfLines(manifest *models.FlightManifest) []string {
+	row := func(columns ...interface{}) string {
+		return fmt.Sprintf("%-8s %-26s %-3s %-10s %-5s %4s %-8s %-6s %s", columns...)
+	}
+
+	var lines []string
+	if branding := manifest.Branding; branding != nil && (branding.DisplayName != "" || branding.Contact != "") {
+		lines = append(lines, strings.TrimSpace(branding.DisplayName+"  "+branding.Contact), "")
+	}
+	lines = append(lines,
+		fmt.Sprintf("DEPARTURE MANIFEST  %s  %s", manifest.FlightNumber, manifest.Date),
+		fmt.Sprintf("Generated %s   Tickets %d   Passengers %d   Infants %d   Checked in %d",
+			manifest.GeneratedAt.UTC().Format("2006-01-02 15:04Z"), manifest.Tickets, manifest.Passengers, manifest.Infants, manifest.CheckedIn),
+		"",
+		row("PNR", "PASSENGER", "PAX", "STATUS", "SEAT", "SEQ", "ROUTE", "DEPARTS", "SSR"),
+	)
+	for _, entry := range manifest.Entries {
+		sequence := ""
+		if entry.SequenceNumber > 0 {
+			sequence = fmt.Sprintf("%d", entry.SequenceNumber)
+		}
+		lines = append(lines, row(entry.ConfirmationID, entry.PassengerName, entry.PassengerType, entry.Status, entry.Seat,
+			sequence, entry.Route, entry.DepartureTime.UTC().Format("15:04Z"), strings.Join(entry.SpecialRequests, " ")))
+	}
+	if len(manifest.Entries) == 0 {
+		lines = append(lines, "No passengers")
+	}
+	if manifest.Branding != nil && manifest.Branding.Footer != "" {
+		lines = append(lines, "", manifest.Branding.Footer)
+	}
+	return lines
+}
+
+// renderPDF writes a PDF 1.4 document with one text page per entry of pages
+func renderPDF(pages [][]string) []byte {
+	var buf bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+
+	// Objects 1-3 are the catalog, the page tree and the font; each page adds a content stream and a page object
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Courier >>")
+
+	for _, lines := range pages {
+		var content strings.Builder
+		fmt.Fprintf(&content, "BT\n/F1 %d Tf\n%d TL\n%d %d Td\n", pdfFontSize, pdfLeading, pdfMargin, pdfPageHeight-pdfMargin)
+		for _, line := range lines {
+			fmt.Fprintf(&content, "(%s) '\n", pdfEscape(line))
+		}
+		content.WriteString("ET")
+
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()))
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, len(offsets)))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return buf.Bytes()
+}
+
+// pdfEscape escapes a line for a PDF string literal; characters outside
+// printable ASCII are not in the standard font encoding and print as '?'
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,186 @@
+package mapping
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// TicketSchemaVersion is the schema version of the ticket documents written
+// by this version. Documents stored without a schema_version are version 0.
+const TicketSchemaVersion = 1
+
+// ticketMigrations[v] upgrades the fields of a version v ticket document to
+// version v+1. A change to TicketDocument that stored documents no longer
+// decode into, such as a field changing type, appends a migration and bumps
+// TicketSchemaVersion.
+var ticketMigrations = []func(fields map[string]interface{}) error{
+	// 1: schema_version is stored; the fields are otherwise unchanged
+	func(fields map[string]interface{}) error { return nil },
+}
+
+// MigrateTicketFields upgrades the fields of a stored ticket to
+// TicketSchemaVersion in place and returns the version they were stored with.
+// Documents written by a newer version are left as they are.
+func MigrateTicketFields(fields map[string]interface{}) (int, error) {
+	stored := schemaVersion(fields)
+	if stored >= TicketSchemaVersion {
+		return stored, nil
+	}
+	for version := stored; version < TicketSchemaVersion; version++ {
+		if err := ticketMigrations[version](fields); err != nil {
+			return stored, fmt.Errorf("failed to migrate ticket from schema version %d to %d: %v", version, version+1, err)
+		}
+	}
+	fields["schema_version"] = int64(TicketSchemaVersion)
+	return stored, nil
+}
+
+// schemaVersion reads schema_version as decoded from Firestore (int64) or JSON (float64)
+func schemaVersion(fields map[string]interface{}) int {
+	switch version := fields["schema_version"].(type) {
+	case int64:
+		return int(version)
+	case float64:
+		return int(version)
+	case int:
+		return version
+	}
+	return 0
+}
+
+// DecodeFields decodes the fields of a stored ticket, upgrading documents of
+// an older schema first
+func (d *TicketDocument) DecodeFields(fields map[string]interface{}) error {
+	stored, err := MigrateTicketFields(fields)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("failed to encode ticket fields: %v", err)
+	}
+	if err := json.Unmarshal(data, d); err != nil {
+		return err
+	}
+	d.migrated = stored < TicketSchemaVersion
+	d.problems = TicketFieldProblems(fields)
+	return nil
+}
+
+// Problems returns what TicketFieldProblems found in the fields decoded by DecodeFields
+func (d *TicketDocument) Problems() []string {
+	return d.problems
+}
+
+// Migrated reports whether DecodeFields upgraded the document, which should
+// then be written back
+func (d *TicketDocument) Migrated() bool {
+	return d.migrated
+}
+
+// Field types of stored tickets, as Firestore decodes them
+const (
+	fieldString    = "string"
+	fieldInteger   = "integer"
+	fieldTimestamp = "timestamp"
+	fieldMap       = "map"
+)
+
+// ticketFieldTypes lists the fields of a current ticket document and their types
+var ticketFieldTypes = map[string]string{
+	"confirmation_id": fieldString,
+	"origin":          fieldString,
+	"destination":     fieldString,
+	"departure_date":  fieldTimestamp,
+	"departure_time":  fieldTimestamp,
+	"flight_number":   fieldString,
+	"passengers":      fieldInteger,
+	"created_at":      fieldTimestamp,
+	"updated_at":      fieldTimestamp,
+	"status":          fieldString,
+	"price":           fieldMap,
+	"labels":          fieldMap,
+	"check_in":        fieldMap,
+	"schema_version":  fieldInteger,
+	"expire_at":       fieldTimestamp,
+}
+
+// optionalTicketFields may be missing from a ticket document
+var optionalTicketFields = map[string]bool{"price": true, "labels": true, "check_in": true, "expire_at": true}
+
+// TicketFieldProblems checks the fields of a ticket read from Firestore, after
+// migration, for missing required fields, values of the wrong type (such as a
+// departure_time edited into a string in the console) and unknown fields.
+// Such documents may still decode, with zero or guessed values.
+func TicketFieldProblems(fields map[string]interface{}) []string {
+	var problems []string
+	for name, kind := range ticketFieldTypes {
+		value, ok := fields[name]
+		if !ok || value == nil {
+			if !optionalTicketFields[name] {
+				problems = append(problems, fmt.Sprintf("missing field %s", name))
+			}
+			continue
+		}
+		if !hasFieldType(value, kind) {
+			problems = append(problems, fmt.Sprintf("field %s is %T, not a %s", name, value, kind))
+		}
+	}
+	for name := range fields {
+		if _, ok := ticketFieldTypes[name]; !ok {
+			problems = append(problems, fmt.Sprintf("unknown field %s", name))
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+func hasFieldType(value interface{}, kind string) bool {
+	switch kind {
+	case fieldString:
+		_, ok := value.(string)
+		return ok
+	case fieldInteger:
+		_, ok := value.(int64)
+		return ok
+	case fieldTimestamp:
+		_, ok := value.(time.Time)
+		return ok
+	case fieldMap:
+		_, ok := value.(map[string]interface{})
+		return ok
+	}
+	return false
+}
+
+// TicketFieldValue converts a value of a ticket field given as JSON, e.g. in
+// an admin repair, to the type the field is stored with: timestamps are RFC
+// 3339 strings and integers JSON numbers
+func TicketFieldValue(name string, value interface{}) (interface{}, error) {
+	kind, ok := ticketFieldTypes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown field %s", name)
+	}
+	switch kind {
+	case fieldInteger:
+		if number, ok := value.(float64); ok && number == float64(int64(number)) {
+			return int64(number), nil
+		}
+	case fieldTimestamp:
+		if text, ok := value.(string); ok {
+			timestamp, err := time.Parse(time.RFC3339, text)
+			if err != nil {
+				return nil, fmt.Errorf("field %s must be an RFC 3339 timestamp: %v", name, err)
+			}
+			return timestamp, nil
+		}
+	default:
+		if hasFieldType(value, kind) {
+			return value, nil
+		}
+	}
+	return nil, fmt.Errorf("field %s must be a %s", name, kind)
+}
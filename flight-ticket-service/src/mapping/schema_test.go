@@ -0,0 +1,111 @@
+package mapping
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTicketMigrationsCoverEveryVersion(t *testing.T) {
+	if len(ticketMigrations) != TicketSchemaVersion {
+		t.Fatalf("Expected %d migrations for schema version %d, got %d", TicketSchemaVersion, TicketSchemaVersion, len(ticketMigrations))
+	}
+}
+
+func TestDecodeFieldsUpgradesOldDocuments(t *testing.T) {
+	departure := time.Date(2024, 12, 25, 14, 30, 0, 0, time.UTC)
+	fields := map[string]interface{}{
+		"confirmation_id": "ABC123",
+		"departure_time":  departure,
+		"passengers":      int64(2),
+		"status":          "CONFIRMED",
+	}
+
+	var doc TicketDocument
+	if err := doc.DecodeFields(fields); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !doc.Migrated() || doc.SchemaVersion != TicketSchemaVersion {
+		t.Errorf("Expected the document to be upgraded to version %d, got version %d (migrated %v)", TicketSchemaVersion, doc.SchemaVersion, doc.Migrated())
+	}
+	if doc.ConfirmationID != "ABC123" || doc.Passengers != 2 || !doc.DepartureTime.Equal(departure) {
+		t.Errorf("Unexpected document %+v", doc)
+	}
+
+	// Current documents decode as they are
+	var current TicketDocument
+	if err := current.DecodeFields(map[string]interface{}{"confirmation_id": "ABC123", "schema_version": int64(TicketSchemaVersion)}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if current.Migrated() {
+		t.Error("Expected a current document not to be migrated")
+	}
+}
+
+func TestMigrateTicketFieldsLeavesNewerDocuments(t *testing.T) {
+	fields := map[string]interface{}{"schema_version": int64(TicketSchemaVersion + 1)}
+	stored, err := MigrateTicketFields(fields)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if stored != TicketSchemaVersion+1 || fields["schema_version"] != int64(TicketSchemaVersion+1) {
+		t.Errorf("Expected a newer document to be left alone, got version %d", stored)
+	}
+}
+
+func TestTicketFieldProblems(t *testing.T) {
+	now := time.Now()
+	fields := map[string]interface{}{
+		"confirmation_id": "ABC123",
+		"origin":          "JFK",
+		"destination":     "LAX",
+		"departure_date":  now,
+		"departure_time":  now,
+		"flight_number":   "AA1234",
+		"passengers":      int64(2),
+		"created_at":      now,
+		"updated_at":      now,
+		"status":          "CONFIRMED",
+		"schema_version":  int64(TicketSchemaVersion),
+	}
+	if problems := TicketFieldProblems(fields); len(problems) != 0 {
+		t.Fatalf("Expected no problems, got %v", problems)
+	}
+
+	// A console edit: the departure time typed as a string, passengers removed and a stray field
+	fields["departure_time"] = "2024-12-25 14:30"
+	delete(fields, "passengers")
+	fields["seat"] = "12A"
+	want := []string{
+		"field departure_time is string, not a timestamp",
+		"missing field passengers",
+		"unknown field seat",
+	}
+	if problems := TicketFieldProblems(fields); !reflect.DeepEqual(problems, want) {
+		t.Errorf("Expected %v, got %v", want, problems)
+	}
+}
+
+func TestTicketFieldValue(t *testing.T) {
+	value, err := TicketFieldValue("departure_time", "2024-12-25T14:30:00Z")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !value.(time.Time).Equal(time.Date(2024, 12, 25, 14, 30, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected departure time %v", value)
+	}
+	if value, err := TicketFieldValue("passengers", float64(2)); err != nil || value != int64(2) {
+		t.Errorf("Expected 2 passengers, got %v (%v)", value, err)
+	}
+
+	for name, value := range map[string]interface{}{
+		"departure_time": "2024-12-25 14:30",
+		"passengers":     2.5,
+		"status":         true,
+		"seat":           "12A",
+	} {
+		if _, err := TicketFieldValue(name, value); err == nil {
+			t.Errorf("Expected an error for %s %v", name, value)
+		}
+	}
+}
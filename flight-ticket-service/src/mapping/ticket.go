@@ -0,0 +1,194 @@
+// Package mapping converts between the domain types in models, which are also
+// the API's JSON wire format, and the documents stored in Firestore. Tickets
+// are stored as TicketDocument, so the stored field names and encodings can
+// change without changing the API, and the other way around.
+package mapping
+
+import (
+	"fmt"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+// TicketDocument is a ticket as stored in Firestore. The JSON tags match the
+// Firestore field names, for decoding documents carried in change events.
+type TicketDocument struct {
+	ConfirmationID string            `firestore:"confirmation_id" json:"confirmation_id"`
+	Origin         string            `firestore:"origin" json:"origin"`
+	Destination    string            `firestore:"destination" json:"destination"`
+	DepartureDate  time.Time         `firestore:"departure_date" json:"departure_date"`
+	DepartureTime  time.Time         `firestore:"departure_time" json:"departure_time"`
+	FlightNumber   string            `firestore:"flight_number" json:"flight_number"`
+	Passengers     int               `firestore:"passengers" json:"passengers"`
+	CreatedAt      time.Time         `firestore:"created_at" json:"created_at"`
+	UpdatedAt      time.Time         `firestore:"updated_at" json:"updated_at"`
+	Status         string            `firestore:"status" json:"status"`
+	Price          *PriceDocument    `firestore:"price,omitempty" json:"price,omitempty"`
+	Labels         map[string]string `firestore:"labels,omitempty" json:"labels,omitempty"`
+	CheckIn        *CheckInDocument  `firestore:"check_in,omitempty" json:"check_in,omitempty"`
+	SchemaVersion  int               `firestore:"schema_version" json:"schema_version"`
+	ExpireAt       *time.Time        `firestore:"expire_at,omitempty" json:"expire_at,omitempty"` // Firestore TTL field of sandbox tickets
+
+	// Set by DecodeFields
+	migrated bool
+	problems []string
+}
+
+// PriceDocument is the price of a stored ticket
+type PriceDocument struct {
+	Amount        float64   `firestore:"amount" json:"amount"`
+	Currency      string    `firestore:"currency" json:"currency"`
+	BaseAmount    float64   `firestore:"base_amount" json:"base_amount"`
+	BaseCurrency  string    `firestore:"base_currency" json:"base_currency"`
+	ExchangeRate  float64   `firestore:"exchange_rate" json:"exchange_rate"`
+	RateAsOf      time.Time `firestore:"rate_as_of" json:"rate_as_of"`
+	VoucherCredit float64   `firestore:"voucher_credit,omitempty" json:"voucher_credit,omitempty"`
+}
+
+// CheckInDocument is the check-in record of a stored ticket
+type CheckInDocument struct {
+	Compartment string                       `firestore:"compartment" json:"compartment"`
+	Passengers  []CheckedInPassengerDocument `firestore:"passengers" json:"passengers"`
+	CheckedInAt time.Time                    `firestore:"checked_in_at" json:"checked_in_at"`
+}
+
+// CheckedInPassengerDocument is a passenger of a stored check-in record
+type CheckedInPassengerDocument struct {
+	PassengerName  string `firestore:"passenger_name" json:"passenger_name"`
+	Seat           string `firestore:"seat,omitempty" json:"seat,omitempty"`
+	SequenceNumber int    `firestore:"sequence_number" json:"sequence_number"`
+}
+
+// TicketToDocument converts a ticket to its stored form. Computed fields, the
+// schedule and notes, are not stored.
+func TicketToDocument(ticket *models.FlightTicket) *TicketDocument {
+	return &TicketDocument{
+		ConfirmationID: ticket.ConfirmationID,
+		Origin:         ticket.Origin,
+		Destination:    ticket.Destination,
+		DepartureDate:  ticket.DepartureDate,
+		DepartureTime:  ticket.DepartureTime,
+		FlightNumber:   ticket.FlightNumber,
+		Passengers:     ticket.Passengers,
+		CreatedAt:      ticket.CreatedAt,
+		UpdatedAt:      ticket.UpdatedAt,
+		Status:         ticket.Status,
+		Price:          priceToDocument(ticket.Price),
+		Labels:         ticket.Labels,
+		CheckIn:        checkInToDocument(ticket.CheckIn),
+		SchemaVersion:  TicketSchemaVersion,
+		ExpireAt:       ticket.ExpiresAt,
+	}
+}
+
+// TicketFromDocument converts a stored ticket to the domain type
+func TicketFromDocument(doc *TicketDocument) *models.FlightTicket {
+	ticket := &models.FlightTicket{
+		ConfirmationID: doc.ConfirmationID,
+		Origin:         doc.Origin,
+		Destination:    doc.Destination,
+		DepartureDate:  doc.DepartureDate,
+		DepartureTime:  doc.DepartureTime,
+		FlightNumber:   doc.FlightNumber,
+		Passengers:     doc.Passengers,
+		CreatedAt:      doc.CreatedAt,
+		UpdatedAt:      doc.UpdatedAt,
+		Status:         doc.Status,
+		Labels:         doc.Labels,
+		ExpiresAt:      doc.ExpireAt,
+	}
+	if doc.Price != nil {
+		ticket.Price = &models.Price{
+			Amount:        doc.Price.Amount,
+			Currency:      doc.Price.Currency,
+			BaseAmount:    doc.Price.BaseAmount,
+			BaseCurrency:  doc.Price.BaseCurrency,
+			ExchangeRate:  doc.Price.ExchangeRate,
+			RateAsOf:      doc.Price.RateAsOf,
+			VoucherCredit: doc.Price.VoucherCredit,
+		}
+	}
+	if doc.CheckIn != nil {
+		ticket.CheckIn = &models.CheckInRecord{
+			Compartment: doc.CheckIn.Compartment,
+			CheckedInAt: doc.CheckIn.CheckedInAt,
+		}
+		for _, passenger := range doc.CheckIn.Passengers {
+			ticket.CheckIn.Passengers = append(ticket.CheckIn.Passengers, models.CheckedInPassenger{
+				PassengerName:  passenger.PassengerName,
+				Seat:           passenger.Seat,
+				SequenceNumber: passenger.SequenceNumber,
+			})
+		}
+	}
+	return ticket
+}
+
+// ticketFields maps the field names of ticket updates to stored field paths
+var ticketFields = map[string]string{
+	"origin":         "origin",
+	"destination":    "destination",
+	"departure_date": "departure_date",
+	"departure_time": "departure_time",
+	"flight_number":  "flight_number",
+	"passengers":     "passengers",
+	"updated_at":     "updated_at",
+	"status":         "status",
+	"price":          "price",
+	"labels":         "labels",
+	"check_in":       "check_in",
+}
+
+// TicketUpdates converts the field updates of a repository UpdateTicket call
+// to updates of the stored document, keyed by field path
+func TicketUpdates(updates map[string]interface{}) (map[string]interface{}, error) {
+	stored := make(map[string]interface{}, len(updates))
+	for field, value := range updates {
+		path, ok := ticketFields[field]
+		if !ok {
+			return nil, fmt.Errorf("unknown ticket field %s", field)
+		}
+		switch v := value.(type) {
+		case *models.Price:
+			value = priceToDocument(v)
+		case *models.CheckInRecord:
+			value = checkInToDocument(v)
+		}
+		stored[path] = value
+	}
+	return stored, nil
+}
+
+func priceToDocument(price *models.Price) *PriceDocument {
+	if price == nil {
+		return nil
+	}
+	return &PriceDocument{
+		Amount:        price.Amount,
+		Currency:      price.Currency,
+		BaseAmount:    price.BaseAmount,
+		BaseCurrency:  price.BaseCurrency,
+		ExchangeRate:  price.ExchangeRate,
+		RateAsOf:      price.RateAsOf,
+		VoucherCredit: price.VoucherCredit,
+	}
+}
+
+func checkInToDocument(record *models.CheckInRecord) *CheckInDocument {
+	if record == nil {
+		return nil
+	}
+	doc := &CheckInDocument{
+		Compartment: record.Compartment,
+		CheckedInAt: record.CheckedInAt,
+	}
+	for _, passenger := range record.Passengers {
+		doc.Passengers = append(doc.Passengers, CheckedInPassengerDocument{
+			PassengerName:  passenger.PassengerName,
+			Seat:           passenger.Seat,
+			SequenceNumber: passenger.SequenceNumber,
+		})
+	}
+	return doc
+}
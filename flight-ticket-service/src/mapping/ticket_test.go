@@ -0,0 +1,81 @@
+package mapping
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+func TestTicketDocumentRoundTrip(t *testing.T) {
+	now := time.Date(2024, 7, 12, 19, 0, 0, 0, time.UTC)
+	expires := now.Add(24 * time.Hour)
+	ticket := &models.FlightTicket{
+		ConfirmationID: "ABC123",
+		Origin:         "JFK",
+		Destination:    "LAX",
+		DepartureDate:  time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC),
+		DepartureTime:  time.Date(2024, 12, 25, 14, 30, 0, 0, time.UTC),
+		FlightNumber:   "AA1234",
+		Passengers:     2,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		Status:         "CHECKED_IN",
+		Price:          &models.Price{Amount: 366.16, Currency: "EUR", BaseAmount: 398, BaseCurrency: "USD", ExchangeRate: 0.92, RateAsOf: now},
+		Labels:         map[string]string{"campaign": "summer-sale"},
+		CheckIn: &models.CheckInRecord{
+			Compartment: "Y",
+			Passengers:  []models.CheckedInPassenger{{PassengerName: "DOE/JOHN", Seat: "12A", SequenceNumber: 1}},
+			CheckedInAt: now,
+		},
+		ExpiresAt: &expires,
+	}
+
+	if got := TicketFromDocument(TicketToDocument(ticket)); !reflect.DeepEqual(got, ticket) {
+		t.Errorf("Round trip changed the ticket:\n got %+v\nwant %+v", got, ticket)
+	}
+
+	// Computed fields are not stored
+	ticket.Schedule = &models.TicketSchedule{TimeZone: "America/New_York"}
+	if got := TicketFromDocument(TicketToDocument(ticket)); got.Schedule != nil {
+		t.Error("Expected the schedule not to be stored")
+	}
+}
+
+func TestPriceDocumentKeepsVoucherCredit(t *testing.T) {
+	ticket := &models.FlightTicket{
+		ConfirmationID: "DEF456",
+		Status:         "CONFIRMED",
+		Price:          &models.Price{Amount: 148, Currency: "USD", BaseAmount: 148, BaseCurrency: "USD", ExchangeRate: 1, VoucherCredit: 250},
+	}
+	if got := TicketFromDocument(TicketToDocument(ticket)); got.Price == nil || got.Price.VoucherCredit != 250 {
+		t.Errorf("Expected the voucher credit to be stored, got %+v", got.Price)
+	}
+
+	fields, err := TicketUpdates(map[string]interface{}{"price": ticket.Price})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if doc, ok := fields["price"].(*PriceDocument); !ok || doc.VoucherCredit != 250 {
+		t.Errorf("Expected a price update with the voucher credit, got %#v", fields["price"])
+	}
+}
+
+func TestTicketUpdates(t *testing.T) {
+	record := &models.CheckInRecord{Compartment: "Y"}
+	fields, err := TicketUpdates(map[string]interface{}{"status": "CHECKED_IN", "check_in": record})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if fields["status"] != "CHECKED_IN" {
+		t.Errorf("Unexpected status %v", fields["status"])
+	}
+	if doc, ok := fields["check_in"].(*CheckInDocument); !ok || doc.Compartment != "Y" {
+		t.Errorf("Expected a check-in document, got %#v", fields["check_in"])
+	}
+
+	if _, err := TicketUpdates(map[string]interface{}{"confirmation_id": "XYZ789"}); err == nil {
+		t.Error("Expected an error for a field that cannot be updated")
+	}
+}
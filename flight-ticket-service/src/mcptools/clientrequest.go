@@ -0,0 +1,165 @@
+package mcptools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// clientRequestTimeout bounds the wait for the client to answer a request of
+// the server, e.g. while its user reviews a sampling request
+const clientRequestTimeout = 2 * time.Minute
+
+// ErrNoClientRequests is returned for requests to the client that the
+// transport cannot carry: an HTTP client has to accept text/event-stream
+// responses to receive them
+var ErrNoClientRequests = errors.New("the transport cannot send requests to the client; HTTP clients must accept text/event-stream")
+
+// errSessionEnded fails the requests to the client a session still awaits
+// when its input ends
+var errSessionEnded = errors.New("the session ended before the client answered")
+
+// sendFunc sends a message to the client of the request being handled
+type sendFunc func(message []byte) error
+
+type senderKey struct{}
+
+func withSender(ctx context.Context, send sendFunc) context.Context {
+	return context.WithValue(ctx, senderKey{}, send)
+}
+
+// clientResponse is the client's response to a request of the server
+type clientResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+// supports reports whether the client declared a capability, e.g. sampling,
+// in its initialize request
+func (sess *session) supports(capability string) bool {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	_, ok := sess.capabilities[capability]
+	return ok
+}
+
+// requestClient sends a request to the client of the session and decodes the
+// result of its response into result. The request goes out with the messages
+// answering the request being handled.
+func (s *Server) requestClient(ctx context.Context, sess *session, method string, params any, result any) error {
+	send, _ := ctx.Value(senderKey{}).(sendFunc)
+	if send == nil {
+		return ErrNoClientRequests
+	}
+	encodedParams, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("%s: failed to encode params: %v", method, err)
+	}
+
+	sess.requestsMu.Lock()
+	if sess.requestsEnded {
+		sess.requestsMu.Unlock()
+		return errSessionEnded
+	}
+	sess.nextRequest++
+	id := fmt.Sprintf("server-%d", sess.nextRequest)
+	answered := make(chan clientResponse, 1)
+	if sess.pending == nil {
+		sess.pending = make(map[string]chan clientResponse)
+	}
+	sess.pending[id] = answered
+	sess.requestsMu.Unlock()
+	defer func() {
+		sess.requestsMu.Lock()
+		delete(sess.pending, id)
+		sess.requestsMu.Unlock()
+	}()
+
+	encodedID, _ := json.Marshal(id)
+	message, _ := json.Marshal(request{JSONRPC: "2.0", ID: encodedID, Method: method, Params: encodedParams})
+	if err := send(message); err != nil {
+		return fmt.Errorf("%s: %v", method, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, clientRequestTimeout)
+	defer cancel()
+	select {
+	case resp, ok := <-answered:
+		if !ok {
+			return fmt.Errorf("%s: %w", method, errSessionEnded)
+		}
+		if resp.Error != nil {
+			return fmt.Errorf("%s: client returned error %d: %s", method, resp.Error.Code, resp.Error.Message)
+		}
+		if err := json.Unmarshal(resp.Result, result); err != nil {
+			return fmt.Errorf("%s: invalid result: %v", method, err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%s: no response from the client: %v", method, ctx.Err())
+	}
+}
+
+// deliver hands a response of the client to the request awaiting it.
+// Responses to unknown requests, e.g. timed out ones, are dropped.
+func (sess *session) deliver(id json.RawMessage, resp clientResponse) {
+	var key string
+	if json.Unmarshal(id, &key) != nil {
+		return
+	}
+	sess.requestsMu.Lock()
+	defer sess.requestsMu.Unlock()
+	if answered, ok := sess.pending[key]; ok {
+		answered <- resp
+		delete(sess.pending, key)
+	}
+}
+
+// endRequests fails the requests to the client the session awaits, and those
+// it makes later, once no response can arrive
+func (sess *session) endRequests() {
+	sess.requestsMu.Lock()
+	defer sess.requestsMu.Unlock()
+	sess.requestsEnded = true
+	for id, answered := range sess.pending {
+		close(answered)
+		delete(sess.pending, id)
+	}
+}
+
+// eventStream answers an HTTP request with a stream of server-sent events
+// once the server sends the client a request while handling it; until then
+// the answer is a plain JSON response
+type eventStream struct {
+	w       http.ResponseWriter
+	accepts bool // the client accepts text/event-stream
+	started bool
+}
+
+func newEventStream(w http.ResponseWriter, r *http.Request) *eventStream {
+	return &eventStream{w: w, accepts: strings.Contains(r.Header.Get("Accept"), "text/event-stream")}
+}
+
+// send writes a message as an event, starting the stream
+func (e *eventStream) send(message []byte) error {
+	if !e.accepts {
+		return ErrNoClientRequests
+	}
+	if !e.started {
+		e.w.Header().Set("Content-Type", "text/event-stream")
+		e.w.Header().Set("Cache-Control", "no-cache")
+		e.w.WriteHeader(http.StatusOK)
+		e.started = true
+	}
+	if _, err := fmt.Fprintf(e.w, "event: message\ndata: %s\n\n", message); err != nil {
+		return err
+	}
+	if flusher, ok := e.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
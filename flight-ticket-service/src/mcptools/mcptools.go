@@ -0,0 +1,199 @@
+// Package mcptools exposes the REST API as MCP tools. The tool definitions
+// and their handlers in tools_gen.go are generated from the OpenAPI spec by
+// cmd/mcpgen, so every documented endpoint becomes a tool:
+//
+//	go generate ./src/mcptools
+//
+// Each tool takes the endpoint's path, query and header parameters as
+// arguments, and the fields of its JSON body. Client.Call turns the arguments
+// back into the HTTP request.
+package mcptools
+
+//go:generate go run ../cmd/mcpgen -spec ../../docs/swagger.json -out tools_gen.go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"google.golang.org/api/idtoken"
+)
+
+// Annotations are the MCP hints describing a tool's side effects
+type Annotations struct {
+	Title           string `json:"title,omitempty"`
+	ReadOnlyHint    bool   `json:"readOnlyHint"`
+	DestructiveHint bool   `json:"destructiveHint"`
+	IdempotentHint  bool   `json:"idempotentHint"`
+	OpenWorldHint   bool   `json:"openWorldHint"`
+}
+
+// Tool is an MCP tool calling one endpoint. It marshals to the tool
+// definition of an MCP tools/list response.
+type Tool struct {
+	Name         string          `json:"name"`
+	Description  string          `json:"description,omitempty"`
+	InputSchema  json.RawMessage `json:"inputSchema"`
+	OutputSchema json.RawMessage `json:"outputSchema,omitempty"` // of the structured content of results
+	Annotations  Annotations     `json:"annotations"`
+
+	Method       string            `json:"-"`
+	Path         string            `json:"-"` // with {name} placeholders
+	PathParams   []string          `json:"-"`
+	QueryParams  []string          `json:"-"`
+	HeaderParams map[string]string `json:"-"` // header by argument
+	BodyParams   []string          `json:"-"` // arguments sent as fields of the JSON body
+	BodyArgument string            `json:"-"` // argument sent as the whole body, when it is not an object
+}
+
+// Handler calls the endpoint of a tool with the tool's arguments
+type Handler func(c *Client, ctx context.Context, args map[string]any) (json.RawMessage, error)
+
+// Lookup returns the tool with the given name
+func Lookup(name string) (Tool, bool) {
+	for _, tool := range Tools {
+		if tool.Name == name {
+			return tool, true
+		}
+	}
+	return Tool{}, false
+}
+
+// Client calls the API on behalf of the tools
+type Client struct {
+	baseURL string
+	apiKey  string
+	tokens  oauth2.TokenSource
+	http    *http.Client
+}
+
+// NewClient creates a client for the service at baseURL, authenticating with apiKey
+func NewClient(baseURL, apiKey string) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// SetTokenSource makes the client send a token of ts with each request, as
+// "Authorization: Bearer <token>"
+func (c *Client) SetTokenSource(ts oauth2.TokenSource) {
+	c.tokens = ts
+}
+
+// UseIDTokens makes the client send Google-signed ID tokens for audience,
+// minted from the application default credentials: on Cloud Run, from the
+// service account the caller runs as. This authenticates it to a service
+// requiring IAM authentication.
+func (c *Client) UseIDTokens(ctx context.Context, audience string) error {
+	ts, err := idtoken.NewTokenSource(ctx, audience)
+	if err != nil {
+		return fmt.Errorf("failed to create ID token source for %s: %v", audience, err)
+	}
+	c.SetTokenSource(ts)
+	return nil
+}
+
+// APIError is a non-2xx response of the API
+type APIError struct {
+	Status int
+	Body   json.RawMessage
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API returned status %d: %s", e.Status, e.Body)
+}
+
+// Call sends the tool's request built from the arguments and returns the
+// JSON response. Path parameters are required; other arguments are optional.
+func (c *Client) Call(ctx context.Context, tool Tool, args map[string]any) (json.RawMessage, error) {
+	path := tool.Path
+	for _, name := range tool.PathParams {
+		value, ok := args[name]
+		if !ok {
+			return nil, fmt.Errorf("%s: missing argument %s", tool.Name, name)
+		}
+		path = strings.ReplaceAll(path, "{"+name+"}", url.PathEscape(fmt.Sprint(value)))
+	}
+	query := url.Values{}
+	for _, name := range tool.QueryParams {
+		if value, ok := args[name]; ok {
+			query.Set(name, fmt.Sprint(value))
+		}
+	}
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var body io.Reader
+	var payload any
+	if tool.BodyArgument != "" {
+		payload = args[tool.BodyArgument]
+	} else if len(tool.BodyParams) > 0 {
+		fields := make(map[string]any, len(tool.BodyParams))
+		for _, name := range tool.BodyParams {
+			if value, ok := args[name]; ok {
+				fields[name] = value
+			}
+		}
+		payload = fields
+	}
+	if payload != nil {
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to encode body: %v", tool.Name, err)
+		}
+		body = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, tool.Method, c.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to create request: %v", tool.Name, err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+	if c.tokens != nil {
+		token, err := c.tokens.Token()
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to get token: %v", tool.Name, err)
+		}
+		token.SetAuthHeader(req)
+	}
+	for name, header := range tool.HeaderParams {
+		if value, ok := args[name]; ok {
+			req.Header.Set(header, fmt.Sprint(value))
+		}
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", tool.Name, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to read response: %v", tool.Name, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &APIError{Status: resp.StatusCode, Body: data}
+	}
+	if len(data) == 0 || !json.Valid(data) {
+		// e.g. 204 No Content or a PDF; tools return JSON
+		encoded, _ := json.Marshal(map[string]any{"status": resp.StatusCode, "content_type": resp.Header.Get("Content-Type"), "bytes": len(data)})
+		return encoded, nil
+	}
+	return data, nil
+}
@@ -0,0 +1,276 @@
+package mcptools
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func TestCall(t *testing.T) {
+	var got *http.Request
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		data, _ := io.ReadAll(r.Body)
+		body = nil
+		json.Unmarshal(data, &body)
+		switch r.URL.Path {
+		case "/ticket/MISSING":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"Ticket not found"}`))
+		case "/ticket/ABC123":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Write([]byte(`{"ok":true}`))
+		}
+	}))
+	defer server.Close()
+	client := NewClient(server.URL+"/", "desk-key")
+	ctx := context.Background()
+
+	tool := Tool{
+		Name:         "approve",
+		Method:       http.MethodPost,
+		Path:         "/admin/review-queue/{confirmationID}/approve",
+		PathParams:   []string{"confirmationID"},
+		QueryParams:  []string{"dry_run"},
+		HeaderParams: map[string]string{"lock_token": "X-Lock-Token"},
+		BodyParams:   []string{"reason"},
+	}
+	result, err := client.Call(ctx, tool, map[string]any{"confirmationID": "ABC 123", "dry_run": true, "lock_token": "t1", "reason": "ok"})
+	if err != nil || string(result) != `{"ok":true}` {
+		t.Fatalf("Unexpected result %s, %v", result, err)
+	}
+	if got.URL.EscapedPath() != "/admin/review-queue/ABC%20123/approve" || got.URL.Query().Get("dry_run") != "true" ||
+		got.Header.Get("X-Lock-Token") != "t1" || got.Header.Get("X-API-Key") != "desk-key" || body["reason"] != "ok" {
+		t.Errorf("Unexpected request %s %v with body %v", got.URL, got.Header, body)
+	}
+
+	if _, err := client.Call(ctx, tool, map[string]any{}); err == nil {
+		t.Error("Expected an error without the path parameter")
+	}
+
+	get := Tool{Name: "get", Method: http.MethodGet, Path: "/ticket/{confirmationID}", PathParams: []string{"confirmationID"}}
+	var apiErr *APIError
+	if _, err := client.Call(ctx, get, map[string]any{"confirmationID": "MISSING"}); !errors.As(err, &apiErr) || apiErr.Status != http.StatusNotFound {
+		t.Errorf("Expected a 404 API error, got %v", err)
+	}
+	if got.Header.Get("Content-Type") != "" {
+		t.Error("Expected no body on a GET")
+	}
+	if result, err := client.Call(ctx, get, map[string]any{"confirmationID": "ABC123"}); err != nil || string(result) != `{"bytes":0,"content_type":"","status":204}` {
+		t.Errorf("Expected the status of an empty response, got %s, %v", result, err)
+	}
+
+	client.SetTokenSource(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "id-token"}))
+	if _, err := client.Call(ctx, get, map[string]any{"confirmationID": "ABC123"}); err != nil || got.Header.Get("Authorization") != "Bearer id-token" {
+		t.Errorf("Expected the token as bearer, got %q, %v", got.Header.Get("Authorization"), err)
+	}
+}
+
+func TestGeneratedTools(t *testing.T) {
+	if len(Handlers) != len(Tools) {
+		t.Fatalf("Expected a handler per tool, got %d handlers for %d tools", len(Handlers), len(Tools))
+	}
+	for _, tool := range Tools {
+		var schema map[string]any
+		if err := json.Unmarshal(tool.InputSchema, &schema); err != nil || schema["type"] != "object" {
+			t.Errorf("%s: invalid input schema %s", tool.Name, tool.InputSchema)
+		}
+		if _, ok := Lookup(tool.Name); !ok || Handlers[tool.Name] == nil {
+			t.Errorf("%s: no handler", tool.Name)
+		}
+	}
+}
+
+func TestServer(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ticket/MISSING" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"Ticket not found","message":"No ticket MISSING"}`))
+			return
+		}
+		w.Write([]byte(`{"confirmation_id":"ABC123"}`))
+	}))
+	defer api.Close()
+	server := NewServer(NewClient(api.URL, "desk-key"), "test", "v1")
+	ctx := context.Background()
+
+	for message, want := range map[string]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05"}}`:                                                  `"protocolVersion":"2024-11-05"`,
+		`{"jsonrpc":"2.0","id":2,"method":"initialize","params":{"protocolVersion":"1999-01-01"}}`:                                                  `"protocolVersion":"` + ProtocolVersion + `"`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"get_ticket_by_confirmation_id","arguments":{"confirmationID":"ABC123"}}}`:  `"structuredContent":{"confirmation_id":"ABC123"}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"get_ticket_by_confirmation_id","arguments":{"confirmationID":"MISSING"}}}`: `"text":"Ticket not found (status 404): No ticket MISSING"}],"isError":true`,
+		`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"get_ticket_by_confirmation_id"}}`:                                          `"code":-32602`,
+		`{"jsonrpc":"2.0","id":6,"method":"prompts/list"}`:                                                                                          `"code":-32601`,
+		`{"jsonrpc":"2.0","id":7}`: `"code":-32600`,
+		`not json`:                 `"code":-32700`,
+	} {
+		if got := string(server.Handle(ctx, []byte(message))); !strings.Contains(got, want) {
+			t.Errorf("Expected %s in the response to %s, got %s", want, message, got)
+		}
+	}
+	if got := server.Handle(ctx, []byte(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)); got != nil {
+		t.Errorf("Expected no response to a notification, got %s", got)
+	}
+
+	var out bytes.Buffer
+	in := strings.NewReader("{\"jsonrpc\":\"2.0\",\"method\":\"notifications/initialized\"}\n\n{\"jsonrpc\":\"2.0\",\"id\":1,\"method\":\"ping\"}\n")
+	if err := server.Serve(ctx, in, &out); err != nil || out.String() != "{\"jsonrpc\":\"2.0\",\"id\":1,\"result\":{}}\n" {
+		t.Errorf("Unexpected stdio output %q, %v", out.String(), err)
+	}
+
+	post := func(sessionID, message string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(message))
+		if sessionID != "" {
+			req.Header.Set(SessionHeader, sessionID)
+		}
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		return rec
+	}
+	initialized := `{"jsonrpc":"2.0","method":"notifications/initialized"}`
+	if rec := post("", initialized); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without a session, got %d", rec.Code)
+	}
+	rec := post("", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`)
+	sessionID := rec.Header().Get(SessionHeader)
+	if rec.Code != http.StatusOK || sessionID == "" {
+		t.Fatalf("Expected a session from initialize, got %d %v", rec.Code, rec.Header())
+	}
+	if rec := post(sessionID, initialized); rec.Code != http.StatusAccepted {
+		t.Errorf("Expected 202 for a notification, got %d", rec.Code)
+	}
+	if rec := post("unknown", initialized); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown session, got %d", rec.Code)
+	}
+	end := httptest.NewRequest(http.MethodDelete, "/mcp", nil)
+	end.Header.Set(SessionHeader, sessionID)
+	server.ServeHTTP(httptest.NewRecorder(), end)
+	if rec := post(sessionID, initialized); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an ended session, got %d", rec.Code)
+	}
+
+	rec = post("", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`)
+	idleID := rec.Header().Get(SessionHeader)
+	server.mu.Lock()
+	server.sessions[idleID].lastUsed = time.Now().Add(-2 * sessionTTL)
+	server.mu.Unlock()
+	for range 2 {
+		rec = post(idleID, `{"jsonrpc":"2.0","id":2,"method":"ping"}`)
+		var expired response
+		json.Unmarshal(rec.Body.Bytes(), &expired)
+		if rec.Code != http.StatusNotFound || expired.Error == nil || expired.Error.Code != codeSessionExpired || expired.Error.Data.(map[string]any)["summary"] == nil {
+			t.Errorf("Expected 404 with the summary for an expired session, got %d %s", rec.Code, rec.Body)
+		}
+	}
+	end.Header.Set(SessionHeader, idleID)
+	rec = httptest.NewRecorder()
+	server.ServeHTTP(rec, end)
+	var receipt SessionSummary
+	if err := json.Unmarshal(rec.Body.Bytes(), &receipt); err != nil || rec.Code != http.StatusOK {
+		t.Errorf("Expected the summary when an expired session ends, got %d %s", rec.Code, rec.Body)
+	}
+	if rec := post(idleID, initialized); rec.Code != http.StatusNotFound || rec.Header().Get("Content-Type") == "application/json" {
+		t.Errorf("Expected a plain 404 once the expired session ended, got %d %s", rec.Code, rec.Body)
+	}
+	rec = httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/mcp", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for a GET, got %d", rec.Code)
+	}
+}
+
+func TestSummarizeTrips(t *testing.T) {
+	now := time.Now().UTC()
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"tickets": []map[string]any{
+			{"confirmation_id": "LATER1", "origin": "JFK", "destination": "LAX", "departure_time": now.Add(72 * time.Hour), "passengers": 1, "status": "CONFIRMED"},
+			{"confirmation_id": "PAST01", "origin": "JFK", "destination": "SFO", "departure_time": now.Add(-time.Hour), "passengers": 1, "status": "CONFIRMED"},
+			{"confirmation_id": "GONE01", "origin": "JFK", "destination": "ORD", "departure_time": now.Add(time.Hour), "passengers": 1, "status": "CANCELLED"},
+			{"confirmation_id": "NEXT01", "origin": "BOS", "destination": "JFK", "departure_time": now.Add(24 * time.Hour), "passengers": 2, "status": "PENDING"},
+		}})
+	}))
+	defer api.Close()
+	mcp := httptest.NewServer(NewServer(NewClient(api.URL, "desk-key"), "test", "v1"))
+	defer mcp.Close()
+
+	post := func(sessionID, message string) *http.Response {
+		req, _ := http.NewRequest(http.MethodPost, mcp.URL, strings.NewReader(message))
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		if sessionID != "" {
+			req.Header.Set(SessionHeader, sessionID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to post %s: %v", message, err)
+		}
+		return resp
+	}
+	initialize := func(capabilities string) string {
+		resp := post("", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"capabilities":`+capabilities+`}}`)
+		resp.Body.Close()
+		return resp.Header.Get(SessionHeader)
+	}
+	call := `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"summarize_upcoming_trips","arguments":{}}}`
+
+	// without sampling the tool fails, answered as JSON
+	resp := post(initialize(`{}`), call)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), `does not support sampling`) || !strings.Contains(string(body), `"isError":true`) {
+		t.Errorf("Expected a sampling error, got %s", body)
+	}
+
+	// with sampling the request to the client and the result are streamed
+	sessionID := initialize(`{"sampling":{}}`)
+	resp = post(sessionID, call)
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got %s", resp.Header.Get("Content-Type"))
+	}
+	events := bufio.NewScanner(resp.Body)
+	next := func() []byte {
+		for events.Scan() {
+			if data, ok := strings.CutPrefix(events.Text(), "data: "); ok {
+				return []byte(data)
+			}
+		}
+		t.Fatalf("Stream ended: %v", events.Err())
+		return nil
+	}
+	var sampling struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+		Params struct {
+			Messages []struct {
+				Content Content `json:"content"`
+			} `json:"messages"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(next(), &sampling); err != nil || sampling.Method != "sampling/createMessage" || len(sampling.Params.Messages) != 1 {
+		t.Fatalf("Expected a sampling request, got %+v, %v", sampling, err)
+	}
+	prompt := sampling.Params.Messages[0].Content.Text
+	if strings.Index(prompt, "NEXT01") > strings.Index(prompt, "LATER1") || strings.Contains(prompt, "PAST01") || strings.Contains(prompt, "GONE01") {
+		t.Errorf("Expected the upcoming trips, soonest first, got %q", prompt)
+	}
+	answer := post(sessionID, `{"jsonrpc":"2.0","id":`+string(sampling.ID)+`,"result":{"role":"assistant","content":{"type":"text","text":"Two trips ahead."},"model":"test-model"}}`)
+	answer.Body.Close()
+	if answer.StatusCode != http.StatusAccepted {
+		t.Errorf("Expected 202 for the client's response, got %d", answer.StatusCode)
+	}
+	if result := string(next()); !strings.Contains(result, `"structuredContent":{"model":"test-model","summary":"Two trips ahead.","trip_count":2}`) {
+		t.Errorf("Unexpected result %s", result)
+	}
+}
@@ -0,0 +1,490 @@
+package mcptools
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProtocolVersion is the MCP revision the server implements, offered to
+// clients asking for a revision it does not know
+const ProtocolVersion = "2025-06-18"
+
+// protocolVersions are the MCP revisions the server accepts
+var protocolVersions = []string{"2024-11-05", "2025-03-26", ProtocolVersion}
+
+// JSON-RPC error codes
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// maxMessageBytes bounds a JSON-RPC message read from stdio or HTTP
+const maxMessageBytes = 1 << 20
+
+// SessionHeader carries the session ID of the HTTP transport
+const SessionHeader = "Mcp-Session-Id"
+
+// Server is an MCP server exposing the tools, calling the API with its
+// client. Each session keeps the changes made by its tool calls, which
+// undo_last_action undoes.
+type Server struct {
+	client   *Client
+	name     string
+	version  string
+	tools    []Tool
+	handlers map[string]Handler
+
+	local    *session // of Handle
+	mu       sync.Mutex
+	sessions map[string]*session      // of the HTTP transport, by ID
+	ended    map[string]*endedSession // HTTP sessions dropped when idle, by ID
+
+	// OnSessionClose, if set, receives the summary of each session that ends:
+	// HTTP sessions deleted by their client or dropped when idle, and stdio
+	// sessions when their input ends
+	OnSessionClose func(SessionSummary)
+}
+
+// NewServer creates a server of the generated tools, undo_last_action and
+// summarize_upcoming_trips, named name and version in the initialize handshake
+func NewServer(client *Client, name, version string) *Server {
+	return &Server{
+		client:   client,
+		name:     name,
+		version:  version,
+		tools:    append(append([]Tool{}, Tools...), UndoTool, SummarizeTripsTool),
+		handlers: Handlers,
+		local:    newSession(),
+		sessions: make(map[string]*session),
+		ended:    make(map[string]*endedSession),
+	}
+}
+
+// endedSession is an HTTP session dropped when idle, whose summary is kept
+// for its client for sessionTTL
+type endedSession struct {
+	at      time.Time
+	done    chan struct{} // closed once summary is set
+	summary SessionSummary
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// Content is a text block of a tool result
+type Content struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// CallResult is the result of a tools/call request. Structured content is
+// the JSON object the API returned, matching the tool's output schema.
+// Failed API calls are results with IsError set and the error as text.
+type CallResult struct {
+	Content           []Content       `json:"content"`
+	StructuredContent json.RawMessage `json:"structuredContent,omitempty"`
+	IsError           bool            `json:"isError,omitempty"`
+}
+
+// Handle answers one JSON-RPC message of the server's single local session.
+// It returns nil for notifications, which have no response. Tools needing
+// requests to the client, like summarize_upcoming_trips, fail with
+// ErrNoClientRequests: Serve carries them.
+func (s *Server) Handle(ctx context.Context, message []byte) []byte {
+	return s.handle(ctx, s.local, message)
+}
+
+func (s *Server) handle(ctx context.Context, sess *session, message []byte) []byte {
+	var req request
+	if err := json.Unmarshal(message, &req); err != nil {
+		return encodeResponse(response{ID: json.RawMessage("null"), Error: &rpcError{Code: codeParseError, Message: "Parse error"}})
+	}
+	if req.JSONRPC == "2.0" && req.Method == "" && req.ID != nil {
+		var resp clientResponse
+		if json.Unmarshal(message, &resp) == nil && (resp.Result != nil || resp.Error != nil) {
+			// the client's response to a request of the server
+			sess.deliver(req.ID, resp)
+			return nil
+		}
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		id := req.ID
+		if id == nil {
+			id = json.RawMessage("null")
+		}
+		return encodeResponse(response{ID: id, Error: &rpcError{Code: codeInvalidRequest, Message: "Invalid request"}})
+	}
+	if req.ID == nil {
+		// notifications/initialized, notifications/cancelled, ...
+		return nil
+	}
+
+	result, rpcErr := s.dispatch(ctx, sess, req)
+	return encodeResponse(response{ID: req.ID, Result: result, Error: rpcErr})
+}
+
+func encodeResponse(resp response) []byte {
+	resp.JSONRPC = "2.0"
+	data, _ := json.Marshal(resp)
+	return data
+}
+
+func (s *Server) dispatch(ctx context.Context, sess *session, req request) (any, *rpcError) {
+	switch req.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string                     `json:"protocolVersion"`
+			Capabilities    map[string]json.RawMessage `json:"capabilities"`
+		}
+		json.Unmarshal(req.Params, &params)
+		sess.mu.Lock()
+		sess.capabilities = params.Capabilities
+		sess.mu.Unlock()
+		version := ProtocolVersion
+		for _, supported := range protocolVersions {
+			if params.ProtocolVersion == supported {
+				version = supported
+			}
+		}
+		return map[string]any{
+			"protocolVersion": version,
+			"capabilities": map[string]any{
+				"tools":     map[string]any{"listChanged": false},
+				"resources": map[string]any{"listChanged": false, "subscribe": false},
+			},
+			"serverInfo": map[string]string{"name": s.name, "version": s.version},
+		}, nil
+	case "ping":
+		return map[string]any{}, nil
+	case "tools/list":
+		return map[string]any{"tools": s.tools}, nil
+	case "tools/call":
+		var params struct {
+			Name      string         `json:"name"`
+			Arguments map[string]any `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "Invalid params: " + err.Error()}
+		}
+		return s.call(ctx, sess, params.Name, params.Arguments)
+	case "resources/list":
+		return map[string]any{"resources": []map[string]string{summaryResource}}, nil
+	case "resources/read":
+		var params struct {
+			URI string `json:"uri"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "Invalid params: " + err.Error()}
+		}
+		return s.readResource(ctx, sess, params.URI)
+	default:
+		return nil, &rpcError{Code: codeMethodNotFound, Message: "Method not found: " + req.Method}
+	}
+}
+
+// call runs a tool. Unknown tools and missing required arguments are
+// protocol errors; errors of the API are tool results.
+func (s *Server) call(ctx context.Context, sess *session, name string, args map[string]any) (*CallResult, *rpcError) {
+	switch name {
+	case UndoToolName:
+		return s.undo(ctx, sess), nil
+	case SummarizeTripsToolName:
+		return s.summarizeTrips(ctx, sess, args), nil
+	}
+	handler, ok := s.handlers[name]
+	if !ok {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "Unknown tool: " + name}
+	}
+	if args == nil {
+		args = map[string]any{}
+	}
+	readOnly := false
+	for _, tool := range s.tools {
+		if tool.Name != name {
+			continue
+		}
+		readOnly = tool.Annotations.ReadOnlyHint
+		var schema struct {
+			Required []string `json:"required"`
+		}
+		json.Unmarshal(tool.InputSchema, &schema)
+		var missing []string
+		for _, arg := range schema.Required {
+			if _, ok := args[arg]; !ok {
+				missing = append(missing, arg)
+			}
+		}
+		if len(missing) > 0 {
+			return nil, &rpcError{Code: codeInvalidParams, Message: fmt.Sprintf("%s: missing required arguments %s", name, strings.Join(missing, ", "))}
+		}
+	}
+
+	var data json.RawMessage
+	var err error
+	if readOnly {
+		data, err = handler(s.client, ctx, args)
+	} else {
+		data, err = s.callMutation(ctx, sess, name, handler, args)
+	}
+	if err != nil {
+		return &CallResult{Content: []Content{{Type: "text", Text: ErrorText(err)}}, IsError: true}, nil
+	}
+	result := &CallResult{Content: []Content{{Type: "text", Text: string(data)}}}
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "{") {
+		result.StructuredContent = data
+	}
+	return result, nil
+}
+
+// ErrorText describes a failed call to the model: the error and message of
+// the API's ErrorResponse with its status, or the error itself
+func ErrorText(err error) string {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return err.Error()
+	}
+	var body struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(apiErr.Body, &body) != nil || body.Error == "" {
+		return apiErr.Error()
+	}
+	text := fmt.Sprintf("%s (status %d)", body.Error, apiErr.Status)
+	if body.Message != "" {
+		text += ": " + body.Message
+	}
+	return text
+}
+
+// Serve runs the stdio transport: newline-delimited messages are read from r
+// and their responses written to w, until r ends or ctx is done. The messages
+// are one session. Requests are handled concurrently, so a tool can wait for
+// the client's response to a request of the server, which is written to w as
+// well.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	sess := newSession()
+	defer s.close(sess)
+
+	var writeMu sync.Mutex
+	var writeErr error
+	write := func(message []byte) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		if writeErr == nil {
+			_, writeErr = w.Write(append(message, '\n'))
+		}
+		return writeErr
+	}
+	handlerCtx := withSender(ctx, write)
+	var handlers sync.WaitGroup
+	defer handlers.Wait()
+	// without input no response of the client can arrive
+	defer sess.endRequests()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageBytes)
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		line := append([]byte{}, scanner.Bytes()...)
+		handlers.Add(1)
+		go func() {
+			defer handlers.Done()
+			if resp := s.handle(handlerCtx, sess, line); resp != nil {
+				write(resp)
+			}
+		}()
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	sess.endRequests()
+	handlers.Wait()
+	writeMu.Lock()
+	defer writeMu.Unlock()
+	return writeErr
+}
+
+// ServeHTTP runs the streamable HTTP transport: each POST carries one message
+// and is answered with its JSON response, or 202 for notifications and the
+// client's responses. A request whose handling sends the client a request,
+// like sampling, is answered with a stream of server-sent events instead,
+// carrying that request and then the response; the client POSTs its answer
+// in the same session. An initialize request starts a session, whose ID is
+// sent in the Mcp-Session-Id header and required on the later requests; a
+// DELETE ends it and is answered with the session's summary.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodDelete {
+		id := r.Header.Get(SessionHeader)
+		s.mu.Lock()
+		sess, ended := s.sessions[id], s.ended[id]
+		delete(s.sessions, id)
+		delete(s.ended, id)
+		s.mu.Unlock()
+		var summary SessionSummary
+		switch {
+		case sess != nil:
+			sess.endRequests()
+			summary = s.close(sess)
+		case ended != nil:
+			<-ended.done
+			summary = ended.summary
+		default:
+			http.Error(w, "Unknown MCP session", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(summary)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST, DELETE")
+		http.Error(w, "MCP messages are POSTed", http.StatusMethodNotAllowed)
+		return
+	}
+	message, err := io.ReadAll(io.LimitReader(r.Body, maxMessageBytes))
+	if err != nil {
+		http.Error(w, "Failed to read message", http.StatusBadRequest)
+		return
+	}
+
+	var sess *session
+	var req request
+	json.Unmarshal(message, &req)
+	if id := r.Header.Get(SessionHeader); id != "" {
+		var ended *endedSession
+		sess, ended = s.lookup(id)
+		if ended != nil {
+			// the client learns what its session did before starting another
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(response{JSONRPC: "2.0", ID: req.ID, Error: &rpcError{
+				Code:    codeSessionExpired,
+				Message: "MCP session expired; initialize a new one",
+				Data:    map[string]any{"summary": ended.summary},
+			}})
+			return
+		}
+		if sess == nil {
+			http.Error(w, "Unknown MCP session; initialize a new one", http.StatusNotFound)
+			return
+		}
+	} else if req.Method == "initialize" {
+		id, created := s.startSession()
+		sess = created
+		w.Header().Set(SessionHeader, id)
+	} else {
+		http.Error(w, "Missing "+SessionHeader+" header; send initialize first", http.StatusBadRequest)
+		return
+	}
+
+	stream := newEventStream(w, r)
+	resp := s.handle(withSender(r.Context(), stream.send), sess, message)
+	if stream.started {
+		if resp != nil {
+			stream.send(resp)
+		}
+		return
+	}
+	if resp == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
+
+// codeSessionExpired is the error code of a request in an HTTP session
+// dropped when idle
+const codeSessionExpired = -32001
+
+// lookup finds the HTTP session id, dropping it if idle for sessionTTL. A
+// dropped session is returned as ended, once its summary is ready.
+func (s *Server) lookup(id string) (*session, *endedSession) {
+	s.mu.Lock()
+	sess := s.sessions[id]
+	if sess != nil && time.Since(sess.lastUsed) > sessionTTL {
+		s.drop(id, sess)
+		sess = nil
+	}
+	if sess != nil {
+		sess.lastUsed = time.Now()
+	}
+	ended := s.ended[id]
+	s.mu.Unlock()
+	if ended != nil {
+		<-ended.done
+	}
+	return sess, ended
+}
+
+// drop closes an idle HTTP session in the background, keeping its summary
+// for the next request of its client. The caller holds s.mu.
+func (s *Server) drop(id string, sess *session) {
+	delete(s.sessions, id)
+	ended := &endedSession{at: time.Now(), done: make(chan struct{})}
+	s.ended[id] = ended
+	go func() {
+		sess.endRequests()
+		ended.summary = s.close(sess)
+		close(ended.done)
+	}()
+}
+
+// startSession starts an HTTP session, dropping those idle for sessionTTL
+// and forgetting the summaries kept for sessionTTL
+func (s *Server) startSession() (string, *session) {
+	b := make([]byte, 16)
+	rand.Read(b)
+	id := hex.EncodeToString(b)
+	sess := newSession()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for other, idle := range s.sessions {
+		if time.Since(idle.lastUsed) > sessionTTL {
+			s.drop(other, idle)
+		}
+	}
+	for other, ended := range s.ended {
+		if time.Since(ended.at) > sessionTTL {
+			delete(s.ended, other)
+		}
+	}
+	s.sessions[id] = sess
+	return id, sess
+}
@@ -0,0 +1,134 @@
+package mcptools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// SummaryURI is the resource summarizing the bookings changed in the session
+const SummaryURI = "session://summary"
+
+// summaryResource describes the summary in resources/list
+var summaryResource = map[string]string{
+	"uri":         SummaryURI,
+	"name":        "session_summary",
+	"title":       "Session summary",
+	"description": "Receipt of the bookings created, modified and cancelled in this session, with their current state",
+	"mimeType":    "application/json",
+}
+
+// codeResourceNotFound is MCP's error code for an unknown resource URI
+const codeResourceNotFound = -32002
+
+// SessionSummary is the receipt of an MCP session: the bookings its tool
+// calls created, modified and cancelled, by their state when it was made
+type SessionSummary struct {
+	SessionStarted time.Time        `json:"session_started"`
+	GeneratedAt    time.Time        `json:"generated_at"`
+	Created        []BookingSummary `json:"created"`   // booked in the session and not cancelled
+	Modified       []BookingSummary `json:"modified"`  // booked before, changed in the session and not cancelled
+	Cancelled      []BookingSummary `json:"cancelled"` // cancelled in the session
+}
+
+// BookingSummary is a booking changed in the session
+type BookingSummary struct {
+	ConfirmationID string          `json:"confirmation_id"`
+	Changes        []string        `json:"changes"`          // tools that changed it, in order
+	Ticket         json.RawMessage `json:"ticket,omitempty"` // current state
+	Error          string          `json:"error,omitempty"`  // why the current state could not be read
+}
+
+// booking is what a session did to a ticket
+type booking struct {
+	confirmationID string
+	booked         bool // in this session
+	cancelled      bool // by the last change
+	changes        []string
+}
+
+// track adds a change of a ticket to the session's bookings. The caller
+// holds the session's lock.
+func (sess *session) track(confirmationID, change string, booked, cancelled bool) {
+	if confirmationID == "" {
+		return
+	}
+	if sess.bookings == nil {
+		sess.bookings = make(map[string]*booking)
+	}
+	b, ok := sess.bookings[confirmationID]
+	if !ok {
+		b = &booking{confirmationID: confirmationID}
+		sess.bookings[confirmationID] = b
+		sess.bookingOrder = append(sess.bookingOrder, confirmationID)
+	}
+	b.booked = b.booked || booked
+	b.cancelled = cancelled
+	b.changes = append(b.changes, change)
+}
+
+// getTicket reads a ticket's current state
+var getTicket = Tool{Name: SummaryURI, Method: http.MethodGet, Path: "/ticket/{confirmationID}", PathParams: []string{"confirmationID"}}
+
+// summary reads the current state of the session's bookings and sorts them
+// by what the session did to them. A booking whose state cannot be read is
+// sorted by the session's last change of it.
+func (s *Server) summary(ctx context.Context, sess *session) SessionSummary {
+	sess.mu.Lock()
+	bookings := make([]booking, 0, len(sess.bookingOrder))
+	for _, id := range sess.bookingOrder {
+		b := *sess.bookings[id]
+		b.changes = append([]string{}, b.changes...)
+		bookings = append(bookings, b)
+	}
+	summary := SessionSummary{SessionStarted: sess.started, Created: []BookingSummary{}, Modified: []BookingSummary{}, Cancelled: []BookingSummary{}}
+	sess.mu.Unlock()
+
+	for _, b := range bookings {
+		entry := BookingSummary{ConfirmationID: b.confirmationID, Changes: b.changes}
+		cancelled := b.cancelled
+		data, err := s.client.Call(ctx, getTicket, map[string]any{"confirmationID": b.confirmationID})
+		if err != nil {
+			entry.Error = ErrorText(err)
+		} else {
+			entry.Ticket = data
+			var ticket struct {
+				Status string `json:"status"`
+			}
+			if json.Unmarshal(data, &ticket) == nil && ticket.Status != "" {
+				cancelled = ticket.Status == "CANCELLED"
+			}
+		}
+		switch {
+		case cancelled:
+			summary.Cancelled = append(summary.Cancelled, entry)
+		case b.booked:
+			summary.Created = append(summary.Created, entry)
+		default:
+			summary.Modified = append(summary.Modified, entry)
+		}
+	}
+	summary.GeneratedAt = time.Now().UTC()
+	return summary
+}
+
+// readResource answers resources/read
+func (s *Server) readResource(ctx context.Context, sess *session, uri string) (any, *rpcError) {
+	if uri != SummaryURI {
+		return nil, &rpcError{Code: codeResourceNotFound, Message: "Resource not found: " + uri}
+	}
+	encoded, _ := json.MarshalIndent(s.summary(ctx, sess), "", "  ")
+	return map[string]any{"contents": []map[string]string{{"uri": SummaryURI, "mimeType": "application/json", "text": string(encoded)}}}, nil
+}
+
+// close ends a session, handing its summary to OnSessionClose
+func (s *Server) close(sess *session) SessionSummary {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	summary := s.summary(ctx, sess)
+	if s.OnSessionClose != nil {
+		s.OnSessionClose(summary)
+	}
+	return summary
+}
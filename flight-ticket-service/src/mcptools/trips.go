@@ -0,0 +1,111 @@
+package mcptools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SummarizeTripsToolName is the tool that summarizes upcoming trips through
+// the client's model
+const SummarizeTripsToolName = "summarize_upcoming_trips"
+
+// SummarizeTripsTool reads the tickets and asks the client, with an MCP
+// sampling request, to write a summary of the upcoming ones
+var SummarizeTripsTool = Tool{
+	Name:         SummarizeTripsToolName,
+	Description:  "Summarize the upcoming trips in plain language. The tickets are read from the API and the summary is written by the client's model through MCP sampling, so the client must support sampling.",
+	InputSchema:  json.RawMessage(`{"type":"object","properties":{"limit":{"type":"integer","default":50,"description":"Maximum number of tickets to read"}}}`),
+	OutputSchema: json.RawMessage(`{"type":"object","properties":{"summary":{"type":"string"},"trip_count":{"type":"integer"},"model":{"type":"string"}}}`),
+	Annotations:  Annotations{Title: "Summarize upcoming trips", ReadOnlyHint: true},
+}
+
+// summaryPrompt is the system prompt of the sampling request
+const summaryPrompt = "You summarize flight bookings in a few friendly sentences: what is coming up next, " +
+	"any busy stretches, and anything that needs attention such as pending bookings. " +
+	"Mention confirmation IDs and do not invent details."
+
+// trip is a ticket as summarized
+type trip struct {
+	ConfirmationID string    `json:"confirmation_id"`
+	Origin         string    `json:"origin"`
+	Destination    string    `json:"destination"`
+	FlightNumber   string    `json:"flight_number"`
+	DepartureTime  time.Time `json:"departure_time"`
+	Passengers     int       `json:"passengers"`
+	Status         string    `json:"status"`
+}
+
+// upcomingTrips returns the trips that depart at or after now and are not
+// cancelled, soonest first
+func upcomingTrips(trips []trip, now time.Time) []trip {
+	var upcoming []trip
+	for _, t := range trips {
+		if t.Status != "CANCELLED" && !t.DepartureTime.Before(now) {
+			upcoming = append(upcoming, t)
+		}
+	}
+	sort.SliceStable(upcoming, func(i, j int) bool { return upcoming[i].DepartureTime.Before(upcoming[j].DepartureTime) })
+	return upcoming
+}
+
+// summarizeTrips reads the tickets and has the client's model summarize the
+// upcoming ones
+func (s *Server) summarizeTrips(ctx context.Context, sess *session, args map[string]any) *CallResult {
+	if !sess.supports("sampling") {
+		return &CallResult{Content: []Content{{Type: "text", Text: "The MCP client does not support sampling, which this tool needs to write the summary"}}, IsError: true}
+	}
+	list, _ := Lookup("get_tickets")
+	query := map[string]any{}
+	if limit, ok := args["limit"]; ok {
+		query["limit"] = limit
+	}
+	data, err := s.client.Call(ctx, list, query)
+	if err != nil {
+		return &CallResult{Content: []Content{{Type: "text", Text: ErrorText(err)}}, IsError: true}
+	}
+	var listing struct {
+		Tickets []trip `json:"tickets"`
+	}
+	if err := json.Unmarshal(data, &listing); err != nil {
+		return &CallResult{Content: []Content{{Type: "text", Text: fmt.Sprintf("Invalid ticket list: %v", err)}}, IsError: true}
+	}
+
+	trips := upcomingTrips(listing.Tickets, time.Now())
+	summary := map[string]any{"summary": "There are no upcoming trips.", "trip_count": 0}
+	if len(trips) > 0 {
+		lines := make([]string, len(trips))
+		for i, t := range trips {
+			flight := t.FlightNumber
+			if flight == "" {
+				flight = "unassigned"
+			}
+			lines[i] = fmt.Sprintf("- %s: %s to %s on flight %s, departing %s, %d passenger(s), status %s",
+				t.ConfirmationID, t.Origin, t.Destination, flight, t.DepartureTime.Format(time.RFC3339), t.Passengers, t.Status)
+		}
+		var created struct {
+			Content Content `json:"content"`
+			Model   string  `json:"model"`
+		}
+		err := s.requestClient(ctx, sess, "sampling/createMessage", map[string]any{
+			"messages": []map[string]any{{
+				"role":    "user",
+				"content": Content{Type: "text", Text: "Summarize these upcoming flights for the traveller:\n" + strings.Join(lines, "\n")},
+			}},
+			"systemPrompt": summaryPrompt,
+			"maxTokens":    400,
+		}, &created)
+		if err != nil {
+			return &CallResult{Content: []Content{{Type: "text", Text: fmt.Sprintf("Failed to sample a summary: %v", err)}}, IsError: true}
+		}
+		if created.Content.Type != "text" {
+			return &CallResult{Content: []Content{{Type: "text", Text: fmt.Sprintf("The client returned %s content instead of a text summary", created.Content.Type)}}, IsError: true}
+		}
+		summary = map[string]any{"summary": created.Content.Text, "trip_count": len(trips), "model": created.Model}
+	}
+	structured, _ := json.Marshal(summary)
+	return &CallResult{Content: []Content{{Type: "text", Text: string(structured)}}, StructuredContent: structured}
+}
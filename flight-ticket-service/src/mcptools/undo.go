@@ -0,0 +1,225 @@
+package mcptools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// UndoToolName is the tool that undoes the last mutation of a session
+const UndoToolName = "undo_last_action"
+
+// UndoTool undoes the last mutation made by a tool call of the session by
+// calling its compensating endpoint, so agent demos can be re-run
+var UndoTool = Tool{
+	Name:        UndoToolName,
+	Description: "Undo the last change made in this session: cancel a booked ticket, or restore an updated or cancelled ticket as it was before, from its history. Call it repeatedly to undo earlier changes.",
+	InputSchema: json.RawMessage(`{"type":"object","properties":{}}`),
+	Annotations: Annotations{Title: "Undo last action", DestructiveHint: true},
+}
+
+// compensation undoes the mutations of a tool. record completes the action
+// from the tool's arguments and result with what undo needs, and reports
+// whether it can be undone.
+type compensation struct {
+	record func(ctx context.Context, c *Client, a *action, args map[string]any, result json.RawMessage) bool
+	undo   func(ctx context.Context, c *Client, a action) (string, error)
+}
+
+// compensations are the undoable tools, by tool name. Mutations of other
+// tools are recorded but cannot be undone.
+var compensations = map[string]compensation{
+	"create_ticket":                    {record: recordConfirmationID, undo: cancelTicket},
+	"update_ticket_by_confirmation_id": {record: recordUpdate, undo: restoreTicket},
+	"delete_ticket_by_confirmation_id": {record: recordUpdate, undo: restoreTicket},
+}
+
+// action is a mutation made by a tool call
+type action struct {
+	Tool           string
+	ConfirmationID string
+	UpdatedAt      time.Time // when an update was made, to find it in the ticket's history
+	Undoable       bool
+}
+
+// session is an MCP session: an initialize handshake and the calls after it
+type session struct {
+	mu           sync.Mutex
+	started      time.Time
+	lastUsed     time.Time
+	actions      []action            // undo history
+	bookings     map[string]*booking // changed tickets, by confirmation ID
+	bookingOrder []string
+	capabilities map[string]json.RawMessage // declared by the client in initialize
+
+	requestsMu    sync.Mutex
+	nextRequest   int
+	pending       map[string]chan clientResponse // requests to the client awaiting its response, by ID
+	requestsEnded bool
+}
+
+func newSession() *session {
+	now := time.Now().UTC()
+	return &session{started: now, lastUsed: now}
+}
+
+// sessionTTL is how long an idle HTTP session is kept
+const sessionTTL = time.Hour
+
+// callMutation calls a tool that changes data, recording the call in the
+// session when it succeeds
+func (s *Server) callMutation(ctx context.Context, sess *session, name string, handler Handler, args map[string]any) (json.RawMessage, error) {
+	data, err := handler(s.client, ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	a := action{Tool: name}
+	if id, ok := args["confirmationID"]; ok {
+		a.ConfirmationID = fmt.Sprint(id)
+	}
+	if comp, ok := compensations[name]; ok {
+		a.Undoable = comp.record(ctx, s.client, &a, args, data)
+	}
+	sess.mu.Lock()
+	sess.actions = append(sess.actions, a)
+	sess.track(a.ConfirmationID, name, name == "create_ticket", name == "delete_ticket_by_confirmation_id")
+	sess.mu.Unlock()
+	return data, nil
+}
+
+// undo undoes the last action of the session. An action that cannot be
+// undone is dropped with an error result, so earlier ones can be undone next.
+func (s *Server) undo(ctx context.Context, sess *session) *CallResult {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if len(sess.actions) == 0 {
+		return &CallResult{Content: []Content{{Type: "text", Text: "Nothing to undo in this session"}}, IsError: true}
+	}
+	last := sess.actions[len(sess.actions)-1]
+	if !last.Undoable {
+		sess.actions = sess.actions[:len(sess.actions)-1]
+		return &CallResult{Content: []Content{{Type: "text", Text: fmt.Sprintf("%s cannot be undone; it was dropped from the undo history", last.Tool)}}, IsError: true}
+	}
+
+	text, err := compensations[last.Tool].undo(ctx, s.client, last)
+	if err != nil {
+		// kept, so the undo can be retried
+		return &CallResult{Content: []Content{{Type: "text", Text: fmt.Sprintf("Failed to undo %s: %s", last.Tool, ErrorText(err))}}, IsError: true}
+	}
+	sess.actions = sess.actions[:len(sess.actions)-1]
+	sess.track(last.ConfirmationID, UndoToolName, false, last.Tool == "create_ticket")
+	structured, _ := json.Marshal(map[string]any{"undone": last.Tool, "confirmation_id": last.ConfirmationID, "remaining": len(sess.actions)})
+	return &CallResult{Content: []Content{{Type: "text", Text: text}}, StructuredContent: structured}
+}
+
+// recordConfirmationID keeps the confirmation ID of a booked ticket
+func recordConfirmationID(ctx context.Context, c *Client, a *action, args map[string]any, result json.RawMessage) bool {
+	var ticket struct {
+		ConfirmationID string `json:"confirmation_id"`
+	}
+	json.Unmarshal(result, &ticket)
+	a.ConfirmationID = ticket.ConfirmationID
+	return a.ConfirmationID != ""
+}
+
+// cancelTicket undoes a booking by cancelling the ticket
+func cancelTicket(ctx context.Context, c *Client, a action) (string, error) {
+	cancel := Tool{Name: UndoToolName, Method: http.MethodDelete, Path: "/ticket/{confirmationID}", PathParams: []string{"confirmationID"}}
+	if _, err := c.Call(ctx, cancel, map[string]any{"confirmationID": a.ConfirmationID}); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Cancelled ticket %s booked by %s", a.ConfirmationID, a.Tool), nil
+}
+
+// recordUpdate keeps when the change was made: the updated_at the API gave
+// the ticket for it, by which the undo finds its revision in the ticket's
+// history. Cancellations do not return the ticket; the undo finds the latest
+// cancellation instead.
+func recordUpdate(ctx context.Context, c *Client, a *action, args map[string]any, result json.RawMessage) bool {
+	a.ConfirmationID = fmt.Sprint(args["confirmationID"])
+	var ticket struct {
+		UpdatedAt time.Time `json:"updated_at"`
+	}
+	json.Unmarshal(result, &ticket)
+	a.UpdatedAt = ticket.UpdatedAt
+	return true
+}
+
+// getRevision reads the changes of a revision of a ticket, the latest unless to is set
+var getRevision = Tool{Name: UndoToolName, Method: http.MethodGet, Path: "/ticket/{confirmationID}/history/diff", PathParams: []string{"confirmationID"}, QueryParams: []string{"to"}}
+
+// undoRevision reverts the target revision of a ticket, refused when the
+// ticket changed after it or revision is no longer the latest
+var undoRevision = Tool{Name: UndoToolName, Method: http.MethodPost, Path: "/ticket/{confirmationID}/undo", PathParams: []string{"confirmationID"}, QueryParams: []string{"target", "revision"}}
+
+// revision is a revision of a ticket's history, by the fields it changed
+type revision struct {
+	To struct {
+		Number int `json:"number"`
+	} `json:"to"`
+	Changes []struct {
+		Field string `json:"field"`
+		To    any    `json:"to"`
+	} `json:"changes"`
+}
+
+// updatedAt returns when the revision updated the ticket, zero if it did not
+func (r revision) updatedAt() time.Time {
+	for _, change := range r.Changes {
+		if change.Field == "updated_at" {
+			updatedAt, _ := time.Parse(time.RFC3339Nano, fmt.Sprint(change.To))
+			return updatedAt
+		}
+	}
+	return time.Time{}
+}
+
+// cancelled reports whether the revision cancelled the ticket
+func (r revision) cancelled() bool {
+	for _, change := range r.Changes {
+		if change.Field == "status" && change.To == "CANCELLED" {
+			return true
+		}
+	}
+	return false
+}
+
+// restoreTicket undoes an update or cancellation with POST
+// /ticket/{confirmationID}/undo, which puts back the whole ticket as it was
+// before the change's revision. The revision is looked up in the ticket's
+// history from the latest back; the API refuses to undo it when the ticket
+// changed after it other than by undone changes, or when its history has
+// not recorded the latest change yet.
+func restoreTicket(ctx context.Context, c *Client, a action) (string, error) {
+	args := map[string]any{"confirmationID": a.ConfirmationID}
+	latest := 0
+	for {
+		data, err := c.Call(ctx, getRevision, args)
+		if err != nil {
+			return "", err
+		}
+		var r revision
+		if err := json.Unmarshal(data, &r); err != nil {
+			return "", fmt.Errorf("invalid ticket history of %s: %v", a.ConfirmationID, err)
+		}
+		if latest == 0 {
+			latest = r.To.Number
+		}
+		// Storage may keep timestamps to the microsecond only
+		updatedAt := r.updatedAt().Truncate(time.Microsecond)
+		if a.UpdatedAt.IsZero() && r.cancelled() || !a.UpdatedAt.IsZero() && updatedAt.Equal(a.UpdatedAt.Truncate(time.Microsecond)) {
+			undo := map[string]any{"confirmationID": a.ConfirmationID, "target": r.To.Number, "revision": latest}
+			if _, err := c.Call(ctx, undoRevision, undo); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Restored ticket %s as it was before %s", a.ConfirmationID, a.Tool), nil
+		}
+		if r.To.Number <= 1 || !a.UpdatedAt.IsZero() && updatedAt.Before(a.UpdatedAt.Truncate(time.Microsecond)) {
+			return "", fmt.Errorf("the history of ticket %s has no revision of %s yet", a.ConfirmationID, a.Tool)
+		}
+		args["to"] = r.To.Number - 1
+	}
+}
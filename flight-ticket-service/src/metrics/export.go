@@ -0,0 +1,342 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/compute/metadata"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/api/googleapi"
+	monitoring "google.golang.org/api/monitoring/v3"
+	"google.golang.org/api/option"
+)
+
+// Metric export defaults
+const (
+	DefaultExportInterval = time.Minute
+	DefaultExportPrefix   = "custom.googleapis.com/flight_ticket_service"
+)
+
+// minExportInterval keeps each time series within Cloud Monitoring's write rate limit
+const minExportInterval = 10 * time.Second
+
+// maxSeriesPerRequest is the most time series one CreateTimeSeries call accepts
+const maxSeriesPerRequest = 200
+
+// exportTimeout bounds a single export so a slow API never piles up goroutines
+const exportTimeout = 30 * time.Second
+
+// DefaultExportMetrics are exported unless METRICS_EXPORT_METRICS names others:
+// request latency, Firestore document operations and document cache lookups,
+// whose hit rate is the ratio of the hit series to the total
+var DefaultExportMetrics = []string{
+	"http_request_duration_seconds",
+	"firestore_document_operations_total",
+	"document_cache_requests_total",
+}
+
+// spanContextType is the type URL of exemplar attachments linking to Cloud Trace
+const spanContextType = "type.googleapis.com/google.monitoring.v3.SpanContext"
+
+// ExportConfig configures exporting metrics to Cloud Monitoring
+type ExportConfig struct {
+	Enabled   bool
+	ProjectID string
+	Interval  time.Duration
+	Prefix    string   // metric type prefix; the metric name is appended
+	Metrics   []string // names of the metric families exported
+	Location  string   // region of the instance, "global" when unknown
+}
+
+// ExportConfigFromEnv reads METRICS_EXPORT_ENABLED, METRICS_EXPORT_INTERVAL,
+// METRICS_EXPORT_PREFIX and METRICS_EXPORT_METRICS
+func ExportConfigFromEnv(projectID, location string) (ExportConfig, error) {
+	config := ExportConfig{
+		ProjectID: projectID,
+		Interval:  DefaultExportInterval,
+		Prefix:    DefaultExportPrefix,
+		Metrics:   DefaultExportMetrics,
+		Location:  location,
+	}
+	if config.Location == "" {
+		config.Location = "global"
+	}
+	if value := strings.TrimSpace(os.Getenv("METRICS_EXPORT_ENABLED")); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return ExportConfig{}, fmt.Errorf("invalid METRICS_EXPORT_ENABLED %q: must be true or false", value)
+		}
+		config.Enabled = enabled
+	}
+	if value := strings.TrimSpace(os.Getenv("METRICS_EXPORT_INTERVAL")); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil || interval < minExportInterval {
+			return ExportConfig{}, fmt.Errorf("invalid METRICS_EXPORT_INTERVAL %q: must be a duration of at least %s", value, minExportInterval)
+		}
+		config.Interval = interval
+	}
+	if value := strings.TrimSpace(os.Getenv("METRICS_EXPORT_PREFIX")); value != "" {
+		config.Prefix = strings.TrimSuffix(value, "/")
+	}
+	if value := strings.TrimSpace(os.Getenv("METRICS_EXPORT_METRICS")); value != "" {
+		config.Metrics = nil
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				config.Metrics = append(config.Metrics, name)
+			}
+		}
+	}
+	if config.Enabled && config.ProjectID == "" {
+		return ExportConfig{}, fmt.Errorf("GOOGLE_CLOUD_PROJECT is required to export metrics")
+	}
+	return config, nil
+}
+
+// Exporter periodically writes metrics gathered from a Prometheus registry to
+// Cloud Monitoring. Every instance writes its own time series, identified by
+// a generic_task resource, and counters are written as cumulative values from
+// the exporter's start. Latency exemplars link to their traces in Cloud Trace.
+type Exporter struct {
+	service  *monitoring.Service
+	config   ExportConfig
+	gatherer prometheus.Gatherer
+	resource *monitoring.MonitoredResource
+	start    time.Time
+
+	stop chan struct{}
+	done sync.WaitGroup
+}
+
+// NewExporter creates an exporter. The service account needs roles/monitoring.metricWriter.
+func NewExporter(ctx context.Context, config ExportConfig, gatherer prometheus.Gatherer, credentialsPath string) (*Exporter, error) {
+	var opts []option.ClientOption
+	if credentialsPath != "" {
+		// Use service account key file
+		opts = append(opts, option.WithCredentialsFile(credentialsPath))
+	}
+
+	service, err := monitoring.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Cloud Monitoring client: %v", err)
+	}
+
+	return &Exporter{
+		service:  service,
+		config:   config,
+		gatherer: gatherer,
+		resource: taskResource(config),
+		start:    time.Now(),
+		stop:     make(chan struct{}),
+	}, nil
+}
+
+// Start exports metrics every interval until Close
+func (e *Exporter) Start() {
+	e.done.Add(1)
+	go func() {
+		defer e.done.Done()
+		ticker := time.NewTicker(e.config.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := e.Export(context.Background()); err != nil {
+					log.Printf("Failed to export metrics: %v", err)
+				}
+			case <-e.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops the exporter and exports the final values
+func (e *Exporter) Close() error {
+	close(e.stop)
+	e.done.Wait()
+	return e.Export(context.Background())
+}
+
+// Export writes the current values of the exported metrics
+func (e *Exporter) Export(ctx context.Context) error {
+	families, err := e.gatherer.Gather()
+	if err != nil {
+		return fmt.Errorf("failed to gather metrics: %v", err)
+	}
+	series := timeSeries(families, e.config, e.resource, e.start, time.Now())
+
+	ctx, cancel := context.WithTimeout(ctx, exportTimeout)
+	defer cancel()
+	for len(series) > 0 {
+		batch := series
+		if len(batch) > maxSeriesPerRequest {
+			batch = batch[:maxSeriesPerRequest]
+		}
+		series = series[len(batch):]
+
+		request := &monitoring.CreateTimeSeriesRequest{TimeSeries: batch}
+		if _, err := e.service.Projects.TimeSeries.Create("projects/"+e.config.ProjectID, request).Context(ctx).Do(); err != nil {
+			return fmt.Errorf("failed to write time series: %v", err)
+		}
+	}
+	return nil
+}
+
+// taskResource identifies the instance: on Cloud Run the service, revision
+// and instance ID, elsewhere the host and process
+func taskResource(config ExportConfig) *monitoring.MonitoredResource {
+	namespace, job, task := os.Getenv("K_SERVICE"), os.Getenv("K_REVISION"), ""
+	if namespace != "" {
+		if id, err := metadata.InstanceID(); err == nil {
+			task = id
+		}
+	} else {
+		namespace, job = "flight-ticket-service", "local"
+	}
+	if task == "" {
+		host, _ := os.Hostname()
+		task = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+	return &monitoring.MonitoredResource{
+		Type: "generic_task",
+		Labels: map[string]string{
+			"project_id": config.ProjectID,
+			"location":   config.Location,
+			"namespace":  namespace,
+			"job":        job,
+			"task_id":    task,
+		},
+	}
+}
+
+// timeSeries converts the exported metric families to time series ending now.
+// Counters and histograms are cumulative since start; gauges are sampled.
+// Other metric types are skipped.
+func timeSeries(families []*dto.MetricFamily, config ExportConfig, resource *monitoring.MonitoredResource, start, now time.Time) []*monitoring.TimeSeries {
+	exported := make(map[string]bool, len(config.Metrics))
+	for _, name := range config.Metrics {
+		exported[name] = true
+	}
+
+	cumulative := &monitoring.TimeInterval{StartTime: start.UTC().Format(time.RFC3339Nano), EndTime: now.UTC().Format(time.RFC3339Nano)}
+	sampled := &monitoring.TimeInterval{EndTime: cumulative.EndTime}
+
+	var series []*monitoring.TimeSeries
+	for _, family := range families {
+		if !exported[family.GetName()] {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			ts := &monitoring.TimeSeries{
+				Metric:   &monitoring.Metric{Type: config.Prefix + "/" + family.GetName(), Labels: metricLabels(metric)},
+				Resource: resource,
+			}
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				value := int64(metric.GetCounter().GetValue())
+				ts.MetricKind, ts.ValueType = "CUMULATIVE", "INT64"
+				ts.Points = []*monitoring.Point{{Interval: cumulative, Value: &monitoring.TypedValue{Int64Value: &value}}}
+			case dto.MetricType_GAUGE:
+				value := metric.GetGauge().GetValue()
+				ts.MetricKind, ts.ValueType = "GAUGE", "DOUBLE"
+				ts.Points = []*monitoring.Point{{Interval: sampled, Value: &monitoring.TypedValue{DoubleValue: &value}}}
+			case dto.MetricType_HISTOGRAM:
+				ts.MetricKind, ts.ValueType = "CUMULATIVE", "DISTRIBUTION"
+				if strings.HasSuffix(family.GetName(), "_seconds") {
+					ts.Unit = "s"
+				}
+				ts.Points = []*monitoring.Point{{Interval: cumulative, Value: &monitoring.TypedValue{DistributionValue: distribution(metric.GetHistogram(), config.ProjectID)}}}
+			default:
+				continue
+			}
+			series = append(series, ts)
+		}
+	}
+	return series
+}
+
+// metricLabels returns the Prometheus labels of a metric
+func metricLabels(metric *dto.Metric) map[string]string {
+	if len(metric.GetLabel()) == 0 {
+		return nil
+	}
+	labels := make(map[string]string, len(metric.GetLabel()))
+	for _, pair := range metric.GetLabel() {
+		labels[pair.GetName()] = pair.GetValue()
+	}
+	return labels
+}
+
+// distribution converts a histogram. Prometheus buckets count the observations
+// up to their bound, cumulatively; Cloud Monitoring buckets count those
+// between consecutive bounds, with an overflow bucket past the last one.
+func distribution(histogram *dto.Histogram, projectID string) *monitoring.Distribution {
+	d := &monitoring.Distribution{
+		Count:         int64(histogram.GetSampleCount()),
+		BucketOptions: &monitoring.BucketOptions{ExplicitBuckets: &monitoring.Explicit{}},
+	}
+	if d.Count > 0 {
+		d.Mean = histogram.GetSampleSum() / float64(d.Count)
+	}
+
+	var below int64
+	for _, bucket := range histogram.GetBucket() {
+		if exemplar := traceExemplar(bucket.GetExemplar(), projectID); exemplar != nil {
+			d.Exemplars = append(d.Exemplars, exemplar)
+		}
+		// The +Inf bucket is only present to carry an exemplar
+		if math.IsInf(bucket.GetUpperBound(), 1) {
+			continue
+		}
+		count := int64(bucket.GetCumulativeCount())
+		d.BucketOptions.ExplicitBuckets.Bounds = append(d.BucketOptions.ExplicitBuckets.Bounds, bucket.GetUpperBound())
+		d.BucketCounts = append(d.BucketCounts, count-below)
+		below = count
+	}
+	d.BucketCounts = append(d.BucketCounts, d.Count-below)
+	return d
+}
+
+// traceExemplar converts an exemplar carrying a trace to one linking to Cloud Trace
+func traceExemplar(exemplar *dto.Exemplar, projectID string) *monitoring.Exemplar {
+	if exemplar == nil {
+		return nil
+	}
+	var traceID, spanID string
+	for _, pair := range exemplar.GetLabel() {
+		switch pair.GetName() {
+		case traceIDLabel:
+			traceID = pair.GetValue()
+		case spanIDLabel:
+			spanID = pair.GetValue()
+		}
+	}
+	if traceID == "" || spanID == "" {
+		return nil
+	}
+
+	attachment, err := json.Marshal(map[string]string{
+		"@type":    spanContextType,
+		"spanName": fmt.Sprintf("projects/%s/traces/%s/spans/%s", projectID, traceID, spanID),
+	})
+	if err != nil {
+		return nil
+	}
+	converted := &monitoring.Exemplar{
+		Value:       exemplar.GetValue(),
+		Attachments: []googleapi.RawMessage{attachment},
+	}
+	if exemplar.GetTimestamp() != nil {
+		converted.Timestamp = exemplar.GetTimestamp().AsTime().UTC().Format(time.RFC3339Nano)
+	}
+	return converted
+}
@@ -0,0 +1,143 @@
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	monitoring "google.golang.org/api/monitoring/v3"
+)
+
+func TestSpanFromRequest(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		span   SpanContext
+		ok     bool
+	}{
+		{"traceparent sampled", TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", SpanContext{"4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"}, true},
+		{"traceparent not sampled", TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", SpanContext{}, false},
+		{"traceparent zero trace", TraceparentHeader, "00-00000000000000000000000000000000-00f067aa0ba902b7-01", SpanContext{}, false},
+		{"cloud trace sampled", CloudTraceContextHeader, "105445AA7843BC8BF206B12000100000/1;o=1", SpanContext{"105445aa7843bc8bf206b12000100000", "0000000000000001"}, true},
+		{"cloud trace not sampled", CloudTraceContextHeader, "105445aa7843bc8bf206b12000100000/1;o=0", SpanContext{}, false},
+		{"cloud trace without options", CloudTraceContextHeader, "105445aa7843bc8bf206b12000100000/1", SpanContext{}, false},
+		{"malformed", CloudTraceContextHeader, "not-a-trace", SpanContext{}, false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/ticket/ABC123", nil)
+		req.Header.Set(tt.header, tt.value)
+		span, ok := SpanFromRequest(req)
+		if ok != tt.ok || span != tt.span {
+			t.Errorf("%s: got %+v, %v; want %+v, %v", tt.name, span, ok, tt.span, tt.ok)
+		}
+	}
+}
+
+func TestTimeSeriesLinksLatencyToTraces(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	tracker, err := NewTracker(SLOs, registry)
+	if err != nil {
+		t.Fatalf("Failed to create tracker: %v", err)
+	}
+	operations := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "firestore_document_operations_total", Help: "Operations."}, []string{"endpoint", "operation"})
+	registry.MustRegister(operations)
+	operations.WithLabelValues("GET /ticket/{confirmationID}", "read").Add(3)
+
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	tracker.now = func() time.Time { return now }
+	r := chi.NewRouter()
+	r.Use(tracker.Middleware)
+	r.Get("/ticket/{confirmationID}", func(w http.ResponseWriter, req *http.Request) {
+		now = now.Add(300 * time.Millisecond)
+	})
+
+	traced := httptest.NewRequest(http.MethodGet, "/ticket/ABC123", nil)
+	traced.Header.Set(CloudTraceContextHeader, "105445aa7843bc8bf206b12000100000/255;o=1")
+	r.ServeHTTP(httptest.NewRecorder(), traced)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ticket/XYZ789", nil))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Failed to gather: %v", err)
+	}
+	config := ExportConfig{ProjectID: "demo", Prefix: DefaultExportPrefix, Metrics: DefaultExportMetrics}
+	resource := &monitoring.MonitoredResource{Type: "generic_task"}
+	series := timeSeries(families, config, resource, now.Add(-time.Hour), now)
+
+	byType := make(map[string]*monitoring.TimeSeries)
+	for _, ts := range series {
+		byType[ts.Metric.Type] = ts
+	}
+	if len(series) != 2 {
+		t.Fatalf("Got %d time series, want the latency and operation series only", len(series))
+	}
+
+	ops := byType[DefaultExportPrefix+"/firestore_document_operations_total"]
+	if ops == nil || ops.MetricKind != "CUMULATIVE" || *ops.Points[0].Value.Int64Value != 3 || ops.Metric.Labels["operation"] != "read" {
+		t.Fatalf("Unexpected operation series %+v", ops)
+	}
+	if ops.Points[0].Interval.StartTime != "2024-06-01T11:00:00.6Z" {
+		t.Errorf("Cumulative series start at %s", ops.Points[0].Interval.StartTime)
+	}
+
+	latency := byType[DefaultExportPrefix+"/http_request_duration_seconds"]
+	if latency == nil || latency.ValueType != "DISTRIBUTION" || latency.Unit != "s" {
+		t.Fatalf("Unexpected latency series %+v", latency)
+	}
+	if route := latency.Metric.Labels["route"]; route != "GET /ticket/{confirmationID}" {
+		t.Errorf("Latency labelled with route %q", route)
+	}
+	d := latency.Points[0].Value.DistributionValue
+	if d.Count != 2 || len(d.BucketCounts) != len(prometheus.DefBuckets)+1 {
+		t.Fatalf("Unexpected distribution %+v", d)
+	}
+	// Both requests took 300ms: the (0.25, 0.5] bucket
+	for i, count := range d.BucketCounts {
+		want := int64(0)
+		if i < len(prometheus.DefBuckets) && prometheus.DefBuckets[i] == 0.5 {
+			want = 2
+		}
+		if count != want {
+			t.Errorf("Bucket %d counts %d, want %d", i, count, want)
+		}
+	}
+
+	// Only the sampled request is an exemplar, linking to its span
+	if len(d.Exemplars) != 1 {
+		t.Fatalf("Got %d exemplars, want 1", len(d.Exemplars))
+	}
+	var attachment map[string]string
+	if err := json.Unmarshal(d.Exemplars[0].Attachments[0], &attachment); err != nil {
+		t.Fatalf("Invalid attachment: %v", err)
+	}
+	if attachment["@type"] != spanContextType || attachment["spanName"] != "projects/demo/traces/105445aa7843bc8bf206b12000100000/spans/00000000000000ff" {
+		t.Errorf("Unexpected attachment %v", attachment)
+	}
+}
+
+func TestExportConfigFromEnv(t *testing.T) {
+	t.Setenv("METRICS_EXPORT_ENABLED", "true")
+	t.Setenv("METRICS_EXPORT_INTERVAL", "30s")
+	t.Setenv("METRICS_EXPORT_METRICS", "http_request_duration_seconds, document_cache_requests_total")
+	config, err := ExportConfigFromEnv("demo", "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !config.Enabled || config.Interval != 30*time.Second || config.Location != "global" || strings.Join(config.Metrics, ",") != "http_request_duration_seconds,document_cache_requests_total" {
+		t.Errorf("Unexpected config %+v", config)
+	}
+
+	if _, err := ExportConfigFromEnv("", "us-central1"); err == nil {
+		t.Error("Expected an error without a project")
+	}
+	t.Setenv("METRICS_EXPORT_INTERVAL", "1s")
+	if _, err := ExportConfigFromEnv("demo", "us-central1"); err == nil {
+		t.Error("Expected an error for an interval below the minimum")
+	}
+}
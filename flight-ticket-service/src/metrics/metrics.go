@@ -0,0 +1,257 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"flight-ticket-service/src/auth"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// sloExcludedPrefixes are probes, docs and operator endpoints that don't count towards the SLOs
+var sloExcludedPrefixes = []string{"/health", "/version", "/metrics", "/swagger", "/admin"}
+
+// unmatchedRoute labels requests that match no route, keeping label cardinality bounded
+const unmatchedRoute = "unmatched"
+
+// anonymousClient labels requests without an API key
+const anonymousClient = "anonymous"
+
+// Tracker records in-flight requests per route and per client, and SLO compliance
+type Tracker struct {
+	slos   []SLO
+	counts []*rollingCounts
+	now    func() time.Time
+
+	routeInFlight  *prometheus.GaugeVec
+	clientInFlight *prometheus.GaugeVec
+	requests       *prometheus.CounterVec
+	latency        *prometheus.HistogramVec
+
+	objective       *prometheus.Desc
+	budgetRemaining *prometheus.Desc
+	burnRate        *prometheus.Desc
+}
+
+// NewTracker creates a tracker for the given SLOs and registers its metrics
+func NewTracker(slos []SLO, registerer prometheus.Registerer) (*Tracker, error) {
+	t := &Tracker{
+		slos: slos,
+		now:  time.Now,
+		routeInFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "http_route_requests_in_flight",
+			Help: "Requests currently being served, by method and route pattern.",
+		}, []string{"route"}),
+		clientInFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "http_client_requests_in_flight",
+			Help: "Requests currently being served, by API key name (anonymous without a key).",
+		}, []string{"client"}),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "slo_requests_total",
+			Help: "Requests counted towards each SLO, by result (good, bad).",
+		}, []string{"slo", "result"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Latency of the requests counted towards the SLOs, by method and route pattern. Sampled traces are attached as exemplars.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route"}),
+		objective: prometheus.NewDesc("slo_objective_ratio",
+			"Target fraction of good requests.", []string{"slo"}, nil),
+		budgetRemaining: prometheus.NewDesc("slo_error_budget_remaining_ratio",
+			"Fraction of the error budget left over the SLO window since this instance started; negative once exceeded.", []string{"slo"}, nil),
+		burnRate: prometheus.NewDesc("slo_burn_rate",
+			"Error budget burn rate over the window; 1 spends exactly the budget over the SLO window.", []string{"slo", "window"}, nil),
+	}
+
+	for _, slo := range slos {
+		window := slo.Window
+		for _, alert := range BurnRateAlerts {
+			if alert.LongWindow > window {
+				window = alert.LongWindow
+			}
+		}
+		t.counts = append(t.counts, newRollingCounts(window))
+	}
+
+	for _, collector := range []prometheus.Collector{t.routeInFlight, t.clientInFlight, t.requests, t.latency, t} {
+		if err := registerer.Register(collector); err != nil {
+			return nil, fmt.Errorf("failed to register SLO metrics: %v", err)
+		}
+	}
+	return t, nil
+}
+
+// Middleware tracks concurrency and SLO compliance. It must run after
+// authentication so the client is known.
+func (t *Tracker) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client := anonymousClient
+		if principal, ok := auth.FromContext(r.Context()); ok {
+			client = principal.Name
+		}
+		route := r.Method + " " + routePattern(r)
+		routeGauge := t.routeInFlight.WithLabelValues(route)
+		clientGauge := t.clientInFlight.WithLabelValues(client)
+		routeGauge.Inc()
+		clientGauge.Inc()
+		defer routeGauge.Dec()
+		defer clientGauge.Dec()
+
+		if !countsTowardsSLOs(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := t.now()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			duration := t.now().Sub(start)
+			t.observeLatency(route, r, duration)
+			// A panic becomes a 500 further up the chain
+			if recovered := recover(); recovered != nil {
+				t.Observe(http.StatusInternalServerError, duration)
+				panic(recovered)
+			}
+			t.Observe(sw.status, duration)
+		}()
+		next.ServeHTTP(sw, r)
+	})
+}
+
+// Observe counts a request towards every SLO
+func (t *Tracker) Observe(status int, duration time.Duration) {
+	now := t.now()
+	for i, slo := range t.slos {
+		good := slo.Good(status, duration)
+		t.counts[i].add(now, good)
+		result := "good"
+		if !good {
+			result = "bad"
+		}
+		t.requests.WithLabelValues(slo.Name, result).Inc()
+	}
+}
+
+// observeLatency records a request's latency, with its trace as the exemplar
+// when the trace is sampled, so that slow buckets link to example traces
+func (t *Tracker) observeLatency(route string, r *http.Request, duration time.Duration) {
+	observer := t.latency.WithLabelValues(route)
+	span, ok := SpanFromRequest(r)
+	if !ok {
+		observer.Observe(duration.Seconds())
+		return
+	}
+	observer.(prometheus.ExemplarObserver).ObserveWithExemplar(duration.Seconds(), prometheus.Labels{
+		traceIDLabel: span.TraceID,
+		spanIDLabel:  span.SpanID,
+	})
+}
+
+// Status is the error budget state of an SLO
+type Status struct {
+	SLO             string             `json:"slo"`
+	Objective       float64            `json:"objective"`
+	BudgetRemaining float64            `json:"budget_remaining"`
+	BurnRates       map[string]float64 `json:"burn_rates"` // by window, e.g. "1h"
+}
+
+// Statuses computes the rolling error budget and burn rates of every SLO
+func (t *Tracker) Statuses() []Status {
+	now := t.now()
+	statuses := make([]Status, 0, len(t.slos))
+	for i, slo := range t.slos {
+		good, bad := t.counts[i].sum(now, slo.Window)
+		status := Status{
+			SLO:             slo.Name,
+			Objective:       slo.Objective,
+			BudgetRemaining: budgetRemaining(slo, good, bad),
+			BurnRates:       make(map[string]float64),
+		}
+		for _, window := range burnRateWindows() {
+			good, bad := t.counts[i].sum(now, window)
+			status.BurnRates[FormatWindow(window)] = burnRate(slo, good, bad)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// Describe implements prometheus.Collector for the computed SLO gauges
+func (t *Tracker) Describe(ch chan<- *prometheus.Desc) {
+	ch <- t.objective
+	ch <- t.budgetRemaining
+	ch <- t.burnRate
+}
+
+// Collect implements prometheus.Collector, computing budgets at scrape time
+func (t *Tracker) Collect(ch chan<- prometheus.Metric) {
+	for _, status := range t.Statuses() {
+		ch <- prometheus.MustNewConstMetric(t.objective, prometheus.GaugeValue, status.Objective, status.SLO)
+		ch <- prometheus.MustNewConstMetric(t.budgetRemaining, prometheus.GaugeValue, status.BudgetRemaining, status.SLO)
+		for window, rate := range status.BurnRates {
+			ch <- prometheus.MustNewConstMetric(t.burnRate, prometheus.GaugeValue, rate, status.SLO, window)
+		}
+	}
+}
+
+// burnRateWindows returns the distinct windows of BurnRateAlerts
+func burnRateWindows() []time.Duration {
+	var windows []time.Duration
+	seen := make(map[time.Duration]bool)
+	for _, alert := range BurnRateAlerts {
+		for _, window := range []time.Duration{alert.ShortWindow, alert.LongWindow} {
+			if !seen[window] {
+				seen[window] = true
+				windows = append(windows, window)
+			}
+		}
+	}
+	return windows
+}
+
+// FormatWindow formats a window as whole hours or minutes, e.g. "6h" or "30m"
+func FormatWindow(window time.Duration) string {
+	if window%time.Hour == 0 {
+		return fmt.Sprintf("%dh", window/time.Hour)
+	}
+	return fmt.Sprintf("%dm", window/time.Minute)
+}
+
+// routePattern finds the chi route pattern before routing has happened
+func routePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.Routes == nil {
+		return unmatchedRoute
+	}
+	if pattern := rctx.Routes.Find(chi.NewRouteContext(), r.Method, r.URL.Path); pattern != "" {
+		return pattern
+	}
+	return unmatchedRoute
+}
+
+func countsTowardsSLOs(r *http.Request) bool {
+	if r.Method == http.MethodOptions {
+		return false
+	}
+	for _, prefix := range sloExcludedPrefixes {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// statusWriter captures the response status
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,132 @@
+package metrics
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/auth"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestStatusesRollingBudget(t *testing.T) {
+	slo := SLO{Name: "availability", Objective: 0.99, Window: 24 * time.Hour}
+	tracker, err := NewTracker([]SLO{slo}, prometheus.NewRegistry())
+	if err != nil {
+		t.Fatalf("Failed to create tracker: %v", err)
+	}
+
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	tracker.now = func() time.Time { return now }
+
+	// Two hours ago: 100 requests, 1 failed
+	now = now.Add(-2 * time.Hour)
+	for i := 0; i < 99; i++ {
+		tracker.Observe(http.StatusOK, time.Millisecond)
+	}
+	tracker.Observe(http.StatusInternalServerError, time.Millisecond)
+
+	// Now: 10 requests, 1 failed
+	now = now.Add(2 * time.Hour)
+	for i := 0; i < 9; i++ {
+		tracker.Observe(http.StatusNotFound, time.Millisecond)
+	}
+	tracker.Observe(http.StatusServiceUnavailable, time.Millisecond)
+
+	status := tracker.Statuses()[0]
+	// 2 bad of 110 over the day against a 1% budget
+	if want := 1 - (2.0/110)/0.01; math.Abs(status.BudgetRemaining-want) > 1e-9 {
+		t.Errorf("Budget remaining %v, want %v", status.BudgetRemaining, want)
+	}
+	// The last hour only has the recent requests: 10% errors burn 10x
+	if rate := status.BurnRates["1h"]; math.Abs(rate-10) > 1e-9 {
+		t.Errorf("1h burn rate %v, want 10", rate)
+	}
+	if rate := status.BurnRates["6h"]; math.Abs(rate-(2.0/110)/0.01) > 1e-9 {
+		t.Errorf("6h burn rate %v", rate)
+	}
+
+	// Everything ages out of the window
+	now = now.Add(25 * time.Hour)
+	if status := tracker.Statuses()[0]; status.BudgetRemaining != 1 || status.BurnRates["1h"] != 0 {
+		t.Errorf("Expected a full budget without traffic, got %+v", status)
+	}
+}
+
+func TestLatencySLOGood(t *testing.T) {
+	slo := SLO{Objective: 0.99, LatencyThreshold: 500 * time.Millisecond}
+	tests := []struct {
+		status   int
+		duration time.Duration
+		good     bool
+	}{
+		{http.StatusOK, 100 * time.Millisecond, true},
+		{http.StatusBadRequest, 500 * time.Millisecond, true},
+		{http.StatusOK, 501 * time.Millisecond, false},
+		{http.StatusBadGateway, time.Millisecond, false},
+	}
+	for _, tt := range tests {
+		if got := slo.Good(tt.status, tt.duration); got != tt.good {
+			t.Errorf("Good(%d, %v) = %v, want %v", tt.status, tt.duration, got, tt.good)
+		}
+	}
+}
+
+func TestMiddlewareLabelsRouteAndClient(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	tracker, err := NewTracker(SLOs, registry)
+	if err != nil {
+		t.Fatalf("Failed to create tracker: %v", err)
+	}
+
+	var routeInFlight, clientInFlight float64
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.Header.Get("X-Client") != "" {
+				req = req.WithContext(auth.WithPrincipal(req.Context(), auth.Principal{Name: req.Header.Get("X-Client"), Role: auth.RoleAgent}))
+			}
+			next.ServeHTTP(w, req)
+		})
+	})
+	r.Use(tracker.Middleware)
+	r.Route("/ticket", func(r chi.Router) {
+		r.Get("/{confirmationID}", func(w http.ResponseWriter, req *http.Request) {
+			routeInFlight = testutil.ToFloat64(tracker.routeInFlight.WithLabelValues("GET /ticket/{confirmationID}"))
+			clientInFlight = testutil.ToFloat64(tracker.clientInFlight.WithLabelValues("web"))
+			w.WriteHeader(http.StatusBadGateway)
+		})
+	})
+	r.Get("/health", func(w http.ResponseWriter, req *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/ticket/ABC123", nil)
+	req.Header.Set("X-Client", "web")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nope", nil))
+
+	if routeInFlight != 1 || clientInFlight != 1 {
+		t.Errorf("In flight during the request: route %v, client %v", routeInFlight, clientInFlight)
+	}
+	if n := testutil.ToFloat64(tracker.routeInFlight.WithLabelValues("GET /ticket/{confirmationID}")); n != 0 {
+		t.Errorf("Route gauge not released: %v", n)
+	}
+	if n := testutil.ToFloat64(tracker.routeInFlight.WithLabelValues("GET unmatched")); n != 0 {
+		t.Errorf("Unmatched gauge not released: %v", n)
+	}
+	// /health is excluded; the 502 and the 404 count
+	if bad := testutil.ToFloat64(tracker.requests.WithLabelValues("availability", "bad")); bad != 1 {
+		t.Errorf("Bad availability requests %v, want 1", bad)
+	}
+	if good := testutil.ToFloat64(tracker.requests.WithLabelValues("availability", "good")); good != 1 {
+		t.Errorf("Good availability requests %v, want 1", good)
+	}
+	if n := testutil.CollectAndCount(registry, "slo_burn_rate"); n != len(SLOs)*len(burnRateWindows()) {
+		t.Errorf("Got %d burn rate series", n)
+	}
+}
@@ -0,0 +1,136 @@
+// Package metrics exposes request concurrency and SLO error budget metrics.
+//
+// The service level objectives are defined here as code. The server uses them
+// to compute rolling error budgets and burn rates on /metrics, and
+// `mage slo:alerts` creates the matching Cloud Monitoring SLOs and burn rate
+// alert policies. Both use the same definitions.
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+// SLO is a service level objective over a rolling window. Requests answered
+// with a 5xx are bad; for latency SLOs, so are requests slower than
+// LatencyThreshold.
+type SLO struct {
+	Name             string
+	Description      string
+	Objective        float64       // target fraction of good requests, e.g. 0.995
+	Window           time.Duration // rolling compliance period
+	LatencyThreshold time.Duration // zero for availability SLOs
+}
+
+// SLOs are the service level objectives of the API
+var SLOs = []SLO{
+	{
+		Name:        "availability",
+		Description: "99.5% of API requests succeed (no 5xx) over 30 days",
+		Objective:   0.995,
+		Window:      30 * 24 * time.Hour,
+	},
+	{
+		Name:             "latency",
+		Description:      "99% of API requests complete within 500ms over 30 days",
+		Objective:        0.99,
+		Window:           30 * 24 * time.Hour,
+		LatencyThreshold: 500 * time.Millisecond,
+	},
+}
+
+// BurnRateAlert fires when the error budget burns faster than Threshold over
+// both the long and the short window (multiwindow, multi-burn-rate alerting).
+type BurnRateAlert struct {
+	Name        string
+	LongWindow  time.Duration
+	ShortWindow time.Duration
+	Threshold   float64
+	Severity    string // "page" or "ticket"
+}
+
+// BurnRateAlerts spend 2%, 5% and 10% of a 30-day budget before firing
+var BurnRateAlerts = []BurnRateAlert{
+	{Name: "fast", LongWindow: time.Hour, ShortWindow: 5 * time.Minute, Threshold: 14.4, Severity: "page"},
+	{Name: "medium", LongWindow: 6 * time.Hour, ShortWindow: 30 * time.Minute, Threshold: 6, Severity: "page"},
+	{Name: "slow", LongWindow: 72 * time.Hour, ShortWindow: 6 * time.Hour, Threshold: 1, Severity: "ticket"},
+}
+
+// Good reports whether a request meets the objective
+func (s SLO) Good(status int, duration time.Duration) bool {
+	if status >= 500 {
+		return false
+	}
+	return s.LatencyThreshold == 0 || duration <= s.LatencyThreshold
+}
+
+// ErrorBudget is the allowed fraction of bad requests
+func (s SLO) ErrorBudget() float64 {
+	return 1 - s.Objective
+}
+
+// bucketWidth is the resolution of the rolling windows
+const bucketWidth = time.Minute
+
+type bucket struct {
+	start     int64 // unix minute
+	good, bad int64
+}
+
+// rollingCounts counts good and bad requests in one-minute buckets
+type rollingCounts struct {
+	mu      sync.Mutex
+	buckets []bucket
+}
+
+func newRollingCounts(window time.Duration) *rollingCounts {
+	return &rollingCounts{buckets: make([]bucket, int(window/bucketWidth)+1)}
+}
+
+func (c *rollingCounts) add(now time.Time, good bool) {
+	minute := now.Unix() / int64(bucketWidth/time.Second)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	b := &c.buckets[minute%int64(len(c.buckets))]
+	if b.start != minute {
+		*b = bucket{start: minute}
+	}
+	if good {
+		b.good++
+	} else {
+		b.bad++
+	}
+}
+
+// sum returns the counts of the buckets within window of now
+func (c *rollingCounts) sum(now time.Time, window time.Duration) (good, bad int64) {
+	minute := now.Unix() / int64(bucketWidth/time.Second)
+	oldest := minute - int64(window/bucketWidth) + 1
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, b := range c.buckets {
+		if b.start >= oldest && b.start <= minute {
+			good += b.good
+			bad += b.bad
+		}
+	}
+	return good, bad
+}
+
+// burnRate is how fast the error budget is being spent: 1 uses exactly the
+// budget over the SLO window. It is 0 without traffic.
+func burnRate(slo SLO, good, bad int64) float64 {
+	if good+bad == 0 || slo.ErrorBudget() <= 0 {
+		return 0
+	}
+	return float64(bad) / float64(good+bad) / slo.ErrorBudget()
+}
+
+// budgetRemaining is the fraction of the error budget left, negative once exceeded
+func budgetRemaining(slo SLO, good, bad int64) float64 {
+	return 1 - burnRate(slo, good, bad)
+}
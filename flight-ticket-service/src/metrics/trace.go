@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Exemplar labels identifying the trace of a request
+const (
+	traceIDLabel = "trace_id"
+	spanIDLabel  = "span_id"
+)
+
+// Trace context headers. Cloud Run samples incoming requests for Cloud Trace
+// and passes the trace on in both.
+const (
+	TraceparentHeader       = "traceparent"
+	CloudTraceContextHeader = "X-Cloud-Trace-Context"
+)
+
+// SpanContext identifies the span of a request in Cloud Trace
+type SpanContext struct {
+	TraceID string // 32 hex digits
+	SpanID  string // 16 hex digits
+}
+
+var (
+	// version-traceid-parentid-flags, e.g. 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+	traceparentPattern = regexp.MustCompile(`^[0-9a-f]{2}-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})$`)
+	// TRACE_ID/SPAN_ID;o=OPTIONS with a decimal span ID
+	cloudTracePattern = regexp.MustCompile(`^([0-9a-fA-F]{32})/([0-9]+)(?:;o=([0-9]+))?$`)
+)
+
+// SpanFromRequest returns the span of a request whose trace is sampled, from
+// the W3C traceparent header or else X-Cloud-Trace-Context. Unsampled traces
+// are not recorded, so they are not returned.
+func SpanFromRequest(r *http.Request) (SpanContext, bool) {
+	span, sampled, ok := requestSpan(r)
+	if !ok || !sampled {
+		return SpanContext{}, false
+	}
+	return span, true
+}
+
+// ForceSampling marks the trace of a request as sampled in both trace headers,
+// starting a new trace when the request has none, and returns its span
+func ForceSampling(r *http.Request) SpanContext {
+	span, _, ok := requestSpan(r)
+	if !ok {
+		traceID, spanID := make([]byte, 16), make([]byte, 8)
+		rand.Read(traceID)
+		rand.Read(spanID)
+		spanID[0] |= 1 // span IDs must not be zero
+		span = SpanContext{TraceID: hex.EncodeToString(traceID), SpanID: hex.EncodeToString(spanID)}
+	}
+	decimalSpanID, _ := strconv.ParseUint(span.SpanID, 16, 64)
+	r.Header.Set(TraceparentHeader, fmt.Sprintf("00-%s-%s-01", span.TraceID, span.SpanID))
+	r.Header.Set(CloudTraceContextHeader, fmt.Sprintf("%s/%d;o=1", span.TraceID, decimalSpanID))
+	return span
+}
+
+// requestSpan returns the span of a request and whether its trace is sampled
+func requestSpan(r *http.Request) (span SpanContext, sampled, ok bool) {
+	if header := strings.TrimSpace(r.Header.Get(TraceparentHeader)); header != "" {
+		return parseTraceparent(header)
+	}
+	if header := strings.TrimSpace(r.Header.Get(CloudTraceContextHeader)); header != "" {
+		return parseCloudTraceContext(header)
+	}
+	return SpanContext{}, false, false
+}
+
+func parseTraceparent(header string) (SpanContext, bool, bool) {
+	match := traceparentPattern.FindStringSubmatch(header)
+	if match == nil || match[1] == strings.Repeat("0", 32) || match[2] == strings.Repeat("0", 16) {
+		return SpanContext{}, false, false
+	}
+	flags, _ := strconv.ParseUint(match[3], 16, 8)
+	return SpanContext{TraceID: match[1], SpanID: match[2]}, flags&1 == 1, true
+}
+
+func parseCloudTraceContext(header string) (SpanContext, bool, bool) {
+	match := cloudTracePattern.FindStringSubmatch(header)
+	if match == nil {
+		return SpanContext{}, false, false
+	}
+	spanID, err := strconv.ParseUint(match[2], 10, 64)
+	if err != nil || spanID == 0 {
+		return SpanContext{}, false, false
+	}
+	return SpanContext{TraceID: strings.ToLower(match[1]), SpanID: fmt.Sprintf("%016x", spanID)}, match[3] == "1", true
+}
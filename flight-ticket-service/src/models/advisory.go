@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+// Advisory severity levels
+const (
+	SeverityLow      = "LOW"
+	SeverityModerate = "MODERATE"
+	SeverityHigh     = "HIGH"
+)
+
+// Travel advisory types
+const (
+	AdvisoryDocuments   = "DOCUMENTS"
+	AdvisoryVisa        = "VISA"
+	AdvisoryArrivalForm = "ARRIVAL_FORM"
+)
+
+// TravelAdvisory is a requirement of the destination country of an international flight
+// @Description Entry requirement of the destination country
+type TravelAdvisory struct {
+	Type    string `json:"type" example:"ARRIVAL_FORM" enums:"DOCUMENTS,VISA,ARRIVAL_FORM" description:"Advisory type"`
+	Country string `json:"country" example:"SG" description:"ISO 3166-1 alpha-2 code of the destination country"`
+	Message string `json:"message" example:"Complete the SG Arrival Card before arriving in SG" description:"What travelers need to do"`
+}
+
+// WeatherConditions represents the weather observed or forecast at an airport
+// @Description Weather conditions at an airport
+type WeatherConditions struct {
+	Time            time.Time `json:"time" example:"2024-12-25T14:00:00Z" description:"Time the conditions apply to"`
+	TemperatureC    float64   `json:"temperature_c" example:"3.5" description:"Air temperature in degrees Celsius"`
+	PrecipitationMM float64   `json:"precipitation_mm" example:"0.4" description:"Precipitation in millimetres for the hour"`
+	WindSpeedKPH    float64   `json:"wind_speed_kph" example:"22.3" description:"Sustained wind speed in km/h"`
+	WindGustsKPH    float64   `json:"wind_gusts_kph" example:"41.0" description:"Wind gusts in km/h"`
+	VisibilityM     float64   `json:"visibility_m,omitempty" example:"9000" description:"Visibility in metres (when reported)"`
+	WeatherCode     int       `json:"weather_code" example:"61" description:"WMO weather interpretation code"`
+	Summary         string    `json:"summary" example:"Slight rain" description:"Human readable weather summary"`
+}
+
+// AirportWeather holds current and forecast conditions for a single airport
+// @Description Current and forecast weather for an airport
+type AirportWeather struct {
+	Airport  string             `json:"airport" example:"JFK" description:"3-letter IATA airport code"`
+	Current  *WeatherConditions `json:"current,omitempty" description:"Current conditions"`
+	Forecast *WeatherConditions `json:"forecast,omitempty" description:"Forecast conditions at departure time (omitted when outside the forecast window)"`
+	Error    string             `json:"error,omitempty" example:"Unknown airport location" description:"Reason weather could not be retrieved"`
+}
+
+// WeatherAdvisory describes a single weather condition likely to cause disruption
+// @Description Weather advisory for an airport
+type WeatherAdvisory struct {
+	Airport  string `json:"airport" example:"JFK" description:"3-letter IATA airport code"`
+	Type     string `json:"type" example:"HIGH_WINDS" enums:"HIGH_WINDS,LOW_VISIBILITY,THUNDERSTORM,SNOW,FREEZING_RAIN,HEAVY_RAIN" description:"Advisory type"`
+	Severity string `json:"severity" example:"MODERATE" enums:"LOW,MODERATE,HIGH" description:"Advisory severity"`
+	Message  string `json:"message" example:"Wind gusts of 65 km/h forecast at departure" description:"Advisory details"`
+}
+
+// TicketAdvisoriesResponse represents the response for ticket weather advisories
+// @Description Weather advisories for a ticket's origin and destination airports
+type TicketAdvisoriesResponse struct {
+	ConfirmationID   string            `json:"confirmation_id" example:"ABC123" description:"Ticket confirmation ID"`
+	DepartureTime    time.Time         `json:"departure_time" example:"2024-12-25T14:30:00Z" description:"Departure time used for the forecast"`
+	Origin           AirportWeather    `json:"origin" description:"Origin airport weather"`
+	Destination      AirportWeather    `json:"destination" description:"Destination airport weather"`
+	Advisories       []WeatherAdvisory `json:"advisories" description:"Weather advisories likely to affect the flight"`
+	DisruptionLikely bool              `json:"disruption_likely" example:"false" description:"True when any advisory has HIGH severity"`
+	Provider         string            `json:"provider" example:"open-meteo" description:"Weather data provider"`
+}
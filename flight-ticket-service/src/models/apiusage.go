@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// EndpointRequests is the number of requests an API key made to one endpoint
+// @Description Requests to an endpoint
+type EndpointRequests struct {
+	Endpoint string `json:"endpoint" example:"GET /tickets" description:"HTTP method and route pattern"`
+	Requests int64  `json:"requests" example:"1200" description:"Requests in the month"`
+}
+
+// APIKeyUsage is the traffic of one API key in a month
+// @Description Requests, errors and top endpoints of an API key
+type APIKeyUsage struct {
+	Key          string             `json:"key" example:"partner-acme" description:"API key name, or anonymous"`
+	Requests     int64              `json:"requests" example:"1520" description:"Requests in the month"`
+	ClientErrors int64              `json:"client_errors" example:"31" description:"Responses with a 4xx status"`
+	ServerErrors int64              `json:"server_errors" example:"2" description:"Responses with a 5xx status"`
+	ErrorRate    float64            `json:"error_rate" example:"0.0217" description:"Share of 4xx and 5xx responses, from 0 to 1"`
+	Endpoints    int                `json:"endpoints" example:"9" description:"Distinct endpoints called"`
+	TopEndpoints []EndpointRequests `json:"top_endpoints" description:"Most requested endpoints, most requests first"`
+}
+
+// APIUsageReport is the response for GET /admin/usage
+// @Description API usage per key in a month
+type APIUsageReport struct {
+	Month       string        `json:"month" example:"2026-09" description:"Month reported (YYYY-MM, UTC)"`
+	GeneratedAt time.Time     `json:"generated_at" example:"2026-10-01T06:00:00Z" description:"When the report was generated"`
+	Requests    int64         `json:"requests" example:"48211" description:"Requests of every key"`
+	Keys        []APIKeyUsage `json:"keys" description:"Usage per key, most requests first"`
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaxAttachmentSize is the largest document accepted by signed upload URLs (10 MiB)
+const MaxAttachmentSize = 10 << 20
+
+// AllowedAttachmentTypes lists the content types that can be attached to a ticket
+var AllowedAttachmentTypes = map[string]bool{
+	"application/pdf": true,
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/heic":      true,
+}
+
+// Attachment represents a document (visa scan, receipt, ...) attached to a ticket
+// @Description Document attached to a flight ticket
+type Attachment struct {
+	ID             string    `json:"id" firestore:"id" example:"3f9c2a7e41b8d05c" description:"Attachment ID"`
+	ConfirmationID string    `json:"confirmation_id" firestore:"confirmation_id" example:"ABC123" description:"Ticket confirmation ID"`
+	FileName       string    `json:"file_name" firestore:"file_name" example:"visa.pdf" description:"Original file name"`
+	ContentType    string    `json:"content_type" firestore:"content_type" example:"application/pdf" description:"MIME type of the document"`
+	Description    string    `json:"description,omitempty" firestore:"description,omitempty" example:"US visa scan" description:"Optional description"`
+	ObjectName     string    `json:"-" firestore:"object_name"`
+	CreatedAt      time.Time `json:"created_at" firestore:"created_at" example:"2024-07-12T19:00:00Z" description:"Attachment creation timestamp"`
+}
+
+// CreateAttachmentRequest represents the request payload for attaching a document
+// @Description Request payload for attaching a document to a ticket
+type CreateAttachmentRequest struct {
+	FileName    string `json:"file_name" example:"visa.pdf" description:"File name of the document" validate:"required"`
+	ContentType string `json:"content_type" example:"application/pdf" enums:"application/pdf,image/jpeg,image/png,image/heic" description:"MIME type of the document" validate:"required"`
+	Description string `json:"description,omitempty" example:"US visa scan" description:"Optional description"`
+}
+
+// Validate checks the file name and content type of an attachment request
+func (r *CreateAttachmentRequest) Validate() error {
+	if strings.TrimSpace(r.FileName) == "" {
+		return fmt.Errorf("file_name is required")
+	}
+	if len(r.FileName) > 255 {
+		return fmt.Errorf("file_name must be at most 255 characters")
+	}
+	if !AllowedAttachmentTypes[r.ContentType] {
+		return fmt.Errorf("unsupported content_type %q: use application/pdf, image/jpeg, image/png or image/heic", r.ContentType)
+	}
+	return nil
+}
+
+// AttachmentUploadResponse is returned when an attachment is created
+// @Description Attachment metadata with a signed upload URL
+type AttachmentUploadResponse struct {
+	Attachment    *Attachment       `json:"attachment" description:"Attachment metadata"`
+	UploadURL     string            `json:"upload_url" example:"https://storage.googleapis.com/bucket/tickets/ABC123/attachments/3f9c2a7e41b8d05c/visa.pdf?X-Goog-Signature=..." description:"Signed URL to PUT the document to"`
+	UploadMethod  string            `json:"upload_method" example:"PUT" description:"HTTP method to use for the upload"`
+	UploadHeaders map[string]string `json:"upload_headers" description:"Headers that must be sent with the upload"`
+	ExpiresAt     time.Time         `json:"expires_at" example:"2024-07-12T19:15:00Z" description:"Upload URL expiry"`
+}
+
+// AttachmentDownload is an attachment with a signed download URL
+// @Description Attachment metadata with a signed download URL
+type AttachmentDownload struct {
+	Attachment
+	DownloadURL string    `json:"download_url" example:"https://storage.googleapis.com/bucket/tickets/ABC123/attachments/3f9c2a7e41b8d05c/visa.pdf?X-Goog-Signature=..." description:"Signed URL to download the document"`
+	ExpiresAt   time.Time `json:"expires_at" example:"2024-07-12T19:15:00Z" description:"Download URL expiry"`
+}
+
+// AttachmentListResponse represents the response for listing a ticket's attachments
+// @Description Attachments of a ticket
+type AttachmentListResponse struct {
+	Attachments []AttachmentDownload `json:"attachments" description:"Attachments with signed download URLs"`
+	Count       int                  `json:"count" example:"2" description:"Number of attachments"`
+}
@@ -0,0 +1,25 @@
+package models
+
+// RouteBookings is the number of bookings of one route on a day
+// @Description Bookings of a route
+type RouteBookings struct {
+	Route    string `json:"route" example:"JFK-LAX" description:"Origin and destination IATA codes"`
+	Bookings int64  `json:"bookings" example:"12" description:"Tickets booked"`
+}
+
+// DailyBookings is the number of bookings made on one day, overall and per route
+// @Description Bookings made on a day
+type DailyBookings struct {
+	Date     string          `json:"date" example:"2024-07-12" description:"Booking date (UTC)"`
+	Bookings int64           `json:"bookings" example:"40" description:"Tickets booked on the day"`
+	Routes   []RouteBookings `json:"routes" description:"Bookings per route, most booked first"`
+}
+
+// BookingStats is the response for GET /admin/stats/bookings
+// @Description Booking counters
+type BookingStats struct {
+	TotalBookings int64           `json:"total_bookings" example:"1520" description:"Tickets booked since the counters were introduced"`
+	From          string          `json:"from" example:"2024-07-06" description:"First day of the range"`
+	To            string          `json:"to" example:"2024-07-12" description:"Last day of the range"`
+	Days          []DailyBookings `json:"days" description:"Bookings per day, oldest first"`
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// BulkCancelFilter selects the tickets of a bulk cancellation
+// @Description Tickets to cancel; a flight number or departure date is required
+type BulkCancelFilter struct {
+	FlightNumber  string `json:"flight_number,omitempty" example:"AA1234" description:"Flight number"`
+	DepartureDate string `json:"departure_date,omitempty" example:"2024-12-25" description:"Scheduled departure date: YYYY-MM-DD, today or tomorrow (UTC)"`
+	Status        string `json:"status,omitempty" example:"CONFIRMED" enums:"CONFIRMED,PENDING,CHECKED_IN,DISRUPTED,REVIEW" description:"Only cancel tickets in this status"`
+}
+
+// Query validates the filter and returns its ticket query. Relative
+// departure dates are resolved against now.
+func (f BulkCancelFilter) Query(now time.Time) (TicketQuery, error) {
+	query := TicketQuery{
+		FlightNumber: strings.ToUpper(strings.TrimSpace(f.FlightNumber)),
+		Status:       strings.ToUpper(strings.TrimSpace(f.Status)),
+	}
+	if f.DepartureDate != "" {
+		date, err := ParseDepartureDate(strings.TrimSpace(f.DepartureDate), now)
+		if err != nil {
+			return query, err
+		}
+		query.DepartureDate = date
+	}
+
+	switch query.Status {
+	case "", "CONFIRMED", "PENDING", "CHECKED_IN", DisruptedStatus, ReviewStatus:
+	default:
+		return query, fmt.Errorf("status must be CONFIRMED, PENDING, CHECKED_IN, DISRUPTED or REVIEW")
+	}
+	if query.FlightNumber == "" && query.DepartureDate.IsZero() {
+		return query, fmt.Errorf("flight_number or departure_date is required")
+	}
+	return query, nil
+}
+
+// BulkCancelFilterOf returns the filter of a query, with the departure date as YYYY-MM-DD
+func BulkCancelFilterOf(query TicketQuery) BulkCancelFilter {
+	resolved := BulkCancelFilter{FlightNumber: query.FlightNumber, Status: query.Status}
+	if !query.DepartureDate.IsZero() {
+		resolved.DepartureDate = query.DepartureDate.Format("2006-01-02")
+	}
+	return resolved
+}
+
+// BulkCancelRequest represents the request payload for cancelling tickets in bulk
+// @Description Bulk cancellation request. Send it with dry_run first, then again with dry_run false and the preview_token of the preview.
+type BulkCancelRequest struct {
+	BulkCancelFilter
+	DryRun       *bool  `json:"dry_run,omitempty" example:"true" description:"Preview the tickets without cancelling them (default true)"`
+	PreviewToken string `json:"preview_token,omitempty" example:"9c1e0b4f7a2d36e8" description:"Token of the dry run being confirmed; required when dry_run is false"`
+	CallbackURL  string `json:"callback_url,omitempty" example:"https://example.com/hooks/jobs" description:"URL to POST the finished job to"`
+}
+
+// Validate checks that a confirmed run carries the token of its dry run
+func (r *BulkCancelRequest) Validate() error {
+	if !r.IsDryRun() && r.PreviewToken == "" {
+		return fmt.Errorf("preview_token is required when dry_run is false; send the request as a dry run first")
+	}
+	return validateCallbackURL(r.CallbackURL)
+}
+
+// IsDryRun reports whether the request only previews the cancellation
+func (r *BulkCancelRequest) IsDryRun() bool {
+	return r.DryRun == nil || *r.DryRun
+}
+
+// BulkCancelPreview lists the tickets a bulk cancellation would cancel
+// @Description Dry run of a bulk cancellation
+type BulkCancelPreview struct {
+	DryRun       bool               `json:"dry_run" example:"true" description:"Always true: nothing was cancelled"`
+	Filter       BulkCancelFilter   `json:"filter" description:"Filter with the departure date resolved"`
+	Matched      int                `json:"matched" example:"2" description:"Tickets that would be cancelled"`
+	Passengers   int                `json:"passengers" example:"3" description:"Passengers on those tickets"`
+	Tickets      []BulkCancelTicket `json:"tickets" description:"Tickets that would be cancelled"`
+	PreviewToken string             `json:"preview_token" example:"9c1e0b4f7a2d36e8" description:"Send with dry_run false to cancel exactly these tickets"`
+}
+
+// BulkCancelTicket is a ticket listed in a bulk cancellation preview
+// @Description Ticket affected by a bulk cancellation
+type BulkCancelTicket struct {
+	ConfirmationID string    `json:"confirmation_id" example:"ABC123" description:"Ticket confirmation ID"`
+	FlightNumber   string    `json:"flight_number" example:"AA1234" description:"Flight number"`
+	DepartureDate  time.Time `json:"departure_date" example:"2024-12-25T14:30:00Z" description:"Departure date"`
+	Status         string    `json:"status" example:"CONFIRMED" description:"Current status"`
+	Passengers     int       `json:"passengers" example:"2" description:"Passengers on the ticket"`
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CheckInPassenger identifies a passenger checking in on a ticket
+// @Description Passenger checking in
+type CheckInPassenger struct {
+	FirstName string `json:"first_name" example:"John" description:"Passenger given name"`
+	LastName  string `json:"last_name" example:"Doe" description:"Passenger surname" validate:"required"`
+	Seat      string `json:"seat,omitempty" example:"12A" description:"Assigned seat (row number and letter)"`
+}
+
+// CheckInRequest represents the request payload for checking in
+// @Description Request payload for checking in passengers on a ticket
+type CheckInRequest struct {
+	Passengers  []CheckInPassenger `json:"passengers" description:"Passengers to check in (at most the number on the ticket)" validate:"required"`
+	Compartment string             `json:"compartment,omitempty" example:"Y" description:"Cabin compartment code (defaults to the ticket's cabin, Y unless upgraded)"`
+}
+
+// Validate checks the passengers of a check-in request against a ticket
+func (r *CheckInRequest) Validate(ticket *FlightTicket) error {
+	if len(r.Passengers) == 0 {
+		return fmt.Errorf("at least one passenger is required")
+	}
+	if len(r.Passengers) > ticket.Passengers {
+		return fmt.Errorf("ticket has %d passengers, got %d", ticket.Passengers, len(r.Passengers))
+	}
+
+	seats := make(map[string]bool)
+	for i, passenger := range r.Passengers {
+		if strings.TrimSpace(passenger.LastName) == "" {
+			return fmt.Errorf("passengers[%d].last_name is required", i)
+		}
+		if passenger.Seat == "" {
+			continue
+		}
+		seat := strings.ToUpper(passenger.Seat)
+		if seats[seat] {
+			return fmt.Errorf("seat %s is assigned to more than one passenger", seat)
+		}
+		seats[seat] = true
+	}
+	return nil
+}
+
+// BoardingPass is a checked-in passenger's boarding pass
+// @Description Boarding pass with IATA BCBP payload
+type BoardingPass struct {
+	PassengerName  string `json:"passenger_name" example:"DOE/JOHN" description:"Passenger name as printed on the boarding pass"`
+	Seat           string `json:"seat,omitempty" example:"12A" description:"Assigned seat"`
+	SequenceNumber int    `json:"sequence_number" example:"1" description:"Check-in sequence number"`
+	BCBP           string `json:"bcbp" example:"M1DOE/JOHN            EABC123 JFKLAXAA 1234 360Y012A0001 100" description:"IATA Bar Coded Boarding Pass (Resolution 792) payload"`
+	QRURL          string `json:"qr_url" example:"/ticket/ABC123/qr?payload=bcbp&sequence=1" description:"QR code image encoding the BCBP payload"`
+}
+
+// CheckInRecord is the stored result of a ticket's latest check-in
+// @Description Checked-in passengers of a ticket
+type CheckInRecord struct {
+	Compartment string               `json:"compartment" example:"Y" description:"Cabin compartment code"`
+	Passengers  []CheckedInPassenger `json:"passengers" description:"Checked-in passengers, in sequence order"`
+	CheckedInAt time.Time            `json:"checked_in_at" example:"2024-12-24T15:00:00Z" description:"Check-in timestamp"`
+}
+
+// CheckedInPassenger is a passenger listed on a check-in record
+// @Description Checked-in passenger
+type CheckedInPassenger struct {
+	PassengerName  string `json:"passenger_name" example:"DOE/JOHN" description:"Passenger name as printed on the boarding pass"`
+	Seat           string `json:"seat,omitempty" example:"12A" description:"Assigned seat"`
+	SequenceNumber int    `json:"sequence_number" example:"1" description:"Check-in sequence number"`
+}
+
+// CheckInResponse represents the response for a check-in
+// @Description Check-in result with boarding passes
+type CheckInResponse struct {
+	ConfirmationID string           `json:"confirmation_id" example:"ABC123" description:"Ticket confirmation ID"`
+	FlightNumber   string           `json:"flight_number" example:"AA1234" description:"Flight number"`
+	Origin         string           `json:"origin" example:"JFK" description:"Origin airport code"`
+	Destination    string           `json:"destination" example:"LAX" description:"Destination airport code"`
+	DepartureTime  time.Time        `json:"departure_time" example:"2024-12-25T14:30:00Z" description:"Departure time"`
+	Schedule       *TicketSchedule  `json:"schedule" description:"Boarding and gate-close times"`
+	BoardingPasses []BoardingPass   `json:"boarding_passes" description:"Boarding passes, one per passenger"`
+	Advisories     []TravelAdvisory `json:"travel_advisories,omitempty" description:"Entry requirements of the destination country, for international flights"`
+	DocumentIssues []DocumentIssue  `json:"document_issues,omitempty" description:"Travel document flags of the ticket's saved travelers"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "fmt"
+
+// MaxDelayMinutes bounds a simulated delay
+const MaxDelayMinutes = 24 * 60
+
+// FlightDelayRequest delays a flight for demos
+// @Description Request payload for simulating a flight delay
+type FlightDelayRequest struct {
+	DelayMinutes int  `json:"delay_minutes" example:"90" description:"Minutes to push the departure back (1-1440)" validate:"required,min=1,max=1440"`
+	Disrupt      bool `json:"disrupt,omitempty" example:"true" description:"Also mark confirmed and checked-in tickets DISRUPTED, so travellers can rebook themselves"`
+}
+
+// FlightDelayResponse lists the tickets moved by a delay
+// @Description Result of a simulated flight delay
+type FlightDelayResponse struct {
+	FlightNumber    string          `json:"flight_number" example:"AA1234" description:"Delayed flight"`
+	Date            string          `json:"date" example:"2024-12-25" description:"Scheduled departure date"`
+	DelayMinutes    int             `json:"delay_minutes" example:"90" description:"Applied delay in minutes"`
+	Tickets         []*FlightTicket `json:"tickets" description:"Tickets with their new departure times"`
+	Failed          int             `json:"failed" example:"0" description:"Tickets that could not be updated"`
+	EventsPublished int             `json:"events_published" example:"2" description:"Change events published by the API; 0 when the change feed service picks up Firestore changes"`
+}
+
+// Validate checks the delay
+func (r *FlightDelayRequest) Validate() error {
+	if r.DelayMinutes < 1 || r.DelayMinutes > MaxDelayMinutes {
+		return fmt.Errorf("delay_minutes must be between 1 and %d", MaxDelayMinutes)
+	}
+	return nil
+}
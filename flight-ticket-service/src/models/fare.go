@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// FareDay is the lowest fare of a day
+// @Description Lowest per-passenger fare of a departure date
+type FareDay struct {
+	Date          string  `json:"date" example:"2025-08-12" description:"Departure date"`
+	Fare          float64 `json:"fare" example:"212.4" description:"Lowest per-passenger fare in the calendar currency"`
+	DepartureTime string  `json:"departure_time" example:"23:00" description:"Departure time (HH:MM, UTC) the fare is for"`
+}
+
+// FareCalendarResponse lists the lowest fare of each day of a month
+// @Description Fare calendar of a route
+type FareCalendarResponse struct {
+	Origin      string     `json:"origin" example:"JFK" description:"Origin airport code"`
+	Destination string     `json:"destination" example:"LAX" description:"Destination airport code"`
+	Month       string     `json:"month" example:"2025-08" description:"Month of the calendar"`
+	Currency    string     `json:"currency" example:"USD" description:"Currency of the fares"`
+	Days        []*FareDay `json:"days" description:"Lowest fare of each day from today on"`
+	Cheapest    *FareDay   `json:"cheapest,omitempty" description:"Cheapest day; the earliest one on a tie"`
+	GeneratedAt time.Time  `json:"generated_at" example:"2025-07-12T19:00:00Z" description:"When the fares were computed"`
+	ExpiresAt   time.Time  `json:"expires_at" example:"2025-07-13T00:00:00Z" description:"When the cached fares are computed again"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// ForecastDay is the forecast demand of a route on one day
+// @Description Forecast bookings of a day
+type ForecastDay struct {
+	Date     string  `json:"date" example:"2026-10-17" description:"Booking date (UTC)"`
+	Bookings float64 `json:"bookings" example:"12.4" description:"Expected tickets booked"`
+	Lower    float64 `json:"lower" example:"8.1" description:"Lower bound of the prediction interval"`
+	Upper    float64 `json:"upper" example:"16.7" description:"Upper bound of the prediction interval"`
+}
+
+// RouteForecast is the booking history and forecast demand of one route
+// @Description Booking history and forecast of a route
+type RouteForecast struct {
+	Route    string        `json:"route" example:"JFK-LAX" description:"Origin and destination IATA codes"`
+	History  []int64       `json:"history" description:"Bookings per day of the history, oldest first"`
+	Forecast []ForecastDay `json:"forecast" description:"Forecast bookings per day, from the day after the history"`
+}
+
+// DemandForecast is the response for GET /admin/forecast
+// @Description Booking demand forecast per route
+type DemandForecast struct {
+	Provider    string          `json:"provider" example:"vertex" description:"Forecast provider (vertex or static)"`
+	Model       string          `json:"model,omitempty" example:"projects/my-project/locations/us-central1/endpoints/1234567890" description:"Vertex AI endpoint that made the forecast"`
+	HistoryFrom string          `json:"history_from" example:"2026-09-19" description:"First day of the booking history"`
+	HistoryTo   string          `json:"history_to" example:"2026-10-16" description:"Last day of the booking history"`
+	Horizon     int             `json:"horizon" example:"7" description:"Days forecast"`
+	GeneratedAt time.Time       `json:"generated_at" example:"2026-10-16T09:00:00Z" description:"When the forecast was made"`
+	Routes      []RouteForecast `json:"routes" description:"Forecast per route, most booked routes first"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Revision types; they match the change feed's event types
+const (
+	RevisionCreated = "created"
+	RevisionUpdated = "updated"
+	RevisionDeleted = "deleted"
+)
+
+// TicketRevision is a version of a ticket, recorded each time the ticket changed
+type TicketRevision struct {
+	ID             string        `json:"id"`
+	ConfirmationID string        `json:"confirmation_id"`
+	Type           string        `json:"type"`
+	Time           time.Time     `json:"time"`
+	ChangedFields  []string      `json:"changed_fields,omitempty"`
+	Ticket         *FlightTicket `json:"ticket,omitempty"`   // the ticket after the change; nil when it was deleted
+	Previous       *FlightTicket `json:"previous,omitempty"` // the ticket before the change; nil when it was created
+}
+
+// TicketDiff is the field-level difference between two revisions of a ticket
+// @Description Fields that changed between two revisions of a ticket
+type TicketDiff struct {
+	ConfirmationID string          `json:"confirmation_id" example:"ABC123" description:"Ticket confirmation ID"`
+	From           RevisionSummary `json:"from" description:"Revision compared from"`
+	To             RevisionSummary `json:"to" description:"Revision compared to"`
+	Changes        []FieldChange   `json:"changes" description:"Changed fields, by path"`
+	Count          int             `json:"count" example:"2" description:"Number of changed fields"`
+}
+
+// RevisionSummary identifies a revision of a ticket in a diff
+// @Description A revision of a ticket, numbered from 1 for the oldest
+type RevisionSummary struct {
+	Number int        `json:"number" example:"3" description:"Position in the ticket's history; 0 is the ticket before its first revision"`
+	ID     string     `json:"id,omitempty" description:"Revision ID"`
+	Type   string     `json:"type,omitempty" example:"updated" enums:"created,updated,deleted" description:"Kind of change"`
+	Time   *time.Time `json:"time,omitempty" example:"2024-12-01T10:00:00Z" description:"When the change was made"`
+}
+
+// FieldChange is a field whose value differs between two revisions
+// @Description A changed field with its value in each revision; null when the field was not set
+type FieldChange struct {
+	Field string      `json:"field" example:"labels.campaign" description:"Dotted path of the field in the ticket's JSON"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
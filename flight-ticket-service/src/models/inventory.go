@@ -0,0 +1,178 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Seat inventory transaction kinds
+const (
+	InventoryBook   = "BOOK"
+	InventoryCancel = "CANCEL"
+	InventoryRebook = "REBOOK"
+	InventoryAdjust = "ADJUST"
+	InventoryLimits = "LIMITS"
+)
+
+// Seat inventory accounts. Seats flow between the capacity account (outside
+// the flight), the available pool and one account per ticket holding seats.
+const (
+	AccountCapacity     = "capacity"
+	AccountAvailable    = "available"
+	TicketAccountPrefix = "ticket:"
+)
+
+// Limits of an inventory adjustment
+const (
+	MaxInventoryAdjustment    = 1000
+	MaxAdjustmentReasonLength = 200
+	MaxOverbookingPercent     = 50
+)
+
+// TicketAccount returns the inventory account holding a ticket's seats
+func TicketAccount(confirmationID string) string {
+	return TicketAccountPrefix + confirmationID
+}
+
+// InventoryEntry is one line of a flight's seat ledger. Every entry moves
+// seats out of one account and into another, so the ledger always balances;
+// entries are never changed or removed once posted.
+// @Description Seat inventory ledger entry
+type InventoryEntry struct {
+	ID                 string    `json:"id" firestore:"id" example:"4f1c2b9e8a7d6c05" description:"Entry ID"`
+	FlightNumber       string    `json:"flight_number" firestore:"flight_number" example:"AA1234" description:"Flight number"`
+	Date               string    `json:"date" firestore:"date" example:"2024-12-25" description:"Departure date"`
+	Sequence           int64     `json:"sequence" firestore:"sequence" example:"3" description:"Position in the flight's ledger, starting at 1"`
+	Kind               string    `json:"kind" firestore:"kind" example:"BOOK" enums:"BOOK,CANCEL,REBOOK,ADJUST,LIMITS" description:"What caused the movement"`
+	From               string    `json:"from" firestore:"from" example:"available" description:"Account the seats leave"`
+	To                 string    `json:"to" firestore:"to" example:"ticket:ABC123" description:"Account the seats enter"`
+	Seats              int       `json:"seats" firestore:"seats" example:"2" description:"Seats moved"`
+	ConfirmationID     string    `json:"confirmation_id,omitempty" firestore:"confirmation_id,omitempty" example:"ABC123" description:"Ticket behind a booking movement"`
+	Actor              string    `json:"actor,omitempty" firestore:"actor,omitempty" example:"desk" description:"Name of the API key or job that made the change"`
+	Reason             string    `json:"reason,omitempty" firestore:"reason,omitempty" example:"Aircraft swap to A321" description:"Reason given for an adjustment"`
+	OverbookingPercent int       `json:"overbooking_percent,omitempty" firestore:"overbooking_percent,omitempty" example:"10" description:"Overbooking percentage set by a LIMITS entry"`
+	Frozen             bool      `json:"frozen,omitempty" firestore:"frozen,omitempty" example:"false" description:"Whether a LIMITS entry froze sales"`
+	AvailableAfter     int       `json:"available_after" firestore:"available_after" example:"148" description:"Available seats after the entry"`
+	CreatedAt          time.Time `json:"created_at" firestore:"created_at" example:"2024-07-12T19:00:00Z" description:"Posting timestamp"`
+}
+
+// InventoryBalance is the running total of a flight's seat ledger
+// @Description Seat inventory of a departure
+type InventoryBalance struct {
+	FlightNumber       string         `json:"flight_number" firestore:"flight_number" example:"AA1234" description:"Flight number"`
+	Date               string         `json:"date" firestore:"date" example:"2024-12-25" description:"Departure date"`
+	Capacity           int            `json:"capacity" firestore:"capacity" example:"150" description:"Seats put on sale by adjustments"`
+	Available          int            `json:"available" firestore:"available" example:"148" description:"Seats left to sell"`
+	Sold               int            `json:"sold" firestore:"sold" example:"2" description:"Seats held by tickets"`
+	Held               map[string]int `json:"held" firestore:"held" description:"Seats held per ticket confirmation ID"`
+	OverbookingPercent int            `json:"overbooking_percent" firestore:"overbooking_percent" example:"10" description:"Percentage of the capacity that may be sold beyond it"`
+	Frozen             bool           `json:"frozen" firestore:"frozen" example:"false" description:"Whether sales are frozen"`
+	Entries            int64          `json:"entries" firestore:"entries" example:"3" description:"Number of ledger entries"`
+	UpdatedAt          time.Time      `json:"updated_at" firestore:"updated_at" example:"2024-07-12T19:00:00Z" description:"Time of the last entry"`
+}
+
+// SellLimit returns the most seats tickets may hold: the capacity plus the
+// overbooking allowance, rounded down
+func (b *InventoryBalance) SellLimit() int {
+	return b.Capacity + b.Capacity*b.OverbookingPercent/100
+}
+
+// NewInventoryBalance returns the empty balance of a flight without a ledger
+func NewInventoryBalance(flightNumber, date string) *InventoryBalance {
+	return &InventoryBalance{FlightNumber: flightNumber, Date: date, Held: map[string]int{}}
+}
+
+// InventoryAdjustmentRequest changes the seats on sale for a departure
+// @Description Request payload for adding or removing seats from sale
+type InventoryAdjustmentRequest struct {
+	Seats  int    `json:"seats" example:"150" description:"Seats to add (positive) or remove (negative) from sale" validate:"required"`
+	Reason string `json:"reason" example:"Aircraft swap to A321" description:"Why the capacity changed (up to 200 characters)" validate:"required"`
+}
+
+// Validate checks the seat change and reason
+func (r *InventoryAdjustmentRequest) Validate() error {
+	if r.Seats == 0 {
+		return fmt.Errorf("seats must not be zero")
+	}
+	if r.Seats > MaxInventoryAdjustment || r.Seats < -MaxInventoryAdjustment {
+		return fmt.Errorf("seats must be between -%d and %d", MaxInventoryAdjustment, MaxInventoryAdjustment)
+	}
+	r.Reason = strings.TrimSpace(r.Reason)
+	if r.Reason == "" {
+		return fmt.Errorf("reason is required")
+	}
+	if utf8.RuneCountInString(r.Reason) > MaxAdjustmentReasonLength {
+		return fmt.Errorf("reason must be at most %d characters", MaxAdjustmentReasonLength)
+	}
+	return nil
+}
+
+// InventoryLimitsRequest sets the sell limits of a departure
+// @Description Request payload for the overbooking percentage and sales freeze of a departure
+type InventoryLimitsRequest struct {
+	OverbookingPercent int    `json:"overbooking_percent" example:"10" description:"Percentage of the capacity that may be sold beyond it (0-50)"`
+	Frozen             bool   `json:"frozen" example:"false" description:"Stop selling seats; cancellations still give seats back"`
+	Reason             string `json:"reason" example:"Weight restrictions on the A320" description:"Why the limits changed (up to 200 characters)" validate:"required"`
+}
+
+// Validate checks the overbooking percentage and reason
+func (r *InventoryLimitsRequest) Validate() error {
+	if r.OverbookingPercent < 0 || r.OverbookingPercent > MaxOverbookingPercent {
+		return fmt.Errorf("overbooking_percent must be between 0 and %d", MaxOverbookingPercent)
+	}
+	r.Reason = strings.TrimSpace(r.Reason)
+	if r.Reason == "" {
+		return fmt.Errorf("reason is required")
+	}
+	if utf8.RuneCountInString(r.Reason) > MaxAdjustmentReasonLength {
+		return fmt.Errorf("reason must be at most %d characters", MaxAdjustmentReasonLength)
+	}
+	return nil
+}
+
+// InventorySnapshot shows how the seats of a departure are taken
+// @Description Sold, held and available seats of a departure
+type InventorySnapshot struct {
+	FlightNumber       string    `json:"flight_number" example:"AA1234" description:"Flight number"`
+	Date               string    `json:"date" example:"2024-12-25" description:"Departure date"`
+	Capacity           int       `json:"capacity" example:"150" description:"Seats put on sale by adjustments"`
+	OverbookingPercent int       `json:"overbooking_percent" example:"10" description:"Percentage of the capacity that may be sold beyond it"`
+	SellLimit          int       `json:"sell_limit" example:"165" description:"Most seats tickets may take: the capacity plus the overbooking allowance"`
+	Sold               int       `json:"sold" example:"120" description:"Seats of confirmed and checked-in tickets"`
+	Held               int       `json:"held" example:"6" description:"Seats of pending tickets"`
+	Available          int       `json:"available" example:"39" description:"Seats left to sell up to the sell limit"`
+	Oversold           int       `json:"oversold" example:"0" description:"Seats taken beyond the capacity"`
+	Frozen             bool      `json:"frozen" example:"false" description:"Whether sales are frozen"`
+	UpdatedAt          time.Time `json:"updated_at" example:"2024-07-12T19:00:00Z" description:"Time of the last ledger entry"`
+}
+
+// InventoryResponse is a flight's balance with its ledger
+// @Description Seat inventory and ledger of a departure
+type InventoryResponse struct {
+	Balance *InventoryBalance `json:"balance" description:"Current balance"`
+	Entries []*InventoryEntry `json:"entries" description:"Ledger entries, oldest first"`
+	Count   int               `json:"count" example:"3" description:"Number of entries"`
+}
+
+// InventoryDiscrepancy is a ticket whose held seats differ from its passengers
+// @Description Ticket whose ledger seats do not match its booking
+type InventoryDiscrepancy struct {
+	ConfirmationID string `json:"confirmation_id" example:"ABC123" description:"Ticket confirmation ID"`
+	Held           int    `json:"held" example:"0" description:"Seats the ledger holds for the ticket"`
+	Expected       int    `json:"expected" example:"2" description:"Seats the ticket occupies (passengers of an active ticket, 0 otherwise)"`
+}
+
+// InventoryReconciliation compares a flight's ledger with its balance and tickets
+// @Description Audit of a departure's seat inventory
+type InventoryReconciliation struct {
+	FlightNumber   string                 `json:"flight_number" example:"AA1234" description:"Flight number"`
+	Date           string                 `json:"date" example:"2024-12-25" description:"Departure date"`
+	Balance        *InventoryBalance      `json:"balance" description:"Stored balance"`
+	Replayed       *InventoryBalance      `json:"replayed" description:"Balance recomputed from the ledger entries"`
+	LedgerMatches  bool                   `json:"ledger_matches" example:"true" description:"Whether the stored balance equals the replayed ledger"`
+	Discrepancies  []InventoryDiscrepancy `json:"discrepancies" description:"Tickets whose held seats differ from their bookings"`
+	Reconciled     bool                   `json:"reconciled" example:"true" description:"Whether the ledger matches and no ticket differs"`
+	TicketsChecked int                    `json:"tickets_checked" example:"42" description:"Tickets on the departure that were compared"`
+}
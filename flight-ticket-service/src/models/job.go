@@ -0,0 +1,68 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Job is a background job: an export or bulk operation submitted to POST
+// /jobs, or a manifest export. Submitted jobs are stored with the tickets so
+// that any instance can run, resume and report them.
+// @Description Background job
+type Job struct {
+	ID            string                 `json:"id" firestore:"id" example:"3f9c2a7e41b8d05c" description:"Job ID"`
+	Kind          string                 `json:"kind" firestore:"kind" example:"bulk_cancel" description:"What the job does"`
+	Status        string                 `json:"status" firestore:"status" example:"succeeded" enums:"queued,running,succeeded,failed" description:"Job status"`
+	Params        map[string]interface{} `json:"params,omitempty" firestore:"params,omitempty" description:"Parameters of the job"`
+	CallbackURL   string                 `json:"callback_url,omitempty" firestore:"callback_url,omitempty" example:"https://example.com/hooks/jobs" description:"URL notified when the job finishes"`
+	Progress      *JobProgress           `json:"progress,omitempty" firestore:"progress,omitempty" description:"Items processed so far"`
+	Result        map[string]interface{} `json:"result,omitempty" firestore:"result,omitempty" description:"Outcome of a finished job"`
+	Error         string                 `json:"error,omitempty" firestore:"error,omitempty" example:"backup bucket is not configured" description:"Why the job failed"`
+	Attempts      int                    `json:"attempts,omitempty" firestore:"attempts" example:"1" description:"Times a worker has started the job"`
+	CallbackError string                 `json:"callback_error,omitempty" firestore:"callback_error,omitempty" description:"Why the callback could not be delivered"`
+	ResultURL     string                 `json:"result_url,omitempty" firestore:"-" example:"/jobs/3f9c2a7e41b8d05c/result" description:"Where to download the document of a finished export"`
+	CreatedAt     time.Time              `json:"created_at" firestore:"created_at" example:"2024-07-12T19:00:00Z" description:"When the job was submitted"`
+	UpdatedAt     time.Time              `json:"updated_at,omitempty" firestore:"updated_at" example:"2024-07-12T19:00:02Z" description:"Last status or progress change"`
+	StartedAt     *time.Time             `json:"started_at,omitempty" firestore:"started_at,omitempty" example:"2024-07-12T19:00:01Z" description:"When a worker last started the job"`
+	FinishedAt    *time.Time             `json:"finished_at,omitempty" firestore:"finished_at,omitempty" example:"2024-07-12T19:00:03Z" description:"When the job finished"`
+
+	// Owner is the worker holding the job until LeaseExpiresAt
+	Owner          string    `json:"-" firestore:"owner,omitempty"`
+	LeaseExpiresAt time.Time `json:"-" firestore:"lease_expires_at,omitempty"`
+}
+
+// JobProgress counts the items a job has processed
+// @Description Job progress
+type JobProgress struct {
+	Done  int `json:"done" firestore:"done" example:"150" description:"Items processed"`
+	Total int `json:"total" firestore:"total" example:"420" description:"Items to process"`
+}
+
+// CreateJobRequest represents the request payload for submitting a job
+// @Description Request payload for submitting a background job
+type CreateJobRequest struct {
+	Kind        string                 `json:"kind" example:"export" enums:"export,import,bulk_cancel" description:"Job kind" validate:"required"`
+	Params      map[string]interface{} `json:"params,omitempty" description:"Parameters of the job kind"`
+	CallbackURL string                 `json:"callback_url,omitempty" example:"https://example.com/hooks/jobs" description:"URL to POST the finished job to"`
+}
+
+// Validate checks the kind and callback URL of a job request
+func (r *CreateJobRequest) Validate() error {
+	if r.Kind == "" {
+		return fmt.Errorf("kind is required")
+	}
+	return validateCallbackURL(r.CallbackURL)
+}
+
+// validateCallbackURL accepts an empty or absolute http(s) callback URL
+func validateCallbackURL(callbackURL string) error {
+	if callbackURL == "" {
+		return nil
+	}
+	parsed, err := url.Parse(callbackURL)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return fmt.Errorf("callback_url must be an absolute http or https URL")
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxLabels is the most labels a ticket can carry
+const MaxLabels = 64
+
+// Label keys and values follow the Google Cloud label format: lowercase letters,
+// digits, underscores and dashes, up to 63 characters. Keys start with a letter.
+var (
+	labelKeyPattern   = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,62}$`)
+	labelValuePattern = regexp.MustCompile(`^[a-z0-9_-]{0,63}$`)
+)
+
+// ValidateLabels checks the number of labels and the format of each key and value
+func ValidateLabels(labels map[string]string) error {
+	if len(labels) > MaxLabels {
+		return fmt.Errorf("at most %d labels are allowed", MaxLabels)
+	}
+
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if !labelKeyPattern.MatchString(key) {
+			return fmt.Errorf("invalid label key %q: use up to 63 lowercase letters, digits, underscores or dashes, starting with a letter", key)
+		}
+		if !labelValuePattern.MatchString(labels[key]) {
+			return fmt.Errorf("invalid label value %q for %s: use up to 63 lowercase letters, digits, underscores or dashes", labels[key], key)
+		}
+	}
+	return nil
+}
+
+// ParseLabelFilters parses key:value label filters, as passed in repeated label query parameters
+func ParseLabelFilters(filters []string) (map[string]string, error) {
+	labels := make(map[string]string, len(filters))
+	for _, filter := range filters {
+		key, value, ok := strings.Cut(filter, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid label filter %q: use key:value", filter)
+		}
+		if previous, seen := labels[key]; seen && previous != value {
+			return nil, fmt.Errorf("conflicting filters for label %s", key)
+		}
+		labels[key] = value
+	}
+	if err := ValidateLabels(labels); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+// TicketQuery filters tickets. Empty fields match every ticket.
+type TicketQuery struct {
+	Labels        map[string]string
+	Status        string
+	Origin        string
+	Destination   string
+	FlightNumber  string
+	DepartureDate time.Time // UTC day; zero matches any date
+	Limit         int
+}
+
+// Relative departure dates accepted by ParseDepartureDate
+const (
+	DepartureToday    = "today"
+	DepartureTomorrow = "tomorrow"
+)
+
+// ParseDepartureDate parses a departure date filter: today, tomorrow (both in UTC) or YYYY-MM-DD
+func ParseDepartureDate(value string, now time.Time) (time.Time, error) {
+	today := now.UTC().Truncate(24 * time.Hour)
+	switch strings.ToLower(value) {
+	case DepartureToday:
+		return today, nil
+	case DepartureTomorrow:
+		return today.AddDate(0, 0, 1), nil
+	}
+
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid departure date %q: use today, tomorrow or YYYY-MM-DD", value)
+	}
+	return date, nil
+}
+
+// Matches reports whether the ticket satisfies every filter of the query
+func (q TicketQuery) Matches(ticket *FlightTicket) bool {
+	if q.Status != "" && ticket.Status != q.Status {
+		return false
+	}
+	if q.Origin != "" && ticket.Origin != q.Origin {
+		return false
+	}
+	if q.Destination != "" && ticket.Destination != q.Destination {
+		return false
+	}
+	if q.FlightNumber != "" && ticket.FlightNumber != q.FlightNumber {
+		return false
+	}
+	if !q.DepartureDate.IsZero() && ticket.DepartureDate.UTC().Format("2006-01-02") != q.DepartureDate.UTC().Format("2006-01-02") {
+		return false
+	}
+	for key, value := range q.Labels {
+		if actual, ok := ticket.Labels[key]; !ok || actual != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestValidateLabels(t *testing.T) {
+	tooMany := make(map[string]string)
+	for i := 0; i <= MaxLabels; i++ {
+		tooMany[fmt.Sprintf("key%d", i)] = "x"
+	}
+
+	tests := []struct {
+		name   string
+		labels map[string]string
+		valid  bool
+	}{
+		{"none", nil, true},
+		{"corporate account", map[string]string{"corporate_account": "acme", "campaign": "summer-2024"}, true},
+		{"empty value", map[string]string{"vip": ""}, true},
+		{"uppercase key", map[string]string{"Campaign": "summer"}, false},
+		{"key starting with digit", map[string]string{"2024": "summer"}, false},
+		{"uppercase value", map[string]string{"campaign": "Summer"}, false},
+		{"value with colon", map[string]string{"campaign": "a:b"}, false},
+		{"too many", tooMany, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateLabels(tt.labels); (err == nil) != tt.valid {
+				t.Errorf("ValidateLabels() = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestParseLabelFilters(t *testing.T) {
+	labels, err := ParseLabelFilters([]string{"corporate_account:acme", "campaign:summer"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(labels) != 2 || labels["corporate_account"] != "acme" || labels["campaign"] != "summer" {
+		t.Errorf("Unexpected labels: %v", labels)
+	}
+
+	for _, filters := range [][]string{{"campaign"}, {"Campaign:summer"}, {"campaign:summer", "campaign:winter"}} {
+		if _, err := ParseLabelFilters(filters); err == nil {
+			t.Errorf("Expected error for %v", filters)
+		}
+	}
+}
+
+func TestTicketQueryMatches(t *testing.T) {
+	ticket := &FlightTicket{
+		Origin:       "JFK",
+		FlightNumber: "AA1234",
+		Status:       "CONFIRMED",
+		Labels:       map[string]string{"corporate_account": "acme", "campaign": "summer"},
+	}
+
+	tests := []struct {
+		name  string
+		query TicketQuery
+		want  bool
+	}{
+		{"empty", TicketQuery{}, true},
+		{"label", TicketQuery{Labels: map[string]string{"corporate_account": "acme"}}, true},
+		{"label and field", TicketQuery{Labels: map[string]string{"campaign": "summer"}, Origin: "JFK"}, true},
+		{"other label value", TicketQuery{Labels: map[string]string{"corporate_account": "globex"}}, false},
+		{"missing label", TicketQuery{Labels: map[string]string{"vip": ""}}, false},
+		{"other status", TicketQuery{Status: "CANCELLED"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.query.Matches(ticket); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Edit lock durations, in seconds
+const (
+	DefaultLockSeconds = 120
+	MaxLockSeconds     = 900
+)
+
+// TicketLock is a short-lived edit lock on a ticket. While it holds, only
+// requests that send its token may update or cancel the ticket.
+// @Description Edit lock on a flight ticket
+type TicketLock struct {
+	ConfirmationID string    `json:"confirmation_id" firestore:"confirmation_id" example:"ABC123" description:"Ticket confirmation ID"`
+	Token          string    `json:"token,omitempty" firestore:"token" example:"3f9a1c0e7b2d4a6f8e1c3b5d7f9a0c2e" description:"Secret to send in X-Lock-Token; only returned to the holder"`
+	Holder         string    `json:"holder,omitempty" firestore:"holder,omitempty" example:"desk" description:"Name of the API key that took the lock"`
+	AcquiredAt     time.Time `json:"acquired_at" firestore:"acquired_at" example:"2024-07-12T19:00:00Z" description:"When the lock was taken"`
+	ExpiresAt      time.Time `json:"expires_at" firestore:"expires_at" example:"2024-07-12T19:02:00Z" description:"When the lock lapses unless renewed"`
+}
+
+// LockTicketRequest represents the optional request payload for taking or renewing an edit lock
+// @Description Request payload for locking a ticket
+type LockTicketRequest struct {
+	TTLSeconds int `json:"ttl_seconds,omitempty" example:"120" description:"How long the lock holds, in seconds (default 120, at most 900)"`
+}
+
+// Validate applies the default duration and checks the maximum
+func (r *LockTicketRequest) Validate() error {
+	if r.TTLSeconds == 0 {
+		r.TTLSeconds = DefaultLockSeconds
+	}
+	if r.TTLSeconds < 1 || r.TTLSeconds > MaxLockSeconds {
+		return fmt.Errorf("ttl_seconds must be between 1 and %d", MaxLockSeconds)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// FlightManifest lists the passengers expected at the gate for one departure
+// @Description Departure manifest of a flight
+type FlightManifest struct {
+	FlightNumber string              `json:"flight_number" example:"AA1234" description:"Flight number"`
+	Date         string              `json:"date" example:"2024-12-25" description:"Scheduled departure date"`
+	GeneratedAt  time.Time           `json:"generated_at" example:"2024-12-25T12:00:00Z" description:"When the manifest was produced"`
+	Tickets      int                 `json:"tickets" example:"2" description:"Confirmed and checked-in tickets on the flight"`
+	Passengers   int                 `json:"passengers" example:"3" description:"Passengers on those tickets"`
+	Infants      int                 `json:"infants" example:"1" description:"Infants among the passengers, travelling without a seat"`
+	CheckedIn    int                 `json:"checked_in" example:"2" description:"Passengers who have checked in"`
+	Entries      []ManifestPassenger `json:"entries" description:"One entry per passenger, by confirmation ID"`
+	Branding     *Branding           `json:"branding,omitempty" description:"Branding of the tenant the manifest was generated for"`
+}
+
+// ManifestPassenger is one passenger line of a manifest
+// @Description Passenger on a departure manifest
+type ManifestPassenger struct {
+	ConfirmationID  string    `json:"confirmation_id" example:"ABC123" description:"Ticket confirmation ID"`
+	PassengerName   string    `json:"passenger_name" example:"DOE/JOHN" description:"Name from check-in, or PAX/ADULTn, PAX/CHILDn or PAX/INFANTn before check-in"`
+	PassengerType   string    `json:"passenger_type" example:"ADT" enums:"ADT,CHD,INF" description:"Passenger type code"`
+	SpecialRequests []string  `json:"special_requests,omitempty" example:"WCHR" description:"SSR codes of the passenger"`
+	Status          string    `json:"status" example:"CHECKED_IN" enums:"CONFIRMED,CHECKED_IN" description:"CHECKED_IN once the passenger has checked in"`
+	Seat            string    `json:"seat,omitempty" example:"12A" description:"Assigned seat"`
+	SequenceNumber  int       `json:"sequence_number,omitempty" example:"1" description:"Check-in sequence number"`
+	Route           string    `json:"route" example:"JFK-LAX" description:"Origin and destination airport codes"`
+	DepartureTime   time.Time `json:"departure_time" example:"2024-12-25T14:30:00Z" description:"Departure time"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxNoteLength is the longest note text accepted, in characters
+const MaxNoteLength = 2000
+
+// TicketNote is a free-text remark left on a ticket by an agent. Notes are
+// internal: passengers never see them.
+// @Description Internal remark on a flight ticket
+type TicketNote struct {
+	ID             string    `json:"id" firestore:"id" example:"9b1e4c2d7a3f6e08" description:"Note ID"`
+	ConfirmationID string    `json:"confirmation_id" firestore:"confirmation_id" example:"ABC123" description:"Ticket confirmation ID"`
+	Text           string    `json:"text" firestore:"text" example:"Passenger requested wheelchair assistance at JFK" description:"Remark"`
+	Author         string    `json:"author" firestore:"author" example:"desk" description:"Name of the API key that wrote the note"`
+	CreatedAt      time.Time `json:"created_at" firestore:"created_at" example:"2024-07-12T19:00:00Z" description:"Note creation timestamp"`
+}
+
+// CreateNoteRequest represents the request payload for adding a note to a ticket
+// @Description Request payload for adding a remark to a ticket
+type CreateNoteRequest struct {
+	Text string `json:"text" example:"Passenger requested wheelchair assistance at JFK" description:"Remark (up to 2000 characters)" validate:"required"`
+}
+
+// NoteListResponse represents the response for listing a ticket's notes
+// @Description Notes of a ticket
+type NoteListResponse struct {
+	Notes []*TicketNote `json:"notes" description:"Notes, oldest first"`
+	Count int           `json:"count" example:"2" description:"Number of notes"`
+}
+
+// Validate checks that the note has text within the length limit
+func (r *CreateNoteRequest) Validate() error {
+	r.Text = strings.TrimSpace(r.Text)
+	if r.Text == "" {
+		return fmt.Errorf("text is required")
+	}
+	if utf8.RuneCountInString(r.Text) > MaxNoteLength {
+		return fmt.Errorf("text must be at most %d characters", MaxNoteLength)
+	}
+	return nil
+}
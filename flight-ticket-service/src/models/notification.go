@@ -0,0 +1,165 @@
+package models
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"time"
+)
+
+// Notification channels
+const (
+	NotificationChannelEmail = "email"
+	NotificationChannelSMS   = "sms"
+	NotificationChannelNone  = "none"
+)
+
+// DefaultNotificationLanguage is used when a ticket has no preferences
+const DefaultNotificationLanguage = "en"
+
+var (
+	languagePattern = regexp.MustCompile(`^[a-z]{2}(-[A-Z]{2})?$`)
+	phonePattern    = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+)
+
+// NotificationPreferences controls how the travellers on a ticket are notified of changes and reminders
+// @Description Notification preferences of a ticket
+type NotificationPreferences struct {
+	ConfirmationID string      `json:"confirmation_id" firestore:"confirmation_id" example:"ABC123" description:"Ticket confirmation ID"`
+	Channel        string      `json:"channel" firestore:"channel" example:"email" enums:"email,sms,none" description:"Delivery channel; none turns notifications off"`
+	Email          string      `json:"email,omitempty" firestore:"email,omitempty" example:"traveller@example.com" description:"Email address, required for the email channel"`
+	Phone          string      `json:"phone,omitempty" firestore:"phone,omitempty" example:"+14155550123" description:"E.164 phone number, required for the sms channel"`
+	Language       string      `json:"language" firestore:"language" example:"en" description:"Language of the messages (ISO 639-1, optionally with a region, e.g. pt-BR)"`
+	QuietHours     *QuietHours `json:"quiet_hours,omitempty" firestore:"quiet_hours,omitempty" description:"Daily period during which messages are held back"`
+	UpdatedAt      time.Time   `json:"updated_at" firestore:"updated_at" example:"2024-07-12T19:00:00Z" description:"Last update timestamp"`
+}
+
+// QuietHours is a daily period in a time zone; it may span midnight
+// @Description Daily quiet period
+type QuietHours struct {
+	Start    string `json:"start" firestore:"start" example:"22:00" description:"Start time in HH:MM format"`
+	End      string `json:"end" firestore:"end" example:"07:00" description:"End time in HH:MM format"`
+	TimeZone string `json:"time_zone,omitempty" firestore:"time_zone,omitempty" example:"Europe/Paris" description:"IANA time zone (optional, defaults to UTC)"`
+}
+
+// UpdateNotificationPreferencesRequest replaces the notification preferences of a ticket
+// @Description Request payload for setting notification preferences
+type UpdateNotificationPreferencesRequest struct {
+	Channel    string      `json:"channel" example:"email" enums:"email,sms,none" description:"Delivery channel; none turns notifications off" validate:"required"`
+	Email      string      `json:"email,omitempty" example:"traveller@example.com" description:"Email address, required for the email channel"`
+	Phone      string      `json:"phone,omitempty" example:"+14155550123" description:"E.164 phone number, required for the sms channel"`
+	Language   string      `json:"language,omitempty" example:"en" description:"Language of the messages (optional, defaults to en)"`
+	QuietHours *QuietHours `json:"quiet_hours,omitempty" description:"Daily period during which messages are held back (optional)"`
+}
+
+// DefaultNotificationPreferences are used for tickets without saved preferences
+func DefaultNotificationPreferences(confirmationID string) *NotificationPreferences {
+	return &NotificationPreferences{
+		ConfirmationID: confirmationID,
+		Channel:        NotificationChannelEmail,
+		Language:       DefaultNotificationLanguage,
+	}
+}
+
+// Validate checks the channel, its contact details, the language and the quiet hours
+func (r *UpdateNotificationPreferencesRequest) Validate() error {
+	switch r.Channel {
+	case NotificationChannelEmail:
+		if r.Email == "" {
+			return fmt.Errorf("email is required for the email channel")
+		}
+	case NotificationChannelSMS:
+		if r.Phone == "" {
+			return fmt.Errorf("phone is required for the sms channel")
+		}
+	case NotificationChannelNone:
+	default:
+		return fmt.Errorf("invalid channel %q: use email, sms or none", r.Channel)
+	}
+
+	if r.Email != "" {
+		if address, err := mail.ParseAddress(r.Email); err != nil || address.Address != r.Email {
+			return fmt.Errorf("invalid email %q", r.Email)
+		}
+	}
+	if r.Phone != "" && !phonePattern.MatchString(r.Phone) {
+		return fmt.Errorf("invalid phone %q: use E.164 format, e.g. +14155550123", r.Phone)
+	}
+	if r.Language != "" && !languagePattern.MatchString(r.Language) {
+		return fmt.Errorf("invalid language %q: use an ISO 639-1 code such as en or pt-BR", r.Language)
+	}
+	if r.QuietHours != nil {
+		if err := r.QuietHours.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Preferences builds the preferences for a ticket from the request
+func (r *UpdateNotificationPreferencesRequest) Preferences(confirmationID string) *NotificationPreferences {
+	language := r.Language
+	if language == "" {
+		language = DefaultNotificationLanguage
+	}
+	return &NotificationPreferences{
+		ConfirmationID: confirmationID,
+		Channel:        r.Channel,
+		Email:          r.Email,
+		Phone:          r.Phone,
+		Language:       language,
+		QuietHours:     r.QuietHours,
+		UpdatedAt:      time.Now(),
+	}
+}
+
+// Validate checks the times and the time zone
+func (q *QuietHours) Validate() error {
+	start, err := time.Parse("15:04", q.Start)
+	if err != nil {
+		return fmt.Errorf("invalid quiet_hours.start %q: use HH:MM", q.Start)
+	}
+	end, err := time.Parse("15:04", q.End)
+	if err != nil {
+		return fmt.Errorf("invalid quiet_hours.end %q: use HH:MM", q.End)
+	}
+	if start.Equal(end) {
+		return fmt.Errorf("quiet_hours.start and quiet_hours.end must differ")
+	}
+	if _, err := time.LoadLocation(q.TimeZone); err != nil {
+		return fmt.Errorf("invalid quiet_hours.time_zone %q", q.TimeZone)
+	}
+	return nil
+}
+
+// Until reports whether t falls within the quiet hours and, if so, when they end.
+// Invalid quiet hours are never active.
+func (q *QuietHours) Until(t time.Time) (time.Time, bool) {
+	location, err := time.LoadLocation(q.TimeZone)
+	if err != nil {
+		return time.Time{}, false
+	}
+	start, err1 := time.Parse("15:04", q.Start)
+	end, err2 := time.Parse("15:04", q.End)
+	if err1 != nil || err2 != nil {
+		return time.Time{}, false
+	}
+
+	local := t.In(location)
+	at := func(day time.Time, clock time.Time) time.Time {
+		return time.Date(day.Year(), day.Month(), day.Day(), clock.Hour(), clock.Minute(), 0, 0, location)
+	}
+
+	// Check the period that started today and the one that started yesterday
+	for _, day := range []time.Time{local, local.AddDate(0, 0, -1)} {
+		from := at(day, start)
+		to := at(day, end)
+		if !to.After(from) {
+			to = at(day.AddDate(0, 0, 1), end)
+		}
+		if !local.Before(from) && local.Before(to) {
+			return to, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUpdateNotificationPreferencesRequestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		req   UpdateNotificationPreferencesRequest
+		valid bool
+	}{
+		{"email", UpdateNotificationPreferencesRequest{Channel: "email", Email: "jane@example.com"}, true},
+		{"sms with quiet hours", UpdateNotificationPreferencesRequest{Channel: "sms", Phone: "+14155550123", Language: "pt-BR",
+			QuietHours: &QuietHours{Start: "22:00", End: "07:00", TimeZone: "Europe/Paris"}}, true},
+		{"none", UpdateNotificationPreferencesRequest{Channel: "none"}, true},
+		{"unknown channel", UpdateNotificationPreferencesRequest{Channel: "pigeon"}, false},
+		{"email without address", UpdateNotificationPreferencesRequest{Channel: "email"}, false},
+		{"display name email", UpdateNotificationPreferencesRequest{Channel: "email", Email: "Jane <jane@example.com>"}, false},
+		{"local phone", UpdateNotificationPreferencesRequest{Channel: "sms", Phone: "4155550123"}, false},
+		{"bad language", UpdateNotificationPreferencesRequest{Channel: "none", Language: "English"}, false},
+		{"bad time zone", UpdateNotificationPreferencesRequest{Channel: "none", QuietHours: &QuietHours{Start: "22:00", End: "07:00", TimeZone: "Mars/Olympus"}}, false},
+		{"empty quiet hours", UpdateNotificationPreferencesRequest{Channel: "none", QuietHours: &QuietHours{Start: "22:00", End: "22:00"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.req.Validate(); (err == nil) != tt.valid {
+				t.Errorf("Validate() = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestQuietHoursUntil(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skipf("Time zone data unavailable: %v", err)
+	}
+	overnight := &QuietHours{Start: "22:00", End: "07:00", TimeZone: "Europe/Paris"}
+	daytime := &QuietHours{Start: "12:00", End: "14:00"}
+
+	tests := []struct {
+		name  string
+		quiet *QuietHours
+		at    time.Time
+		until time.Time // zero when not quiet
+	}{
+		{"before midnight", overnight, time.Date(2024, 7, 12, 23, 30, 0, 0, paris), time.Date(2024, 7, 13, 7, 0, 0, 0, paris)},
+		{"after midnight", overnight, time.Date(2024, 7, 13, 6, 59, 0, 0, paris), time.Date(2024, 7, 13, 7, 0, 0, 0, paris)},
+		{"at the end", overnight, time.Date(2024, 7, 13, 7, 0, 0, 0, paris), time.Time{}},
+		{"daytime", overnight, time.Date(2024, 7, 13, 12, 0, 0, 0, paris), time.Time{}},
+		{"other zone", overnight, time.Date(2024, 7, 12, 21, 0, 0, 0, time.UTC), time.Date(2024, 7, 13, 7, 0, 0, 0, paris)},
+		{"utc default", daytime, time.Date(2024, 7, 12, 13, 0, 0, 0, time.UTC), time.Date(2024, 7, 12, 14, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			until, quiet := tt.quiet.Until(tt.at)
+			if quiet != !tt.until.IsZero() || !until.Equal(tt.until) {
+				t.Errorf("Until(%v) = %v, %v; want %v", tt.at, until, quiet, tt.until)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Passenger type codes
+const (
+	PassengerAdult  = "ADT"
+	PassengerChild  = "CHD"
+	PassengerInfant = "INF"
+)
+
+// MaxInfantsPerAdult is how many infants can travel on the lap of one adult
+const MaxInfantsPerAdult = 1
+
+// Fares of children and infants, as a share of the adult fare
+const (
+	ChildFareShare  = 0.75
+	InfantFareShare = 0.10
+)
+
+// Reserved ticket labels holding the number of children and infants on a
+// ticket; the other passengers are adults
+const (
+	ChildrenLabel = "passengers_chd"
+	InfantsLabel  = "passengers_inf"
+)
+
+// PassengerTypes counts the passengers of a ticket by type
+// @Description Passengers by type
+type PassengerTypes struct {
+	Adults   int `json:"ADT" example:"2" description:"Adults, 12 and over"`
+	Children int `json:"CHD" example:"1" description:"Children, 2 to 11, charged 75% of the fare"`
+	Infants  int `json:"INF" example:"1" description:"Infants under 2, on an adult's lap without a seat, charged 10% of the fare"`
+}
+
+// IsPassengerTypeLabel reports whether a label key holds a passenger type count
+func IsPassengerTypeLabel(key string) bool {
+	return key == ChildrenLabel || key == InfantsLabel
+}
+
+// TicketPassengerTypes returns the passengers of a ticket by type, from its labels
+func TicketPassengerTypes(ticket *FlightTicket) PassengerTypes {
+	count := func(key string) int {
+		n, _ := strconv.Atoi(ticket.Labels[key])
+		return n
+	}
+	types := PassengerTypes{Children: count(ChildrenLabel), Infants: count(InfantsLabel)}
+	types.Adults = ticket.Passengers - types.Children - types.Infants
+	return types
+}
+
+// Seats returns the seats the ticket's passengers occupy; infants have none
+func (t *FlightTicket) Seats() int {
+	return t.Passengers - TicketPassengerTypes(t).Infants
+}
+
+// Total returns the number of passengers
+func (p PassengerTypes) Total() int {
+	return p.Adults + p.Children + p.Infants
+}
+
+// Validate checks that every infant has an adult to travel with
+func (p PassengerTypes) Validate() error {
+	if p.Adults < 0 || p.Children < 0 || p.Infants < 0 {
+		return fmt.Errorf("passenger counts must not be negative")
+	}
+	if p.Adults < 1 {
+		return fmt.Errorf("at least one adult (ADT) is required")
+	}
+	if p.Infants > p.Adults*MaxInfantsPerAdult {
+		return fmt.Errorf("at most %d infant (INF) per adult (ADT) is allowed", MaxInfantsPerAdult)
+	}
+	return nil
+}
+
+// FareUnits returns the number of adult fares the passengers are charged
+func (p PassengerTypes) FareUnits() float64 {
+	return float64(p.Adults) + float64(p.Children)*ChildFareShare + float64(p.Infants)*InfantFareShare
+}
+
+// Type returns the type code of the nth passenger, counting from 0: adults
+// come first, then children, then infants
+func (p PassengerTypes) Type(n int) string {
+	switch {
+	case n < p.Adults:
+		return PassengerAdult
+	case n < p.Adults+p.Children:
+		return PassengerChild
+	default:
+		return PassengerInfant
+	}
+}
+
+// Labels returns the labels recording the children and infants
+func (p PassengerTypes) Labels() map[string]string {
+	labels := make(map[string]string, 2)
+	if p.Children > 0 {
+		labels[ChildrenLabel] = strconv.Itoa(p.Children)
+	}
+	if p.Infants > 0 {
+		labels[InfantsLabel] = strconv.Itoa(p.Infants)
+	}
+	return labels
+}
@@ -0,0 +1,40 @@
+package models
+
+import "testing"
+
+func TestPassengerTypes(t *testing.T) {
+	types := PassengerTypes{Adults: 2, Children: 1, Infants: 1}
+	if err := types.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if types.Total() != 4 || types.FareUnits() != 2.85 {
+		t.Errorf("Expected 4 passengers charged 2.85 fares, got %d and %v", types.Total(), types.FareUnits())
+	}
+	for n, want := range []string{PassengerAdult, PassengerAdult, PassengerChild, PassengerInfant} {
+		if got := types.Type(n); got != want {
+			t.Errorf("Type(%d) = %s, want %s", n, got, want)
+		}
+	}
+
+	ticket := &FlightTicket{Passengers: 4, Labels: types.Labels()}
+	if got := TicketPassengerTypes(ticket); got != types {
+		t.Errorf("TicketPassengerTypes = %+v, want %+v", got, types)
+	}
+	if ticket.Seats() != 3 {
+		t.Errorf("Expected infants to take no seat, got %d seats", ticket.Seats())
+	}
+	if labels := (PassengerTypes{Adults: 3}).Labels(); len(labels) != 0 {
+		t.Errorf("Expected no labels for adults only, got %v", labels)
+	}
+
+	invalid := map[string]PassengerTypes{
+		"no-adult":     {Children: 1},
+		"negative":     {Adults: 1, Children: -1},
+		"infant-ratio": {Adults: 1, Infants: 2},
+	}
+	for name, types := range invalid {
+		if err := types.Validate(); err == nil {
+			t.Errorf("Expected an error for %s", name)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// QuarantinedTicket is a copy of a ticket document that could not be read,
+// kept with the reason until the document is repaired. The ticket itself stays
+// in place but is left out of lists and searches.
+// @Description Unreadable ticket document set aside for repair
+type QuarantinedTicket struct {
+	ConfirmationID string                 `json:"confirmation_id" firestore:"confirmation_id" example:"ABC123" description:"Document ID of the ticket"`
+	Document       string                 `json:"document" firestore:"document" example:"flight_tickets/ABC123" description:"Path of the ticket document"`
+	Error          string                 `json:"error" firestore:"error" example:"invalid ticket document ABC123: field departure_time is string, not a timestamp" description:"Why the document could not be read"`
+	Fields         map[string]interface{} `json:"fields" firestore:"fields" description:"Stored fields of the document when it was quarantined"`
+	QuarantinedAt  time.Time              `json:"quarantined_at" firestore:"quarantined_at" example:"2024-07-12T19:00:00Z" description:"When the document was quarantined"`
+}
+
+// QuarantineListResponse represents the response for listing quarantined tickets
+// @Description Quarantined ticket documents
+type QuarantineListResponse struct {
+	Tickets []*QuarantinedTicket `json:"tickets" description:"Quarantined documents, oldest first"`
+	Count   int                  `json:"count" example:"1" description:"Number of quarantined documents"`
+}
+
+// RepairTicketRequest represents the request payload for repairing a quarantined ticket
+// @Description Field changes that make a ticket document readable again
+type RepairTicketRequest struct {
+	Set    map[string]interface{} `json:"set,omitempty" description:"Fields to store, by document field name; timestamps as RFC 3339 strings"`
+	Remove []string               `json:"remove,omitempty" example:"seat" description:"Fields to delete from the document"`
+}
+
+// Validate checks that the repair does not both set and remove a field
+func (r *RepairTicketRequest) Validate() error {
+	for _, field := range r.Remove {
+		if _, ok := r.Set[field]; ok {
+			return fmt.Errorf("field %s is both set and removed", field)
+		}
+	}
+	return nil
+}
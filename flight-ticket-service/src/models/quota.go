@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// QuotaStatus is an API key's booking quota for the current day
+// @Description Daily booking quota of the calling API key
+type QuotaStatus struct {
+	Name      string    `json:"name" example:"desk" description:"API key name, or anonymous"`
+	Limit     int       `json:"limit" example:"100" description:"Bookings allowed per day; 0 means unlimited"`
+	Used      int64     `json:"used" example:"42" description:"Bookings made today"`
+	Remaining int64     `json:"remaining" example:"58" description:"Bookings left today; -1 when unlimited"`
+	ResetsAt  time.Time `json:"resets_at" example:"2024-07-13T00:00:00Z" description:"When the count starts again (midnight UTC)"`
+}
+
+// QuotaExceededResponse is returned with 429 when an API key has used its booking quota
+// @Description Booking quota exceeded error response
+type QuotaExceededResponse struct {
+	Error    string    `json:"error" example:"Booking quota exceeded" description:"Error message"`
+	Message  string    `json:"message" example:"API key desk may book 100 tickets per day" description:"Details"`
+	Limit    int       `json:"limit" example:"100" description:"Bookings allowed per day"`
+	Used     int64     `json:"used" example:"100" description:"Bookings made today"`
+	ResetsAt time.Time `json:"resets_at" example:"2024-07-13T00:00:00Z" description:"When the count starts again (midnight UTC)"`
+}
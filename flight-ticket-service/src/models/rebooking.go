@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// DisruptedStatus is the status of tickets whose flight was disrupted, e.g.
+// by a long delay; travellers can move them to another flight themselves
+const DisruptedStatus = "DISRUPTED"
+
+// RebookingDays is the number of days, from the disrupted departure date, searched for rebooking options
+const RebookingDays = 3
+
+// RebookingOption is another flight a disrupted ticket can be moved to
+// @Description Flight a disrupted ticket can be rebooked on
+type RebookingOption struct {
+	ID              string     `json:"id" example:"AA1250-202412251430" description:"Option ID, to accept the option"`
+	FlightNumber    string     `json:"flight_number" example:"AA1250" description:"Flight number"`
+	Origin          string     `json:"origin" example:"JFK" description:"Origin airport code"`
+	Destination     string     `json:"destination" example:"LAX" description:"Destination airport code"`
+	DepartureDate   string     `json:"departure_date" example:"2024-12-25" description:"Departure date"`
+	DepartureTime   time.Time  `json:"departure_time" example:"2024-12-25T14:30:00Z" description:"Departure time"`
+	ArrivalTime     *time.Time `json:"arrival_time,omitempty" example:"2024-12-25T20:30:00Z" description:"Estimated arrival time"`
+	DurationMinutes int        `json:"duration_minutes,omitempty" example:"360" description:"Estimated flight time in minutes"`
+	DelayMinutes    int        `json:"delay_minutes" example:"300" description:"Minutes after the departure of the disrupted flight; negative for earlier flights"`
+}
+
+// RebookingOptionID identifies the option of a flight departure
+func RebookingOptionID(flightNumber string, departure time.Time) string {
+	return flightNumber + "-" + departure.UTC().Format("200601021504")
+}
+
+// RebookingOptionsResponse lists the rebooking options of a disrupted ticket
+// @Description Rebooking options of a disrupted ticket
+type RebookingOptionsResponse struct {
+	ConfirmationID string            `json:"confirmation_id" example:"ABC123" description:"Disrupted ticket"`
+	FlightNumber   string            `json:"flight_number" example:"AA1234" description:"Disrupted flight"`
+	DepartureTime  time.Time         `json:"departure_time" example:"2024-12-25T09:00:00Z" description:"Departure time of the disrupted flight"`
+	Options        []RebookingOption `json:"options" description:"Flights on the same route with seats for the passengers, earliest first"`
+	Count          int               `json:"count" example:"2" description:"Number of options"`
+}
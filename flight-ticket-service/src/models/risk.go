@@ -0,0 +1,84 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ReviewStatus is the status of bookings held for review because they look
+// risky or break a booking rule that holds them; they keep their seats until
+// an admin approves or rejects them
+const ReviewStatus = "REVIEW"
+
+// Reserved ticket labels recording why a booking was held for review
+const (
+	RiskScoreLabel   = "risk_score"
+	RiskReasonsLabel = "risk_reasons"
+	ReviewRuleLabel  = "review_rule"
+)
+
+// MaxReviewReasonLength is the longest reason of a review decision, in characters
+const MaxReviewReasonLength = 500
+
+// RiskAssessment is the risk score of a booking and the signals behind it
+// @Description Risk score of a booking
+type RiskAssessment struct {
+	Score   int      `json:"score" example:"80" description:"Sum of the scores of the risk signals"`
+	Reasons []string `json:"reasons,omitempty" example:"key_velocity,last_minute_group" description:"Risk signals of the booking"`
+	Rule    string   `json:"rule,omitempty" example:"groups-review" description:"Booking rule that held the booking for review"`
+}
+
+// Labels returns the reserved labels recording the assessment on a ticket
+func (a *RiskAssessment) Labels() map[string]string {
+	labels := map[string]string{
+		RiskScoreLabel:   strconv.Itoa(a.Score),
+		RiskReasonsLabel: strings.Join(a.Reasons, "-"),
+	}
+	if a.Rule != "" {
+		labels[ReviewRuleLabel] = a.Rule
+	}
+	return labels
+}
+
+// TicketRisk returns the assessment recorded on a ticket's labels, or nil
+func TicketRisk(ticket *FlightTicket) *RiskAssessment {
+	value, ok := ticket.Labels[RiskScoreLabel]
+	if !ok {
+		return nil
+	}
+	score, _ := strconv.Atoi(value)
+	assessment := &RiskAssessment{Score: score, Rule: ticket.Labels[ReviewRuleLabel]}
+	if reasons := ticket.Labels[RiskReasonsLabel]; reasons != "" {
+		assessment.Reasons = strings.Split(reasons, "-")
+	}
+	return assessment
+}
+
+// IsRiskLabel reports whether a label key records why a booking was held for review
+func IsRiskLabel(key string) bool {
+	return key == RiskScoreLabel || key == RiskReasonsLabel || key == ReviewRuleLabel
+}
+
+// ReviewQueueResponse lists the bookings held for review
+// @Description Bookings held for review
+type ReviewQueueResponse struct {
+	Tickets []*FlightTicket `json:"tickets" description:"Tickets in REVIEW, oldest first, with their risk"`
+	Count   int             `json:"count" example:"1" description:"Number of tickets"`
+}
+
+// ReviewDecisionRequest represents the request payload for approving or rejecting a booking
+// @Description Request payload for a review decision
+type ReviewDecisionRequest struct {
+	Reason string `json:"reason,omitempty" example:"Corporate account confirmed by phone" description:"Why the booking was approved or rejected (up to 500 characters), kept in the ticket's notes"`
+}
+
+// Validate normalizes the request and checks the reason
+func (r *ReviewDecisionRequest) Validate() error {
+	r.Reason = strings.TrimSpace(r.Reason)
+	if utf8.RuneCountInString(r.Reason) > MaxReviewReasonLength {
+		return fmt.Errorf("reason must be at most %d characters", MaxReviewReasonLength)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// RouteFlight is a flight of the route network
+// @Description Flight between two airports
+type RouteFlight struct {
+	FlightNumber    string     `json:"flight_number" example:"AA1234" description:"Flight number"`
+	Origin          string     `json:"origin" example:"JFK" description:"Origin airport code"`
+	Destination     string     `json:"destination" example:"ORD" description:"Destination airport code"`
+	DepartureTime   time.Time  `json:"departure_time" example:"2024-07-15T14:30:00Z" description:"Departure time"`
+	ArrivalTime     *time.Time `json:"arrival_time,omitempty" example:"2024-07-15T16:30:00Z" description:"Estimated arrival time; missing for airports without coordinates"`
+	DurationMinutes int        `json:"duration_minutes,omitempty" example:"120" description:"Estimated flight time in minutes"`
+}
+
+// RouteOption is a way to travel from one airport to another
+// @Description Direct or one-stop option
+type RouteOption struct {
+	Stops             int            `json:"stops" example:"1" description:"Number of connections"`
+	Flights           []*RouteFlight `json:"flights" description:"Flights in travel order"`
+	Via               string         `json:"via,omitempty" example:"ORD" description:"Connecting airport"`
+	ConnectionMinutes int            `json:"connection_minutes,omitempty" example:"95" description:"Time between landing and the next departure"`
+	DurationMinutes   int            `json:"duration_minutes,omitempty" example:"430" description:"Estimated time from the first departure to the last arrival"`
+}
+
+// RouteSearchResponse lists the options found by a route search
+// @Description Route search results
+type RouteSearchResponse struct {
+	Origin         string         `json:"origin" example:"JFK" description:"Origin airport code"`
+	Destination    string         `json:"destination" example:"LAX" description:"Destination airport code"`
+	Date           string         `json:"date" example:"2024-07-15" description:"Departure date of the first flight"`
+	Options        []*RouteOption `json:"options" description:"Direct options first, then one-stop options, by departure time"`
+	Count          int            `json:"count" example:"3" description:"Number of options"`
+	GraphUpdatedAt time.Time      `json:"graph_updated_at" example:"2024-07-12T19:00:00Z" description:"When the route network was last rebuilt"`
+}
@@ -0,0 +1,187 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Booking rule types
+const (
+	RuleMaxPassengers     = "max_passengers"
+	RuleMinConnectionTime = "min_connection_time"
+	RuleAdvancePurchase   = "advance_purchase"
+	RuleBlockedRoute      = "blocked_route"
+	RuleCompensation      = "cancellation_compensation"
+)
+
+// RuleTypes lists every booking rule type
+var RuleTypes = []string{RuleMaxPassengers, RuleMinConnectionTime, RuleAdvancePurchase, RuleBlockedRoute, RuleCompensation}
+
+// MaxCompensationPercent is the highest share of the fare a cancellation_compensation rule can refund
+const MaxCompensationPercent = 200
+
+// ItineraryLabel groups the tickets of one journey; the minimum connection
+// time is checked between tickets with the same value
+const ItineraryLabel = "itinerary"
+
+// MaxRuleDescriptionLength is the longest rule description, in characters
+const MaxRuleDescriptionLength = 200
+
+// BookingRule is a rule checked when tickets are booked or changed. Origin
+// and destination limit the rule to a route; empty fields match any airport.
+// For min_connection_time the origin is the connecting airport.
+// cancellation_compensation rules are not checked; they set the vouchers
+// issued when the airline cancels a flight on the route.
+// @Description Booking rule
+type BookingRule struct {
+	ID                   string    `json:"id" firestore:"id" example:"max-9-passengers" description:"Rule ID"`
+	Type                 string    `json:"type" firestore:"type" example:"max_passengers" enums:"max_passengers,min_connection_time,advance_purchase,blocked_route,cancellation_compensation" description:"Rule type"`
+	Description          string    `json:"description,omitempty" firestore:"description,omitempty" example:"Group bookings go through the groups desk" description:"Why the rule exists"`
+	Enabled              bool      `json:"enabled" firestore:"enabled" example:"true" description:"Whether the rule is checked"`
+	Origin               string    `json:"origin,omitempty" firestore:"origin,omitempty" example:"JFK" description:"Origin airport the rule applies to; the connecting airport for min_connection_time"`
+	Destination          string    `json:"destination,omitempty" firestore:"destination,omitempty" example:"LAX" description:"Destination airport the rule applies to"`
+	MaxPassengers        int       `json:"max_passengers,omitempty" firestore:"max_passengers,omitempty" example:"9" description:"Most passengers on one ticket (max_passengers)"`
+	MinConnectionMinutes int       `json:"min_connection_minutes,omitempty" firestore:"min_connection_minutes,omitempty" example:"60" description:"Shortest connection between tickets of an itinerary (min_connection_time)"`
+	MinAdvanceHours      int       `json:"min_advance_hours,omitempty" firestore:"min_advance_hours,omitempty" example:"2" description:"Hours before departure when booking closes (advance_purchase)"`
+	MaxAdvanceDays       int       `json:"max_advance_days,omitempty" firestore:"max_advance_days,omitempty" example:"330" description:"Days before departure when booking opens (advance_purchase)"`
+	CompensationPercent  int       `json:"compensation_percent,omitempty" firestore:"compensation_percent,omitempty" example:"100" description:"Share of the fare issued as a voucher, in percent (cancellation_compensation)"`
+	CompensationAmount   float64   `json:"compensation_amount,omitempty" firestore:"compensation_amount,omitempty" example:"50.00" description:"Voucher amount per passenger in the base currency, on top of the share of the fare (cancellation_compensation)"`
+	Review               bool      `json:"review,omitempty" firestore:"review,omitempty" example:"true" description:"Hold new bookings that break the rule for review instead of refusing them; changes are still refused"`
+	UpdatedAt            time.Time `json:"updated_at" firestore:"updated_at" example:"2024-07-12T19:00:00Z" description:"Last update timestamp"`
+}
+
+// Matches reports whether the rule applies to the route of a ticket
+func (b *BookingRule) Matches(ticket *FlightTicket) bool {
+	return (b.Origin == "" || b.Origin == ticket.Origin) && (b.Destination == "" || b.Destination == ticket.Destination)
+}
+
+// Route describes the airports the rule applies to, e.g. "JFK-any"
+func (b *BookingRule) Route() string {
+	origin, destination := b.Origin, b.Destination
+	if origin == "" {
+		origin = "any"
+	}
+	if destination == "" {
+		destination = "any"
+	}
+	return origin + "-" + destination
+}
+
+// BookingRuleRequest represents the request payload for creating or replacing a booking rule
+// @Description Request payload for saving a booking rule
+type BookingRuleRequest struct {
+	Type                 string  `json:"type" example:"max_passengers" enums:"max_passengers,min_connection_time,advance_purchase,blocked_route,cancellation_compensation" description:"Rule type" validate:"required"`
+	Description          string  `json:"description,omitempty" example:"Group bookings go through the groups desk" description:"Why the rule exists"`
+	Enabled              *bool   `json:"enabled,omitempty" example:"true" description:"Whether the rule is checked (default true)"`
+	Origin               string  `json:"origin,omitempty" example:"JFK" description:"Origin airport the rule applies to"`
+	Destination          string  `json:"destination,omitempty" example:"LAX" description:"Destination airport the rule applies to"`
+	MaxPassengers        int     `json:"max_passengers,omitempty" example:"9" description:"Most passengers on one ticket (max_passengers)"`
+	MinConnectionMinutes int     `json:"min_connection_minutes,omitempty" example:"60" description:"Shortest connection (min_connection_time)"`
+	MinAdvanceHours      int     `json:"min_advance_hours,omitempty" example:"2" description:"Hours before departure when booking closes (advance_purchase)"`
+	MaxAdvanceDays       int     `json:"max_advance_days,omitempty" example:"330" description:"Days before departure when booking opens (advance_purchase)"`
+	CompensationPercent  int     `json:"compensation_percent,omitempty" example:"100" description:"Share of the fare issued as a voucher, in percent (cancellation_compensation)"`
+	CompensationAmount   float64 `json:"compensation_amount,omitempty" example:"50.00" description:"Voucher amount per passenger in the base currency (cancellation_compensation)"`
+	Review               bool    `json:"review,omitempty" example:"true" description:"Hold new bookings that break the rule for review instead of refusing them (default false)"`
+}
+
+// RulesResponse lists the booking rules that apply to the caller
+// @Description Active booking rules
+type RulesResponse struct {
+	Rules  []*BookingRule `json:"rules" description:"Enabled rules, by ID"`
+	Count  int            `json:"count" example:"2" description:"Number of rules"`
+	Tenant *TenantRules   `json:"tenant,omitempty" description:"Booking rules of the caller's tenant, checked as well"`
+}
+
+// TenantRules are the booking rules of a tenant
+// @Description Booking rules of a tenant
+type TenantRules struct {
+	ID           string       `json:"id" example:"acme" description:"Tenant ID"`
+	BookingRules BookingRules `json:"booking_rules" description:"Booking rules"`
+}
+
+// ValidateRuleID checks that a rule ID is usable in a URL path
+func ValidateRuleID(id string) error {
+	if !labelKeyPattern.MatchString(id) {
+		return fmt.Errorf("invalid rule ID %q: use up to 63 lowercase letters, digits, underscores or dashes, starting with a letter", id)
+	}
+	return nil
+}
+
+// Rule normalizes and checks the request and returns the rule it describes
+func (r *BookingRuleRequest) Rule(id string) (*BookingRule, error) {
+	rule := &BookingRule{
+		ID:          id,
+		Type:        strings.ToLower(strings.TrimSpace(r.Type)),
+		Description: strings.TrimSpace(r.Description),
+		Enabled:     r.Enabled == nil || *r.Enabled,
+		Origin:      strings.ToUpper(strings.TrimSpace(r.Origin)),
+		Destination: strings.ToUpper(strings.TrimSpace(r.Destination)),
+		Review:      r.Review,
+	}
+	if err := ValidateRuleID(id); err != nil {
+		return nil, err
+	}
+	if utf8.RuneCountInString(rule.Description) > MaxRuleDescriptionLength {
+		return nil, fmt.Errorf("description must be at most %d characters", MaxRuleDescriptionLength)
+	}
+	for _, code := range []string{rule.Origin, rule.Destination} {
+		if code != "" && !ValidateAirportCode(code) {
+			return nil, fmt.Errorf("invalid airport code %q: use 3-letter IATA codes", code)
+		}
+	}
+	if r.MaxPassengers < 0 || r.MinConnectionMinutes < 0 || r.MinAdvanceHours < 0 || r.MaxAdvanceDays < 0 || r.CompensationPercent < 0 || r.CompensationAmount < 0 {
+		return nil, fmt.Errorf("rule parameters must not be negative")
+	}
+
+	switch rule.Type {
+	case RuleMaxPassengers:
+		if r.MaxPassengers == 0 {
+			return nil, fmt.Errorf("max_passengers is required")
+		}
+		rule.MaxPassengers = r.MaxPassengers
+	case RuleMinConnectionTime:
+		if r.MinConnectionMinutes == 0 {
+			return nil, fmt.Errorf("min_connection_minutes is required")
+		}
+		rule.MinConnectionMinutes = r.MinConnectionMinutes
+	case RuleAdvancePurchase:
+		if r.MinAdvanceHours == 0 && r.MaxAdvanceDays == 0 {
+			return nil, fmt.Errorf("min_advance_hours or max_advance_days is required")
+		}
+		if r.MaxAdvanceDays > 0 && r.MinAdvanceHours >= r.MaxAdvanceDays*24 {
+			return nil, fmt.Errorf("min_advance_hours must be less than max_advance_days")
+		}
+		rule.MinAdvanceHours, rule.MaxAdvanceDays = r.MinAdvanceHours, r.MaxAdvanceDays
+	case RuleBlockedRoute:
+		if rule.Origin == "" && rule.Destination == "" {
+			return nil, fmt.Errorf("origin or destination is required")
+		}
+	case RuleCompensation:
+		if r.CompensationPercent == 0 && r.CompensationAmount == 0 {
+			return nil, fmt.Errorf("compensation_percent or compensation_amount is required")
+		}
+		if r.CompensationPercent > MaxCompensationPercent {
+			return nil, fmt.Errorf("compensation_percent must be at most %d", MaxCompensationPercent)
+		}
+		if r.Review {
+			return nil, fmt.Errorf("review does not apply to %s rules, which refuse nothing", RuleCompensation)
+		}
+		rule.CompensationPercent, rule.CompensationAmount = r.CompensationPercent, r.CompensationAmount
+	default:
+		return nil, fmt.Errorf("unknown rule type %q (known: %s)", r.Type, strings.Join(RuleTypes, ", "))
+	}
+	return rule, nil
+}
+
+// Compensation returns the voucher amount of a cancellation_compensation rule
+// for a ticket, in the base currency: the rule's share of the fare plus its
+// amount for every passenger
+func (b *BookingRule) Compensation(ticket *FlightTicket) float64 {
+	var fare float64
+	if ticket.Price != nil {
+		fare = ticket.Price.BaseAmount
+	}
+	return fare*float64(b.CompensationPercent)/100 + b.CompensationAmount*float64(ticket.Passengers)
+}
@@ -0,0 +1,35 @@
+package models
+
+import "testing"
+
+func TestBookingRuleRequest(t *testing.T) {
+	disabled := false
+	rule, err := (&BookingRuleRequest{Type: " Blocked_Route ", Origin: "jfk", Enabled: &disabled}).Rule("no-jfk")
+	if err != nil {
+		t.Fatalf("Rule failed: %v", err)
+	}
+	if rule.Type != RuleBlockedRoute || rule.Origin != "JFK" || rule.Enabled || rule.Route() != "JFK-any" {
+		t.Errorf("Unexpected rule %+v", rule)
+	}
+	if rule, _ := (&BookingRuleRequest{Type: RuleMaxPassengers, MaxPassengers: 9, MinAdvanceHours: 5}).Rule("max-9"); !rule.Enabled || rule.MinAdvanceHours != 0 {
+		t.Errorf("Expected an enabled rule with only its own parameters, got %+v", rule)
+	}
+
+	invalid := map[string]BookingRuleRequest{
+		"Bad ID":          {Type: RuleMaxPassengers, MaxPassengers: 9},
+		"unknown-type":    {Type: "max_price"},
+		"no-parameter":    {Type: RuleMinConnectionTime},
+		"no-route":        {Type: RuleBlockedRoute},
+		"bad-airport":     {Type: RuleBlockedRoute, Origin: "JFKX"},
+		"negative":        {Type: RuleMaxPassengers, MaxPassengers: -1},
+		"empty-window":    {Type: RuleAdvancePurchase, MinAdvanceHours: 48, MaxAdvanceDays: 2},
+		"no-refund":       {Type: RuleCompensation},
+		"too-generous":    {Type: RuleCompensation, CompensationPercent: 500},
+		"review-vouchers": {Type: RuleCompensation, CompensationPercent: 100, Review: true},
+	}
+	for id, req := range invalid {
+		if _, err := req.Rule(id); err == nil {
+			t.Errorf("Expected an error for %s", id)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Seat hold limits; durations are in seconds
+const (
+	DefaultSeatHoldSeconds = 600
+	MaxSeatHoldSeconds     = 1800
+	MaxSeatsPerHold        = 9
+)
+
+// Seat statuses on a departure's seat map
+const (
+	SeatHeld     = "HELD"
+	SeatAssigned = "ASSIGNED"
+)
+
+// seatPattern matches seats such as 12A: a row from 1 to 99 and a letter from A to K
+var seatPattern = regexp.MustCompile(`^[1-9][0-9]?[A-K]$`)
+
+// seatLabelPrefix starts the reserved ticket labels holding the seats
+// assigned to a ticket's passengers, e.g. seat_1: 12a
+const seatLabelPrefix = "seat_"
+
+// SeatHold is a short-lived reservation of seats on a departure, taken while
+// a booking is made. Booking with the hold's ID assigns its seats to the ticket.
+// @Description Seats held on a departure
+type SeatHold struct {
+	ID           string    `json:"id" firestore:"id" example:"3f9a1c0e7b2d4a6f8e1c3b5d7f9a0c2e" description:"Hold ID; send it as seat_hold_id when booking, or to release the hold"`
+	FlightNumber string    `json:"flight_number" firestore:"flight_number" example:"AA1234" description:"Flight number"`
+	Date         string    `json:"date" firestore:"date" example:"2024-12-25" description:"Departure date"`
+	Seats        []string  `json:"seats" firestore:"seats" example:"12A,12B" description:"Held seats"`
+	Holder       string    `json:"holder,omitempty" firestore:"holder,omitempty" example:"desk" description:"Name of the API key that placed the hold"`
+	CreatedAt    time.Time `json:"created_at" firestore:"created_at" example:"2024-07-12T19:00:00Z" description:"When the hold was placed"`
+	ExpiresAt    time.Time `json:"expires_at" firestore:"expires_at" example:"2024-07-12T19:10:00Z" description:"When the seats are released unless booked"`
+}
+
+// Expired reports whether the hold lapsed at the given time
+func (h *SeatHold) Expired(now time.Time) bool {
+	return !now.Before(h.ExpiresAt)
+}
+
+// SeatHoldRequest represents the request payload for holding seats
+// @Description Request payload for holding seats on a departure
+type SeatHoldRequest struct {
+	Seats      []string `json:"seats" example:"12A,12B" description:"Seats to hold, such as 12A (rows 1-99, letters A-K), up to 9" validate:"required"`
+	TTLSeconds int      `json:"ttl_seconds,omitempty" example:"600" description:"How long the hold lasts, in seconds (default 600, at most 1800)"`
+}
+
+// Validate normalizes the seats, applies the default duration and checks the limits
+func (r *SeatHoldRequest) Validate() error {
+	if len(r.Seats) == 0 || len(r.Seats) > MaxSeatsPerHold {
+		return fmt.Errorf("seats must list 1 to %d seats", MaxSeatsPerHold)
+	}
+	listed := make(map[string]bool, len(r.Seats))
+	for i, seat := range r.Seats {
+		seat = strings.ToUpper(strings.TrimSpace(seat))
+		if !seatPattern.MatchString(seat) {
+			return fmt.Errorf("invalid seat %q: use a row from 1 to 99 and a letter from A to K, e.g. 12A", r.Seats[i])
+		}
+		if listed[seat] {
+			return fmt.Errorf("seat %s is listed twice", seat)
+		}
+		listed[seat] = true
+		r.Seats[i] = seat
+	}
+
+	if r.TTLSeconds == 0 {
+		r.TTLSeconds = DefaultSeatHoldSeconds
+	}
+	if r.TTLSeconds < 1 || r.TTLSeconds > MaxSeatHoldSeconds {
+		return fmt.Errorf("ttl_seconds must be between 1 and %d", MaxSeatHoldSeconds)
+	}
+	return nil
+}
+
+// SeatStatus is a taken seat on a departure's seat map
+// @Description Held or assigned seat
+type SeatStatus struct {
+	Seat      string     `json:"seat" example:"12A" description:"Seat"`
+	Status    string     `json:"status" example:"HELD" enums:"HELD,ASSIGNED" description:"HELD while a booking is made, ASSIGNED once booked"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" example:"2024-07-12T19:10:00Z" description:"When a held seat is released unless booked"`
+}
+
+// SeatMapResponse lists the taken seats of a departure
+// @Description Held and assigned seats of a departure
+type SeatMapResponse struct {
+	FlightNumber string       `json:"flight_number" example:"AA1234" description:"Flight number"`
+	Date         string       `json:"date" example:"2024-12-25" description:"Departure date"`
+	Seats        []SeatStatus `json:"seats" description:"Taken seats, by row and letter; seats not listed are free"`
+	Count        int          `json:"count" example:"2" description:"Number of taken seats"`
+}
+
+// SortSeats orders seats by row, then letter
+func SortSeats(seats []string) {
+	sort.Slice(seats, func(i, j int) bool {
+		return SeatLess(seats[i], seats[j])
+	})
+}
+
+// SeatLess reports whether seat a comes before seat b, by row, then letter
+func SeatLess(a, b string) bool {
+	rowA, _ := strconv.Atoi(a[:len(a)-1])
+	rowB, _ := strconv.Atoi(b[:len(b)-1])
+	if rowA != rowB {
+		return rowA < rowB
+	}
+	return a < b
+}
+
+// SeatLabel returns the ticket label holding the seat assigned to the nth
+// passenger, counting from 1
+func SeatLabel(n int) string {
+	return fmt.Sprintf("%s%d", seatLabelPrefix, n)
+}
+
+// IsSeatLabel reports whether a label key holds an assigned seat; other
+// labels starting with seat_, such as seat_preference, are free to use
+func IsSeatLabel(key string) bool {
+	n, err := strconv.Atoi(strings.TrimPrefix(key, seatLabelPrefix))
+	return strings.HasPrefix(key, seatLabelPrefix) && err == nil && n > 0
+}
+
+// TicketSeats returns the seats assigned to a ticket, from its labels, by passenger
+func TicketSeats(ticket *FlightTicket) []string {
+	var seats []string
+	for n := 1; ; n++ {
+		seat, ok := ticket.Labels[SeatLabel(n)]
+		if !ok {
+			return seats
+		}
+		seats = append(seats, strings.ToUpper(seat))
+	}
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MaxSpecialRequestsPerPassenger limits the SSR codes of one passenger
+const MaxSpecialRequestsPerPassenger = 4
+
+// specialRequestLabelPrefix starts the reserved ticket labels holding the SSR
+// codes of a passenger, e.g. ssr_2: vgml-wchr
+const specialRequestLabelPrefix = "ssr_"
+
+// SpecialServiceCode describes an IATA special service request code
+type SpecialServiceCode struct {
+	Description string
+	// PassengerType restricts the code to one passenger type; empty allows any
+	PassengerType string
+}
+
+// SpecialServiceCodes lists the SSR codes bookings accept
+var SpecialServiceCodes = map[string]SpecialServiceCode{
+	"WCHR": {Description: "Wheelchair for the distance to the aircraft"},
+	"WCHS": {Description: "Wheelchair, cannot use stairs"},
+	"WCHC": {Description: "Wheelchair to and from the seat"},
+	"BLND": {Description: "Blind passenger"},
+	"DEAF": {Description: "Deaf passenger"},
+	"VGML": {Description: "Vegetarian meal"},
+	"KSML": {Description: "Kosher meal"},
+	"MOML": {Description: "Muslim meal"},
+	"DBML": {Description: "Diabetic meal"},
+	"CHML": {Description: "Child meal"},
+	"UMNR": {Description: "Unaccompanied minor", PassengerType: PassengerChild},
+}
+
+// SpecialServiceRequest is an SSR code attached to one passenger of a ticket
+// @Description Special service request of a passenger
+type SpecialServiceRequest struct {
+	Passenger   int    `json:"passenger" example:"1" description:"Passenger number on the ticket, from 1; adults come first, then children, then infants"`
+	Code        string `json:"code" example:"WCHR" enums:"WCHR,WCHS,WCHC,BLND,DEAF,VGML,KSML,MOML,DBML,CHML,UMNR" description:"IATA SSR code"`
+	Description string `json:"description,omitempty" example:"Wheelchair for the distance to the aircraft" description:"What the code asks for (set by the service)"`
+}
+
+// SpecialRequestLabel returns the ticket label holding the SSR codes of the
+// nth passenger, counting from 1
+func SpecialRequestLabel(n int) string {
+	return fmt.Sprintf("%s%d", specialRequestLabelPrefix, n)
+}
+
+// IsSpecialRequestLabel reports whether a label key holds SSR codes
+func IsSpecialRequestLabel(key string) bool {
+	return strings.HasPrefix(key, specialRequestLabelPrefix)
+}
+
+// ValidateSpecialRequests normalizes the codes and checks them against the
+// known codes and the passengers of the ticket
+func ValidateSpecialRequests(requests []SpecialServiceRequest, types PassengerTypes) error {
+	perPassenger := make(map[int]map[string]bool)
+	for i := range requests {
+		request := &requests[i]
+		request.Code = strings.ToUpper(strings.TrimSpace(request.Code))
+		code, ok := SpecialServiceCodes[request.Code]
+		if !ok {
+			return fmt.Errorf("special_requests[%d]: unknown SSR code %q", i, request.Code)
+		}
+		if request.Passenger < 1 || request.Passenger > types.Total() {
+			return fmt.Errorf("special_requests[%d]: passenger must be between 1 and %d", i, types.Total())
+		}
+		if code.PassengerType != "" && types.Type(request.Passenger-1) != code.PassengerType {
+			return fmt.Errorf("special_requests[%d]: %s is only for %s passengers", i, request.Code, code.PassengerType)
+		}
+		codes := perPassenger[request.Passenger]
+		if codes == nil {
+			codes = make(map[string]bool)
+			perPassenger[request.Passenger] = codes
+		}
+		if codes[request.Code] {
+			return fmt.Errorf("special_requests[%d]: %s is listed twice for passenger %d", i, request.Code, request.Passenger)
+		}
+		codes[request.Code] = true
+		if len(codes) > MaxSpecialRequestsPerPassenger {
+			return fmt.Errorf("at most %d SSR codes per passenger are allowed", MaxSpecialRequestsPerPassenger)
+		}
+	}
+	return nil
+}
+
+// SpecialRequestLabels returns the labels recording the SSR codes, one label per passenger
+func SpecialRequestLabels(requests []SpecialServiceRequest) map[string]string {
+	codes := make(map[int][]string)
+	for _, request := range requests {
+		codes[request.Passenger] = append(codes[request.Passenger], strings.ToLower(request.Code))
+	}
+	labels := make(map[string]string, len(codes))
+	for passenger, list := range codes {
+		sort.Strings(list)
+		labels[SpecialRequestLabel(passenger)] = strings.Join(list, "-")
+	}
+	return labels
+}
+
+// TicketSpecialRequests returns the SSRs of a ticket, from its labels, by passenger and code
+func TicketSpecialRequests(ticket *FlightTicket) []SpecialServiceRequest {
+	var requests []SpecialServiceRequest
+	for key, value := range ticket.Labels {
+		if !IsSpecialRequestLabel(key) {
+			continue
+		}
+		passenger, err := strconv.Atoi(strings.TrimPrefix(key, specialRequestLabelPrefix))
+		if err != nil {
+			continue
+		}
+		for _, code := range strings.Split(value, "-") {
+			code = strings.ToUpper(code)
+			if known, ok := SpecialServiceCodes[code]; ok {
+				requests = append(requests, SpecialServiceRequest{Passenger: passenger, Code: code, Description: known.Description})
+			}
+		}
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		if requests[i].Passenger != requests[j].Passenger {
+			return requests[i].Passenger < requests[j].Passenger
+		}
+		return requests[i].Code < requests[j].Code
+	})
+	return requests
+}
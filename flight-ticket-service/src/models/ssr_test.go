@@ -0,0 +1,39 @@
+package models
+
+import "testing"
+
+func TestSpecialRequests(t *testing.T) {
+	types := PassengerTypes{Adults: 1, Children: 1}
+	requests := []SpecialServiceRequest{{Passenger: 1, Code: " wchr"}, {Passenger: 2, Code: "UMNR"}, {Passenger: 1, Code: "VGML"}}
+	if err := ValidateSpecialRequests(requests, types); err != nil {
+		t.Fatalf("ValidateSpecialRequests failed: %v", err)
+	}
+	labels := SpecialRequestLabels(requests)
+	if labels["ssr_1"] != "vgml-wchr" || labels["ssr_2"] != "umnr" || len(labels) != 2 {
+		t.Errorf("Unexpected labels %v", labels)
+	}
+
+	got := TicketSpecialRequests(&FlightTicket{Passengers: 2, Labels: labels})
+	want := []string{"VGML", "WCHR", "UMNR"}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d requests, got %+v", len(want), got)
+	}
+	for i, code := range want {
+		if got[i].Code != code || got[i].Description == "" {
+			t.Errorf("Request %d = %+v, want %s", i, got[i], code)
+		}
+	}
+
+	invalid := map[string][]SpecialServiceRequest{
+		"unknown-code": {{Passenger: 1, Code: "XXXX"}},
+		"no-passenger": {{Passenger: 3, Code: "WCHR"}},
+		"adult-umnr":   {{Passenger: 1, Code: "UMNR"}},
+		"listed-twice": {{Passenger: 1, Code: "WCHR"}, {Passenger: 1, Code: "wchr"}},
+		"too-many":     {{Passenger: 1, Code: "WCHR"}, {Passenger: 1, Code: "VGML"}, {Passenger: 1, Code: "BLND"}, {Passenger: 1, Code: "DEAF"}, {Passenger: 1, Code: "DBML"}},
+	}
+	for name, requests := range invalid {
+		if err := ValidateSpecialRequests(requests, types); err == nil {
+			t.Errorf("Expected an error for %s", name)
+		}
+	}
+}
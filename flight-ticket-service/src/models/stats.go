@@ -0,0 +1,80 @@
+package models
+
+import "time"
+
+// OperationCounts holds Firestore document operation counts
+// @Description Firestore document operation counts
+type OperationCounts struct {
+	Reads   int64 `json:"reads" example:"1520" description:"Document reads"`
+	Writes  int64 `json:"writes" example:"230" description:"Document writes"`
+	Deletes int64 `json:"deletes" example:"0" description:"Document deletes"`
+}
+
+// EndpointUsage holds the Firestore usage attributed to one endpoint
+// @Description Firestore usage of an endpoint
+type EndpointUsage struct {
+	Endpoint string `json:"endpoint" example:"GET /tickets" description:"HTTP method and route pattern"`
+	Requests int64  `json:"requests" example:"38" description:"Requests that touched Firestore"`
+	OperationCounts
+	EstimatedMonthlyCostUSD float64 `json:"estimated_monthly_cost_usd" example:"0.42" description:"Projected monthly cost of this endpoint, before the free tier"`
+}
+
+// FirestorePricing is the price per 100,000 document operations in USD
+// @Description Firestore price per 100,000 operations
+type FirestorePricing struct {
+	Tier   string  `json:"tier" example:"regional" description:"Pricing tier (regional or multi-region)"`
+	Read   float64 `json:"read" example:"0.03" description:"USD per 100,000 reads"`
+	Write  float64 `json:"write" example:"0.09" description:"USD per 100,000 writes"`
+	Delete float64 `json:"delete" example:"0.01" description:"USD per 100,000 deletes"`
+}
+
+// UsageStats is the response for GET /admin/stats
+// @Description Firestore quota usage and cost estimate
+type UsageStats struct {
+	Backend                 string           `json:"backend" example:"firestore" description:"Configured storage backend"`
+	Since                   time.Time        `json:"since" example:"2024-07-12T19:00:00Z" description:"When counting started (process start)"`
+	UptimeSeconds           float64          `json:"uptime_seconds" example:"3600" description:"Seconds since counting started"`
+	Totals                  OperationCounts  `json:"totals" description:"Total operations since start"`
+	Endpoints               []EndpointUsage  `json:"endpoints" description:"Usage per endpoint, most expensive first"`
+	Pricing                 FirestorePricing `json:"pricing" description:"Prices used for the estimate"`
+	EstimatedMonthlyCostUSD float64          `json:"estimated_monthly_cost_usd" example:"1.25" description:"Projected monthly cost at the current rate, after the daily free tier"`
+}
+
+// MemoryStats is a subset of runtime.MemStats
+// @Description Go heap and garbage collector statistics
+type MemoryStats struct {
+	HeapAllocBytes   uint64    `json:"heap_alloc_bytes" example:"8388608" description:"Bytes of allocated heap objects"`
+	HeapInuseBytes   uint64    `json:"heap_inuse_bytes" example:"10485760" description:"Bytes in in-use heap spans"`
+	HeapObjects      uint64    `json:"heap_objects" example:"52000" description:"Number of allocated heap objects"`
+	SysBytes         uint64    `json:"sys_bytes" example:"25165824" description:"Total bytes obtained from the OS"`
+	TotalAllocBytes  uint64    `json:"total_alloc_bytes" example:"104857600" description:"Cumulative bytes allocated"`
+	NumGC            uint32    `json:"num_gc" example:"42" description:"Completed GC cycles"`
+	LastGC           time.Time `json:"last_gc" example:"2024-07-12T19:00:00Z" description:"Time of the last GC"`
+	PauseTotalNs     uint64    `json:"pause_total_ns" example:"1500000" description:"Cumulative GC pause time in nanoseconds"`
+	GCCPUFraction    float64   `json:"gc_cpu_fraction" example:"0.001" description:"Fraction of CPU time used by the GC"`
+	NextGCBytes      uint64    `json:"next_gc_bytes" example:"16777216" description:"Target heap size of the next GC"`
+	StackInuseBytes  uint64    `json:"stack_inuse_bytes" example:"1048576" description:"Bytes in stack spans"`
+	MemoryLimitBytes int64     `json:"memory_limit_bytes" example:"536870912" description:"Soft memory limit (GOMEMLIMIT)"`
+}
+
+// BuildInfo describes the running binary
+// @Description Build information of the running binary
+type BuildInfo struct {
+	GoVersion   string `json:"go_version" example:"go1.24.5" description:"Go toolchain version"`
+	Path        string `json:"path" example:"flight-ticket-service/src/cmd/server" description:"Main package path"`
+	VCSRevision string `json:"vcs_revision,omitempty" example:"5b6962d" description:"Source revision, when built from a VCS checkout"`
+	VCSTime     string `json:"vcs_time,omitempty" example:"2024-07-12T19:00:00Z" description:"Commit time of the source revision"`
+	VCSModified bool   `json:"vcs_modified,omitempty" example:"false" description:"Whether the working tree had local changes"`
+}
+
+// DebugVars is the response for GET /admin/debug/vars
+// @Description Runtime diagnostics
+type DebugVars struct {
+	Timestamp     time.Time   `json:"timestamp" example:"2024-07-12T19:00:00Z" description:"When the snapshot was taken"`
+	UptimeSeconds float64     `json:"uptime_seconds" example:"3600" description:"Seconds since the server started"`
+	Goroutines    int         `json:"goroutines" example:"24" description:"Number of goroutines"`
+	NumCPU        int         `json:"num_cpu" example:"2" description:"Logical CPUs available"`
+	GOMAXPROCS    int         `json:"gomaxprocs" example:"2" description:"Current GOMAXPROCS"`
+	Memory        MemoryStats `json:"memory" description:"Heap and GC statistics"`
+	Build         BuildInfo   `json:"build" description:"Build information"`
+}
@@ -0,0 +1,138 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// TenantLabel is the reserved ticket label holding the ID of the tenant that booked the ticket
+const TenantLabel = "tenant"
+
+// MaxBrandingLength is the longest branding text, in characters
+const MaxBrandingLength = 80
+
+// BookingRules restrict the tickets a tenant can book. Zero fields do not restrict.
+// @Description Booking rules of a tenant
+type BookingRules struct {
+	MaxPassengers   int `json:"max_passengers,omitempty" firestore:"max_passengers,omitempty" example:"4" description:"Most passengers on one ticket"`
+	MinAdvanceHours int `json:"min_advance_hours,omitempty" firestore:"min_advance_hours,omitempty" example:"24" description:"Hours before departure when booking closes"`
+	MaxAdvanceDays  int `json:"max_advance_days,omitempty" firestore:"max_advance_days,omitempty" example:"180" description:"Days before departure when booking opens"`
+}
+
+// Check returns why a ticket breaks the rules, or nil; the advance booking
+// window is measured from now
+func (b BookingRules) Check(ticket *FlightTicket, now time.Time) error {
+	if b.MaxPassengers > 0 && ticket.Passengers > b.MaxPassengers {
+		return fmt.Errorf("at most %d passengers can be booked on one ticket", b.MaxPassengers)
+	}
+	advance := ticket.DepartureTime.Sub(now)
+	if b.MinAdvanceHours > 0 && advance < time.Duration(b.MinAdvanceHours)*time.Hour {
+		return fmt.Errorf("flights must be booked at least %d hours before departure", b.MinAdvanceHours)
+	}
+	if b.MaxAdvanceDays > 0 && advance > time.Duration(b.MaxAdvanceDays)*24*time.Hour {
+		return fmt.Errorf("flights can be booked at most %d days before departure", b.MaxAdvanceDays)
+	}
+	return nil
+}
+
+// Branding is printed on the documents generated for a tenant
+// @Description Branding of generated documents
+type Branding struct {
+	DisplayName string `json:"display_name,omitempty" firestore:"display_name,omitempty" example:"Acme Travel" description:"Name printed in document headers"`
+	Contact     string `json:"contact,omitempty" firestore:"contact,omitempty" example:"ops@acme.example" description:"Contact printed in document headers"`
+	Footer      string `json:"footer,omitempty" firestore:"footer,omitempty" example:"Acme Travel - for internal use" description:"Line printed at the end of documents"`
+}
+
+// IsZero reports whether no branding is set
+func (b Branding) IsZero() bool {
+	return b == Branding{}
+}
+
+// Tenant is a customer of the service with its own configuration overrides,
+// applied to the requests of its API keys and to the tickets it booked
+// @Description Tenant configuration overrides
+type Tenant struct {
+	ID                    string            `json:"id" firestore:"id" example:"acme" description:"Tenant ID, stored in the tenant label of its tickets"`
+	Name                  string            `json:"name" firestore:"name" example:"Acme Travel" description:"Tenant name"`
+	APIKeys               []string          `json:"api_keys" firestore:"api_keys" example:"acme-desk" description:"Names of the API keys whose requests belong to the tenant"`
+	NotificationTemplates map[string]string `json:"notification_templates,omitempty" firestore:"notification_templates,omitempty" example:"ticket_changed:acme-ticket-changed" description:"Template IDs by notification type, for the delivery service"`
+	BookingRules          BookingRules      `json:"booking_rules" firestore:"booking_rules" description:"Booking rules"`
+	Branding              Branding          `json:"branding" firestore:"branding" description:"Branding of generated documents"`
+	UpdatedAt             time.Time         `json:"updated_at" firestore:"updated_at" example:"2024-07-12T19:00:00Z" description:"Last update timestamp"`
+}
+
+// TenantRequest represents the request payload for creating or replacing a tenant
+// @Description Request payload for saving a tenant
+type TenantRequest struct {
+	Name                  string            `json:"name" example:"Acme Travel" description:"Tenant name" validate:"required"`
+	APIKeys               []string          `json:"api_keys" example:"acme-desk" description:"Names of the API keys whose requests belong to the tenant"`
+	NotificationTemplates map[string]string `json:"notification_templates,omitempty" example:"ticket_changed:acme-ticket-changed" description:"Template IDs by notification type (departure_reminder, ticket_changed)"`
+	BookingRules          BookingRules      `json:"booking_rules" description:"Booking rules"`
+	Branding              Branding          `json:"branding" description:"Branding of generated documents"`
+}
+
+// TenantListResponse represents the response for listing tenants
+// @Description Tenants
+type TenantListResponse struct {
+	Tenants []*Tenant `json:"tenants" description:"Tenants, by ID"`
+	Count   int       `json:"count" example:"1" description:"Number of tenants"`
+}
+
+// ValidateTenantID checks that a tenant ID is usable as a label value and in a URL path
+func ValidateTenantID(id string) error {
+	if !labelKeyPattern.MatchString(id) {
+		return fmt.Errorf("invalid tenant ID %q: use up to 63 lowercase letters, digits, underscores or dashes, starting with a letter", id)
+	}
+	return nil
+}
+
+// Validate normalizes and checks the tenant's name, API keys, templates, rules and branding
+func (r *TenantRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	seen := make(map[string]bool, len(r.APIKeys))
+	keys := make([]string, 0, len(r.APIKeys))
+	for _, key := range r.APIKeys {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return fmt.Errorf("API key names must not be empty")
+		}
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	r.APIKeys = keys
+
+	for notificationType, template := range r.NotificationTemplates {
+		if !labelKeyPattern.MatchString(notificationType) {
+			return fmt.Errorf("invalid notification type %q", notificationType)
+		}
+		if strings.TrimSpace(template) == "" {
+			return fmt.Errorf("template of %s must not be empty", notificationType)
+		}
+	}
+
+	rules := r.BookingRules
+	if rules.MaxPassengers < 0 || rules.MinAdvanceHours < 0 || rules.MaxAdvanceDays < 0 {
+		return fmt.Errorf("booking rules must not be negative")
+	}
+	if rules.MaxAdvanceDays > 0 && rules.MinAdvanceHours >= rules.MaxAdvanceDays*24 {
+		return fmt.Errorf("min_advance_hours must be less than max_advance_days")
+	}
+
+	for name, text := range map[string]*string{"display_name": &r.Branding.DisplayName, "contact": &r.Branding.Contact, "footer": &r.Branding.Footer} {
+		*text = strings.TrimSpace(*text)
+		if utf8.RuneCountInString(*text) > MaxBrandingLength {
+			return fmt.Errorf("branding %s must be at most %d characters", name, MaxBrandingLength)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBookingRulesCheck(t *testing.T) {
+	now := time.Date(2024, 7, 12, 12, 0, 0, 0, time.UTC)
+	rules := BookingRules{MaxPassengers: 4, MinAdvanceHours: 24, MaxAdvanceDays: 90}
+	tests := []struct {
+		name       string
+		passengers int
+		departure  time.Time
+		valid      bool
+	}{
+		{"within rules", 4, now.AddDate(0, 0, 7), true},
+		{"too many passengers", 5, now.AddDate(0, 0, 7), false},
+		{"too late", 1, now.Add(23 * time.Hour), false},
+		{"too early", 1, now.AddDate(0, 0, 91), false},
+	}
+	for _, tt := range tests {
+		err := rules.Check(&FlightTicket{Passengers: tt.passengers, DepartureTime: tt.departure}, now)
+		if (err == nil) != tt.valid {
+			t.Errorf("%s: got %v", tt.name, err)
+		}
+	}
+	if err := (BookingRules{}).Check(&FlightTicket{Passengers: 99, DepartureTime: now}, now); err != nil {
+		t.Errorf("Expected no restrictions by default, got %v", err)
+	}
+}
+
+func TestTenantRequestValidate(t *testing.T) {
+	req := TenantRequest{Name: " Acme ", APIKeys: []string{"b", "a", "b"}}
+	if err := req.Validate(); err != nil || req.Name != "Acme" || len(req.APIKeys) != 2 || req.APIKeys[0] != "a" {
+		t.Errorf("Unexpected normalization %+v: %v", req, err)
+	}
+	for _, invalid := range []TenantRequest{
+		{},
+		{Name: "Acme", APIKeys: []string{" "}},
+		{Name: "Acme", BookingRules: BookingRules{MaxPassengers: -1}},
+		{Name: "Acme", BookingRules: BookingRules{MinAdvanceHours: 48, MaxAdvanceDays: 2}},
+		{Name: "Acme", NotificationTemplates: map[string]string{"ticket_changed": ""}},
+		{Name: "Acme", Branding: Branding{Footer: string(make([]byte, MaxBrandingLength+1))}},
+	} {
+		if err := invalid.Validate(); err == nil {
+			t.Errorf("Expected %+v to be invalid", invalid)
+		}
+	}
+}
@@ -10,39 +10,87 @@ import (
 // FlightTicket represents a flight ticket with standard airline format
 // @Description Flight ticket information
 type FlightTicket struct {
-	ConfirmationID string    `json:"confirmation_id" firestore:"confirmation_id" example:"ABC123" description:"6-character alphanumeric confirmation ID"`
-	Origin         string    `json:"origin" firestore:"origin" example:"JFK" description:"3-letter IATA origin airport code"`
-	Destination    string    `json:"destination" firestore:"destination" example:"LAX" description:"3-letter IATA destination airport code"`
-	DepartureDate  time.Time `json:"departure_date" firestore:"departure_date" example:"2024-12-25T00:00:00Z" description:"Departure date"`
-	DepartureTime  time.Time `json:"departure_time" firestore:"departure_time" example:"2024-01-01T14:30:00Z" description:"Departure time"`
-	FlightNumber   string    `json:"flight_number" firestore:"flight_number" example:"AA1234" description:"Flight number in airline format"`
-	Passengers     int       `json:"passengers" firestore:"passengers" example:"2" description:"Number of passengers"`
-	CreatedAt      time.Time `json:"created_at" firestore:"created_at" example:"2024-07-12T19:00:00Z" description:"Ticket creation timestamp"`
-	UpdatedAt      time.Time `json:"updated_at" firestore:"updated_at" example:"2024-07-12T19:00:00Z" description:"Last update timestamp"`
-	Status         string    `json:"status" firestore:"status" example:"CONFIRMED" enums:"CONFIRMED,CANCELLED,PENDING" description:"Ticket status"`
+	ConfirmationID  string                  `json:"confirmation_id" example:"ABC123" description:"6-character alphanumeric confirmation ID"`
+	Origin          string                  `json:"origin" example:"JFK" description:"3-letter IATA origin airport code"`
+	Destination     string                  `json:"destination" example:"LAX" description:"3-letter IATA destination airport code"`
+	DepartureDate   time.Time               `json:"departure_date" example:"2024-12-25T00:00:00Z" description:"Departure date"`
+	DepartureTime   time.Time               `json:"departure_time" example:"2024-01-01T14:30:00Z" description:"Departure time"`
+	FlightNumber    string                  `json:"flight_number" example:"AA1234" description:"Flight number in airline format"`
+	Passengers      int                     `json:"passengers" example:"2" description:"Number of passengers"`
+	CreatedAt       time.Time               `json:"created_at" example:"2024-07-12T19:00:00Z" description:"Ticket creation timestamp"`
+	UpdatedAt       time.Time               `json:"updated_at" example:"2024-07-12T19:00:00Z" description:"Last update timestamp"`
+	Status          string                  `json:"status" example:"CONFIRMED" enums:"CONFIRMED,CHECKED_IN,CANCELLED,PENDING,DISRUPTED,REVIEW" description:"Ticket status"`
+	Price           *Price                  `json:"price,omitempty" description:"Ticket price"`
+	Labels          map[string]string       `json:"labels,omitempty" example:"corporate_account:acme,campaign:summer-sale" description:"Key/value labels for grouping and search"`
+	CheckIn         *CheckInRecord          `json:"check_in,omitempty" description:"Passengers checked in on the ticket"`
+	Schedule        *TicketSchedule         `json:"schedule,omitempty" description:"Check-in and boarding times, computed from the departure time"`
+	Notes           []*TicketNote           `json:"notes,omitempty" description:"Internal agent notes, only included for admin callers"`
+	ExpiresAt       *time.Time              `json:"expires_at,omitempty" example:"2024-07-13T19:00:00Z" description:"When a sandbox ticket is deleted; not set on live tickets"`
+	PassengerTypes  *PassengerTypes         `json:"passenger_types,omitempty" description:"Passengers by type, for tickets with children or infants"`
+	SpecialRequests []SpecialServiceRequest `json:"special_requests,omitempty" description:"Special service requests (SSRs) of the passengers"`
+	AssignedSeats   []string                `json:"assigned_seats,omitempty" example:"12A,12B" description:"Seats assigned from a seat hold when booking, by passenger"`
+	Cabin           string                  `json:"cabin,omitempty" example:"J" description:"Cabin the ticket was upgraded to; not set for economy"`
+	International   bool                    `json:"international,omitempty" example:"true" description:"Whether the flight crosses a border"`
+	Advisories      []TravelAdvisory        `json:"travel_advisories,omitempty" description:"Entry requirements of the destination country, for international flights"`
+	DocumentIssues  []DocumentIssue         `json:"document_issues,omitempty" description:"Travel document flags of the ticket's saved travelers, returned when booking"`
+	Risk            *RiskAssessment         `json:"risk,omitempty" description:"Risk score of a booking held for review, from its reserved labels"`
+}
+
+// TicketSchedule holds the airport milestones of a flight, in the origin airport's time zone
+// @Description Check-in and boarding times
+type TicketSchedule struct {
+	TimeZone       string    `json:"time_zone" example:"America/New_York" description:"IANA time zone of the origin airport"`
+	Departure      time.Time `json:"departure" example:"2024-12-25T14:30:00-05:00" description:"Departure time"`
+	CheckInOpens   time.Time `json:"check_in_opens" example:"2024-12-24T14:30:00-05:00" description:"When online check-in opens"`
+	CheckInCloses  time.Time `json:"check_in_closes" example:"2024-12-25T13:30:00-05:00" description:"When online check-in closes"`
+	BoardingStarts time.Time `json:"boarding_starts" example:"2024-12-25T13:50:00-05:00" description:"When boarding starts"`
+	GateCloses     time.Time `json:"gate_closes" example:"2024-12-25T14:15:00-05:00" description:"When the gate closes"`
+}
+
+// DefaultBaseFare is the per-passenger fare (in the base currency) used when none is provided
+const DefaultBaseFare = 199.00
+
+// Price represents a ticket price together with the exchange rate used to derive it
+// @Description Ticket price with base amount and exchange rate
+type Price struct {
+	Amount        float64   `json:"amount" example:"366.16" description:"Total price in the display currency"`
+	Currency      string    `json:"currency" example:"EUR" description:"ISO 4217 display currency code"`
+	BaseAmount    float64   `json:"base_amount" example:"398.00" description:"Total price in the base currency"`
+	BaseCurrency  string    `json:"base_currency" example:"USD" description:"ISO 4217 base currency code"`
+	ExchangeRate  float64   `json:"exchange_rate" example:"0.92" description:"Rate used to convert from the base currency"`
+	RateAsOf      time.Time `json:"rate_as_of" example:"2024-07-12T00:00:00Z" description:"Publication date of the exchange rate"`
+	VoucherCredit float64   `json:"voucher_credit,omitempty" example:"250.00" description:"Voucher credit in the base currency, already taken off the amounts"`
 }
 
 // CreateTicketRequest represents the request payload for creating a ticket
 // @Description Request payload for creating a new flight ticket
 type CreateTicketRequest struct {
-	Origin        string `json:"origin" example:"JFK" description:"3-letter IATA origin airport code" validate:"required"`
-	Destination   string `json:"destination" example:"LAX" description:"3-letter IATA destination airport code" validate:"required"`
-	DepartureDate string `json:"departure_date" example:"2024-12-25" description:"Departure date in YYYY-MM-DD format" validate:"required"`
-	DepartureTime string `json:"departure_time" example:"14:30" description:"Departure time in HH:MM format" validate:"required"`
-	FlightNumber  string `json:"flight_number,omitempty" example:"AA1234" description:"Flight number (optional, will be generated if not provided)"`
-	Passengers    int    `json:"passengers" example:"2" description:"Number of passengers" validate:"required,min=1"`
+	Origin          string                  `json:"origin" example:"JFK" description:"3-letter IATA origin airport code" validate:"required"`
+	Destination     string                  `json:"destination" example:"LAX" description:"3-letter IATA destination airport code" validate:"required"`
+	DepartureDate   string                  `json:"departure_date" example:"2024-12-25" description:"Departure date in YYYY-MM-DD format" validate:"required"`
+	DepartureTime   string                  `json:"departure_time" example:"14:30" description:"Departure time in HH:MM format" validate:"required"`
+	FlightNumber    string                  `json:"flight_number,omitempty" example:"AA1234" description:"Flight number (optional, will be generated if not provided)"`
+	Passengers      int                     `json:"passengers" example:"2" description:"Number of passengers, infants included (optional with passenger_types or traveler_ids)" validate:"min=0"`
+	PassengerTypes  *PassengerTypes         `json:"passenger_types,omitempty" description:"Passengers by type (optional, all adults by default); passengers defaults to their total"`
+	SpecialRequests []SpecialServiceRequest `json:"special_requests,omitempty" description:"Special service requests (SSRs) of the passengers (optional, up to 4 codes per passenger)"`
+	TravelerIDs     []string                `json:"traveler_ids,omitempty" example:"3f9a1c0e5b7d" description:"IDs of saved travelers of the caller flying on the ticket (optional, up to 9)"`
+	SeatHoldID      string                  `json:"seat_hold_id,omitempty" example:"3f9a1c0e7b2d4a6f8e1c3b5d7f9a0c2e" description:"Seat hold on the same departure whose seats are assigned to the passengers (optional)"`
+	BaseFare        float64                 `json:"base_fare,omitempty" example:"199.00" description:"Fare per passenger in the base currency (optional, defaults to 199.00)"`
+	Currency        string                  `json:"currency,omitempty" example:"EUR" description:"ISO 4217 currency to price the ticket in (optional, defaults to USD)"`
+	Labels          map[string]string       `json:"labels,omitempty" example:"corporate_account:acme" description:"Key/value labels (optional, lowercase letters, digits, underscores and dashes)"`
 }
 
 // UpdateTicketRequest represents the request payload for updating a ticket
 // @Description Request payload for updating an existing flight ticket
 type UpdateTicketRequest struct {
-	Origin        string `json:"origin,omitempty" example:"JFK" description:"3-letter IATA origin airport code"`
-	Destination   string `json:"destination,omitempty" example:"LAX" description:"3-letter IATA destination airport code"`
-	DepartureDate string `json:"departure_date,omitempty" example:"2024-12-25" description:"Departure date in YYYY-MM-DD format"`
-	DepartureTime string `json:"departure_time,omitempty" example:"14:30" description:"Departure time in HH:MM format"`
-	FlightNumber  string `json:"flight_number,omitempty" example:"AA1234" description:"Flight number"`
-	Passengers    int    `json:"passengers,omitempty" example:"2" description:"Number of passengers" validate:"min=1"`
-	Status        string `json:"status,omitempty" example:"CONFIRMED" enums:"CONFIRMED,CANCELLED,PENDING" description:"Ticket status"`
+	Origin        string            `json:"origin,omitempty" example:"JFK" description:"3-letter IATA origin airport code"`
+	Destination   string            `json:"destination,omitempty" example:"LAX" description:"3-letter IATA destination airport code"`
+	DepartureDate string            `json:"departure_date,omitempty" example:"2024-12-25" description:"Departure date in YYYY-MM-DD format"`
+	DepartureTime string            `json:"departure_time,omitempty" example:"14:30" description:"Departure time in HH:MM format"`
+	FlightNumber  string            `json:"flight_number,omitempty" example:"AA1234" description:"Flight number"`
+	Passengers    int               `json:"passengers,omitempty" example:"2" description:"Number of passengers" validate:"min=1"`
+	Status        string            `json:"status,omitempty" example:"CONFIRMED" enums:"CONFIRMED,CANCELLED,PENDING,DISRUPTED" description:"Ticket status"`
+	Labels        map[string]string `json:"labels,omitempty" example:"corporate_account:acme" description:"Replaces all labels of the ticket; an empty object removes them"`
 }
 
 // TicketListResponse represents the response for listing tickets
@@ -93,17 +141,17 @@ func ValidateAirportCode(code string) bool {
 // NewFlightTicket creates a new flight ticket with generated confirmation ID
 func NewFlightTicket(origin, destination string, departureDate, departureTime time.Time, flightNumber string, passengers int) *FlightTicket {
 	now := time.Now()
-	
+
 	// Validate airport codes
 	if !ValidateAirportCode(origin) || !ValidateAirportCode(destination) {
 		return nil
 	}
-	
+
 	// Generate flight number if not provided
 	if flightNumber == "" {
 		flightNumber = GenerateFlightNumber("AA")
 	}
-	
+
 	return &FlightTicket{
 		ConfirmationID: GenerateConfirmationID(),
 		Origin:         strings.ToUpper(origin),
@@ -0,0 +1,176 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Traveler profile limits
+const (
+	MaxTravelerNameLength = 50
+	MaxTravelerDocuments  = 5
+	MaxTravelersPerTicket = 9
+	MaxTravelersPerOwner  = 100
+	maxTravelerAge        = 120 // years
+)
+
+// Travel document types
+const (
+	DocumentPassport   = "PASSPORT"
+	DocumentNationalID = "NATIONAL_ID"
+)
+
+var (
+	travelerIDPattern     = regexp.MustCompile(`^[a-f0-9]{12}$`)
+	documentNumberPattern = regexp.MustCompile(`^[A-Z0-9]{1,20}$`)
+	countryCodePattern    = regexp.MustCompile(`^[A-Z]{2}$`)
+)
+
+// travelerLabelPrefix starts the reserved ticket labels referencing saved travelers
+const travelerLabelPrefix = "traveler_"
+
+// TravelerLabel returns the ticket label holding the traveler ID of the nth
+// passenger, counting from 1
+func TravelerLabel(n int) string {
+	return fmt.Sprintf("%s%d", travelerLabelPrefix, n)
+}
+
+// IsTravelerLabel reports whether a label key references a saved traveler
+func IsTravelerLabel(key string) bool {
+	return strings.HasPrefix(key, travelerLabelPrefix)
+}
+
+// ValidateTravelerID checks the format of a traveler ID
+func ValidateTravelerID(id string) bool {
+	return travelerIDPattern.MatchString(id)
+}
+
+// TravelDocument is a passport or identity card of a traveler
+// @Description Travel document
+type TravelDocument struct {
+	Type           string `json:"type" firestore:"type" example:"PASSPORT" enums:"PASSPORT,NATIONAL_ID" description:"Document type"`
+	Number         string `json:"number" firestore:"number" example:"X1234567" description:"Document number (letters and digits)"`
+	IssuingCountry string `json:"issuing_country" firestore:"issuing_country" example:"US" description:"ISO 3166-1 alpha-2 code of the issuing country"`
+	ExpiresOn      string `json:"expires_on" firestore:"expires_on" example:"2030-05-31" description:"Expiry date (YYYY-MM-DD)"`
+}
+
+// Traveler is a passenger profile saved for repeat bookings
+// @Description Saved passenger details
+type Traveler struct {
+	ID            string           `json:"id" firestore:"id" example:"3f9a1c0e5b7d" description:"Traveler ID, referenced in traveler_ids when booking"`
+	Owner         string           `json:"owner" firestore:"owner" example:"desk" description:"Name of the API key the profile belongs to"`
+	FirstName     string           `json:"first_name" firestore:"first_name" example:"John" description:"Given name as on the travel document"`
+	LastName      string           `json:"last_name" firestore:"last_name" example:"Doe" description:"Surname as on the travel document"`
+	DateOfBirth   string           `json:"date_of_birth" firestore:"date_of_birth" example:"1985-04-12" description:"Date of birth (YYYY-MM-DD)"`
+	Documents     []TravelDocument `json:"documents,omitempty" firestore:"documents,omitempty" description:"Travel documents"`
+	LoyaltyNumber string           `json:"loyalty_number,omitempty" firestore:"loyalty_number,omitempty" example:"AA12345678" description:"Frequent flyer number"`
+	CreatedAt     time.Time        `json:"created_at" firestore:"created_at" example:"2024-07-12T19:00:00Z" description:"Profile creation timestamp"`
+	UpdatedAt     time.Time        `json:"updated_at" firestore:"updated_at" example:"2024-07-12T19:00:00Z" description:"Last update timestamp"`
+}
+
+// PassengerName returns the name as printed on boarding passes, SURNAME/GIVEN
+func (t *Traveler) PassengerName() string {
+	return strings.ToUpper(t.LastName + "/" + t.FirstName)
+}
+
+// TravelerRequest represents the request payload for saving a traveler
+// @Description Request payload for creating or replacing a traveler profile
+type TravelerRequest struct {
+	FirstName     string           `json:"first_name" example:"John" description:"Given name as on the travel document" validate:"required"`
+	LastName      string           `json:"last_name" example:"Doe" description:"Surname as on the travel document" validate:"required"`
+	DateOfBirth   string           `json:"date_of_birth" example:"1985-04-12" description:"Date of birth (YYYY-MM-DD)" validate:"required"`
+	Documents     []TravelDocument `json:"documents,omitempty" description:"Travel documents (up to 5)"`
+	LoyaltyNumber string           `json:"loyalty_number,omitempty" example:"AA12345678" description:"Frequent flyer number (optional, letters and digits)"`
+}
+
+// TravelerListResponse represents the response for listing travelers
+// @Description Traveler profiles of the caller
+type TravelerListResponse struct {
+	Travelers []*Traveler `json:"travelers" description:"Travelers, by surname and given name"`
+	Count     int         `json:"count" example:"2" description:"Number of travelers"`
+}
+
+// Validate normalizes and checks the traveler's details; dates are read against now
+func (r *TravelerRequest) Validate(now time.Time) error {
+	r.FirstName = strings.TrimSpace(r.FirstName)
+	r.LastName = strings.TrimSpace(r.LastName)
+	if err := validateTravelerName("first_name", r.FirstName); err != nil {
+		return err
+	}
+	if err := validateTravelerName("last_name", r.LastName); err != nil {
+		return err
+	}
+
+	birth, err := time.Parse("2006-01-02", r.DateOfBirth)
+	if err != nil {
+		return fmt.Errorf("invalid date_of_birth %q: use YYYY-MM-DD", r.DateOfBirth)
+	}
+	if birth.After(now) || birth.Before(now.AddDate(-maxTravelerAge, 0, 0)) {
+		return fmt.Errorf("date_of_birth must be in the past %d years", maxTravelerAge)
+	}
+
+	if len(r.Documents) > MaxTravelerDocuments {
+		return fmt.Errorf("at most %d documents are allowed", MaxTravelerDocuments)
+	}
+	for i := range r.Documents {
+		if err := r.Documents[i].Validate(); err != nil {
+			return fmt.Errorf("documents[%d]: %v", i, err)
+		}
+	}
+
+	r.LoyaltyNumber = strings.ToUpper(strings.TrimSpace(r.LoyaltyNumber))
+	if r.LoyaltyNumber != "" && !documentNumberPattern.MatchString(r.LoyaltyNumber) {
+		return fmt.Errorf("loyalty_number must be up to 20 letters and digits")
+	}
+	return nil
+}
+
+func validateTravelerName(field, name string) error {
+	if name == "" {
+		return fmt.Errorf("%s is required", field)
+	}
+	if utf8.RuneCountInString(name) > MaxTravelerNameLength || strings.ContainsAny(name, "/\n") {
+		return fmt.Errorf("%s must be at most %d characters without slashes", field, MaxTravelerNameLength)
+	}
+	return nil
+}
+
+// Validate normalizes and checks the document's type, number, country and expiry
+func (d *TravelDocument) Validate() error {
+	d.Type = strings.ToUpper(strings.TrimSpace(d.Type))
+	d.Number = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(d.Number), " ", ""))
+	d.IssuingCountry = strings.ToUpper(strings.TrimSpace(d.IssuingCountry))
+
+	if d.Type != DocumentPassport && d.Type != DocumentNationalID {
+		return fmt.Errorf("type must be %s or %s", DocumentPassport, DocumentNationalID)
+	}
+	if !documentNumberPattern.MatchString(d.Number) {
+		return fmt.Errorf("number must be up to 20 letters and digits")
+	}
+	if !countryCodePattern.MatchString(d.IssuingCountry) {
+		return fmt.Errorf("issuing_country must be a 2-letter ISO 3166-1 code")
+	}
+	if _, err := time.Parse("2006-01-02", d.ExpiresOn); err != nil {
+		return fmt.Errorf("invalid expires_on %q: use YYYY-MM-DD", d.ExpiresOn)
+	}
+	return nil
+}
+
+// Travel document issue codes
+const (
+	DocumentIssuePassportMissing  = "PASSPORT_MISSING"
+	DocumentIssuePassportExpiring = "PASSPORT_EXPIRING"
+	DocumentIssueVisaRequired     = "VISA_REQUIRED"
+)
+
+// DocumentIssue is a problem with the travel documents of a saved traveler on an international flight
+// @Description Travel document problem found for a traveler
+type DocumentIssue struct {
+	TravelerID string `json:"traveler_id" example:"3f9a1c0e5b7d" description:"Traveler the issue is about"`
+	Code       string `json:"code" example:"VISA_REQUIRED" enums:"PASSPORT_MISSING,PASSPORT_EXPIRING,VISA_REQUIRED" description:"Issue code"`
+	Message    string `json:"message" example:"holders of US passports may need a visa for IN; check before travel" description:"What is wrong"`
+	Blocking   bool   `json:"blocking" example:"false" description:"Whether the issue refuses the booking or check-in; other issues are flags to check"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTravelerRequest(t *testing.T) {
+	now := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	req := TravelerRequest{
+		FirstName:     " John ",
+		LastName:      "Doe",
+		DateOfBirth:   "1985-04-12",
+		Documents:     []TravelDocument{{Type: "passport", Number: "x12 345", IssuingCountry: "us", ExpiresOn: "2030-05-31"}},
+		LoyaltyNumber: " aa123 ",
+	}
+	if err := req.Validate(now); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if req.FirstName != "John" || req.LoyaltyNumber != "AA123" || req.Documents[0] != (TravelDocument{DocumentPassport, "X12345", "US", "2030-05-31"}) {
+		t.Errorf("Expected normalized details, got %+v", req)
+	}
+	traveler := Traveler{FirstName: req.FirstName, LastName: req.LastName}
+	if got := traveler.PassengerName(); got != "DOE/JOHN" {
+		t.Errorf("PassengerName = %q", got)
+	}
+
+	invalid := map[string]TravelerRequest{
+		"no-name":      {LastName: "Doe", DateOfBirth: "1985-04-12"},
+		"slash":        {FirstName: "John/Jr", LastName: "Doe", DateOfBirth: "1985-04-12"},
+		"bad-birth":    {FirstName: "John", LastName: "Doe", DateOfBirth: "12/04/1985"},
+		"future-birth": {FirstName: "John", LastName: "Doe", DateOfBirth: "2026-01-01"},
+		"bad-document": {FirstName: "John", LastName: "Doe", DateOfBirth: "1985-04-12", Documents: []TravelDocument{{Type: "VISA", Number: "1", IssuingCountry: "US", ExpiresOn: "2030-01-01"}}},
+		"bad-country":  {FirstName: "John", LastName: "Doe", DateOfBirth: "1985-04-12", Documents: []TravelDocument{{Type: DocumentPassport, Number: "1", IssuingCountry: "USA", ExpiresOn: "2030-01-01"}}},
+		"bad-loyalty":  {FirstName: "John", LastName: "Doe", DateOfBirth: "1985-04-12", LoyaltyNumber: "AA-123"},
+	}
+	for name, req := range invalid {
+		if err := req.Validate(now); err == nil {
+			t.Errorf("Expected an error for %s", name)
+		}
+	}
+
+	if !IsTravelerLabel(TravelerLabel(3)) || IsTravelerLabel(TenantLabel) {
+		t.Error("Expected traveler labels to be recognized")
+	}
+}
@@ -0,0 +1,211 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Cabins, from the lowest; tickets are booked in economy
+const (
+	CabinEconomy        = "Y"
+	CabinPremiumEconomy = "W"
+	CabinBusiness       = "J"
+	CabinFirst          = "F"
+)
+
+// cabinRanks orders the cabins
+var cabinRanks = map[string]int{CabinEconomy: 0, CabinPremiumEconomy: 1, CabinBusiness: 2, CabinFirst: 3}
+
+// CabinLabel is the reserved ticket label holding the cabin a ticket was
+// upgraded to, e.g. cabin: j; tickets without it fly economy
+const CabinLabel = "cabin"
+
+// Upgrade offer types
+const (
+	UpgradeFixed = "FIXED"
+	UpgradeBid   = "BID"
+)
+
+// Upgrade offer statuses
+const (
+	UpgradeOffered   = "OFFERED"
+	UpgradeBidPlaced = "BID_PLACED"
+	UpgradeAccepted  = "ACCEPTED"
+	UpgradeDeclined  = "DECLINED"
+	UpgradeRejected  = "REJECTED"
+	UpgradeExpired   = "EXPIRED"
+)
+
+// Upgrade offer limits
+const (
+	DefaultUpgradeOfferHours = 24
+	MaxUpgradeOfferHours     = 168
+)
+
+// TicketCabin returns the cabin of a ticket, from its labels
+func TicketCabin(ticket *FlightTicket) string {
+	if cabin, ok := ticket.Labels[CabinLabel]; ok {
+		return strings.ToUpper(cabin)
+	}
+	return CabinEconomy
+}
+
+// CabinAbove reports whether cabin a is a higher cabin than b
+func CabinAbove(a, b string) bool {
+	rankA, okA := cabinRanks[a]
+	rankB, okB := cabinRanks[b]
+	return okA && okB && rankA > rankB
+}
+
+// UpgradeOffer is an offer to move a ticket's passengers to a higher cabin,
+// at a fixed price or for a bid. Prices are per passenger, in the base currency.
+// @Description Upgrade offer of a ticket
+type UpgradeOffer struct {
+	ID             string     `json:"id" firestore:"id" example:"5b7d3f9a1c0e4a6f" description:"Offer ID"`
+	ConfirmationID string     `json:"confirmation_id" firestore:"confirmation_id" example:"ABC123" description:"Ticket the offer is made to"`
+	Tenant         string     `json:"tenant,omitempty" firestore:"tenant,omitempty" example:"acme" description:"Tenant that booked the ticket"`
+	FlightNumber   string     `json:"flight_number" firestore:"flight_number" example:"AA1234" description:"Flight number"`
+	Date           string     `json:"date" firestore:"date" example:"2024-12-25" description:"Departure date"`
+	Cabin          string     `json:"cabin" firestore:"cabin" example:"J" enums:"W,J,F" description:"Cabin offered: W (premium economy), J (business) or F (first)"`
+	Type           string     `json:"type" firestore:"type" example:"FIXED" enums:"FIXED,BID" description:"FIXED to accept at the price, BID to bid between min_bid and max_bid"`
+	Passengers     int        `json:"passengers" firestore:"passengers" example:"2" description:"Passengers upgraded together; infants on a lap are not counted"`
+	Price          float64    `json:"price,omitempty" firestore:"price,omitempty" example:"150.00" description:"Fixed price per passenger"`
+	MinBid         float64    `json:"min_bid,omitempty" firestore:"min_bid,omitempty" example:"80.00" description:"Lowest bid per passenger"`
+	MaxBid         float64    `json:"max_bid,omitempty" firestore:"max_bid,omitempty" example:"300.00" description:"Highest bid per passenger, if any"`
+	Bid            float64    `json:"bid,omitempty" firestore:"bid,omitempty" example:"120.00" description:"Bid per passenger placed by the traveller"`
+	Currency       string     `json:"currency" firestore:"currency" example:"USD" description:"Currency of the prices and bids"`
+	Status         string     `json:"status" firestore:"status" example:"OFFERED" enums:"OFFERED,BID_PLACED,ACCEPTED,DECLINED,REJECTED,EXPIRED" description:"OFFERED until answered, BID_PLACED until bids are awarded, then ACCEPTED or REJECTED; DECLINED by the traveller, or EXPIRED unanswered"`
+	OfferedBy      string     `json:"offered_by,omitempty" firestore:"offered_by,omitempty" example:"ops" description:"Name of the API key that made the offer"`
+	CreatedAt      time.Time  `json:"created_at" firestore:"created_at" example:"2024-12-20T10:00:00Z" description:"When the offer was made"`
+	ExpiresAt      time.Time  `json:"expires_at" firestore:"expires_at" example:"2024-12-21T10:00:00Z" description:"Deadline to accept, bid or decline"`
+	DecidedAt      *time.Time `json:"decided_at,omitempty" firestore:"decided_at,omitempty" example:"2024-12-20T12:00:00Z" description:"When the offer was accepted, declined or rejected"`
+}
+
+// Expire marks an offer left unanswered past its deadline as EXPIRED
+func (o *UpgradeOffer) Expire(now time.Time) {
+	if o.Status == UpgradeOffered && !now.Before(o.ExpiresAt) {
+		o.Status = UpgradeExpired
+	}
+}
+
+// Open reports whether the offer can still be answered
+func (o *UpgradeOffer) Open(now time.Time) bool {
+	return (o.Status == UpgradeOffered || o.Status == UpgradeBidPlaced) && now.Before(o.ExpiresAt)
+}
+
+// Amount is the total charged for the upgrade: the price or the bid, for every passenger
+func (o *UpgradeOffer) Amount() float64 {
+	if o.Type == UpgradeBid {
+		return o.Bid * float64(o.Passengers)
+	}
+	return o.Price * float64(o.Passengers)
+}
+
+// CheckBid checks a bid per passenger against the offer's bounds
+func (o *UpgradeOffer) CheckBid(bid float64) error {
+	if bid < o.MinBid || (o.MaxBid > 0 && bid > o.MaxBid) {
+		if o.MaxBid > 0 {
+			return fmt.Errorf("bid must be between %.2f and %.2f", o.MinBid, o.MaxBid)
+		}
+		return fmt.Errorf("bid must be at least %.2f", o.MinBid)
+	}
+	return nil
+}
+
+// UpgradeOfferRequest represents the request payload for offering upgrades on a departure
+// @Description Request payload for offering upgrades to the eligible tickets of a departure
+type UpgradeOfferRequest struct {
+	Cabin    string  `json:"cabin" example:"J" enums:"W,J,F" description:"Cabin to offer" validate:"required"`
+	Type     string  `json:"type,omitempty" example:"FIXED" enums:"FIXED,BID" description:"Offer type (default FIXED)"`
+	Price    float64 `json:"price,omitempty" example:"150.00" description:"Price per passenger in the base currency, for FIXED offers"`
+	MinBid   float64 `json:"min_bid,omitempty" example:"80.00" description:"Lowest bid per passenger, for BID offers"`
+	MaxBid   float64 `json:"max_bid,omitempty" example:"300.00" description:"Highest bid per passenger, for BID offers (optional)"`
+	TTLHours int     `json:"ttl_hours,omitempty" example:"24" description:"Hours the travellers have to answer (default 24, at most 168)"`
+}
+
+// Validate normalizes the request and checks its prices and deadline
+func (r *UpgradeOfferRequest) Validate() error {
+	r.Cabin = strings.ToUpper(r.Cabin)
+	if !CabinAbove(r.Cabin, CabinEconomy) {
+		return fmt.Errorf("cabin must be W, J or F")
+	}
+	r.Type = strings.ToUpper(r.Type)
+	switch r.Type {
+	case "", UpgradeFixed:
+		r.Type = UpgradeFixed
+		if r.Price <= 0 || r.MinBid != 0 || r.MaxBid != 0 {
+			return fmt.Errorf("FIXED offers need a positive price and no bids")
+		}
+	case UpgradeBid:
+		if r.Price != 0 || r.MinBid <= 0 || (r.MaxBid != 0 && r.MaxBid < r.MinBid) {
+			return fmt.Errorf("BID offers need a positive min_bid, no more than max_bid, and no price")
+		}
+	default:
+		return fmt.Errorf("type must be FIXED or BID")
+	}
+	if r.TTLHours == 0 {
+		r.TTLHours = DefaultUpgradeOfferHours
+	}
+	if r.TTLHours < 1 || r.TTLHours > MaxUpgradeOfferHours {
+		return fmt.Errorf("ttl_hours must be between 1 and %d", MaxUpgradeOfferHours)
+	}
+	return nil
+}
+
+// UpgradeAcceptRequest represents the request payload for accepting an upgrade offer
+// @Description Request payload for accepting an upgrade offer; BID offers need a bid
+type UpgradeAcceptRequest struct {
+	Bid float64 `json:"bid,omitempty" example:"120.00" description:"Bid per passenger, for BID offers"`
+}
+
+// UpgradeAwardRequest represents the request payload for awarding the bids of a departure
+// @Description Request payload for awarding upgrade bids
+type UpgradeAwardRequest struct {
+	Cabin string `json:"cabin" example:"J" enums:"W,J,F" description:"Cabin whose bids are decided" validate:"required"`
+}
+
+// Validate normalizes the cabin and checks it is a premium cabin
+func (r *UpgradeAwardRequest) Validate() error {
+	r.Cabin = strings.ToUpper(r.Cabin)
+	if !CabinAbove(r.Cabin, CabinEconomy) {
+		return fmt.Errorf("cabin must be W, J or F")
+	}
+	return nil
+}
+
+// UpgradeOffersResponse lists upgrade offers
+// @Description Upgrade offers
+type UpgradeOffersResponse struct {
+	Offers []*UpgradeOffer `json:"offers" description:"Offers, newest first"`
+	Count  int             `json:"count" example:"1" description:"Number of offers"`
+}
+
+// CabinAvailability is the upgrade capacity of a premium cabin on a departure
+// @Description Upgrade seats of a cabin
+type CabinAvailability struct {
+	Cabin     string `json:"cabin" example:"J" description:"Cabin"`
+	Capacity  int    `json:"capacity" example:"8" description:"Seats of the cabin available for upgrades"`
+	Upgraded  int    `json:"upgraded" example:"3" description:"Passengers upgraded to the cabin"`
+	Available int    `json:"available" example:"5" description:"Seats left for upgrades"`
+}
+
+// UpgradeDepartureResponse lists the upgrade offers and cabin capacity of a departure
+// @Description Upgrade offers and premium cabin capacity of a departure
+type UpgradeDepartureResponse struct {
+	FlightNumber string              `json:"flight_number" example:"AA1234" description:"Flight number"`
+	Date         string              `json:"date" example:"2024-12-25" description:"Departure date"`
+	Cabins       []CabinAvailability `json:"cabins" description:"Upgrade seats of each premium cabin"`
+	Offers       []*UpgradeOffer     `json:"offers" description:"Offers, newest first"`
+	Count        int                 `json:"count" example:"4" description:"Number of offers"`
+}
+
+// UpgradeAwardResponse lists the bids awarded and rejected on a departure
+// @Description Result of awarding upgrade bids
+type UpgradeAwardResponse struct {
+	FlightNumber string          `json:"flight_number" example:"AA1234" description:"Flight number"`
+	Date         string          `json:"date" example:"2024-12-25" description:"Departure date"`
+	Cabin        string          `json:"cabin" example:"J" description:"Cabin"`
+	Awarded      []*UpgradeOffer `json:"awarded" description:"Bids accepted, highest first"`
+	Rejected     []*UpgradeOffer `json:"rejected" description:"Bids that did not fit in the cabin"`
+}
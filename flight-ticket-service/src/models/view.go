@@ -0,0 +1,142 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// View limits
+const (
+	DefaultViewLimit         = 50
+	MaxViewLimit             = 500
+	MaxViewDescriptionLength = 500
+)
+
+// ViewFilters are the ticket filters of a saved view. Empty fields match every ticket.
+// @Description Ticket filters of a saved view
+type ViewFilters struct {
+	Labels        map[string]string `json:"labels,omitempty" firestore:"labels,omitempty" example:"corporate_account:acme" description:"Labels every ticket must carry"`
+	Status        string            `json:"status,omitempty" firestore:"status,omitempty" example:"CONFIRMED" enums:"CONFIRMED,CHECKED_IN,CANCELLED,PENDING,DISRUPTED,REVIEW" description:"Ticket status"`
+	Origin        string            `json:"origin,omitempty" firestore:"origin,omitempty" example:"JFK" description:"3-letter IATA origin airport code"`
+	Destination   string            `json:"destination,omitempty" firestore:"destination,omitempty" example:"LAX" description:"3-letter IATA destination airport code"`
+	FlightNumber  string            `json:"flight_number,omitempty" firestore:"flight_number,omitempty" example:"AA1234" description:"Flight number"`
+	DepartureDate string            `json:"departure_date,omitempty" firestore:"departure_date,omitempty" example:"today" description:"Departure date: today, tomorrow (UTC, resolved when the view runs) or YYYY-MM-DD"`
+	Limit         int               `json:"limit,omitempty" firestore:"limit,omitempty" example:"50" description:"Maximum number of tickets returned (default 50, at most 500)"`
+}
+
+// SavedView is a named ticket search shared by the CLI and dashboards
+// @Description Named ticket search
+type SavedView struct {
+	Name        string      `json:"name" firestore:"name" example:"jfk-departures-today" description:"View name"`
+	Description string      `json:"description,omitempty" firestore:"description,omitempty" example:"Today's departures from JFK" description:"What the view shows"`
+	Filters     ViewFilters `json:"filters" firestore:"filters" description:"Ticket filters"`
+	CreatedBy   string      `json:"created_by,omitempty" firestore:"created_by,omitempty" example:"desk" description:"Name of the API key that created the view"`
+	CreatedAt   time.Time   `json:"created_at" firestore:"created_at" example:"2024-07-12T19:00:00Z" description:"View creation timestamp"`
+	UpdatedAt   time.Time   `json:"updated_at" firestore:"updated_at" example:"2024-07-12T19:00:00Z" description:"Last update timestamp"`
+}
+
+// CreateViewRequest represents the request payload for saving a new view
+// @Description Request payload for saving a named search
+type CreateViewRequest struct {
+	Name        string      `json:"name" example:"jfk-departures-today" description:"View name (lowercase letters, digits, underscores and dashes, starting with a letter)" validate:"required"`
+	Description string      `json:"description,omitempty" example:"Today's departures from JFK" description:"What the view shows"`
+	Filters     ViewFilters `json:"filters" description:"Ticket filters"`
+}
+
+// UpdateViewRequest represents the request payload for replacing a view's filters
+// @Description Request payload for updating a named search
+type UpdateViewRequest struct {
+	Description string      `json:"description,omitempty" example:"Today's departures from JFK" description:"What the view shows"`
+	Filters     ViewFilters `json:"filters" description:"Ticket filters, replacing the current ones"`
+}
+
+// ViewListResponse represents the response for listing saved views
+// @Description Saved views
+type ViewListResponse struct {
+	Views []*SavedView `json:"views" description:"Views, by name"`
+	Count int          `json:"count" example:"2" description:"Number of views"`
+}
+
+// ValidateViewName checks that a view name is usable in a URL path
+func ValidateViewName(name string) error {
+	if !labelKeyPattern.MatchString(name) {
+		return fmt.Errorf("invalid view name %q: use up to 63 lowercase letters, digits, underscores or dashes, starting with a letter", name)
+	}
+	return nil
+}
+
+// Validate normalizes and checks the view's name, description and filters
+func (r *CreateViewRequest) Validate() error {
+	if err := ValidateViewName(r.Name); err != nil {
+		return err
+	}
+	return validateView(&r.Description, &r.Filters)
+}
+
+// Validate normalizes and checks the view's description and filters
+func (r *UpdateViewRequest) Validate() error {
+	return validateView(&r.Description, &r.Filters)
+}
+
+func validateView(description *string, filters *ViewFilters) error {
+	*description = strings.TrimSpace(*description)
+	if utf8.RuneCountInString(*description) > MaxViewDescriptionLength {
+		return fmt.Errorf("description must be at most %d characters", MaxViewDescriptionLength)
+	}
+	return filters.Validate()
+}
+
+// Validate upper-cases codes and checks every filter
+func (f *ViewFilters) Validate() error {
+	f.Status = strings.ToUpper(f.Status)
+	f.Origin = strings.ToUpper(f.Origin)
+	f.Destination = strings.ToUpper(f.Destination)
+	f.FlightNumber = strings.ToUpper(f.FlightNumber)
+	f.DepartureDate = strings.ToLower(f.DepartureDate)
+
+	if err := ValidateLabels(f.Labels); err != nil {
+		return err
+	}
+	if f.Status != "" && f.Status != "CONFIRMED" && f.Status != "CHECKED_IN" && f.Status != "CANCELLED" && f.Status != "PENDING" && f.Status != DisruptedStatus && f.Status != ReviewStatus {
+		return fmt.Errorf("status must be CONFIRMED, CHECKED_IN, CANCELLED, PENDING, DISRUPTED or REVIEW")
+	}
+	for _, code := range []string{f.Origin, f.Destination} {
+		if code != "" && !ValidateAirportCode(code) {
+			return fmt.Errorf("invalid airport code %q: use 3-letter IATA codes", code)
+		}
+	}
+	if f.DepartureDate != "" {
+		if _, err := ParseDepartureDate(f.DepartureDate, time.Now()); err != nil {
+			return err
+		}
+	}
+	if f.Limit < 0 || f.Limit > MaxViewLimit {
+		return fmt.Errorf("limit must be between 1 and %d", MaxViewLimit)
+	}
+	return nil
+}
+
+// Query resolves the filters into a ticket query; relative dates are read against now
+func (f ViewFilters) Query(now time.Time) (TicketQuery, error) {
+	query := TicketQuery{
+		Labels:       f.Labels,
+		Status:       f.Status,
+		Origin:       f.Origin,
+		Destination:  f.Destination,
+		FlightNumber: f.FlightNumber,
+		Limit:        f.Limit,
+	}
+	if query.Limit == 0 {
+		query.Limit = DefaultViewLimit
+	}
+	if f.DepartureDate != "" {
+		date, err := ParseDepartureDate(f.DepartureDate, now)
+		if err != nil {
+			return TicketQuery{}, err
+		}
+		query.DepartureDate = date
+	}
+	return query, nil
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCreateViewRequestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		req   CreateViewRequest
+		valid bool
+	}{
+		{"departures today", CreateViewRequest{Name: "jfk-departures-today", Filters: ViewFilters{Origin: "jfk", DepartureDate: "Today"}}, true},
+		{"labels", CreateViewRequest{Name: "acme", Filters: ViewFilters{Labels: map[string]string{"corporate_account": "acme"}, Limit: 100}}, true},
+		{"no filters", CreateViewRequest{Name: "everything"}, true},
+		{"bad name", CreateViewRequest{Name: "JFK today"}, false},
+		{"bad status", CreateViewRequest{Name: "bad", Filters: ViewFilters{Status: "BOARDED"}}, false},
+		{"bad airport", CreateViewRequest{Name: "bad", Filters: ViewFilters{Origin: "JFKX"}}, false},
+		{"bad date", CreateViewRequest{Name: "bad", Filters: ViewFilters{DepartureDate: "yesterday"}}, false},
+		{"limit too high", CreateViewRequest{Name: "bad", Filters: ViewFilters{Limit: MaxViewLimit + 1}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.req.Validate(); (err == nil) != tt.valid {
+				t.Errorf("Validate() = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestViewFiltersQueryResolvesRelativeDates(t *testing.T) {
+	now := time.Date(2024, 12, 24, 23, 30, 0, 0, time.UTC)
+
+	query, err := ViewFilters{Origin: "JFK", DepartureDate: DepartureTomorrow}.Query(now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC); !query.DepartureDate.Equal(want) {
+		t.Errorf("DepartureDate = %v, want %v", query.DepartureDate, want)
+	}
+	if query.Limit != DefaultViewLimit || query.Origin != "JFK" {
+		t.Errorf("Unexpected query: %+v", query)
+	}
+
+	ticket := &FlightTicket{Origin: "JFK", DepartureDate: time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC)}
+	if !query.Matches(ticket) {
+		t.Error("Expected tomorrow's JFK departure to match")
+	}
+	ticket.DepartureDate = ticket.DepartureDate.AddDate(0, 0, 1)
+	if query.Matches(ticket) {
+		t.Error("Expected a later departure not to match")
+	}
+}
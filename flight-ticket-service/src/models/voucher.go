@@ -0,0 +1,84 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Voucher statuses
+const (
+	VoucherIssued   = "ISSUED"
+	VoucherRedeemed = "REDEEMED"
+	VoucherExpired  = "EXPIRED"
+)
+
+// VoucherValidity is how long a voucher can be redeemed after it is issued
+const VoucherValidity = 365 * 24 * time.Hour
+
+// Voucher is travel credit issued to the travellers of a ticket cancelled
+// by the airline. It can be redeemed against new bookings until its balance
+// is used up or it expires. Amounts are in the base currency.
+// @Description Travel credit issued for a cancelled ticket
+type Voucher struct {
+	Code           string              `json:"code" firestore:"code" example:"VQ7K2M9X4TPA" description:"Voucher code, to redeem it"`
+	ConfirmationID string              `json:"confirmation_id" firestore:"confirmation_id" example:"ABC123" description:"Cancelled ticket the voucher compensates"`
+	Tenant         string              `json:"tenant,omitempty" firestore:"tenant,omitempty" example:"acme" description:"Tenant that booked the cancelled ticket"`
+	FlightNumber   string              `json:"flight_number" firestore:"flight_number" example:"AA1234" description:"Cancelled flight"`
+	DepartureDate  string              `json:"departure_date" firestore:"departure_date" example:"2024-12-25" description:"Departure date of the cancelled flight"`
+	Amount         float64             `json:"amount" firestore:"amount" example:"398.00" description:"Amount issued"`
+	Balance        float64             `json:"balance" firestore:"balance" example:"398.00" description:"Amount left to redeem"`
+	Currency       string              `json:"currency" firestore:"currency" example:"USD" description:"Currency of the amounts"`
+	Rule           string              `json:"rule,omitempty" firestore:"rule,omitempty" example:"fare-refund" description:"cancellation_compensation rule that set the amount; the full fare without one"`
+	Status         string              `json:"status" firestore:"status" example:"ISSUED" enums:"ISSUED,REDEEMED,EXPIRED" description:"ISSUED while a balance is left, REDEEMED once it is used up, EXPIRED past expires_at"`
+	IssuedBy       string              `json:"issued_by,omitempty" firestore:"issued_by,omitempty" example:"ops" description:"Name of the API key that cancelled the flight"`
+	IssuedAt       time.Time           `json:"issued_at" firestore:"issued_at" example:"2024-12-20T10:00:00Z" description:"When the voucher was issued"`
+	ExpiresAt      time.Time           `json:"expires_at" firestore:"expires_at" example:"2025-12-20T10:00:00Z" description:"Last moment to redeem the voucher"`
+	Redemptions    []VoucherRedemption `json:"redemptions,omitempty" firestore:"redemptions,omitempty" description:"Bookings the voucher was redeemed against, oldest first"`
+}
+
+// VoucherRedemption is the credit a voucher gave a booking
+// @Description Redemption of a voucher
+type VoucherRedemption struct {
+	ConfirmationID string    `json:"confirmation_id" firestore:"confirmation_id" example:"DEF456" description:"Ticket the credit went to"`
+	Amount         float64   `json:"amount" firestore:"amount" example:"250.00" description:"Credit taken off the ticket's price"`
+	RedeemedBy     string    `json:"redeemed_by,omitempty" firestore:"redeemed_by,omitempty" example:"desk" description:"Name of the API key that redeemed the voucher"`
+	RedeemedAt     time.Time `json:"redeemed_at" firestore:"redeemed_at" example:"2024-12-22T09:00:00Z" description:"When the voucher was redeemed"`
+}
+
+// Expire marks a voucher with a balance left past its expiry as EXPIRED
+func (v *Voucher) Expire(now time.Time) {
+	if v.Status == VoucherIssued && !now.Before(v.ExpiresAt) {
+		v.Status = VoucherExpired
+	}
+}
+
+// VoucherRedeemRequest represents the request payload for redeeming a voucher
+// @Description Request payload for redeeming a voucher against a booking
+type VoucherRedeemRequest struct {
+	ConfirmationID string `json:"confirmation_id" example:"DEF456" description:"Ticket to take the credit off" validate:"required"`
+}
+
+// Validate normalizes the request and checks the confirmation ID
+func (r *VoucherRedeemRequest) Validate() error {
+	r.ConfirmationID = strings.ToUpper(strings.TrimSpace(r.ConfirmationID))
+	if r.ConfirmationID == "" {
+		return fmt.Errorf("confirmation_id is required")
+	}
+	return nil
+}
+
+// VoucherRedeemResponse is the result of redeeming a voucher
+// @Description Voucher redeemed against a booking
+type VoucherRedeemResponse struct {
+	Credit  float64       `json:"credit" example:"250.00" description:"Credit taken off the ticket's price, in the voucher's currency"`
+	Voucher *Voucher      `json:"voucher" description:"Voucher with its new balance"`
+	Ticket  *FlightTicket `json:"ticket" description:"Ticket with its reduced price"`
+}
+
+// VouchersResponse lists vouchers
+// @Description Vouchers
+type VouchersResponse struct {
+	Vouchers []*Voucher `json:"vouchers" description:"Vouchers, newest first"`
+	Count    int        `json:"count" example:"1" description:"Number of vouchers"`
+}
@@ -0,0 +1,198 @@
+// Package network keeps an in-memory graph of the route network and searches
+// it for direct and one-stop options between two airports.
+//
+// The service has no flight schedule of its own, so the graph holds the
+// flights its tickets are booked on: every flight number and departure from
+// today on with at least one ticket that is not cancelled. Arrival times are
+// estimated from the distance between the airports. The graph is rebuilt from
+// the tickets when a search finds it older than ROUTES_REFRESH_INTERVAL
+// (default 5m).
+package network
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/rules"
+	"flight-ticket-service/src/services"
+)
+
+// DefaultRefreshInterval is how long a graph is used before it is rebuilt
+const DefaultRefreshInterval = 5 * time.Minute
+
+// DefaultMinConnection is the minimum connection time at airports without a
+// min_connection_time rule
+const DefaultMinConnection = 45 * time.Minute
+
+// MaxOptions is the most options a search returns
+const MaxOptions = 50
+
+// ConnectionTimes supplies the minimum connection time at an airport, or 0
+// for the default; *rules.Engine implements it
+type ConnectionTimes interface {
+	MinConnection(airport string) time.Duration
+}
+
+// RefreshIntervalFromEnv reads ROUTES_REFRESH_INTERVAL
+func RefreshIntervalFromEnv() (time.Duration, error) {
+	value := strings.TrimSpace(os.Getenv("ROUTES_REFRESH_INTERVAL"))
+	if value == "" {
+		return DefaultRefreshInterval, nil
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval < 0 {
+		return 0, fmt.Errorf("invalid ROUTES_REFRESH_INTERVAL %q: must be a non-negative duration", value)
+	}
+	return interval, nil
+}
+
+// Graph holds the flights of the route network by origin airport
+type Graph struct {
+	departures map[string][]*models.RouteFlight // by departure time
+	builtAt    time.Time
+}
+
+// Build creates the graph of the flights that tickets are booked on,
+// leaving out cancelled and disrupted tickets and flights departing before since
+func Build(tickets []*models.FlightTicket, since, now time.Time) *Graph {
+	g := &Graph{departures: make(map[string][]*models.RouteFlight), builtAt: now}
+	seen := make(map[string]bool)
+	for _, ticket := range tickets {
+		if ticket.Status == "CANCELLED" || ticket.Status == models.DisruptedStatus || ticket.DepartureTime.Before(since) {
+			continue
+		}
+		departure := ticket.DepartureTime.UTC()
+		key := strings.Join([]string{ticket.FlightNumber, departure.Format(time.RFC3339), ticket.Origin, ticket.Destination}, "|")
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		flight := &models.RouteFlight{
+			FlightNumber:  ticket.FlightNumber,
+			Origin:        ticket.Origin,
+			Destination:   ticket.Destination,
+			DepartureTime: departure,
+		}
+		if duration, ok := services.FlightDuration(ticket.Origin, ticket.Destination); ok {
+			arrival := departure.Add(duration)
+			flight.ArrivalTime = &arrival
+			flight.DurationMinutes = int(duration.Minutes())
+		}
+		g.departures[ticket.Origin] = append(g.departures[ticket.Origin], flight)
+	}
+	for _, flights := range g.departures {
+		sort.Slice(flights, func(i, j int) bool { return flights[i].DepartureTime.Before(flights[j].DepartureTime) })
+	}
+	return g
+}
+
+// BuiltAt returns when the graph was built
+func (g *Graph) BuiltAt() time.Time {
+	return g.builtAt
+}
+
+// Search returns the direct and one-stop options from origin to destination
+// whose first flight departs on the date (UTC). Connections need at least the
+// minimum connection time of the airport and at most rules.MaxConnection;
+// flights without an estimated arrival cannot be connected from.
+func (g *Graph) Search(origin, destination string, date time.Time, times ConnectionTimes) []*models.RouteOption {
+	from := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 1)
+
+	var options []*models.RouteOption
+	for _, first := range g.departures[origin] {
+		if first.DepartureTime.Before(from) || !first.DepartureTime.Before(to) {
+			continue
+		}
+		if first.Destination == destination {
+			options = append(options, &models.RouteOption{Flights: []*models.RouteFlight{first}, DurationMinutes: first.DurationMinutes})
+			continue
+		}
+		if first.ArrivalTime == nil || first.Destination == origin {
+			continue
+		}
+
+		via := first.Destination
+		minimum := times.MinConnection(via)
+		if minimum <= 0 {
+			minimum = DefaultMinConnection
+		}
+		for _, second := range g.departures[via] {
+			connection := second.DepartureTime.Sub(*first.ArrivalTime)
+			if second.Destination != destination || connection < minimum || connection > rules.MaxConnection {
+				continue
+			}
+			option := &models.RouteOption{
+				Stops:             1,
+				Flights:           []*models.RouteFlight{first, second},
+				Via:               via,
+				ConnectionMinutes: int(connection.Minutes()),
+			}
+			if second.ArrivalTime != nil {
+				option.DurationMinutes = int(second.ArrivalTime.Sub(first.DepartureTime).Minutes())
+			}
+			options = append(options, option)
+		}
+	}
+
+	sort.SliceStable(options, func(i, j int) bool {
+		a, b := options[i], options[j]
+		if a.Stops != b.Stops {
+			return a.Stops < b.Stops
+		}
+		if !a.Flights[0].DepartureTime.Equal(b.Flights[0].DepartureTime) {
+			return a.Flights[0].DepartureTime.Before(b.Flights[0].DepartureTime)
+		}
+		return a.DurationMinutes < b.DurationMinutes
+	})
+	if len(options) > MaxOptions {
+		options = options[:MaxOptions]
+	}
+	return options
+}
+
+// Network caches the graph of a repository's tickets
+type Network struct {
+	repository services.TicketRepository
+	maxAge     time.Duration
+
+	mu    sync.Mutex
+	graph *Graph
+}
+
+// New creates a network that rebuilds its graph once it is older than maxAge
+func New(repository services.TicketRepository, maxAge time.Duration) *Network {
+	return &Network{repository: repository, maxAge: maxAge}
+}
+
+// Graph returns the cached graph, rebuilding it when it is too old. When the
+// tickets cannot be read the previous graph is kept, if there is one.
+func (n *Network) Graph(ctx context.Context) (*Graph, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := time.Now().UTC()
+	if n.graph != nil && now.Sub(n.graph.builtAt) < n.maxAge {
+		return n.graph, nil
+	}
+
+	tickets, err := n.repository.ListTickets(ctx, 0)
+	if err != nil {
+		if n.graph != nil {
+			log.Printf("Keeping route network built at %s: %v", n.graph.builtAt.Format(time.RFC3339), err)
+			return n.graph, nil
+		}
+		return nil, fmt.Errorf("failed to build route network: %v", err)
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	n.graph = Build(tickets, today, now)
+	return n.graph, nil
+}
@@ -0,0 +1,112 @@
+package network
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/services"
+)
+
+type fixedTimes map[string]time.Duration
+
+func (f fixedTimes) MinConnection(airport string) time.Duration {
+	return f[airport]
+}
+
+func TestSearch(t *testing.T) {
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	leg := func(flightNumber, origin, destination string, departure time.Time) *models.FlightTicket {
+		return models.NewFlightTicket(origin, destination, departure, departure, flightNumber, 1)
+	}
+	toORD, _ := services.FlightDuration("JFK", "ORD")
+	toDEN, _ := services.FlightDuration("JFK", "DEN")
+	morning := day.Add(8 * time.Hour)
+
+	cancelled := leg("AA9", "JFK", "LAX", day.Add(7*time.Hour))
+	cancelled.Status = "CANCELLED"
+	tickets := []*models.FlightTicket{
+		leg("AA1", "JFK", "LAX", day.Add(9*time.Hour)),
+		leg("AA1", "JFK", "LAX", day.Add(9*time.Hour)), // same flight, another ticket
+		cancelled,
+		leg("AA2", "JFK", "ORD", morning),
+		leg("AA3", "ORD", "LAX", morning.Add(toORD+30*time.Minute)), // too short at ORD
+		leg("AA4", "ORD", "LAX", morning.Add(toORD+2*time.Hour)),
+		leg("AA5", "JFK", "DEN", morning),
+		leg("AA6", "DEN", "LAX", morning.Add(toDEN+50*time.Minute)), // enough with the default
+		leg("AA7", "ORD", "LAX", morning.Add(toORD+30*time.Hour)),   // stopover
+		leg("AA8", "JFK", "LAX", day.AddDate(0, 0, 1)),              // next day
+		leg("AA0", "JFK", "LAX", day.AddDate(0, 0, -1)),             // before since
+	}
+	graph := Build(tickets, day, day)
+
+	options := graph.Search("JFK", "LAX", day, fixedTimes{"ORD": 60 * time.Minute})
+	var got []string
+	for _, option := range options {
+		numbers := ""
+		for _, flight := range option.Flights {
+			numbers += flight.FlightNumber
+		}
+		got = append(got, numbers)
+	}
+	want := []string{"AA1", "AA5AA6", "AA2AA4"} // same departure, shorter first
+	if len(got) != len(want) {
+		t.Fatalf("Options = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Options = %v, want %v", got, want)
+			break
+		}
+	}
+
+	if options[2].Via != "ORD" || options[2].ConnectionMinutes != 120 || options[2].DurationMinutes == 0 {
+		t.Errorf("Unexpected one-stop option %+v", options[2])
+	}
+	if options[0].Stops != 0 || options[0].Flights[0].ArrivalTime == nil {
+		t.Errorf("Unexpected direct option %+v", options[0])
+	}
+
+	// A longer minimum connection at DEN rules AA5-AA6 out
+	if options := graph.Search("JFK", "LAX", day, fixedTimes{"DEN": time.Hour}); len(options) != 2 {
+		t.Errorf("Expected DEN connection ruled out, got %d options", len(options))
+	}
+}
+
+func TestNetworkRebuild(t *testing.T) {
+	ctx := context.Background()
+	repository := services.NewMemoryRepository()
+	// Noon, so the later flight departs the same day
+	departure := time.Now().UTC().AddDate(0, 0, 3).Truncate(24 * time.Hour).Add(12 * time.Hour)
+	repository.CreateTicket(ctx, models.NewFlightTicket("JFK", "LAX", departure, departure, "AA1", 1))
+
+	cached := New(repository, time.Hour)
+	graph, err := cached.Graph(ctx)
+	if err != nil {
+		t.Fatalf("Graph failed: %v", err)
+	}
+	if options := graph.Search("JFK", "LAX", departure, fixedTimes{}); len(options) != 1 {
+		t.Fatalf("Expected the booked flight, got %d options", len(options))
+	}
+
+	repository.CreateTicket(ctx, models.NewFlightTicket("JFK", "LAX", departure, departure.Add(time.Hour), "AA2", 1))
+	if again, _ := cached.Graph(ctx); again != graph {
+		t.Error("Expected the cached graph within maxAge")
+	}
+	fresh, _ := New(repository, 0).Graph(ctx)
+	if options := fresh.Search("JFK", "LAX", departure, fixedTimes{}); len(options) != 2 {
+		t.Errorf("Expected both flights after a rebuild, got %d options", len(options))
+	}
+}
+
+func TestRefreshIntervalFromEnv(t *testing.T) {
+	t.Setenv("ROUTES_REFRESH_INTERVAL", "")
+	if got, err := RefreshIntervalFromEnv(); err != nil || got != DefaultRefreshInterval {
+		t.Errorf("Expected the default, got %v, %v", got, err)
+	}
+	t.Setenv("ROUTES_REFRESH_INTERVAL", "soon")
+	if _, err := RefreshIntervalFromEnv(); err == nil {
+		t.Error("Expected an error for an invalid duration")
+	}
+}
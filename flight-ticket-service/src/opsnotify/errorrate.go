@@ -0,0 +1,96 @@
+package opsnotify
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+)
+
+// errorRateBuckets is the number of buckets a window is counted in
+const errorRateBuckets = 10
+
+type errorRateBucket struct {
+	start          int64 // bucket number since the epoch
+	total, errored int
+}
+
+// ErrorRateMonitor counts the responses of an instance and posts an
+// error_rate event when the share of 5xx responses over the window reaches
+// the threshold. It posts at most once per window while the spike lasts.
+type ErrorRateMonitor struct {
+	notifier    *Notifier
+	threshold   float64
+	window      time.Duration
+	minRequests int
+
+	mu        sync.Mutex
+	buckets   [errorRateBuckets]errorRateBucket
+	lastAlert time.Time
+}
+
+// NewErrorRateMonitor creates a monitor, or returns nil when error_rate
+// events have no webhook
+func NewErrorRateMonitor(notifier *Notifier, config Config) *ErrorRateMonitor {
+	if notifier == nil || !notifier.Enabled(EventErrorRate) {
+		return nil
+	}
+	return &ErrorRateMonitor{
+		notifier:    notifier,
+		threshold:   config.ErrorRateThreshold,
+		window:      config.ErrorRateWindow,
+		minRequests: config.ErrorRateMinRequests,
+	}
+}
+
+// Middleware records the status of every response
+func (m *ErrorRateMonitor) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		defer func() {
+			m.Record(time.Now(), ww.Status())
+		}()
+		next.ServeHTTP(ww, r)
+	})
+}
+
+// Record counts a response and posts an event when the error rate reaches
+// the threshold
+func (m *ErrorRateMonitor) Record(now time.Time, status int) {
+	width := int64(m.window / errorRateBuckets)
+	slot := now.UnixNano() / width
+
+	m.mu.Lock()
+	bucket := &m.buckets[slot%errorRateBuckets]
+	if bucket.start != slot {
+		*bucket = errorRateBucket{start: slot}
+	}
+	bucket.total++
+	if status >= 500 {
+		bucket.errored++
+	}
+
+	var total, errored int
+	for _, b := range m.buckets {
+		if b.start > slot-errorRateBuckets {
+			total += b.total
+			errored += b.errored
+		}
+	}
+	rate := float64(errored) / float64(total)
+	alert := total >= m.minRequests && rate >= m.threshold && now.Sub(m.lastAlert) >= m.window
+	if alert {
+		m.lastAlert = now
+	}
+	m.mu.Unlock()
+
+	if alert {
+		m.notifier.Send(Event{
+			Type: EventErrorRate,
+			Text: fmt.Sprintf("error rate %.1f%% over the last %s: %d of %d requests answered with 5xx (threshold %.1f%%)",
+				rate*100, m.window, errored, total, m.threshold*100),
+		})
+	}
+}
@@ -0,0 +1,236 @@
+// Package opsnotify posts operational events to Slack or Google Chat
+// incoming webhooks: deployments, error-rate spikes and high-value bookings.
+//
+// Both chat services accept a JSON body with a "text" field, so one webhook
+// client serves either. Each event type posts to its own webhook, or to the
+// default one, and event types without a webhook are not posted.
+package opsnotify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+// Event types
+const (
+	EventDeployment   = "deployment"    // a revision was deployed, promoted or rolled back
+	EventErrorRate    = "error_rate"    // the share of 5xx responses crossed the threshold
+	EventGroupBooking = "group_booking" // a booking of many passengers was made
+)
+
+// EventTypes are the event types that can be posted
+var EventTypes = []string{EventDeployment, EventErrorRate, EventGroupBooking}
+
+// DefaultConfig holds the default thresholds; no webhook is configured
+var DefaultConfig = Config{
+	GroupBookingPassengers: 10,
+	ErrorRateThreshold:     0.05,
+	ErrorRateWindow:        5 * time.Minute,
+	ErrorRateMinRequests:   20,
+}
+
+// notifyTimeout bounds a single post so a slow webhook never piles up goroutines
+const notifyTimeout = 10 * time.Second
+
+// Event is an operational event
+type Event struct {
+	Type string
+	Text string // Slack and Google Chat both render *bold* and <url|label>
+}
+
+// Config configures the notifier
+type Config struct {
+	Source                 string            // names the service in messages
+	WebhookURLs            map[string]string // by event type
+	GroupBookingPassengers int               // bookings of at least this many passengers are posted
+	ErrorRateThreshold     float64           // share of 5xx responses, from 0 to 1
+	ErrorRateWindow        time.Duration     // period the error rate is measured over
+	ErrorRateMinRequests   int               // requests in the window before the rate counts
+}
+
+// ConfigFromEnv reads OPS_WEBHOOK_URL, the default webhook of every event
+// type, and OPS_WEBHOOK_URL_DEPLOYMENT, OPS_WEBHOOK_URL_ERROR_RATE and
+// OPS_WEBHOOK_URL_GROUP_BOOKING, which override it for one event type; "off"
+// turns an event type off. It also reads OPS_GROUP_BOOKING_PASSENGERS,
+// OPS_ERROR_RATE_THRESHOLD, OPS_ERROR_RATE_WINDOW and
+// OPS_ERROR_RATE_MIN_REQUESTS. Messages name the Cloud Run revision in
+// K_REVISION, when set.
+func ConfigFromEnv() (Config, error) {
+	config := DefaultConfig
+	config.Source = "flight-ticket-service"
+	if revision := os.Getenv("K_REVISION"); revision != "" {
+		config.Source = revision
+	}
+
+	defaultURL := strings.TrimSpace(os.Getenv("OPS_WEBHOOK_URL"))
+	if err := checkWebhookURL("OPS_WEBHOOK_URL", defaultURL); err != nil {
+		return Config{}, err
+	}
+	config.WebhookURLs = make(map[string]string, len(EventTypes))
+	for _, eventType := range EventTypes {
+		name := "OPS_WEBHOOK_URL_" + strings.ToUpper(eventType)
+		webhookURL := strings.TrimSpace(os.Getenv(name))
+		switch {
+		case webhookURL == "off":
+			continue
+		case webhookURL == "":
+			webhookURL = defaultURL
+		default:
+			if err := checkWebhookURL(name, webhookURL); err != nil {
+				return Config{}, err
+			}
+		}
+		if webhookURL != "" {
+			config.WebhookURLs[eventType] = webhookURL
+		}
+	}
+
+	if value := strings.TrimSpace(os.Getenv("OPS_GROUP_BOOKING_PASSENGERS")); value != "" {
+		passengers, err := strconv.Atoi(value)
+		if err != nil || passengers < 1 {
+			return Config{}, fmt.Errorf("invalid OPS_GROUP_BOOKING_PASSENGERS %q: must be a positive number", value)
+		}
+		config.GroupBookingPassengers = passengers
+	}
+	if value := strings.TrimSpace(os.Getenv("OPS_ERROR_RATE_THRESHOLD")); value != "" {
+		threshold, err := strconv.ParseFloat(value, 64)
+		if err != nil || threshold <= 0 || threshold > 1 {
+			return Config{}, fmt.Errorf("invalid OPS_ERROR_RATE_THRESHOLD %q: must be a share above 0 and up to 1, e.g. 0.05", value)
+		}
+		config.ErrorRateThreshold = threshold
+	}
+	if value := strings.TrimSpace(os.Getenv("OPS_ERROR_RATE_WINDOW")); value != "" {
+		window, err := time.ParseDuration(value)
+		if err != nil || window < errorRateBuckets*time.Second {
+			return Config{}, fmt.Errorf("invalid OPS_ERROR_RATE_WINDOW %q: must be a duration of at least %ds", value, errorRateBuckets)
+		}
+		config.ErrorRateWindow = window
+	}
+	if value := strings.TrimSpace(os.Getenv("OPS_ERROR_RATE_MIN_REQUESTS")); value != "" {
+		requests, err := strconv.Atoi(value)
+		if err != nil || requests < 1 {
+			return Config{}, fmt.Errorf("invalid OPS_ERROR_RATE_MIN_REQUESTS %q: must be a positive number", value)
+		}
+		config.ErrorRateMinRequests = requests
+	}
+	return config, nil
+}
+
+// checkWebhookURL checks that an optional webhook URL is absolute
+func checkWebhookURL(name, value string) error {
+	if value == "" {
+		return nil
+	}
+	parsed, err := url.Parse(value)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return fmt.Errorf("invalid %s %q: must be an http or https URL", name, value)
+	}
+	return nil
+}
+
+// Notifier posts events to the webhooks of their types
+type Notifier struct {
+	source    string
+	urls      map[string]string
+	groupSize int
+	client    *http.Client
+	pending   sync.WaitGroup
+}
+
+// New creates a notifier, or returns nil when no event type has a webhook
+func New(config Config) *Notifier {
+	if len(config.WebhookURLs) == 0 {
+		return nil
+	}
+	return &Notifier{
+		source:    config.Source,
+		urls:      config.WebhookURLs,
+		groupSize: config.GroupBookingPassengers,
+		client:    &http.Client{Timeout: notifyTimeout},
+	}
+}
+
+// Enabled reports whether events of the type are posted
+func (n *Notifier) Enabled(eventType string) bool {
+	return n.urls[eventType] != ""
+}
+
+// Notify posts the event to the webhook of its type and treats any non-2xx
+// response as a failure. Events of types without a webhook are dropped.
+func (n *Notifier) Notify(ctx context.Context, event Event) error {
+	webhookURL := n.urls[event.Type]
+	if webhookURL == "" {
+		return nil
+	}
+	text := event.Text
+	if n.source != "" {
+		text = "*" + n.source + "*: " + text
+	}
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %v", event.Type, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call webhook: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Send posts the event in the background, so requests never wait for the
+// chat service, and logs failures
+func (n *Notifier) Send(event Event) {
+	if !n.Enabled(event.Type) {
+		return
+	}
+	n.pending.Add(1)
+	go func() {
+		defer n.pending.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+		defer cancel()
+		if err := n.Notify(ctx, event); err != nil {
+			log.Printf("Failed to post %s event: %v", event.Type, err)
+		}
+	}()
+}
+
+// Close waits for the events being sent
+func (n *Notifier) Close() {
+	n.pending.Wait()
+}
+
+// GroupBooking posts a new booking of at least the configured number of passengers
+func (n *Notifier) GroupBooking(ticket *models.FlightTicket, actor string) {
+	if ticket.Passengers < n.groupSize {
+		return
+	}
+	n.Send(Event{
+		Type: EventGroupBooking,
+		Text: fmt.Sprintf("group booking %s of %d passengers on %s %s-%s departing %s, by %s (%s)",
+			ticket.ConfirmationID, ticket.Passengers, ticket.FlightNumber, ticket.Origin, ticket.Destination,
+			ticket.DepartureDate.Format("2006-01-02"), actor, ticket.Status),
+	})
+}
@@ -0,0 +1,131 @@
+package opsnotify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+// chat collects the messages posted to a test webhook
+type chat struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func newChat(t *testing.T) (*chat, string) {
+	c := &chat{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct{ Text string }
+		json.NewDecoder(r.Body).Decode(&body)
+		c.mu.Lock()
+		c.messages = append(c.messages, body.Text)
+		c.mu.Unlock()
+	}))
+	t.Cleanup(server.Close)
+	return c, server.URL
+}
+
+func (c *chat) posted() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.messages...)
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("K_REVISION", "flight-ticket-service-00042-abc")
+	t.Setenv("OPS_WEBHOOK_URL", "https://hooks.slack.com/services/T0/B0/x")
+	t.Setenv("OPS_WEBHOOK_URL_GROUP_BOOKING", "https://chat.googleapis.com/v1/spaces/AAA/messages?key=k")
+	t.Setenv("OPS_WEBHOOK_URL_ERROR_RATE", "off")
+	t.Setenv("OPS_GROUP_BOOKING_PASSENGERS", "8")
+	config, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Source != "flight-ticket-service-00042-abc" || config.GroupBookingPassengers != 8 || config.ErrorRateWindow != DefaultConfig.ErrorRateWindow {
+		t.Errorf("Unexpected config %+v", config)
+	}
+	notifier := New(config)
+	if !notifier.Enabled(EventDeployment) || notifier.Enabled(EventErrorRate) || !strings.HasPrefix(config.WebhookURLs[EventGroupBooking], "https://chat.googleapis.com/") {
+		t.Errorf("Unexpected webhooks %v", config.WebhookURLs)
+	}
+	if NewErrorRateMonitor(notifier, config) != nil {
+		t.Error("Expected no error rate monitor when error_rate is off")
+	}
+
+	t.Setenv("OPS_WEBHOOK_URL", "hooks.slack.com/services")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Error("Expected an error for a URL without a scheme")
+	}
+	t.Setenv("OPS_WEBHOOK_URL", "")
+	t.Setenv("OPS_ERROR_RATE_THRESHOLD", "5")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Error("Expected an error for a threshold above 1")
+	}
+	t.Setenv("OPS_ERROR_RATE_THRESHOLD", "")
+	if config, err := ConfigFromEnv(); err != nil || New(config) == nil || New(DefaultConfig) != nil {
+		t.Errorf("Expected a notifier only with a webhook, got %v", err)
+	}
+}
+
+func TestGroupBooking(t *testing.T) {
+	chat, webhookURL := newChat(t)
+	notifier := New(Config{Source: "test", WebhookURLs: map[string]string{EventGroupBooking: webhookURL}, GroupBookingPassengers: 10})
+	departure := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	for _, passengers := range []int{9, 12} {
+		notifier.GroupBooking(&models.FlightTicket{
+			ConfirmationID: "GRP123",
+			FlightNumber:   "AA1234",
+			Origin:         "JFK",
+			Destination:    "LAX",
+			DepartureDate:  departure,
+			Passengers:     passengers,
+			Status:         "CONFIRMED",
+		}, "desk")
+	}
+	notifier.Send(Event{Type: EventDeployment, Text: "deployed"})
+	notifier.Close()
+
+	want := "*test*: group booking GRP123 of 12 passengers on AA1234 JFK-LAX departing 2025-03-01, by desk (CONFIRMED)"
+	if posted := chat.posted(); len(posted) != 1 || posted[0] != want {
+		t.Errorf("Expected only the group of 12 posted, got %q", posted)
+	}
+}
+
+func TestErrorRateMonitor(t *testing.T) {
+	chat, webhookURL := newChat(t)
+	config := DefaultConfig
+	config.WebhookURLs = map[string]string{EventErrorRate: webhookURL}
+	config.ErrorRateMinRequests = 10
+	notifier := New(config)
+	monitor := NewErrorRateMonitor(notifier, config)
+
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	// 1 error in 10 requests is above 5%, but the minimum is reached first
+	for i := 0; i < 9; i++ {
+		monitor.Record(now, http.StatusOK)
+	}
+	monitor.Record(now, http.StatusInternalServerError)
+	monitor.Record(now.Add(time.Minute), http.StatusServiceUnavailable)
+	// Within the window, the spike is posted once
+	monitor.Record(now.Add(2*time.Minute), http.StatusInternalServerError)
+	notifier.Close()
+	if posted := chat.posted(); len(posted) != 1 || !strings.Contains(posted[0], "error rate 10.0% over the last 5m0s: 1 of 10 requests") {
+		t.Fatalf("Expected one error rate event, got %q", posted)
+	}
+
+	// Requests older than the window no longer count
+	for i := 0; i < 20; i++ {
+		monitor.Record(now.Add(time.Hour), http.StatusOK)
+	}
+	monitor.Record(now.Add(time.Hour), http.StatusBadGateway)
+	notifier.Close()
+	if posted := chat.posted(); len(posted) != 1 {
+		t.Errorf("Expected no event below the threshold, got %q", posted)
+	}
+}
@@ -0,0 +1,110 @@
+// Package pnr renders bookings as GDS-style (Amadeus/Sabre-like) plain-text PNR blocks.
+package pnr
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"flight-ticket-service/src/bcbp"
+	"flight-ticket-service/src/models"
+)
+
+// OfficeID is the booking office printed in the record header
+const OfficeID = "FTS1A0001"
+
+// segmentStatus maps ticket statuses to GDS segment action/status codes
+var segmentStatus = map[string]string{
+	"CONFIRMED":  "HK", // Holding confirmed
+	"CHECKED_IN": "HK",
+	"PENDING":    "HL", // Have listed (waitlisted)
+	"CANCELLED":  "XX", // Cancelled
+	"DISRUPTED":  "TK", // Schedule change
+	"REVIEW":     "HN", // Holding need (awaiting confirmation)
+}
+
+// Format renders a ticket as a numbered PNR block. Passenger names are formatted
+// as SURNAME/GIVEN; when fewer names than passengers are given the remaining
+// passengers are listed as PAX/ADULTn placeholders.
+func Format(ticket *models.FlightTicket, names []string) string {
+	var b strings.Builder
+	line := 1
+
+	fmt.Fprintf(&b, "RP/%s/%s  %s/%sZ   %s\n",
+		OfficeID, OfficeID,
+		gdsDate(ticket.CreatedAt, true), ticket.CreatedAt.UTC().Format("1504"), ticket.ConfirmationID)
+
+	// Passenger names, two per line as in Amadeus displays
+	var paxLine []string
+	for i := 0; i < ticket.Passengers; i++ {
+		name := fmt.Sprintf("PAX/ADULT%d", i+1)
+		if i < len(names) && strings.TrimSpace(names[i]) != "" {
+			last, first, _ := strings.Cut(names[i], "/")
+			name = bcbp.FormatName(last, first)
+		}
+		paxLine = append(paxLine, fmt.Sprintf("%d.%s", line, name))
+		line++
+		if len(paxLine) == 2 {
+			fmt.Fprintf(&b, "  %s\n", strings.Join(paxLine, "   "))
+			paxLine = nil
+		}
+	}
+	if len(paxLine) > 0 {
+		fmt.Fprintf(&b, "  %s\n", strings.Join(paxLine, "   "))
+	}
+
+	// Air segment: carrier, number, class, date, day of week, city pair, status + party size, departure
+	carrier, number := ticket.FlightNumber, ""
+	if c, n, suffix, err := bcbp.SplitFlightNumber(ticket.FlightNumber); err == nil {
+		carrier, number = c, n+suffix
+	}
+	status, ok := segmentStatus[ticket.Status]
+	if !ok {
+		status = "UN"
+	}
+	fmt.Fprintf(&b, "%3d  %-2s%4s Y %s %d %s%s %s%d  %s    E0\n",
+		line, carrier, number,
+		gdsDate(ticket.DepartureDate, false), isoWeekday(ticket.DepartureDate),
+		ticket.Origin, ticket.Destination,
+		status, ticket.Passengers,
+		ticket.DepartureTime.UTC().Format("1504"))
+	line++
+
+	// Remarks
+	remarks := []string{"RM STATUS " + ticket.Status}
+	if ticket.Price != nil {
+		remarks = append(remarks, fmt.Sprintf("RM FARE %s %.2f", ticket.Price.Currency, ticket.Price.Amount))
+		if ticket.Price.Currency != ticket.Price.BaseCurrency {
+			remarks = append(remarks, fmt.Sprintf("RM BASE FARE %s %.2f ROE %.6f",
+				ticket.Price.BaseCurrency, ticket.Price.BaseAmount, ticket.Price.ExchangeRate))
+		}
+	}
+	remarks = append(remarks,
+		"RM CREATED "+gdsDate(ticket.CreatedAt, true)+" "+ticket.CreatedAt.UTC().Format("1504")+"Z",
+		"RM UPDATED "+gdsDate(ticket.UpdatedAt, true)+" "+ticket.UpdatedAt.UTC().Format("1504")+"Z",
+	)
+	for _, remark := range remarks {
+		fmt.Fprintf(&b, "%3d %s\n", line, remark)
+		line++
+	}
+
+	return b.String()
+}
+
+// gdsDate formats a date as 25DEC or, with the year, 25DEC24
+func gdsDate(t time.Time, withYear bool) string {
+	layout := "02Jan"
+	if withYear {
+		layout = "02Jan06"
+	}
+	return strings.ToUpper(t.UTC().Format(layout))
+}
+
+// isoWeekday returns the day of the week as 1 (Monday) to 7 (Sunday)
+func isoWeekday(t time.Time) int {
+	day := int(t.UTC().Weekday())
+	if day == 0 {
+		return 7
+	}
+	return day
+}
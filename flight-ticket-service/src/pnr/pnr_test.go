@@ -0,0 +1,64 @@
+package pnr
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+func TestFormat(t *testing.T) {
+	created := time.Date(2024, 7, 12, 19, 0, 0, 0, time.UTC)
+	ticket := &models.FlightTicket{
+		ConfirmationID: "ABC123",
+		Origin:         "JFK",
+		Destination:    "LAX",
+		DepartureDate:  time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC),
+		DepartureTime:  time.Date(2024, 12, 25, 14, 30, 0, 0, time.UTC),
+		FlightNumber:   "AA1234",
+		Passengers:     3,
+		Status:         "CONFIRMED",
+		Price: &models.Price{
+			Amount:       549.24,
+			Currency:     "EUR",
+			BaseAmount:   597,
+			BaseCurrency: "USD",
+			ExchangeRate: 0.92,
+		},
+		CreatedAt: created,
+		UpdatedAt: created,
+	}
+
+	expected := "RP/FTS1A0001/FTS1A0001  12JUL24/1900Z   ABC123\n" +
+		"  1.DOE/JOHN   2.DOE/JANE\n" +
+		"  3.PAX/ADULT3\n" +
+		"  4  AA1234 Y 25DEC 3 JFKLAX HK3  1430    E0\n" +
+		"  5 RM STATUS CONFIRMED\n" +
+		"  6 RM FARE EUR 549.24\n" +
+		"  7 RM BASE FARE USD 597.00 ROE 0.920000\n" +
+		"  8 RM CREATED 12JUL24 1900Z\n" +
+		"  9 RM UPDATED 12JUL24 1900Z\n"
+
+	if got := Format(ticket, []string{"Doe/John", "DOE/JANE"}); got != expected {
+		t.Errorf("Unexpected PNR:\n%s\nexpected:\n%s", got, expected)
+	}
+}
+
+func TestFormatCancelledSegment(t *testing.T) {
+	ticket := &models.FlightTicket{
+		ConfirmationID: "XYZ789",
+		Origin:         "LHR",
+		Destination:    "CDG",
+		DepartureDate:  time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC),
+		DepartureTime:  time.Date(2024, 3, 3, 7, 5, 0, 0, time.UTC),
+		FlightNumber:   "BA304",
+		Passengers:     1,
+		Status:         "CANCELLED",
+	}
+
+	expected := "  2  BA 304 Y 03MAR 7 LHRCDG XX1  0705    E0\n"
+	if got := Format(ticket, nil); !strings.Contains(got, expected) {
+		t.Errorf("Expected segment line %q in:\n%s", expected, got)
+	}
+}
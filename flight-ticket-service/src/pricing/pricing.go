@@ -0,0 +1,236 @@
+// Package pricing computes fares and the fare calendars built from them.
+//
+// Fares follow a simple model in the base currency: a fixed charge plus a
+// rate per kilometre of great-circle distance, or models.DefaultBaseFare
+// between airports without coordinates, adjusted for the day of the week and
+// the hour of departure (UTC) and for how far ahead the flight is booked.
+// Calendars are cached in memory for FARES_CACHE_TTL (default 6h), and never
+// past the end of the UTC day, since the advance purchase factor changes with
+// the booking day.
+package pricing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"flight-ticket-service/src/currency"
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/services"
+)
+
+// DefaultCacheTTL is how long a fare calendar is cached
+const DefaultCacheTTL = 6 * time.Hour
+
+// MaxMonthsAhead is how many months after the current one a calendar can be requested for
+const MaxMonthsAhead = 12
+
+// maxCacheEntries bounds the calendars kept in memory
+const maxCacheEntries = 10000
+
+// Fare model in the base currency
+const (
+	fixedCharge = 49.00
+	ratePerKM   = 0.08
+)
+
+// ErrMonthOutOfRange is returned for months before the current one or more
+// than MaxMonthsAhead after it
+var ErrMonthOutOfRange = errors.New("month out of range")
+
+// CacheTTLFromEnv reads FARES_CACHE_TTL
+func CacheTTLFromEnv() (time.Duration, error) {
+	value := strings.TrimSpace(os.Getenv("FARES_CACHE_TTL"))
+	if value == "" {
+		return DefaultCacheTTL, nil
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl < 0 {
+		return 0, fmt.Errorf("invalid FARES_CACHE_TTL %q: must be a non-negative duration", value)
+	}
+	return ttl, nil
+}
+
+// Fare returns the per-passenger fare in the base currency for a flight
+// departing at departure and booked at now
+func Fare(origin, destination string, departure, now time.Time) float64 {
+	fare := models.DefaultBaseFare
+	if distance, ok := services.Distance(origin, destination); ok {
+		fare = fixedCharge + ratePerKM*distance
+	}
+	departure = departure.UTC()
+	fare *= weekdayFactor(departure.Weekday()) * hourFactor(departure.Hour()) * advanceFactor(departure.Sub(now))
+	return currency.Round(fare)
+}
+
+// weekdayFactor makes midweek flights cheaper and weekend returns dearer
+func weekdayFactor(day time.Weekday) float64 {
+	switch day {
+	case time.Tuesday, time.Wednesday:
+		return 0.85
+	case time.Saturday:
+		return 0.9
+	case time.Friday, time.Sunday:
+		return 1.15
+	default:
+		return 1
+	}
+}
+
+// hourFactor makes overnight flights cheaper and rush-hour flights dearer
+func hourFactor(hour int) float64 {
+	switch {
+	case hour < 6 || hour >= 22:
+		return 0.8
+	case hour < 9 || (hour >= 16 && hour < 19):
+		return 1.1
+	default:
+		return 1
+	}
+}
+
+// advanceFactor makes flights dearer as departure gets closer
+func advanceFactor(ahead time.Duration) float64 {
+	days := ahead.Hours() / 24
+	switch {
+	case days < 7:
+		return 1.5
+	case days < 14:
+		return 1.25
+	case days < 21:
+		return 1.1
+	default:
+		return 1
+	}
+}
+
+// LowestFare returns the lowest fare in the base currency of the hourly
+// departures on the day (UTC) that are still ahead of now, and the departure
+// it is for; ok is false when none are
+func LowestFare(origin, destination string, day, now time.Time) (fare float64, departure time.Time, ok bool) {
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	for hour := 0; hour < 24; hour++ {
+		candidate := start.Add(time.Duration(hour) * time.Hour)
+		if !candidate.After(now) {
+			continue
+		}
+		if price := Fare(origin, destination, candidate, now); !ok || price < fare {
+			fare, departure, ok = price, candidate, true
+		}
+	}
+	return fare, departure, ok
+}
+
+// Calendar builds and caches fare calendars
+type Calendar struct {
+	converter *currency.Converter
+	ttl       time.Duration
+	now       func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*models.FareCalendarResponse
+}
+
+// NewCalendar creates a calendar that caches its months for ttl; 0 disables the cache
+func NewCalendar(converter *currency.Converter, ttl time.Duration) *Calendar {
+	return &Calendar{
+		converter: converter,
+		ttl:       ttl,
+		now:       time.Now,
+		entries:   make(map[string]*models.FareCalendarResponse),
+	}
+}
+
+// Month returns the lowest fare of each day of the month from today on, in
+// the display currency. The result is shared between callers and must not
+// be modified.
+func (c *Calendar) Month(ctx context.Context, origin, destination string, month time.Time, displayCurrency string) (*models.FareCalendarResponse, error) {
+	if displayCurrency == "" {
+		displayCurrency = currency.BaseCurrency
+	}
+	displayCurrency, err := currency.Normalize(displayCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	now := c.now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	first := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	current := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if first.Before(current) || first.After(current.AddDate(0, MaxMonthsAhead, 0)) {
+		return nil, ErrMonthOutOfRange
+	}
+
+	key := strings.Join([]string{origin, destination, first.Format("2006-01"), displayCurrency, today.Format("2006-01-02")}, "|")
+	c.mu.Lock()
+	cached, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && now.Before(cached.ExpiresAt) {
+		return cached, nil
+	}
+
+	rate := 1.0
+	if displayCurrency != currency.BaseCurrency {
+		if rate, _, err = c.converter.Rate(ctx, currency.BaseCurrency, displayCurrency); err != nil {
+			return nil, err
+		}
+	}
+
+	calendar := &models.FareCalendarResponse{
+		Origin:      origin,
+		Destination: destination,
+		Month:       first.Format("2006-01"),
+		Currency:    displayCurrency,
+		Days:        []*models.FareDay{},
+		GeneratedAt: now,
+		ExpiresAt:   now,
+	}
+	for day := first; day.Month() == first.Month(); day = day.AddDate(0, 0, 1) {
+		if day.Before(today) {
+			continue
+		}
+		fare, departure, ok := LowestFare(origin, destination, day, now)
+		if !ok {
+			continue
+		}
+		fareDay := &models.FareDay{
+			Date:          day.Format("2006-01-02"),
+			Fare:          currency.Round(fare * rate),
+			DepartureTime: departure.Format("15:04"),
+		}
+		calendar.Days = append(calendar.Days, fareDay)
+		if calendar.Cheapest == nil || fareDay.Fare < calendar.Cheapest.Fare {
+			calendar.Cheapest = fareDay
+		}
+	}
+
+	if c.ttl > 0 {
+		calendar.ExpiresAt = now.Add(c.ttl)
+		if tomorrow := today.AddDate(0, 0, 1); calendar.ExpiresAt.After(tomorrow) {
+			calendar.ExpiresAt = tomorrow
+		}
+		c.store(key, calendar, now)
+	}
+	return calendar, nil
+}
+
+// store caches a calendar, dropping expired ones first
+func (c *Calendar) store(key string, calendar *models.FareCalendarResponse, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= maxCacheEntries {
+		for k, cached := range c.entries {
+			if !now.Before(cached.ExpiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= maxCacheEntries {
+			return
+		}
+	}
+	c.entries[key] = calendar
+}
@@ -0,0 +1,102 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/currency"
+	"flight-ticket-service/src/models"
+)
+
+func TestFare(t *testing.T) {
+	now := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	tuesday := time.Date(2025, 8, 12, 12, 0, 0, 0, time.UTC)
+
+	if Fare("JFK", "LAX", tuesday, now) <= Fare("JFK", "BOS", tuesday, now) {
+		t.Error("Expected longer routes to cost more")
+	}
+	if Fare("JFK", "LAX", tuesday, now) >= Fare("JFK", "LAX", tuesday.AddDate(0, 0, 3), now) {
+		t.Error("Expected Tuesday to be cheaper than Friday")
+	}
+	if Fare("JFK", "LAX", tuesday.Add(11*time.Hour), now) >= Fare("JFK", "LAX", tuesday, now) {
+		t.Error("Expected a late departure to be cheaper than midday")
+	}
+	if Fare("JFK", "LAX", tuesday, now) >= Fare("JFK", "LAX", tuesday, tuesday.AddDate(0, 0, -3)) {
+		t.Error("Expected booking ahead to be cheaper")
+	}
+	if got := Fare("JFK", "XXX", tuesday, now); got != currency.Round(models.DefaultBaseFare*0.85) {
+		t.Errorf("Expected the default base fare for unknown airports, got %v", got)
+	}
+}
+
+func TestLowestFare(t *testing.T) {
+	day := time.Date(2025, 8, 12, 0, 0, 0, 0, time.UTC)
+	fare, departure, ok := LowestFare("JFK", "LAX", day, day.AddDate(0, -1, 0))
+	if !ok || departure.Hour() != 0 || fare != Fare("JFK", "LAX", departure, day.AddDate(0, -1, 0)) {
+		t.Errorf("Expected the first overnight departure, got %v at %v", fare, departure)
+	}
+
+	// Only departures still ahead count
+	if _, departure, ok := LowestFare("JFK", "LAX", day, day.Add(20*time.Hour)); !ok || departure.Hour() != 22 {
+		t.Errorf("Expected 22:00, got %v", departure)
+	}
+	if _, _, ok := LowestFare("JFK", "LAX", day, day.Add(23*time.Hour)); ok {
+		t.Error("Expected no departures left")
+	}
+}
+
+func TestCalendarMonth(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 7, 20, 9, 30, 0, 0, time.UTC)
+	calendar := NewCalendar(currency.NewConverter(currency.NewStaticRateProvider(), time.Hour), 24*time.Hour)
+	calendar.now = func() time.Time { return now }
+
+	july, err := calendar.Month(ctx, "JFK", "LAX", now, "")
+	if err != nil {
+		t.Fatalf("Month failed: %v", err)
+	}
+	if len(july.Days) != 12 || july.Days[0].Date != "2025-07-20" || july.Currency != "USD" {
+		t.Fatalf("Expected 20-31 July in USD, got %d days from %s in %s", len(july.Days), july.Days[0].Date, july.Currency)
+	}
+	for _, day := range july.Days {
+		if day.Fare < july.Cheapest.Fare {
+			t.Errorf("%s at %v is cheaper than the cheapest day %s", day.Date, day.Fare, july.Cheapest.Date)
+		}
+	}
+	if !july.ExpiresAt.Equal(time.Date(2025, 7, 21, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the cache to expire at midnight, got %v", july.ExpiresAt)
+	}
+	if again, _ := calendar.Month(ctx, "JFK", "LAX", now, "usd"); again != july {
+		t.Error("Expected the cached calendar")
+	}
+
+	august, err := calendar.Month(ctx, "JFK", "LAX", time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC), "EUR")
+	if err != nil {
+		t.Fatalf("Month failed: %v", err)
+	}
+	if len(august.Days) != 31 || august.Currency != "EUR" || august.Days[0].Fare == Fare("JFK", "LAX", time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC), now) {
+		t.Errorf("Expected 31 days converted to EUR, got %d days in %s", len(august.Days), august.Currency)
+	}
+
+	for _, month := range []time.Time{now.AddDate(0, -1, 0), now.AddDate(0, MaxMonthsAhead+1, 0)} {
+		if _, err := calendar.Month(ctx, "JFK", "LAX", month, ""); !errors.Is(err, ErrMonthOutOfRange) {
+			t.Errorf("%s: expected ErrMonthOutOfRange, got %v", month.Format("2006-01"), err)
+		}
+	}
+	if _, err := calendar.Month(ctx, "JFK", "LAX", now, "EURO"); !errors.Is(err, currency.ErrUnsupportedCurrency) {
+		t.Errorf("Expected ErrUnsupportedCurrency, got %v", err)
+	}
+}
+
+func TestCacheTTLFromEnv(t *testing.T) {
+	t.Setenv("FARES_CACHE_TTL", "")
+	if got, err := CacheTTLFromEnv(); err != nil || got != DefaultCacheTTL {
+		t.Errorf("Expected the default, got %v, %v", got, err)
+	}
+	t.Setenv("FARES_CACHE_TTL", "-1h")
+	if _, err := CacheTTLFromEnv(); err == nil {
+		t.Error("Expected an error for a negative duration")
+	}
+}
@@ -0,0 +1,78 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"flight-ticket-service/src/internal/shardcounter"
+
+	"cloud.google.com/go/firestore"
+)
+
+// DefaultShards is the number of counter shards per key and day
+const DefaultShards = 5
+
+// counterRetention keeps counter documents for a day after their day ends,
+// for a Firestore TTL policy on expires_at to remove
+const counterRetention = 48 * time.Hour
+
+// FirestoreCounter keeps counts in sharded Firestore counters, shared by every
+// instance. Each key and day is a document of the booking_quotas collection
+// whose shards subcollection holds the counts; a booking increments one
+// random shard, so concurrent bookings rarely write the same document.
+type FirestoreCounter struct {
+	client  *firestore.Client
+	counter shardcounter.Counter
+}
+
+// NewFirestoreCounter creates a counter in the database of the given client with the given number of shards per key and day
+func NewFirestoreCounter(client *firestore.Client, shards int) (*FirestoreCounter, error) {
+	counter, err := shardcounter.New(shards)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FirestoreCounter{client: client, counter: counter}, nil
+}
+
+// Count sums the shards of a key and day
+func (c *FirestoreCounter) Count(ctx context.Context, name, day string) (int64, error) {
+	docs, err := c.client.GetAll(ctx, c.counter.ShardRefs(c.counterDoc(name, day)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read booking quota counter: %v", err)
+	}
+
+	var shards []map[string]interface{}
+	for _, doc := range docs {
+		if doc.Exists() {
+			shards = append(shards, doc.Data())
+		}
+	}
+	return shardcounter.Sum(shards).Counts["count"], nil
+}
+
+// Increment adds one to a random shard of a key and day
+func (c *FirestoreCounter) Increment(ctx context.Context, name, day string) error {
+	expiresAt := time.Now().Add(counterRetention)
+	if start, err := time.Parse("2006-01-02", day); err == nil {
+		expiresAt = start.Add(24*time.Hour + counterRetention)
+	}
+
+	fields := shardcounter.Increments(map[string]int64{"count": 1}, nil)
+	fields["expires_at"] = expiresAt
+	_, err := c.counter.ShardRef(c.counterDoc(name, day)).Set(ctx, fields, firestore.MergeAll)
+	if err != nil {
+		return fmt.Errorf("failed to increment booking quota counter: %v", err)
+	}
+	return nil
+}
+
+// Close leaves the client open: it belongs to the ticket repository
+func (c *FirestoreCounter) Close() error {
+	return nil
+}
+
+func (c *FirestoreCounter) counterDoc(name, day string) *firestore.DocumentRef {
+	return c.client.Collection("booking_quotas").Doc(day + "_" + name)
+}
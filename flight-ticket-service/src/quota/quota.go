@@ -0,0 +1,279 @@
+// Package quota limits how many tickets each API key may book per day, so a
+// runaway client cannot fill the demo project.
+//
+// BOOKING_QUOTA sets the daily limit of every key (unset or 0 means no limit)
+// and BOOKING_QUOTA_OVERRIDES sets it per key name, e.g. "ci:1000,bot:20".
+// Requests without a key share the "anonymous" quota. Days start at midnight
+// UTC. The quota is soft: bookings that race past the limit are not undone.
+package quota
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"flight-ticket-service/src/auth"
+	"flight-ticket-service/src/models"
+)
+
+// Anonymous is the quota name of requests without an API key
+const Anonymous = "anonymous"
+
+// Counter stores the number of bookings per key and day
+type Counter interface {
+	// Count returns the bookings recorded for a key on a day (YYYY-MM-DD)
+	Count(ctx context.Context, name, day string) (int64, error)
+	// Increment records one booking for a key on a day
+	Increment(ctx context.Context, name, day string) error
+}
+
+// Config holds the daily limits; a limit of 0 means unlimited
+type Config struct {
+	Limit     int
+	Overrides map[string]int
+	Shards    int // Firestore counter shards per key and day
+}
+
+// ConfigFromEnv reads BOOKING_QUOTA, BOOKING_QUOTA_OVERRIDES and BOOKING_QUOTA_SHARDS
+func ConfigFromEnv() (Config, error) {
+	config := Config{Overrides: map[string]int{}, Shards: DefaultShards}
+	limit, err := ParseLimit(os.Getenv("BOOKING_QUOTA"))
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid BOOKING_QUOTA: %v", err)
+	}
+	config.Limit = limit
+
+	overrides, err := ParseOverrides(os.Getenv("BOOKING_QUOTA_OVERRIDES"))
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid BOOKING_QUOTA_OVERRIDES: %v", err)
+	}
+	config.Overrides = overrides
+
+	if value := strings.TrimSpace(os.Getenv("BOOKING_QUOTA_SHARDS")); value != "" {
+		shards, err := strconv.Atoi(value)
+		if err != nil || shards < 1 {
+			return Config{}, fmt.Errorf("invalid BOOKING_QUOTA_SHARDS %q: must be a positive number", value)
+		}
+		config.Shards = shards
+	}
+	return config, nil
+}
+
+// ParseLimit parses a daily limit; empty means unlimited
+func ParseLimit(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		return 0, fmt.Errorf("%q must be a non-negative number", value)
+	}
+	return limit, nil
+}
+
+// ParseOverrides parses a comma-separated list of name:limit pairs
+func ParseOverrides(spec string) (map[string]int, error) {
+	overrides := make(map[string]int)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, ":")
+		limit, err := strconv.Atoi(strings.TrimSpace(value))
+		if !ok || strings.TrimSpace(name) == "" || err != nil || limit < 0 {
+			return nil, fmt.Errorf("entry %q must be name:limit with a non-negative limit", entry)
+		}
+		overrides[strings.TrimSpace(name)] = limit
+	}
+	return overrides, nil
+}
+
+// Enabled reports whether any key has a limit
+func (c Config) Enabled() bool {
+	if c.Limit > 0 {
+		return true
+	}
+	for _, limit := range c.Overrides {
+		if limit > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// LimitFor returns the daily limit of a key
+func (c Config) LimitFor(name string) int {
+	if limit, ok := c.Overrides[name]; ok {
+		return limit
+	}
+	return c.Limit
+}
+
+// Limiter enforces the daily booking quotas
+type Limiter struct {
+	counter Counter
+	now     func() time.Time
+
+	mu     sync.RWMutex
+	config Config
+}
+
+// New creates a limiter that keeps its counts in counter
+func New(config Config, counter Counter) *Limiter {
+	return &Limiter{config: config, counter: counter, now: time.Now}
+}
+
+// Enabled reports whether any key has a limit
+func (l *Limiter) Enabled() bool {
+	return l.Config().Enabled()
+}
+
+// Config returns the current limits
+func (l *Limiter) Config() Config {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.config
+}
+
+// SetLimits changes the daily limits at runtime; counts are kept, so a lower
+// limit applies to the bookings already made today
+func (l *Limiter) SetLimits(limit int, overrides map[string]int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.config.Limit = limit
+	l.config.Overrides = overrides
+}
+
+// Status returns the quota of a key for the current day
+func (l *Limiter) Status(ctx context.Context, name string) (models.QuotaStatus, error) {
+	now := l.now().UTC()
+	status := models.QuotaStatus{
+		Name:      name,
+		Limit:     l.Config().LimitFor(name),
+		Remaining: -1,
+		ResetsAt:  time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC),
+	}
+	if status.Limit == 0 {
+		return status, nil
+	}
+
+	used, err := l.counter.Count(ctx, name, now.Format("2006-01-02"))
+	if err != nil {
+		return models.QuotaStatus{}, err
+	}
+	status.Used = used
+	status.Remaining = int64(status.Limit) - used
+	if status.Remaining < 0 {
+		status.Remaining = 0
+	}
+	return status, nil
+}
+
+// Middleware rejects bookings with 429 once the caller's key has used its
+// quota for the day, and counts each successful booking. Counter failures
+// let the request through so an outage does not stop bookings.
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := CallerName(r)
+		status, err := l.Status(r.Context(), name)
+		if err != nil {
+			log.Printf("Failed to read booking quota of %s: %v", name, err)
+			next.ServeHTTP(w, r)
+			return
+		}
+		if status.Limit == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		setHeaders(w, status)
+		if status.Remaining == 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(status.ResetsAt.Sub(l.now()).Seconds())+1))
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusTooManyRequests)
+			json.NewEncoder(w).Encode(models.QuotaExceededResponse{
+				Error:    "Booking quota exceeded",
+				Message:  fmt.Sprintf("API key %s may book %d tickets per day", name, status.Limit),
+				Limit:    status.Limit,
+				Used:     status.Used,
+				ResetsAt: status.ResetsAt,
+			})
+			return
+		}
+
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+		if sw.status != http.StatusCreated {
+			return
+		}
+		if err := l.counter.Increment(r.Context(), name, l.now().UTC().Format("2006-01-02")); err != nil {
+			log.Printf("Failed to count booking of %s: %v", name, err)
+		}
+	})
+}
+
+// setHeaders adds the X-RateLimit headers describing the quota before this request
+func setHeaders(w http.ResponseWriter, status models.QuotaStatus) {
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(status.Limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.FormatInt(status.Remaining, 10))
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(status.ResetsAt.Unix(), 10))
+}
+
+// CallerName returns the API key name of the request, or Anonymous
+func CallerName(r *http.Request) string {
+	if principal, ok := auth.FromContext(r.Context()); ok && principal.Name != "" {
+		return principal.Name
+	}
+	return Anonymous
+}
+
+// statusWriter captures the response status
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// MemoryCounter keeps counts in memory, per instance; counts are lost on restart
+type MemoryCounter struct {
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+// NewMemoryCounter creates an empty in-memory counter
+func NewMemoryCounter() *MemoryCounter {
+	return &MemoryCounter{counts: make(map[string]int64)}
+}
+
+// Count returns the bookings recorded for a key on a day
+func (c *MemoryCounter) Count(ctx context.Context, name, day string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[day+"/"+name], nil
+}
+
+// Increment records one booking and forgets earlier days
+func (c *MemoryCounter) Increment(ctx context.Context, name, day string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.counts {
+		if !strings.HasPrefix(key, day+"/") {
+			delete(c.counts, key)
+		}
+	}
+	c.counts[day+"/"+name]++
+	return nil
+}
@@ -0,0 +1,105 @@
+package quota
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/auth"
+	"flight-ticket-service/src/models"
+)
+
+func TestParseOverrides(t *testing.T) {
+	overrides, err := ParseOverrides(" ci:1000, bot:20 ,,")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(overrides) != 2 || overrides["ci"] != 1000 || overrides["bot"] != 20 {
+		t.Errorf("Unexpected overrides %v", overrides)
+	}
+
+	for _, spec := range []string{"ci", "ci:many", ":5", "bot:-1"} {
+		if _, err := ParseOverrides(spec); err == nil {
+			t.Errorf("Expected error for %q", spec)
+		}
+	}
+}
+
+func TestConfigLimitFor(t *testing.T) {
+	config := Config{Limit: 100, Overrides: map[string]int{"ci": 0, "bot": 5}}
+	if !config.Enabled() {
+		t.Error("Expected quotas to be enabled")
+	}
+	if config.LimitFor("desk") != 100 || config.LimitFor("ci") != 0 || config.LimitFor("bot") != 5 {
+		t.Errorf("Unexpected limits for %+v", config)
+	}
+	if (Config{Overrides: map[string]int{"ci": 0}}).Enabled() {
+		t.Error("Expected quotas to be disabled without positive limits")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	limiter := New(Config{Limit: 2}, NewMemoryCounter())
+	limiter.now = func() time.Time { return time.Date(2024, 7, 12, 22, 0, 0, 0, time.UTC) }
+
+	status := http.StatusCreated
+	handler := limiter.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	book := func(name string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/ticket/", nil)
+		if name != "" {
+			req = req.WithContext(auth.WithPrincipal(req.Context(), auth.Principal{Name: name, Role: auth.RoleAgent}))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Failed bookings are not counted
+	status = http.StatusBadRequest
+	book("desk")
+	status = http.StatusCreated
+
+	for i := 0; i < 2; i++ {
+		if rec := book("desk"); rec.Code != http.StatusCreated || rec.Header().Get("X-RateLimit-Remaining") != strconv.Itoa(2-i) {
+			t.Fatalf("Booking %d: status %d, remaining %q", i, rec.Code, rec.Header().Get("X-RateLimit-Remaining"))
+		}
+	}
+
+	rec := book("desk")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") != strconv.Itoa(2*60*60+1) {
+		t.Errorf("Unexpected Retry-After %q", rec.Header().Get("Retry-After"))
+	}
+	var response models.QuotaExceededResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Limit != 2 || response.Used != 2 || !response.ResetsAt.Equal(time.Date(2024, 7, 13, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected response %+v", response)
+	}
+
+	// Other keys and anonymous callers have their own counts
+	if rec := book("ops"); rec.Code != http.StatusCreated {
+		t.Errorf("Expected another key to book, got %d", rec.Code)
+	}
+	if rec := book(""); rec.Code != http.StatusCreated {
+		t.Errorf("Expected anonymous caller to book, got %d", rec.Code)
+	}
+	if quota, _ := limiter.Status(context.Background(), Anonymous); quota.Used != 1 || quota.Remaining != 1 {
+		t.Errorf("Unexpected anonymous quota %+v", quota)
+	}
+
+	// The count starts again the next day
+	limiter.now = func() time.Time { return time.Date(2024, 7, 13, 0, 5, 0, 0, time.UTC) }
+	if rec := book("desk"); rec.Code != http.StatusCreated {
+		t.Errorf("Expected booking on the next day, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,347 @@
+// Package recording captures sanitized API requests so they can be replayed
+// against another environment (staging or the local emulator stack) to
+// reproduce production issues.
+//
+// Recording is enabled with RECORD_DESTINATION, either a local directory or a
+// gs://bucket/prefix. Records are JSON lines; credentials and passenger
+// details are removed before they leave the request path.
+package recording
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"flight-ticket-service/src/auth"
+
+	"github.com/go-chi/chi/middleware"
+)
+
+// Redacted replaces sensitive values in recorded bodies
+const Redacted = "REDACTED"
+
+// Defaults for the recorder settings
+const (
+	DefaultMaxBodyBytes  = 64 << 10
+	DefaultFlushInterval = 10 * time.Second
+	bufferSize           = 1000
+)
+
+// recordedHeaders are kept; all others, including credentials and cookies, are dropped
+var recordedHeaders = []string{"Accept", "Accept-Language", "Content-Type", "User-Agent"}
+
+// sensitiveFields are JSON fields whose values are replaced with Redacted
+var sensitiveFields = map[string]bool{
+	"first_name":     true,
+	"last_name":      true,
+	"passenger_name": true,
+	"bcbp":           true,
+	"email":          true,
+	"phone":          true,
+	"upload_url":     true,
+	"upload_headers": true,
+	"download_url":   true,
+	"qr_url":         true,
+	"text":           true, // agent notes
+}
+
+// skippedPrefixes are never recorded: probes, metrics, docs and operator endpoints
+var skippedPrefixes = []string{"/health", "/version", "/metrics", "/swagger", "/admin"}
+
+// Record is one recorded request and the status it produced
+type Record struct {
+	ID           string            `json:"id,omitempty"`
+	Time         time.Time         `json:"time"`
+	Method       string            `json:"method"`
+	Path         string            `json:"path"`
+	Query        string            `json:"query,omitempty"`
+	Header       map[string]string `json:"header,omitempty"`
+	Body         string            `json:"body,omitempty"`
+	Principal    string            `json:"principal,omitempty"` // role of the API key used, never the key
+	Status       int               `json:"status"`
+	ResponseBody string            `json:"response_body,omitempty"`
+	DurationMs   int64             `json:"duration_ms"`
+}
+
+// URI returns the path with its query string
+func (rec Record) URI() string {
+	if rec.Query == "" {
+		return rec.Path
+	}
+	return rec.Path + "?" + rec.Query
+}
+
+// Config configures a Recorder
+type Config struct {
+	// Destination is a local directory or gs://bucket/prefix
+	Destination     string
+	CredentialsPath string
+	// SampleRate is the fraction of requests recorded, between 0 and 1
+	SampleRate    float64
+	MaxBodyBytes  int
+	FlushInterval time.Duration
+}
+
+// ConfigFromEnv reads RECORD_DESTINATION and RECORD_SAMPLE_RATE.
+// Recording is disabled when the destination is empty.
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		Destination:     os.Getenv("RECORD_DESTINATION"),
+		CredentialsPath: os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"),
+		SampleRate:      1,
+		MaxBodyBytes:    DefaultMaxBodyBytes,
+		FlushInterval:   DefaultFlushInterval,
+	}
+
+	if rate := os.Getenv("RECORD_SAMPLE_RATE"); rate != "" {
+		parsed, err := strconv.ParseFloat(rate, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			return cfg, fmt.Errorf("invalid RECORD_SAMPLE_RATE %q: must be between 0 and 1", rate)
+		}
+		cfg.SampleRate = parsed
+	}
+	return cfg, nil
+}
+
+// Recorder writes sampled, sanitized requests to a sink in the background
+type Recorder struct {
+	sink          Sink
+	sampleRate    float64
+	maxBodyBytes  int
+	flushInterval time.Duration
+
+	records chan Record
+	done    chan struct{}
+	once    sync.Once
+	dropped atomic.Int64
+}
+
+// New creates a recorder writing to the configured destination
+func New(ctx context.Context, cfg Config) (*Recorder, error) {
+	sink, err := NewSink(ctx, cfg.Destination, cfg.CredentialsPath)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithSink(sink, cfg), nil
+}
+
+// NewWithSink creates a recorder writing to sink
+func NewWithSink(sink Sink, cfg Config) *Recorder {
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = DefaultFlushInterval
+	}
+
+	r := &Recorder{
+		sink:          sink,
+		sampleRate:    cfg.SampleRate,
+		maxBodyBytes:  cfg.MaxBodyBytes,
+		flushInterval: cfg.FlushInterval,
+		records:       make(chan Record, bufferSize),
+		done:          make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+// Sink returns the name of the destination
+func (r *Recorder) Sink() string {
+	return r.sink.Name()
+}
+
+// Middleware records requests. Bodies larger than the limit are not recorded,
+// and records are dropped rather than slowing requests down when the sink falls behind.
+func (r *Recorder) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !r.shouldRecord(req) {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		start := time.Now()
+		var body []byte
+		if req.Body != nil {
+			data, err := io.ReadAll(io.LimitReader(req.Body, int64(r.maxBodyBytes)+1))
+			if err != nil {
+				log.Printf("Recording: failed to read request body: %v", err)
+			}
+			// Hand the full body to the handler, including anything past the limit
+			req.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(data), req.Body), req.Body}
+			body = data
+		}
+
+		rw := &responseRecorder{ResponseWriter: w, status: http.StatusOK, limit: r.maxBodyBytes}
+		next.ServeHTTP(rw, req)
+
+		rec := Record{
+			ID:         middleware.GetReqID(req.Context()),
+			Time:       start.UTC(),
+			Method:     req.Method,
+			Path:       req.URL.Path,
+			Query:      req.URL.RawQuery,
+			Header:     make(map[string]string),
+			Status:     rw.status,
+			DurationMs: time.Since(start).Milliseconds(),
+		}
+		for _, name := range recordedHeaders {
+			if value := req.Header.Get(name); value != "" {
+				rec.Header[name] = value
+			}
+		}
+		if principal, ok := auth.FromContext(req.Context()); ok {
+			rec.Principal = string(principal.Role)
+		}
+		if len(body) <= r.maxBodyBytes {
+			rec.Body = Sanitize(body)
+		}
+		if !rw.truncated {
+			rec.ResponseBody = Sanitize(rw.body.Bytes())
+		}
+
+		select {
+		case r.records <- rec:
+		default:
+			if r.dropped.Add(1)%100 == 1 {
+				log.Printf("Recording: buffer full, %d records dropped so far", r.dropped.Load())
+			}
+		}
+	})
+}
+
+func (r *Recorder) shouldRecord(req *http.Request) bool {
+	for _, prefix := range skippedPrefixes {
+		if strings.HasPrefix(req.URL.Path, prefix) {
+			return false
+		}
+	}
+	if req.Method == http.MethodOptions {
+		return false
+	}
+	return r.sampleRate >= 1 || rand.Float64() < r.sampleRate
+}
+
+// run batches records and writes them to the sink every flush interval
+func (r *Recorder) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.flushInterval)
+	defer ticker.Stop()
+
+	var batch []Record
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := r.sink.Write(ctx, batch); err != nil {
+			log.Printf("Recording: failed to write %d records to %s: %v", len(batch), r.sink.Name(), err)
+		}
+		batch = nil
+	}
+
+	for {
+		select {
+		case rec, ok := <-r.records:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, rec)
+			if len(batch) >= bufferSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// Close flushes pending records and closes the sink. Requests must no
+// longer be served through the middleware.
+func (r *Recorder) Close() error {
+	r.once.Do(func() { close(r.records) })
+	<-r.done
+	return r.sink.Close()
+}
+
+// Sanitize returns a JSON body with sensitive fields redacted. Non-JSON
+// bodies are not recorded because they cannot be checked for passenger data.
+func Sanitize(body []byte) string {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return ""
+	}
+
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return ""
+	}
+
+	data, err := json.Marshal(redact(value))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func redact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if sensitiveFields[key] {
+				v[key] = Redacted
+			} else {
+				v[key] = redact(field)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redact(item)
+		}
+	}
+	return value
+}
+
+// responseRecorder captures the status and the start of the response body
+type responseRecorder struct {
+	http.ResponseWriter
+	status    int
+	body      bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (w *responseRecorder) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	if !w.truncated {
+		if w.body.Len()+len(data) > w.limit {
+			w.truncated = true
+			w.body.Reset()
+		} else {
+			w.body.Write(data)
+		}
+	}
+	return w.ResponseWriter.Write(data)
+}
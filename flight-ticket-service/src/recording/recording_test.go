@@ -0,0 +1,117 @@
+package recording
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type memorySink struct {
+	mu      sync.Mutex
+	records []Record
+}
+
+func (s *memorySink) Name() string { return "memory" }
+
+func (s *memorySink) Write(ctx context.Context, records []Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, records...)
+	return nil
+}
+
+func (s *memorySink) Close() error { return nil }
+
+func TestSanitizeRedactsPassengerData(t *testing.T) {
+	body := `{"passengers":[{"first_name":"Jane","last_name":"Doe"}],"boarding_passes":[{"passenger_name":"DOE/JANE","bcbp":"M1DOE","seat":"12A"}],"amount":398.10}`
+
+	sanitized := Sanitize([]byte(body))
+	var got map[string]interface{}
+	if err := json.Unmarshal([]byte(sanitized), &got); err != nil {
+		t.Fatalf("Sanitized body is not JSON: %v", err)
+	}
+	for _, secret := range []string{"Jane", "Doe", "DOE/JANE", "M1DOE"} {
+		if strings.Contains(sanitized, secret) {
+			t.Errorf("Sanitized body still contains %q: %s", secret, sanitized)
+		}
+	}
+	if !strings.Contains(sanitized, `"seat":"12A"`) || !strings.Contains(sanitized, "398.10") {
+		t.Errorf("Sanitized body lost non-sensitive fields: %s", sanitized)
+	}
+	if Sanitize([]byte("not json")) != "" {
+		t.Error("Non-JSON bodies should not be recorded")
+	}
+}
+
+func TestMiddlewareRecordsSanitizedRequests(t *testing.T) {
+	sink := &memorySink{}
+	recorder := NewWithSink(sink, Config{SampleRate: 1})
+
+	handler := recorder.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method == http.MethodPost && !strings.Contains(string(body), "Jane") {
+			t.Errorf("Handler received a modified body: %s", body)
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"confirmation_id":"ABC123"}`))
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/ticket/ABC123/checkin?x=1", strings.NewReader(`{"passengers":[{"first_name":"Jane"}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", "s3cret")
+	req.Header.Set("Cookie", "session=1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(sink.records) != 1 {
+		t.Fatalf("Expected 1 record, got %d", len(sink.records))
+	}
+
+	rec := sink.records[0]
+	if rec.Method != http.MethodPost || rec.URI() != "/ticket/ABC123/checkin?x=1" || rec.Status != http.StatusCreated {
+		t.Errorf("Unexpected record %+v", rec)
+	}
+	if len(rec.Header) != 1 || rec.Header["Content-Type"] != "application/json" {
+		t.Errorf("Unexpected headers %v", rec.Header)
+	}
+	if strings.Contains(rec.Body, "Jane") || rec.ResponseBody != `{"confirmation_id":"ABC123"}` {
+		t.Errorf("Unexpected bodies %q, %q", rec.Body, rec.ResponseBody)
+	}
+}
+
+func TestReplayerRewritesConfirmationIDs(t *testing.T) {
+	var paths []string
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"confirmation_id":"NEW999"}`))
+			return
+		}
+		if r.URL.Path != "/ticket/NEW999" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer target.Close()
+
+	replayer := NewReplayer(target.URL, "")
+	var summary Summary
+	for _, rec := range []Record{
+		{Method: http.MethodPost, Path: "/ticket/", Body: `{"origin":"JFK"}`, Status: 201, ResponseBody: `{"confirmation_id":"ABC123"}`},
+		{Method: http.MethodGet, Path: "/ticket/ABC123", Status: 200},
+	} {
+		summary.Add(replayer.Replay(context.Background(), rec))
+	}
+
+	if summary.Matched != 2 || len(paths) != 2 || paths[1] != "/ticket/NEW999" {
+		t.Errorf("Got %s, paths %v", summary, paths)
+	}
+}
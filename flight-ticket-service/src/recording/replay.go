@@ -0,0 +1,132 @@
+package recording
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Result is the outcome of replaying one record
+type Result struct {
+	ID             string `json:"id,omitempty"`
+	Method         string `json:"method"`
+	URI            string `json:"uri"`
+	RecordedStatus int    `json:"recorded_status"`
+	Status         int    `json:"status"`
+	DurationMs     int64  `json:"duration_ms"`
+	Error          string `json:"error,omitempty"`
+}
+
+// Matches reports whether the replayed request produced the recorded status
+func (r Result) Matches() bool {
+	return r.Error == "" && r.Status == r.RecordedStatus
+}
+
+// Replayer re-sends recorded requests to a target environment. Confirmation
+// IDs issued by the target are substituted for the recorded ones, so a
+// recorded create, get and cancel sequence still refers to the same ticket.
+type Replayer struct {
+	target string
+	apiKey string
+	client *http.Client
+	ids    map[string]string
+}
+
+// NewReplayer creates a replayer for the target base URL. apiKey is sent
+// with requests that were recorded with an API key.
+func NewReplayer(target, apiKey string) *Replayer {
+	return &Replayer{
+		target: strings.TrimSuffix(target, "/"),
+		apiKey: apiKey,
+		client: &http.Client{Timeout: 60 * time.Second},
+		ids:    make(map[string]string),
+	}
+}
+
+// Replay sends one record to the target
+func (p *Replayer) Replay(ctx context.Context, rec Record) Result {
+	uri := p.rewrite(rec.URI())
+	result := Result{ID: rec.ID, Method: rec.Method, URI: uri, RecordedStatus: rec.Status}
+
+	var body io.Reader
+	if rec.Body != "" {
+		body = strings.NewReader(p.rewrite(rec.Body))
+	}
+	req, err := http.NewRequestWithContext(ctx, rec.Method, p.target+uri, body)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	for name, value := range rec.Header {
+		req.Header.Set(name, value)
+	}
+	if rec.Principal != "" && p.apiKey != "" {
+		req.Header.Set("X-API-Key", p.apiKey)
+	}
+
+	start := time.Now()
+	resp, err := p.client.Do(req)
+	result.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+	result.Status = resp.StatusCode
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, DefaultMaxBodyBytes))
+	recordedID := confirmationID([]byte(rec.ResponseBody))
+	if replayedID := confirmationID(data); recordedID != "" && replayedID != "" && recordedID != replayedID {
+		p.ids[recordedID] = replayedID
+	}
+	return result
+}
+
+// rewrite replaces recorded confirmation IDs with the ones issued by the target
+func (p *Replayer) rewrite(s string) string {
+	for recorded, replayed := range p.ids {
+		s = strings.ReplaceAll(s, recorded, replayed)
+	}
+	return s
+}
+
+func confirmationID(body []byte) string {
+	var response struct {
+		ConfirmationID string `json:"confirmation_id"`
+	}
+	if err := json.Unmarshal(bytes.TrimSpace(body), &response); err != nil {
+		return ""
+	}
+	return response.ConfirmationID
+}
+
+// Summary counts replay outcomes
+type Summary struct {
+	Total      int `json:"total"`
+	Matched    int `json:"matched"`
+	Mismatched int `json:"mismatched"`
+	Errors     int `json:"errors"`
+}
+
+// Add counts a result
+func (s *Summary) Add(r Result) {
+	s.Total++
+	switch {
+	case r.Error != "":
+		s.Errors++
+	case r.Matches():
+		s.Matched++
+	default:
+		s.Mismatched++
+	}
+}
+
+// String formats the summary for logs
+func (s Summary) String() string {
+	return fmt.Sprintf("%d replayed, %d matched, %d mismatched, %d errors", s.Total, s.Matched, s.Mismatched, s.Errors)
+}
@@ -0,0 +1,287 @@
+package recording
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+// Sink stores batches of records
+type Sink interface {
+	// Name identifies the sink in logs
+	Name() string
+	// Write stores a batch of records
+	Write(ctx context.Context, records []Record) error
+	// Close releases resources
+	Close() error
+}
+
+// NewSink returns a GCS sink for gs:// destinations and a file sink otherwise
+func NewSink(ctx context.Context, destination, credentialsPath string) (Sink, error) {
+	if destination == "" {
+		return nil, fmt.Errorf("a recording destination is required")
+	}
+	if strings.HasPrefix(destination, "gs://") {
+		return NewGCSSink(ctx, destination, credentialsPath)
+	}
+	return NewFileSink(destination)
+}
+
+// batchName names the file or object for a batch written at the given time.
+// Names sort chronologically; the host keeps instances from overwriting each other.
+func batchName(at time.Time, seq int) string {
+	host, _ := os.Hostname()
+	if host == "" {
+		host = "local"
+	}
+	return fmt.Sprintf("%s-%s-%04d.jsonl", at.UTC().Format("20060102T150405.000Z"), host, seq)
+}
+
+func encodeRecords(records []Record) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, rec := range records {
+		if err := encoder.Encode(rec); err != nil {
+			return nil, fmt.Errorf("failed to encode record: %v", err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// FileSink appends records to a JSON lines file in a local directory
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileSink creates the directory if needed and opens a new file in it
+func NewFileSink(dir string) (*FileSink, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create recording directory: %v", err)
+	}
+	file, err := os.OpenFile(filepath.Join(dir, batchName(time.Now(), 0)), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording file: %v", err)
+	}
+	return &FileSink{file: file}, nil
+}
+
+// Name returns the file path
+func (s *FileSink) Name() string {
+	return s.file.Name()
+}
+
+// Write appends the records to the file
+func (s *FileSink) Write(ctx context.Context, records []Record) error {
+	data, err := encodeRecords(records)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.file.Write(data); err != nil {
+		return fmt.Errorf("failed to write recording file: %v", err)
+	}
+	return nil
+}
+
+// Close closes the file
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
+
+// GCSSink writes each batch as an object under <prefix>/<yyyy>/<mm>/<dd>/
+type GCSSink struct {
+	client *storage.Client
+	bucket string
+	prefix string
+
+	mu  sync.Mutex
+	seq int
+}
+
+// NewGCSSink creates a sink for a gs://bucket/prefix destination
+func NewGCSSink(ctx context.Context, destination, credentialsPath string) (*GCSSink, error) {
+	bucket, prefix := splitGCSPath(destination)
+	if bucket == "" {
+		return nil, fmt.Errorf("invalid recording destination %q: expected gs://bucket/prefix", destination)
+	}
+
+	var opts []option.ClientOption
+	if credentialsPath != "" {
+		// Use service account key file
+		opts = append(opts, option.WithCredentialsFile(credentialsPath))
+	}
+
+	client, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Cloud Storage client: %v", err)
+	}
+
+	return &GCSSink{client: client, bucket: bucket, prefix: prefix}, nil
+}
+
+// Name returns the destination URI
+func (s *GCSSink) Name() string {
+	return "gs://" + path.Join(s.bucket, s.prefix)
+}
+
+// Write stores the batch as a new object
+func (s *GCSSink) Write(ctx context.Context, records []Record) error {
+	data, err := encodeRecords(records)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.seq++
+	seq := s.seq
+	s.mu.Unlock()
+
+	now := time.Now().UTC()
+	name := path.Join(s.prefix, now.Format("2006/01/02"), batchName(now, seq))
+	w := s.client.Bucket(s.bucket).Object(name).NewWriter(ctx)
+	w.ContentType = "application/x-ndjson"
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to write %s: %v", name, err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %v", name, err)
+	}
+	return nil
+}
+
+// Close closes the client
+func (s *GCSSink) Close() error {
+	return s.client.Close()
+}
+
+func splitGCSPath(uri string) (bucket, prefix string) {
+	bucket, prefix, _ = strings.Cut(strings.TrimPrefix(uri, "gs://"), "/")
+	return bucket, strings.Trim(prefix, "/")
+}
+
+// Load reads the records from a JSON lines file, a directory of them or a
+// gs://bucket/prefix, ordered by request time
+func Load(ctx context.Context, source, credentialsPath string) ([]Record, error) {
+	var records []Record
+	var err error
+	if strings.HasPrefix(source, "gs://") {
+		records, err = loadGCS(ctx, source, credentialsPath)
+	} else {
+		records, err = loadFiles(source)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Time.Before(records[j].Time)
+	})
+	return records, nil
+}
+
+func loadFiles(source string) ([]Record, error) {
+	info, err := os.Stat(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", source, err)
+	}
+
+	files := []string{source}
+	if info.IsDir() {
+		files, err = filepath.Glob(filepath.Join(source, "*.jsonl"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %v", source, err)
+		}
+		sort.Strings(files)
+	}
+
+	var records []Record
+	for _, name := range files {
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %v", name, err)
+		}
+		batch, err := decodeRecords(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", name, err)
+		}
+		records = append(records, batch...)
+	}
+	return records, nil
+}
+
+func loadGCS(ctx context.Context, source, credentialsPath string) ([]Record, error) {
+	bucket, prefix := splitGCSPath(source)
+
+	var opts []option.ClientOption
+	if credentialsPath != "" {
+		opts = append(opts, option.WithCredentialsFile(credentialsPath))
+	}
+	client, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Cloud Storage client: %v", err)
+	}
+	defer client.Close()
+
+	var records []Record
+	it := client.Bucket(bucket).Objects(ctx, &storage.Query{Prefix: prefix})
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %v", source, err)
+		}
+		if !strings.HasSuffix(attrs.Name, ".jsonl") {
+			continue
+		}
+
+		reader, err := client.Bucket(bucket).Object(attrs.Name).NewReader(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", attrs.Name, err)
+		}
+		batch, err := decodeRecords(reader)
+		reader.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", attrs.Name, err)
+		}
+		records = append(records, batch...)
+	}
+	return records, nil
+}
+
+func decodeRecords(r io.Reader) ([]Record, error) {
+	var records []Record
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 8*DefaultMaxBodyBytes)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		records = append(records, rec)
+	}
+	return records, scanner.Err()
+}
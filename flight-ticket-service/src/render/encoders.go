@@ -0,0 +1,153 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// JSON encodes values with encoding/json
+type JSON struct{}
+
+// ContentType returns application/json
+func (JSON) ContentType() string { return "application/json" }
+
+// Encode writes v as JSON
+func (JSON) Encode(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+// MessagePack encodes values as MessagePack maps keyed by their JSON field
+// names, so binary and JSON clients see the same field names
+type MessagePack struct{}
+
+// ContentType returns application/msgpack
+func (MessagePack) ContentType() string { return "application/msgpack" }
+
+// Encode writes v as MessagePack; times use the MessagePack timestamp extension
+func (MessagePack) Encode(w io.Writer, v interface{}) error {
+	encoder := msgpack.NewEncoder(w)
+	encoder.SetCustomStructTag("json")
+	return encoder.Encode(v)
+}
+
+// XML encodes values as XML elements named after their JSON fields. The
+// models carry JSON tags only and use maps (labels), which encoding/xml
+// cannot marshal, so the JSON form of the value is translated element by
+// element. The root element is named after the Go type, e.g. flight_ticket,
+// and array entries are item elements.
+type XML struct{}
+
+// ContentType returns application/xml
+func (XML) ContentType() string { return "application/xml" }
+
+// Encode writes v as an XML document
+func (XML) Encode(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	encoder := xml.NewEncoder(w)
+	if err := writeXMLValue(encoder, decoder, rootName(v)); err != nil {
+		return err
+	}
+	return encoder.Flush()
+}
+
+// writeXMLValue translates the next JSON value into an element called name
+func writeXMLValue(encoder *xml.Encoder, decoder *json.Decoder, name string) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	start := xmlStart(name)
+	switch value := token.(type) {
+	case nil:
+		return nil // null fields are left out
+	case json.Delim:
+		if err := encoder.EncodeToken(start); err != nil {
+			return err
+		}
+		for decoder.More() {
+			child := "item"
+			if value == '{' {
+				key, err := decoder.Token()
+				if err != nil {
+					return err
+				}
+				child = fmt.Sprint(key)
+			}
+			if err := writeXMLValue(encoder, decoder, child); err != nil {
+				return err
+			}
+		}
+		if _, err := decoder.Token(); err != nil { // closing delimiter
+			return err
+		}
+		return encoder.EncodeToken(start.End())
+	default:
+		return encoder.EncodeElement(fmt.Sprint(value), start)
+	}
+}
+
+// xmlStart returns the start element for a JSON key. Keys that are not valid
+// XML names become entry elements with the key in a name attribute.
+func xmlStart(name string) xml.StartElement {
+	if validXMLName(name) {
+		return xml.StartElement{Name: xml.Name{Local: name}}
+	}
+	return xml.StartElement{
+		Name: xml.Name{Local: "entry"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "name"}, Value: name}},
+	}
+}
+
+func validXMLName(name string) bool {
+	if name == "" || strings.HasPrefix(strings.ToLower(name), "xml") {
+		return false
+	}
+	for i, r := range name {
+		if unicode.IsLetter(r) || r == '_' || (i > 0 && (unicode.IsDigit(r) || r == '-' || r == '.')) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// rootName names the root element after the value's type, e.g. FlightTicket becomes flight_ticket
+func rootName(v interface{}) string {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Name() == "" {
+		return "response"
+	}
+
+	var name strings.Builder
+	for i, r := range t.Name() {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				name.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		name.WriteRune(r)
+	}
+	return name.String()
+}
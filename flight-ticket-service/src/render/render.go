@@ -0,0 +1,168 @@
+// Package render writes API responses in the encoding the client asks for
+// with the Accept header. JSON is the default; XML serves a partner
+// integration and MessagePack gives the MCP server a compact binary encoding.
+//
+// Encoders are kept in a Registry so that further encodings can be added
+// without touching the handlers. Clients that accept none of the registered
+// media types get JSON.
+package render
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Encoder writes values in one media type
+type Encoder interface {
+	// ContentType is the media type of the encoding, e.g. application/xml
+	ContentType() string
+	// Encode writes v to w
+	Encode(w io.Writer, v interface{}) error
+}
+
+// Registry holds the encoders a response can be written in. The first
+// encoder registered is the default.
+type Registry struct {
+	mu       sync.RWMutex
+	encoders []Encoder
+}
+
+// NewRegistry creates a registry with the given encoders, the first being the default
+func NewRegistry(encoders ...Encoder) *Registry {
+	r := &Registry{}
+	for _, encoder := range encoders {
+		r.Register(encoder)
+	}
+	return r
+}
+
+// Default serves JSON, XML and MessagePack
+var Default = NewRegistry(JSON{}, XML{}, MessagePack{})
+
+// Register adds an encoder, replacing one with the same content type
+func (r *Registry) Register(encoder Encoder) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, existing := range r.encoders {
+		if existing.ContentType() == encoder.ContentType() {
+			r.encoders[i] = encoder
+			return
+		}
+	}
+	r.encoders = append(r.encoders, encoder)
+}
+
+// ContentTypes lists the registered media types, default first
+func (r *Registry) ContentTypes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	types := make([]string, len(r.encoders))
+	for i, encoder := range r.encoders {
+		types[i] = encoder.ContentType()
+	}
+	return types
+}
+
+// Negotiate picks the encoder for an Accept header, honoring q-values. It
+// falls back to the default encoder when nothing registered is acceptable
+// and for browsers, which accept text/html.
+func (r *Registry) Negotiate(accept string) Encoder {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.encoders) == 0 {
+		return JSON{}
+	}
+
+	ranges := parseAccept(accept)
+	for _, mediaRange := range ranges {
+		// Browsers list application/xml ahead of */*; opening the API in a tab should still show JSON
+		if mediaRange.mediaType == "text/html" {
+			return r.encoders[0]
+		}
+	}
+	for _, mediaRange := range ranges {
+		for _, encoder := range r.encoders {
+			if mediaRange.matches(encoder.ContentType()) {
+				return encoder
+			}
+		}
+	}
+	return r.encoders[0]
+}
+
+// Encode encodes v with the encoder negotiated for the request and returns the content type and body
+func (r *Registry) Encode(req *http.Request, v interface{}) (string, []byte, error) {
+	encoder := r.Negotiate(req.Header.Get("Accept"))
+	var body bytes.Buffer
+	if err := encoder.Encode(&body, v); err != nil {
+		return "", nil, err
+	}
+	return encoder.ContentType(), body.Bytes(), nil
+}
+
+// Write encodes v with the encoder negotiated for the request and writes it with the status
+func (r *Registry) Write(w http.ResponseWriter, req *http.Request, status int, v interface{}) {
+	encoder := r.Negotiate(req.Header.Get("Accept"))
+	w.Header().Set("Content-Type", encoder.ContentType())
+	w.Header().Add("Vary", "Accept")
+	w.WriteHeader(status)
+	if err := encoder.Encode(w, v); err != nil {
+		log.Printf("Failed to encode %s response: %v", encoder.ContentType(), err)
+	}
+}
+
+// mediaRange is one entry of an Accept header
+type mediaRange struct {
+	mediaType string
+	quality   float64
+}
+
+func (m mediaRange) matches(contentType string) bool {
+	switch {
+	case m.mediaType == "*/*":
+		return false // the default encoder answers wildcards
+	case strings.HasSuffix(m.mediaType, "/*"):
+		return strings.HasPrefix(contentType, strings.TrimSuffix(m.mediaType, "*"))
+	default:
+		return m.mediaType == contentType || aliases[m.mediaType] == contentType
+	}
+}
+
+// aliases maps alternative media types to the registered ones
+var aliases = map[string]string{
+	"text/xml":                "application/xml",
+	"application/x-msgpack":   "application/msgpack",
+	"application/vnd.msgpack": "application/msgpack",
+}
+
+// parseAccept returns the acceptable media ranges, most preferred first
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+				quality = parsed
+			}
+		}
+		if quality <= 0 {
+			continue
+		}
+		ranges = append(ranges, mediaRange{mediaType: mediaType, quality: quality})
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].quality > ranges[j].quality
+	})
+	return ranges
+}
@@ -0,0 +1,104 @@
+package render
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", "application/json"},
+		{"*/*", "application/json"},
+		{"application/xml", "application/xml"},
+		{"text/xml", "application/xml"},
+		{"application/x-msgpack", "application/msgpack"},
+		{"application/json;q=0.5, application/msgpack", "application/msgpack"},
+		{"application/xml;q=0, application/json", "application/json"},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", "application/json"},
+		{"image/png", "application/json"},
+	}
+	for _, tt := range tests {
+		if got := Default.Negotiate(tt.accept).ContentType(); got != tt.want {
+			t.Errorf("Negotiate(%q) = %s, want %s", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestRegisterReplacesEncoder(t *testing.T) {
+	registry := NewRegistry(JSON{}, XML{})
+	registry.Register(XML{})
+	if types := registry.ContentTypes(); len(types) != 2 || types[0] != "application/json" {
+		t.Errorf("Unexpected content types %v", types)
+	}
+}
+
+func TestWriteXML(t *testing.T) {
+	ticket := &models.FlightTicket{
+		ConfirmationID: "ABC123",
+		Origin:         "JFK",
+		Destination:    "LAX",
+		Passengers:     2,
+		Labels:         map[string]string{"campaign": "summer"},
+		CreatedAt:      time.Date(2024, 7, 12, 19, 0, 0, 0, time.UTC),
+	}
+	req := httptest.NewRequest(http.MethodGet, "/ticket/ABC123", nil)
+	req.Header.Set("Accept", "application/xml")
+	rec := httptest.NewRecorder()
+	Default.Write(rec, req, http.StatusOK, models.TicketListResponse{Tickets: []*models.FlightTicket{ticket}, Count: 1})
+
+	if rec.Header().Get("Content-Type") != "application/xml" || rec.Header().Get("Vary") != "Accept" {
+		t.Errorf("Unexpected headers %v", rec.Header())
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		"<ticket_list_response><tickets><item>",
+		"<confirmation_id>ABC123</confirmation_id>",
+		"<passengers>2</passengers>",
+		"<labels><campaign>summer</campaign></labels>",
+		"<created_at>2024-07-12T19:00:00Z</created_at>",
+		"<count>1</count></ticket_list_response>",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %s in %s", want, body)
+		}
+	}
+}
+
+func TestWriteXMLEscapesInvalidNames(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (XML{}).Encode(&buf, map[string]string{"1st <leg>": "a&b"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), `<entry name="1st &lt;leg&gt;">a&amp;b</entry>`) {
+		t.Errorf("Unexpected XML %s", buf.String())
+	}
+}
+
+func TestWriteMessagePack(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/ticket/ABC123", nil)
+	req.Header.Set("Accept", "application/msgpack")
+	rec := httptest.NewRecorder()
+	Default.Write(rec, req, http.StatusCreated, &models.FlightTicket{ConfirmationID: "ABC123", Passengers: 2})
+
+	if rec.Code != http.StatusCreated || rec.Header().Get("Content-Type") != "application/msgpack" {
+		t.Fatalf("Unexpected response %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var decoded map[string]interface{}
+	if err := msgpack.Unmarshal(rec.Body.Bytes(), &decoded); err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	if decoded["confirmation_id"] != "ABC123" {
+		t.Errorf("Expected JSON field names, got %v", decoded)
+	}
+}
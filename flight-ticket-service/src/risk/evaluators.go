@@ -0,0 +1,112 @@
+package risk
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Scores of the signals of the built-in evaluators
+const (
+	VelocityScore        = 50
+	RouteDateScore       = 30
+	LastMinuteGroupScore = 30
+)
+
+// Last-minute group bookings are bookings of at least LastMinuteGroupSize
+// passengers departing within LastMinuteWindow
+const (
+	LastMinuteGroupSize = 4
+	LastMinuteWindow    = 24 * time.Hour
+)
+
+// counter counts events per key over a sliding window
+type counter struct {
+	mu     sync.Mutex
+	window time.Duration
+	events map[string][]time.Time
+}
+
+func newCounter(window time.Duration) *counter {
+	return &counter{window: window, events: make(map[string][]time.Time)}
+}
+
+// add records an event for each key at now and returns the number of events
+// of each key in the window, the new one included. Older events are dropped.
+func (c *counter) add(now time.Time, keys ...string) []int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cutoff := now.Add(-c.window)
+	for key, events := range c.events {
+		for len(events) > 0 && !events[0].After(cutoff) {
+			events = events[1:]
+		}
+		if len(events) == 0 {
+			delete(c.events, key)
+			continue
+		}
+		c.events[key] = events
+	}
+
+	counts := make([]int, len(keys))
+	for i, key := range keys {
+		c.events[key] = append(c.events[key], now)
+		counts[i] = len(c.events[key])
+	}
+	return counts
+}
+
+// Velocity flags API keys and client IPs booking more than their limit
+// within the window
+type Velocity struct {
+	keyLimit int
+	ipLimit  int
+	bookings *counter
+}
+
+// NewVelocity creates a velocity check
+func NewVelocity(window time.Duration, keyLimit, ipLimit int) *Velocity {
+	return &Velocity{keyLimit: keyLimit, ipLimit: ipLimit, bookings: newCounter(window)}
+}
+
+// Evaluate counts the booking and returns key_velocity and ip_velocity past the limits
+func (v *Velocity) Evaluate(ctx context.Context, booking Booking) ([]Signal, error) {
+	counts := v.bookings.add(booking.Time, "key:"+booking.Actor, "ip:"+booking.ClientIP)
+	var signals []Signal
+	if booking.Actor != "" && counts[0] > v.keyLimit {
+		signals = append(signals, Signal{Reason: "key_velocity", Score: VelocityScore})
+	}
+	if booking.ClientIP != "" && counts[1] > v.ipLimit {
+		signals = append(signals, Signal{Reason: "ip_velocity", Score: VelocityScore})
+	}
+	return signals, nil
+}
+
+// RoutePatterns flags suspicious route and date patterns: an API key
+// booking the same route and date over and over, as when card details are
+// tested in small bookings, and large groups departing within a day
+type RoutePatterns struct {
+	routeDateLimit int
+	bookings       *counter
+}
+
+// NewRoutePatterns creates a route and date pattern check
+func NewRoutePatterns(window time.Duration, routeDateLimit int) *RoutePatterns {
+	return &RoutePatterns{routeDateLimit: routeDateLimit, bookings: newCounter(window)}
+}
+
+// Evaluate counts the booking and returns repeated_route_date past the limit
+// and last_minute_group
+func (p *RoutePatterns) Evaluate(ctx context.Context, booking Booking) ([]Signal, error) {
+	ticket := booking.Ticket
+	key := booking.Actor + ":" + ticket.Origin + "-" + ticket.Destination + ":" + ticket.DepartureDate.Format("2006-01-02")
+	var signals []Signal
+	if counts := p.bookings.add(booking.Time, key); counts[0] > p.routeDateLimit {
+		signals = append(signals, Signal{Reason: "repeated_route_date", Score: RouteDateScore})
+	}
+	if ticket.Passengers >= LastMinuteGroupSize && ticket.DepartureTime.Sub(booking.Time) < LastMinuteWindow {
+		signals = append(signals, Signal{Reason: "last_minute_group", Score: LastMinuteGroupScore})
+	}
+	return signals, nil
+}
@@ -0,0 +1,141 @@
+// Package risk scores new bookings for fraud before they are confirmed.
+//
+// Evaluators look at a booking and at who makes it, and return the signals
+// that make it look risky, each with a score. A Scorer adds up the scores of
+// its evaluators; bookings scoring at least the review score are held in
+// REVIEW for an admin instead of being confirmed. The built-in evaluators
+// count recent bookings in memory, so with several instances each one sees
+// the bookings it served.
+package risk
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+// Booking is a booking being created, with who makes it
+type Booking struct {
+	Ticket   *models.FlightTicket
+	Actor    string // name of the API key
+	ClientIP string
+	Time     time.Time
+}
+
+// Signal is something that makes a booking look risky
+type Signal struct {
+	Reason string // lowercase code, e.g. key_velocity
+	Score  int
+}
+
+// Evaluator finds the risk signals of bookings. Evaluate is called once per
+// booking created, also for bookings that are refused later on.
+type Evaluator interface {
+	Evaluate(ctx context.Context, booking Booking) ([]Signal, error)
+}
+
+// Scorer adds up the signals of its evaluators
+type Scorer struct {
+	evaluators  []Evaluator
+	reviewScore int
+}
+
+// NewScorer creates a scorer holding bookings that score at least reviewScore for review
+func NewScorer(reviewScore int, evaluators ...Evaluator) *Scorer {
+	return &Scorer{evaluators: evaluators, reviewScore: reviewScore}
+}
+
+// Assess scores a booking with every evaluator. It returns the assessment,
+// nil without signals, and whether the booking is to be held for review.
+func (s *Scorer) Assess(ctx context.Context, booking Booking) (*models.RiskAssessment, bool, error) {
+	var assessment *models.RiskAssessment
+	for _, evaluator := range s.evaluators {
+		signals, err := evaluator.Evaluate(ctx, booking)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, signal := range signals {
+			if assessment == nil {
+				assessment = &models.RiskAssessment{}
+			}
+			assessment.Score += signal.Score
+			assessment.Reasons = append(assessment.Reasons, signal.Reason)
+		}
+	}
+	return assessment, assessment != nil && assessment.Score >= s.reviewScore, nil
+}
+
+// Config configures the built-in evaluators
+type Config struct {
+	Enabled        bool
+	ReviewScore    int
+	Window         time.Duration // period bookings are counted over
+	KeyLimit       int           // bookings per API key in the window
+	IPLimit        int           // bookings per client IP in the window
+	RouteDateLimit int           // bookings of one route and date per API key in the window
+}
+
+// DefaultConfig is used for the settings that are not set
+var DefaultConfig = Config{
+	ReviewScore:    50,
+	Window:         10 * time.Minute,
+	KeyLimit:       20,
+	IPLimit:        10,
+	RouteDateLimit: 3,
+}
+
+// ConfigFromEnv reads RISK_SCORING_ENABLED, RISK_REVIEW_SCORE,
+// RISK_VELOCITY_WINDOW, RISK_KEY_VELOCITY, RISK_IP_VELOCITY and
+// RISK_ROUTE_DATE_LIMIT, using DefaultConfig for the unset ones
+func ConfigFromEnv() (Config, error) {
+	config := DefaultConfig
+	if value := strings.TrimSpace(os.Getenv("RISK_SCORING_ENABLED")); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid RISK_SCORING_ENABLED %q: must be true or false", value)
+		}
+		config.Enabled = enabled
+	}
+	if value := strings.TrimSpace(os.Getenv("RISK_VELOCITY_WINDOW")); value != "" {
+		window, err := time.ParseDuration(value)
+		if err != nil || window <= 0 {
+			return Config{}, fmt.Errorf("invalid RISK_VELOCITY_WINDOW %q: must be a positive duration such as 10m", value)
+		}
+		config.Window = window
+	}
+	for _, setting := range []struct {
+		name  string
+		value *int
+	}{
+		{"RISK_REVIEW_SCORE", &config.ReviewScore},
+		{"RISK_KEY_VELOCITY", &config.KeyLimit},
+		{"RISK_IP_VELOCITY", &config.IPLimit},
+		{"RISK_ROUTE_DATE_LIMIT", &config.RouteDateLimit},
+	} {
+		if value := strings.TrimSpace(os.Getenv(setting.name)); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed <= 0 {
+				return Config{}, fmt.Errorf("invalid %s %q: must be a positive number", setting.name, value)
+			}
+			*setting.value = parsed
+		}
+	}
+	return config, nil
+}
+
+// New returns the scorer of the built-in evaluators, or nil when scoring is
+// not enabled
+func New(config Config) *Scorer {
+	if !config.Enabled {
+		return nil
+	}
+	return NewScorer(config.ReviewScore,
+		NewVelocity(config.Window, config.KeyLimit, config.IPLimit),
+		NewRoutePatterns(config.Window, config.RouteDateLimit),
+	)
+}
@@ -0,0 +1,97 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+func testBooking(actor, ip, destination string, passengers int, now time.Time) Booking {
+	departure := now.AddDate(0, 0, 10)
+	return Booking{
+		Ticket: &models.FlightTicket{
+			Origin:        "JFK",
+			Destination:   destination,
+			DepartureDate: departure.Truncate(24 * time.Hour),
+			DepartureTime: departure,
+			Passengers:    passengers,
+		},
+		Actor:    actor,
+		ClientIP: ip,
+		Time:     now,
+	}
+}
+
+func TestScorer(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	scorer := NewScorer(50, NewVelocity(10*time.Minute, 3, 5), NewRoutePatterns(10*time.Minute, 2))
+
+	for i, want := range []struct {
+		reasons []string
+		review  bool
+	}{
+		{nil, false},
+		{nil, false},
+		{[]string{"repeated_route_date"}, false},
+		{[]string{"key_velocity", "repeated_route_date"}, true},
+	} {
+		assessment, review, err := scorer.Assess(ctx, testBooking("desk", "192.0.2.1", "LAX", 1, now.Add(time.Duration(i)*time.Minute)))
+		var reasons []string
+		if assessment != nil {
+			reasons = assessment.Reasons
+		}
+		if err != nil || review != want.review || !reflect.DeepEqual(reasons, want.reasons) {
+			t.Errorf("Booking %d: expected %v (review %v), got %+v (review %v), %v", i+1, want.reasons, want.review, assessment, review, err)
+		}
+	}
+
+	// Other keys and routes are counted apart, and bookings fall out of the window
+	if assessment, _, _ := scorer.Assess(ctx, testBooking("web", "198.51.100.7", "LAX", 1, now.Add(5*time.Minute))); assessment != nil {
+		t.Errorf("Expected another key not flagged, got %+v", assessment)
+	}
+	if assessment, _, _ := scorer.Assess(ctx, testBooking("desk", "192.0.2.1", "SFO", 1, now.Add(20*time.Minute))); assessment != nil {
+		t.Errorf("Expected no signals after the window, got %+v", assessment)
+	}
+
+	// A group departing within a day
+	booking := testBooking("agency", "203.0.113.9", "ORD", 6, now)
+	booking.Ticket.DepartureTime = now.Add(5 * time.Hour)
+	if assessment, review, _ := scorer.Assess(ctx, booking); assessment == nil || assessment.Score != LastMinuteGroupScore || review {
+		t.Errorf("Expected last_minute_group below the review score, got %+v", assessment)
+	}
+}
+
+type failingEvaluator struct{}
+
+func (failingEvaluator) Evaluate(ctx context.Context, booking Booking) ([]Signal, error) {
+	return nil, errors.New("unavailable")
+}
+
+func TestScorerError(t *testing.T) {
+	scorer := NewScorer(50, failingEvaluator{})
+	if _, review, err := scorer.Assess(context.Background(), testBooking("desk", "192.0.2.1", "LAX", 1, time.Now())); err == nil || review {
+		t.Errorf("Expected the evaluator's error, got %v", err)
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("RISK_SCORING_ENABLED", "true")
+	t.Setenv("RISK_KEY_VELOCITY", "5")
+	config, err := ConfigFromEnv()
+	if err != nil || !config.Enabled || config.KeyLimit != 5 || config.IPLimit != DefaultConfig.IPLimit || config.ReviewScore != DefaultConfig.ReviewScore {
+		t.Errorf("Unexpected config %+v, %v", config, err)
+	}
+	if New(config) == nil || New(DefaultConfig) != nil {
+		t.Error("Expected a scorer only when enabled")
+	}
+
+	t.Setenv("RISK_VELOCITY_WINDOW", "-1m")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Error("Expected an error for a negative window")
+	}
+}
@@ -0,0 +1,53 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+
+	"flight-ticket-service/src/internal/docstore"
+	"flight-ticket-service/src/models"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// firestoreCollection holds one document per booking rule, keyed by rule ID
+const firestoreCollection = "booking_rules"
+
+// FirestoreStore keeps booking rules in Firestore, shared by every instance
+type FirestoreStore struct {
+	client *firestore.Client
+}
+
+// NewFirestoreStore creates a rule store in the database of the given client
+func NewFirestoreStore(client *firestore.Client) *FirestoreStore {
+	return &FirestoreStore{client: client}
+}
+
+// List returns every rule
+func (s *FirestoreStore) List(ctx context.Context) ([]*models.BookingRule, error) {
+	return docstore.List[models.BookingRule](ctx, s.client.Collection(firestoreCollection).Query, "booking rule")
+}
+
+// Save creates or replaces a rule
+func (s *FirestoreStore) Save(ctx context.Context, rule *models.BookingRule) error {
+	return docstore.Set(ctx, s.client.Collection(firestoreCollection).Doc(rule.ID), rule, "booking rule")
+}
+
+// Delete removes a rule
+func (s *FirestoreStore) Delete(ctx context.Context, id string) error {
+	_, err := s.client.Collection(firestoreCollection).Doc(id).Delete(ctx, firestore.Exists)
+	if status.Code(err) == codes.NotFound {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete booking rule: %v", err)
+	}
+	return nil
+}
+
+// Close leaves the client open: it belongs to the ticket repository
+func (s *FirestoreStore) Close() error {
+	return nil
+}
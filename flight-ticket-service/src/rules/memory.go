@@ -0,0 +1,57 @@
+package rules
+
+import (
+	"context"
+	"sync"
+
+	"flight-ticket-service/src/models"
+)
+
+// MemoryStore keeps booking rules in memory, for single-instance deployments
+// and tests. Rules are lost when the instance stops.
+type MemoryStore struct {
+	mu    sync.Mutex
+	rules map[string]*models.BookingRule
+}
+
+// NewMemoryStore creates an empty rule store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{rules: make(map[string]*models.BookingRule)}
+}
+
+// List returns copies of every rule
+func (s *MemoryStore) List(ctx context.Context) ([]*models.BookingRule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rules := make([]*models.BookingRule, 0, len(s.rules))
+	for _, rule := range s.rules {
+		c := *rule
+		rules = append(rules, &c)
+	}
+	return rules, nil
+}
+
+// Save creates or replaces a rule
+func (s *MemoryStore) Save(ctx context.Context, rule *models.BookingRule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := *rule
+	s.rules[rule.ID] = &c
+	return nil
+}
+
+// Delete removes a rule
+func (s *MemoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.rules[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.rules, id)
+	return nil
+}
+
+// Close does nothing; memory stores hold no connections
+func (s *MemoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,293 @@
+// Package rules checks bookings against booking rules stored as data: the
+// most passengers per ticket, the minimum connection time between the tickets
+// of an itinerary, advance purchase limits and blocked routes. Compensation
+// rules are not checked; they set the vouchers issued for cancelled flights.
+//
+// Rules are stored in the booking_rules collection (Firestore, or memory with
+// the other backends) and cached by every instance, which reloads them every
+// RULES_REFRESH_INTERVAL (default 1m). Tickets are checked when they are
+// booked and when a change touches their route, schedule, passengers or
+// labels. The booking rules of the caller's tenant are checked as well.
+package rules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/services"
+)
+
+// DefaultRefreshInterval is how often the cache is reloaded from the store
+const DefaultRefreshInterval = time.Minute
+
+// MaxConnection is the longest gap between two flights that is still a
+// connection; longer gaps are stopovers
+const MaxConnection = 24 * time.Hour
+
+// ErrNotFound is returned for unknown rule IDs
+var ErrNotFound = errors.New("booking rule not found")
+
+// Violation is returned for a ticket that breaks a rule
+type Violation struct {
+	Rule    string // rule ID, or tenant:<id> for the rules of a tenant
+	Message string
+	Review  bool // the rule holds new bookings for review instead of refusing them
+}
+
+func (v *Violation) Error() string {
+	return fmt.Sprintf("%s (rule %s)", v.Message, v.Rule)
+}
+
+// Store keeps the booking rules
+type Store interface {
+	// List returns every rule
+	List(ctx context.Context) ([]*models.BookingRule, error)
+	// Save creates or replaces a rule
+	Save(ctx context.Context, rule *models.BookingRule) error
+	// Delete removes a rule, or returns ErrNotFound
+	Delete(ctx context.Context, id string) error
+	// Close releases the store's connections
+	Close() error
+}
+
+// NewStore returns the store of the repository's backend: Firestore rules are
+// shared by every instance, the other backends keep them in memory. A
+// Firestore store uses the repository's client.
+func NewStore(repository services.TicketRepository) (Store, error) {
+	firestoreService, ok := repository.(*services.FirestoreService)
+	if !ok {
+		return NewMemoryStore(), nil
+	}
+	return NewFirestoreStore(firestoreService.Client()), nil
+}
+
+// RefreshIntervalFromEnv reads RULES_REFRESH_INTERVAL
+func RefreshIntervalFromEnv() (time.Duration, error) {
+	value := strings.TrimSpace(os.Getenv("RULES_REFRESH_INTERVAL"))
+	if value == "" {
+		return DefaultRefreshInterval, nil
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval < time.Second {
+		return 0, fmt.Errorf("invalid RULES_REFRESH_INTERVAL %q: must be a duration of at least 1s", value)
+	}
+	return interval, nil
+}
+
+// Engine caches the rules of a store and checks tickets against them
+type Engine struct {
+	store Store
+
+	mu    sync.RWMutex
+	rules []*models.BookingRule // by ID
+}
+
+// NewEngine creates an engine without rules; call Load to fill it
+func NewEngine(store Store) *Engine {
+	return &Engine{store: store}
+}
+
+// Load replaces the cache with the rules of the store
+func (e *Engine) Load(ctx context.Context) error {
+	rules, err := e.store.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load booking rules: %v", err)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+
+	e.mu.Lock()
+	e.rules = rules
+	e.mu.Unlock()
+	return nil
+}
+
+// Watch reloads the cache every interval until ctx is done. Failed reloads
+// are logged and keep the previous rules.
+func (e *Engine) Watch(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+			if err := e.Load(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Keeping cached booking rules: %v", err)
+			}
+		}
+	}()
+}
+
+// List returns the cached rules by ID, with the disabled ones when all is set
+func (e *Engine) List(all bool) []*models.BookingRule {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	rules := make([]*models.BookingRule, 0, len(e.rules))
+	for _, rule := range e.rules {
+		if all || rule.Enabled {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// Save stores a rule and reloads the cache
+func (e *Engine) Save(ctx context.Context, rule *models.BookingRule) error {
+	if err := e.store.Save(ctx, rule); err != nil {
+		return err
+	}
+	return e.Load(ctx)
+}
+
+// Delete removes a rule and reloads the cache
+func (e *Engine) Delete(ctx context.Context, id string) error {
+	if err := e.store.Delete(ctx, id); err != nil {
+		return err
+	}
+	return e.Load(ctx)
+}
+
+// MinConnection returns the longest minimum connection time of the enabled
+// rules for a connecting airport, or 0 when no rule applies
+func (e *Engine) MinConnection(airport string) time.Duration {
+	var minimum time.Duration
+	for _, rule := range e.List(false) {
+		if rule.Type != models.RuleMinConnectionTime || (rule.Origin != "" && rule.Origin != airport) {
+			continue
+		}
+		if d := time.Duration(rule.MinConnectionMinutes) * time.Minute; d > minimum {
+			minimum = d
+		}
+	}
+	return minimum
+}
+
+// Compensation returns the highest voucher amount of the enabled
+// cancellation_compensation rules of the ticket's route, and the ID of that
+// rule; ok is false when no rule applies
+func (e *Engine) Compensation(ticket *models.FlightTicket) (amount float64, ruleID string, ok bool) {
+	for _, rule := range e.List(false) {
+		if rule.Type != models.RuleCompensation || !rule.Matches(ticket) {
+			continue
+		}
+		if compensation := rule.Compensation(ticket); !ok || compensation > amount {
+			amount, ruleID, ok = compensation, rule.ID, true
+		}
+	}
+	return amount, ruleID, ok
+}
+
+// Check returns a *Violation for the first rule the ticket breaks, in the
+// order of their IDs after the rules of the tenant (nil for none). Rules that
+// hold bookings for review come after those that refuse them, so a Violation
+// with Review set means no rule refuses the ticket. The repository supplies
+// the other tickets of the ticket's itinerary; an error reading them is
+// returned as is.
+func (e *Engine) Check(ctx context.Context, repository services.TicketRepository, ticket *models.FlightTicket, tenant *models.Tenant, now time.Time) error {
+	if tenant != nil {
+		if err := tenant.BookingRules.Check(ticket, now); err != nil {
+			return &Violation{Rule: "tenant:" + tenant.ID, Message: err.Error()}
+		}
+	}
+
+	var connections []*models.BookingRule
+	var held *Violation
+	for _, rule := range e.List(false) {
+		if rule.Type == models.RuleMinConnectionTime {
+			connections = append(connections, rule)
+			continue
+		}
+		if !rule.Matches(ticket) {
+			continue
+		}
+		if message := checkTicket(rule, ticket, now); message != "" {
+			violation := &Violation{Rule: rule.ID, Message: message, Review: rule.Review}
+			if !rule.Review {
+				return violation
+			}
+			if held == nil {
+				held = violation
+			}
+		}
+	}
+	if err := checkConnections(ctx, repository, ticket, connections); err != nil || held == nil {
+		return err
+	}
+	return held
+}
+
+// checkTicket returns why a ticket breaks a rule on its own, or ""
+func checkTicket(rule *models.BookingRule, ticket *models.FlightTicket, now time.Time) string {
+	switch rule.Type {
+	case models.RuleMaxPassengers:
+		if ticket.Passengers > rule.MaxPassengers {
+			return fmt.Sprintf("at most %d passengers can be booked on one ticket on %s", rule.MaxPassengers, rule.Route())
+		}
+	case models.RuleAdvancePurchase:
+		advance := ticket.DepartureTime.Sub(now)
+		if rule.MinAdvanceHours > 0 && advance < time.Duration(rule.MinAdvanceHours)*time.Hour {
+			return fmt.Sprintf("flights on %s must be booked at least %d hours before departure", rule.Route(), rule.MinAdvanceHours)
+		}
+		if rule.MaxAdvanceDays > 0 && advance > time.Duration(rule.MaxAdvanceDays)*24*time.Hour {
+			return fmt.Sprintf("flights on %s can be booked at most %d days before departure", rule.Route(), rule.MaxAdvanceDays)
+		}
+	case models.RuleBlockedRoute:
+		return fmt.Sprintf("bookings on %s are blocked", rule.Route())
+	}
+	return ""
+}
+
+// checkConnections checks the connections between the ticket and the other
+// active tickets of its itinerary. Arrival times are estimated from the
+// distance between the airports; connections at airports without
+// coordinates are not checked.
+func checkConnections(ctx context.Context, repository services.TicketRepository, ticket *models.FlightTicket, rules []*models.BookingRule) error {
+	itinerary := ticket.Labels[models.ItineraryLabel]
+	if itinerary == "" || len(rules) == 0 {
+		return nil
+	}
+	others, err := services.SearchTickets(ctx, repository, models.TicketQuery{Labels: map[string]string{models.ItineraryLabel: itinerary}})
+	if err != nil {
+		return fmt.Errorf("failed to read itinerary %s: %v", itinerary, err)
+	}
+
+	for _, other := range others {
+		if other.ConfirmationID == ticket.ConfirmationID || other.Status == "CANCELLED" {
+			continue
+		}
+		for _, leg := range [][2]*models.FlightTicket{{other, ticket}, {ticket, other}} {
+			inbound, outbound := leg[0], leg[1]
+			if inbound.Destination != outbound.Origin || !outbound.DepartureTime.After(inbound.DepartureTime) {
+				continue
+			}
+			duration, ok := services.FlightDuration(inbound.Origin, inbound.Destination)
+			if !ok {
+				continue
+			}
+			connection := outbound.DepartureTime.Sub(inbound.DepartureTime.Add(duration))
+			if connection > MaxConnection {
+				continue
+			}
+			for _, rule := range rules {
+				if rule.Origin != "" && rule.Origin != outbound.Origin {
+					continue
+				}
+				if minimum := time.Duration(rule.MinConnectionMinutes) * time.Minute; connection < minimum {
+					return &Violation{Rule: rule.ID, Review: rule.Review, Message: fmt.Sprintf("the connection at %s from %s to %s is %d minutes; at least %d are needed",
+						outbound.Origin, inbound.ConfirmationID, outbound.ConfirmationID, int(connection.Minutes()), rule.MinConnectionMinutes)}
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package rules
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/services"
+)
+
+func newEngine(t *testing.T, rules ...*models.BookingRule) *Engine {
+	t.Helper()
+	store := NewMemoryStore()
+	for _, rule := range rules {
+		store.Save(context.Background(), rule)
+	}
+	engine := NewEngine(store)
+	if err := engine.Load(context.Background()); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	return engine
+}
+
+func violatedRule(err error) string {
+	var violation *Violation
+	if errors.As(err, &violation) {
+		return violation.Rule
+	}
+	return ""
+}
+
+func TestCheck(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	engine := newEngine(t,
+		&models.BookingRule{ID: "jfk-max-4", Type: models.RuleMaxPassengers, Enabled: true, Origin: "JFK", MaxPassengers: 4},
+		&models.BookingRule{ID: "no-sea", Type: models.RuleBlockedRoute, Enabled: true, Destination: "SEA"},
+		&models.BookingRule{ID: "window", Type: models.RuleAdvancePurchase, Enabled: true, MinAdvanceHours: 2, MaxAdvanceDays: 330},
+		&models.BookingRule{ID: "off", Type: models.RuleMaxPassengers, Enabled: false, MaxPassengers: 1},
+	)
+	if got := len(engine.List(false)); got != 3 {
+		t.Errorf("Expected 3 enabled rules, got %d", got)
+	}
+
+	repository := services.NewMemoryRepository()
+	ticket := func(origin, destination string, departure time.Time, passengers int) *models.FlightTicket {
+		return models.NewFlightTicket(origin, destination, departure, departure, "AA100", passengers)
+	}
+	tests := []struct {
+		name   string
+		ticket *models.FlightTicket
+		tenant *models.Tenant
+		want   string
+	}{
+		{"within the rules", ticket("JFK", "LAX", now.AddDate(0, 0, 7), 4), nil, ""},
+		{"too many passengers", ticket("JFK", "LAX", now.AddDate(0, 0, 7), 5), nil, "jfk-max-4"},
+		{"other origin", ticket("BOS", "LAX", now.AddDate(0, 0, 7), 5), nil, ""},
+		{"blocked route", ticket("BOS", "SEA", now.AddDate(0, 0, 7), 1), nil, "no-sea"},
+		{"too soon", ticket("BOS", "LAX", now.Add(time.Hour), 1), nil, "window"},
+		{"too far ahead", ticket("BOS", "LAX", now.AddDate(1, 0, 0), 1), nil, "window"},
+		{"tenant rules first", ticket("JFK", "LAX", now.AddDate(0, 0, 7), 5), &models.Tenant{ID: "acme", BookingRules: models.BookingRules{MaxPassengers: 2}}, "tenant:acme"},
+	}
+	for _, tt := range tests {
+		err := engine.Check(ctx, repository, tt.ticket, tt.tenant, now)
+		if got := violatedRule(err); got != tt.want || (tt.want == "" && err != nil) {
+			t.Errorf("%s: got %v, want rule %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestCheckReview(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	engine := newEngine(t,
+		&models.BookingRule{ID: "groups-review", Type: models.RuleMaxPassengers, Enabled: true, MaxPassengers: 2, Review: true},
+		&models.BookingRule{ID: "no-sea", Type: models.RuleBlockedRoute, Enabled: true, Destination: "SEA"},
+	)
+	repository := services.NewMemoryRepository()
+	departure := now.AddDate(0, 0, 7)
+
+	var violation *Violation
+	err := engine.Check(ctx, repository, models.NewFlightTicket("JFK", "LAX", departure, departure, "AA100", 3), nil, now)
+	if !errors.As(err, &violation) || violation.Rule != "groups-review" || !violation.Review {
+		t.Errorf("Expected the group held by groups-review, got %v", err)
+	}
+	// A rule that refuses wins over an earlier one that holds for review
+	err = engine.Check(ctx, repository, models.NewFlightTicket("JFK", "SEA", departure, departure, "AA100", 3), nil, now)
+	if !errors.As(err, &violation) || violation.Rule != "no-sea" || violation.Review {
+		t.Errorf("Expected the booking refused by no-sea, got %v", err)
+	}
+}
+
+func TestCheckConnections(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	engine := newEngine(t, &models.BookingRule{ID: "mct-ord", Type: models.RuleMinConnectionTime, Enabled: true, Origin: "ORD", MinConnectionMinutes: 60})
+	if engine.MinConnection("ORD") != time.Hour || engine.MinConnection("DEN") != 0 {
+		t.Errorf("Unexpected minimum connection times %v, %v", engine.MinConnection("ORD"), engine.MinConnection("DEN"))
+	}
+
+	duration, ok := services.FlightDuration("JFK", "ORD")
+	if !ok {
+		t.Fatal("Expected a flight time for JFK-ORD")
+	}
+	departure := now.AddDate(0, 0, 7)
+	arrival := departure.Add(duration)
+
+	repository := services.NewMemoryRepository()
+	inbound := models.NewFlightTicket("JFK", "ORD", departure, departure, "AA100", 1)
+	inbound.Labels = map[string]string{models.ItineraryLabel: "trip-1"}
+	if err := repository.CreateTicket(ctx, inbound); err != nil {
+		t.Fatalf("CreateTicket failed: %v", err)
+	}
+
+	outbound := func(departure time.Time, itinerary string) *models.FlightTicket {
+		ticket := models.NewFlightTicket("ORD", "LAX", departure, departure, "AA200", 1)
+		ticket.Labels = map[string]string{models.ItineraryLabel: itinerary}
+		return ticket
+	}
+	tests := []struct {
+		name   string
+		ticket *models.FlightTicket
+		want   string
+	}{
+		{"short connection", outbound(arrival.Add(30*time.Minute), "trip-1"), "mct-ord"},
+		{"long enough", outbound(arrival.Add(90*time.Minute), "trip-1"), ""},
+		{"stopover", outbound(arrival.Add(30*time.Hour), "trip-1"), ""},
+		{"other itinerary", outbound(arrival.Add(30*time.Minute), "trip-2"), ""},
+	}
+	for _, tt := range tests {
+		err := engine.Check(ctx, repository, tt.ticket, nil, now)
+		if got := violatedRule(err); got != tt.want || (tt.want == "" && err != nil) {
+			t.Errorf("%s: got %v, want rule %q", tt.name, err, tt.want)
+		}
+	}
+
+	// Moving the first leg later is checked against the stored second leg
+	if err := repository.CreateTicket(ctx, outbound(arrival.Add(90*time.Minute), "trip-1")); err != nil {
+		t.Fatalf("CreateTicket failed: %v", err)
+	}
+	inbound.DepartureTime = departure.Add(time.Hour)
+	if got := violatedRule(engine.Check(ctx, repository, inbound, nil, now)); got != "mct-ord" {
+		t.Errorf("Expected the moved inbound leg to break mct-ord, got %q", got)
+	}
+
+	// Cancelled legs do not count
+	inbound.Status = "CANCELLED"
+	repository.UpdateTicket(ctx, inbound.ConfirmationID, map[string]interface{}{"status": "CANCELLED"})
+	if err := engine.Check(ctx, repository, outbound(arrival.Add(30*time.Minute), "trip-1"), nil, now); err != nil {
+		t.Errorf("Expected the cancelled leg ignored, got %v", err)
+	}
+}
+
+func TestCompensation(t *testing.T) {
+	engine := newEngine(t,
+		&models.BookingRule{ID: "fare", Type: models.RuleCompensation, Enabled: true, CompensationPercent: 100},
+		&models.BookingRule{ID: "jfk-extra", Type: models.RuleCompensation, Enabled: true, Origin: "JFK", CompensationPercent: 100, CompensationAmount: 50},
+		&models.BookingRule{ID: "no-sea", Type: models.RuleBlockedRoute, Enabled: true, Destination: "SEA"},
+	)
+	departure := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	ticket := models.NewFlightTicket("JFK", "LAX", departure, departure, "AA100", 2)
+	ticket.Price = &models.Price{BaseAmount: 400}
+
+	if amount, rule, ok := engine.Compensation(ticket); !ok || rule != "jfk-extra" || amount != 500 {
+		t.Errorf("Expected 500 from jfk-extra, got %v from %q", amount, rule)
+	}
+	ticket.Origin = "BOS"
+	if amount, rule, ok := engine.Compensation(ticket); !ok || rule != "fare" || amount != 400 {
+		t.Errorf("Expected the fare from fare, got %v from %q", amount, rule)
+	}
+	if err := engine.Check(context.Background(), services.NewMemoryRepository(), ticket, nil, departure.AddDate(0, 0, -7)); err != nil {
+		t.Errorf("Expected compensation rules not checked, got %v", err)
+	}
+	if _, _, ok := newEngine(t).Compensation(ticket); ok {
+		t.Error("Expected no compensation without rules")
+	}
+}
+
+func TestEngineSaveDelete(t *testing.T) {
+	ctx := context.Background()
+	engine := newEngine(t)
+	if err := engine.Save(ctx, &models.BookingRule{ID: "no-sea", Type: models.RuleBlockedRoute, Destination: "SEA"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if len(engine.List(true)) != 1 || len(engine.List(false)) != 0 {
+		t.Errorf("Expected one disabled rule, got %+v", engine.List(true))
+	}
+	if err := engine.Delete(ctx, "no-sea"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := engine.Delete(ctx, "no-sea"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestRefreshIntervalFromEnv(t *testing.T) {
+	t.Setenv("RULES_REFRESH_INTERVAL", "")
+	if got, err := RefreshIntervalFromEnv(); err != nil || got != DefaultRefreshInterval {
+		t.Errorf("Expected the default, got %v, %v", got, err)
+	}
+	t.Setenv("RULES_REFRESH_INTERVAL", "10ms")
+	if _, err := RefreshIntervalFromEnv(); err == nil {
+		t.Error("Expected an error below 1s")
+	}
+}
@@ -0,0 +1,142 @@
+// Package scheduling computes the airport timeline of a flight: when check-in
+// opens and closes, when boarding starts and when the gate closes.
+//
+// Ticket departure times are the local wall-clock time at the origin airport,
+// so they are interpreted in the airport's time zone. Airports without a known
+// time zone fall back to UTC.
+package scheduling
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+	_ "time/tzdata" // airport time zones must resolve in minimal containers
+
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/services"
+)
+
+// Policy holds how long before departure each milestone happens
+type Policy struct {
+	CheckInOpens   time.Duration
+	CheckInCloses  time.Duration
+	BoardingStarts time.Duration
+	GateCloses     time.Duration
+}
+
+// DefaultPolicy opens check-in 24 hours before departure and closes it 1 hour
+// before; boarding starts 40 minutes and the gate closes 15 minutes before departure.
+var DefaultPolicy = Policy{
+	CheckInOpens:   24 * time.Hour,
+	CheckInCloses:  time.Hour,
+	BoardingStarts: 40 * time.Minute,
+	GateCloses:     15 * time.Minute,
+}
+
+// Check-in window errors
+var (
+	ErrCheckInNotOpen = errors.New("check-in is not open yet")
+	ErrCheckInClosed  = errors.New("check-in has closed")
+)
+
+// Validate checks that the milestones are in order
+func (p Policy) Validate() error {
+	if p.GateCloses < 0 {
+		return fmt.Errorf("gate close must not be after departure")
+	}
+	if p.BoardingStarts <= p.GateCloses {
+		return fmt.Errorf("boarding must start before the gate closes")
+	}
+	if p.CheckInOpens <= p.CheckInCloses {
+		return fmt.Errorf("check-in must open before it closes")
+	}
+	if p.CheckInCloses < p.GateCloses {
+		return fmt.Errorf("check-in must close before the gate closes")
+	}
+	return nil
+}
+
+// PolicyFromEnv reads CHECKIN_OPENS_BEFORE, CHECKIN_CLOSES_BEFORE,
+// BOARDING_STARTS_BEFORE and GATE_CLOSES_BEFORE as durations (e.g. 24h, 45m),
+// using DefaultPolicy for the unset ones.
+func PolicyFromEnv() (Policy, error) {
+	policy := DefaultPolicy
+	for _, setting := range []struct {
+		name  string
+		value *time.Duration
+	}{
+		{"CHECKIN_OPENS_BEFORE", &policy.CheckInOpens},
+		{"CHECKIN_CLOSES_BEFORE", &policy.CheckInCloses},
+		{"BOARDING_STARTS_BEFORE", &policy.BoardingStarts},
+		{"GATE_CLOSES_BEFORE", &policy.GateCloses},
+	} {
+		if value := os.Getenv(setting.name); value != "" {
+			parsed, err := time.ParseDuration(value)
+			if err != nil {
+				return policy, fmt.Errorf("invalid %s %q: %v", setting.name, value, err)
+			}
+			*setting.value = parsed
+		}
+	}
+	if err := policy.Validate(); err != nil {
+		return policy, fmt.Errorf("invalid check-in and boarding times: %v", err)
+	}
+	return policy, nil
+}
+
+// Scheduler computes ticket schedules with a policy
+type Scheduler struct {
+	policy Policy
+	now    func() time.Time
+}
+
+// NewScheduler creates a scheduler
+func NewScheduler(policy Policy) *Scheduler {
+	return &Scheduler{policy: policy, now: time.Now}
+}
+
+// Policy returns the scheduler's policy
+func (s *Scheduler) Policy() Policy {
+	return s.policy
+}
+
+// Departure returns the departure instant of a ticket in the origin airport's time zone
+func Departure(ticket *models.FlightTicket) time.Time {
+	location := time.UTC
+	if airport, ok := services.LookupAirport(ticket.Origin); ok {
+		if loaded, err := time.LoadLocation(airport.Timezone); err == nil {
+			location = loaded
+		}
+	}
+
+	wall := ticket.DepartureTime
+	return time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), 0, location)
+}
+
+// Schedule computes the check-in and boarding times of a ticket
+func (s *Scheduler) Schedule(ticket *models.FlightTicket) *models.TicketSchedule {
+	departure := Departure(ticket)
+	return &models.TicketSchedule{
+		TimeZone:       departure.Location().String(),
+		Departure:      departure,
+		CheckInOpens:   departure.Add(-s.policy.CheckInOpens),
+		CheckInCloses:  departure.Add(-s.policy.CheckInCloses),
+		BoardingStarts: departure.Add(-s.policy.BoardingStarts),
+		GateCloses:     departure.Add(-s.policy.GateCloses),
+	}
+}
+
+// CheckInAllowed returns ErrCheckInNotOpen or ErrCheckInClosed when the
+// ticket is outside its check-in window
+func (s *Scheduler) CheckInAllowed(ticket *models.FlightTicket) error {
+	schedule := s.Schedule(ticket)
+	now := s.now()
+	if now.Before(schedule.CheckInOpens) {
+		return fmt.Errorf("%w: opens at %s", ErrCheckInNotOpen, schedule.CheckInOpens.Format(time.RFC3339))
+	}
+	if !now.Before(schedule.CheckInCloses) {
+		return fmt.Errorf("%w: closed at %s", ErrCheckInClosed, schedule.CheckInCloses.Format(time.RFC3339))
+	}
+	return nil
+}
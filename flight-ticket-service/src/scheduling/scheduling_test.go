@@ -0,0 +1,86 @@
+package scheduling
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+func TestScheduleUsesOriginTimeZone(t *testing.T) {
+	ticket := &models.FlightTicket{Origin: "JFK", DepartureTime: time.Date(2024, 12, 25, 14, 30, 0, 0, time.UTC)}
+	schedule := NewScheduler(DefaultPolicy).Schedule(ticket)
+
+	newYork, _ := time.LoadLocation("America/New_York")
+	departure := time.Date(2024, 12, 25, 14, 30, 0, 0, newYork)
+	if schedule.TimeZone != "America/New_York" || !schedule.Departure.Equal(departure) {
+		t.Fatalf("Unexpected departure %v in %s", schedule.Departure, schedule.TimeZone)
+	}
+
+	want := map[string]time.Time{
+		"check-in opens":  time.Date(2024, 12, 24, 14, 30, 0, 0, newYork),
+		"check-in closes": time.Date(2024, 12, 25, 13, 30, 0, 0, newYork),
+		"boarding starts": time.Date(2024, 12, 25, 13, 50, 0, 0, newYork),
+		"gate closes":     time.Date(2024, 12, 25, 14, 15, 0, 0, newYork),
+	}
+	got := map[string]time.Time{
+		"check-in opens":  schedule.CheckInOpens,
+		"check-in closes": schedule.CheckInCloses,
+		"boarding starts": schedule.BoardingStarts,
+		"gate closes":     schedule.GateCloses,
+	}
+	for name, at := range want {
+		if !got[name].Equal(at) {
+			t.Errorf("%s at %v, want %v", name, got[name], at)
+		}
+	}
+
+	// Unknown airports use UTC
+	unknown := NewScheduler(DefaultPolicy).Schedule(&models.FlightTicket{Origin: "XXX", DepartureTime: ticket.DepartureTime})
+	if unknown.TimeZone != "UTC" || !unknown.Departure.Equal(ticket.DepartureTime) {
+		t.Errorf("Unexpected departure %v in %s", unknown.Departure, unknown.TimeZone)
+	}
+}
+
+func TestCheckInAllowed(t *testing.T) {
+	// 14:30 in London in summer is 13:30 UTC
+	ticket := &models.FlightTicket{Origin: "LHR", DepartureTime: time.Date(2024, 7, 12, 14, 30, 0, 0, time.UTC)}
+	scheduler := NewScheduler(DefaultPolicy)
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want error
+	}{
+		{"too early", time.Date(2024, 7, 11, 13, 29, 0, 0, time.UTC), ErrCheckInNotOpen},
+		{"opens", time.Date(2024, 7, 11, 13, 30, 0, 0, time.UTC), nil},
+		{"last minute", time.Date(2024, 7, 12, 12, 29, 0, 0, time.UTC), nil},
+		{"closed", time.Date(2024, 7, 12, 12, 30, 0, 0, time.UTC), ErrCheckInClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheduler.now = func() time.Time { return tt.now }
+			if err := scheduler.CheckInAllowed(ticket); !errors.Is(err, tt.want) {
+				t.Errorf("CheckInAllowed() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyFromEnv(t *testing.T) {
+	t.Setenv("CHECKIN_OPENS_BEFORE", "48h")
+	t.Setenv("CHECKIN_CLOSES_BEFORE", "45m")
+	policy, err := PolicyFromEnv()
+	if err != nil {
+		t.Fatalf("PolicyFromEnv failed: %v", err)
+	}
+	if policy.CheckInOpens != 48*time.Hour || policy.CheckInCloses != 45*time.Minute || policy.GateCloses != DefaultPolicy.GateCloses {
+		t.Errorf("Unexpected policy %+v", policy)
+	}
+
+	t.Setenv("GATE_CLOSES_BEFORE", "1h")
+	if _, err := PolicyFromEnv(); err == nil {
+		t.Error("Expected an error when the gate closes after boarding starts")
+	}
+}
@@ -0,0 +1,285 @@
+package seats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"flight-ticket-service/src/internal/docstore"
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/services"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Firestore collections: one document per taken seat, keyed by flight number,
+// date and seat, and one per hold, keyed by hold ID
+const (
+	seatsCollection = "seats"
+	holdsCollection = "seat_holds"
+)
+
+// seatDocument is a held or assigned seat; held seats carry an expiry
+type seatDocument struct {
+	FlightNumber   string     `firestore:"flight_number"`
+	Date           string     `firestore:"date"`
+	Seat           string     `firestore:"seat"`
+	HoldID         string     `firestore:"hold_id,omitempty"`
+	ExpiresAt      *time.Time `firestore:"expires_at,omitempty"`
+	ConfirmationID string     `firestore:"confirmation_id,omitempty"`
+}
+
+// taken reports whether the seat is assigned or held at the given time
+func (d *seatDocument) taken(now time.Time) bool {
+	return d.ConfirmationID != "" || (d.ExpiresAt != nil && now.Before(*d.ExpiresAt))
+}
+
+// FirestoreStore keeps seats in Firestore, shared by every instance. Holds
+// and assignments run in transactions over the seat documents, so two
+// instances cannot hand out the same seat.
+type FirestoreStore struct {
+	client *firestore.Client
+}
+
+// NewFirestoreStore creates a seat store in the database of the given client
+func NewFirestoreStore(client *firestore.Client) *FirestoreStore {
+	return &FirestoreStore{client: client}
+}
+
+// Hold stores the hold and its seats in a transaction if the seats are free
+func (s *FirestoreStore) Hold(ctx context.Context, hold *models.SeatHold, now time.Time) error {
+	refs := s.seatRefs(hold.FlightNumber, hold.Date, hold.Seats)
+	if err := services.ReserveUsage(ctx, len(refs), len(refs)+1, 0); err != nil {
+		return err
+	}
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		current, err := getSeats(tx, refs)
+		if err != nil {
+			return err
+		}
+		for i, seat := range current {
+			if seat != nil && seat.taken(now) {
+				return takenError(hold.Seats[i])
+			}
+		}
+		expiresAt := hold.ExpiresAt
+		for i, ref := range refs {
+			doc := &seatDocument{FlightNumber: hold.FlightNumber, Date: hold.Date, Seat: hold.Seats[i], HoldID: hold.ID, ExpiresAt: &expiresAt}
+			if err := tx.Set(ref, doc); err != nil {
+				return err
+			}
+		}
+		return tx.Set(s.client.Collection(holdsCollection).Doc(hold.ID), hold)
+	})
+	if err != nil && !errors.Is(err, ErrSeatTaken) {
+		return fmt.Errorf("failed to hold seats: %v", err)
+	}
+	return err
+}
+
+// GetHold returns a hold
+func (s *FirestoreStore) GetHold(ctx context.Context, id string) (*models.SeatHold, error) {
+	if err := services.ReserveUsage(ctx, 1, 0, 0); err != nil {
+		return nil, err
+	}
+	hold, err := docstore.Get[models.SeatHold](ctx, s.client.Collection(holdsCollection).Doc(id), "seat hold")
+	if errors.Is(err, docstore.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	return hold, err
+}
+
+// Release removes the hold and the seats it still holds in a transaction. It
+// counts a read and a delete of the hold and of each of its seats.
+func (s *FirestoreStore) Release(ctx context.Context, id string) error {
+	if err := services.ReserveUsage(ctx, 1, 0, 1); err != nil {
+		return err
+	}
+	seats := 0
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		hold, refs, current, err := s.getHold(tx, id)
+		if err != nil {
+			return err
+		}
+		seats = len(refs)
+		return s.deleteHold(tx, hold, refs, current)
+	})
+	if budgetErr := services.RecordUsage(ctx, seats, 0, seats); budgetErr != nil && err == nil {
+		return budgetErr
+	}
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("failed to release seat hold: %v", err)
+	}
+	return err
+}
+
+// Assign turns the seats of the hold into assignments in a transaction. It
+// counts a read and a delete of the hold and a read and a write of each of
+// its seats.
+func (s *FirestoreStore) Assign(ctx context.Context, id, confirmationID string, now time.Time) (*models.SeatHold, error) {
+	if err := services.ReserveUsage(ctx, 1, 0, 1); err != nil {
+		return nil, err
+	}
+	var assigned *models.SeatHold
+	seats := 0
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		hold, refs, _, err := s.getHold(tx, id)
+		if err != nil {
+			return err
+		}
+		seats = len(refs)
+		if hold.Expired(now) {
+			return ErrHoldExpired
+		}
+		for i, ref := range refs {
+			doc := &seatDocument{FlightNumber: hold.FlightNumber, Date: hold.Date, Seat: hold.Seats[i], ConfirmationID: confirmationID}
+			if err := tx.Set(ref, doc); err != nil {
+				return err
+			}
+		}
+		assigned = hold
+		return tx.Delete(s.client.Collection(holdsCollection).Doc(id))
+	})
+	if budgetErr := services.RecordUsage(ctx, seats, seats, 0); budgetErr != nil && err == nil {
+		return nil, budgetErr
+	}
+	if err != nil && !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrHoldExpired) {
+		return nil, fmt.Errorf("failed to assign seats: %v", err)
+	}
+	return assigned, err
+}
+
+// Unassign deletes the seats assigned to the ticket in a transaction
+func (s *FirestoreStore) Unassign(ctx context.Context, flightNumber, date, confirmationID string, seats []string) error {
+	refs := s.seatRefs(flightNumber, date, seats)
+	if err := services.ReserveUsage(ctx, len(refs), 0, len(refs)); err != nil {
+		return err
+	}
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		current, err := getSeats(tx, refs)
+		if err != nil {
+			return err
+		}
+		for i, seat := range current {
+			if seat != nil && seat.ConfirmationID == confirmationID {
+				if err := tx.Delete(refs[i]); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to unassign seats: %v", err)
+	}
+	return nil
+}
+
+// List returns the taken seats of the departure
+func (s *FirestoreStore) List(ctx context.Context, flightNumber, date string, now time.Time) ([]models.SeatStatus, error) {
+	query := s.client.Collection(seatsCollection).Where("flight_number", "==", flightNumber).Where("date", "==", date)
+	docs, err := docstore.List[seatDocument](ctx, query, "seat")
+	if budgetErr := services.RecordUsage(ctx, services.QueryReads(len(docs)), 0, 0); budgetErr != nil && err == nil {
+		return nil, budgetErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []models.SeatStatus
+	for _, doc := range docs {
+		if !doc.taken(now) {
+			continue
+		}
+		status := models.SeatStatus{Seat: doc.Seat, Status: models.SeatAssigned}
+		if doc.ConfirmationID == "" {
+			status.Status, status.ExpiresAt = models.SeatHeld, doc.ExpiresAt
+		}
+		list = append(list, status)
+	}
+	sortStatuses(list)
+	return list, nil
+}
+
+// Sweep deletes the expired holds and the seats they still hold
+func (s *FirestoreStore) Sweep(ctx context.Context, now time.Time) (int, error) {
+	expired, err := docstore.List[models.SeatHold](ctx, s.client.Collection(holdsCollection).Where("expires_at", "<=", now), "seat hold")
+	if err != nil {
+		return 0, err
+	}
+	swept := 0
+	for _, hold := range expired {
+		if err := s.Release(ctx, hold.ID); err != nil && !errors.Is(err, ErrNotFound) {
+			return swept, err
+		}
+		swept++
+	}
+	return swept, nil
+}
+
+// getHold reads a hold and its seat documents in a transaction
+func (s *FirestoreStore) getHold(tx *firestore.Transaction, id string) (*models.SeatHold, []*firestore.DocumentRef, []*seatDocument, error) {
+	doc, err := tx.Get(s.client.Collection(holdsCollection).Doc(id))
+	if status.Code(err) == codes.NotFound {
+		return nil, nil, nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	hold, err := docstore.Decode[models.SeatHold](doc, "seat hold")
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	refs := s.seatRefs(hold.FlightNumber, hold.Date, hold.Seats)
+	current, err := getSeats(tx, refs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return hold, refs, current, nil
+}
+
+// deleteHold deletes a hold and the seats it still holds in a transaction
+func (s *FirestoreStore) deleteHold(tx *firestore.Transaction, hold *models.SeatHold, refs []*firestore.DocumentRef, current []*seatDocument) error {
+	for i, seat := range current {
+		if seat != nil && seat.HoldID == hold.ID && seat.ConfirmationID == "" {
+			if err := tx.Delete(refs[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Delete(s.client.Collection(holdsCollection).Doc(hold.ID))
+}
+
+// seatRefs returns the documents of the departure's seats
+func (s *FirestoreStore) seatRefs(flightNumber, date string, seats []string) []*firestore.DocumentRef {
+	refs := make([]*firestore.DocumentRef, len(seats))
+	for i, seat := range seats {
+		refs[i] = s.client.Collection(seatsCollection).Doc(seatKey(flightNumber, date, seat))
+	}
+	return refs
+}
+
+// getSeats reads seat documents in a transaction; missing seats are nil
+func getSeats(tx *firestore.Transaction, refs []*firestore.DocumentRef) ([]*seatDocument, error) {
+	docs, err := tx.GetAll(refs)
+	if err != nil {
+		return nil, err
+	}
+	seats := make([]*seatDocument, len(docs))
+	for i, doc := range docs {
+		if !doc.Exists() {
+			continue
+		}
+		if seats[i], err = docstore.Decode[seatDocument](doc, "seat"); err != nil {
+			return nil, err
+		}
+	}
+	return seats, nil
+}
+
+// Close leaves the client open: it belongs to the ticket repository
+func (s *FirestoreStore) Close() error {
+	return nil
+}
@@ -0,0 +1,160 @@
+package seats
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+// seat is a held or assigned seat of a departure
+type seat struct {
+	holdID         string
+	expiresAt      time.Time
+	confirmationID string
+}
+
+// MemoryStore keeps seats in memory, for single-instance deployments and
+// tests. Holds and assignments are lost when the instance stops.
+type MemoryStore struct {
+	mu    sync.Mutex
+	holds map[string]*models.SeatHold
+	seats map[string]*seat // by seatKey
+}
+
+// NewMemoryStore creates an empty seat store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{holds: make(map[string]*models.SeatHold), seats: make(map[string]*seat)}
+}
+
+// Hold stores a copy of the hold if its seats are free
+func (s *MemoryStore) Hold(ctx context.Context, hold *models.SeatHold, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, number := range hold.Seats {
+		if taken, ok := s.seats[seatKey(hold.FlightNumber, hold.Date, number)]; ok && (taken.confirmationID != "" || now.Before(taken.expiresAt)) {
+			return takenError(number)
+		}
+	}
+	for _, number := range hold.Seats {
+		s.seats[seatKey(hold.FlightNumber, hold.Date, number)] = &seat{holdID: hold.ID, expiresAt: hold.ExpiresAt}
+	}
+	s.holds[hold.ID] = copyHold(hold)
+	return nil
+}
+
+// GetHold returns a copy of a hold
+func (s *MemoryStore) GetHold(ctx context.Context, id string) (*models.SeatHold, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hold, ok := s.holds[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyHold(hold), nil
+}
+
+// Release removes a hold and the seats it still holds
+func (s *MemoryStore) Release(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hold, ok := s.holds[id]
+	if !ok {
+		return ErrNotFound
+	}
+	s.remove(hold)
+	return nil
+}
+
+// Assign turns the seats of the hold into assignments to the ticket
+func (s *MemoryStore) Assign(ctx context.Context, id, confirmationID string, now time.Time) (*models.SeatHold, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hold, ok := s.holds[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if hold.Expired(now) {
+		return nil, ErrHoldExpired
+	}
+	for _, number := range hold.Seats {
+		s.seats[seatKey(hold.FlightNumber, hold.Date, number)] = &seat{confirmationID: confirmationID}
+	}
+	delete(s.holds, id)
+	return copyHold(hold), nil
+}
+
+// Unassign frees the ticket's seats
+func (s *MemoryStore) Unassign(ctx context.Context, flightNumber, date, confirmationID string, seats []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, number := range seats {
+		key := seatKey(flightNumber, date, number)
+		if taken, ok := s.seats[key]; ok && taken.confirmationID == confirmationID {
+			delete(s.seats, key)
+		}
+	}
+	return nil
+}
+
+// List returns the taken seats of the departure
+func (s *MemoryStore) List(ctx context.Context, flightNumber, date string, now time.Time) ([]models.SeatStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prefix := seatKey(flightNumber, date, "")
+	var list []models.SeatStatus
+	for key, taken := range s.seats {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		status := models.SeatStatus{Seat: strings.TrimPrefix(key, prefix), Status: models.SeatAssigned}
+		if taken.confirmationID == "" {
+			if !now.Before(taken.expiresAt) {
+				continue
+			}
+			expiresAt := taken.expiresAt
+			status.Status, status.ExpiresAt = models.SeatHeld, &expiresAt
+		}
+		list = append(list, status)
+	}
+	sortStatuses(list)
+	return list, nil
+}
+
+// Sweep deletes the expired holds
+func (s *MemoryStore) Sweep(ctx context.Context, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	swept := 0
+	for _, hold := range s.holds {
+		if hold.Expired(now) {
+			s.remove(hold)
+			swept++
+		}
+	}
+	return swept, nil
+}
+
+// remove deletes a hold and the seats it still holds; the caller holds the lock
+func (s *MemoryStore) remove(hold *models.SeatHold) {
+	for _, number := range hold.Seats {
+		key := seatKey(hold.FlightNumber, hold.Date, number)
+		if taken, ok := s.seats[key]; ok && taken.holdID == hold.ID && taken.confirmationID == "" {
+			delete(s.seats, key)
+		}
+	}
+	delete(s.holds, hold.ID)
+}
+
+// Close is a no-op
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+func copyHold(hold *models.SeatHold) *models.SeatHold {
+	copied := *hold
+	copied.Seats = append([]string(nil), hold.Seats...)
+	return &copied
+}
@@ -0,0 +1,137 @@
+// Package seats keeps the seats held and assigned on each departure, so that
+// two shoppers cannot pick the same seat.
+//
+// A hold reserves seats for a few minutes while a booking is made. Booking
+// with the hold's ID turns its seats into assignments to the ticket, which
+// last until the ticket is cancelled. Holds that lapse free their seats at
+// once; a sweeper deletes them in the background. Seats are stored in the
+// seats and seat_holds collections (Firestore, or memory with the other
+// backends).
+package seats
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/services"
+)
+
+// SweepInterval is how often expired holds are deleted
+const SweepInterval = time.Minute
+
+// Seat errors
+var (
+	// ErrNotFound is returned for unknown hold IDs
+	ErrNotFound = errors.New("seat hold not found")
+	// ErrHoldExpired is returned when booking with a hold that lapsed
+	ErrHoldExpired = errors.New("seat hold expired")
+	// ErrSeatTaken is returned when a seat is held by another hold or assigned to a ticket
+	ErrSeatTaken = errors.New("seat is taken")
+)
+
+// Store keeps the holds and assignments of seats
+type Store interface {
+	// Hold stores a hold unless one of its seats is held by another
+	// unexpired hold or assigned, returning ErrSeatTaken
+	Hold(ctx context.Context, hold *models.SeatHold, now time.Time) error
+	// GetHold returns a hold, expired or not, or ErrNotFound
+	GetHold(ctx context.Context, id string) (*models.SeatHold, error)
+	// Release removes a hold and frees its seats, or returns ErrNotFound
+	Release(ctx context.Context, id string) error
+	// Assign turns the seats of an unexpired hold into assignments to a
+	// ticket and removes the hold. It returns ErrNotFound or ErrHoldExpired.
+	Assign(ctx context.Context, id, confirmationID string, now time.Time) (*models.SeatHold, error)
+	// Unassign frees the seats of a departure assigned to a ticket
+	Unassign(ctx context.Context, flightNumber, date, confirmationID string, seats []string) error
+	// List returns the held and assigned seats of a departure; expired holds are left out
+	List(ctx context.Context, flightNumber, date string, now time.Time) ([]models.SeatStatus, error)
+	// Sweep deletes the holds that expired before now and returns how many
+	Sweep(ctx context.Context, now time.Time) (int, error)
+	// Close releases the store's connections
+	Close() error
+}
+
+// NewStore returns the store of the repository's backend: Firestore seats are
+// shared by every instance, the other backends keep them in memory. A
+// Firestore store uses the repository's client.
+func NewStore(repository services.TicketRepository) (Store, error) {
+	firestoreService, ok := repository.(*services.FirestoreService)
+	if !ok {
+		return NewMemoryStore(), nil
+	}
+	return NewFirestoreStore(firestoreService.Client()), nil
+}
+
+// NewHold returns a hold of the seats with a random ID
+func NewHold(flightNumber, date string, seats []string, holder string, now time.Time, ttl time.Duration) (*models.SeatHold, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate seat hold ID: %v", err)
+	}
+	held := append([]string(nil), seats...)
+	models.SortSeats(held)
+	return &models.SeatHold{
+		ID:           hex.EncodeToString(id),
+		FlightNumber: strings.ToUpper(flightNumber),
+		Date:         date,
+		Seats:        held,
+		Holder:       holder,
+		CreatedAt:    now.UTC(),
+		ExpiresAt:    now.Add(ttl).UTC(),
+	}, nil
+}
+
+// takenError names the taken seat
+func takenError(seat string) error {
+	return fmt.Errorf("%w: %s", ErrSeatTaken, seat)
+}
+
+// sortStatuses orders seats by row, then letter
+func sortStatuses(list []models.SeatStatus) {
+	sort.Slice(list, func(i, j int) bool {
+		return models.SeatLess(list[i].Seat, list[j].Seat)
+	})
+}
+
+// seatKey identifies a seat of a departure
+func seatKey(flightNumber, date, seat string) string {
+	return strings.ToUpper(flightNumber) + "_" + date + "_" + seat
+}
+
+// StartSweeper deletes expired holds every SweepInterval until the returned
+// stop function is called
+func StartSweeper(store Store) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(SweepInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				swept, err := store.Sweep(ctx, time.Now())
+				if err != nil && ctx.Err() == nil {
+					log.Printf("Seat hold sweeper: %v", err)
+				}
+				if swept > 0 {
+					log.Printf("Released %d expired seat holds", swept)
+				}
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
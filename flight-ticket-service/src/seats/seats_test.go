@@ -0,0 +1,112 @@
+package seats
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreHolds(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	first, err := NewHold("aa1234", "2025-03-10", []string{"12B", "12A"}, "desk", now, 10*time.Minute)
+	if err != nil {
+		t.Fatalf("NewHold failed: %v", err)
+	}
+	if first.FlightNumber != "AA1234" || first.Seats[0] != "12A" {
+		t.Errorf("Expected an uppercased flight and sorted seats, got %+v", first)
+	}
+	if err := store.Hold(ctx, first, now); err != nil {
+		t.Fatalf("Hold failed: %v", err)
+	}
+
+	second, _ := NewHold("AA1234", "2025-03-10", []string{"12B", "12C"}, "web", now, 10*time.Minute)
+	if err := store.Hold(ctx, second, now.Add(time.Minute)); !errors.Is(err, ErrSeatTaken) {
+		t.Errorf("Expected ErrSeatTaken for a held seat, got %v", err)
+	}
+	other, _ := NewHold("AA1234", "2025-03-11", []string{"12B"}, "web", now, 10*time.Minute)
+	if err := store.Hold(ctx, other, now); err != nil {
+		t.Errorf("Expected the seat free on another date, got %v", err)
+	}
+
+	list, _ := store.List(ctx, "AA1234", "2025-03-10", now)
+	if len(list) != 2 || list[0].Seat != "12A" || list[0].Status != "HELD" || list[0].ExpiresAt == nil {
+		t.Errorf("Expected 12A and 12B held, got %+v", list)
+	}
+
+	// A lapsed hold frees its seats before it is swept
+	later := now.Add(11 * time.Minute)
+	if list, _ := store.List(ctx, "AA1234", "2025-03-10", later); len(list) != 0 {
+		t.Errorf("Expected no taken seats after expiry, got %+v", list)
+	}
+	second, _ = NewHold("AA1234", "2025-03-10", []string{"12B", "12C"}, "web", later, 10*time.Minute)
+	if err := store.Hold(ctx, second, later); err != nil {
+		t.Fatalf("Expected the seat free after expiry, got %v", err)
+	}
+	if _, err := store.Assign(ctx, first.ID, "ABC123", later); !errors.Is(err, ErrHoldExpired) {
+		t.Errorf("Expected ErrHoldExpired, got %v", err)
+	}
+
+	swept, _ := store.Sweep(ctx, later)
+	if swept != 2 {
+		t.Errorf("Expected 2 expired holds swept, got %d", swept)
+	}
+	if _, err := store.GetHold(ctx, first.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected the swept hold gone, got %v", err)
+	}
+	if list, _ := store.List(ctx, "AA1234", "2025-03-10", later); len(list) != 2 {
+		t.Errorf("Expected the newer hold's seats kept by the sweep, got %+v", list)
+	}
+
+	if err := store.Release(ctx, second.ID); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	if list, _ := store.List(ctx, "AA1234", "2025-03-10", later); len(list) != 0 {
+		t.Errorf("Expected released seats free, got %+v", list)
+	}
+	if err := store.Release(ctx, second.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound releasing twice, got %v", err)
+	}
+}
+
+func TestMemoryStoreAssign(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	hold, _ := NewHold("AA1234", "2025-03-10", []string{"3C", "3D"}, "desk", now, time.Minute)
+	if err := store.Hold(ctx, hold, now); err != nil {
+		t.Fatalf("Hold failed: %v", err)
+	}
+	assigned, err := store.Assign(ctx, hold.ID, "ABC123", now.Add(30*time.Second))
+	if err != nil || len(assigned.Seats) != 2 {
+		t.Fatalf("Assign failed: %+v, %v", assigned, err)
+	}
+	if _, err := store.GetHold(ctx, hold.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected the hold removed once assigned, got %v", err)
+	}
+
+	// Assignments outlive the hold's expiry
+	later := now.Add(time.Hour)
+	list, _ := store.List(ctx, "AA1234", "2025-03-10", later)
+	if len(list) != 2 || list[0].Status != "ASSIGNED" || list[0].ExpiresAt != nil {
+		t.Errorf("Expected 3C and 3D assigned, got %+v", list)
+	}
+	again, _ := NewHold("AA1234", "2025-03-10", []string{"3D"}, "web", later, time.Minute)
+	if err := store.Hold(ctx, again, later); !errors.Is(err, ErrSeatTaken) {
+		t.Errorf("Expected ErrSeatTaken for an assigned seat, got %v", err)
+	}
+
+	// Only the ticket's own seats are freed
+	store.Unassign(ctx, "AA1234", "2025-03-10", "XYZ789", []string{"3C"})
+	if list, _ := store.List(ctx, "AA1234", "2025-03-10", later); len(list) != 2 {
+		t.Errorf("Expected another ticket's seat kept, got %+v", list)
+	}
+	store.Unassign(ctx, "AA1234", "2025-03-10", "ABC123", []string{"3C", "3D"})
+	if list, _ := store.List(ctx, "AA1234", "2025-03-10", later); len(list) != 0 {
+		t.Errorf("Expected unassigned seats free, got %+v", list)
+	}
+}
@@ -0,0 +1,108 @@
+// Package selfcheck runs startup checks and reports their results, for
+// `server --check`: a deployment gate that tries the configuration,
+// credentials and dependencies of a new image before it receives traffic.
+package selfcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Result statuses
+const (
+	StatusPass = "PASS"
+	StatusFail = "FAIL"
+	StatusSkip = "SKIP"
+)
+
+// DefaultTimeout bounds each check
+const DefaultTimeout = 30 * time.Second
+
+// Skipped is returned by checks that do not apply to the configuration
+type Skipped struct {
+	Reason string
+}
+
+func (s Skipped) Error() string {
+	return s.Reason
+}
+
+// Skip returns the error of a check that does not apply
+func Skip(format string, args ...interface{}) error {
+	return Skipped{Reason: fmt.Sprintf(format, args...)}
+}
+
+// Check is a named check. Run returns a short description of what it found,
+// and an error when the check failed or, as Skipped, did not apply.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) (string, error)
+}
+
+// Result is the outcome of a check
+type Result struct {
+	Name     string
+	Status   string
+	Detail   string
+	Duration time.Duration
+}
+
+// Report holds the results of all checks
+type Report struct {
+	Results []Result
+}
+
+// Run runs the checks in order, each with the timeout. Later checks run even
+// when earlier ones failed, so the report lists every problem at once.
+func Run(ctx context.Context, timeout time.Duration, checks ...Check) Report {
+	var report Report
+	for _, check := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, timeout)
+		start := time.Now()
+		detail, err := check.Run(checkCtx)
+		cancel()
+
+		result := Result{Name: check.Name, Status: StatusPass, Detail: detail, Duration: time.Since(start)}
+		var skipped Skipped
+		switch {
+		case errors.As(err, &skipped):
+			result.Status, result.Detail = StatusSkip, skipped.Reason
+		case err != nil:
+			result.Status, result.Detail = StatusFail, err.Error()
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report
+}
+
+// Failed returns the number of failed checks
+func (r Report) Failed() int {
+	failed := 0
+	for _, result := range r.Results {
+		if result.Status == StatusFail {
+			failed++
+		}
+	}
+	return failed
+}
+
+// Write prints one line per check and a summary
+func (r Report) Write(w io.Writer) {
+	width := 0
+	for _, result := range r.Results {
+		width = max(width, len(result.Name))
+	}
+	for _, result := range r.Results {
+		detail := strings.ReplaceAll(result.Detail, "\n", "\n"+strings.Repeat(" ", width+8))
+		fmt.Fprintf(w, "%s  %-*s  %s (%s)\n", result.Status, width, result.Name, detail, result.Duration.Round(time.Millisecond))
+	}
+	if failed := r.Failed(); failed > 0 {
+		fmt.Fprintf(w, "Self-check failed: %d of %d checks failed\n", failed, len(r.Results))
+		return
+	}
+	fmt.Fprintf(w, "Self-check passed\n")
+}
@@ -0,0 +1,57 @@
+package selfcheck
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunReportsEveryCheck(t *testing.T) {
+	ran := 0
+	report := Run(context.Background(), 100*time.Millisecond,
+		Check{Name: "configuration", Run: func(ctx context.Context) (string, error) { ran++; return "12 settings valid", nil }},
+		Check{Name: "storage", Run: func(ctx context.Context) (string, error) { ran++; return "", errors.New("connection refused") }},
+		Check{Name: "secret manager", Run: func(ctx context.Context) (string, error) { ran++; return "", Skip("%s not set", "SELF_CHECK_SECRETS") }},
+		Check{Name: "slow", Run: func(ctx context.Context) (string, error) {
+			ran++
+			<-ctx.Done()
+			return "", ctx.Err()
+		}},
+	)
+
+	if ran != 4 {
+		t.Fatalf("Expected every check to run after a failure, ran %d", ran)
+	}
+	statuses := []string{StatusPass, StatusFail, StatusSkip, StatusFail}
+	for i, result := range report.Results {
+		if result.Status != statuses[i] {
+			t.Errorf("%s: expected %s, got %s (%s)", result.Name, statuses[i], result.Status, result.Detail)
+		}
+	}
+	if report.Results[2].Detail != "SELF_CHECK_SECRETS not set" {
+		t.Errorf("Expected the skip reason, got %q", report.Results[2].Detail)
+	}
+	if report.Failed() != 2 {
+		t.Errorf("Expected 2 failures, got %d", report.Failed())
+	}
+
+	var out bytes.Buffer
+	report.Write(&out)
+	for _, want := range []string{"PASS  configuration   12 settings valid", "FAIL  storage         connection refused", "Self-check failed: 2 of 4 checks failed"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Report lacks %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestReportPasses(t *testing.T) {
+	report := Run(context.Background(), time.Second, Check{Name: "configuration", Run: func(ctx context.Context) (string, error) { return "ok", nil }})
+	var out bytes.Buffer
+	report.Write(&out)
+	if report.Failed() != 0 || !strings.HasSuffix(out.String(), "Self-check passed\n") {
+		t.Errorf("Expected a passing report, got:\n%s", out.String())
+	}
+}
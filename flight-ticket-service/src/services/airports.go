@@ -0,0 +1,119 @@
+package services
+
+import (
+	"math"
+	"time"
+)
+
+// Flight time estimates: cruise speed over the great-circle distance, plus taxi, climb and descent
+const (
+	cruiseSpeedKMH = 800
+	flightOverhead = 30 * time.Minute
+	earthRadiusKM  = 6371
+)
+
+// AirportLocation holds the coordinates of an airport
+type AirportLocation struct {
+	Latitude  float64
+	Longitude float64
+	Timezone  string
+	Country   string // ISO 3166-1 alpha-2 code
+}
+
+// airportLocations maps IATA codes of commonly used airports to their coordinates and country
+var airportLocations = map[string]AirportLocation{
+	"ATL": {33.6407, -84.4277, "America/New_York", "US"},
+	"BOS": {42.3656, -71.0096, "America/New_York", "US"},
+	"CLT": {35.2140, -80.9431, "America/New_York", "US"},
+	"DEN": {39.8561, -104.6737, "America/Denver", "US"},
+	"DFW": {32.8998, -97.0403, "America/Chicago", "US"},
+	"DTW": {42.2162, -83.3554, "America/Detroit", "US"},
+	"EWR": {40.6895, -74.1745, "America/New_York", "US"},
+	"IAD": {38.9531, -77.4565, "America/New_York", "US"},
+	"IAH": {29.9902, -95.3368, "America/Chicago", "US"},
+	"JFK": {40.6413, -73.7781, "America/New_York", "US"},
+	"LAS": {36.0840, -115.1537, "America/Los_Angeles", "US"},
+	"LAX": {33.9416, -118.4085, "America/Los_Angeles", "US"},
+	"LGA": {40.7769, -73.8740, "America/New_York", "US"},
+	"MCO": {28.4312, -81.3081, "America/New_York", "US"},
+	"MIA": {25.7959, -80.2870, "America/New_York", "US"},
+	"MSP": {44.8848, -93.2223, "America/Chicago", "US"},
+	"ORD": {41.9742, -87.9073, "America/Chicago", "US"},
+	"PHL": {39.8744, -75.2424, "America/New_York", "US"},
+	"PHX": {33.4352, -112.0101, "America/Phoenix", "US"},
+	"SEA": {47.4502, -122.3088, "America/Los_Angeles", "US"},
+	"SFO": {37.6213, -122.3790, "America/Los_Angeles", "US"},
+	"SLC": {40.7899, -111.9791, "America/Denver", "US"},
+	"YYZ": {43.6777, -79.6248, "America/Toronto", "CA"},
+	"YVR": {49.1967, -123.1815, "America/Vancouver", "CA"},
+	"MEX": {19.4361, -99.0719, "America/Mexico_City", "MX"},
+	"GRU": {-23.4356, -46.4731, "America/Sao_Paulo", "BR"},
+	"LHR": {51.4700, -0.4543, "Europe/London", "GB"},
+	"CDG": {49.0097, 2.5479, "Europe/Paris", "FR"},
+	"AMS": {52.3105, 4.7683, "Europe/Amsterdam", "NL"},
+	"FRA": {50.0379, 8.5622, "Europe/Berlin", "DE"},
+	"MAD": {40.4983, -3.5676, "Europe/Madrid", "ES"},
+	"FCO": {41.8003, 12.2389, "Europe/Rome", "IT"},
+	"DXB": {25.2532, 55.3657, "Asia/Dubai", "AE"},
+	"DEL": {28.5562, 77.1000, "Asia/Kolkata", "IN"},
+	"BOM": {19.0896, 72.8656, "Asia/Kolkata", "IN"},
+	"SIN": {1.3644, 103.9915, "Asia/Singapore", "SG"},
+	"HKG": {22.3080, 113.9185, "Asia/Hong_Kong", "HK"},
+	"NRT": {35.7720, 140.3929, "Asia/Tokyo", "JP"},
+	"HND": {35.5494, 139.7798, "Asia/Tokyo", "JP"},
+	"ICN": {37.4602, 126.4407, "Asia/Seoul", "KR"},
+	"SYD": {-33.9399, 151.1753, "Australia/Sydney", "AU"},
+}
+
+// LookupAirport returns the location of an airport by IATA code
+func LookupAirport(code string) (AirportLocation, bool) {
+	loc, ok := airportLocations[code]
+	return loc, ok
+}
+
+// AirportCountry returns the ISO 3166-1 alpha-2 code of an airport's country
+func AirportCountry(code string) (string, bool) {
+	loc, ok := airportLocations[code]
+	return loc.Country, ok
+}
+
+// International reports whether a flight crosses a border; flights from or
+// to airports of unknown country are not
+func International(origin, destination string) bool {
+	from, ok := AirportCountry(origin)
+	if !ok {
+		return false
+	}
+	to, ok := AirportCountry(destination)
+	return ok && from != to
+}
+
+// Distance returns the great-circle distance between two airports in
+// kilometres; ok is false when either airport is unknown
+func Distance(origin, destination string) (float64, bool) {
+	from, ok := LookupAirport(origin)
+	if !ok {
+		return 0, false
+	}
+	to, ok := LookupAirport(destination)
+	if !ok {
+		return 0, false
+	}
+
+	lat1, lat2 := from.Latitude*math.Pi/180, to.Latitude*math.Pi/180
+	dLat, dLon := lat2-lat1, (to.Longitude-from.Longitude)*math.Pi/180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKM * math.Asin(math.Sqrt(h)), true
+}
+
+// FlightDuration estimates the block time between two airports from their
+// great-circle distance; ok is false when either airport is unknown
+func FlightDuration(origin, destination string) (time.Duration, bool) {
+	distance, ok := Distance(origin, destination)
+	if !ok {
+		return 0, false
+	}
+
+	cruise := time.Duration(distance / cruiseSpeedKMH * float64(time.Hour))
+	return (cruise + flightOverhead).Round(5 * time.Minute), true
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"flight-ticket-service/src/models"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/option"
+)
+
+// AttachmentRepository is implemented by storage backends that can hold attachment metadata
+type AttachmentRepository interface {
+	// CreateAttachment stores attachment metadata for a ticket
+	CreateAttachment(ctx context.Context, attachment *models.Attachment) error
+	// GetAttachment retrieves a single attachment of a ticket
+	GetAttachment(ctx context.Context, confirmationID, attachmentID string) (*models.Attachment, error)
+	// ListAttachments retrieves the attachments of a ticket, oldest first
+	ListAttachments(ctx context.Context, confirmationID string) ([]*models.Attachment, error)
+}
+
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// AttachmentStorage issues signed Cloud Storage URLs for ticket attachments
+type AttachmentStorage struct {
+	client *storage.Client
+	bucket string
+	expiry time.Duration
+}
+
+// NewAttachmentStorage creates an attachment store for the given bucket.
+// Signing uses the service account key when credentialsPath is set, otherwise the
+// IAM signBlob API for the runtime service account (requires roles/iam.serviceAccountTokenCreator).
+func NewAttachmentStorage(bucket, credentialsPath string, expiry time.Duration) (*AttachmentStorage, error) {
+	ctx := context.Background()
+
+	var opts []option.ClientOption
+	if credentialsPath != "" {
+		// Use service account key file
+		opts = append(opts, option.WithCredentialsFile(credentialsPath))
+	}
+
+	client, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Cloud Storage client: %v", err)
+	}
+
+	return &AttachmentStorage{
+		client: client,
+		bucket: strings.TrimPrefix(bucket, "gs://"),
+		expiry: expiry,
+	}, nil
+}
+
+// NewAttachment builds attachment metadata with a fresh ID and object name
+func NewAttachment(confirmationID string, req *models.CreateAttachmentRequest) (*models.Attachment, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate attachment ID: %v", err)
+	}
+
+	attachment := &models.Attachment{
+		ID:             hex.EncodeToString(id),
+		ConfirmationID: confirmationID,
+		FileName:       path.Base(strings.ReplaceAll(req.FileName, "\\", "/")),
+		ContentType:    req.ContentType,
+		Description:    req.Description,
+		CreatedAt:      time.Now(),
+	}
+	attachment.ObjectName = fmt.Sprintf("tickets/%s/attachments/%s/%s",
+		confirmationID, attachment.ID, SanitizeFileName(attachment.FileName))
+
+	return attachment, nil
+}
+
+// SanitizeFileName makes a file name safe to use in an object name
+func SanitizeFileName(name string) string {
+	name = strings.Trim(unsafeFileNameChars.ReplaceAllString(name, "_"), "._")
+	if name == "" {
+		return "document"
+	}
+	return name
+}
+
+// UploadURL returns a signed PUT URL for the attachment and the headers the client must send
+func (as *AttachmentStorage) UploadURL(attachment *models.Attachment) (string, map[string]string, time.Time, error) {
+	expiresAt := time.Now().Add(as.expiry)
+	headers := map[string]string{
+		"Content-Type":                attachment.ContentType,
+		"x-goog-content-length-range": fmt.Sprintf("0,%d", models.MaxAttachmentSize),
+	}
+
+	signedURL, err := as.client.Bucket(as.bucket).SignedURL(attachment.ObjectName, &storage.SignedURLOptions{
+		Scheme:      storage.SigningSchemeV4,
+		Method:      "PUT",
+		ContentType: attachment.ContentType,
+		Headers:     []string{"x-goog-content-length-range:" + headers["x-goog-content-length-range"]},
+		Expires:     expiresAt,
+	})
+	if err != nil {
+		return "", nil, time.Time{}, fmt.Errorf("failed to sign upload URL: %v", err)
+	}
+
+	return signedURL, headers, expiresAt, nil
+}
+
+// DownloadURL returns a signed GET URL that downloads the attachment under its original file name
+func (as *AttachmentStorage) DownloadURL(attachment *models.Attachment) (string, time.Time, error) {
+	expiresAt := time.Now().Add(as.expiry)
+
+	signedURL, err := as.client.Bucket(as.bucket).SignedURL(attachment.ObjectName, &storage.SignedURLOptions{
+		Scheme:  storage.SigningSchemeV4,
+		Method:  "GET",
+		Expires: expiresAt,
+		QueryParameters: url.Values{
+			"response-content-disposition": {fmt.Sprintf("attachment; filename=%q", SanitizeFileName(attachment.FileName))},
+		},
+	})
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign download URL: %v", err)
+	}
+
+	return signedURL, expiresAt, nil
+}
+
+// Close closes the Cloud Storage client
+func (as *AttachmentStorage) Close() error {
+	return as.client.Close()
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"flight-ticket-service/src/models"
+)
+
+func TestSanitizeFileName(t *testing.T) {
+	tests := map[string]string{
+		"visa.pdf":             "visa.pdf",
+		"my receipt (1).png":   "my_receipt_1_.png",
+		"..":                   "document",
+		"Reçu d'hôtel.jpeg":    "Re_u_d_h_tel.jpeg",
+		"___":                  "document",
+		"scan-2024_07_12.heic": "scan-2024_07_12.heic",
+	}
+	for input, expected := range tests {
+		if got := SanitizeFileName(input); got != expected {
+			t.Errorf("SanitizeFileName(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
+
+func TestNewAttachment(t *testing.T) {
+	req := &models.CreateAttachmentRequest{FileName: `C:\Users\me\visa scan.pdf`, ContentType: "application/pdf"}
+
+	attachment, err := NewAttachment("ABC123", req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(attachment.ID) != 16 {
+		t.Errorf("Expected 16-character ID, got %q", attachment.ID)
+	}
+	if attachment.FileName != "visa scan.pdf" {
+		t.Errorf("Expected directory to be stripped from file name, got %q", attachment.FileName)
+	}
+	expectedPrefix := "tickets/ABC123/attachments/" + attachment.ID + "/"
+	if !strings.HasPrefix(attachment.ObjectName, expectedPrefix) || !strings.HasSuffix(attachment.ObjectName, "/visa_scan.pdf") {
+		t.Errorf("Unexpected object name %q", attachment.ObjectName)
+	}
+}
+
+func TestCreateAttachmentRequestValidate(t *testing.T) {
+	valid := models.CreateAttachmentRequest{FileName: "receipt.png", ContentType: "image/png"}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Expected valid request, got %v", err)
+	}
+
+	invalid := []models.CreateAttachmentRequest{
+		{FileName: "", ContentType: "image/png"},
+		{FileName: "script.sh", ContentType: "text/x-shellscript"},
+		{FileName: strings.Repeat("a", 256), ContentType: "application/pdf"},
+	}
+	for _, req := range invalid {
+		if err := req.Validate(); err == nil {
+			t.Errorf("Expected error for %+v", req)
+		}
+	}
+}
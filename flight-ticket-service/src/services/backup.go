@@ -0,0 +1,387 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"flight-ticket-service/src/models"
+
+	firestoreadmin "google.golang.org/api/firestore/v1"
+	"google.golang.org/api/option"
+	storageapi "google.golang.org/api/storage/v1"
+)
+
+const (
+	// backupPrefix holds JSON dumps, one object per snapshot: backups/<label>.json
+	backupPrefix = "backups/"
+	// firestoreExportPrefix holds Firestore managed exports: firestore-exports/<label>/
+	firestoreExportPrefix = "firestore-exports/"
+	// BackupLabelFormat is the point-in-time label used when none is given
+	BackupLabelFormat = "20060102T150405Z"
+)
+
+var backupLabelPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// BackupSnapshot is the JSON dump of the tickets collection written to Cloud Storage
+type BackupSnapshot struct {
+	Label       string                 `json:"label"`
+	CreatedAt   time.Time              `json:"created_at"`
+	Backend     string                 `json:"backend"`
+	TicketCount int                    `json:"ticket_count"`
+	Tickets     []*models.FlightTicket `json:"tickets"`
+}
+
+// BackupInfo describes a stored snapshot without its tickets
+type BackupInfo struct {
+	Label       string    `json:"label"`
+	Kind        string    `json:"kind"` // "json" or "firestore-export"
+	URI         string    `json:"uri"`
+	CreatedAt   time.Time `json:"created_at"`
+	TicketCount int       `json:"ticket_count,omitempty"`
+}
+
+// RestoreResult summarizes a JSON restore
+type RestoreResult struct {
+	Created     int `json:"created"`
+	Overwritten int `json:"overwritten"`
+	Skipped     int `json:"skipped"`
+	Failed      int `json:"failed"`
+}
+
+// BackupService exports and restores the tickets collection via Cloud Storage
+type BackupService struct {
+	repository TicketRepository
+	backend    string
+	projectID  string
+	database   string
+	collection string
+	bucket     string
+	storage    *storageapi.Service
+	admin      *firestoreadmin.Service
+}
+
+// NewBackupService creates a backup service writing to the given Cloud Storage bucket
+func NewBackupService(repository TicketRepository, cfg StorageConfig, bucket string) (*BackupService, error) {
+	if bucket == "" {
+		return nil, fmt.Errorf("a backup bucket is required")
+	}
+
+	ctx := context.Background()
+
+	var opts []option.ClientOption
+	if cfg.CredentialsPath != "" {
+		// Use service account key file
+		opts = append(opts, option.WithCredentialsFile(cfg.CredentialsPath))
+	}
+
+	storageClient, err := storageapi.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Cloud Storage client: %v", err)
+	}
+
+	adminClient, err := firestoreadmin.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Firestore admin client: %v", err)
+	}
+
+	return &BackupService{
+		repository: repository,
+		backend:    cfg.Backend,
+		projectID:  cfg.ProjectID,
+		database:   cfg.FirestoreDatabase,
+		collection: cfg.FirestoreCollection,
+		bucket:     strings.TrimPrefix(bucket, "gs://"),
+		storage:    storageClient,
+		admin:      adminClient,
+	}, nil
+}
+
+// NewBackupLabel returns a point-in-time label for the given time
+func NewBackupLabel(at time.Time) string {
+	return at.UTC().Format(BackupLabelFormat)
+}
+
+// ValidateBackupLabel checks that a label is safe to use in object names
+func ValidateBackupLabel(label string) error {
+	if !backupLabelPattern.MatchString(label) {
+		return fmt.Errorf("invalid backup label %q: use letters, digits, '.', '_' or '-'", label)
+	}
+	return nil
+}
+
+// Backup dumps all tickets as JSON to gs://BUCKET/backups/<label>.json
+func (bs *BackupService) Backup(ctx context.Context, label string) (*BackupInfo, error) {
+	if err := ValidateBackupLabel(label); err != nil {
+		return nil, err
+	}
+
+	tickets, err := bs.repository.ListTickets(ctx, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tickets: %v", err)
+	}
+
+	snapshot := BackupSnapshot{
+		Label:       label,
+		CreatedAt:   time.Now().UTC(),
+		Backend:     bs.backend,
+		TicketCount: len(tickets),
+		Tickets:     tickets,
+	}
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode backup: %v", err)
+	}
+
+	object := &storageapi.Object{
+		Name:        backupPrefix + label + ".json",
+		ContentType: "application/json",
+		Metadata: map[string]string{
+			"label":        label,
+			"backend":      bs.backend,
+			"ticket_count": strconv.Itoa(len(tickets)),
+		},
+	}
+	// Refuse to overwrite an existing snapshot with the same label
+	_, err = bs.storage.Objects.Insert(bs.bucket, object).IfGenerationMatch(0).Media(bytes.NewReader(data)).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload backup: %v", err)
+	}
+
+	log.Printf("Backed up %d tickets to gs://%s/%s", len(tickets), bs.bucket, object.Name)
+	return &BackupInfo{
+		Label:       label,
+		Kind:        "json",
+		URI:         fmt.Sprintf("gs://%s/%s", bs.bucket, object.Name),
+		CreatedAt:   snapshot.CreatedAt,
+		TicketCount: len(tickets),
+	}, nil
+}
+
+// ListBackups lists JSON dumps and Firestore managed exports, newest first
+func (bs *BackupService) ListBackups(ctx context.Context) ([]BackupInfo, error) {
+	var backups []BackupInfo
+
+	err := bs.storage.Objects.List(bs.bucket).Prefix(backupPrefix).Pages(ctx, func(objects *storageapi.Objects) error {
+		for _, obj := range objects.Items {
+			if !strings.HasSuffix(obj.Name, ".json") {
+				continue
+			}
+			info := BackupInfo{
+				Label: strings.TrimSuffix(strings.TrimPrefix(obj.Name, backupPrefix), ".json"),
+				Kind:  "json",
+				URI:   fmt.Sprintf("gs://%s/%s", bs.bucket, obj.Name),
+			}
+			info.CreatedAt, _ = time.Parse(time.RFC3339, obj.TimeCreated)
+			info.TicketCount, _ = strconv.Atoi(obj.Metadata["ticket_count"])
+			backups = append(backups, info)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %v", err)
+	}
+
+	// Managed exports write an overall_export_metadata file at the root of each export
+	err = bs.storage.Objects.List(bs.bucket).Prefix(firestoreExportPrefix).Pages(ctx, func(objects *storageapi.Objects) error {
+		for _, obj := range objects.Items {
+			if !strings.HasSuffix(obj.Name, ".overall_export_metadata") {
+				continue
+			}
+			label := strings.SplitN(strings.TrimPrefix(obj.Name, firestoreExportPrefix), "/", 2)[0]
+			info := BackupInfo{
+				Label: label,
+				Kind:  "firestore-export",
+				URI:   fmt.Sprintf("gs://%s/%s%s", bs.bucket, firestoreExportPrefix, label),
+			}
+			info.CreatedAt, _ = time.Parse(time.RFC3339, obj.TimeCreated)
+			backups = append(backups, info)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Firestore exports: %v", err)
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+	return backups, nil
+}
+
+// Restore loads a JSON dump and writes its tickets back to the repository.
+// Tickets missing from the repository are created; existing tickets are skipped
+// unless overwrite is set, in which case they are reset to the snapshot contents.
+func (bs *BackupService) Restore(ctx context.Context, label string, overwrite bool) (*RestoreResult, error) {
+	if err := ValidateBackupLabel(label); err != nil {
+		return nil, err
+	}
+
+	resp, err := bs.storage.Objects.Get(bs.bucket, backupPrefix+label+".json").Context(ctx).Download()
+	if err != nil {
+		return nil, fmt.Errorf("failed to download backup %s: %v", label, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup %s: %v", label, err)
+	}
+
+	var snapshot BackupSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode backup %s: %v", label, err)
+	}
+
+	result := &RestoreResult{}
+	for _, ticket := range snapshot.Tickets {
+		if _, err := bs.repository.GetTicket(ctx, ticket.ConfirmationID); err == nil {
+			if !overwrite {
+				result.Skipped++
+				continue
+			}
+			if err := bs.repository.UpdateTicket(ctx, ticket.ConfirmationID, ticketFields(ticket)); err != nil {
+				log.Printf("Failed to restore ticket %s: %v", ticket.ConfirmationID, err)
+				result.Failed++
+				continue
+			}
+			result.Overwritten++
+			continue
+		}
+
+		if err := bs.repository.CreateTicket(ctx, ticket); err != nil {
+			log.Printf("Failed to restore ticket %s: %v", ticket.ConfirmationID, err)
+			result.Failed++
+			continue
+		}
+		result.Created++
+	}
+
+	log.Printf("Restored backup %s: %d created, %d overwritten, %d skipped, %d failed",
+		label, result.Created, result.Overwritten, result.Skipped, result.Failed)
+	return result, nil
+}
+
+// ExportFirestore starts a Firestore managed export of the ticket collection to
+// gs://BUCKET/firestore-exports/<label> and waits for it to finish. A non-zero snapshotTime
+// exports a consistent point-in-time view (requires point-in-time recovery for times older than an hour).
+func (bs *BackupService) ExportFirestore(ctx context.Context, label string, snapshotTime time.Time) (*BackupInfo, error) {
+	if err := ValidateBackupLabel(label); err != nil {
+		return nil, err
+	}
+
+	req := &firestoreadmin.GoogleFirestoreAdminV1ExportDocumentsRequest{
+		CollectionIds:   []string{bs.ticketCollection()},
+		OutputUriPrefix: fmt.Sprintf("gs://%s/%s%s", bs.bucket, firestoreExportPrefix, label),
+	}
+	if !snapshotTime.IsZero() {
+		req.SnapshotTime = snapshotTime.UTC().Truncate(time.Minute).Format(time.RFC3339)
+	}
+
+	op, err := bs.admin.Projects.Databases.ExportDocuments(bs.firestoreDatabase(), req).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start Firestore export: %v", err)
+	}
+	if err := bs.waitForOperation(ctx, op); err != nil {
+		return nil, fmt.Errorf("Firestore export failed: %v", err)
+	}
+
+	log.Printf("Exported %s to %s", bs.ticketCollection(), req.OutputUriPrefix)
+	return &BackupInfo{
+		Label:     label,
+		Kind:      "firestore-export",
+		URI:       req.OutputUriPrefix,
+		CreatedAt: time.Now().UTC(),
+	}, nil
+}
+
+// ImportFirestore restores the ticket collection from a Firestore managed export.
+// Imported documents overwrite existing documents with the same ID.
+func (bs *BackupService) ImportFirestore(ctx context.Context, label string) error {
+	if err := ValidateBackupLabel(label); err != nil {
+		return err
+	}
+
+	req := &firestoreadmin.GoogleFirestoreAdminV1ImportDocumentsRequest{
+		CollectionIds:  []string{bs.ticketCollection()},
+		InputUriPrefix: fmt.Sprintf("gs://%s/%s%s", bs.bucket, firestoreExportPrefix, label),
+	}
+
+	op, err := bs.admin.Projects.Databases.ImportDocuments(bs.firestoreDatabase(), req).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("failed to start Firestore import: %v", err)
+	}
+	if err := bs.waitForOperation(ctx, op); err != nil {
+		return fmt.Errorf("Firestore import failed: %v", err)
+	}
+
+	log.Printf("Imported %s from %s", bs.ticketCollection(), req.InputUriPrefix)
+	return nil
+}
+
+func (bs *BackupService) firestoreDatabase() string {
+	database := bs.database
+	if database == "" {
+		database = DefaultFirestoreDatabase
+	}
+	return fmt.Sprintf("projects/%s/databases/%s", bs.projectID, database)
+}
+
+func (bs *BackupService) ticketCollection() string {
+	if bs.collection == "" {
+		return DefaultFirestoreCollection
+	}
+	return bs.collection
+}
+
+func (bs *BackupService) waitForOperation(ctx context.Context, op *firestoreadmin.GoogleLongrunningOperation) error {
+	var err error
+	for !op.Done {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+		}
+
+		op, err = bs.admin.Projects.Databases.Operations.Get(op.Name).Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("failed to poll operation: %v", err)
+		}
+	}
+
+	if op.Error != nil {
+		return fmt.Errorf("%s", op.Error.Message)
+	}
+	return nil
+}
+
+// ticketFields returns the storage fields of a ticket as an update map
+func ticketFields(ticket *models.FlightTicket) map[string]interface{} {
+	fields := map[string]interface{}{
+		"origin":         ticket.Origin,
+		"destination":    ticket.Destination,
+		"departure_date": ticket.DepartureDate,
+		"departure_time": ticket.DepartureTime,
+		"flight_number":  ticket.FlightNumber,
+		"passengers":     ticket.Passengers,
+		"status":         ticket.Status,
+	}
+	if ticket.Price != nil {
+		fields["price"] = ticket.Price
+	}
+	if ticket.Labels != nil {
+		fields["labels"] = ticket.Labels
+	}
+	if ticket.CheckIn != nil {
+		fields["check_in"] = ticket.CheckIn
+	}
+	return fields
+}
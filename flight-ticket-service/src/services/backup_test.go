@@ -0,0 +1,41 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+func TestNewBackupLabel(t *testing.T) {
+	at := time.Date(2024, 7, 12, 15, 4, 5, 0, time.FixedZone("EDT", -4*60*60))
+	if label := NewBackupLabel(at); label != "20240712T190405Z" {
+		t.Errorf("Expected label 20240712T190405Z, got %s", label)
+	}
+}
+
+func TestValidateBackupLabel(t *testing.T) {
+	for _, label := range []string{"20240712T190405Z", "pre-release_1.2"} {
+		if err := ValidateBackupLabel(label); err != nil {
+			t.Errorf("Expected %q to be valid: %v", label, err)
+		}
+	}
+	for _, label := range []string{"", "../secrets", "a/b", "with space"} {
+		if err := ValidateBackupLabel(label); err == nil {
+			t.Errorf("Expected %q to be invalid", label)
+		}
+	}
+}
+
+func TestTicketFieldsOmitsNilPrice(t *testing.T) {
+	fields := ticketFields(&models.FlightTicket{ConfirmationID: "ABC123", Status: "CONFIRMED"})
+	if _, ok := fields["price"]; ok {
+		t.Error("Expected no price field for a ticket without price")
+	}
+	if fields["status"] != "CONFIRMED" {
+		t.Errorf("Expected status CONFIRMED, got %v", fields["status"])
+	}
+	if _, ok := fields["confirmation_id"]; ok {
+		t.Error("Expected confirmation_id to be excluded from updates")
+	}
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+// MaxBookingStatsDays is the longest date range of a booking stats request
+const MaxBookingStatsDays = 31
+
+// BookingCounterStore is implemented by storage backends that keep booking counters
+type BookingCounterStore interface {
+	// CountBooking adds one booking to the global counter and to the counter of the route on the day
+	CountBooking(ctx context.Context, route, day string) error
+	// GetBookingCounts returns the global count and the counts per route for each of the days
+	GetBookingCounts(ctx context.Context, days []string) (int64, map[string]map[string]int64, error)
+}
+
+// BookingStats keeps running booking counts so that statistics are read from
+// a handful of counter documents instead of aggregating the tickets collection.
+// Counts only grow: cancellations do not take bookings back off.
+type BookingStats struct {
+	counters BookingCounterStore // nil when the backend cannot keep counters
+}
+
+// NewBookingStats creates the booking counters of a repository
+func NewBookingStats(repository TicketRepository) *BookingStats {
+	counters, _ := Capability[BookingCounterStore](repository)
+	return &BookingStats{counters: counters}
+}
+
+// Enabled reports whether the storage backend keeps booking counters
+func (s *BookingStats) Enabled() bool {
+	return s.counters != nil
+}
+
+// Record counts the booking of a new ticket on the day it was created
+func (s *BookingStats) Record(ctx context.Context, ticket *models.FlightTicket) error {
+	if s.counters == nil {
+		return nil
+	}
+	return s.counters.CountBooking(ctx, BookingRoute(ticket), ticket.CreatedAt.UTC().Format("2006-01-02"))
+}
+
+// Stats returns the bookings from one day to another, both included
+func (s *BookingStats) Stats(ctx context.Context, from, to time.Time) (*models.BookingStats, error) {
+	if s.counters == nil {
+		return nil, fmt.Errorf("booking counters are not supported by the storage backend")
+	}
+
+	var days []string
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		days = append(days, day.Format("2006-01-02"))
+	}
+	if len(days) == 0 || len(days) > MaxBookingStatsDays {
+		return nil, fmt.Errorf("date range must cover 1 to %d days", MaxBookingStatsDays)
+	}
+
+	total, counts, err := s.counters.GetBookingCounts(ctx, days)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &models.BookingStats{
+		TotalBookings: total,
+		From:          days[0],
+		To:            days[len(days)-1],
+		Days:          make([]models.DailyBookings, 0, len(days)),
+	}
+	for _, day := range days {
+		daily := models.DailyBookings{Date: day, Routes: []models.RouteBookings{}}
+		for route, bookings := range counts[day] {
+			if bookings == 0 {
+				continue
+			}
+			daily.Bookings += bookings
+			daily.Routes = append(daily.Routes, models.RouteBookings{Route: route, Bookings: bookings})
+		}
+		sort.Slice(daily.Routes, func(i, j int) bool {
+			if daily.Routes[i].Bookings != daily.Routes[j].Bookings {
+				return daily.Routes[i].Bookings > daily.Routes[j].Bookings
+			}
+			return daily.Routes[i].Route < daily.Routes[j].Route
+		})
+		stats.Days = append(stats.Days, daily)
+	}
+
+	return stats, nil
+}
+
+// BookingRoute names the route of a ticket in the booking counters, e.g. JFK-LAX
+func BookingRoute(ticket *models.FlightTicket) string {
+	return ticket.Origin + "-" + ticket.Destination
+}
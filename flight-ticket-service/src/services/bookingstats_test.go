@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+func TestBookingStatsCountsPerDayAndRoute(t *testing.T) {
+	ctx := context.Background()
+	stats := NewBookingStats(NewMemoryRepository())
+	if !stats.Enabled() {
+		t.Fatal("Expected the memory repository to keep booking counters")
+	}
+
+	book := func(origin, destination string, day int) {
+		ticket := &models.FlightTicket{Origin: origin, Destination: destination, CreatedAt: time.Date(2024, 7, day, 18, 0, 0, 0, time.UTC)}
+		if err := stats.Record(ctx, ticket); err != nil {
+			t.Fatalf("Unexpected error recording booking: %v", err)
+		}
+	}
+	book("JFK", "LAX", 11)
+	book("JFK", "LAX", 12)
+	book("SFO", "ORD", 12)
+	book("JFK", "LAX", 12)
+	book("JFK", "LAX", 20)
+
+	result, err := stats.Stats(ctx, time.Date(2024, 7, 10, 0, 0, 0, 0, time.UTC), time.Date(2024, 7, 12, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.TotalBookings != 5 || result.From != "2024-07-10" || result.To != "2024-07-12" || len(result.Days) != 3 {
+		t.Fatalf("Unexpected stats %+v", result)
+	}
+	if result.Days[0].Bookings != 0 || len(result.Days[0].Routes) != 0 {
+		t.Errorf("Expected no bookings on the first day, got %+v", result.Days[0])
+	}
+	if result.Days[1].Bookings != 1 {
+		t.Errorf("Expected 1 booking on 2024-07-11, got %+v", result.Days[1])
+	}
+	day := result.Days[2]
+	if day.Bookings != 3 || len(day.Routes) != 2 || day.Routes[0] != (models.RouteBookings{Route: "JFK-LAX", Bookings: 2}) {
+		t.Errorf("Unexpected bookings on 2024-07-12: %+v", day)
+	}
+
+	if _, err := stats.Stats(ctx, time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 8, 15, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Error("Expected an error for a range longer than the maximum")
+	}
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"flight-ticket-service/src/models"
+)
+
+// BulkCancelBatchSize is how many tickets a bulk cancellation handles between progress reports
+const BulkCancelBatchSize = 50
+
+// BulkCancelResult summarizes a bulk cancellation
+type BulkCancelResult struct {
+	Matched        int `json:"matched"`
+	Cancelled      int `json:"cancelled"`
+	Failed         int `json:"failed"`
+	VouchersIssued int `json:"vouchers_issued,omitempty"`
+}
+
+// VoucherIssuer compensates the travellers of tickets the airline cancels
+type VoucherIssuer interface {
+	// IssueVoucher issues the voucher of a cancelled ticket; it returns nil
+	// when the ticket gets none
+	IssueVoucher(ctx context.Context, ticket *models.FlightTicket, actor string) (*models.Voucher, error)
+}
+
+// SeatAssignments frees the seats assigned to tickets; seats.Store implements it
+type SeatAssignments interface {
+	// Unassign frees the seats of a departure assigned to a ticket
+	Unassign(ctx context.Context, flightNumber, date, confirmationID string, seats []string) error
+}
+
+// UnassignTicketSeats frees the seats assigned to a ticket, named by its seat labels
+func UnassignTicketSeats(ctx context.Context, assignments SeatAssignments, ticket *models.FlightTicket) error {
+	assigned := models.TicketSeats(ticket)
+	if len(assigned) == 0 {
+		return nil
+	}
+	return assignments.Unassign(ctx, ticket.FlightNumber, ticket.DepartureDate.Format("2006-01-02"), ticket.ConfirmationID, assigned)
+}
+
+// PreviewBulkCancel returns the tickets a bulk cancellation would cancel and
+// the token that confirms cancelling exactly those tickets
+func PreviewBulkCancel(ctx context.Context, repository TicketRepository, query models.TicketQuery) ([]*models.FlightTicket, string, error) {
+	tickets, err := bulkCancelTickets(ctx, repository, query)
+	if err != nil {
+		return nil, "", err
+	}
+	return tickets, BulkCancelToken(query, tickets), nil
+}
+
+// BulkCancelToken identifies a filter and the tickets it matched, so that a
+// confirmed cancellation can check that nothing changed since its dry run
+func BulkCancelToken(query models.TicketQuery, tickets []*models.FlightTicket) string {
+	ids := make([]string, len(tickets))
+	for i, ticket := range tickets {
+		ids[i] = ticket.ConfirmationID
+	}
+	sort.Strings(ids)
+
+	filter := models.BulkCancelFilterOf(query)
+	sum := sha256.Sum256([]byte(strings.Join(append([]string{filter.FlightNumber, filter.DepartureDate, filter.Status}, ids...), "\x00")))
+	return hex.EncodeToString(sum[:16])
+}
+
+// BulkCancel cancels the tickets matching the query that are not cancelled
+// yet, releases their seats and frees their assigned seats, reporting progress after each batch. With
+// confirmationIDs, only those of the matching tickets are cancelled. With an
+// issuer, each cancelled ticket gets a voucher. Running it again after an
+// interruption picks up the tickets it had not reached.
+func (j *TicketJobs) BulkCancel(ctx context.Context, query models.TicketQuery, confirmationIDs []string, actor string, issuer VoucherIssuer, progress func(done, total int)) (*BulkCancelResult, error) {
+	tickets, err := bulkCancelTickets(ctx, j.repository, query)
+	if err != nil {
+		return nil, err
+	}
+	if confirmationIDs != nil {
+		selected := make(map[string]bool, len(confirmationIDs))
+		for _, id := range confirmationIDs {
+			selected[id] = true
+		}
+		kept := tickets[:0]
+		for _, ticket := range tickets {
+			if selected[ticket.ConfirmationID] {
+				kept = append(kept, ticket)
+			}
+		}
+		tickets = kept
+	}
+
+	result := &BulkCancelResult{Matched: len(tickets)}
+	progress(0, len(tickets))
+	for i, ticket := range tickets {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if err := j.repository.DeleteTicket(ctx, ticket.ConfirmationID); err != nil {
+			log.Printf("Failed to cancel ticket %s: %v", ticket.ConfirmationID, err)
+			result.Failed++
+		} else {
+			if err := j.inventory.Release(ctx, ticket, actor); err != nil {
+				log.Printf("Failed to release seats of ticket %s: %v", ticket.ConfirmationID, err)
+			}
+			j.unassignSeats(ctx, ticket)
+			if issuer != nil {
+				if voucher, err := issuer.IssueVoucher(ctx, ticket, actor); err != nil {
+					log.Printf("Failed to issue voucher for ticket %s: %v", ticket.ConfirmationID, err)
+				} else if voucher != nil {
+					result.VouchersIssued++
+				}
+			}
+			result.Cancelled++
+		}
+
+		if done := i + 1; done%BulkCancelBatchSize == 0 || done == len(tickets) {
+			progress(done, len(tickets))
+		}
+	}
+
+	log.Printf("Bulk cancellation by %s: %d matched, %d cancelled, %d failed, %d vouchers issued", actor, result.Matched, result.Cancelled, result.Failed, result.VouchersIssued)
+	return result, nil
+}
+
+// bulkCancelTickets returns the tickets matching the query that can still be cancelled
+func bulkCancelTickets(ctx context.Context, repository TicketRepository, query models.TicketQuery) ([]*models.FlightTicket, error) {
+	if query.FlightNumber == "" && query.DepartureDate.IsZero() {
+		return nil, fmt.Errorf("a flight number or departure date is required")
+	}
+
+	tickets, err := SearchTickets(ctx, repository, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search tickets: %v", err)
+	}
+
+	cancellable := make([]*models.FlightTicket, 0, len(tickets))
+	for _, ticket := range tickets {
+		if ticket.Status != "CANCELLED" {
+			cancellable = append(cancellable, ticket)
+		}
+	}
+	return cancellable, nil
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+// DelayResult summarizes a flight delay
+type DelayResult struct {
+	Scanned int
+	Delayed []DelayedTicket
+	Failed  int
+}
+
+// DelayedTicket is a ticket before and after its departure was moved
+type DelayedTicket struct {
+	Previous *models.FlightTicket
+	Current  *models.FlightTicket
+}
+
+// DelayFlight moves the departure time of every ticket on the flight departing
+// on date (confirmed or pending; cancelled tickets are left alone). The ticket
+// keeps its scheduled departure date, as airlines do when a flight slips past
+// midnight. With disrupt, confirmed and checked-in tickets also become DISRUPTED.
+func (j *TicketJobs) DelayFlight(ctx context.Context, flightNumber string, date time.Time, delay time.Duration, disrupt bool) (*DelayResult, error) {
+	tickets, err := j.repository.ListTickets(ctx, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tickets: %v", err)
+	}
+
+	result := &DelayResult{Scanned: len(tickets)}
+	for _, ticket := range tickets {
+		if ticket.FlightNumber != flightNumber || ticket.Status == "CANCELLED" || !sameDay(ticket.DepartureDate, date) {
+			continue
+		}
+
+		updates := map[string]interface{}{"departure_time": ticket.DepartureTime.Add(delay)}
+		if disrupt && (ticket.Status == "CONFIRMED" || ticket.Status == "CHECKED_IN") {
+			updates["status"] = models.DisruptedStatus
+		}
+		if err := j.repository.UpdateTicket(ctx, ticket.ConfirmationID, updates); err != nil {
+			log.Printf("Failed to delay ticket %s: %v", ticket.ConfirmationID, err)
+			result.Failed++
+			continue
+		}
+
+		current, err := j.repository.GetTicket(ctx, ticket.ConfirmationID)
+		if err != nil {
+			log.Printf("Delayed ticket %s but failed to retrieve it: %v", ticket.ConfirmationID, err)
+			result.Failed++
+			continue
+		}
+		log.Printf("Delayed ticket %s on flight %s by %s", ticket.ConfirmationID, flightNumber, delay)
+		result.Delayed = append(result.Delayed, DelayedTicket{Previous: ticket, Current: current})
+	}
+
+	return result, nil
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.Month() == b.Month() && a.Day() == b.Day()
+}
@@ -0,0 +1,254 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/api/option"
+)
+
+// DocumentStore holds generated documents (QR codes, manifest PDFs) and signs
+// short-lived URLs to them
+type DocumentStore interface {
+	// Exists reports whether a document has already been stored under the object name
+	Exists(ctx context.Context, objectName string) (bool, error)
+	// Upload stores a document under the object name
+	Upload(ctx context.Context, objectName, contentType string, data []byte) error
+	// SignedURL returns a URL that downloads the document until the returned expiry
+	SignedURL(objectName, fileName string) (string, time.Time, error)
+}
+
+// Document is a generated document that can be served through a signed URL
+type Document struct {
+	// ObjectName identifies the document; it must change when the content does
+	ObjectName  string
+	FileName    string
+	ContentType string
+}
+
+// DocumentLink is a signed URL to a stored document
+type DocumentLink struct {
+	URL       string
+	ExpiresAt time.Time
+	// Cached is true when the stored document was reused instead of generated
+	Cached bool
+}
+
+// DocumentCache serves generated documents from a DocumentStore. Documents
+// are keyed by a version of their source (the ticket's update time, the
+// manifest content), so a document is rendered and uploaded once per version
+// and every later request only signs a new URL.
+type DocumentCache struct {
+	store   DocumentStore
+	lookups *prometheus.CounterVec // nil unless instrumented
+}
+
+// NewDocumentCache creates a document cache on the given store
+func NewDocumentCache(store DocumentStore) *DocumentCache {
+	return &DocumentCache{store: store}
+}
+
+// Instrument counts cache hits and misses in document_cache_requests_total
+func (dc *DocumentCache) Instrument(registerer prometheus.Registerer) error {
+	lookups := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "document_cache_requests_total",
+		Help: "Generated document requests by result: hit (stored copy reused) or miss (rendered and uploaded).",
+	}, []string{"result"})
+	if err := registerer.Register(lookups); err != nil {
+		return fmt.Errorf("failed to register document cache metrics: %v", err)
+	}
+	dc.lookups = lookups
+	return nil
+}
+
+// Link returns a signed URL to the document, calling render to generate and
+// upload it when no stored copy exists yet
+func (dc *DocumentCache) Link(ctx context.Context, document Document, render func() ([]byte, error)) (*DocumentLink, error) {
+	exists, err := dc.store.Exists(ctx, document.ObjectName)
+	if err != nil {
+		return nil, err
+	}
+	if dc.lookups != nil {
+		result := "miss"
+		if exists {
+			result = "hit"
+		}
+		dc.lookups.WithLabelValues(result).Inc()
+	}
+
+	if !exists {
+		data, err := render()
+		if err != nil {
+			return nil, err
+		}
+		if err := dc.store.Upload(ctx, document.ObjectName, document.ContentType, data); err != nil {
+			return nil, err
+		}
+	}
+
+	signedURL, expiresAt, err := dc.store.SignedURL(document.ObjectName, document.FileName)
+	if err != nil {
+		return nil, err
+	}
+	return &DocumentLink{URL: signedURL, ExpiresAt: expiresAt, Cached: exists}, nil
+}
+
+// DocumentObjectName names a generated document, e.g.
+// tickets/ABC123/documents/qr-1f3a9c0b7d2e4f6a.png. The version and the
+// rendering parameters are hashed into the name so that a changed ticket or
+// other parameters produce a new object instead of a stale one.
+func DocumentObjectName(prefix, kind, extension string, version ...string) string {
+	hash := sha256.Sum256([]byte(strings.Join(version, "\x00")))
+	return fmt.Sprintf("%s/documents/%s-%s.%s", prefix, kind, hex.EncodeToString(hash[:8]), extension)
+}
+
+// CDNSigner signs Cloud CDN URLs with a signed-URL key of the backend bucket,
+// so that documents are served from the CDN edge instead of Cloud Storage
+type CDNSigner struct {
+	baseURL string
+	keyName string
+	key     []byte
+}
+
+// NewCDNSigner creates a signer for the CDN host serving the document bucket.
+// The key is the base64url-encoded 128-bit key registered under keyName.
+func NewCDNSigner(baseURL, keyName, key string) (*CDNSigner, error) {
+	parsed, err := url.Parse(baseURL)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid CDN URL %q", baseURL)
+	}
+	if keyName == "" {
+		return nil, fmt.Errorf("CDN signing key name is required")
+	}
+	decoded, err := base64.URLEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CDN signing key: %v", err)
+	}
+
+	return &CDNSigner{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		keyName: keyName,
+		key:     decoded,
+	}, nil
+}
+
+// Sign returns the CDN URL of an object, valid until expiresAt:
+// <url>?Expires=<unix time>&KeyName=<key name>&Signature=<base64url HMAC-SHA1>
+func (cs *CDNSigner) Sign(objectName string, expiresAt time.Time) string {
+	unsigned := fmt.Sprintf("%s/%s?Expires=%d&KeyName=%s",
+		cs.baseURL, escapeObjectName(objectName), expiresAt.Unix(), url.QueryEscape(cs.keyName))
+
+	mac := hmac.New(sha1.New, cs.key)
+	mac.Write([]byte(unsigned))
+	return unsigned + "&Signature=" + base64.URLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// escapeObjectName escapes each segment of an object name for use in a URL path
+func escapeObjectName(objectName string) string {
+	segments := strings.Split(objectName, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// DocumentStorage stores generated documents in a Cloud Storage bucket and
+// signs Cloud Storage URLs to them, or Cloud CDN URLs when a CDN signer is set
+type DocumentStorage struct {
+	client *storage.Client
+	bucket string
+	expiry time.Duration
+	cdn    *CDNSigner // optional
+}
+
+// NewDocumentStorage creates a document store for the given bucket. Signing
+// Cloud Storage URLs works as for attachments (see NewAttachmentStorage).
+func NewDocumentStorage(bucket, credentialsPath string, expiry time.Duration, cdn *CDNSigner) (*DocumentStorage, error) {
+	ctx := context.Background()
+
+	var opts []option.ClientOption
+	if credentialsPath != "" {
+		// Use service account key file
+		opts = append(opts, option.WithCredentialsFile(credentialsPath))
+	}
+
+	client, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Cloud Storage client: %v", err)
+	}
+
+	return &DocumentStorage{
+		client: client,
+		bucket: strings.TrimPrefix(bucket, "gs://"),
+		expiry: expiry,
+		cdn:    cdn,
+	}, nil
+}
+
+// Exists reports whether the object is in the bucket
+func (ds *DocumentStorage) Exists(ctx context.Context, objectName string) (bool, error) {
+	_, err := ds.client.Bucket(ds.bucket).Object(objectName).Attrs(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to look up document %s: %v", objectName, err)
+	}
+	return true, nil
+}
+
+// Upload writes the document to the bucket. Objects never change once
+// written, so they are cacheable for as long as they exist.
+func (ds *DocumentStorage) Upload(ctx context.Context, objectName, contentType string, data []byte) error {
+	writer := ds.client.Bucket(ds.bucket).Object(objectName).NewWriter(ctx)
+	writer.ContentType = contentType
+	writer.CacheControl = "private, max-age=31536000, immutable"
+	if _, err := io.Copy(writer, bytes.NewReader(data)); err != nil {
+		writer.Close()
+		return fmt.Errorf("failed to upload document %s: %v", objectName, err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to upload document %s: %v", objectName, err)
+	}
+	return nil
+}
+
+// SignedURL returns a signed GET URL for the document
+func (ds *DocumentStorage) SignedURL(objectName, fileName string) (string, time.Time, error) {
+	expiresAt := time.Now().Add(ds.expiry)
+	if ds.cdn != nil {
+		return ds.cdn.Sign(objectName, expiresAt), expiresAt, nil
+	}
+
+	signedURL, err := ds.client.Bucket(ds.bucket).SignedURL(objectName, &storage.SignedURLOptions{
+		Scheme:  storage.SigningSchemeV4,
+		Method:  "GET",
+		Expires: expiresAt,
+		QueryParameters: url.Values{
+			"response-content-disposition": {fmt.Sprintf("inline; filename=%q", SanitizeFileName(fileName))},
+		},
+	})
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign document URL: %v", err)
+	}
+
+	return signedURL, expiresAt, nil
+}
+
+// Close closes the Cloud Storage client
+func (ds *DocumentStorage) Close() error {
+	return ds.client.Close()
+}
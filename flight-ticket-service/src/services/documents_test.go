@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// memoryDocumentStore keeps documents in memory and counts uploads
+type memoryDocumentStore struct {
+	objects map[string][]byte
+	uploads int
+}
+
+func (s *memoryDocumentStore) Exists(ctx context.Context, objectName string) (bool, error) {
+	_, ok := s.objects[objectName]
+	return ok, nil
+}
+
+func (s *memoryDocumentStore) Upload(ctx context.Context, objectName, contentType string, data []byte) error {
+	s.objects[objectName] = data
+	s.uploads++
+	return nil
+}
+
+func (s *memoryDocumentStore) SignedURL(objectName, fileName string) (string, time.Time, error) {
+	return "https://storage.example.com/" + objectName, time.Now().Add(time.Minute), nil
+}
+
+func TestDocumentCacheReusesStoredDocuments(t *testing.T) {
+	store := &memoryDocumentStore{objects: map[string][]byte{}}
+	cache := NewDocumentCache(store)
+	if err := cache.Instrument(prometheus.NewRegistry()); err != nil {
+		t.Fatalf("Failed to instrument cache: %v", err)
+	}
+
+	renders := 0
+	render := func() ([]byte, error) {
+		renders++
+		return []byte("%PDF"), nil
+	}
+	document := Document{
+		ObjectName:  DocumentObjectName("tickets/ABC123", "qr", "png", "2024-07-12T19:00:00Z"),
+		FileName:    "ABC123-qr.png",
+		ContentType: "image/png",
+	}
+
+	first, err := cache.Link(context.Background(), document, render)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if first.Cached || renders != 1 || store.uploads != 1 {
+		t.Errorf("Expected the first request to render and upload, got cached=%v renders=%d uploads=%d", first.Cached, renders, store.uploads)
+	}
+
+	second, err := cache.Link(context.Background(), document, render)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !second.Cached || renders != 1 || store.uploads != 1 {
+		t.Errorf("Expected the stored document to be reused, got cached=%v renders=%d uploads=%d", second.Cached, renders, store.uploads)
+	}
+	if second.URL != "https://storage.example.com/"+document.ObjectName {
+		t.Errorf("Unexpected URL %q", second.URL)
+	}
+	if hits, misses := testutil.ToFloat64(cache.lookups.WithLabelValues("hit")), testutil.ToFloat64(cache.lookups.WithLabelValues("miss")); hits != 1 || misses != 1 {
+		t.Errorf("Counted %v hits and %v misses, want 1 each", hits, misses)
+	}
+}
+
+func TestDocumentObjectName(t *testing.T) {
+	name := DocumentObjectName("tickets/ABC123", "qr", "png", "2024-07-12T19:00:00Z", "256")
+	if !strings.HasPrefix(name, "tickets/ABC123/documents/qr-") || !strings.HasSuffix(name, ".png") {
+		t.Errorf("Unexpected object name %q", name)
+	}
+	if name != DocumentObjectName("tickets/ABC123", "qr", "png", "2024-07-12T19:00:00Z", "256") {
+		t.Error("Expected the same version to give the same object name")
+	}
+	if name == DocumentObjectName("tickets/ABC123", "qr", "png", "2024-07-13T08:30:00Z", "256") {
+		t.Error("Expected an updated ticket to give a new object name")
+	}
+	if name == DocumentObjectName("tickets/ABC123", "qr", "png", "2024-07-12T19:00:00Z", "512") {
+		t.Error("Expected other parameters to give a new object name")
+	}
+}
+
+func TestCDNSigner(t *testing.T) {
+	key := base64.URLEncoding.EncodeToString([]byte("0123456789abcdef"))
+	signer, err := NewCDNSigner("https://cdn.example.com/", "documents-key", key)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expiresAt := time.Unix(1720810800, 0)
+	signed := signer.Sign("manifests/AA1234/2024-12-25/manifest 1.pdf", expiresAt)
+
+	unsigned, signature, found := strings.Cut(signed, "&Signature=")
+	if !found {
+		t.Fatalf("Expected a signature in %q", signed)
+	}
+	if unsigned != "https://cdn.example.com/manifests/AA1234/2024-12-25/manifest%201.pdf?Expires=1720810800&KeyName=documents-key" {
+		t.Errorf("Unexpected URL %q", unsigned)
+	}
+
+	mac := hmac.New(sha1.New, []byte("0123456789abcdef"))
+	mac.Write([]byte(unsigned))
+	if signature != base64.URLEncoding.EncodeToString(mac.Sum(nil)) {
+		t.Errorf("Unexpected signature %q", signature)
+	}
+	if _, err := url.Parse(signed); err != nil {
+		t.Errorf("Expected a valid URL, got %v", err)
+	}
+
+	if _, err := NewCDNSigner("cdn.example.com", "documents-key", key); err == nil {
+		t.Error("Expected an error for a URL without scheme")
+	}
+	if _, err := NewCDNSigner("https://cdn.example.com", "", key); err == nil {
+		t.Error("Expected an error without a key name")
+	}
+	if _, err := NewCDNSigner("https://cdn.example.com", "documents-key", "not base64!"); err == nil {
+		t.Error("Expected an error for an invalid key")
+	}
+}
@@ -2,48 +2,89 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
+	"sync"
 	"time"
 
-	"cloud.google.com/go/firestore"
+	"flight-ticket-service/src/internal/docstore"
+	"flight-ticket-service/src/internal/shardcounter"
+	"flight-ticket-service/src/mapping"
 	"flight-ticket-service/src/models"
+
+	"cloud.google.com/go/firestore"
 	"google.golang.org/api/option"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type FirestoreService struct {
 	client     *firestore.Client
 	collection string
+	strict     bool // reject ticket documents with missing, mistyped or unknown fields
+	shared     bool // the client belongs to the service this one was made from
+
+	// quarantined remembers the tickets this instance has quarantined, so that
+	// every list does not copy them again
+	quarantined sync.Map
 }
 
-// NewFirestoreService creates a new Firestore service instance
-func NewFirestoreService(projectID string, credentialsPath string) (*FirestoreService, error) {
-	ctx := context.Background()
-	
-	var client *firestore.Client
-	var err error
-	
+// NewFirestoreClient opens a client of the given database, authenticated with
+// the service account key at credentialsPath or, without one, with Application
+// Default Credentials
+func NewFirestoreClient(ctx context.Context, projectID, databaseID, credentialsPath string) (*firestore.Client, error) {
+	var opts []option.ClientOption
 	if credentialsPath != "" {
-		// Use service account key file
-		client, err = firestore.NewClient(ctx, projectID, option.WithCredentialsFile(credentialsPath))
-	} else {
-		// Use default credentials (ADC)
-		client, err = firestore.NewClient(ctx, projectID)
+		opts = append(opts, option.WithCredentialsFile(credentialsPath))
 	}
-	
+
+	client, err := firestore.NewClientWithDatabase(ctx, projectID, databaseID, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Firestore client: %v", err)
 	}
+	return client, nil
+}
+
+// NewFirestoreService creates a new Firestore service instance storing tickets
+// in the given collection of the given database. With strict set, tickets whose
+// documents fail the field checks of mapping.TicketFieldProblems are treated as
+// unreadable instead of being returned with a warning.
+func NewFirestoreService(projectID, databaseID, collection, credentialsPath string, strict bool) (*FirestoreService, error) {
+	client, err := NewFirestoreClient(context.Background(), projectID, databaseID, credentialsPath)
+	if err != nil {
+		return nil, err
+	}
 
 	return &FirestoreService{
 		client:     client,
-		collection: "flight_tickets",
+		collection: collection,
+		strict:     strict,
 	}, nil
 }
 
+// Client returns the service's Firestore client. The stores kept in the same
+// database use it instead of opening clients of their own; it stays open
+// until the service is closed.
+func (fs *FirestoreService) Client() *firestore.Client {
+	return fs.client
+}
+
+// WithCollection returns a service storing tickets in another collection
+// through the same client. Closing it leaves the client open.
+func (fs *FirestoreService) WithCollection(collection string) *FirestoreService {
+	return &FirestoreService{
+		client:     fs.client,
+		collection: collection,
+		strict:     fs.strict,
+		shared:     true,
+	}
+}
+
 // CreateTicket creates a new flight ticket in Firestore
 func (fs *FirestoreService) CreateTicket(ctx context.Context, ticket *models.FlightTicket) error {
-	_, err := fs.client.Collection(fs.collection).Doc(ticket.ConfirmationID).Set(ctx, ticket)
+	_, err := fs.client.Collection(fs.collection).Doc(ticket.ConfirmationID).Set(ctx, mapping.TicketToDocument(ticket))
 	if err != nil {
 		return fmt.Errorf("failed to create ticket: %v", err)
 	}
@@ -54,33 +95,94 @@ func (fs *FirestoreService) CreateTicket(ctx context.Context, ticket *models.Fli
 
 // GetTicket retrieves a flight ticket by confirmation ID
 func (fs *FirestoreService) GetTicket(ctx context.Context, confirmationID string) (*models.FlightTicket, error) {
-	doc, err := fs.client.Collection(fs.collection).Doc(confirmationID).Get(ctx)
+	ref := fs.client.Collection(fs.collection).Doc(confirmationID)
+	doc, err := docstore.Get[mapping.TicketDocument](ctx, ref, "ticket")
 	if err != nil {
-		return nil, fmt.Errorf("failed to get ticket: %v", err)
+		return nil, err
 	}
-	
-	var ticket models.FlightTicket
-	if err := doc.DataTo(&ticket); err != nil {
-		return nil, fmt.Errorf("failed to parse ticket data: %v", err)
+	if err := fs.checkTicket(confirmationID, doc); err != nil {
+		return nil, err
 	}
-	
-	return &ticket, nil
+	if doc.Migrated() {
+		// Write the upgrade back, so the document is migrated once; reads do not fail if it is not
+		if _, err := fs.upgradeTicket(ctx, ref); err != nil {
+			log.Printf("Failed to store the upgraded ticket %s: %v", confirmationID, err)
+		}
+	}
+	return mapping.TicketFromDocument(doc), nil
+}
+
+// upgradeTicket rewrites a ticket stored with an older schema version in the
+// current one. It runs in a transaction so that a concurrent update is not lost.
+func (fs *FirestoreService) upgradeTicket(ctx context.Context, ref *firestore.DocumentRef) (bool, error) {
+	var upgraded bool
+	err := fs.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		upgraded = false
+		snapshot, err := tx.Get(ref)
+		if err != nil {
+			return err
+		}
+		doc, err := docstore.Decode[mapping.TicketDocument](snapshot, "ticket")
+		if err != nil || !doc.Migrated() {
+			return err
+		}
+		if problems := doc.Problems(); len(problems) > 0 {
+			// Rewriting would drop unknown fields and replace mistyped values
+			return fmt.Errorf("not upgrading invalid ticket document: %s", strings.Join(problems, "; "))
+		}
+		upgraded = true
+		return tx.Set(ref, doc)
+	})
+	return upgraded, err
+}
+
+// MigrateTickets rewrites every ticket stored with an older schema version in
+// the current one. Only the schema_version field is read to find them.
+func (fs *FirestoreService) MigrateTickets(ctx context.Context, progress func(done, total int)) (*SchemaMigrationResult, error) {
+	docs, err := fs.client.Collection(fs.collection).Select("schema_version").Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tickets: %v", err)
+	}
+
+	result := &SchemaMigrationResult{Scanned: len(docs), SchemaVersion: mapping.TicketSchemaVersion}
+	for i, doc := range docs {
+		if version, _ := doc.Data()["schema_version"].(int64); version < mapping.TicketSchemaVersion {
+			upgraded, err := fs.upgradeTicket(ctx, doc.Ref)
+			switch {
+			case err != nil:
+				log.Printf("Failed to migrate ticket %s: %v", doc.Ref.ID, err)
+				result.Failed++
+			case upgraded:
+				result.Migrated++
+			}
+		}
+		if progress != nil {
+			progress(i+1, len(docs))
+		}
+	}
+
+	log.Printf("Migrated %d of %d tickets to schema version %d", result.Migrated, result.Scanned, mapping.TicketSchemaVersion)
+	return result, nil
 }
 
 // UpdateTicket updates an existing flight ticket
 func (fs *FirestoreService) UpdateTicket(ctx context.Context, confirmationID string, updates map[string]interface{}) error {
 	updates["updated_at"] = time.Now()
+	fields, err := mapping.TicketUpdates(updates)
+	if err != nil {
+		return fmt.Errorf("failed to update ticket: %v", err)
+	}
 	
 	// Build the update array
 	var updateArray []firestore.Update
-	for field, value := range updates {
+	for field, value := range fields {
 		updateArray = append(updateArray, firestore.Update{
 			Path:  field,
 			Value: value,
 		})
 	}
 	
-	_, err := fs.client.Collection(fs.collection).Doc(confirmationID).Update(ctx, updateArray)
+	_, err = fs.client.Collection(fs.collection).Doc(confirmationID).Update(ctx, updateArray)
 	if err != nil {
 		return fmt.Errorf("failed to update ticket: %v", err)
 	}
@@ -108,25 +210,547 @@ func (fs *FirestoreService) ListTickets(ctx context.Context, limit int) ([]*mode
 		query = query.Limit(limit)
 	}
 	
-	docs, err := query.Documents(ctx).GetAll()
+	return fs.queryTickets(ctx, query)
+}
+
+// queryTickets runs a ticket query. Documents that do not decode, or in strict
+// mode fail the field checks, are left out and quarantined.
+func (fs *FirestoreService) queryTickets(ctx context.Context, query firestore.Query) ([]*models.FlightTicket, error) {
+	snapshots, err := query.Documents(ctx).GetAll()
 	if err != nil {
-		return nil, fmt.Errorf("failed to list tickets: %v", err)
+		return nil, fmt.Errorf("failed to list ticket documents: %v", err)
 	}
-	
+	return fs.decodeTickets(ctx, snapshots), nil
+}
+
+// decodeTickets decodes ticket documents, quarantining those that are left out
+func (fs *FirestoreService) decodeTickets(ctx context.Context, snapshots []*firestore.DocumentSnapshot) []*models.FlightTicket {
 	var tickets []*models.FlightTicket
-	for _, doc := range docs {
-		var ticket models.FlightTicket
-		if err := doc.DataTo(&ticket); err != nil {
-			log.Printf("Failed to parse ticket %s: %v", doc.Ref.ID, err)
+	for _, snapshot := range snapshots {
+		doc, err := docstore.Decode[mapping.TicketDocument](snapshot, "ticket")
+		if err == nil {
+			err = fs.checkTicket(snapshot.Ref.ID, doc)
+		}
+		if err != nil {
+			log.Printf("Skipping ticket document %s: %v", snapshot.Ref.ID, err)
+			fs.quarantine(ctx, snapshot, err)
 			continue
 		}
-		tickets = append(tickets, &ticket)
+		tickets = append(tickets, mapping.TicketFromDocument(doc))
 	}
-	
-	return tickets, nil
+	return tickets
+}
+
+// quarantine copies an unreadable ticket document to the quarantine
+// collection with the reason, once per instance. Failures are only logged.
+func (fs *FirestoreService) quarantine(ctx context.Context, snapshot *firestore.DocumentSnapshot, reason error) {
+	if _, seen := fs.quarantined.LoadOrStore(snapshot.Ref.ID, true); seen {
+		return
+	}
+	entry := &models.QuarantinedTicket{
+		ConfirmationID: snapshot.Ref.ID,
+		Document:       fs.collection + "/" + snapshot.Ref.ID,
+		Error:          reason.Error(),
+		Fields:         snapshot.Data(),
+		QuarantinedAt:  time.Now().UTC(),
+	}
+	if err := docstore.Set(ctx, fs.quarantineCollection().Doc(snapshot.Ref.ID), entry, "quarantined ticket"); err != nil {
+		log.Printf("Failed to quarantine ticket document %s: %v", snapshot.Ref.ID, err)
+		fs.quarantined.Delete(snapshot.Ref.ID)
+		return
+	}
+	log.Printf("Quarantined ticket document %s", snapshot.Ref.ID)
+}
+
+// ListQuarantined retrieves the quarantined ticket documents, oldest first
+func (fs *FirestoreService) ListQuarantined(ctx context.Context) ([]*models.QuarantinedTicket, error) {
+	return docstore.List[models.QuarantinedTicket](ctx, fs.quarantineCollection().OrderBy("quarantined_at", firestore.Asc), "quarantined ticket")
+}
+
+// GetQuarantined reads a quarantined ticket document, nil when the ticket is not quarantined
+func (fs *FirestoreService) GetQuarantined(ctx context.Context, confirmationID string) (*models.QuarantinedTicket, error) {
+	return docstore.Find[models.QuarantinedTicket](ctx, fs.quarantineCollection().Doc(confirmationID), "quarantined ticket")
+}
+
+// RepairQuarantined sets and removes fields of a quarantined ticket's document.
+// When the result passes the strict field checks, the ticket is rewritten in
+// the current schema and released from quarantine; otherwise nothing changes
+// and the error wraps ErrInvalidTicketDocument.
+func (fs *FirestoreService) RepairQuarantined(ctx context.Context, confirmationID string, set map[string]interface{}, remove []string) (*models.FlightTicket, error) {
+	ref := fs.client.Collection(fs.collection).Doc(confirmationID)
+	var repaired *models.FlightTicket
+	err := fs.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snapshot, err := tx.Get(ref)
+		if err != nil {
+			return fmt.Errorf("failed to get ticket: %v", err)
+		}
+
+		fields := snapshot.Data()
+		for name, value := range set {
+			if fields[name], err = mapping.TicketFieldValue(name, value); err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidTicketDocument, err)
+			}
+		}
+		for _, name := range remove {
+			delete(fields, name)
+		}
+
+		var doc mapping.TicketDocument
+		if err := doc.DecodeFields(fields); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidTicketDocument, err)
+		}
+		if problems := doc.Problems(); len(problems) > 0 {
+			return fmt.Errorf("%w: %s", ErrInvalidTicketDocument, strings.Join(problems, "; "))
+		}
+
+		repaired = mapping.TicketFromDocument(&doc)
+		if err := tx.Set(ref, &doc); err != nil {
+			return err
+		}
+		return tx.Delete(fs.quarantineCollection().Doc(confirmationID))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fs.quarantined.Delete(confirmationID)
+	log.Printf("Repaired quarantined ticket %s", confirmationID)
+	return repaired, nil
+}
+
+func (fs *FirestoreService) quarantineCollection() *firestore.CollectionRef {
+	return fs.client.Collection(fs.collection + "_quarantine")
+}
+
+// checkTicket reports the field problems of a decoded ticket document: as an
+// error in strict mode, otherwise as a logged warning
+func (fs *FirestoreService) checkTicket(id string, doc *mapping.TicketDocument) error {
+	problems := doc.Problems()
+	if len(problems) == 0 {
+		return nil
+	}
+	if fs.strict {
+		return fmt.Errorf("invalid ticket document %s: %s", id, strings.Join(problems, "; "))
+	}
+	log.Printf("Warning: ticket document %s: %s", id, strings.Join(problems, "; "))
+	return nil
+}
+
+// SearchTickets filters tickets with equality queries on labels and ticket
+// fields. A limited search is ordered, and departure dates matched, by
+// Firestore and read a page at a time, past quarantined documents, from a
+// cursor; its filter combinations need composite indexes ending in created_at
+// descending (see firestore_indexes in infra/terraform). An unlimited search,
+// as the inventory and the booking rules run, is ordered in memory so that
+// it needs no composite index.
+func (fs *FirestoreService) SearchTickets(ctx context.Context, query models.TicketQuery) ([]*models.FlightTicket, error) {
+	q := fs.client.Collection(fs.collection).Query
+	for key, value := range query.Labels {
+		q = q.WherePath(firestore.FieldPath{"labels", key}, "==", value)
+	}
+	for field, value := range map[string]string{
+		"status":        query.Status,
+		"origin":        query.Origin,
+		"destination":   query.Destination,
+		"flight_number": query.FlightNumber,
+	} {
+		if value != "" {
+			q = q.Where(field, "==", value)
+		}
+	}
+
+	if query.Limit <= 0 {
+		tickets, err := fs.queryTickets(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		return filterTickets(tickets, query), nil
+	}
+
+	// A range filter must be ordered on first; departure dates are stored
+	// at midnight, so the tickets of a day are still newest first
+	if !query.DepartureDate.IsZero() {
+		day := query.DepartureDate.UTC().Truncate(24 * time.Hour)
+		q = q.Where("departure_date", ">=", day).Where("departure_date", "<", day.AddDate(0, 0, 1)).OrderBy("departure_date", firestore.Asc)
+	}
+	q = q.OrderBy("created_at", firestore.Desc)
+
+	var tickets []*models.FlightTicket
+	page := q.Limit(query.Limit)
+	for {
+		size := query.Limit - len(tickets)
+		snapshots, err := page.Documents(ctx).GetAll()
+		if err != nil {
+			return nil, fmt.Errorf("failed to search ticket documents: %v", err)
+		}
+		tickets = append(tickets, fs.decodeTickets(ctx, snapshots)...)
+		if len(snapshots) < size || len(tickets) >= query.Limit {
+			return tickets, nil
+		}
+		page = q.StartAfter(snapshots[len(snapshots)-1]).Limit(query.Limit - len(tickets))
+	}
+}
+
+// CreateAttachment stores attachment metadata in the ticket's attachments subcollection
+func (fs *FirestoreService) CreateAttachment(ctx context.Context, attachment *models.Attachment) error {
+	_, err := fs.attachments(attachment.ConfirmationID).Doc(attachment.ID).Set(ctx, attachment)
+	if err != nil {
+		return fmt.Errorf("failed to create attachment: %v", err)
+	}
+
+	log.Printf("Created attachment %s for ticket %s", attachment.ID, attachment.ConfirmationID)
+	return nil
+}
+
+// GetAttachment retrieves a single attachment of a ticket
+func (fs *FirestoreService) GetAttachment(ctx context.Context, confirmationID, attachmentID string) (*models.Attachment, error) {
+	return docstore.Get[models.Attachment](ctx, fs.attachments(confirmationID).Doc(attachmentID), "attachment")
+}
+
+// ListAttachments retrieves the attachments of a ticket, oldest first
+func (fs *FirestoreService) ListAttachments(ctx context.Context, confirmationID string) ([]*models.Attachment, error) {
+	return docstore.List[models.Attachment](ctx, fs.attachments(confirmationID).OrderBy("created_at", firestore.Asc), "attachment")
+}
+
+func (fs *FirestoreService) attachments(confirmationID string) *firestore.CollectionRef {
+	return fs.client.Collection(fs.collection).Doc(confirmationID).Collection("attachments")
+}
+
+// GetNotificationPreferences reads the ticket's preferences/notifications document
+func (fs *FirestoreService) GetNotificationPreferences(ctx context.Context, confirmationID string) (*models.NotificationPreferences, error) {
+	return docstore.Find[models.NotificationPreferences](ctx, fs.notificationPreferences(confirmationID), "notification preferences")
+}
+
+// SaveNotificationPreferences replaces the ticket's preferences/notifications document
+func (fs *FirestoreService) SaveNotificationPreferences(ctx context.Context, preferences *models.NotificationPreferences) error {
+	if err := docstore.Set(ctx, fs.notificationPreferences(preferences.ConfirmationID), preferences, "notification preferences"); err != nil {
+		return err
+	}
+
+	log.Printf("Saved notification preferences for ticket %s", preferences.ConfirmationID)
+	return nil
+}
+
+func (fs *FirestoreService) notificationPreferences(confirmationID string) *firestore.DocumentRef {
+	return fs.client.Collection(fs.collection).Doc(confirmationID).Collection("preferences").Doc("notifications")
+}
+
+// CreateNote stores a note in the ticket's notes subcollection
+func (fs *FirestoreService) CreateNote(ctx context.Context, note *models.TicketNote) error {
+	if _, err := fs.notes(note.ConfirmationID).Doc(note.ID).Set(ctx, note); err != nil {
+		return fmt.Errorf("failed to create note: %v", err)
+	}
+
+	log.Printf("Created note %s for ticket %s", note.ID, note.ConfirmationID)
+	return nil
+}
+
+// ListNotes retrieves the notes of a ticket, oldest first
+func (fs *FirestoreService) ListNotes(ctx context.Context, confirmationID string) ([]*models.TicketNote, error) {
+	return docstore.List[models.TicketNote](ctx, fs.notes(confirmationID).OrderBy("created_at", firestore.Asc), "note")
+}
+
+func (fs *FirestoreService) notes(confirmationID string) *firestore.CollectionRef {
+	return fs.client.Collection(fs.collection).Doc(confirmationID).Collection("notes")
+}
+
+// GetLock reads the ticket's edit lock document
+func (fs *FirestoreService) GetLock(ctx context.Context, confirmationID string) (*models.TicketLock, error) {
+	return docstore.Find[models.TicketLock](ctx, fs.lock(confirmationID), "lock")
+}
+
+// UpdateLock reads, changes and writes back the ticket's edit lock document in a transaction
+func (fs *FirestoreService) UpdateLock(ctx context.Context, confirmationID string, change func(*models.TicketLock) (*models.TicketLock, error)) (*models.TicketLock, error) {
+	ref := fs.lock(confirmationID)
+	var updated *models.TicketLock
+	err := fs.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		var current *models.TicketLock
+		doc, err := tx.Get(ref)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return fmt.Errorf("failed to get lock: %v", err)
+		}
+		if err == nil {
+			if current, err = docstore.Decode[models.TicketLock](doc, "lock"); err != nil {
+				return err
+			}
+		}
+
+		if updated, err = change(current); err != nil {
+			return err
+		}
+		if updated == nil {
+			return tx.Delete(ref)
+		}
+		return tx.Set(ref, updated)
+	})
+	if err != nil {
+		if errors.Is(err, ErrTicketLocked) || errors.Is(err, ErrStaleLockToken) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to update lock: %v", err)
+	}
+
+	return updated, nil
+}
+
+// lock is the edit lock document of a ticket; the change feed ignores subcollections
+func (fs *FirestoreService) lock(confirmationID string) *firestore.DocumentRef {
+	return fs.client.Collection(fs.collection).Doc(confirmationID).Collection("locks").Doc("edit")
+}
+
+// revisionDocument is a TicketRevision as stored in a ticket's history
+// subcollection, with the tickets in their stored form
+type revisionDocument struct {
+	ID             string                  `firestore:"id"`
+	ConfirmationID string                  `firestore:"confirmation_id"`
+	Type           string                  `firestore:"type"`
+	Time           time.Time               `firestore:"time"`
+	ChangedFields  []string                `firestore:"changed_fields,omitempty"`
+	Ticket         *mapping.TicketDocument `firestore:"ticket,omitempty"`
+	Previous       *mapping.TicketDocument `firestore:"previous,omitempty"`
+}
+
+// RecordRevision stores a revision in the ticket's history subcollection, keyed by its ID
+func (fs *FirestoreService) RecordRevision(ctx context.Context, revision *models.TicketRevision) error {
+	doc := &revisionDocument{
+		ID:             revision.ID,
+		ConfirmationID: revision.ConfirmationID,
+		Type:           revision.Type,
+		Time:           revision.Time,
+		ChangedFields:  revision.ChangedFields,
+	}
+	if revision.Ticket != nil {
+		doc.Ticket = mapping.TicketToDocument(revision.Ticket)
+	}
+	if revision.Previous != nil {
+		doc.Previous = mapping.TicketToDocument(revision.Previous)
+	}
+	return docstore.Set(ctx, fs.history(revision.ConfirmationID).Doc(revision.ID), doc, "revision")
+}
+
+// ListRevisions retrieves the ticket's history, oldest first
+func (fs *FirestoreService) ListRevisions(ctx context.Context, confirmationID string) ([]*models.TicketRevision, error) {
+	docs, err := docstore.List[revisionDocument](ctx, fs.history(confirmationID).OrderBy("time", firestore.Asc), "revision")
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := make([]*models.TicketRevision, 0, len(docs))
+	for _, doc := range docs {
+		revision := &models.TicketRevision{
+			ID:             doc.ID,
+			ConfirmationID: doc.ConfirmationID,
+			Type:           doc.Type,
+			Time:           doc.Time,
+			ChangedFields:  doc.ChangedFields,
+		}
+		if doc.Ticket != nil {
+			revision.Ticket = mapping.TicketFromDocument(doc.Ticket)
+		}
+		if doc.Previous != nil {
+			revision.Previous = mapping.TicketFromDocument(doc.Previous)
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, nil
+}
+
+// history is the subcollection of a ticket's revisions, written by the change feed
+func (fs *FirestoreService) history(confirmationID string) *firestore.CollectionRef {
+	return fs.client.Collection(fs.collection).Doc(confirmationID).Collection("history")
+}
+
+// GetView reads a document of the views collection
+func (fs *FirestoreService) GetView(ctx context.Context, name string) (*models.SavedView, error) {
+	return docstore.Find[models.SavedView](ctx, fs.views().Doc(name), "view")
+}
+
+// SaveView creates or replaces a document of the views collection
+func (fs *FirestoreService) SaveView(ctx context.Context, view *models.SavedView) error {
+	if err := docstore.Set(ctx, fs.views().Doc(view.Name), view, "view"); err != nil {
+		return err
+	}
+
+	log.Printf("Saved view %s", view.Name)
+	return nil
+}
+
+// ListViews retrieves every view, ordered by name
+func (fs *FirestoreService) ListViews(ctx context.Context) ([]*models.SavedView, error) {
+	return docstore.List[models.SavedView](ctx, fs.views().OrderBy("name", firestore.Asc), "view")
+}
+
+// DeleteView deletes a document of the views collection
+func (fs *FirestoreService) DeleteView(ctx context.Context, name string) error {
+	if _, err := fs.views().Doc(name).Delete(ctx); err != nil {
+		return fmt.Errorf("failed to delete view: %v", err)
+	}
+
+	log.Printf("Deleted view %s", name)
+	return nil
+}
+
+func (fs *FirestoreService) views() *firestore.CollectionRef {
+	return fs.client.Collection(fs.collection + "_views")
+}
+
+// PostInventory applies the entries in a Firestore transaction. Each flight's
+// balance document is read and rewritten with the new entries, so concurrent
+// bookings of the same flight are serialized by the transaction.
+func (fs *FirestoreService) PostInventory(ctx context.Context, entries []*models.InventoryEntry) ([]*models.InventoryEntry, error) {
+	var posted []*models.InventoryEntry
+	err := fs.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		balances := make(map[string]*models.InventoryBalance)
+		for _, entry := range entries {
+			key := inventoryKey(entry.FlightNumber, entry.Date)
+			if _, loaded := balances[key]; loaded {
+				continue
+			}
+			doc, err := tx.Get(fs.inventory().Doc(key))
+			if status.Code(err) == codes.NotFound {
+				balances[key] = nil
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to get inventory: %v", err)
+			}
+			balance, err := docstore.Decode[models.InventoryBalance](doc, "inventory")
+			if err != nil {
+				return err
+			}
+			if balance.Held == nil {
+				balance.Held = map[string]int{}
+			}
+			balances[key] = balance
+		}
+
+		var err error
+		posted, err = postInventoryEntries(balances, entries, time.Now())
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range posted {
+			ref := fs.inventory().Doc(inventoryKey(entry.FlightNumber, entry.Date)).Collection("entries").Doc(entry.ID)
+			if err := tx.Create(ref, entry); err != nil {
+				return err
+			}
+		}
+		for key, balance := range balances {
+			if balance.Entries == 0 {
+				continue
+			}
+			if err := tx.Set(fs.inventory().Doc(key), balance); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrInsufficientSeats) || errors.Is(err, ErrSalesFrozen) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to post inventory: %v", err)
+	}
+
+	return posted, nil
+}
+
+// GetInventory reads a document of the inventory collection
+func (fs *FirestoreService) GetInventory(ctx context.Context, flightNumber, date string) (*models.InventoryBalance, error) {
+	balance, err := docstore.Find[models.InventoryBalance](ctx, fs.inventory().Doc(inventoryKey(flightNumber, date)), "inventory")
+	if balance == nil || err != nil {
+		return nil, err
+	}
+	if balance.Held == nil {
+		balance.Held = map[string]int{}
+	}
+
+	return balance, nil
+}
+
+// ListInventoryEntries retrieves a flight's ledger, oldest first
+func (fs *FirestoreService) ListInventoryEntries(ctx context.Context, flightNumber, date string) ([]*models.InventoryEntry, error) {
+	docs, err := fs.inventory().Doc(inventoryKey(flightNumber, date)).Collection("entries").
+		OrderBy("sequence", firestore.Asc).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list inventory entries: %v", err)
+	}
+
+	var entries []*models.InventoryEntry
+	for _, doc := range docs {
+		// Unlike docstore.List, a ledger with an unreadable entry is an error
+		entry, err := docstore.Decode[models.InventoryEntry](doc, "inventory entry")
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func (fs *FirestoreService) inventory() *firestore.CollectionRef {
+	return fs.client.Collection(fs.collection + "_inventory")
+}
+
+// bookingCounter shards each booking counter over ten documents, so that
+// concurrent bookings rarely write the same one
+var bookingCounter = shardcounter.MustNew(10)
+
+// CountBooking increments a random shard of the global counter and of the
+// day's counter, which keeps a count per route in its routes map
+func (fs *FirestoreService) CountBooking(ctx context.Context, route, day string) error {
+	batch := fs.client.Batch()
+	batch.Set(bookingCounter.ShardRef(fs.bookingCounters().Doc("total")),
+		shardcounter.Increments(map[string]int64{"count": 1}, nil), firestore.MergeAll)
+	batch.Set(bookingCounter.ShardRef(fs.bookingCounters().Doc(day)),
+		shardcounter.Increments(map[string]int64{"count": 1}, map[string]map[string]int64{"routes": {route: 1}}), firestore.MergeAll)
+	if _, err := batch.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to count booking: %v", err)
+	}
+	return nil
+}
+
+// GetBookingCounts reads every shard of the global counter and of the days' counters in one call
+func (fs *FirestoreService) GetBookingCounts(ctx context.Context, days []string) (int64, map[string]map[string]int64, error) {
+	counters := append([]string{"total"}, days...)
+	var refs []*firestore.DocumentRef
+	for _, counter := range counters {
+		refs = append(refs, bookingCounter.ShardRefs(fs.bookingCounters().Doc(counter))...)
+	}
+
+	docs, err := fs.client.GetAll(ctx, refs)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read booking counters: %v", err)
+	}
+
+	shards := make(map[string][]map[string]interface{})
+	for _, doc := range docs {
+		if doc.Exists() {
+			counter := doc.Ref.Parent.Parent.ID
+			shards[counter] = append(shards[counter], doc.Data())
+		}
+	}
+	total := shardcounter.Sum(shards["total"]).Counts["count"]
+	counts := make(map[string]map[string]int64)
+	for _, day := range days {
+		if routes := shardcounter.Sum(shards[day]).Maps["routes"]; len(routes) > 0 {
+			counts[day] = routes
+		}
+	}
+
+	return total, counts, nil
+}
+
+func (fs *FirestoreService) bookingCounters() *firestore.CollectionRef {
+	return fs.client.Collection(fs.collection + "_booking_counters")
 }
 
 // Close closes the Firestore client
 func (fs *FirestoreService) Close() error {
+	if fs.shared {
+		return nil
+	}
 	return fs.client.Close()
 }
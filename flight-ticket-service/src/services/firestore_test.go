@@ -0,0 +1,37 @@
+package services
+
+import (
+	"testing"
+
+	"cloud.google.com/go/firestore"
+)
+
+func TestFirestoreCollectionsFollowTicketCollection(t *testing.T) {
+	fs := &FirestoreService{client: &firestore.Client{}, collection: "tickets_staging"}
+	for _, test := range []struct {
+		collection *firestore.CollectionRef
+		expected   string
+	}{
+		{fs.views(), "tickets_staging_views"},
+		{fs.inventory(), "tickets_staging_inventory"},
+		{fs.bookingCounters(), "tickets_staging_booking_counters"},
+		{fs.quarantineCollection(), "tickets_staging_quarantine"},
+		{fs.WithCollection("tickets_sandbox").views(), "tickets_sandbox_views"},
+	} {
+		if test.collection.ID != test.expected || test.collection.Parent != nil {
+			t.Errorf("Expected the top-level collection %s, got %s", test.expected, test.collection.Path)
+		}
+	}
+}
+
+func TestFirestoreWithCollectionLeavesClientOpen(t *testing.T) {
+	// A zero client panics when closed, so only its owner may close it
+	fs := &FirestoreService{client: &firestore.Client{}, collection: "tickets"}
+	sandbox := fs.WithCollection("tickets_sandbox")
+	if sandbox.client != fs.client {
+		t.Fatal("Expected the sandbox collection to share the client")
+	}
+	if err := sandbox.Close(); err != nil {
+		t.Errorf("Expected closing a shared service to leave the client open, got %v", err)
+	}
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+// History errors
+var (
+	// ErrTicketNotCreated is returned for a time before the ticket was created or after it was deleted
+	ErrTicketNotCreated = errors.New("ticket did not exist at that time")
+	// ErrNoTicketHistory is returned for a time before the ticket's history was first recorded
+	ErrNoTicketHistory = errors.New("no history of the ticket at that time")
+	// ErrRevisionNotFound is returned for a revision number outside the ticket's history
+	ErrRevisionNotFound = errors.New("no such revision of the ticket")
+	// ErrNotReversible is returned for a revision that cannot be undone
+	ErrNotReversible = errors.New("the change cannot be undone")
+)
+
+// TicketHistory is implemented by storage backends that keep past versions of tickets
+type TicketHistory interface {
+	// RecordRevision stores a version of a ticket; recording a revision ID again replaces it
+	RecordRevision(ctx context.Context, revision *models.TicketRevision) error
+	// ListRevisions retrieves the versions of a ticket, oldest first
+	ListRevisions(ctx context.Context, confirmationID string) ([]*models.TicketRevision, error)
+}
+
+// TicketAsOf reconstructs a ticket as it was at a past time from its
+// revisions, oldest first, and its current state. The last revision at or
+// before the time holds the answer. Without one, the state before the first
+// later revision, or the current ticket when it has not changed since, is used
+// if it was already in force at that time.
+func TicketAsOf(revisions []*models.TicketRevision, current *models.FlightTicket, asOf time.Time) (*models.FlightTicket, error) {
+	if current.CreatedAt.After(asOf) {
+		return nil, ErrTicketNotCreated
+	}
+
+	var before, after *models.TicketRevision
+	for _, revision := range revisions {
+		if !revision.Time.After(asOf) {
+			before = revision
+		} else if after == nil {
+			after = revision
+		}
+	}
+
+	switch {
+	case before != nil && before.Ticket == nil:
+		return nil, ErrTicketNotCreated
+	case before != nil:
+		return before.Ticket, nil
+	case after == nil && !current.UpdatedAt.After(asOf):
+		return current, nil
+	case after != nil && after.Previous != nil && !after.Previous.UpdatedAt.After(asOf):
+		return after.Previous, nil
+	}
+	return nil, ErrNoTicketHistory
+}
+
+// RevisionTicket returns the ticket as it was after a revision, numbered from
+// 1 for the oldest. Revision 0 is the ticket before its first revision. The
+// ticket is nil before it was created and after it was deleted.
+func RevisionTicket(revisions []*models.TicketRevision, number int) (*models.FlightTicket, error) {
+	if len(revisions) == 0 || number < 0 || number > len(revisions) {
+		return nil, ErrRevisionNotFound
+	}
+	if number == 0 {
+		return revisions[0].Previous, nil
+	}
+	return revisions[number-1].Ticket, nil
+}
+
+// DiffTickets returns the fields that differ between two versions of a
+// ticket, by their dotted path in the ticket's JSON, sorted by path. Objects
+// such as the price and labels are compared field by field, lists as a whole.
+// A nil ticket has no fields.
+func DiffTickets(from, to *models.FlightTicket) ([]models.FieldChange, error) {
+	before, err := flattenTicket(from)
+	if err != nil {
+		return nil, err
+	}
+	after, err := flattenTicket(to)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []models.FieldChange{}
+	for field, value := range before {
+		if other, ok := after[field]; !ok || !reflect.DeepEqual(value, other) {
+			changes = append(changes, models.FieldChange{Field: field, From: value, To: other})
+		}
+	}
+	for field, value := range after {
+		if _, ok := before[field]; !ok {
+			changes = append(changes, models.FieldChange{Field: field, To: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes, nil
+}
+
+// flattenTicket flattens the JSON form of a ticket into values by dotted path
+func flattenTicket(ticket *models.FlightTicket) (map[string]interface{}, error) {
+	fields := make(map[string]interface{})
+	if ticket == nil {
+		return fields, nil
+	}
+	data, err := json.Marshal(ticket)
+	if err != nil {
+		return nil, err
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+	flattenFields("", object, fields)
+	return fields, nil
+}
+
+func flattenFields(prefix string, object map[string]interface{}, fields map[string]interface{}) {
+	for key, value := range object {
+		if nested, ok := value.(map[string]interface{}); ok {
+			flattenFields(prefix+key+".", nested, fields)
+			continue
+		}
+		fields[prefix+key] = value
+	}
+}
+
+// undoableStatuses are the statuses an undo may put back, those a ticket update may set
+var undoableStatuses = map[string]bool{StatusConfirmed: true, "CANCELLED": true, StatusPending: true, models.DisruptedStatus: true}
+
+// UndoUpdates returns the ticket updates that put a ticket back as it was
+// before a revision. Only changes of the fields a ticket update sets can be
+// undone: bookings, check-ins, price changes and changes of assigned seats
+// cannot, nor can cancellations that freed seats.
+func UndoUpdates(revision *models.TicketRevision) (map[string]interface{}, error) {
+	previous, ticket := revision.Previous, revision.Ticket
+	if previous == nil || ticket == nil {
+		return nil, fmt.Errorf("%w: the revision %s the ticket", ErrNotReversible, revision.Type)
+	}
+	changes, err := DiffTickets(previous, ticket)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make(map[string]interface{})
+	for _, change := range changes {
+		field, key, _ := strings.Cut(change.Field, ".")
+		switch {
+		case field == "updated_at":
+		case field == "labels" && models.IsSeatLabel(key):
+			return nil, fmt.Errorf("%w: it changed the assigned seats", ErrNotReversible)
+		case field == "labels":
+			labels := make(map[string]string, len(previous.Labels))
+			for key, value := range previous.Labels {
+				labels[key] = value
+			}
+			updates["labels"] = labels
+		case field == "origin":
+			updates[field] = previous.Origin
+		case field == "destination":
+			updates[field] = previous.Destination
+		case field == "departure_date":
+			updates[field] = previous.DepartureDate
+		case field == "departure_time":
+			updates[field] = previous.DepartureTime
+		case field == "flight_number":
+			updates[field] = previous.FlightNumber
+		case field == "passengers":
+			updates[field] = previous.Passengers
+		case field == "status" && undoableStatuses[previous.Status]:
+			updates[field] = previous.Status
+		default:
+			return nil, fmt.Errorf("%w: it changed %s", ErrNotReversible, change.Field)
+		}
+	}
+	if len(updates) == 0 {
+		return nil, fmt.Errorf("%w: it changed no fields", ErrNotReversible)
+	}
+	if previous.Status != "CANCELLED" && ticket.Status == "CANCELLED" && len(models.TicketSeats(ticket)) > 0 {
+		return nil, fmt.Errorf("%w: the cancellation freed the assigned seats", ErrNotReversible)
+	}
+	return updates, nil
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+func TestTicketAsOf(t *testing.T) {
+	created := time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)
+	version := func(passengers int, updated time.Time) *models.FlightTicket {
+		return &models.FlightTicket{ConfirmationID: "ABC123", Passengers: passengers, CreatedAt: created, UpdatedAt: updated}
+	}
+	first, second := created.AddDate(0, 0, 10), created.AddDate(0, 0, 20)
+	current := version(3, second)
+	revisions := []*models.TicketRevision{
+		{Type: models.RevisionUpdated, Time: first, Previous: version(1, created), Ticket: version(2, first)},
+		{Type: models.RevisionUpdated, Time: second, Previous: version(2, first), Ticket: current},
+	}
+
+	tests := []struct {
+		name       string
+		revisions  []*models.TicketRevision
+		at         time.Time
+		passengers int
+		err        error
+	}{
+		{"between revisions", revisions, first.Add(time.Hour), 2, nil},
+		{"after the last revision", revisions, second.Add(time.Hour), 3, nil},
+		{"before the history began", revisions, created.Add(time.Hour), 1, nil},
+		{"before the ticket existed", revisions, created.Add(-time.Hour), 0, ErrTicketNotCreated},
+		{"unchanged without history", nil, second.Add(time.Hour), 3, nil},
+		{"changed without history", nil, first, 0, ErrNoTicketHistory},
+	}
+	for _, tt := range tests {
+		ticket, err := TicketAsOf(tt.revisions, current, tt.at)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: expected error %v, got %v", tt.name, tt.err, err)
+			continue
+		}
+		if err == nil && ticket.Passengers != tt.passengers {
+			t.Errorf("%s: expected %d passengers, got %d", tt.name, tt.passengers, ticket.Passengers)
+		}
+	}
+}
+
+func TestDiffTickets(t *testing.T) {
+	created := time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC)
+	before := &models.FlightTicket{
+		ConfirmationID: "ABC123", Passengers: 2, Status: "CONFIRMED", CreatedAt: created, UpdatedAt: created,
+		Price:  &models.Price{Amount: 398, Currency: "USD"},
+		Labels: map[string]string{"campaign": "summer-sale", "channel": "web"},
+	}
+	after := copyTicket(before)
+	after.Passengers, after.Status, after.UpdatedAt = 3, "CANCELLED", created.AddDate(0, 0, 1)
+	after.Price.Amount = 597
+	after.Labels = map[string]string{"campaign": "summer-sale", "corporate_account": "acme"}
+
+	changes, err := DiffTickets(before, after)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var fields []string
+	for _, change := range changes {
+		fields = append(fields, change.Field)
+	}
+	want := []string{"labels.channel", "labels.corporate_account", "passengers", "price.amount", "status", "updated_at"}
+	if !reflect.DeepEqual(fields, want) {
+		t.Fatalf("Expected changes of %v, got %v", want, fields)
+	}
+	if changes[0].From != "web" || changes[0].To != nil || changes[1].From != nil || changes[1].To != "acme" || changes[2].From != float64(2) {
+		t.Errorf("Unexpected values %+v", changes)
+	}
+
+	if changes, _ := DiffTickets(before, before); len(changes) != 0 {
+		t.Errorf("Expected no changes between equal tickets, got %+v", changes)
+	}
+	if changes, _ := DiffTickets(nil, before); len(changes) == 0 || changes[0].From != nil {
+		t.Errorf("Expected every field added on creation, got %+v", changes)
+	}
+}
+
+func TestRevisionTicket(t *testing.T) {
+	first, second := &models.FlightTicket{Passengers: 1}, &models.FlightTicket{Passengers: 2}
+	revisions := []*models.TicketRevision{
+		{Type: models.RevisionCreated, Ticket: first},
+		{Type: models.RevisionUpdated, Previous: first, Ticket: second},
+	}
+	for number, want := range []*models.FlightTicket{nil, first, second} {
+		if ticket, err := RevisionTicket(revisions, number); err != nil || ticket != want {
+			t.Errorf("Revision %d: expected %+v, got %+v, %v", number, want, ticket, err)
+		}
+	}
+	for _, number := range []int{-1, 3} {
+		if _, err := RevisionTicket(revisions, number); !errors.Is(err, ErrRevisionNotFound) {
+			t.Errorf("Revision %d: expected ErrRevisionNotFound, got %v", number, err)
+		}
+	}
+}
+
+func TestUndoUpdates(t *testing.T) {
+	before := &models.FlightTicket{ConfirmationID: "ABC123", Origin: "JFK", Passengers: 2, Status: StatusConfirmed, Labels: map[string]string{"seat_1": "12a", "campaign": "summer-sale"}}
+	change := func(edit func(ticket *models.FlightTicket)) *models.TicketRevision {
+		after := copyTicket(before)
+		after.UpdatedAt = time.Now()
+		edit(after)
+		return &models.TicketRevision{Type: models.RevisionUpdated, Previous: before, Ticket: after}
+	}
+
+	updates, err := UndoUpdates(change(func(ticket *models.FlightTicket) {
+		ticket.Passengers, ticket.Origin = 3, "EWR"
+		ticket.Labels["campaign"] = "spring"
+	}))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	labels, _ := updates["labels"].(map[string]string)
+	if len(updates) != 3 || updates["passengers"] != 2 || updates["origin"] != "JFK" || labels["campaign"] != "summer-sale" || labels["seat_1"] != "12a" {
+		t.Errorf("Unexpected updates %+v", updates)
+	}
+
+	for name, revision := range map[string]*models.TicketRevision{
+		"booking":            {Type: models.RevisionCreated, Ticket: before},
+		"check-in":           change(func(ticket *models.FlightTicket) { ticket.CheckIn = &models.CheckInRecord{Compartment: "Y"} }),
+		"price":              change(func(ticket *models.FlightTicket) { ticket.Price = &models.Price{Amount: 100} }),
+		"seat change":        change(func(ticket *models.FlightTicket) { delete(ticket.Labels, "seat_1") }),
+		"seated cancel":      change(func(ticket *models.FlightTicket) { ticket.Status = "CANCELLED" }),
+		"no change":          change(func(ticket *models.FlightTicket) {}),
+		"status from review": {Type: models.RevisionUpdated, Previous: &models.FlightTicket{Status: models.ReviewStatus}, Ticket: &models.FlightTicket{Status: StatusConfirmed}},
+	} {
+		if _, err := UndoUpdates(revision); !errors.Is(err, ErrNotReversible) {
+			t.Errorf("%s: expected ErrNotReversible, got %v", name, err)
+		}
+	}
+}
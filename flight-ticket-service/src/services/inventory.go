@@ -0,0 +1,512 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+// ErrInsufficientSeats is returned when a movement would take more seats than are available
+var ErrInsufficientSeats = errors.New("not enough seats available")
+
+// ErrSalesFrozen is returned when a movement would sell seats on a departure whose sales are frozen
+var ErrSalesFrozen = errors.New("sales are frozen")
+
+// ErrNoInventory is returned when limits are set on a departure without a ledger
+var ErrNoInventory = errors.New("departure has no seat inventory")
+
+// InventoryLedger is implemented by storage backends that can keep seat ledgers
+type InventoryLedger interface {
+	// PostInventory applies the entries with applyInventory in one transaction,
+	// reading and updating each flight's balance, and returns the entries posted.
+	// Either every entry is posted or none is.
+	PostInventory(ctx context.Context, entries []*models.InventoryEntry) ([]*models.InventoryEntry, error)
+	// GetInventory returns a flight's balance, or nil when the flight has no ledger
+	GetInventory(ctx context.Context, flightNumber, date string) (*models.InventoryBalance, error)
+	// ListInventoryEntries retrieves a flight's ledger, oldest first
+	ListInventoryEntries(ctx context.Context, flightNumber, date string) ([]*models.InventoryEntry, error)
+}
+
+// SeatInventory keeps the seat ledgers in step with ticket bookings. Flights
+// only have a ledger once an admin puts seats on sale; bookings on other
+// flights are not counted.
+type SeatInventory struct {
+	repository TicketRepository
+	ledger     InventoryLedger // nil when the backend cannot keep ledgers
+}
+
+// NewSeatInventory creates the seat inventory of a repository
+func NewSeatInventory(repository TicketRepository) *SeatInventory {
+	ledger, _ := Capability[InventoryLedger](repository)
+	return &SeatInventory{repository: repository, ledger: ledger}
+}
+
+// Enabled reports whether the storage backend keeps seat ledgers
+func (s *SeatInventory) Enabled() bool {
+	return s.ledger != nil
+}
+
+// Book holds the seats of a new ticket. It returns ErrInsufficientSeats when the flight is sold out.
+func (s *SeatInventory) Book(ctx context.Context, ticket *models.FlightTicket, actor string) error {
+	return s.Change(ctx, nil, ticket, actor)
+}
+
+// Release returns the seats held by a ticket that was cancelled or never stored
+func (s *SeatInventory) Release(ctx context.Context, ticket *models.FlightTicket, actor string) error {
+	return s.Change(ctx, ticket, nil, actor)
+}
+
+// Change moves the seats of a ticket from its previous booking to its current
+// one. A nil or cancelled ticket holds no seats. It returns ErrInsufficientSeats
+// when the current flight cannot take the extra seats; nothing is posted then.
+func (s *SeatInventory) Change(ctx context.Context, previous, current *models.FlightTicket, actor string) error {
+	if s.ledger == nil {
+		return nil
+	}
+
+	entries := inventoryMovements(previous, current)
+	if len(entries) == 0 {
+		return nil
+	}
+	for _, entry := range entries {
+		entry.Actor = actor
+	}
+
+	posted, err := s.ledger.PostInventory(ctx, entries)
+	if err != nil {
+		return err
+	}
+	for _, entry := range posted {
+		log.Printf("Posted %s of %d seats on %s %s: %s -> %s", entry.Kind, entry.Seats, entry.FlightNumber, entry.Date, entry.From, entry.To)
+	}
+	return nil
+}
+
+// Check reports whether Change would succeed, with ErrInsufficientSeats when
+// the current flight cannot take the extra seats, without posting anything
+func (s *SeatInventory) Check(ctx context.Context, previous, current *models.FlightTicket) error {
+	if s.ledger == nil {
+		return nil
+	}
+
+	entries := inventoryMovements(previous, current)
+	balances := make(map[string]*models.InventoryBalance)
+	for _, entry := range entries {
+		key := inventoryKey(entry.FlightNumber, entry.Date)
+		if _, ok := balances[key]; ok {
+			continue
+		}
+		balance, err := s.ledger.GetInventory(ctx, entry.FlightNumber, entry.Date)
+		if err != nil {
+			return err
+		}
+		balances[key] = balance
+	}
+	_, err := postInventoryEntries(balances, entries, time.Now())
+	return err
+}
+
+// Adjust puts seats on sale (positive) or takes them off sale (negative).
+// Seats already held by tickets cannot be taken off sale.
+func (s *SeatInventory) Adjust(ctx context.Context, flightNumber, date string, seats int, reason, actor string) (*models.InventoryEntry, error) {
+	if s.ledger == nil {
+		return nil, fmt.Errorf("seat inventory is not supported by the storage backend")
+	}
+
+	entry := &models.InventoryEntry{
+		FlightNumber: strings.ToUpper(flightNumber),
+		Date:         date,
+		Kind:         models.InventoryAdjust,
+		From:         models.AccountCapacity,
+		To:           models.AccountAvailable,
+		Seats:        seats,
+		Actor:        actor,
+		Reason:       reason,
+	}
+	if seats < 0 {
+		entry.From, entry.To, entry.Seats = models.AccountAvailable, models.AccountCapacity, -seats
+	}
+
+	posted, err := s.ledger.PostInventory(ctx, []*models.InventoryEntry{entry})
+	if err != nil {
+		return nil, err
+	}
+	if len(posted) == 0 {
+		return nil, fmt.Errorf("adjustment of %s %s was not posted", entry.FlightNumber, date)
+	}
+
+	log.Printf("Adjusted seats on %s %s by %d: %s", entry.FlightNumber, date, seats, reason)
+	return posted[0], nil
+}
+
+// SetLimits sets how far beyond its capacity a departure may be sold, as a
+// percentage, and freezes or reopens its sales. The limits are posted as a
+// LIMITS entry, so bookings are checked against them in the same transaction
+// as the balance. It returns ErrNoInventory when the departure has no ledger.
+func (s *SeatInventory) SetLimits(ctx context.Context, flightNumber, date string, overbookingPercent int, frozen bool, reason, actor string) (*models.InventoryEntry, error) {
+	if s.ledger == nil {
+		return nil, fmt.Errorf("seat inventory is not supported by the storage backend")
+	}
+
+	entry := &models.InventoryEntry{
+		FlightNumber:       strings.ToUpper(flightNumber),
+		Date:               date,
+		Kind:               models.InventoryLimits,
+		Actor:              actor,
+		Reason:             reason,
+		OverbookingPercent: overbookingPercent,
+		Frozen:             frozen,
+	}
+	posted, err := s.ledger.PostInventory(ctx, []*models.InventoryEntry{entry})
+	if err != nil {
+		return nil, err
+	}
+	if len(posted) == 0 {
+		return nil, ErrNoInventory
+	}
+
+	log.Printf("Set limits of %s %s to %d%% overbooking, frozen %t: %s", entry.FlightNumber, date, overbookingPercent, frozen, reason)
+	return posted[0], nil
+}
+
+// Snapshot counts the seats of a departure that are sold to confirmed
+// tickets, held by pending ones and left to sell. It returns nil when the
+// departure has no ledger.
+func (s *SeatInventory) Snapshot(ctx context.Context, flightNumber, date string) (*models.InventorySnapshot, error) {
+	if s.ledger == nil {
+		return nil, fmt.Errorf("seat inventory is not supported by the storage backend")
+	}
+	flightNumber = strings.ToUpper(flightNumber)
+
+	departureDate, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q: %v", date, err)
+	}
+	balance, err := s.ledger.GetInventory(ctx, flightNumber, date)
+	if err != nil || balance == nil {
+		return nil, err
+	}
+	tickets, err := SearchTickets(ctx, s.repository, models.TicketQuery{FlightNumber: flightNumber, DepartureDate: departureDate})
+	if err != nil {
+		return nil, err
+	}
+	pending := make(map[string]bool)
+	for _, ticket := range tickets {
+		if ticket.Status == StatusPending {
+			pending[ticket.ConfirmationID] = true
+		}
+	}
+
+	snapshot := &models.InventorySnapshot{
+		FlightNumber:       flightNumber,
+		Date:               date,
+		Capacity:           balance.Capacity,
+		OverbookingPercent: balance.OverbookingPercent,
+		SellLimit:          balance.SellLimit(),
+		Frozen:             balance.Frozen,
+		UpdatedAt:          balance.UpdatedAt,
+	}
+	for confirmationID, seats := range balance.Held {
+		if pending[confirmationID] {
+			snapshot.Held += seats
+		} else {
+			snapshot.Sold += seats
+		}
+	}
+	if left := snapshot.SellLimit - balance.Sold; left > 0 {
+		snapshot.Available = left
+	}
+	if over := balance.Sold - balance.Capacity; over > 0 {
+		snapshot.Oversold = over
+	}
+	return snapshot, nil
+}
+
+// Statement returns a flight's balance and ledger. The balance is nil when the flight has no ledger.
+func (s *SeatInventory) Statement(ctx context.Context, flightNumber, date string) (*models.InventoryBalance, []*models.InventoryEntry, error) {
+	if s.ledger == nil {
+		return nil, nil, fmt.Errorf("seat inventory is not supported by the storage backend")
+	}
+	flightNumber = strings.ToUpper(flightNumber)
+
+	balance, err := s.ledger.GetInventory(ctx, flightNumber, date)
+	if err != nil || balance == nil {
+		return nil, nil, err
+	}
+	entries, err := s.ledger.ListInventoryEntries(ctx, flightNumber, date)
+	if err != nil {
+		return nil, nil, err
+	}
+	return balance, entries, nil
+}
+
+// Reconcile replays a flight's ledger and compares it with the stored balance
+// and with the seats the flight's tickets occupy
+func (s *SeatInventory) Reconcile(ctx context.Context, flightNumber, date string) (*models.InventoryReconciliation, error) {
+	if s.ledger == nil {
+		return nil, fmt.Errorf("seat inventory is not supported by the storage backend")
+	}
+	flightNumber = strings.ToUpper(flightNumber)
+
+	departureDate, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q: %v", date, err)
+	}
+
+	balance, err := s.ledger.GetInventory(ctx, flightNumber, date)
+	if err != nil {
+		return nil, err
+	}
+	if balance == nil {
+		balance = models.NewInventoryBalance(flightNumber, date)
+	}
+	entries, err := s.ledger.ListInventoryEntries(ctx, flightNumber, date)
+	if err != nil {
+		return nil, err
+	}
+	tickets, err := SearchTickets(ctx, s.repository, models.TicketQuery{FlightNumber: flightNumber, DepartureDate: departureDate})
+	if err != nil {
+		return nil, err
+	}
+
+	replayed := ReplayInventory(flightNumber, date, entries)
+	result := &models.InventoryReconciliation{
+		FlightNumber:   flightNumber,
+		Date:           date,
+		Balance:        balance,
+		Replayed:       replayed,
+		LedgerMatches:  sameInventory(balance, replayed),
+		Discrepancies:  []models.InventoryDiscrepancy{},
+		TicketsChecked: len(tickets),
+	}
+
+	expected := make(map[string]int)
+	for _, ticket := range tickets {
+		expected[ticket.ConfirmationID] = heldSeats(ticket)
+	}
+	for confirmationID := range replayed.Held {
+		if _, ok := expected[confirmationID]; !ok {
+			expected[confirmationID] = 0
+		}
+	}
+	for confirmationID, seats := range expected {
+		if held := replayed.Held[confirmationID]; held != seats {
+			result.Discrepancies = append(result.Discrepancies, models.InventoryDiscrepancy{
+				ConfirmationID: confirmationID,
+				Held:           held,
+				Expected:       seats,
+			})
+		}
+	}
+	sort.Slice(result.Discrepancies, func(i, j int) bool {
+		return result.Discrepancies[i].ConfirmationID < result.Discrepancies[j].ConfirmationID
+	})
+
+	result.Reconciled = result.LedgerMatches && len(result.Discrepancies) == 0
+	return result, nil
+}
+
+// ReplayInventory rebuilds a flight's balance from its posted entries
+func ReplayInventory(flightNumber, date string, entries []*models.InventoryEntry) *models.InventoryBalance {
+	balance := models.NewInventoryBalance(flightNumber, date)
+	for _, entry := range entries {
+		if entry.Kind == models.InventoryLimits {
+			balance.OverbookingPercent, balance.Frozen = entry.OverbookingPercent, entry.Frozen
+		}
+		moveSeats(balance, entry.From, -entry.Seats)
+		moveSeats(balance, entry.To, entry.Seats)
+		balance.Entries++
+		balance.UpdatedAt = entry.CreatedAt
+	}
+	return balance
+}
+
+// inventoryKey identifies a flight's ledger
+func inventoryKey(flightNumber, date string) string {
+	return flightNumber + "_" + date
+}
+
+// postInventoryEntries applies entries in order to the balances of their
+// flights, keyed by inventoryKey. Backends load the balances, call it and
+// store the posted entries and balances in the same transaction.
+func postInventoryEntries(balances map[string]*models.InventoryBalance, entries []*models.InventoryEntry, now time.Time) ([]*models.InventoryEntry, error) {
+	var posted []*models.InventoryEntry
+	for _, requested := range entries {
+		// Work on a copy so a retried transaction starts from the requested entries
+		entry := *requested
+		key := inventoryKey(entry.FlightNumber, entry.Date)
+		balance, ok := balances[key]
+		if !ok || balance == nil {
+			balance = models.NewInventoryBalance(entry.FlightNumber, entry.Date)
+			balances[key] = balance
+		}
+
+		ok, err := applyInventory(balance, &entry, now)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			posted = append(posted, &entry)
+		}
+	}
+	return posted, nil
+}
+
+// applyInventory posts an entry to its flight's balance, filling in the
+// entry's ID, sequence and resulting availability. It reports false when the
+// entry is skipped: flights without a ledger only take adjustments, and a
+// ticket gives back at most the seats it holds (tickets booked before the
+// flight had a ledger hold none). Tickets may take seats up to the sell limit
+// unless sales are frozen; adjustments only take seats that are available
+// within the capacity.
+func applyInventory(balance *models.InventoryBalance, entry *models.InventoryEntry, now time.Time) (bool, error) {
+	if entry.Seats <= 0 && entry.Kind != models.InventoryLimits {
+		return false, fmt.Errorf("invalid inventory entry: %d seats", entry.Seats)
+	}
+	if balance.Entries == 0 && entry.Kind != models.InventoryAdjust {
+		return false, nil
+	}
+
+	selling := entry.From == models.AccountAvailable && entry.Kind != models.InventoryAdjust
+	available := balance.Available
+	if selling {
+		available += balance.SellLimit() - balance.Capacity
+	}
+	switch {
+	case entry.Kind == models.InventoryLimits:
+		balance.OverbookingPercent, balance.Frozen = entry.OverbookingPercent, entry.Frozen
+	case selling && balance.Frozen:
+		return false, fmt.Errorf("%w: %s on %s", ErrSalesFrozen, entry.FlightNumber, entry.Date)
+	case entry.From == models.AccountAvailable && available < entry.Seats:
+		return false, fmt.Errorf("%w: %s on %s has %d seats left", ErrInsufficientSeats, entry.FlightNumber, entry.Date, max(available, 0))
+	case strings.HasPrefix(entry.From, models.TicketAccountPrefix):
+		held := balance.Held[strings.TrimPrefix(entry.From, models.TicketAccountPrefix)]
+		if held == 0 {
+			return false, nil
+		}
+		if entry.Seats > held {
+			entry.Seats = held
+		}
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return false, fmt.Errorf("failed to generate inventory entry ID: %v", err)
+	}
+
+	moveSeats(balance, entry.From, -entry.Seats)
+	moveSeats(balance, entry.To, entry.Seats)
+	balance.Entries++
+	balance.UpdatedAt = now.UTC()
+
+	entry.ID = hex.EncodeToString(id)
+	entry.Sequence = balance.Entries
+	entry.AvailableAfter = balance.Available
+	entry.CreatedAt = balance.UpdatedAt
+	return true, nil
+}
+
+// moveSeats adds delta seats to an account of the balance
+func moveSeats(balance *models.InventoryBalance, account string, delta int) {
+	switch {
+	case account == models.AccountCapacity:
+		// Seats leaving the capacity account go on sale
+		balance.Capacity -= delta
+	case account == models.AccountAvailable:
+		balance.Available += delta
+	case strings.HasPrefix(account, models.TicketAccountPrefix):
+		confirmationID := strings.TrimPrefix(account, models.TicketAccountPrefix)
+		if balance.Held == nil {
+			balance.Held = map[string]int{}
+		}
+		balance.Held[confirmationID] += delta
+		if balance.Held[confirmationID] == 0 {
+			delete(balance.Held, confirmationID)
+		}
+		balance.Sold += delta
+	}
+}
+
+// inventoryMovements returns the entries that move a ticket's seats from its previous booking to its current one
+func inventoryMovements(previous, current *models.FlightTicket) []*models.InventoryEntry {
+	var ticket *models.FlightTicket
+	if current != nil {
+		ticket = current
+	} else if previous != nil {
+		ticket = previous
+	} else {
+		return nil
+	}
+	account := models.TicketAccount(ticket.ConfirmationID)
+
+	held, wanted := heldSeats(previous), heldSeats(current)
+	movement := func(kind string, from *models.FlightTicket, seats int, release bool) *models.InventoryEntry {
+		entry := &models.InventoryEntry{
+			FlightNumber:   strings.ToUpper(from.FlightNumber),
+			Date:           from.DepartureDate.Format("2006-01-02"),
+			Kind:           kind,
+			From:           models.AccountAvailable,
+			To:             account,
+			Seats:          seats,
+			ConfirmationID: ticket.ConfirmationID,
+		}
+		if release {
+			entry.From, entry.To = account, models.AccountAvailable
+		}
+		return entry
+	}
+
+	switch {
+	case held == 0 && wanted == 0:
+		return nil
+	case held == 0:
+		return []*models.InventoryEntry{movement(models.InventoryBook, current, wanted, false)}
+	case wanted == 0:
+		return []*models.InventoryEntry{movement(models.InventoryCancel, previous, held, true)}
+	case sameDeparture(previous, current):
+		if wanted > held {
+			return []*models.InventoryEntry{movement(models.InventoryRebook, current, wanted-held, false)}
+		}
+		if wanted < held {
+			return []*models.InventoryEntry{movement(models.InventoryRebook, previous, held-wanted, true)}
+		}
+		return nil
+	}
+	return []*models.InventoryEntry{
+		movement(models.InventoryRebook, previous, held, true),
+		movement(models.InventoryRebook, current, wanted, false),
+	}
+}
+
+// heldSeats returns the seats a ticket occupies: its passengers but infants, unless it is cancelled
+func heldSeats(ticket *models.FlightTicket) int {
+	if ticket == nil || ticket.Status == "CANCELLED" || ticket.FlightNumber == "" {
+		return 0
+	}
+	return ticket.Seats()
+}
+
+func sameDeparture(a, b *models.FlightTicket) bool {
+	return strings.EqualFold(a.FlightNumber, b.FlightNumber) && sameDay(a.DepartureDate, b.DepartureDate)
+}
+
+func sameInventory(a, b *models.InventoryBalance) bool {
+	if a.Capacity != b.Capacity || a.Available != b.Available || a.Sold != b.Sold || a.Entries != b.Entries || len(a.Held) != len(b.Held) ||
+		a.OverbookingPercent != b.OverbookingPercent || a.Frozen != b.Frozen {
+		return false
+	}
+	for confirmationID, seats := range a.Held {
+		if b.Held[confirmationID] != seats {
+			return false
+		}
+	}
+	return true
+}
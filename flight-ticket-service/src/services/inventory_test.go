@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+func inventoryTicket(confirmationID, flightNumber string, day, passengers int) *models.FlightTicket {
+	return &models.FlightTicket{
+		ConfirmationID: confirmationID,
+		FlightNumber:   flightNumber,
+		DepartureDate:  time.Date(2024, 12, day, 0, 0, 0, 0, time.UTC),
+		Passengers:     passengers,
+		Status:         StatusConfirmed,
+	}
+}
+
+func TestSeatInventoryBooksAgainstCapacity(t *testing.T) {
+	ctx := context.Background()
+	inventory := NewSeatInventory(NewMemoryRepository())
+
+	// Flights without a ledger are not counted
+	if err := inventory.Book(ctx, inventoryTicket("OLD001", "AA1234", 25, 2), "desk"); err != nil {
+		t.Fatalf("Unexpected error booking untracked flight: %v", err)
+	}
+
+	if _, err := inventory.Adjust(ctx, "aa1234", "2024-12-25", 3, "Opening sale", "admin"); err != nil {
+		t.Fatalf("Unexpected error adjusting: %v", err)
+	}
+	if err := inventory.Book(ctx, inventoryTicket("ABC123", "AA1234", 25, 2), "desk"); err != nil {
+		t.Fatalf("Unexpected error booking: %v", err)
+	}
+	if err := inventory.Book(ctx, inventoryTicket("DEF456", "AA1234", 25, 2), "desk"); !errors.Is(err, ErrInsufficientSeats) {
+		t.Fatalf("Expected ErrInsufficientSeats, got %v", err)
+	}
+	if _, err := inventory.Adjust(ctx, "AA1234", "2024-12-25", -2, "Blocked for crew", "admin"); !errors.Is(err, ErrInsufficientSeats) {
+		t.Fatalf("Expected sold seats to stay on sale, got %v", err)
+	}
+
+	// Cancelling the ticket booked before the ledger gives back nothing
+	if err := inventory.Release(ctx, inventoryTicket("OLD001", "AA1234", 25, 2), "desk"); err != nil {
+		t.Fatalf("Unexpected error releasing: %v", err)
+	}
+
+	balance, entries, err := inventory.Statement(ctx, "AA1234", "2024-12-25")
+	if err != nil {
+		t.Fatalf("Unexpected error getting statement: %v", err)
+	}
+	if balance.Capacity != 3 || balance.Available != 1 || balance.Sold != 2 || balance.Held["ABC123"] != 2 {
+		t.Errorf("Unexpected balance %+v", balance)
+	}
+	if len(entries) != 2 || entries[1].Kind != models.InventoryBook || entries[1].Sequence != 2 || entries[1].AvailableAfter != 1 {
+		t.Errorf("Unexpected entries %+v", entries)
+	}
+}
+
+func TestSeatInventoryCheckPostsNothing(t *testing.T) {
+	ctx := context.Background()
+	inventory := NewSeatInventory(NewMemoryRepository())
+	if _, err := inventory.Adjust(ctx, "AA1234", "2024-12-25", 3, "Opening sale", "admin"); err != nil {
+		t.Fatalf("Unexpected error adjusting: %v", err)
+	}
+
+	if err := inventory.Check(ctx, nil, inventoryTicket("ABC123", "AA1234", 25, 3)); err != nil {
+		t.Errorf("Unexpected error checking: %v", err)
+	}
+	if err := inventory.Check(ctx, nil, inventoryTicket("ABC123", "AA1234", 25, 4)); !errors.Is(err, ErrInsufficientSeats) {
+		t.Errorf("Expected ErrInsufficientSeats, got %v", err)
+	}
+
+	balance, entries, err := inventory.Statement(ctx, "AA1234", "2024-12-25")
+	if err != nil {
+		t.Fatalf("Unexpected error getting statement: %v", err)
+	}
+	if balance.Available != 3 || len(entries) != 1 {
+		t.Errorf("Expected nothing to be posted, got %+v with %d entries", balance, len(entries))
+	}
+}
+
+func TestSeatInventoryRebookIsAtomic(t *testing.T) {
+	ctx := context.Background()
+	inventory := NewSeatInventory(NewMemoryRepository())
+	for _, date := range []string{"2024-12-25", "2024-12-26"} {
+		if _, err := inventory.Adjust(ctx, "AA1234", date, 2, "Opening sale", "admin"); err != nil {
+			t.Fatalf("Unexpected error adjusting: %v", err)
+		}
+	}
+
+	booked := inventoryTicket("ABC123", "AA1234", 25, 2)
+	if err := inventory.Book(ctx, booked, "desk"); err != nil {
+		t.Fatalf("Unexpected error booking: %v", err)
+	}
+
+	// Moving to a flight that cannot take three passengers leaves both ledgers untouched
+	if err := inventory.Change(ctx, booked, inventoryTicket("ABC123", "AA1234", 26, 3), "desk"); !errors.Is(err, ErrInsufficientSeats) {
+		t.Fatalf("Expected ErrInsufficientSeats, got %v", err)
+	}
+	first, _, _ := inventory.Statement(ctx, "AA1234", "2024-12-25")
+	if first.Held["ABC123"] != 2 || first.Entries != 2 {
+		t.Errorf("Failed rebook changed the original flight: %+v", first)
+	}
+
+	rebooked := inventoryTicket("ABC123", "AA1234", 26, 2)
+	if err := inventory.Change(ctx, booked, rebooked, "desk"); err != nil {
+		t.Fatalf("Unexpected error rebooking: %v", err)
+	}
+	first, _, _ = inventory.Statement(ctx, "AA1234", "2024-12-25")
+	second, entries, _ := inventory.Statement(ctx, "AA1234", "2024-12-26")
+	if first.Available != 2 || first.Sold != 0 || second.Available != 0 || second.Held["ABC123"] != 2 {
+		t.Errorf("Unexpected balances after rebook: %+v, %+v", first, second)
+	}
+	if last := entries[len(entries)-1]; last.Kind != models.InventoryRebook || last.To != models.TicketAccount("ABC123") {
+		t.Errorf("Unexpected rebook entry %+v", last)
+	}
+
+	// Fewer passengers on the same flight give seats back
+	if err := inventory.Change(ctx, rebooked, inventoryTicket("ABC123", "AA1234", 26, 1), "desk"); err != nil {
+		t.Fatalf("Unexpected error reducing passengers: %v", err)
+	}
+	second, _, _ = inventory.Statement(ctx, "AA1234", "2024-12-26")
+	if second.Available != 1 || second.Sold != 1 {
+		t.Errorf("Unexpected balance after reducing passengers: %+v", second)
+	}
+}
+
+func TestSeatInventoryReconcile(t *testing.T) {
+	ctx := context.Background()
+	repository := NewMemoryRepository()
+	inventory := NewSeatInventory(repository)
+
+	early := inventoryTicket("OLD001", "AA1234", 25, 2)
+	if err := repository.CreateTicket(ctx, early); err != nil {
+		t.Fatalf("Unexpected error creating ticket: %v", err)
+	}
+	if _, err := inventory.Adjust(ctx, "AA1234", "2024-12-25", 10, "Opening sale", "admin"); err != nil {
+		t.Fatalf("Unexpected error adjusting: %v", err)
+	}
+	booked := inventoryTicket("ABC123", "AA1234", 25, 3)
+	if err := inventory.Book(ctx, booked, "desk"); err != nil {
+		t.Fatalf("Unexpected error booking: %v", err)
+	}
+	if err := repository.CreateTicket(ctx, booked); err != nil {
+		t.Fatalf("Unexpected error creating ticket: %v", err)
+	}
+
+	result, err := inventory.Reconcile(ctx, "AA1234", "2024-12-25")
+	if err != nil {
+		t.Fatalf("Unexpected error reconciling: %v", err)
+	}
+	if !result.LedgerMatches || result.Reconciled || result.TicketsChecked != 2 {
+		t.Errorf("Unexpected reconciliation %+v", result)
+	}
+	if len(result.Discrepancies) != 1 || result.Discrepancies[0] != (models.InventoryDiscrepancy{ConfirmationID: "OLD001", Held: 0, Expected: 2}) {
+		t.Errorf("Unexpected discrepancies %+v", result.Discrepancies)
+	}
+}
+
+func TestSeatInventoryLimits(t *testing.T) {
+	ctx := context.Background()
+	repository := NewMemoryRepository()
+	inventory := NewSeatInventory(repository)
+
+	if _, err := inventory.SetLimits(ctx, "AA1234", "2024-12-25", 10, false, "Overbook", "admin"); !errors.Is(err, ErrNoInventory) {
+		t.Fatalf("Expected ErrNoInventory before the ledger is opened, got %v", err)
+	}
+	if _, err := inventory.Adjust(ctx, "AA1234", "2024-12-25", 10, "Opening sale", "admin"); err != nil {
+		t.Fatalf("Unexpected error adjusting: %v", err)
+	}
+	if _, err := inventory.SetLimits(ctx, "AA1234", "2024-12-25", 20, false, "Overbook", "admin"); err != nil {
+		t.Fatalf("Unexpected error setting limits: %v", err)
+	}
+
+	// 20% of 10 seats may be sold beyond the capacity
+	if err := inventory.Book(ctx, inventoryTicket("ABC123", "AA1234", 25, 11), "desk"); err != nil {
+		t.Fatalf("Unexpected error overbooking: %v", err)
+	}
+	if err := inventory.Book(ctx, inventoryTicket("DEF456", "AA1234", 25, 2), "desk"); !errors.Is(err, ErrInsufficientSeats) {
+		t.Fatalf("Expected ErrInsufficientSeats beyond the sell limit, got %v", err)
+	}
+	pending := inventoryTicket("GHI789", "AA1234", 25, 1)
+	pending.Status = StatusPending
+	if err := inventory.Book(ctx, pending, "desk"); err != nil {
+		t.Fatalf("Unexpected error booking: %v", err)
+	}
+	for _, ticket := range []*models.FlightTicket{inventoryTicket("ABC123", "AA1234", 25, 11), pending} {
+		if err := repository.CreateTicket(ctx, ticket); err != nil {
+			t.Fatalf("Unexpected error creating ticket: %v", err)
+		}
+	}
+
+	snapshot, err := inventory.Snapshot(ctx, "AA1234", "2024-12-25")
+	if err != nil {
+		t.Fatalf("Unexpected error getting snapshot: %v", err)
+	}
+	want := models.InventorySnapshot{FlightNumber: "AA1234", Date: "2024-12-25", Capacity: 10, OverbookingPercent: 20, SellLimit: 12, Sold: 11, Held: 1, Available: 0, Oversold: 2, UpdatedAt: snapshot.UpdatedAt}
+	if *snapshot != want {
+		t.Errorf("Snapshot = %+v, want %+v", *snapshot, want)
+	}
+
+	// Frozen sales take no seats, but cancellations still give them back
+	if _, err := inventory.SetLimits(ctx, "AA1234", "2024-12-25", 20, true, "Aircraft swap", "admin"); err != nil {
+		t.Fatalf("Unexpected error freezing: %v", err)
+	}
+	if err := inventory.Release(ctx, pending, "desk"); err != nil {
+		t.Fatalf("Unexpected error releasing: %v", err)
+	}
+	if err := inventory.Book(ctx, inventoryTicket("JKL012", "AA1234", 25, 1), "desk"); !errors.Is(err, ErrSalesFrozen) {
+		t.Errorf("Expected ErrSalesFrozen, got %v", err)
+	}
+
+	result, err := inventory.Reconcile(ctx, "AA1234", "2024-12-25")
+	if err != nil {
+		t.Fatalf("Unexpected error reconciling: %v", err)
+	}
+	if !result.LedgerMatches || !result.Replayed.Frozen || result.Replayed.OverbookingPercent != 20 {
+		t.Errorf("Expected the limits replayed from the ledger, got %+v", result.Replayed)
+	}
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+// Ticket statuses used by the batch jobs
+const (
+	StatusConfirmed = "CONFIRMED"
+	StatusCheckedIn = "CHECKED_IN"
+	StatusPending   = "PENDING"
+)
+
+// CleanupResult summarizes a cleanup run
+type CleanupResult struct {
+	Scanned   int  `json:"scanned"`
+	Cancelled int  `json:"cancelled"`
+	Failed    int  `json:"failed"`
+	DryRun    bool `json:"dry_run,omitempty"`
+}
+
+// ReminderResult summarizes a reminder run
+type ReminderResult struct {
+	Scanned     int       `json:"scanned"`
+	Sent        int       `json:"sent"`
+	Skipped     int       `json:"skipped"` // notifications turned off for the ticket
+	Failed      int       `json:"failed"`
+	WindowStart time.Time `json:"window_start"`
+	WindowEnd   time.Time `json:"window_end"`
+}
+
+// Reminder is sent for a confirmed ticket shortly before departure
+type Reminder struct {
+	ConfirmationID string    `json:"confirmation_id"`
+	FlightNumber   string    `json:"flight_number"`
+	Origin         string    `json:"origin"`
+	Destination    string    `json:"destination"`
+	DepartureTime  time.Time `json:"departure_time"`
+	Passengers     int       `json:"passengers"`
+	Tenant         string    `json:"-"` // tenant that booked the ticket, for its notification template
+}
+
+// ReminderSender delivers departure reminders
+type ReminderSender interface {
+	SendReminder(ctx context.Context, reminder Reminder) error
+}
+
+// TicketJobs runs the batch workloads over the ticket repository
+type TicketJobs struct {
+	repository TicketRepository
+	inventory  *SeatInventory
+	seats      SeatAssignments
+	now        func() time.Time
+}
+
+// NewTicketJobs creates the batch jobs for a repository
+func NewTicketJobs(repository TicketRepository) *TicketJobs {
+	return &TicketJobs{repository: repository, inventory: NewSeatInventory(repository), now: time.Now}
+}
+
+// WithSeats frees the seats assigned to the tickets the jobs cancel
+func (j *TicketJobs) WithSeats(seats SeatAssignments) *TicketJobs {
+	j.seats = seats
+	return j
+}
+
+// unassignSeats frees the seats assigned to a cancelled ticket, logging failures
+func (j *TicketJobs) unassignSeats(ctx context.Context, ticket *models.FlightTicket) {
+	if j.seats == nil {
+		return
+	}
+	if err := UnassignTicketSeats(ctx, j.seats, ticket); err != nil {
+		log.Printf("Failed to free seats of ticket %s: %v", ticket.ConfirmationID, err)
+	}
+}
+
+// CleanupPending cancels PENDING tickets created more than maxAge ago or whose
+// departure has passed. With dryRun, tickets are counted but not changed.
+func (j *TicketJobs) CleanupPending(ctx context.Context, maxAge time.Duration, dryRun bool) (*CleanupResult, error) {
+	tickets, err := j.repository.ListTickets(ctx, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tickets: %v", err)
+	}
+
+	now := j.now()
+	result := &CleanupResult{Scanned: len(tickets), DryRun: dryRun}
+	for _, ticket := range tickets {
+		if ticket.Status != StatusPending {
+			continue
+		}
+		if now.Sub(ticket.CreatedAt) < maxAge && ticket.DepartureTime.After(now) {
+			continue
+		}
+
+		if dryRun {
+			log.Printf("Would cancel stale pending ticket %s", ticket.ConfirmationID)
+			result.Cancelled++
+			continue
+		}
+		if err := j.repository.DeleteTicket(ctx, ticket.ConfirmationID); err != nil {
+			log.Printf("Failed to cancel stale pending ticket %s: %v", ticket.ConfirmationID, err)
+			result.Failed++
+			continue
+		}
+		if err := j.inventory.Release(ctx, ticket, "cleanup"); err != nil {
+			log.Printf("Failed to release seats of stale pending ticket %s: %v", ticket.ConfirmationID, err)
+		}
+		j.unassignSeats(ctx, ticket)
+		log.Printf("Cancelled stale pending ticket %s", ticket.ConfirmationID)
+		result.Cancelled++
+	}
+
+	return result, nil
+}
+
+// SendReminders sends a reminder for each confirmed ticket departing within
+// the window-aligned slot containing now+lead, e.g. with a 1h window a run at
+// 10:05 covers departures from 10:00 to 11:00 tomorrow. Running the job once
+// per window covers each departure once.
+func (j *TicketJobs) SendReminders(ctx context.Context, lead, window time.Duration, sender ReminderSender) (*ReminderResult, error) {
+	if window <= 0 {
+		return nil, fmt.Errorf("reminder window must be positive")
+	}
+
+	tickets, err := j.repository.ListTickets(ctx, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tickets: %v", err)
+	}
+
+	start := j.now().Add(lead).Truncate(window)
+	result := &ReminderResult{Scanned: len(tickets), WindowStart: start, WindowEnd: start.Add(window)}
+	for _, ticket := range tickets {
+		if (ticket.Status != StatusConfirmed && ticket.Status != StatusCheckedIn) || ticket.DepartureTime.Before(result.WindowStart) || !ticket.DepartureTime.Before(result.WindowEnd) {
+			continue
+		}
+
+		err := sender.SendReminder(ctx, newReminder(ticket))
+		if errors.Is(err, ErrNotificationsDisabled) {
+			result.Skipped++
+			continue
+		}
+		if err != nil {
+			log.Printf("Failed to send reminder for ticket %s: %v", ticket.ConfirmationID, err)
+			result.Failed++
+			continue
+		}
+		result.Sent++
+	}
+
+	return result, nil
+}
+
+func newReminder(ticket *models.FlightTicket) Reminder {
+	return Reminder{
+		ConfirmationID: ticket.ConfirmationID,
+		FlightNumber:   ticket.FlightNumber,
+		Origin:         ticket.Origin,
+		Destination:    ticket.Destination,
+		DepartureTime:  ticket.DepartureTime,
+		Passengers:     ticket.Passengers,
+		Tenant:         ticket.Labels[models.TenantLabel],
+	}
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+type jobsRepository struct {
+	TicketRepository
+	tickets   []*models.FlightTicket
+	cancelled []string
+}
+
+func (r *jobsRepository) ListTickets(ctx context.Context, limit int) ([]*models.FlightTicket, error) {
+	return r.tickets, nil
+}
+
+func (r *jobsRepository) DeleteTicket(ctx context.Context, confirmationID string) error {
+	r.cancelled = append(r.cancelled, confirmationID)
+	return nil
+}
+
+type recordingSender struct {
+	sent []string
+}
+
+func (s *recordingSender) SendReminder(ctx context.Context, reminder Reminder) error {
+	s.sent = append(s.sent, reminder.ConfirmationID)
+	return nil
+}
+
+func TestCleanupPendingCancelsStaleTickets(t *testing.T) {
+	now := time.Date(2024, 7, 12, 10, 0, 0, 0, time.UTC)
+	repo := &jobsRepository{tickets: []*models.FlightTicket{
+		{ConfirmationID: "OLD001", Status: StatusPending, CreatedAt: now.Add(-48 * time.Hour), DepartureTime: now.Add(72 * time.Hour)},
+		{ConfirmationID: "NEW001", Status: StatusPending, CreatedAt: now.Add(-time.Hour), DepartureTime: now.Add(72 * time.Hour)},
+		{ConfirmationID: "GONE01", Status: StatusPending, CreatedAt: now.Add(-time.Hour), DepartureTime: now.Add(-time.Minute)},
+		{ConfirmationID: "CONF01", Status: StatusConfirmed, CreatedAt: now.Add(-48 * time.Hour), DepartureTime: now.Add(-time.Hour)},
+	}}
+	jobs := NewTicketJobs(repo)
+	jobs.now = func() time.Time { return now }
+
+	result, err := jobs.CleanupPending(context.Background(), 24*time.Hour, true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Cancelled != 2 || len(repo.cancelled) != 0 {
+		t.Errorf("Dry run: got %+v, cancelled %v", result, repo.cancelled)
+	}
+
+	result, err = jobs.CleanupPending(context.Background(), 24*time.Hour, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Cancelled != 2 || len(repo.cancelled) != 2 || repo.cancelled[0] != "OLD001" || repo.cancelled[1] != "GONE01" {
+		t.Errorf("Got %+v, cancelled %v", result, repo.cancelled)
+	}
+}
+
+type recordingSeats struct {
+	freed map[string][]string
+}
+
+func (s *recordingSeats) Unassign(ctx context.Context, flightNumber, date, confirmationID string, seats []string) error {
+	s.freed[confirmationID] = append(s.freed[confirmationID], flightNumber+"/"+date+"/"+strings.Join(seats, ","))
+	return nil
+}
+
+func TestCancellingJobsFreeAssignedSeats(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 7, 12, 10, 0, 0, 0, time.UTC)
+	date := time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC)
+	seats := &recordingSeats{freed: make(map[string][]string)}
+
+	repo := NewMemoryRepository()
+	for _, ticket := range []*models.FlightTicket{
+		{ConfirmationID: "SEAT01", FlightNumber: "AA1234", Status: StatusConfirmed, DepartureDate: date, Passengers: 2, Labels: map[string]string{"seat_1": "12a", "seat_2": "12b"}},
+		{ConfirmationID: "NOSEAT", FlightNumber: "AA1234", Status: StatusConfirmed, DepartureDate: date, Passengers: 1},
+	} {
+		repo.CreateTicket(ctx, ticket)
+	}
+	result, err := NewTicketJobs(repo).WithSeats(seats).BulkCancel(ctx, models.TicketQuery{FlightNumber: "AA1234"}, nil, "test", nil, func(done, total int) {})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Cancelled != 2 || len(seats.freed) != 1 || seats.freed["SEAT01"][0] != "AA1234/2024-12-25/12A,12B" {
+		t.Errorf("Got %+v, freed %v", result, seats.freed)
+	}
+
+	stale := &jobsRepository{tickets: []*models.FlightTicket{
+		{ConfirmationID: "OLD001", FlightNumber: "UA0001", Status: StatusPending, CreatedAt: now.Add(-48 * time.Hour), DepartureDate: date, DepartureTime: now.Add(72 * time.Hour), Labels: map[string]string{"seat_1": "3c"}},
+	}}
+	jobs := NewTicketJobs(stale).WithSeats(seats)
+	jobs.now = func() time.Time { return now }
+	if _, err := jobs.CleanupPending(ctx, 24*time.Hour, false); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if freed := seats.freed["OLD001"]; len(freed) != 1 || freed[0] != "UA0001/2024-12-25/3C" {
+		t.Errorf("Expected the seat of the stale ticket freed, got %v", freed)
+	}
+}
+
+func TestSendRemindersCoversAlignedWindow(t *testing.T) {
+	now := time.Date(2024, 7, 12, 10, 5, 0, 0, time.UTC)
+	tomorrow := time.Date(2024, 7, 13, 10, 0, 0, 0, time.UTC)
+	repo := &jobsRepository{tickets: []*models.FlightTicket{
+		{ConfirmationID: "START1", Status: StatusConfirmed, DepartureTime: tomorrow},
+		{ConfirmationID: "INSIDE", Status: StatusConfirmed, DepartureTime: tomorrow.Add(59 * time.Minute)},
+		{ConfirmationID: "END001", Status: StatusConfirmed, DepartureTime: tomorrow.Add(time.Hour)},
+		{ConfirmationID: "EARLY1", Status: StatusConfirmed, DepartureTime: tomorrow.Add(-time.Minute)},
+		{ConfirmationID: "CANCEL", Status: "CANCELLED", DepartureTime: tomorrow.Add(30 * time.Minute)},
+	}}
+	jobs := NewTicketJobs(repo)
+	jobs.now = func() time.Time { return now }
+	sender := &recordingSender{}
+
+	result, err := jobs.SendReminders(context.Background(), 24*time.Hour, time.Hour, sender)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !result.WindowStart.Equal(tomorrow) || !result.WindowEnd.Equal(tomorrow.Add(time.Hour)) {
+		t.Errorf("Unexpected window %s - %s", result.WindowStart, result.WindowEnd)
+	}
+	if result.Sent != 2 || len(sender.sent) != 2 || sender.sent[0] != "START1" || sender.sent[1] != "INSIDE" {
+		t.Errorf("Got %+v, sent %v", result, sender.sent)
+	}
+}
+
+func TestDelayFlightMovesDepartures(t *testing.T) {
+	ctx := context.Background()
+	date := time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC)
+	departure := time.Date(2024, 12, 25, 22, 30, 0, 0, time.UTC)
+
+	repo := NewMemoryRepository()
+	for _, ticket := range []*models.FlightTicket{
+		{ConfirmationID: "DLY001", FlightNumber: "AA1234", Status: StatusConfirmed, DepartureDate: date, DepartureTime: departure},
+		{ConfirmationID: "DLY002", FlightNumber: "AA1234", Status: StatusPending, DepartureDate: date, DepartureTime: departure},
+		{ConfirmationID: "CXL001", FlightNumber: "AA1234", Status: "CANCELLED", DepartureDate: date, DepartureTime: departure},
+		{ConfirmationID: "NXT001", FlightNumber: "AA1234", Status: StatusConfirmed, DepartureDate: date.AddDate(0, 0, 1), DepartureTime: departure.AddDate(0, 0, 1)},
+		{ConfirmationID: "OTH001", FlightNumber: "UA0001", Status: StatusConfirmed, DepartureDate: date, DepartureTime: departure},
+	} {
+		repo.CreateTicket(ctx, ticket)
+	}
+
+	result, err := NewTicketJobs(repo).DelayFlight(ctx, "AA1234", date, 2*time.Hour, false)
+	if err != nil {
+		t.Fatalf("DelayFlight failed: %v", err)
+	}
+	if result.Scanned != 5 || result.Failed != 0 || len(result.Delayed) != 2 {
+		t.Fatalf("Unexpected result %+v", result)
+	}
+
+	for _, delayed := range result.Delayed {
+		// Slipping past midnight keeps the scheduled date
+		want := time.Date(2024, 12, 26, 0, 30, 0, 0, time.UTC)
+		if !delayed.Previous.DepartureTime.Equal(departure) || !delayed.Current.DepartureTime.Equal(want) || !delayed.Current.DepartureDate.Equal(date) {
+			t.Errorf("Ticket %s moved from %v to %v on %v", delayed.Current.ConfirmationID, delayed.Previous.DepartureTime, delayed.Current.DepartureTime, delayed.Current.DepartureDate)
+		}
+	}
+
+	untouched, _ := repo.GetTicket(ctx, "CXL001")
+	if !untouched.DepartureTime.Equal(departure) {
+		t.Errorf("Cancelled ticket was delayed to %v", untouched.DepartureTime)
+	}
+}
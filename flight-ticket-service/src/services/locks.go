@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+// Lock errors
+var (
+	// ErrTicketLocked is returned when another holder's lock is in force
+	ErrTicketLocked = errors.New("ticket is locked")
+	// ErrStaleLockToken is returned for a token of a lock that expired, was
+	// released or was replaced, so a retried or replayed request cannot write
+	// under a lock its sender no longer holds
+	ErrStaleLockToken = errors.New("lock token is not current")
+)
+
+// TicketLockStore is implemented by storage backends that can hold edit locks on tickets
+type TicketLockStore interface {
+	// GetLock returns the lock of a ticket, or nil when it has none; the lock may have expired
+	GetLock(ctx context.Context, confirmationID string) (*models.TicketLock, error)
+	// UpdateLock reads the lock of a ticket (nil when it has none), applies
+	// change and stores the lock it returns in one transaction; a nil lock
+	// removes it. An error returned by change aborts the update and is returned as is.
+	UpdateLock(ctx context.Context, confirmationID string, change func(*models.TicketLock) (*models.TicketLock, error)) (*models.TicketLock, error)
+}
+
+// AcquireLock takes the edit lock of a ticket for ttl. Sending the token of
+// the lock in force renews it, and renewed reports that it was. A new lock
+// gets a new random token.
+func AcquireLock(ctx context.Context, store TicketLockStore, confirmationID, holder, token string, ttl time.Duration) (lock *models.TicketLock, renewed bool, err error) {
+	now := time.Now().UTC()
+	lock, err = store.UpdateLock(ctx, confirmationID, func(current *models.TicketLock) (*models.TicketLock, error) {
+		if err := checkLock(current, token, now); err != nil {
+			return nil, err
+		}
+		if token != "" {
+			renewed = true
+			extended := *current
+			extended.ExpiresAt = now.Add(ttl)
+			return &extended, nil
+		}
+
+		secret := make([]byte, 16)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("failed to generate lock token: %v", err)
+		}
+		return &models.TicketLock{
+			ConfirmationID: confirmationID,
+			Token:          hex.EncodeToString(secret),
+			Holder:         holder,
+			AcquiredAt:     now,
+			ExpiresAt:      now.Add(ttl),
+		}, nil
+	})
+	return lock, renewed, err
+}
+
+// ReleaseLock removes the lock whose token is given
+func ReleaseLock(ctx context.Context, store TicketLockStore, confirmationID, token string) error {
+	now := time.Now().UTC()
+	_, err := store.UpdateLock(ctx, confirmationID, func(current *models.TicketLock) (*models.TicketLock, error) {
+		if token == "" {
+			return nil, ErrStaleLockToken
+		}
+		if err := checkLock(current, token, now); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	})
+	return err
+}
+
+// CheckLock checks that a write to a ticket may go ahead: the ticket is not
+// locked, or the token is that of its lock. The check is not part of the
+// write's transaction; locks keep cooperating agents apart, they do not
+// serialize writes. Backends without locks accept every write.
+func CheckLock(ctx context.Context, repository TicketRepository, confirmationID, token string) error {
+	store, ok := Capability[TicketLockStore](repository)
+	if !ok {
+		return nil
+	}
+	lock, err := store.GetLock(ctx, confirmationID)
+	if err != nil {
+		return err
+	}
+	return checkLock(lock, token, time.Now())
+}
+
+// checkLock compares a token with a ticket's lock at a given time
+func checkLock(lock *models.TicketLock, token string, now time.Time) error {
+	held := lock != nil && now.Before(lock.ExpiresAt)
+	switch {
+	case held && token == lock.Token:
+		return nil
+	case held:
+		return fmt.Errorf("%w by %s until %s", ErrTicketLocked, lockHolder(lock), lock.ExpiresAt.Format(time.RFC3339))
+	case token != "":
+		return ErrStaleLockToken
+	}
+	return nil
+}
+
+// lockHolder names the holder of a lock in errors
+func lockHolder(lock *models.TicketLock) string {
+	if lock.Holder == "" {
+		return "an anonymous caller"
+	}
+	return lock.Holder
+}
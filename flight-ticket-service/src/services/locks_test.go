@@ -0,0 +1,35 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+func TestCheckLockExpiry(t *testing.T) {
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	lock := &models.TicketLock{Token: "secret", Holder: "desk", ExpiresAt: now.Add(time.Minute)}
+
+	if err := checkLock(lock, "secret", now); err != nil {
+		t.Errorf("Expected the holder to pass, got %v", err)
+	}
+	if err := checkLock(lock, "", now); !errors.Is(err, ErrTicketLocked) {
+		t.Errorf("Expected ErrTicketLocked, got %v", err)
+	}
+	if err := checkLock(lock, "other", now); !errors.Is(err, ErrTicketLocked) {
+		t.Errorf("Expected ErrTicketLocked for another token, got %v", err)
+	}
+
+	expired := now.Add(time.Minute)
+	if err := checkLock(lock, "", expired); err != nil {
+		t.Errorf("Expected an expired lock not to refuse writes, got %v", err)
+	}
+	if err := checkLock(lock, "secret", expired); !errors.Is(err, ErrStaleLockToken) {
+		t.Errorf("Expected the token of an expired lock to be stale, got %v", err)
+	}
+	if err := checkLock(nil, "", now); err != nil {
+		t.Errorf("Expected an unlocked ticket to pass, got %v", err)
+	}
+}
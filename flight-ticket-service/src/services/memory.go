@@ -0,0 +1,519 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+// MemoryRepository keeps tickets in memory. Data is lost on restart; it is
+// meant for tests and throwaway local runs.
+type MemoryRepository struct {
+	mu          sync.RWMutex
+	tickets     map[string]*models.FlightTicket
+	preferences map[string]*models.NotificationPreferences
+	notes       map[string][]*models.TicketNote
+	views       map[string]*models.SavedView
+	inventory   map[string]*models.InventoryBalance
+	ledger      map[string][]*models.InventoryEntry
+	bookings    map[string]map[string]int64
+	totalBooked int64
+	locks       map[string]*models.TicketLock
+	history     map[string][]*models.TicketRevision
+	revisions   int
+}
+
+// NewMemoryRepository creates an empty in-memory repository
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		tickets:     make(map[string]*models.FlightTicket),
+		preferences: make(map[string]*models.NotificationPreferences),
+		notes:       make(map[string][]*models.TicketNote),
+		views:       make(map[string]*models.SavedView),
+		inventory:   make(map[string]*models.InventoryBalance),
+		ledger:      make(map[string][]*models.InventoryEntry),
+		bookings:    make(map[string]map[string]int64),
+		locks:       make(map[string]*models.TicketLock),
+		history:     make(map[string][]*models.TicketRevision),
+	}
+}
+
+// CreateTicket stores a copy of the ticket
+func (mr *MemoryRepository) CreateTicket(ctx context.Context, ticket *models.FlightTicket) error {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	if _, exists := mr.tickets[ticket.ConfirmationID]; exists {
+		return fmt.Errorf("failed to create ticket: %s already exists", ticket.ConfirmationID)
+	}
+	mr.tickets[ticket.ConfirmationID] = copyTicket(ticket)
+	mr.recordWrite(models.RevisionCreated, nil, ticket, nil)
+	return nil
+}
+
+// GetTicket returns a copy of the ticket
+func (mr *MemoryRepository) GetTicket(ctx context.Context, confirmationID string) (*models.FlightTicket, error) {
+	mr.mu.RLock()
+	defer mr.mu.RUnlock()
+
+	ticket, ok := mr.tickets[confirmationID]
+	if !ok {
+		return nil, fmt.Errorf("failed to get ticket: %s not found", confirmationID)
+	}
+	return copyTicket(ticket), nil
+}
+
+// UpdateTicket applies field updates keyed by storage field name
+func (mr *MemoryRepository) UpdateTicket(ctx context.Context, confirmationID string, updates map[string]interface{}) error {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	stored, ok := mr.tickets[confirmationID]
+	if !ok {
+		return fmt.Errorf("failed to update ticket: %s not found", confirmationID)
+	}
+
+	// Apply to a copy so a bad field leaves the ticket unchanged
+	ticket := copyTicket(stored)
+	for field, value := range updates {
+		var ok bool
+		switch field {
+		case "origin":
+			ticket.Origin, ok = value.(string)
+		case "destination":
+			ticket.Destination, ok = value.(string)
+		case "departure_date":
+			ticket.DepartureDate, ok = value.(time.Time)
+		case "departure_time":
+			ticket.DepartureTime, ok = value.(time.Time)
+		case "flight_number":
+			ticket.FlightNumber, ok = value.(string)
+		case "passengers":
+			ticket.Passengers, ok = value.(int)
+		case "status":
+			ticket.Status, ok = value.(string)
+		case "price":
+			ticket.Price, ok = value.(*models.Price)
+		case "labels":
+			ticket.Labels, ok = value.(map[string]string)
+			ticket.Labels = copyLabels(ticket.Labels)
+		case "check_in":
+			ticket.CheckIn, ok = value.(*models.CheckInRecord)
+			ticket.CheckIn = copyCheckIn(ticket.CheckIn)
+		case "updated_at":
+			ticket.UpdatedAt, ok = value.(time.Time)
+		default:
+			return fmt.Errorf("failed to update ticket: unknown field %s", field)
+		}
+		if !ok {
+			return fmt.Errorf("failed to update ticket: %s: unsupported value type %T", field, value)
+		}
+	}
+	if _, set := updates["updated_at"]; !set {
+		ticket.UpdatedAt = time.Now()
+	}
+
+	mr.tickets[confirmationID] = ticket
+	fields := make([]string, 0, len(updates))
+	for field := range updates {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	mr.recordWrite(models.RevisionUpdated, stored, ticket, fields)
+	return nil
+}
+
+// DeleteTicket marks the ticket as cancelled
+func (mr *MemoryRepository) DeleteTicket(ctx context.Context, confirmationID string) error {
+	return mr.UpdateTicket(ctx, confirmationID, map[string]interface{}{"status": "CANCELLED"})
+}
+
+// ListTickets returns tickets newest first
+func (mr *MemoryRepository) ListTickets(ctx context.Context, limit int) ([]*models.FlightTicket, error) {
+	mr.mu.RLock()
+	defer mr.mu.RUnlock()
+
+	tickets := make([]*models.FlightTicket, 0, len(mr.tickets))
+	for _, ticket := range mr.tickets {
+		tickets = append(tickets, copyTicket(ticket))
+	}
+	sort.Slice(tickets, func(i, j int) bool {
+		return tickets[i].CreatedAt.After(tickets[j].CreatedAt)
+	})
+	if limit > 0 && len(tickets) > limit {
+		tickets = tickets[:limit]
+	}
+	return tickets, nil
+}
+
+// SearchTickets returns the tickets matching the query, newest first
+func (mr *MemoryRepository) SearchTickets(ctx context.Context, query models.TicketQuery) ([]*models.FlightTicket, error) {
+	mr.mu.RLock()
+	defer mr.mu.RUnlock()
+
+	var matches []*models.FlightTicket
+	for _, ticket := range mr.tickets {
+		if query.Matches(ticket) {
+			matches = append(matches, copyTicket(ticket))
+		}
+	}
+	return filterTickets(matches, query), nil
+}
+
+// GetNotificationPreferences returns a copy of the ticket's preferences, or nil
+func (mr *MemoryRepository) GetNotificationPreferences(ctx context.Context, confirmationID string) (*models.NotificationPreferences, error) {
+	mr.mu.RLock()
+	defer mr.mu.RUnlock()
+
+	preferences, ok := mr.preferences[confirmationID]
+	if !ok {
+		return nil, nil
+	}
+	return copyPreferences(preferences), nil
+}
+
+// SaveNotificationPreferences stores a copy of the preferences
+func (mr *MemoryRepository) SaveNotificationPreferences(ctx context.Context, preferences *models.NotificationPreferences) error {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	mr.preferences[preferences.ConfirmationID] = copyPreferences(preferences)
+	return nil
+}
+
+// CreateNote stores a copy of the note
+func (mr *MemoryRepository) CreateNote(ctx context.Context, note *models.TicketNote) error {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	copied := *note
+	mr.notes[note.ConfirmationID] = append(mr.notes[note.ConfirmationID], &copied)
+	return nil
+}
+
+// ListNotes returns copies of the ticket's notes in the order they were added
+func (mr *MemoryRepository) ListNotes(ctx context.Context, confirmationID string) ([]*models.TicketNote, error) {
+	mr.mu.RLock()
+	defer mr.mu.RUnlock()
+
+	var notes []*models.TicketNote
+	for _, note := range mr.notes[confirmationID] {
+		copied := *note
+		notes = append(notes, &copied)
+	}
+	return notes, nil
+}
+
+// GetView returns a copy of the view, or nil
+func (mr *MemoryRepository) GetView(ctx context.Context, name string) (*models.SavedView, error) {
+	mr.mu.RLock()
+	defer mr.mu.RUnlock()
+
+	view, ok := mr.views[name]
+	if !ok {
+		return nil, nil
+	}
+	return copyView(view), nil
+}
+
+// SaveView stores a copy of the view
+func (mr *MemoryRepository) SaveView(ctx context.Context, view *models.SavedView) error {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	mr.views[view.Name] = copyView(view)
+	return nil
+}
+
+// ListViews returns copies of every view, ordered by name
+func (mr *MemoryRepository) ListViews(ctx context.Context) ([]*models.SavedView, error) {
+	mr.mu.RLock()
+	defer mr.mu.RUnlock()
+
+	views := make([]*models.SavedView, 0, len(mr.views))
+	for _, view := range mr.views {
+		views = append(views, copyView(view))
+	}
+	sort.Slice(views, func(i, j int) bool {
+		return views[i].Name < views[j].Name
+	})
+	return views, nil
+}
+
+// DeleteView removes the view
+func (mr *MemoryRepository) DeleteView(ctx context.Context, name string) error {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	delete(mr.views, name)
+	return nil
+}
+
+// PostInventory applies the entries to copies of the balances and stores them only if every entry succeeds
+func (mr *MemoryRepository) PostInventory(ctx context.Context, entries []*models.InventoryEntry) ([]*models.InventoryEntry, error) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	balances := make(map[string]*models.InventoryBalance)
+	for _, entry := range entries {
+		key := inventoryKey(entry.FlightNumber, entry.Date)
+		if balance, ok := mr.inventory[key]; ok {
+			balances[key] = copyInventory(balance)
+		}
+	}
+
+	posted, err := postInventoryEntries(balances, entries, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	for key, balance := range balances {
+		if balance.Entries > 0 {
+			mr.inventory[key] = balance
+		}
+	}
+	for _, entry := range posted {
+		key := inventoryKey(entry.FlightNumber, entry.Date)
+		copied := *entry
+		mr.ledger[key] = append(mr.ledger[key], &copied)
+	}
+	return posted, nil
+}
+
+// GetInventory returns a copy of the flight's balance, or nil
+func (mr *MemoryRepository) GetInventory(ctx context.Context, flightNumber, date string) (*models.InventoryBalance, error) {
+	mr.mu.RLock()
+	defer mr.mu.RUnlock()
+
+	balance, ok := mr.inventory[inventoryKey(flightNumber, date)]
+	if !ok {
+		return nil, nil
+	}
+	return copyInventory(balance), nil
+}
+
+// ListInventoryEntries returns copies of the flight's ledger entries in the order they were posted
+func (mr *MemoryRepository) ListInventoryEntries(ctx context.Context, flightNumber, date string) ([]*models.InventoryEntry, error) {
+	mr.mu.RLock()
+	defer mr.mu.RUnlock()
+
+	var entries []*models.InventoryEntry
+	for _, entry := range mr.ledger[inventoryKey(flightNumber, date)] {
+		copied := *entry
+		entries = append(entries, &copied)
+	}
+	return entries, nil
+}
+
+// CountBooking increments the global counter and the route's counter for the day
+func (mr *MemoryRepository) CountBooking(ctx context.Context, route, day string) error {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	if mr.bookings[day] == nil {
+		mr.bookings[day] = make(map[string]int64)
+	}
+	mr.bookings[day][route]++
+	mr.totalBooked++
+	return nil
+}
+
+// GetBookingCounts returns copies of the counters of the days
+func (mr *MemoryRepository) GetBookingCounts(ctx context.Context, days []string) (int64, map[string]map[string]int64, error) {
+	mr.mu.RLock()
+	defer mr.mu.RUnlock()
+
+	counts := make(map[string]map[string]int64)
+	for _, day := range days {
+		if routes, ok := mr.bookings[day]; ok {
+			counts[day] = make(map[string]int64, len(routes))
+			for route, count := range routes {
+				counts[day][route] = count
+			}
+		}
+	}
+	return mr.totalBooked, counts, nil
+}
+
+// GetLock returns a copy of the ticket's lock
+func (mr *MemoryRepository) GetLock(ctx context.Context, confirmationID string) (*models.TicketLock, error) {
+	mr.mu.RLock()
+	defer mr.mu.RUnlock()
+
+	lock, ok := mr.locks[confirmationID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *lock
+	return &copied, nil
+}
+
+// UpdateLock applies change to the ticket's lock while holding the write lock
+func (mr *MemoryRepository) UpdateLock(ctx context.Context, confirmationID string, change func(*models.TicketLock) (*models.TicketLock, error)) (*models.TicketLock, error) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	var current *models.TicketLock
+	if lock, ok := mr.locks[confirmationID]; ok {
+		copied := *lock
+		current = &copied
+	}
+	updated, err := change(current)
+	if err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		delete(mr.locks, confirmationID)
+		return nil, nil
+	}
+	stored := *updated
+	mr.locks[confirmationID] = &stored
+	return updated, nil
+}
+
+// recordWrite keeps a revision of a ticket written through the repository;
+// with no change feed, the memory backend records its own history. The
+// caller holds the write lock.
+func (mr *MemoryRepository) recordWrite(kind string, previous, ticket *models.FlightTicket, fields []string) {
+	mr.revisions++
+	revision := &models.TicketRevision{
+		ID:             fmt.Sprintf("memory-%d", mr.revisions),
+		ConfirmationID: ticket.ConfirmationID,
+		Type:           kind,
+		Time:           time.Now().UTC(),
+		ChangedFields:  fields,
+		Ticket:         copyTicket(ticket),
+	}
+	if previous != nil {
+		revision.Previous = copyTicket(previous)
+	}
+	mr.history[ticket.ConfirmationID] = append(mr.history[ticket.ConfirmationID], revision)
+}
+
+// RecordRevision stores a copy of the revision, replacing one with the same ID
+func (mr *MemoryRepository) RecordRevision(ctx context.Context, revision *models.TicketRevision) error {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	copied := copyRevision(revision)
+	revisions := mr.history[revision.ConfirmationID]
+	for i, stored := range revisions {
+		if stored.ID == revision.ID {
+			revisions[i] = copied
+			return nil
+		}
+	}
+	revisions = append(revisions, copied)
+	sort.SliceStable(revisions, func(i, j int) bool { return revisions[i].Time.Before(revisions[j].Time) })
+	mr.history[revision.ConfirmationID] = revisions
+	return nil
+}
+
+// ListRevisions returns copies of the ticket's revisions, oldest first
+func (mr *MemoryRepository) ListRevisions(ctx context.Context, confirmationID string) ([]*models.TicketRevision, error) {
+	mr.mu.RLock()
+	defer mr.mu.RUnlock()
+
+	var revisions []*models.TicketRevision
+	for _, revision := range mr.history[confirmationID] {
+		revisions = append(revisions, copyRevision(revision))
+	}
+	return revisions, nil
+}
+
+// Sweep deletes the tickets past their expiry, with their preferences,
+// notes, lock and history
+func (mr *MemoryRepository) Sweep(ctx context.Context, now time.Time) (int, error) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	swept := 0
+	for id, ticket := range mr.tickets {
+		if ticket.ExpiresAt == nil || now.Before(*ticket.ExpiresAt) {
+			continue
+		}
+		delete(mr.tickets, id)
+		delete(mr.preferences, id)
+		delete(mr.notes, id)
+		delete(mr.locks, id)
+		delete(mr.history, id)
+		swept++
+	}
+	return swept, nil
+}
+
+// Close is a no-op
+func (mr *MemoryRepository) Close() error {
+	return nil
+}
+
+func copyTicket(ticket *models.FlightTicket) *models.FlightTicket {
+	copied := *ticket
+	if ticket.Price != nil {
+		price := *ticket.Price
+		copied.Price = &price
+	}
+	copied.Labels = copyLabels(ticket.Labels)
+	copied.CheckIn = copyCheckIn(ticket.CheckIn)
+	return &copied
+}
+
+func copyCheckIn(record *models.CheckInRecord) *models.CheckInRecord {
+	if record == nil {
+		return nil
+	}
+	copied := *record
+	copied.Passengers = append([]models.CheckedInPassenger(nil), record.Passengers...)
+	return &copied
+}
+
+func copyRevision(revision *models.TicketRevision) *models.TicketRevision {
+	copied := *revision
+	copied.ChangedFields = append([]string(nil), revision.ChangedFields...)
+	if revision.Ticket != nil {
+		copied.Ticket = copyTicket(revision.Ticket)
+	}
+	if revision.Previous != nil {
+		copied.Previous = copyTicket(revision.Previous)
+	}
+	return &copied
+}
+
+func copyInventory(balance *models.InventoryBalance) *models.InventoryBalance {
+	copied := *balance
+	copied.Held = make(map[string]int, len(balance.Held))
+	for confirmationID, seats := range balance.Held {
+		copied.Held[confirmationID] = seats
+	}
+	return &copied
+}
+
+func copyView(view *models.SavedView) *models.SavedView {
+	copied := *view
+	copied.Filters.Labels = copyLabels(view.Filters.Labels)
+	return &copied
+}
+
+func copyLabels(labels map[string]string) map[string]string {
+	if len(labels) == 0 {
+		return nil
+	}
+	copied := make(map[string]string, len(labels))
+	for key, value := range labels {
+		copied[key] = value
+	}
+	return copied
+}
+
+func copyPreferences(preferences *models.NotificationPreferences) *models.NotificationPreferences {
+	copied := *preferences
+	if preferences.QuietHours != nil {
+		quietHours := *preferences.QuietHours
+		copied.QuietHours = &quietHours
+	}
+	return &copied
+}
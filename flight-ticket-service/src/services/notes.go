@@ -0,0 +1,35 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+// NoteRepository is implemented by storage backends that can hold agent notes
+type NoteRepository interface {
+	// CreateNote stores a note for a ticket
+	CreateNote(ctx context.Context, note *models.TicketNote) error
+	// ListNotes retrieves the notes of a ticket, oldest first
+	ListNotes(ctx context.Context, confirmationID string) ([]*models.TicketNote, error)
+}
+
+// NewNote builds a note with a fresh ID
+func NewNote(confirmationID, author, text string) (*models.TicketNote, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate note ID: %v", err)
+	}
+
+	return &models.TicketNote{
+		ID:             hex.EncodeToString(id),
+		ConfirmationID: confirmationID,
+		Text:           text,
+		Author:         author,
+		CreatedAt:      time.Now().UTC(),
+	}, nil
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"flight-ticket-service/src/models"
+
+	"cloud.google.com/go/pubsub"
+	"google.golang.org/api/option"
+)
+
+// NotificationPreferenceRepository is implemented by storage backends that can hold notification preferences
+type NotificationPreferenceRepository interface {
+	// GetNotificationPreferences returns the saved preferences of a ticket, or nil when none were saved
+	GetNotificationPreferences(ctx context.Context, confirmationID string) (*models.NotificationPreferences, error)
+	// SaveNotificationPreferences replaces the preferences of a ticket
+	SaveNotificationPreferences(ctx context.Context, preferences *models.NotificationPreferences) error
+}
+
+// Notification types
+const (
+	NotificationDepartureReminder = "departure_reminder"
+	NotificationTicketChanged     = "ticket_changed"
+	NotificationUpgradeOffered    = "upgrade_offered"
+	NotificationUpgradeAccepted   = "upgrade_accepted"
+	NotificationUpgradeRejected   = "upgrade_rejected"
+)
+
+// ErrNotificationsDisabled is returned for tickets whose preferences turn notifications off
+var ErrNotificationsDisabled = errors.New("notifications are disabled for this ticket")
+
+// Notification is a message for the travellers of one ticket, addressed
+// according to the ticket's preferences
+type Notification struct {
+	Type           string     `json:"type"`
+	Template       string     `json:"template"`         // template the delivery service renders; the type unless the tenant overrides it
+	Tenant         string     `json:"tenant,omitempty"` // tenant that booked the ticket
+	ConfirmationID string     `json:"confirmation_id"`
+	Channel        string     `json:"channel"`
+	Email          string     `json:"email,omitempty"`
+	Phone          string     `json:"phone,omitempty"`
+	Language       string     `json:"language"`
+	DeliverAfter   *time.Time `json:"deliver_after,omitempty"` // end of the quiet hours the message was sent in
+
+	// Departure reminders inline the reminder fields, as published before preferences existed
+	*Reminder
+	Change  *TicketChange        `json:"change,omitempty"`
+	Upgrade *models.UpgradeOffer `json:"upgrade,omitempty"`
+}
+
+// TicketChange describes a change to a ticket the travellers should know about
+type TicketChange struct {
+	ChangedFields []string             `json:"changed_fields,omitempty"`
+	Ticket        *models.FlightTicket `json:"ticket"`
+}
+
+// NotificationTemplates resolves the templates tenants use instead of the defaults
+type NotificationTemplates interface {
+	// NotificationTemplate returns the template of a tenant for a notification type, or "" for the default
+	NotificationTemplate(tenantID, notificationType string) string
+}
+
+// NotificationSender delivers notifications
+type NotificationSender interface {
+	SendNotification(ctx context.Context, notification *Notification) error
+}
+
+// NotificationService addresses notifications using each ticket's preferences
+// (channel, language and quiet hours) and hands them to a sender
+type NotificationService struct {
+	preferences NotificationPreferenceRepository // nil when the backend has no preferences
+	sender      NotificationSender
+	templates   NotificationTemplates // nil when tenants cannot override templates
+	now         func() time.Time
+}
+
+// NewNotificationService creates a notification service. Tickets use the
+// default preferences when the repository cannot store preferences.
+func NewNotificationService(repository TicketRepository, sender NotificationSender) *NotificationService {
+	preferences, _ := Capability[NotificationPreferenceRepository](repository)
+	return &NotificationService{preferences: preferences, sender: sender, now: time.Now}
+}
+
+// WithTemplates lets tenants override the template of each notification type
+func (s *NotificationService) WithTemplates(templates NotificationTemplates) *NotificationService {
+	s.templates = templates
+	return s
+}
+
+// Notify addresses the notification and sends it. Messages falling in the
+// ticket's quiet hours carry DeliverAfter so the delivery service holds them
+// back. It returns ErrNotificationsDisabled when the ticket opted out.
+func (s *NotificationService) Notify(ctx context.Context, notification *Notification) error {
+	preferences, err := s.Preferences(ctx, notification.ConfirmationID)
+	if err != nil {
+		return err
+	}
+	if preferences.Channel == models.NotificationChannelNone {
+		return ErrNotificationsDisabled
+	}
+
+	notification.Template = notification.Type
+	if s.templates != nil && notification.Tenant != "" {
+		if template := s.templates.NotificationTemplate(notification.Tenant, notification.Type); template != "" {
+			notification.Template = template
+		}
+	}
+	notification.Channel = preferences.Channel
+	notification.Language = preferences.Language
+	switch preferences.Channel {
+	case models.NotificationChannelEmail:
+		notification.Email = preferences.Email
+	case models.NotificationChannelSMS:
+		notification.Phone = preferences.Phone
+	}
+	if preferences.QuietHours != nil {
+		if end, quiet := preferences.QuietHours.Until(s.now()); quiet {
+			notification.DeliverAfter = &end
+		}
+	}
+
+	return s.sender.SendNotification(ctx, notification)
+}
+
+// Preferences returns the saved preferences of a ticket, or the defaults
+func (s *NotificationService) Preferences(ctx context.Context, confirmationID string) (*models.NotificationPreferences, error) {
+	if s.preferences == nil {
+		return models.DefaultNotificationPreferences(confirmationID), nil
+	}
+	preferences, err := s.preferences.GetNotificationPreferences(ctx, confirmationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification preferences: %v", err)
+	}
+	if preferences == nil {
+		return models.DefaultNotificationPreferences(confirmationID), nil
+	}
+	return preferences, nil
+}
+
+// SendReminder sends a departure reminder, so the service can be used as the reminders job's sender
+func (s *NotificationService) SendReminder(ctx context.Context, reminder Reminder) error {
+	return s.Notify(ctx, &Notification{
+		Type:           NotificationDepartureReminder,
+		ConfirmationID: reminder.ConfirmationID,
+		Tenant:         reminder.Tenant,
+		Reminder:       &reminder,
+	})
+}
+
+// NotifyTicketChange tells the travellers that their ticket changed
+func (s *NotificationService) NotifyTicketChange(ctx context.Context, ticket *models.FlightTicket, changedFields []string) error {
+	return s.Notify(ctx, &Notification{
+		Type:           NotificationTicketChanged,
+		ConfirmationID: ticket.ConfirmationID,
+		Tenant:         ticket.Labels[models.TenantLabel],
+		Change:         &TicketChange{ChangedFields: changedFields, Ticket: ticket},
+	})
+}
+
+// NotifyUpgrade tells the travellers of an upgrade offer made to their ticket,
+// or of its outcome
+func (s *NotificationService) NotifyUpgrade(ctx context.Context, notificationType string, offer *models.UpgradeOffer) error {
+	return s.Notify(ctx, &Notification{
+		Type:           notificationType,
+		ConfirmationID: offer.ConfirmationID,
+		Tenant:         offer.Tenant,
+		Upgrade:        offer,
+	})
+}
+
+// LogNotificationSender logs notifications instead of delivering them
+type LogNotificationSender struct{}
+
+// SendNotification logs the notification
+func (LogNotificationSender) SendNotification(ctx context.Context, notification *Notification) error {
+	deliver := "now"
+	if notification.DeliverAfter != nil {
+		deliver = "after " + notification.DeliverAfter.Format(time.RFC3339)
+	}
+	log.Printf("Notification: %s for ticket %s by %s (%s), deliver %s",
+		notification.Type, notification.ConfirmationID, notification.Channel, notification.Language, deliver)
+	return nil
+}
+
+// PubSubNotificationSender publishes notifications as JSON to a Pub/Sub topic
+// for a delivery service to send by email or SMS
+type PubSubNotificationSender struct {
+	client *pubsub.Client
+	topic  *pubsub.Topic
+}
+
+// NewPubSubNotificationSender creates a sender publishing to the given topic
+func NewPubSubNotificationSender(ctx context.Context, projectID, credentialsPath, topicID string) (*PubSubNotificationSender, error) {
+	var opts []option.ClientOption
+	if credentialsPath != "" {
+		opts = append(opts, option.WithCredentialsFile(credentialsPath))
+	}
+
+	client, err := pubsub.NewClient(ctx, projectID, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Pub/Sub client: %v", err)
+	}
+
+	return &PubSubNotificationSender{client: client, topic: client.Topic(topicID)}, nil
+}
+
+// SendNotification publishes the notification and waits for the result. The
+// type, channel and language are also message attributes for subscription filters.
+func (s *PubSubNotificationSender) SendNotification(ctx context.Context, notification *Notification) error {
+	data, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %v", err)
+	}
+
+	attributes := map[string]string{
+		"type":            notification.Type,
+		"template":        notification.Template,
+		"confirmation_id": notification.ConfirmationID,
+		"channel":         notification.Channel,
+		"language":        notification.Language,
+	}
+	if notification.DeliverAfter != nil {
+		attributes["deliver_after"] = notification.DeliverAfter.UTC().Format(time.RFC3339)
+	}
+
+	result := s.topic.Publish(ctx, &pubsub.Message{Data: data, Attributes: attributes})
+	if _, err := result.Get(ctx); err != nil {
+		return fmt.Errorf("failed to publish notification: %v", err)
+	}
+	return nil
+}
+
+// Close flushes pending messages and closes the client
+func (s *PubSubNotificationSender) Close() error {
+	s.topic.Stop()
+	return s.client.Close()
+}
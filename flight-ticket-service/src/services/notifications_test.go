@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+type capturingSender struct {
+	sent []*Notification
+}
+
+func (s *capturingSender) SendNotification(ctx context.Context, notification *Notification) error {
+	s.sent = append(s.sent, notification)
+	return nil
+}
+
+func TestNotificationServiceFollowsPreferences(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	repo.SaveNotificationPreferences(ctx, &models.NotificationPreferences{
+		ConfirmationID: "SMS001", Channel: models.NotificationChannelSMS, Phone: "+14155550123", Email: "ignored@example.com", Language: "fr",
+		QuietHours: &models.QuietHours{Start: "22:00", End: "07:00"},
+	})
+	repo.SaveNotificationPreferences(ctx, &models.NotificationPreferences{ConfirmationID: "OFF001", Channel: models.NotificationChannelNone})
+
+	sender := &capturingSender{}
+	service := NewNotificationService(repo, sender)
+	service.now = func() time.Time { return time.Date(2024, 7, 12, 23, 0, 0, 0, time.UTC) }
+
+	if err := service.SendReminder(ctx, Reminder{ConfirmationID: "SMS001", FlightNumber: "AA1234"}); err != nil {
+		t.Fatalf("SendReminder failed: %v", err)
+	}
+	if err := service.SendReminder(ctx, Reminder{ConfirmationID: "OFF001"}); !errors.Is(err, ErrNotificationsDisabled) {
+		t.Errorf("Expected ErrNotificationsDisabled, got %v", err)
+	}
+	if err := service.NotifyTicketChange(ctx, &models.FlightTicket{ConfirmationID: "NEW001"}, []string{"status"}); err != nil {
+		t.Fatalf("NotifyTicketChange failed: %v", err)
+	}
+
+	if len(sender.sent) != 2 {
+		t.Fatalf("Sent %d notifications, want 2", len(sender.sent))
+	}
+
+	sms := sender.sent[0]
+	if sms.Channel != "sms" || sms.Phone != "+14155550123" || sms.Email != "" || sms.Language != "fr" {
+		t.Errorf("Unexpected addressing %+v", sms)
+	}
+	if want := time.Date(2024, 7, 13, 7, 0, 0, 0, time.UTC); sms.DeliverAfter == nil || !sms.DeliverAfter.Equal(want) {
+		t.Errorf("DeliverAfter = %v, want %v", sms.DeliverAfter, want)
+	}
+
+	// Reminder fields stay at the top level for existing consumers
+	var message map[string]interface{}
+	data, _ := json.Marshal(sms)
+	json.Unmarshal(data, &message)
+	if message["flight_number"] != "AA1234" || message["type"] != NotificationDepartureReminder {
+		t.Errorf("Unexpected message %s", data)
+	}
+
+	// Tickets without preferences get the defaults
+	change := sender.sent[1]
+	if change.Channel != "email" || change.Language != "en" || change.DeliverAfter != nil || change.Change == nil {
+		t.Errorf("Unexpected default notification %+v", change)
+	}
+}
+
+func TestSendRemindersSkipsOptedOutTickets(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 7, 12, 10, 5, 0, 0, time.UTC)
+	departure := now.Add(24 * time.Hour)
+
+	repo := NewMemoryRepository()
+	for _, id := range []string{"KEEP01", "OFF001"} {
+		repo.CreateTicket(ctx, &models.FlightTicket{ConfirmationID: id, Status: StatusConfirmed, DepartureTime: departure})
+	}
+	repo.SaveNotificationPreferences(ctx, &models.NotificationPreferences{ConfirmationID: "OFF001", Channel: models.NotificationChannelNone})
+
+	jobs := NewTicketJobs(repo)
+	jobs.now = func() time.Time { return now }
+	sender := &capturingSender{}
+	result, err := jobs.SendReminders(ctx, 24*time.Hour, time.Hour, NewNotificationService(repo, sender))
+	if err != nil {
+		t.Fatalf("SendReminders failed: %v", err)
+	}
+	if result.Sent != 1 || result.Skipped != 1 || result.Failed != 0 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	if len(sender.sent) != 1 || sender.sent[0].ConfirmationID != "KEEP01" {
+		t.Errorf("Unexpected notifications %+v", sender.sent)
+	}
+}
+
+type staticTemplates map[string]string
+
+func (t staticTemplates) NotificationTemplate(tenantID, notificationType string) string {
+	return t[tenantID+"/"+notificationType]
+}
+
+func TestNotificationTemplatesOfTenants(t *testing.T) {
+	ctx := context.Background()
+	sender := &capturingSender{}
+	service := NewNotificationService(NewMemoryRepository(), sender).WithTemplates(staticTemplates{"acme/ticket_changed": "acme-changed"})
+
+	tenantTicket := &models.FlightTicket{ConfirmationID: "ACME01", Labels: map[string]string{models.TenantLabel: "acme"}}
+	service.NotifyTicketChange(ctx, tenantTicket, []string{"status"})
+	service.NotifyTicketChange(ctx, &models.FlightTicket{ConfirmationID: "OWN001"}, []string{"status"})
+	service.SendReminder(ctx, Reminder{ConfirmationID: "ACME01", Tenant: "acme"})
+
+	want := []string{"acme-changed", NotificationTicketChanged, NotificationDepartureReminder}
+	for i, notification := range sender.sent {
+		if notification.Template != want[i] {
+			t.Errorf("Notification %d: template %q, want %q", i, notification.Template, want[i])
+		}
+	}
+	if len(sender.sent) != 3 || sender.sent[0].Tenant != "acme" || sender.sent[1].Tenant != "" || sender.sent[2].Tenant != "acme" {
+		t.Errorf("Unexpected notifications %+v", sender.sent)
+	}
+}
@@ -0,0 +1,344 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"flight-ticket-service/src/db/postgres"
+	"flight-ticket-service/src/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PostgresService struct {
+	pool    *pgxpool.Pool
+	queries *postgres.Queries
+	dsn     string
+}
+
+// NewPostgresService creates a new PostgreSQL service instance.
+// The DSN may point at a Cloud SQL unix socket (host=/cloudsql/PROJECT:REGION:INSTANCE).
+func NewPostgresService(dsn string) (*PostgresService, error) {
+	ctx := context.Background()
+
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create PostgreSQL pool: %v", err)
+	}
+
+	return &PostgresService{
+		pool:    pool,
+		queries: postgres.New(pool),
+		dsn:     dsn,
+	}, nil
+}
+
+// CreateSchema applies pending schema migrations
+func (ps *PostgresService) CreateSchema(ctx context.Context) error {
+	if err := postgres.Migrate(ps.dsn); err != nil {
+		return err
+	}
+
+	log.Println("Applied PostgreSQL migrations")
+	return nil
+}
+
+// CreateTicket creates a new flight ticket
+func (ps *PostgresService) CreateTicket(ctx context.Context, ticket *models.FlightTicket) error {
+	price, err := marshalPrice(ticket.Price)
+	if err != nil {
+		return fmt.Errorf("failed to create ticket: %v", err)
+	}
+	labels, err := marshalLabels(ticket.Labels)
+	if err != nil {
+		return fmt.Errorf("failed to create ticket: %v", err)
+	}
+	checkIn, err := marshalCheckIn(ticket.CheckIn)
+	if err != nil {
+		return fmt.Errorf("failed to create ticket: %v", err)
+	}
+
+	err = ps.queries.CreateTicket(ctx, postgres.CreateTicketParams{
+		ConfirmationID: ticket.ConfirmationID,
+		Origin:         ticket.Origin,
+		Destination:    ticket.Destination,
+		DepartureDate:  ticket.DepartureDate,
+		DepartureTime:  ticket.DepartureTime,
+		FlightNumber:   ticket.FlightNumber,
+		Passengers:     int32(ticket.Passengers),
+		Status:         ticket.Status,
+		Price:          price,
+		CreatedAt:      ticket.CreatedAt,
+		UpdatedAt:      ticket.UpdatedAt,
+		Labels:         labels,
+		CheckIn:        checkIn,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create ticket: %v", err)
+	}
+
+	log.Printf("Created ticket with confirmation ID: %s", ticket.ConfirmationID)
+	return nil
+}
+
+// GetTicket retrieves a flight ticket by confirmation ID
+func (ps *PostgresService) GetTicket(ctx context.Context, confirmationID string) (*models.FlightTicket, error) {
+	row, err := ps.queries.GetTicket(ctx, confirmationID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get ticket: %s not found", confirmationID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ticket: %v", err)
+	}
+
+	ticket, err := postgresToTicket(row)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ticket data: %v", err)
+	}
+
+	return ticket, nil
+}
+
+// UpdateTicket updates an existing flight ticket
+func (ps *PostgresService) UpdateTicket(ctx context.Context, confirmationID string, updates map[string]interface{}) error {
+	params := postgres.UpdateTicketParams{
+		ConfirmationID: confirmationID,
+		UpdatedAt:      time.Now(),
+	}
+
+	for field, value := range updates {
+		var ok bool
+		switch field {
+		case "origin":
+			params.Origin, ok = stringPtr(value)
+		case "destination":
+			params.Destination, ok = stringPtr(value)
+		case "flight_number":
+			params.FlightNumber, ok = stringPtr(value)
+		case "status":
+			params.Status, ok = stringPtr(value)
+		case "departure_date":
+			params.DepartureDate, ok = timePtr(value)
+		case "departure_time":
+			params.DepartureTime, ok = timePtr(value)
+		case "passengers":
+			var n int
+			if n, ok = value.(int); ok {
+				passengers := int32(n)
+				params.Passengers = &passengers
+			}
+		case "price":
+			var price *models.Price
+			if price, ok = value.(*models.Price); ok {
+				data, err := marshalPrice(price)
+				if err != nil {
+					return fmt.Errorf("failed to update ticket: %v", err)
+				}
+				params.Price = data
+			}
+		case "labels":
+			var labels map[string]string
+			if labels, ok = value.(map[string]string); ok {
+				// Store an empty object rather than NULL so that clearing labels is not skipped by COALESCE
+				data, err := json.Marshal(labels)
+				if err != nil {
+					return fmt.Errorf("failed to update ticket: %v", err)
+				}
+				params.Labels = data
+			}
+		case "check_in":
+			var record *models.CheckInRecord
+			if record, ok = value.(*models.CheckInRecord); ok {
+				data, err := marshalCheckIn(record)
+				if err != nil {
+					return fmt.Errorf("failed to update ticket: %v", err)
+				}
+				params.CheckIn = data
+			}
+		case "updated_at":
+			var t time.Time
+			if t, ok = value.(time.Time); ok {
+				params.UpdatedAt = t
+			}
+		default:
+			return fmt.Errorf("failed to update ticket: unknown field %s", field)
+		}
+		if !ok {
+			return fmt.Errorf("failed to update ticket: unexpected type %T for %s", value, field)
+		}
+	}
+
+	rows, err := ps.queries.UpdateTicket(ctx, params)
+	if err != nil {
+		return fmt.Errorf("failed to update ticket: %v", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("failed to update ticket: %s not found", confirmationID)
+	}
+
+	log.Printf("Updated ticket with confirmation ID: %s", confirmationID)
+	return nil
+}
+
+// DeleteTicket deletes a flight ticket (or marks as cancelled)
+func (ps *PostgresService) DeleteTicket(ctx context.Context, confirmationID string) error {
+	// Instead of deleting, we'll mark as cancelled for audit purposes
+	updates := map[string]interface{}{
+		"status": "CANCELLED",
+	}
+
+	return ps.UpdateTicket(ctx, confirmationID, updates)
+}
+
+// ListTickets retrieves flight tickets, newest first
+func (ps *PostgresService) ListTickets(ctx context.Context, limit int) ([]*models.FlightTicket, error) {
+	var limitParam *int32
+	if limit > 0 {
+		l := int32(limit)
+		limitParam = &l
+	}
+
+	rows, err := ps.queries.ListTickets(ctx, limitParam)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tickets: %v", err)
+	}
+
+	return postgresToTickets(rows), nil
+}
+
+// SearchTickets filters tickets in SQL, matching labels with JSONB containment
+func (ps *PostgresService) SearchTickets(ctx context.Context, query models.TicketQuery) ([]*models.FlightTicket, error) {
+	labels, err := marshalLabels(query.Labels)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search tickets: %v", err)
+	}
+
+	params := postgres.SearchTicketsParams{
+		Labels:       labels,
+		Status:       optionalString(query.Status),
+		Origin:       optionalString(query.Origin),
+		Destination:  optionalString(query.Destination),
+		FlightNumber: optionalString(query.FlightNumber),
+	}
+	if !query.DepartureDate.IsZero() {
+		from := query.DepartureDate.UTC().Truncate(24 * time.Hour)
+		until := from.AddDate(0, 0, 1)
+		params.DepartureFrom = &from
+		params.DepartureUntil = &until
+	}
+	if query.Limit > 0 {
+		l := int32(query.Limit)
+		params.Limit = &l
+	}
+
+	rows, err := ps.queries.SearchTickets(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search tickets: %v", err)
+	}
+
+	return postgresToTickets(rows), nil
+}
+
+// Close closes the connection pool
+func (ps *PostgresService) Close() error {
+	ps.pool.Close()
+	return nil
+}
+
+func postgresToTickets(rows []postgres.FlightTicket) []*models.FlightTicket {
+	var tickets []*models.FlightTicket
+	for _, row := range rows {
+		ticket, err := postgresToTicket(row)
+		if err != nil {
+			log.Printf("Failed to parse ticket %s: %v", row.ConfirmationID, err)
+			continue
+		}
+		tickets = append(tickets, ticket)
+	}
+	return tickets
+}
+
+func postgresToTicket(row postgres.FlightTicket) (*models.FlightTicket, error) {
+	ticket := &models.FlightTicket{
+		ConfirmationID: row.ConfirmationID,
+		Origin:         row.Origin,
+		Destination:    row.Destination,
+		DepartureDate:  row.DepartureDate.UTC(),
+		DepartureTime:  row.DepartureTime.UTC(),
+		FlightNumber:   row.FlightNumber,
+		Passengers:     int(row.Passengers),
+		Status:         row.Status,
+		CreatedAt:      row.CreatedAt.UTC(),
+		UpdatedAt:      row.UpdatedAt.UTC(),
+	}
+
+	if len(row.Price) > 0 {
+		var price models.Price
+		if err := json.Unmarshal(row.Price, &price); err != nil {
+			return nil, fmt.Errorf("price: %v", err)
+		}
+		ticket.Price = &price
+	}
+
+	if len(row.Labels) > 0 {
+		if err := json.Unmarshal(row.Labels, &ticket.Labels); err != nil {
+			return nil, fmt.Errorf("labels: %v", err)
+		}
+		if len(ticket.Labels) == 0 {
+			ticket.Labels = nil
+		}
+	}
+
+	if len(row.CheckIn) > 0 {
+		ticket.CheckIn = &models.CheckInRecord{}
+		if err := json.Unmarshal(row.CheckIn, ticket.CheckIn); err != nil {
+			return nil, fmt.Errorf("check_in: %v", err)
+		}
+	}
+
+	return ticket, nil
+}
+
+func marshalPrice(price *models.Price) ([]byte, error) {
+	if price == nil {
+		return nil, nil
+	}
+	return json.Marshal(price)
+}
+
+func marshalLabels(labels map[string]string) ([]byte, error) {
+	if len(labels) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(labels)
+}
+
+func marshalCheckIn(record *models.CheckInRecord) ([]byte, error) {
+	if record == nil {
+		return nil, nil
+	}
+	return json.Marshal(record)
+}
+
+// optionalString returns nil for an empty filter
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func stringPtr(value interface{}) (*string, bool) {
+	s, ok := value.(string)
+	return &s, ok
+}
+
+func timePtr(value interface{}) (*time.Time, bool) {
+	t, ok := value.(time.Time)
+	return &t, ok
+}
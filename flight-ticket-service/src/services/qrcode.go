@@ -0,0 +1,143 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+const (
+	// qrPayloadVersion prefixes signed payloads so the format can evolve
+	qrPayloadVersion = "FTS1"
+
+	// QR code size limits in pixels
+	DefaultQRSize = 256
+	MinQRSize     = 64
+	MaxQRSize     = 1024
+)
+
+var qrRecoveryLevels = map[string]qrcode.RecoveryLevel{
+	"L": qrcode.Low,
+	"M": qrcode.Medium,
+	"Q": qrcode.High,
+	"H": qrcode.Highest,
+}
+
+// QRPayload is the decoded content of a verified ticket QR code
+type QRPayload struct {
+	ConfirmationID string
+	IssuedAt       time.Time
+}
+
+// QRService renders QR codes carrying an HMAC-signed confirmation ID payload
+type QRService struct {
+	key []byte
+}
+
+// NewQRService creates a QR service signing payloads with the given key.
+// An empty key generates a random one, so codes only verify until the service restarts.
+func NewQRService(key string) (*QRService, error) {
+	if key != "" {
+		return &QRService{key: []byte(key)}, nil
+	}
+
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return nil, fmt.Errorf("failed to generate QR signing key: %v", err)
+	}
+	return &QRService{key: random}, nil
+}
+
+// Payload returns the signed payload for a confirmation ID:
+// FTS1.<confirmation ID>.<issued unix time>.<base64url HMAC-SHA256>
+func (qs *QRService) Payload(confirmationID string, issuedAt time.Time) string {
+	body := fmt.Sprintf("%s.%s.%d", qrPayloadVersion, confirmationID, issuedAt.Unix())
+	return body + "." + qs.sign(body)
+}
+
+// Verify checks a scanned payload's signature and returns its contents
+func (qs *QRService) Verify(payload string) (*QRPayload, error) {
+	parts := strings.Split(payload, ".")
+	if len(parts) != 4 || parts[0] != qrPayloadVersion {
+		return nil, fmt.Errorf("unrecognized QR payload")
+	}
+
+	body := strings.Join(parts[:3], ".")
+	if !hmac.Equal([]byte(parts[3]), []byte(qs.sign(body))) {
+		return nil, fmt.Errorf("invalid QR payload signature")
+	}
+
+	issued, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid QR payload timestamp: %v", err)
+	}
+
+	return &QRPayload{
+		ConfirmationID: parts[1],
+		IssuedAt:       time.Unix(issued, 0).UTC(),
+	}, nil
+}
+
+// ParseQRLevel maps an error-correction level (L, M, Q or H) to its recovery level
+func ParseQRLevel(level string) (qrcode.RecoveryLevel, error) {
+	if level == "" {
+		return qrcode.Medium, nil
+	}
+	recovery, ok := qrRecoveryLevels[strings.ToUpper(level)]
+	if !ok {
+		return 0, fmt.Errorf("invalid error-correction level %q: use L, M, Q or H", level)
+	}
+	return recovery, nil
+}
+
+// PNG renders content as a size x size pixel PNG QR code
+func (qs *QRService) PNG(content string, level qrcode.RecoveryLevel, size int) ([]byte, error) {
+	png, err := qrcode.Encode(content, level, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode QR code: %v", err)
+	}
+	return png, nil
+}
+
+// SVG renders content as a size x size SVG QR code
+func (qs *QRService) SVG(content string, level qrcode.RecoveryLevel, size int) ([]byte, error) {
+	code, err := qrcode.New(content, level)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode QR code: %v", err)
+	}
+
+	bitmap := code.Bitmap()
+	modules := len(bitmap)
+
+	var path strings.Builder
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&path, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		size, size, modules, modules)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#fff"/>`, modules, modules)
+	fmt.Fprintf(&buf, `<path d="%s" fill="#000"/>`, path.String())
+	buf.WriteString(`</svg>`)
+
+	return buf.Bytes(), nil
+}
+
+func (qs *QRService) sign(body string) string {
+	mac := hmac.New(sha256.New, qs.key)
+	mac.Write([]byte(body))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
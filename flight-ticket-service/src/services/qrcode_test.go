@@ -0,0 +1,80 @@
+package services
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+func TestQRPayloadRoundTrip(t *testing.T) {
+	qs, _ := NewQRService("test-key")
+	issued := time.Date(2024, 7, 12, 19, 0, 0, 0, time.UTC)
+
+	payload := qs.Payload("ABC123", issued)
+	if !strings.HasPrefix(payload, "FTS1.ABC123.1720810800.") {
+		t.Errorf("Unexpected payload %q", payload)
+	}
+
+	decoded, err := qs.Verify(payload)
+	if err != nil {
+		t.Fatalf("Unexpected error verifying payload: %v", err)
+	}
+	if decoded.ConfirmationID != "ABC123" || !decoded.IssuedAt.Equal(issued) {
+		t.Errorf("Unexpected decoded payload %+v", decoded)
+	}
+}
+
+func TestQRVerifyRejectsTampering(t *testing.T) {
+	qs, _ := NewQRService("test-key")
+	payload := qs.Payload("ABC123", time.Now())
+
+	tampered := strings.Replace(payload, "ABC123", "XYZ789", 1)
+	if _, err := qs.Verify(tampered); err == nil {
+		t.Error("Expected error for tampered payload")
+	}
+
+	other, _ := NewQRService("other-key")
+	if _, err := other.Verify(payload); err == nil {
+		t.Error("Expected error for payload signed with a different key")
+	}
+
+	if _, err := qs.Verify("ABC123"); err == nil {
+		t.Error("Expected error for unsigned payload")
+	}
+}
+
+func TestParseQRLevel(t *testing.T) {
+	if level, err := ParseQRLevel(""); err != nil || level != qrcode.Medium {
+		t.Errorf("Expected default level Medium, got %v (%v)", level, err)
+	}
+	if level, err := ParseQRLevel("h"); err != nil || level != qrcode.Highest {
+		t.Errorf("Expected level Highest, got %v (%v)", level, err)
+	}
+	if _, err := ParseQRLevel("X"); err == nil {
+		t.Error("Expected error for invalid level")
+	}
+}
+
+func TestQRImages(t *testing.T) {
+	qs, _ := NewQRService("test-key")
+	payload := qs.Payload("ABC123", time.Now())
+
+	png, err := qs.PNG(payload, qrcode.Medium, 128)
+	if err != nil {
+		t.Fatalf("Unexpected error rendering PNG: %v", err)
+	}
+	if !bytes.HasPrefix(png, []byte("\x89PNG")) {
+		t.Error("Expected PNG signature")
+	}
+
+	svg, err := qs.SVG(payload, qrcode.Medium, 128)
+	if err != nil {
+		t.Fatalf("Unexpected error rendering SVG: %v", err)
+	}
+	if !bytes.HasPrefix(svg, []byte("<svg")) || !bytes.Contains(svg, []byte(`width="128"`)) {
+		t.Errorf("Unexpected SVG output: %.80s", svg)
+	}
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"strings"
+
+	"cloud.google.com/go/compute/metadata"
+	firestoreadmin "google.golang.org/api/firestore/v1"
+	"google.golang.org/api/option"
+)
+
+// firestoreMultiRegions lists the regions that serve each Firestore multi-region location
+var firestoreMultiRegions = map[string][]string{
+	"nam5": {"us-central1", "us-central2"},
+	"nam7": {"us-central1", "us-east4"},
+	"eur3": {"europe-west1", "europe-west4"},
+}
+
+// FirestoreLocation returns the location of a Firestore database, a region
+// such as us-east1 or a multi-region such as nam5
+func FirestoreLocation(ctx context.Context, projectID, databaseID, credentialsPath string) (string, error) {
+	var opts []option.ClientOption
+	if credentialsPath != "" {
+		opts = append(opts, option.WithCredentialsFile(credentialsPath))
+	}
+
+	admin, err := firestoreadmin.NewService(ctx, opts...)
+	if err != nil {
+		return "", fmt.Errorf("failed to create Firestore admin client: %v", err)
+	}
+
+	database, err := admin.Projects.Databases.Get(fmt.Sprintf("projects/%s/databases/%s", projectID, databaseID)).Context(ctx).Do()
+	if err != nil {
+		return "", fmt.Errorf("failed to get Firestore database %s: %v", databaseID, err)
+	}
+	return database.LocationId, nil
+}
+
+// ServiceRegion returns the region the service runs in: SERVICE_REGION, or on
+// Cloud Run the region of the instance. It is empty when unknown, e.g. locally.
+func ServiceRegion() (string, error) {
+	if region := strings.TrimSpace(os.Getenv("SERVICE_REGION")); region != "" {
+		return region, nil
+	}
+	if os.Getenv("K_SERVICE") == "" {
+		return "", nil
+	}
+
+	// projects/PROJECT_NUMBER/regions/REGION
+	region, err := metadata.Get("instance/region")
+	if err != nil {
+		return "", fmt.Errorf("failed to read the instance region from the metadata server: %v", err)
+	}
+	return path.Base(region), nil
+}
+
+// ServesRegion reports whether a Firestore location is in, or replicated to, a region
+func ServesRegion(location, region string) bool {
+	if location == region {
+		return true
+	}
+	for _, replica := range firestoreMultiRegions[location] {
+		if replica == region {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckFirestoreRegion looks up the location of the configured Firestore
+// database and returns it. It fails when FIRESTORE_LOCATION is set and the
+// database is elsewhere, and warns when the database is far from the service.
+// Without FIRESTORE_LOCATION a failed lookup only logs a warning.
+func CheckFirestoreRegion(ctx context.Context, cfg StorageConfig) (string, error) {
+	location, err := FirestoreLocation(ctx, cfg.ProjectID, cfg.FirestoreDatabase, cfg.CredentialsPath)
+	if err != nil {
+		if cfg.FirestoreLocation != "" {
+			return "", err
+		}
+		log.Printf("Warning: could not check the Firestore database location: %v", err)
+		return "", nil
+	}
+
+	region, err := ServiceRegion()
+	if err != nil {
+		log.Printf("Warning: could not determine the service region: %v", err)
+	}
+
+	warning, err := compareRegions(location, cfg.FirestoreLocation, region)
+	if err != nil {
+		return "", fmt.Errorf("Firestore database %s: %v", cfg.FirestoreDatabase, err)
+	}
+	if warning != "" {
+		log.Printf("Warning: Firestore database %s: %s", cfg.FirestoreDatabase, warning)
+	}
+	return location, nil
+}
+
+// compareRegions checks a database location against the expected location and
+// the service region; an unknown region or expectation is not checked
+func compareRegions(location, expected, region string) (warning string, err error) {
+	if expected != "" && !strings.EqualFold(location, expected) {
+		return "", fmt.Errorf("located in %s, but FIRESTORE_LOCATION expects %s", location, expected)
+	}
+	if region != "" && !ServesRegion(location, region) {
+		return fmt.Sprintf("located in %s while the service runs in %s; every request pays cross-region latency", location, region), nil
+	}
+	return "", nil
+}
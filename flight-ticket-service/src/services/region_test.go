@@ -0,0 +1,51 @@
+package services
+
+import "testing"
+
+func TestServesRegion(t *testing.T) {
+	tests := []struct {
+		location string
+		region   string
+		expected bool
+	}{
+		{"us-east1", "us-east1", true},
+		{"us-east1", "us-central1", false},
+		{"nam5", "us-central1", true},
+		{"nam5", "us-east1", false},
+		{"eur3", "europe-west4", true},
+		{"eur3", "us-east1", false},
+	}
+	for _, tt := range tests {
+		if got := ServesRegion(tt.location, tt.region); got != tt.expected {
+			t.Errorf("ServesRegion(%q, %q) = %v, expected %v", tt.location, tt.region, got, tt.expected)
+		}
+	}
+}
+
+func TestCompareRegions(t *testing.T) {
+	if _, err := compareRegions("nam5", "us-east1", ""); err == nil {
+		t.Error("Expected an error when the database is not in the expected location")
+	}
+	if warning, err := compareRegions("nam5", "NAM5", "us-central1"); err != nil || warning != "" {
+		t.Errorf("Expected no warning for a service served by the multi-region, got %q, %v", warning, err)
+	}
+	if warning, err := compareRegions("us-east1", "", "europe-west1"); err != nil || warning == "" {
+		t.Errorf("Expected a latency warning for a distant service region, got %q, %v", warning, err)
+	}
+	if warning, err := compareRegions("us-east1", "", ""); err != nil || warning != "" {
+		t.Errorf("Expected no check without a service region, got %q, %v", warning, err)
+	}
+}
+
+func TestServiceRegionFromEnv(t *testing.T) {
+	t.Setenv("SERVICE_REGION", "us-east1")
+	if region, err := ServiceRegion(); err != nil || region != "us-east1" {
+		t.Errorf("Expected SERVICE_REGION, got %q, %v", region, err)
+	}
+
+	t.Setenv("SERVICE_REGION", "")
+	t.Setenv("K_SERVICE", "")
+	if region, err := ServiceRegion(); err != nil || region != "" {
+		t.Errorf("Expected an unknown region outside Cloud Run, got %q, %v", region, err)
+	}
+}
@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"flight-ticket-service/src/models"
+)
+
+// TicketRepository is the storage interface for flight tickets
+type TicketRepository interface {
+	// CreateTicket stores a new ticket
+	CreateTicket(ctx context.Context, ticket *models.FlightTicket) error
+	// GetTicket retrieves a ticket by confirmation ID
+	GetTicket(ctx context.Context, confirmationID string) (*models.FlightTicket, error)
+	// UpdateTicket applies field updates (keyed by storage field name) to a ticket
+	UpdateTicket(ctx context.Context, confirmationID string, updates map[string]interface{}) error
+	// DeleteTicket cancels a ticket
+	DeleteTicket(ctx context.Context, confirmationID string) error
+	// ListTickets retrieves tickets newest first; a limit of 0 returns all tickets
+	ListTickets(ctx context.Context, limit int) ([]*models.FlightTicket, error)
+	// Close releases the underlying client
+	Close() error
+}
+
+// Capability returns the optional capability T of a repository, such as its
+// TicketHistory. Wrappers like InstrumentedRepository offer the capabilities
+// of the repository they wrap through Capabilities, and the rest through
+// Unwrap; other wrappers hide them.
+func Capability[T any](repository TicketRepository) (T, bool) {
+	if capability, ok := repository.(T); ok {
+		return capability, true
+	}
+	if provider, ok := repository.(interface{ Capabilities() []any }); ok {
+		for _, capability := range provider.Capabilities() {
+			if found, ok := capability.(T); ok {
+				return found, true
+			}
+		}
+	}
+	if wrapper, ok := repository.(interface{ Unwrap() TicketRepository }); ok {
+		return Capability[T](wrapper.Unwrap())
+	}
+	var none T
+	return none, false
+}
+
+// SchemaCreator is implemented by backends that manage their own schema
+type SchemaCreator interface {
+	// CreateSchema creates or migrates the backend schema
+	CreateSchema(ctx context.Context) error
+}
+
+// TicketSchemaMigrator is implemented by backends that store tickets as
+// versioned documents, which are upgraded lazily on read
+type TicketSchemaMigrator interface {
+	// MigrateTickets upgrades every ticket stored with an older schema version
+	MigrateTickets(ctx context.Context, progress func(done, total int)) (*SchemaMigrationResult, error)
+}
+
+// SchemaMigrationResult summarizes a bulk schema migration
+type SchemaMigrationResult struct {
+	Scanned       int `json:"scanned"`
+	Migrated      int `json:"migrated"`
+	Failed        int `json:"failed"`
+	SchemaVersion int `json:"schema_version"`
+}
+
+// ErrInvalidTicketDocument is returned when a repaired ticket document still cannot be read
+var ErrInvalidTicketDocument = errors.New("invalid ticket document")
+
+// TicketQuarantine is implemented by backends that set unreadable ticket
+// documents aside for inspection and repair
+type TicketQuarantine interface {
+	// ListQuarantined retrieves the quarantined documents, oldest first
+	ListQuarantined(ctx context.Context) ([]*models.QuarantinedTicket, error)
+	// GetQuarantined returns a quarantined document, or nil if the ticket is not quarantined
+	GetQuarantined(ctx context.Context, confirmationID string) (*models.QuarantinedTicket, error)
+	// RepairQuarantined changes fields of a quarantined ticket and releases it once it can be read
+	RepairQuarantined(ctx context.Context, confirmationID string, set map[string]interface{}, remove []string) (*models.FlightTicket, error)
+}
+
+// Storage backends
+const (
+	BackendFirestore = "firestore"
+	BackendSpanner   = "spanner"
+	BackendPostgres  = "postgres"
+	BackendSQLite    = "sqlite"
+	BackendMemory    = "memory"
+)
+
+// DefaultSQLitePath is the database file used by the sqlite backend when SQLITE_PATH is unset
+const DefaultSQLitePath = "flight-tickets.db"
+
+// Firestore database and ticket collection used when FIRESTORE_DATABASE and FIRESTORE_COLLECTION are unset
+const (
+	DefaultFirestoreDatabase   = "(default)"
+	DefaultFirestoreCollection = "flight_tickets"
+)
+
+// StorageConfig selects and configures the ticket storage backend
+type StorageConfig struct {
+	Backend         string
+	ProjectID       string
+	CredentialsPath string
+	SpannerInstance string
+	SpannerDatabase string
+
+	// FirestoreDatabase is shared by every Firestore store of the service (tickets, jobs, quota
+	// counters and feature flags). FirestoreCollection names the ticket collection and prefixes the
+	// collections kept alongside it: <collection>_views, _inventory, _booking_counters and _quarantine
+	FirestoreDatabase   string
+	FirestoreCollection string
+	FirestoreLocation   string // expected location of the database, checked at startup when set
+	FirestoreStrict     bool   // reject ticket documents that fail the field checks
+
+	PostgresURL      string
+	CloudSQLInstance string
+	PostgresUser     string
+	PostgresPassword string
+	PostgresDatabase string
+
+	SQLitePath string
+}
+
+// StorageConfigFromEnv reads the storage configuration from environment variables
+func StorageConfigFromEnv() StorageConfig {
+	backend := os.Getenv("STORAGE_BACKEND")
+	if backend == "" {
+		backend = BackendFirestore
+	}
+
+	sqlitePath := os.Getenv("SQLITE_PATH")
+	if sqlitePath == "" {
+		sqlitePath = DefaultSQLitePath
+	}
+
+	firestoreDatabase := strings.TrimSpace(os.Getenv("FIRESTORE_DATABASE"))
+	if firestoreDatabase == "" {
+		firestoreDatabase = DefaultFirestoreDatabase
+	}
+
+	firestoreCollection := strings.TrimSpace(os.Getenv("FIRESTORE_COLLECTION"))
+	if firestoreCollection == "" {
+		firestoreCollection = DefaultFirestoreCollection
+	}
+
+	// Anything but a true value keeps the lenient default
+	firestoreStrict, _ := strconv.ParseBool(strings.TrimSpace(os.Getenv("FIRESTORE_STRICT_DECODE")))
+
+	return StorageConfig{
+		Backend:         backend,
+		ProjectID:       os.Getenv("GOOGLE_CLOUD_PROJECT"),
+		CredentialsPath: os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"),
+		SpannerInstance: os.Getenv("SPANNER_INSTANCE"),
+		SpannerDatabase: os.Getenv("SPANNER_DATABASE"),
+
+		FirestoreDatabase:   firestoreDatabase,
+		FirestoreCollection: firestoreCollection,
+		FirestoreLocation:   strings.TrimSpace(os.Getenv("FIRESTORE_LOCATION")),
+		FirestoreStrict:     firestoreStrict,
+
+		PostgresURL:      os.Getenv("POSTGRES_URL"),
+		CloudSQLInstance: os.Getenv("CLOUD_SQL_INSTANCE"),
+		PostgresUser:     os.Getenv("POSTGRES_USER"),
+		PostgresPassword: os.Getenv("POSTGRES_PASSWORD"),
+		PostgresDatabase: os.Getenv("POSTGRES_DB"),
+
+		SQLitePath: sqlitePath,
+	}
+}
+
+// ValidateFirestore checks the Firestore database ID and collection name
+func (cfg StorageConfig) ValidateFirestore() error {
+	if cfg.FirestoreDatabase != DefaultFirestoreDatabase && !firestoreDatabasePattern.MatchString(cfg.FirestoreDatabase) {
+		return fmt.Errorf("invalid FIRESTORE_DATABASE %q: must be (default) or 4-63 lowercase letters, digits and hyphens, starting with a letter", cfg.FirestoreDatabase)
+	}
+	collection := cfg.FirestoreCollection
+	if collection == "" || collection == "." || collection == ".." || strings.Contains(collection, "/") ||
+		(strings.HasPrefix(collection, "__") && strings.HasSuffix(collection, "__")) {
+		return fmt.Errorf("invalid FIRESTORE_COLLECTION %q: must be a top-level collection ID", collection)
+	}
+	return nil
+}
+
+// firestoreDatabasePattern matches named Firestore database IDs
+var firestoreDatabasePattern = regexp.MustCompile(`^[a-z][a-z0-9-]{2,61}[a-z0-9]$`)
+
+// PostgresDSN returns the PostgreSQL connection string. An explicit POSTGRES_URL takes
+// precedence; otherwise the Cloud SQL unix socket mounted by Cloud Run is used.
+func (cfg StorageConfig) PostgresDSN() (string, error) {
+	if cfg.PostgresURL != "" {
+		return cfg.PostgresURL, nil
+	}
+	if cfg.CloudSQLInstance == "" || cfg.PostgresUser == "" || cfg.PostgresDatabase == "" {
+		return "", fmt.Errorf("POSTGRES_URL or CLOUD_SQL_INSTANCE, POSTGRES_USER and POSTGRES_DB are required for the postgres backend")
+	}
+
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.PostgresUser, cfg.PostgresPassword),
+		Path:     "/" + cfg.PostgresDatabase,
+		RawQuery: url.Values{"host": {"/cloudsql/" + cfg.CloudSQLInstance}}.Encode(),
+	}
+	return dsn.String(), nil
+}
+
+// NewTicketRepository creates the ticket repository for the configured backend
+func NewTicketRepository(cfg StorageConfig) (TicketRepository, error) {
+	switch cfg.Backend {
+	case BackendFirestore:
+		if cfg.ProjectID == "" {
+			return nil, fmt.Errorf("GOOGLE_CLOUD_PROJECT is required for the firestore backend")
+		}
+		if err := cfg.ValidateFirestore(); err != nil {
+			return nil, err
+		}
+		return NewFirestoreService(cfg.ProjectID, cfg.FirestoreDatabase, cfg.FirestoreCollection, cfg.CredentialsPath, cfg.FirestoreStrict)
+	case BackendSpanner:
+		if cfg.ProjectID == "" {
+			return nil, fmt.Errorf("GOOGLE_CLOUD_PROJECT is required for the spanner backend")
+		}
+		if cfg.SpannerInstance == "" || cfg.SpannerDatabase == "" {
+			return nil, fmt.Errorf("SPANNER_INSTANCE and SPANNER_DATABASE are required for the spanner backend")
+		}
+		return NewSpannerService(cfg.ProjectID, cfg.SpannerInstance, cfg.SpannerDatabase, cfg.CredentialsPath)
+	case BackendPostgres:
+		dsn, err := cfg.PostgresDSN()
+		if err != nil {
+			return nil, err
+		}
+		return NewPostgresService(dsn)
+	case BackendSQLite:
+		return NewSQLiteService(cfg.SQLitePath)
+	case BackendMemory:
+		return NewMemoryRepository(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", cfg.Backend)
+	}
+}
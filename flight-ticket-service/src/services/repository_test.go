@@ -0,0 +1,73 @@
+package services
+
+import "testing"
+
+func TestPostgresDSN(t *testing.T) {
+	cfg := StorageConfig{
+		CloudSQLInstance: "my-project:us-east1:tickets",
+		PostgresUser:     "app",
+		PostgresPassword: "p@ss word",
+		PostgresDatabase: "flights",
+	}
+
+	dsn, err := cfg.PostgresDSN()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "postgres://app:p%40ss%20word@/flights?host=%2Fcloudsql%2Fmy-project%3Aus-east1%3Atickets"
+	if dsn != expected {
+		t.Errorf("Expected DSN %s, got %s", expected, dsn)
+	}
+
+	cfg.PostgresURL = "postgres://localhost/flights"
+	if dsn, _ := cfg.PostgresDSN(); dsn != cfg.PostgresURL {
+		t.Errorf("Expected POSTGRES_URL to take precedence, got %s", dsn)
+	}
+}
+
+func TestPostgresDSNRequiresSettings(t *testing.T) {
+	if _, err := (StorageConfig{CloudSQLInstance: "p:r:i"}).PostgresDSN(); err == nil {
+		t.Error("Expected error when user and database are missing")
+	}
+}
+
+func TestNewTicketRepositoryUnknownBackend(t *testing.T) {
+	if _, err := NewTicketRepository(StorageConfig{Backend: "mongodb"}); err == nil {
+		t.Error("Expected error for unknown backend")
+	}
+}
+
+func TestStorageConfigFirestoreDefaults(t *testing.T) {
+	t.Setenv("FIRESTORE_DATABASE", "")
+	t.Setenv("FIRESTORE_COLLECTION", "")
+	cfg := StorageConfigFromEnv()
+	if cfg.FirestoreDatabase != "(default)" || cfg.FirestoreCollection != "flight_tickets" {
+		t.Errorf("Expected the default database and collection, got %q and %q", cfg.FirestoreDatabase, cfg.FirestoreCollection)
+	}
+
+	t.Setenv("FIRESTORE_DATABASE", "tickets-staging")
+	t.Setenv("FIRESTORE_COLLECTION", "staging_tickets")
+	cfg = StorageConfigFromEnv()
+	if cfg.FirestoreDatabase != "tickets-staging" || cfg.FirestoreCollection != "staging_tickets" {
+		t.Errorf("Expected the configured database and collection, got %q and %q", cfg.FirestoreDatabase, cfg.FirestoreCollection)
+	}
+	if err := cfg.ValidateFirestore(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestValidateFirestore(t *testing.T) {
+	invalid := []StorageConfig{
+		{FirestoreDatabase: "Tickets", FirestoreCollection: "flight_tickets"},
+		{FirestoreDatabase: "db", FirestoreCollection: "flight_tickets"},
+		{FirestoreDatabase: "(default)", FirestoreCollection: "tickets/ABC123/notes"},
+		{FirestoreDatabase: "(default)", FirestoreCollection: "__tickets__"},
+		{FirestoreDatabase: "(default)", FirestoreCollection: ""},
+	}
+	for _, cfg := range invalid {
+		if err := cfg.ValidateFirestore(); err == nil {
+			t.Errorf("Expected an error for database %q and collection %q", cfg.FirestoreDatabase, cfg.FirestoreCollection)
+		}
+	}
+}
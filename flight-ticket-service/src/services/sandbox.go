@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+// DefaultSandboxTTL is how long sandbox tickets live when SANDBOX_TTL is unset
+const DefaultSandboxTTL = 24 * time.Hour
+
+// SandboxConfig configures the sandbox, where integration partners and demos
+// book tickets that are kept apart from live tickets and deleted after TTL
+type SandboxConfig struct {
+	Enabled    bool
+	TTL        time.Duration
+	Collection string          // Firestore collection of sandbox tickets
+	Keys       map[string]bool // names of API keys whose requests always use the sandbox
+}
+
+// SandboxConfigFromEnv reads SANDBOX_ENABLED, SANDBOX_TTL, SANDBOX_COLLECTION
+// and SANDBOX_API_KEYS. The collection defaults to the ticket collection with
+// "_sandbox" appended.
+func SandboxConfigFromEnv(storage StorageConfig) (SandboxConfig, error) {
+	config := SandboxConfig{
+		TTL:        DefaultSandboxTTL,
+		Collection: strings.TrimSpace(os.Getenv("SANDBOX_COLLECTION")),
+		Keys:       map[string]bool{},
+	}
+	if value := strings.TrimSpace(os.Getenv("SANDBOX_ENABLED")); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return SandboxConfig{}, fmt.Errorf("invalid SANDBOX_ENABLED %q: must be true or false", value)
+		}
+		config.Enabled = enabled
+	}
+	if value := strings.TrimSpace(os.Getenv("SANDBOX_TTL")); value != "" {
+		ttl, err := time.ParseDuration(value)
+		if err != nil || ttl <= 0 {
+			return SandboxConfig{}, fmt.Errorf("invalid SANDBOX_TTL %q: must be a positive duration such as 24h", value)
+		}
+		config.TTL = ttl
+	}
+	if config.Collection == "" {
+		config.Collection = storage.FirestoreCollection + "_sandbox"
+	}
+	if config.Collection == storage.FirestoreCollection {
+		return SandboxConfig{}, fmt.Errorf("invalid SANDBOX_COLLECTION %q: must differ from the ticket collection", config.Collection)
+	}
+	for _, name := range strings.Split(os.Getenv("SANDBOX_API_KEYS"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			config.Keys[name] = true
+		}
+	}
+	// Keys meant for the sandbox must not reach live tickets because it is off
+	if len(config.Keys) > 0 && !config.Enabled {
+		return SandboxConfig{}, fmt.Errorf("SANDBOX_API_KEYS requires SANDBOX_ENABLED")
+	}
+	return config, nil
+}
+
+// NewSandboxStore opens the store of sandbox tickets: a separate collection
+// for the firestore backend, whose TTL policy deletes them, and memory for
+// the other backends, which StartSandboxSweeper sweeps. The collection is read through the client of the live
+// repository. It is not wrapped in a SandboxRepository.
+func NewSandboxStore(storage StorageConfig, config SandboxConfig, live TicketRepository) (TicketRepository, error) {
+	firestoreService, ok := live.(*FirestoreService)
+	if storage.Backend != BackendFirestore || !ok {
+		return NewMemoryRepository(), nil
+	}
+	storage.FirestoreCollection = config.Collection
+	if err := storage.ValidateFirestore(); err != nil {
+		return nil, fmt.Errorf("invalid sandbox collection: %v", err)
+	}
+	return firestoreService.WithCollection(config.Collection), nil
+}
+
+// SandboxSweepInterval is how often StartSandboxSweeper deletes expired tickets
+const SandboxSweepInterval = 5 * time.Minute
+
+// ExpiredTicketSweeper deletes tickets past their expiry, for stores whose
+// backend does not delete them itself
+type ExpiredTicketSweeper interface {
+	Sweep(ctx context.Context, now time.Time) (int, error)
+}
+
+// StartSandboxSweeper deletes the expired tickets of the sandbox store every
+// SandboxSweepInterval until the returned stop function is called. Firestore
+// stores are left to the collection's TTL policy.
+func StartSandboxSweeper(store TicketRepository) (stop func()) {
+	sweeper, ok := Capability[ExpiredTicketSweeper](store)
+	if !ok {
+		return func() {}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(SandboxSweepInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				swept, err := sweeper.Sweep(ctx, time.Now())
+				if err != nil && ctx.Err() == nil {
+					log.Printf("Sandbox sweeper: %v", err)
+				}
+				if swept > 0 {
+					log.Printf("Deleted %d expired sandbox tickets", swept)
+				}
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// SandboxRepository stamps new tickets with an expiry and hides expired
+// tickets, which stay in storage until the collection's TTL policy or
+// StartSandboxSweeper deletes them. Besides the
+// TicketRepository methods it only offers search, so features built on the
+// other optional interfaces, such as the seat inventory and booking
+// statistics, are off.
+type SandboxRepository struct {
+	TicketRepository
+	ttl time.Duration
+	now func() time.Time
+}
+
+// NewSandboxRepository wraps the store of sandbox tickets
+func NewSandboxRepository(repository TicketRepository, ttl time.Duration) *SandboxRepository {
+	return &SandboxRepository{TicketRepository: repository, ttl: ttl, now: time.Now}
+}
+
+// CreateTicket stores a ticket that expires after the sandbox TTL
+func (s *SandboxRepository) CreateTicket(ctx context.Context, ticket *models.FlightTicket) error {
+	expiresAt := s.now().Add(s.ttl).UTC()
+	ticket.ExpiresAt = &expiresAt
+	return s.TicketRepository.CreateTicket(ctx, ticket)
+}
+
+// GetTicket retrieves a ticket that has not expired
+func (s *SandboxRepository) GetTicket(ctx context.Context, confirmationID string) (*models.FlightTicket, error) {
+	ticket, err := s.TicketRepository.GetTicket(ctx, confirmationID)
+	if err != nil {
+		return nil, err
+	}
+	if s.expired(ticket) {
+		return nil, fmt.Errorf("failed to get ticket: sandbox ticket %s expired", confirmationID)
+	}
+	return ticket, nil
+}
+
+// UpdateTicket updates a ticket that has not expired
+func (s *SandboxRepository) UpdateTicket(ctx context.Context, confirmationID string, updates map[string]interface{}) error {
+	if _, err := s.GetTicket(ctx, confirmationID); err != nil {
+		return err
+	}
+	return s.TicketRepository.UpdateTicket(ctx, confirmationID, updates)
+}
+
+// DeleteTicket cancels a ticket that has not expired
+func (s *SandboxRepository) DeleteTicket(ctx context.Context, confirmationID string) error {
+	if _, err := s.GetTicket(ctx, confirmationID); err != nil {
+		return err
+	}
+	return s.TicketRepository.DeleteTicket(ctx, confirmationID)
+}
+
+// ListTickets retrieves the tickets that have not expired, newest first
+func (s *SandboxRepository) ListTickets(ctx context.Context, limit int) ([]*models.FlightTicket, error) {
+	tickets, err := s.TicketRepository.ListTickets(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	return s.live(tickets), nil
+}
+
+// SearchTickets runs the query on the sandbox store and drops expired tickets
+func (s *SandboxRepository) SearchTickets(ctx context.Context, query models.TicketQuery) ([]*models.FlightTicket, error) {
+	searcher, ok := Capability[TicketSearcher](s.TicketRepository)
+	if !ok {
+		return nil, ErrSearchUnsupported
+	}
+	tickets, err := searcher.SearchTickets(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return s.live(tickets), nil
+}
+
+// live keeps the tickets that have not expired
+func (s *SandboxRepository) live(tickets []*models.FlightTicket) []*models.FlightTicket {
+	live := tickets[:0]
+	for _, ticket := range tickets {
+		if !s.expired(ticket) {
+			live = append(live, ticket)
+		}
+	}
+	return live
+}
+
+// expired reports whether a ticket is past its expiry; tickets without one never expire
+func (s *SandboxRepository) expired(ticket *models.FlightTicket) bool {
+	return ticket.ExpiresAt != nil && !s.now().Before(*ticket.ExpiresAt)
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+func TestSandboxRepositoryExpiresTickets(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	sandbox := NewSandboxRepository(NewMemoryRepository(), time.Hour)
+	sandbox.now = func() time.Time { return now }
+
+	ticket := &models.FlightTicket{ConfirmationID: "SANDBX", Origin: "JFK", Destination: "LAX", Passengers: 1, Status: "CONFIRMED"}
+	if err := sandbox.CreateTicket(ctx, ticket); err != nil {
+		t.Fatalf("Failed to create ticket: %v", err)
+	}
+	if ticket.ExpiresAt == nil || !ticket.ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Fatalf("Expected the ticket to expire after the TTL, got %v", ticket.ExpiresAt)
+	}
+	if _, err := sandbox.GetTicket(ctx, "SANDBX"); err != nil {
+		t.Errorf("Expected the ticket before it expires, got %v", err)
+	}
+
+	now = now.Add(time.Hour)
+	if _, err := sandbox.GetTicket(ctx, "SANDBX"); err == nil {
+		t.Error("Expected an expired ticket not to be found")
+	}
+	if err := sandbox.UpdateTicket(ctx, "SANDBX", map[string]interface{}{"passengers": 2}); err == nil {
+		t.Error("Expected an expired ticket not to be updated")
+	}
+	if tickets, err := sandbox.ListTickets(ctx, 10); err != nil || len(tickets) != 0 {
+		t.Errorf("Expected no tickets after expiry, got %d, %v", len(tickets), err)
+	}
+}
+
+func TestMemoryRepositorySweepsExpiredTickets(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryRepository()
+	sandbox := NewSandboxRepository(store, time.Hour)
+	sandbox.now = func() time.Time { return now }
+
+	for _, id := range []string{"OLDONE", "NEWONE"} {
+		ticket := &models.FlightTicket{ConfirmationID: id, Origin: "JFK", Destination: "LAX", Passengers: 1, Status: "CONFIRMED"}
+		if err := sandbox.CreateTicket(ctx, ticket); err != nil {
+			t.Fatalf("Failed to create ticket: %v", err)
+		}
+		now = now.Add(30 * time.Minute)
+	}
+	if _, ok := Capability[ExpiredTicketSweeper](store); !ok {
+		t.Fatal("Expected the memory store to sweep expired tickets")
+	}
+
+	swept, err := store.Sweep(ctx, now.Add(-time.Minute))
+	if err != nil || swept != 0 {
+		t.Fatalf("Expected nothing swept before expiry, got %d, %v", swept, err)
+	}
+	swept, err = store.Sweep(ctx, now)
+	if err != nil || swept != 1 {
+		t.Fatalf("Expected one ticket swept, got %d, %v", swept, err)
+	}
+	if _, err := store.GetTicket(ctx, "OLDONE"); err == nil {
+		t.Error("Expected the expired ticket to be deleted")
+	}
+	if _, err := store.GetTicket(ctx, "NEWONE"); err != nil {
+		t.Errorf("Expected the live ticket to stay, got %v", err)
+	}
+}
+
+func TestSandboxConfigFromEnv(t *testing.T) {
+	storage := StorageConfig{Backend: BackendFirestore, FirestoreCollection: "flight_tickets"}
+	t.Setenv("SANDBOX_ENABLED", "")
+	t.Setenv("SANDBOX_TTL", "")
+	t.Setenv("SANDBOX_COLLECTION", "")
+	t.Setenv("SANDBOX_API_KEYS", "")
+	config, err := SandboxConfigFromEnv(storage)
+	if err != nil || config.Enabled || config.TTL != DefaultSandboxTTL || config.Collection != "flight_tickets_sandbox" {
+		t.Errorf("Expected the defaults, got %+v, %v", config, err)
+	}
+
+	t.Setenv("SANDBOX_ENABLED", "true")
+	t.Setenv("SANDBOX_TTL", "2h")
+	t.Setenv("SANDBOX_API_KEYS", "partner, demo")
+	config, err = SandboxConfigFromEnv(storage)
+	if err != nil || !config.Enabled || config.TTL != 2*time.Hour || !config.Keys["partner"] || !config.Keys["demo"] {
+		t.Errorf("Expected the sandbox settings, got %+v, %v", config, err)
+	}
+
+	t.Setenv("SANDBOX_COLLECTION", "flight_tickets")
+	if _, err := SandboxConfigFromEnv(storage); err == nil {
+		t.Error("Expected an error when the sandbox shares the ticket collection")
+	}
+	t.Setenv("SANDBOX_COLLECTION", "")
+	t.Setenv("SANDBOX_TTL", "-1h")
+	if _, err := SandboxConfigFromEnv(storage); err == nil {
+		t.Error("Expected an error for a negative TTL")
+	}
+	t.Setenv("SANDBOX_TTL", "")
+	t.Setenv("SANDBOX_ENABLED", "false")
+	if _, err := SandboxConfigFromEnv(storage); err == nil {
+		t.Error("Expected an error for sandbox keys without the sandbox")
+	}
+}
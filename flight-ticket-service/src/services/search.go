@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"flight-ticket-service/src/models"
+)
+
+// TicketSearcher is implemented by storage backends that can filter tickets server-side
+type TicketSearcher interface {
+	// SearchTickets retrieves the tickets matching the query, newest first
+	SearchTickets(ctx context.Context, query models.TicketQuery) ([]*models.FlightTicket, error)
+}
+
+// ErrSearchUnsupported is returned when searching a backend that cannot filter tickets
+var ErrSearchUnsupported = errors.New("ticket search is not supported by the storage backend")
+
+// SearchTickets runs the query on the backend. Backends without search are
+// refused rather than searched by loading every ticket.
+func SearchTickets(ctx context.Context, repository TicketRepository, query models.TicketQuery) ([]*models.FlightTicket, error) {
+	searcher, ok := Capability[TicketSearcher](repository)
+	if !ok {
+		return nil, ErrSearchUnsupported
+	}
+	return searcher.SearchTickets(ctx, query)
+}
+
+// filterTickets keeps the tickets matching the query, newest first, up to the query limit
+func filterTickets(tickets []*models.FlightTicket, query models.TicketQuery) []*models.FlightTicket {
+	var matches []*models.FlightTicket
+	for _, ticket := range tickets {
+		if query.Matches(ticket) {
+			matches = append(matches, ticket)
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].CreatedAt.After(matches[j].CreatedAt)
+	})
+	if query.Limit > 0 && len(matches) > query.Limit {
+		matches = matches[:query.Limit]
+	}
+	return matches
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+func TestMemorySearchTickets(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	created := time.Date(2024, 7, 12, 19, 0, 0, 0, time.UTC)
+	day := time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC)
+	for i, ticket := range []*models.FlightTicket{
+		{ConfirmationID: "AAA111", FlightNumber: "AA1234", DepartureDate: day, Status: "CONFIRMED", Labels: map[string]string{"campaign": "summer"}},
+		{ConfirmationID: "BBB222", FlightNumber: "AA1234", DepartureDate: day, Status: "CANCELLED"},
+		{ConfirmationID: "CCC333", FlightNumber: "AA1234", DepartureDate: day, Status: "CONFIRMED", Labels: map[string]string{"campaign": "summer"}},
+		{ConfirmationID: "DDD444", FlightNumber: "AA1234", DepartureDate: day.AddDate(0, 0, 1), Status: "CONFIRMED"},
+	} {
+		ticket.CreatedAt = created.Add(time.Duration(i) * time.Minute)
+		if err := repo.CreateTicket(ctx, ticket); err != nil {
+			t.Fatalf("Failed to create ticket: %v", err)
+		}
+	}
+
+	tests := []struct {
+		query    models.TicketQuery
+		expected []string
+	}{
+		{models.TicketQuery{FlightNumber: "AA1234", DepartureDate: day}, []string{"CCC333", "BBB222", "AAA111"}},
+		{models.TicketQuery{FlightNumber: "AA1234", DepartureDate: day, Limit: 2}, []string{"CCC333", "BBB222"}},
+		{models.TicketQuery{Status: "CONFIRMED", Labels: map[string]string{"campaign": "summer"}}, []string{"CCC333", "AAA111"}},
+		{models.TicketQuery{FlightNumber: "BA304"}, nil},
+	}
+
+	for _, test := range tests {
+		tickets, err := repo.SearchTickets(ctx, test.query)
+		if err != nil {
+			t.Fatalf("SearchTickets(%+v) failed: %v", test.query, err)
+		}
+		var ids []string
+		for _, ticket := range tickets {
+			ids = append(ids, ticket.ConfirmationID)
+		}
+		if len(ids) != len(test.expected) {
+			t.Errorf("SearchTickets(%+v) = %v, expected %v", test.query, ids, test.expected)
+			continue
+		}
+		for i := range ids {
+			if ids[i] != test.expected[i] {
+				t.Errorf("SearchTickets(%+v) = %v, expected %v", test.query, ids, test.expected)
+				break
+			}
+		}
+	}
+}
+
+func TestSearchTicketsRequiresSearcher(t *testing.T) {
+	repo := &stubRepository{tickets: []*models.FlightTicket{{ConfirmationID: "ABC123", Status: "CONFIRMED"}}}
+	if _, err := SearchTickets(context.Background(), repo, models.TicketQuery{Status: "CONFIRMED"}); !errors.Is(err, ErrSearchUnsupported) {
+		t.Errorf("Expected ErrSearchUnsupported without a searcher, got %v", err)
+	}
+}
+
+func TestSandboxSearchTicketsHidesExpired(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	sandbox := NewSandboxRepository(NewMemoryRepository(), time.Hour)
+	sandbox.now = func() time.Time { return now }
+
+	for _, id := range []string{"OLD111", "NEW222"} {
+		if err := sandbox.CreateTicket(ctx, &models.FlightTicket{ConfirmationID: id, Status: "CONFIRMED", CreatedAt: now}); err != nil {
+			t.Fatalf("Failed to create ticket: %v", err)
+		}
+		now = now.Add(30 * time.Minute)
+	}
+
+	tickets, err := SearchTickets(ctx, sandbox, models.TicketQuery{Status: "CONFIRMED"})
+	if err != nil {
+		t.Fatalf("Failed to search the sandbox: %v", err)
+	}
+	if len(tickets) != 1 || tickets[0].ConfirmationID != "NEW222" {
+		t.Errorf("Expected only the ticket that has not expired, got %d tickets", len(tickets))
+	}
+}
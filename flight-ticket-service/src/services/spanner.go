@@ -0,0 +1,608 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"flight-ticket-service/src/models"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	spannerapi "google.golang.org/api/spanner/v1"
+)
+
+// SpannerSchema is the DDL for the flight tickets table
+var SpannerSchema = []string{
+	`CREATE TABLE flight_tickets (
+	confirmation_id STRING(16) NOT NULL,
+	origin STRING(3) NOT NULL,
+	destination STRING(3) NOT NULL,
+	departure_date TIMESTAMP NOT NULL,
+	departure_time TIMESTAMP NOT NULL,
+	flight_number STRING(16) NOT NULL,
+	passengers INT64 NOT NULL,
+	status STRING(16) NOT NULL,
+	price JSON,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	labels JSON,
+	check_in JSON,
+) PRIMARY KEY (confirmation_id)`,
+	`CREATE INDEX flight_tickets_by_created_at ON flight_tickets(created_at DESC)`,
+}
+
+// spannerColumns lists the ticket columns in row order, with their Spanner type codes
+var spannerColumns = []struct {
+	name     string
+	typeCode string
+}{
+	{"confirmation_id", "STRING"},
+	{"origin", "STRING"},
+	{"destination", "STRING"},
+	{"departure_date", "TIMESTAMP"},
+	{"departure_time", "TIMESTAMP"},
+	{"flight_number", "STRING"},
+	{"passengers", "INT64"},
+	{"status", "STRING"},
+	{"price", "JSON"},
+	{"created_at", "TIMESTAMP"},
+	{"updated_at", "TIMESTAMP"},
+	{"labels", "JSON"},
+	{"check_in", "JSON"},
+}
+
+// Maximum number of idle sessions kept for reuse
+const spannerSessionPoolSize = 10
+
+// spannerLabelKey matches the label keys that may be spliced into a JSON path
+var spannerLabelKey = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+type SpannerService struct {
+	client   *spannerapi.Service
+	database string
+	table    string
+	sessions chan string
+}
+
+// NewSpannerService creates a new Spanner service instance using the Spanner REST API
+func NewSpannerService(projectID, instanceID, databaseID, credentialsPath string) (*SpannerService, error) {
+	ctx := context.Background()
+
+	var opts []option.ClientOption
+	if credentialsPath != "" {
+		// Use service account key file
+		opts = append(opts, option.WithCredentialsFile(credentialsPath))
+	}
+
+	client, err := spannerapi.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Spanner client: %v", err)
+	}
+
+	return &SpannerService{
+		client:   client,
+		database: fmt.Sprintf("projects/%s/instances/%s/databases/%s", projectID, instanceID, databaseID),
+		table:    "flight_tickets",
+		sessions: make(chan string, spannerSessionPoolSize),
+	}, nil
+}
+
+// CreateSchema applies SpannerSchema to the database and waits for the operation to finish
+func (ss *SpannerService) CreateSchema(ctx context.Context) error {
+	op, err := ss.client.Projects.Instances.Databases.UpdateDdl(ss.database, &spannerapi.UpdateDatabaseDdlRequest{
+		Statements: SpannerSchema,
+	}).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("failed to update schema: %v", err)
+	}
+
+	for !op.Done {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+
+		op, err = ss.client.Projects.Instances.Databases.Operations.Get(op.Name).Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("failed to poll schema operation: %v", err)
+		}
+	}
+
+	if op.Error != nil {
+		return fmt.Errorf("schema update failed: %s", op.Error.Message)
+	}
+
+	log.Printf("Applied Spanner schema to %s", ss.database)
+	return nil
+}
+
+// CreateTicket inserts a new flight ticket in a single-use read-write transaction
+func (ss *SpannerService) CreateTicket(ctx context.Context, ticket *models.FlightTicket) error {
+	values, err := spannerTicketValues(ticket)
+	if err != nil {
+		return fmt.Errorf("failed to create ticket: %v", err)
+	}
+
+	columns := make([]string, len(spannerColumns))
+	for i, col := range spannerColumns {
+		columns[i] = col.name
+	}
+
+	err = ss.withSession(ctx, func(session string) error {
+		_, err := ss.client.Projects.Instances.Databases.Sessions.Commit(session, &spannerapi.CommitRequest{
+			SingleUseTransaction: &spannerapi.TransactionOptions{ReadWrite: &spannerapi.ReadWrite{}},
+			Mutations: []*spannerapi.Mutation{{
+				Insert: &spannerapi.Write{
+					Table:   ss.table,
+					Columns: columns,
+					Values:  [][]interface{}{values},
+				},
+			}},
+		}).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create ticket: %v", err)
+	}
+
+	log.Printf("Created ticket with confirmation ID: %s", ticket.ConfirmationID)
+	return nil
+}
+
+// GetTicket retrieves a flight ticket by confirmation ID
+func (ss *SpannerService) GetTicket(ctx context.Context, confirmationID string) (*models.FlightTicket, error) {
+	params, paramTypes, err := spannerParams(map[string]interface{}{"confirmation_id": confirmationID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ticket: %v", err)
+	}
+
+	rs, err := ss.executeSQL(ctx, &spannerapi.ExecuteSqlRequest{
+		Sql:        fmt.Sprintf("SELECT %s FROM %s WHERE confirmation_id = @confirmation_id", spannerColumnList(), ss.table),
+		Params:     params,
+		ParamTypes: paramTypes,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ticket: %v", err)
+	}
+
+	if len(rs.Rows) == 0 {
+		return nil, fmt.Errorf("failed to get ticket: %s not found", confirmationID)
+	}
+
+	ticket, err := spannerDecodeTicket(rs.Rows[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ticket data: %v", err)
+	}
+
+	return ticket, nil
+}
+
+// UpdateTicket updates an existing flight ticket with a DML statement in a read-write transaction
+func (ss *SpannerService) UpdateTicket(ctx context.Context, confirmationID string, updates map[string]interface{}) error {
+	updates["updated_at"] = time.Now()
+
+	var assignments []string
+	for field := range updates {
+		if spannerColumnType(field) == "" || field == "confirmation_id" {
+			return fmt.Errorf("failed to update ticket: unknown field %s", field)
+		}
+		assignments = append(assignments, fmt.Sprintf("%s = @%s", field, field))
+	}
+
+	paramValues := map[string]interface{}{"confirmation_id": confirmationID}
+	for field, value := range updates {
+		paramValues[field] = value
+	}
+
+	params, paramTypes, err := spannerParams(paramValues)
+	if err != nil {
+		return fmt.Errorf("failed to update ticket: %v", err)
+	}
+
+	sql := fmt.Sprintf("UPDATE %s SET %s WHERE confirmation_id = @confirmation_id", ss.table, strings.Join(assignments, ", "))
+
+	err = ss.withSession(ctx, func(session string) error {
+		rs, err := ss.client.Projects.Instances.Databases.Sessions.ExecuteSql(session, &spannerapi.ExecuteSqlRequest{
+			Sql:         sql,
+			Params:      params,
+			ParamTypes:  paramTypes,
+			Seqno:       1,
+			Transaction: &spannerapi.TransactionSelector{Begin: &spannerapi.TransactionOptions{ReadWrite: &spannerapi.ReadWrite{}}},
+		}).Context(ctx).Do()
+		if err != nil {
+			return err
+		}
+
+		if rs.Metadata == nil || rs.Metadata.Transaction == nil {
+			return errors.New("transaction was not started")
+		}
+		txID := rs.Metadata.Transaction.Id
+
+		if rs.Stats == nil || rs.Stats.RowCountExact == 0 {
+			ss.client.Projects.Instances.Databases.Sessions.Rollback(session, &spannerapi.RollbackRequest{TransactionId: txID}).Context(ctx).Do()
+			return fmt.Errorf("%s not found", confirmationID)
+		}
+
+		_, err = ss.client.Projects.Instances.Databases.Sessions.Commit(session, &spannerapi.CommitRequest{
+			TransactionId: txID,
+		}).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update ticket: %v", err)
+	}
+
+	log.Printf("Updated ticket with confirmation ID: %s", confirmationID)
+	return nil
+}
+
+// DeleteTicket deletes a flight ticket (or marks as cancelled)
+func (ss *SpannerService) DeleteTicket(ctx context.Context, confirmationID string) error {
+	// Instead of deleting, we'll mark as cancelled for audit purposes
+	updates := map[string]interface{}{
+		"status": "CANCELLED",
+	}
+
+	return ss.UpdateTicket(ctx, confirmationID, updates)
+}
+
+// ListTickets retrieves flight tickets, newest first
+func (ss *SpannerService) ListTickets(ctx context.Context, limit int) ([]*models.FlightTicket, error) {
+	req := &spannerapi.ExecuteSqlRequest{
+		Sql: fmt.Sprintf("SELECT %s FROM %s ORDER BY created_at DESC", spannerColumnList(), ss.table),
+	}
+
+	if limit > 0 {
+		params, paramTypes, err := spannerParams(map[string]interface{}{"limit": limit})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tickets: %v", err)
+		}
+		req.Sql += " LIMIT @limit"
+		req.Params = params
+		req.ParamTypes = paramTypes
+	}
+
+	rs, err := ss.executeSQL(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tickets: %v", err)
+	}
+
+	var tickets []*models.FlightTicket
+	for _, row := range rs.Rows {
+		ticket, err := spannerDecodeTicket(row)
+		if err != nil {
+			log.Printf("Failed to parse ticket row: %v", err)
+			continue
+		}
+		tickets = append(tickets, ticket)
+	}
+
+	return tickets, nil
+}
+
+// SearchTickets filters tickets in SQL, matching labels with JSON_VALUE
+func (ss *SpannerService) SearchTickets(ctx context.Context, query models.TicketQuery) ([]*models.FlightTicket, error) {
+	var conditions []string
+	values := map[string]interface{}{}
+	keys := make([]string, 0, len(query.Labels))
+	for key := range query.Labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for i, key := range keys {
+		// JSON paths are literals, so only well-formed label keys are spliced in
+		if !spannerLabelKey.MatchString(key) {
+			return nil, fmt.Errorf("failed to search tickets: invalid label key %q", key)
+		}
+		name := fmt.Sprintf("label%d", i)
+		conditions = append(conditions, fmt.Sprintf(`JSON_VALUE(labels, '$."%s"') = @%s`, key, name))
+		values[name] = query.Labels[key]
+	}
+	for _, filter := range []struct{ column, value string }{
+		{"status", query.Status},
+		{"origin", query.Origin},
+		{"destination", query.Destination},
+		{"flight_number", query.FlightNumber},
+	} {
+		if filter.value != "" {
+			conditions = append(conditions, filter.column+" = @"+filter.column)
+			values[filter.column] = filter.value
+		}
+	}
+	if !query.DepartureDate.IsZero() {
+		day := query.DepartureDate.UTC().Truncate(24 * time.Hour)
+		conditions = append(conditions, "departure_date >= @day AND departure_date < @next_day")
+		values["day"], values["next_day"] = day, day.AddDate(0, 0, 1)
+	}
+
+	req := &spannerapi.ExecuteSqlRequest{
+		Sql: fmt.Sprintf("SELECT %s FROM %s", spannerColumnList(), ss.table),
+	}
+	if len(conditions) > 0 {
+		req.Sql += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	req.Sql += " ORDER BY created_at DESC"
+	if query.Limit > 0 {
+		req.Sql += " LIMIT @limit"
+		values["limit"] = query.Limit
+	}
+	if len(values) > 0 {
+		params, paramTypes, err := spannerParams(values)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search tickets: %v", err)
+		}
+		req.Params = params
+		req.ParamTypes = paramTypes
+	}
+
+	rs, err := ss.executeSQL(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search tickets: %v", err)
+	}
+
+	var tickets []*models.FlightTicket
+	for _, row := range rs.Rows {
+		ticket, err := spannerDecodeTicket(row)
+		if err != nil {
+			log.Printf("Failed to parse ticket row: %v", err)
+			continue
+		}
+		tickets = append(tickets, ticket)
+	}
+
+	return tickets, nil
+}
+
+// Close deletes pooled sessions
+func (ss *SpannerService) Close() error {
+	for {
+		select {
+		case session := <-ss.sessions:
+			ss.client.Projects.Instances.Databases.Sessions.Delete(session).Do()
+		default:
+			return nil
+		}
+	}
+}
+
+// executeSQL runs a query in a single-use read-only transaction
+func (ss *SpannerService) executeSQL(ctx context.Context, req *spannerapi.ExecuteSqlRequest) (*spannerapi.ResultSet, error) {
+	var rs *spannerapi.ResultSet
+	err := ss.withSession(ctx, func(session string) error {
+		var err error
+		rs, err = ss.client.Projects.Instances.Databases.Sessions.ExecuteSql(session, req).Context(ctx).Do()
+		return err
+	})
+	return rs, err
+}
+
+// withSession runs fn with a pooled session, replacing the session once if Spanner has expired it
+func (ss *SpannerService) withSession(ctx context.Context, fn func(session string) error) error {
+	for attempt := 0; ; attempt++ {
+		session, err := ss.acquireSession(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = fn(session)
+		if isSessionNotFound(err) && attempt == 0 {
+			continue
+		}
+
+		ss.releaseSession(session)
+		return err
+	}
+}
+
+func (ss *SpannerService) acquireSession(ctx context.Context) (string, error) {
+	select {
+	case session := <-ss.sessions:
+		return session, nil
+	default:
+	}
+
+	session, err := ss.client.Projects.Instances.Databases.Sessions.Create(ss.database, &spannerapi.CreateSessionRequest{}).Context(ctx).Do()
+	if err != nil {
+		return "", fmt.Errorf("failed to create Spanner session: %v", err)
+	}
+	return session.Name, nil
+}
+
+func (ss *SpannerService) releaseSession(session string) {
+	select {
+	case ss.sessions <- session:
+	default:
+		// Pool is full
+		go ss.client.Projects.Instances.Databases.Sessions.Delete(session).Do()
+	}
+}
+
+func isSessionNotFound(err error) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound && strings.Contains(apiErr.Message, "Session not found")
+}
+
+func spannerColumnType(name string) string {
+	for _, col := range spannerColumns {
+		if col.name == name {
+			return col.typeCode
+		}
+	}
+	return ""
+}
+
+func spannerColumnList() string {
+	names := make([]string, len(spannerColumns))
+	for i, col := range spannerColumns {
+		names[i] = col.name
+	}
+	return strings.Join(names, ", ")
+}
+
+// spannerParams encodes query parameters using the Spanner JSON value encoding
+func spannerParams(values map[string]interface{}) (googleapi.RawMessage, map[string]spannerapi.Type, error) {
+	encoded := make(map[string]interface{}, len(values))
+	types := make(map[string]spannerapi.Type, len(values))
+
+	for name, value := range values {
+		v, typeCode, err := spannerEncodeValue(value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parameter %s: %v", name, err)
+		}
+		encoded[name] = v
+		types[name] = spannerapi.Type{Code: typeCode}
+	}
+
+	raw, err := json.Marshal(encoded)
+	if err != nil {
+		return nil, nil, err
+	}
+	return raw, types, nil
+}
+
+// spannerEncodeValue converts a Go value to its Spanner JSON encoding and type code
+func spannerEncodeValue(value interface{}) (interface{}, string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, "STRING", nil
+	case int:
+		return strconv.Itoa(v), "INT64", nil
+	case int64:
+		return strconv.FormatInt(v, 10), "INT64", nil
+	case float64:
+		return v, "FLOAT64", nil
+	case bool:
+		return v, "BOOL", nil
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano), "TIMESTAMP", nil
+	case *models.Price:
+		if v == nil {
+			return nil, "JSON", nil
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, "", err
+		}
+		return string(data), "JSON", nil
+	case *models.CheckInRecord:
+		if v == nil {
+			return nil, "JSON", nil
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, "", err
+		}
+		return string(data), "JSON", nil
+	case map[string]string:
+		if len(v) == 0 {
+			return nil, "JSON", nil
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, "", err
+		}
+		return string(data), "JSON", nil
+	default:
+		return nil, "", fmt.Errorf("unsupported value type %T", value)
+	}
+}
+
+func spannerTicketValues(ticket *models.FlightTicket) ([]interface{}, error) {
+	fields := []interface{}{
+		ticket.ConfirmationID,
+		ticket.Origin,
+		ticket.Destination,
+		ticket.DepartureDate,
+		ticket.DepartureTime,
+		ticket.FlightNumber,
+		ticket.Passengers,
+		ticket.Status,
+		ticket.Price,
+		ticket.CreatedAt,
+		ticket.UpdatedAt,
+		ticket.Labels,
+		ticket.CheckIn,
+	}
+
+	values := make([]interface{}, len(fields))
+	for i, field := range fields {
+		v, _, err := spannerEncodeValue(field)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %v", spannerColumns[i].name, err)
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// spannerDecodeTicket decodes a result row whose columns are in spannerColumns order
+func spannerDecodeTicket(row []interface{}) (*models.FlightTicket, error) {
+	if len(row) != len(spannerColumns) {
+		return nil, fmt.Errorf("expected %d columns, got %d", len(spannerColumns), len(row))
+	}
+
+	str := func(i int) string {
+		s, _ := row[i].(string)
+		return s
+	}
+	timestamp := func(i int) (time.Time, error) {
+		if row[i] == nil {
+			return time.Time{}, nil
+		}
+		return time.Parse(time.RFC3339Nano, str(i))
+	}
+
+	ticket := &models.FlightTicket{
+		ConfirmationID: str(0),
+		Origin:         str(1),
+		Destination:    str(2),
+		FlightNumber:   str(5),
+		Status:         str(7),
+	}
+
+	var err error
+	if ticket.DepartureDate, err = timestamp(3); err != nil {
+		return nil, fmt.Errorf("departure_date: %v", err)
+	}
+	if ticket.DepartureTime, err = timestamp(4); err != nil {
+		return nil, fmt.Errorf("departure_time: %v", err)
+	}
+	if ticket.Passengers, err = strconv.Atoi(str(6)); err != nil {
+		return nil, fmt.Errorf("passengers: %v", err)
+	}
+	if row[8] != nil {
+		var price models.Price
+		if err := json.Unmarshal([]byte(str(8)), &price); err != nil {
+			return nil, fmt.Errorf("price: %v", err)
+		}
+		ticket.Price = &price
+	}
+	if ticket.CreatedAt, err = timestamp(9); err != nil {
+		return nil, fmt.Errorf("created_at: %v", err)
+	}
+	if ticket.UpdatedAt, err = timestamp(10); err != nil {
+		return nil, fmt.Errorf("updated_at: %v", err)
+	}
+	if row[11] != nil {
+		if err := json.Unmarshal([]byte(str(11)), &ticket.Labels); err != nil {
+			return nil, fmt.Errorf("labels: %v", err)
+		}
+	}
+	if row[12] != nil {
+		ticket.CheckIn = &models.CheckInRecord{}
+		if err := json.Unmarshal([]byte(str(12)), ticket.CheckIn); err != nil {
+			return nil, fmt.Errorf("check_in: %v", err)
+		}
+	}
+
+	return ticket, nil
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+func TestSpannerTicketRoundTrip(t *testing.T) {
+	now := time.Date(2024, 7, 12, 19, 0, 0, 0, time.UTC)
+	ticket := &models.FlightTicket{
+		ConfirmationID: "ABC123",
+		Origin:         "JFK",
+		Destination:    "LAX",
+		DepartureDate:  time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC),
+		DepartureTime:  time.Date(2024, 12, 25, 14, 30, 0, 0, time.UTC),
+		FlightNumber:   "AA1234",
+		Passengers:     2,
+		Status:         "CONFIRMED",
+		Price: &models.Price{
+			Amount:       366.16,
+			Currency:     "EUR",
+			BaseAmount:   398,
+			BaseCurrency: "USD",
+			ExchangeRate: 0.92,
+			RateAsOf:     now,
+		},
+		Labels:    map[string]string{"corporate_account": "acme"},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	values, err := spannerTicketValues(ticket)
+	if err != nil {
+		t.Fatalf("Unexpected error encoding ticket: %v", err)
+	}
+
+	// Simulate the JSON round trip through the REST API
+	data, err := json.Marshal(values)
+	if err != nil {
+		t.Fatalf("Unexpected error marshaling row: %v", err)
+	}
+	var row []interface{}
+	if err := json.Unmarshal(data, &row); err != nil {
+		t.Fatalf("Unexpected error unmarshaling row: %v", err)
+	}
+
+	decoded, err := spannerDecodeTicket(row)
+	if err != nil {
+		t.Fatalf("Unexpected error decoding ticket: %v", err)
+	}
+
+	if decoded.ConfirmationID != ticket.ConfirmationID || decoded.Passengers != ticket.Passengers || decoded.Status != ticket.Status {
+		t.Errorf("Decoded ticket %+v does not match original %+v", decoded, ticket)
+	}
+	if !decoded.DepartureTime.Equal(ticket.DepartureTime) || !decoded.CreatedAt.Equal(ticket.CreatedAt) {
+		t.Errorf("Decoded timestamps do not match: %v, %v", decoded.DepartureTime, decoded.CreatedAt)
+	}
+	if decoded.Price == nil || decoded.Price.Amount != 366.16 || decoded.Price.Currency != "EUR" {
+		t.Errorf("Decoded price does not match: %+v", decoded.Price)
+	}
+	if decoded.Labels["corporate_account"] != "acme" {
+		t.Errorf("Decoded labels do not match: %+v", decoded.Labels)
+	}
+}
+
+func TestSpannerDecodeTicketWithoutPrice(t *testing.T) {
+	ticket := &models.FlightTicket{ConfirmationID: "ABC123", Passengers: 1}
+
+	values, err := spannerTicketValues(ticket)
+	if err != nil {
+		t.Fatalf("Unexpected error encoding ticket: %v", err)
+	}
+
+	decoded, err := spannerDecodeTicket(values)
+	if err != nil {
+		t.Fatalf("Unexpected error decoding ticket: %v", err)
+	}
+	if decoded.Price != nil {
+		t.Errorf("Expected nil price, got %+v", decoded.Price)
+	}
+}
+
+func TestSpannerParamsRejectsUnsupportedTypes(t *testing.T) {
+	if _, _, err := spannerParams(map[string]interface{}{"bad": []string{"x"}}); err == nil {
+		t.Error("Expected error for unsupported parameter type")
+	}
+}
@@ -0,0 +1,705 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"flight-ticket-service/src/models"
+
+	_ "modernc.org/sqlite" // Register the pure-Go sqlite driver
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS flight_tickets (
+	confirmation_id TEXT PRIMARY KEY,
+	origin          TEXT NOT NULL,
+	destination     TEXT NOT NULL,
+	departure_date  TEXT NOT NULL,
+	departure_time  TEXT NOT NULL,
+	flight_number   TEXT NOT NULL,
+	passengers      INTEGER NOT NULL,
+	status          TEXT NOT NULL,
+	price           TEXT,
+	created_at      TEXT NOT NULL,
+	updated_at      TEXT NOT NULL,
+	labels          TEXT,
+	check_in        TEXT
+);
+CREATE INDEX IF NOT EXISTS flight_tickets_created_at_idx ON flight_tickets (created_at DESC);
+CREATE TABLE IF NOT EXISTS notification_preferences (
+	confirmation_id TEXT PRIMARY KEY,
+	preferences     TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS ticket_notes (
+	id              TEXT PRIMARY KEY,
+	confirmation_id TEXT NOT NULL,
+	text            TEXT NOT NULL,
+	author          TEXT NOT NULL,
+	created_at      TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS ticket_notes_confirmation_id_idx ON ticket_notes (confirmation_id, created_at);
+CREATE TABLE IF NOT EXISTS saved_views (
+	name TEXT PRIMARY KEY,
+	view TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS seat_inventory (
+	flight_number TEXT NOT NULL,
+	date          TEXT NOT NULL,
+	balance       TEXT NOT NULL,
+	PRIMARY KEY (flight_number, date)
+);
+CREATE TABLE IF NOT EXISTS seat_ledger (
+	id            TEXT PRIMARY KEY,
+	flight_number TEXT NOT NULL,
+	date          TEXT NOT NULL,
+	sequence      INTEGER NOT NULL,
+	entry         TEXT NOT NULL,
+	UNIQUE (flight_number, date, sequence)
+);
+`
+
+const sqliteColumns = "confirmation_id, origin, destination, departure_date, departure_time, flight_number, passengers, status, price, created_at, updated_at, labels, check_in"
+
+// sqliteAddedColumns are added to tables created before the column existed
+var sqliteAddedColumns = []struct {
+	table, column, definition string
+}{
+	{"flight_tickets", "labels", "TEXT"},
+	{"flight_tickets", "check_in", "TEXT"},
+}
+
+// sqliteUpdatableColumns lists the fields UpdateTicket may set
+var sqliteUpdatableColumns = map[string]bool{
+	"origin":         true,
+	"destination":    true,
+	"departure_date": true,
+	"departure_time": true,
+	"flight_number":  true,
+	"passengers":     true,
+	"status":         true,
+	"price":          true,
+	"labels":         true,
+	"check_in":       true,
+	"updated_at":     true,
+}
+
+type SQLiteService struct {
+	db *sql.DB
+}
+
+// NewSQLiteService opens (or creates) a SQLite database file and ensures the schema exists
+func NewSQLiteService(path string) (*SQLiteService, error) {
+	db, err := sql.Open("sqlite", path+"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SQLite database: %v", err)
+	}
+
+	// SQLite allows a single writer; serialize access through one connection
+	db.SetMaxOpenConns(1)
+
+	ss := &SQLiteService{db: db}
+	if err := ss.CreateSchema(context.Background()); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return ss, nil
+}
+
+// CreateSchema creates the tickets, notification preferences, notes, views and seat inventory tables if they do not exist
+func (ss *SQLiteService) CreateSchema(ctx context.Context) error {
+	if _, err := ss.db.ExecContext(ctx, sqliteSchema); err != nil {
+		return fmt.Errorf("failed to create SQLite schema: %v", err)
+	}
+
+	for _, added := range sqliteAddedColumns {
+		var count int
+		err := ss.db.QueryRowContext(ctx,
+			"SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", added.table, added.column).Scan(&count)
+		if err != nil {
+			return fmt.Errorf("failed to inspect SQLite schema: %v", err)
+		}
+		if count > 0 {
+			continue
+		}
+		if _, err := ss.db.ExecContext(ctx, "ALTER TABLE "+added.table+" ADD COLUMN "+added.column+" "+added.definition); err != nil {
+			return fmt.Errorf("failed to add SQLite column %s.%s: %v", added.table, added.column, err)
+		}
+		log.Printf("Added column %s to SQLite table %s", added.column, added.table)
+	}
+	return nil
+}
+
+// CreateTicket creates a new flight ticket
+func (ss *SQLiteService) CreateTicket(ctx context.Context, ticket *models.FlightTicket) error {
+	price, err := sqliteEncodeValue(ticket.Price)
+	if err != nil {
+		return fmt.Errorf("failed to create ticket: %v", err)
+	}
+	labels, err := sqliteEncodeValue(ticket.Labels)
+	if err != nil {
+		return fmt.Errorf("failed to create ticket: %v", err)
+	}
+	checkIn, err := sqliteEncodeValue(ticket.CheckIn)
+	if err != nil {
+		return fmt.Errorf("failed to create ticket: %v", err)
+	}
+
+	_, err = ss.db.ExecContext(ctx,
+		"INSERT INTO flight_tickets ("+sqliteColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		ticket.ConfirmationID,
+		ticket.Origin,
+		ticket.Destination,
+		sqliteTime(ticket.DepartureDate),
+		sqliteTime(ticket.DepartureTime),
+		ticket.FlightNumber,
+		ticket.Passengers,
+		ticket.Status,
+		price,
+		sqliteTime(ticket.CreatedAt),
+		sqliteTime(ticket.UpdatedAt),
+		labels,
+		checkIn,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create ticket: %v", err)
+	}
+
+	log.Printf("Created ticket with confirmation ID: %s", ticket.ConfirmationID)
+	return nil
+}
+
+// GetTicket retrieves a flight ticket by confirmation ID
+func (ss *SQLiteService) GetTicket(ctx context.Context, confirmationID string) (*models.FlightTicket, error) {
+	row := ss.db.QueryRowContext(ctx, "SELECT "+sqliteColumns+" FROM flight_tickets WHERE confirmation_id = ?", confirmationID)
+
+	ticket, err := sqliteScanTicket(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get ticket: %s not found", confirmationID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ticket: %v", err)
+	}
+
+	return ticket, nil
+}
+
+// UpdateTicket updates an existing flight ticket
+func (ss *SQLiteService) UpdateTicket(ctx context.Context, confirmationID string, updates map[string]interface{}) error {
+	updates["updated_at"] = time.Now()
+
+	var assignments []string
+	var args []interface{}
+	for field, value := range updates {
+		if !sqliteUpdatableColumns[field] {
+			return fmt.Errorf("failed to update ticket: unknown field %s", field)
+		}
+
+		encoded, err := sqliteEncodeValue(value)
+		if err != nil {
+			return fmt.Errorf("failed to update ticket: %s: %v", field, err)
+		}
+		assignments = append(assignments, field+" = ?")
+		args = append(args, encoded)
+	}
+	args = append(args, confirmationID)
+
+	result, err := ss.db.ExecContext(ctx,
+		"UPDATE flight_tickets SET "+strings.Join(assignments, ", ")+" WHERE confirmation_id = ?",
+		args...,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update ticket: %v", err)
+	}
+
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return fmt.Errorf("failed to update ticket: %s not found", confirmationID)
+	}
+
+	log.Printf("Updated ticket with confirmation ID: %s", confirmationID)
+	return nil
+}
+
+// DeleteTicket deletes a flight ticket (or marks as cancelled)
+func (ss *SQLiteService) DeleteTicket(ctx context.Context, confirmationID string) error {
+	// Instead of deleting, we'll mark as cancelled for audit purposes
+	updates := map[string]interface{}{
+		"status": "CANCELLED",
+	}
+
+	return ss.UpdateTicket(ctx, confirmationID, updates)
+}
+
+// ListTickets retrieves flight tickets, newest first
+func (ss *SQLiteService) ListTickets(ctx context.Context, limit int) ([]*models.FlightTicket, error) {
+	query := "SELECT " + sqliteColumns + " FROM flight_tickets ORDER BY created_at DESC"
+	var args []interface{}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	tickets, err := ss.queryTickets(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tickets: %v", err)
+	}
+	return tickets, nil
+}
+
+// SearchTickets filters tickets in SQL, matching labels with json_extract
+func (ss *SQLiteService) SearchTickets(ctx context.Context, query models.TicketQuery) ([]*models.FlightTicket, error) {
+	var conditions []string
+	var args []interface{}
+	for key, value := range query.Labels {
+		conditions = append(conditions, "json_extract(labels, ?) = ?")
+		args = append(args, `$."`+key+`"`, value)
+	}
+	for _, filter := range []struct{ column, value string }{
+		{"status", query.Status},
+		{"origin", query.Origin},
+		{"destination", query.Destination},
+		{"flight_number", query.FlightNumber},
+	} {
+		if filter.value != "" {
+			conditions = append(conditions, filter.column+" = ?")
+			args = append(args, filter.value)
+		}
+	}
+	if !query.DepartureDate.IsZero() {
+		day := query.DepartureDate.UTC().Truncate(24 * time.Hour)
+		conditions = append(conditions, "departure_date >= ? AND departure_date < ?")
+		args = append(args, sqliteTime(day), sqliteTime(day.AddDate(0, 0, 1)))
+	}
+
+	sqlQuery := "SELECT " + sqliteColumns + " FROM flight_tickets"
+	if len(conditions) > 0 {
+		sqlQuery += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	sqlQuery += " ORDER BY created_at DESC"
+	if query.Limit > 0 {
+		sqlQuery += " LIMIT ?"
+		args = append(args, query.Limit)
+	}
+
+	tickets, err := ss.queryTickets(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search tickets: %v", err)
+	}
+	return tickets, nil
+}
+
+// GetView returns a view by name, or nil when it does not exist
+func (ss *SQLiteService) GetView(ctx context.Context, name string) (*models.SavedView, error) {
+	var data string
+	err := ss.db.QueryRowContext(ctx, "SELECT view FROM saved_views WHERE name = ?", name).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get view: %v", err)
+	}
+
+	var view models.SavedView
+	if err := json.Unmarshal([]byte(data), &view); err != nil {
+		return nil, fmt.Errorf("failed to parse view %s: %v", name, err)
+	}
+	return &view, nil
+}
+
+// SaveView creates or replaces a view
+func (ss *SQLiteService) SaveView(ctx context.Context, view *models.SavedView) error {
+	data, err := json.Marshal(view)
+	if err != nil {
+		return fmt.Errorf("failed to encode view: %v", err)
+	}
+
+	_, err = ss.db.ExecContext(ctx,
+		"INSERT INTO saved_views (name, view) VALUES (?, ?) ON CONFLICT (name) DO UPDATE SET view = excluded.view",
+		view.Name, string(data))
+	if err != nil {
+		return fmt.Errorf("failed to save view: %v", err)
+	}
+
+	log.Printf("Saved view %s", view.Name)
+	return nil
+}
+
+// ListViews retrieves every view, ordered by name
+func (ss *SQLiteService) ListViews(ctx context.Context) ([]*models.SavedView, error) {
+	rows, err := ss.db.QueryContext(ctx, "SELECT name, view FROM saved_views ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list views: %v", err)
+	}
+	defer rows.Close()
+
+	var views []*models.SavedView
+	for rows.Next() {
+		var name, data string
+		if err := rows.Scan(&name, &data); err != nil {
+			return nil, fmt.Errorf("failed to list views: %v", err)
+		}
+		var view models.SavedView
+		if err := json.Unmarshal([]byte(data), &view); err != nil {
+			log.Printf("Failed to parse view %s: %v", name, err)
+			continue
+		}
+		views = append(views, &view)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list views: %v", err)
+	}
+
+	return views, nil
+}
+
+// DeleteView removes a view
+func (ss *SQLiteService) DeleteView(ctx context.Context, name string) error {
+	if _, err := ss.db.ExecContext(ctx, "DELETE FROM saved_views WHERE name = ?", name); err != nil {
+		return fmt.Errorf("failed to delete view: %v", err)
+	}
+
+	log.Printf("Deleted view %s", name)
+	return nil
+}
+
+// PostInventory applies the entries inside a transaction, so the balance checks see every earlier posting
+func (ss *SQLiteService) PostInventory(ctx context.Context, entries []*models.InventoryEntry) ([]*models.InventoryEntry, error) {
+	tx, err := ss.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to post inventory: %v", err)
+	}
+	defer tx.Rollback()
+
+	balances := make(map[string]*models.InventoryBalance)
+	for _, entry := range entries {
+		key := inventoryKey(entry.FlightNumber, entry.Date)
+		if _, loaded := balances[key]; loaded {
+			continue
+		}
+		balance, err := sqliteGetInventory(ctx, tx, entry.FlightNumber, entry.Date)
+		if err != nil {
+			return nil, err
+		}
+		balances[key] = balance
+	}
+
+	posted, err := postInventoryEntries(balances, entries, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range posted {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode inventory entry: %v", err)
+		}
+		_, err = tx.ExecContext(ctx,
+			"INSERT INTO seat_ledger (id, flight_number, date, sequence, entry) VALUES (?, ?, ?, ?, ?)",
+			entry.ID, entry.FlightNumber, entry.Date, entry.Sequence, string(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to post inventory: %v", err)
+		}
+	}
+	for _, balance := range balances {
+		if balance.Entries == 0 {
+			continue
+		}
+		data, err := json.Marshal(balance)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode inventory balance: %v", err)
+		}
+		_, err = tx.ExecContext(ctx,
+			"INSERT INTO seat_inventory (flight_number, date, balance) VALUES (?, ?, ?) ON CONFLICT (flight_number, date) DO UPDATE SET balance = excluded.balance",
+			balance.FlightNumber, balance.Date, string(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to post inventory: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to post inventory: %v", err)
+	}
+	return posted, nil
+}
+
+// GetInventory returns a flight's balance, or nil when the flight has no ledger
+func (ss *SQLiteService) GetInventory(ctx context.Context, flightNumber, date string) (*models.InventoryBalance, error) {
+	balance, err := sqliteGetInventory(ctx, ss.db, flightNumber, date)
+	if err != nil || balance.Entries == 0 {
+		return nil, err
+	}
+	return balance, nil
+}
+
+// ListInventoryEntries retrieves a flight's ledger, oldest first
+func (ss *SQLiteService) ListInventoryEntries(ctx context.Context, flightNumber, date string) ([]*models.InventoryEntry, error) {
+	rows, err := ss.db.QueryContext(ctx,
+		"SELECT entry FROM seat_ledger WHERE flight_number = ? AND date = ? ORDER BY sequence", flightNumber, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list inventory entries: %v", err)
+	}
+	defer rows.Close()
+
+	var entries []*models.InventoryEntry
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to list inventory entries: %v", err)
+		}
+		var entry models.InventoryEntry
+		if err := json.Unmarshal([]byte(data), &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse inventory entry: %v", err)
+		}
+		entries = append(entries, &entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list inventory entries: %v", err)
+	}
+
+	return entries, nil
+}
+
+type sqliteQueryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// sqliteGetInventory reads a flight's balance, returning an empty one when the flight has no ledger
+func sqliteGetInventory(ctx context.Context, db sqliteQueryer, flightNumber, date string) (*models.InventoryBalance, error) {
+	var data string
+	err := db.QueryRowContext(ctx, "SELECT balance FROM seat_inventory WHERE flight_number = ? AND date = ?", flightNumber, date).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.NewInventoryBalance(flightNumber, date), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get inventory: %v", err)
+	}
+
+	var balance models.InventoryBalance
+	if err := json.Unmarshal([]byte(data), &balance); err != nil {
+		return nil, fmt.Errorf("failed to parse inventory of %s %s: %v", flightNumber, date, err)
+	}
+	if balance.Held == nil {
+		balance.Held = map[string]int{}
+	}
+	return &balance, nil
+}
+
+// queryTickets runs a ticket SELECT, skipping rows that cannot be parsed
+func (ss *SQLiteService) queryTickets(ctx context.Context, query string, args ...interface{}) ([]*models.FlightTicket, error) {
+	rows, err := ss.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tickets []*models.FlightTicket
+	for rows.Next() {
+		ticket, err := sqliteScanTicket(rows)
+		if err != nil {
+			log.Printf("Failed to parse ticket row: %v", err)
+			continue
+		}
+		tickets = append(tickets, ticket)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tickets, nil
+}
+
+// GetNotificationPreferences returns the ticket's preferences, or nil when none were saved
+func (ss *SQLiteService) GetNotificationPreferences(ctx context.Context, confirmationID string) (*models.NotificationPreferences, error) {
+	var data string
+	err := ss.db.QueryRowContext(ctx, "SELECT preferences FROM notification_preferences WHERE confirmation_id = ?", confirmationID).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification preferences: %v", err)
+	}
+
+	var preferences models.NotificationPreferences
+	if err := json.Unmarshal([]byte(data), &preferences); err != nil {
+		return nil, fmt.Errorf("failed to parse notification preferences: %v", err)
+	}
+	return &preferences, nil
+}
+
+// SaveNotificationPreferences replaces the ticket's preferences
+func (ss *SQLiteService) SaveNotificationPreferences(ctx context.Context, preferences *models.NotificationPreferences) error {
+	data, err := json.Marshal(preferences)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification preferences: %v", err)
+	}
+
+	_, err = ss.db.ExecContext(ctx,
+		"INSERT INTO notification_preferences (confirmation_id, preferences) VALUES (?, ?) ON CONFLICT (confirmation_id) DO UPDATE SET preferences = excluded.preferences",
+		preferences.ConfirmationID, string(data))
+	if err != nil {
+		return fmt.Errorf("failed to save notification preferences: %v", err)
+	}
+
+	log.Printf("Saved notification preferences for ticket %s", preferences.ConfirmationID)
+	return nil
+}
+
+// CreateNote stores a note for a ticket
+func (ss *SQLiteService) CreateNote(ctx context.Context, note *models.TicketNote) error {
+	_, err := ss.db.ExecContext(ctx,
+		"INSERT INTO ticket_notes (id, confirmation_id, text, author, created_at) VALUES (?, ?, ?, ?, ?)",
+		note.ID, note.ConfirmationID, note.Text, note.Author, note.CreatedAt.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return fmt.Errorf("failed to create note: %v", err)
+	}
+
+	log.Printf("Created note %s for ticket %s", note.ID, note.ConfirmationID)
+	return nil
+}
+
+// ListNotes retrieves the notes of a ticket, oldest first
+func (ss *SQLiteService) ListNotes(ctx context.Context, confirmationID string) ([]*models.TicketNote, error) {
+	rows, err := ss.db.QueryContext(ctx,
+		"SELECT id, confirmation_id, text, author, created_at FROM ticket_notes WHERE confirmation_id = ? ORDER BY created_at, id", confirmationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notes: %v", err)
+	}
+	defer rows.Close()
+
+	var notes []*models.TicketNote
+	for rows.Next() {
+		var note models.TicketNote
+		var createdAt string
+		if err := rows.Scan(&note.ID, &note.ConfirmationID, &note.Text, &note.Author, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to list notes: %v", err)
+		}
+		if note.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+			return nil, fmt.Errorf("failed to parse note %s: %v", note.ID, err)
+		}
+		notes = append(notes, &note)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list notes: %v", err)
+	}
+
+	return notes, nil
+}
+
+// Close closes the database
+func (ss *SQLiteService) Close() error {
+	return ss.db.Close()
+}
+
+type sqliteScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func sqliteScanTicket(row sqliteScanner) (*models.FlightTicket, error) {
+	var ticket models.FlightTicket
+	var departureDate, departureTime, createdAt, updatedAt string
+	var price, labels, checkIn sql.NullString
+
+	err := row.Scan(
+		&ticket.ConfirmationID,
+		&ticket.Origin,
+		&ticket.Destination,
+		&departureDate,
+		&departureTime,
+		&ticket.FlightNumber,
+		&ticket.Passengers,
+		&ticket.Status,
+		&price,
+		&createdAt,
+		&updatedAt,
+		&labels,
+		&checkIn,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, field := range []struct {
+		value string
+		dest  *time.Time
+	}{
+		{departureDate, &ticket.DepartureDate},
+		{departureTime, &ticket.DepartureTime},
+		{createdAt, &ticket.CreatedAt},
+		{updatedAt, &ticket.UpdatedAt},
+	} {
+		if *field.dest, err = time.Parse(time.RFC3339Nano, field.value); err != nil {
+			return nil, fmt.Errorf("invalid timestamp %q: %v", field.value, err)
+		}
+	}
+
+	if price.Valid && price.String != "" {
+		ticket.Price = &models.Price{}
+		if err := json.Unmarshal([]byte(price.String), ticket.Price); err != nil {
+			return nil, fmt.Errorf("invalid price: %v", err)
+		}
+	}
+
+	if labels.Valid && labels.String != "" {
+		if err := json.Unmarshal([]byte(labels.String), &ticket.Labels); err != nil {
+			return nil, fmt.Errorf("invalid labels: %v", err)
+		}
+	}
+
+	if checkIn.Valid && checkIn.String != "" {
+		ticket.CheckIn = &models.CheckInRecord{}
+		if err := json.Unmarshal([]byte(checkIn.String), ticket.CheckIn); err != nil {
+			return nil, fmt.Errorf("invalid check-in: %v", err)
+		}
+	}
+
+	return &ticket, nil
+}
+
+// sqliteTime formats timestamps so that lexical order matches chronological order
+func sqliteTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000000000Z07:00")
+}
+
+func sqliteEncodeValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return sqliteTime(v), nil
+	case *models.Price:
+		if v == nil {
+			return nil, nil
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return string(data), nil
+	case map[string]string:
+		if len(v) == 0 {
+			return nil, nil
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return string(data), nil
+	case *models.CheckInRecord:
+		if v == nil {
+			return nil, nil
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return string(data), nil
+	case string, int, int64, float64, bool:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("unsupported value type %T", value)
+	}
+}
@@ -0,0 +1,250 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+func TestSQLiteServiceCRUD(t *testing.T) {
+	ctx := context.Background()
+
+	ss, err := NewSQLiteService(filepath.Join(t.TempDir(), "tickets.db"))
+	if err != nil {
+		t.Fatalf("Unexpected error opening database: %v", err)
+	}
+	defer ss.Close()
+
+	now := time.Date(2024, 7, 12, 19, 0, 0, 0, time.UTC)
+	ticket := &models.FlightTicket{
+		ConfirmationID: "ABC123",
+		Origin:         "JFK",
+		Destination:    "LAX",
+		DepartureDate:  time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC),
+		DepartureTime:  time.Date(2024, 12, 25, 14, 30, 0, 0, time.UTC),
+		FlightNumber:   "AA1234",
+		Passengers:     2,
+		Status:         "CONFIRMED",
+		Price:          &models.Price{Amount: 398, Currency: "USD", BaseAmount: 398, BaseCurrency: "USD", ExchangeRate: 1},
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := ss.CreateTicket(ctx, ticket); err != nil {
+		t.Fatalf("Unexpected error creating ticket: %v", err)
+	}
+
+	got, err := ss.GetTicket(ctx, "ABC123")
+	if err != nil {
+		t.Fatalf("Unexpected error getting ticket: %v", err)
+	}
+	if got.Origin != "JFK" || got.Passengers != 2 || !got.DepartureTime.Equal(ticket.DepartureTime) {
+		t.Errorf("Retrieved ticket %+v does not match original", got)
+	}
+	if got.Price == nil || got.Price.Amount != 398 {
+		t.Errorf("Retrieved price does not match: %+v", got.Price)
+	}
+
+	if err := ss.UpdateTicket(ctx, "ABC123", map[string]interface{}{"passengers": 3}); err != nil {
+		t.Fatalf("Unexpected error updating ticket: %v", err)
+	}
+	if err := ss.DeleteTicket(ctx, "ABC123"); err != nil {
+		t.Fatalf("Unexpected error cancelling ticket: %v", err)
+	}
+
+	got, err = ss.GetTicket(ctx, "ABC123")
+	if err != nil {
+		t.Fatalf("Unexpected error getting ticket: %v", err)
+	}
+	if got.Passengers != 3 || got.Status != "CANCELLED" {
+		t.Errorf("Expected 3 passengers and CANCELLED status, got %d and %s", got.Passengers, got.Status)
+	}
+
+	if _, err := ss.GetTicket(ctx, "ZZZ999"); err == nil {
+		t.Error("Expected error for missing ticket")
+	}
+	if err := ss.UpdateTicket(ctx, "ZZZ999", map[string]interface{}{"status": "CONFIRMED"}); err == nil {
+		t.Error("Expected error updating missing ticket")
+	}
+}
+
+func TestSQLiteListTicketsNewestFirst(t *testing.T) {
+	ctx := context.Background()
+
+	ss, err := NewSQLiteService(filepath.Join(t.TempDir(), "tickets.db"))
+	if err != nil {
+		t.Fatalf("Unexpected error opening database: %v", err)
+	}
+	defer ss.Close()
+
+	base := time.Date(2024, 7, 12, 19, 0, 0, 0, time.UTC)
+	for i, id := range []string{"AAA111", "BBB222", "CCC333"} {
+		created := base.Add(time.Duration(i) * time.Minute)
+		ticket := &models.FlightTicket{ConfirmationID: id, Passengers: 1, Status: "CONFIRMED", CreatedAt: created, UpdatedAt: created}
+		if err := ss.CreateTicket(ctx, ticket); err != nil {
+			t.Fatalf("Unexpected error creating ticket: %v", err)
+		}
+	}
+
+	tickets, err := ss.ListTickets(ctx, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error listing tickets: %v", err)
+	}
+	if len(tickets) != 2 || tickets[0].ConfirmationID != "CCC333" || tickets[1].ConfirmationID != "BBB222" {
+		t.Errorf("Unexpected list order: %+v", tickets)
+	}
+
+	if all, _ := ss.ListTickets(ctx, 0); len(all) != 3 {
+		t.Errorf("Expected 3 tickets with no limit, got %d", len(all))
+	}
+}
+
+func TestSQLiteNotesOldestFirst(t *testing.T) {
+	ctx := context.Background()
+
+	ss, err := NewSQLiteService(filepath.Join(t.TempDir(), "tickets.db"))
+	if err != nil {
+		t.Fatalf("Unexpected error opening database: %v", err)
+	}
+	defer ss.Close()
+
+	base := time.Date(2024, 7, 12, 19, 0, 0, 0, time.UTC)
+	for i, text := range []string{"Wheelchair at JFK", "Rebooked after delay"} {
+		note := &models.TicketNote{ID: text[:4], ConfirmationID: "ABC123", Text: text, Author: "desk", CreatedAt: base.Add(time.Duration(i) * time.Minute)}
+		if err := ss.CreateNote(ctx, note); err != nil {
+			t.Fatalf("Unexpected error creating note: %v", err)
+		}
+	}
+
+	notes, err := ss.ListNotes(ctx, "ABC123")
+	if err != nil {
+		t.Fatalf("Unexpected error listing notes: %v", err)
+	}
+	if len(notes) != 2 || notes[0].Text != "Wheelchair at JFK" || notes[1].Author != "desk" || !notes[1].CreatedAt.Equal(base.Add(time.Minute)) {
+		t.Errorf("Unexpected notes: %+v", notes)
+	}
+
+	if others, _ := ss.ListNotes(ctx, "XYZ789"); len(others) != 0 {
+		t.Errorf("Expected no notes for another ticket, got %d", len(others))
+	}
+}
+
+func TestSQLiteSearchTicketsByLabel(t *testing.T) {
+	ctx := context.Background()
+
+	ss, err := NewSQLiteService(filepath.Join(t.TempDir(), "tickets.db"))
+	if err != nil {
+		t.Fatalf("Unexpected error opening database: %v", err)
+	}
+	defer ss.Close()
+
+	base := time.Date(2024, 7, 12, 19, 0, 0, 0, time.UTC)
+	for i, labels := range []map[string]string{
+		{"corporate_account": "acme", "campaign": "summer"},
+		{"corporate_account": "acme"},
+		{"corporate_account": "globex"},
+		nil,
+	} {
+		created := base.Add(time.Duration(i) * time.Minute)
+		ticket := &models.FlightTicket{ConfirmationID: fmt.Sprintf("LBL00%d", i), Origin: "JFK", Passengers: 1, Status: "CONFIRMED", Labels: labels, CreatedAt: created, UpdatedAt: created}
+		if err := ss.CreateTicket(ctx, ticket); err != nil {
+			t.Fatalf("Unexpected error creating ticket: %v", err)
+		}
+	}
+
+	tickets, err := ss.SearchTickets(ctx, models.TicketQuery{Labels: map[string]string{"corporate_account": "acme"}, Origin: "JFK"})
+	if err != nil {
+		t.Fatalf("Unexpected error searching tickets: %v", err)
+	}
+	if len(tickets) != 2 || tickets[0].ConfirmationID != "LBL001" || tickets[1].Labels["campaign"] != "summer" {
+		t.Errorf("Unexpected search results: %+v", tickets)
+	}
+
+	// Replacing labels with an empty map clears them
+	if err := ss.UpdateTicket(ctx, "LBL000", map[string]interface{}{"labels": map[string]string{}}); err != nil {
+		t.Fatalf("Unexpected error updating labels: %v", err)
+	}
+	if got, _ := ss.GetTicket(ctx, "LBL000"); got == nil || got.Labels != nil {
+		t.Errorf("Expected labels to be cleared, got %+v", got)
+	}
+}
+
+func TestSQLiteViews(t *testing.T) {
+	ctx := context.Background()
+
+	ss, err := NewSQLiteService(filepath.Join(t.TempDir(), "tickets.db"))
+	if err != nil {
+		t.Fatalf("Unexpected error opening database: %v", err)
+	}
+	defer ss.Close()
+
+	for _, name := range []string{"jfk-today", "acme"} {
+		view := &models.SavedView{Name: name, Filters: models.ViewFilters{Origin: "JFK", DepartureDate: models.DepartureToday}}
+		if err := ss.SaveView(ctx, view); err != nil {
+			t.Fatalf("Unexpected error saving view: %v", err)
+		}
+	}
+
+	got, err := ss.GetView(ctx, "jfk-today")
+	if err != nil || got == nil || got.Filters.DepartureDate != models.DepartureToday {
+		t.Fatalf("Unexpected view %+v (err %v)", got, err)
+	}
+	if views, _ := ss.ListViews(ctx); len(views) != 2 || views[0].Name != "acme" {
+		t.Errorf("Unexpected views: %+v", views)
+	}
+
+	if err := ss.DeleteView(ctx, "acme"); err != nil {
+		t.Fatalf("Unexpected error deleting view: %v", err)
+	}
+	if missing, err := ss.GetView(ctx, "acme"); err != nil || missing != nil {
+		t.Errorf("Expected deleted view to be gone, got %+v (err %v)", missing, err)
+	}
+}
+
+func TestSQLitePostInventory(t *testing.T) {
+	ctx := context.Background()
+
+	ss, err := NewSQLiteService(filepath.Join(t.TempDir(), "tickets.db"))
+	if err != nil {
+		t.Fatalf("Unexpected error opening database: %v", err)
+	}
+	defer ss.Close()
+
+	if balance, err := ss.GetInventory(ctx, "AA1234", "2024-12-25"); err != nil || balance != nil {
+		t.Fatalf("Expected no inventory, got %+v, %v", balance, err)
+	}
+
+	inventory := NewSeatInventory(ss)
+	if _, err := inventory.Adjust(ctx, "AA1234", "2024-12-25", 2, "Opening sale", "admin"); err != nil {
+		t.Fatalf("Unexpected error adjusting: %v", err)
+	}
+	ticket := &models.FlightTicket{
+		ConfirmationID: "ABC123",
+		FlightNumber:   "AA1234",
+		DepartureDate:  time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC),
+		Passengers:     2,
+		Status:         "CONFIRMED",
+	}
+	if err := inventory.Book(ctx, ticket, "desk"); err != nil {
+		t.Fatalf("Unexpected error booking: %v", err)
+	}
+	ticket.ConfirmationID = "DEF456"
+	if err := inventory.Book(ctx, ticket, "desk"); !errors.Is(err, ErrInsufficientSeats) {
+		t.Fatalf("Expected ErrInsufficientSeats, got %v", err)
+	}
+
+	balance, entries, err := inventory.Statement(ctx, "AA1234", "2024-12-25")
+	if err != nil {
+		t.Fatalf("Unexpected error getting statement: %v", err)
+	}
+	if balance.Available != 0 || balance.Sold != 2 || len(entries) != 2 {
+		t.Errorf("Unexpected balance %+v with %d entries", balance, len(entries))
+	}
+	if replayed := ReplayInventory("AA1234", "2024-12-25", entries); !sameInventory(balance, replayed) {
+		t.Errorf("Stored balance %+v does not match replayed ledger %+v", balance, replayed)
+	}
+}
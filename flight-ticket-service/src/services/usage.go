@@ -0,0 +1,681 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"flight-ticket-service/src/models"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Firestore pricing per 100,000 operations (USD)
+var firestorePricing = map[string]models.FirestorePricing{
+	"regional":     {Tier: "regional", Read: 0.03, Write: 0.09, Delete: 0.01},
+	"multi-region": {Tier: "multi-region", Read: 0.06, Write: 0.18, Delete: 0.02},
+}
+
+// Firestore free tier, per day
+const (
+	firestoreFreeReads   = 50000
+	firestoreFreeWrites  = 20000
+	firestoreFreeDeletes = 20000
+)
+
+const daysPerMonth = 30
+
+// ErrOperationBudgetExceeded is returned by the repository once a request has
+// used up its budget of document operations
+var ErrOperationBudgetExceeded = errors.New("request exceeded its storage operation budget")
+
+// Default per-request operation budget, far above what any endpoint needs for a
+// single page of results
+const (
+	DefaultRequestMaxReads  = 10000
+	DefaultRequestMaxWrites = 1000
+)
+
+// OperationBudget caps the document operations of a single request. Deletes
+// count against Writes. A zero limit is unlimited.
+type OperationBudget struct {
+	Reads  int64
+	Writes int64
+}
+
+// OperationBudgetFromEnv reads FIRESTORE_REQUEST_MAX_READS and FIRESTORE_REQUEST_MAX_WRITES
+func OperationBudgetFromEnv() (OperationBudget, error) {
+	budget := OperationBudget{Reads: DefaultRequestMaxReads, Writes: DefaultRequestMaxWrites}
+	for _, setting := range []struct {
+		name  string
+		limit *int64
+	}{
+		{"FIRESTORE_REQUEST_MAX_READS", &budget.Reads},
+		{"FIRESTORE_REQUEST_MAX_WRITES", &budget.Writes},
+	} {
+		value := os.Getenv(setting.name)
+		if value == "" {
+			continue
+		}
+		limit, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || limit < 0 {
+			return OperationBudget{}, fmt.Errorf("invalid %s %q: must be a non-negative integer, 0 for no limit", setting.name, value)
+		}
+		*setting.limit = limit
+	}
+	return budget, nil
+}
+
+// allows reports whether counts are within the budget
+func (b OperationBudget) allows(counts models.OperationCounts) bool {
+	return (b.Reads == 0 || counts.Reads <= b.Reads) &&
+		(b.Writes == 0 || counts.Writes+counts.Deletes <= b.Writes)
+}
+
+// UsageScope accumulates the document operations of a single request
+type UsageScope struct {
+	mu       sync.Mutex
+	counts   models.OperationCounts
+	budget   OperationBudget
+	exceeded bool
+}
+
+type usageScopeKey struct{}
+
+// WithUsageScope returns a context whose repository operations are counted in the returned scope
+func WithUsageScope(ctx context.Context) (context.Context, *UsageScope) {
+	return WithUsageBudget(ctx, OperationBudget{})
+}
+
+// WithUsageBudget is WithUsageScope with a cap on the request's operations:
+// once it is reached, repository calls fail with ErrOperationBudgetExceeded
+func WithUsageBudget(ctx context.Context, budget OperationBudget) (context.Context, *UsageScope) {
+	scope := &UsageScope{budget: budget}
+	return context.WithValue(ctx, usageScopeKey{}, scope), scope
+}
+
+// Counts returns the operations recorded so far
+func (s *UsageScope) Counts() models.OperationCounts {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.counts
+}
+
+// Budget returns the scope's operation budget
+func (s *UsageScope) Budget() OperationBudget {
+	return s.budget
+}
+
+// Exceeded reports whether a repository call was refused or cut short by the budget
+func (s *UsageScope) Exceeded() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.exceeded
+}
+
+// add counts operations; with reserve set, operations that would exceed the
+// budget are refused and not counted, otherwise they have already been made
+func (s *UsageScope) add(reads, writes, deletes int, reserve bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := s.counts
+	counts.Reads += int64(reads)
+	counts.Writes += int64(writes)
+	counts.Deletes += int64(deletes)
+	if s.budget.allows(counts) {
+		s.counts = counts
+		return nil
+	}
+	if !reserve {
+		s.counts = counts
+	}
+	s.exceeded = true
+	return fmt.Errorf("%w: %d reads and %d writes, limits %d and %d", ErrOperationBudgetExceeded,
+		counts.Reads, counts.Writes+counts.Deletes, s.budget.Reads, s.budget.Writes)
+}
+
+// ReserveUsage counts operations about to be made, failing when they would exceed the request's budget
+func ReserveUsage(ctx context.Context, reads, writes, deletes int) error {
+	scope, ok := ctx.Value(usageScopeKey{}).(*UsageScope)
+	if !ok {
+		return nil
+	}
+	return scope.add(reads, writes, deletes, true)
+}
+
+// RecordUsage counts operations already made, failing when they exceeded the request's budget
+func RecordUsage(ctx context.Context, reads, writes, deletes int) error {
+	scope, ok := ctx.Value(usageScopeKey{}).(*UsageScope)
+	if !ok {
+		return nil
+	}
+	return scope.add(reads, writes, deletes, false)
+}
+
+// readLimit caps the limit of a query at the reads left in the request's
+// budget, so that the query is not run past it, and refuses the query when no
+// read is left. capped reports whether the limit was lowered to the reads left.
+func readLimit(ctx context.Context, limit int) (int, bool, error) {
+	scope, ok := ctx.Value(usageScopeKey{}).(*UsageScope)
+	if !ok || scope.budget.Reads == 0 {
+		return limit, false, nil
+	}
+	remaining := int(scope.budget.Reads - scope.Counts().Reads)
+	if remaining <= 0 {
+		return 0, false, scope.add(1, 0, 0, true)
+	}
+	if limit > 0 && limit <= remaining {
+		return limit, false, nil
+	}
+	return remaining, true, nil
+}
+
+// checkCutShort fails a query whose limit readLimit capped and that returned
+// that many results: its next result would have exceeded the budget
+func checkCutShort(ctx context.Context, capped bool, limit, results int) error {
+	if capped && results >= limit {
+		return ReserveUsage(ctx, 1, 0, 0)
+	}
+	return nil
+}
+
+// UsageTracker aggregates per-request Firestore usage by endpoint and estimates cost
+type UsageTracker struct {
+	backend    string
+	pricing    models.FirestorePricing
+	start      time.Time
+	operations *prometheus.CounterVec
+
+	mu        sync.Mutex
+	endpoints map[string]*models.EndpointUsage
+}
+
+// NewUsageTracker creates a tracker and registers its metrics.
+// The pricing tier is "regional" (default) or "multi-region".
+func NewUsageTracker(backend, pricingTier string, registerer prometheus.Registerer) (*UsageTracker, error) {
+	if pricingTier == "" {
+		pricingTier = "regional"
+	}
+	pricing, ok := firestorePricing[pricingTier]
+	if !ok {
+		return nil, fmt.Errorf("unknown Firestore pricing tier: %s", pricingTier)
+	}
+
+	t := &UsageTracker{
+		backend: backend,
+		pricing: pricing,
+		start:   time.Now(),
+		operations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "firestore_document_operations_total",
+			Help: "Firestore document operations by endpoint and operation (read, write, delete).",
+		}, []string{"endpoint", "operation"}),
+		endpoints: make(map[string]*models.EndpointUsage),
+	}
+
+	costGauge := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "firestore_estimated_monthly_cost_usd",
+		Help: "Projected monthly Firestore cost at the current operation rate, after the daily free tier.",
+	}, func() float64 {
+		return t.Stats(time.Now()).EstimatedMonthlyCostUSD
+	})
+
+	if err := registerer.Register(t.operations); err != nil {
+		return nil, fmt.Errorf("failed to register usage metrics: %v", err)
+	}
+	if err := registerer.Register(costGauge); err != nil {
+		return nil, fmt.Errorf("failed to register usage metrics: %v", err)
+	}
+
+	return t, nil
+}
+
+// Record attributes a request's operations to an endpoint
+func (t *UsageTracker) Record(endpoint string, counts models.OperationCounts) {
+	if counts.Reads == 0 && counts.Writes == 0 && counts.Deletes == 0 {
+		return
+	}
+
+	t.operations.WithLabelValues(endpoint, "read").Add(float64(counts.Reads))
+	t.operations.WithLabelValues(endpoint, "write").Add(float64(counts.Writes))
+	t.operations.WithLabelValues(endpoint, "delete").Add(float64(counts.Deletes))
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	usage, ok := t.endpoints[endpoint]
+	if !ok {
+		usage = &models.EndpointUsage{Endpoint: endpoint}
+		t.endpoints[endpoint] = usage
+	}
+	usage.Requests++
+	usage.Reads += counts.Reads
+	usage.Writes += counts.Writes
+	usage.Deletes += counts.Deletes
+}
+
+// Stats returns usage totals, per-endpoint breakdown and the monthly cost estimate
+func (t *UsageTracker) Stats(now time.Time) models.UsageStats {
+	elapsed := now.Sub(t.start)
+	stats := models.UsageStats{
+		Backend:       t.backend,
+		Since:         t.start.UTC(),
+		UptimeSeconds: elapsed.Seconds(),
+		Endpoints:     []models.EndpointUsage{},
+		Pricing:       t.pricing,
+	}
+
+	t.mu.Lock()
+	for _, usage := range t.endpoints {
+		endpoint := *usage
+		endpoint.EstimatedMonthlyCostUSD = estimateMonthlyCost(endpoint.OperationCounts, elapsed, t.pricing, false)
+		stats.Endpoints = append(stats.Endpoints, endpoint)
+
+		stats.Totals.Reads += usage.Reads
+		stats.Totals.Writes += usage.Writes
+		stats.Totals.Deletes += usage.Deletes
+	}
+	t.mu.Unlock()
+
+	sort.Slice(stats.Endpoints, func(i, j int) bool {
+		if stats.Endpoints[i].EstimatedMonthlyCostUSD != stats.Endpoints[j].EstimatedMonthlyCostUSD {
+			return stats.Endpoints[i].EstimatedMonthlyCostUSD > stats.Endpoints[j].EstimatedMonthlyCostUSD
+		}
+		return stats.Endpoints[i].Endpoint < stats.Endpoints[j].Endpoint
+	})
+
+	stats.EstimatedMonthlyCostUSD = estimateMonthlyCost(stats.Totals, elapsed, t.pricing, true)
+	return stats
+}
+
+// estimateMonthlyCost extrapolates operation counts observed over elapsed to a 30-day month
+func estimateMonthlyCost(counts models.OperationCounts, elapsed time.Duration, pricing models.FirestorePricing, applyFreeTier bool) float64 {
+	// Avoid wild extrapolation right after startup
+	if elapsed < time.Minute {
+		elapsed = time.Minute
+	}
+	scale := float64(daysPerMonth*24*time.Hour) / float64(elapsed)
+
+	cost := func(count int64, freePerDay int, price float64) float64 {
+		monthly := float64(count) * scale
+		if applyFreeTier {
+			monthly -= float64(freePerDay * daysPerMonth)
+		}
+		if monthly <= 0 {
+			return 0
+		}
+		return monthly / 100000 * price
+	}
+
+	total := cost(counts.Reads, firestoreFreeReads, pricing.Read) +
+		cost(counts.Writes, firestoreFreeWrites, pricing.Write) +
+		cost(counts.Deletes, firestoreFreeDeletes, pricing.Delete)
+
+	return float64(int64(total*100+0.5)) / 100
+}
+
+// InstrumentedRepository counts the Firestore document operations made by
+// each repository call. The optional capabilities of the repository, such as
+// its TicketHistory, are wrapped one by one, so it offers exactly those the
+// repository has; callers find them with Capability.
+type InstrumentedRepository struct {
+	TicketRepository
+	capabilities []any // counting adapters of the repository's optional capabilities
+}
+
+// NewInstrumentedRepository wraps a repository so that operations are recorded in the request's UsageScope
+func NewInstrumentedRepository(repository TicketRepository) TicketRepository {
+	instrumented := &InstrumentedRepository{TicketRepository: repository}
+	if attachments, ok := Capability[AttachmentRepository](repository); ok {
+		instrumented.capabilities = append(instrumented.capabilities, instrumentedAttachments{attachments})
+	}
+	if preferences, ok := Capability[NotificationPreferenceRepository](repository); ok {
+		instrumented.capabilities = append(instrumented.capabilities, instrumentedPreferences{preferences})
+	}
+	if notes, ok := Capability[NoteRepository](repository); ok {
+		instrumented.capabilities = append(instrumented.capabilities, instrumentedNotes{notes})
+	}
+	if searcher, ok := Capability[TicketSearcher](repository); ok {
+		instrumented.capabilities = append(instrumented.capabilities, instrumentedSearcher{searcher})
+	}
+	if views, ok := Capability[ViewRepository](repository); ok {
+		instrumented.capabilities = append(instrumented.capabilities, instrumentedViews{views})
+	}
+	if inventory, ok := Capability[InventoryLedger](repository); ok {
+		instrumented.capabilities = append(instrumented.capabilities, instrumentedInventory{inventory})
+	}
+	if bookings, ok := Capability[BookingCounterStore](repository); ok {
+		instrumented.capabilities = append(instrumented.capabilities, instrumentedBookingCounters{bookings})
+	}
+	if quarantine, ok := Capability[TicketQuarantine](repository); ok {
+		instrumented.capabilities = append(instrumented.capabilities, instrumentedQuarantine{quarantine})
+	}
+	if locks, ok := Capability[TicketLockStore](repository); ok {
+		instrumented.capabilities = append(instrumented.capabilities, instrumentedLocks{locks})
+	}
+	if history, ok := Capability[TicketHistory](repository); ok {
+		instrumented.capabilities = append(instrumented.capabilities, instrumentedHistory{history})
+	}
+	return instrumented
+}
+
+// Capabilities returns the counting adapters of the repository's optional capabilities
+func (r *InstrumentedRepository) Capabilities() []any {
+	return r.capabilities
+}
+
+// Unwrap returns the wrapped repository. Capabilities that are not counted,
+// such as TicketSchemaMigrator, whose migrations run as background jobs
+// outside any request, are found on it.
+func (r *InstrumentedRepository) Unwrap() TicketRepository {
+	return r.TicketRepository
+}
+
+// instrumentedAttachments counts attachment operations
+type instrumentedAttachments struct {
+	AttachmentRepository
+}
+
+// instrumentedPreferences counts notification preference operations
+type instrumentedPreferences struct {
+	NotificationPreferenceRepository
+}
+
+// instrumentedNotes counts note operations
+type instrumentedNotes struct {
+	NoteRepository
+}
+
+// instrumentedSearcher counts search operations
+type instrumentedSearcher struct {
+	TicketSearcher
+}
+
+// instrumentedViews counts saved view operations
+type instrumentedViews struct {
+	ViewRepository
+}
+
+// instrumentedInventory counts inventory operations
+type instrumentedInventory struct {
+	InventoryLedger
+}
+
+// instrumentedBookingCounters counts booking counter operations
+type instrumentedBookingCounters struct {
+	BookingCounterStore
+}
+
+// instrumentedQuarantine counts quarantine operations
+type instrumentedQuarantine struct {
+	TicketQuarantine
+}
+
+// instrumentedLocks counts lock operations
+type instrumentedLocks struct {
+	TicketLockStore
+}
+
+// instrumentedHistory counts history operations
+type instrumentedHistory struct {
+	TicketHistory
+}
+
+// CreateTicket records one document write
+func (r *InstrumentedRepository) CreateTicket(ctx context.Context, ticket *models.FlightTicket) error {
+	if err := ReserveUsage(ctx, 0, 1, 0); err != nil {
+		return err
+	}
+	return r.TicketRepository.CreateTicket(ctx, ticket)
+}
+
+// GetTicket records one document read
+func (r *InstrumentedRepository) GetTicket(ctx context.Context, confirmationID string) (*models.FlightTicket, error) {
+	if err := ReserveUsage(ctx, 1, 0, 0); err != nil {
+		return nil, err
+	}
+	return r.TicketRepository.GetTicket(ctx, confirmationID)
+}
+
+// UpdateTicket records one document write
+func (r *InstrumentedRepository) UpdateTicket(ctx context.Context, confirmationID string, updates map[string]interface{}) error {
+	if err := ReserveUsage(ctx, 0, 1, 0); err != nil {
+		return err
+	}
+	return r.TicketRepository.UpdateTicket(ctx, confirmationID, updates)
+}
+
+// DeleteTicket records one document write (tickets are soft-deleted)
+func (r *InstrumentedRepository) DeleteTicket(ctx context.Context, confirmationID string) error {
+	if err := ReserveUsage(ctx, 0, 1, 0); err != nil {
+		return err
+	}
+	return r.TicketRepository.DeleteTicket(ctx, confirmationID)
+}
+
+// ListTickets records one read per returned document (minimum one per query).
+// The limit is capped at the reads left in the request's budget.
+func (r *InstrumentedRepository) ListTickets(ctx context.Context, limit int) ([]*models.FlightTicket, error) {
+	limit, capped, err := readLimit(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	tickets, err := r.TicketRepository.ListTickets(ctx, limit)
+	if budgetErr := RecordUsage(ctx, QueryReads(len(tickets)), 0, 0); budgetErr != nil {
+		return nil, budgetErr
+	}
+	if err == nil {
+		err = checkCutShort(ctx, capped, limit, len(tickets))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tickets, nil
+}
+
+func (r instrumentedAttachments) CreateAttachment(ctx context.Context, attachment *models.Attachment) error {
+	if err := ReserveUsage(ctx, 0, 1, 0); err != nil {
+		return err
+	}
+	return r.AttachmentRepository.CreateAttachment(ctx, attachment)
+}
+
+func (r instrumentedAttachments) GetAttachment(ctx context.Context, confirmationID, attachmentID string) (*models.Attachment, error) {
+	if err := ReserveUsage(ctx, 1, 0, 0); err != nil {
+		return nil, err
+	}
+	return r.AttachmentRepository.GetAttachment(ctx, confirmationID, attachmentID)
+}
+
+func (r instrumentedAttachments) ListAttachments(ctx context.Context, confirmationID string) ([]*models.Attachment, error) {
+	attachments, err := r.AttachmentRepository.ListAttachments(ctx, confirmationID)
+	if budgetErr := RecordUsage(ctx, QueryReads(len(attachments)), 0, 0); budgetErr != nil {
+		return nil, budgetErr
+	}
+	return attachments, err
+}
+
+func (r instrumentedPreferences) GetNotificationPreferences(ctx context.Context, confirmationID string) (*models.NotificationPreferences, error) {
+	if err := ReserveUsage(ctx, 1, 0, 0); err != nil {
+		return nil, err
+	}
+	return r.NotificationPreferenceRepository.GetNotificationPreferences(ctx, confirmationID)
+}
+
+func (r instrumentedPreferences) SaveNotificationPreferences(ctx context.Context, preferences *models.NotificationPreferences) error {
+	if err := ReserveUsage(ctx, 0, 1, 0); err != nil {
+		return err
+	}
+	return r.NotificationPreferenceRepository.SaveNotificationPreferences(ctx, preferences)
+}
+
+func (r instrumentedNotes) CreateNote(ctx context.Context, note *models.TicketNote) error {
+	if err := ReserveUsage(ctx, 0, 1, 0); err != nil {
+		return err
+	}
+	return r.NoteRepository.CreateNote(ctx, note)
+}
+
+func (r instrumentedNotes) ListNotes(ctx context.Context, confirmationID string) ([]*models.TicketNote, error) {
+	notes, err := r.NoteRepository.ListNotes(ctx, confirmationID)
+	if budgetErr := RecordUsage(ctx, QueryReads(len(notes)), 0, 0); budgetErr != nil {
+		return nil, budgetErr
+	}
+	return notes, err
+}
+
+func (r instrumentedSearcher) SearchTickets(ctx context.Context, query models.TicketQuery) ([]*models.FlightTicket, error) {
+	limit, capped, err := readLimit(ctx, query.Limit)
+	if err != nil {
+		return nil, err
+	}
+	query.Limit = limit
+	tickets, err := r.TicketSearcher.SearchTickets(ctx, query)
+	if budgetErr := RecordUsage(ctx, QueryReads(len(tickets)), 0, 0); budgetErr != nil {
+		return nil, budgetErr
+	}
+	if err == nil {
+		err = checkCutShort(ctx, capped, limit, len(tickets))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tickets, nil
+}
+
+func (r instrumentedViews) GetView(ctx context.Context, name string) (*models.SavedView, error) {
+	if err := ReserveUsage(ctx, 1, 0, 0); err != nil {
+		return nil, err
+	}
+	return r.ViewRepository.GetView(ctx, name)
+}
+
+func (r instrumentedViews) SaveView(ctx context.Context, view *models.SavedView) error {
+	if err := ReserveUsage(ctx, 0, 1, 0); err != nil {
+		return err
+	}
+	return r.ViewRepository.SaveView(ctx, view)
+}
+
+func (r instrumentedViews) ListViews(ctx context.Context) ([]*models.SavedView, error) {
+	views, err := r.ViewRepository.ListViews(ctx)
+	if budgetErr := RecordUsage(ctx, QueryReads(len(views)), 0, 0); budgetErr != nil {
+		return nil, budgetErr
+	}
+	return views, err
+}
+
+func (r instrumentedViews) DeleteView(ctx context.Context, name string) error {
+	if err := ReserveUsage(ctx, 0, 0, 1); err != nil {
+		return err
+	}
+	return r.ViewRepository.DeleteView(ctx, name)
+}
+
+// PostInventory records a read and a write of each flight's balance and a write per entry
+func (r instrumentedInventory) PostInventory(ctx context.Context, entries []*models.InventoryEntry) ([]*models.InventoryEntry, error) {
+	flights := make(map[string]bool)
+	for _, entry := range entries {
+		flights[inventoryKey(entry.FlightNumber, entry.Date)] = true
+	}
+	if err := ReserveUsage(ctx, len(flights), len(flights)+len(entries), 0); err != nil {
+		return nil, err
+	}
+	return r.InventoryLedger.PostInventory(ctx, entries)
+}
+
+func (r instrumentedInventory) GetInventory(ctx context.Context, flightNumber, date string) (*models.InventoryBalance, error) {
+	if err := ReserveUsage(ctx, 1, 0, 0); err != nil {
+		return nil, err
+	}
+	return r.InventoryLedger.GetInventory(ctx, flightNumber, date)
+}
+
+func (r instrumentedInventory) ListInventoryEntries(ctx context.Context, flightNumber, date string) ([]*models.InventoryEntry, error) {
+	entries, err := r.InventoryLedger.ListInventoryEntries(ctx, flightNumber, date)
+	if budgetErr := RecordUsage(ctx, QueryReads(len(entries)), 0, 0); budgetErr != nil {
+		return nil, budgetErr
+	}
+	return entries, err
+}
+
+// CountBooking records a write to a shard of the global counter and of the day's counter
+func (r instrumentedBookingCounters) CountBooking(ctx context.Context, route, day string) error {
+	if err := ReserveUsage(ctx, 0, 2, 0); err != nil {
+		return err
+	}
+	return r.BookingCounterStore.CountBooking(ctx, route, day)
+}
+
+// GetBookingCounts records a read of every shard of the global counter and of the days' counters
+func (r instrumentedBookingCounters) GetBookingCounts(ctx context.Context, days []string) (int64, map[string]map[string]int64, error) {
+	if err := ReserveUsage(ctx, (1+len(days))*bookingCounter.Shards(), 0, 0); err != nil {
+		return 0, nil, err
+	}
+	return r.BookingCounterStore.GetBookingCounts(ctx, days)
+}
+
+func (r instrumentedQuarantine) ListQuarantined(ctx context.Context) ([]*models.QuarantinedTicket, error) {
+	tickets, err := r.TicketQuarantine.ListQuarantined(ctx)
+	if budgetErr := RecordUsage(ctx, QueryReads(len(tickets)), 0, 0); budgetErr != nil {
+		return nil, budgetErr
+	}
+	return tickets, err
+}
+
+func (r instrumentedQuarantine) GetQuarantined(ctx context.Context, confirmationID string) (*models.QuarantinedTicket, error) {
+	if err := ReserveUsage(ctx, 1, 0, 0); err != nil {
+		return nil, err
+	}
+	return r.TicketQuarantine.GetQuarantined(ctx, confirmationID)
+}
+
+// RepairQuarantined records a read and a write of the ticket and the deletion of its quarantine document
+func (r instrumentedQuarantine) RepairQuarantined(ctx context.Context, confirmationID string, set map[string]interface{}, remove []string) (*models.FlightTicket, error) {
+	if err := ReserveUsage(ctx, 1, 1, 1); err != nil {
+		return nil, err
+	}
+	return r.TicketQuarantine.RepairQuarantined(ctx, confirmationID, set, remove)
+}
+
+func (r instrumentedLocks) GetLock(ctx context.Context, confirmationID string) (*models.TicketLock, error) {
+	if err := ReserveUsage(ctx, 1, 0, 0); err != nil {
+		return nil, err
+	}
+	return r.TicketLockStore.GetLock(ctx, confirmationID)
+}
+
+// UpdateLock records a read and a write of the lock document
+func (r instrumentedLocks) UpdateLock(ctx context.Context, confirmationID string, change func(*models.TicketLock) (*models.TicketLock, error)) (*models.TicketLock, error) {
+	if err := ReserveUsage(ctx, 1, 1, 0); err != nil {
+		return nil, err
+	}
+	return r.TicketLockStore.UpdateLock(ctx, confirmationID, change)
+}
+
+func (r instrumentedHistory) RecordRevision(ctx context.Context, revision *models.TicketRevision) error {
+	if err := ReserveUsage(ctx, 0, 1, 0); err != nil {
+		return err
+	}
+	return r.TicketHistory.RecordRevision(ctx, revision)
+}
+
+func (r instrumentedHistory) ListRevisions(ctx context.Context, confirmationID string) ([]*models.TicketRevision, error) {
+	revisions, err := r.TicketHistory.ListRevisions(ctx, confirmationID)
+	if budgetErr := RecordUsage(ctx, QueryReads(len(revisions)), 0, 0); budgetErr != nil {
+		return nil, budgetErr
+	}
+	return revisions, err
+}
+
+// QueryReads returns the billed reads of a query: Firestore charges at least one read per query
+func QueryReads(results int) int {
+	if results == 0 {
+		return 1
+	}
+	return results
+}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type stubRepository struct {
+	TicketRepository
+	tickets []*models.FlightTicket
+	limits  []int // limits of the ListTickets calls
+}
+
+func (s *stubRepository) GetTicket(ctx context.Context, confirmationID string) (*models.FlightTicket, error) {
+	return &models.FlightTicket{ConfirmationID: confirmationID}, nil
+}
+
+func (s *stubRepository) ListTickets(ctx context.Context, limit int) ([]*models.FlightTicket, error) {
+	s.limits = append(s.limits, limit)
+	if limit > 0 && limit < len(s.tickets) {
+		return s.tickets[:limit], nil
+	}
+	return s.tickets, nil
+}
+
+// stubSearcher is a stubRepository that searches by listing
+type stubSearcher struct {
+	*stubRepository
+}
+
+func (s stubSearcher) SearchTickets(ctx context.Context, query models.TicketQuery) ([]*models.FlightTicket, error) {
+	return s.ListTickets(ctx, query.Limit)
+}
+
+func TestInstrumentedRepositoryCountsOperations(t *testing.T) {
+	repo := NewInstrumentedRepository(&stubRepository{tickets: make([]*models.FlightTicket, 3)})
+	ctx, scope := WithUsageScope(context.Background())
+
+	repo.GetTicket(ctx, "ABC123")
+	repo.ListTickets(ctx, 0)
+
+	counts := scope.Counts()
+	if counts.Reads != 4 || counts.Writes != 0 || counts.Deletes != 0 {
+		t.Errorf("Unexpected counts %+v", counts)
+	}
+
+	// Operations outside a usage scope are ignored
+	if _, err := repo.GetTicket(context.Background(), "ABC123"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+// historyRepository has one optional capability, a history, and a schema
+// migrator, which is not counted
+type historyRepository struct {
+	stubRepository
+}
+
+func (h *historyRepository) RecordRevision(ctx context.Context, revision *models.TicketRevision) error {
+	return nil
+}
+
+func (h *historyRepository) ListRevisions(ctx context.Context, confirmationID string) ([]*models.TicketRevision, error) {
+	return make([]*models.TicketRevision, 2), nil
+}
+
+func (h *historyRepository) MigrateTickets(ctx context.Context, progress func(done, total int)) (*SchemaMigrationResult, error) {
+	return &SchemaMigrationResult{}, nil
+}
+
+func TestInstrumentedRepositoryCapabilities(t *testing.T) {
+	repo := NewInstrumentedRepository(&historyRepository{})
+
+	// the capabilities the repository has are offered, and counted, one by one
+	history, ok := Capability[TicketHistory](repo)
+	if !ok {
+		t.Fatal("Expected the history of the wrapped repository")
+	}
+	ctx, scope := WithUsageScope(context.Background())
+	history.ListRevisions(ctx, "ABC123")
+	history.RecordRevision(ctx, &models.TicketRevision{})
+	if counts := scope.Counts(); counts.Reads != 2 || counts.Writes != 1 {
+		t.Errorf("Expected the history operations to be counted, got %+v", counts)
+	}
+	if _, ok := Capability[TicketSchemaMigrator](repo); !ok {
+		t.Error("Expected the uncounted schema migrator of the wrapped repository")
+	}
+
+	// the ones it lacks are not
+	if _, ok := Capability[TicketLockStore](repo); ok {
+		t.Error("Expected no lock store")
+	}
+	if _, ok := Capability[AttachmentRepository](repo); ok {
+		t.Error("Expected no attachments")
+	}
+}
+
+func TestEmptyQueryCountsOneRead(t *testing.T) {
+	repo := NewInstrumentedRepository(&stubRepository{})
+	ctx, scope := WithUsageScope(context.Background())
+
+	repo.ListTickets(ctx, 10)
+
+	if reads := scope.Counts().Reads; reads != 1 {
+		t.Errorf("Expected 1 read for an empty query, got %d", reads)
+	}
+}
+
+func TestOperationBudget(t *testing.T) {
+	repo := NewInstrumentedRepository(&stubRepository{tickets: make([]*models.FlightTicket, 3)})
+	ctx, scope := WithUsageBudget(context.Background(), OperationBudget{Reads: 4})
+
+	if _, err := repo.ListTickets(ctx, 0); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := repo.GetTicket(ctx, "ABC123"); err != nil {
+		t.Fatalf("Unexpected error at the limit: %v", err)
+	}
+	if scope.Exceeded() {
+		t.Fatal("Expected the budget to hold at the limit")
+	}
+
+	// A read beyond the budget is refused and not counted
+	if _, err := repo.GetTicket(ctx, "ABC123"); !errors.Is(err, ErrOperationBudgetExceeded) {
+		t.Fatalf("Expected ErrOperationBudgetExceeded, got %v", err)
+	}
+	if !scope.Exceeded() {
+		t.Error("Expected the scope to report the exceeded budget")
+	}
+	if reads := scope.Counts().Reads; reads != 4 {
+		t.Errorf("Expected 4 reads, got %d", reads)
+	}
+
+}
+
+func TestOperationBudgetLimitsQueries(t *testing.T) {
+	stub := &stubRepository{tickets: make([]*models.FlightTicket, 3)}
+	repo := NewInstrumentedRepository(stub)
+
+	// A query is limited to the reads left, and fails when that cut it short
+	ctx, scope := WithUsageBudget(context.Background(), OperationBudget{Reads: 2})
+	if _, err := repo.ListTickets(ctx, 0); !errors.Is(err, ErrOperationBudgetExceeded) {
+		t.Fatalf("Expected ErrOperationBudgetExceeded, got %v", err)
+	}
+	if len(stub.limits) != 1 || stub.limits[0] != 2 {
+		t.Errorf("Expected the query to be limited to the 2 reads left, got limits %v", stub.limits)
+	}
+	if reads := scope.Counts().Reads; reads != 2 || !scope.Exceeded() {
+		t.Errorf("Expected 2 reads and the budget exceeded, got %d reads", reads)
+	}
+
+	// A query with no reads left is refused before it runs
+	if _, err := repo.ListTickets(ctx, 10); !errors.Is(err, ErrOperationBudgetExceeded) {
+		t.Fatalf("Expected ErrOperationBudgetExceeded, got %v", err)
+	}
+	if len(stub.limits) != 1 {
+		t.Errorf("Expected no query once the budget is used up, got limits %v", stub.limits)
+	}
+
+	// A query within the reads left keeps its limit
+	ctx, _ = WithUsageBudget(context.Background(), OperationBudget{Reads: 5})
+	if tickets, err := repo.ListTickets(ctx, 0); err != nil || len(tickets) != 3 {
+		t.Errorf("Expected the 3 tickets within the budget, got %d, %v", len(tickets), err)
+	}
+	if tickets, err := repo.ListTickets(ctx, 1); err != nil || len(tickets) != 1 || stub.limits[len(stub.limits)-1] != 1 {
+		t.Errorf("Expected the query limit to be kept, got %d tickets, limits %v, %v", len(tickets), stub.limits, err)
+	}
+}
+
+func TestOperationBudgetLimitsSearches(t *testing.T) {
+	stub := &stubRepository{tickets: make([]*models.FlightTicket, 3)}
+	repo := NewInstrumentedRepository(stubSearcher{stub})
+
+	ctx, _ := WithUsageBudget(context.Background(), OperationBudget{Reads: 2})
+	if _, err := SearchTickets(ctx, repo, models.TicketQuery{Status: "CONFIRMED", Limit: 50}); !errors.Is(err, ErrOperationBudgetExceeded) {
+		t.Fatalf("Expected ErrOperationBudgetExceeded, got %v", err)
+	}
+	if _, err := SearchTickets(ctx, repo, models.TicketQuery{Status: "CONFIRMED"}); !errors.Is(err, ErrOperationBudgetExceeded) {
+		t.Fatalf("Expected ErrOperationBudgetExceeded, got %v", err)
+	}
+	if len(stub.limits) != 1 || stub.limits[0] != 2 {
+		t.Errorf("Expected one search limited to the 2 reads left, got limits %v", stub.limits)
+	}
+}
+
+func TestOperationBudgetFromEnv(t *testing.T) {
+	t.Setenv("FIRESTORE_REQUEST_MAX_READS", "")
+	t.Setenv("FIRESTORE_REQUEST_MAX_WRITES", "0")
+	budget, err := OperationBudgetFromEnv()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if budget.Reads != DefaultRequestMaxReads || budget.Writes != 0 {
+		t.Errorf("Unexpected budget %+v", budget)
+	}
+
+	t.Setenv("FIRESTORE_REQUEST_MAX_READS", "-1")
+	if _, err := OperationBudgetFromEnv(); err == nil {
+		t.Error("Expected an error for a negative limit")
+	}
+}
+
+func TestEstimateMonthlyCost(t *testing.T) {
+	pricing := firestorePricing["regional"]
+
+	// 1M reads and 100k writes in one day: 30M reads and 3M writes per month
+	counts := models.OperationCounts{Reads: 1000000, Writes: 100000}
+	if cost := estimateMonthlyCost(counts, 24*time.Hour, pricing, false); cost != 11.70 {
+		t.Errorf("Expected 11.70, got %.2f", cost)
+	}
+
+	// Free tier: 1.5M reads and 600k writes per month
+	if cost := estimateMonthlyCost(counts, 24*time.Hour, pricing, true); cost != 10.71 {
+		t.Errorf("Expected 10.71 after free tier, got %.2f", cost)
+	}
+
+	// Usage within the free tier costs nothing
+	if cost := estimateMonthlyCost(models.OperationCounts{Reads: 100}, 24*time.Hour, pricing, true); cost != 0 {
+		t.Errorf("Expected 0 within free tier, got %.2f", cost)
+	}
+}
+
+func TestUsageTrackerStats(t *testing.T) {
+	tracker, err := NewUsageTracker(BackendFirestore, "", prometheus.NewRegistry())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tracker.Record("GET /tickets", models.OperationCounts{Reads: 50})
+	tracker.Record("GET /tickets", models.OperationCounts{Reads: 50})
+	tracker.Record("POST /ticket/", models.OperationCounts{Writes: 1})
+	tracker.Record("GET /health", models.OperationCounts{})
+
+	stats := tracker.Stats(time.Now())
+	if stats.Totals.Reads != 100 || stats.Totals.Writes != 1 {
+		t.Errorf("Unexpected totals %+v", stats.Totals)
+	}
+	if len(stats.Endpoints) != 2 {
+		t.Fatalf("Expected 2 endpoints, got %d", len(stats.Endpoints))
+	}
+	if stats.Endpoints[0].Endpoint != "GET /tickets" || stats.Endpoints[0].Requests != 2 {
+		t.Errorf("Expected GET /tickets first with 2 requests, got %+v", stats.Endpoints[0])
+	}
+	if stats.Pricing.Tier != "regional" {
+		t.Errorf("Expected regional pricing, got %s", stats.Pricing.Tier)
+	}
+
+	if _, err := NewUsageTracker(BackendFirestore, "global", prometheus.NewRegistry()); err == nil {
+		t.Error("Expected error for unknown pricing tier")
+	}
+}
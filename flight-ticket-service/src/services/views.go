@@ -0,0 +1,19 @@
+package services
+
+import (
+	"context"
+
+	"flight-ticket-service/src/models"
+)
+
+// ViewRepository is implemented by storage backends that can hold saved views
+type ViewRepository interface {
+	// GetView returns a view by name, or nil when it does not exist
+	GetView(ctx context.Context, name string) (*models.SavedView, error)
+	// SaveView creates or replaces a view
+	SaveView(ctx context.Context, view *models.SavedView) error
+	// ListViews retrieves every view, ordered by name
+	ListViews(ctx context.Context) ([]*models.SavedView, error)
+	// DeleteView removes a view; deleting a missing view is not an error
+	DeleteView(ctx context.Context, name string) error
+}
@@ -0,0 +1,370 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+// Open-Meteo only serves hourly forecasts up to 16 days ahead
+const forecastWindow = 16 * 24 * time.Hour
+
+// WeatherProvider fetches current and forecast weather for an airport
+type WeatherProvider interface {
+	// Name returns the provider identifier reported in responses
+	Name() string
+	// GetWeather returns current conditions and, when available, the forecast at the given time
+	GetWeather(ctx context.Context, airport string, loc AirportLocation, at time.Time) (*models.AirportWeather, error)
+}
+
+// NewWeatherProvider creates a weather provider by name
+func NewWeatherProvider(name string) (WeatherProvider, error) {
+	switch name {
+	case "", "open-meteo":
+		return NewOpenMeteoProvider(), nil
+	case "static":
+		return &StaticWeatherProvider{}, nil
+	default:
+		return nil, fmt.Errorf("unknown weather provider: %s", name)
+	}
+}
+
+// OpenMeteoProvider retrieves weather from the Open-Meteo API (no API key required)
+type OpenMeteoProvider struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewOpenMeteoProvider creates a new Open-Meteo weather provider
+func NewOpenMeteoProvider() *OpenMeteoProvider {
+	return &OpenMeteoProvider{
+		baseURL:    "https://api.open-meteo.com/v1/forecast",
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name returns the provider identifier
+func (p *OpenMeteoProvider) Name() string {
+	return "open-meteo"
+}
+
+type openMeteoCurrent struct {
+	Time          string  `json:"time"`
+	Temperature   float64 `json:"temperature_2m"`
+	Precipitation float64 `json:"precipitation"`
+	WindSpeed     float64 `json:"wind_speed_10m"`
+	WindGusts     float64 `json:"wind_gusts_10m"`
+	Visibility    float64 `json:"visibility"`
+	WeatherCode   int     `json:"weather_code"`
+}
+
+type openMeteoHourly struct {
+	Time          []string  `json:"time"`
+	Temperature   []float64 `json:"temperature_2m"`
+	Precipitation []float64 `json:"precipitation"`
+	WindSpeed     []float64 `json:"wind_speed_10m"`
+	WindGusts     []float64 `json:"wind_gusts_10m"`
+	Visibility    []float64 `json:"visibility"`
+	WeatherCode   []int     `json:"weather_code"`
+}
+
+type openMeteoResponse struct {
+	Current openMeteoCurrent `json:"current"`
+	Hourly  openMeteoHourly  `json:"hourly"`
+}
+
+// GetWeather fetches current conditions and the hourly forecast closest to the given time
+func (p *OpenMeteoProvider) GetWeather(ctx context.Context, airport string, loc AirportLocation, at time.Time) (*models.AirportWeather, error) {
+	const fields = "temperature_2m,precipitation,wind_speed_10m,wind_gusts_10m,visibility,weather_code"
+
+	params := url.Values{}
+	params.Set("latitude", fmt.Sprintf("%.4f", loc.Latitude))
+	params.Set("longitude", fmt.Sprintf("%.4f", loc.Longitude))
+	params.Set("current", fields)
+	params.Set("timezone", "UTC")
+	params.Set("wind_speed_unit", "kmh")
+
+	forecastHour := at.UTC().Truncate(time.Hour)
+	now := time.Now().UTC()
+	inWindow := forecastHour.After(now.Add(-time.Hour)) && forecastHour.Before(now.Add(forecastWindow))
+	if inWindow {
+		params.Set("hourly", fields)
+		params.Set("start_hour", forecastHour.Format("2006-01-02T15:04"))
+		params.Set("end_hour", forecastHour.Format("2006-01-02T15:04"))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build weather request: %v", err)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch weather: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("weather provider returned HTTP %d", resp.StatusCode)
+	}
+
+	var data openMeteoResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to parse weather response: %v", err)
+	}
+
+	weather := &models.AirportWeather{Airport: airport}
+
+	currentTime, _ := time.Parse("2006-01-02T15:04", data.Current.Time)
+	weather.Current = &models.WeatherConditions{
+		Time:            currentTime,
+		TemperatureC:    data.Current.Temperature,
+		PrecipitationMM: data.Current.Precipitation,
+		WindSpeedKPH:    data.Current.WindSpeed,
+		WindGustsKPH:    data.Current.WindGusts,
+		VisibilityM:     data.Current.Visibility,
+		WeatherCode:     data.Current.WeatherCode,
+		Summary:         DescribeWeatherCode(data.Current.WeatherCode),
+	}
+
+	if inWindow && len(data.Hourly.Time) > 0 {
+		h := data.Hourly
+		forecast := &models.WeatherConditions{Time: forecastHour}
+		if len(h.Temperature) > 0 {
+			forecast.TemperatureC = h.Temperature[0]
+		}
+		if len(h.Precipitation) > 0 {
+			forecast.PrecipitationMM = h.Precipitation[0]
+		}
+		if len(h.WindSpeed) > 0 {
+			forecast.WindSpeedKPH = h.WindSpeed[0]
+		}
+		if len(h.WindGusts) > 0 {
+			forecast.WindGustsKPH = h.WindGusts[0]
+		}
+		if len(h.Visibility) > 0 {
+			forecast.VisibilityM = h.Visibility[0]
+		}
+		if len(h.WeatherCode) > 0 {
+			forecast.WeatherCode = h.WeatherCode[0]
+		}
+		forecast.Summary = DescribeWeatherCode(forecast.WeatherCode)
+		weather.Forecast = forecast
+	}
+
+	return weather, nil
+}
+
+// StaticWeatherProvider returns fixed clear-sky conditions, useful for offline demos
+type StaticWeatherProvider struct{}
+
+// Name returns the provider identifier
+func (p *StaticWeatherProvider) Name() string {
+	return "static"
+}
+
+// GetWeather returns clear-sky conditions for both now and the requested time
+func (p *StaticWeatherProvider) GetWeather(ctx context.Context, airport string, loc AirportLocation, at time.Time) (*models.AirportWeather, error) {
+	clear := func(t time.Time) *models.WeatherConditions {
+		return &models.WeatherConditions{
+			Time:         t.UTC().Truncate(time.Hour),
+			TemperatureC: 18,
+			WindSpeedKPH: 10,
+			WindGustsKPH: 15,
+			VisibilityM:  10000,
+			WeatherCode:  0,
+			Summary:      DescribeWeatherCode(0),
+		}
+	}
+
+	return &models.AirportWeather{
+		Airport:  airport,
+		Current:  clear(time.Now()),
+		Forecast: clear(at),
+	}, nil
+}
+
+type cachedWeather struct {
+	weather   *models.AirportWeather
+	expiresAt time.Time
+}
+
+// WeatherService wraps a WeatherProvider with caching and disruption assessment
+type WeatherService struct {
+	provider WeatherProvider
+	ttl      time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedWeather
+}
+
+// NewWeatherService creates a new weather service with the given cache TTL
+func NewWeatherService(provider WeatherProvider, ttl time.Duration) *WeatherService {
+	return &WeatherService{
+		provider: provider,
+		ttl:      ttl,
+		cache:    make(map[string]cachedWeather),
+	}
+}
+
+// GetAirportWeather returns (possibly cached) weather for an airport at the given time.
+// Lookup failures are reported in the Error field rather than returned.
+func (ws *WeatherService) GetAirportWeather(ctx context.Context, airport string, at time.Time) models.AirportWeather {
+	key := fmt.Sprintf("%s|%s", airport, at.UTC().Truncate(time.Hour).Format(time.RFC3339))
+
+	ws.mu.Lock()
+	entry, ok := ws.cache[key]
+	ws.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return *entry.weather
+	}
+
+	loc, ok := LookupAirport(airport)
+	if !ok {
+		return models.AirportWeather{Airport: airport, Error: "Unknown airport location"}
+	}
+
+	weather, err := ws.provider.GetWeather(ctx, airport, loc, at)
+	if err != nil {
+		log.Printf("Failed to get weather for %s: %v", airport, err)
+		return models.AirportWeather{Airport: airport, Error: "Weather data unavailable"}
+	}
+
+	ws.mu.Lock()
+	ws.cache[key] = cachedWeather{weather: weather, expiresAt: time.Now().Add(ws.ttl)}
+	ws.mu.Unlock()
+
+	return *weather
+}
+
+// GetTicketAdvisories fetches weather for the ticket's airports and flags likely disruption
+func (ws *WeatherService) GetTicketAdvisories(ctx context.Context, ticket *models.FlightTicket) *models.TicketAdvisoriesResponse {
+	var origin, destination models.AirportWeather
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		origin = ws.GetAirportWeather(ctx, ticket.Origin, ticket.DepartureTime)
+	}()
+	go func() {
+		defer wg.Done()
+		destination = ws.GetAirportWeather(ctx, ticket.Destination, ticket.DepartureTime)
+	}()
+	wg.Wait()
+
+	advisories := []models.WeatherAdvisory{}
+	advisories = append(advisories, AssessAirportWeather(origin)...)
+	advisories = append(advisories, AssessAirportWeather(destination)...)
+
+	disruptionLikely := false
+	for _, a := range advisories {
+		if a.Severity == models.SeverityHigh {
+			disruptionLikely = true
+			break
+		}
+	}
+
+	return &models.TicketAdvisoriesResponse{
+		ConfirmationID:   ticket.ConfirmationID,
+		DepartureTime:    ticket.DepartureTime,
+		Origin:           origin,
+		Destination:      destination,
+		Advisories:       advisories,
+		DisruptionLikely: disruptionLikely,
+		Provider:         ws.provider.Name(),
+	}
+}
+
+// AssessAirportWeather derives advisories from the forecast, falling back to current conditions
+func AssessAirportWeather(weather models.AirportWeather) []models.WeatherAdvisory {
+	conditions, when := weather.Forecast, "forecast at departure"
+	if conditions == nil {
+		conditions, when = weather.Current, "currently reported"
+	}
+	if conditions == nil {
+		return nil
+	}
+
+	var advisories []models.WeatherAdvisory
+	add := func(kind, severity, format string, args ...interface{}) {
+		advisories = append(advisories, models.WeatherAdvisory{
+			Airport:  weather.Airport,
+			Type:     kind,
+			Severity: severity,
+			Message:  fmt.Sprintf(format, args...) + " " + when,
+		})
+	}
+
+	switch code := conditions.WeatherCode; {
+	case code >= 95:
+		add("THUNDERSTORM", models.SeverityHigh, "Thunderstorms")
+	case code == 66 || code == 67:
+		add("FREEZING_RAIN", models.SeverityHigh, "Freezing rain")
+	case code == 75 || code == 86:
+		add("SNOW", models.SeverityHigh, "Heavy snow")
+	case (code >= 71 && code <= 77) || code == 85:
+		add("SNOW", models.SeverityModerate, "Snow")
+	}
+
+	switch gusts := conditions.WindGustsKPH; {
+	case gusts >= 75:
+		add("HIGH_WINDS", models.SeverityHigh, "Wind gusts of %.0f km/h", gusts)
+	case gusts >= 50:
+		add("HIGH_WINDS", models.SeverityModerate, "Wind gusts of %.0f km/h", gusts)
+	}
+
+	if vis := conditions.VisibilityM; vis > 0 {
+		switch {
+		case vis < 200:
+			add("LOW_VISIBILITY", models.SeverityHigh, "Visibility of %.0f m", vis)
+		case vis < 1000:
+			add("LOW_VISIBILITY", models.SeverityModerate, "Visibility of %.0f m", vis)
+		}
+	}
+
+	switch precip := conditions.PrecipitationMM; {
+	case precip >= 16:
+		add("HEAVY_RAIN", models.SeverityModerate, "Precipitation of %.1f mm/h", precip)
+	case precip >= 7.6:
+		add("HEAVY_RAIN", models.SeverityLow, "Precipitation of %.1f mm/h", precip)
+	}
+
+	return advisories
+}
+
+// DescribeWeatherCode converts a WMO weather interpretation code to a short summary
+func DescribeWeatherCode(code int) string {
+	switch {
+	case code == 0:
+		return "Clear sky"
+	case code <= 2:
+		return "Partly cloudy"
+	case code == 3:
+		return "Overcast"
+	case code == 45 || code == 48:
+		return "Fog"
+	case code >= 51 && code <= 57:
+		return "Drizzle"
+	case code == 66 || code == 67:
+		return "Freezing rain"
+	case code >= 61 && code <= 65:
+		return "Rain"
+	case code >= 71 && code <= 77:
+		return "Snow"
+	case code >= 80 && code <= 82:
+		return "Rain showers"
+	case code == 85 || code == 86:
+		return "Snow showers"
+	case code >= 95:
+		return "Thunderstorm"
+	default:
+		return "Unknown"
+	}
+}
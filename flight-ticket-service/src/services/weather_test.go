@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+type countingProvider struct {
+	calls      int
+	conditions models.WeatherConditions
+}
+
+func (p *countingProvider) Name() string { return "counting" }
+
+func (p *countingProvider) GetWeather(ctx context.Context, airport string, loc AirportLocation, at time.Time) (*models.AirportWeather, error) {
+	p.calls++
+	c := p.conditions
+	return &models.AirportWeather{Airport: airport, Current: &c, Forecast: &c}, nil
+}
+
+func TestWeatherServiceCachesLookups(t *testing.T) {
+	provider := &countingProvider{}
+	ws := NewWeatherService(provider, time.Minute)
+	at := time.Date(2024, 12, 25, 14, 30, 0, 0, time.UTC)
+
+	ws.GetAirportWeather(context.Background(), "JFK", at)
+	ws.GetAirportWeather(context.Background(), "JFK", at.Add(10*time.Minute))
+
+	if provider.calls != 1 {
+		t.Errorf("Expected 1 provider call for the same airport and hour, got %d", provider.calls)
+	}
+
+	ws.GetAirportWeather(context.Background(), "JFK", at.Add(time.Hour))
+	if provider.calls != 2 {
+		t.Errorf("Expected 2 provider calls after the hour changed, got %d", provider.calls)
+	}
+}
+
+func TestWeatherServiceUnknownAirport(t *testing.T) {
+	provider := &countingProvider{}
+	ws := NewWeatherService(provider, time.Minute)
+
+	weather := ws.GetAirportWeather(context.Background(), "XXX", time.Now())
+	if weather.Error == "" {
+		t.Error("Expected error for unknown airport")
+	}
+	if provider.calls != 0 {
+		t.Errorf("Expected no provider calls for unknown airport, got %d", provider.calls)
+	}
+}
+
+func TestGetTicketAdvisoriesFlagsDisruption(t *testing.T) {
+	provider := &countingProvider{conditions: models.WeatherConditions{WeatherCode: 95, WindGustsKPH: 30}}
+	ws := NewWeatherService(provider, time.Minute)
+	ticket := &models.FlightTicket{
+		ConfirmationID: "ABC123",
+		Origin:         "JFK",
+		Destination:    "LAX",
+		DepartureTime:  time.Date(2024, 12, 25, 14, 30, 0, 0, time.UTC),
+	}
+
+	resp := ws.GetTicketAdvisories(context.Background(), ticket)
+
+	if !resp.DisruptionLikely {
+		t.Error("Expected disruption to be likely with thunderstorms forecast")
+	}
+	if len(resp.Advisories) != 2 {
+		t.Errorf("Expected 2 advisories, got %d", len(resp.Advisories))
+	}
+}
+
+func TestAssessAirportWeather(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions models.WeatherConditions
+		expected   []string
+	}{
+		{"clear", models.WeatherConditions{WeatherCode: 0, WindGustsKPH: 20, VisibilityM: 10000}, nil},
+		{"strong gusts", models.WeatherConditions{WindGustsKPH: 60}, []string{"HIGH_WINDS/MODERATE"}},
+		{"fog", models.WeatherConditions{WeatherCode: 45, VisibilityM: 150}, []string{"LOW_VISIBILITY/HIGH"}},
+		{"snow and wind", models.WeatherConditions{WeatherCode: 73, WindGustsKPH: 80}, []string{"SNOW/MODERATE", "HIGH_WINDS/HIGH"}},
+	}
+
+	for _, test := range tests {
+		c := test.conditions
+		advisories := AssessAirportWeather(models.AirportWeather{Airport: "JFK", Forecast: &c})
+
+		var got []string
+		for _, a := range advisories {
+			got = append(got, a.Type+"/"+a.Severity)
+		}
+
+		if len(got) != len(test.expected) {
+			t.Errorf("%s: expected advisories %v, got %v", test.name, test.expected, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.expected[i] {
+				t.Errorf("%s: expected advisories %v, got %v", test.name, test.expected, got)
+				break
+			}
+		}
+	}
+}
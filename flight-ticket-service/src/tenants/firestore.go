@@ -0,0 +1,63 @@
+package tenants
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"flight-ticket-service/src/internal/docstore"
+	"flight-ticket-service/src/models"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// firestoreCollection holds one document per tenant, keyed by tenant ID
+const firestoreCollection = "tenants"
+
+// FirestoreStore keeps tenants in Firestore, shared by every instance
+type FirestoreStore struct {
+	client *firestore.Client
+}
+
+// NewFirestoreStore creates a tenant store in the database of the given client
+func NewFirestoreStore(client *firestore.Client) *FirestoreStore {
+	return &FirestoreStore{client: client}
+}
+
+// List returns every tenant
+func (s *FirestoreStore) List(ctx context.Context) ([]*models.Tenant, error) {
+	return docstore.List[models.Tenant](ctx, s.client.Collection(firestoreCollection).Query, "tenant")
+}
+
+// Get returns a tenant
+func (s *FirestoreStore) Get(ctx context.Context, id string) (*models.Tenant, error) {
+	tenant, err := docstore.Get[models.Tenant](ctx, s.client.Collection(firestoreCollection).Doc(id), "tenant")
+	if errors.Is(err, docstore.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	return tenant, err
+}
+
+// Save creates or replaces a tenant
+func (s *FirestoreStore) Save(ctx context.Context, tenant *models.Tenant) error {
+	return docstore.Set(ctx, s.client.Collection(firestoreCollection).Doc(tenant.ID), tenant, "tenant")
+}
+
+// Delete removes a tenant
+func (s *FirestoreStore) Delete(ctx context.Context, id string) error {
+	_, err := s.client.Collection(firestoreCollection).Doc(id).Delete(ctx, firestore.Exists)
+	if status.Code(err) == codes.NotFound {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete tenant: %v", err)
+	}
+	return nil
+}
+
+// Close leaves the client open: it belongs to the ticket repository
+func (s *FirestoreStore) Close() error {
+	return nil
+}
@@ -0,0 +1,79 @@
+package tenants
+
+import (
+	"context"
+	"sync"
+
+	"flight-ticket-service/src/models"
+)
+
+// MemoryStore keeps tenants in memory, for single-instance deployments and
+// tests. Tenants are lost when the instance stops.
+type MemoryStore struct {
+	mu      sync.Mutex
+	tenants map[string]*models.Tenant
+}
+
+// NewMemoryStore creates an empty tenant store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{tenants: make(map[string]*models.Tenant)}
+}
+
+// List returns copies of every tenant
+func (s *MemoryStore) List(ctx context.Context) ([]*models.Tenant, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tenants := make([]*models.Tenant, 0, len(s.tenants))
+	for _, tenant := range s.tenants {
+		tenants = append(tenants, copyTenant(tenant))
+	}
+	return tenants, nil
+}
+
+// Get returns a copy of a tenant
+func (s *MemoryStore) Get(ctx context.Context, id string) (*models.Tenant, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tenant, ok := s.tenants[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyTenant(tenant), nil
+}
+
+// Save creates or replaces a tenant
+func (s *MemoryStore) Save(ctx context.Context, tenant *models.Tenant) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tenants[tenant.ID] = copyTenant(tenant)
+	return nil
+}
+
+// Delete removes a tenant
+func (s *MemoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tenants[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.tenants, id)
+	return nil
+}
+
+// Close does nothing; memory stores hold no connections
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// copyTenant copies a tenant, including its key list and template map
+func copyTenant(tenant *models.Tenant) *models.Tenant {
+	c := *tenant
+	c.APIKeys = append([]string(nil), tenant.APIKeys...)
+	if tenant.NotificationTemplates != nil {
+		c.NotificationTemplates = make(map[string]string, len(tenant.NotificationTemplates))
+		for notificationType, template := range tenant.NotificationTemplates {
+			c.NotificationTemplates[notificationType] = template
+		}
+	}
+	return &c
+}
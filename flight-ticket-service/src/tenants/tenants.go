@@ -0,0 +1,228 @@
+// Package tenants holds the configuration overrides of tenants: the
+// notification templates, booking rules and document branding that replace
+// the service defaults for one customer.
+//
+// Tenants are stored in the tenants collection (Firestore, or memory with the
+// other backends) and cached by every instance, which reloads them every
+// TENANTS_REFRESH_INTERVAL (default 1m). A request belongs to the tenant
+// listing the name of its API key; tickets it books carry the tenant in the
+// reserved tenant label, so later notifications use the tenant's templates.
+package tenants
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"flight-ticket-service/src/auth"
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/services"
+)
+
+// DefaultRefreshInterval is how often the cache is reloaded from the store
+const DefaultRefreshInterval = time.Minute
+
+var (
+	// ErrNotFound is returned for unknown tenant IDs
+	ErrNotFound = errors.New("tenant not found")
+	// ErrKeyInUse is wrapped when an API key already belongs to another tenant
+	ErrKeyInUse = errors.New("API key belongs to another tenant")
+)
+
+// Store keeps the tenants
+type Store interface {
+	// List returns every tenant
+	List(ctx context.Context) ([]*models.Tenant, error)
+	// Get returns a tenant, or ErrNotFound
+	Get(ctx context.Context, id string) (*models.Tenant, error)
+	// Save creates or replaces a tenant
+	Save(ctx context.Context, tenant *models.Tenant) error
+	// Delete removes a tenant, or returns ErrNotFound
+	Delete(ctx context.Context, id string) error
+	// Close releases the store's connections
+	Close() error
+}
+
+// NewStore returns the store of the repository's backend: Firestore tenants are
+// shared by every instance, the other backends keep them in memory. A
+// Firestore store uses the repository's client.
+func NewStore(repository services.TicketRepository) (Store, error) {
+	firestoreService, ok := repository.(*services.FirestoreService)
+	if !ok {
+		return NewMemoryStore(), nil
+	}
+	return NewFirestoreStore(firestoreService.Client()), nil
+}
+
+// RefreshIntervalFromEnv reads TENANTS_REFRESH_INTERVAL
+func RefreshIntervalFromEnv() (time.Duration, error) {
+	value := strings.TrimSpace(os.Getenv("TENANTS_REFRESH_INTERVAL"))
+	if value == "" {
+		return DefaultRefreshInterval, nil
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval < time.Second {
+		return 0, fmt.Errorf("invalid TENANTS_REFRESH_INTERVAL %q: must be a duration of at least 1s", value)
+	}
+	return interval, nil
+}
+
+// Registry caches the tenants of a store, indexed by ID and API key name
+type Registry struct {
+	store Store
+
+	mu    sync.RWMutex
+	byID  map[string]*models.Tenant
+	byKey map[string]*models.Tenant
+}
+
+// NewRegistry creates an empty registry of a store; call Load to fill it
+func NewRegistry(store Store) *Registry {
+	return &Registry{store: store, byID: map[string]*models.Tenant{}, byKey: map[string]*models.Tenant{}}
+}
+
+// Load replaces the cache with the tenants of the store
+func (r *Registry) Load(ctx context.Context) error {
+	tenants, err := r.store.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load tenants: %v", err)
+	}
+
+	byID := make(map[string]*models.Tenant, len(tenants))
+	byKey := make(map[string]*models.Tenant)
+	for _, tenant := range tenants {
+		byID[tenant.ID] = tenant
+		for _, key := range tenant.APIKeys {
+			if other, ok := byKey[key]; ok {
+				log.Printf("API key %s belongs to tenants %s and %s; using %s", key, other.ID, tenant.ID, other.ID)
+				continue
+			}
+			byKey[key] = tenant
+		}
+	}
+
+	r.mu.Lock()
+	r.byID, r.byKey = byID, byKey
+	r.mu.Unlock()
+	return nil
+}
+
+// Watch reloads the cache every interval until ctx is done. Failed reloads
+// are logged and keep the previous tenants.
+func (r *Registry) Watch(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+			if err := r.Load(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Keeping cached tenants: %v", err)
+			}
+		}
+	}()
+}
+
+// Len returns the number of cached tenants
+func (r *Registry) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.byID)
+}
+
+// List returns the cached tenants by ID
+func (r *Registry) List() []*models.Tenant {
+	r.mu.RLock()
+	tenants := make([]*models.Tenant, 0, len(r.byID))
+	for _, tenant := range r.byID {
+		tenants = append(tenants, tenant)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(tenants, func(i, j int) bool { return tenants[i].ID < tenants[j].ID })
+	return tenants
+}
+
+// Tenant returns a cached tenant, or nil
+func (r *Registry) Tenant(id string) *models.Tenant {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.byID[id]
+}
+
+// ForKey returns the tenant of an API key name, or nil
+func (r *Registry) ForKey(name string) *models.Tenant {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.byKey[name]
+}
+
+// Save stores a tenant and reloads the cache. It fails with ErrKeyInUse when
+// one of its API keys belongs to another tenant.
+func (r *Registry) Save(ctx context.Context, tenant *models.Tenant) error {
+	if err := r.Load(ctx); err != nil {
+		return err
+	}
+	for _, key := range tenant.APIKeys {
+		if other := r.ForKey(key); other != nil && other.ID != tenant.ID {
+			return fmt.Errorf("%w: %s belongs to %s", ErrKeyInUse, key, other.ID)
+		}
+	}
+	if err := r.store.Save(ctx, tenant); err != nil {
+		return err
+	}
+	return r.Load(ctx)
+}
+
+// Delete removes a tenant and reloads the cache
+func (r *Registry) Delete(ctx context.Context, id string) error {
+	if err := r.store.Delete(ctx, id); err != nil {
+		return err
+	}
+	return r.Load(ctx)
+}
+
+// NotificationTemplate returns the template a tenant uses for a notification
+// type, or "" for the default template
+func (r *Registry) NotificationTemplate(tenantID, notificationType string) string {
+	if tenant := r.Tenant(tenantID); tenant != nil {
+		return tenant.NotificationTemplates[notificationType]
+	}
+	return ""
+}
+
+type contextKey struct{}
+
+// NewContext returns a context carrying the tenant of a request
+func NewContext(ctx context.Context, tenant *models.Tenant) context.Context {
+	return context.WithValue(ctx, contextKey{}, tenant)
+}
+
+// FromContext returns the tenant of a request, or nil when it has none
+func FromContext(ctx context.Context) *models.Tenant {
+	tenant, _ := ctx.Value(contextKey{}).(*models.Tenant)
+	return tenant
+}
+
+// Middleware resolves the tenant of each request from its API key; it must
+// run after authentication
+func (r *Registry) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if principal, ok := auth.FromContext(req.Context()); ok {
+			if tenant := r.ForKey(principal.Name); tenant != nil {
+				req = req.WithContext(NewContext(req.Context(), tenant))
+			}
+		}
+		next.ServeHTTP(w, req)
+	})
+}
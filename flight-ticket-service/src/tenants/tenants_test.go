@@ -0,0 +1,80 @@
+package tenants
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"flight-ticket-service/src/auth"
+	"flight-ticket-service/src/models"
+)
+
+func TestRegistry(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	store.Save(ctx, &models.Tenant{ID: "acme", APIKeys: []string{"acme-desk"}, NotificationTemplates: map[string]string{"ticket_changed": "acme-changed"}})
+
+	registry := NewRegistry(store)
+	if err := registry.Load(ctx); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if tenant := registry.ForKey("acme-desk"); tenant == nil || tenant.ID != "acme" {
+		t.Errorf("Expected acme for its key, got %+v", tenant)
+	}
+	if registry.ForKey("other") != nil {
+		t.Error("Expected no tenant for other keys")
+	}
+	if got := registry.NotificationTemplate("acme", "ticket_changed"); got != "acme-changed" {
+		t.Errorf("Template = %q", got)
+	}
+	if got := registry.NotificationTemplate("acme", "departure_reminder") + registry.NotificationTemplate("unknown", "ticket_changed"); got != "" {
+		t.Errorf("Expected default templates, got %q", got)
+	}
+
+	err := registry.Save(ctx, &models.Tenant{ID: "globex", APIKeys: []string{"acme-desk"}})
+	if !errors.Is(err, ErrKeyInUse) {
+		t.Errorf("Expected ErrKeyInUse, got %v", err)
+	}
+	if err := registry.Save(ctx, &models.Tenant{ID: "globex", APIKeys: []string{"globex-desk"}}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if registry.Len() != 2 || registry.List()[1].ID != "globex" {
+		t.Errorf("Expected two tenants by ID, got %d", registry.Len())
+	}
+
+	if err := registry.Delete(ctx, "acme"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if registry.ForKey("acme-desk") != nil {
+		t.Error("Expected the key released")
+	}
+	if err := registry.Delete(ctx, "acme"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	store := NewMemoryStore()
+	store.Save(context.Background(), &models.Tenant{ID: "acme", APIKeys: []string{"acme-desk"}})
+	registry := NewRegistry(store)
+	registry.Load(context.Background())
+
+	var got *models.Tenant
+	handler := registry.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = FromContext(r.Context())
+	}))
+
+	for name, want := range map[string]string{"acme-desk": "acme", "ops": "", "": ""} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if name != "" {
+			req = req.WithContext(auth.WithPrincipal(req.Context(), auth.Principal{Name: name, Role: auth.RoleAgent}))
+		}
+		got = nil
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		if (got == nil && want != "") || (got != nil && got.ID != want) {
+			t.Errorf("Key %q: got tenant %+v, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package travelers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"flight-ticket-service/src/internal/docstore"
+	"flight-ticket-service/src/models"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// firestoreCollection holds one document per traveler, keyed by traveler ID
+const firestoreCollection = "travelers"
+
+// FirestoreStore keeps travelers in Firestore, shared by every instance
+type FirestoreStore struct {
+	client *firestore.Client
+}
+
+// NewFirestoreStore creates a traveler store in the database of the given client
+func NewFirestoreStore(client *firestore.Client) *FirestoreStore {
+	return &FirestoreStore{client: client}
+}
+
+// Get returns a traveler
+func (s *FirestoreStore) Get(ctx context.Context, id string) (*models.Traveler, error) {
+	traveler, err := docstore.Get[models.Traveler](ctx, s.client.Collection(firestoreCollection).Doc(id), "traveler")
+	if errors.Is(err, docstore.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	return traveler, err
+}
+
+// List returns the travelers of an owner
+func (s *FirestoreStore) List(ctx context.Context, owner string) ([]*models.Traveler, error) {
+	query := s.client.Collection(firestoreCollection).Where("owner", "==", owner)
+	return docstore.List[models.Traveler](ctx, query, "traveler")
+}
+
+// Save creates or replaces a traveler
+func (s *FirestoreStore) Save(ctx context.Context, traveler *models.Traveler) error {
+	return docstore.Set(ctx, s.client.Collection(firestoreCollection).Doc(traveler.ID), traveler, "traveler")
+}
+
+// Delete removes a traveler
+func (s *FirestoreStore) Delete(ctx context.Context, id string) error {
+	_, err := s.client.Collection(firestoreCollection).Doc(id).Delete(ctx, firestore.Exists)
+	if status.Code(err) == codes.NotFound {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete traveler: %v", err)
+	}
+	return nil
+}
+
+// Close leaves the client open: it belongs to the ticket repository
+func (s *FirestoreStore) Close() error {
+	return nil
+}
@@ -0,0 +1,74 @@
+package travelers
+
+import (
+	"context"
+	"sync"
+
+	"flight-ticket-service/src/models"
+)
+
+// MemoryStore keeps travelers in memory, for single-instance deployments and
+// tests. Travelers are lost when the instance stops.
+type MemoryStore struct {
+	mu        sync.Mutex
+	travelers map[string]*models.Traveler
+}
+
+// NewMemoryStore creates an empty traveler store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{travelers: make(map[string]*models.Traveler)}
+}
+
+// Get returns a copy of a traveler
+func (s *MemoryStore) Get(ctx context.Context, id string) (*models.Traveler, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	traveler, ok := s.travelers[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyTraveler(traveler), nil
+}
+
+// List returns copies of the owner's travelers
+func (s *MemoryStore) List(ctx context.Context, owner string) ([]*models.Traveler, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var travelers []*models.Traveler
+	for _, traveler := range s.travelers {
+		if traveler.Owner == owner {
+			travelers = append(travelers, copyTraveler(traveler))
+		}
+	}
+	return travelers, nil
+}
+
+// Save creates or replaces a traveler
+func (s *MemoryStore) Save(ctx context.Context, traveler *models.Traveler) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.travelers[traveler.ID] = copyTraveler(traveler)
+	return nil
+}
+
+// Delete removes a traveler
+func (s *MemoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.travelers[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.travelers, id)
+	return nil
+}
+
+// Close does nothing; memory stores hold no connections
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+func copyTraveler(traveler *models.Traveler) *models.Traveler {
+	c := *traveler
+	c.Documents = append([]models.TravelDocument(nil), traveler.Documents...)
+	return &c
+}
@@ -0,0 +1,92 @@
+// Package travelers keeps the passenger profiles callers save for repeat
+// bookings: names, date of birth, travel documents and loyalty number.
+//
+// A profile belongs to the API key that saved it and is only visible to that
+// key. Bookings reference profiles by ID, so personal details are entered
+// once instead of on every booking, and tickets only carry the IDs. Profiles
+// are stored in the travelers collection (Firestore, or memory with the other
+// backends).
+package travelers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/services"
+)
+
+// ErrNotFound is returned for unknown traveler IDs and for travelers of another owner
+var ErrNotFound = errors.New("traveler not found")
+
+// Store keeps the traveler profiles
+type Store interface {
+	// Get returns a traveler, or ErrNotFound
+	Get(ctx context.Context, id string) (*models.Traveler, error)
+	// List returns the travelers of an owner
+	List(ctx context.Context, owner string) ([]*models.Traveler, error)
+	// Save creates or replaces a traveler
+	Save(ctx context.Context, traveler *models.Traveler) error
+	// Delete removes a traveler, or returns ErrNotFound
+	Delete(ctx context.Context, id string) error
+	// Close releases the store's connections
+	Close() error
+}
+
+// NewStore returns the store of the repository's backend: Firestore travelers are
+// shared by every instance, the other backends keep them in memory. A
+// Firestore store uses the repository's client.
+func NewStore(repository services.TicketRepository) (Store, error) {
+	firestoreService, ok := repository.(*services.FirestoreService)
+	if !ok {
+		return NewMemoryStore(), nil
+	}
+	return NewFirestoreStore(firestoreService.Client()), nil
+}
+
+// NewID returns a random traveler ID of 12 lowercase hex digits, usable as a label value
+func NewID() (string, error) {
+	id := make([]byte, 6)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("failed to generate traveler ID: %v", err)
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// Owned returns a traveler of the owner, or ErrNotFound
+func Owned(ctx context.Context, store Store, owner, id string) (*models.Traveler, error) {
+	if owner == "" || !models.ValidateTravelerID(id) {
+		return nil, ErrNotFound
+	}
+	traveler, err := store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if traveler.Owner != owner {
+		return nil, ErrNotFound
+	}
+	return traveler, nil
+}
+
+// ListOwned returns the travelers of the owner by surname and given name
+func ListOwned(ctx context.Context, store Store, owner string) ([]*models.Traveler, error) {
+	travelers, err := store.List(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(travelers, func(i, j int) bool {
+		a, b := travelers[i], travelers[j]
+		if a.LastName != b.LastName {
+			return a.LastName < b.LastName
+		}
+		if a.FirstName != b.FirstName {
+			return a.FirstName < b.FirstName
+		}
+		return a.ID < b.ID
+	})
+	return travelers, nil
+}
@@ -0,0 +1,63 @@
+package travelers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"flight-ticket-service/src/models"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	for _, traveler := range []*models.Traveler{
+		{ID: "aaaaaaaaaaaa", Owner: "desk", FirstName: "John", LastName: "Smith"},
+		{ID: "bbbbbbbbbbbb", Owner: "desk", FirstName: "Jane", LastName: "Doe"},
+		{ID: "cccccccccccc", Owner: "ops", FirstName: "Ann", LastName: "Able"},
+	} {
+		if err := store.Save(ctx, traveler); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+
+	list, err := ListOwned(ctx, store, "desk")
+	if err != nil {
+		t.Fatalf("ListOwned failed: %v", err)
+	}
+	if len(list) != 2 || list[0].LastName != "Doe" || list[1].LastName != "Smith" {
+		t.Errorf("Expected desk's travelers by surname, got %+v", list)
+	}
+
+	if _, err := Owned(ctx, store, "desk", "cccccccccccc"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected another owner's traveler hidden, got %v", err)
+	}
+	if _, err := Owned(ctx, store, "desk", "not-an-id"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a malformed ID, got %v", err)
+	}
+	traveler, err := Owned(ctx, store, "desk", "aaaaaaaaaaaa")
+	if err != nil {
+		t.Fatalf("Owned failed: %v", err)
+	}
+	traveler.FirstName = "Changed"
+	if stored, _ := store.Get(ctx, "aaaaaaaaaaaa"); stored.FirstName != "John" {
+		t.Error("Expected the store to hand out copies")
+	}
+
+	if err := store.Delete(ctx, "aaaaaaaaaaaa"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := store.Delete(ctx, "aaaaaaaaaaaa"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestNewID(t *testing.T) {
+	id, err := NewID()
+	if err != nil {
+		t.Fatalf("NewID failed: %v", err)
+	}
+	if !models.ValidateTravelerID(id) {
+		t.Errorf("Expected a valid traveler ID, got %q", id)
+	}
+}
@@ -0,0 +1,152 @@
+package upgrades
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"flight-ticket-service/src/internal/docstore"
+	"flight-ticket-service/src/models"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// FirestoreStore keeps offers in Firestore, shared by every instance.
+// Accepting and awarding read the departure's offers in a transaction, so two
+// instances cannot hand out the same cabin seats.
+type FirestoreStore struct {
+	client *firestore.Client
+}
+
+// NewFirestoreStore creates an offer store in the database of the given client
+func NewFirestoreStore(client *firestore.Client) *FirestoreStore {
+	return &FirestoreStore{client: client}
+}
+
+// Create stores the offers in a transaction
+func (s *FirestoreStore) Create(ctx context.Context, offers []*models.UpgradeOffer) error {
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		for _, offer := range offers {
+			if err := tx.Create(s.client.Collection(Collection).Doc(offer.ID), offer); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save upgrade offers: %v", err)
+	}
+	return nil
+}
+
+// Get returns an offer
+func (s *FirestoreStore) Get(ctx context.Context, id string) (*models.UpgradeOffer, error) {
+	offer, err := docstore.Get[models.UpgradeOffer](ctx, s.client.Collection(Collection).Doc(id), "upgrade offer")
+	if errors.Is(err, docstore.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	return offer, err
+}
+
+// ListTicket returns the ticket's offers
+func (s *FirestoreStore) ListTicket(ctx context.Context, confirmationID string) ([]*models.UpgradeOffer, error) {
+	query := s.client.Collection(Collection).Where("confirmation_id", "==", confirmationID)
+	return docstore.List[models.UpgradeOffer](ctx, query, "upgrade offer")
+}
+
+// ListDeparture returns the departure's offers
+func (s *FirestoreStore) ListDeparture(ctx context.Context, flightNumber, date string) ([]*models.UpgradeOffer, error) {
+	return docstore.List[models.UpgradeOffer](ctx, s.departureQuery(flightNumber, date), "upgrade offer")
+}
+
+// Update changes an offer in a transaction
+func (s *FirestoreStore) Update(ctx context.Context, id string, change func(*models.UpgradeOffer) error) (*models.UpgradeOffer, error) {
+	offer, err := docstore.Update[models.UpgradeOffer](ctx, s.client, s.client.Collection(Collection).Doc(id), "upgrade offer", change)
+	if errors.Is(err, docstore.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	return offer, err
+}
+
+// Accept accepts the offer in a transaction over the departure's offers
+func (s *FirestoreStore) Accept(ctx context.Context, id string, capacity int, now time.Time) (*models.UpgradeOffer, error) {
+	var accepted *models.UpgradeOffer
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(s.client.Collection(Collection).Doc(id))
+		if status.Code(err) == codes.NotFound {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		offer, err := docstore.Decode[models.UpgradeOffer](doc, "upgrade offer")
+		if err != nil {
+			return err
+		}
+		departure, err := s.getDeparture(tx, offer.FlightNumber, offer.Date)
+		if err != nil {
+			return err
+		}
+		if err := accept(offer, departure, capacity, now); err != nil {
+			return err
+		}
+		accepted = offer
+		return tx.Set(doc.Ref, offer)
+	})
+	if err != nil && !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrNotOpen) && !errors.Is(err, ErrCabinFull) {
+		return nil, fmt.Errorf("failed to accept upgrade offer: %v", err)
+	}
+	return accepted, err
+}
+
+// Award decides the bids in a transaction over the departure's offers
+func (s *FirestoreStore) Award(ctx context.Context, flightNumber, date, cabin string, capacity int, now time.Time) ([]*models.UpgradeOffer, []*models.UpgradeOffer, error) {
+	var awarded, rejected []*models.UpgradeOffer
+	err := s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		departure, err := s.getDeparture(tx, flightNumber, date)
+		if err != nil {
+			return err
+		}
+		awarded, rejected = award(departure, cabin, capacity, now)
+		for _, offer := range append(append([]*models.UpgradeOffer(nil), awarded...), rejected...) {
+			if err := tx.Set(s.client.Collection(Collection).Doc(offer.ID), offer); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to award upgrade bids: %v", err)
+	}
+	return awarded, rejected, nil
+}
+
+// departureQuery selects the offers of a departure
+func (s *FirestoreStore) departureQuery(flightNumber, date string) firestore.Query {
+	return s.client.Collection(Collection).Where("flight_number", "==", flightNumber).Where("date", "==", date)
+}
+
+// getDeparture reads the offers of a departure in a transaction
+func (s *FirestoreStore) getDeparture(tx *firestore.Transaction, flightNumber, date string) ([]*models.UpgradeOffer, error) {
+	docs, err := tx.Documents(s.departureQuery(flightNumber, date)).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	offers := make([]*models.UpgradeOffer, 0, len(docs))
+	for _, doc := range docs {
+		offer, err := docstore.Decode[models.UpgradeOffer](doc, "upgrade offer")
+		if err != nil {
+			return nil, err
+		}
+		offers = append(offers, offer)
+	}
+	return offers, nil
+}
+
+// Close leaves the client open: it belongs to the ticket repository
+func (s *FirestoreStore) Close() error {
+	return nil
+}
@@ -0,0 +1,138 @@
+package upgrades
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+// MemoryStore keeps offers in memory, for single-instance deployments and
+// tests. Offers are lost when the instance stops.
+type MemoryStore struct {
+	mu     sync.Mutex
+	offers map[string]*models.UpgradeOffer
+}
+
+// NewMemoryStore creates an empty offer store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{offers: make(map[string]*models.UpgradeOffer)}
+}
+
+// Create stores copies of the offers
+func (s *MemoryStore) Create(ctx context.Context, offers []*models.UpgradeOffer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, offer := range offers {
+		s.offers[offer.ID] = copyOffer(offer)
+	}
+	return nil
+}
+
+// Get returns a copy of an offer
+func (s *MemoryStore) Get(ctx context.Context, id string) (*models.UpgradeOffer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	offer, ok := s.offers[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyOffer(offer), nil
+}
+
+// ListTicket returns copies of the ticket's offers
+func (s *MemoryStore) ListTicket(ctx context.Context, confirmationID string) ([]*models.UpgradeOffer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var list []*models.UpgradeOffer
+	for _, offer := range s.offers {
+		if offer.ConfirmationID == confirmationID {
+			list = append(list, copyOffer(offer))
+		}
+	}
+	return list, nil
+}
+
+// ListDeparture returns copies of the departure's offers
+func (s *MemoryStore) ListDeparture(ctx context.Context, flightNumber, date string) ([]*models.UpgradeOffer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var list []*models.UpgradeOffer
+	for _, offer := range s.departure(flightNumber, date) {
+		list = append(list, copyOffer(offer))
+	}
+	return list, nil
+}
+
+// Update applies change to a copy of the offer and stores it if change succeeds
+func (s *MemoryStore) Update(ctx context.Context, id string, change func(*models.UpgradeOffer) error) (*models.UpgradeOffer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	offer, ok := s.offers[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	updated := copyOffer(offer)
+	if err := change(updated); err != nil {
+		return nil, err
+	}
+	s.offers[id] = updated
+	return copyOffer(updated), nil
+}
+
+// Accept accepts the offer if the cabin has seats left
+func (s *MemoryStore) Accept(ctx context.Context, id string, capacity int, now time.Time) (*models.UpgradeOffer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	offer, ok := s.offers[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	updated := copyOffer(offer)
+	if err := accept(updated, s.departure(offer.FlightNumber, offer.Date), capacity, now); err != nil {
+		return nil, err
+	}
+	s.offers[id] = updated
+	return copyOffer(updated), nil
+}
+
+// Award decides the bids placed on the cabin
+func (s *MemoryStore) Award(ctx context.Context, flightNumber, date, cabin string, capacity int, now time.Time) ([]*models.UpgradeOffer, []*models.UpgradeOffer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var departure []*models.UpgradeOffer
+	for _, offer := range s.departure(flightNumber, date) {
+		departure = append(departure, copyOffer(offer))
+	}
+	awarded, rejected := award(departure, cabin, capacity, now)
+	for _, offer := range append(append([]*models.UpgradeOffer(nil), awarded...), rejected...) {
+		s.offers[offer.ID] = copyOffer(offer)
+	}
+	return awarded, rejected, nil
+}
+
+// Close is a no-op
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// departure returns the stored offers of a departure; the caller holds the lock
+func (s *MemoryStore) departure(flightNumber, date string) []*models.UpgradeOffer {
+	var list []*models.UpgradeOffer
+	for _, offer := range s.offers {
+		if offer.FlightNumber == flightNumber && offer.Date == date {
+			list = append(list, offer)
+		}
+	}
+	return list
+}
+
+func copyOffer(offer *models.UpgradeOffer) *models.UpgradeOffer {
+	copied := *offer
+	if offer.DecidedAt != nil {
+		decided := *offer.DecidedAt
+		copied.DecidedAt = &decided
+	}
+	return &copied
+}
@@ -0,0 +1,177 @@
+// Package upgrades keeps the offers that move tickets to a premium cabin.
+//
+// Offers are made to the eligible tickets of a departure, either at a fixed
+// price or for a bid. Accepting a fixed-price offer upgrades the ticket at
+// once if the cabin still has seats for upgrades. Bids wait until they are
+// awarded, highest first, while seats are left. Each departure has a number
+// of seats per premium cabin for upgrades (UPGRADE_CABIN_SEATS). Offers are
+// stored in the upgrade_offers collection (Firestore, or memory with the
+// other backends).
+package upgrades
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/services"
+)
+
+// Collection is the Firestore collection of upgrade offers, keyed by offer ID
+const Collection = "upgrade_offers"
+
+// Upgrade errors
+var (
+	// ErrNotFound is returned for unknown offer IDs
+	ErrNotFound = errors.New("upgrade offer not found")
+	// ErrNotOpen is returned when answering an offer that was answered, decided or expired
+	ErrNotOpen = errors.New("upgrade offer is no longer open")
+	// ErrCabinFull is returned when the cabin has no seats left for the offer's passengers
+	ErrCabinFull = errors.New("not enough upgrade seats left in the cabin")
+)
+
+// Capacity is the number of seats of each premium cabin available for upgrades on every departure
+type Capacity map[string]int
+
+// DefaultCapacity applies when UPGRADE_CABIN_SEATS is not set
+var DefaultCapacity = Capacity{models.CabinPremiumEconomy: 12, models.CabinBusiness: 4, models.CabinFirst: 0}
+
+// CapacityFromEnv reads UPGRADE_CABIN_SEATS, comma-separated cabin=seats
+// entries such as W=12,J=4,F=2; cabins left out keep their default
+func CapacityFromEnv() (Capacity, error) {
+	capacity := make(Capacity, len(DefaultCapacity))
+	for cabin, seats := range DefaultCapacity {
+		capacity[cabin] = seats
+	}
+	for _, entry := range strings.Split(os.Getenv("UPGRADE_CABIN_SEATS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		cabin, value, _ := strings.Cut(entry, "=")
+		cabin = strings.ToUpper(strings.TrimSpace(cabin))
+		seats, err := strconv.Atoi(strings.TrimSpace(value))
+		if !models.CabinAbove(cabin, models.CabinEconomy) || err != nil || seats < 0 {
+			return nil, fmt.Errorf("invalid UPGRADE_CABIN_SEATS entry %q: use W, J or F=seats", entry)
+		}
+		capacity[cabin] = seats
+	}
+	return capacity, nil
+}
+
+// Store keeps the upgrade offers
+type Store interface {
+	// Create stores new offers
+	Create(ctx context.Context, offers []*models.UpgradeOffer) error
+	// Get returns an offer, or ErrNotFound
+	Get(ctx context.Context, id string) (*models.UpgradeOffer, error)
+	// ListTicket returns the offers made to a ticket
+	ListTicket(ctx context.Context, confirmationID string) ([]*models.UpgradeOffer, error)
+	// ListDeparture returns the offers made on a departure
+	ListDeparture(ctx context.Context, flightNumber, date string) ([]*models.UpgradeOffer, error)
+	// Update applies change to an offer and stores it, serialized with other
+	// changes of the offer. It returns ErrNotFound, or the error of change.
+	Update(ctx context.Context, id string, change func(*models.UpgradeOffer) error) (*models.UpgradeOffer, error)
+	// Accept accepts an open fixed-price offer if the departure's cabin has
+	// seats left for its passengers, or returns ErrNotOpen or ErrCabinFull
+	Accept(ctx context.Context, id string, capacity int, now time.Time) (*models.UpgradeOffer, error)
+	// Award accepts the bids placed on a departure's cabin, highest first,
+	// while seats are left, and rejects the others
+	Award(ctx context.Context, flightNumber, date, cabin string, capacity int, now time.Time) (awarded, rejected []*models.UpgradeOffer, err error)
+	// Close releases the store's connections
+	Close() error
+}
+
+// NewStore returns the store of the repository's backend: Firestore offers are
+// shared by every instance, the other backends keep them in memory. A
+// Firestore store uses the repository's client.
+func NewStore(repository services.TicketRepository) (Store, error) {
+	firestoreService, ok := repository.(*services.FirestoreService)
+	if !ok {
+		return NewMemoryStore(), nil
+	}
+	return NewFirestoreStore(firestoreService.Client()), nil
+}
+
+// NewID returns a random offer ID of 16 lowercase hex digits
+func NewID() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("failed to generate upgrade offer ID: %v", err)
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// Upgraded returns the passengers upgraded to a cabin by accepted offers
+func Upgraded(offers []*models.UpgradeOffer, cabin string) int {
+	upgraded := 0
+	for _, offer := range offers {
+		if offer.Cabin == cabin && offer.Status == models.UpgradeAccepted {
+			upgraded += offer.Passengers
+		}
+	}
+	return upgraded
+}
+
+// SortNewest orders offers from the newest
+func SortNewest(offers []*models.UpgradeOffer) {
+	sort.Slice(offers, func(i, j int) bool {
+		if !offers[i].CreatedAt.Equal(offers[j].CreatedAt) {
+			return offers[i].CreatedAt.After(offers[j].CreatedAt)
+		}
+		return offers[i].ID < offers[j].ID
+	})
+}
+
+// accept accepts the offer if it is an open fixed-price offer and its
+// passengers fit in the cabin next to the departure's accepted offers
+func accept(offer *models.UpgradeOffer, departure []*models.UpgradeOffer, capacity int, now time.Time) error {
+	if offer.Type != models.UpgradeFixed || offer.Status != models.UpgradeOffered || !offer.Open(now) {
+		return ErrNotOpen
+	}
+	if Upgraded(departure, offer.Cabin)+offer.Passengers > capacity {
+		return ErrCabinFull
+	}
+	decided := now.UTC()
+	offer.Status, offer.DecidedAt = models.UpgradeAccepted, &decided
+	return nil
+}
+
+// award decides the bids placed on the cabin, highest first and then oldest
+// first, and returns the offers it changed
+func award(departure []*models.UpgradeOffer, cabin string, capacity int, now time.Time) (awarded, rejected []*models.UpgradeOffer) {
+	var bids []*models.UpgradeOffer
+	for _, offer := range departure {
+		if offer.Cabin == cabin && offer.Status == models.UpgradeBidPlaced {
+			bids = append(bids, offer)
+		}
+	}
+	sort.SliceStable(bids, func(i, j int) bool {
+		if bids[i].Bid != bids[j].Bid {
+			return bids[i].Bid > bids[j].Bid
+		}
+		return bids[i].CreatedAt.Before(bids[j].CreatedAt)
+	})
+
+	left := capacity - Upgraded(departure, cabin)
+	decided := now.UTC()
+	for _, bid := range bids {
+		if bid.Passengers <= left {
+			left -= bid.Passengers
+			bid.Status, bid.DecidedAt = models.UpgradeAccepted, &decided
+			awarded = append(awarded, bid)
+		} else {
+			bid.Status, bid.DecidedAt = models.UpgradeRejected, &decided
+			rejected = append(rejected, bid)
+		}
+	}
+	return awarded, rejected
+}
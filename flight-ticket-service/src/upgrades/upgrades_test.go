@@ -0,0 +1,110 @@
+package upgrades
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+func testOffer(id, confirmationID, offerType string, passengers int, created time.Time) *models.UpgradeOffer {
+	return &models.UpgradeOffer{
+		ID:             id,
+		ConfirmationID: confirmationID,
+		FlightNumber:   "AA1234",
+		Date:           "2025-03-10",
+		Cabin:          models.CabinBusiness,
+		Type:           offerType,
+		Passengers:     passengers,
+		Price:          150,
+		MinBid:         80,
+		Status:         models.UpgradeOffered,
+		CreatedAt:      created,
+		ExpiresAt:      created.Add(24 * time.Hour),
+	}
+}
+
+func TestMemoryStoreAccept(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	store.Create(ctx, []*models.UpgradeOffer{
+		testOffer("a", "ABC123", models.UpgradeFixed, 2, now),
+		testOffer("b", "DEF456", models.UpgradeFixed, 2, now),
+		testOffer("c", "GHI789", models.UpgradeFixed, 1, now),
+	})
+
+	accepted, err := store.Accept(ctx, "a", 3, now)
+	if err != nil || accepted.Status != models.UpgradeAccepted || accepted.DecidedAt == nil {
+		t.Fatalf("Expected the offer accepted, got %+v, %v", accepted, err)
+	}
+	if _, err := store.Accept(ctx, "a", 3, now); !errors.Is(err, ErrNotOpen) {
+		t.Errorf("Expected ErrNotOpen accepting twice, got %v", err)
+	}
+	if _, err := store.Accept(ctx, "b", 3, now); !errors.Is(err, ErrCabinFull) {
+		t.Errorf("Expected ErrCabinFull for 2 passengers and 1 seat left, got %v", err)
+	}
+	if _, err := store.Accept(ctx, "c", 3, now.Add(25*time.Hour)); !errors.Is(err, ErrNotOpen) {
+		t.Errorf("Expected ErrNotOpen after the deadline, got %v", err)
+	}
+	if _, err := store.Accept(ctx, "c", 3, now); err != nil {
+		t.Errorf("Expected the last seat taken, got %v", err)
+	}
+	if _, err := store.Accept(ctx, "x", 3, now); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	departure, _ := store.ListDeparture(ctx, "AA1234", "2025-03-10")
+	if upgraded := Upgraded(departure, models.CabinBusiness); upgraded != 3 {
+		t.Errorf("Expected 3 passengers upgraded, got %d", upgraded)
+	}
+}
+
+func TestMemoryStoreAward(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	bids := []*models.UpgradeOffer{
+		testOffer("low", "ABC123", models.UpgradeBid, 1, now),
+		testOffer("high", "DEF456", models.UpgradeBid, 2, now.Add(time.Minute)),
+		testOffer("tie", "GHI789", models.UpgradeBid, 1, now.Add(2*time.Minute)),
+		testOffer("open", "JKL012", models.UpgradeBid, 1, now),
+	}
+	bids[0].Bid, bids[1].Bid, bids[2].Bid = 100, 200, 100
+	bids[0].Status, bids[1].Status, bids[2].Status = models.UpgradeBidPlaced, models.UpgradeBidPlaced, models.UpgradeBidPlaced
+	store.Create(ctx, bids)
+
+	awarded, rejected, err := store.Award(ctx, "AA1234", "2025-03-10", models.CabinBusiness, 3, now.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("Award failed: %v", err)
+	}
+	if len(awarded) != 2 || awarded[0].ID != "high" || awarded[1].ID != "low" {
+		t.Errorf("Expected the highest and then the oldest bid awarded, got %+v", awarded)
+	}
+	if len(rejected) != 1 || rejected[0].ID != "tie" || rejected[0].Status != models.UpgradeRejected {
+		t.Errorf("Expected the newer equal bid rejected, got %+v", rejected)
+	}
+	if offer, _ := store.Get(ctx, "open"); offer.Status != models.UpgradeOffered {
+		t.Errorf("Expected the offer without a bid left alone, got %s", offer.Status)
+	}
+}
+
+func TestCapacityFromEnv(t *testing.T) {
+	t.Setenv("UPGRADE_CABIN_SEATS", "j=8, F=2")
+	capacity, err := CapacityFromEnv()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if capacity[models.CabinBusiness] != 8 || capacity[models.CabinFirst] != 2 || capacity[models.CabinPremiumEconomy] != DefaultCapacity[models.CabinPremiumEconomy] {
+		t.Errorf("Unexpected capacity %v", capacity)
+	}
+
+	for _, value := range []string{"Y=10", "J=-1", "J"} {
+		t.Setenv("UPGRADE_CABIN_SEATS", value)
+		if _, err := CapacityFromEnv(); err == nil {
+			t.Errorf("Expected error for %q", value)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+// Package version holds build information injected at link time, e.g.
+//
+//	go build -ldflags "-X flight-ticket-service/src/version.Version=v1.2.0 \
+//	  -X flight-ticket-service/src/version.Commit=5b6962d \
+//	  -X flight-ticket-service/src/version.BuildTime=2024-07-12T19:00:00Z" ./src/cmd/server
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Set via -ldflags -X
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+// Info describes the running build
+// @Description Build and version information
+type Info struct {
+	Version   string `json:"version" example:"v1.2.0" description:"Release version (git describe)"`
+	Commit    string `json:"commit" example:"5b6962d" description:"Git commit the binary was built from"`
+	BuildTime string `json:"build_time" example:"2024-07-12T19:00:00Z" description:"UTC build time"`
+	GoVersion string `json:"go_version" example:"go1.24.5" description:"Go toolchain version"`
+}
+
+// Get returns the build information. When the binary was built without ldflags,
+// the commit and time fall back to the VCS stamp embedded by go build.
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+
+	if info.Commit == "" || info.BuildTime == "" {
+		if build, ok := debug.ReadBuildInfo(); ok {
+			for _, setting := range build.Settings {
+				switch {
+				case setting.Key == "vcs.revision" && info.Commit == "":
+					info.Commit = setting.Value
+				case setting.Key == "vcs.time" && info.BuildTime == "":
+					info.BuildTime = setting.Value
+				}
+			}
+		}
+	}
+
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.BuildTime == "" {
+		info.BuildTime = "unknown"
+	}
+	return info
+}
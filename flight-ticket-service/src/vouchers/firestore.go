@@ -0,0 +1,61 @@
+package vouchers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"flight-ticket-service/src/internal/docstore"
+	"flight-ticket-service/src/models"
+
+	"cloud.google.com/go/firestore"
+)
+
+// FirestoreStore keeps vouchers in Firestore, shared by every instance.
+// Redemptions update the voucher in a transaction, so its balance cannot be
+// spent twice.
+type FirestoreStore struct {
+	client *firestore.Client
+}
+
+// NewFirestoreStore creates a voucher store in the database of the given client
+func NewFirestoreStore(client *firestore.Client) *FirestoreStore {
+	return &FirestoreStore{client: client}
+}
+
+// Create stores a new voucher, failing if the code is taken
+func (s *FirestoreStore) Create(ctx context.Context, voucher *models.Voucher) error {
+	if _, err := s.client.Collection(Collection).Doc(voucher.Code).Create(ctx, voucher); err != nil {
+		return fmt.Errorf("failed to save voucher: %v", err)
+	}
+	return nil
+}
+
+// Get returns a voucher
+func (s *FirestoreStore) Get(ctx context.Context, code string) (*models.Voucher, error) {
+	voucher, err := docstore.Get[models.Voucher](ctx, s.client.Collection(Collection).Doc(code), "voucher")
+	if errors.Is(err, docstore.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	return voucher, err
+}
+
+// ListTicket returns the vouchers issued for the ticket
+func (s *FirestoreStore) ListTicket(ctx context.Context, confirmationID string) ([]*models.Voucher, error) {
+	query := s.client.Collection(Collection).Where("confirmation_id", "==", confirmationID)
+	return docstore.List[models.Voucher](ctx, query, "voucher")
+}
+
+// Update changes a voucher in a transaction
+func (s *FirestoreStore) Update(ctx context.Context, code string, change func(*models.Voucher) error) (*models.Voucher, error) {
+	voucher, err := docstore.Update[models.Voucher](ctx, s.client, s.client.Collection(Collection).Doc(code), "voucher", change)
+	if errors.Is(err, docstore.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	return voucher, err
+}
+
+// Close leaves the client open: it belongs to the ticket repository
+func (s *FirestoreStore) Close() error {
+	return nil
+}
@@ -0,0 +1,83 @@
+package vouchers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"flight-ticket-service/src/models"
+)
+
+// MemoryStore keeps vouchers in memory, for single-instance deployments and
+// tests. Vouchers are lost when the instance stops.
+type MemoryStore struct {
+	mu       sync.Mutex
+	vouchers map[string]*models.Voucher
+}
+
+// NewMemoryStore creates an empty voucher store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{vouchers: make(map[string]*models.Voucher)}
+}
+
+// Create stores a copy of the voucher
+func (s *MemoryStore) Create(ctx context.Context, voucher *models.Voucher) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.vouchers[voucher.Code]; ok {
+		return fmt.Errorf("voucher %s already exists", voucher.Code)
+	}
+	s.vouchers[voucher.Code] = copyVoucher(voucher)
+	return nil
+}
+
+// Get returns a copy of a voucher
+func (s *MemoryStore) Get(ctx context.Context, code string) (*models.Voucher, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	voucher, ok := s.vouchers[code]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyVoucher(voucher), nil
+}
+
+// ListTicket returns copies of the vouchers issued for the ticket
+func (s *MemoryStore) ListTicket(ctx context.Context, confirmationID string) ([]*models.Voucher, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var list []*models.Voucher
+	for _, voucher := range s.vouchers {
+		if voucher.ConfirmationID == confirmationID {
+			list = append(list, copyVoucher(voucher))
+		}
+	}
+	return list, nil
+}
+
+// Update applies change to a copy of the voucher and stores it if change succeeds
+func (s *MemoryStore) Update(ctx context.Context, code string, change func(*models.Voucher) error) (*models.Voucher, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	voucher, ok := s.vouchers[code]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	updated := copyVoucher(voucher)
+	if err := change(updated); err != nil {
+		return nil, err
+	}
+	s.vouchers[code] = updated
+	return copyVoucher(updated), nil
+}
+
+// Close is a no-op
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+func copyVoucher(voucher *models.Voucher) *models.Voucher {
+	copied := *voucher
+	copied.Redemptions = append([]models.VoucherRedemption(nil), voucher.Redemptions...)
+	return &copied
+}
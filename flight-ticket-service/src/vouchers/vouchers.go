@@ -0,0 +1,197 @@
+// Package vouchers keeps the travel credit issued when the airline cancels
+// a flight.
+//
+// Cancelling a flight's tickets in bulk issues a voucher for each ticket. Its
+// amount comes from the cancellation_compensation booking rules of the
+// ticket's route, or is the ticket's fare when none applies. Vouchers are
+// redeemed against new bookings, which take the credit off their price, until
+// their balance is used up or they expire. They are stored in the vouchers
+// collection (Firestore, or memory with the other backends).
+package vouchers
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"flight-ticket-service/src/currency"
+	"flight-ticket-service/src/models"
+	"flight-ticket-service/src/rules"
+	"flight-ticket-service/src/services"
+)
+
+// Collection is the Firestore collection of vouchers, keyed by code
+const Collection = "vouchers"
+
+// Voucher errors
+var (
+	// ErrNotFound is returned for unknown voucher codes
+	ErrNotFound = errors.New("voucher not found")
+	// ErrNotRedeemable is returned when redeeming a voucher that is used up or expired
+	ErrNotRedeemable = errors.New("voucher has no balance left to redeem")
+	// ErrAlreadyRedeemed is returned when redeeming a voucher against a ticket twice
+	ErrAlreadyRedeemed = errors.New("voucher was already redeemed against the ticket")
+)
+
+// Store keeps the vouchers
+type Store interface {
+	// Create stores a new voucher
+	Create(ctx context.Context, voucher *models.Voucher) error
+	// Get returns a voucher, or ErrNotFound
+	Get(ctx context.Context, code string) (*models.Voucher, error)
+	// ListTicket returns the vouchers issued for a ticket
+	ListTicket(ctx context.Context, confirmationID string) ([]*models.Voucher, error)
+	// Update applies change to a voucher and stores it, serialized with other
+	// changes of the voucher. It returns ErrNotFound, or the error of change.
+	Update(ctx context.Context, code string, change func(*models.Voucher) error) (*models.Voucher, error)
+	// Close releases the store's connections
+	Close() error
+}
+
+// NewStore returns the store of the repository's backend: Firestore vouchers are
+// shared by every instance, the other backends keep them in memory. A
+// Firestore store uses the repository's client.
+func NewStore(repository services.TicketRepository) (Store, error) {
+	firestoreService, ok := repository.(*services.FirestoreService)
+	if !ok {
+		return NewMemoryStore(), nil
+	}
+	return NewFirestoreStore(firestoreService.Client()), nil
+}
+
+// codeAlphabet leaves out letters and digits that are easily confused
+const codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// NewCode returns a random voucher code of 12 letters and digits
+func NewCode() (string, error) {
+	random := make([]byte, 12)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("failed to generate voucher code: %v", err)
+	}
+	code := make([]byte, len(random))
+	for i, b := range random {
+		code[i] = codeAlphabet[int(b)%len(codeAlphabet)]
+	}
+	return string(code), nil
+}
+
+// SortNewest orders vouchers from the newest
+func SortNewest(vouchers []*models.Voucher) {
+	sort.Slice(vouchers, func(i, j int) bool {
+		if !vouchers[i].IssuedAt.Equal(vouchers[j].IssuedAt) {
+			return vouchers[i].IssuedAt.After(vouchers[j].IssuedAt)
+		}
+		return vouchers[i].Code < vouchers[j].Code
+	})
+}
+
+// Redeem takes the credit a voucher gives a ticket off its balance: the
+// balance, up to the ticket's fare. A voucher used up becomes REDEEMED.
+func Redeem(voucher *models.Voucher, ticket *models.FlightTicket, actor string, now time.Time) (float64, error) {
+	voucher.Expire(now)
+	if voucher.Status != models.VoucherIssued || voucher.Balance <= 0 {
+		return 0, ErrNotRedeemable
+	}
+	for _, redemption := range voucher.Redemptions {
+		if redemption.ConfirmationID == ticket.ConfirmationID {
+			return 0, ErrAlreadyRedeemed
+		}
+	}
+
+	credit := currency.Round(math.Min(voucher.Balance, ticket.Price.BaseAmount))
+	voucher.Balance = currency.Round(voucher.Balance - credit)
+	if voucher.Balance <= 0 {
+		voucher.Status = models.VoucherRedeemed
+	}
+	voucher.Redemptions = append(voucher.Redemptions, models.VoucherRedemption{
+		ConfirmationID: ticket.ConfirmationID,
+		Amount:         credit,
+		RedeemedBy:     actor,
+		RedeemedAt:     now,
+	})
+	return credit, nil
+}
+
+// Restore undoes the redemption of a voucher against a ticket, when the
+// ticket's price could not be reduced
+func Restore(voucher *models.Voucher, confirmationID string) {
+	for i, redemption := range voucher.Redemptions {
+		if redemption.ConfirmationID != confirmationID {
+			continue
+		}
+		voucher.Balance = currency.Round(voucher.Balance + redemption.Amount)
+		voucher.Status = models.VoucherIssued
+		voucher.Redemptions = append(voucher.Redemptions[:i], voucher.Redemptions[i+1:]...)
+		return
+	}
+}
+
+// Issuer issues the vouchers of cancelled tickets; it implements
+// services.VoucherIssuer
+type Issuer struct {
+	store Store
+	rules *rules.Engine
+	now   func() time.Time
+}
+
+// NewIssuer creates an issuer taking amounts from the compensation rules of the engine
+func NewIssuer(store Store, engine *rules.Engine) *Issuer {
+	return &Issuer{store: store, rules: engine, now: time.Now}
+}
+
+// IssueVoucher issues the voucher of a ticket the airline cancelled. It
+// returns nil without issuing one for pending tickets and tickets held for
+// review, which were not paid for, tickets that already have a voucher, and
+// compensations of nothing.
+func (i *Issuer) IssueVoucher(ctx context.Context, ticket *models.FlightTicket, actor string) (*models.Voucher, error) {
+	if ticket.Status == services.StatusPending || ticket.Status == models.ReviewStatus {
+		return nil, nil
+	}
+	existing, err := i.store.ListTicket(ctx, ticket.ConfirmationID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		return nil, nil
+	}
+
+	amount, rule, ok := i.rules.Compensation(ticket)
+	if !ok && ticket.Price != nil {
+		amount = ticket.Price.BaseAmount
+	}
+	amount = currency.Round(amount)
+	if amount <= 0 {
+		return nil, nil
+	}
+
+	code, err := NewCode()
+	if err != nil {
+		return nil, err
+	}
+	now := i.now().UTC()
+	voucher := &models.Voucher{
+		Code:           code,
+		ConfirmationID: ticket.ConfirmationID,
+		Tenant:         ticket.Labels[models.TenantLabel],
+		FlightNumber:   ticket.FlightNumber,
+		DepartureDate:  ticket.DepartureDate.Format("2006-01-02"),
+		Amount:         amount,
+		Balance:        amount,
+		Currency:       currency.BaseCurrency,
+		Rule:           rule,
+		Status:         models.VoucherIssued,
+		IssuedBy:       actor,
+		IssuedAt:       now,
+		ExpiresAt:      now.Add(models.VoucherValidity),
+	}
+	if err := i.store.Create(ctx, voucher); err != nil {
+		return nil, err
+	}
+	log.Printf("Issued voucher of %.2f %s for cancelled ticket %s", amount, voucher.Currency, ticket.ConfirmationID)
+	return voucher, nil
+}
@@ -0,0 +1,68 @@
+package vouchers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"flight-ticket-service/src/models"
+)
+
+func TestRedeemRestore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	issued := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	store.Create(ctx, &models.Voucher{
+		Code:           "VQ7K2M9X4TPA",
+		ConfirmationID: "ABC123",
+		Amount:         300,
+		Balance:        300,
+		Status:         models.VoucherIssued,
+		IssuedAt:       issued,
+		ExpiresAt:      issued.Add(models.VoucherValidity),
+	})
+	ticket := func(confirmationID string, fare float64) *models.FlightTicket {
+		return &models.FlightTicket{ConfirmationID: confirmationID, Price: &models.Price{BaseAmount: fare}}
+	}
+	redeem := func(target *models.FlightTicket, now time.Time) (float64, error) {
+		var credit float64
+		_, err := store.Update(ctx, "VQ7K2M9X4TPA", func(voucher *models.Voucher) (err error) {
+			credit, err = Redeem(voucher, target, "desk", now)
+			return err
+		})
+		return credit, err
+	}
+
+	now := issued.Add(24 * time.Hour)
+	if credit, err := redeem(ticket("DEF456", 250), now); err != nil || credit != 250 {
+		t.Fatalf("Expected 250 credited, got %.2f, %v", credit, err)
+	}
+	if _, err := redeem(ticket("DEF456", 250), now); !errors.Is(err, ErrAlreadyRedeemed) {
+		t.Errorf("Expected ErrAlreadyRedeemed, got %v", err)
+	}
+	if credit, err := redeem(ticket("GHI789", 250), now); err != nil || credit != 50 {
+		t.Fatalf("Expected the 50 left credited, got %.2f, %v", credit, err)
+	}
+	voucher, _ := store.Get(ctx, "VQ7K2M9X4TPA")
+	if voucher.Balance != 0 || voucher.Status != models.VoucherRedeemed || len(voucher.Redemptions) != 2 {
+		t.Errorf("Expected the voucher used up, got %+v", voucher)
+	}
+	if _, err := redeem(ticket("JKL012", 250), now); !errors.Is(err, ErrNotRedeemable) {
+		t.Errorf("Expected ErrNotRedeemable once used up, got %v", err)
+	}
+
+	voucher, _ = store.Update(ctx, "VQ7K2M9X4TPA", func(voucher *models.Voucher) error {
+		Restore(voucher, "GHI789")
+		return nil
+	})
+	if voucher.Balance != 50 || voucher.Status != models.VoucherIssued || len(voucher.Redemptions) != 1 {
+		t.Errorf("Expected the 50 restored, got %+v", voucher)
+	}
+	if _, err := redeem(ticket("JKL012", 250), issued.Add(models.VoucherValidity)); !errors.Is(err, ErrNotRedeemable) {
+		t.Errorf("Expected ErrNotRedeemable once expired, got %v", err)
+	}
+	if _, err := redeem(ticket("JKL012", 250), now); err != nil {
+		t.Errorf("Expected the expiry not stored, got %v", err)
+	}
+}
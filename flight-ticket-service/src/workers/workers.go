@@ -0,0 +1,311 @@
+// Package workers renders documents and exports (PDF and CSV manifests) on a
+// bounded pool of goroutines. Requests queue their work instead of rendering
+// it themselves, so a burst of large exports cannot use up the request
+// goroutines and the Cloud Run concurrency slots of an instance.
+//
+// Work can be waited for, or queued in the background and polled by job ID.
+// Jobs and their results are kept in memory by the instance that ran them
+// for a retention period after they finish.
+//
+// EXPORT_WORKERS sets the number of workers (default: the number of CPUs),
+// EXPORT_QUEUE_SIZE how many jobs may wait (default 32) and
+// EXPORT_JOB_RETENTION how long finished jobs are kept (default 1h).
+package workers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Job statuses
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Defaults of the pool configuration
+const (
+	DefaultQueueSize = 32
+	DefaultRetention = time.Hour
+)
+
+// ErrQueueFull is returned when no more jobs can be queued
+var ErrQueueFull = errors.New("job queue is full")
+
+// ErrNotFound is returned for unknown or expired job IDs
+var ErrNotFound = errors.New("job not found")
+
+// Result is the document produced by a job
+type Result struct {
+	ContentType string
+	FileName    string
+	Data        []byte
+}
+
+// Task produces the result of a job
+type Task func(ctx context.Context) (*Result, error)
+
+// Job is the state of a submitted task
+type Job struct {
+	ID         string
+	Kind       string
+	Status     string
+	CreatedAt  time.Time
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Error      string
+	Result     *Result // set once the job succeeded
+}
+
+// Done reports whether the job has finished
+func (j Job) Done() bool {
+	return j.Status == StatusSucceeded || j.Status == StatusFailed
+}
+
+// Config holds the size of a pool
+type Config struct {
+	Workers   int
+	QueueSize int
+	Retention time.Duration
+}
+
+// ConfigFromEnv reads EXPORT_WORKERS, EXPORT_QUEUE_SIZE and EXPORT_JOB_RETENTION
+func ConfigFromEnv() (Config, error) {
+	config := Config{Workers: runtime.NumCPU(), QueueSize: DefaultQueueSize, Retention: DefaultRetention}
+	if value := strings.TrimSpace(os.Getenv("EXPORT_WORKERS")); value != "" {
+		workers, err := strconv.Atoi(value)
+		if err != nil || workers < 1 {
+			return Config{}, fmt.Errorf("invalid EXPORT_WORKERS %q: must be a positive number", value)
+		}
+		config.Workers = workers
+	}
+	if value := strings.TrimSpace(os.Getenv("EXPORT_QUEUE_SIZE")); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size < 0 {
+			return Config{}, fmt.Errorf("invalid EXPORT_QUEUE_SIZE %q: must be a non-negative number", value)
+		}
+		config.QueueSize = size
+	}
+	if value := strings.TrimSpace(os.Getenv("EXPORT_JOB_RETENTION")); value != "" {
+		retention, err := time.ParseDuration(value)
+		if err != nil || retention <= 0 {
+			return Config{}, fmt.Errorf("invalid EXPORT_JOB_RETENTION %q: must be a positive duration", value)
+		}
+		config.Retention = retention
+	}
+	return config, nil
+}
+
+// job is a queued task with its state
+type job struct {
+	Job
+	task Task
+	done chan struct{}
+}
+
+// Pool runs tasks on a fixed number of workers
+type Pool struct {
+	config Config
+	queue  chan *job
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	now    func() time.Time
+
+	mu   sync.Mutex
+	jobs map[string]*job
+}
+
+// New starts a pool with the given configuration
+func New(config Config) *Pool {
+	if config.Workers < 1 {
+		config.Workers = 1
+	}
+	if config.Retention <= 0 {
+		config.Retention = DefaultRetention
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pool{
+		config: config,
+		queue:  make(chan *job, config.QueueSize),
+		ctx:    ctx,
+		cancel: cancel,
+		now:    time.Now,
+		jobs:   make(map[string]*job),
+	}
+	for i := 0; i < config.Workers; i++ {
+		p.wg.Add(1)
+		go p.work()
+	}
+	return p
+}
+
+// Submit queues a task and returns its job without waiting for it. Tasks run
+// with the pool's context, not the request's, so they outlive the request.
+func (p *Pool) Submit(kind string, task Task) (Job, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return Job{}, fmt.Errorf("failed to generate job ID: %v", err)
+	}
+
+	j := &job{
+		Job: Job{
+			ID:        hex.EncodeToString(id),
+			Kind:      kind,
+			Status:    StatusQueued,
+			CreatedAt: p.now(),
+		},
+		task: task,
+		done: make(chan struct{}),
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.prune()
+	select {
+	case p.queue <- j:
+	default:
+		return Job{}, ErrQueueFull
+	}
+	p.jobs[j.ID] = j
+	return j.Job, nil
+}
+
+// Run queues a task and waits for its result. The job keeps running when ctx
+// is done first.
+func (p *Pool) Run(ctx context.Context, kind string, task Task) (*Result, error) {
+	submitted, err := p.Submit(kind, task)
+	if err != nil {
+		return nil, err
+	}
+	finished, err := p.Wait(ctx, submitted.ID)
+	if err != nil {
+		return nil, err
+	}
+	if finished.Status == StatusFailed {
+		return nil, errors.New(finished.Error)
+	}
+	return finished.Result, nil
+}
+
+// Get returns the current state of a job
+func (p *Pool) Get(id string) (Job, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	j, ok := p.jobs[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+	return j.Job, nil
+}
+
+// Wait blocks until a job has finished or ctx is done
+func (p *Pool) Wait(ctx context.Context, id string) (Job, error) {
+	p.mu.Lock()
+	j, ok := p.jobs[id]
+	p.mu.Unlock()
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+
+	select {
+	case <-j.done:
+		return p.Get(id)
+	case <-ctx.Done():
+		return Job{}, ctx.Err()
+	}
+}
+
+// Close stops the workers after the running jobs; queued jobs fail
+func (p *Pool) Close() {
+	p.cancel()
+	p.wg.Wait()
+	for {
+		select {
+		case j := <-p.queue:
+			p.finish(j, nil, fmt.Errorf("worker pool stopped"))
+		default:
+			return
+		}
+	}
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case j := <-p.queue:
+			p.run(j)
+		}
+	}
+}
+
+// run executes a job, recovering from panics so that a bad document does not take the worker down
+func (p *Pool) run(j *job) {
+	p.update(j, func(job *Job) {
+		job.Status = StatusRunning
+		job.StartedAt = p.now()
+	})
+
+	var result *Result
+	var err error
+	func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				err = fmt.Errorf("job panicked: %v", recovered)
+			}
+		}()
+		result, err = j.task(p.ctx)
+	}()
+
+	if err != nil {
+		log.Printf("Job %s (%s) failed: %v", j.ID, j.Kind, err)
+	}
+	p.finish(j, result, err)
+}
+
+// finish records the outcome of a job and wakes up its waiters
+func (p *Pool) finish(j *job, result *Result, err error) {
+	p.update(j, func(job *Job) {
+		job.FinishedAt = p.now()
+		if err != nil {
+			job.Status = StatusFailed
+			job.Error = err.Error()
+			return
+		}
+		job.Status = StatusSucceeded
+		job.Result = result
+	})
+	close(j.done)
+}
+
+func (p *Pool) update(j *job, change func(*Job)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	change(&j.Job)
+}
+
+// prune forgets jobs that finished more than the retention period ago; callers hold p.mu
+func (p *Pool) prune() {
+	cutoff := p.now().Add(-p.config.Retention)
+	for id, j := range p.jobs {
+		if j.Done() && j.FinishedAt.Before(cutoff) {
+			delete(p.jobs, id)
+		}
+	}
+}
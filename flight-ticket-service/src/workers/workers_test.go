@@ -0,0 +1,116 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPoolRunsJobs(t *testing.T) {
+	pool := New(Config{Workers: 2, QueueSize: 4})
+	defer pool.Close()
+
+	result, err := pool.Run(context.Background(), "test", func(ctx context.Context) (*Result, error) {
+		return &Result{ContentType: "text/csv", Data: []byte("a,b\n")}, nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(result.Data) != "a,b\n" {
+		t.Errorf("Unexpected result %q", result.Data)
+	}
+
+	if _, err := pool.Run(context.Background(), "test", func(ctx context.Context) (*Result, error) {
+		return nil, errors.New("render failed")
+	}); err == nil || err.Error() != "render failed" {
+		t.Errorf("Expected the task error, got %v", err)
+	}
+
+	if _, err := pool.Run(context.Background(), "test", func(ctx context.Context) (*Result, error) {
+		panic("bad document")
+	}); err == nil {
+		t.Error("Expected a panicking task to fail")
+	}
+}
+
+func TestPoolJobStatus(t *testing.T) {
+	pool := New(Config{Workers: 1, QueueSize: 1})
+	defer pool.Close()
+
+	release := make(chan struct{})
+	job, err := pool.Submit("export", func(ctx context.Context) (*Result, error) {
+		<-release
+		return &Result{Data: []byte("done")}, nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if job.Status != StatusQueued || job.Kind != "export" || len(job.ID) != 16 {
+		t.Errorf("Unexpected submitted job %+v", job)
+	}
+
+	close(release)
+	finished, err := pool.Wait(context.Background(), job.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if finished.Status != StatusSucceeded || string(finished.Result.Data) != "done" || finished.StartedAt.IsZero() || finished.FinishedAt.IsZero() {
+		t.Errorf("Unexpected finished job %+v", finished)
+	}
+
+	if _, err := pool.Get("unknown"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestPoolQueueFull(t *testing.T) {
+	pool := New(Config{Workers: 1, QueueSize: 1})
+	defer pool.Close()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	block := func(ctx context.Context) (*Result, error) {
+		started <- struct{}{}
+		<-release
+		return &Result{}, nil
+	}
+
+	if _, err := pool.Submit("export", block); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	<-started // the worker is busy
+
+	if _, err := pool.Submit("export", func(ctx context.Context) (*Result, error) { return &Result{}, nil }); err != nil {
+		t.Fatalf("Expected the job to be queued, got %v", err)
+	}
+	if _, err := pool.Submit("export", block); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Expected ErrQueueFull, got %v", err)
+	}
+}
+
+func TestPoolPrunesFinishedJobs(t *testing.T) {
+	pool := New(Config{Workers: 1, QueueSize: 2, Retention: time.Hour})
+	defer pool.Close()
+
+	now := time.Now()
+	pool.now = func() time.Time { return now }
+	job, err := pool.Submit("export", func(ctx context.Context) (*Result, error) { return &Result{}, nil })
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := pool.Wait(context.Background(), job.ID); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	pool.mu.Lock()
+	pool.now = func() time.Time { return now.Add(2 * time.Hour) }
+	pool.mu.Unlock()
+	if _, err := pool.Submit("export", func(ctx context.Context) (*Result, error) { return &Result{}, nil }); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := pool.Get(job.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected the expired job to be forgotten, got %v", err)
+	}
+}
@@ -0,0 +1,205 @@
+//go:build mage
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/magefile/mage/sh"
+)
+
+// configFile holds deployment settings as KEY=VALUE lines; environment variables take precedence
+const configFile = "deploy.env"
+
+// Deployment setting defaults
+const (
+	defaultRegion      = "us-east1"
+	defaultServiceName = "flight-ticket-tools"
+)
+
+var (
+	projectIDPattern   = regexp.MustCompile(`^[a-z][a-z0-9-]{4,28}[a-z0-9]$`)
+	regionPattern      = regexp.MustCompile(`^[a-z]+-[a-z]+[0-9]+$`)
+	repositoryPattern  = regexp.MustCompile(`^[a-z][a-z0-9-]{0,62}$`)
+	serviceNamePattern = regexp.MustCompile(`^[a-z][a-z0-9-]{0,48}$`)
+)
+
+// deployConfig is the Google Cloud deployment configuration
+type deployConfig struct {
+	projectID      string // GOOGLE_CLOUD_PROJECT
+	region         string // GCP_REGION
+	repository     string // ARTIFACT_REPOSITORY
+	serviceName    string // SERVICE_NAME
+	serviceAccount string // SERVICE_ACCOUNT
+}
+
+// registryURL returns the Artifact Registry path images are pushed to
+func (c deployConfig) registryURL() string {
+	return fmt.Sprintf("%s-docker.pkg.dev/%s/%s", c.region, c.projectID, c.repository)
+}
+
+// image returns the full image name for a tag
+func (c deployConfig) image(tag string) string {
+	return fmt.Sprintf("%s/%s:%s", c.registryURL(), imageName, tag)
+}
+
+// loadConfig reads the deployment configuration from the environment and deploy.env
+func loadConfig() (deployConfig, error) {
+	file, err := readConfigFile(configFile)
+	if err != nil {
+		return deployConfig{}, err
+	}
+	get := func(key, fallback string) string {
+		if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+			return value
+		}
+		if value := file[key]; value != "" {
+			return value
+		}
+		return fallback
+	}
+
+	cfg := deployConfig{
+		projectID:   get("GOOGLE_CLOUD_PROJECT", ""),
+		region:      get("GCP_REGION", defaultRegion),
+		repository:  get("ARTIFACT_REPOSITORY", ""),
+		serviceName: get("SERVICE_NAME", defaultServiceName),
+	}
+	cfg.serviceAccount = get("SERVICE_ACCOUNT", fmt.Sprintf("%s@%s.iam.gserviceaccount.com", cfg.serviceName, cfg.projectID))
+
+	var errs []string
+	check := func(key, value, example string, pattern *regexp.Regexp) {
+		switch {
+		case value == "":
+			errs = append(errs, fmt.Sprintf("%s is not set: export it or add %s=%s to %s", key, key, example, configFile))
+		case isPlaceholder(value):
+			errs = append(errs, fmt.Sprintf("%s is still a placeholder (%q): set it to a real value, e.g. %s", key, value, example))
+		case !pattern.MatchString(value):
+			errs = append(errs, fmt.Sprintf("%s=%q is not valid, expected something like %s", key, value, example))
+		}
+	}
+
+	check("GOOGLE_CLOUD_PROJECT", cfg.projectID, "my-project-123", projectIDPattern)
+	check("GCP_REGION", cfg.region, "us-east1", regionPattern)
+	check("ARTIFACT_REPOSITORY", cfg.repository, "mcp-servers", repositoryPattern)
+	check("SERVICE_NAME", cfg.serviceName, "flight-ticket-tools", serviceNamePattern)
+	if !strings.Contains(cfg.serviceAccount, "@") || isPlaceholder(cfg.serviceAccount) {
+		errs = append(errs, fmt.Sprintf("SERVICE_ACCOUNT=%q is not a service account email", cfg.serviceAccount))
+	}
+
+	if len(errs) > 0 {
+		return cfg, fmt.Errorf("invalid deployment configuration (run mage doctor for details):\n  %s", strings.Join(errs, "\n  "))
+	}
+	return cfg, nil
+}
+
+// readConfigFile parses KEY=VALUE lines, ignoring blanks and comments; a missing file is empty
+func readConfigFile(path string) (map[string]string, error) {
+	values := make(map[string]string)
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return values, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, n)
+		}
+		values[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+	}
+	return values, scanner.Err()
+}
+
+// isPlaceholder detects template values such as [Project ID] or <SERVICE_ACCOUNT_EMAIL>
+func isPlaceholder(value string) bool {
+	return strings.ContainsAny(value, "[]<>")
+}
+
+// Doctor checks tools, gcloud authentication, configuration and project access
+func Doctor() error {
+	failed := 0
+	report := func(ok bool, name, detail string) {
+		mark := "✅"
+		if !ok {
+			mark = "❌"
+			failed++
+		}
+		fmt.Printf("%s %-28s %s\n", mark, name, detail)
+	}
+
+	_, gcloudErr := exec.LookPath("gcloud")
+	if gcloudErr != nil {
+		report(false, "gcloud installed", "install the Google Cloud CLI: https://cloud.google.com/sdk/docs/install")
+	} else {
+		report(true, "gcloud installed", "")
+		account, err := sh.Output("gcloud", "auth", "list", "--filter=status:ACTIVE", "--format=value(account)")
+		if err != nil || account == "" {
+			report(false, "gcloud authenticated", "run: gcloud auth login")
+		} else {
+			report(true, "gcloud authenticated", account)
+		}
+	}
+
+	if _, err := exec.LookPath("docker"); err != nil {
+		report(false, "docker installed", "install Docker: https://docs.docker.com/get-docker/")
+	} else if _, err := sh.Output("docker", "info", "--format", "{{.ServerVersion}}"); err != nil {
+		report(false, "docker daemon running", "start Docker and retry")
+	} else {
+		report(true, "docker daemon running", "")
+	}
+
+	if _, err := exec.LookPath("uv"); err != nil {
+		report(false, "uv installed", "run: mage setup")
+	} else {
+		report(true, "uv installed", "")
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		report(false, "configuration", err.Error())
+	} else {
+		report(true, "configuration", fmt.Sprintf("project=%s region=%s repository=%s service=%s", cfg.projectID, cfg.region, cfg.repository, cfg.serviceName))
+	}
+
+	// Project, repository and service account checks need gcloud and a usable configuration
+	if gcloudErr == nil && err == nil {
+		if _, err := sh.Output("gcloud", "projects", "describe", cfg.projectID, "--format=value(projectId)"); err != nil {
+			report(false, "project access", fmt.Sprintf("cannot access %s: check the project ID and your IAM roles", cfg.projectID))
+		} else {
+			report(true, "project access", cfg.projectID)
+		}
+		if _, err := sh.Output("gcloud", "artifacts", "repositories", "describe", cfg.repository, "--location", cfg.region, "--project", cfg.projectID); err != nil {
+			report(false, "artifact repository", fmt.Sprintf("%s not found in %s", cfg.repository, cfg.region))
+		} else {
+			report(true, "artifact repository", cfg.registryURL())
+		}
+		if _, err := sh.Output("gcloud", "iam", "service-accounts", "describe", cfg.serviceAccount, "--project", cfg.projectID); err != nil {
+			report(false, "service account", fmt.Sprintf("%s not found: set SERVICE_ACCOUNT", cfg.serviceAccount))
+		} else {
+			report(true, "service account", cfg.serviceAccount)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d check(s) failed", failed)
+	}
+	fmt.Println("All checks passed")
+	return nil
+}
@@ -12,15 +12,8 @@ import (
 
 const (
 	// Docker configuration
-	registryURL = ""
-	imageName   = "flight-ticket-tools"
-	dockerFile  = "Dockerfile"
-
-	// Google Cloud configuration
-	projectID      = "a3rlabs-sandbox"
-	region         = "us-east1"
-	serviceName    = "flight-ticket-tools"
-	serviceAccount = "<SERVICE_ACCOUNT_EMAIL>"
+	imageName  = "flight-ticket-tools"
+	dockerFile = "Dockerfile"
 )
 
 // Docker namespace contains Docker-related build targets
@@ -28,14 +21,19 @@ type Docker mg.Namespace
 
 // Build builds the Docker image
 func (Docker) Build() error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
 	fmt.Println("Building Docker image...")
 
 	tag := getImageTag()
-	fullImageName := fmt.Sprintf("%s/%s:%s", registryURL, imageName, tag)
+	fullImageName := cfg.image(tag)
 
 	return sh.Run("docker", "build",
 		"-t", fullImageName,
-		"-t", fmt.Sprintf("%s/%s:latest", registryURL, imageName),
+		"-t", cfg.image("latest"),
 		"-f", dockerFile,
 		".")
 }
@@ -44,16 +42,21 @@ func (Docker) Build() error {
 func (Docker) Push() error {
 	mg.Deps(Docker.Build)
 
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
 	fmt.Println("Configuring Docker for Artifact Registry...")
-	if err := sh.Run("gcloud", "auth", "configure-docker", fmt.Sprintf("%s-docker.pkg.dev", region)); err != nil {
+	if err := sh.Run("gcloud", "auth", "configure-docker", fmt.Sprintf("%s-docker.pkg.dev", cfg.region)); err != nil {
 		return fmt.Errorf("failed to configure docker auth: %w", err)
 	}
 
 	fmt.Println("Pushing Docker image to Artifact Registry...")
 
 	tag := getImageTag()
-	fullImageName := fmt.Sprintf("%s/%s:%s", registryURL, imageName, tag)
-	latestImageName := fmt.Sprintf("%s/%s:latest", registryURL, imageName)
+	fullImageName := cfg.image(tag)
+	latestImageName := cfg.image("latest")
 
 	// Push both tagged and latest versions
 	if err := sh.Run("docker", "push", fullImageName); err != nil {
@@ -72,10 +75,14 @@ func (Docker) Push() error {
 func (Docker) Run() error {
 	mg.Deps(Docker.Build)
 
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
 	fmt.Println("Running Docker image locally...")
 
-	tag := getImageTag()
-	fullImageName := fmt.Sprintf("%s/%s:%s", registryURL, imageName, tag)
+	fullImageName := cfg.image(getImageTag())
 
 	return sh.Run("docker", "run",
 		"-p", "8080:8080",
@@ -92,17 +99,21 @@ type CloudRun mg.Namespace
 func (CloudRun) Deploy() error {
 	mg.Deps(Docker.Push)
 
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
 	fmt.Println("Deploying to Cloud Run...")
 
-	tag := getImageTag()
-	fullImageName := fmt.Sprintf("%s/%s:%s", registryURL, imageName, tag)
+	fullImageName := cfg.image(getImageTag())
 
-	return sh.Run("gcloud", "run", "deploy", serviceName,
+	return sh.Run("gcloud", "run", "deploy", cfg.serviceName,
 		"--image", fullImageName,
 		"--platform", "managed",
-		"--region", region,
-		"--project", projectID,
-		"--service-account", serviceAccount,
+		"--region", cfg.region,
+		"--project", cfg.projectID,
+		"--service-account", cfg.serviceAccount,
 		"--allow-unauthenticated",
 		"--port", "8080",
 		"--memory", "512Mi",
@@ -117,39 +128,58 @@ func (CloudRun) Deploy() error {
 func (CloudRun) Update() error {
 	mg.Deps(Docker.Push)
 
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
 	fmt.Println("Updating Cloud Run service...")
 
-	tag := getImageTag()
-	fullImageName := fmt.Sprintf("%s/%s:%s", registryURL, imageName, tag)
+	fullImageName := cfg.image(getImageTag())
 
-	return sh.Run("gcloud", "run", "services", "update", serviceName,
+	return sh.Run("gcloud", "run", "services", "update", cfg.serviceName,
 		"--image", fullImageName,
-		"--region", region,
-		"--project", projectID)
+		"--region", cfg.region,
+		"--project", cfg.projectID)
 }
 
 // Logs shows Cloud Run service logs
 func (CloudRun) Logs() error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
 	fmt.Println("Fetching Cloud Run logs...")
 	return sh.Run("gcloud", "logs", "tail",
-		fmt.Sprintf("projects/%s/logs/run.googleapis.com%%2Fstdout", projectID),
-		"--filter", fmt.Sprintf(`resource.labels.service_name="%s"`, serviceName))
+		fmt.Sprintf("projects/%s/logs/run.googleapis.com%%2Fstdout", cfg.projectID),
+		"--filter", fmt.Sprintf(`resource.labels.service_name="%s"`, cfg.serviceName))
 }
 
 // Status shows Cloud Run service status
 func (CloudRun) Status() error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
 	fmt.Println("Getting Cloud Run service status...")
-	return sh.Run("gcloud", "run", "services", "describe", serviceName,
-		"--region", region,
-		"--project", projectID)
+	return sh.Run("gcloud", "run", "services", "describe", cfg.serviceName,
+		"--region", cfg.region,
+		"--project", cfg.projectID)
 }
 
 // Delete deletes the Cloud Run service
 func (CloudRun) Delete() error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
 	fmt.Println("Deleting Cloud Run service...")
-	return sh.Run("gcloud", "run", "services", "delete", serviceName,
-		"--region", region,
-		"--project", projectID,
+	return sh.Run("gcloud", "run", "services", "delete", cfg.serviceName,
+		"--region", cfg.region,
+		"--project", cfg.projectID,
 		"--quiet")
 }
 
@@ -172,6 +202,37 @@ func (Dev) Http() error {
 	return sh.RunWith(env, "uv", "run", "python", "main.py")
 }
 
+// composeFile is the local development stack shared with flight-ticket-service
+const composeFile = "../docker-compose.yml"
+
+// Up starts the emulators, ticket service and MCP server with seeded data
+func (Dev) Up() error {
+	fmt.Println("Starting local stack (Firestore and Pub/Sub emulators, ticket service, MCP server)...")
+	if err := sh.Run("docker", "compose", "-f", composeFile, "up", "--build", "--detach", "--wait",
+		"firestore", "pubsub", "ticket-service", "mcp-server"); err != nil {
+		return fmt.Errorf("failed to start local stack: %w", err)
+	}
+
+	// One-shot containers: create topics and book the demo tickets
+	if err := sh.Run("docker", "compose", "-f", composeFile, "run", "--rm", "pubsub-init"); err != nil {
+		return fmt.Errorf("failed to create Pub/Sub topics: %w", err)
+	}
+	if err := sh.Run("docker", "compose", "-f", composeFile, "run", "--rm", "seed"); err != nil {
+		return fmt.Errorf("failed to seed tickets: %w", err)
+	}
+
+	fmt.Println("Ticket service: http://localhost:8080 (Swagger UI at /swagger/)")
+	fmt.Println("MCP server:     http://localhost:8090/mcp")
+	fmt.Println("Stop with: mage dev:down")
+	return nil
+}
+
+// Down stops the local stack and discards emulator data
+func (Dev) Down() error {
+	fmt.Println("Stopping local stack...")
+	return sh.Run("docker", "compose", "-f", composeFile, "down", "--remove-orphans")
+}
+
 // Test runs local tests (placeholder for future tests)
 func (Dev) Test() error {
 	fmt.Println("Running tests...")
@@ -185,12 +246,10 @@ func Clean() error {
 	fmt.Println("Cleaning up local Docker images...")
 
 	// Remove local images (ignore errors if images don't exist)
-	tag := getImageTag()
-	fullImageName := fmt.Sprintf("%s/%s:%s", registryURL, imageName, tag)
-	latestImageName := fmt.Sprintf("%s/%s:latest", registryURL, imageName)
-
-	sh.Run("docker", "rmi", fullImageName)
-	sh.Run("docker", "rmi", latestImageName)
+	if cfg, err := loadConfig(); err == nil {
+		sh.Run("docker", "rmi", cfg.image(getImageTag()))
+		sh.Run("docker", "rmi", cfg.image("latest"))
+	}
 
 	// Clean up dangling images
 	return sh.Run("docker", "image", "prune", "-f")
@@ -214,10 +273,10 @@ func Setup() error {
 		return fmt.Errorf("failed to sync dependencies: %w", err)
 	}
 
-	// Configure gcloud if needed
-	fmt.Println("Checking gcloud configuration...")
-	if err := sh.Run("gcloud", "config", "get-value", "project"); err != nil {
-		fmt.Printf("Please run 'gcloud auth login' and 'gcloud config set project %s'\n", projectID)
+	// Check gcloud, Docker and the deployment configuration
+	fmt.Println("Checking deployment prerequisites...")
+	if err := Doctor(); err != nil {
+		fmt.Println("Fix the failed checks above before deploying (see README: Deployment configuration)")
 	}
 
 	return nil
@@ -1,14 +1,25 @@
 import asyncio
+import base64
+import functools
+import hashlib
+import hmac
+import inspect
+import math
 import os
 import sys
 import signal
 import httpx
 import json
+import time
 import uuid
-from typing import Optional, Dict, Any
-from datetime import datetime
+from collections import deque
+from contextvars import ContextVar
+from typing import Optional, Dict, Any, List
+from datetime import datetime, timezone
 
-from mcp.server.fastmcp import FastMCP
+from mcp.server.fastmcp import FastMCP, Context
+from mcp.types import ClientCapabilities, ElicitationCapability, SamplingCapability, SamplingMessage, TextContent, ToolAnnotations
+from pydantic import Field, create_model
 from starlette.applications import Starlette
 from starlette.responses import JSONResponse, Response
 from starlette.routing import Route
@@ -21,15 +32,481 @@ PORT = int(os.getenv("PORT", "8080"))
 ENVIRONMENT = os.getenv("ENVIRONMENT", "local")  # "local" or "cloudrun"
 
 # Base URL for the Flight Ticket Service API
-BASE_URL = "https://flight-ticket-service-858333166396.us-east1.run.app"
+# Override with FLIGHT_TICKET_SERVICE_URL to point at a local service (e.g. http://localhost:6000)
+BASE_URL = os.getenv("FLIGHT_TICKET_SERVICE_URL", "https://flight-ticket-service-858333166396.us-east1.run.app").rstrip("/")
+
+def parse_service_environments(value: str) -> Dict[str, str]:
+    """Parse FLIGHT_TICKET_SERVICE_ENVIRONMENTS, comma-separated NAME=URL pairs, in order."""
+    environments = {}
+    for entry in value.split(","):
+        if not entry.strip():
+            continue
+        name, sep, url = entry.partition("=")
+        if not sep or not name.strip() or not url.strip():
+            raise ValueError(f"FLIGHT_TICKET_SERVICE_ENVIRONMENTS entry {entry.strip()!r} is not NAME=URL")
+        environments[name.strip()] = url.strip().rstrip("/")
+    return environments
+
+# Ticket service deployments a session can switch between with select_environment,
+# e.g. "dev=http://localhost:8080,staging=https://...,prod=https://...". Without
+# it the only environment is "default", at BASE_URL.
+SERVICE_ENVIRONMENTS = parse_service_environments(os.getenv("FLIGHT_TICKET_SERVICE_ENVIRONMENTS", "")) or {"default": BASE_URL}
+
+# Environment new sessions start in; the first one listed unless set
+DEFAULT_SERVICE_ENVIRONMENT = os.getenv("FLIGHT_TICKET_SERVICE_DEFAULT_ENVIRONMENT") or next(iter(SERVICE_ENVIRONMENTS))
+if DEFAULT_SERVICE_ENVIRONMENT not in SERVICE_ENVIRONMENTS:
+    raise ValueError(f"FLIGHT_TICKET_SERVICE_DEFAULT_ENVIRONMENT {DEFAULT_SERVICE_ENVIRONMENT!r} is not one of {', '.join(SERVICE_ENVIRONMENTS)}")
+
+# Leave out tools that change or cancel existing tickets, e.g. for a read-mostly demo
+DISABLE_DESTRUCTIVE_TOOLS = os.getenv("MCP_DISABLE_DESTRUCTIVE_TOOLS", "false").lower() in ("1", "true", "yes")
+
+# Limits per caller that stop a looping agent from flooding the ticket service; 0 disables a limit
+MAX_TOOL_CALLS_PER_MINUTE = int(os.getenv("MCP_MAX_TOOL_CALLS_PER_MINUTE", "60"))
+MAX_BOOKINGS_PER_SESSION = int(os.getenv("MCP_MAX_BOOKINGS_PER_SESSION", "20"))
+MAX_PASSENGERS_PER_BOOKING = int(os.getenv("MCP_MAX_PASSENGERS_PER_BOOKING", "9"))
+
+# How long HTTP sessions, and the limit counters of callers, are kept once idle
+SESSION_TTL_SECONDS = int(os.getenv("MCP_SESSION_TTL_SECONDS", "3600"))
+
+# Tool calls kept in memory for the /audit endpoint, and the bearer token it requires; without one the endpoint is not served
+AUDIT_LOG_SIZE = int(os.getenv("MCP_AUDIT_LOG_SIZE", "1000"))
+AUDIT_TOKEN = os.getenv("MCP_AUDIT_TOKEN", "")
 
 # Initialize MCP server
 mcp = FastMCP("FlightTicketTools")
 
-# Session storage for streamable HTTP
-sessions = {}
+# Tool hints for MCP hosts, e.g. to ask for confirmation before destructive calls
+READ_ONLY = ToolAnnotations(readOnlyHint=True, destructiveHint=False, idempotentHint=True)
+CREATES = ToolAnnotations(readOnlyHint=False, destructiveHint=False, idempotentHint=False)
+DESTRUCTIVE = ToolAnnotations(readOnlyHint=False, destructiveHint=True, idempotentHint=True)
+
+# Tools that change data, whose calls are kept in the session's undo history
+mutating_tools = set()
+
+def tool(annotations: ToolAnnotations):
+    """Register a tool with its hints, unless destructive tools are disabled and it is one."""
+    def register(fn):
+        if annotations.destructiveHint and DISABLE_DESTRUCTIVE_TOOLS:
+            return fn
+        if not annotations.readOnlyHint:
+            mutating_tools.add(fn.__name__)
+        mcp.tool(annotations=annotations)(guarded(fn))
+        return fn
+    return register
+
+def guarded(fn):
+    """Wrap a tool so MCP calls to it are checked against the caller's limits and audited."""
+    if inspect.iscoroutinefunction(fn):
+        @functools.wraps(fn)
+        async def call_async(**arguments):
+            result = check_limits(fn.__name__, arguments) or await fn(**arguments)
+            record_call(fn.__name__, arguments, result)
+            return result
+        return call_async
+    
+    @functools.wraps(fn)
+    def call(**arguments):
+        result = check_limits(fn.__name__, arguments) or fn(**arguments)
+        record_call(fn.__name__, arguments, result)
+        return result
+    return call
+
+# API key sent to the ticket service by stdio sessions; HTTP sessions forward their client's x-api-key header
+SERVICE_API_KEY = os.getenv("FLIGHT_TICKET_SERVICE_API_KEY", "")
+
+# Session storage for streamable HTTP, by session ID
+sessions: Dict[str, Dict[str, Any]] = {}
+
+# HTTP sessions dropped when idle, by session ID, with their summary kept for
+# their client for SESSION_TTL_SECONDS
+ended_sessions: Dict[str, Dict[str, Any]] = {}
+
+# Limit counters by caller identity, shared by all sessions of a caller so that
+# starting a new session does not start a new budget
+caller_limits: Dict[str, Dict[str, Any]] = {}
+
+# State of the session being served: the stdio session, or the HTTP session of the current request
+stdio_session = {"id": "stdio", "identity": "stdio", "created_at": datetime.now(timezone.utc), "limits": {}}
+current_session: ContextVar[Dict[str, Any]] = ContextVar("current_session", default=stdio_session)
+
+def session_environment() -> str:
+    """Return the environment selected by the current session."""
+    return current_session.get().get("environment", DEFAULT_SERVICE_ENVIRONMENT)
+
+def service_url() -> str:
+    """Return the base URL of the ticket service for the current session."""
+    return SERVICE_ENVIRONMENTS[session_environment()]
+
+# Authenticate to ticket services that require IAM authentication (deployed without
+# --allow-unauthenticated) with Google-signed ID tokens of the runtime service account
+SERVICE_ID_TOKENS = os.getenv("FLIGHT_TICKET_SERVICE_ID_TOKENS", "false").lower() in ("1", "true", "yes")
+
+METADATA_IDENTITY_URL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/identity"
+
+class IDTokenAuth(httpx.Auth):
+    """Send an ID token for the ticket service's URL, fetched from the metadata server and cached until shortly before it expires."""
+
+    def __init__(self):
+        self.tokens: Dict[str, tuple] = {}  # audience -> (token, expiry)
+
+    def token(self, audience: str) -> str:
+        cached = self.tokens.get(audience)
+        if cached and cached[1] > time.time() + 60:
+            return cached[0]
+        response = httpx.get(METADATA_IDENTITY_URL, params={"audience": audience, "format": "full"}, headers={"Metadata-Flavor": "Google"}, timeout=5)
+        response.raise_for_status()
+        token = response.text.strip()
+        # ID tokens live an hour; the expiry is read from the token rather than trusted blindly
+        expiry = time.time() + 3600
+        try:
+            payload = token.split(".")[1]
+            expiry = json.loads(base64.urlsafe_b64decode(payload + "=" * (-len(payload) % 4)))["exp"]
+        except (IndexError, KeyError, ValueError):
+            pass
+        self.tokens[audience] = (token, expiry)
+        return token
+
+    def auth_flow(self, request: httpx.Request):
+        try:
+            token = self.token(f"{request.url.scheme}://{request.url.host}")
+        except httpx.HTTPError as e:
+            raise httpx.RequestError(f"Failed to get an ID token from the metadata server: {e}", request=request)
+        request.headers["Authorization"] = f"Bearer {token}"
+        yield request
+
+id_token_auth = IDTokenAuth() if SERVICE_ID_TOKENS else None
+
+def service_client() -> httpx.Client:
+    """Return a client for the ticket service, sending ID tokens when FLIGHT_TICKET_SERVICE_ID_TOKENS is set."""
+    return httpx.Client(auth=id_token_auth)
+
+def api_key_headers() -> Dict[str, str]:
+    """Return the header carrying the session's ticket service API key, if it has one."""
+    key = current_session.get().get("api_key") or SERVICE_API_KEY
+    return {"X-API-Key": key} if key else {}
+
+def session_locks() -> Dict[str, str]:
+    """Return the edit lock tokens this session holds, by environment and confirmation ID."""
+    return current_session.get().setdefault("locks", {})
+
+def lock_key(confirmation_id: str, environment: Optional[str] = None) -> str:
+    return f"{environment or session_environment()}/{confirmation_id}"
+
+def lock_headers(confirmation_id: str, environment: Optional[str] = None) -> Dict[str, str]:
+    """Return the header carrying this session's lock token for a ticket, if it holds one."""
+    token = session_locks().get(lock_key(confirmation_id, environment))
+    return {"X-Lock-Token": token} if token else {}
+
+def change_headers(confirmation_id: str, environment: Optional[str] = None) -> Dict[str, str]:
+    """Return the headers of a change to a ticket: the session's API key and its lock token for the ticket,
+    in the selected environment unless another is given."""
+    return {**api_key_headers(), **lock_headers(confirmation_id, environment)}
+
+def caller_identity(request: Request, session_id: str) -> str:
+    """Identify the caller of an HTTP request, whose limits it counts against: by its API key, else the
+    principal Identity-Aware Proxy authenticated, else by the session it started."""
+    if api_key := request.headers.get("x-api-key"):
+        # Hashed, as the identity is written to the audit trail
+        return "key:" + hashlib.sha256(api_key.encode()).hexdigest()[:16]
+    if principal := request.headers.get("x-goog-authenticated-user-email"):
+        return "principal:" + principal.removeprefix("accounts.google.com:")
+    # Not by address: every caller behind the same proxy or NAT would share one budget
+    return "session:" + session_id
+
+def evict_idle_sessions():
+    """
+    Drop the HTTP sessions and caller limit counters idle for longer than SESSION_TTL_SECONDS,
+    keeping the summaries of dropped sessions for as long again.
+    """
+    now = time.monotonic()
+    for session_id in [session_id for session_id, session in sessions.items() if now - session["last_used"] > SESSION_TTL_SECONDS]:
+        session = sessions.pop(session_id)
+        # The summary reads the session's bookings from the service, so it is built off the event loop
+        summary = asyncio.get_running_loop().run_in_executor(None, log_session_summary, session)
+        ended_sessions[session_id] = {"identity": session["identity"], "ended": now, "summary": summary}
+    for session_id in [session_id for session_id, ended in ended_sessions.items() if now - ended["ended"] > SESSION_TTL_SECONDS]:
+        del ended_sessions[session_id]
+    for identity in [identity for identity, limits in caller_limits.items() if now - limits["last_used"] > SESSION_TTL_SECONDS]:
+        del caller_limits[identity]
+
+def refusal(limit: str, message: str, **details) -> Dict[str, Any]:
+    """Build the result of a call refused by a session limit, for agents to act on."""
+    return {"error": message, "refused": {"limit": limit, **details}}
+
+def check_limits(tool_name: str, arguments: Dict[str, Any]) -> Optional[Dict[str, Any]]:
+    """Check a tool call against the limits of the current session's caller, returning a refusal if one is reached."""
+    limits = current_session.get()["limits"]
+    
+    now = time.monotonic()
+    calls = limits.setdefault("tool_calls", deque())
+    while calls and now - calls[0] >= 60:
+        calls.popleft()
+    if MAX_TOOL_CALLS_PER_MINUTE and len(calls) >= MAX_TOOL_CALLS_PER_MINUTE:
+        return refusal(
+            "tool_calls_per_minute",
+            f"Caller made {MAX_TOOL_CALLS_PER_MINUTE} tool calls in the last minute; wait before calling again",
+            max=MAX_TOOL_CALLS_PER_MINUTE,
+            retry_after_seconds=math.ceil(60 - (now - calls[0])),
+        )
+    calls.append(now)
+    
+    if tool_name in ("create_flight_ticket", "update_flight_ticket") and (refused := check_passengers(arguments.get("passengers"))):
+        return refused
+    
+    if tool_name == "create_flight_ticket" and not arguments.get("dry_run") and MAX_BOOKINGS_PER_SESSION and limits.get("bookings", 0) >= MAX_BOOKINGS_PER_SESSION:
+        return refusal(
+            "bookings_per_session",
+            f"Caller already created {MAX_BOOKINGS_PER_SESSION} tickets, the most it may create before it has been idle for {SESSION_TTL_SECONDS} seconds",
+            max=MAX_BOOKINGS_PER_SESSION,
+        )
+    return None
+
+def check_passengers(passengers: Optional[int]) -> Optional[Dict[str, Any]]:
+    """Return a refusal if a booking has more passengers than allowed."""
+    if MAX_PASSENGERS_PER_BOOKING and passengers is not None and passengers > MAX_PASSENGERS_PER_BOOKING:
+        return refusal(
+            "passengers_per_booking",
+            f"A booking may have at most {MAX_PASSENGERS_PER_BOOKING} passengers",
+            max=MAX_PASSENGERS_PER_BOOKING,
+            requested=passengers,
+        )
+    return None
+
+# Resource summarizing the bookings changed in a session
+SUMMARY_URI = "session://summary"
+SUMMARY_DESCRIPTION = "Receipt of the bookings created, modified and cancelled in this session, with their current state"
+
+# Recent tool calls, newest last
+audit_log: deque = deque(maxlen=AUDIT_LOG_SIZE)
+
+def record_call(tool_name: str, arguments: Dict[str, Any], result: Dict[str, Any]):
+    """Count what a tool call used of the caller's limits, keep the change it made for undo and the
+    session summary, and add it to the audit trail."""
+    session = current_session.get()
+    if tool_name == "create_flight_ticket" and "error" not in result and not arguments.get("dry_run"):
+        session["limits"]["bookings"] = session["limits"].get("bookings", 0) + 1
+    record_action(tool_name, arguments, result)
+    
+    if "refused" in result:
+        status = "refused"
+    elif "error" in result:
+        status = "error"
+    else:
+        status = "ok"
+    # Arguments are hashed, not stored, so the trail shows repeated calls without keeping passenger data
+    stored = {name: value for name, value in arguments.items() if not isinstance(value, Context)}
+    entry = {
+        "timestamp": datetime.now(timezone.utc).isoformat(),
+        "session_id": session.get("id"),
+        "caller": session.get("identity"),
+        "environment": session_environment(),
+        "tool": tool_name,
+        "arguments_sha256": hashlib.sha256(json.dumps(stored, sort_keys=True, default=str).encode()).hexdigest(),
+        "status": status,
+    }
+    audit_log.append(entry)
+    # Cloud Run sends structured stderr lines to Cloud Logging; stdout carries the stdio transport
+    print(json.dumps({"severity": "INFO", "message": f"MCP tool call {tool_name}: {status}", "mcp_audit": entry}), file=sys.stderr)
+
+# Tools whose calls change the session or its edit locks rather than bookings, and are not kept for undo
+SESSION_TOOLS = {"select_environment", "lock_flight_ticket", "unlock_flight_ticket", "undo_last_action"}
+
+def record_action(tool_name: str, arguments: Dict[str, Any], result: Dict[str, Any]):
+    """Keep a successful change of a tool call in the session's undo history and its bookings."""
+    if tool_name not in mutating_tools or tool_name in SESSION_TOOLS or "error" in result:
+        return
+    if tool_name == "create_flight_ticket" and arguments.get("dry_run"):
+        return
+    action = {"tool": tool_name, "environment": session_environment(), "confirmation_id": arguments.get("confirmation_id"), "undoable": False}
+    if tool_name in COMPENSATIONS:
+        record, _ = COMPENSATIONS[tool_name]
+        action["undoable"] = record(action, result)
+    current_session.get().setdefault("actions", []).append(action)
+    track_booking(action, tool_name, booked=tool_name == "create_flight_ticket", cancelled=tool_name == "cancel_flight_ticket")
 
-@mcp.tool()
+def track_booking(action: Dict[str, Any], change: str, booked: bool, cancelled: bool):
+    """Add a change of a ticket to the session's bookings, kept for its summary."""
+    if not action["confirmation_id"]:
+        return
+    bookings = current_session.get().setdefault("bookings", {})
+    booking = bookings.setdefault(
+        f"{action['environment']}/{action['confirmation_id']}",
+        {"confirmation_id": action["confirmation_id"], "environment": action["environment"], "booked": False, "changes": []},
+    )
+    booking["booked"] = booking["booked"] or booked
+    booking["cancelled"] = cancelled
+    booking["changes"].append(change)
+
+def record_confirmation_id(action: Dict[str, Any], result: Dict[str, Any]) -> bool:
+    """Keep the confirmation ID of a booked ticket, to cancel it on undo."""
+    action["confirmation_id"] = result.get("confirmation_id")
+    return bool(action["confirmation_id"])
+
+# Whether the service of each environment keeps ticket history, probed once per environment
+history_support: Dict[str, bool] = {}
+
+def history_available(environment: str, confirmation_id: str) -> bool:
+    """
+    Report whether the service of an environment keeps ticket history, which undoing an update or
+    cancellation needs. The memory backend records its own writes. Firestore only
+    has a history when the change feed's history sink (CHANGEFEED_HISTORY=true) is deployed, which
+    writes it a few seconds after each change; the other backends answer 501. The answer is probed
+    with the ticket's history diff once per environment and kept; a service that cannot be reached
+    is probed again next time.
+    """
+    if environment not in history_support:
+        try:
+            with service_client() as client:
+                response = client.get(f"{SERVICE_ENVIRONMENTS[environment]}/ticket/{confirmation_id}/history/diff", headers=api_key_headers())
+        except httpx.RequestError:
+            return False
+        history_support[environment] = response.status_code != 501
+    return history_support[environment]
+
+def record_update(action: Dict[str, Any], result: Dict[str, Any]) -> bool:
+    """Keep when a change of a ticket was made: the updated_at the service gave the ticket for it, by
+    which the undo finds its revision in the ticket's history. Cancellations do not return the ticket;
+    the undo finds the latest cancellation instead. Changes in an environment without ticket history
+    cannot be undone."""
+    if not history_available(action["environment"], action["confirmation_id"]):
+        return False
+    action["updated_at"] = result.get("updated_at")
+    return True
+
+def cancel_booking(action: Dict[str, Any]) -> str:
+    """Undo a booking by cancelling the ticket."""
+    with service_client() as client:
+        response = client.delete(
+            f"{SERVICE_ENVIRONMENTS[action['environment']]}/ticket/{action['confirmation_id']}",
+            headers=change_headers(action["confirmation_id"], action["environment"]),
+        )
+        response.raise_for_status()
+    return f"Cancelled ticket {action['confirmation_id']} booked by {action['tool']}"
+
+def restore_ticket(action: Dict[str, Any]) -> str:
+    """Undo an update or cancellation with POST /ticket/{id}/undo, which puts back the whole ticket as it
+    was before the change's revision. The revision is looked up in the ticket's history from the latest
+    back. The service refuses to undo it when the ticket changed after it, other than by changes undone
+    since, or when its history has not recorded the latest change yet, as with Firestore right after a
+    change; the undo then fails and can be retried."""
+    url = f"{SERVICE_ENVIRONMENTS[action['environment']]}/ticket/{action['confirmation_id']}"
+    # Storage may keep timestamps to the microsecond only, which is all a datetime holds
+    updated_at = datetime.fromisoformat(action["updated_at"]) if action.get("updated_at") else None
+    params = {}
+    latest = None
+    with service_client() as client:
+        while True:
+            response = client.get(f"{url}/history/diff", params=params, headers=api_key_headers())
+            response.raise_for_status()
+            revision = response.json()
+            number = revision["to"]["number"]
+            latest = latest or number
+            changes = {change["field"]: change["to"] for change in revision["changes"]}
+            made = datetime.fromisoformat(changes["updated_at"]) if changes.get("updated_at") else None
+            found = made == updated_at if updated_at else changes.get("status") == "CANCELLED"
+            if found:
+                break
+            if number <= 1 or (updated_at and made and made < updated_at):
+                raise LookupError(f"the history of ticket {action['confirmation_id']} has no revision of {action['tool']} yet")
+            params = {"to": number - 1}
+        response = client.post(
+            f"{url}/undo",
+            params={"target": number, "revision": latest},
+            headers=change_headers(action["confirmation_id"], action["environment"]),
+        )
+        response.raise_for_status()
+    return f"Restored ticket {action['confirmation_id']} as it was before {action['tool']}"
+
+# Undoable tools: how a change is recorded, returning whether it can be undone, and how it is undone.
+# Changes of other tools are kept in the undo history but cannot be undone.
+COMPENSATIONS = {
+    "create_flight_ticket": (record_confirmation_id, cancel_booking),
+    "update_flight_ticket": (record_update, restore_ticket),
+    "cancel_flight_ticket": (record_update, restore_ticket),
+}
+
+def session_summary(session: Dict[str, Any]) -> Dict[str, Any]:
+    """
+    Build the receipt of a session: the bookings its tool calls created, modified and cancelled,
+    sorted by their current state, read from the service. A booking whose state cannot be read is
+    sorted by the session's last change of it.
+    """
+    summary = {"session_started": session["created_at"].isoformat(), "created": [], "modified": [], "cancelled": []}
+    key = session.get("api_key") or SERVICE_API_KEY
+    with service_client() as client:
+        for booking in list(session.get("bookings", {}).values()):
+            entry = {"confirmation_id": booking["confirmation_id"], "environment": booking["environment"], "changes": list(booking["changes"])}
+            cancelled = booking["cancelled"]
+            try:
+                response = client.get(
+                    f"{SERVICE_ENVIRONMENTS[booking['environment']]}/ticket/{booking['confirmation_id']}",
+                    headers={"X-API-Key": key} if key else {},
+                )
+                response.raise_for_status()
+                entry["ticket"] = response.json()
+                if status := entry["ticket"].get("status"):
+                    cancelled = status == "CANCELLED"
+            except httpx.RequestError as e:
+                entry["error"] = f"Failed to get ticket: {str(e)}"
+            except httpx.HTTPStatusError as e:
+                entry["error"] = f"HTTP {e.response.status_code}: {e.response.text}"
+            if cancelled:
+                summary["cancelled"].append(entry)
+            elif booking["booked"]:
+                summary["created"].append(entry)
+            else:
+                summary["modified"].append(entry)
+    summary["generated_at"] = datetime.now(timezone.utc).isoformat()
+    return summary
+
+def log_session_summary(session: Dict[str, Any]) -> Dict[str, Any]:
+    """Log the summary of a session that ended as a structured line, and return it."""
+    summary = session_summary(session)
+    print(json.dumps({"severity": "INFO", "message": f"MCP session {session['id']} ended", "mcp_session_summary": summary}), file=sys.stderr)
+    return summary
+
+@mcp.resource(SUMMARY_URI, name="session_summary", description=SUMMARY_DESCRIPTION, mime_type="application/json")
+def session_summary_resource() -> str:
+    """Serve the summary of the current session."""
+    return json.dumps(session_summary(current_session.get()), indent=2)
+
+@tool(DESTRUCTIVE)
+def undo_last_action() -> Dict[str, Any]:
+    """
+    Undo the last change made in this session: cancel a booked ticket, or restore an
+    updated or cancelled ticket as it was before, from its history. Call it repeatedly
+    to undo earlier changes. A change that cannot be undone is dropped from the undo
+    history, so earlier ones can be undone next.
+    
+    Returns:
+        Dict containing the undone tool, the confirmation ID and the number of changes
+        left to undo, or error details.
+    """
+    actions = current_session.get().setdefault("actions", [])
+    if not actions:
+        return {"error": "Nothing to undo in this session"}
+    last = actions[-1]
+    if not last["undoable"]:
+        actions.pop()
+        return {"error": f"{last['tool']} cannot be undone; it was dropped from the undo history"}
+    
+    _, undo = COMPENSATIONS[last["tool"]]
+    try:
+        message = undo(last)
+    except (httpx.RequestError, LookupError, ValueError) as e:
+        # Kept, so the undo can be retried
+        return {"error": f"Failed to undo {last['tool']}: {str(e)}"}
+    except httpx.HTTPStatusError as e:
+        try:
+            error_data = e.response.json()
+            return {"error": error_data}
+        except:
+            return {"error": f"HTTP {e.response.status_code}: {e.response.text}"}
+    actions.pop()
+    track_booking(last, "undo_last_action", booked=False, cancelled=last["tool"] == "create_flight_ticket")
+    return {"message": message, "undone": last["tool"], "confirmation_id": last["confirmation_id"], "remaining": len(actions)}
+
+@tool(READ_ONLY)
 def health_check() -> Dict[str, Any]:
     """
     Check the health status of the Flight Ticket Service.
@@ -38,8 +515,8 @@ def health_check() -> Dict[str, Any]:
         Dict containing service health information including status, service name, version, and timestamp.
     """
     try:
-        with httpx.Client() as client:
-            response = client.get(f"{BASE_URL}/health")
+        with service_client() as client:
+            response = client.get(f"{service_url()}/health")
             response.raise_for_status()
             return response.json()
     except httpx.RequestError as e:
@@ -51,17 +528,52 @@ def health_check() -> Dict[str, Any]:
         except:
             return {"error": f"HTTP {e.response.status_code}: {e.response.text}"}
 
-@mcp.tool()
-def create_flight_ticket(
-    origin: str,
-    destination: str,
-    departure_date: str,
-    departure_time: str,
-    passengers: int,
-    flight_number: Optional[str] = None
+# Booking fields the client is asked for when create_flight_ticket is called without them
+BOOKING_FIELDS = {
+    "origin": (str, Field(description="Origin airport code (e.g., JFK)")),
+    "destination": (str, Field(description="Destination airport code (e.g., LAX)")),
+    "departure_date": (str, Field(description="Departure date in YYYY-MM-DD format (e.g., 2024-12-25)")),
+    "departure_time": (str, Field(description="Departure time in HH:MM format (e.g., 14:30)")),
+    "passengers": (int, Field(description="Number of passengers", ge=1)),
+}
+
+async def elicit_booking_fields(ctx: Optional[Context], missing: List[str]) -> Dict[str, Any]:
+    """
+    Ask the client for missing booking fields through MCP elicitation. Returns the
+    values given, or an error naming the missing fields when the client cannot be
+    asked or the user declines.
+    """
+    error = {"error": f"Missing required booking fields: {', '.join(missing)}", "missing_fields": missing}
+    if ctx is None or not ctx.session.check_client_capability(ClientCapabilities(elicitation=ElicitationCapability())):
+        return error
+    
+    details = create_model("BookingDetails", **{name: BOOKING_FIELDS[name] for name in missing})
+    result = await ctx.elicit(message=f"To book the flight, please provide: {', '.join(missing)}", schema=details)
+    if result.action != "accept":
+        return {**error, "elicitation": result.action}
+    return result.data.model_dump()
+
+@tool(CREATES)
+async def create_flight_ticket(
+    origin: Optional[str] = None,
+    destination: Optional[str] = None,
+    departure_date: Optional[str] = None,
+    departure_time: Optional[str] = None,
+    passengers: Optional[int] = None,
+    flight_number: Optional[str] = None,
+    base_fare: Optional[float] = None,
+    currency: Optional[str] = None,
+    traveler_ids: Optional[List[str]] = None,
+    passenger_types: Optional[Dict[str, int]] = None,
+    special_requests: Optional[List[Dict[str, Any]]] = None,
+    seat_hold_id: Optional[str] = None,
+    dry_run: bool = False,
+    ctx: Context = None
 ) -> Dict[str, Any]:
     """
-    Create a new flight ticket with the provided details.
+    Create a new flight ticket with the provided details. Origin, destination,
+    departure date and time and passengers are required; when any is left out,
+    clients that support elicitation are asked for them.
     
     Args:
         origin: Origin airport code (e.g., "JFK")
@@ -70,10 +582,29 @@ def create_flight_ticket(
         departure_time: Departure time in HH:MM format (e.g., "14:30")
         passengers: Number of passengers (minimum 1)
         flight_number: Flight number (e.g., "AA1234") - optional
+        base_fare: Fare per passenger in USD (e.g., 199.00) - optional
+        currency: ISO 4217 currency to price the ticket in (e.g., "EUR") - optional
+        traveler_ids: IDs of saved travelers flying on the ticket, from list_my_travelers - optional;
+            passengers defaults to their number
+        passenger_types: Passengers by type code, e.g. {"ADT": 2, "CHD": 1, "INF": 1} - optional;
+            children pay 75% of the fare, infants 10% and sit on an adult's lap (one per adult);
+            passengers defaults to their total
+        special_requests: SSR codes of passengers, e.g. [{"passenger": 1, "code": "WCHR"}] - optional;
+            passengers are numbered from 1 (adults, then children, then infants); codes are WCHR, WCHS,
+            WCHC, BLND, DEAF, VGML, KSML, MOML, DBML, CHML and UMNR (children only)
+        seat_hold_id: ID of a hold from hold_seats on the same flight and date whose seats are
+            assigned to the passengers, in order - optional
+        dry_run: Validate and price the ticket without booking it, to preview it (default: False)
     
     Returns:
-        Dict containing the created flight ticket information or error details.
+        Dict containing the created flight ticket information or error details. A booking
+        that looks risky is created with status REVIEW and is not confirmed until an admin
+        reviews it; tell the user so.
     """
+    if passenger_types and passengers is None:
+        passengers = sum(passenger_types.values())
+    if traveler_ids and passengers is None:
+        passengers = len(traveler_ids)
     ticket_data = {
         "origin": origin,
         "destination": destination,
@@ -82,12 +613,35 @@ def create_flight_ticket(
         "passengers": passengers
     }
     
+    missing = [name for name in BOOKING_FIELDS if ticket_data[name] is None]
+    if missing:
+        elicited = await elicit_booking_fields(ctx, missing)
+        if "error" in elicited:
+            return elicited
+        ticket_data.update(elicited)
+    # Passengers elicited, or counted from travelers or passenger types, are held to the same limit as given ones
+    if refused := check_passengers(ticket_data["passengers"]):
+        return refused
+    
     if flight_number:
         ticket_data["flight_number"] = flight_number
+    if base_fare is not None:
+        ticket_data["base_fare"] = base_fare
+    if currency:
+        ticket_data["currency"] = currency
+    if traveler_ids:
+        ticket_data["traveler_ids"] = traveler_ids
+    if passenger_types:
+        ticket_data["passenger_types"] = passenger_types
+    if special_requests:
+        ticket_data["special_requests"] = special_requests
+    if seat_hold_id:
+        ticket_data["seat_hold_id"] = seat_hold_id
     
     try:
-        with httpx.Client() as client:
-            response = client.post(f"{BASE_URL}/ticket", json=ticket_data)
+        with service_client() as client:
+            params = {"dry_run": "true"} if dry_run else None
+            response = client.post(f"{service_url()}/ticket", json=ticket_data, params=params, headers=api_key_headers())
             response.raise_for_status()
             return response.json()
     except httpx.RequestError as e:
@@ -99,20 +653,25 @@ def create_flight_ticket(
         except:
             return {"error": f"HTTP {e.response.status_code}: {e.response.text}"}
 
-@mcp.tool()
-def get_flight_ticket(confirmation_id: str) -> Dict[str, Any]:
+@tool(READ_ONLY)
+def get_flight_ticket(confirmation_id: str, currency: Optional[str] = None) -> Dict[str, Any]:
     """
     Retrieve a flight ticket using its confirmation ID.
     
     Args:
         confirmation_id: Ticket confirmation ID (e.g., "ABC123")
+        currency: ISO 4217 currency to display the price in (e.g., "EUR") - optional
     
     Returns:
         Dict containing the flight ticket information or error details.
     """
+    params = {}
+    if currency:
+        params["currency"] = currency
+    
     try:
-        with httpx.Client() as client:
-            response = client.get(f"{BASE_URL}/ticket/{confirmation_id}")
+        with service_client() as client:
+            response = client.get(f"{service_url()}/ticket/{confirmation_id}", params=params, headers=api_key_headers())
             response.raise_for_status()
             return response.json()
     except httpx.RequestError as e:
@@ -124,7 +683,7 @@ def get_flight_ticket(confirmation_id: str) -> Dict[str, Any]:
         except:
             return {"error": f"HTTP {e.response.status_code}: {e.response.text}"}
 
-@mcp.tool()
+@tool(DESTRUCTIVE)
 def update_flight_ticket(
     confirmation_id: str,
     origin: Optional[str] = None,
@@ -136,7 +695,8 @@ def update_flight_ticket(
     status: Optional[str] = None
 ) -> Dict[str, Any]:
     """
-    Update an existing flight ticket with new information.
+    Update an existing flight ticket with new information. When this session
+    holds the ticket's edit lock its token is sent along.
     
     Args:
         confirmation_id: Ticket confirmation ID (e.g., "ABC123")
@@ -169,8 +729,8 @@ def update_flight_ticket(
         update_data["status"] = status
     
     try:
-        with httpx.Client() as client:
-            response = client.put(f"{BASE_URL}/ticket/{confirmation_id}", json=update_data)
+        with service_client() as client:
+            response = client.put(f"{service_url()}/ticket/{confirmation_id}", json=update_data, headers=change_headers(confirmation_id))
             response.raise_for_status()
             return response.json()
     except httpx.RequestError as e:
@@ -182,10 +742,11 @@ def update_flight_ticket(
         except:
             return {"error": f"HTTP {e.response.status_code}: {e.response.text}"}
 
-@mcp.tool()
+@tool(DESTRUCTIVE)
 def cancel_flight_ticket(confirmation_id: str) -> Dict[str, Any]:
     """
     Cancel (soft delete) a flight ticket by setting its status to CANCELLED.
+    When this session holds the ticket's edit lock its token is sent along.
     
     Args:
         confirmation_id: Ticket confirmation ID (e.g., "ABC123")
@@ -194,8 +755,8 @@ def cancel_flight_ticket(confirmation_id: str) -> Dict[str, Any]:
         Dict containing success message and confirmation ID or error details.
     """
     try:
-        with httpx.Client() as client:
-            response = client.delete(f"{BASE_URL}/ticket/{confirmation_id}")
+        with service_client() as client:
+            response = client.delete(f"{service_url()}/ticket/{confirmation_id}", headers=change_headers(confirmation_id))
             response.raise_for_status()
             return response.json()
     except httpx.RequestError as e:
@@ -207,13 +768,88 @@ def cancel_flight_ticket(confirmation_id: str) -> Dict[str, Any]:
         except:
             return {"error": f"HTTP {e.response.status_code}: {e.response.text}"}
 
-@mcp.tool()
-def list_flight_tickets(limit: Optional[int] = 50) -> Dict[str, Any]:
+@tool(ToolAnnotations(readOnlyHint=False, destructiveHint=False, idempotentHint=True, openWorldHint=False))
+def lock_flight_ticket(confirmation_id: str, ttl_seconds: Optional[int] = None) -> Dict[str, Any]:
+    """
+    Take a short-lived edit lock on a flight ticket, so that other agents cannot
+    update or cancel it until the lock is released or expires. The session keeps
+    the lock's token and sends it with its own updates and cancellations. Calling
+    the tool again renews the lock.
+    
+    Args:
+        confirmation_id: Ticket confirmation ID (e.g., "ABC123")
+        ttl_seconds: How long the lock holds, in seconds (default: 120, at most 900) - optional
+    
+    Returns:
+        Dict containing the ticket's lock (holder and expiry) or error details.
+    """
+    lock_data = {}
+    if ttl_seconds is not None:
+        lock_data["ttl_seconds"] = ttl_seconds
+    
+    locks = session_locks()
+    key = lock_key(confirmation_id)
+    try:
+        with service_client() as client:
+            url = f"{service_url()}/ticket/{confirmation_id}/lock"
+            response = client.post(url, json=lock_data, headers=change_headers(confirmation_id))
+            if response.status_code == 409:
+                # The held lock lapsed; take a new one
+                locks.pop(key, None)
+                response = client.post(url, json=lock_data, headers=api_key_headers())
+            response.raise_for_status()
+            lock = response.json()
+            locks[key] = lock.pop("token")
+            return lock
+    except httpx.RequestError as e:
+        return {"error": f"Failed to lock ticket: {str(e)}"}
+    except httpx.HTTPStatusError as e:
+        try:
+            error_data = e.response.json()
+            return {"error": error_data}
+        except:
+            return {"error": f"HTTP {e.response.status_code}: {e.response.text}"}
+
+@tool(ToolAnnotations(readOnlyHint=False, destructiveHint=False, idempotentHint=True, openWorldHint=False))
+def unlock_flight_ticket(confirmation_id: str) -> Dict[str, Any]:
+    """
+    Release the edit lock this session holds on a flight ticket.
+    
+    Args:
+        confirmation_id: Ticket confirmation ID (e.g., "ABC123")
+    
+    Returns:
+        Dict containing success message and confirmation ID or error details.
+    """
+    headers = lock_headers(confirmation_id)
+    if not headers:
+        return {"error": f"This session does not hold a lock on ticket {confirmation_id}"}
+    
+    try:
+        with service_client() as client:
+            response = client.delete(f"{service_url()}/ticket/{confirmation_id}/lock", headers=change_headers(confirmation_id))
+            if response.status_code in (200, 409):
+                # A lapsed lock is gone as well
+                session_locks().pop(lock_key(confirmation_id), None)
+            response.raise_for_status()
+            return response.json()
+    except httpx.RequestError as e:
+        return {"error": f"Failed to unlock ticket: {str(e)}"}
+    except httpx.HTTPStatusError as e:
+        try:
+            error_data = e.response.json()
+            return {"error": error_data}
+        except:
+            return {"error": f"HTTP {e.response.status_code}: {e.response.text}"}
+
+@tool(READ_ONLY)
+def list_flight_tickets(limit: Optional[int] = 50, currency: Optional[str] = None) -> Dict[str, Any]:
     """
     Retrieve a list of all flight tickets with optional pagination.
     
     Args:
         limit: Maximum number of tickets to return (default: 50)
+        currency: ISO 4217 currency to display prices in (e.g., "EUR") - optional
     
     Returns:
         Dict containing list of tickets with count or error details.
@@ -221,10 +857,12 @@ def list_flight_tickets(limit: Optional[int] = 50) -> Dict[str, Any]:
     params = {}
     if limit is not None:
         params["limit"] = limit
+    if currency:
+        params["currency"] = currency
     
     try:
-        with httpx.Client() as client:
-            response = client.get(f"{BASE_URL}/tickets", params=params)
+        with service_client() as client:
+            response = client.get(f"{service_url()}/tickets", params=params, headers=api_key_headers())
             response.raise_for_status()
             return response.json()
     except httpx.RequestError as e:
@@ -236,15 +874,456 @@ def list_flight_tickets(limit: Optional[int] = 50) -> Dict[str, Any]:
         except:
             return {"error": f"HTTP {e.response.status_code}: {e.response.text}"}
 
+@tool(READ_ONLY)
+def get_flight_advisories(confirmation_id: str) -> Dict[str, Any]:
+    """
+    Get weather advisories for a flight ticket's origin and destination airports.
+    
+    Args:
+        confirmation_id: Ticket confirmation ID (e.g., "ABC123")
+    
+    Returns:
+        Dict containing current and forecast weather for both airports, any advisories,
+        and whether disruption is likely, or error details.
+    """
+    try:
+        with service_client() as client:
+            response = client.get(f"{service_url()}/ticket/{confirmation_id}/advisories", headers=api_key_headers())
+            response.raise_for_status()
+            return response.json()
+    except httpx.RequestError as e:
+        return {"error": f"Failed to get advisories: {str(e)}"}
+    except httpx.HTTPStatusError as e:
+        try:
+            error_data = e.response.json()
+            return {"error": error_data}
+        except:
+            return {"error": f"HTTP {e.response.status_code}: {e.response.text}"}
+
+@tool(READ_ONLY)
+def search_flights(origin: str, destination: str, departure_date: str) -> Dict[str, Any]:
+    """
+    Search for direct and one-stop flight options between two airports.
+    
+    Args:
+        origin: Origin airport code (e.g., "JFK")
+        destination: Destination airport code (e.g., "LAX")
+        departure_date: Departure date of the first flight in YYYY-MM-DD format (e.g., "2024-07-15")
+    
+    Returns:
+        Dict containing the options, direct first, each with its flights, estimated arrival
+        times, connecting airport and connection time, or error details.
+    """
+    params = {"origin": origin, "destination": destination, "date": departure_date}
+    
+    try:
+        with service_client() as client:
+            response = client.get(f"{service_url()}/routes/search", params=params, headers=api_key_headers())
+            response.raise_for_status()
+            return response.json()
+    except httpx.RequestError as e:
+        return {"error": f"Failed to search flights: {str(e)}"}
+    except httpx.HTTPStatusError as e:
+        try:
+            error_data = e.response.json()
+            return {"error": error_data}
+        except:
+            return {"error": f"HTTP {e.response.status_code}: {e.response.text}"}
+
+@tool(READ_ONLY)
+def get_fare_calendar(origin: str, destination: str, month: str, currency: Optional[str] = None) -> Dict[str, Any]:
+    """
+    Get the lowest per-passenger fare of each day of a month, to find the cheapest day to fly.
+    
+    Args:
+        origin: Origin airport code (e.g., "JFK")
+        destination: Destination airport code (e.g., "LAX")
+        month: Month in YYYY-MM format, from the current one up to 12 months ahead (e.g., "2025-08")
+        currency: ISO 4217 currency code for the fares (e.g., "EUR") - optional, defaults to USD
+    
+    Returns:
+        Dict containing the fare and departure time of each day from today on and the
+        cheapest day, or error details.
+    """
+    params = {"origin": origin, "destination": destination, "month": month}
+    if currency:
+        params["currency"] = currency
+    
+    try:
+        with service_client() as client:
+            response = client.get(f"{service_url()}/fares/calendar", params=params, headers=api_key_headers())
+            response.raise_for_status()
+            return response.json()
+    except httpx.RequestError as e:
+        return {"error": f"Failed to get fare calendar: {str(e)}"}
+    except httpx.HTTPStatusError as e:
+        try:
+            error_data = e.response.json()
+            return {"error": error_data}
+        except:
+            return {"error": f"HTTP {e.response.status_code}: {e.response.text}"}
+
+@tool(READ_ONLY)
+def list_my_travelers() -> Dict[str, Any]:
+    """
+    List the travelers saved for the caller's API key, to book them by ID instead of asking
+    for their names, dates of birth and documents again.
+    
+    Returns:
+        Dict containing the travelers, each with its id, names, date of birth, documents and
+        loyalty number, or error details.
+    """
+    headers = api_key_headers()
+    if not headers:
+        return {"error": "Saved travelers need an API key: send x-api-key, or set FLIGHT_TICKET_SERVICE_API_KEY"}
+    
+    try:
+        with service_client() as client:
+            response = client.get(f"{service_url()}/travelers", headers=headers)
+            response.raise_for_status()
+            return response.json()
+    except httpx.RequestError as e:
+        return {"error": f"Failed to list travelers: {str(e)}"}
+    except httpx.HTTPStatusError as e:
+        try:
+            error_data = e.response.json()
+            return {"error": error_data}
+        except:
+            return {"error": f"HTTP {e.response.status_code}: {e.response.text}"}
+
+@tool(CREATES)
+def hold_seats(flight_number: str, departure_date: str, seats: List[str], ttl_seconds: Optional[int] = None) -> Dict[str, Any]:
+    """
+    Hold seats on a departure for a few minutes while booking, so no other shopper can pick them.
+    Book with the returned id as seat_hold_id to assign the seats to the passengers.
+    
+    Args:
+        flight_number: Flight number (e.g., "AA1234")
+        departure_date: Departure date in YYYY-MM-DD format (e.g., "2024-12-25")
+        seats: Seats to hold, a row from 1 to 99 and a letter from A to K (e.g., ["12A", "12B"]), up to 9
+        ttl_seconds: How long the hold lasts, in seconds - optional, defaults to 600, at most 1800
+    
+    Returns:
+        Dict containing the hold's id, seats and expires_at, or error details; seats already
+        held or assigned are refused with a conflict.
+    """
+    hold_data: Dict[str, Any] = {"seats": seats}
+    if ttl_seconds is not None:
+        hold_data["ttl_seconds"] = ttl_seconds
+    
+    try:
+        with service_client() as client:
+            response = client.post(f"{service_url()}/flights/{flight_number}/{departure_date}/seats/hold", json=hold_data, headers=api_key_headers())
+            response.raise_for_status()
+            return response.json()
+    except httpx.RequestError as e:
+        return {"error": f"Failed to hold seats: {str(e)}"}
+    except httpx.HTTPStatusError as e:
+        try:
+            error_data = e.response.json()
+            return {"error": error_data}
+        except:
+            return {"error": f"HTTP {e.response.status_code}: {e.response.text}"}
+
+@tool(READ_ONLY)
+def list_upgrade_offers(confirmation_id: str) -> Dict[str, Any]:
+    """
+    List the premium cabin upgrade offers made to a flight ticket, newest first.
+    
+    Args:
+        confirmation_id: Ticket confirmation ID (e.g., "ABC123")
+    
+    Returns:
+        Dict containing the offers (id, cabin, type FIXED or BID, price or min_bid/max_bid per
+        passenger, status and expires_at) and their count, or error details.
+    """
+    try:
+        with service_client() as client:
+            response = client.get(f"{service_url()}/ticket/{confirmation_id}/upgrades", headers=api_key_headers())
+            response.raise_for_status()
+            return response.json()
+    except httpx.RequestError as e:
+        return {"error": f"Failed to list upgrade offers: {str(e)}"}
+    except httpx.HTTPStatusError as e:
+        try:
+            error_data = e.response.json()
+            return {"error": error_data}
+        except:
+            return {"error": f"HTTP {e.response.status_code}: {e.response.text}"}
+
+@tool(CREATES)
+def respond_to_upgrade_offer(confirmation_id: str, offer_id: str, accept: bool, bid: Optional[float] = None) -> Dict[str, Any]:
+    """
+    Accept or decline an upgrade offer of a flight ticket. Accepting a FIXED offer moves the
+    ticket to the cabin at once and adds the price for each passenger; BID offers need a bid,
+    which waits until bids are awarded.
+    
+    Args:
+        confirmation_id: Ticket confirmation ID (e.g., "ABC123")
+        offer_id: Offer ID from list_upgrade_offers
+        accept: True to accept or bid, False to decline (or withdraw a bid)
+        bid: Bid per passenger, between the offer's min_bid and max_bid - BID offers only
+    
+    Returns:
+        Dict containing the offer with its new status, or error details; offers no longer
+        open, or FIXED offers once the cabin is full, are refused with a conflict.
+    """
+    action = "accept" if accept else "decline"
+    body: Dict[str, Any] = {}
+    if accept and bid is not None:
+        body["bid"] = bid
+    
+    try:
+        with service_client() as client:
+            response = client.post(f"{service_url()}/ticket/{confirmation_id}/upgrades/{offer_id}/{action}", json=body, headers=api_key_headers())
+            response.raise_for_status()
+            return response.json()
+    except httpx.RequestError as e:
+        return {"error": f"Failed to {action} upgrade offer: {str(e)}"}
+    except httpx.HTTPStatusError as e:
+        try:
+            error_data = e.response.json()
+            return {"error": error_data}
+        except:
+            return {"error": f"HTTP {e.response.status_code}: {e.response.text}"}
+
+@tool(READ_ONLY)
+def get_rebooking_options(confirmation_id: str) -> Dict[str, Any]:
+    """
+    List the flights a DISRUPTED flight ticket can be moved to: direct flights on the same
+    route departing later, within 3 days, with seats left for its passengers. Rebooking
+    keeps the ticket's price.
+    
+    Args:
+        confirmation_id: Ticket confirmation ID (e.g., "ABC123")
+    
+    Returns:
+        Dict containing the options (id, flight_number, departure_date, departure_time,
+        arrival_time and delay_minutes after the disrupted departure) and their count, or
+        error details; tickets that are not disrupted are refused with a conflict.
+    """
+    try:
+        with service_client() as client:
+            response = client.get(f"{service_url()}/ticket/{confirmation_id}/rebooking-options", headers=api_key_headers())
+            response.raise_for_status()
+            return response.json()
+    except httpx.RequestError as e:
+        return {"error": f"Failed to get rebooking options: {str(e)}"}
+    except httpx.HTTPStatusError as e:
+        try:
+            error_data = e.response.json()
+            return {"error": error_data}
+        except:
+            return {"error": f"HTTP {e.response.status_code}: {e.response.text}"}
+
+@tool(CREATES)
+def accept_rebooking_option(confirmation_id: str, option_id: str) -> Dict[str, Any]:
+    """
+    Rebook a DISRUPTED flight ticket on one of its rebooking options, on behalf of the
+    passenger. The seats move to the new flight and the ticket is confirmed on it in one
+    step. Confirm the chosen flight with the passenger first.
+    
+    Args:
+        confirmation_id: Ticket confirmation ID (e.g., "ABC123")
+        option_id: Option ID from get_rebooking_options
+    
+    Returns:
+        Dict containing the rebooked ticket, or error details; options that are gone are
+        not found, and flights that filled up meanwhile are refused with a conflict.
+    """
+    try:
+        with service_client() as client:
+            response = client.post(f"{service_url()}/ticket/{confirmation_id}/rebooking-options/{option_id}/accept", headers=change_headers(confirmation_id))
+            response.raise_for_status()
+            return response.json()
+    except httpx.RequestError as e:
+        return {"error": f"Failed to accept rebooking option: {str(e)}"}
+    except httpx.HTTPStatusError as e:
+        try:
+            error_data = e.response.json()
+            return {"error": error_data}
+        except:
+            return {"error": f"HTTP {e.response.status_code}: {e.response.text}"}
+
+@tool(READ_ONLY)
+def list_ticket_vouchers(confirmation_id: str) -> Dict[str, Any]:
+    """
+    List the vouchers issued for a flight ticket the airline cancelled. Each voucher is
+    travel credit, worth the fare or what the compensation rules of the route give, that
+    can be redeemed against new bookings for a year.
+    
+    Args:
+        confirmation_id: Confirmation ID of the cancelled ticket (e.g., "ABC123")
+    
+    Returns:
+        Dict containing the vouchers (code, amount, balance, currency, status, expires_at and
+        redemptions), newest first, and their count, or error details.
+    """
+    try:
+        with service_client() as client:
+            response = client.get(f"{service_url()}/ticket/{confirmation_id}/vouchers", headers=api_key_headers())
+            response.raise_for_status()
+            return response.json()
+    except httpx.RequestError as e:
+        return {"error": f"Failed to list vouchers: {str(e)}"}
+    except httpx.HTTPStatusError as e:
+        try:
+            error_data = e.response.json()
+            return {"error": error_data}
+        except:
+            return {"error": f"HTTP {e.response.status_code}: {e.response.text}"}
+
+@tool(CREATES)
+def redeem_voucher(code: str, confirmation_id: str) -> Dict[str, Any]:
+    """
+    Redeem a voucher against a booking: its balance, up to the ticket's fare, is taken off
+    the ticket's price. What is left stays on the voucher. Confirm with the passenger
+    which booking the credit goes to first.
+    
+    Args:
+        code: Voucher code (e.g., "VQ7K2M9X4TPA")
+        confirmation_id: Confirmation ID of the booking to credit (e.g., "DEF456")
+    
+    Returns:
+        Dict containing the credit, the voucher with its new balance and the ticket with its
+        reduced price, or error details; vouchers used up, expired or already redeemed
+        against the ticket, and cancelled tickets, are refused with a conflict.
+    """
+    try:
+        with service_client() as client:
+            response = client.post(
+                f"{service_url()}/vouchers/{code}/redeem",
+                json={"confirmation_id": confirmation_id},
+                headers=change_headers(confirmation_id),
+            )
+            response.raise_for_status()
+            return response.json()
+    except httpx.RequestError as e:
+        return {"error": f"Failed to redeem voucher: {str(e)}"}
+    except httpx.HTTPStatusError as e:
+        try:
+            error_data = e.response.json()
+            return {"error": error_data}
+        except:
+            return {"error": f"HTTP {e.response.status_code}: {e.response.text}"}
+
+@tool(READ_ONLY)
+def get_flight_ticket_pnr(confirmation_id: str, passenger_names: Optional[List[str]] = None) -> Dict[str, Any]:
+    """
+    Export a flight ticket as a GDS-style (Amadeus/Sabre-like) plain-text PNR block.
+    
+    Args:
+        confirmation_id: Ticket confirmation ID (e.g., "ABC123")
+        passenger_names: Passenger names as SURNAME/GIVEN (e.g., ["DOE/JOHN", "DOE/JANE"]) - optional
+    
+    Returns:
+        Dict containing the PNR text block or error details.
+    """
+    params = {"format": "pnr"}
+    if passenger_names:
+        params["names"] = ",".join(passenger_names)
+    
+    try:
+        with service_client() as client:
+            response = client.get(f"{service_url()}/ticket/{confirmation_id}", params=params, headers=api_key_headers())
+            response.raise_for_status()
+            return {"confirmation_id": confirmation_id, "pnr": response.text}
+    except httpx.RequestError as e:
+        return {"error": f"Failed to export PNR: {str(e)}"}
+    except httpx.HTTPStatusError as e:
+        try:
+            error_data = e.response.json()
+            return {"error": error_data}
+        except:
+            return {"error": f"HTTP {e.response.status_code}: {e.response.text}"}
+
+@tool(ToolAnnotations(readOnlyHint=False, destructiveHint=False, idempotentHint=True, openWorldHint=False))
+def select_environment(environment: Optional[str] = None) -> Dict[str, Any]:
+    """
+    Show or change the ticket service deployment (e.g. dev, staging, prod) that this
+    session's tools operate on. The choice only applies to the current session.
+    
+    Args:
+        environment: Name of the environment to switch to - optional; omit to only show the current one
+    
+    Returns:
+        Dict containing the selected environment, its URL and the available environments, or error details.
+    """
+    if environment is not None:
+        if environment not in SERVICE_ENVIRONMENTS:
+            return {"error": f"Unknown environment {environment!r}; available: {', '.join(SERVICE_ENVIRONMENTS)}"}
+        current_session.get()["environment"] = environment
+    
+    return {
+        "environment": session_environment(),
+        "url": service_url(),
+        "available": SERVICE_ENVIRONMENTS,
+    }
+
+def upcoming_trips(tickets: List[Dict[str, Any]]) -> List[Dict[str, Any]]:
+    """Return the tickets that have not departed and are not cancelled, soonest first."""
+    now = datetime.now(timezone.utc)
+    upcoming = []
+    for ticket in tickets:
+        if ticket.get("status") == "CANCELLED":
+            continue
+        try:
+            departure = datetime.fromisoformat(ticket["departure_time"])
+        except (KeyError, TypeError, ValueError):
+            continue
+        if departure >= now:
+            upcoming.append((departure, ticket))
+    upcoming.sort(key=lambda trip: trip[0])
+    return [ticket for _, ticket in upcoming]
+
+@tool(READ_ONLY)
+async def summarize_upcoming_trips(ctx: Context, limit: Optional[int] = 50) -> Dict[str, Any]:
+    """
+    Summarize upcoming trips in plain language. The tickets are read from the
+    Flight Ticket Service and the summary is written by the client's model
+    through MCP sampling, so the client must support sampling.
+    
+    Args:
+        limit: Maximum number of tickets to read (default: 50)
+    
+    Returns:
+        Dict containing the summary, the number of upcoming trips and the model that wrote it, or error details.
+    """
+    if not ctx.session.check_client_capability(ClientCapabilities(sampling=SamplingCapability())):
+        return {"error": "The MCP client does not support sampling, which this tool needs to write the summary"}
+    
+    listing = list_flight_tickets(limit=limit)
+    if "error" in listing:
+        return listing
+    trips = upcoming_trips(listing.get("tickets") or [])
+    if not trips:
+        return {"summary": "There are no upcoming trips.", "trip_count": 0}
+    
+    trip_lines = "\n".join(
+        f"- {trip['confirmation_id']}: {trip['origin']} to {trip['destination']} "
+        f"on flight {trip.get('flight_number') or 'unassigned'}, departing {trip['departure_time']}, "
+        f"{trip['passengers']} passenger(s), status {trip['status']}"
+        for trip in trips
+    )
+    result = await ctx.session.create_message(
+        messages=[
+            SamplingMessage(
+                role="user",
+                content=TextContent(type="text", text=f"Summarize these upcoming flights for the traveller:\n{trip_lines}"),
+            )
+        ],
+        system_prompt="You summarize flight bookings in a few friendly sentences: what is coming up next, "
+                      "any busy stretches, and anything that needs attention such as pending bookings. "
+                      "Mention confirmation IDs and do not invent details.",
+        max_tokens=400,
+    )
+    if not isinstance(result.content, TextContent):
+        return {"error": f"The client returned {result.content.type} content instead of a text summary"}
+    return {"summary": result.content.text, "trip_count": len(trips), "model": result.model}
+
 async def handle_streamable_http(request: Request):
     """Handle streamable HTTP requests with proper session management."""
     try:
-        # Get or create session ID
-        session_id = request.headers.get("x-session-id")
-        if not session_id:
-            session_id = str(uuid.uuid4())
-            sessions[session_id] = {"created_at": datetime.now()}
-        
         # Parse the request body
         body = await request.body()
         if not body:
@@ -273,6 +1352,61 @@ async def handle_streamable_http(request: Request):
         method = message.get("method")
         msg_id = message.get("id")
         
+        # Sessions are started by initialize and only continued by the caller that started them;
+        # their limits are the caller's, whichever session it uses
+        evict_idle_sessions()
+        session_id = request.headers.get("x-session-id")
+        if session_id:
+            session = sessions.get(session_id)
+            identity = caller_identity(request, session_id)
+            ended = ended_sessions.get(session_id)
+            if session is None and ended is not None and ended["identity"] == identity:
+                # The client learns what its session did before starting another
+                return Response(
+                    content=json.dumps({
+                        "jsonrpc": "2.0",
+                        "id": msg_id,
+                        "error": {
+                            "code": -32600,
+                            "message": "Session expired; send initialize to start a new one",
+                            "data": {"summary": await ended["summary"]}
+                        }
+                    }),
+                    media_type="application/json",
+                    status_code=404
+                )
+            if session is None or session["identity"] != identity:
+                return Response(
+                    content=json.dumps({
+                        "jsonrpc": "2.0",
+                        "id": msg_id,
+                        "error": {"code": -32600, "message": "Unknown or expired session; send initialize to start a new one"}
+                    }),
+                    media_type="application/json",
+                    status_code=404
+                )
+        elif method == "initialize":
+            session_id = str(uuid.uuid4())
+            identity = caller_identity(request, session_id)
+            session = sessions[session_id] = {"id": session_id, "identity": identity, "created_at": datetime.now(timezone.utc)}
+        else:
+            return Response(
+                content=json.dumps({
+                    "jsonrpc": "2.0",
+                    "id": msg_id,
+                    "error": {"code": -32600, "message": "Missing x-session-id header; send initialize first"}
+                }),
+                media_type="application/json",
+                status_code=400
+            )
+        now = time.monotonic()
+        session["last_used"] = now
+        session["limits"] = caller_limits.setdefault(identity, {})
+        session["limits"]["last_used"] = now
+        current_session.set(session)
+        if api_key := request.headers.get("x-api-key"):
+            session["api_key"] = api_key
+        
         if method == "initialize":
             response = {
                 "jsonrpc": "2.0",
@@ -301,7 +1435,8 @@ async def handle_streamable_http(request: Request):
                         {
                             "name": tool.name,
                             "description": tool.description,
-                            "inputSchema": tool.inputSchema
+                            "inputSchema": tool.inputSchema,
+                            "annotations": tool.annotations.model_dump(exclude_none=True) if tool.annotations else None
                         } for tool in tools
                     ]
                 }
@@ -310,22 +1445,65 @@ async def handle_streamable_http(request: Request):
             tool_name = message.get("params", {}).get("name")
             arguments = message.get("params", {}).get("arguments", {})
             
+            registered = {tool.name for tool in await mcp.list_tools()}
+            
             try:
                 # Call the tool function directly instead of using FastMCP's call_tool
-                if tool_name == "health_check":
+                if tool_name not in registered:
+                    result = {"error": f"Unknown tool: {tool_name}"}
+                elif refused := check_limits(tool_name, arguments):
+                    result = refused
+                elif tool_name == "health_check":
                     result = health_check()
                 elif tool_name == "create_flight_ticket":
-                    result = create_flight_ticket(**arguments)
+                    # Without a context missing fields are reported, as this handler cannot elicit them
+                    result = await create_flight_ticket(**arguments)
                 elif tool_name == "get_flight_ticket":
                     result = get_flight_ticket(**arguments)
                 elif tool_name == "update_flight_ticket":
                     result = update_flight_ticket(**arguments)
                 elif tool_name == "cancel_flight_ticket":
                     result = cancel_flight_ticket(**arguments)
+                elif tool_name == "lock_flight_ticket":
+                    result = lock_flight_ticket(**arguments)
+                elif tool_name == "unlock_flight_ticket":
+                    result = unlock_flight_ticket(**arguments)
                 elif tool_name == "list_flight_tickets":
                     result = list_flight_tickets(**arguments)
+                elif tool_name == "get_flight_advisories":
+                    result = get_flight_advisories(**arguments)
+                elif tool_name == "search_flights":
+                    result = search_flights(**arguments)
+                elif tool_name == "get_fare_calendar":
+                    result = get_fare_calendar(**arguments)
+                elif tool_name == "list_my_travelers":
+                    result = list_my_travelers(**arguments)
+                elif tool_name == "hold_seats":
+                    result = hold_seats(**arguments)
+                elif tool_name == "list_upgrade_offers":
+                    result = list_upgrade_offers(**arguments)
+                elif tool_name == "respond_to_upgrade_offer":
+                    result = respond_to_upgrade_offer(**arguments)
+                elif tool_name == "get_rebooking_options":
+                    result = get_rebooking_options(**arguments)
+                elif tool_name == "accept_rebooking_option":
+                    result = accept_rebooking_option(**arguments)
+                elif tool_name == "list_ticket_vouchers":
+                    result = list_ticket_vouchers(**arguments)
+                elif tool_name == "redeem_voucher":
+                    result = redeem_voucher(**arguments)
+                elif tool_name == "get_flight_ticket_pnr":
+                    result = get_flight_ticket_pnr(**arguments)
+                elif tool_name == "select_environment":
+                    result = select_environment(**arguments)
+                elif tool_name == "undo_last_action":
+                    result = undo_last_action()
+                elif tool_name == "summarize_upcoming_trips":
+                    # This handler answers each request on its own and cannot send the client a sampling request
+                    result = {"error": "summarize_upcoming_trips needs MCP sampling, which the Cloud Run HTTP transport does not support; use the stdio transport, or the Go MCP server, which samples over HTTP"}
                 else:
                     result = {"error": f"Unknown tool: {tool_name}"}
+                record_call(tool_name, arguments, result)
                 
                 response = {
                     "jsonrpc": "2.0",
@@ -348,6 +1526,46 @@ async def handle_streamable_http(request: Request):
                         "message": f"Tool execution error: {str(e)}"
                     }
                 }
+        elif method == "resources/list":
+            response = {
+                "jsonrpc": "2.0",
+                "id": msg_id,
+                "result": {
+                    "resources": [
+                        {
+                            "uri": SUMMARY_URI,
+                            "name": "session_summary",
+                            "description": SUMMARY_DESCRIPTION,
+                            "mimeType": "application/json"
+                        }
+                    ]
+                }
+            }
+        elif method == "resources/read":
+            uri = message.get("params", {}).get("uri")
+            if uri == SUMMARY_URI:
+                response = {
+                    "jsonrpc": "2.0",
+                    "id": msg_id,
+                    "result": {
+                        "contents": [
+                            {
+                                "uri": SUMMARY_URI,
+                                "mimeType": "application/json",
+                                "text": json.dumps(session_summary(session), indent=2)
+                            }
+                        ]
+                    }
+                }
+            else:
+                response = {
+                    "jsonrpc": "2.0",
+                    "id": msg_id,
+                    "error": {
+                        "code": -32002,
+                        "message": f"Resource not found: {uri}"
+                    }
+                }
         else:
             response = {
                 "jsonrpc": "2.0",
@@ -365,8 +1583,8 @@ async def handle_streamable_http(request: Request):
             headers={
                 "x-session-id": session_id,
                 "Access-Control-Allow-Origin": "*",
-                "Access-Control-Allow-Methods": "POST, OPTIONS",
-                "Access-Control-Allow-Headers": "Content-Type, x-session-id",
+                "Access-Control-Allow-Methods": "POST, DELETE, OPTIONS",
+                "Access-Control-Allow-Headers": "Content-Type, x-session-id, x-api-key",
                 "Access-Control-Expose-Headers": "x-session-id"
             }
         )
@@ -385,19 +1603,79 @@ async def handle_streamable_http(request: Request):
             status_code=500
         )
 
+async def handle_session_end(request: Request):
+    """
+    End the session in the x-session-id header and return its summary, which is logged as well.
+    A session dropped when idle is ended by returning the summary it was dropped with.
+    """
+    session_id = request.headers.get("x-session-id", "")
+    session = sessions.get(session_id)
+    ended = ended_sessions.get(session_id)
+    if session is None and ended is not None and ended["identity"] == caller_identity(request, session_id):
+        del ended_sessions[session_id]
+        return JSONResponse(await ended["summary"], headers={"Access-Control-Allow-Origin": "*"})
+    if session is None or session["identity"] != caller_identity(request, session_id):
+        return JSONResponse({"error": "Unknown session"}, status_code=404)
+    del sessions[session["id"]]
+    return JSONResponse(log_session_summary(session), headers={"Access-Control-Allow-Origin": "*"})
+
+async def handle_audit(request: Request):
+    """List recent tool calls, newest first, filtered by session_id, caller, tool and status."""
+    if not AUDIT_TOKEN:
+        return JSONResponse({"error": "Not found"}, status_code=404)
+    if not hmac.compare_digest(request.headers.get("authorization", "").encode(), f"Bearer {AUDIT_TOKEN}".encode()):
+        return JSONResponse({"error": "Missing or invalid audit token"}, status_code=401)
+    
+    try:
+        limit = int(request.query_params.get("limit", "100"))
+    except ValueError:
+        return JSONResponse({"error": "limit must be an integer"}, status_code=400)
+    
+    filters = {name: request.query_params[name] for name in ("session_id", "caller", "tool", "status") if name in request.query_params}
+    entries = [
+        entry for entry in reversed(audit_log)
+        if all(entry[name] == value for name, value in filters.items())
+    ][:max(limit, 0)]
+    return JSONResponse({"calls": entries, "count": len(entries)})
+
 async def handle_options(request: Request):
     """Handle CORS preflight requests."""
     return Response(
         content="",
         headers={
             "Access-Control-Allow-Origin": "*",
-            "Access-Control-Allow-Methods": "POST, OPTIONS",
-            "Access-Control-Allow-Headers": "Content-Type, x-session-id",
+            "Access-Control-Allow-Methods": "POST, DELETE, OPTIONS",
+            "Access-Control-Allow-Headers": "Content-Type, x-session-id, x-api-key",
             "Access-Control-Expose-Headers": "x-session-id",
             "Access-Control-Max-Age": "86400"
         }
     )
 
+def create_app() -> Starlette:
+    """Create the Cloud Run mode app, with the streamable HTTP session management."""
+    app = Starlette(routes=[
+        Route('/health', lambda request: JSONResponse({
+            "status": "healthy",
+            "service": "flight-ticket-tools",
+            "timestamp": datetime.now().isoformat(),
+            "environment": ENVIRONMENT
+        }), methods=['GET']),
+        Route('/audit', handle_audit, methods=['GET']),
+        Route('/mcp', handle_streamable_http, methods=['POST']),
+        Route('/mcp', handle_session_end, methods=['DELETE']),
+        Route('/mcp', handle_options, methods=['OPTIONS']),
+    ])
+    
+    # Add CORS middleware
+    app.add_middleware(
+        CORSMiddleware,
+        allow_origins=["*"],
+        allow_credentials=True,
+        allow_methods=["*"],
+        allow_headers=["*"],
+    )
+    return app
+
 async def main():
     """Main entry point that handles both local and Cloud Run environments."""
     
@@ -413,26 +1691,7 @@ async def main():
         # Cloud Run mode: Use custom streamable HTTP handler with session management
         print(f"Starting MCP server in Cloud Run mode on port {PORT}")
         
-        # Create custom Starlette app with proper session management
-        app = Starlette(routes=[
-            Route('/health', lambda request: JSONResponse({
-                "status": "healthy",
-                "service": "flight-ticket-tools",
-                "timestamp": datetime.now().isoformat(),
-                "environment": ENVIRONMENT
-            }), methods=['GET']),
-            Route('/mcp', handle_streamable_http, methods=['POST']),
-            Route('/mcp', handle_options, methods=['OPTIONS']),
-        ])
-        
-        # Add CORS middleware
-        app.add_middleware(
-            CORSMiddleware,
-            allow_origins=["*"],
-            allow_credentials=True,
-            allow_methods=["*"],
-            allow_headers=["*"],
-        )
+        app = create_app()
         
         # Run the server
         config = uvicorn.Config(app, host="0.0.0.0", port=PORT, log_level="info")
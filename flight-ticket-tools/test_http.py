@@ -7,7 +7,11 @@ This simulates how the server would behave in Cloud Run.
 import asyncio
 import httpx
 import json
-from datetime import datetime
+import sys
+from collections import deque
+from datetime import datetime, timedelta, timezone
+from types import SimpleNamespace
+from typing import Any, Dict, Optional
 
 async def test_health_endpoint():
     """Test the health endpoint."""
@@ -49,8 +53,576 @@ async def test_mcp_tools():
         except Exception as e:
             print(f"MCP tool test failed: {e}")
 
+# Tools called over HTTP with sample arguments, to check each one is dispatched
+DISPATCHED_TOOLS = [
+    ("hold_seats", {"flight_number": "AA1234", "departure_date": "2030-12-25", "seats": ["12A", "12B"]}),
+    ("list_upgrade_offers", {"confirmation_id": "ABC123"}),
+    ("respond_to_upgrade_offer", {"confirmation_id": "ABC123", "offer_id": "missing", "accept": False}),
+    ("get_rebooking_options", {"confirmation_id": "ABC123"}),
+    ("accept_rebooking_option", {"confirmation_id": "ABC123", "option_id": "missing"}),
+    ("list_ticket_vouchers", {"confirmation_id": "ABC123"}),
+    ("redeem_voucher", {"code": "VQ7K2M9X4TPA", "confirmation_id": "DEF456"}),
+]
+
+async def start_session(client, headers=None):
+    """Send initialize to the streamable HTTP endpoint and return the ID of the session it starts."""
+    response = await client.post(
+        "http://localhost:8080/mcp",
+        json={"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {}},
+        headers={"Content-Type": "application/json", **(headers or {})}
+    )
+    response.raise_for_status()
+    return response.headers["x-session-id"]
+
+async def call_tool(client, session_id, name, arguments, headers=None):
+    """Call a tool on the streamable HTTP endpoint in a session and return its decoded result."""
+    payload = {
+        "jsonrpc": "2.0",
+        "id": 1,
+        "method": "tools/call",
+        "params": {
+            "name": name,
+            "arguments": arguments
+        }
+    }
+    response = await client.post(
+        "http://localhost:8080/mcp",
+        json=payload,
+        headers={"Content-Type": "application/json", "x-session-id": session_id, **(headers or {})}
+    )
+    response.raise_for_status()
+    return json.loads(response.json()["result"]["content"][0]["text"])
+
+def in_process_client():
+    """Return a client of the Cloud Run mode app served in this process, for tests that need no running server."""
+    import main as server
+    return httpx.AsyncClient(transport=httpx.ASGITransport(app=server.create_app()))
+
+async def test_tool_dispatch():
+    """Test that every tool in DISPATCHED_TOOLS reaches its function over HTTP."""
+    ok = True
+    async with httpx.AsyncClient() as client:
+        session_id = await start_session(client)
+        for name, arguments in DISPATCHED_TOOLS:
+            try:
+                result = await call_tool(client, session_id, name, arguments)
+                if "Unknown tool" in str(result.get("error", "")):
+                    print(f"{name}: not dispatched")
+                    ok = False
+                else:
+                    print(f"{name}: {result}")
+            except Exception as e:
+                print(f"{name} call failed: {e}")
+                ok = False
+    return ok
+
+async def test_sessions():
+    """Test that calls need a session started by initialize and that unknown sessions are refused."""
+    ok = True
+    async with httpx.AsyncClient() as client:
+        call = {"jsonrpc": "2.0", "id": 2, "method": "tools/call", "params": {"name": "health_check", "arguments": {}}}
+        for session_id, status in ((None, 400), ("unknown", 404)):
+            headers = {"x-session-id": session_id} if session_id else {}
+            response = await client.post("http://localhost:8080/mcp", json=call, headers=headers)
+            if response.status_code != status:
+                print(f"Session {session_id}: expected {status}, got {response.status_code}")
+                ok = False
+        try:
+            await call_tool(client, await start_session(client), "health_check", {})
+        except Exception as e:
+            print(f"Call in a started session failed: {e}")
+            ok = False
+    return ok
+
+def test_change_headers():
+    """Test that ticket changes send the session's API key along with its lock token."""
+    import main as server
+    requests = []
+    
+    def handler(request):
+        requests.append(request)
+        return httpx.Response(200, json={"confirmation_id": "ABC123"})
+    
+    server.service_client = lambda: httpx.Client(transport=httpx.MockTransport(handler))
+    server.current_session.set({"id": "test", "api_key": "desk-key", "locks": {server.lock_key("ABC123"): "lock-token"}})
+    server.update_flight_ticket(confirmation_id="ABC123", passengers=2)
+    server.cancel_flight_ticket(confirmation_id="ABC123")
+    server.accept_rebooking_option(confirmation_id="ABC123", option_id="opt-1")
+    server.redeem_voucher(code="VQ7K2M9X4TPA", confirmation_id="ABC123")
+    
+    ok = len(requests) == 4
+    for request in requests:
+        if request.headers.get("x-api-key") != "desk-key" or request.headers.get("x-lock-token") != "lock-token":
+            print(f"{request.method} {request.url.path}: missing headers {dict(request.headers)}")
+            ok = False
+    return ok
+
+def test_undo():
+    """Test that undo_last_action undoes an updated ticket's revision, found in its history, and that the session summary lists it."""
+    import main as server
+    requests = []
+    # Revision 2 is the update, kept to the microsecond; revision 3 came after it
+    revisions = {"3": "2030-01-01T08:00:05Z", "2": "2030-01-01T08:00:00.000002Z"}
+    
+    def handler(request):
+        requests.append(request)
+        if request.url.path.endswith("/history/diff"):
+            number = request.url.params.get("to", "3")
+            return httpx.Response(200, json={
+                "confirmation_id": "ABC123", "to": {"number": int(number)},
+                "changes": [{"field": "updated_at", "from": None, "to": revisions[number]}],
+            })
+        return httpx.Response(200, json={"confirmation_id": "ABC123", "passengers": 1, "status": "CONFIRMED", "updated_at": "2030-01-01T08:00:00.000002345Z"})
+    
+    server.service_client = lambda: httpx.Client(transport=httpx.MockTransport(handler))
+    server.history_support.clear()
+    server.current_session.set({"id": "test", "api_key": "desk-key", "created_at": datetime.now(timezone.utc), "limits": {}})
+    arguments = {"confirmation_id": "ABC123", "passengers": 2}
+    server.record_call("update_flight_ticket", arguments, server.update_flight_ticket(**arguments))
+    del requests[:]
+    result = server.undo_last_action()
+    
+    ok = result.get("undone") == "update_flight_ticket" and result.get("remaining") == 0
+    if [request.url.params.get("to") for request in requests[:-1]] != [None, "2"]:
+        print(f"Expected the history to be read from the latest revision back, read {[str(r.url) for r in requests]}")
+        ok = False
+    undo = requests[-1]
+    if undo.method != "POST" or undo.url.path != "/ticket/ABC123/undo" or dict(undo.url.params) != {"target": "2", "revision": "3"}:
+        print(f"Expected the update's revision to be undone, got {undo.method} {undo.url}")
+        ok = False
+    if "error" not in server.undo_last_action():
+        print("Expected nothing left to undo")
+        ok = False
+    
+    summary = server.session_summary(server.current_session.get())
+    if [entry["changes"] for entry in summary["modified"]] != [["update_flight_ticket", "undo_last_action"]]:
+        print(f"Expected the restored ticket in the summary, got {summary}")
+        ok = False
+    return ok
+
+def test_undo_cancellation():
+    """Test that a cancellation is recorded without reading the ticket, and that its undo waits for the history to record it."""
+    import main as server
+    requests = []
+    recorded = []
+    
+    def handler(request):
+        requests.append(request)
+        if request.method == "DELETE":
+            return httpx.Response(200, json={"message": "Ticket cancelled successfully", "confirmation_id": "ABC123"})
+        if request.url.path.endswith("/history/diff"):
+            changes = [{"field": "status", "from": "CONFIRMED", "to": "CANCELLED"}] if recorded else [{"field": "passengers", "from": 1, "to": 2}]
+            return httpx.Response(200, json={"confirmation_id": "ABC123", "to": {"number": 1 + len(recorded)}, "changes": changes})
+        return httpx.Response(200, json={"confirmation_id": "ABC123", "status": "CONFIRMED"})
+    
+    server.service_client = lambda: httpx.Client(transport=httpx.MockTransport(handler))
+    server.history_support.clear()
+    server.current_session.set({"id": "test", "api_key": "desk-key", "created_at": datetime.now(timezone.utc), "limits": {}})
+    for _ in range(2):
+        arguments = {"confirmation_id": "ABC123"}
+        server.record_call("cancel_flight_ticket", arguments, server.cancel_flight_ticket(**arguments))
+    
+    # One history probe for the environment, and no read per cancellation
+    ok = [(request.method, request.url.path) for request in requests] == [
+        ("DELETE", "/ticket/ABC123"), ("GET", "/ticket/ABC123/history/diff"), ("DELETE", "/ticket/ABC123"),
+    ]
+    if not ok:
+        print(f"Expected only the cancellations and one history probe, got {[(r.method, r.url.path) for r in requests]}")
+    
+    # Until the history records the cancellation, the undo fails and the change is kept
+    result = server.undo_last_action()
+    if "no revision of cancel_flight_ticket" not in str(result.get("error")) or len(server.current_session.get()["actions"]) != 2:
+        print(f"Expected the undo to fail until the history records the cancellation, got {result}")
+        ok = False
+    
+    recorded.append(True)
+    del requests[:]
+    result = server.undo_last_action()
+    undo = requests[-1]
+    if result.get("undone") != "cancel_flight_ticket" or undo.method != "POST" or dict(undo.url.params) != {"target": "2", "revision": "2"}:
+        print(f"Expected the cancellation's revision to be undone, got {result} {undo.method} {undo.url}")
+        ok = False
+    return ok
+
+def test_undo_without_history():
+    """Test that changes in an environment without ticket history are marked as not undoable."""
+    import main as server
+    requests = []
+    
+    def handler(request):
+        requests.append(request)
+        if request.url.path.endswith("/history/diff"):
+            return httpx.Response(501, json={"error": "Ticket history is not supported by the configured storage backend"})
+        return httpx.Response(200, json={"confirmation_id": "ABC123", "status": "CONFIRMED", "updated_at": "2030-01-01T08:00:00Z"})
+    
+    server.service_client = lambda: httpx.Client(transport=httpx.MockTransport(handler))
+    server.history_support.clear()
+    server.current_session.set({"id": "test", "api_key": "desk-key", "created_at": datetime.now(timezone.utc), "limits": {}})
+    for passengers in (2, 3):
+        arguments = {"confirmation_id": "ABC123", "passengers": passengers}
+        server.record_call("update_flight_ticket", arguments, server.update_flight_ticket(**arguments))
+    
+    ok = True
+    probes = [request for request in requests if request.url.path.endswith("/history/diff")]
+    if len(probes) != 1:
+        print(f"Expected the history to be probed once per environment, got {len(probes)} probes")
+        ok = False
+    if [action["undoable"] for action in server.current_session.get()["actions"]] != [False, False]:
+        print(f"Expected the updates not to be undoable, got {server.current_session.get()['actions']}")
+        ok = False
+    
+    del requests[:]
+    result = server.undo_last_action()
+    if "cannot be undone" not in str(result.get("error")) or requests:
+        print(f"Expected the undo to be refused without calling the service, got {result}")
+        ok = False
+    return ok
+
+async def test_expired_session_summary():
+    """Test that the client of a session dropped when idle gets its summary with the 404 of its next request and from its DELETE."""
+    import main as server
+    server.caller_limits.clear()
+    ok = True
+    async with in_process_client() as client:
+        session_id = await start_session(client)
+        server.sessions[session_id]["last_used"] -= server.SESSION_TTL_SECONDS + 1
+        headers = {"Content-Type": "application/json", "x-session-id": session_id}
+        call = {"jsonrpc": "2.0", "id": 2, "method": "tools/call", "params": {"name": "health_check", "arguments": {}}}
+        for _ in range(2):
+            response = await client.post("http://localhost:8080/mcp", json=call, headers=headers)
+            error = response.json().get("error", {})
+            if response.status_code != 404 or "created" not in error.get("data", {}).get("summary", {}):
+                print(f"Expected 404 with the summary of the expired session, got {response.status_code} {response.text}")
+                ok = False
+        
+        response = await client.delete("http://localhost:8080/mcp", headers=headers)
+        if response.status_code != 200 or "created" not in response.json():
+            print(f"Expected the summary when the expired session ends, got {response.status_code} {response.text}")
+            ok = False
+        response = await client.post("http://localhost:8080/mcp", json=call, headers=headers)
+        if response.status_code != 404 or "data" in response.json().get("error", {}):
+            print(f"Expected a 404 without a summary once the expired session ended, got {response.status_code} {response.text}")
+            ok = False
+    return ok
+
+class FakeSamplingSession:
+    """Stands in for the MCP client session: answers sampling requests with a fixed summary when sampling is supported."""
+    
+    def __init__(self, sampling: bool):
+        self.sampling = sampling
+        self.requests = []
+    
+    def check_client_capability(self, capability):
+        return self.sampling or capability.sampling is None
+    
+    async def create_message(self, **request):
+        from mcp.types import CreateMessageResult, TextContent
+        self.requests.append(request)
+        return CreateMessageResult(role="assistant", content=TextContent(type="text", text="You fly to LAX next month."), model="fake-model")
+
+class FakeContext:
+    def __init__(self, session):
+        self.session = session
+
+async def test_summarize_trips():
+    """Test that summarize_upcoming_trips has the client's model write the summary, and is refused without sampling."""
+    import main as server
+    requests = []
+    departure = datetime.now(timezone.utc) + timedelta(days=30)
+    
+    def handler(request):
+        requests.append(request)
+        trip = {"origin": "JFK", "destination": "LAX", "flight_number": "AA1234", "passengers": 2, "status": "CONFIRMED"}
+        return httpx.Response(200, json={"count": 3, "tickets": [
+            dict(trip, confirmation_id="ABC123", departure_time=departure.isoformat()),
+            dict(trip, confirmation_id="DEF456", departure_time=departure.isoformat(), status="CANCELLED"),
+            dict(trip, confirmation_id="GHI789", departure_time=(departure - timedelta(days=60)).isoformat()),
+        ]})
+    
+    server.service_client = lambda: httpx.Client(transport=httpx.MockTransport(handler))
+    server.current_session.set({"id": "test", "api_key": "desk-key", "created_at": datetime.now(timezone.utc), "limits": {}})
+    
+    ok = True
+    session = FakeSamplingSession(sampling=True)
+    result = await server.summarize_upcoming_trips(FakeContext(session), limit=10)
+    if result != {"summary": "You fly to LAX next month.", "trip_count": 1, "model": "fake-model"}:
+        print(f"Expected the sampled summary of one trip, got {result}")
+        ok = False
+    prompt = session.requests[0]["messages"][0].content.text if session.requests else ""
+    if "ABC123" not in prompt or "DEF456" in prompt or "GHI789" in prompt:
+        print(f"Expected only the upcoming trip in the sampling request, got {prompt!r}")
+        ok = False
+    
+    del requests[:]
+    session = FakeSamplingSession(sampling=False)
+    result = await server.summarize_upcoming_trips(FakeContext(session), limit=10)
+    if "does not support sampling" not in str(result.get("error")) or requests or session.requests:
+        print(f"Expected the call to be refused before reading tickets, got {result}")
+        ok = False
+    return ok
+
+async def test_environments():
+    """Test that select_environment refuses unknown environments and that each HTTP session keeps its own."""
+    import main as server
+    requests = []
+    
+    def handler(request):
+        requests.append(request)
+        return httpx.Response(200, json={"confirmation_id": "ABC123", "status": "CONFIRMED"})
+    
+    environments = server.SERVICE_ENVIRONMENTS, server.DEFAULT_SERVICE_ENVIRONMENT
+    server.SERVICE_ENVIRONMENTS = {"dev": "http://dev.test", "staging": "http://staging.test"}
+    server.DEFAULT_SERVICE_ENVIRONMENT = "dev"
+    server.service_client = lambda: httpx.Client(transport=httpx.MockTransport(handler))
+    ok = True
+    try:
+        async with in_process_client() as client:
+            first, second = await start_session(client), await start_session(client)
+            
+            result = await call_tool(client, first, "select_environment", {"environment": "prod"})
+            if "Unknown environment" not in str(result.get("error")):
+                print(f"Expected the unknown environment to be refused, got {result}")
+                ok = False
+            result = await call_tool(client, first, "select_environment", {})
+            if result.get("environment") != "dev":
+                print(f"Expected the refused switch to keep the session in dev, got {result}")
+                ok = False
+            
+            result = await call_tool(client, first, "select_environment", {"environment": "staging"})
+            if result.get("environment") != "staging" or result.get("url") != "http://staging.test":
+                print(f"Expected the first session to switch to staging, got {result}")
+                ok = False
+            for session_id in (first, second):
+                await call_tool(client, session_id, "get_flight_ticket", {"confirmation_id": "ABC123"})
+            hosts = [request.url.host for request in requests]
+            if hosts != ["staging.test", "dev.test"]:
+                print(f"Expected each session to call its own environment, called {hosts}")
+                ok = False
+    finally:
+        server.SERVICE_ENVIRONMENTS, server.DEFAULT_SERVICE_ENVIRONMENT = environments
+    return ok
+
+async def test_limits():
+    """Test the passenger cap, the booking cap and the per-minute window, and that keyless callers behind one proxy are counted apart."""
+    import main as server
+    requests = []
+    
+    def handler(request):
+        requests.append(request)
+        return httpx.Response(201, json={"confirmation_id": f"T{len(requests):05d}", "status": "CONFIRMED"})
+    
+    limits = server.MAX_TOOL_CALLS_PER_MINUTE, server.MAX_BOOKINGS_PER_SESSION, server.MAX_PASSENGERS_PER_BOOKING
+    server.MAX_TOOL_CALLS_PER_MINUTE, server.MAX_BOOKINGS_PER_SESSION, server.MAX_PASSENGERS_PER_BOOKING = 5, 2, 4
+    server.service_client = lambda: httpx.Client(transport=httpx.MockTransport(handler))
+    server.caller_limits.clear()
+    booking = {"origin": "JFK", "destination": "LAX", "departure_date": "2030-12-25", "departure_time": "14:30", "passengers": 2}
+    keyed = {"x-api-key": "agent-key"}
+    ok = True
+    try:
+        async with in_process_client() as client:
+            first = await start_session(client, keyed)
+            result = await call_tool(client, first, "create_flight_ticket", dict(booking, passengers=5), keyed)
+            if result.get("refused") != {"limit": "passengers_per_booking", "max": 4, "requested": 5} or requests:
+                print(f"Expected the booking over the passenger cap to be refused without calling the service, got {result}")
+                ok = False
+            
+            # The booking cap is the caller's, whichever session it books in
+            second = await start_session(client, keyed)
+            for session_id in (first, second):
+                result = await call_tool(client, session_id, "create_flight_ticket", booking, keyed)
+                if "error" in result:
+                    print(f"Expected the booking to be made, got {result}")
+                    ok = False
+            result = await call_tool(client, await start_session(client, keyed), "create_flight_ticket", booking, keyed)
+            if result.get("refused", {}).get("limit") != "bookings_per_session":
+                print(f"Expected the third booking to be refused, got {result}")
+                ok = False
+            result = await call_tool(client, first, "create_flight_ticket", dict(booking, dry_run=True), keyed)
+            if "error" in result:
+                print(f"Expected a dry run to be allowed over the booking cap, got {result}")
+                ok = False
+            
+            # Five calls made; the sixth in the same minute is refused until the first ones age out
+            result = await call_tool(client, first, "health_check", {}, keyed)
+            refused = result.get("refused", {})
+            if refused.get("limit") != "tool_calls_per_minute" or not 0 < refused.get("retry_after_seconds", 0) <= 60:
+                print(f"Expected the sixth call in a minute to be refused, got {result}")
+                ok = False
+            for caller in server.caller_limits.values():
+                caller["tool_calls"] = deque(called - 60 for called in caller["tool_calls"])
+            result = await call_tool(client, first, "health_check", {}, keyed)
+            if "refused" in result:
+                print(f"Expected calls to be allowed once the minute has passed, got {result}")
+                ok = False
+            
+            # Callers without a key behind the same proxy do not share a budget
+            proxy = {"x-forwarded-for": "203.0.113.7"}
+            crowded, other = await start_session(client, proxy), await start_session(client, proxy)
+            for _ in range(server.MAX_TOOL_CALLS_PER_MINUTE):
+                await call_tool(client, crowded, "health_check", {}, proxy)
+            if "refused" not in await call_tool(client, crowded, "health_check", {}, proxy):
+                print("Expected the busy keyless session to reach the per-minute limit")
+                ok = False
+            result = await call_tool(client, other, "health_check", {}, proxy)
+            if "refused" in result:
+                print(f"Expected another keyless caller behind the same proxy to keep its own budget, got {result}")
+                ok = False
+    finally:
+        server.MAX_TOOL_CALLS_PER_MINUTE, server.MAX_BOOKINGS_PER_SESSION, server.MAX_PASSENGERS_PER_BOOKING = limits
+    return ok
+
+async def test_audit_endpoint():
+    """Test that /audit is not served without MCP_AUDIT_TOKEN and refuses requests without the token."""
+    import main as server
+    token = server.AUDIT_TOKEN
+    ok = True
+    try:
+        async with in_process_client() as client:
+            server.AUDIT_TOKEN = ""
+            response = await client.get("http://localhost:8080/audit", headers={"Authorization": "Bearer "})
+            if response.status_code != 404:
+                print(f"Expected 404 without a configured token, got {response.status_code}")
+                ok = False
+            
+            server.AUDIT_TOKEN = "audit-secret"
+            for headers in ({}, {"Authorization": "Bearer wrong-secret"}, {"Authorization": "audit-secret"}):
+                response = await client.get("http://localhost:8080/audit", headers=headers)
+                if response.status_code != 401:
+                    print(f"Expected 401 with {headers}, got {response.status_code}")
+                    ok = False
+            response = await client.get("http://localhost:8080/audit", headers={"Authorization": "Bearer audit-secret"})
+            if response.status_code != 200 or "calls" not in response.json():
+                print(f"Expected the audit trail with the token, got {response.status_code} {response.text}")
+                ok = False
+    finally:
+        server.AUDIT_TOKEN = token
+    return ok
+
+async def test_api_keys():
+    """Test that tool calls send the HTTP client's x-api-key to the ticket service, and the stdio session FLIGHT_TICKET_SERVICE_API_KEY."""
+    import main as server
+    requests = []
+    
+    def handler(request):
+        requests.append(request)
+        return httpx.Response(200, json={"confirmation_id": "ABC123"})
+    
+    service_key = server.SERVICE_API_KEY
+    server.SERVICE_API_KEY = "service-key"
+    server.service_client = lambda: httpx.Client(transport=httpx.MockTransport(handler))
+    server.caller_limits.clear()
+    ok = True
+    try:
+        async with in_process_client() as client:
+            keyed = {"x-api-key": "agent-key"}
+            await call_tool(client, await start_session(client, keyed), "get_flight_ticket", {"confirmation_id": "ABC123"}, keyed)
+            if len(requests) != 1 or requests[0].headers.get("x-api-key") != "agent-key":
+                print(f"Expected the HTTP client's key on the service request, got {[dict(r.headers) for r in requests]}")
+                ok = False
+        
+        requests.clear()
+        server.current_session.set(server.stdio_session)
+        server.get_flight_ticket(confirmation_id="ABC123")
+        if len(requests) != 1 or requests[0].headers.get("x-api-key") != "service-key":
+            print(f"Expected FLIGHT_TICKET_SERVICE_API_KEY on the stdio session's request, got {[dict(r.headers) for r in requests]}")
+            ok = False
+    finally:
+        server.SERVICE_API_KEY = service_key
+    return ok
+
+class FakeElicitingContext:
+    """Stands in for the tool context of a client that answers elicitation requests with fixed values, or declines them."""
+    
+    def __init__(self, elicitation: bool, action: str = "accept", values: Optional[Dict[str, Any]] = None):
+        self.session = self
+        self.elicitation = elicitation
+        self.action = action
+        self.values = values or {}
+        self.schemas = []
+    
+    def check_client_capability(self, capability):
+        return self.elicitation or capability.elicitation is None
+    
+    async def elicit(self, message, schema):
+        self.schemas.append(schema)
+        data = schema(**self.values) if self.action == "accept" else None
+        return SimpleNamespace(action=self.action, data=data)
+
+async def test_elicitation():
+    """Test that create_flight_ticket asks for missing fields, reports them when declined or when the client cannot be asked, and holds elicited passengers to the limit."""
+    import main as server
+    requests = []
+    
+    def handler(request):
+        requests.append(request)
+        return httpx.Response(201, json={"confirmation_id": "ABC123", "status": "CONFIRMED"})
+    
+    server.service_client = lambda: httpx.Client(transport=httpx.MockTransport(handler))
+    server.current_session.set({"id": "test", "api_key": "desk-key", "created_at": datetime.now(timezone.utc), "limits": {}})
+    partial = {"origin": "JFK", "destination": "LAX", "departure_date": "2030-12-25"}
+    ok = True
+    
+    ctx = FakeElicitingContext(elicitation=True, values={"departure_time": "14:30", "passengers": 2})
+    result = await server.create_flight_ticket(**partial, ctx=ctx)
+    asked = [sorted(schema.model_fields) for schema in ctx.schemas]
+    if result.get("confirmation_id") != "ABC123" or asked != [["departure_time", "passengers"]]:
+        print(f"Expected the booking to be made after asking for the missing fields, asked {asked}, got {result}")
+        ok = False
+    elif json.loads(requests[-1].content) != {**partial, "departure_time": "14:30", "passengers": 2}:
+        print(f"Expected the elicited fields in the booking, sent {requests[-1].content}")
+        ok = False
+    
+    del requests[:]
+    for ctx in (FakeElicitingContext(elicitation=True, action="decline"), FakeElicitingContext(elicitation=False)):
+        result = await server.create_flight_ticket(**partial, ctx=ctx)
+        if result.get("missing_fields") != ["departure_time", "passengers"] or requests:
+            print(f"Expected the missing fields to be reported without booking, got {result}")
+            ok = False
+        if ctx.elicitation and result.get("elicitation") != "decline":
+            print(f"Expected the declined elicitation to be reported, got {result}")
+            ok = False
+        if not ctx.elicitation and ctx.schemas:
+            print("Expected a client without elicitation not to be asked")
+            ok = False
+    
+    ctx = FakeElicitingContext(elicitation=True, values={"departure_time": "14:30", "passengers": server.MAX_PASSENGERS_PER_BOOKING + 1})
+    result = await server.create_flight_ticket(**partial, ctx=ctx)
+    if result.get("refused", {}).get("limit") != "passengers_per_booking" or requests:
+        print(f"Expected elicited passengers over the limit to be refused, got {result}")
+        ok = False
+    result = await server.create_flight_ticket(**partial, departure_time="14:30", passenger_types={"ADT": server.MAX_PASSENGERS_PER_BOOKING + 1})
+    if result.get("refused", {}).get("limit") != "passengers_per_booking" or requests:
+        print(f"Expected passengers counted from passenger types over the limit to be refused, got {result}")
+        ok = False
+    return ok
+
+async def test_tool_parity():
+    """Test that the HTTP transport lists and dispatches the same tools as the stdio transport's registry."""
+    import main as server
+    registered = {tool.name for tool in await server.mcp.list_tools()}
+    server.service_client = lambda: httpx.Client(transport=httpx.MockTransport(lambda request: httpx.Response(404, json={"error": "Ticket not found"})))
+    server.caller_limits.clear()
+    ok = True
+    async with in_process_client() as client:
+        session_id = await start_session(client)
+        headers = {"Content-Type": "application/json", "x-session-id": session_id}
+        response = await client.post("http://localhost:8080/mcp", json={"jsonrpc": "2.0", "id": 2, "method": "tools/list", "params": {}}, headers=headers)
+        listed = {tool["name"] for tool in response.json()["result"]["tools"]}
+        if listed != registered:
+            print(f"tools/list differs from the registry: missing {sorted(registered - listed)}, extra {sorted(listed - registered)}")
+            ok = False
+        
+        # Calls without arguments fail in the tool, not for want of a dispatch branch
+        for name in sorted(registered):
+            call = {"jsonrpc": "2.0", "id": 3, "method": "tools/call", "params": {"name": name, "arguments": {}}}
+            result = (await client.post("http://localhost:8080/mcp", json=call, headers=headers)).json().get("result")
+            if result and "Unknown tool" in result["content"][0]["text"]:
+                print(f"{name}: registered but not dispatched over HTTP")
+                ok = False
+    return ok
+
 async def main():
-    """Main test function."""
+    """Main test function; returns whether every test passed."""
     print("Testing Flight Ticket Tools MCP Server in HTTP mode")
     print("=" * 50)
     
@@ -60,10 +632,72 @@ async def main():
     if health_ok:
         print("\n2. Testing MCP tools...")
         await test_mcp_tools()
+        
+        print("\n3. Testing tool dispatch...")
+        dispatch_ok = await test_tool_dispatch()
+        print(f"Tool dispatch {'passed' if dispatch_ok else 'failed'}")
+        
+        print("\n4. Testing sessions...")
+        sessions_ok = await test_sessions()
+        print(f"Sessions {'passed' if sessions_ok else 'failed'}")
     else:
         print("Health check failed, skipping MCP tool tests")
     
-    print("\nTest completed!")
+    print("\n5. Testing headers of ticket changes...")
+    headers_ok = test_change_headers()
+    print(f"Change headers {'passed' if headers_ok else 'failed'}")
+    
+    print("\n6. Testing undo and the session summary...")
+    undo_ok = test_undo()
+    print(f"Undo {'passed' if undo_ok else 'failed'}")
+    
+    print("\n7. Testing undo of a cancellation...")
+    cancellation_ok = test_undo_cancellation()
+    print(f"Undo of a cancellation {'passed' if cancellation_ok else 'failed'}")
+    
+    print("\n8. Testing undo without ticket history...")
+    no_history_ok = test_undo_without_history()
+    print(f"Undo without history {'passed' if no_history_ok else 'failed'}")
+    
+    print("\n9. Testing the summary of an expired session...")
+    expired_ok = await test_expired_session_summary()
+    print(f"Expired session summary {'passed' if expired_ok else 'failed'}")
+    
+    print("\n10. Testing the sampled trip summary...")
+    summary_ok = await test_summarize_trips()
+    print(f"Trip summary {'passed' if summary_ok else 'failed'}")
+    
+    print("\n11. Testing environments of HTTP sessions...")
+    environments_ok = await test_environments()
+    print(f"Environments {'passed' if environments_ok else 'failed'}")
+    
+    print("\n12. Testing caller limits...")
+    limits_ok = await test_limits()
+    print(f"Caller limits {'passed' if limits_ok else 'failed'}")
+    
+    print("\n13. Testing the audit endpoint's token...")
+    audit_ok = await test_audit_endpoint()
+    print(f"Audit token {'passed' if audit_ok else 'failed'}")
+    
+    print("\n14. Testing API keys sent to the ticket service...")
+    api_keys_ok = await test_api_keys()
+    print(f"API keys {'passed' if api_keys_ok else 'failed'}")
+    
+    print("\n15. Testing elicitation of missing booking fields...")
+    elicitation_ok = await test_elicitation()
+    print(f"Elicitation {'passed' if elicitation_ok else 'failed'}")
+    
+    print("\n16. Testing that both transports serve the same tools...")
+    parity_ok = await test_tool_parity()
+    print(f"Tool parity {'passed' if parity_ok else 'failed'}")
+    
+    results = [health_ok, headers_ok, undo_ok, cancellation_ok, no_history_ok, expired_ok, summary_ok,
+               environments_ok, limits_ok, audit_ok, api_keys_ok, elicitation_ok, parity_ok]
+    if health_ok:
+        results += [dispatch_ok, sessions_ok]
+    failed = results.count(False)
+    print(f"\nTest completed! {failed} failed" if failed else "\nTest completed!")
+    return failed == 0
 
 if __name__ == "__main__":
-    asyncio.run(main())
+    sys.exit(0 if asyncio.run(main()) else 1)